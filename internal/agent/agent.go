@@ -14,9 +14,11 @@ import (
 	"log/slog"
 	"os"
 	"regexp"
+	"slices"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"charm.land/catwalk/pkg/catwalk"
@@ -30,10 +32,16 @@ import (
 	"charm.land/lipgloss/v2"
 	"github.com/charmbracelet/x/exp/charmtone"
 	"github.com/purpose168/crush-cn/internal/agent/hyper"
+	"github.com/purpose168/crush-cn/internal/agent/prompt"
 	"github.com/purpose168/crush-cn/internal/agent/tools"
 	"github.com/purpose168/crush-cn/internal/agent/tools/mcp"
+	"github.com/purpose168/crush-cn/internal/budget"
+	"github.com/purpose168/crush-cn/internal/capability"
+	"github.com/purpose168/crush-cn/internal/checks"
 	"github.com/purpose168/crush-cn/internal/config"
 	"github.com/purpose168/crush-cn/internal/csync"
+	"github.com/purpose168/crush-cn/internal/dryrun"
+	"github.com/purpose168/crush-cn/internal/eventlog"
 	"github.com/purpose168/crush-cn/internal/message"
 	"github.com/purpose168/crush-cn/internal/permission"
 	"github.com/purpose168/crush-cn/internal/session"
@@ -43,6 +51,9 @@ import (
 const (
 	defaultSessionName = "Untitled Session"
 
+	// maxTitleReplyLength 是生成标题时使用的助手回复的最大字节数。
+	maxTitleReplyLength = 2000
+
 	// 自动摘要阈值常量
 	largeContextWindowThreshold = 200_000
 	largeContextWindowBuffer    = 20_000
@@ -63,12 +74,17 @@ type SessionAgentCall struct {
 	Prompt           string
 	ProviderOptions  fantasy.ProviderOptions
 	Attachments      []message.Attachment
+	PinnedFiles      []message.Attachment
 	MaxOutputTokens  int64
 	Temperature      *float64
 	TopP             *float64
 	TopK             *int64
 	FrequencyPenalty *float64
 	PresencePenalty  *float64
+	// DisablePromptCache 表示不在请求中设置提示缓存断点
+	DisablePromptCache bool
+	// Regenerate 表示重新生成最后一轮回复，不删除备选回复版本
+	Regenerate bool
 }
 
 type SessionAgent interface {
@@ -86,6 +102,9 @@ type SessionAgent interface {
 	CancelAll()
 	// IsSessionBusy 检查指定会话是否忙碌
 	IsSessionBusy(sessionID string) bool
+	// ClaimSession 在会话空闲时将其标记为忙碌，cancel 供 Cancel 取消这次占用。
+	// 会话已忙碌时返回 false，否则返回释放占用的函数，释放后在 ctx 中运行占用期间排队的提示
+	ClaimSession(ctx context.Context, sessionID string, cancel context.CancelFunc) (release func(), ok bool)
 	// IsBusy 检查是否有任何会话忙碌
 	IsBusy() bool
 	// QueuedPrompts 获取指定会话的排队提示数量
@@ -94,6 +113,12 @@ type SessionAgent interface {
 	QueuedPromptsList(sessionID string) []string
 	// ClearQueue 清除指定会话的提示队列
 	ClearQueue(sessionID string)
+	// EditQueuedPrompt 修改指定会话队列中指定位置的提示
+	EditQueuedPrompt(sessionID string, index int, prompt string) error
+	// MoveQueuedPrompt 将指定会话队列中的提示从 from 移动到 to
+	MoveQueuedPrompt(sessionID string, from, to int) error
+	// RemoveQueuedPrompt 删除指定会话队列中指定位置的提示
+	RemoveQueuedPrompt(sessionID string, index int) error
 	// Summarize 总结指定会话
 	Summarize(context.Context, string, fantasy.ProviderOptions) error
 	// Model 获取当前使用的模型
@@ -117,10 +142,20 @@ type sessionAgent struct {
 	sessions             session.Service
 	messages             message.Service
 	disableAutoSummarize bool
+	disableAutoTitle     bool
+	discardReasoning     bool
 	isYolo               bool
+	eventLog             *eventlog.Logger
+	budget               budget.Service
+	checks               *editChecks
+	toolRepair           *config.ToolRepair
 
 	messageQueue   *csync.Map[string, []SessionAgentCall]
 	activeRequests *csync.Map[string, context.CancelFunc]
+	// stopping 在退出时设置，此后不再删除或修改运行记录。
+	stopping atomic.Bool
+	// compressed 按原文哈希缓存压缩后的消息内容。
+	compressed *csync.Map[string, string]
 }
 
 type SessionAgentOptions struct {
@@ -130,10 +165,17 @@ type SessionAgentOptions struct {
 	SystemPrompt         string
 	IsSubAgent           bool
 	DisableAutoSummarize bool
+	DisableAutoTitle     bool
 	IsYolo               bool
 	Sessions             session.Service
 	Messages             message.Service
 	Tools                []fantasy.AgentTool
+	EventLog             *eventlog.Logger
+	Budget               budget.Service
+	DiscardReasoning     bool
+	Checks               *checks.Runner
+	DryRun               dryrun.Service
+	ToolRepair           *config.ToolRepair
 }
 
 func NewSessionAgent(
@@ -148,10 +190,17 @@ func NewSessionAgent(
 		sessions:             opts.Sessions,
 		messages:             opts.Messages,
 		disableAutoSummarize: opts.DisableAutoSummarize,
+		disableAutoTitle:     opts.DisableAutoTitle,
+		discardReasoning:     opts.DiscardReasoning,
 		tools:                csync.NewSliceFrom(opts.Tools),
 		isYolo:               opts.IsYolo,
+		eventLog:             opts.EventLog,
+		budget:               opts.Budget,
+		checks:               newEditChecks(opts.Checks, opts.DryRun, opts.Messages),
+		toolRepair:           opts.ToolRepair,
 		messageQueue:         csync.NewMap[string, []SessionAgentCall](),
 		activeRequests:       csync.NewMap[string, context.CancelFunc](),
+		compressed:           csync.NewMap[string, string](),
 	}
 }
 
@@ -171,12 +220,16 @@ func (a *sessionAgent) Run(ctx context.Context, call SessionAgentCall) (*fantasy
 		}
 		existing = append(existing, call)
 		a.messageQueue.Set(call.SessionID, existing)
+		a.saveQueue(call.SessionID)
 		return nil, nil
 	}
 
 	// 在锁下复制可变字段，以避免与 SetTools/SetModels 发生竞争。
 	agentTools := a.tools.Copy()
 	largeModel := a.largeModel.Get()
+	if !capability.Of(largeModel.CatwalkCfg, largeModel.ModelCfg).Supports(capability.Tools) {
+		agentTools = nil
+	}
 	systemPrompt := a.systemPrompt.Get()
 	promptPrefix := a.systemPromptPrefix.Get()
 	var instructions strings.Builder
@@ -195,9 +248,10 @@ func (a *sessionAgent) Run(ctx context.Context, call SessionAgentCall) (*fantasy
 		systemPrompt += "\n\n<mcp-instructions>\n" + s + "\n</mcp-instructions>"
 	}
 
-	if len(agentTools) > 0 {
-		// 为最后一个工具添加 Anthropic 缓存。
-		agentTools[len(agentTools)-1].SetProviderOptions(a.getCacheControlOptions())
+	cacheOptions := a.getCacheControlOptions(call.DisablePromptCache)
+	if len(agentTools) > 0 && !strings.Contains(systemPrompt, prompt.CacheBreakpoint) {
+		// 为最后一个工具添加 Anthropic 缓存。系统提示包含缓存断点时由静态指令的断点覆盖工具定义。
+		agentTools[len(agentTools)-1].SetProviderOptions(cacheOptions)
 	}
 
 	agent := fantasy.NewAgent(
@@ -212,20 +266,33 @@ func (a *sessionAgent) Run(ctx context.Context, call SessionAgentCall) (*fantasy
 		return nil, fmt.Errorf("获取会话失败: %w", err)
 	}
 
+	// 之前完成的检查报告已保存在消息中
+	a.checks.forget(call.SessionID)
 	msgs, err := a.getSessionMessages(ctx, currentSession)
 	if err != nil {
 		return nil, fmt.Errorf("获取会话消息失败: %w", err)
 	}
 
 	var wg sync.WaitGroup
-	// 如果是第一条消息则生成标题。
-	if len(msgs) == 0 {
-		titleCtx := ctx // 复制以避免与下面的 ctx 重新分配发生竞争。
-		wg.Go(func() {
-			a.generateTitle(titleCtx, call.SessionID, call.Prompt)
-		})
-	}
+	// 如果是第一条消息，则在助手首次给出文本回复后在后台生成标题。重新生成第一轮回复时保留原来的标题。
+	shouldGenerateTitle := len(msgs) == 0 && len(currentSession.Variants) == 0 && !a.disableAutoTitle
+	titleCtx := ctx // 复制以避免与下面的 ctx 重新分配发生竞争。
 	defer wg.Wait()
+	defer func() {
+		// 运行在助手给出文本回复之前结束（失败、取消或只调用了工具）时，仅根据提示生成标题。
+		if shouldGenerateTitle {
+			shouldGenerateTitle = false
+			wg.Go(func() {
+				a.generateTitle(titleCtx, call.SessionID, call.Prompt, "")
+			})
+		}
+	}()
+
+	if !call.Regenerate {
+		if err := discardVariants(ctx, a.sessions, a.messages, currentSession); err != nil {
+			return nil, fmt.Errorf("删除备选回复版本失败: %w", err)
+		}
+	}
 
 	// 将用户消息添加到会话中。
 	_, err = a.createUserMessage(ctx, call)
@@ -235,20 +302,45 @@ func (a *sessionAgent) Run(ctx context.Context, call SessionAgentCall) (*fantasy
 
 	// 将会话添加到上下文中。
 	ctx = context.WithValue(ctx, tools.SessionIDContextKey, call.SessionID)
+	if currentSession.WorkingDir != "" {
+		ctx = context.WithValue(ctx, tools.WorkingDirContextKey, currentSession.WorkingDir)
+	}
 
 	genCtx, cancel := context.WithCancel(ctx)
 	a.activeRequests.Set(call.SessionID, cancel)
+	a.recordRun(call)
 
 	defer cancel()
 	defer a.activeRequests.Del(call.SessionID)
+	defer a.finishRun(call.SessionID)
 
 	history, files := a.preparePrompt(msgs, call.Attachments...)
+	if len(call.PinnedFiles) > 0 {
+		history = append(history, pinnedFilesMessage(call.PinnedFiles))
+	}
+	if msg, ok := planModeMessage(currentSession); ok {
+		history = append(history, msg)
+	}
+	if msg, ok := workingDirMessage(ctx); ok {
+		history = append(history, msg)
+	}
+	if msg, ok := userTodosMessage(msgs, currentSession); ok {
+		history = append(history, msg)
+	}
 
 	startTime := time.Now()
 	a.eventPromptSent(call.SessionID)
+	a.logEvent(eventlog.Event{SessionID: call.SessionID, Type: eventlog.TypeRunStarted})
 
 	var currentAssistant *message.Message
+	var assistantIDs []string
+	if a.discardReasoning {
+		defer func() { a.discardReasoningTraces(assistantIDs) }()
+	}
+	var metrics *streamMetrics
 	var shouldSummarize bool
+	// malformedSteps 是模型连续只生成无效工具调用的步数
+	var malformedSteps int
 	result, err := agent.Stream(genCtx, fantasy.AgentStreamCall{
 		Prompt:           message.PromptWithTextAttachments(call.Prompt, call.Attachments),
 		Files:            files,
@@ -260,14 +352,18 @@ func (a *sessionAgent) Run(ctx context.Context, call SessionAgentCall) (*fantasy
 		PresencePenalty:  call.PresencePenalty,
 		TopK:             call.TopK,
 		FrequencyPenalty: call.FrequencyPenalty,
+		RepairToolCall:   a.repairToolCallFunc(largeModel),
 		PrepareStep: func(callContext context.Context, options fantasy.PrepareStepFunctionOptions) (_ context.Context, prepared fantasy.PrepareStepResult, err error) {
-			prepared.Messages = options.Messages
+			prepared.Messages = sanitizeToolCallInputs(options.Messages)
 			for i := range prepared.Messages {
 				prepared.Messages[i].ProviderOptions = nil
 			}
 
 			queuedCalls, _ := a.messageQueue.Get(call.SessionID)
 			a.messageQueue.Del(call.SessionID)
+			if len(queuedCalls) > 0 {
+				a.saveQueue(call.SessionID)
+			}
 			for _, queued := range queuedCalls {
 				userMessage, createErr := a.createUserMessage(callContext, queued)
 				if createErr != nil {
@@ -276,7 +372,15 @@ func (a *sessionAgent) Run(ctx context.Context, call SessionAgentCall) (*fantasy
 				prepared.Messages = append(prepared.Messages, userMessage.ToAIMessage()...)
 			}
 
+			prepared.Messages = a.checks.annotate(call.SessionID, prepared.Messages)
 			prepared.Messages = a.workaroundProviderMediaLimitations(prepared.Messages, largeModel)
+			prepared.Messages = a.compressStaleMessages(callContext, prepared.Messages, largeModel)
+
+			if _, ok := cacheOptions[largeModel.Model.Provider()]; ok {
+				prepared.Messages = splitSystemPrompt(prepared.Messages, cacheOptions)
+			} else {
+				prepared.Messages = splitSystemPrompt(prepared.Messages, nil)
+			}
 
 			lastSystemRoleInx := 0
 			systemMessageUpdated := false
@@ -285,12 +389,12 @@ func (a *sessionAgent) Run(ctx context.Context, call SessionAgentCall) (*fantasy
 				if msg.Role == fantasy.MessageRoleSystem {
 					lastSystemRoleInx = i
 				} else if !systemMessageUpdated {
-					prepared.Messages[lastSystemRoleInx].ProviderOptions = a.getCacheControlOptions()
+					prepared.Messages[lastSystemRoleInx].ProviderOptions = cacheOptions
 					systemMessageUpdated = true
 				}
 				// Than add cache control to the last 2 messages.
 				if i > len(prepared.Messages)-3 {
-					prepared.Messages[i].ProviderOptions = a.getCacheControlOptions()
+					prepared.Messages[i].ProviderOptions = cacheOptions
 				}
 			}
 
@@ -308,12 +412,24 @@ func (a *sessionAgent) Run(ctx context.Context, call SessionAgentCall) (*fantasy
 			if err != nil {
 				return callContext, prepared, err
 			}
+			a.logEvent(eventlog.Event{
+				SessionID: call.SessionID,
+				Type:      eventlog.TypeModelRequest,
+				Messages:  len(prepared.Messages),
+			})
 			callContext = context.WithValue(callContext, tools.MessageIDContextKey, assistantMsg.ID)
 			callContext = context.WithValue(callContext, tools.SupportsImagesContextKey, largeModel.CatwalkCfg.SupportsImages)
 			callContext = context.WithValue(callContext, tools.ModelNameContextKey, largeModel.CatwalkCfg.Name)
 			currentAssistant = &assistantMsg
+			assistantIDs = append(assistantIDs, assistantMsg.ID)
+			metrics = newStreamMetrics()
+			callContext = metrics.withTrace(callContext)
 			return callContext, prepared, err
 		},
+		OnChunk: func(part fantasy.StreamPart) error {
+			metrics.observe(part)
+			return nil
+		},
 		OnReasoningStart: func(id string, reasoning fantasy.ReasoningContent) error {
 			currentAssistant.AppendReasoningContent(reasoning.Text)
 			return a.messages.Update(genCtx, *currentAssistant)
@@ -363,7 +479,7 @@ func (a *sessionAgent) Run(ctx context.Context, call SessionAgentCall) (*fantasy
 			return a.messages.Update(genCtx, *currentAssistant)
 		},
 		OnRetry: func(err *fantasy.ProviderError, delay time.Duration) {
-			// TODO: 实现
+			metrics.restart(delay)
 		},
 		OnToolCall: func(tc fantasy.ToolCallContent) error {
 			toolCall := message.ToolCall{
@@ -374,17 +490,36 @@ func (a *sessionAgent) Run(ctx context.Context, call SessionAgentCall) (*fantasy
 				Finished:         true,
 			}
 			currentAssistant.AddToolCall(toolCall)
+			a.logEvent(eventlog.Event{
+				SessionID:  call.SessionID,
+				Type:       eventlog.TypeToolCall,
+				ToolCallID: tc.ToolCallID,
+				ToolName:   tc.ToolName,
+				Input:      tc.Input,
+			})
 			return a.messages.Update(genCtx, *currentAssistant)
 		},
 		OnToolResult: func(result fantasy.ToolResultContent) error {
 			toolResult := a.convertToToolResult(result)
-			_, createMsgErr := a.messages.Create(genCtx, currentAssistant.SessionID, message.CreateMessageParams{
+			a.logEvent(eventlog.Event{
+				SessionID:   call.SessionID,
+				Type:        eventlog.TypeToolResult,
+				ToolCallID:  toolResult.ToolCallID,
+				ToolName:    toolResult.Name,
+				OutputBytes: len(toolResult.Content),
+				IsError:     toolResult.IsError,
+			})
+			toolMsg, createMsgErr := a.messages.Create(genCtx, currentAssistant.SessionID, message.CreateMessageParams{
 				Role: message.Tool,
 				Parts: []message.ContentPart{
 					toolResult,
 				},
 			})
-			return createMsgErr
+			if createMsgErr != nil {
+				return createMsgErr
+			}
+			a.checks.schedule(toolMsg, toolResult)
+			return nil
 		},
 		OnStepFinish: func(stepResult fantasy.StepResult) error {
 			finishReason := message.FinishReasonUnknown
@@ -397,6 +532,24 @@ func (a *sessionAgent) Run(ctx context.Context, call SessionAgentCall) (*fantasy
 				finishReason = message.FinishReasonToolUse
 			}
 			currentAssistant.AddFinish(finishReason, "", "")
+			if malformedToolStep(stepResult) {
+				malformedSteps++
+			} else {
+				malformedSteps = 0
+			}
+			responseMetrics := metrics.result(stepResult.Usage.OutputTokens, time.Now())
+			responseMetrics.InputTokens = stepResult.Usage.InputTokens + stepResult.Usage.CacheReadTokens
+			responseMetrics.ReasoningTokens = stepResult.Usage.ReasoningTokens
+			responseMetrics.CacheReadTokens = stepResult.Usage.CacheReadTokens
+			responseMetrics.CacheCreationTokens = stepResult.Usage.CacheCreationTokens
+			currentAssistant.SetResponseMetrics(responseMetrics)
+			// 只调用工具的步骤没有文本回复，等待之后的步骤。
+			if assistantReply := currentAssistant.Content().Text; shouldGenerateTitle && strings.TrimSpace(assistantReply) != "" {
+				shouldGenerateTitle = false
+				wg.Go(func() {
+					a.generateTitle(titleCtx, call.SessionID, call.Prompt, assistantReply)
+				})
+			}
 			sessionLock.Lock()
 			defer sessionLock.Unlock()
 
@@ -404,7 +557,9 @@ func (a *sessionAgent) Run(ctx context.Context, call SessionAgentCall) (*fantasy
 			if getSessionErr != nil {
 				return getSessionErr
 			}
-			a.updateSessionUsage(largeModel, &updatedSession, stepResult.Usage, a.openrouterCost(stepResult.ProviderMetadata))
+			cost := a.updateSessionUsage(largeModel, &updatedSession, stepResult.Usage, a.openrouterCost(stepResult.ProviderMetadata))
+			a.logModelResponse(call.SessionID, largeModel, string(stepResult.FinishReason), stepResult.Usage, cost)
+			a.recordCost(ctx, call.SessionID, largeModel, cost)
 			_, sessionErr := a.sessions.Save(ctx, updatedSession)
 			if sessionErr != nil {
 				return sessionErr
@@ -429,10 +584,14 @@ func (a *sessionAgent) Run(ctx context.Context, call SessionAgentCall) (*fantasy
 				}
 				return false
 			},
+			func(_ []fantasy.StepResult) bool {
+				return malformedSteps >= maxMalformedToolSteps
+			},
 		},
 	})
 
 	a.eventPromptResponded(call.SessionID, time.Since(startTime).Truncate(time.Second))
+	a.logRunFinished(call.SessionID, time.Since(startTime), err)
 
 	if err != nil {
 		isCancelErr := errors.Is(err, context.Canceled)
@@ -535,6 +694,18 @@ func (a *sessionAgent) Run(ctx context.Context, call SessionAgentCall) (*fantasy
 		return nil, err
 	}
 
+	if malformedSteps >= maxMalformedToolSteps {
+		currentAssistant.AddFinish(
+			message.FinishReasonError,
+			"工具参数无效",
+			fmt.Sprintf("模型连续 %d 次生成了无法解析的工具参数，已停止运行。可以换一个模型后重试。", malformedSteps),
+		)
+		if updateErr := a.messages.Update(ctx, *currentAssistant); updateErr != nil {
+			return nil, updateErr
+		}
+		return nil, ErrMalformedToolCalls
+	}
+
 	if shouldSummarize {
 		a.activeRequests.Del(call.SessionID)
 		if summarizeErr := a.Summarize(genCtx, call.SessionID, call.ProviderOptions); summarizeErr != nil {
@@ -549,6 +720,7 @@ func (a *sessionAgent) Run(ctx context.Context, call SessionAgentCall) (*fantasy
 			call.Prompt = fmt.Sprintf("The previous session was interrupted because it got too long, the initial user request was: `%s`", call.Prompt)
 			existing = append(existing, call)
 			a.messageQueue.Set(call.SessionID, existing)
+			a.saveQueue(call.SessionID)
 		}
 	}
 
@@ -579,6 +751,9 @@ func (a *sessionAgent) Summarize(ctx context.Context, sessionID string, opts fan
 	if err != nil {
 		return fmt.Errorf("获取会话失败: %w", err)
 	}
+	if err := discardVariants(ctx, a.sessions, a.messages, currentSession); err != nil {
+		return fmt.Errorf("删除备选回复版本失败: %w", err)
+	}
 	msgs, err := a.getSessionMessages(ctx, currentSession)
 	if err != nil {
 		return err
@@ -608,14 +783,14 @@ func (a *sessionAgent) Summarize(ctx context.Context, sessionID string, opts fan
 		return err
 	}
 
-	summaryPromptText := buildSummaryPrompt(currentSession.Todos)
+	summaryPromptText := buildSummaryPrompt(currentSession.Todos, currentSession.PinnedFiles)
 
 	resp, err := agent.Stream(genCtx, fantasy.AgentStreamCall{
 		Prompt:          summaryPromptText,
 		Messages:        aiMsgs,
 		ProviderOptions: opts,
 		PrepareStep: func(callContext context.Context, options fantasy.PrepareStepFunctionOptions) (_ context.Context, prepared fantasy.PrepareStepResult, err error) {
-			prepared.Messages = options.Messages
+			prepared.Messages = sanitizeToolCallInputs(options.Messages)
 			if systemPromptPrefix != "" {
 				prepared.Messages = append([]fantasy.Message{fantasy.NewSystemMessage(systemPromptPrefix)}, prepared.Messages...)
 			}
@@ -668,7 +843,9 @@ func (a *sessionAgent) Summarize(ctx context.Context, sessionID string, opts fan
 		}
 	}
 
-	a.updateSessionUsage(largeModel, &currentSession, resp.TotalUsage, openrouterCost)
+	cost := a.updateSessionUsage(largeModel, &currentSession, resp.TotalUsage, openrouterCost)
+	a.logModelResponse(sessionID, largeModel, string(resp.Response.FinishReason), resp.TotalUsage, cost)
+	a.recordCost(ctx, sessionID, largeModel, cost)
 
 	// Just in case, get just the last usage info.
 	usage := resp.Response.Usage
@@ -679,8 +856,8 @@ func (a *sessionAgent) Summarize(ctx context.Context, sessionID string, opts fan
 	return err
 }
 
-func (a *sessionAgent) getCacheControlOptions() fantasy.ProviderOptions {
-	if t, _ := strconv.ParseBool(os.Getenv("CRUSH_DISABLE_ANTHROPIC_CACHE")); t {
+func (a *sessionAgent) getCacheControlOptions(disabled bool) fantasy.ProviderOptions {
+	if t, _ := strconv.ParseBool(os.Getenv("CRUSH_DISABLE_ANTHROPIC_CACHE")); t || disabled {
 		return fantasy.ProviderOptions{}
 	}
 	return fantasy.ProviderOptions{
@@ -756,6 +933,9 @@ func (a *sessionAgent) getSessionMessages(ctx context.Context, session session.S
 	if err != nil {
 		return nil, fmt.Errorf("failed to list messages: %w", err)
 	}
+	if hidden := session.HiddenMessages(); len(hidden) > 0 {
+		msgs = slices.DeleteFunc(msgs, func(msg message.Message) bool { return hidden[msg.ID] })
+	}
 
 	if session.SummaryMessageID != "" {
 		summaryMsgIndex := -1
@@ -773,12 +953,20 @@ func (a *sessionAgent) getSessionMessages(ctx context.Context, session session.S
 	return msgs, nil
 }
 
-// generateTitle 根据初始提示生成会话标题。
-func (a *sessionAgent) generateTitle(ctx context.Context, sessionID string, userPrompt string) {
+// generateTitle 根据初始提示和助手的首次回复生成会话标题。
+func (a *sessionAgent) generateTitle(ctx context.Context, sessionID string, userPrompt, assistantReply string) {
 	if userPrompt == "" {
 		return
 	}
 
+	content := userPrompt
+	if assistantReply = strings.TrimSpace(assistantReply); assistantReply != "" {
+		if len(assistantReply) > maxTitleReplyLength {
+			assistantReply = strings.ToValidUTF8(assistantReply[:maxTitleReplyLength], "")
+		}
+		content = fmt.Sprintf("User: %s\n\nAssistant: %s", userPrompt, assistantReply)
+	}
+
 	smallModel := a.smallModel.Get()
 	largeModel := a.largeModel.Get()
 	systemPromptPrefix := a.systemPromptPrefix.Get()
@@ -796,7 +984,7 @@ func (a *sessionAgent) generateTitle(ctx context.Context, sessionID string, user
 	}
 
 	streamCall := fantasy.AgentStreamCall{
-		Prompt: fmt.Sprintf("Generate a concise title for the following content:\n\n%s\n <think>\n\n</think>", userPrompt),
+		Prompt: fmt.Sprintf("Generate a concise title for the following content:\n\n%s\n <think>\n\n</think>", content),
 		PrepareStep: func(callCtx context.Context, opts fantasy.PrepareStepFunctionOptions) (_ context.Context, prepared fantasy.PrepareStepResult, err error) {
 			prepared.Messages = opts.Messages
 			if systemPromptPrefix != "" {
@@ -883,6 +1071,8 @@ func (a *sessionAgent) generateTitle(ctx context.Context, sessionID string, user
 		cost = *openrouterCost
 	}
 
+	a.recordCost(ctx, sessionID, model, cost)
+
 	promptTokens := resp.TotalUsage.InputTokens + resp.TotalUsage.CacheCreationTokens
 	completionTokens := resp.TotalUsage.OutputTokens
 
@@ -908,7 +1098,8 @@ func (a *sessionAgent) openrouterCost(metadata fantasy.ProviderMetadata) *float6
 	return &opts.Usage.Cost
 }
 
-func (a *sessionAgent) updateSessionUsage(model Model, session *session.Session, usage fantasy.Usage, overrideCost *float64) {
+// updateSessionUsage 将用量和费用累加到会话中，并返回本次的费用。
+func (a *sessionAgent) updateSessionUsage(model Model, session *session.Session, usage fantasy.Usage, overrideCost *float64) float64 {
 	modelConfig := model.CatwalkCfg
 	cost := modelConfig.CostPer1MInCached/1e6*float64(usage.CacheCreationTokens) +
 		modelConfig.CostPer1MOutCached/1e6*float64(usage.CacheReadTokens) +
@@ -918,13 +1109,23 @@ func (a *sessionAgent) updateSessionUsage(model Model, session *session.Session,
 	a.eventTokensUsed(session.ID, model, usage, cost)
 
 	if overrideCost != nil {
-		session.Cost += *overrideCost
-	} else {
-		session.Cost += cost
+		cost = *overrideCost
 	}
+	session.Cost += cost
 
 	session.CompletionTokens = usage.OutputTokens
 	session.PromptTokens = usage.InputTokens + usage.CacheReadTokens
+	return cost
+}
+
+// recordCost 记录一次模型请求的费用，用于统计预算。
+func (a *sessionAgent) recordCost(ctx context.Context, sessionID string, model Model, cost float64) {
+	if a.budget == nil {
+		return
+	}
+	if err := a.budget.Record(ctx, sessionID, model.ModelCfg.Provider, model.ModelCfg.Model, cost); err != nil {
+		slog.Error("Failed to record request cost", "error", err)
+	}
 }
 
 func (a *sessionAgent) Cancel(sessionID string) {
@@ -946,6 +1147,7 @@ func (a *sessionAgent) Cancel(sessionID string) {
 	if a.QueuedPrompts(sessionID) > 0 {
 		slog.Debug("清除排队的提示", "session_id", sessionID)
 		a.messageQueue.Del(sessionID)
+		a.saveQueue(sessionID)
 	}
 }
 
@@ -953,6 +1155,7 @@ func (a *sessionAgent) ClearQueue(sessionID string) {
 	if a.QueuedPrompts(sessionID) > 0 {
 		slog.Debug("清除排队的提示", "session_id", sessionID)
 		a.messageQueue.Del(sessionID)
+		a.saveQueue(sessionID)
 	}
 }
 
@@ -960,6 +1163,9 @@ func (a *sessionAgent) CancelAll() {
 	if !a.IsBusy() {
 		return
 	}
+	// CancelAll 只在退出时调用，保留运行记录以便下次启动时恢复。
+	a.stopping.Store(true)
+	a.checks.cancelAll()
 	for key := range a.activeRequests.Seq2() {
 		a.Cancel(key) // key is sessionID
 	}
@@ -991,6 +1197,23 @@ func (a *sessionAgent) IsSessionBusy(sessionID string) bool {
 	return busy
 }
 
+func (a *sessionAgent) ClaimSession(ctx context.Context, sessionID string, cancel context.CancelFunc) (func(), bool) {
+	if !a.activeRequests.SetIfAbsent(sessionID, cancel) {
+		return nil, false
+	}
+	return func() {
+		a.activeRequests.Del(sessionID)
+		queuedMessages, ok := a.messageQueue.Get(sessionID)
+		if !ok || len(queuedMessages) == 0 {
+			return
+		}
+		a.messageQueue.Set(sessionID, queuedMessages[1:])
+		if _, err := a.Run(ctx, queuedMessages[0]); err != nil {
+			slog.Error("Failed to run queued prompt", "session_id", sessionID, "error", err)
+		}
+	}, true
+}
+
 func (a *sessionAgent) QueuedPrompts(sessionID string) int {
 	l, ok := a.messageQueue.Get(sessionID)
 	if !ok {
@@ -1011,6 +1234,108 @@ func (a *sessionAgent) QueuedPromptsList(sessionID string) []string {
 	return prompts
 }
 
+func (a *sessionAgent) EditQueuedPrompt(sessionID string, index int, prompt string) error {
+	if prompt == "" {
+		return ErrEmptyPrompt
+	}
+	l, ok := a.messageQueue.Get(sessionID)
+	if !ok || index < 0 || index >= len(l) {
+		return ErrQueueIndexOutOfRange
+	}
+	// 复制队列，避免修改正在被其他地方读取的切片。
+	queue := slices.Clone(l)
+	queue[index].Prompt = prompt
+	a.messageQueue.Set(sessionID, queue)
+	a.saveQueue(sessionID)
+	return nil
+}
+
+func (a *sessionAgent) MoveQueuedPrompt(sessionID string, from, to int) error {
+	l, ok := a.messageQueue.Get(sessionID)
+	if !ok || from < 0 || from >= len(l) || to < 0 || to >= len(l) {
+		return ErrQueueIndexOutOfRange
+	}
+	if from == to {
+		return nil
+	}
+	queue := slices.Clone(l)
+	call := queue[from]
+	queue = slices.Delete(queue, from, from+1)
+	queue = slices.Insert(queue, to, call)
+	a.messageQueue.Set(sessionID, queue)
+	a.saveQueue(sessionID)
+	return nil
+}
+
+func (a *sessionAgent) RemoveQueuedPrompt(sessionID string, index int) error {
+	l, ok := a.messageQueue.Get(sessionID)
+	if !ok || index < 0 || index >= len(l) {
+		return ErrQueueIndexOutOfRange
+	}
+	if len(l) == 1 {
+		a.messageQueue.Del(sessionID)
+	} else {
+		a.messageQueue.Set(sessionID, slices.Delete(slices.Clone(l), index, index+1))
+	}
+	a.saveQueue(sessionID)
+	return nil
+}
+
+// discardReasoningTraces 在运行结束后从消息中删除推理内容。运行过程中模型需要
+// 前几步的推理签名，因此只在运行结束后删除。
+func (a *sessionAgent) discardReasoningTraces(messageIDs []string) {
+	// 运行可能已被取消，使用新的上下文
+	ctx := context.Background()
+	for _, id := range messageIDs {
+		msg, err := a.messages.Get(ctx, id)
+		if err != nil {
+			slog.Error("Failed to load message to discard reasoning", "message_id", id, "error", err)
+			continue
+		}
+		if !msg.DiscardReasoning() {
+			continue
+		}
+		if err := a.messages.Update(ctx, msg); err != nil {
+			slog.Error("Failed to discard reasoning", "message_id", id, "error", err)
+		}
+	}
+}
+
+// tracksRuns 报告是否记录运行，子代理的运行随父会话的运行一起恢复
+func (a *sessionAgent) tracksRuns() bool {
+	return !a.isSubAgent && a.sessions != nil
+}
+
+// recordRun 记录会话开始运行，进程在运行中退出时下次启动可以恢复
+func (a *sessionAgent) recordRun(call SessionAgentCall) {
+	if !a.tracksRuns() {
+		return
+	}
+	if err := a.sessions.StartRun(context.Background(), call.SessionID, call.Prompt, a.QueuedPromptsList(call.SessionID)); err != nil {
+		slog.Error("Failed to record session run", "session_id", call.SessionID, "error", err)
+	}
+}
+
+// finishRun 删除会话的运行记录，退出时被取消的运行保留记录
+func (a *sessionAgent) finishRun(sessionID string) {
+	if !a.tracksRuns() || a.stopping.Load() {
+		return
+	}
+	if err := a.sessions.FinishRun(context.Background(), sessionID); err != nil {
+		slog.Error("Failed to clear session run", "session_id", sessionID, "error", err)
+	}
+}
+
+// saveQueue 把会话的排队提示写入运行记录
+func (a *sessionAgent) saveQueue(sessionID string) {
+	if !a.tracksRuns() || a.stopping.Load() {
+		return
+	}
+	if err := a.sessions.SetRunQueue(context.Background(), sessionID, a.QueuedPromptsList(sessionID)); err != nil {
+		slog.Error("Failed to save queued prompts", "session_id", sessionID, "error", err)
+	}
+}
+
 func (a *sessionAgent) SetModels(large Model, small Model) {
 	a.largeModel.Set(large)
 	a.smallModel.Set(small)
@@ -1150,7 +1475,7 @@ func (a *sessionAgent) workaroundProviderMediaLimitations(messages []fantasy.Mes
 }
 
 // buildSummaryPrompt 构建会话摘要的提示文本。
-func buildSummaryPrompt(todos []session.Todo) string {
+func buildSummaryPrompt(todos []session.Todo, pinnedFiles []string) string {
 	var sb strings.Builder
 	sb.WriteString("Provide a detailed summary of our conversation above.")
 	if len(todos) > 0 {
@@ -1161,5 +1486,13 @@ func buildSummaryPrompt(todos []session.Todo) string {
 		sb.WriteString("\nInclude these tasks and their statuses in your summary. ")
 		sb.WriteString("Instruct the resuming assistant to use the `todos` tool to continue tracking progress on these tasks.")
 	}
+	if len(pinnedFiles) > 0 {
+		sb.WriteString("\n\n## Pinned Files\n\n")
+		for _, path := range pinnedFiles {
+			fmt.Fprintf(&sb, "- %s\n", path)
+		}
+		sb.WriteString("\nThese files are pinned and their current contents will be provided to the resuming assistant automatically. ")
+		sb.WriteString("Do not reproduce their contents; summarize only what was decided or changed about them.")
+	}
 	return sb.String()
 }
@@ -11,6 +11,7 @@ import (
 	"charm.land/fantasy"
 	"charm.land/x/vcr"
 	"github.com/purpose168/crush-cn/internal/agent/tools"
+	"github.com/purpose168/crush-cn/internal/csync"
 	"github.com/purpose168/crush-cn/internal/message"
 	"github.com/purpose168/crush-cn/internal/session"
 	"github.com/stretchr/testify/assert"
@@ -652,8 +653,54 @@ func BenchmarkBuildSummaryPrompt(b *testing.B) {
 		b.Run(tc.name, func(b *testing.B) {
 			b.ReportAllocs()
 			for range b.N {
-				_ = buildSummaryPrompt(todos)
+				_ = buildSummaryPrompt(todos, nil)
 			}
 		})
 	}
 }
+
+// TestQueuedPromptManagement 测试排队提示的编辑、移动和删除
+func TestQueuedPromptManagement(t *testing.T) {
+	t.Parallel()
+
+	const sessionID = "session"
+	newAgent := func() *sessionAgent {
+		a := &sessionAgent{messageQueue: csync.NewMap[string, []SessionAgentCall]()}
+		a.messageQueue.Set(sessionID, []SessionAgentCall{
+			{SessionID: sessionID, Prompt: "a"},
+			{SessionID: sessionID, Prompt: "b"},
+			{SessionID: sessionID, Prompt: "c"},
+		})
+		return a
+	}
+
+	t.Run("编辑", func(t *testing.T) {
+		t.Parallel()
+		a := newAgent()
+		require.NoError(t, a.EditQueuedPrompt(sessionID, 1, "B"))
+		require.Equal(t, []string{"a", "B", "c"}, a.QueuedPromptsList(sessionID))
+		require.ErrorIs(t, a.EditQueuedPrompt(sessionID, 1, ""), ErrEmptyPrompt)
+		require.ErrorIs(t, a.EditQueuedPrompt(sessionID, 3, "d"), ErrQueueIndexOutOfRange)
+	})
+
+	t.Run("移动", func(t *testing.T) {
+		t.Parallel()
+		a := newAgent()
+		require.NoError(t, a.MoveQueuedPrompt(sessionID, 2, 0))
+		require.Equal(t, []string{"c", "a", "b"}, a.QueuedPromptsList(sessionID))
+		require.NoError(t, a.MoveQueuedPrompt(sessionID, 0, 2))
+		require.Equal(t, []string{"a", "b", "c"}, a.QueuedPromptsList(sessionID))
+		require.ErrorIs(t, a.MoveQueuedPrompt(sessionID, -1, 0), ErrQueueIndexOutOfRange)
+	})
+
+	t.Run("删除", func(t *testing.T) {
+		t.Parallel()
+		a := newAgent()
+		require.NoError(t, a.RemoveQueuedPrompt(sessionID, 1))
+		require.Equal(t, []string{"a", "c"}, a.QueuedPromptsList(sessionID))
+		require.NoError(t, a.RemoveQueuedPrompt(sessionID, 0))
+		require.NoError(t, a.RemoveQueuedPrompt(sessionID, 0))
+		require.Zero(t, a.QueuedPrompts(sessionID))
+		require.ErrorIs(t, a.RemoveQueuedPrompt(sessionID, 0), ErrQueueIndexOutOfRange)
+	})
+}
@@ -1,10 +1,12 @@
 package agent
 
 import (
+	"cmp"
 	"context"
 	_ "embed"
 	"errors"
 	"fmt"
+	"strings"
 
 	"charm.land/fantasy"
 
@@ -21,6 +23,7 @@ var agentToolDescription []byte
 
 type AgentParams struct {
 	Prompt string `json:"prompt" description:"The task for the agent to perform"`
+	Agent  string `json:"agent,omitempty" description:"Name of a custom agent to run the task; omit to use the default search agent"`
 }
 
 // AgentToolName 代理工具名称
@@ -34,23 +37,48 @@ func (c *coordinator) agentTool(ctx context.Context) (fantasy.AgentTool, error)
 	if !ok {
 		return nil, errors.New("任务代理未配置")
 	}
-	prompt, err := taskPrompt(prompt.WithWorkingDir(c.cfg.WorkingDir()))
+	promptOpts := []prompt.Option{prompt.WithWorkingDir(c.toolWorkingDir())}
+	prompt, err := taskPrompt(promptOpts...)
 	if err != nil {
 		return nil, err
 	}
 
-	agent, err := c.buildAgent(ctx, prompt, agentCfg, true)
+	taskAgent, err := c.buildAgent(ctx, prompt, agentCfg, true)
 	if err != nil {
 		return nil, err
 	}
+
+	customNames := c.cfg.CustomAgentNames()
+	customAgents := make(map[string]SessionAgent, len(customNames))
+	for _, name := range customNames {
+		customCfg := c.cfg.Agents[name]
+		customPrompt, err := customAgentPrompt(name, customCfg.SystemPrompt, promptOpts...)
+		if err != nil {
+			return nil, err
+		}
+		customAgents[name], err = c.buildAgent(ctx, customPrompt, customCfg, true)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	return fantasy.NewParallelAgentTool(
 		AgentToolName,
-		string(agentToolDescription),
+		agentToolDescriptionFor(c.cfg, customNames),
 		func(ctx context.Context, params AgentParams, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
 			if params.Prompt == "" {
 				return fantasy.NewTextErrorResponse("提示词是必需的"), nil
 			}
 
+			agent := taskAgent
+			if params.Agent != "" {
+				custom, ok := customAgents[params.Agent]
+				if !ok {
+					return fantasy.NewTextErrorResponse(fmt.Sprintf("未知的代理 %q，可用的代理: %s", params.Agent, strings.Join(customNames, ", "))), nil
+				}
+				agent = custom
+			}
+
 			sessionID := tools.GetSessionFromContext(ctx)
 			if sessionID == "" {
 				return fantasy.ToolResponse{}, errors.New("上下文缺少会话ID")
@@ -108,3 +136,19 @@ func (c *coordinator) agentTool(ctx context.Context) (fantasy.AgentTool, error)
 			return fantasy.NewTextResponse(result.Response.Content.Text()), nil
 		}), nil
 }
+
+// agentToolDescriptionFor 返回代理工具的描述，配置了自定义代理时附加它们的名称和用途
+func agentToolDescriptionFor(cfg *config.Config, customNames []string) string {
+	if len(customNames) == 0 {
+		return string(agentToolDescription)
+	}
+	var sb strings.Builder
+	sb.Write(agentToolDescription)
+	sb.WriteString("\n<custom_agents>\n")
+	sb.WriteString("Set the `agent` parameter to one of the names below to run the task with that purpose-built agent instead of the default search agent. Custom agents have their own system prompt and may have a different set of tools than described above.\n")
+	for _, name := range customNames {
+		fmt.Fprintf(&sb, "- %s: %s\n", name, cmp.Or(cfg.Agents[name].Description, "(no description)"))
+	}
+	sb.WriteString("</custom_agents>\n")
+	return sb.String()
+}
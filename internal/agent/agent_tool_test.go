@@ -0,0 +1,36 @@
+package agent
+
+import (
+	"testing"
+
+	"github.com/purpose168/crush-cn/internal/agent/prompt"
+	"github.com/purpose168/crush-cn/internal/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCustomAgentPrompt(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	p, err := customAgentPrompt("reviewer", "Review {{.Diff}} for \"bugs\".\n审查代码。", prompt.WithWorkingDir(dir))
+	require.NoError(t, err)
+
+	built, err := p.Build(t.Context(), "", "", config.Config{Options: &config.Options{}})
+	require.NoError(t, err)
+	require.Contains(t, built, "Review {{.Diff}} for \"bugs\".\n审查代码。\n")
+	require.Contains(t, built, "Working directory: "+dir)
+}
+
+func TestAgentToolDescriptionFor(t *testing.T) {
+	t.Parallel()
+
+	cfg := &config.Config{Agents: map[string]config.Agent{
+		"reviewer": {Description: "Reviews diffs"},
+		"tester":   {},
+	}}
+	require.Equal(t, string(agentToolDescription), agentToolDescriptionFor(cfg, nil))
+
+	description := agentToolDescriptionFor(cfg, []string{"reviewer", "tester"})
+	require.Contains(t, description, "- reviewer: Reviews diffs\n")
+	require.Contains(t, description, "- tester: (no description)\n")
+}
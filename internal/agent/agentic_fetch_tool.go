@@ -5,15 +5,18 @@ import (
 	_ "embed"
 	"errors"
 	"fmt"
+	"log/slog"
 	"net/http"
 	"os"
-	"time"
 
 	"charm.land/fantasy"
 
 	"github.com/purpose168/crush-cn/internal/agent/prompt"
 	"github.com/purpose168/crush-cn/internal/agent/tools"
+	"github.com/purpose168/crush-cn/internal/httpcache"
+	"github.com/purpose168/crush-cn/internal/message"
 	"github.com/purpose168/crush-cn/internal/permission"
+	"github.com/purpose168/crush-cn/internal/vfs"
 )
 
 //go:embed templates/agentic_fetch.md
@@ -54,16 +57,10 @@ var agenticFetchPromptTmpl []byte
 func (c *coordinator) agenticFetchTool(_ context.Context, client *http.Client) (fantasy.AgentTool, error) {
 	if client == nil {
 		// 如果没有提供 HTTP 客户端，创建一个带有合理配置的客户端
-		transport := http.DefaultTransport.(*http.Transport).Clone()
-		transport.MaxIdleConns = 100
-		transport.MaxIdleConnsPerHost = 10
-		transport.IdleConnTimeout = 90 * time.Second
-
-		client = &http.Client{
-			Timeout:   30 * time.Second,
-			Transport: transport,
-		}
+		client = newFetchClient()
 	}
+	// 页面抓取经过磁盘缓存，搜索请求不缓存
+	fetchClient := c.cachedClient(client)
 
 	return fantasy.NewParallelAgentTool(
 		tools.AgenticFetchToolName,
@@ -102,6 +99,15 @@ func (c *coordinator) agenticFetchTool(_ context.Context, client *http.Client) (
 				return fantasy.ToolResponse{}, permission.ErrorPermissionDenied
 			}
 
+			// 同一 URL 和提示词的提取结果在页面缓存有效期内直接复用，不再消耗令牌
+			cacheKey := agenticFetchCacheKey(params.URL, params.Prompt)
+			if params.URL != "" {
+				if cached, _, ok := c.fetchCache.Get(cacheKey); ok {
+					slog.Debug("Using cached agentic fetch result", "url", params.URL)
+					return fantasy.NewTextResponse(string(cached)), nil
+				}
+			}
+
 			// 创建临时目录用于存储获取的内容
 			tmpDir, err := os.MkdirTemp(c.cfg.Options.DataDirectory, "crush-fetch-*")
 			if err != nil {
@@ -113,7 +119,7 @@ func (c *coordinator) agenticFetchTool(_ context.Context, client *http.Client) (
 
 			if params.URL != "" {
 				// URL 模式: 先获取 URL 内容
-				content, err := tools.FetchURLAndConvert(ctx, client, params.URL)
+				content, err := tools.FetchURLAndConvert(ctx, fetchClient, params.URL)
 				if err != nil {
 					return fantasy.NewTextErrorResponse(fmt.Sprintf("获取 URL 失败: %s", err)), nil
 				}
@@ -174,7 +180,7 @@ func (c *coordinator) agenticFetchTool(_ context.Context, client *http.Client) (
 			}
 
 			// 创建网络工具
-			webFetchTool := tools.NewWebFetchTool(tmpDir, client)
+			webFetchTool := tools.NewWebFetchTool(tmpDir, fetchClient)
 			webSearchTool := tools.NewWebSearchTool(client)
 			fetchTools := []fantasy.AgentTool{
 				webFetchTool,
@@ -182,7 +188,7 @@ func (c *coordinator) agenticFetchTool(_ context.Context, client *http.Client) (
 				tools.NewGlobTool(tmpDir),
 				tools.NewGrepTool(tmpDir),
 				tools.NewSourcegraphTool(client),
-				tools.NewViewTool(c.lspManager, c.permissions, c.filetracker, tmpDir),
+				tools.NewViewTool(c.lspManager, c.permissions, c.filetracker, vfs.Local{}, tmpDir),
 			}
 
 			// 创建会话代理
@@ -192,10 +198,15 @@ func (c *coordinator) agenticFetchTool(_ context.Context, client *http.Client) (
 				SystemPromptPrefix:   smallProviderCfg.SystemPromptPrefix,
 				SystemPrompt:         systemPrompt,
 				DisableAutoSummarize: c.cfg.Options.DisableAutoSummarize,
+				DisableAutoTitle:     c.cfg.Options.DisableAutoTitle,
 				IsYolo:               c.permissions.SkipRequests(),
 				Sessions:             c.sessions,
 				Messages:             c.messages,
 				Tools:                fetchTools,
+				EventLog:             c.eventLog,
+				Budget:               c.budget,
+				DiscardReasoning:     c.cfg.Options.DiscardReasoning,
+				ToolRepair:           c.cfg.Options.ToolRepair,
 			})
 
 			// 创建代理工具会话
@@ -248,7 +259,44 @@ func (c *coordinator) agenticFetchTool(_ context.Context, client *http.Client) (
 				return fantasy.ToolResponse{}, fmt.Errorf("保存父会话失败: %s", err)
 			}
 
-			// 返回代理的响应
-			return fantasy.NewTextResponse(result.Response.Content.Text()), nil
+			// 提取结果与页面缓存同时过期
+			response := result.Response.Content.Text()
+			if params.URL != "" {
+				if expires, ok := c.fetchCache.Expires(httpcache.ResponseKey(params.URL)); ok {
+					if err := c.fetchCache.Put(cacheKey, []byte(response), expires); err != nil {
+						slog.Warn("Failed to cache agentic fetch result", "url", params.URL, "error", err)
+					}
+				}
+			}
+
+			// 返回代理的响应，元数据中列出子代理抓取和搜索到的来源
+			return fantasy.WithResponseMetadata(fantasy.NewTextResponse(response), c.agenticFetchSources(ctx, session.ID, params.URL)), nil
 		}), nil
 }
+
+// agenticFetchSources 收集子代理会话中抓取和搜索到的来源，指定的 URL 排在最前面。
+func (c *coordinator) agenticFetchSources(ctx context.Context, sessionID, url string) tools.WebSourcesMetadata {
+	var sources []tools.WebSource
+	if url != "" {
+		sources = append(sources, tools.WebSource{URL: url})
+	}
+	msgs, err := c.messages.List(ctx, sessionID)
+	if err != nil {
+		slog.Warn("Failed to list agentic fetch messages", "session_id", sessionID, "error", err)
+		return tools.WebSourcesMetadata{Sources: sources}
+	}
+	calls := make(map[string]message.ToolCall)
+	for _, msg := range msgs {
+		for _, tc := range msg.ToolCalls() {
+			calls[tc.ID] = tc
+		}
+		for _, result := range msg.ToolResults() {
+			tc, ok := calls[result.ToolCallID]
+			if !ok || result.IsError {
+				continue
+			}
+			sources = tools.MergeWebSources(sources, tools.WebSources(tc.Name, tc.Input, result.Metadata)...)
+		}
+	}
+	return tools.WebSourcesMetadata{Sources: sources}
+}
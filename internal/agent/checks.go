@@ -0,0 +1,188 @@
+package agent
+
+import (
+	"context"
+	"log/slog"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+
+	"charm.land/fantasy"
+	"github.com/purpose168/crush-cn/internal/agent/tools"
+	"github.com/purpose168/crush-cn/internal/checks"
+	"github.com/purpose168/crush-cn/internal/dryrun"
+	"github.com/purpose168/crush-cn/internal/message"
+)
+
+// checkedTools 是完成后触发检查的编辑工具
+var checkedTools = []string{
+	tools.EditToolName,
+	tools.MultiEditToolName,
+	tools.WriteToolName,
+}
+
+// checksRunner 返回编辑后运行的检查，子智能体不运行检查
+func (c *coordinator) checksRunner(isSubAgent bool) *checks.Runner {
+	if isSubAgent {
+		return nil
+	}
+	return checks.NewRunner(c.toolWorkingDir(), c.cfg.Options.Checks, c.shellRunner())
+}
+
+// editChecks 在编辑工具完成后异步运行配置的检查。同一会话中新的编辑会取消仍在运行的检查。
+// 失败报告附加到触发检查的工具结果上：保存的消息会立即更新以便界面显示，
+// 运行中的智能体在下一步请求中看到。
+type editChecks struct {
+	runner   *checks.Runner
+	dryRun   dryrun.Service
+	messages message.Service
+
+	mu      sync.Mutex
+	running map[string]*checkRun         // 按会话 ID
+	reports map[string]map[string]string // 会话 ID -> 工具调用 ID -> 失败报告
+}
+
+type checkRun struct {
+	cancel context.CancelFunc
+}
+
+// newEditChecks 创建 [editChecks]，没有配置检查时返回 nil
+func newEditChecks(runner *checks.Runner, dryRun dryrun.Service, messages message.Service) *editChecks {
+	if runner == nil || messages == nil {
+		return nil
+	}
+	return &editChecks{
+		runner:   runner,
+		dryRun:   dryRun,
+		messages: messages,
+		running:  make(map[string]*checkRun),
+		reports:  make(map[string]map[string]string),
+	}
+}
+
+// schedule 在编辑工具的结果保存后启动检查
+func (c *editChecks) schedule(toolMsg message.Message, result message.ToolResult) {
+	if c == nil || result.IsError || !slices.Contains(checkedTools, result.Name) {
+		return
+	}
+	if c.dryRun != nil && c.dryRun.Enabled() {
+		// 演练模式下文件没有变化
+		return
+	}
+
+	sessionID := toolMsg.SessionID
+	ctx, cancel := context.WithCancel(context.Background())
+	run := &checkRun{cancel: cancel}
+	c.mu.Lock()
+	if previous, ok := c.running[sessionID]; ok {
+		previous.cancel()
+	}
+	c.running[sessionID] = run
+	c.mu.Unlock()
+
+	go func() {
+		defer cancel()
+		failures, err := c.runner.Run(ctx)
+
+		c.mu.Lock()
+		if c.running[sessionID] == run {
+			delete(c.running, sessionID)
+		}
+		report := checks.Format(failures)
+		if err == nil && report != "" {
+			if c.reports[sessionID] == nil {
+				c.reports[sessionID] = make(map[string]string)
+			}
+			c.reports[sessionID][result.ToolCallID] = report
+		}
+		c.mu.Unlock()
+
+		if err != nil || report == "" {
+			return
+		}
+		slog.Info("Post-edit checks failed", "session_id", sessionID, "tool_call_id", result.ToolCallID, "failures", len(failures))
+		c.attach(ctx, toolMsg.ID, result.ToolCallID, report)
+	}()
+}
+
+// attach 将失败报告追加到保存的工具结果中
+func (c *editChecks) attach(ctx context.Context, messageID, toolCallID, report string) {
+	msg, err := c.messages.Get(ctx, messageID)
+	if err != nil {
+		slog.Error("Failed to load tool message for check report", "message_id", messageID, "error", err)
+		return
+	}
+	for i, part := range msg.Parts {
+		toolResult, ok := part.(message.ToolResult)
+		if !ok || toolResult.ToolCallID != toolCallID {
+			continue
+		}
+		toolResult.Content += report
+		msg.Parts[i] = toolResult
+		if err := c.messages.Update(ctx, msg); err != nil {
+			slog.Error("Failed to attach check report", "message_id", messageID, "error", err)
+		}
+		return
+	}
+}
+
+// annotate 将已完成的失败报告追加到发送给模型的对应工具结果中，已包含报告的结果保持不变
+func (c *editChecks) annotate(sessionID string, messages []fantasy.Message) []fantasy.Message {
+	if c == nil {
+		return messages
+	}
+	c.mu.Lock()
+	reports := maps.Clone(c.reports[sessionID])
+	c.mu.Unlock()
+	if len(reports) == 0 {
+		return messages
+	}
+
+	result := make([]fantasy.Message, len(messages))
+	copy(result, messages)
+	for i, msg := range result {
+		if msg.Role != fantasy.MessageRoleTool {
+			continue
+		}
+		parts := make([]fantasy.MessagePart, 0, len(msg.Content))
+		for _, part := range msg.Content {
+			if toolResult, ok := fantasy.AsMessagePart[fantasy.ToolResultPart](part); ok {
+				output, ok := fantasy.AsToolResultOutputType[fantasy.ToolResultOutputContentText](toolResult.Output)
+				report := reports[toolResult.ToolCallID]
+				if ok && report != "" && !strings.Contains(output.Text, report) {
+					output.Text += report
+					toolResult.Output = output
+					parts = append(parts, toolResult)
+					continue
+				}
+			}
+			parts = append(parts, part)
+		}
+		msg.Content = parts
+		result[i] = msg
+	}
+	return result
+}
+
+// forget 丢弃会话已完成的失败报告，报告已保存在消息中，新的运行会从消息中读取
+func (c *editChecks) forget(sessionID string) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	delete(c.reports, sessionID)
+	c.mu.Unlock()
+}
+
+// cancelAll 取消所有正在运行的检查
+func (c *editChecks) cancelAll() {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, run := range c.running {
+		run.cancel()
+	}
+}
@@ -0,0 +1,48 @@
+package agent
+
+import (
+	"testing"
+
+	"charm.land/fantasy"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEditChecksAnnotate(t *testing.T) {
+	t.Parallel()
+
+	toolMessage := func(id, text string) fantasy.Message {
+		return fantasy.Message{
+			Role: fantasy.MessageRoleTool,
+			Content: []fantasy.MessagePart{fantasy.ToolResultPart{
+				ToolCallID: id,
+				Output:     fantasy.ToolResultOutputContentText{Text: text},
+			}},
+		}
+	}
+	outputText := func(msg fantasy.Message) string {
+		part, ok := fantasy.AsMessagePart[fantasy.ToolResultPart](msg.Content[0])
+		require.True(t, ok)
+		output, ok := fantasy.AsToolResultOutputType[fantasy.ToolResultOutputContentText](part.Output)
+		require.True(t, ok)
+		return output.Text
+	}
+
+	var disabled *editChecks
+	messages := []fantasy.Message{toolMessage("call-1", "edited"), toolMessage("call-2", "edited")}
+	require.Equal(t, messages, disabled.annotate("session", messages))
+
+	c := &editChecks{reports: map[string]map[string]string{
+		"session": {"call-1": "\n<check_failures>\nbroken\n</check_failures>\n"},
+	}}
+	annotated := c.annotate("session", messages)
+	require.Equal(t, "edited\n<check_failures>\nbroken\n</check_failures>\n", outputText(annotated[0]))
+	require.Equal(t, "edited", outputText(annotated[1]))
+	require.Equal(t, "edited", outputText(messages[0]), "original messages must not change")
+
+	// 已包含报告的结果不会重复追加
+	require.Equal(t, annotated, c.annotate("session", annotated))
+	require.Equal(t, messages, c.annotate("other", messages))
+
+	c.forget("session")
+	require.Equal(t, messages, c.annotate("session", messages))
+}
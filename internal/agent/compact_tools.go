@@ -0,0 +1,220 @@
+package agent
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"charm.land/fantasy"
+	"github.com/purpose168/crush-cn/internal/config"
+)
+
+const (
+	// defaultCompactDescriptionLength 是精简工具定义时描述默认的最大字符数
+	defaultCompactDescriptionLength = 200
+	// compactOptionsParam 是合并后的可选参数对象的参数名
+	compactOptionsParam = "options"
+)
+
+// compactTool 包装工具，向模型提供精简后的工具定义。合并到 options 对象中的可选参数
+// 在调用工具前展开为原来的参数，工具看到的参数与未精简时相同。
+type compactTool struct {
+	fantasy.AgentTool
+	info fantasy.ToolInfo
+	// merged 是合并到 options 对象中的可选参数名
+	merged []string
+}
+
+// compactToolsConfig 返回代理使用的模型适用的工具精简配置，不需要精简时返回 nil
+func (c *coordinator) compactToolsConfig(agent config.Agent) *config.CompactTools {
+	modelCfg, ok := c.cfg.Models[agent.Model]
+	if !ok {
+		return nil
+	}
+	providerCfg, ok := c.cfg.Providers.Get(modelCfg.Provider)
+	if !ok || providerCfg.CompactTools == nil {
+		return nil
+	}
+	compact := providerCfg.CompactTools
+	if compact.MaxContextWindow > 0 {
+		model := c.cfg.GetModel(modelCfg.Provider, modelCfg.Model)
+		if model == nil || model.ContextWindow == 0 || model.ContextWindow > compact.MaxContextWindow {
+			return nil
+		}
+	}
+	return compact
+}
+
+// applyCompactTools 在模型适用工具精简配置时精简每个工具的定义。
+// 严格工具参数要求列出所有属性，因此启用时不合并可选参数。
+func (c *coordinator) applyCompactTools(agent config.Agent, agentTools []fantasy.AgentTool) []fantasy.AgentTool {
+	compact := c.compactToolsConfig(agent)
+	if compact == nil {
+		return agentTools
+	}
+	maxLength := cmp.Or(compact.MaxDescriptionLength, defaultCompactDescriptionLength)
+	mergeAbove := compact.MergeOptionalAbove
+	if c.strictToolsEnabled(agent) {
+		mergeAbove = 0
+	}
+	result := make([]fantasy.AgentTool, 0, len(agentTools))
+	for _, tool := range agentTools {
+		info, merged := compactToolInfo(tool.Info(), maxLength, mergeAbove)
+		result = append(result, &compactTool{AgentTool: tool, info: info, merged: merged})
+	}
+	return result
+}
+
+// Info 实现 [fantasy.AgentTool]
+func (t *compactTool) Info() fantasy.ToolInfo {
+	return t.info
+}
+
+// Run 实现 [fantasy.AgentTool]
+func (t *compactTool) Run(ctx context.Context, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+	if len(t.merged) > 0 {
+		call.Input = expandOptions(call.Input)
+	}
+	return t.AgentTool.Run(ctx, call)
+}
+
+// compactToolInfo 精简工具定义：描述截断到 maxLength 个字符并移除参数示例。
+// mergeAbove 大于 0 且可选参数多于 mergeAbove 个时，可选参数合并为一个 options 对象，
+// 返回被合并的参数名。
+func compactToolInfo(info fantasy.ToolInfo, maxLength, mergeAbove int) (fantasy.ToolInfo, []string) {
+	info.Description = shortenDescription(info.Description, maxLength)
+	info.Parameters = compactProperties(info.Parameters, maxLength)
+
+	var optional []string
+	for _, name := range slices.Sorted(maps.Keys(info.Parameters)) {
+		if !slices.Contains(info.Required, name) {
+			optional = append(optional, name)
+		}
+	}
+	if mergeAbove <= 0 || len(optional) <= mergeAbove {
+		return info, nil
+	}
+	if _, exists := info.Parameters[compactOptionsParam]; exists {
+		return info, nil
+	}
+
+	summaries := make([]string, 0, len(optional))
+	for _, name := range optional {
+		summaries = append(summaries, fmt.Sprintf("%s (%s)", name, schemaSummary(info.Parameters[name])))
+		delete(info.Parameters, name)
+	}
+	info.Parameters[compactOptionsParam] = map[string]any{
+		"type":        "object",
+		"description": "Optional parameters: " + strings.Join(summaries, ", "),
+	}
+	return info, optional
+}
+
+// compactProperties 递归精简属性结构：缩短描述，移除示例
+func compactProperties(properties map[string]any, maxLength int) map[string]any {
+	result := make(map[string]any, len(properties))
+	for name, prop := range properties {
+		result[name] = compactSchema(prop, maxLength)
+	}
+	return result
+}
+
+// compactSchema 精简单个属性的结构
+func compactSchema(schema any, maxLength int) any {
+	m, ok := schema.(map[string]any)
+	if !ok {
+		return schema
+	}
+	result := maps.Clone(m)
+	delete(result, "examples")
+	delete(result, "example")
+	if description, ok := result["description"].(string); ok {
+		result["description"] = shortenDescription(description, maxLength)
+	}
+	if properties, ok := result["properties"].(map[string]any); ok {
+		result["properties"] = compactProperties(properties, maxLength)
+	}
+	if items, ok := result["items"]; ok {
+		result["items"] = compactSchema(items, maxLength)
+	}
+	return result
+}
+
+// shortenDescription 只保留描述的第一段并合并空白，超过 maxLength 个字符时
+// 尽量在句子结尾处截断，否则直接截断并添加省略号
+func shortenDescription(description string, maxLength int) string {
+	paragraph, _, _ := strings.Cut(strings.TrimSpace(description), "\n\n")
+	paragraph = strings.Join(strings.Fields(paragraph), " ")
+	if utf8.RuneCountInString(paragraph) <= maxLength {
+		return paragraph
+	}
+	runes := []rune(paragraph)
+	truncated := string(runes[:maxLength])
+	if i := strings.LastIndex(truncated, ". "); i > 0 {
+		return truncated[:i+1]
+	}
+	return strings.TrimSpace(string(runes[:max(0, maxLength-1)])) + "…"
+}
+
+// schemaSummary 返回属性类型的简短说明，例如 integer 或 one of a|b
+func schemaSummary(schema any) string {
+	m, _ := schema.(map[string]any)
+	if enum, ok := m["enum"].([]any); ok && len(enum) > 0 {
+		values := make([]string, 0, len(enum))
+		for _, v := range enum {
+			values = append(values, fmt.Sprint(v))
+		}
+		return "one of " + strings.Join(values, "|")
+	}
+	switch typ := m["type"].(type) {
+	case string:
+		if typ == "array" {
+			if items := schemaSummary(m["items"]); items != "any" {
+				return "array of " + items
+			}
+		}
+		return typ
+	case []any:
+		types := make([]string, 0, len(typ))
+		for _, t := range typ {
+			types = append(types, fmt.Sprint(t))
+		}
+		return strings.Join(types, "|")
+	}
+	return "any"
+}
+
+// expandOptions 将调用参数中的 options 对象展开为顶层参数，不覆盖已有的同名参数。
+// 部分模型会把对象编码为 JSON 字符串传回，同样予以展开。
+func expandOptions(input string) string {
+	var args map[string]any
+	if err := json.Unmarshal([]byte(input), &args); err != nil {
+		return input
+	}
+	raw, ok := args[compactOptionsParam]
+	if !ok {
+		return input
+	}
+	delete(args, compactOptionsParam)
+	options, ok := raw.(map[string]any)
+	if s, isString := raw.(string); isString {
+		ok = json.Unmarshal([]byte(s), &options) == nil
+	}
+	if ok {
+		for name, value := range options {
+			if _, exists := args[name]; !exists {
+				args[name] = value
+			}
+		}
+	}
+	data, err := json.Marshal(args)
+	if err != nil {
+		return input
+	}
+	return string(data)
+}
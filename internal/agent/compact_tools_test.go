@@ -0,0 +1,71 @@
+package agent
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"charm.land/fantasy"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShortenDescription(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, "Reads a file.", shortenDescription("Reads a file.\n\nUsage notes:\n- long details", 100))
+	require.Equal(t, "Reads a file from disk.", shortenDescription("Reads a file   from\ndisk. Supports offsets and limits for large files.", 40))
+	require.Equal(t, "abcdefghi…", shortenDescription(strings.Repeat("abcdefghij", 3), 10))
+}
+
+func TestCompactToolInfo(t *testing.T) {
+	t.Parallel()
+
+	info := fantasy.ToolInfo{
+		Name:        "view",
+		Description: "Views a file.\n\nLong usage notes.",
+		Parameters: map[string]any{
+			"file_path": map[string]any{"type": "string", "description": "The path.\n\nMore.", "examples": []any{"/a"}},
+			"offset":    map[string]any{"type": "integer"},
+			"limit":     map[string]any{"type": "integer"},
+			"mode":      map[string]any{"type": "string", "enum": []any{"raw", "lines"}},
+			"tags":      map[string]any{"type": "array", "items": map[string]any{"type": "string"}},
+		},
+		Required: []string{"file_path"},
+	}
+
+	compacted, merged := compactToolInfo(info, 100, 0)
+	require.Nil(t, merged)
+	require.Equal(t, "Views a file.", compacted.Description)
+	require.Equal(t, map[string]any{"type": "string", "description": "The path."}, compacted.Parameters["file_path"])
+	require.Len(t, compacted.Parameters, 5)
+
+	compacted, merged = compactToolInfo(info, 100, 3)
+	require.Equal(t, []string{"limit", "mode", "offset", "tags"}, merged)
+	require.Len(t, compacted.Parameters, 2)
+	require.Equal(t, map[string]any{
+		"type":        "object",
+		"description": "Optional parameters: limit (integer), mode (one of raw|lines), offset (integer), tags (array of string)",
+	}, compacted.Parameters[compactOptionsParam])
+	require.Len(t, info.Parameters, 5, "original parameters must not be modified")
+}
+
+func TestCompactToolExpandsOptions(t *testing.T) {
+	t.Parallel()
+
+	var got string
+	tool := &compactTool{
+		AgentTool: fantasy.NewAgentTool("echo", "echo", func(_ context.Context, _ echoParams, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+			got = call.Input
+			return fantasy.NewTextResponse(call.Input), nil
+		}),
+		merged: []string{"limit", "text"},
+	}
+
+	_, err := tool.Run(t.Context(), fantasy.ToolCall{ID: "call-1", Name: "echo", Input: `{"text":"hi","options":{"limit":5,"text":"ignored"}}`})
+	require.NoError(t, err)
+	require.JSONEq(t, `{"text":"hi","limit":5}`, got)
+
+	_, err = tool.Run(t.Context(), fantasy.ToolCall{ID: "call-2", Name: "echo", Input: `{"options":"{\"limit\":2}"}`})
+	require.NoError(t, err)
+	require.JSONEq(t, `{"limit":2}`, got)
+}
@@ -0,0 +1,138 @@
+package agent
+
+import (
+	"context"
+	"crypto/sha256"
+	_ "embed"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"charm.land/fantasy"
+	"github.com/purpose168/crush-cn/internal/config"
+	"github.com/purpose168/crush-cn/internal/csync"
+)
+
+const (
+	// compressKeepRecent 是末尾保持原样的消息数，模型正在处理的内容不做压缩。
+	compressKeepRecent = 6
+	// compressMinLength 是值得压缩的最小内容长度（字节）。
+	compressMinLength = 1500
+	// compressMaxOutputTokens 是每次压缩允许小模型输出的最大令牌数。
+	compressMaxOutputTokens = 1024
+)
+
+//go:embed templates/compress.md
+var compressPrompt []byte
+
+// compressFunc 把一段文本压缩为更短的版本。
+type compressFunc func(ctx context.Context, text string) (string, error)
+
+// compressStaleMessages 在大模型配置了 compress 上下文策略时，使用小模型压缩过时的工具输出
+// 和较早的助手消息。会话中保存的消息不受影响，只改变发送给模型的内容。
+func (a *sessionAgent) compressStaleMessages(ctx context.Context, messages []fantasy.Message, largeModel Model) []fantasy.Message {
+	if largeModel.ModelCfg.ContextStrategy != config.ContextStrategyCompress {
+		return messages
+	}
+	smallModel := a.smallModel.Get()
+	systemPromptPrefix := a.systemPromptPrefix.Get()
+	compress := func(ctx context.Context, text string) (string, error) {
+		return compressWithModel(ctx, smallModel, systemPromptPrefix, text)
+	}
+	return compressMessages(ctx, messages, compress, a.compressed)
+}
+
+// compressMessages 压缩除最后 [compressKeepRecent] 条以外的工具结果文本和助手文本。
+// 压缩结果按原文哈希缓存，每段内容只压缩一次；压缩失败时保留原文。
+func compressMessages(ctx context.Context, messages []fantasy.Message, compress compressFunc, cache *csync.Map[string, string]) []fantasy.Message {
+	stale := len(messages) - compressKeepRecent
+	if stale <= 0 {
+		return messages
+	}
+
+	shorten := func(text string) string {
+		if len(text) < compressMinLength {
+			return text
+		}
+		sum := sha256.Sum256([]byte(text))
+		key := hex.EncodeToString(sum[:])
+		if compressed, ok := cache.Get(key); ok {
+			return compressed
+		}
+		compressed, err := compress(ctx, text)
+		if err != nil {
+			if !errors.Is(err, context.Canceled) {
+				slog.Warn("Failed to compress stale message", "error", err)
+			}
+			return text
+		}
+		compressed = strings.TrimSpace(compressed)
+		if compressed == "" || len(compressed) >= len(text) {
+			// 压缩后没有变短，记住原文以免重复尝试。
+			cache.Set(key, text)
+			return text
+		}
+		compressed = fmt.Sprintf("[Compressed from %d bytes]\n%s", len(text), compressed)
+		cache.Set(key, compressed)
+		return compressed
+	}
+
+	result := make([]fantasy.Message, len(messages))
+	copy(result, messages)
+	for i := range stale {
+		msg := result[i]
+		if msg.Role != fantasy.MessageRoleTool && msg.Role != fantasy.MessageRoleAssistant {
+			continue
+		}
+		parts := make([]fantasy.MessagePart, 0, len(msg.Content))
+		for _, part := range msg.Content {
+			if text, ok := fantasy.AsMessagePart[fantasy.TextPart](part); ok && msg.Role == fantasy.MessageRoleAssistant {
+				text.Text = shorten(text.Text)
+				parts = append(parts, text)
+				continue
+			}
+			if toolResult, ok := fantasy.AsMessagePart[fantasy.ToolResultPart](part); ok {
+				if output, ok := fantasy.AsToolResultOutputType[fantasy.ToolResultOutputContentText](toolResult.Output); ok {
+					output.Text = shorten(output.Text)
+					toolResult.Output = output
+					parts = append(parts, toolResult)
+					continue
+				}
+			}
+			parts = append(parts, part)
+		}
+		msg.Content = parts
+		result[i] = msg
+	}
+	return result
+}
+
+// compressWithModel 使用给定模型压缩文本。
+func compressWithModel(ctx context.Context, model Model, systemPromptPrefix, text string) (string, error) {
+	var maxOutputTokens int64 = compressMaxOutputTokens
+	if model.CatwalkCfg.CanReason {
+		maxOutputTokens = max(maxOutputTokens, model.CatwalkCfg.DefaultMaxTokens)
+	}
+	agent := fantasy.NewAgent(model.Model,
+		fantasy.WithSystemPrompt(string(compressPrompt)+"\n /no_think"),
+		fantasy.WithMaxOutputTokens(maxOutputTokens),
+	)
+	resp, err := agent.Generate(ctx, fantasy.AgentCall{
+		Prompt: fmt.Sprintf("Compress the following content:\n\n%s", text),
+		PrepareStep: func(callCtx context.Context, opts fantasy.PrepareStepFunctionOptions) (_ context.Context, prepared fantasy.PrepareStepResult, err error) {
+			prepared.Messages = opts.Messages
+			if systemPromptPrefix != "" {
+				prepared.Messages = append([]fantasy.Message{
+					fantasy.NewSystemMessage(systemPromptPrefix),
+				}, prepared.Messages...)
+			}
+			return callCtx, prepared, nil
+		},
+	})
+	if err != nil {
+		return "", err
+	}
+	return thinkTagRegex.ReplaceAllString(resp.Response.Content.Text(), ""), nil
+}
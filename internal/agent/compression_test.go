@@ -0,0 +1,109 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"charm.land/fantasy"
+	"github.com/purpose168/crush-cn/internal/csync"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompressMessages(t *testing.T) {
+	t.Parallel()
+
+	long := strings.Repeat("line of tool output\n", 200)
+	toolResult := func(id, text string) fantasy.Message {
+		return fantasy.Message{
+			Role: fantasy.MessageRoleTool,
+			Content: []fantasy.MessagePart{fantasy.ToolResultPart{
+				ToolCallID: id,
+				Output:     fantasy.ToolResultOutputContentText{Text: text},
+			}},
+		}
+	}
+	assistant := func(text string) fantasy.Message {
+		return fantasy.Message{
+			Role:    fantasy.MessageRoleAssistant,
+			Content: []fantasy.MessagePart{fantasy.TextPart{Text: text}},
+		}
+	}
+	outputText := func(msg fantasy.Message) string {
+		part, ok := fantasy.AsMessagePart[fantasy.ToolResultPart](msg.Content[0])
+		require.True(t, ok)
+		output, ok := fantasy.AsToolResultOutputType[fantasy.ToolResultOutputContentText](part.Output)
+		require.True(t, ok)
+		return output.Text
+	}
+	assistantText := func(msg fantasy.Message) string {
+		part, ok := fantasy.AsMessagePart[fantasy.TextPart](msg.Content[0])
+		require.True(t, ok)
+		return part.Text
+	}
+
+	messages := []fantasy.Message{
+		fantasy.NewSystemMessage(long),
+		fantasy.NewUserMessage(long),
+		assistant(long),
+		toolResult("1", long),
+		toolResult("2", "short"),
+	}
+	for range compressKeepRecent {
+		messages = append(messages, toolResult("recent", long))
+	}
+
+	calls := 0
+	compress := func(ctx context.Context, text string) (string, error) {
+		calls++
+		return "summary", nil
+	}
+	cache := csync.NewMap[string, string]()
+
+	result := compressMessages(t.Context(), messages, compress, cache)
+	require.Len(t, result, len(messages))
+	require.Equal(t, 1, calls, "identical content should be compressed once")
+
+	// 系统消息和用户消息保持原样。
+	require.Equal(t, messages[0], result[0])
+	require.Equal(t, messages[1], result[1])
+
+	require.Equal(t, "[Compressed from 4000 bytes]\nsummary", assistantText(result[2]))
+	require.Equal(t, "[Compressed from 4000 bytes]\nsummary", outputText(result[3]))
+	require.Equal(t, "short", outputText(result[4]))
+	for _, msg := range result[len(result)-compressKeepRecent:] {
+		require.Equal(t, long, outputText(msg))
+	}
+	// 原始消息不被修改。
+	require.Equal(t, long, outputText(messages[3]))
+
+	// 后续请求使用缓存。
+	compressMessages(t.Context(), messages, compress, cache)
+	require.Equal(t, 1, calls)
+}
+
+func TestCompressMessagesKeepsOriginalOnFailure(t *testing.T) {
+	t.Parallel()
+
+	long := strings.Repeat("x", compressMinLength)
+	messages := make([]fantasy.Message, compressKeepRecent+1)
+	for i := range messages {
+		messages[i] = fantasy.Message{
+			Role:    fantasy.MessageRoleAssistant,
+			Content: []fantasy.MessagePart{fantasy.TextPart{Text: long}},
+		}
+	}
+
+	failing := func(ctx context.Context, text string) (string, error) {
+		return "", errors.New("boom")
+	}
+	result := compressMessages(t.Context(), messages, failing, csync.NewMap[string, string]())
+	require.Equal(t, messages, result)
+
+	longer := func(ctx context.Context, text string) (string, error) {
+		return text + text, nil
+	}
+	result = compressMessages(t.Context(), messages, longer, csync.NewMap[string, string]())
+	require.Equal(t, messages, result)
+}
@@ -0,0 +1,26 @@
+package agent
+
+import (
+	"context"
+	"log/slog"
+)
+
+// reloadContextFiles 在上下文文件或其中 @include 的文件于会话中途变化后，
+// 重新读取它们并重建编码代理的系统提示
+func (c *coordinator) reloadContextFiles(ctx context.Context) {
+	if c.mainPrompt == nil {
+		return
+	}
+	changed := c.mainPrompt.ChangedContextFiles()
+	if len(changed) == 0 {
+		return
+	}
+	model := c.currentAgent.Model()
+	systemPrompt, err := c.mainPrompt.Build(ctx, model.Model.Provider(), model.Model.Model(), *c.cfg)
+	if err != nil {
+		slog.Error("Failed to rebuild system prompt after context files changed", "changed", changed, "error", err)
+		return
+	}
+	c.currentAgent.SetSystemPrompt(systemPrompt)
+	slog.Info("Reloaded context files into system prompt", "changed", changed, "files", c.mainPrompt.ContextFiles())
+}
@@ -12,23 +12,32 @@ import (
 	"maps"
 	"net/http"
 	"os"
+	"path/filepath"
 	"slices"
 	"strings"
 
 	"charm.land/catwalk/pkg/catwalk"
 	"charm.land/fantasy"
+	mcpsdk "github.com/modelcontextprotocol/go-sdk/mcp"
 	"github.com/purpose168/crush-cn/internal/agent/hyper"
 	"github.com/purpose168/crush-cn/internal/agent/prompt"
 	"github.com/purpose168/crush-cn/internal/agent/tools"
+	"github.com/purpose168/crush-cn/internal/budget"
+	"github.com/purpose168/crush-cn/internal/capability"
 	"github.com/purpose168/crush-cn/internal/config"
+	"github.com/purpose168/crush-cn/internal/dryrun"
+	"github.com/purpose168/crush-cn/internal/eventlog"
 	"github.com/purpose168/crush-cn/internal/filetracker"
 	"github.com/purpose168/crush-cn/internal/history"
-	"github.com/purpose168/crush-cn/internal/log"
+	"github.com/purpose168/crush-cn/internal/httpcache"
 	"github.com/purpose168/crush-cn/internal/lsp"
 	"github.com/purpose168/crush-cn/internal/message"
-	"github.com/purpose168/crush-cn/internal/oauth/copilot"
 	"github.com/purpose168/crush-cn/internal/permission"
+	"github.com/purpose168/crush-cn/internal/redact"
 	"github.com/purpose168/crush-cn/internal/session"
+	"github.com/purpose168/crush-cn/internal/shell"
+	"github.com/purpose168/crush-cn/internal/tooloutput"
+	"github.com/purpose168/crush-cn/internal/vfs"
 	"golang.org/x/sync/errgroup"
 
 	"charm.land/fantasy/providers/anthropic"
@@ -64,12 +73,32 @@ type Coordinator interface {
 	QueuedPromptsList(sessionID string) []string
 	// ClearQueue 清除指定会话的队列
 	ClearQueue(sessionID string)
+	// EditQueuedPrompt 修改指定会话队列中指定位置的提示
+	EditQueuedPrompt(sessionID string, index int, prompt string) error
+	// MoveQueuedPrompt 将指定会话队列中的提示从 from 移动到 to
+	MoveQueuedPrompt(sessionID string, from, to int) error
+	// RemoveQueuedPrompt 删除指定会话队列中指定位置的提示
+	RemoveQueuedPrompt(sessionID string, index int) error
 	// Summarize 总结指定会话
 	Summarize(context.Context, string) error
 	// Model 获取当前模型
 	Model() Model
 	// UpdateModels 更新模型
 	UpdateModels(ctx context.Context) error
+	// RedactionReport 获取指定会话中已脱敏内容的统计
+	RedactionReport(sessionID string) []redact.Finding
+	// EnhancePrompt 结合会话上下文改写提示，使其更具体
+	EnhancePrompt(ctx context.Context, sessionID, prompt string) (string, error)
+	// Sample 使用配置的模型为 MCP 服务器的采样请求生成补全
+	Sample(ctx context.Context, params *mcpsdk.CreateMessageParams) (*mcpsdk.CreateMessageResult, error)
+	// RunCommand 使用 bash 工具在会话中执行用户发起的命令，并将输出追加为工具结果
+	RunCommand(ctx context.Context, sessionID, description, command string) error
+	// Regenerate 使用当前模型重新生成最后一轮回复，原来的回复保存为备选版本
+	Regenerate(ctx context.Context, sessionID string) (*fantasy.AgentResult, error)
+	// KeepVariant 将最后一轮对话换成指定的备选回复版本
+	KeepVariant(ctx context.Context, sessionID string, index int) error
+	// DeleteVariant 删除指定的备选回复版本
+	DeleteVariant(ctx context.Context, sessionID string, index int) error
 }
 
 // coordinator 协调器实现
@@ -80,7 +109,16 @@ type coordinator struct {
 	permissions permission.Service  // 权限服务
 	history     history.Service     // 历史服务
 	filetracker filetracker.Service // 文件追踪服务
+	dryRun      dryrun.Service      // 演练服务
+	fsys        vfs.FS              // 文件工具使用的文件系统，远程开发时通过 SSH 访问远程主机
+	budget      budget.Service      // 预算服务
 	lspManager  *lsp.Manager        // LSP 管理器
+	eventLog    *eventlog.Logger    // 事件日志
+	redactor    *redact.Redactor    // 敏感信息脱敏，禁用时为 nil
+	fetchCache  *httpcache.Cache    // 抓取类工具的磁盘缓存，禁用时为 nil
+	limiters    providerLimiters    // 各提供者的请求限速器
+	geminiCache geminiCaches        // 各 Gemini 提供者上传的文件和上下文缓存
+	mainPrompt  *prompt.Prompt      // 编码代理的提示，上下文文件变化时用于重建系统提示
 
 	currentAgent SessionAgent            // 当前代理
 	agents       map[string]SessionAgent // 代理映射
@@ -97,16 +135,26 @@ func NewCoordinator(
 	permissions permission.Service,
 	history history.Service,
 	filetracker filetracker.Service,
+	dryRun dryrun.Service,
+	fsys vfs.FS,
+	budget budget.Service,
 	lspManager *lsp.Manager,
 ) (Coordinator, error) {
+	eventLog := eventlog.New(filepath.Join(cfg.Options.DataDirectory, eventlog.DirName))
 	c := &coordinator{
 		cfg:         cfg,
 		sessions:    sessions,
 		messages:    messages,
-		permissions: permissions,
+		permissions: eventlog.WrapPermissions(permissions, eventLog),
+		eventLog:    eventLog,
 		history:     history,
 		filetracker: filetracker,
+		dryRun:      dryRun,
+		fsys:        vfs.OrLocal(fsys),
+		budget:      budget,
 		lspManager:  lspManager,
+		redactor:    newRedactor(cfg.Options.Redaction),
+		fetchCache:  newFetchCache(cfg),
 		agents:      make(map[string]SessionAgent),
 	}
 
@@ -116,7 +164,7 @@ func NewCoordinator(
 	}
 
 	// TODO: 当我们支持多个代理时，使其动态化
-	prompt, err := coderPrompt(prompt.WithWorkingDir(c.cfg.WorkingDir()))
+	prompt, err := coderPrompt(prompt.WithWorkingDir(c.toolWorkingDir()))
 	if err != nil {
 		return nil, err
 	}
@@ -125,6 +173,7 @@ func NewCoordinator(
 	if err != nil {
 		return nil, err
 	}
+	c.mainPrompt = prompt
 	c.currentAgent = agent
 	c.agents[config.AgentCoder] = agent
 	return c, nil
@@ -132,6 +181,11 @@ func NewCoordinator(
 
 // Run 实现 Coordinator 接口的 Run 方法
 func (c *coordinator) Run(ctx context.Context, sessionID string, prompt string, attachments ...message.Attachment) (*fantasy.AgentResult, error) {
+	return c.run(ctx, sessionID, prompt, false, attachments...)
+}
+
+// run 运行代理。regenerate 表示重新生成最后一轮回复，此时保留备选回复版本。
+func (c *coordinator) run(ctx context.Context, sessionID string, prompt string, regenerate bool, attachments ...message.Attachment) (*fantasy.AgentResult, error) {
 	if err := c.readyWg.Wait(); err != nil {
 		return nil, err
 	}
@@ -140,6 +194,7 @@ func (c *coordinator) Run(ctx context.Context, sessionID string, prompt string,
 	if err := c.UpdateModels(ctx); err != nil {
 		return nil, fmt.Errorf("更新模型失败: %w", err)
 	}
+	c.reloadContextFiles(ctx)
 
 	model := c.currentAgent.Model()
 	maxTokens := model.CatwalkCfg.DefaultMaxTokens
@@ -147,7 +202,10 @@ func (c *coordinator) Run(ctx context.Context, sessionID string, prompt string,
 		maxTokens = model.ModelCfg.MaxTokens
 	}
 
-	if !model.CatwalkCfg.SupportsImages && attachments != nil {
+	attachments = c.redactAttachments(sessionID, attachments)
+	pinnedFiles := c.loadPinnedFiles(ctx, sessionID)
+
+	if !capability.Of(model.CatwalkCfg, model.ModelCfg).Supports(capability.Images) && attachments != nil {
 		// 过滤掉图像附件
 		filteredAttachments := make([]message.Attachment, 0, len(attachments))
 		for _, att := range attachments {
@@ -164,6 +222,7 @@ func (c *coordinator) Run(ctx context.Context, sessionID string, prompt string,
 	}
 
 	mergedOptions, temp, topP, topK, freqPenalty, presPenalty := mergeCallOptions(model, providerCfg)
+	setPromptCacheKey(mergedOptions, providerCfg, sessionID)
 
 	if providerCfg.OAuthToken != nil && providerCfg.OAuthToken.IsExpired() {
 		slog.Debug("Token needs to be refreshed", "provider", providerCfg.ID)
@@ -177,6 +236,7 @@ func (c *coordinator) Run(ctx context.Context, sessionID string, prompt string,
 			SessionID:        sessionID,
 			Prompt:           prompt,
 			Attachments:      attachments,
+			PinnedFiles:      pinnedFiles,
 			MaxOutputTokens:  maxTokens,
 			ProviderOptions:  mergedOptions,
 			Temperature:      temp,
@@ -184,6 +244,9 @@ func (c *coordinator) Run(ctx context.Context, sessionID string, prompt string,
 			TopK:             topK,
 			FrequencyPenalty: freqPenalty,
 			PresencePenalty:  presPenalty,
+
+			DisablePromptCache: providerCfg.CacheDisabled(),
+			Regenerate:         regenerate,
 		})
 	}
 	result, originalErr := run()
@@ -279,6 +342,7 @@ func getProviderOptions(model Model, providerCfg config.ProviderConfig) fantasy.
 			mergedOptions["reasoning_effort"] = model.ModelCfg.ReasoningEffort
 		}
 		if openai.IsResponsesModel(model.CatwalkCfg.ID) {
+			setStrictJSONSchema(mergedOptions, providerCfg)
 			if openai.IsResponsesReasoningModel(model.CatwalkCfg.ID) {
 				mergedOptions["reasoning_summary"] = "auto"
 				mergedOptions["include"] = []openai.IncludeType{openai.IncludeReasoningEncryptedContent}
@@ -347,6 +411,15 @@ func getProviderOptions(model Model, providerCfg config.ProviderConfig) fantasy.
 		if !hasReasoningEffort && model.ModelCfg.ReasoningEffort != "" {
 			mergedOptions["reasoning_effort"] = model.ModelCfg.ReasoningEffort
 		}
+		if usesResponsesAPI(providerCfg, model.CatwalkCfg.ID) {
+			// Responses API 的模型按 openai 提供者读取调用选项
+			setStrictJSONSchema(mergedOptions, providerCfg)
+			parsed, err := openai.ParseResponsesOptions(mergedOptions)
+			if err == nil {
+				options[openai.Name] = parsed
+			}
+			break
+		}
 		parsed, err := openaicompat.ParseOptions(mergedOptions)
 		if err == nil {
 			options[openaicompat.Name] = parsed
@@ -356,6 +429,13 @@ func getProviderOptions(model Model, providerCfg config.ProviderConfig) fantasy.
 	return options
 }
 
+// setStrictJSONSchema 在提供者启用严格工具参数且未显式配置时，要求 Responses API 严格遵守工具参数结构
+func setStrictJSONSchema(mergedOptions map[string]any, providerCfg config.ProviderConfig) {
+	if _, ok := mergedOptions["strict_json_schema"]; !ok && providerCfg.StrictTools {
+		mergedOptions["strict_json_schema"] = true
+	}
+}
+
 // mergeCallOptions 合并调用选项
 func mergeCallOptions(model Model, cfg config.ProviderConfig) (fantasy.ProviderOptions, *float64, *float64, *int64, *float64, *float64) {
 	modelOptions := getProviderOptions(model, cfg)
@@ -373,6 +453,9 @@ func (c *coordinator) buildAgent(ctx context.Context, prompt *prompt.Prompt, age
 	if err != nil {
 		return nil, err
 	}
+	if agent.Model == config.SelectedModelTypeSmall {
+		large = small
+	}
 
 	largeProviderCfg, _ := c.cfg.Providers.Get(large.ModelCfg.Provider)
 	result := NewSessionAgent(SessionAgentOptions{
@@ -382,10 +465,17 @@ func (c *coordinator) buildAgent(ctx context.Context, prompt *prompt.Prompt, age
 		"",
 		isSubAgent,
 		c.cfg.Options.DisableAutoSummarize,
+		c.cfg.Options.DisableAutoTitle,
 		c.permissions.SkipRequests(),
 		c.sessions,
 		c.messages,
 		nil,
+		c.eventLog,
+		c.budget,
+		c.cfg.Options.DiscardReasoning,
+		c.checksRunner(isSubAgent),
+		c.dryRun,
+		c.cfg.Options.ToolRepair,
 	})
 
 	c.readyWg.Go(func() error {
@@ -393,6 +483,9 @@ func (c *coordinator) buildAgent(ctx context.Context, prompt *prompt.Prompt, age
 		if err != nil {
 			return err
 		}
+		if !isSubAgent {
+			slog.Info("Loaded context files into system prompt", "files", prompt.ContextFiles())
+		}
 		result.SetSystemPrompt(systemPrompt)
 		return nil
 	})
@@ -409,6 +502,75 @@ func (c *coordinator) buildAgent(ctx context.Context, prompt *prompt.Prompt, age
 	return result, nil
 }
 
+// issueFetchTokens 解析 issue_fetch 工具配置中的令牌
+func (c *coordinator) issueFetchTokens() tools.IssueFetchTokens {
+	resolve := func(value string) string {
+		if value == "" {
+			return ""
+		}
+		resolved, err := c.cfg.Resolve(value)
+		if err != nil {
+			slog.Warn("Failed to resolve issue_fetch token", "error", err)
+			return ""
+		}
+		return resolved
+	}
+	cfg := c.cfg.Tools.IssueFetch
+	return tools.IssueFetchTokens{
+		GitHub:      resolve(cfg.GitHubToken),
+		GitLab:      resolve(cfg.GitLabToken),
+		GitLabHosts: cfg.GitLabHosts,
+		AzureDevOps: resolve(cfg.AzureDevOpsToken),
+	}
+}
+
+// shellType 返回 bash 工具使用的 shell 类型。配置无效时回退到 POSIX shell。
+func (c *coordinator) shellType() shell.ShellType {
+	shellType, err := shell.ParseShellType(c.cfg.Options.Shell)
+	if err != nil {
+		slog.Warn("Invalid shell option, falling back to posix", "shell", c.cfg.Options.Shell, "error", err)
+	}
+	return shellType
+}
+
+// semanticSearchEmbedding 返回 semantic_search 工具使用的嵌入配置。如果配置了
+// provider，则使用该提供者的 base_url 和 api_key，显式配置的值优先。
+func (c *coordinator) semanticSearchEmbedding() tools.EmbeddingConfig {
+	cfg := c.cfg.Tools.SemanticSearch
+	embedding := tools.EmbeddingConfig{Model: cfg.Model}
+	if cfg.Model == "" {
+		return embedding
+	}
+
+	resolve := func(value string) string {
+		resolved, err := c.cfg.Resolve(value)
+		if err != nil {
+			slog.Warn("Failed to resolve semantic_search setting", "error", err)
+			return ""
+		}
+		return resolved
+	}
+
+	if cfg.Provider != "" {
+		providerCfg, ok := c.cfg.Providers.Get(cfg.Provider)
+		if !ok {
+			slog.Warn("Unknown semantic_search provider", "provider", cfg.Provider)
+		} else {
+			// 与 buildProvider 一样，提供者的 base_url 和 api_key 可能是 $ENV 模板。
+			embedding.BaseURL = resolve(providerCfg.BaseURL)
+			embedding.APIKey = resolve(providerCfg.APIKey)
+			embedding.Headers = providerCfg.ExtraHeaders
+		}
+	}
+	if cfg.BaseURL != "" {
+		embedding.BaseURL = resolve(cfg.BaseURL)
+	}
+	if cfg.APIKey != "" {
+		embedding.APIKey = resolve(cfg.APIKey)
+	}
+	return embedding
+}
+
 // buildTools 构建工具列表
 func (c *coordinator) buildTools(ctx context.Context, agent config.Agent) ([]fantasy.AgentTool, error) {
 	var allTools []fantasy.AgentTool
@@ -437,25 +599,43 @@ func (c *coordinator) buildTools(ctx context.Context, agent config.Agent) ([]fan
 	}
 
 	allTools = append(allTools,
-		tools.NewBashTool(c.permissions, c.cfg.WorkingDir(), c.cfg.Options.Attribution, modelName),
+		tools.NewBashTool(c.permissions, c.sessions, c.toolWorkingDir(), c.cfg.Options.Attribution, modelName, c.cfg.User.Author(), c.shellType(), c.shellRunner()),
 		tools.NewJobOutputTool(),
 		tools.NewJobKillTool(),
 		tools.NewDownloadTool(c.permissions, c.cfg.WorkingDir(), nil),
-		tools.NewEditTool(c.lspManager, c.permissions, c.history, c.filetracker, c.cfg.WorkingDir()),
-		tools.NewMultiEditTool(c.lspManager, c.permissions, c.history, c.filetracker, c.cfg.WorkingDir()),
-		tools.NewFetchTool(c.permissions, c.cfg.WorkingDir(), nil),
+		tools.NewEditTool(c.lspManager, c.permissions, c.history, c.filetracker, c.dryRun, c.fsys, c.toolWorkingDir()),
+		tools.NewMultiEditTool(c.lspManager, c.permissions, c.history, c.filetracker, c.dryRun, c.fsys, c.toolWorkingDir()),
+		tools.NewFetchTool(c.permissions, c.cfg.WorkingDir(), c.cachedClient(nil)),
+		tools.NewIssueFetchTool(c.permissions, c.cfg.WorkingDir(), c.issueFetchTokens(), nil),
+		tools.NewGitStatusTool(c.cfg.WorkingDir()),
+		tools.NewGitDiffTool(c.cfg.WorkingDir()),
+		tools.NewGitCommitTool(c.permissions, c.cfg.WorkingDir(), c.cfg.Options.Attribution, modelName, c.cfg.User.Author()),
 		tools.NewGlobTool(c.cfg.WorkingDir()),
 		tools.NewGrepTool(c.cfg.WorkingDir()),
 		tools.NewLsTool(c.permissions, c.cfg.WorkingDir(), c.cfg.Tools.Ls),
+		tools.NewRepoMapTool(c.cfg.WorkingDir()),
+		tools.NewRunTestsTool(c.permissions, c.cfg.WorkingDir()),
 		tools.NewSourcegraphTool(nil),
 		tools.NewTodosTool(c.sessions),
-		tools.NewViewTool(c.lspManager, c.permissions, c.filetracker, c.cfg.WorkingDir(), c.cfg.Options.SkillsPaths...),
-		tools.NewWriteTool(c.lspManager, c.permissions, c.history, c.filetracker, c.cfg.WorkingDir()),
+		tools.NewViewTool(c.lspManager, c.permissions, c.filetracker, c.fsys, c.toolWorkingDir(), c.cfg.Options.SkillsPaths...),
+		tools.NewWriteTool(c.lspManager, c.permissions, c.history, c.filetracker, c.dryRun, c.fsys, c.toolWorkingDir()),
 	)
 
 	// 如果用户配置了 LSP 或启用了 auto_lsp，则添加 LSP 工具
 	if len(c.cfg.LSP) > 0 || c.cfg.Options.AutoLSP == nil || *c.cfg.Options.AutoLSP {
-		allTools = append(allTools, tools.NewDiagnosticsTool(c.lspManager), tools.NewReferencesTool(c.lspManager), tools.NewLSPRestartTool(c.lspManager))
+		allTools = append(allTools,
+			tools.NewDiagnosticsTool(c.lspManager),
+			tools.NewReferencesTool(c.lspManager),
+			tools.NewLSPSymbolsTool(c.lspManager, c.cfg.WorkingDir()),
+			tools.NewLSPRestartTool(c.lspManager),
+			tools.NewLSPRenameTool(c.lspManager, c.permissions, c.history, c.filetracker, c.cfg.WorkingDir()),
+			tools.NewLSPCodeActionTool(c.lspManager, c.permissions, c.history, c.filetracker, c.cfg.WorkingDir()),
+		)
+	}
+
+	// 如果用户配置了嵌入模型，则添加语义搜索工具
+	if embedding := c.semanticSearchEmbedding(); embedding.Enabled() {
+		allTools = append(allTools, tools.NewSemanticSearchTool(c.cfg.WorkingDir(), c.cfg.Options.DataDirectory, embedding, nil))
 	}
 
 	if len(c.cfg.MCP) > 0 {
@@ -467,7 +647,7 @@ func (c *coordinator) buildTools(ctx context.Context, agent config.Agent) ([]fan
 	}
 
 	var filteredTools []fantasy.AgentTool
-	for _, tool := range allTools {
+	for _, tool := range c.withoutLocalOnlyTools(allTools) {
 		if slices.Contains(agent.AllowedTools, tool.Info().Name) {
 			filteredTools = append(filteredTools, tool)
 		}
@@ -498,7 +678,18 @@ func (c *coordinator) buildTools(ctx context.Context, agent config.Agent) ([]fan
 	slices.SortFunc(filteredTools, func(a, b fantasy.AgentTool) int {
 		return strings.Compare(a.Info().Name, b.Info().Name)
 	})
-	return filteredTools, nil
+	return c.applyStrictTools(agent, c.applyCompactTools(agent, c.wrapTools(filteredTools))), nil
+}
+
+// wrapTools 为工具添加执行时的包装：目录说明、计划模式、执行时限、输出脱敏和截断。
+// 用户直接执行的工具调用也必须经过这些包装。
+func (c *coordinator) wrapTools(agentTools []fantasy.AgentTool) []fantasy.AgentTool {
+	return c.limitToolOutputs(c.redactToolOutputs(c.applyToolTimeouts(c.applyPlanMode(c.applyDirectoryOverlays(agentTools)))))
+}
+
+// toolOutputDir 返回保存被截断工具结果完整输出的目录
+func (c *coordinator) toolOutputDir() string {
+	return filepath.Join(c.cfg.Options.DataDirectory, tooloutput.DirName)
 }
 
 // buildAgentModels 构建代理模型
@@ -586,7 +777,7 @@ func (c *coordinator) buildAgentModels(ctx context.Context, isSubAgent bool) (Mo
 		}, nil
 }
 
-func (c *coordinator) buildAnthropicProvider(baseURL, apiKey string, headers map[string]string) (fantasy.Provider, error) {
+func (c *coordinator) buildAnthropicProvider(baseURL, apiKey string, headers map[string]string, httpClient *http.Client) (fantasy.Provider, error) {
 	var opts []anthropic.Option
 
 	if strings.HasPrefix(apiKey, "Bearer ") {
@@ -606,20 +797,18 @@ func (c *coordinator) buildAnthropicProvider(baseURL, apiKey string, headers map
 		opts = append(opts, anthropic.WithBaseURL(baseURL))
 	}
 
-	if c.cfg.Options.Debug {
-		httpClient := log.NewHTTPClient()
+	if httpClient != nil {
 		opts = append(opts, anthropic.WithHTTPClient(httpClient))
 	}
 	return anthropic.New(opts...)
 }
 
-func (c *coordinator) buildOpenaiProvider(baseURL, apiKey string, headers map[string]string) (fantasy.Provider, error) {
+func (c *coordinator) buildOpenaiProvider(baseURL, apiKey string, headers map[string]string, httpClient *http.Client) (fantasy.Provider, error) {
 	opts := []openai.Option{
 		openai.WithAPIKey(apiKey),
 		openai.WithUseResponsesAPI(),
 	}
-	if c.cfg.Options.Debug {
-		httpClient := log.NewHTTPClient()
+	if httpClient != nil {
 		opts = append(opts, openai.WithHTTPClient(httpClient))
 	}
 	if len(headers) > 0 {
@@ -631,12 +820,11 @@ func (c *coordinator) buildOpenaiProvider(baseURL, apiKey string, headers map[st
 	return openai.New(opts...)
 }
 
-func (c *coordinator) buildOpenrouterProvider(_, apiKey string, headers map[string]string) (fantasy.Provider, error) {
+func (c *coordinator) buildOpenrouterProvider(_, apiKey string, headers map[string]string, httpClient *http.Client) (fantasy.Provider, error) {
 	opts := []openrouter.Option{
 		openrouter.WithAPIKey(apiKey),
 	}
-	if c.cfg.Options.Debug {
-		httpClient := log.NewHTTPClient()
+	if httpClient != nil {
 		opts = append(opts, openrouter.WithHTTPClient(httpClient))
 	}
 	if len(headers) > 0 {
@@ -645,12 +833,11 @@ func (c *coordinator) buildOpenrouterProvider(_, apiKey string, headers map[stri
 	return openrouter.New(opts...)
 }
 
-func (c *coordinator) buildVercelProvider(_, apiKey string, headers map[string]string) (fantasy.Provider, error) {
+func (c *coordinator) buildVercelProvider(_, apiKey string, headers map[string]string, httpClient *http.Client) (fantasy.Provider, error) {
 	opts := []vercel.Option{
 		vercel.WithAPIKey(apiKey),
 	}
-	if c.cfg.Options.Debug {
-		httpClient := log.NewHTTPClient()
+	if httpClient != nil {
 		opts = append(opts, vercel.WithHTTPClient(httpClient))
 	}
 	if len(headers) > 0 {
@@ -659,19 +846,14 @@ func (c *coordinator) buildVercelProvider(_, apiKey string, headers map[string]s
 	return vercel.New(opts...)
 }
 
-func (c *coordinator) buildOpenaiCompatProvider(baseURL, apiKey string, headers map[string]string, extraBody map[string]any, providerID string, isSubAgent bool) (fantasy.Provider, error) {
+func (c *coordinator) buildOpenaiCompatProvider(baseURL, apiKey string, headers map[string]string, extraBody map[string]any, useResponsesAPI bool, httpClient *http.Client) (fantasy.Provider, error) {
 	opts := []openaicompat.Option{
 		openaicompat.WithBaseURL(baseURL),
 		openaicompat.WithAPIKey(apiKey),
 	}
 
-	// Set HTTP client based on provider and debug mode.
-	var httpClient *http.Client
-	if providerID == string(catwalk.InferenceProviderCopilot) {
+	if useResponsesAPI {
 		opts = append(opts, openaicompat.WithUseResponsesAPI())
-		httpClient = copilot.NewClient(isSubAgent, c.cfg.Options.Debug)
-	} else if c.cfg.Options.Debug {
-		httpClient = log.NewHTTPClient()
 	}
 	if httpClient != nil {
 		opts = append(opts, openaicompat.WithHTTPClient(httpClient))
@@ -688,14 +870,13 @@ func (c *coordinator) buildOpenaiCompatProvider(baseURL, apiKey string, headers
 	return openaicompat.New(opts...)
 }
 
-func (c *coordinator) buildAzureProvider(baseURL, apiKey string, headers map[string]string, options map[string]string) (fantasy.Provider, error) {
+func (c *coordinator) buildAzureProvider(baseURL, apiKey string, headers map[string]string, options map[string]string, httpClient *http.Client) (fantasy.Provider, error) {
 	opts := []azure.Option{
 		azure.WithBaseURL(baseURL),
 		azure.WithAPIKey(apiKey),
 		azure.WithUseResponsesAPI(),
 	}
-	if c.cfg.Options.Debug {
-		httpClient := log.NewHTTPClient()
+	if httpClient != nil {
 		opts = append(opts, azure.WithHTTPClient(httpClient))
 	}
 	if options == nil {
@@ -711,10 +892,9 @@ func (c *coordinator) buildAzureProvider(baseURL, apiKey string, headers map[str
 	return azure.New(opts...)
 }
 
-func (c *coordinator) buildBedrockProvider(headers map[string]string) (fantasy.Provider, error) {
+func (c *coordinator) buildBedrockProvider(headers map[string]string, httpClient *http.Client) (fantasy.Provider, error) {
 	var opts []bedrock.Option
-	if c.cfg.Options.Debug {
-		httpClient := log.NewHTTPClient()
+	if httpClient != nil {
 		opts = append(opts, bedrock.WithHTTPClient(httpClient))
 	}
 	if len(headers) > 0 {
@@ -727,13 +907,12 @@ func (c *coordinator) buildBedrockProvider(headers map[string]string) (fantasy.P
 	return bedrock.New(opts...)
 }
 
-func (c *coordinator) buildGoogleProvider(baseURL, apiKey string, headers map[string]string) (fantasy.Provider, error) {
+func (c *coordinator) buildGoogleProvider(baseURL, apiKey string, headers map[string]string, httpClient *http.Client) (fantasy.Provider, error) {
 	opts := []google.Option{
 		google.WithBaseURL(baseURL),
 		google.WithGeminiAPIKey(apiKey),
 	}
-	if c.cfg.Options.Debug {
-		httpClient := log.NewHTTPClient()
+	if httpClient != nil {
 		opts = append(opts, google.WithHTTPClient(httpClient))
 	}
 	if len(headers) > 0 {
@@ -742,10 +921,9 @@ func (c *coordinator) buildGoogleProvider(baseURL, apiKey string, headers map[st
 	return google.New(opts...)
 }
 
-func (c *coordinator) buildGoogleVertexProvider(headers map[string]string, options map[string]string) (fantasy.Provider, error) {
+func (c *coordinator) buildGoogleVertexProvider(headers map[string]string, options map[string]string, httpClient *http.Client) (fantasy.Provider, error) {
 	opts := []google.Option{}
-	if c.cfg.Options.Debug {
-		httpClient := log.NewHTTPClient()
+	if httpClient != nil {
 		opts = append(opts, google.WithHTTPClient(httpClient))
 	}
 	if len(headers) > 0 {
@@ -760,13 +938,12 @@ func (c *coordinator) buildGoogleVertexProvider(headers map[string]string, optio
 	return google.New(opts...)
 }
 
-func (c *coordinator) buildHyperProvider(baseURL, apiKey string) (fantasy.Provider, error) {
+func (c *coordinator) buildHyperProvider(baseURL, apiKey string, httpClient *http.Client) (fantasy.Provider, error) {
 	opts := []hyper.Option{
 		hyper.WithBaseURL(baseURL),
 		hyper.WithAPIKey(apiKey),
 	}
-	if c.cfg.Options.Debug {
-		httpClient := log.NewHTTPClient()
+	if httpClient != nil {
 		opts = append(opts, hyper.WithHTTPClient(httpClient))
 	}
 	return hyper.New(opts...)
@@ -808,24 +985,25 @@ func (c *coordinator) buildProvider(providerCfg config.ProviderConfig, model con
 
 	apiKey, _ := c.cfg.Resolve(providerCfg.APIKey)
 	baseURL, _ := c.cfg.Resolve(providerCfg.BaseURL)
+	httpClient := c.providerHTTPClient(providerCfg, isSubAgent)
 
 	switch providerCfg.Type {
 	case openai.Name:
-		return c.buildOpenaiProvider(baseURL, apiKey, headers)
+		return c.buildOpenaiProvider(baseURL, apiKey, headers, httpClient)
 	case anthropic.Name:
-		return c.buildAnthropicProvider(baseURL, apiKey, headers)
+		return c.buildAnthropicProvider(baseURL, apiKey, headers, httpClient)
 	case openrouter.Name:
-		return c.buildOpenrouterProvider(baseURL, apiKey, headers)
+		return c.buildOpenrouterProvider(baseURL, apiKey, headers, httpClient)
 	case vercel.Name:
-		return c.buildVercelProvider(baseURL, apiKey, headers)
+		return c.buildVercelProvider(baseURL, apiKey, headers, httpClient)
 	case azure.Name:
-		return c.buildAzureProvider(baseURL, apiKey, headers, providerCfg.ExtraParams)
+		return c.buildAzureProvider(baseURL, apiKey, headers, providerCfg.ExtraParams, httpClient)
 	case bedrock.Name:
-		return c.buildBedrockProvider(headers)
+		return c.buildBedrockProvider(headers, httpClient)
 	case google.Name:
-		return c.buildGoogleProvider(baseURL, apiKey, headers)
+		return c.buildGoogleProvider(baseURL, apiKey, headers, httpClient)
 	case "google-vertex":
-		return c.buildGoogleVertexProvider(headers, providerCfg.ExtraParams)
+		return c.buildGoogleVertexProvider(headers, providerCfg.ExtraParams, httpClient)
 	case openaicompat.Name:
 		if providerCfg.ID == string(catwalk.InferenceProviderZAI) {
 			if providerCfg.ExtraBody == nil {
@@ -833,9 +1011,10 @@ func (c *coordinator) buildProvider(providerCfg config.ProviderConfig, model con
 			}
 			providerCfg.ExtraBody["tool_stream"] = true
 		}
-		return c.buildOpenaiCompatProvider(baseURL, apiKey, headers, providerCfg.ExtraBody, providerCfg.ID, isSubAgent)
+		useResponsesAPI := providerCfg.UseResponsesAPI || providerCfg.ID == string(catwalk.InferenceProviderCopilot)
+		return c.buildOpenaiCompatProvider(baseURL, apiKey, headers, providerCfg.ExtraBody, useResponsesAPI, httpClient)
 	case hyper.Name:
-		return c.buildHyperProvider(baseURL, apiKey)
+		return c.buildHyperProvider(baseURL, apiKey, httpClient)
 	default:
 		return nil, fmt.Errorf("provider type not supported: %q", providerCfg.Type)
 	}
@@ -905,6 +1084,18 @@ func (c *coordinator) QueuedPromptsList(sessionID string) []string {
 	return c.currentAgent.QueuedPromptsList(sessionID)
 }
 
+func (c *coordinator) EditQueuedPrompt(sessionID string, index int, prompt string) error {
+	return c.currentAgent.EditQueuedPrompt(sessionID, index, prompt)
+}
+
+func (c *coordinator) MoveQueuedPrompt(sessionID string, from, to int) error {
+	return c.currentAgent.MoveQueuedPrompt(sessionID, from, to)
+}
+
+func (c *coordinator) RemoveQueuedPrompt(sessionID string, index int) error {
+	return c.currentAgent.RemoveQueuedPrompt(sessionID, index)
+}
+
 func (c *coordinator) Summarize(ctx context.Context, sessionID string) error {
 	providerCfg, ok := c.cfg.Providers.Get(c.currentAgent.Model().ModelCfg.Provider)
 	if !ok {
@@ -0,0 +1,34 @@
+package agent
+
+import (
+	"testing"
+
+	"charm.land/catwalk/pkg/catwalk"
+	"github.com/purpose168/crush-cn/internal/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSemanticSearchEmbeddingResolvesProvider(t *testing.T) {
+	t.Setenv("CRUSH_TEST_EMBED_KEY", "secret")
+	t.Setenv("CRUSH_TEST_EMBED_URL", "http://localhost:11434/v1")
+
+	cfg, err := config.NewBuilder(t.TempDir(),
+		config.WithDataDirectory(t.TempDir()),
+		config.WithProvider("local", config.ProviderConfig{
+			Type:    catwalk.TypeOpenAICompat,
+			BaseURL: "$CRUSH_TEST_EMBED_URL",
+			APIKey:  "$CRUSH_TEST_EMBED_KEY",
+			Models:  []catwalk.Model{{ID: "qwen", Name: "Qwen", DefaultMaxTokens: 4096}},
+		}),
+		config.WithModel(config.SelectedModelTypeLarge, config.SelectedModel{Provider: "local", Model: "qwen"}),
+		config.WithModel(config.SelectedModelTypeSmall, config.SelectedModel{Provider: "local", Model: "qwen"}),
+		config.WithTools(config.Tools{SemanticSearch: config.ToolSemanticSearch{Provider: "local", Model: "nomic-embed-text"}}),
+	).Build()
+	require.NoError(t, err)
+
+	c := &coordinator{cfg: cfg}
+	embedding := c.semanticSearchEmbedding()
+	require.Equal(t, "secret", embedding.APIKey)
+	require.Equal(t, "http://localhost:11434/v1", embedding.BaseURL)
+	require.True(t, embedding.Enabled())
+}
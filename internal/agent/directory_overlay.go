@@ -0,0 +1,64 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"charm.land/fantasy"
+	"github.com/purpose168/crush-cn/internal/config"
+	"github.com/purpose168/crush-cn/internal/fsext"
+)
+
+// overlayPathParams 是工具参数中表示操作路径的参数名
+var overlayPathParams = []string{"file_path", "path", "working_dir"}
+
+// overlayTool 包装工具，在调用前检查操作路径所在子目录的覆盖配置，
+// 被覆盖配置禁用时返回错误结果而不执行工具
+type overlayTool struct {
+	fantasy.AgentTool
+	cfg *config.Config
+}
+
+// applyDirectoryOverlays 使工具遵循子目录覆盖配置中的 disabled_tools。
+// 远程开发时覆盖配置不可用，工具保持不变。
+func (c *coordinator) applyDirectoryOverlays(agentTools []fantasy.AgentTool) []fantasy.AgentTool {
+	if c.remote() {
+		return agentTools
+	}
+	result := make([]fantasy.AgentTool, 0, len(agentTools))
+	for _, tool := range agentTools {
+		result = append(result, &overlayTool{AgentTool: tool, cfg: c.cfg})
+	}
+	return result
+}
+
+// Run 实现 [fantasy.AgentTool]
+func (t *overlayTool) Run(ctx context.Context, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+	for _, path := range toolCallPaths(call.Input) {
+		dirCfg := t.cfg.DirectoryConfig(path)
+		if dirCfg.ToolDisabled(call.Name) {
+			return fantasy.NewTextErrorResponse(fmt.Sprintf(
+				"工具 %s 已被 %s 禁用，不能用于 %s，请改用其他工具或操作其他目录。",
+				call.Name, fsext.PrettyPath(dirCfg.DisabledToolsFile), path,
+			)), nil
+		}
+	}
+	return t.AgentTool.Run(ctx, call)
+}
+
+// toolCallPaths 返回工具调用参数中的操作路径
+func toolCallPaths(input string) []string {
+	var args map[string]any
+	if err := json.Unmarshal([]byte(input), &args); err != nil {
+		return nil
+	}
+	var paths []string
+	for _, name := range overlayPathParams {
+		if path, ok := args[name].(string); ok && strings.TrimSpace(path) != "" {
+			paths = append(paths, path)
+		}
+	}
+	return paths
+}
@@ -0,0 +1,16 @@
+package agent
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestToolCallPaths(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, []string{"frontend/app.ts"}, toolCallPaths(`{"file_path":"frontend/app.ts","content":"x"}`))
+	require.Equal(t, []string{"src", "web"}, toolCallPaths(`{"path":"src","working_dir":"web","pattern":"*.go"}`))
+	require.Empty(t, toolCallPaths(`{"command":"ls","working_dir":" "}`))
+	require.Empty(t, toolCallPaths(`not json`))
+}
@@ -0,0 +1,116 @@
+package agent
+
+import (
+	"context"
+	_ "embed"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"charm.land/fantasy"
+	"github.com/purpose168/crush-cn/internal/message"
+)
+
+const (
+	// enhanceRecentMessages 是作为上下文提供给小模型的最近消息数。
+	enhanceRecentMessages = 6
+	// enhanceMessageLength 是每条上下文消息保留的最大字节数。
+	enhanceMessageLength = 1000
+	// enhanceMaxOutputTokens 是改写提示允许输出的最大令牌数。
+	enhanceMaxOutputTokens = 1024
+)
+
+//go:embed templates/enhance.md
+var enhancePrompt []byte
+
+// EnhancePrompt 使用小模型结合会话上下文改写提示，使其更具体。小模型失败时改用大模型。
+func (c *coordinator) EnhancePrompt(ctx context.Context, sessionID, prompt string) (string, error) {
+	large, small, err := c.buildAgentModels(ctx, false)
+	if err != nil {
+		return "", err
+	}
+	providerCfg, _ := c.cfg.Providers.Get(small.ModelCfg.Provider)
+	input := fmt.Sprintf("<context>\n%s</context>\n\n<prompt>\n%s\n</prompt>", c.enhanceContext(ctx, sessionID), prompt)
+
+	rewritten, err := enhanceWithModel(ctx, small, providerCfg.SystemPromptPrefix, input)
+	if err != nil {
+		if errors.Is(err, context.Canceled) {
+			return "", err
+		}
+		slog.Error("Error enhancing prompt with small model; trying big model", "err", err)
+		providerCfg, _ = c.cfg.Providers.Get(large.ModelCfg.Provider)
+		rewritten, err = enhanceWithModel(ctx, large, providerCfg.SystemPromptPrefix, input)
+		if err != nil {
+			return "", err
+		}
+	}
+	rewritten = strings.TrimSpace(rewritten)
+	if rewritten == "" {
+		return "", errors.New("model returned an empty prompt")
+	}
+	return rewritten, nil
+}
+
+// enhanceContext 返回会话标题、固定文件和最近的消息，供改写提示时参考。
+func (c *coordinator) enhanceContext(ctx context.Context, sessionID string) string {
+	if sessionID == "" {
+		return "New session, no previous messages.\n"
+	}
+	var sb strings.Builder
+	sess, err := c.sessions.Get(ctx, sessionID)
+	if err == nil {
+		fmt.Fprintf(&sb, "Session title: %s\n", sess.Title)
+		if len(sess.PinnedFiles) > 0 {
+			fmt.Fprintf(&sb, "Pinned files: %s\n", strings.Join(sess.PinnedFiles, ", "))
+		}
+	}
+	msgs, err := c.messages.List(ctx, sessionID)
+	if err != nil {
+		slog.Warn("Failed to list messages for prompt enhancement", "error", err)
+		return sb.String()
+	}
+	msgs = msgs[max(0, len(msgs)-enhanceRecentMessages):]
+	for _, msg := range msgs {
+		if msg.Role != message.User && msg.Role != message.Assistant {
+			continue
+		}
+		text := strings.TrimSpace(msg.Content().Text)
+		if text == "" {
+			continue
+		}
+		if len(text) > enhanceMessageLength {
+			text = strings.ToValidUTF8(text[:enhanceMessageLength], "") + "..."
+		}
+		fmt.Fprintf(&sb, "\n[%s]\n%s\n", msg.Role, text)
+	}
+	return sb.String()
+}
+
+// enhanceWithModel 使用给定模型改写提示。
+func enhanceWithModel(ctx context.Context, model Model, systemPromptPrefix, input string) (string, error) {
+	var maxOutputTokens int64 = enhanceMaxOutputTokens
+	if model.CatwalkCfg.CanReason {
+		maxOutputTokens = max(maxOutputTokens, model.CatwalkCfg.DefaultMaxTokens)
+	}
+	agent := fantasy.NewAgent(model.Model,
+		fantasy.WithSystemPrompt(string(enhancePrompt)+"\n /no_think"),
+		fantasy.WithMaxOutputTokens(maxOutputTokens),
+	)
+	resp, err := agent.Generate(ctx, fantasy.AgentCall{
+		Prompt: fmt.Sprintf("Rewrite the prompt below:\n\n%s", input),
+		PrepareStep: func(callCtx context.Context, opts fantasy.PrepareStepFunctionOptions) (_ context.Context, prepared fantasy.PrepareStepResult, err error) {
+			prepared.Messages = opts.Messages
+			if systemPromptPrefix != "" {
+				prepared.Messages = append([]fantasy.Message{
+					fantasy.NewSystemMessage(systemPromptPrefix),
+				}, prepared.Messages...)
+			}
+			return callCtx, prepared, nil
+		},
+	})
+	if err != nil {
+		return "", err
+	}
+	return thinkTagRegex.ReplaceAllString(resp.Response.Content.Text(), ""), nil
+}
@@ -11,4 +11,10 @@ var (
 	ErrEmptyPrompt = errors.New("提示词为空")
 	// ErrSessionMissing 会话ID缺失
 	ErrSessionMissing = errors.New("会话ID缺失")
+	// ErrQueueIndexOutOfRange 队列索引超出范围
+	ErrQueueIndexOutOfRange = errors.New("队列索引超出范围")
+	// ErrNothingToRegenerate 会话中没有可以重新生成的回复
+	ErrNothingToRegenerate = errors.New("没有可以重新生成的回复")
+	// ErrMalformedToolCalls 模型连续生成了无法解析的工具参数
+	ErrMalformedToolCalls = errors.New("模型连续生成了无法解析的工具参数")
 )
@@ -5,6 +5,7 @@ import (
 
 	"charm.land/fantasy"
 	"github.com/purpose168/crush-cn/internal/event"
+	"github.com/purpose168/crush-cn/internal/eventlog"
 )
 
 // eventPromptSent 发送提示时的事件
@@ -53,3 +54,47 @@ func (a *sessionAgent) eventCommon(sessionID string, model Model) []any {
 		"yolo mode", a.isYolo,
 	}
 }
+
+// logEvent 将事件写入会话的事件日志，未指定模型时使用当前的大型模型
+func (a *sessionAgent) logEvent(e eventlog.Event) {
+	if a.eventLog == nil {
+		return
+	}
+	if e.Model == "" {
+		m := a.largeModel.Get().ModelCfg
+		e.Provider, e.Model = m.Provider, m.Model
+	}
+	a.eventLog.Log(e)
+}
+
+// logModelResponse 记录模型响应的用量和费用
+func (a *sessionAgent) logModelResponse(sessionID string, model Model, finishReason string, usage fantasy.Usage, cost float64) {
+	a.logEvent(eventlog.Event{
+		SessionID:    sessionID,
+		Type:         eventlog.TypeModelResponse,
+		Provider:     model.ModelCfg.Provider,
+		Model:        model.ModelCfg.Model,
+		FinishReason: finishReason,
+		Usage: &eventlog.Usage{
+			InputTokens:         usage.InputTokens,
+			OutputTokens:        usage.OutputTokens,
+			CacheCreationTokens: usage.CacheCreationTokens,
+			CacheReadTokens:     usage.CacheReadTokens,
+			ReasoningTokens:     usage.ReasoningTokens,
+		},
+		Cost: &cost,
+	})
+}
+
+// logRunFinished 记录代理运行结束，失败时包含错误
+func (a *sessionAgent) logRunFinished(sessionID string, duration time.Duration, err error) {
+	e := eventlog.Event{
+		SessionID:  sessionID,
+		Type:       eventlog.TypeRunFinished,
+		DurationMS: duration.Milliseconds(),
+	}
+	if err != nil {
+		e.Error = err.Error()
+	}
+	a.logEvent(e)
+}
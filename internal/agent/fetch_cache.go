@@ -0,0 +1,43 @@
+package agent
+
+import (
+	"net/http"
+	"path/filepath"
+	"time"
+
+	"github.com/purpose168/crush-cn/internal/config"
+	"github.com/purpose168/crush-cn/internal/httpcache"
+	"github.com/purpose168/crush-cn/internal/network"
+)
+
+// newFetchCache 根据配置创建抓取类工具的磁盘缓存，禁用时返回 nil
+func newFetchCache(cfg *config.Config) *httpcache.Cache {
+	if cfg.Tools.Fetch.DisableCache {
+		return nil
+	}
+	return httpcache.New(filepath.Join(cfg.Options.DataDirectory, httpcache.DirName), cfg.Tools.Fetch.CacheDuration())
+}
+
+// newFetchClient 创建抓取类工具默认使用的 HTTP 客户端
+func newFetchClient() *http.Client {
+	return network.NewClient(30 * time.Second)
+}
+
+// cachedClient 返回在 client 的传输层之上启用磁盘缓存的客户端。client 为 nil 时使用
+// 默认客户端；缓存禁用时原样返回 client
+func (c *coordinator) cachedClient(client *http.Client) *http.Client {
+	if c.fetchCache == nil {
+		return client
+	}
+	if client == nil {
+		client = newFetchClient()
+	}
+	cached := *client
+	cached.Transport = &httpcache.Transport{Cache: c.fetchCache, Base: client.Transport}
+	return &cached
+}
+
+// agenticFetchCacheKey 返回 agentic_fetch 对 URL 按提示词提取的结果在缓存中的键
+func agenticFetchCacheKey(url, prompt string) string {
+	return "agentic_fetch\n" + httpcache.ResponseKey(url) + "\n" + prompt
+}
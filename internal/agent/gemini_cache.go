@@ -0,0 +1,37 @@
+package agent
+
+import (
+	"log/slog"
+	"net/http"
+	"sync"
+
+	"github.com/purpose168/crush-cn/internal/config"
+	"github.com/purpose168/crush-cn/internal/gemini"
+)
+
+// geminiCaches 保存每个 Gemini 提供者上传的文件和上下文缓存。重建模型时复用，
+// 使编码代理和子代理在整个运行期间共享同一批文件和缓存
+type geminiCaches struct {
+	mu     sync.Mutex
+	caches map[string]*gemini.Cache
+}
+
+// transport 返回使用提供者共享缓存的 Gemini 传输层
+func (g *geminiCaches) transport(providerCfg config.ProviderConfig, base http.RoundTripper) *gemini.Transport {
+	ttl, err := providerCfg.PromptCache.CacheTTL()
+	if err != nil {
+		slog.Warn("Invalid Gemini cache TTL, using the default", "provider", providerCfg.ID, "error", err)
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	cache, ok := g.caches[providerCfg.ID]
+	if !ok {
+		cache = gemini.NewCache()
+		if g.caches == nil {
+			g.caches = make(map[string]*gemini.Cache)
+		}
+		g.caches[providerCfg.ID] = cache
+	}
+	return &gemini.Transport{Base: base, TTL: ttl, Cache: cache}
+}
@@ -0,0 +1,66 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	"charm.land/fantasy"
+	"github.com/purpose168/crush-cn/internal/message"
+)
+
+// maxPinnedFileSize 是单个固定文件随每次请求重新发送的最大字节数
+const maxPinnedFileSize = 100 * 1024
+
+// loadPinnedFiles 读取会话中固定的文件的最新内容，作为文本附件返回。
+// 不存在、过大或非文本的文件会被跳过。
+func (c *coordinator) loadPinnedFiles(ctx context.Context, sessionID string) []message.Attachment {
+	sess, err := c.sessions.Get(ctx, sessionID)
+	if err != nil || len(sess.PinnedFiles) == 0 {
+		return nil
+	}
+	files := make([]message.Attachment, 0, len(sess.PinnedFiles))
+	for _, path := range sess.PinnedFiles {
+		info, err := os.Stat(path)
+		if err != nil {
+			slog.Warn("Skipping pinned file", "path", path, "error", err)
+			continue
+		}
+		if info.IsDir() || info.Size() > maxPinnedFileSize {
+			slog.Warn("Skipping pinned file", "path", path, "size", info.Size())
+			continue
+		}
+		content, err := os.ReadFile(path)
+		if err != nil || !utf8.Valid(content) {
+			slog.Warn("Skipping pinned file", "path", path, "error", err)
+			continue
+		}
+		files = append(files, message.Attachment{
+			FilePath: path,
+			FileName: filepath.Base(path),
+			MimeType: "text/plain",
+			Content:  content,
+		})
+	}
+	return c.redactAttachments(sessionID, files)
+}
+
+// pinnedFilesMessage 构建包含固定文件最新内容的用户消息。它在每次请求时追加到
+// 历史记录末尾，因此即使会话被总结，这些文件也始终保留在上下文中。
+func pinnedFilesMessage(files []message.Attachment) fantasy.Message {
+	var sb strings.Builder
+	sb.WriteString("<system_reminder>The user pinned the following files to this conversation. ")
+	sb.WriteString("Their current contents are provided on every request; prefer them over older copies in the conversation. ")
+	sb.WriteString("Do not mention this reminder to the user.</system_reminder>\n<pinned_files>\n")
+	for _, f := range files {
+		fmt.Fprintf(&sb, "<file path='%s'>\n", f.FilePath)
+		sb.Write(f.Content)
+		sb.WriteString("\n</file>\n")
+	}
+	sb.WriteString("</pinned_files>")
+	return fantasy.NewUserMessage(sb.String())
+}
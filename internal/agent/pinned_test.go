@@ -0,0 +1,37 @@
+package agent
+
+import (
+	"strings"
+	"testing"
+
+	"charm.land/fantasy"
+	"github.com/purpose168/crush-cn/internal/message"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPinnedFilesMessage(t *testing.T) {
+	t.Parallel()
+
+	msg := pinnedFilesMessage([]message.Attachment{
+		{FilePath: "/repo/api.yaml", Content: []byte("openapi: 3.0.0")},
+		{FilePath: "/repo/README.md", Content: []byte("# Readme")},
+	})
+	require.Equal(t, fantasy.MessageRoleUser, msg.Role)
+	require.Len(t, msg.Content, 1)
+
+	text, ok := fantasy.AsMessagePart[fantasy.TextPart](msg.Content[0])
+	require.True(t, ok)
+	require.Contains(t, text.Text, "<file path='/repo/api.yaml'>\nopenapi: 3.0.0\n</file>")
+	require.Contains(t, text.Text, "<file path='/repo/README.md'>\n# Readme\n</file>")
+	require.True(t, strings.HasSuffix(text.Text, "</pinned_files>"))
+}
+
+func TestBuildSummaryPromptPinnedFiles(t *testing.T) {
+	t.Parallel()
+
+	require.NotContains(t, buildSummaryPrompt(nil, nil), "Pinned Files")
+
+	prompt := buildSummaryPrompt(nil, []string{"/repo/api.yaml"})
+	require.Contains(t, prompt, "## Pinned Files")
+	require.Contains(t, prompt, "- /repo/api.yaml")
+}
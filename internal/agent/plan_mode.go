@@ -0,0 +1,116 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+
+	"charm.land/fantasy"
+	"github.com/purpose168/crush-cn/internal/agent/tools"
+	"github.com/purpose168/crush-cn/internal/session"
+)
+
+// planModeTools 是计划等待批准时仍然可以使用的工具，它们不会修改工作区
+var planModeTools = []string{
+	AgentToolName,
+	tools.AgenticFetchToolName,
+	tools.DiagnosticsToolName,
+	tools.FetchToolName,
+	tools.GitDiffToolName,
+	tools.GitStatusToolName,
+	tools.GlobToolName,
+	tools.GrepToolName,
+	tools.IssueFetchToolName,
+	tools.JobOutputToolName,
+	tools.ListMCPResourcesToolName,
+	tools.LSPSymbolsToolName,
+	tools.LSToolName,
+	tools.ReadMCPResourceToolName,
+	tools.ReferencesToolName,
+	tools.RepoMapToolName,
+	tools.SemanticSearchToolName,
+	tools.SourcegraphToolName,
+	tools.TodosToolName,
+	tools.ViewToolName,
+	tools.WebFetchToolName,
+	tools.WebSearchToolName,
+}
+
+// planModeTool 包装工具，会话处于计划模式且计划尚未批准时拒绝执行修改类工具
+type planModeTool struct {
+	fantasy.AgentTool
+	sessions session.Service
+}
+
+// applyPlanMode 使工具遵循会话的计划模式。
+func (c *coordinator) applyPlanMode(agentTools []fantasy.AgentTool) []fantasy.AgentTool {
+	result := make([]fantasy.AgentTool, 0, len(agentTools))
+	for _, tool := range agentTools {
+		result = append(result, &planModeTool{AgentTool: tool, sessions: c.sessions})
+	}
+	return result
+}
+
+// Run 实现 [fantasy.AgentTool]
+func (t *planModeTool) Run(ctx context.Context, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+	sessionID := tools.GetSessionFromContext(ctx)
+	allowed := slices.Contains(planModeTools, call.Name)
+	if sessionID == "" || (allowed && call.Name != tools.TodosToolName) {
+		return t.AgentTool.Run(ctx, call)
+	}
+	sess, err := t.sessions.Get(ctx, sessionID)
+	if err != nil || !sess.PlanMode.Enabled() {
+		return t.AgentTool.Run(ctx, call)
+	}
+	if !allowed && sess.PlanMode == session.PlanModePlanning {
+		return fantasy.NewTextErrorResponse(fmt.Sprintf(
+			"计划模式：用户批准计划之前不能使用 %s。请使用只读工具调查后用 %s 工具写出编号的计划，然后停止并等待用户批准。",
+			call.Name, tools.TodosToolName,
+		)), nil
+	}
+
+	resp, err := t.AgentTool.Run(ctx, call)
+	if err == nil && !resp.IsError && call.Name == tools.TodosToolName && sess.PlanMode == session.PlanModeApproved {
+		t.finishPlan(ctx, sessionID)
+	}
+	return resp, err
+}
+
+// finishPlan 在已批准的计划全部完成后回到制定计划的状态，之后的任务需要新的计划
+func (t *planModeTool) finishPlan(ctx context.Context, sessionID string) {
+	sess, err := t.sessions.Get(ctx, sessionID)
+	if err != nil {
+		return
+	}
+	for _, todo := range sess.Todos {
+		if todo.Status != session.TodoStatusCompleted {
+			return
+		}
+	}
+	if _, err := t.sessions.SetPlanMode(ctx, sessionID, session.PlanModePlanning); err != nil {
+		slog.Error("Failed to reset plan mode", "session_id", sessionID, "error", err)
+	}
+}
+
+// planModeMessage 返回提醒模型当前计划模式状态的消息，未启用计划模式时返回 false
+func planModeMessage(sess session.Session) (fantasy.Message, bool) {
+	var reminder string
+	switch {
+	case sess.PlanMode == session.PlanModeApproved:
+		reminder = "Plan mode is on and the user approved the plan in the todo list; they may have edited it. " +
+			"Execute it step by step and keep the todo statuses up to date. " +
+			"If the plan turns out to need substantial changes stop and ask the user."
+	case sess.PlanAwaitingApproval():
+		reminder = "Plan mode is on and your plan in the todo list is waiting for the user's approval. " +
+			"Tools that modify files or run commands stay blocked until the user approves it. " +
+			"If the user asks for changes revise the plan with the todos tool and stop again."
+	case sess.PlanMode == session.PlanModePlanning:
+		reminder = "Plan mode is on. Before changing anything investigate with read-only tools, " +
+			"then write a numbered step-by-step plan with the todos tool (all steps pending), summarize it briefly and stop. " +
+			"Tools that modify files or run commands are blocked until the user approves the plan."
+	default:
+		return fantasy.Message{}, false
+	}
+	return fantasy.NewUserMessage("<system_reminder>" + reminder + " Do not mention this reminder to the user.</system_reminder>"), true
+}
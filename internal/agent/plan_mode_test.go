@@ -0,0 +1,78 @@
+package agent
+
+import (
+	"context"
+	"testing"
+
+	"charm.land/fantasy"
+	"github.com/purpose168/crush-cn/internal/agent/tools"
+	"github.com/purpose168/crush-cn/internal/db"
+	"github.com/purpose168/crush-cn/internal/session"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPlanModeTool(t *testing.T) {
+	t.Parallel()
+
+	conn, err := db.Connect(t.Context(), t.TempDir())
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	sessions := session.NewService(db.New(conn), conn, "")
+
+	sess, err := sessions.Create(t.Context(), "plan")
+	require.NoError(t, err)
+	_, err = sessions.SetPlanMode(t.Context(), sess.ID, session.PlanModePlanning)
+	require.NoError(t, err)
+
+	bash := fantasy.NewAgentTool(tools.BashToolName, "bash", func(ctx context.Context, params echoParams, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+		return fantasy.NewTextResponse("ran"), nil
+	})
+	todos := fantasy.NewAgentTool(tools.TodosToolName, "todos", func(ctx context.Context, params echoParams, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+		_, err := sessions.SetTodos(ctx, sess.ID, []session.Todo{{Content: "Update the parser", Status: session.TodoStatus(params.Text)}})
+		return fantasy.NewTextResponse("ok"), err
+	})
+	c := &coordinator{sessions: sessions}
+	wrapped := c.applyPlanMode([]fantasy.AgentTool{bash, todos})
+
+	ctx := context.WithValue(t.Context(), tools.SessionIDContextKey, sess.ID)
+	resp, err := wrapped[0].Run(ctx, fantasy.ToolCall{ID: "call-1", Name: tools.BashToolName, Input: `{"text":""}`})
+	require.NoError(t, err)
+	require.True(t, resp.IsError)
+
+	_, err = wrapped[1].Run(ctx, fantasy.ToolCall{ID: "call-2", Name: tools.TodosToolName, Input: `{"text":"pending"}`})
+	require.NoError(t, err)
+	sess, err = sessions.Get(t.Context(), sess.ID)
+	require.NoError(t, err)
+	require.True(t, sess.PlanAwaitingApproval())
+
+	// 批准后可以使用修改类工具，全部完成后回到制定计划的状态
+	_, err = sessions.SetPlanMode(t.Context(), sess.ID, session.PlanModeApproved)
+	require.NoError(t, err)
+	resp, err = wrapped[0].Run(ctx, fantasy.ToolCall{ID: "call-3", Name: tools.BashToolName, Input: `{"text":""}`})
+	require.NoError(t, err)
+	require.Equal(t, "ran", resp.Content)
+
+	_, err = wrapped[1].Run(ctx, fantasy.ToolCall{ID: "call-4", Name: tools.TodosToolName, Input: `{"text":"completed"}`})
+	require.NoError(t, err)
+	sess, err = sessions.Get(t.Context(), sess.ID)
+	require.NoError(t, err)
+	require.Equal(t, session.PlanModePlanning, sess.PlanMode)
+}
+
+func TestPlanModeMessage(t *testing.T) {
+	t.Parallel()
+
+	_, ok := planModeMessage(session.Session{})
+	require.False(t, ok)
+
+	msg, ok := planModeMessage(session.Session{PlanMode: session.PlanModePlanning})
+	require.True(t, ok)
+	require.Contains(t, msg.Content[0].(fantasy.TextPart).Text, "write a numbered step-by-step plan")
+
+	msg, ok = planModeMessage(session.Session{
+		PlanMode: session.PlanModePlanning,
+		Todos:    []session.Todo{{Content: "Update the parser"}},
+	})
+	require.True(t, ok)
+	require.Contains(t, msg.Content[0].(fantasy.TextPart).Text, "waiting for the user's approval")
+}
@@ -0,0 +1,232 @@
+package prompt
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/purpose168/crush-cn/internal/config"
+	"github.com/purpose168/crush-cn/internal/home"
+)
+
+const (
+	// maxIncludeDepth 是 @include 指令的最大嵌套层数。
+	maxIncludeDepth = 10
+	// maxIncludeSize 是通过 @include 读入的单个文件的最大字节数。
+	maxIncludeSize = 256 * 1024
+)
+
+// includeDirective 匹配独占一行的 "@include <路径>" 指令。
+var includeDirective = regexp.MustCompile(`^\s*@include\s+(\S+)\s*$`)
+
+// fileStamp 记录文件在读取时的状态，用于判断文件之后是否发生了变化。
+type fileStamp struct {
+	exists  bool
+	size    int64
+	modTime time.Time
+}
+
+func (s fileStamp) equal(o fileStamp) bool {
+	return s.exists == o.exists && s.size == o.size && s.modTime.Equal(o.modTime)
+}
+
+func statFile(path string) fileStamp {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fileStamp{}
+	}
+	return fileStamp{exists: true, size: info.Size(), modTime: info.ModTime()}
+}
+
+// contextLoader 读取上下文文件并展开其中的 @include 指令，同时记录读取过的文件和目录，
+// 包括当时不存在的上下文路径，以便之后判断上下文是否需要重新加载。
+type contextLoader struct {
+	cfg     config.Config
+	sources map[string]fileStamp
+	// included 是通过 @include 读入的文件，按读取顺序排列。
+	included []string
+}
+
+func newContextLoader(cfg config.Config) *contextLoader {
+	return &contextLoader{cfg: cfg, sources: make(map[string]fileStamp)}
+}
+
+// loadPath 读取上下文路径。路径是目录时读取其中的所有文件。工作目录中的上下文文件只能
+// 包含工作目录中的文件，工作目录之外的全局上下文路径只能包含该路径所在目录中的文件。
+func (l *contextLoader) loadPath(p string) []ContextFile {
+	var contexts []ContextFile
+	fullPath := p
+	if !filepath.IsAbs(p) {
+		fullPath = filepath.Join(l.cfg.WorkingDir(), p)
+	}
+	l.sources[fullPath] = statFile(fullPath)
+	info, err := os.Stat(fullPath)
+	if err != nil {
+		return contexts
+	}
+	root := l.cfg.WorkingDir()
+	if root == "" || !withinRoot(root, fullPath) {
+		root = fullPath
+		if !info.IsDir() {
+			root = filepath.Dir(fullPath)
+		}
+	}
+	if info.IsDir() {
+		filepath.WalkDir(fullPath, func(path string, d os.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() {
+				l.sources[path] = statFile(path)
+				return nil
+			}
+			if result := l.loadFile(path, root); result != nil {
+				contexts = append(contexts, *result)
+			}
+			return nil
+		})
+	} else {
+		result := l.loadFile(fullPath, root)
+		if result != nil {
+			contexts = append(contexts, *result)
+		}
+	}
+	return contexts
+}
+
+// loadFile 读取单个上下文文件并展开其中的 @include 指令，被包含的文件必须位于 root 中。
+func (l *contextLoader) loadFile(filePath, root string) *ContextFile {
+	l.sources[filePath] = statFile(filePath)
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil
+	}
+	return &ContextFile{
+		Path:    filePath,
+		Content: l.expandIncludes(string(content), filePath, root, []string{filepath.Clean(filePath)}),
+	}
+}
+
+// expandIncludes 将 content 中的 @include 指令替换为被包含文件的内容。相对路径相对于
+// 包含它的文件所在目录解析；代码块中的指令保持原样。stack 是当前的包含链，用于检测循环包含。
+func (l *contextLoader) expandIncludes(content, filePath, root string, stack []string) string {
+	if !strings.Contains(content, "@include") {
+		return content
+	}
+
+	lines := strings.SplitAfter(content, "\n")
+	var sb strings.Builder
+	inFence := false
+	for _, line := range lines {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			inFence = !inFence
+		}
+		match := includeDirective.FindStringSubmatch(strings.TrimRight(line, "\r\n"))
+		if inFence || match == nil {
+			sb.WriteString(line)
+			continue
+		}
+
+		target := home.Long(match[1])
+		if !filepath.IsAbs(target) {
+			target = filepath.Join(filepath.Dir(filePath), target)
+		}
+		target = filepath.Clean(target)
+
+		included, err := l.include(target, root, stack)
+		if err != nil {
+			slog.Warn("Skipped context file include", "file", filePath, "include", match[1], "error", err)
+			included = fmt.Sprintf("<!-- @include %s skipped: %s -->", match[1], err)
+		}
+		sb.WriteString(included)
+		if strings.HasSuffix(line, "\n") && !strings.HasSuffix(included, "\n") {
+			sb.WriteString("\n")
+		}
+	}
+	return sb.String()
+}
+
+// include 读取被包含的文件并递归展开其中的指令。被包含的文件必须是 root 中的普通文件，
+// 且不超过 maxIncludeSize，以免仓库中的上下文文件把主目录中的密钥或设备文件读入提示词。
+func (l *contextLoader) include(target, root string, stack []string) (string, error) {
+	if slices.Contains(stack, target) {
+		return "", errors.New("include cycle")
+	}
+	if len(stack) > maxIncludeDepth {
+		return "", fmt.Errorf("include depth exceeds %d", maxIncludeDepth)
+	}
+	if !withinRoot(root, target) {
+		return "", errors.New("outside of the context root")
+	}
+	l.sources[target] = statFile(target)
+	content, err := readInclude(target, root)
+	if err != nil {
+		return "", err
+	}
+	if !slices.Contains(l.included, target) {
+		l.included = append(l.included, target)
+	}
+	return l.expandIncludes(content, target, root, append(slices.Clone(stack), target)), nil
+}
+
+// readInclude 读取被包含的文件。符号链接解析后的路径同样必须位于 root 中。
+func readInclude(target, root string) (string, error) {
+	resolved, err := filepath.EvalSymlinks(target)
+	if err != nil {
+		return "", errors.New("file not readable")
+	}
+	if resolvedRoot, err := filepath.EvalSymlinks(root); err != nil || !withinRoot(resolvedRoot, resolved) {
+		return "", errors.New("outside of the context root")
+	}
+	info, err := os.Stat(resolved)
+	if err != nil {
+		return "", errors.New("file not readable")
+	}
+	if !info.Mode().IsRegular() {
+		return "", errors.New("not a regular file")
+	}
+	if info.Size() > maxIncludeSize {
+		return "", fmt.Errorf("file exceeds %d bytes", maxIncludeSize)
+	}
+	f, err := os.Open(resolved)
+	if err != nil {
+		return "", errors.New("file not readable")
+	}
+	defer f.Close()
+	content, err := io.ReadAll(io.LimitReader(f, maxIncludeSize+1))
+	if err != nil {
+		return "", errors.New("file not readable")
+	}
+	if len(content) > maxIncludeSize {
+		return "", fmt.Errorf("file exceeds %d bytes", maxIncludeSize)
+	}
+	return string(content), nil
+}
+
+// withinRoot 判断 path 是否位于 root 目录中。
+func withinRoot(root, path string) bool {
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		return false
+	}
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) && !filepath.IsAbs(rel)
+}
+
+// changedSources 返回自 sources 记录以来发生变化的路径，按路径排序。
+func changedSources(sources map[string]fileStamp) []string {
+	var changed []string
+	for _, path := range slices.Sorted(maps.Keys(sources)) {
+		if !statFile(path).equal(sources[path]) {
+			changed = append(changed, path)
+		}
+	}
+	return changed
+}
@@ -0,0 +1,105 @@
+package prompt
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/purpose168/crush-cn/internal/config"
+	"github.com/stretchr/testify/require"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+}
+
+func TestContextLoaderIncludes(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "AGENTS.md"), "# Rules\n@include docs/style.md\n```\n@include docs/style.md\n```\n@include missing.md\n")
+	writeFile(t, filepath.Join(dir, "docs", "style.md"), "Use tabs.\n  @include ../AGENTS.md\n@include nested.md")
+	writeFile(t, filepath.Join(dir, "docs", "nested.md"), "Nested rule.")
+
+	loader := newContextLoader(config.Config{})
+	file := loader.loadFile(filepath.Join(dir, "AGENTS.md"), dir)
+	require.NotNil(t, file)
+	require.Equal(t, "# Rules\n"+
+		"Use tabs.\n"+
+		"<!-- @include ../AGENTS.md skipped: include cycle -->\n"+
+		"Nested rule.\n"+
+		"```\n@include docs/style.md\n```\n"+
+		"<!-- @include missing.md skipped: file not readable -->\n", file.Content)
+	require.Equal(t, []string{filepath.Join(dir, "docs", "style.md"), filepath.Join(dir, "docs", "nested.md")}, loader.included)
+}
+
+func TestContextLoaderRejectsUnsafeIncludes(t *testing.T) {
+	t.Parallel()
+	if runtime.GOOS == "windows" {
+		t.Skip("符号链接和 /dev/zero 在 Windows 上不可用")
+	}
+
+	outside := t.TempDir()
+	secret := filepath.Join(outside, "id_rsa")
+	writeFile(t, secret, "PRIVATE KEY")
+
+	dir := t.TempDir()
+	require.NoError(t, os.Symlink(secret, filepath.Join(dir, "link.md")))
+	writeFile(t, filepath.Join(dir, "big.md"), strings.Repeat("a", maxIncludeSize+1))
+	writeFile(t, filepath.Join(dir, "AGENTS.md"), "@include "+secret+"\n"+
+		"@include ../"+filepath.Base(outside)+"/id_rsa\n"+
+		"@include link.md\n"+
+		"@include /dev/zero\n"+
+		"@include docs\n"+
+		"@include big.md\n")
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "docs"), 0o755))
+
+	loader := newContextLoader(config.Config{})
+	file := loader.loadFile(filepath.Join(dir, "AGENTS.md"), dir)
+	require.NotNil(t, file)
+	require.NotContains(t, file.Content, "PRIVATE KEY")
+	require.Equal(t, "<!-- @include "+secret+" skipped: outside of the context root -->\n"+
+		"<!-- @include ../"+filepath.Base(outside)+"/id_rsa skipped: outside of the context root -->\n"+
+		"<!-- @include link.md skipped: outside of the context root -->\n"+
+		"<!-- @include /dev/zero skipped: outside of the context root -->\n"+
+		"<!-- @include docs skipped: not a regular file -->\n"+
+		fmt.Sprintf("<!-- @include big.md skipped: file exceeds %d bytes -->\n", maxIncludeSize), file.Content)
+	require.Empty(t, loader.included)
+}
+
+func TestPromptChangedContextFiles(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	agents := filepath.Join(dir, "AGENTS.md")
+	style := filepath.Join(dir, "style.md")
+	writeFile(t, agents, "@include style.md\n")
+	writeFile(t, style, "Use tabs.\n")
+
+	p, err := NewPrompt("test", "{{range .ContextFiles}}{{.Content}}{{end}}", WithWorkingDir(dir))
+	require.NoError(t, err)
+	cfg := config.Config{Options: &config.Options{ContextPaths: []string{agents, filepath.Join(dir, "CRUSH.md")}}}
+
+	built, err := p.Build(t.Context(), "", "", cfg)
+	require.NoError(t, err)
+	require.Equal(t, "Use tabs.\n", built)
+	require.Equal(t, []string{agents, style}, p.ContextFiles())
+	require.Empty(t, p.ChangedContextFiles())
+
+	writeFile(t, style, "Use spaces.\n")
+	require.NoError(t, os.Chtimes(style, time.Now(), time.Now().Add(time.Second)))
+	writeFile(t, filepath.Join(dir, "CRUSH.md"), "Be brief.\n")
+	require.Equal(t, []string{filepath.Join(dir, "CRUSH.md"), style}, p.ChangedContextFiles())
+
+	built, err = p.Build(t.Context(), "", "", cfg)
+	require.NoError(t, err)
+	require.Contains(t, built, "Use spaces.\n")
+	require.Contains(t, built, "Be brief.\n")
+	require.Empty(t, p.ChangedContextFiles())
+}
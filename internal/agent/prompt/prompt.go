@@ -4,10 +4,13 @@ import (
 	"cmp"
 	"context"
 	"fmt"
+	"log/slog"
 	"os"
 	"path/filepath"
 	"runtime"
+	"slices"
 	"strings"
+	"sync"
 	"text/template"
 	"time"
 
@@ -17,6 +20,10 @@ import (
 	"github.com/purpose168/crush-cn/internal/skills"
 )
 
+// CacheBreakpoint 标记系统提示中可以单独缓存的前缀的结束位置。模板通过 .CacheBreakpoint
+// 把它放在静态指令之后、随环境变化的内容之前；发送请求前智能体在标记处拆分系统提示并移除标记。
+const CacheBreakpoint = "<!-- crush:cache-breakpoint -->"
+
 // Prompt 表示一个基于模板的提示生成器。
 type Prompt struct {
 	name       string
@@ -24,6 +31,12 @@ type Prompt struct {
 	now        func() time.Time
 	platform   string
 	workingDir string
+	analysis   string
+
+	// sources 是上次构建时读取的上下文文件和目录的状态，loaded 是进入提示的上下文文件
+	mu      sync.Mutex
+	sources map[string]fileStamp
+	loaded  []string
 }
 
 // PromptDat 包含提示模板所需的数据。
@@ -38,6 +51,10 @@ type PromptDat struct {
 	GitStatus     string
 	ContextFiles  []ContextFile
 	AvailSkillXML string
+	// ProjectAnalysis 是分析项目配置得到的构建、测试和检查命令，仅用于项目初始化
+	ProjectAnalysis string
+	// CacheBreakpoint 是提示缓存断点标记，见 [CacheBreakpoint]
+	CacheBreakpoint string
 }
 
 // ContextFile 表示一个上下文文件。
@@ -66,6 +83,13 @@ func WithWorkingDir(workingDir string) Option {
 	}
 }
 
+// WithProjectAnalysis 设置项目分析结果，在模板中通过 .ProjectAnalysis 使用
+func WithProjectAnalysis(analysis string) Option {
+	return func(p *Prompt) {
+		p.analysis = analysis
+	}
+}
+
 func NewPrompt(name, promptTemplate string, opts ...Option) (*Prompt, error) {
 	p := &Prompt{
 		name:     name,
@@ -95,48 +119,6 @@ func (p *Prompt) Build(ctx context.Context, provider, model string, cfg config.C
 	return sb.String(), nil
 }
 
-func processFile(filePath string) *ContextFile {
-	content, err := os.ReadFile(filePath)
-	if err != nil {
-		return nil
-	}
-	return &ContextFile{
-		Path:    filePath,
-		Content: string(content),
-	}
-}
-
-func processContextPath(p string, cfg config.Config) []ContextFile {
-	var contexts []ContextFile
-	fullPath := p
-	if !filepath.IsAbs(p) {
-		fullPath = filepath.Join(cfg.WorkingDir(), p)
-	}
-	info, err := os.Stat(fullPath)
-	if err != nil {
-		return contexts
-	}
-	if info.IsDir() {
-		filepath.WalkDir(fullPath, func(path string, d os.DirEntry, err error) error {
-			if err != nil {
-				return err
-			}
-			if !d.IsDir() {
-				if result := processFile(path); result != nil {
-					contexts = append(contexts, *result)
-				}
-			}
-			return nil
-		})
-	} else {
-		result := processFile(fullPath)
-		if result != nil {
-			contexts = append(contexts, *result)
-		}
-	}
-	return contexts
-}
-
 // expandPath expands ~ and environment variables in file paths
 func expandPath(path string, cfg config.Config) string {
 	path = home.Long(path)
@@ -156,13 +138,14 @@ func (p *Prompt) promptData(ctx context.Context, provider, model string, cfg con
 
 	files := map[string][]ContextFile{}
 
+	loader := newContextLoader(cfg)
 	for _, pth := range cfg.Options.ContextPaths {
 		expanded := expandPath(pth, cfg)
 		pathKey := strings.ToLower(expanded)
 		if _, ok := files[pathKey]; ok {
 			continue
 		}
-		content := processContextPath(expanded, cfg)
+		content := loader.loadPath(expanded)
 		files[pathKey] = content
 	}
 
@@ -180,14 +163,16 @@ func (p *Prompt) promptData(ctx context.Context, provider, model string, cfg con
 
 	isGit := isGitRepo(cfg.WorkingDir())
 	data := PromptDat{
-		Provider:      provider,
-		Model:         model,
-		Config:        cfg,
-		WorkingDir:    filepath.ToSlash(workingDir),
-		IsGitRepo:     isGit,
-		Platform:      platform,
-		Date:          p.now().Format("1/2/2006"),
-		AvailSkillXML: availSkillXML,
+		Provider:        provider,
+		Model:           model,
+		Config:          cfg,
+		WorkingDir:      filepath.ToSlash(workingDir),
+		IsGitRepo:       isGit,
+		Platform:        platform,
+		Date:            p.now().Format("1/2/2006"),
+		AvailSkillXML:   availSkillXML,
+		ProjectAnalysis: p.analysis,
+		CacheBreakpoint: CacheBreakpoint,
 	}
 	if isGit {
 		var err error
@@ -197,9 +182,16 @@ func (p *Prompt) promptData(ctx context.Context, provider, model string, cfg con
 		}
 	}
 
+	var loaded []string
 	for _, contextFiles := range files {
 		data.ContextFiles = append(data.ContextFiles, contextFiles...)
+		for _, file := range contextFiles {
+			loaded = append(loaded, file.Path)
+		}
 	}
+	slices.Sort(loaded)
+	p.setSources(loader.sources, append(loaded, loader.included...))
+	slog.Debug("Loaded context files into prompt", "prompt", p.name, "files", loaded, "included", loader.included)
 	return data, nil
 }
 
@@ -260,6 +252,30 @@ func getGitRecentCommits(ctx context.Context, sh *shell.Shell) (string, error) {
 	return fmt.Sprintf("Recent commits:\n%s\n", out), nil
 }
 
+// setSources 记录本次构建读取的上下文文件和目录，以及进入提示的文件。
+func (p *Prompt) setSources(sources map[string]fileStamp, loaded []string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.sources = sources
+	p.loaded = loaded
+}
+
+// ContextFiles 返回上次构建时进入提示的上下文文件，包括通过 @include 包含的文件。
+func (p *Prompt) ContextFiles() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return slices.Clone(p.loaded)
+}
+
+// ChangedContextFiles 返回上次构建后发生变化、新建或删除的上下文文件，包括通过
+// @include 包含的文件。返回非空时应重新构建提示。
+func (p *Prompt) ChangedContextFiles() []string {
+	p.mu.Lock()
+	sources := p.sources
+	p.mu.Unlock()
+	return changedSources(sources)
+}
+
 func (p *Prompt) Name() string {
 	return p.name
 }
@@ -0,0 +1,72 @@
+package agent
+
+import (
+	"strings"
+
+	"charm.land/fantasy"
+	"charm.land/fantasy/providers/openai"
+	"github.com/purpose168/crush-cn/internal/agent/prompt"
+	"github.com/purpose168/crush-cn/internal/config"
+)
+
+// splitSystemPrompt 在缓存断点标记处把第一条系统消息拆分为多条系统消息，除最后一段外每段都
+// 设置 cacheOptions，最后一段由调用方与其他系统消息一起标记。提供者不支持缓存断点时
+// （cacheOptions 为空）只移除标记。
+//
+// Anthropic 按工具定义、系统提示、消息的顺序计算缓存前缀，最多允许 4 个断点：静态指令的断点
+// 同时覆盖了工具定义，所以拆分后工具定义不再单独设置断点。
+func splitSystemPrompt(messages []fantasy.Message, cacheOptions fantasy.ProviderOptions) []fantasy.Message {
+	if len(messages) == 0 || messages[0].Role != fantasy.MessageRoleSystem {
+		return messages
+	}
+	text, ok := systemMessageText(messages[0])
+	if !ok || !strings.Contains(text, prompt.CacheBreakpoint) {
+		return messages
+	}
+
+	var segments []string
+	for segment := range strings.SplitSeq(text, prompt.CacheBreakpoint) {
+		if segment = strings.TrimSpace(segment); segment != "" {
+			segments = append(segments, segment)
+		}
+	}
+	if len(cacheOptions) == 0 {
+		segments = []string{strings.Join(segments, "\n\n")}
+	}
+
+	split := make([]fantasy.Message, 0, len(messages)+len(segments)-1)
+	for i, segment := range segments {
+		msg := fantasy.NewSystemMessage(segment)
+		if i < len(segments)-1 {
+			msg.ProviderOptions = cacheOptions
+		}
+		split = append(split, msg)
+	}
+	return append(split, messages[1:]...)
+}
+
+// systemMessageText 返回只包含一段文本的系统消息的文本。
+func systemMessageText(msg fantasy.Message) (string, bool) {
+	if len(msg.Content) != 1 {
+		return "", false
+	}
+	part, ok := fantasy.AsMessagePart[fantasy.TextPart](msg.Content[0])
+	if !ok {
+		return "", false
+	}
+	return part.Text, true
+}
+
+// setPromptCacheKey 在提供者启用了会话缓存键时，把会话 ID 作为 OpenAI 的 prompt_cache_key
+// 发送，使同一会话的请求路由到同一个缓存。
+func setPromptCacheKey(options fantasy.ProviderOptions, providerCfg config.ProviderConfig, sessionID string) {
+	if providerCfg.PromptCache == nil || !providerCfg.PromptCache.SessionKey {
+		return
+	}
+	switch opts := options[openai.Name].(type) {
+	case *openai.ProviderOptions:
+		opts.PromptCacheKey = &sessionID
+	case *openai.ResponsesProviderOptions:
+		opts.PromptCacheKey = &sessionID
+	}
+}
@@ -0,0 +1,56 @@
+package agent
+
+import (
+	"testing"
+
+	"charm.land/fantasy"
+	"charm.land/fantasy/providers/anthropic"
+	"charm.land/fantasy/providers/openai"
+	"github.com/purpose168/crush-cn/internal/agent/prompt"
+	"github.com/purpose168/crush-cn/internal/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitSystemPrompt(t *testing.T) {
+	t.Parallel()
+
+	cacheOptions := fantasy.ProviderOptions{
+		anthropic.Name: &anthropic.ProviderCacheControlOptions{
+			CacheControl: anthropic.CacheControl{Type: "ephemeral"},
+		},
+	}
+	messages := []fantasy.Message{
+		fantasy.NewSystemMessage("instructions\n" + prompt.CacheBreakpoint + "\n\n<env>date</env>"),
+		fantasy.NewUserMessage("hello"),
+	}
+
+	split := splitSystemPrompt(messages, cacheOptions)
+	require.Len(t, split, 3)
+	text, _ := systemMessageText(split[0])
+	require.Equal(t, "instructions", text)
+	require.Equal(t, cacheOptions, split[0].ProviderOptions)
+	text, _ = systemMessageText(split[1])
+	require.Equal(t, "<env>date</env>", text)
+	require.Nil(t, split[1].ProviderOptions, "the last segment is marked by the caller")
+	require.Equal(t, fantasy.MessageRoleUser, split[2].Role)
+
+	joined := splitSystemPrompt(messages, nil)
+	require.Len(t, joined, 2)
+	text, _ = systemMessageText(joined[0])
+	require.Equal(t, "instructions\n\n<env>date</env>", text)
+
+	plain := []fantasy.Message{fantasy.NewSystemMessage("no marker")}
+	require.Equal(t, plain, splitSystemPrompt(plain, cacheOptions))
+}
+
+func TestSetPromptCacheKey(t *testing.T) {
+	t.Parallel()
+
+	options := fantasy.ProviderOptions{openai.Name: &openai.ResponsesProviderOptions{}}
+	setPromptCacheKey(options, config.ProviderConfig{}, "session")
+	require.Nil(t, options[openai.Name].(*openai.ResponsesProviderOptions).PromptCacheKey)
+
+	cfg := config.ProviderConfig{PromptCache: &config.PromptCache{SessionKey: true}}
+	setPromptCacheKey(options, cfg, "session")
+	require.Equal(t, "session", *options[openai.Name].(*openai.ResponsesProviderOptions).PromptCacheKey)
+}
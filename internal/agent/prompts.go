@@ -3,9 +3,11 @@ package agent
 import (
 	"context"
 	_ "embed"
+	"strconv"
 
 	"github.com/purpose168/crush-cn/internal/agent/prompt"
 	"github.com/purpose168/crush-cn/internal/config"
+	"github.com/purpose168/crush-cn/internal/projectinfo"
 )
 
 //go:embed templates/coder.md.tpl
@@ -14,6 +16,9 @@ var coderPromptTmpl []byte
 //go:embed templates/task.md.tpl
 var taskPromptTmpl []byte
 
+//go:embed templates/custom_agent.md.tpl
+var customAgentPromptTmpl []byte
+
 //go:embed templates/initialize.md.tpl
 var initializePromptTmpl []byte
 
@@ -35,9 +40,17 @@ func taskPrompt(opts ...prompt.Option) (*prompt.Prompt, error) {
 	return systemPrompt, nil
 }
 
-// InitializePrompt 初始化提示
+// customAgentPrompt 创建自定义代理的提示，在配置的系统提示后附加环境信息。
+// 配置的系统提示按原样输出，不作为模板解析
+func customAgentPrompt(name, systemPrompt string, opts ...prompt.Option) (*prompt.Prompt, error) {
+	tmpl := "{{" + strconv.Quote(systemPrompt) + "}}" + string(customAgentPromptTmpl)
+	return prompt.NewPrompt(name, tmpl, opts...)
+}
+
+// InitializePrompt 初始化提示，附带从项目配置中分析出的构建、测试和检查命令
 func InitializePrompt(cfg config.Config) (string, error) {
-	systemPrompt, err := prompt.NewPrompt("initialize", string(initializePromptTmpl))
+	analysis := projectinfo.Analyze(cfg.WorkingDir()).Markdown()
+	systemPrompt, err := prompt.NewPrompt("initialize", string(initializePromptTmpl), prompt.WithProjectAnalysis(analysis))
 	if err != nil {
 		return "", err
 	}
@@ -0,0 +1,69 @@
+package agent
+
+import (
+	"log/slog"
+	"net/http"
+	"sync"
+
+	"charm.land/catwalk/pkg/catwalk"
+	"charm.land/fantasy/providers/google"
+	"github.com/purpose168/crush-cn/internal/config"
+	"github.com/purpose168/crush-cn/internal/log"
+	"github.com/purpose168/crush-cn/internal/network"
+	"github.com/purpose168/crush-cn/internal/oauth/copilot"
+	"github.com/purpose168/crush-cn/internal/ratelimit"
+)
+
+// providerLimiters 保存每个提供者的限速器。重建模型时复用限额未变的限速器，
+// 使编码代理和子代理的请求始终计入同一个限额
+type providerLimiters struct {
+	mu       sync.Mutex
+	limiters map[string]*ratelimit.Limiter
+}
+
+// get 返回提供者的限速器，未配置限速时返回 nil
+func (p *providerLimiters) get(providerCfg config.ProviderConfig) *ratelimit.Limiter {
+	var rpm, concurrent int
+	if providerCfg.RateLimit != nil {
+		rpm, concurrent = providerCfg.RateLimit.RPM, providerCfg.RateLimit.Concurrent
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if limiter, ok := p.limiters[providerCfg.ID]; ok && limiter.Matches(rpm, concurrent) {
+		return limiter
+	}
+	limiter := ratelimit.New(rpm, concurrent)
+	if limiter == nil {
+		delete(p.limiters, providerCfg.ID)
+		return nil
+	}
+	if p.limiters == nil {
+		p.limiters = make(map[string]*ratelimit.Limiter)
+	}
+	p.limiters[providerCfg.ID] = limiter
+	slog.Info("Rate limiting provider requests", "provider", providerCfg.ID, "rpm", rpm, "concurrent", concurrent)
+	return limiter
+}
+
+// providerHTTPClient 返回提供者使用的 HTTP 客户端。同一提供者的客户端共享一个传输层，
+// 以复用连接并缓存 DNS 解析结果；Gemini 提供者的请求还会使用文件 API 和上下文缓存
+func (c *coordinator) providerHTTPClient(providerCfg config.ProviderConfig, isSubAgent bool) *http.Client {
+	var base http.RoundTripper = network.ForProvider(providerCfg.ID)
+	var httpClient *http.Client
+	if providerCfg.ID == string(catwalk.InferenceProviderCopilot) {
+		httpClient = copilot.NewClient(isSubAgent, c.cfg.Options.Debug, base)
+	} else if c.cfg.Options.Debug {
+		httpClient = log.NewHTTPClient(base)
+	} else {
+		httpClient = &http.Client{Transport: base}
+	}
+
+	if providerCfg.Type == google.Name && !providerCfg.CacheDisabled() {
+		httpClient.Transport = c.geminiCache.transport(providerCfg, httpClient.Transport)
+	}
+	if limiter := c.limiters.get(providerCfg); limiter != nil {
+		httpClient.Transport = &ratelimit.Transport{Limiter: limiter, Base: httpClient.Transport}
+	}
+	return httpClient
+}
@@ -0,0 +1,112 @@
+package agent
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+
+	"charm.land/fantasy"
+	"github.com/purpose168/crush-cn/internal/agent/tools"
+	"github.com/purpose168/crush-cn/internal/config"
+	"github.com/purpose168/crush-cn/internal/eventlog"
+	"github.com/purpose168/crush-cn/internal/message"
+	"github.com/purpose168/crush-cn/internal/redact"
+)
+
+// attachmentSource 是附件脱敏在报告中的来源名称
+const attachmentSource = "attachment"
+
+// newRedactor 根据配置创建脱敏器，禁用时返回 nil。附加规则无效时仅使用内置规则。
+func newRedactor(cfg *config.Redaction) *redact.Redactor {
+	var patterns map[string]string
+	if cfg != nil {
+		if cfg.Disabled {
+			return nil
+		}
+		patterns = cfg.Patterns
+	}
+	r, err := redact.New(patterns)
+	if err != nil {
+		slog.Error("Invalid redaction pattern, using built-in rules only", "error", err)
+		r, _ = redact.New(nil)
+	}
+	return r
+}
+
+// redactingTool 包装工具，在结果交给模型和写入会话前替换其中的敏感信息
+type redactingTool struct {
+	fantasy.AgentTool
+	redactor *redact.Redactor
+	eventLog *eventlog.Logger
+}
+
+// redactToolOutputs 为每个工具启用结果脱敏
+func (c *coordinator) redactToolOutputs(agentTools []fantasy.AgentTool) []fantasy.AgentTool {
+	if c.redactor == nil {
+		return agentTools
+	}
+	redacted := make([]fantasy.AgentTool, 0, len(agentTools))
+	for _, tool := range agentTools {
+		redacted = append(redacted, &redactingTool{
+			AgentTool: tool,
+			redactor:  c.redactor,
+			eventLog:  c.eventLog,
+		})
+	}
+	return redacted
+}
+
+// Run 实现 [fantasy.AgentTool]
+func (t *redactingTool) Run(ctx context.Context, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+	resp, err := t.AgentTool.Run(ctx, call)
+	if err != nil || resp.Type != "text" {
+		return resp, err
+	}
+	content, counts := t.redactor.Redact(resp.Content)
+	if len(counts) == 0 {
+		return resp, nil
+	}
+
+	sessionID := tools.GetSessionFromContext(ctx)
+	t.redactor.Record(sessionID, call.Name, counts)
+	t.eventLog.Log(eventlog.Event{
+		SessionID:  sessionID,
+		Type:       eventlog.TypeRedaction,
+		ToolCallID: call.ID,
+		ToolName:   call.Name,
+		Redactions: counts,
+	})
+	resp.Content = content
+	return resp, nil
+}
+
+// redactAttachments 替换文本附件中的敏感信息
+func (c *coordinator) redactAttachments(sessionID string, attachments []message.Attachment) []message.Attachment {
+	if c.redactor == nil {
+		return attachments
+	}
+	attachments = slices.Clone(attachments)
+	for i, att := range attachments {
+		if !att.IsText() {
+			continue
+		}
+		content, counts := c.redactor.Redact(string(att.Content))
+		if len(counts) == 0 {
+			continue
+		}
+		c.redactor.Record(sessionID, attachmentSource, counts)
+		c.eventLog.Log(eventlog.Event{
+			SessionID:  sessionID,
+			Type:       eventlog.TypeRedaction,
+			Path:       att.FilePath,
+			Redactions: counts,
+		})
+		attachments[i].Content = []byte(content)
+	}
+	return attachments
+}
+
+// RedactionReport 实现 Coordinator 接口
+func (c *coordinator) RedactionReport(sessionID string) []redact.Finding {
+	return c.redactor.Report(sessionID)
+}
@@ -0,0 +1,151 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"charm.land/fantasy"
+	"github.com/purpose168/crush-cn/internal/message"
+	"github.com/purpose168/crush-cn/internal/session"
+)
+
+// Regenerate 使用当前模型重新运行会话的最后一轮对话。最后一条用户消息及其后的回复保存为
+// 备选回复版本，然后以相同的提示和附件重新生成回复，用户比较后可以用 [coordinator.KeepVariant]
+// 换回原来的版本。继续对话时未保留的版本会被删除。
+func (c *coordinator) Regenerate(ctx context.Context, sessionID string) (*fantasy.AgentResult, error) {
+	if err := c.readyWg.Wait(); err != nil {
+		return nil, err
+	}
+	if c.IsSessionBusy(sessionID) {
+		return nil, ErrSessionBusy
+	}
+	sess, err := c.sessions.Get(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	msgs, err := c.messages.List(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	current, user, ok := LastTurn(sess, msgs)
+	if !ok {
+		return nil, ErrNothingToRegenerate
+	}
+
+	prompt := user.Content().Text
+	var attachments []message.Attachment
+	for _, bin := range user.BinaryContent() {
+		attachments = append(attachments, message.Attachment{
+			FilePath: bin.Path,
+			FileName: filepath.Base(bin.Path),
+			MimeType: bin.MIMEType,
+			Content:  bin.Data,
+		})
+	}
+
+	if _, err := c.sessions.SetVariants(ctx, sessionID, append(sess.Variants, current)); err != nil {
+		return nil, err
+	}
+	return c.run(ctx, sessionID, prompt, true, attachments...)
+}
+
+// KeepVariant 将会话最后一轮对话换成第 index 个备选回复版本，当前版本成为备选版本。
+func (c *coordinator) KeepVariant(ctx context.Context, sessionID string, index int) error {
+	if c.IsSessionBusy(sessionID) {
+		return ErrSessionBusy
+	}
+	sess, err := c.sessions.Get(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+	msgs, err := c.messages.List(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+	current, _, ok := LastTurn(sess, msgs)
+	if !ok {
+		// 重新生成在创建用户消息之前失败，当前没有可以保存的版本
+		current = session.Variant{}
+	}
+	variants, _, err := session.SwapVariant(sess.Variants, index, current)
+	if err != nil {
+		return err
+	}
+	variants = slices.DeleteFunc(variants, func(v session.Variant) bool { return len(v.MessageIDs) == 0 })
+	_, err = c.sessions.SetVariants(ctx, sessionID, variants)
+	return err
+}
+
+// DeleteVariant 删除会话的第 index 个备选回复版本及其消息。
+func (c *coordinator) DeleteVariant(ctx context.Context, sessionID string, index int) error {
+	sess, err := c.sessions.Get(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+	if index < 0 || index >= len(sess.Variants) {
+		return fmt.Errorf("回复版本不存在: %d", index)
+	}
+	for _, id := range sess.Variants[index].MessageIDs {
+		if err := c.messages.Delete(ctx, id); err != nil {
+			return err
+		}
+	}
+	_, err = c.sessions.SetVariants(ctx, sessionID, slices.Delete(slices.Clone(sess.Variants), index, index+1))
+	return err
+}
+
+// LastTurn 返回会话当前的最后一轮对话（不含备选版本中的消息）及其用户消息。
+// 总结之前的对话不能重新生成。
+func LastTurn(sess session.Session, msgs []message.Message) (session.Variant, message.Message, bool) {
+	hidden := sess.HiddenMessages()
+	start := -1
+	for i, msg := range msgs {
+		switch {
+		case hidden[msg.ID]:
+		case msg.ID == sess.SummaryMessageID:
+			start = -1
+		case msg.Role == message.User:
+			start = i
+		}
+	}
+	if start == -1 {
+		return session.Variant{}, message.Message{}, false
+	}
+
+	user := msgs[start]
+	variant := session.Variant{CreatedAt: user.CreatedAt}
+	for _, msg := range msgs[start:] {
+		if hidden[msg.ID] {
+			continue
+		}
+		variant.MessageIDs = append(variant.MessageIDs, msg.ID)
+		if msg.Role != message.Assistant {
+			continue
+		}
+		if variant.Model == "" {
+			variant.Model, variant.Provider = msg.Model, msg.Provider
+		}
+		if text := strings.TrimSpace(msg.Content().Text); text != "" {
+			variant.Preview, _, _ = strings.Cut(text, "\n")
+		}
+	}
+	return variant, user, true
+}
+
+// discardVariants 删除会话的备选回复版本及其消息。继续对话后最后一轮对话不再是
+// 这些版本的替代，因此不再保留。
+func discardVariants(ctx context.Context, sessions session.Service, messages message.Service, sess session.Session) error {
+	if len(sess.Variants) == 0 {
+		return nil
+	}
+	for id := range sess.HiddenMessages() {
+		if err := messages.Delete(ctx, id); err != nil {
+			return err
+		}
+	}
+	_, err := sessions.SetVariants(ctx, sess.ID, nil)
+	return err
+}
@@ -0,0 +1,56 @@
+package agent
+
+import (
+	"testing"
+
+	"github.com/purpose168/crush-cn/internal/message"
+	"github.com/purpose168/crush-cn/internal/session"
+	"github.com/stretchr/testify/require"
+)
+
+func textMessage(id string, role message.MessageRole, text, model string) message.Message {
+	return message.Message{
+		ID:       id,
+		Role:     role,
+		Parts:    []message.ContentPart{message.TextContent{Text: text}},
+		Model:    model,
+		Provider: "test",
+	}
+}
+
+func TestLastTurn(t *testing.T) {
+	t.Parallel()
+
+	msgs := []message.Message{
+		textMessage("u1", message.User, "first", ""),
+		textMessage("a1", message.Assistant, "ok", "gpt"),
+		textMessage("u2", message.User, "fix the bug", ""),
+		textMessage("a2", message.Assistant, "", "gpt"),
+		textMessage("t2", message.Tool, "", ""),
+		textMessage("a3", message.Assistant, "Fixed it.\nDetails follow.", "gpt"),
+		textMessage("u3", message.User, "fix the bug", ""),
+		textMessage("a4", message.Assistant, "Done.", "claude"),
+	}
+
+	// 没有备选版本时最后一轮是 u3 之后的消息
+	variant, user, ok := LastTurn(session.Session{}, msgs)
+	require.True(t, ok)
+	require.Equal(t, "u3", user.ID)
+	require.Equal(t, []string{"u3", "a4"}, variant.MessageIDs)
+	require.Equal(t, "claude", variant.Model)
+	require.Equal(t, "Done.", variant.Preview)
+
+	// u3 是重新生成前的版本时，最后一轮从 u2 开始并跳过隐藏的消息
+	sess := session.Session{Variants: []session.Variant{{MessageIDs: []string{"u3", "a4"}}}}
+	variant, user, ok = LastTurn(sess, msgs)
+	require.True(t, ok)
+	require.Equal(t, "u2", user.ID)
+	require.Equal(t, []string{"u2", "a2", "t2", "a3"}, variant.MessageIDs)
+	require.Equal(t, "gpt", variant.Model)
+	require.Equal(t, "Fixed it.", variant.Preview)
+
+	// 总结之前的对话不能重新生成
+	summarized := append(msgs[:2:2], textMessage("s", message.Assistant, "summary", "gpt"))
+	_, _, ok = LastTurn(session.Session{SummaryMessageID: "s"}, summarized)
+	require.False(t, ok)
+}
@@ -0,0 +1,79 @@
+package agent
+
+import (
+	"slices"
+
+	"charm.land/fantasy"
+	"github.com/purpose168/crush-cn/internal/agent/tools"
+	"github.com/purpose168/crush-cn/internal/sandbox"
+	"github.com/purpose168/crush-cn/internal/shell"
+)
+
+// localOnlyTools 是直接访问本地文件系统或在主机上启动进程的工具。远程开发时文件在远程主机上，
+// 这些工具不可用，模型可以通过 bash 工具在远程主机上执行对应的命令。启用沙箱时这些工具
+// 同样不可用：它们在沙箱之外执行，会运行沙箱中的命令写入工作区的代码，例如 git 钩子、
+// .git/config 中的 core.fsmonitor 或测试文件，使沙箱失去作用。
+var localOnlyTools = []string{
+	tools.DownloadToolName,
+	tools.GitCommitToolName,
+	tools.GitDiffToolName,
+	tools.GitStatusToolName,
+	tools.GlobToolName,
+	tools.GrepToolName,
+	tools.LSToolName,
+	tools.RepoMapToolName,
+	tools.RunTestsToolName,
+	tools.SemanticSearchToolName,
+	tools.DiagnosticsToolName,
+	tools.ReferencesToolName,
+	tools.LSPSymbolsToolName,
+	tools.LSPRestartToolName,
+	tools.LSPRenameToolName,
+	tools.LSPCodeActionToolName,
+}
+
+// remote 报告工具是否在远程主机上运行
+func (c *coordinator) remote() bool {
+	return c.cfg.Remote.Enabled()
+}
+
+// sandboxed 报告 bash 工具是否在本机的沙箱中运行
+func (c *coordinator) sandboxed() bool {
+	return !c.remote() && c.cfg.Options.Sandbox.Enabled()
+}
+
+// toolWorkingDir 返回文件和 shell 工具的工作目录，远程开发时是远程主机上的项目目录
+func (c *coordinator) toolWorkingDir() string {
+	if c.remote() {
+		return c.cfg.Remote.Path
+	}
+	return c.cfg.WorkingDir()
+}
+
+// shellRunner 返回 bash 工具的执行器：远程开发时是远程主机，配置了沙箱时是沙箱，
+// 直接在本机运行时返回 nil
+func (c *coordinator) shellRunner() shell.Runner {
+	if !c.remote() {
+		if sb := c.cfg.Options.Sandbox; c.sandboxed() {
+			return sandbox.New(sandbox.Options{
+				Engine:    sb.Engine,
+				Image:     sb.Image,
+				Network:   sb.Network,
+				Workspace: c.cfg.WorkingDir(),
+			})
+		}
+		return nil
+	}
+	runner, _ := c.fsys.(shell.Runner)
+	return runner
+}
+
+// withoutLocalOnlyTools 在远程开发或启用沙箱时移除在主机上执行的工具
+func (c *coordinator) withoutLocalOnlyTools(agentTools []fantasy.AgentTool) []fantasy.AgentTool {
+	if !c.remote() && !c.sandboxed() {
+		return agentTools
+	}
+	return slices.DeleteFunc(agentTools, func(tool fantasy.AgentTool) bool {
+		return slices.Contains(localOnlyTools, tool.Info().Name)
+	})
+}
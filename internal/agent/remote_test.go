@@ -0,0 +1,54 @@
+package agent
+
+import (
+	"slices"
+	"testing"
+
+	"charm.land/catwalk/pkg/catwalk"
+	"github.com/purpose168/crush-cn/internal/agent/tools"
+	"github.com/purpose168/crush-cn/internal/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSandboxRemovesLocalOnlyTools(t *testing.T) {
+	t.Parallel()
+
+	build := func(sandbox *config.Sandbox) []string {
+		cfg, err := config.NewBuilder(t.TempDir(),
+			config.WithDataDirectory(t.TempDir()),
+			config.WithProvider("local", config.ProviderConfig{
+				Type:    catwalk.TypeOpenAICompat,
+				BaseURL: "http://localhost:11434/v1",
+				Models:  []catwalk.Model{{ID: "qwen", Name: "Qwen", DefaultMaxTokens: 4096}},
+			}),
+			config.WithModel(config.SelectedModelTypeLarge, config.SelectedModel{Provider: "local", Model: "qwen"}),
+			config.WithModel(config.SelectedModelTypeSmall, config.SelectedModel{Provider: "local", Model: "qwen"}),
+			config.WithTools(config.Tools{SemanticSearch: config.ToolSemanticSearch{Model: "nomic-embed-text", BaseURL: "http://localhost:11434/v1"}}),
+			config.WithOptions(func(o *config.Options) { o.Sandbox = sandbox }),
+		).Build()
+		require.NoError(t, err)
+
+		c := &coordinator{cfg: cfg}
+		allowed := append([]string{tools.BashToolName, tools.ViewToolName}, localOnlyTools...)
+		agentTools, err := c.buildTools(t.Context(), config.Agent{AllowedTools: allowed})
+		require.NoError(t, err)
+		var names []string
+		for _, tool := range agentTools {
+			names = append(names, tool.Info().Name)
+		}
+		return names
+	}
+
+	// 未启用沙箱时所有工具都可用，确保下面的检查覆盖了每个只能在主机上执行的工具
+	names := build(nil)
+	for _, name := range localOnlyTools {
+		require.Contains(t, names, name)
+	}
+
+	names = build(&config.Sandbox{Engine: "docker", Image: "alpine"})
+	require.Contains(t, names, tools.BashToolName)
+	require.Contains(t, names, tools.ViewToolName)
+	for _, name := range names {
+		require.False(t, slices.Contains(localOnlyTools, name), "启用沙箱时 %s 工具仍然可用", name)
+	}
+}
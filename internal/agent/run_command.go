@@ -0,0 +1,96 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"charm.land/fantasy"
+	"github.com/google/uuid"
+	"github.com/purpose168/crush-cn/internal/agent/tools"
+	"github.com/purpose168/crush-cn/internal/message"
+	"github.com/purpose168/crush-cn/internal/permission"
+)
+
+// RunCommand 使用 bash 工具在会话中执行用户发起的命令，例如运行助手消息中的代码块。
+// 命令与模型发起的调用一样需要权限，调用和输出作为一条助手消息和工具结果追加到会话，
+// 模型在后续对话中可以看到输出。
+func (c *coordinator) RunCommand(ctx context.Context, sessionID, description, command string) error {
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	release, ok := c.currentAgent.ClaimSession(ctx, sessionID, cancel)
+	if !ok {
+		return ErrSessionBusy
+	}
+	defer release()
+
+	if sess, err := c.sessions.Get(ctx, sessionID); err == nil {
+		if err := discardVariants(ctx, c.sessions, c.messages, sess); err != nil {
+			return err
+		}
+	}
+	input, err := json.Marshal(tools.BashParams{Description: description, Command: command})
+	if err != nil {
+		return err
+	}
+	call := message.ToolCall{
+		ID:       uuid.New().String(),
+		Name:     tools.BashToolName,
+		Input:    string(input),
+		Finished: true,
+	}
+	model := c.Model()
+	assistant, err := c.messages.Create(ctx, sessionID, message.CreateMessageParams{
+		Role:     message.Assistant,
+		Parts:    []message.ContentPart{call},
+		Model:    model.ModelCfg.Model,
+		Provider: model.ModelCfg.Provider,
+	})
+	if err != nil {
+		return err
+	}
+
+	// 与模型发起的调用一样经过计划模式、执行时限、脱敏和截断等包装
+	bash := c.wrapTools([]fantasy.AgentTool{
+		tools.NewBashTool(c.permissions, c.sessions, c.toolWorkingDir(), c.cfg.Options.Attribution, model.CatwalkCfg.Name, c.cfg.User.Author(), c.shellType(), c.shellRunner()),
+	})[0]
+	toolCtx := context.WithValue(runCtx, tools.SessionIDContextKey, sessionID)
+	if sess, err := c.sessions.Get(ctx, sessionID); err == nil && sess.WorkingDir != "" {
+		toolCtx = context.WithValue(toolCtx, tools.WorkingDirContextKey, sess.WorkingDir)
+	}
+	toolCtx = context.WithValue(toolCtx, tools.MessageIDContextKey, assistant.ID)
+	resp, runErr := bash.Run(toolCtx, fantasy.ToolCall{ID: call.ID, Name: call.Name, Input: call.Input})
+
+	result := message.ToolResult{
+		ToolCallID: call.ID,
+		Name:       call.Name,
+		Content:    resp.Content,
+		Metadata:   resp.Metadata,
+		IsError:    resp.IsError,
+	}
+	finish := message.FinishReasonToolUse
+	if runErr != nil {
+		result.IsError = true
+		switch {
+		case errors.Is(runErr, permission.ErrorPermissionDenied):
+			result.Content = "用户拒绝了权限"
+		case errors.Is(runErr, context.Canceled):
+			result.Content = "用户取消了工具执行"
+		default:
+			result.Content = fmt.Sprintf("执行工具时出错: %v", runErr)
+		}
+		finish = message.FinishReasonCanceled
+	}
+	assistant.AddFinish(finish, "", "")
+	if err := c.messages.Update(ctx, assistant); err != nil {
+		return err
+	}
+	if _, err := c.messages.Create(ctx, sessionID, message.CreateMessageParams{
+		Role:  message.Tool,
+		Parts: []message.ContentPart{result},
+	}); err != nil {
+		return err
+	}
+	return runErr
+}
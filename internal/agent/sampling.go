@@ -0,0 +1,141 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"charm.land/fantasy"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// samplingMaxOutputTokens 是 MCP 服务器未指定最大令牌数时采样允许输出的最大令牌数。
+const samplingMaxOutputTokens = 1024
+
+// Sample 使用配置的模型为 MCP 服务器的采样请求生成补全。服务器的模型偏好更看重速度或成本时使用小模型，
+// 否则使用大模型。
+func (c *coordinator) Sample(ctx context.Context, params *mcp.CreateMessageParams) (*mcp.CreateMessageResult, error) {
+	large, small, err := c.buildAgentModels(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+	model := large
+	if prefersSmallModel(params.ModelPreferences, large.ModelCfg.Model, small.ModelCfg.Model) {
+		model = small
+	}
+	providerCfg, _ := c.cfg.Providers.Get(model.ModelCfg.Provider)
+
+	prompt, err := samplingPrompt(providerCfg.SystemPromptPrefix, params)
+	if err != nil {
+		return nil, err
+	}
+	maxOutputTokens := params.MaxTokens
+	if maxOutputTokens <= 0 {
+		maxOutputTokens = samplingMaxOutputTokens
+	}
+	if model.CatwalkCfg.CanReason {
+		maxOutputTokens = max(maxOutputTokens, model.CatwalkCfg.DefaultMaxTokens)
+	}
+	call := fantasy.Call{
+		Prompt:          prompt,
+		MaxOutputTokens: &maxOutputTokens,
+	}
+	if params.Temperature > 0 {
+		temperature := params.Temperature
+		call.Temperature = &temperature
+	}
+
+	resp, err := model.Model.Generate(ctx, call)
+	if err != nil {
+		return nil, err
+	}
+	text := strings.TrimSpace(thinkTagRegex.ReplaceAllString(resp.Content.Text(), ""))
+	if text == "" {
+		return nil, errors.New("model returned an empty completion")
+	}
+	return &mcp.CreateMessageResult{
+		Content:    &mcp.TextContent{Text: text},
+		Model:      model.ModelCfg.Model,
+		Role:       "assistant",
+		StopReason: samplingStopReason(resp.FinishReason),
+	}, nil
+}
+
+// prefersSmallModel 根据 MCP 服务器的模型偏好选择模型。提示按顺序匹配模型 ID 的子串，
+// 没有匹配的提示时，速度或成本优先级高于智能优先级则选择小模型。
+func prefersSmallModel(prefs *mcp.ModelPreferences, largeID, smallID string) bool {
+	if prefs == nil {
+		return false
+	}
+	for _, hint := range prefs.Hints {
+		if hint == nil || hint.Name == "" {
+			continue
+		}
+		switch {
+		case strings.Contains(largeID, hint.Name):
+			return false
+		case strings.Contains(smallID, hint.Name):
+			return true
+		}
+	}
+	return max(prefs.SpeedPriority, prefs.CostPriority) > prefs.IntelligencePriority
+}
+
+// samplingPrompt 将采样请求的系统提示和消息转换为模型的提示。
+func samplingPrompt(systemPromptPrefix string, params *mcp.CreateMessageParams) (fantasy.Prompt, error) {
+	var prompt fantasy.Prompt
+	var system []string
+	if systemPromptPrefix != "" {
+		system = append(system, systemPromptPrefix)
+	}
+	if params.SystemPrompt != "" {
+		system = append(system, params.SystemPrompt)
+	}
+	if len(system) > 0 {
+		prompt = append(prompt, fantasy.NewSystemMessage(system...))
+	}
+
+	for _, msg := range params.Messages {
+		if msg == nil {
+			continue
+		}
+		var part fantasy.MessagePart
+		switch content := msg.Content.(type) {
+		case *mcp.TextContent:
+			part = fantasy.TextPart{Text: content.Text}
+		case *mcp.ImageContent:
+			part = fantasy.FilePart{Data: content.Data, MediaType: content.MIMEType}
+		case *mcp.AudioContent:
+			part = fantasy.FilePart{Data: content.Data, MediaType: content.MIMEType}
+		default:
+			return nil, fmt.Errorf("unsupported sampling content %T", msg.Content)
+		}
+		role := fantasy.MessageRoleUser
+		if msg.Role == "assistant" {
+			role = fantasy.MessageRoleAssistant
+		}
+		// 同一角色的连续消息合并为一条，部分提供者不接受连续的同角色消息。
+		if n := len(prompt); n > 0 && prompt[n-1].Role == role {
+			prompt[n-1].Content = append(prompt[n-1].Content, part)
+			continue
+		}
+		prompt = append(prompt, fantasy.Message{Role: role, Content: []fantasy.MessagePart{part}})
+	}
+	if len(prompt) == 0 || prompt[len(prompt)-1].Role == fantasy.MessageRoleSystem {
+		return nil, errors.New("sampling request has no messages")
+	}
+	return prompt, nil
+}
+
+// samplingStopReason 将模型的结束原因转换为 MCP 采样的停止原因。
+func samplingStopReason(reason fantasy.FinishReason) string {
+	switch reason {
+	case fantasy.FinishReasonStop:
+		return "endTurn"
+	case fantasy.FinishReasonLength:
+		return "maxTokens"
+	default:
+		return string(reason)
+	}
+}
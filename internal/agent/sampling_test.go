@@ -0,0 +1,62 @@
+package agent
+
+import (
+	"testing"
+
+	"charm.land/fantasy"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrefersSmallModel(t *testing.T) {
+	t.Parallel()
+
+	const large, small = "claude-sonnet-4", "claude-haiku-4"
+	require.False(t, prefersSmallModel(nil, large, small))
+	require.True(t, prefersSmallModel(&mcp.ModelPreferences{SpeedPriority: 0.8, IntelligencePriority: 0.2}, large, small))
+	require.False(t, prefersSmallModel(&mcp.ModelPreferences{CostPriority: 0.3, IntelligencePriority: 0.9}, large, small))
+	require.True(t, prefersSmallModel(&mcp.ModelPreferences{
+		Hints:                []*mcp.ModelHint{{Name: "gpt-4o"}, {Name: "haiku"}},
+		IntelligencePriority: 1,
+	}, large, small))
+	require.False(t, prefersSmallModel(&mcp.ModelPreferences{
+		Hints:         []*mcp.ModelHint{{Name: "sonnet"}},
+		SpeedPriority: 1,
+	}, large, small))
+}
+
+func TestSamplingPrompt(t *testing.T) {
+	t.Parallel()
+
+	prompt, err := samplingPrompt("prefix", &mcp.CreateMessageParams{
+		SystemPrompt: "You summarize text.",
+		Messages: []*mcp.SamplingMessage{
+			{Role: "user", Content: &mcp.TextContent{Text: "Summarize:"}},
+			{Role: "user", Content: &mcp.ImageContent{Data: []byte("png"), MIMEType: "image/png"}},
+			{Role: "assistant", Content: &mcp.TextContent{Text: "Sure."}},
+			{Role: "user", Content: &mcp.TextContent{Text: "Go on."}},
+		},
+	})
+	require.NoError(t, err)
+	require.Len(t, prompt, 4)
+	require.Equal(t, fantasy.MessageRoleSystem, prompt[0].Role)
+	require.Len(t, prompt[0].Content, 2)
+	require.Equal(t, fantasy.MessageRoleUser, prompt[1].Role)
+	require.Len(t, prompt[1].Content, 2)
+	file, ok := fantasy.AsMessagePart[fantasy.FilePart](prompt[1].Content[1])
+	require.True(t, ok)
+	require.Equal(t, "image/png", file.MediaType)
+	require.Equal(t, fantasy.MessageRoleAssistant, prompt[2].Role)
+	require.Equal(t, fantasy.MessageRoleUser, prompt[3].Role)
+
+	_, err = samplingPrompt("", &mcp.CreateMessageParams{SystemPrompt: "only system"})
+	require.Error(t, err)
+}
+
+func TestSamplingStopReason(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, "endTurn", samplingStopReason(fantasy.FinishReasonStop))
+	require.Equal(t, "maxTokens", samplingStopReason(fantasy.FinishReasonLength))
+	require.Equal(t, "content-filter", samplingStopReason(fantasy.FinishReasonContentFilter))
+}
@@ -0,0 +1,70 @@
+package agent
+
+import (
+	"context"
+	"net/http/httptrace"
+	"sync/atomic"
+	"time"
+
+	"charm.land/fantasy"
+	"github.com/purpose168/crush-cn/internal/message"
+)
+
+// streamMetrics 测量一次模型请求的首令牌时间、生成速度和提供商延迟
+type streamMetrics struct {
+	start time.Time
+	// firstByte 是收到响应首字节的时间（UnixNano），由 HTTP 传输的 goroutine 写入
+	firstByte  atomic.Int64
+	firstToken time.Time
+}
+
+func newStreamMetrics() *streamMetrics {
+	return &streamMetrics{start: time.Now()}
+}
+
+// withTrace 返回附带 HTTP 跟踪的上下文，提供商客户端使用该上下文发出请求时
+// 记录收到响应首字节的时间。之后的请求（例如工具发出的请求）不会覆盖它
+func (s *streamMetrics) withTrace(ctx context.Context) context.Context {
+	return httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{
+		GotFirstResponseByte: func() {
+			s.firstByte.CompareAndSwap(0, time.Now().UnixNano())
+		},
+	})
+}
+
+// restart 在请求重试时重新开始测量，重试会在 delay 之后发出
+func (s *streamMetrics) restart(delay time.Duration) {
+	s.start = time.Now().Add(delay)
+	s.firstByte.Store(0)
+	s.firstToken = time.Time{}
+}
+
+// observe 在收到第一个生成内容的流片段时记录首令牌时间
+func (s *streamMetrics) observe(part fantasy.StreamPart) {
+	if !s.firstToken.IsZero() {
+		return
+	}
+	switch part.Type {
+	case fantasy.StreamPartTypeTextDelta,
+		fantasy.StreamPartTypeReasoningStart,
+		fantasy.StreamPartTypeReasoningDelta,
+		fantasy.StreamPartTypeToolInputStart:
+		s.firstToken = time.Now()
+	}
+}
+
+// result 返回响应在 finished 时结束的指标
+func (s *streamMetrics) result(outputTokens int64, finished time.Time) message.ResponseMetrics {
+	metrics := message.ResponseMetrics{OutputTokens: outputTokens}
+	if firstByte := s.firstByte.Load(); firstByte > 0 {
+		metrics.LatencyMs = max(0, time.Unix(0, firstByte).Sub(s.start).Milliseconds())
+	}
+	if s.firstToken.IsZero() {
+		return metrics
+	}
+	metrics.FirstTokenMs = s.firstToken.Sub(s.start).Milliseconds()
+	if generation := finished.Sub(s.firstToken).Seconds(); generation > 0 && outputTokens > 0 {
+		metrics.TokensPerSecond = float64(outputTokens) / generation
+	}
+	return metrics
+}
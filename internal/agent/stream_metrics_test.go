@@ -0,0 +1,38 @@
+package agent
+
+import (
+	"testing"
+	"time"
+
+	"charm.land/fantasy"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStreamMetrics(t *testing.T) {
+	t.Parallel()
+
+	start := time.Now()
+	m := &streamMetrics{start: start}
+	m.firstByte.Store(start.Add(200 * time.Millisecond).UnixNano())
+
+	// 只有生成内容的流片段才算作首令牌
+	m.observe(fantasy.StreamPart{Type: fantasy.StreamPartTypeWarnings})
+	require.True(t, m.firstToken.IsZero())
+	m.observe(fantasy.StreamPart{Type: fantasy.StreamPartTypeTextDelta})
+	require.False(t, m.firstToken.IsZero())
+	m.firstToken = start.Add(500 * time.Millisecond)
+	m.observe(fantasy.StreamPart{Type: fantasy.StreamPartTypeTextDelta})
+	require.Equal(t, start.Add(500*time.Millisecond), m.firstToken)
+
+	metrics := m.result(100, start.Add(2500*time.Millisecond))
+	require.Equal(t, int64(200), metrics.LatencyMs)
+	require.Equal(t, int64(500), metrics.FirstTokenMs)
+	require.Equal(t, int64(100), metrics.OutputTokens)
+	require.InDelta(t, 50.0, metrics.TokensPerSecond, 0.001)
+
+	m.restart(time.Second)
+	metrics = m.result(0, time.Now())
+	require.Zero(t, metrics.LatencyMs)
+	require.Zero(t, metrics.FirstTokenMs)
+	require.Zero(t, metrics.TokensPerSecond)
+}
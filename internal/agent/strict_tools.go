@@ -0,0 +1,154 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"maps"
+	"slices"
+
+	"charm.land/catwalk/pkg/catwalk"
+	"charm.land/fantasy"
+	"charm.land/fantasy/providers/azure"
+	"charm.land/fantasy/providers/openai"
+	"charm.land/fantasy/providers/openaicompat"
+	"github.com/purpose168/crush-cn/internal/config"
+)
+
+// strictTool 包装工具，把参数结构改写为 OpenAI 严格模式接受的形式：
+// 所有属性都列为必填，原本可选的属性允许为 null，对象禁止额外属性。
+// 模型传回的 null 值在调用工具前会被移除，工具看到的参数与非严格模式相同。
+type strictTool struct {
+	fantasy.AgentTool
+	info fantasy.ToolInfo
+}
+
+// usesResponsesAPI 报告提供者是否通过 OpenAI Responses API 请求该模型
+func usesResponsesAPI(providerCfg config.ProviderConfig, modelID string) bool {
+	switch providerCfg.Type {
+	case openai.Name, azure.Name:
+	case openaicompat.Name:
+		if !providerCfg.UseResponsesAPI && providerCfg.ID != string(catwalk.InferenceProviderCopilot) {
+			return false
+		}
+	default:
+		return false
+	}
+	return openai.IsResponsesModel(modelID)
+}
+
+// strictToolsEnabled 报告代理使用的模型是否启用了严格的工具参数结构
+func (c *coordinator) strictToolsEnabled(agent config.Agent) bool {
+	modelCfg, ok := c.cfg.Models[agent.Model]
+	if !ok {
+		return false
+	}
+	providerCfg, ok := c.cfg.Providers.Get(modelCfg.Provider)
+	return ok && providerCfg.StrictTools && usesResponsesAPI(providerCfg, modelCfg.Model)
+}
+
+// applyStrictTools 在启用严格工具参数时改写每个工具的参数结构
+func (c *coordinator) applyStrictTools(agent config.Agent, agentTools []fantasy.AgentTool) []fantasy.AgentTool {
+	if !c.strictToolsEnabled(agent) {
+		return agentTools
+	}
+	strict := make([]fantasy.AgentTool, 0, len(agentTools))
+	for _, tool := range agentTools {
+		info := tool.Info()
+		info.Parameters, info.Required = strictProperties(info.Parameters, info.Required)
+		strict = append(strict, &strictTool{AgentTool: tool, info: info})
+	}
+	return strict
+}
+
+// Info 实现 [fantasy.AgentTool]
+func (t *strictTool) Info() fantasy.ToolInfo {
+	return t.info
+}
+
+// Run 实现 [fantasy.AgentTool]
+func (t *strictTool) Run(ctx context.Context, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+	var input any
+	if err := json.Unmarshal([]byte(call.Input), &input); err == nil {
+		if data, err := json.Marshal(withoutNulls(input)); err == nil {
+			call.Input = string(data)
+		}
+	}
+	return t.AgentTool.Run(ctx, call)
+}
+
+// strictProperties 改写对象的属性：所有属性都列为必填，原本可选的属性允许为 null
+func strictProperties(properties map[string]any, required []string) (map[string]any, []string) {
+	result := make(map[string]any, len(properties))
+	for name, prop := range properties {
+		result[name] = strictSchema(prop, slices.Contains(required, name))
+	}
+	return result, slices.Sorted(maps.Keys(result))
+}
+
+// strictSchema 递归改写单个属性的结构，required 为 false 时允许该属性为 null
+func strictSchema(schema any, required bool) any {
+	m, ok := schema.(map[string]any)
+	if !ok {
+		return schema
+	}
+	result := maps.Clone(m)
+	if typ, _ := result["type"].(string); typ == "object" || result["properties"] != nil {
+		properties, _ := result["properties"].(map[string]any)
+		result["properties"], result["required"] = strictProperties(properties, stringSlice(result["required"]))
+		result["additionalProperties"] = false
+	}
+	if items, ok := result["items"]; ok {
+		result["items"] = strictSchema(items, true)
+	}
+	if required {
+		return result
+	}
+	switch typ := result["type"].(type) {
+	case string:
+		result["type"] = []any{typ, "null"}
+	case []any:
+		if !slices.Contains(typ, any("null")) {
+			result["type"] = append(slices.Clone(typ), "null")
+		}
+	}
+	if enum, ok := result["enum"].([]any); ok && !slices.Contains(enum, nil) {
+		result["enum"] = append(slices.Clone(enum), nil)
+	}
+	return result
+}
+
+// stringSlice 将 []string 或 []any 形式的 required 列表转换为 []string
+func stringSlice(v any) []string {
+	switch v := v.(type) {
+	case []string:
+		return v
+	case []any:
+		result := make([]string, 0, len(v))
+		for _, s := range v {
+			if s, ok := s.(string); ok {
+				result = append(result, s)
+			}
+		}
+		return result
+	}
+	return nil
+}
+
+// withoutNulls 递归移除对象中值为 null 的键
+func withoutNulls(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for key, value := range v {
+			if value == nil {
+				delete(v, key)
+				continue
+			}
+			v[key] = withoutNulls(value)
+		}
+	case []any:
+		for i, value := range v {
+			v[i] = withoutNulls(value)
+		}
+	}
+	return v
+}
@@ -0,0 +1,64 @@
+package agent
+
+import (
+	"context"
+	"testing"
+
+	"charm.land/fantasy"
+	"github.com/purpose168/crush-cn/internal/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStrictProperties(t *testing.T) {
+	t.Parallel()
+
+	properties, required := strictProperties(map[string]any{
+		"path": map[string]any{"type": "string"},
+		"todos": map[string]any{
+			"type": "array",
+			"items": map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"content": map[string]any{"type": "string"},
+					"status":  map[string]any{"type": "string", "enum": []any{"pending", "completed"}},
+					"note":    map[string]any{"type": "string", "enum": []any{"a", "b"}},
+				},
+				"required": []any{"content", "status"},
+			},
+		},
+	}, []string{"todos"})
+
+	require.Equal(t, []string{"path", "todos"}, required)
+	require.Equal(t, map[string]any{"type": []any{"string", "null"}}, properties["path"])
+
+	items := properties["todos"].(map[string]any)["items"].(map[string]any)
+	require.Equal(t, false, items["additionalProperties"])
+	require.Equal(t, []string{"content", "note", "status"}, items["required"])
+	itemProps := items["properties"].(map[string]any)
+	require.Equal(t, map[string]any{"type": "string", "enum": []any{"pending", "completed"}}, itemProps["status"])
+	require.Equal(t, map[string]any{"type": []any{"string", "null"}, "enum": []any{"a", "b", nil}}, itemProps["note"])
+}
+
+func TestStrictToolDropsNulls(t *testing.T) {
+	t.Parallel()
+
+	var got echoParams
+	tool := &strictTool{AgentTool: fantasy.NewAgentTool("echo", "echo", func(_ context.Context, params echoParams, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+		got = params
+		return fantasy.NewTextResponse(call.Input), nil
+	})}
+	resp, err := tool.Run(t.Context(), fantasy.ToolCall{ID: "call-1", Name: "echo", Input: `{"text":null}`})
+	require.NoError(t, err)
+	require.Equal(t, "{}", resp.Content)
+	require.Empty(t, got.Text)
+}
+
+func TestUsesResponsesAPI(t *testing.T) {
+	t.Parallel()
+
+	require.True(t, usesResponsesAPI(config.ProviderConfig{Type: "openai"}, "gpt-5"))
+	require.False(t, usesResponsesAPI(config.ProviderConfig{Type: "openai"}, "llama-3"))
+	require.False(t, usesResponsesAPI(config.ProviderConfig{Type: "openai-compat"}, "gpt-5"))
+	require.True(t, usesResponsesAPI(config.ProviderConfig{Type: "openai-compat", UseResponsesAPI: true}, "gpt-5"))
+	require.False(t, usesResponsesAPI(config.ProviderConfig{Type: "anthropic", UseResponsesAPI: true}, "gpt-5"))
+}
@@ -0,0 +1,13 @@
+
+
+<rules>
+1. When you are done, reply with a single final message; it is returned to the agent that invoked you and is not shown to the user directly.
+2. Any file paths you return in your final response MUST be absolute. DO NOT use relative paths.
+</rules>
+
+<env>
+Working directory: {{.WorkingDir}}
+Is directory a git repo: {{if .IsGitRepo}} yes {{else}} no {{end}}
+Platform: {{.Platform}}
+Today's date: {{.Date}}
+</env>
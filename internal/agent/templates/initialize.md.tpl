@@ -24,4 +24,12 @@ Analyze this codebase and create/update **{{.Config.Options.InitializeAs}}** to
 
 **Format**: Clear markdown sections. Use your judgment on structure based on what you find. Aim for completeness over brevity - include everything an agent would need to know.
 
+{{- if .ProjectAnalysis}}
+
+**Project analysis**: The following section was generated by parsing the project's configuration files (go.mod, package.json, Makefile). Include it in {{.Config.Options.InitializeAs}} as the "How to build/test/lint" section. You may drop commands that are clearly irrelevant to day-to-day work and add a short note on when to use each, but keep the commands exactly as written and do not invent new ones.
+
+<project_analysis>
+{{.ProjectAnalysis}}</project_analysis>
+{{- end}}
+
 **Critical**: Only document what you actually observe. Never invent commands, patterns, or conventions. If you can't find something, don't include it.
@@ -0,0 +1,143 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+
+	"charm.land/catwalk/pkg/catwalk"
+	"charm.land/fantasy"
+	"github.com/stretchr/testify/require"
+)
+
+// scriptedModel 是按脚本返回流式响应的模型，并记录每次调用的用户提示。
+type scriptedModel struct {
+	fantasy.LanguageModel
+	parts []fantasy.StreamPart
+
+	mu      sync.Mutex
+	prompts []string
+}
+
+func (m *scriptedModel) Stream(_ context.Context, call fantasy.Call) (fantasy.StreamResponse, error) {
+	var prompt string
+	for _, msg := range call.Prompt {
+		if msg.Role != fantasy.MessageRoleUser {
+			continue
+		}
+		for _, part := range msg.Content {
+			if text, ok := fantasy.AsMessagePart[fantasy.TextPart](part); ok {
+				prompt = text.Text
+			}
+		}
+	}
+	m.mu.Lock()
+	m.prompts = append(m.prompts, prompt)
+	m.mu.Unlock()
+
+	return func(yield func(fantasy.StreamPart) bool) {
+		for _, part := range m.parts {
+			if !yield(part) {
+				return
+			}
+		}
+	}, nil
+}
+
+func (m *scriptedModel) Provider() string { return "test" }
+func (m *scriptedModel) Model() string    { return "test" }
+
+func (m *scriptedModel) calls() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]string(nil), m.prompts...)
+}
+
+func textReply(text string) []fantasy.StreamPart {
+	return []fantasy.StreamPart{
+		{Type: fantasy.StreamPartTypeTextStart, ID: "t"},
+		{Type: fantasy.StreamPartTypeTextDelta, ID: "t", Delta: text},
+		{Type: fantasy.StreamPartTypeTextEnd, ID: "t"},
+		{Type: fantasy.StreamPartTypeFinish, FinishReason: fantasy.FinishReasonStop},
+	}
+}
+
+func titleTestAgent(env fakeEnv, large, small *scriptedModel, disableAutoTitle bool) SessionAgent {
+	catwalkCfg := catwalk.Model{ContextWindow: 200000, DefaultMaxTokens: 10000}
+	return NewSessionAgent(SessionAgentOptions{
+		LargeModel:       Model{Model: large, CatwalkCfg: catwalkCfg},
+		SmallModel:       Model{Model: small, CatwalkCfg: catwalkCfg},
+		DisableAutoTitle: disableAutoTitle,
+		IsYolo:           true,
+		Sessions:         env.sessions,
+		Messages:         env.messages,
+	})
+}
+
+func TestSessionTitle(t *testing.T) {
+	t.Run("使用助手的回复生成标题", func(t *testing.T) {
+		env := testEnv(t)
+		large := &scriptedModel{parts: textReply("这个项目是一个终端编码助手。")}
+		small := &scriptedModel{parts: textReply("项目介绍")}
+		sess, err := env.sessions.Create(t.Context(), "")
+		require.NoError(t, err)
+
+		_, err = titleTestAgent(env, large, small, false).Run(t.Context(), SessionAgentCall{SessionID: sess.ID, Prompt: "介绍这个项目"})
+		require.NoError(t, err)
+
+		require.Len(t, small.calls(), 1)
+		require.Contains(t, small.calls()[0], "User: 介绍这个项目\n\nAssistant: 这个项目是一个终端编码助手。")
+		sess, err = env.sessions.Get(t.Context(), sess.ID)
+		require.NoError(t, err)
+		require.Equal(t, "项目介绍", sess.Title)
+	})
+
+	t.Run("运行失败时仅根据提示生成标题", func(t *testing.T) {
+		env := testEnv(t)
+		large := &scriptedModel{parts: []fantasy.StreamPart{{Type: fantasy.StreamPartTypeError, Error: errors.New("服务不可用")}}}
+		small := &scriptedModel{parts: textReply("项目介绍")}
+		sess, err := env.sessions.Create(t.Context(), "")
+		require.NoError(t, err)
+
+		_, err = titleTestAgent(env, large, small, false).Run(t.Context(), SessionAgentCall{SessionID: sess.ID, Prompt: "介绍这个项目"})
+		require.Error(t, err)
+
+		require.Len(t, small.calls(), 1)
+		require.Contains(t, small.calls()[0], "介绍这个项目")
+		require.NotContains(t, small.calls()[0], "Assistant:")
+		sess, err = env.sessions.Get(t.Context(), sess.ID)
+		require.NoError(t, err)
+		require.Equal(t, "项目介绍", sess.Title)
+	})
+
+	t.Run("关闭自动标题", func(t *testing.T) {
+		env := testEnv(t)
+		large := &scriptedModel{parts: textReply("好的。")}
+		small := &scriptedModel{parts: textReply("标题")}
+		sess, err := env.sessions.Create(t.Context(), "")
+		require.NoError(t, err)
+
+		_, err = titleTestAgent(env, large, small, true).Run(t.Context(), SessionAgentCall{SessionID: sess.ID, Prompt: "你好"})
+		require.NoError(t, err)
+		require.Empty(t, small.calls())
+	})
+}
+
+func TestGenerateTitleTruncatesReply(t *testing.T) {
+	env := testEnv(t)
+	small := &scriptedModel{parts: textReply("标题")}
+	a := titleTestAgent(env, small, small, false).(*sessionAgent)
+	sess, err := env.sessions.Create(t.Context(), "")
+	require.NoError(t, err)
+
+	// 截断位置落在多字节字符中间时，不完整的字符会被丢弃。
+	reply := "a" + strings.Repeat("字", maxTitleReplyLength)
+	a.generateTitle(t.Context(), sess.ID, "问题", reply)
+
+	require.Len(t, small.calls(), 1)
+	truncated := strings.Repeat("字", (maxTitleReplyLength-1)/len("字"))
+	require.Contains(t, small.calls()[0], "Assistant: a"+truncated+"\n")
+	require.NotContains(t, small.calls()[0], truncated+"字")
+}
@@ -0,0 +1,59 @@
+package agent
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"charm.land/fantasy"
+	"github.com/purpose168/crush-cn/internal/agent/tools"
+	"github.com/purpose168/crush-cn/internal/message"
+	"github.com/purpose168/crush-cn/internal/session"
+)
+
+// userTodosMessage 在用户从界面修改了待办事项后返回提醒模型当前列表的消息。模型最后看到的列表
+// 来自历史中最后一次 todos 工具调用的结果，会话中的列表与它不同时说明用户修改过。
+func userTodosMessage(msgs []message.Message, sess session.Session) (fantasy.Message, bool) {
+	seen, found := lastTodosResult(msgs)
+	if !found && (len(sess.Todos) == 0 || sess.SummaryMessageID != "") {
+		// 没有调用过 todos 工具且没有待办事项，或者之前的调用已被摘要取代
+		return fantasy.Message{}, false
+	}
+	if slices.Equal(seen, sess.Todos) {
+		return fantasy.Message{}, false
+	}
+
+	var sb strings.Builder
+	sb.WriteString("<system_reminder>")
+	if len(sess.Todos) == 0 {
+		sb.WriteString("The user cleared the todo list in the UI. ")
+	} else {
+		sb.WriteString("The user edited the todo list in the UI; this is the current list and replaces the one from your last todos call. ")
+		sb.WriteString("Follow the new order and statuses, and keep the list up to date with the todos tool.\n")
+		for i, todo := range sess.Todos {
+			fmt.Fprintf(&sb, "%d. [%s] %s\n", i+1, todo.Status, todo.Content)
+		}
+	}
+	sb.WriteString("Do not mention this reminder to the user.</system_reminder>")
+	return fantasy.NewUserMessage(sb.String()), true
+}
+
+// lastTodosResult 返回历史中最后一次成功的 todos 工具调用写入的列表
+func lastTodosResult(msgs []message.Message) ([]session.Todo, bool) {
+	for i := len(msgs) - 1; i >= 0; i-- {
+		results := msgs[i].ToolResults()
+		for j := len(results) - 1; j >= 0; j-- {
+			r := results[j]
+			if r.Name != tools.TodosToolName || r.IsError {
+				continue
+			}
+			var meta tools.TodosResponseMetadata
+			if err := json.Unmarshal([]byte(r.Metadata), &meta); err != nil {
+				continue
+			}
+			return meta.Todos, true
+		}
+	}
+	return nil, false
+}
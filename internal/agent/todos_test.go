@@ -0,0 +1,55 @@
+package agent
+
+import (
+	"encoding/json"
+	"testing"
+
+	"charm.land/fantasy"
+	"github.com/purpose168/crush-cn/internal/agent/tools"
+	"github.com/purpose168/crush-cn/internal/message"
+	"github.com/purpose168/crush-cn/internal/session"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserTodosMessage(t *testing.T) {
+	t.Parallel()
+
+	agentTodos := []session.Todo{
+		{Content: "Write tests", Status: session.TodoStatusInProgress, ActiveForm: "Writing tests"},
+		{Content: "Fix parser", Status: session.TodoStatusPending},
+	}
+	metadata, err := json.Marshal(tools.TodosResponseMetadata{Todos: agentTodos})
+	require.NoError(t, err)
+	history := []message.Message{{
+		Role:  message.Tool,
+		Parts: []message.ContentPart{message.ToolResult{Name: tools.TodosToolName, Metadata: string(metadata)}},
+	}}
+
+	// 会话中的列表与模型最后写入的列表相同
+	_, ok := userTodosMessage(history, session.Session{Todos: agentTodos})
+	require.False(t, ok)
+	_, ok = userTodosMessage(nil, session.Session{})
+	require.False(t, ok)
+
+	// 用户调换了顺序并勾选了一项
+	edited := []session.Todo{
+		{Content: "Fix parser", Status: session.TodoStatusCompleted},
+		agentTodos[0],
+	}
+	msg, ok := userTodosMessage(history, session.Session{Todos: edited})
+	require.True(t, ok)
+	text := msg.Content[0].(fantasy.TextPart).Text
+	require.Contains(t, text, "The user edited the todo list")
+	require.Contains(t, text, "1. [completed] Fix parser\n2. [in_progress] Write tests\n")
+
+	// 用户在模型从未使用 todos 工具的会话中添加了待办事项
+	_, ok = userTodosMessage(nil, session.Session{Todos: edited})
+	require.True(t, ok)
+	// 摘要之后历史中没有 todos 调用，不能判断是否被修改
+	_, ok = userTodosMessage(nil, session.Session{Todos: edited, SummaryMessageID: "summary"})
+	require.False(t, ok)
+
+	msg, ok = userTodosMessage(history, session.Session{})
+	require.True(t, ok)
+	require.Contains(t, msg.Content[0].(fantasy.TextPart).Text, "The user cleared the todo list")
+}
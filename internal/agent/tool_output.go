@@ -0,0 +1,58 @@
+package agent
+
+import (
+	"context"
+	"log/slog"
+
+	"charm.land/fantasy"
+	"github.com/purpose168/crush-cn/internal/agent/tools"
+	"github.com/purpose168/crush-cn/internal/tooloutput"
+)
+
+// outputLimitedTool 包装工具，在结果交给模型和写入会话前按策略截断过长的文本输出，
+// 并将完整输出保存到溢出文件。
+type outputLimitedTool struct {
+	fantasy.AgentTool
+	policy tooloutput.Policy
+	dir    string
+}
+
+// limitToolOutputs 为每个工具应用配置的输出截断策略
+func (c *coordinator) limitToolOutputs(agentTools []fantasy.AgentTool) []fantasy.AgentTool {
+	output := c.cfg.Tools.Output
+	limited := make([]fantasy.AgentTool, 0, len(agentTools))
+	for _, tool := range agentTools {
+		policy := tooloutput.NewPolicy(output.Limit(tool.Info().Name))
+		if policy.MaxBytes <= 0 {
+			limited = append(limited, tool)
+			continue
+		}
+		limited = append(limited, &outputLimitedTool{
+			AgentTool: tool,
+			policy:    policy,
+			dir:       c.toolOutputDir(),
+		})
+	}
+	return limited
+}
+
+// Run 实现 [fantasy.AgentTool]
+func (t *outputLimitedTool) Run(ctx context.Context, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+	resp, err := t.AgentTool.Run(ctx, call)
+	if err != nil || resp.Type != "text" {
+		return resp, err
+	}
+	content, truncated := t.policy.Truncate(resp.Content)
+	if !truncated {
+		return resp, nil
+	}
+
+	path, saveErr := tooloutput.Save(t.dir, tools.GetSessionFromContext(ctx), call.ID, resp.Content)
+	if saveErr != nil {
+		slog.Error("Failed to save full tool output", "tool", call.Name, "error", saveErr)
+	} else {
+		content += tooloutput.Notice(path, len(resp.Content))
+	}
+	resp.Content = content
+	return resp, nil
+}
@@ -0,0 +1,44 @@
+package agent
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"charm.land/fantasy"
+	"github.com/purpose168/crush-cn/internal/agent/tools"
+	"github.com/purpose168/crush-cn/internal/tooloutput"
+	"github.com/stretchr/testify/require"
+)
+
+type echoParams struct {
+	Text string `json:"text"`
+}
+
+func TestOutputLimitedTool(t *testing.T) {
+	t.Parallel()
+
+	output := strings.Repeat("line\n", 100)
+	echo := fantasy.NewAgentTool("echo", "echo", func(ctx context.Context, params echoParams, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+		return fantasy.NewTextResponse(output), nil
+	})
+	dir := t.TempDir()
+	tool := &outputLimitedTool{
+		AgentTool: echo,
+		policy:    tooloutput.Policy{Mode: tooloutput.ModeHead, MaxBytes: 50},
+		dir:       dir,
+	}
+
+	ctx := context.WithValue(t.Context(), tools.SessionIDContextKey, "s1")
+	resp, err := tool.Run(ctx, fantasy.ToolCall{ID: "call-1", Name: "echo", Input: `{"text":""}`})
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(resp.Content, strings.Repeat("line\n", 10)))
+
+	path, ok := tooloutput.SpillPath(resp.Content)
+	require.True(t, ok)
+	require.Equal(t, tooloutput.Path(dir, "s1", "call-1"), path)
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, output, string(data))
+}
@@ -0,0 +1,189 @@
+package agent
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"slices"
+	"strings"
+
+	"charm.land/fantasy"
+)
+
+const (
+	// toolRepairMinOutputTokens 是修正工具参数时允许模型输出的最小令牌数，写文件等工具的参数可能很长。
+	toolRepairMinOutputTokens = 4096
+	// maxMalformedToolSteps 是模型连续只生成无效工具调用的最多步数，超过后结束运行并报告错误。
+	maxMalformedToolSteps = 3
+)
+
+//go:embed templates/tool_repair.md
+var toolRepairPrompt []byte
+
+// trailingCommaRegex 匹配对象或数组结尾前多余的逗号。
+var trailingCommaRegex = regexp.MustCompile(`,\s*([}\]])`)
+
+// toolRepairAskFunc 把修正请求发送给模型并返回模型的回复。
+type toolRepairAskFunc func(ctx context.Context, prompt string) (string, error)
+
+// repairToolCallFunc 返回本次运行修正无效工具参数所用的函数。
+func (a *sessionAgent) repairToolCallFunc(largeModel Model) fantasy.RepairToolCallFunction {
+	attempts := a.toolRepair.Attempts()
+	model := largeModel
+	if a.toolRepair.UseSmallModel() {
+		model = a.smallModel.Get()
+	}
+	systemPromptPrefix := a.systemPromptPrefix.Get()
+	ask := func(ctx context.Context, prompt string) (string, error) {
+		return repairWithModel(ctx, model, systemPromptPrefix, prompt)
+	}
+	return func(ctx context.Context, opts fantasy.ToolCallRepairOptions) (*fantasy.ToolCallContent, error) {
+		return repairToolCall(ctx, opts, attempts, ask)
+	}
+}
+
+// repairToolCall 修正不是有效 JSON 的工具参数：先在本地去掉代码围栏、多余文字和结尾的逗号，
+// 仍然无效时最多请求模型 attempts 次，每次都附上上一次的解析错误。参数能够解析但缺少必填参数
+// 或工具不存在时不做修正，错误直接返回给代理。
+func repairToolCall(ctx context.Context, opts fantasy.ToolCallRepairOptions, attempts int, ask toolRepairAskFunc) (*fantasy.ToolCallContent, error) {
+	call := opts.OriginalToolCall
+	if !malformedToolInput(call.Input) {
+		return nil, opts.ValidationError
+	}
+	idx := slices.IndexFunc(opts.AvailableTools, func(tool fantasy.AgentTool) bool {
+		return tool.Info().Name == call.ToolName
+	})
+	if idx < 0 {
+		return nil, opts.ValidationError
+	}
+	info := opts.AvailableTools[idx].Info()
+
+	input := cleanToolInput(call.Input)
+	validationErr := validateToolInput(info, input)
+	for attempt := 0; validationErr != nil && attempt < attempts; attempt++ {
+		reply, err := ask(ctx, toolRepairRequest(info, call.Input, validationErr))
+		if err != nil {
+			return nil, err
+		}
+		input = cleanToolInput(reply)
+		validationErr = validateToolInput(info, input)
+	}
+	if validationErr != nil {
+		slog.Warn("Failed to repair malformed tool call", "tool", call.ToolName, "attempts", attempts, "error", validationErr)
+		return nil, validationErr
+	}
+	slog.Info("Repaired malformed tool call", "tool", call.ToolName)
+	call.Input = input
+	return &call, nil
+}
+
+// malformedToolInput 报告工具参数是否不是 JSON 对象。
+func malformedToolInput(input string) bool {
+	var args map[string]any
+	return json.Unmarshal([]byte(input), &args) != nil
+}
+
+// cleanToolInput 修正工具参数中常见的格式问题：空参数、代码围栏、对象前后的文字和结尾多余的逗号。
+func cleanToolInput(input string) string {
+	input = strings.TrimSpace(thinkTagRegex.ReplaceAllString(input, ""))
+	if input == "" {
+		return "{}"
+	}
+	if start, end := strings.IndexByte(input, '{'), strings.LastIndexByte(input, '}'); start >= 0 && end > start {
+		input = input[start : end+1]
+	}
+	if !json.Valid([]byte(input)) {
+		if fixed := trailingCommaRegex.ReplaceAllString(input, "$1"); json.Valid([]byte(fixed)) {
+			input = fixed
+		}
+	}
+	return input
+}
+
+// validateToolInput 按照 fantasy 的规则校验工具参数：必须是 JSON 对象且包含所有必填参数。
+func validateToolInput(info fantasy.ToolInfo, input string) error {
+	var args map[string]any
+	if err := json.Unmarshal([]byte(input), &args); err != nil {
+		return fmt.Errorf("invalid JSON input: %w", err)
+	}
+	for _, required := range info.Required {
+		if _, ok := args[required]; !ok {
+			return fmt.Errorf("missing required parameter: %s", required)
+		}
+	}
+	return nil
+}
+
+// toolRepairRequest 返回请求模型修正工具参数的提示，包含参数结构、原始参数和解析错误。
+func toolRepairRequest(info fantasy.ToolInfo, input string, validationErr error) string {
+	schema, _ := json.MarshalIndent(map[string]any{
+		"type":       "object",
+		"properties": info.Parameters,
+		"required":   info.Required,
+	}, "", "  ")
+	return fmt.Sprintf(
+		"Tool: %s\n\nParameter schema:\n%s\n\nOriginal arguments:\n%s\n\nError: %s\n\nReply with the corrected arguments.",
+		info.Name, schema, input, validationErr,
+	)
+}
+
+// repairWithModel 使用给定模型修正工具参数。
+func repairWithModel(ctx context.Context, model Model, systemPromptPrefix, prompt string) (string, error) {
+	agent := fantasy.NewAgent(model.Model,
+		fantasy.WithSystemPrompt(string(toolRepairPrompt)+"\n /no_think"),
+		fantasy.WithMaxOutputTokens(max(toolRepairMinOutputTokens, model.CatwalkCfg.DefaultMaxTokens)),
+	)
+	resp, err := agent.Generate(ctx, fantasy.AgentCall{
+		Prompt: prompt,
+		PrepareStep: func(callCtx context.Context, opts fantasy.PrepareStepFunctionOptions) (_ context.Context, prepared fantasy.PrepareStepResult, err error) {
+			prepared.Messages = opts.Messages
+			if systemPromptPrefix != "" {
+				prepared.Messages = append([]fantasy.Message{
+					fantasy.NewSystemMessage(systemPromptPrefix),
+				}, prepared.Messages...)
+			}
+			return callCtx, prepared, nil
+		},
+	})
+	if err != nil {
+		return "", err
+	}
+	return resp.Response.Content.Text(), nil
+}
+
+// sanitizeToolCallInputs 把无法修正的工具参数替换为空对象。提供者在转换消息时会丢弃参数不是
+// JSON 对象的工具调用，但保留对应的工具结果，请求会因此失败；替换后模型仍能从工具结果中看到解析错误。
+func sanitizeToolCallInputs(messages []fantasy.Message) []fantasy.Message {
+	for i, msg := range messages {
+		if msg.Role != fantasy.MessageRoleAssistant {
+			continue
+		}
+		var content []fantasy.MessagePart
+		for j, part := range msg.Content {
+			toolCall, ok := fantasy.AsMessagePart[fantasy.ToolCallPart](part)
+			if !ok || toolCall.ProviderExecuted || !malformedToolInput(toolCall.Input) {
+				continue
+			}
+			if content == nil {
+				content = slices.Clone(msg.Content)
+			}
+			toolCall.Input = "{}"
+			content[j] = toolCall
+		}
+		if content != nil {
+			messages[i].Content = content
+		}
+	}
+	return messages
+}
+
+// malformedToolStep 报告一步中是否只有无效的工具调用。
+func malformedToolStep(step fantasy.StepResult) bool {
+	toolCalls := step.Content.ToolCalls()
+	return len(toolCalls) > 0 && !slices.ContainsFunc(toolCalls, func(tc fantasy.ToolCallContent) bool {
+		return !tc.Invalid
+	})
+}
@@ -0,0 +1,112 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"charm.land/fantasy"
+	"github.com/stretchr/testify/require"
+)
+
+type repairParams struct {
+	Path    string `json:"path"`
+	Content string `json:"content,omitempty"`
+}
+
+func repairOptions(input string) fantasy.ToolCallRepairOptions {
+	tool := fantasy.NewAgentTool("write", "write", func(_ context.Context, _ repairParams, _ fantasy.ToolCall) (fantasy.ToolResponse, error) {
+		return fantasy.NewTextResponse(""), nil
+	})
+	return fantasy.ToolCallRepairOptions{
+		OriginalToolCall: fantasy.ToolCallContent{ToolCallID: "call", ToolName: "write", Input: input},
+		ValidationError:  errors.New("invalid JSON input"),
+		AvailableTools:   []fantasy.AgentTool{tool},
+	}
+}
+
+func TestRepairToolCall(t *testing.T) {
+	t.Parallel()
+
+	noAsk := func(context.Context, string) (string, error) {
+		t.Fatal("不应该请求模型")
+		return "", nil
+	}
+
+	// 本地修正代码围栏和结尾的逗号
+	repaired, err := repairToolCall(t.Context(), repairOptions("```json\n{\"path\": \"a.go\",}\n```"), 2, noAsk)
+	require.NoError(t, err)
+	require.Equal(t, `{"path": "a.go"}`, repaired.Input)
+	require.Equal(t, "call", repaired.ToolCallID)
+
+	// 能够解析的参数不做修正，错误直接返回给代理
+	opts := repairOptions(`{"content": "x"}`)
+	_, err = repairToolCall(t.Context(), opts, 2, noAsk)
+	require.Equal(t, opts.ValidationError, err)
+
+	// 本地无法修正时请求模型，每次都附上上一次的错误
+	var prompts []string
+	replies := []string{`{"path": "a.go", "content": "x`, `{"path": "a.go", "content": "x\"y"}`}
+	ask := func(_ context.Context, prompt string) (string, error) {
+		prompts = append(prompts, prompt)
+		reply := replies[0]
+		replies = replies[1:]
+		return reply, nil
+	}
+	repaired, err = repairToolCall(t.Context(), repairOptions(`{"path": "a.go", "content": "x"y"}`), 2, ask)
+	require.NoError(t, err)
+	require.Equal(t, `{"path": "a.go", "content": "x\"y"}`, repaired.Input)
+	require.Len(t, prompts, 2)
+	require.Contains(t, prompts[0], "Tool: write")
+	require.Contains(t, prompts[0], `"content": "x"y"`)
+	require.Contains(t, prompts[1], "unexpected end of JSON input")
+
+	// 次数用尽后返回最后一次的错误
+	ask = func(context.Context, string) (string, error) {
+		return "not json", nil
+	}
+	_, err = repairToolCall(t.Context(), repairOptions(`{"path": `), 1, ask)
+	require.ErrorContains(t, err, "invalid JSON input")
+
+	// 空参数视为空对象，缺少必填参数时请求模型
+	ask = func(context.Context, string) (string, error) {
+		return `{"path": "b.go"}`, nil
+	}
+	repaired, err = repairToolCall(t.Context(), repairOptions(""), 1, ask)
+	require.NoError(t, err)
+	require.Equal(t, `{"path": "b.go"}`, repaired.Input)
+}
+
+func TestSanitizeToolCallInputs(t *testing.T) {
+	t.Parallel()
+
+	valid := fantasy.ToolCallPart{ToolCallID: "1", ToolName: "view", Input: `{"path":"a.go"}`}
+	invalid := fantasy.ToolCallPart{ToolCallID: "2", ToolName: "write", Input: `{"path":`}
+	original := []fantasy.MessagePart{fantasy.TextPart{Text: "ok"}, valid, invalid}
+	messages := sanitizeToolCallInputs([]fantasy.Message{
+		{Role: fantasy.MessageRoleUser, Content: []fantasy.MessagePart{fantasy.TextPart{Text: "{"}}},
+		{Role: fantasy.MessageRoleAssistant, Content: original},
+	})
+
+	require.Equal(t, valid, messages[1].Content[1])
+	sanitized, ok := fantasy.AsMessagePart[fantasy.ToolCallPart](messages[1].Content[2])
+	require.True(t, ok)
+	require.Equal(t, "{}", sanitized.Input)
+	// 原来的消息内容不被修改
+	require.Equal(t, invalid, original[2])
+}
+
+func TestMalformedToolStep(t *testing.T) {
+	t.Parallel()
+
+	step := func(calls ...fantasy.ToolCallContent) fantasy.StepResult {
+		var content fantasy.ResponseContent
+		for _, call := range calls {
+			content = append(content, call)
+		}
+		return fantasy.StepResult{Response: fantasy.Response{Content: content}}
+	}
+	require.False(t, malformedToolStep(step()))
+	require.True(t, malformedToolStep(step(fantasy.ToolCallContent{Invalid: true})))
+	require.False(t, malformedToolStep(step(fantasy.ToolCallContent{Invalid: true}, fantasy.ToolCallContent{})))
+}
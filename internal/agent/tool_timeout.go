@@ -0,0 +1,133 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"charm.land/fantasy"
+	"github.com/purpose168/crush-cn/internal/permission"
+)
+
+// errToolTimeout 是工具超过执行时限时取消上下文的原因
+var errToolTimeout = errors.New("tool timed out")
+
+// timeoutTool 包装工具，超过配置的执行时限后取消工具并返回错误结果。
+// 等待用户确认权限的时间不计入时限。
+type timeoutTool struct {
+	fantasy.AgentTool
+	timeout time.Duration
+}
+
+// applyToolTimeouts 为配置了执行时限的工具启用超时取消
+func (c *coordinator) applyToolTimeouts(agentTools []fantasy.AgentTool) []fantasy.AgentTool {
+	limited := make([]fantasy.AgentTool, 0, len(agentTools))
+	for _, tool := range agentTools {
+		timeout := c.cfg.Tools.Timeout(tool.Info().Name)
+		if timeout <= 0 {
+			limited = append(limited, tool)
+			continue
+		}
+		limited = append(limited, &timeoutTool{AgentTool: tool, timeout: timeout})
+	}
+	return limited
+}
+
+// Run 实现 [fantasy.AgentTool]
+func (t *timeoutTool) Run(ctx context.Context, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+	ctx, deadline := newToolDeadline(ctx, t.timeout)
+	defer deadline.stop()
+
+	type result struct {
+		resp fantasy.ToolResponse
+		err  error
+	}
+	done := make(chan result, 1)
+	go func() {
+		resp, err := t.AgentTool.Run(permission.WithPauser(ctx, deadline), call)
+		done <- result{resp, err}
+	}()
+
+	select {
+	case r := <-done:
+		if r.err != nil && errors.Is(context.Cause(ctx), errToolTimeout) {
+			return t.timeoutResponse(), nil
+		}
+		return r.resp, r.err
+	case <-ctx.Done():
+		if !errors.Is(context.Cause(ctx), errToolTimeout) {
+			// 外层取消：等待工具自行退出，保持与未设置时限时相同的行为
+			r := <-done
+			return r.resp, r.err
+		}
+		// 不等待忽略取消的工具，其结果会被丢弃
+		return t.timeoutResponse(), nil
+	}
+}
+
+func (t *timeoutTool) timeoutResponse() fantasy.ToolResponse {
+	return fantasy.NewTextErrorResponse(fmt.Sprintf("工具执行超过了 %s 的时限，已被取消。", t.timeout))
+}
+
+// toolDeadline 是可以暂停的执行时限，到期时以 errToolTimeout 取消上下文
+type toolDeadline struct {
+	mu        sync.Mutex
+	cancel    context.CancelCauseFunc
+	timer     *time.Timer
+	remaining time.Duration
+	started   time.Time
+	paused    int
+	expired   bool
+}
+
+func newToolDeadline(ctx context.Context, timeout time.Duration) (context.Context, *toolDeadline) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	d := &toolDeadline{cancel: cancel, remaining: timeout}
+	d.start()
+	return ctx, d
+}
+
+// start 在持有锁或初始化时调用，以剩余时间启动计时器
+func (d *toolDeadline) start() {
+	d.started = time.Now()
+	d.timer = time.AfterFunc(d.remaining, func() {
+		d.mu.Lock()
+		d.expired = true
+		d.mu.Unlock()
+		d.cancel(errToolTimeout)
+	})
+}
+
+// Pause 实现 [permission.Pauser]
+func (d *toolDeadline) Pause() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.paused++
+	if d.paused > 1 || d.expired {
+		return
+	}
+	if d.timer.Stop() {
+		d.remaining -= time.Since(d.started)
+	}
+}
+
+// Resume 实现 [permission.Pauser]
+func (d *toolDeadline) Resume() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.paused--
+	if d.paused > 0 || d.expired {
+		return
+	}
+	d.start()
+}
+
+// stop 释放计时器和上下文
+func (d *toolDeadline) stop() {
+	d.mu.Lock()
+	d.timer.Stop()
+	d.mu.Unlock()
+	d.cancel(nil)
+}
@@ -0,0 +1,100 @@
+package agent
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"charm.land/fantasy"
+	"github.com/purpose168/crush-cn/internal/permission"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTimeoutTool(t *testing.T) {
+	t.Parallel()
+
+	call := fantasy.ToolCall{ID: "call-1", Name: "slow", Input: `{"text":""}`}
+	newTool := func(run func(ctx context.Context) (fantasy.ToolResponse, error)) *timeoutTool {
+		return &timeoutTool{
+			AgentTool: fantasy.NewAgentTool("slow", "slow", func(ctx context.Context, _ echoParams, _ fantasy.ToolCall) (fantasy.ToolResponse, error) {
+				return run(ctx)
+			}),
+			timeout: 50 * time.Millisecond,
+		}
+	}
+
+	t.Run("fast tool", func(t *testing.T) {
+		t.Parallel()
+		tool := newTool(func(context.Context) (fantasy.ToolResponse, error) {
+			return fantasy.NewTextResponse("ok"), nil
+		})
+		resp, err := tool.Run(t.Context(), call)
+		require.NoError(t, err)
+		require.Equal(t, "ok", resp.Content)
+	})
+
+	t.Run("honors cancellation", func(t *testing.T) {
+		t.Parallel()
+		tool := newTool(func(ctx context.Context) (fantasy.ToolResponse, error) {
+			<-ctx.Done()
+			return fantasy.ToolResponse{}, ctx.Err()
+		})
+		resp, err := tool.Run(t.Context(), call)
+		require.NoError(t, err)
+		require.True(t, resp.IsError)
+		require.Contains(t, resp.Content, "50ms")
+	})
+
+	t.Run("ignores cancellation", func(t *testing.T) {
+		t.Parallel()
+		release := make(chan struct{})
+		t.Cleanup(func() { close(release) })
+		tool := newTool(func(context.Context) (fantasy.ToolResponse, error) {
+			<-release
+			return fantasy.NewTextResponse("late"), nil
+		})
+		resp, err := tool.Run(t.Context(), call)
+		require.NoError(t, err)
+		require.True(t, resp.IsError)
+	})
+
+	t.Run("parent canceled", func(t *testing.T) {
+		t.Parallel()
+		ctx, cancel := context.WithCancel(t.Context())
+		cancel()
+		tool := newTool(func(ctx context.Context) (fantasy.ToolResponse, error) {
+			<-ctx.Done()
+			return fantasy.ToolResponse{}, ctx.Err()
+		})
+		_, err := tool.Run(ctx, call)
+		require.ErrorIs(t, err, context.Canceled)
+	})
+
+	t.Run("permission wait is not counted", func(t *testing.T) {
+		t.Parallel()
+		permissions := permission.NewPermissionService(t.TempDir(), false, nil)
+		requests := permissions.Subscribe(t.Context())
+		go func() {
+			event := <-requests
+			time.Sleep(150 * time.Millisecond)
+			permissions.Grant(event.Payload)
+		}()
+
+		tool := newTool(func(ctx context.Context) (fantasy.ToolResponse, error) {
+			granted, err := permissions.Request(ctx, permission.CreatePermissionRequest{
+				SessionID: "s1",
+				ToolName:  "slow",
+				Action:    "execute",
+				Path:      t.TempDir(),
+			})
+			if err != nil {
+				return fantasy.ToolResponse{}, err
+			}
+			require.True(t, granted)
+			return fantasy.NewTextResponse("ok"), nil
+		})
+		resp, err := tool.Run(t.Context(), call)
+		require.NoError(t, err)
+		require.Equal(t, "ok", resp.Content)
+	})
+}
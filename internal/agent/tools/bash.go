@@ -7,6 +7,7 @@ import (
 	_ "embed"
 	"fmt"
 	"html/template"
+	"log/slog"
 	"os"
 	"path/filepath"
 	"runtime"
@@ -16,6 +17,9 @@ import (
 	"charm.land/fantasy"
 	"github.com/purpose168/crush-cn/internal/config"
 	"github.com/purpose168/crush-cn/internal/permission"
+	"github.com/purpose168/crush-cn/internal/pubsub"
+	"github.com/purpose168/crush-cn/internal/sandbox"
+	"github.com/purpose168/crush-cn/internal/session"
 	"github.com/purpose168/crush-cn/internal/shell"
 )
 
@@ -43,9 +47,29 @@ type BashResponseMetadata struct {
 	ShellID          string `json:"shell_id,omitempty"`
 }
 
+// BashOutput 是正在运行的 bash 命令的最近输出
+type BashOutput struct {
+	ToolCallID string `json:"tool_call_id"`
+	// Tail 是标准输出和标准错误按写入顺序交错的最后 BashOutputTailLines 行
+	Tail string `json:"tail"`
+}
+
+// bashOutput 发布正在运行的命令的实时输出
+var bashOutput = pubsub.NewBroker[BashOutput]()
+
+// SubscribeBashOutput 返回一个用于接收 bash 实时输出事件的通道
+func SubscribeBashOutput(ctx context.Context) <-chan pubsub.Event[BashOutput] {
+	return bashOutput.Subscribe(ctx)
+}
+
 const (
 	BashToolName = "bash"
 
+	// BashOutputTailLines 是实时输出中保留的最大行数
+	BashOutputTailLines = 10
+	// bashOutputInterval 是发布实时输出的最小间隔
+	bashOutputInterval = 250 * time.Millisecond
+
 	AutoBackgroundThreshold = 1 * time.Minute // 执行时间超过此阈值的命令会自动成为后台作业
 	MaxOutputLength         = 30000
 	BashNoOutput            = "no output"
@@ -64,6 +88,12 @@ type bashDescriptionData struct {
 	MaxOutputLength int
 	Attribution     config.Attribution
 	ModelName       string
+	// Author 是 "Name <email>" 格式的用户身份，尖括号不能被转义
+	Author     template.HTML
+	PowerShell bool
+	Remote     bool
+	// Sandbox 是执行命令的沙箱，命令直接在本机或远程主机上执行时为 nil
+	Sandbox *sandbox.Runner
 }
 
 var bannedCommands = []string{
@@ -77,12 +107,17 @@ var bannedCommands = []string{
 	"firefox",
 	"http-prompt",
 	"httpie",
+	"invoke-restmethod",
+	"invoke-webrequest",
+	"irm",
+	"iwr",
 	"links",
 	"lynx",
 	"nc",
 	"safari",
 	"scp",
 	"ssh",
+	"start-bitstransfer",
 	"telnet",
 	"w3m",
 	"wget",
@@ -139,7 +174,8 @@ var bannedCommands = []string{
 	"ufw",
 }
 
-func bashDescription(attribution *config.Attribution, modelName string) string {
+func bashDescription(attribution *config.Attribution, modelName, author string, shellType shell.ShellType, remote shell.Runner) string {
+	sb, sandboxed := remote.(*sandbox.Runner)
 	bannedCommandsStr := strings.Join(bannedCommands, ", ")
 	var out bytes.Buffer
 	if err := bashDescriptionTpl.Execute(&out, bashDescriptionData{
@@ -147,6 +183,10 @@ func bashDescription(attribution *config.Attribution, modelName string) string {
 		MaxOutputLength: MaxOutputLength,
 		Attribution:     *attribution,
 		ModelName:       modelName,
+		Author:          template.HTML(author),
+		PowerShell:      shellType == shell.ShellTypePowerShell,
+		Remote:          remote != nil && !sandboxed,
+		Sandbox:         sb,
 	}); err != nil {
 		// 这应该永远不会发生
 		panic("执行 bash 描述模板失败: " + err.Error())
@@ -186,17 +226,19 @@ func blockFuncs() []shell.BlockFunc {
 	}
 }
 
-func NewBashTool(permissions permission.Service, workingDir string, attribution *config.Attribution, modelName string) fantasy.AgentTool {
+// NewBashTool 创建 bash 工具。remote 不为 nil 时命令通过它在远程主机或沙箱中执行，远程开发时 workingDir 是远程目录。
+// author 是配置的用户身份，提交时作为 Requested-by 尾注。
+func NewBashTool(permissions permission.Service, sessions session.Service, workingDir string, attribution *config.Attribution, modelName, author string, shellType shell.ShellType, remote shell.Runner) fantasy.AgentTool {
 	return fantasy.NewAgentTool(
 		BashToolName,
-		string(bashDescription(attribution, modelName)),
+		string(bashDescription(attribution, modelName, author, shellType, remote)),
 		func(ctx context.Context, params BashParams, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
 			if params.Command == "" {
 				return fantasy.NewTextErrorResponse("缺少命令"), nil
 			}
 
-			// 确定工作目录
-			execWorkingDir := cmp.Or(params.WorkingDir, workingDir)
+			// 确定工作目录，未指定时使用会话的工作目录
+			execWorkingDir := cmp.Or(params.WorkingDir, sessionWorkingDir(ctx, workingDir))
 
 			isSafeReadOnly := false
 			cmdLower := strings.ToLower(params.Command)
@@ -234,13 +276,21 @@ func NewBashTool(permissions permission.Service, workingDir string, attribution
 				}
 			}
 
+			sessionEnv := loadSessionEnv(ctx, sessions, sessionID)
+
 			// 如果明确要求在后台运行，立即使用分离的上下文启动
 			if params.RunInBackground {
 				startTime := time.Now()
 				bgManager := shell.GetBackgroundShellManager()
 				bgManager.Cleanup()
 				// 使用后台上下文，以便在工具返回后继续运行
-				bgShell, err := bgManager.Start(context.Background(), execWorkingDir, blockFuncs(), params.Command, params.Description)
+				bgShell, err := bgManager.StartWithOptions(context.Background(), &shell.Options{
+					WorkingDir: execWorkingDir,
+					BlockFuncs: blockFuncs(),
+					Type:       shellType,
+					Remote:     remote,
+					ExtraEnv:   sessionEnv,
+				}, params.Command, params.Description)
 				if err != nil {
 					return fantasy.ToolResponse{}, fmt.Errorf("启动后台 shell 错误: %w", err)
 				}
@@ -295,7 +345,13 @@ func NewBashTool(permissions permission.Service, workingDir string, attribution
 			// 使用分离的上下文启动，以便在移至后台时能够继续运行
 			bgManager := shell.GetBackgroundShellManager()
 			bgManager.Cleanup()
-			bgShell, err := bgManager.Start(context.Background(), execWorkingDir, blockFuncs(), params.Command, params.Description)
+			bgShell, err := bgManager.StartWithOptions(context.Background(), &shell.Options{
+				WorkingDir: execWorkingDir,
+				BlockFuncs: blockFuncs(),
+				Type:       shellType,
+				Remote:     remote,
+				ExtraEnv:   sessionEnv,
+			}, params.Command, params.Description)
 			if err != nil {
 				return fantasy.ToolResponse{}, fmt.Errorf("启动 shell 错误: %w", err)
 			}
@@ -308,6 +364,9 @@ func NewBashTool(permissions permission.Service, workingDir string, attribution
 			var stdout, stderr string
 			var done bool
 			var execErr error
+			// 只在输出有变化时发布实时输出
+			var published time.Time
+			var publishedBytes int64
 
 		waitLoop:
 			for {
@@ -317,6 +376,12 @@ func NewBashTool(permissions permission.Service, workingDir string, attribution
 					if done {
 						break waitLoop
 					}
+					if now := time.Now(); now.Sub(published) >= bashOutputInterval {
+						if tail, written := bgShell.Tail(BashOutputTailLines); written != publishedBytes {
+							published, publishedBytes = now, written
+							bashOutput.Publish(pubsub.UpdatedEvent, BashOutput{ToolCallID: call.ID, Tail: tail})
+						}
+					}
 				case <-timeout:
 					stdout, stderr, done, execErr = bgShell.GetOutput()
 					break waitLoop
@@ -371,6 +436,19 @@ func NewBashTool(permissions permission.Service, workingDir string, attribution
 		})
 }
 
+// loadSessionEnv 返回会话中设置的环境变量，读取失败时记录日志并返回 nil
+func loadSessionEnv(ctx context.Context, sessions session.Service, sessionID string) map[string]string {
+	if sessions == nil {
+		return nil
+	}
+	sess, err := sessions.Get(ctx, sessionID)
+	if err != nil {
+		slog.Warn("读取会话环境变量失败", "session_id", sessionID, "error", err)
+		return nil
+	}
+	return sess.Env
+}
+
 // formatOutput 格式化已完成命令的输出，包含错误处理
 func formatOutput(stdout, stderr string, execErr error) string {
 	interrupted := shell.IsInterrupt(execErr)
@@ -1,10 +1,34 @@
 Executes bash commands with automatic background conversion for long-running tasks.
 
+{{ if .Remote -}}
+<remote>
+Commands run over SSH on a remote host with POSIX sh, starting in the remote project directory.
+Each command runs in a fresh shell: `cd` and exported variables do not persist between calls, so chain them in one command.
+Local environment variables and files outside the remote host are not available.
+</remote>
+{{- else if .Sandbox -}}
+<sandbox>
+Commands run with POSIX sh inside an isolated {{ .Sandbox.Engine }} sandbox, starting in the project directory.
+Only the project directory is writable; changes elsewhere (including /tmp) are discarded after each command.
+{{- if not .Sandbox.Network }}
+Network access is disabled: downloads and package installs fail, so work with what is already available.
+{{- end }}
+Each command runs in a fresh shell: `cd` and exported variables do not persist between calls, so chain them in one command.
+</sandbox>
+{{- else if .PowerShell -}}
+<cross_platform>
+Commands run in PowerShell (pwsh, or Windows PowerShell when pwsh is not installed), not bash.
+Write PowerShell syntax: `$env:NAME = 'value'`, `Get-ChildItem`, `Select-String`, `Remove-Item -Recurse -Force`.
+Common POSIX idioms are translated automatically: '&&' and '||', export/unset, rm -rf, mkdir -p, cp -r, ls -la, touch, which and redirects to /dev/null.
+Other bash-only syntax such as heredocs and [[ ]] tests is not supported.
+</cross_platform>
+{{- else -}}
 <cross_platform>
 Uses mvdan/sh interpreter (Bash-compatible on all platforms including Windows).
 Use forward slashes for paths: "ls C:/foo/bar" not "ls C:\foo\bar".
 Common shell builtins and core utils available on Windows.
 </cross_platform>
+{{- end }}
 
 <execution_steps>
 1. Directory Verification: If creating directories/files, use LS tool to verify parent exists
@@ -60,7 +84,7 @@ When user asks to create git commit:
    - Use clear language, accurate reflection ("add"=new feature, "update"=enhancement, "fix"=bug fix)
    - Avoid generic messages, review draft
 
-4. Create commit{{ if or (eq .Attribution.TrailerStyle "assisted-by") (eq .Attribution.TrailerStyle "co-authored-by")}} with attribution{{ end }} using HEREDOC:
+4. Create commit{{ if or (eq .Attribution.TrailerStyle "assisted-by") (eq .Attribution.TrailerStyle "co-authored-by") .Author }} with attribution{{ end }} using HEREDOC:
    git commit -m "$(cat <<'EOF'
    Commit message here.
 
@@ -73,6 +97,8 @@ When user asks to create git commit:
 {{ else if eq .Attribution.TrailerStyle "co-authored-by" }}
 
    Co-Authored-By: Crush <crush@charm.land>
+{{ end }}
+{{- if .Author }}   Requested-by: {{ .Author }}
 {{ end }}
 
    EOF
@@ -3,34 +3,66 @@ package tools
 import (
 	"cmp"
 	"context"
+	"crypto/sha256"
 	_ "embed"
+	"encoding/hex"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
 	"charm.land/fantasy"
 	"github.com/purpose168/crush-cn/internal/filepathext"
+	"github.com/purpose168/crush-cn/internal/network"
 	"github.com/purpose168/crush-cn/internal/permission"
+	"github.com/purpose168/crush-cn/internal/pubsub"
 )
 
 type DownloadParams struct {
 	URL      string `json:"url" description:"要下载的URL地址"`
 	FilePath string `json:"file_path" description:"下载内容应保存的本地文件路径"`
 	Timeout  int    `json:"timeout,omitempty" description:"可选的超时时间（秒），最大600秒"`
+	SHA256   string `json:"sha256,omitempty" description:"可选的SHA-256校验和（十六进制），下载完成后校验文件内容，不匹配时丢弃文件"`
+	Resume   bool   `json:"resume,omitempty" description:"从之前中断的下载留下的部分文件继续下载"`
 }
 
 type DownloadPermissionsParams struct {
 	URL      string `json:"url"`
 	FilePath string `json:"file_path"`
 	Timeout  int    `json:"timeout,omitempty"`
+	SHA256   string `json:"sha256,omitempty"`
+	Resume   bool   `json:"resume,omitempty"`
+}
+
+// DownloadProgress 描述正在进行的下载的进度
+type DownloadProgress struct {
+	ToolCallID string `json:"tool_call_id"`
+	// Downloaded 是已写入文件的字节数，包括续传前已有的部分
+	Downloaded int64 `json:"downloaded"`
+	// Total 是文件的总字节数，服务器没有提供长度时为 0
+	Total int64 `json:"total"`
 }
 
 const DownloadToolName = "download"
 
+const (
+	// partialSuffix 是下载过程中使用的临时文件后缀，下载中断时保留该文件以便续传
+	partialSuffix = ".part"
+	// progressInterval 是发布下载进度的最小间隔
+	progressInterval = 250 * time.Millisecond
+)
+
+var downloadProgress = pubsub.NewBroker[DownloadProgress]()
+
+// SubscribeDownloadProgress 返回一个用于接收下载进度事件的通道
+func SubscribeDownloadProgress(ctx context.Context) <-chan pubsub.Event[DownloadProgress] {
+	return downloadProgress.Subscribe(ctx)
+}
+
 //go:embed download.md
 var downloadDescription []byte
 
@@ -40,15 +72,7 @@ var downloadDescription []byte
 // client: HTTP客户端（如果为nil，将创建一个默认客户端）
 func NewDownloadTool(permissions permission.Service, workingDir string, client *http.Client) fantasy.AgentTool {
 	if client == nil {
-		transport := http.DefaultTransport.(*http.Transport).Clone()
-		transport.MaxIdleConns = 100
-		transport.MaxIdleConnsPerHost = 10
-		transport.IdleConnTimeout = 90 * time.Second
-
-		client = &http.Client{
-			Timeout:   5 * time.Minute, // 下载默认超时时间为5分钟
-			Transport: transport,
-		}
+		client = network.NewClient(5 * time.Minute) // 下载默认超时时间为5分钟
 	}
 	return fantasy.NewParallelAgentTool(
 		DownloadToolName,
@@ -66,6 +90,11 @@ func NewDownloadTool(permissions permission.Service, workingDir string, client *
 				return fantasy.NewTextErrorResponse("URL必须以http://或https://开头"), nil
 			}
 
+			params.SHA256 = strings.ToLower(strings.TrimSpace(params.SHA256))
+			if params.SHA256 != "" && !isSHA256(params.SHA256) {
+				return fantasy.NewTextErrorResponse("sha256必须是64个字符的十六进制字符串"), nil
+			}
+
 			filePath := filepathext.SmartJoin(workingDir, params.FilePath)
 			relPath, _ := filepath.Rel(workingDir, filePath)
 			relPath = filepath.ToSlash(cmp.Or(relPath, filePath))
@@ -104,12 +133,28 @@ func NewDownloadTool(permissions permission.Service, workingDir string, client *
 				defer cancel()
 			}
 
+			// 如果父目录不存在，则创建
+			if err := os.MkdirAll(filepath.Dir(filePath), 0o755); err != nil {
+				return fantasy.ToolResponse{}, fmt.Errorf("创建父目录失败: %w", err)
+			}
+
+			partPath := filePath + partialSuffix
+			var offset int64
+			if params.Resume {
+				if info, err := os.Stat(partPath); err == nil {
+					offset = info.Size()
+				}
+			}
+
 			req, err := http.NewRequestWithContext(requestCtx, "GET", params.URL, nil)
 			if err != nil {
 				return fantasy.ToolResponse{}, fmt.Errorf("创建请求失败: %w", err)
 			}
 
 			req.Header.Set("User-Agent", "crush/1.0")
+			if offset > 0 {
+				req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+			}
 
 			resp, err := client.Do(req)
 			if err != nil {
@@ -117,35 +162,152 @@ func NewDownloadTool(permissions permission.Service, workingDir string, client *
 			}
 			defer resp.Body.Close()
 
-			if resp.StatusCode != http.StatusOK {
+			var total int64
+			switch {
+			case offset > 0 && resp.StatusCode == http.StatusPartialContent:
+				start, size, ok := parseContentRange(resp.Header.Get("Content-Range"))
+				if !ok || start != offset {
+					return fantasy.NewTextErrorResponse(fmt.Sprintf("服务器返回了无效的续传范围: %s", resp.Header.Get("Content-Range"))), nil
+				}
+				total = size
+			case offset > 0 && resp.StatusCode == http.StatusRequestedRangeNotSatisfiable:
+				// 部分文件可能已经完整，只需要校验并移动到目标路径
+				if _, size, ok := parseContentRange(resp.Header.Get("Content-Range")); !ok || size != offset {
+					return fantasy.NewTextErrorResponse("服务器无法从部分文件的位置继续下载，请不使用resume重新下载"), nil
+				}
+				total = offset
+				resp.Body = http.NoBody
+			case resp.StatusCode == http.StatusOK:
+				// 服务器不支持范围请求时从头开始下载
+				offset = 0
+				total = max(0, resp.ContentLength)
+			default:
 				return fantasy.NewTextErrorResponse(fmt.Sprintf("请求失败，状态码: %d", resp.StatusCode)), nil
 			}
 
-			// 如果父目录不存在，则创建
-			if err := os.MkdirAll(filepath.Dir(filePath), 0o755); err != nil {
-				return fantasy.ToolResponse{}, fmt.Errorf("创建父目录失败: %w", err)
+			flags := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+			if offset > 0 {
+				flags = os.O_CREATE | os.O_WRONLY | os.O_APPEND
 			}
-
-			// 创建输出文件
-			outFile, err := os.Create(filePath)
+			outFile, err := os.OpenFile(partPath, flags, 0o644)
 			if err != nil {
 				return fantasy.ToolResponse{}, fmt.Errorf("创建输出文件失败: %w", err)
 			}
 			defer outFile.Close()
 
+			hash := sha256.New()
+			if offset > 0 && params.SHA256 != "" {
+				if err := hashFile(hash, partPath); err != nil {
+					return fantasy.ToolResponse{}, fmt.Errorf("读取部分文件失败: %w", err)
+				}
+			}
+
+			progress := &progressWriter{
+				progress: DownloadProgress{ToolCallID: call.ID, Downloaded: offset, Total: total},
+			}
+
 			// 复制数据，不设置显式大小限制
 			// 整体下载仍然受到HTTP客户端超时和上游服务器限制的约束
-			bytesWritten, err := io.Copy(outFile, resp.Body)
+			bytesWritten, err := io.Copy(io.MultiWriter(outFile, hash, progress), resp.Body)
 			if err != nil {
+				return fantasy.NewTextErrorResponse(fmt.Sprintf(
+					"下载中断: %s\n已下载的 %d 字节保存在 %s，可以使用resume参数继续下载",
+					err, offset+bytesWritten, relPath+partialSuffix,
+				)), nil
+			}
+			if err := outFile.Close(); err != nil {
 				return fantasy.ToolResponse{}, fmt.Errorf("写入文件失败: %w", err)
 			}
 
+			if params.SHA256 != "" {
+				if actual := hex.EncodeToString(hash.Sum(nil)); actual != params.SHA256 {
+					_ = os.Remove(partPath)
+					return fantasy.NewTextErrorResponse(fmt.Sprintf("SHA-256校验失败: 期望 %s，实际 %s。已删除下载的文件", params.SHA256, actual)), nil
+				}
+			}
+
+			if err := os.Rename(partPath, filePath); err != nil {
+				return fantasy.ToolResponse{}, fmt.Errorf("移动下载的文件失败: %w", err)
+			}
+
 			contentType := resp.Header.Get("Content-Type")
-			responseMsg := fmt.Sprintf("成功下载 %d 字节到 %s", bytesWritten, relPath)
+			responseMsg := fmt.Sprintf("成功下载 %d 字节到 %s", offset+bytesWritten, relPath)
+			if offset > 0 {
+				responseMsg += fmt.Sprintf("（从第 %d 字节继续下载）", offset)
+			}
 			if contentType != "" {
 				responseMsg += fmt.Sprintf(" (Content-Type: %s)", contentType)
 			}
+			if params.SHA256 != "" {
+				responseMsg += "\nSHA-256校验通过"
+			}
 
 			return fantasy.NewTextResponse(responseMsg), nil
 		})
 }
+
+// progressWriter 统计写入的字节数，并按固定间隔发布下载进度
+type progressWriter struct {
+	progress  DownloadProgress
+	published time.Time
+}
+
+func (w *progressWriter) Write(p []byte) (int, error) {
+	w.progress.Downloaded += int64(len(p))
+	if now := time.Now(); now.Sub(w.published) >= progressInterval {
+		w.published = now
+		downloadProgress.Publish(pubsub.UpdatedEvent, w.progress)
+	}
+	return len(p), nil
+}
+
+// parseContentRange 解析 "bytes 起始-结束/总长度" 或 "bytes */总长度" 格式的 Content-Range 头，
+// 返回起始位置和总长度。总长度未知（"*"）时返回 0
+func parseContentRange(header string) (start, total int64, ok bool) {
+	spec, found := strings.CutPrefix(strings.TrimSpace(header), "bytes ")
+	if !found {
+		return 0, 0, false
+	}
+	rng, size, found := strings.Cut(spec, "/")
+	if !found {
+		return 0, 0, false
+	}
+	if size != "*" {
+		var err error
+		if total, err = strconv.ParseInt(size, 10, 64); err != nil {
+			return 0, 0, false
+		}
+	}
+	if rng == "*" {
+		return 0, total, true
+	}
+	first, _, found := strings.Cut(rng, "-")
+	if !found {
+		return 0, 0, false
+	}
+	start, err := strconv.ParseInt(first, 10, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	return start, total, true
+}
+
+// hashFile 将文件内容写入哈希
+func hashFile(hash io.Writer, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = io.Copy(hash, f)
+	return err
+}
+
+// isSHA256 判断字符串是否是十六进制编码的SHA-256校验和
+func isSHA256(s string) bool {
+	if len(s) != sha256.Size*2 {
+		return false
+	}
+	_, err := hex.DecodeString(s)
+	return err == nil
+}
@@ -0,0 +1,90 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"charm.land/fantasy"
+	"github.com/purpose168/crush-cn/internal/permission"
+	"github.com/purpose168/crush-cn/internal/pubsub"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDownloadResumeAndChecksum(t *testing.T) {
+	t.Parallel()
+
+	content := bytes.Repeat([]byte("0123456789"), 1000)
+	sum := sha256.Sum256(content)
+	checksum := hex.EncodeToString(sum[:])
+
+	var ranges []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ranges = append(ranges, r.Header.Get("Range"))
+		http.ServeContent(w, r, "file.bin", time.Time{}, bytes.NewReader(content))
+	}))
+	t.Cleanup(srv.Close)
+
+	dir := t.TempDir()
+	permissions := &mockPermissionService{Broker: pubsub.NewBroker[permission.PermissionRequest]()}
+	tool := NewDownloadTool(permissions, dir, srv.Client())
+	ctx := context.WithValue(t.Context(), SessionIDContextKey, "s1")
+	run := func(params DownloadParams) fantasy.ToolResponse {
+		t.Helper()
+		input, err := json.Marshal(params)
+		require.NoError(t, err)
+		resp, err := tool.Run(ctx, fantasy.ToolCall{ID: "call", Name: DownloadToolName, Input: string(input)})
+		require.NoError(t, err)
+		return resp
+	}
+
+	// 从中断的下载留下的部分文件继续下载
+	target := filepath.Join(dir, "file.bin")
+	require.NoError(t, os.WriteFile(target+partialSuffix, content[:4000], 0o644))
+	resp := run(DownloadParams{URL: srv.URL, FilePath: "file.bin", SHA256: checksum, Resume: true})
+	require.False(t, resp.IsError, resp.Content)
+	require.Equal(t, []string{"bytes=4000-"}, ranges)
+	got, err := os.ReadFile(target)
+	require.NoError(t, err)
+	require.Equal(t, content, got)
+	require.NoFileExists(t, target+partialSuffix)
+
+	// 校验和不匹配时丢弃下载的文件
+	resp = run(DownloadParams{URL: srv.URL, FilePath: "other.bin", SHA256: checksum[:63] + "0"})
+	require.True(t, resp.IsError)
+	require.Contains(t, resp.Content, "SHA-256")
+	require.NoFileExists(t, filepath.Join(dir, "other.bin"))
+	require.NoFileExists(t, filepath.Join(dir, "other.bin"+partialSuffix))
+
+	resp = run(DownloadParams{URL: srv.URL, FilePath: "other.bin", SHA256: "not-a-checksum"})
+	require.True(t, resp.IsError)
+}
+
+func TestParseContentRange(t *testing.T) {
+	t.Parallel()
+
+	start, total, ok := parseContentRange("bytes 100-199/1000")
+	require.True(t, ok)
+	require.Equal(t, int64(100), start)
+	require.Equal(t, int64(1000), total)
+
+	start, total, ok = parseContentRange("bytes 5-9/*")
+	require.True(t, ok)
+	require.Equal(t, int64(5), start)
+	require.Zero(t, total)
+
+	_, total, ok = parseContentRange("bytes */1000")
+	require.True(t, ok)
+	require.Equal(t, int64(1000), total)
+
+	_, _, ok = parseContentRange("items 1-2/3")
+	require.False(t, ok)
+}
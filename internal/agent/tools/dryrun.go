@@ -0,0 +1,85 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"charm.land/fantasy"
+	"github.com/purpose168/crush-cn/internal/dryrun"
+	"github.com/purpose168/crush-cn/internal/filetracker"
+	"github.com/purpose168/crush-cn/internal/vfs"
+)
+
+// dryRunActive 报告是否处于演练模式。dr 为 nil 时视为关闭。
+func dryRunActive(dr dryrun.Service) bool {
+	return dr != nil && dr.Enabled()
+}
+
+// statFile 与 fsys.Stat 相同，但在演练模式下，只存在于补丁中的文件也视为存在。
+func statFile(ctx context.Context, fsys vfs.FS, dr dryrun.Service, path string) (fs.FileInfo, error) {
+	info, err := fsys.Stat(ctx, path)
+	if err == nil || !os.IsNotExist(err) || !dryRunActive(dr) {
+		return info, err
+	}
+	if content, ok := dr.Pending(GetSessionFromContext(ctx), path); ok {
+		return pendingFileInfo{name: filepath.Base(path), size: int64(len(content))}, nil
+	}
+	return info, err
+}
+
+// readFile 与 fsys.ReadFile 相同，但在演练模式下优先返回补丁中的预期内容，
+// 使同一文件的多次编辑依次叠加。
+func readFile(ctx context.Context, fsys vfs.FS, dr dryrun.Service, path string) ([]byte, error) {
+	if dryRunActive(dr) {
+		if content, ok := dr.Pending(GetSessionFromContext(ctx), path); ok {
+			return []byte(content), nil
+		}
+	}
+	return fsys.ReadFile(ctx, path)
+}
+
+// mkdirAll 与 fsys.MkdirAll 相同，演练模式下不创建任何目录。
+func mkdirAll(ctx context.Context, fsys vfs.FS, dr dryrun.Service, dir string) error {
+	if dryRunActive(dr) {
+		return nil
+	}
+	return fsys.MkdirAll(ctx, dir, 0o755)
+}
+
+// recordDryRun 将预期内容记录到补丁文件，返回告知模型的结果文本。
+func recordDryRun(ctx context.Context, dr dryrun.Service, path, content string) (string, error) {
+	patchPath, err := dr.Record(GetSessionFromContext(ctx), path, content)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("演练模式：未修改文件 %s。更改已写入补丁文件 %s，可使用 git apply 应用。", path, patchPath), nil
+}
+
+// pendingFileInfo 是只存在于演练补丁中的文件的 [fs.FileInfo]。
+type pendingFileInfo struct {
+	name string
+	size int64
+}
+
+func (i pendingFileInfo) Name() string       { return i.name }
+func (i pendingFileInfo) Size() int64        { return i.size }
+func (i pendingFileInfo) Mode() fs.FileMode  { return 0o644 }
+func (i pendingFileInfo) ModTime() time.Time { return time.Time{} }
+func (i pendingFileInfo) IsDir() bool        { return false }
+func (i pendingFileInfo) Sys() any           { return nil }
+
+// dryRunResponse 在演练模式下记录更改并返回带有差异元数据的工具响应，代替实际写入文件。
+// result 是正常写入时的结果文本，描述预期的更改。文件仍会被标记为已读取，
+// 以便后续编辑基于预期内容继续进行。
+func dryRunResponse(ctx context.Context, dr dryrun.Service, tracker filetracker.Service, path, content, result string, metadata any) (fantasy.ToolResponse, error) {
+	msg, err := recordDryRun(ctx, dr, path, content)
+	if err != nil {
+		return fantasy.ToolResponse{}, err
+	}
+	tracker.RecordRead(ctx, GetSessionFromContext(ctx), path)
+	return fantasy.WithResponseMetadata(fantasy.NewTextResponse(msg+"\n预期的更改："+result), metadata), nil
+}
@@ -0,0 +1,55 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/purpose168/crush-cn/internal/dryrun"
+	"github.com/purpose168/crush-cn/internal/vfs"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDryRunFileHelpers(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	ctx := context.WithValue(t.Context(), SessionIDContextKey, "s1")
+	dr := dryrun.NewService(dir, filepath.Join(dir, ".crush", dryrun.DirName), true, nil)
+
+	existing := filepath.Join(dir, "main.go")
+	require.NoError(t, os.WriteFile(existing, []byte("package main\n"), 0o644))
+	created := filepath.Join(dir, "sub", "new.txt")
+
+	_, err := statFile(ctx, vfs.Local{}, dr, created)
+	require.True(t, os.IsNotExist(err))
+
+	require.NoError(t, mkdirAll(ctx, vfs.Local{}, dr, filepath.Dir(created)))
+	_, err = recordDryRun(ctx, dr, created, "hello\n")
+	require.NoError(t, err)
+	_, err = recordDryRun(ctx, dr, existing, "package main\n\nfunc main() {}\n")
+	require.NoError(t, err)
+
+	// 演练不修改文件系统，但后续读取能看到预期内容。
+	require.NoDirExists(t, filepath.Dir(created))
+	data, err := os.ReadFile(existing)
+	require.NoError(t, err)
+	require.Equal(t, "package main\n", string(data))
+
+	info, err := statFile(ctx, vfs.Local{}, dr, created)
+	require.NoError(t, err)
+	require.Equal(t, int64(len("hello\n")), info.Size())
+	require.False(t, info.IsDir())
+
+	data, err = readFile(ctx, vfs.Local{}, dr, existing)
+	require.NoError(t, err)
+	require.Equal(t, "package main\n\nfunc main() {}\n", string(data))
+
+	// 关闭演练模式后恢复读取磁盘内容。
+	dr.SetEnabled(false)
+	data, err = readFile(ctx, vfs.Local{}, dr, existing)
+	require.NoError(t, err)
+	require.Equal(t, "package main\n", string(data))
+	require.FileExists(t, dr.PatchPath("s1"))
+}
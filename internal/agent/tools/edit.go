@@ -12,6 +12,7 @@ import (
 
 	"charm.land/fantasy"
 	"github.com/purpose168/crush-cn/internal/diff"
+	"github.com/purpose168/crush-cn/internal/dryrun"
 	"github.com/purpose168/crush-cn/internal/filepathext"
 	"github.com/purpose168/crush-cn/internal/filetracker"
 	"github.com/purpose168/crush-cn/internal/fsext"
@@ -19,6 +20,7 @@ import (
 
 	"github.com/purpose168/crush-cn/internal/lsp"
 	"github.com/purpose168/crush-cn/internal/permission"
+	"github.com/purpose168/crush-cn/internal/vfs"
 )
 
 type EditParams struct {
@@ -56,6 +58,8 @@ type editContext struct {
 	permissions permission.Service
 	files       history.Service
 	filetracker filetracker.Service
+	dryRun      dryrun.Service
+	fsys        vfs.FS
 	workingDir  string
 }
 
@@ -64,8 +68,11 @@ func NewEditTool(
 	permissions permission.Service,
 	files history.Service,
 	filetracker filetracker.Service,
+	dryRun dryrun.Service,
+	fsys vfs.FS,
 	workingDir string,
 ) fantasy.AgentTool {
+	fsys = vfs.OrLocal(fsys)
 	return fantasy.NewAgentTool(
 		EditToolName,
 		string(editDescription),
@@ -79,7 +86,7 @@ func NewEditTool(
 			var response fantasy.ToolResponse
 			var err error
 
-			editCtx := editContext{ctx, permissions, files, filetracker, workingDir}
+			editCtx := editContext{ctx, permissions, files, filetracker, dryRun, fsys, workingDir}
 
 			if params.OldString == "" {
 				response, err = createNewFile(editCtx, params.FilePath, params.NewString, call)
@@ -97,6 +104,11 @@ func NewEditTool(
 				// 这可以防止不必要的 LSP 诊断处理
 				return response, nil
 			}
+			if dryRunActive(dryRun) {
+				// 文件没有变化，无需通知 LSP
+				response.Content = fmt.Sprintf("<result>\n%s\n</result>\n", response.Content)
+				return response, nil
+			}
 
 			notifyLSPs(ctx, lspManager, params.FilePath)
 
@@ -108,7 +120,7 @@ func NewEditTool(
 }
 
 func createNewFile(edit editContext, filePath, content string, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
-	fileInfo, err := os.Stat(filePath)
+	fileInfo, err := statFile(edit.ctx, edit.fsys, edit.dryRun, filePath)
 	if err == nil {
 		if fileInfo.IsDir() {
 			return fantasy.NewTextErrorResponse(fmt.Sprintf("路径是目录，不是文件: %s", filePath)), nil
@@ -119,7 +131,7 @@ func createNewFile(edit editContext, filePath, content string, call fantasy.Tool
 	}
 
 	dir := filepath.Dir(filePath)
-	if err = os.MkdirAll(dir, 0o755); err != nil {
+	if err = mkdirAll(edit.ctx, edit.fsys, edit.dryRun, dir); err != nil {
 		return fantasy.ToolResponse{}, fmt.Errorf("创建父目录失败: %w", err)
 	}
 
@@ -155,7 +167,17 @@ func createNewFile(edit editContext, filePath, content string, call fantasy.Tool
 		return fantasy.ToolResponse{}, permission.ErrorPermissionDenied
 	}
 
-	err = os.WriteFile(filePath, []byte(content), 0o644)
+	metadata := EditResponseMetadata{
+		OldContent: "",
+		NewContent: content,
+		Additions:  additions,
+		Removals:   removals,
+	}
+	if dryRunActive(edit.dryRun) {
+		return dryRunResponse(edit.ctx, edit.dryRun, edit.filetracker, filePath, content, "文件已创建: "+filePath, metadata)
+	}
+
+	err = edit.fsys.WriteFile(edit.ctx, filePath, []byte(content), 0o644)
 	if err != nil {
 		return fantasy.ToolResponse{}, fmt.Errorf("写入文件失败: %w", err)
 	}
@@ -176,19 +198,11 @@ func createNewFile(edit editContext, filePath, content string, call fantasy.Tool
 
 	edit.filetracker.RecordRead(edit.ctx, sessionID, filePath)
 
-	return fantasy.WithResponseMetadata(
-		fantasy.NewTextResponse("文件已创建: "+filePath),
-		EditResponseMetadata{
-			OldContent: "",
-			NewContent: content,
-			Additions:  additions,
-			Removals:   removals,
-		},
-	), nil
+	return fantasy.WithResponseMetadata(fantasy.NewTextResponse("文件已创建: "+filePath), metadata), nil
 }
 
 func deleteContent(edit editContext, filePath, oldString string, replaceAll bool, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
-	fileInfo, err := os.Stat(filePath)
+	fileInfo, err := statFile(edit.ctx, edit.fsys, edit.dryRun, filePath)
 	if err != nil {
 		if os.IsNotExist(err) {
 			return fantasy.NewTextErrorResponse(fmt.Sprintf("文件未找到: %s", filePath)), nil
@@ -218,7 +232,7 @@ func deleteContent(edit editContext, filePath, oldString string, replaceAll bool
 			)), nil
 	}
 
-	content, err := os.ReadFile(filePath)
+	content, err := readFile(edit.ctx, edit.fsys, edit.dryRun, filePath)
 	if err != nil {
 		return fantasy.ToolResponse{}, fmt.Errorf("读取文件失败: %w", err)
 	}
@@ -278,7 +292,17 @@ func deleteContent(edit editContext, filePath, oldString string, replaceAll bool
 		newContent, _ = fsext.ToWindowsLineEndings(newContent)
 	}
 
-	err = os.WriteFile(filePath, []byte(newContent), 0o644)
+	metadata := EditResponseMetadata{
+		OldContent: oldContent,
+		NewContent: newContent,
+		Additions:  additions,
+		Removals:   removals,
+	}
+	if dryRunActive(edit.dryRun) {
+		return dryRunResponse(edit.ctx, edit.dryRun, edit.filetracker, filePath, newContent, "已从文件中删除内容: "+filePath, metadata)
+	}
+
+	err = edit.fsys.WriteFile(edit.ctx, filePath, []byte(newContent), 0o644)
 	if err != nil {
 		return fantasy.ToolResponse{}, fmt.Errorf("写入文件失败: %w", err)
 	}
@@ -307,19 +331,11 @@ func deleteContent(edit editContext, filePath, oldString string, replaceAll bool
 
 	edit.filetracker.RecordRead(edit.ctx, sessionID, filePath)
 
-	return fantasy.WithResponseMetadata(
-		fantasy.NewTextResponse("已从文件中删除内容: "+filePath),
-		EditResponseMetadata{
-			OldContent: oldContent,
-			NewContent: newContent,
-			Additions:  additions,
-			Removals:   removals,
-		},
-	), nil
+	return fantasy.WithResponseMetadata(fantasy.NewTextResponse("已从文件中删除内容: "+filePath), metadata), nil
 }
 
 func replaceContent(edit editContext, filePath, oldString, newString string, replaceAll bool, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
-	fileInfo, err := os.Stat(filePath)
+	fileInfo, err := statFile(edit.ctx, edit.fsys, edit.dryRun, filePath)
 	if err != nil {
 		if os.IsNotExist(err) {
 			return fantasy.NewTextErrorResponse(fmt.Sprintf("文件未找到: %s", filePath)), nil
@@ -349,7 +365,7 @@ func replaceContent(edit editContext, filePath, oldString, newString string, rep
 			)), nil
 	}
 
-	content, err := os.ReadFile(filePath)
+	content, err := readFile(edit.ctx, edit.fsys, edit.dryRun, filePath)
 	if err != nil {
 		return fantasy.ToolResponse{}, fmt.Errorf("读取文件失败: %w", err)
 	}
@@ -409,7 +425,17 @@ func replaceContent(edit editContext, filePath, oldString, newString string, rep
 		newContent, _ = fsext.ToWindowsLineEndings(newContent)
 	}
 
-	err = os.WriteFile(filePath, []byte(newContent), 0o644)
+	metadata := EditResponseMetadata{
+		OldContent: oldContent,
+		NewContent: newContent,
+		Additions:  additions,
+		Removals:   removals,
+	}
+	if dryRunActive(edit.dryRun) {
+		return dryRunResponse(edit.ctx, edit.dryRun, edit.filetracker, filePath, newContent, "已替换文件中的内容: "+filePath, metadata)
+	}
+
+	err = edit.fsys.WriteFile(edit.ctx, filePath, []byte(newContent), 0o644)
 	if err != nil {
 		return fantasy.ToolResponse{}, fmt.Errorf("写入文件失败: %w", err)
 	}
@@ -438,12 +464,5 @@ func replaceContent(edit editContext, filePath, oldString, newString string, rep
 
 	edit.filetracker.RecordRead(edit.ctx, sessionID, filePath)
 
-	return fantasy.WithResponseMetadata(
-		fantasy.NewTextResponse("已替换文件中的内容: "+filePath),
-		EditResponseMetadata{
-			OldContent: oldContent,
-			NewContent: newContent,
-			Additions:  additions,
-			Removals:   removals,
-		}), nil
+	return fantasy.WithResponseMetadata(fantasy.NewTextResponse("已替换文件中的内容: "+filePath), metadata), nil
 }
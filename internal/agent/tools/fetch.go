@@ -13,6 +13,7 @@ import (
 	"charm.land/fantasy"
 	md "github.com/JohannesKaufmann/html-to-markdown"
 	"github.com/PuerkitoBio/goquery"
+	"github.com/purpose168/crush-cn/internal/network"
 	"github.com/purpose168/crush-cn/internal/permission"
 )
 
@@ -27,15 +28,7 @@ var fetchDescription []byte
 // client: HTTP客户端（如果为nil，将创建一个默认客户端）
 func NewFetchTool(permissions permission.Service, workingDir string, client *http.Client) fantasy.AgentTool {
 	if client == nil {
-		transport := http.DefaultTransport.(*http.Transport).Clone()
-		transport.MaxIdleConns = 100
-		transport.MaxIdleConnsPerHost = 10
-		transport.IdleConnTimeout = 90 * time.Second
-
-		client = &http.Client{
-			Timeout:   30 * time.Second,
-			Transport: transport,
-		}
+		client = network.NewClient(30 * time.Second)
 	}
 
 	return fantasy.NewParallelAgentTool(
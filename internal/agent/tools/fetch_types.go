@@ -48,3 +48,14 @@ type FetchPermissionsParams struct {
 	Format  string `json:"format"`
 	Timeout int    `json:"timeout,omitempty"`
 }
+
+// WebSource 是网络工具抓取或搜索到的来源
+type WebSource struct {
+	Title string `json:"title,omitempty"`
+	URL   string `json:"url"`
+}
+
+// WebSourcesMetadata 是网络搜索和智能抓取工具响应的元数据，列出用到的来源
+type WebSourcesMetadata struct {
+	Sources []WebSource `json:"sources,omitempty"`
+}
@@ -0,0 +1,145 @@
+package tools
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/purpose168/crush-cn/internal/config"
+	"github.com/purpose168/crush-cn/internal/filepathext"
+)
+
+// gitTimeout 是单个 git 命令的最长执行时间
+const gitTimeout = 30 * time.Second
+
+// runGit 在 dir 中执行 git 命令并返回标准输出。stdin 非空时作为命令的标准输入。
+// 命令失败时返回 git 的错误输出。
+func runGit(ctx context.Context, dir, stdin string, args ...string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, gitTimeout)
+	defer cancel()
+
+	base := []string{"-C", dir, "--no-pager", "-c", "color.ui=false", "-c", "core.quotepath=false"}
+	cmd := exec.CommandContext(ctx, "git", append(base, args...)...)
+	if stdin != "" {
+		cmd.Stdin = strings.NewReader(stdin)
+	}
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if msg == "" {
+			msg = err.Error()
+		}
+		return stdout.String(), errors.New(msg)
+	}
+	return stdout.String(), nil
+}
+
+// gitWorktree 描述目录所在的 git 工作树
+type gitWorktree struct {
+	Root     string // 工作树根目录
+	MainRoot string // 主工作树根目录
+	Branch   string // 当前分支，分离 HEAD 时为空
+}
+
+// IsLinked 报告工作树是否是通过 git worktree add 创建的链接工作树
+func (w gitWorktree) IsLinked() bool {
+	return w.MainRoot != w.Root
+}
+
+// Describe 返回工作树的简短描述
+func (w gitWorktree) Describe() string {
+	branch := cmp.Or(w.Branch, "(分离 HEAD)")
+	if w.IsLinked() {
+		return fmt.Sprintf("%s [%s]（%s 的链接工作树）", w.Root, branch, w.MainRoot)
+	}
+	return fmt.Sprintf("%s [%s]", w.Root, branch)
+}
+
+// resolveGitWorktree 返回 path 所在的 git 工作树。path 为空时使用 workingDir。
+func resolveGitWorktree(ctx context.Context, workingDir, path string) (gitWorktree, error) {
+	dir := workingDir
+	if path != "" {
+		dir = filepathext.SmartJoin(workingDir, path)
+	}
+	out, err := runGit(ctx, dir, "", "rev-parse", "--path-format=absolute", "--show-toplevel", "--git-common-dir")
+	if err != nil {
+		return gitWorktree{}, fmt.Errorf("%s 不在 git 仓库中: %w", dir, err)
+	}
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	if len(lines) < 2 {
+		return gitWorktree{}, fmt.Errorf("无法解析 git 仓库信息: %q", out)
+	}
+
+	wt := gitWorktree{Root: lines[0], MainRoot: lines[0]}
+	if commonDir := lines[1]; filepath.Base(commonDir) == ".git" {
+		wt.MainRoot = filepath.Dir(commonDir)
+	}
+	branch, err := runGit(ctx, wt.Root, "", "branch", "--show-current")
+	if err == nil {
+		wt.Branch = strings.TrimSpace(branch)
+	}
+	return wt, nil
+}
+
+// gitPathspecs 将相对于 workingDir 的路径转换为绝对路径，作为 git 的路径参数
+func gitPathspecs(workingDir string, paths []string) []string {
+	specs := make([]string, 0, len(paths))
+	for _, p := range paths {
+		if p = strings.TrimSpace(p); p != "" {
+			specs = append(specs, filepathext.SmartJoin(workingDir, p))
+		}
+	}
+	return specs
+}
+
+// validGitRef 报告 ref 是否可以安全地作为修订参数传给 git，防止被解析为选项
+func validGitRef(ref string) bool {
+	return ref != "" && !strings.HasPrefix(ref, "-") && !strings.ContainsAny(ref, " \t\n")
+}
+
+// commitMessageWithAttribution 根据署名配置在提交信息末尾添加署名行和尾注。
+// author 不为空时添加 Requested-by 尾注，记录是谁让 Crush 做出的提交。
+// 提交信息中已包含相同尾注时不会重复添加。
+func commitMessageWithAttribution(message string, attribution *config.Attribution, modelName, author string) string {
+	message = strings.TrimSpace(message)
+
+	var sb strings.Builder
+	sb.WriteString(message)
+	if attribution != nil && attribution.GeneratedWith && !strings.Contains(message, "Generated with Crush") {
+		sb.WriteString("\n\n💘 Generated with Crush")
+	}
+	var trailers []string
+	if attribution != nil {
+		switch attribution.TrailerStyle {
+		case config.TrailerStyleAssistedBy:
+			if modelName != "" {
+				trailers = append(trailers, fmt.Sprintf("Assisted-by: %s via Crush <crush@charm.land>", modelName))
+			} else {
+				trailers = append(trailers, "Assisted-by: Crush <crush@charm.land>")
+			}
+		case config.TrailerStyleCoAuthoredBy:
+			trailers = append(trailers, "Co-Authored-By: Crush <crush@charm.land>")
+		}
+	}
+	if author != "" {
+		trailers = append(trailers, "Requested-by: "+author)
+	}
+	trailers = slices.DeleteFunc(trailers, func(trailer string) bool {
+		return strings.Contains(message, trailer)
+	})
+	if len(trailers) > 0 {
+		sb.WriteString("\n\n")
+		sb.WriteString(strings.Join(trailers, "\n"))
+	}
+	sb.WriteString("\n")
+	return sb.String()
+}
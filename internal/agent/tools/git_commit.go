@@ -0,0 +1,155 @@
+package tools
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+	"slices"
+	"strings"
+
+	"charm.land/fantasy"
+	"github.com/purpose168/crush-cn/internal/config"
+	"github.com/purpose168/crush-cn/internal/permission"
+)
+
+type GitCommitParams struct {
+	Message string   `json:"message" description:"提交信息。第一行是简短摘要，空一行后可以写详细说明"`
+	Files   []string `json:"files,omitempty" description:"提交前要暂存的文件。省略时仅提交已暂存的更改"`
+	All     bool     `json:"all,omitempty" description:"提交前暂存所有已跟踪文件的修改和删除（相当于 git commit -a）"`
+	Path    string   `json:"path,omitempty" description:"仓库或工作树中的目录（默认为当前工作目录）"`
+}
+
+type GitCommitPermissionsParams struct {
+	Worktree string   `json:"worktree"`
+	Branch   string   `json:"branch"`
+	Message  string   `json:"message"`
+	Files    []string `json:"files"`
+}
+
+type GitCommitResponseMetadata struct {
+	Hash    string   `json:"hash"`
+	Branch  string   `json:"branch"`
+	Message string   `json:"message"`
+	Files   []string `json:"files"`
+}
+
+const GitCommitToolName = "git_commit"
+
+//go:embed git_commit.md
+var gitCommitDescription []byte
+
+func NewGitCommitTool(permissions permission.Service, workingDir string, attribution *config.Attribution, modelName, author string) fantasy.AgentTool {
+	return fantasy.NewAgentTool(
+		GitCommitToolName,
+		string(gitCommitDescription),
+		func(ctx context.Context, params GitCommitParams, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+			if strings.TrimSpace(params.Message) == "" {
+				return fantasy.NewTextErrorResponse("缺少提交信息"), nil
+			}
+
+			sessionID := GetSessionFromContext(ctx)
+			if sessionID == "" {
+				return fantasy.ToolResponse{}, fmt.Errorf("需要 session_id")
+			}
+
+			wt, err := resolveGitWorktree(ctx, workingDir, params.Path)
+			if err != nil {
+				return fantasy.NewTextErrorResponse(err.Error()), nil
+			}
+
+			pathspecs := gitPathspecs(workingDir, params.Files)
+			files, err := gitCommitFiles(ctx, wt.Root, pathspecs, params.All)
+			if err != nil {
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("获取待提交文件失败: %s", err)), nil
+			}
+			if len(files) == 0 {
+				return fantasy.NewTextErrorResponse("没有可提交的更改，请先暂存文件或通过 files 指定要提交的文件"), nil
+			}
+
+			message := commitMessageWithAttribution(params.Message, attribution, modelName, author)
+			subject, _, _ := strings.Cut(message, "\n")
+
+			p, err := permissions.Request(ctx,
+				permission.CreatePermissionRequest{
+					SessionID:   sessionID,
+					Path:        wt.Root,
+					ToolCallID:  call.ID,
+					ToolName:    GitCommitToolName,
+					Action:      "commit",
+					Description: fmt.Sprintf("提交 %d 个文件: %s", len(files), subject),
+					Params: GitCommitPermissionsParams{
+						Worktree: wt.Root,
+						Branch:   wt.Branch,
+						Message:  message,
+						Files:    files,
+					},
+				},
+			)
+			if err != nil {
+				return fantasy.ToolResponse{}, err
+			}
+			if !p {
+				return fantasy.ToolResponse{}, permission.ErrorPermissionDenied
+			}
+
+			if len(pathspecs) > 0 {
+				if _, err := runGit(ctx, wt.Root, "", append([]string{"add", "--"}, pathspecs...)...); err != nil {
+					return fantasy.NewTextErrorResponse(fmt.Sprintf("暂存文件失败: %s", err)), nil
+				}
+			}
+			args := []string{"commit", "--file=-"}
+			if params.All {
+				args = append(args, "--all")
+			}
+			out, err := runGit(ctx, wt.Root, message, args...)
+			if err != nil {
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("提交失败: %s\n%s", err, out)), nil
+			}
+
+			hash, err := runGit(ctx, wt.Root, "", "rev-parse", "--short", "HEAD")
+			if err != nil {
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("获取提交哈希失败: %s", err)), nil
+			}
+			hash = strings.TrimSpace(hash)
+
+			return fantasy.WithResponseMetadata(
+				fantasy.NewTextResponse(fmt.Sprintf("Committed %s on %s\n\n%s", hash, wt.Describe(), strings.TrimSpace(out))),
+				GitCommitResponseMetadata{
+					Hash:    hash,
+					Branch:  wt.Branch,
+					Message: message,
+					Files:   files,
+				},
+			), nil
+		})
+}
+
+// gitCommitFiles 返回提交将包含的文件：已暂存的文件、要暂存的 pathspecs 中有更改的文件，
+// 以及 all 为 true 时所有已修改的已跟踪文件。
+func gitCommitFiles(ctx context.Context, root string, pathspecs []string, all bool) ([]string, error) {
+	queries := [][]string{{"diff", "--cached", "--name-only"}}
+	if len(pathspecs) > 0 {
+		queries = append(queries,
+			append([]string{"diff", "--name-only", "--"}, pathspecs...),
+			append([]string{"ls-files", "--others", "--exclude-standard", "--full-name", "--"}, pathspecs...),
+		)
+	}
+	if all {
+		queries = append(queries, []string{"diff", "--name-only"})
+	}
+
+	var files []string
+	for _, args := range queries {
+		out, err := runGit(ctx, root, "", args...)
+		if err != nil {
+			return nil, err
+		}
+		for line := range strings.SplitSeq(out, "\n") {
+			if line != "" && !slices.Contains(files, line) {
+				files = append(files, line)
+			}
+		}
+	}
+	slices.Sort(files)
+	return files, nil
+}
@@ -0,0 +1,156 @@
+package tools
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"charm.land/fantasy"
+)
+
+type GitDiffParams struct {
+	Path   string   `json:"path,omitempty" description:"仓库或工作树中的目录（默认为当前工作目录）"`
+	Staged bool     `json:"staged,omitempty" description:"显示已暂存（将被提交）的更改，而不是未暂存的更改"`
+	Ref    string   `json:"ref,omitempty" description:"将工作区与此提交、分支或标签进行比较（例如 HEAD、main、HEAD~3）"`
+	Files  []string `json:"files,omitempty" description:"仅显示这些文件或目录的差异"`
+}
+
+// GitDiffFile 是差异中单个文件的变更，内容用于在界面中渲染差异视图
+type GitDiffFile struct {
+	Path       string `json:"path"`
+	OldContent string `json:"old_content,omitempty"`
+	NewContent string `json:"new_content,omitempty"`
+	Additions  int    `json:"additions"`
+	Removals   int    `json:"removals"`
+	Binary     bool   `json:"binary,omitempty"`
+}
+
+type GitDiffResponseMetadata struct {
+	Root      string        `json:"root"`
+	Files     []GitDiffFile `json:"files"`
+	Additions int           `json:"additions"`
+	Removals  int           `json:"removals"`
+}
+
+const (
+	GitDiffToolName = "git_diff"
+
+	// gitDiffMaxRenderFiles 是元数据中包含完整内容的最大文件数
+	gitDiffMaxRenderFiles = 10
+	// gitDiffMaxFileSize 是元数据中包含完整内容的单个文件的最大字节数
+	gitDiffMaxFileSize = 64 * 1024
+)
+
+//go:embed git_diff.md
+var gitDiffDescription []byte
+
+func NewGitDiffTool(workingDir string) fantasy.AgentTool {
+	return fantasy.NewAgentTool(
+		GitDiffToolName,
+		string(gitDiffDescription),
+		func(ctx context.Context, params GitDiffParams, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+			if params.Staged && params.Ref != "" {
+				return fantasy.NewTextErrorResponse("staged 和 ref 不能同时使用"), nil
+			}
+			if params.Ref != "" && !validGitRef(params.Ref) {
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("无效的 ref: %q", params.Ref)), nil
+			}
+
+			wt, err := resolveGitWorktree(ctx, workingDir, params.Path)
+			if err != nil {
+				return fantasy.NewTextErrorResponse(err.Error()), nil
+			}
+
+			args := []string{"diff", "--no-ext-diff", "--no-renames"}
+			switch {
+			case params.Staged:
+				args = append(args, "--cached")
+			case params.Ref != "":
+				args = append(args, params.Ref)
+			}
+			pathspecs := append([]string{"--"}, gitPathspecs(workingDir, params.Files)...)
+
+			diff, err := runGit(ctx, wt.Root, "", append(args, pathspecs...)...)
+			if err != nil {
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("获取差异失败: %s", err)), nil
+			}
+			if strings.TrimSpace(diff) == "" {
+				return fantasy.NewTextResponse("No changes"), nil
+			}
+
+			numstat, err := runGit(ctx, wt.Root, "", append(append(args, "--numstat"), pathspecs...)...)
+			if err != nil {
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("获取差异统计失败: %s", err)), nil
+			}
+
+			meta := GitDiffResponseMetadata{Root: wt.Root}
+			for line := range strings.SplitSeq(strings.TrimSpace(numstat), "\n") {
+				file, ok := parseGitNumstat(line)
+				if !ok {
+					continue
+				}
+				if !file.Binary && len(meta.Files) < gitDiffMaxRenderFiles {
+					file.OldContent, file.NewContent = gitDiffContents(ctx, wt.Root, file.Path, params)
+				}
+				meta.Additions += file.Additions
+				meta.Removals += file.Removals
+				meta.Files = append(meta.Files, file)
+			}
+
+			return fantasy.WithResponseMetadata(fantasy.NewTextResponse(diff), meta), nil
+		})
+}
+
+// parseGitNumstat 解析 git diff --numstat 的一行输出
+func parseGitNumstat(line string) (GitDiffFile, bool) {
+	parts := strings.SplitN(line, "\t", 3)
+	if len(parts) != 3 {
+		return GitDiffFile{}, false
+	}
+	file := GitDiffFile{Path: parts[2]}
+	if parts[0] == "-" && parts[1] == "-" {
+		file.Binary = true
+		return file, true
+	}
+	file.Additions, _ = strconv.Atoi(parts[0])
+	file.Removals, _ = strconv.Atoi(parts[1])
+	return file, true
+}
+
+// gitDiffContents 返回文件在差异两侧的内容，过大或非文本的内容返回空字符串
+func gitDiffContents(ctx context.Context, root, path string, params GitDiffParams) (string, string) {
+	show := func(rev string) string {
+		out, err := runGit(ctx, root, "", "show", rev+":"+path)
+		if err != nil {
+			return ""
+		}
+		return out
+	}
+	worktree := func() string {
+		data, err := os.ReadFile(filepath.Join(root, path))
+		if err != nil {
+			return ""
+		}
+		return string(data)
+	}
+
+	var oldContent, newContent string
+	switch {
+	case params.Staged:
+		oldContent, newContent = show("HEAD"), show("")
+	case params.Ref != "":
+		oldContent, newContent = show(params.Ref), worktree()
+	default:
+		oldContent, newContent = show(""), worktree()
+	}
+	if len(oldContent) > gitDiffMaxFileSize || len(newContent) > gitDiffMaxFileSize ||
+		!utf8.ValidString(oldContent) || !utf8.ValidString(newContent) {
+		return "", ""
+	}
+	return oldContent, newContent
+}
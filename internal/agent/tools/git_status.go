@@ -0,0 +1,73 @@
+package tools
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+	"strings"
+
+	"charm.land/fantasy"
+)
+
+type GitStatusParams struct {
+	Path string `json:"path,omitempty" description:"仓库或工作树中的目录（默认为当前工作目录）"`
+}
+
+type GitStatusResponseMetadata struct {
+	Root     string `json:"root"`
+	MainRoot string `json:"main_root"`
+	Branch   string `json:"branch"`
+	Linked   bool   `json:"linked"`
+	Changes  int    `json:"changes"`
+}
+
+const GitStatusToolName = "git_status"
+
+//go:embed git_status.md
+var gitStatusDescription []byte
+
+func NewGitStatusTool(workingDir string) fantasy.AgentTool {
+	return fantasy.NewAgentTool(
+		GitStatusToolName,
+		string(gitStatusDescription),
+		func(ctx context.Context, params GitStatusParams, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+			wt, err := resolveGitWorktree(ctx, workingDir, params.Path)
+			if err != nil {
+				return fantasy.NewTextErrorResponse(err.Error()), nil
+			}
+
+			status, err := runGit(ctx, wt.Root, "", "status", "--short", "--branch", "--untracked-files=all")
+			if err != nil {
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("获取 git 状态失败: %s", err)), nil
+			}
+
+			var output strings.Builder
+			fmt.Fprintf(&output, "Worktree: %s\n", wt.Describe())
+			if worktrees, err := runGit(ctx, wt.Root, "", "worktree", "list"); err == nil && strings.Count(worktrees, "\n") > 1 {
+				output.WriteString("\nWorktrees:\n")
+				output.WriteString(worktrees)
+			}
+
+			// 第一行是分支摘要，其余每行是一个变更的文件
+			branchLine, changes, _ := strings.Cut(strings.TrimRight(status, "\n"), "\n")
+			fmt.Fprintf(&output, "\n%s\n", branchLine)
+			changeCount := 0
+			if changes == "" {
+				output.WriteString("\nWorking tree clean\n")
+			} else {
+				changeCount = strings.Count(changes, "\n") + 1
+				fmt.Fprintf(&output, "\n%s\n", changes)
+			}
+
+			return fantasy.WithResponseMetadata(
+				fantasy.NewTextResponse(output.String()),
+				GitStatusResponseMetadata{
+					Root:     wt.Root,
+					MainRoot: wt.MainRoot,
+					Branch:   wt.Branch,
+					Linked:   wt.IsLinked(),
+					Changes:  changeCount,
+				},
+			), nil
+		})
+}
@@ -0,0 +1,171 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"charm.land/fantasy"
+	"github.com/purpose168/crush-cn/internal/config"
+	"github.com/purpose168/crush-cn/internal/permission"
+	"github.com/purpose168/crush-cn/internal/pubsub"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCommitMessageWithAttribution(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		message     string
+		attribution *config.Attribution
+		author      string
+		want        string
+	}{
+		{
+			name:    "nil attribution",
+			message: "Fix bug\n",
+			want:    "Fix bug\n",
+		},
+		{
+			name:        "assisted-by with generated line",
+			message:     "Fix bug",
+			attribution: &config.Attribution{TrailerStyle: config.TrailerStyleAssistedBy, GeneratedWith: true},
+			want:        "Fix bug\n\n💘 Generated with Crush\n\nAssisted-by: Model X via Crush <crush@charm.land>\n",
+		},
+		{
+			name:        "co-authored-by",
+			message:     "Fix bug",
+			attribution: &config.Attribution{TrailerStyle: config.TrailerStyleCoAuthoredBy},
+			want:        "Fix bug\n\nCo-Authored-By: Crush <crush@charm.land>\n",
+		},
+		{
+			name:        "none",
+			message:     "Fix bug",
+			attribution: &config.Attribution{TrailerStyle: config.TrailerStyleNone},
+			want:        "Fix bug\n",
+		},
+		{
+			name:        "requested-by after assisted-by",
+			message:     "Fix bug",
+			attribution: &config.Attribution{TrailerStyle: config.TrailerStyleAssistedBy},
+			author:      "Ada Lovelace <ada@example.com>",
+			want:        "Fix bug\n\nAssisted-by: Model X via Crush <crush@charm.land>\nRequested-by: Ada Lovelace <ada@example.com>\n",
+		},
+		{
+			name:    "requested-by without attribution",
+			message: "Fix bug",
+			author:  "Ada Lovelace <ada@example.com>",
+			want:    "Fix bug\n\nRequested-by: Ada Lovelace <ada@example.com>\n",
+		},
+		{
+			name:        "trailer already present",
+			message:     "Fix bug\n\nCo-Authored-By: Crush <crush@charm.land>",
+			attribution: &config.Attribution{TrailerStyle: config.TrailerStyleCoAuthoredBy},
+			want:        "Fix bug\n\nCo-Authored-By: Crush <crush@charm.land>\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			require.Equal(t, tt.want, commitMessageWithAttribution(tt.message, tt.attribution, "Model X", tt.author))
+		})
+	}
+}
+
+func TestParseGitNumstat(t *testing.T) {
+	t.Parallel()
+
+	file, ok := parseGitNumstat("3\t1\tinternal/foo.go")
+	require.True(t, ok)
+	require.Equal(t, GitDiffFile{Path: "internal/foo.go", Additions: 3, Removals: 1}, file)
+
+	file, ok = parseGitNumstat("-\t-\timage.png")
+	require.True(t, ok)
+	require.True(t, file.Binary)
+
+	_, ok = parseGitNumstat("")
+	require.False(t, ok)
+}
+
+func TestGitTools(t *testing.T) {
+	t.Parallel()
+
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+
+	dir := t.TempDir()
+	gitCmd := func(args ...string) string {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+		cmd.Env = append(os.Environ(), "GIT_CONFIG_NOSYSTEM=1", "HOME="+dir)
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(out))
+		return string(out)
+	}
+	gitCmd("init", "-q", "-b", "main")
+	gitCmd("config", "user.name", "Test")
+	gitCmd("config", "user.email", "test@example.com")
+	gitCmd("config", "commit.gpgsign", "false")
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "a.txt"), []byte("one\n"), 0o644))
+	gitCmd("add", "a.txt")
+	gitCmd("commit", "-q", "-m", "initial")
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "a.txt"), []byte("one\ntwo\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "b.txt"), []byte("new\n"), 0o644))
+
+	ctx := context.WithValue(t.Context(), SessionIDContextKey, "s1")
+	run := func(tool fantasy.AgentTool, params any) fantasy.ToolResponse {
+		t.Helper()
+		input, err := json.Marshal(params)
+		require.NoError(t, err)
+		resp, err := tool.Run(ctx, fantasy.ToolCall{ID: "call", Name: tool.Info().Name, Input: string(input)})
+		require.NoError(t, err)
+		require.False(t, resp.IsError, resp.Content)
+		return resp
+	}
+
+	status := run(NewGitStatusTool(dir), GitStatusParams{})
+	require.Contains(t, status.Content, "## main")
+	require.Contains(t, status.Content, " M a.txt")
+	require.Contains(t, status.Content, "?? b.txt")
+	var statusMeta GitStatusResponseMetadata
+	require.NoError(t, json.Unmarshal([]byte(status.Metadata), &statusMeta))
+	require.Equal(t, "main", statusMeta.Branch)
+	require.Equal(t, 2, statusMeta.Changes)
+	require.False(t, statusMeta.Linked)
+
+	diff := run(NewGitDiffTool(dir), GitDiffParams{})
+	require.Contains(t, diff.Content, "+two")
+	var diffMeta GitDiffResponseMetadata
+	require.NoError(t, json.Unmarshal([]byte(diff.Metadata), &diffMeta))
+	require.Len(t, diffMeta.Files, 1)
+	require.Equal(t, GitDiffFile{Path: "a.txt", OldContent: "one\n", NewContent: "one\ntwo\n", Additions: 1}, diffMeta.Files[0])
+
+	permissions := &mockPermissionService{Broker: pubsub.NewBroker[permission.PermissionRequest]()}
+	attribution := &config.Attribution{TrailerStyle: config.TrailerStyleAssistedBy}
+	commit := run(NewGitCommitTool(permissions, dir, attribution, "Model X", ""), GitCommitParams{
+		Message: "Add b",
+		Files:   []string{"a.txt", "b.txt"},
+	})
+	var commitMeta GitCommitResponseMetadata
+	require.NoError(t, json.Unmarshal([]byte(commit.Metadata), &commitMeta))
+	require.Equal(t, []string{"a.txt", "b.txt"}, commitMeta.Files)
+
+	body := gitCmd("log", "-1", "--format=%B")
+	require.Equal(t, "Add b\n\nAssisted-by: Model X via Crush <crush@charm.land>", strings.TrimSpace(body))
+	require.Empty(t, strings.TrimSpace(gitCmd("status", "--porcelain")))
+
+	linked := filepath.Join(t.TempDir(), "linked")
+	gitCmd("worktree", "add", "-q", "-b", "feature", linked)
+	status = run(NewGitStatusTool(dir), GitStatusParams{Path: linked})
+	require.NoError(t, json.Unmarshal([]byte(status.Metadata), &statusMeta))
+	require.True(t, statusMeta.Linked)
+	require.Equal(t, "feature", statusMeta.Branch)
+	require.Contains(t, status.Content, "Worktrees:")
+}
@@ -41,7 +41,7 @@ func NewGlobTool(workingDir string) fantasy.AgentTool {
 
 			searchPath := params.Path
 			if searchPath == "" {
-				searchPath = workingDir
+				searchPath = sessionWorkingDir(ctx, workingDir)
 			}
 
 			files, truncated, err := globFiles(ctx, params.Pattern, searchPath, 100)
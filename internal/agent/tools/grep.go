@@ -129,7 +129,7 @@ func NewGrepTool(workingDir string) fantasy.AgentTool {
 
 			searchPath := params.Path
 			if searchPath == "" {
-				searchPath = workingDir
+				searchPath = sessionWorkingDir(ctx, workingDir)
 			}
 
 			matches, truncated, err := searchFiles(ctx, searchPattern, searchPath, params.Include, 100)
@@ -1,11 +1,14 @@
 package tools
 
 import (
+	"context"
+	"encoding/json"
 	"os"
 	"path/filepath"
 	"regexp"
 	"testing"
 
+	"charm.land/fantasy"
 	"github.com/stretchr/testify/require"
 )
 
@@ -121,6 +124,26 @@ func TestGrepWithIgnoreFiles(t *testing.T) {
 	}
 }
 
+func TestGrepDefaultsToSessionWorkingDir(t *testing.T) {
+	t.Parallel()
+	tempDir := t.TempDir()
+	for _, path := range []string{"root.txt", "packages/api/api.txt", "packages/web/web.txt"} {
+		fullPath := filepath.Join(tempDir, filepath.FromSlash(path))
+		require.NoError(t, os.MkdirAll(filepath.Dir(fullPath), 0o755))
+		require.NoError(t, os.WriteFile(fullPath, []byte("needle"), 0o644))
+	}
+
+	tool := NewGrepTool(tempDir)
+	input, err := json.Marshal(GrepParams{Pattern: "needle"})
+	require.NoError(t, err)
+	ctx := context.WithValue(t.Context(), WorkingDirContextKey, "packages/api")
+	resp, err := tool.Run(ctx, fantasy.ToolCall{ID: "call", Name: GrepToolName, Input: string(input)})
+	require.NoError(t, err)
+	require.Contains(t, resp.Content, "api.txt")
+	require.NotContains(t, resp.Content, "web.txt")
+	require.NotContains(t, resp.Content, "root.txt")
+}
+
 func TestSearchImplementations(t *testing.T) {
 	t.Parallel()
 	tempDir := t.TempDir()
@@ -0,0 +1,349 @@
+package tools
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"charm.land/fantasy"
+	"github.com/purpose168/crush-cn/internal/permission"
+)
+
+const IssueFetchToolName = "issue_fetch"
+
+//go:embed issue_fetch.md
+var issueFetchDescription []byte
+
+type IssueFetchParams struct {
+	Reference string `json:"reference" description:"议题或拉取请求的 URL，或当前仓库中的编号（如 #123）"`
+	Type      string `json:"type,omitempty" description:"当仅提供编号时的类型：issue 或 pr（默认 issue）"`
+}
+
+type IssueFetchPermissionsParams struct {
+	Reference string `json:"reference"`
+	Type      string `json:"type,omitempty"`
+}
+
+type IssueFetchResponseMetadata struct {
+	Platform string `json:"platform"`
+	URL      string `json:"url"`
+	Title    string `json:"title"`
+	Comments int    `json:"comments"`
+	HasDiff  bool   `json:"has_diff"`
+}
+
+// IssueFetchTokens 保存访问各平台 API 的令牌。
+type IssueFetchTokens struct {
+	GitHub string
+	GitLab string
+	// GitLabHosts 是除 gitlab.com 之外可以接收 GitLab 令牌的自托管实例。
+	GitLabHosts []string
+	AzureDevOps string
+}
+
+// issuePlatform 表示托管议题的平台。
+type issuePlatform string
+
+const (
+	platformGitHub      issuePlatform = "github"
+	platformGitLab      issuePlatform = "gitlab"
+	platformAzureDevOps issuePlatform = "azure_devops"
+)
+
+// issueRef 是解析后的议题或拉取请求引用。
+type issueRef struct {
+	platform issuePlatform
+	// host 是平台的主机名，用于自托管的 GitLab。
+	host string
+	// project 对于 GitHub 是 owner/repo，对于 GitLab 是完整的项目路径，
+	// 对于 Azure DevOps 是 organization/project。
+	project string
+	// repository 是 Azure DevOps 拉取请求所属的仓库。
+	repository string
+	number     int
+	isPR       bool
+}
+
+// issueComment 是议题或拉取请求上的单条评论。
+type issueComment struct {
+	Author  string
+	Created string
+	Body    string
+}
+
+// issueDetails 是从平台获取的议题或拉取请求的完整内容。
+type issueDetails struct {
+	Title    string
+	State    string
+	Author   string
+	URL      string
+	Labels   []string
+	Body     string
+	Comments []issueComment
+	Diff     string
+}
+
+// maxIssueDiffSize 是输出中包含的差异的最大字节数。
+const maxIssueDiffSize = 100 * 1024
+
+func NewIssueFetchTool(permissions permission.Service, workingDir string, tokens IssueFetchTokens, client *http.Client) fantasy.AgentTool {
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+	fetcher := &issueFetcher{
+		client:    stripTokenOnRedirect(client),
+		tokens:    tokens,
+		githubAPI: "https://api.github.com",
+	}
+
+	return fantasy.NewParallelAgentTool(
+		IssueFetchToolName,
+		string(issueFetchDescription),
+		func(ctx context.Context, params IssueFetchParams, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+			if strings.TrimSpace(params.Reference) == "" {
+				return fantasy.NewTextErrorResponse("reference 参数是必需的"), nil
+			}
+			if params.Type != "" && params.Type != "issue" && params.Type != "pr" {
+				return fantasy.NewTextErrorResponse("type 必须是 issue 或 pr"), nil
+			}
+
+			ref, err := parseIssueReference(params.Reference, params.Type == "pr", func() (string, error) {
+				return gitRemoteURL(ctx, workingDir)
+			})
+			if err != nil {
+				return fantasy.NewTextErrorResponse(err.Error()), nil
+			}
+
+			sessionID := GetSessionFromContext(ctx)
+			if sessionID == "" {
+				return fantasy.ToolResponse{}, fmt.Errorf("获取议题需要会话ID")
+			}
+
+			p, err := permissions.Request(ctx,
+				permission.CreatePermissionRequest{
+					SessionID:   sessionID,
+					Path:        workingDir,
+					ToolCallID:  call.ID,
+					ToolName:    IssueFetchToolName,
+					Action:      "fetch",
+					Description: fmt.Sprintf("从 %s 获取议题: %s", ref.platform, params.Reference),
+					Params:      IssueFetchPermissionsParams(params),
+				},
+			)
+			if err != nil {
+				return fantasy.ToolResponse{}, err
+			}
+			if !p {
+				return fantasy.ToolResponse{}, permission.ErrorPermissionDenied
+			}
+
+			details, err := fetcher.fetch(ctx, ref)
+			if err != nil {
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("获取议题失败: %v", err)), nil
+			}
+
+			return fantasy.WithResponseMetadata(
+				fantasy.NewTextResponse(details.markdown(ref)),
+				IssueFetchResponseMetadata{
+					Platform: string(ref.platform),
+					URL:      details.URL,
+					Title:    details.Title,
+					Comments: len(details.Comments),
+					HasDiff:  details.Diff != "",
+				},
+			), nil
+		})
+}
+
+// gitRemoteURL 返回工作目录中 origin 远程仓库的 URL。
+func gitRemoteURL(ctx context.Context, dir string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", "remote", "get-url", "origin")
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("无法确定当前仓库的远程地址，请提供完整的 URL")
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+var (
+	githubIssueRe = regexp.MustCompile(`^/([^/]+)/([^/]+)/(issues|pull)/(\d+)`)
+	gitlabIssueRe = regexp.MustCompile(`^/(.+?)/-/(issues|merge_requests)/(\d+)`)
+	azureItemRe   = regexp.MustCompile(`^/([^/]+)/([^/]+)/_workitems/edit/(\d+)`)
+	azurePRRe     = regexp.MustCompile(`^/([^/]+)/([^/]+)/_git/([^/]+)/pullrequest/(\d+)`)
+	// scpRemoteRe 匹配 git@host:path 形式的远程地址。
+	scpRemoteRe = regexp.MustCompile(`^[\w.-]+@([\w.-]+):(.+)$`)
+)
+
+// parseIssueReference 解析议题或拉取请求的 URL 或编号。当仅提供编号时，
+// 通过 remote 获取当前仓库的远程地址来确定平台和项目。
+func parseIssueReference(reference string, isPR bool, remote func() (string, error)) (issueRef, error) {
+	reference = strings.TrimSpace(reference)
+
+	if n, err := strconv.Atoi(strings.TrimPrefix(reference, "#")); err == nil {
+		remoteURL, err := remote()
+		if err != nil {
+			return issueRef{}, err
+		}
+		ref, err := parseRemoteURL(remoteURL)
+		if err != nil {
+			return issueRef{}, err
+		}
+		ref.number = n
+		ref.isPR = isPR
+		if ref.platform == platformAzureDevOps && isPR && ref.repository == "" {
+			return issueRef{}, fmt.Errorf("无法从远程地址确定 Azure DevOps 仓库，请提供完整的 URL")
+		}
+		return ref, nil
+	}
+
+	u, err := url.Parse(reference)
+	if err != nil || u.Host == "" {
+		return issueRef{}, fmt.Errorf("无效的议题引用: %s", reference)
+	}
+	host := strings.ToLower(u.Hostname())
+	path := strings.TrimSuffix(u.Path, "/")
+
+	switch {
+	case host == "github.com":
+		m := githubIssueRe.FindStringSubmatch(path)
+		if m == nil {
+			break
+		}
+		n, _ := strconv.Atoi(m[4])
+		return issueRef{platform: platformGitHub, host: host, project: m[1] + "/" + m[2], number: n, isPR: m[3] == "pull"}, nil
+	case host == "dev.azure.com" || strings.HasSuffix(host, ".visualstudio.com"):
+		if strings.HasSuffix(host, ".visualstudio.com") {
+			// 旧式 URL 将组织放在子域名中。
+			path = "/" + strings.TrimSuffix(host, ".visualstudio.com") + path
+		}
+		if m := azurePRRe.FindStringSubmatch(path); m != nil {
+			n, _ := strconv.Atoi(m[4])
+			return issueRef{platform: platformAzureDevOps, host: host, project: m[1] + "/" + m[2], repository: m[3], number: n, isPR: true}, nil
+		}
+		if m := azureItemRe.FindStringSubmatch(path); m != nil {
+			n, _ := strconv.Atoi(m[3])
+			return issueRef{platform: platformAzureDevOps, host: host, project: m[1] + "/" + m[2], number: n}, nil
+		}
+	default:
+		// GitLab 可能是自托管的，因此按路径格式识别。
+		if m := gitlabIssueRe.FindStringSubmatch(path); m != nil {
+			n, _ := strconv.Atoi(m[3])
+			return issueRef{platform: platformGitLab, host: u.Host, project: m[1], number: n, isPR: m[2] == "merge_requests"}, nil
+		}
+	}
+	return issueRef{}, fmt.Errorf("不支持的议题 URL: %s", reference)
+}
+
+// parseRemoteURL 从 git 远程地址中解析平台和项目。
+func parseRemoteURL(remote string) (issueRef, error) {
+	var host, path string
+	if m := scpRemoteRe.FindStringSubmatch(remote); m != nil {
+		host, path = m[1], m[2]
+	} else {
+		u, err := url.Parse(remote)
+		if err != nil || u.Host == "" {
+			return issueRef{}, fmt.Errorf("无法解析远程地址: %s", remote)
+		}
+		host, path = u.Host, u.Path
+		if u.Scheme == "ssh" {
+			// SSH 端口与平台 API 使用的 HTTPS 端口无关。
+			host = u.Hostname()
+		}
+	}
+	host = strings.ToLower(host)
+	path = strings.TrimSuffix(strings.Trim(path, "/"), ".git")
+	parts := strings.Split(path, "/")
+
+	switch {
+	case host == "github.com":
+		if len(parts) != 2 {
+			break
+		}
+		return issueRef{platform: platformGitHub, host: host, project: path}, nil
+	case host == "ssh.dev.azure.com":
+		// git@ssh.dev.azure.com:v3/org/project/repo
+		if len(parts) != 4 || parts[0] != "v3" {
+			break
+		}
+		return issueRef{platform: platformAzureDevOps, host: "dev.azure.com", project: parts[1] + "/" + parts[2], repository: parts[3]}, nil
+	case host == "dev.azure.com":
+		// https://dev.azure.com/org/project/_git/repo
+		if len(parts) != 4 || parts[2] != "_git" {
+			break
+		}
+		return issueRef{platform: platformAzureDevOps, host: host, project: parts[0] + "/" + parts[1], repository: parts[3]}, nil
+	case strings.Contains(host, "gitlab"):
+		if len(parts) < 2 {
+			break
+		}
+		return issueRef{platform: platformGitLab, host: host, project: path}, nil
+	}
+	return issueRef{}, fmt.Errorf("不支持的远程仓库: %s", remote)
+}
+
+// markdown 将议题内容渲染为结构化的 Markdown。
+func (d issueDetails) markdown(ref issueRef) string {
+	kind := "议题"
+	if ref.isPR {
+		kind = "拉取请求"
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "# %s\n\n", d.Title)
+	fmt.Fprintf(&sb, "- **来源:** %s %s %s#%d\n", ref.platform, kind, ref.project, ref.number)
+	if d.State != "" {
+		fmt.Fprintf(&sb, "- **状态:** %s\n", d.State)
+	}
+	if d.Author != "" {
+		fmt.Fprintf(&sb, "- **作者:** %s\n", d.Author)
+	}
+	if len(d.Labels) > 0 {
+		fmt.Fprintf(&sb, "- **标签:** %s\n", strings.Join(d.Labels, ", "))
+	}
+	if d.URL != "" {
+		fmt.Fprintf(&sb, "- **链接:** %s\n", d.URL)
+	}
+
+	sb.WriteString("\n## 描述\n\n")
+	if body := strings.TrimSpace(d.Body); body != "" {
+		sb.WriteString(body)
+	} else {
+		sb.WriteString("(无描述)")
+	}
+	sb.WriteString("\n")
+
+	if len(d.Comments) > 0 {
+		fmt.Fprintf(&sb, "\n## 评论 (%d)\n", len(d.Comments))
+		for _, c := range d.Comments {
+			fmt.Fprintf(&sb, "\n### %s", c.Author)
+			if c.Created != "" {
+				fmt.Fprintf(&sb, " (%s)", c.Created)
+			}
+			fmt.Fprintf(&sb, "\n\n%s\n", strings.TrimSpace(c.Body))
+		}
+	}
+
+	if d.Diff != "" {
+		diff := d.Diff
+		var truncated bool
+		if len(diff) > maxIssueDiffSize {
+			diff = diff[:maxIssueDiffSize]
+			truncated = true
+		}
+		sb.WriteString("\n## 差异\n\n```diff\n")
+		sb.WriteString(strings.TrimRight(diff, "\n"))
+		sb.WriteString("\n```\n")
+		if truncated {
+			fmt.Fprintf(&sb, "\n[差异已截断为 %d 字节]\n", maxIssueDiffSize)
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,413 @@
+package tools
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// maxIssueResponseSize 是单个 API 响应读取的最大字节数。
+const maxIssueResponseSize = 5 * 1024 * 1024
+
+// issueFetcher 通过各平台的 REST API 获取议题和拉取请求。
+type issueFetcher struct {
+	client *http.Client
+	tokens IssueFetchTokens
+	// githubAPI 是 GitHub API 的基础 URL，测试时可替换。
+	githubAPI string
+	// gitlabScheme 是访问 GitLab 实例使用的协议，测试时可替换。
+	gitlabScheme string
+	// azureAPI 是 Azure DevOps API 的基础 URL，测试时可替换。
+	azureAPI string
+}
+
+// stripTokenOnRedirect 返回 client 的副本，重定向到其他主机时删除 PRIVATE-TOKEN 头。
+// http.Client 只在跨主机重定向时删除 Authorization 等标准头，自定义头会被原样转发。
+func stripTokenOnRedirect(client *http.Client) *http.Client {
+	c := *client
+	next := client.CheckRedirect
+	c.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if req.URL.Host != via[0].URL.Host {
+			req.Header.Del("PRIVATE-TOKEN")
+		}
+		if next != nil {
+			return next(req, via)
+		}
+		if len(via) >= 10 {
+			return errors.New("重定向次数过多")
+		}
+		return nil
+	}
+	return &c
+}
+
+func (f *issueFetcher) fetch(ctx context.Context, ref issueRef) (issueDetails, error) {
+	switch ref.platform {
+	case platformGitHub:
+		return f.fetchGitHub(ctx, ref)
+	case platformGitLab:
+		return f.fetchGitLab(ctx, ref)
+	case platformAzureDevOps:
+		return f.fetchAzureDevOps(ctx, ref)
+	default:
+		return issueDetails{}, fmt.Errorf("不支持的平台: %s", ref.platform)
+	}
+}
+
+// get 发送 GET 请求并返回响应体。
+func (f *issueFetcher) get(ctx context.Context, rawURL string, headers map[string]string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("创建请求失败: %w", err)
+	}
+	req.Header.Set("User-Agent", "crush/1.0")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("请求失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxIssueResponseSize))
+	if err != nil {
+		return nil, fmt.Errorf("读取响应体失败: %w", err)
+	}
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return body, nil
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return nil, fmt.Errorf("访问被拒绝 (状态码 %d)，请检查 tools.issue_fetch 中配置的令牌", resp.StatusCode)
+	case http.StatusNotFound:
+		return nil, fmt.Errorf("未找到 (状态码 404)，私有仓库需要在 tools.issue_fetch 中配置令牌")
+	default:
+		return nil, fmt.Errorf("请求失败，状态码: %d", resp.StatusCode)
+	}
+}
+
+// getJSON 发送 GET 请求并将响应解码到 v。
+func (f *issueFetcher) getJSON(ctx context.Context, rawURL string, headers map[string]string, v any) error {
+	body, err := f.get(ctx, rawURL, headers)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("解析响应失败: %w", err)
+	}
+	return nil
+}
+
+func (f *issueFetcher) fetchGitHub(ctx context.Context, ref issueRef) (issueDetails, error) {
+	headers := map[string]string{
+		"Accept":               "application/vnd.github+json",
+		"X-GitHub-Api-Version": "2022-11-28",
+	}
+	if f.tokens.GitHub != "" {
+		headers["Authorization"] = "Bearer " + f.tokens.GitHub
+	}
+	base := fmt.Sprintf("%s/repos/%s", f.githubAPI, ref.project)
+
+	// 议题接口同样适用于拉取请求。
+	var issue struct {
+		Title   string `json:"title"`
+		Body    string `json:"body"`
+		State   string `json:"state"`
+		HTMLURL string `json:"html_url"`
+		User    struct {
+			Login string `json:"login"`
+		} `json:"user"`
+		Labels []struct {
+			Name string `json:"name"`
+		} `json:"labels"`
+		PullRequest *struct{} `json:"pull_request"`
+	}
+	if err := f.getJSON(ctx, fmt.Sprintf("%s/issues/%d", base, ref.number), headers, &issue); err != nil {
+		return issueDetails{}, err
+	}
+
+	details := issueDetails{
+		Title:  issue.Title,
+		State:  issue.State,
+		Author: issue.User.Login,
+		URL:    issue.HTMLURL,
+		Body:   issue.Body,
+	}
+	for _, l := range issue.Labels {
+		details.Labels = append(details.Labels, l.Name)
+	}
+
+	var comments []struct {
+		Body      string `json:"body"`
+		CreatedAt string `json:"created_at"`
+		User      struct {
+			Login string `json:"login"`
+		} `json:"user"`
+	}
+	if err := f.getJSON(ctx, fmt.Sprintf("%s/issues/%d/comments?per_page=100", base, ref.number), headers, &comments); err != nil {
+		return issueDetails{}, err
+	}
+	for _, c := range comments {
+		details.Comments = append(details.Comments, issueComment{Author: c.User.Login, Created: c.CreatedAt, Body: c.Body})
+	}
+
+	if issue.PullRequest != nil {
+		diffHeaders := map[string]string{"Accept": "application/vnd.github.diff"}
+		if auth, ok := headers["Authorization"]; ok {
+			diffHeaders["Authorization"] = auth
+		}
+		diff, err := f.get(ctx, fmt.Sprintf("%s/pulls/%d", base, ref.number), diffHeaders)
+		if err != nil {
+			return issueDetails{}, err
+		}
+		details.Diff = string(diff)
+	}
+	return details, nil
+}
+
+func (f *issueFetcher) fetchGitLab(ctx context.Context, ref issueRef) (issueDetails, error) {
+	headers := map[string]string{}
+	if f.tokens.GitLab != "" && f.trustedGitLabHost(ref.host) {
+		headers["PRIVATE-TOKEN"] = f.tokens.GitLab
+	}
+	scheme := f.gitlabScheme
+	if scheme == "" {
+		scheme = "https"
+	}
+	kind := "issues"
+	if ref.isPR {
+		kind = "merge_requests"
+	}
+	base := fmt.Sprintf("%s://%s/api/v4/projects/%s/%s/%d", scheme, ref.host, url.PathEscape(ref.project), kind, ref.number)
+
+	var issue struct {
+		Title       string   `json:"title"`
+		Description string   `json:"description"`
+		State       string   `json:"state"`
+		WebURL      string   `json:"web_url"`
+		Labels      []string `json:"labels"`
+		Author      struct {
+			Username string `json:"username"`
+		} `json:"author"`
+	}
+	if err := f.getJSON(ctx, base, headers, &issue); err != nil {
+		return issueDetails{}, err
+	}
+
+	details := issueDetails{
+		Title:  issue.Title,
+		State:  issue.State,
+		Author: issue.Author.Username,
+		URL:    issue.WebURL,
+		Labels: issue.Labels,
+		Body:   issue.Description,
+	}
+
+	var notes []struct {
+		Body      string `json:"body"`
+		CreatedAt string `json:"created_at"`
+		System    bool   `json:"system"`
+		Author    struct {
+			Username string `json:"username"`
+		} `json:"author"`
+	}
+	if err := f.getJSON(ctx, base+"/notes?sort=asc&per_page=100", headers, &notes); err != nil {
+		return issueDetails{}, err
+	}
+	for _, n := range notes {
+		// 跳过系统生成的事件记录。
+		if n.System {
+			continue
+		}
+		details.Comments = append(details.Comments, issueComment{Author: n.Author.Username, Created: n.CreatedAt, Body: n.Body})
+	}
+
+	if ref.isPR {
+		var diffs []struct {
+			OldPath string `json:"old_path"`
+			NewPath string `json:"new_path"`
+			Diff    string `json:"diff"`
+		}
+		if err := f.getJSON(ctx, base+"/diffs?per_page=100", headers, &diffs); err != nil {
+			return issueDetails{}, err
+		}
+		var sb strings.Builder
+		for _, d := range diffs {
+			fmt.Fprintf(&sb, "diff --git a/%s b/%s\n--- a/%[1]s\n+++ b/%[2]s\n%s", d.OldPath, d.NewPath, d.Diff)
+			if !strings.HasSuffix(d.Diff, "\n") {
+				sb.WriteString("\n")
+			}
+		}
+		details.Diff = sb.String()
+	}
+	return details, nil
+}
+
+// trustedGitLabHost 判断是否可以向 host 发送 GitLab 令牌。GitLab 按 URL 路径识别，
+// 任意主机都可能被当作 GitLab，因此令牌只发送给 gitlab.com 和显式配置的主机。
+func (f *issueFetcher) trustedGitLabHost(host string) bool {
+	host = strings.ToLower(host)
+	hostname := host
+	if u, err := url.Parse("//" + host); err == nil {
+		hostname = u.Hostname()
+	}
+	if hostname == "gitlab.com" {
+		return true
+	}
+	for _, allowed := range f.tokens.GitLabHosts {
+		allowed = strings.ToLower(strings.TrimSpace(allowed))
+		if allowed == host || allowed == hostname {
+			return true
+		}
+	}
+	return false
+}
+
+func (f *issueFetcher) fetchAzureDevOps(ctx context.Context, ref issueRef) (issueDetails, error) {
+	headers := map[string]string{"Accept": "application/json"}
+	if f.tokens.AzureDevOps != "" {
+		// 个人访问令牌使用空用户名的基本认证。
+		headers["Authorization"] = "Basic " + base64.StdEncoding.EncodeToString([]byte(":"+f.tokens.AzureDevOps))
+	}
+	api := f.azureAPI
+	if api == "" {
+		api = "https://dev.azure.com"
+	}
+	org, project, _ := strings.Cut(ref.project, "/")
+	base := fmt.Sprintf("%s/%s/%s/_apis", api, url.PathEscape(org), url.PathEscape(project))
+
+	if ref.isPR {
+		return f.fetchAzurePullRequest(ctx, ref, base, headers)
+	}
+
+	var item struct {
+		Fields map[string]any `json:"fields"`
+		Links  struct {
+			HTML struct {
+				Href string `json:"href"`
+			} `json:"html"`
+		} `json:"_links"`
+	}
+	if err := f.getJSON(ctx, fmt.Sprintf("%s/wit/workitems/%d?$expand=links&api-version=7.1", base, ref.number), headers, &item); err != nil {
+		return issueDetails{}, err
+	}
+
+	details := issueDetails{
+		Title:  azureField(item.Fields, "System.Title"),
+		State:  azureField(item.Fields, "System.State"),
+		Author: azureField(item.Fields, "System.CreatedBy"),
+		URL:    item.Links.HTML.Href,
+		Body:   azureHTML(azureField(item.Fields, "System.Description")),
+	}
+	if tags := azureField(item.Fields, "System.Tags"); tags != "" {
+		for tag := range strings.SplitSeq(tags, ";") {
+			details.Labels = append(details.Labels, strings.TrimSpace(tag))
+		}
+	}
+
+	var comments struct {
+		Comments []struct {
+			Text        string `json:"text"`
+			CreatedDate string `json:"createdDate"`
+			CreatedBy   struct {
+				DisplayName string `json:"displayName"`
+			} `json:"createdBy"`
+		} `json:"comments"`
+	}
+	if err := f.getJSON(ctx, fmt.Sprintf("%s/wit/workItems/%d/comments?api-version=7.1-preview.4", base, ref.number), headers, &comments); err != nil {
+		return issueDetails{}, err
+	}
+	for _, c := range comments.Comments {
+		details.Comments = append(details.Comments, issueComment{Author: c.CreatedBy.DisplayName, Created: c.CreatedDate, Body: azureHTML(c.Text)})
+	}
+	return details, nil
+}
+
+func (f *issueFetcher) fetchAzurePullRequest(ctx context.Context, ref issueRef, base string, headers map[string]string) (issueDetails, error) {
+	prBase := fmt.Sprintf("%s/git/repositories/%s/pullrequests/%d", base, url.PathEscape(ref.repository), ref.number)
+
+	var pr struct {
+		Title       string `json:"title"`
+		Description string `json:"description"`
+		Status      string `json:"status"`
+		CreatedBy   struct {
+			DisplayName string `json:"displayName"`
+		} `json:"createdBy"`
+		Labels []struct {
+			Name string `json:"name"`
+		} `json:"labels"`
+	}
+	if err := f.getJSON(ctx, prBase+"?api-version=7.1", headers, &pr); err != nil {
+		return issueDetails{}, err
+	}
+
+	org, project, _ := strings.Cut(ref.project, "/")
+	details := issueDetails{
+		Title:  pr.Title,
+		State:  pr.Status,
+		Author: pr.CreatedBy.DisplayName,
+		URL:    fmt.Sprintf("https://dev.azure.com/%s/%s/_git/%s/pullrequest/%d", org, project, ref.repository, ref.number),
+		Body:   pr.Description,
+	}
+	for _, l := range pr.Labels {
+		details.Labels = append(details.Labels, l.Name)
+	}
+
+	var threads struct {
+		Value []struct {
+			Comments []struct {
+				Content       string `json:"content"`
+				CommentType   string `json:"commentType"`
+				PublishedDate string `json:"publishedDate"`
+				Author        struct {
+					DisplayName string `json:"displayName"`
+				} `json:"author"`
+			} `json:"comments"`
+		} `json:"value"`
+	}
+	if err := f.getJSON(ctx, prBase+"/threads?api-version=7.1", headers, &threads); err != nil {
+		return issueDetails{}, err
+	}
+	for _, t := range threads.Value {
+		for _, c := range t.Comments {
+			// 跳过系统生成的评论。
+			if c.CommentType == "system" || c.Content == "" {
+				continue
+			}
+			details.Comments = append(details.Comments, issueComment{Author: c.Author.DisplayName, Created: c.PublishedDate, Body: c.Content})
+		}
+	}
+	return details, nil
+}
+
+// azureField 以字符串形式返回工作项字段。身份字段返回显示名称。
+func azureField(fields map[string]any, name string) string {
+	switch v := fields[name].(type) {
+	case string:
+		return v
+	case map[string]any:
+		if s, ok := v["displayName"].(string); ok {
+			return s
+		}
+	}
+	return ""
+}
+
+// azureHTML 将 Azure DevOps 返回的 HTML 内容转换为 Markdown。
+func azureHTML(content string) string {
+	if content == "" {
+		return ""
+	}
+	markdown, err := convertHTMLToMarkdown(content)
+	if err != nil {
+		return content
+	}
+	return markdown
+}
@@ -0,0 +1,207 @@
+package tools
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseIssueReference(t *testing.T) {
+	t.Parallel()
+
+	noRemote := func() (string, error) { return "", errors.New("无远程仓库") }
+	remote := func(url string) func() (string, error) {
+		return func() (string, error) { return url, nil }
+	}
+
+	tests := []struct {
+		name      string
+		reference string
+		isPR      bool
+		remote    func() (string, error)
+		want      issueRef
+		wantErr   bool
+	}{
+		{
+			name:      "GitHub 议题 URL",
+			reference: "https://github.com/owner/repo/issues/12",
+			remote:    noRemote,
+			want:      issueRef{platform: platformGitHub, host: "github.com", project: "owner/repo", number: 12},
+		},
+		{
+			name:      "GitHub 拉取请求 URL",
+			reference: "https://github.com/owner/repo/pull/34/files",
+			remote:    noRemote,
+			want:      issueRef{platform: platformGitHub, host: "github.com", project: "owner/repo", number: 34, isPR: true},
+		},
+		{
+			name:      "自托管 GitLab 合并请求",
+			reference: "https://git.example.com/group/sub/project/-/merge_requests/5",
+			remote:    noRemote,
+			want:      issueRef{platform: platformGitLab, host: "git.example.com", project: "group/sub/project", number: 5, isPR: true},
+		},
+		{
+			name:      "Azure DevOps 工作项",
+			reference: "https://dev.azure.com/org/proj/_workitems/edit/77",
+			remote:    noRemote,
+			want:      issueRef{platform: platformAzureDevOps, host: "dev.azure.com", project: "org/proj", number: 77},
+		},
+		{
+			name:      "旧式 Azure DevOps 拉取请求",
+			reference: "https://org.visualstudio.com/proj/_git/repo/pullrequest/8",
+			remote:    noRemote,
+			want:      issueRef{platform: platformAzureDevOps, host: "org.visualstudio.com", project: "org/proj", repository: "repo", number: 8, isPR: true},
+		},
+		{
+			name:      "编号与 SSH 远程地址",
+			reference: "#123",
+			remote:    remote("git@github.com:owner/repo.git"),
+			want:      issueRef{platform: platformGitHub, host: "github.com", project: "owner/repo", number: 123},
+		},
+		{
+			name:      "编号与 GitLab HTTPS 远程地址",
+			reference: "9",
+			isPR:      true,
+			remote:    remote("https://gitlab.com/group/project.git"),
+			want:      issueRef{platform: platformGitLab, host: "gitlab.com", project: "group/project", number: 9, isPR: true},
+		},
+		{
+			name:      "编号与带端口的 GitLab SSH 远程地址",
+			reference: "#3",
+			remote:    remote("ssh://git@gitlab.example.com:2222/group/project.git"),
+			want:      issueRef{platform: platformGitLab, host: "gitlab.example.com", project: "group/project", number: 3},
+		},
+		{
+			name:      "编号与 Azure DevOps SSH 远程地址",
+			reference: "#4",
+			isPR:      true,
+			remote:    remote("git@ssh.dev.azure.com:v3/org/proj/repo"),
+			want:      issueRef{platform: platformAzureDevOps, host: "dev.azure.com", project: "org/proj", repository: "repo", number: 4, isPR: true},
+		},
+		{
+			name:      "没有远程仓库的编号",
+			reference: "#1",
+			remote:    noRemote,
+			wantErr:   true,
+		},
+		{
+			name:      "不支持的 URL",
+			reference: "https://example.com/foo",
+			remote:    noRemote,
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := parseIssueReference(tt.reference, tt.isPR, tt.remote)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestIssueFetcherGitHub(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+		switch r.URL.Path {
+		case "/repos/owner/repo/issues/7":
+			fmt.Fprint(w, `{"title":"修复崩溃","body":"启动时崩溃","state":"open","html_url":"https://github.com/owner/repo/pull/7","user":{"login":"alice"},"labels":[{"name":"bug"}],"pull_request":{}}`)
+		case "/repos/owner/repo/issues/7/comments":
+			fmt.Fprint(w, `[{"body":"已复现","created_at":"2025-01-02T03:04:05Z","user":{"login":"bob"}}]`)
+		case "/repos/owner/repo/pulls/7":
+			assert.Equal(t, "application/vnd.github.diff", r.Header.Get("Accept"))
+			fmt.Fprint(w, "diff --git a/main.go b/main.go\n+fixed\n")
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	f := &issueFetcher{client: server.Client(), tokens: IssueFetchTokens{GitHub: "secret"}, githubAPI: server.URL}
+	ref := issueRef{platform: platformGitHub, project: "owner/repo", number: 7}
+	details, err := f.fetch(t.Context(), ref)
+	require.NoError(t, err)
+	require.Equal(t, "修复崩溃", details.Title)
+	require.Equal(t, []string{"bug"}, details.Labels)
+	require.Len(t, details.Comments, 1)
+
+	ref.isPR = true
+	out := details.markdown(ref)
+	require.True(t, strings.HasPrefix(out, "# 修复崩溃\n"))
+	require.Contains(t, out, "- **标签:** bug")
+	require.Contains(t, out, "### bob (2025-01-02T03:04:05Z)\n\n已复现")
+	require.Contains(t, out, "```diff\ndiff --git a/main.go b/main.go\n+fixed\n```")
+}
+
+func TestIssueFetcherGitLabTokenHosts(t *testing.T) {
+	t.Parallel()
+
+	tokens := make(chan string, 2)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tokens <- r.Header.Get("PRIVATE-TOKEN")
+		if strings.HasSuffix(r.URL.Path, "/notes") {
+			fmt.Fprint(w, `[]`)
+			return
+		}
+		fmt.Fprint(w, `{"title":"议题"}`)
+	}))
+	defer server.Close()
+	host := strings.TrimPrefix(server.URL, "http://")
+	ref := issueRef{platform: platformGitLab, host: host, project: "group/project", number: 1}
+
+	// 任意主机都可能按路径被识别为 GitLab，不能收到令牌。
+	f := &issueFetcher{client: server.Client(), tokens: IssueFetchTokens{GitLab: "secret"}, gitlabScheme: "http"}
+	_, err := f.fetch(t.Context(), ref)
+	require.NoError(t, err)
+	require.Empty(t, <-tokens)
+	require.Empty(t, <-tokens)
+
+	f.tokens.GitLabHosts = []string{host}
+	_, err = f.fetch(t.Context(), ref)
+	require.NoError(t, err)
+	require.Equal(t, "secret", <-tokens)
+	require.Equal(t, "secret", <-tokens)
+}
+
+func TestIssueFetcherGitLabTokenRedirect(t *testing.T) {
+	t.Parallel()
+
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Empty(t, r.Header.Get("PRIVATE-TOKEN"))
+		if strings.HasSuffix(r.URL.Path, "/notes") {
+			fmt.Fprint(w, `[]`)
+			return
+		}
+		fmt.Fprint(w, `{"title":"议题"}`)
+	}))
+	defer other.Close()
+	trusted := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "secret", r.Header.Get("PRIVATE-TOKEN"))
+		http.Redirect(w, r, other.URL+r.URL.RequestURI(), http.StatusFound)
+	}))
+	defer trusted.Close()
+
+	host := strings.TrimPrefix(trusted.URL, "http://")
+	f := &issueFetcher{
+		client:       stripTokenOnRedirect(trusted.Client()),
+		tokens:       IssueFetchTokens{GitLab: "secret", GitLabHosts: []string{host}},
+		gitlabScheme: "http",
+	}
+	details, err := f.fetch(t.Context(), issueRef{platform: platformGitLab, host: host, project: "group/project", number: 1})
+	require.NoError(t, err)
+	require.Equal(t, "议题", details.Title)
+}
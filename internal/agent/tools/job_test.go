@@ -2,9 +2,14 @@ package tools
 
 import (
 	"context"
+	"encoding/json"
 	"testing"
 	"time"
 
+	"charm.land/fantasy"
+	"github.com/purpose168/crush-cn/internal/config"
+	"github.com/purpose168/crush-cn/internal/permission"
+	"github.com/purpose168/crush-cn/internal/pubsub"
 	"github.com/purpose168/crush-cn/internal/shell"
 	"github.com/stretchr/testify/require"
 )
@@ -327,3 +332,33 @@ func TestBackgroundShell_AutoBackground(t *testing.T) {
 		require.Equal(t, bgShell.ID, retrieved.ID)
 	})
 }
+
+func TestBashTool_StreamsOutput(t *testing.T) {
+	t.Parallel()
+
+	events := SubscribeBashOutput(t.Context())
+	permissions := &mockPermissionService{Broker: pubsub.NewBroker[permission.PermissionRequest]()}
+	tool := NewBashTool(permissions, nil, t.TempDir(), &config.Attribution{}, "", "", shell.ShellTypePOSIX, nil)
+	input, err := json.Marshal(BashParams{Command: "echo first; sleep 1; echo second"})
+	require.NoError(t, err)
+
+	ctx := context.WithValue(t.Context(), SessionIDContextKey, "session")
+	resp, err := tool.Run(ctx, fantasy.ToolCall{ID: "stream-call", Name: BashToolName, Input: string(input)})
+	require.NoError(t, err)
+	require.Contains(t, resp.Content, "first\nsecond")
+
+	// 命令运行期间至少发布过一次只包含第一行输出的实时输出
+	var tails []string
+	for {
+		select {
+		case ev := <-events:
+			if ev.Payload.ToolCallID == "stream-call" {
+				tails = append(tails, ev.Payload.Tail)
+			}
+			continue
+		default:
+		}
+		break
+	}
+	require.Contains(t, tails, "first")
+}
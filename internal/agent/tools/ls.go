@@ -60,12 +60,14 @@ func NewLsTool(permissions permission.Service, workingDir string, lsConfig confi
 		LSToolName,
 		string(lsDescription),
 		func(ctx context.Context, params LSParams, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
-			searchPath, err := fsext.Expand(cmp.Or(params.Path, workingDir))
+			// 相对路径基于会话的工作目录解析，权限检查仍以项目根目录为准
+			sessionDir := sessionWorkingDir(ctx, workingDir)
+			searchPath, err := fsext.Expand(cmp.Or(params.Path, sessionDir))
 			if err != nil {
 				return fantasy.NewTextErrorResponse(fmt.Sprintf("扩展路径错误: %v", err)), nil
 			}
 
-			searchPath = filepathext.SmartJoin(workingDir, searchPath)
+			searchPath = filepathext.SmartJoin(sessionDir, searchPath)
 
 			// 检查目录是否在工作目录外，如需请求权限
 			absWorkingDir, err := filepath.Abs(workingDir)
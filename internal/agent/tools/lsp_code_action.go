@@ -0,0 +1,164 @@
+package tools
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/x/powernap/pkg/lsp/protocol"
+	"github.com/purpose168/crush-cn/internal/filepathext"
+	"github.com/purpose168/crush-cn/internal/filetracker"
+	"github.com/purpose168/crush-cn/internal/history"
+	"github.com/purpose168/crush-cn/internal/lsp"
+	"github.com/purpose168/crush-cn/internal/permission"
+)
+
+type LSPCodeActionParams struct {
+	FilePath string `json:"file_path" description:"要获取代码操作的文件路径"`
+	Line     int    `json:"line" description:"范围的起始行号（从1开始）"`
+	EndLine  int    `json:"end_line,omitempty" description:"范围的结束行号（从1开始），默认与起始行相同"`
+	Kind     string `json:"kind,omitempty" description:"只返回该类型的代码操作，例如 quickfix、refactor、source.organizeImports"`
+	Apply    string `json:"apply,omitempty" description:"要应用的代码操作的标题或列表中的序号。为空时只列出可用的代码操作"`
+}
+
+const LSPCodeActionToolName = "lsp_code_action"
+
+//go:embed lsp_code_action.md
+var lspCodeActionDescription []byte
+
+// NewLSPCodeActionTool 创建一个列出并应用语言服务器代码操作的工具实例
+// lspManager: LSP客户端管理器
+// permissions: 权限服务
+// files: 文件历史服务
+// filetracker: 文件跟踪服务
+// workingDir: 工作目录
+func NewLSPCodeActionTool(
+	lspManager *lsp.Manager,
+	permissions permission.Service,
+	files history.Service,
+	filetracker filetracker.Service,
+	workingDir string,
+) fantasy.AgentTool {
+	return fantasy.NewAgentTool(
+		LSPCodeActionToolName,
+		string(lspCodeActionDescription),
+		func(ctx context.Context, params LSPCodeActionParams, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+			if params.FilePath == "" {
+				return fantasy.NewTextErrorResponse("file_path是必需的"), nil
+			}
+			if params.EndLine == 0 {
+				params.EndLine = params.Line
+			}
+			if params.EndLine < params.Line {
+				return fantasy.NewTextErrorResponse("end_line不能小于line"), nil
+			}
+
+			params.FilePath = filepathext.SmartJoin(workingDir, params.FilePath)
+			client := clientForFile(lspManager, params.FilePath)
+			if client == nil {
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("没有LSP客户端可以处理文件: %s", params.FilePath)), nil
+			}
+
+			if _, err := readLine(params.FilePath, params.Line); err != nil {
+				return fantasy.NewTextErrorResponse(err.Error()), nil
+			}
+			endText, err := readLine(params.FilePath, params.EndLine)
+			if err != nil {
+				return fantasy.NewTextErrorResponse(err.Error()), nil
+			}
+			rng := protocol.Range{
+				Start: protocol.Position{Line: uint32(params.Line - 1)},                                          //nolint:gosec
+				End:   protocol.Position{Line: uint32(params.EndLine - 1), Character: uint32(utf16Len(endText))}, //nolint:gosec
+			}
+
+			var only []protocol.CodeActionKind
+			if params.Kind != "" {
+				only = []protocol.CodeActionKind{protocol.CodeActionKind(params.Kind)}
+			}
+			actions, err := client.CodeActions(ctx, params.FilePath, rng, only)
+			if err != nil {
+				return fantasy.NewTextErrorResponse(err.Error()), nil
+			}
+			if len(actions) == 0 {
+				return fantasy.NewTextResponse("该范围内没有可用的代码操作"), nil
+			}
+
+			if params.Apply == "" {
+				return fantasy.NewTextResponse(formatCodeActions(actions)), nil
+			}
+
+			action, ok := selectCodeAction(actions, params.Apply)
+			if !ok {
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("未找到代码操作 '%s'\n\n%s", params.Apply, formatCodeActions(actions))), nil
+			}
+			if action.Disabled != nil {
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("代码操作 '%s' 当前不可用: %s", action.Title, action.Disabled.Reason)), nil
+			}
+			if action.Edit == nil {
+				resolved, err := client.ResolveCodeAction(ctx, action)
+				if err != nil {
+					return fantasy.NewTextErrorResponse(err.Error()), nil
+				}
+				action = resolved
+			}
+			if action.Edit == nil {
+				// 只包含命令的操作由服务器自行修改文件，无法预览差异，因此不予执行
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("代码操作 '%s' 没有提供工作区编辑，无法应用", action.Title)), nil
+			}
+
+			editCtx := lspEditContext{lspManager, permissions, files, filetracker, workingDir}
+			return applyWorkspaceEdit(
+				ctx,
+				editCtx,
+				call,
+				LSPCodeActionToolName,
+				fmt.Sprintf("应用代码操作: %s", action.Title),
+				*action.Edit,
+			)
+		})
+}
+
+// selectCodeAction 按序号（从1开始）或标题查找代码操作
+func selectCodeAction(actions []protocol.CodeAction, apply string) (protocol.CodeAction, bool) {
+	if n, err := strconv.Atoi(strings.TrimSpace(apply)); err == nil {
+		if n >= 1 && n <= len(actions) {
+			return actions[n-1], true
+		}
+		return protocol.CodeAction{}, false
+	}
+	for _, action := range actions {
+		if action.Title == apply {
+			return action, true
+		}
+	}
+	for _, action := range actions {
+		if strings.EqualFold(action.Title, strings.TrimSpace(apply)) {
+			return action, true
+		}
+	}
+	return protocol.CodeAction{}, false
+}
+
+// formatCodeActions 格式化可用的代码操作列表
+func formatCodeActions(actions []protocol.CodeAction) string {
+	var output strings.Builder
+	fmt.Fprintf(&output, "找到 %d 个代码操作:\n", len(actions))
+	for i, action := range actions {
+		fmt.Fprintf(&output, "%d. %s", i+1, action.Title)
+		if action.Kind != "" {
+			fmt.Fprintf(&output, " [%s]", action.Kind)
+		}
+		if action.IsPreferred {
+			output.WriteString(" (推荐)")
+		}
+		if action.Disabled != nil {
+			fmt.Fprintf(&output, " (不可用: %s)", action.Disabled.Reason)
+		}
+		output.WriteString("\n")
+	}
+	output.WriteString("\n使用 apply 参数传入序号或标题来应用代码操作。")
+	return output.String()
+}
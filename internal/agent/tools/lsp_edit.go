@@ -0,0 +1,193 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf16"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/x/powernap/pkg/lsp/protocol"
+	"github.com/purpose168/crush-cn/internal/diff"
+	"github.com/purpose168/crush-cn/internal/filetracker"
+	"github.com/purpose168/crush-cn/internal/fsext"
+	"github.com/purpose168/crush-cn/internal/history"
+	"github.com/purpose168/crush-cn/internal/lsp"
+	"github.com/purpose168/crush-cn/internal/lsp/util"
+	"github.com/purpose168/crush-cn/internal/permission"
+)
+
+// WorkspaceEditFile 描述 LSP 工作区编辑对单个文件的更改
+type WorkspaceEditFile struct {
+	FilePath   string `json:"file_path"`
+	OldPath    string `json:"old_path,omitempty"`
+	OldContent string `json:"old_content,omitempty"`
+	NewContent string `json:"new_content,omitempty"`
+	Deleted    bool   `json:"deleted,omitempty"`
+	Additions  int    `json:"additions"`
+	Removals   int    `json:"removals"`
+}
+
+// WorkspaceEditPermissionsParams 是应用 LSP 工作区编辑前请求权限的参数
+type WorkspaceEditPermissionsParams struct {
+	Files []WorkspaceEditFile `json:"files"`
+}
+
+// WorkspaceEditResponseMetadata 是应用 LSP 工作区编辑后的响应元数据
+type WorkspaceEditResponseMetadata struct {
+	Files     []WorkspaceEditFile `json:"files,omitempty"`
+	Additions int                 `json:"additions"`
+	Removals  int                 `json:"removals"`
+}
+
+// lspEditContext 包含应用 LSP 工作区编辑所需的服务
+type lspEditContext struct {
+	lspManager  *lsp.Manager
+	permissions permission.Service
+	files       history.Service
+	filetracker filetracker.Service
+	workingDir  string
+}
+
+// applyWorkspaceEdit 在用户批准多文件差异后应用工作区编辑，并记录文件历史
+// ctx: 上下文对象
+// edit: 编辑上下文
+// call: 工具调用信息
+// toolName: 请求权限的工具名称
+// description: 权限请求的描述
+// workspaceEdit: 语言服务器返回的工作区编辑
+// 返回工具响应
+func applyWorkspaceEdit(
+	ctx context.Context,
+	edit lspEditContext,
+	call fantasy.ToolCall,
+	toolName, description string,
+	workspaceEdit protocol.WorkspaceEdit,
+) (fantasy.ToolResponse, error) {
+	sessionID := GetSessionFromContext(ctx)
+	if sessionID == "" {
+		return fantasy.ToolResponse{}, fmt.Errorf("编辑文件需要会话ID")
+	}
+
+	changes, err := util.PreviewWorkspaceEdit(workspaceEdit)
+	if err != nil {
+		return fantasy.NewTextErrorResponse(fmt.Sprintf("无法计算工作区编辑: %s", err)), nil
+	}
+	if len(changes) == 0 {
+		return fantasy.NewTextErrorResponse("语言服务器没有返回任何更改"), nil
+	}
+
+	var meta WorkspaceEditResponseMetadata
+	for _, change := range changes {
+		_, additions, removals := diff.GenerateDiff(change.OldContent, change.NewContent, strings.TrimPrefix(change.Path, edit.workingDir))
+		meta.Files = append(meta.Files, WorkspaceEditFile{
+			FilePath:   change.Path,
+			OldPath:    change.OldPath,
+			OldContent: change.OldContent,
+			NewContent: change.NewContent,
+			Deleted:    change.Deleted,
+			Additions:  additions,
+			Removals:   removals,
+		})
+		meta.Additions += additions
+		meta.Removals += removals
+	}
+
+	p, err := edit.permissions.Request(ctx, permission.CreatePermissionRequest{
+		SessionID:   sessionID,
+		Path:        fsext.PathOrPrefix(changes[0].Path, edit.workingDir),
+		ToolCallID:  call.ID,
+		ToolName:    toolName,
+		Action:      "write",
+		Description: description,
+		Params:      WorkspaceEditPermissionsParams{Files: meta.Files},
+	})
+	if err != nil {
+		return fantasy.ToolResponse{}, err
+	}
+	if !p {
+		return fantasy.ToolResponse{}, permission.ErrorPermissionDenied
+	}
+
+	if err := util.ApplyWorkspaceEdit(workspaceEdit); err != nil {
+		return fantasy.NewTextErrorResponse(fmt.Sprintf("应用工作区编辑失败: %s", err)), nil
+	}
+
+	var output strings.Builder
+	fmt.Fprintf(&output, "已更改 %d 个文件:\n", len(changes))
+	for _, f := range meta.Files {
+		recordFileVersion(ctx, edit.files, edit.filetracker, sessionID, f)
+		if !f.Deleted {
+			notifyLSPs(ctx, edit.lspManager, f.FilePath)
+		}
+		switch {
+		case f.Deleted:
+			fmt.Fprintf(&output, "- %s (已删除)\n", f.FilePath)
+		case f.OldPath != "":
+			fmt.Fprintf(&output, "- %s (由 %s 重命名, +%d -%d)\n", f.FilePath, f.OldPath, f.Additions, f.Removals)
+		default:
+			fmt.Fprintf(&output, "- %s (+%d -%d)\n", f.FilePath, f.Additions, f.Removals)
+		}
+	}
+
+	text := fmt.Sprintf("<result>\n%s</result>\n", output.String())
+	text += getDiagnostics(changes[0].Path, edit.lspManager)
+	return fantasy.WithResponseMetadata(fantasy.NewTextResponse(text), meta), nil
+}
+
+// recordFileVersion 在文件历史中记录多文件编辑前后的文件版本
+func recordFileVersion(ctx context.Context, files history.Service, tracker filetracker.Service, sessionID string, f WorkspaceEditFile) {
+	if f.Deleted {
+		return
+	}
+	file, err := files.GetByPathAndSession(ctx, f.FilePath, sessionID)
+	if err != nil {
+		if _, err := files.Create(ctx, sessionID, f.FilePath, f.OldContent); err != nil {
+			slog.Error("创建文件历史失败", "error", err)
+			return
+		}
+	} else if file.Content != f.OldContent {
+		// 用户手动更改了内容，存储中间版本
+		if _, err := files.CreateVersion(ctx, sessionID, f.FilePath, f.OldContent); err != nil {
+			slog.Error("创建文件历史版本失败", "error", err)
+		}
+	}
+	if _, err := files.CreateVersion(ctx, sessionID, f.FilePath, f.NewContent); err != nil {
+		slog.Error("创建文件历史版本失败", "error", err)
+	}
+	tracker.RecordRead(ctx, sessionID, f.FilePath)
+}
+
+// clientForFile 返回处理该文件的 LSP 客户端，没有时返回 nil
+func clientForFile(lspManager *lsp.Manager, path string) *lsp.Client {
+	for client := range lspManager.Clients().Seq() {
+		if client.HandlesFile(path) {
+			return client
+		}
+	}
+	return nil
+}
+
+// readLine 返回文件中第 line 行（从1开始）的内容
+func readLine(path string, line int) (string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", fmt.Errorf("文件未找到: %s", path)
+		}
+		return "", fmt.Errorf("读取文件失败: %w", err)
+	}
+	lines := strings.Split(strings.ReplaceAll(string(content), "\r\n", "\n"), "\n")
+	if line < 1 || line > len(lines) {
+		return "", fmt.Errorf("行号 %d 超出文件 %s 的范围（共 %d 行）", line, filepath.Base(path), len(lines))
+	}
+	return lines[line-1], nil
+}
+
+// utf16Len 返回字符串的 UTF-16 代码单元数，LSP 位置默认以此计算列号
+func utf16Len(s string) int {
+	return len(utf16.Encode([]rune(s)))
+}
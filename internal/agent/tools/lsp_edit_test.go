@@ -0,0 +1,54 @@
+package tools
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/charmbracelet/x/powernap/pkg/lsp/protocol"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSymbolColumn(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "main.go")
+	content := "package main\n\nvar 名字 = fmt.Sprintf(\"x\")\n"
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+
+	col, err := symbolColumn(path, 3, "名字")
+	require.NoError(t, err)
+	require.Equal(t, 5, col)
+
+	// 列号以 UTF-16 代码单元计，限定符号指向实际的成员名称
+	col, err = symbolColumn(path, 3, "fmt.Sprintf")
+	require.NoError(t, err)
+	require.Equal(t, 14, col)
+
+	_, err = symbolColumn(path, 1, "missing")
+	require.Error(t, err)
+	_, err = symbolColumn(path, 10, "main")
+	require.Error(t, err)
+}
+
+func TestSelectCodeAction(t *testing.T) {
+	t.Parallel()
+
+	actions := []protocol.CodeAction{
+		{Title: "Organize imports"},
+		{Title: "Extract function"},
+	}
+
+	action, ok := selectCodeAction(actions, "2")
+	require.True(t, ok)
+	require.Equal(t, "Extract function", action.Title)
+
+	action, ok = selectCodeAction(actions, "organize imports")
+	require.True(t, ok)
+	require.Equal(t, "Organize imports", action.Title)
+
+	_, ok = selectCodeAction(actions, "3")
+	require.False(t, ok)
+	_, ok = selectCodeAction(actions, "Inline variable")
+	require.False(t, ok)
+}
@@ -0,0 +1,100 @@
+package tools
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+	"strings"
+
+	"charm.land/fantasy"
+	"github.com/purpose168/crush-cn/internal/filepathext"
+	"github.com/purpose168/crush-cn/internal/filetracker"
+	"github.com/purpose168/crush-cn/internal/history"
+	"github.com/purpose168/crush-cn/internal/lsp"
+	"github.com/purpose168/crush-cn/internal/permission"
+)
+
+type LSPRenameParams struct {
+	FilePath string `json:"file_path" description:"包含要重命名符号的文件路径"`
+	Line     int    `json:"line" description:"符号所在的行号（从1开始）"`
+	Symbol   string `json:"symbol" description:"该行中要重命名的符号名称"`
+	NewName  string `json:"new_name" description:"符号的新名称"`
+}
+
+const LSPRenameToolName = "lsp_rename"
+
+//go:embed lsp_rename.md
+var lspRenameDescription []byte
+
+// NewLSPRenameTool 创建一个通过语言服务器重命名符号的工具实例
+// lspManager: LSP客户端管理器
+// permissions: 权限服务
+// files: 文件历史服务
+// filetracker: 文件跟踪服务
+// workingDir: 工作目录
+func NewLSPRenameTool(
+	lspManager *lsp.Manager,
+	permissions permission.Service,
+	files history.Service,
+	filetracker filetracker.Service,
+	workingDir string,
+) fantasy.AgentTool {
+	return fantasy.NewAgentTool(
+		LSPRenameToolName,
+		string(lspRenameDescription),
+		func(ctx context.Context, params LSPRenameParams, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+			if params.FilePath == "" {
+				return fantasy.NewTextErrorResponse("file_path是必需的"), nil
+			}
+			if params.Symbol == "" {
+				return fantasy.NewTextErrorResponse("symbol是必需的"), nil
+			}
+			if params.NewName == "" {
+				return fantasy.NewTextErrorResponse("new_name是必需的"), nil
+			}
+
+			params.FilePath = filepathext.SmartJoin(workingDir, params.FilePath)
+			client := clientForFile(lspManager, params.FilePath)
+			if client == nil {
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("没有LSP客户端可以处理文件: %s", params.FilePath)), nil
+			}
+
+			character, err := symbolColumn(params.FilePath, params.Line, params.Symbol)
+			if err != nil {
+				return fantasy.NewTextErrorResponse(err.Error()), nil
+			}
+
+			workspaceEdit, err := client.Rename(ctx, params.FilePath, params.Line, character, params.NewName)
+			if err != nil {
+				return fantasy.NewTextErrorResponse(err.Error()), nil
+			}
+			if workspaceEdit == nil {
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("语言服务器无法重命名符号 '%s'", params.Symbol)), nil
+			}
+
+			editCtx := lspEditContext{lspManager, permissions, files, filetracker, workingDir}
+			return applyWorkspaceEdit(
+				ctx,
+				editCtx,
+				call,
+				LSPRenameToolName,
+				fmt.Sprintf("将 %s 重命名为 %s", params.Symbol, params.NewName),
+				*workspaceEdit,
+			)
+		})
+}
+
+// symbolColumn 返回符号在指定行中的列号（从1开始，以UTF-16代码单元计）
+// 对于限定符号（例如 pkg.Func），返回实际符号名称所在的列
+func symbolColumn(path string, line int, symbol string) (int, error) {
+	text, err := readLine(path, line)
+	if err != nil {
+		return 0, err
+	}
+	idx := strings.Index(text, symbol)
+	if idx == -1 {
+		return 0, fmt.Errorf("第 %d 行中未找到符号 '%s'", line, symbol)
+	}
+	idx += len(symbol[:getSymbolOffset(symbol)])
+	return utf16Len(text[:idx]) + 1, nil
+}
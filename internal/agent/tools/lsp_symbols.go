@@ -0,0 +1,104 @@
+package tools
+
+import (
+	"cmp"
+	"context"
+	_ "embed"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"charm.land/fantasy"
+	"github.com/purpose168/crush-cn/internal/lsp"
+)
+
+const LSPSymbolsToolName = "lsp_symbols"
+
+const (
+	// defaultSymbolsLimit 和 maxSymbolsLimit 是返回符号数的默认值和上限
+	defaultSymbolsLimit = 50
+	maxSymbolsLimit     = 200
+)
+
+//go:embed lsp_symbols.md
+var lspSymbolsDescription []byte
+
+type LSPSymbolsParams struct {
+	Query string `json:"query" description:"要搜索的符号名称或名称的一部分（例如，函数名、类型名、方法名）"`
+	Limit int    `json:"limit,omitempty" description:"最多返回的符号数（默认50，最多200）"`
+}
+
+// NewLSPSymbolsTool 创建一个新的工作区符号搜索工具实例
+// lspManager: LSP客户端管理器
+// workingDir: 工作目录，工作目录内的路径显示为相对路径
+func NewLSPSymbolsTool(lspManager *lsp.Manager, workingDir string) fantasy.AgentTool {
+	return fantasy.NewAgentTool(
+		LSPSymbolsToolName,
+		string(lspSymbolsDescription),
+		func(ctx context.Context, params LSPSymbolsParams, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+			query := strings.TrimSpace(params.Query)
+			if query == "" {
+				return fantasy.NewTextErrorResponse("query是必需的"), nil
+			}
+
+			clients := lspManager.ReadyClients()
+			if len(clients) == 0 {
+				return fantasy.NewTextErrorResponse("没有已就绪的LSP客户端"), nil
+			}
+
+			limit := min(cmp.Or(max(params.Limit, 0), defaultSymbolsLimit), maxSymbolsLimit)
+			symbols, err := lsp.SearchWorkspaceSymbols(ctx, clients, query, workingDir, 0)
+			if err != nil {
+				slog.Warn("搜索工作区符号失败", "query", query, "error", err)
+				if len(symbols) == 0 {
+					return fantasy.NewTextErrorResponse(fmt.Sprintf("搜索工作区符号失败: %s", err)), nil
+				}
+			}
+			if len(symbols) == 0 {
+				return fantasy.NewTextResponse(fmt.Sprintf("未找到与 '%s' 匹配的符号", query)), nil
+			}
+			return fantasy.NewTextResponse(formatWorkspaceSymbols(query, symbols, limit)), nil
+		})
+}
+
+// formatWorkspaceSymbols 格式化工作区符号，名称与查询完全相同的符号排在最前面，
+// 其余保持语言服务器返回的顺序，最多列出 limit 个
+func formatWorkspaceSymbols(query string, symbols []lsp.WorkspaceSymbol, limit int) string {
+	symbols = slices.Clone(symbols)
+	slices.SortStableFunc(symbols, func(a, b lsp.WorkspaceSymbol) int {
+		return cmp.Compare(symbolRank(query, a), symbolRank(query, b))
+	})
+
+	var output strings.Builder
+	if len(symbols) > limit {
+		output.WriteString(fmt.Sprintf("找到 %d 个符号，显示前 %d 个:\n\n", len(symbols), limit))
+		symbols = symbols[:limit]
+	} else {
+		output.WriteString(fmt.Sprintf("找到 %d 个符号:\n\n", len(symbols)))
+	}
+	for _, symbol := range symbols {
+		name := symbol.Name
+		if symbol.Container != "" {
+			name = symbol.Container + "." + name
+		}
+		output.WriteString(name)
+		if kind := lsp.SymbolKindName(symbol.Kind); kind != "" {
+			output.WriteString(" (" + kind + ")")
+		}
+		output.WriteString(fmt.Sprintf(" - %s:%d\n", symbol.Location(), symbol.Character))
+	}
+	return output.String()
+}
+
+// symbolRank 返回符号的排序等级：名称与查询完全相同为0，忽略大小写相同为1，其他为2
+func symbolRank(query string, symbol lsp.WorkspaceSymbol) int {
+	switch {
+	case symbol.Name == query:
+		return 0
+	case strings.EqualFold(symbol.Name, query):
+		return 1
+	default:
+		return 2
+	}
+}
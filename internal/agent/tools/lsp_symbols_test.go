@@ -0,0 +1,32 @@
+package tools
+
+import (
+	"testing"
+
+	"github.com/charmbracelet/x/powernap/pkg/lsp/protocol"
+	"github.com/purpose168/crush-cn/internal/lsp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormatWorkspaceSymbols(t *testing.T) {
+	t.Parallel()
+
+	symbols := []lsp.WorkspaceSymbol{
+		{Name: "NewServerConfig", Kind: protocol.Function, Path: "config.go", Line: 10, Character: 6},
+		{Name: "newServer", Kind: protocol.Function, Path: "server.go", Line: 3, Character: 6},
+		{Name: "NewServer", Kind: protocol.Function, Path: "server.go", Line: 20, Character: 6},
+		{Name: "Start", Kind: protocol.Method, Container: "Server", Path: "server.go", Line: 40, Character: 18},
+	}
+
+	// 名称完全相同的符号排在最前面，其余保持原顺序
+	require.Equal(t, "找到 4 个符号:\n\n"+
+		"NewServer (function) - server.go:20:6\n"+
+		"newServer (function) - server.go:3:6\n"+
+		"NewServerConfig (function) - config.go:10:6\n"+
+		"Server.Start (method) - server.go:40:18\n",
+		formatWorkspaceSymbols("NewServer", symbols, 50))
+
+	require.Equal(t, "找到 4 个符号，显示前 1 个:\n\n"+
+		"NewServerConfig (function) - config.go:10:6\n",
+		formatWorkspaceSymbols("Config", symbols, 1))
+}
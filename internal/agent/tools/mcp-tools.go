@@ -31,6 +31,14 @@ func GetMCPTools(permissions permission.Service, cfg *config.Config, wd string)
 	return result
 }
 
+// MCPPermissionsParams MCP工具调用的权限请求参数
+type MCPPermissionsParams struct {
+	MCPName     string `json:"mcp_name"`    // MCP服务器名称
+	ToolName    string `json:"tool_name"`   // MCP服务器上的工具名称
+	Description string `json:"description"` // 工具描述
+	Input       string `json:"input"`       // 调用参数（JSON）
+}
+
 // Tool 是来自MCP的工具
 type Tool struct {
 	mcpName         string
@@ -120,7 +128,12 @@ func (m *Tool) Run(ctx context.Context, params fantasy.ToolCall) (fantasy.ToolRe
 			ToolName:    m.Info().Name,
 			Action:      "execute",
 			Description: permissionDescription,
-			Params:      params.Input,
+			Params: MCPPermissionsParams{
+				MCPName:     m.mcpName,
+				ToolName:    m.tool.Name,
+				Description: m.tool.Description,
+				Input:       params.Input,
+			},
 		},
 	)
 	if err != nil {
@@ -54,6 +54,7 @@ const (
 	StateStarting
 	StateConnected
 	StateError
+	StateUnauthorized
 )
 
 func (s State) String() string {
@@ -66,6 +67,8 @@ func (s State) String() string {
 		return "connected"
 	case StateError:
 		return "error"
+	case StateUnauthorized:
+		return "unauthorized"
 	default:
 		return "unknown"
 	}
@@ -151,6 +154,8 @@ func Close(ctx context.Context) error {
 // Initialize initializes MCP clients based on the provided configuration.
 func Initialize(ctx context.Context, permissions permission.Service, cfg *config.Config) {
 	slog.Info("Initializing MCP clients")
+	samplingPermissions.Set(permissions)
+	SetRoots(cfg.WorkspaceRoots())
 	var wg sync.WaitGroup
 	// Initialize states for all configured MCPs
 	for name, m := range cfg.MCP {
@@ -163,69 +168,91 @@ func Initialize(ctx context.Context, permissions permission.Service, cfg *config
 		// Set initial starting state
 		updateState(name, StateStarting, nil, nil, Counts{})
 
-		wg.Add(1)
-		go func(name string, m config.MCPConfig) {
-			defer func() {
-				wg.Done()
-				if r := recover(); r != nil {
-					var err error
-					switch v := r.(type) {
-					case error:
-						err = v
-					case string:
-						err = fmt.Errorf("panic: %s", v)
-					default:
-						err = fmt.Errorf("panic: %v", v)
-					}
-					updateState(name, StateError, err, nil, Counts{})
-					slog.Error("Panic in MCP client initialization", "error", err, "name", name)
-				}
-			}()
+		wg.Go(func() {
+			initClient(ctx, cfg, name, m)
+		})
+	}
+	wg.Wait()
+	initOnce.Do(func() { close(initDone) })
+}
 
-			// createSession handles its own timeout internally.
-			session, err := createSession(ctx, name, m, cfg.Resolver())
-			if err != nil {
-				return
+// initClient connects to the named MCP server and loads its tools, prompts
+// and resources, reporting progress through state updates.
+func initClient(ctx context.Context, cfg *config.Config, name string, m config.MCPConfig) {
+	defer func() {
+		if r := recover(); r != nil {
+			var err error
+			switch v := r.(type) {
+			case error:
+				err = v
+			case string:
+				err = fmt.Errorf("panic: %s", v)
+			default:
+				err = fmt.Errorf("panic: %v", v)
 			}
+			updateState(name, StateError, err, nil, Counts{})
+			slog.Error("Panic in MCP client initialization", "error", err, "name", name)
+		}
+	}()
 
-			tools, err := getTools(ctx, session)
-			if err != nil {
-				slog.Error("Error listing tools", "error", err)
-				updateState(name, StateError, err, nil, Counts{})
-				session.Close()
-				return
-			}
+	// createSession handles its own timeout internally.
+	session, err := createSession(ctx, name, m, cfg.Resolver())
+	if err != nil {
+		return
+	}
 
-			prompts, err := getPrompts(ctx, session)
-			if err != nil {
-				slog.Error("Error listing prompts", "error", err)
-				updateState(name, StateError, err, nil, Counts{})
-				session.Close()
-				return
-			}
+	tools, err := getTools(ctx, session)
+	if err != nil {
+		slog.Error("Error listing tools", "error", err)
+		updateState(name, StateError, err, nil, Counts{})
+		session.Close()
+		return
+	}
 
-			resources, err := getResources(ctx, session)
-			if err != nil {
-				slog.Error("Error listing resources", "error", err)
-				updateState(name, StateError, err, nil, Counts{})
-				session.Close()
-				return
-			}
+	prompts, err := getPrompts(ctx, session)
+	if err != nil {
+		slog.Error("Error listing prompts", "error", err)
+		updateState(name, StateError, err, nil, Counts{})
+		session.Close()
+		return
+	}
 
-			toolCount := updateTools(cfg, name, tools)
-			updatePrompts(name, prompts)
-			resourceCount := updateResources(name, resources)
-			sessions.Set(name, session)
-
-			updateState(name, StateConnected, nil, session, Counts{
-				Tools:     toolCount,
-				Prompts:   len(prompts),
-				Resources: resourceCount,
-			})
-		}(name, m)
+	resources, err := getResources(ctx, session)
+	if err != nil {
+		slog.Error("Error listing resources", "error", err)
+		updateState(name, StateError, err, nil, Counts{})
+		session.Close()
+		return
 	}
-	wg.Wait()
-	initOnce.Do(func() { close(initDone) })
+
+	toolCount := updateTools(cfg, name, tools)
+	updatePrompts(name, prompts)
+	resourceCount := updateResources(name, resources)
+	sessions.Set(name, session)
+
+	updateState(name, StateConnected, nil, session, Counts{
+		Tools:     toolCount,
+		Prompts:   len(prompts),
+		Resources: resourceCount,
+	})
+}
+
+// Reconnect closes the named MCP client, if connected, and connects it again,
+// for example after the user authorized it.
+func Reconnect(ctx context.Context, cfg *config.Config, name string) error {
+	m, ok := cfg.MCP[name]
+	if !ok {
+		return fmt.Errorf("mcp '%s' not configured", name)
+	}
+	if sess, ok := sessions.Take(name); ok {
+		sess.Close()
+	}
+	updateState(name, StateStarting, nil, nil, Counts{})
+	initClient(ctx, cfg, name, m)
+	if state, ok := states.Get(name); ok && state.State != StateConnected {
+		return cmp.Or(state.Error, fmt.Errorf("mcp '%s' failed to connect", name))
+	}
+	return nil
 }
 
 // WaitForInit blocks until MCP initialization is complete.
@@ -279,7 +306,7 @@ func updateState(name string, state State, err error, client *mcp.ClientSession,
 	switch state {
 	case StateConnected:
 		info.ConnectedAt = time.Now()
-	case StateError:
+	case StateError, StateUnauthorized:
 		sessions.Del(name)
 	}
 	states.Set(name, info)
@@ -296,10 +323,11 @@ func updateState(name string, state State, err error, client *mcp.ClientSession,
 
 func createSession(ctx context.Context, name string, m config.MCPConfig, resolver config.VariableResolver) (*mcp.ClientSession, error) {
 	timeout := mcpTimeout(m)
+	unauthorized.Del(name)
 	mcpCtx, cancel := context.WithCancel(ctx)
 	cancelTimer := time.AfterFunc(timeout, cancel)
 
-	transport, err := createTransport(mcpCtx, m, resolver)
+	transport, err := createTransport(mcpCtx, name, m, resolver)
 	if err != nil {
 		updateState(name, StateError, err, nil, Counts{})
 		slog.Error("Error creating MCP client", "error", err, "name", name)
@@ -337,12 +365,24 @@ func createSession(ctx context.Context, name string, m config.MCPConfig, resolve
 				level := parseLevel(req.Params.Level)
 				slog.Log(ctx, level, "MCP log", "name", name, "logger", req.Params.Logger, "data", req.Params.Data)
 			},
+			CreateMessageHandler: samplingHandler(name, m),
 		},
 	)
+	// Register the client before adding the roots so that a concurrent
+	// SetRoots either sees the client or has already updated the roots.
+	clients.Set(name, client)
+	client.AddRoots(currentRoots()...)
 
 	session, err := client.Connect(mcpCtx, transport, nil)
 	if err != nil {
 		err = maybeStdioErr(err, transport)
+		if _, ok := unauthorized.Get(name); ok {
+			updateState(name, StateUnauthorized, err, nil, Counts{})
+			slog.Warn("MCP server requires authorization", "name", name)
+			cancel()
+			cancelTimer.Stop()
+			return nil, err
+		}
 		updateState(name, StateError, maybeTimeoutErr(err, timeout), nil, Counts{})
 		slog.Error("MCP client failed to initialize", "error", err, "name", name)
 		cancel()
@@ -383,7 +423,7 @@ func maybeTimeoutErr(err error, timeout time.Duration) error {
 	return err
 }
 
-func createTransport(ctx context.Context, m config.MCPConfig, resolver config.VariableResolver) (mcp.Transport, error) {
+func createTransport(ctx context.Context, name string, m config.MCPConfig, resolver config.VariableResolver) (mcp.Transport, error) {
 	switch m.Type {
 	case config.MCPStdio:
 		command, err := resolver.ResolveValue(m.Command)
@@ -403,8 +443,12 @@ func createTransport(ctx context.Context, m config.MCPConfig, resolver config.Va
 			return nil, fmt.Errorf("mcp http config requires a non-empty 'url' field")
 		}
 		client := &http.Client{
-			Transport: &headerRoundTripper{
-				headers: m.ResolvedHeaders(),
+			Transport: &oauthRoundTripper{
+				name: name,
+				url:  m.URL,
+				base: &headerRoundTripper{
+					headers: m.ResolvedHeaders(),
+				},
 			},
 		}
 		return &mcp.StreamableClientTransport{
@@ -416,8 +460,12 @@ func createTransport(ctx context.Context, m config.MCPConfig, resolver config.Va
 			return nil, fmt.Errorf("mcp sse config requires a non-empty 'url' field")
 		}
 		client := &http.Client{
-			Transport: &headerRoundTripper{
-				headers: m.ResolvedHeaders(),
+			Transport: &oauthRoundTripper{
+				name: name,
+				url:  m.URL,
+				base: &headerRoundTripper{
+					headers: m.ResolvedHeaders(),
+				},
 			},
 		}
 		return &mcp.SSEClientTransport{
@@ -0,0 +1,145 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/purpose168/crush-cn/internal/config"
+	"github.com/purpose168/crush-cn/internal/csync"
+	"github.com/purpose168/crush-cn/internal/oauth"
+	"github.com/purpose168/crush-cn/internal/oauth/mcpauth"
+)
+
+// oauthStoreFile is the name of the file, in the global data directory,
+// holding OAuth credentials for MCP servers.
+const oauthStoreFile = "mcp-oauth.json"
+
+var (
+	// oauthStore holds OAuth credentials keyed by MCP server URL.
+	oauthStore = sync.OnceValue(func() *mcpauth.Store {
+		return mcpauth.NewStore(filepath.Join(filepath.Dir(config.GlobalConfigData()), oauthStoreFile))
+	})
+
+	// unauthorized holds the MCP servers that answered with 401 Unauthorized,
+	// along with the resource metadata URL from their challenge, if any.
+	unauthorized = csync.NewMap[string, string]()
+
+	// oauthHTTPClient is used for discovery, registration and token requests.
+	oauthHTTPClient = &http.Client{Timeout: 30 * time.Second}
+)
+
+// oauthRoundTripper authenticates requests to an MCP server with the stored
+// OAuth token, refreshing it when it expires, and records 401 responses so
+// the user can be asked to authorize the server.
+type oauthRoundTripper struct {
+	name string
+	url  string
+	base http.RoundTripper
+
+	mu sync.Mutex
+}
+
+func (rt *oauthRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	if token := rt.accessToken(req.Context()); token != "" {
+		req = req.Clone(req.Context())
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := rt.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusUnauthorized {
+		unauthorized.Set(rt.name, mcpauth.ResourceMetadataURL(resp.Header))
+	}
+	return resp, nil
+}
+
+// accessToken returns the stored access token for the server, refreshing it
+// first if it expired. A failed refresh keeps the old token, so the server
+// answers with 401 and the user is asked to authorize again.
+func (rt *oauthRoundTripper) accessToken(ctx context.Context) string {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+
+	creds, ok := oauthStore().Load(rt.url)
+	if !ok || creds.Token == nil {
+		return ""
+	}
+	if creds.NeedsRefresh() {
+		token, err := mcpauth.Refresh(ctx, oauthHTTPClient, creds, rt.url)
+		if err != nil {
+			slog.Warn("Failed to refresh MCP OAuth token", "name", rt.name, "error", err)
+			return creds.Token.AccessToken
+		}
+		creds.Token = token
+		if err := oauthStore().Save(rt.url, creds); err != nil {
+			slog.Error("Failed to save MCP OAuth token", "name", rt.name, "error", err)
+		}
+	}
+	return creds.Token.AccessToken
+}
+
+// Authorization is an OAuth authorization in progress for an MCP server.
+type Authorization struct {
+	*mcpauth.Authorization
+
+	url   string
+	creds mcpauth.Credentials
+}
+
+// Save stores the token obtained by the authorization, along with the client
+// credentials used to refresh it.
+func (a *Authorization) Save(token *oauth.Token) error {
+	a.creds.Token = token
+	return oauthStore().Save(a.url, a.creds)
+}
+
+// Authorize starts an OAuth authorization for the named HTTP or SSE MCP
+// server. Call [Authorization.Wait] to wait for the user, then
+// [Authorization.Save] and [Reconnect].
+func Authorize(ctx context.Context, cfg *config.Config, name string) (*Authorization, error) {
+	m, ok := cfg.MCP[name]
+	if !ok {
+		return nil, fmt.Errorf("mcp '%s' not configured", name)
+	}
+	if m.Type != config.MCPHttp && m.Type != config.MCPSSE {
+		return nil, fmt.Errorf("mcp '%s' does not support oauth: only http and sse servers do", name)
+	}
+
+	resourceMetadata, _ := unauthorized.Get(name)
+	meta, err := mcpauth.Discover(ctx, oauthHTTPClient, m.URL, resourceMetadata)
+	if err != nil {
+		return nil, err
+	}
+
+	creds, _ := oauthStore().Load(m.URL)
+	var scopes []string
+	if m.OAuth != nil {
+		scopes = m.OAuth.Scopes
+		if m.OAuth.ClientID != "" {
+			creds.ClientID, creds.ClientSecret = m.OAuth.ClientID, ""
+		}
+		if m.OAuth.ClientSecret != "" {
+			secret, err := cfg.Resolver().ResolveValue(m.OAuth.ClientSecret)
+			if err != nil {
+				return nil, fmt.Errorf("invalid mcp oauth client secret: %w", err)
+			}
+			creds.ClientSecret = secret
+		}
+	}
+
+	auth, err := mcpauth.Authorize(ctx, oauthHTTPClient, m.URL, meta, &creds, scopes)
+	if err != nil {
+		return nil, err
+	}
+	return &Authorization{
+		Authorization: auth,
+		url:           m.URL,
+		creds:         creds,
+	}, nil
+}
@@ -0,0 +1,84 @@
+package mcp
+
+import (
+	"log/slog"
+	"net/url"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/purpose168/crush-cn/internal/csync"
+)
+
+var (
+	// clients holds the MCP client of each server, used to keep the roots
+	// advertised to the server up to date.
+	clients = csync.NewMap[string, *mcp.Client]()
+
+	rootsMu sync.Mutex
+	// roots are the workspace roots advertised to every MCP server.
+	roots []*mcp.Root
+)
+
+// SetRoots sets the workspace roots advertised to MCP servers. Connected
+// servers are sent a roots/list_changed notification when the list changes.
+func SetRoots(dirs []string) {
+	next := make([]*mcp.Root, 0, len(dirs))
+	for _, dir := range dirs {
+		root := newRoot(dir)
+		if !slices.ContainsFunc(next, func(r *mcp.Root) bool { return r.URI == root.URI }) {
+			next = append(next, root)
+		}
+	}
+
+	rootsMu.Lock()
+	prev := roots
+	roots = next
+	rootsMu.Unlock()
+
+	var removed []string
+	for _, r := range prev {
+		if !slices.ContainsFunc(next, func(n *mcp.Root) bool { return n.URI == r.URI }) {
+			removed = append(removed, r.URI)
+		}
+	}
+	var added []*mcp.Root
+	for _, r := range next {
+		if !slices.ContainsFunc(prev, func(p *mcp.Root) bool { return p.URI == r.URI }) {
+			added = append(added, r)
+		}
+	}
+	if len(removed) == 0 && len(added) == 0 {
+		return
+	}
+
+	for name, client := range clients.Seq2() {
+		slog.Debug("Updating MCP roots", "name", name, "added", len(added), "removed", len(removed))
+		if len(removed) > 0 {
+			client.RemoveRoots(removed...)
+		}
+		client.AddRoots(added...)
+	}
+}
+
+// currentRoots returns the workspace roots advertised to MCP servers.
+func currentRoots() []*mcp.Root {
+	rootsMu.Lock()
+	defer rootsMu.Unlock()
+	return slices.Clone(roots)
+}
+
+// newRoot returns the root for the given absolute directory.
+func newRoot(dir string) *mcp.Root {
+	path := filepath.ToSlash(dir)
+	// Windows paths like C:/src need a leading slash in file URIs.
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	return &mcp.Root{
+		Name: filepath.Base(dir),
+		URI:  (&url.URL{Scheme: "file", Path: path}).String(),
+	}
+}
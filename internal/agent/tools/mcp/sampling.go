@@ -0,0 +1,121 @@
+package mcp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/purpose168/crush-cn/internal/config"
+	"github.com/purpose168/crush-cn/internal/csync"
+	"github.com/purpose168/crush-cn/internal/permission"
+)
+
+// SamplingToolName is the tool name used in permission requests for MCP
+// sampling. The action of the request is the name of the MCP server, so
+// permissions can be granted per server.
+const SamplingToolName = "mcp_sampling"
+
+// SamplingPermissionsParams describes a sampling request in the permission
+// prompt.
+type SamplingPermissionsParams struct {
+	MCPName      string `json:"mcp_name"`
+	SystemPrompt string `json:"system_prompt"`
+	Messages     string `json:"messages"`
+	MaxTokens    int64  `json:"max_tokens"`
+}
+
+// Sampler generates a completion with the configured model for a sampling
+// request from an MCP server.
+type Sampler func(ctx context.Context, params *mcp.CreateMessageParams) (*mcp.CreateMessageResult, error)
+
+var (
+	sampler = csync.NewValue[Sampler](nil)
+	// samplingPermissions asks the user to approve sampling requests.
+	samplingPermissions = csync.NewValue[permission.Service](nil)
+)
+
+// SetSampler sets the function used to answer sampling requests from MCP
+// servers. Requests fail until a sampler is set.
+func SetSampler(fn Sampler) {
+	sampler.Set(fn)
+}
+
+// samplingHandler returns the handler for sampling requests from the named
+// MCP server, or nil when sampling is disabled for it so the capability is
+// not advertised.
+func samplingHandler(name string, m config.MCPConfig) func(context.Context, *mcp.CreateMessageRequest) (*mcp.CreateMessageResult, error) {
+	if m.Sampling == config.MCPSamplingDeny {
+		return nil
+	}
+	return func(ctx context.Context, req *mcp.CreateMessageRequest) (*mcp.CreateMessageResult, error) {
+		sample := sampler.Get()
+		if sample == nil {
+			return nil, errors.New("sampling is not available yet")
+		}
+		params := req.Params
+		if params == nil || len(params.Messages) == 0 {
+			return nil, errors.New("sampling request has no messages")
+		}
+
+		if m.Sampling != config.MCPSamplingAllow {
+			permissions := samplingPermissions.Get()
+			if permissions == nil {
+				return nil, errors.New("sampling is not available yet")
+			}
+			granted, err := permissions.Request(ctx, permission.CreatePermissionRequest{
+				ToolName:    SamplingToolName,
+				Action:      name,
+				Description: fmt.Sprintf("MCP server %s requests a completion from the model", name),
+				Path:        ".",
+				Params: SamplingPermissionsParams{
+					MCPName:      name,
+					SystemPrompt: params.SystemPrompt,
+					Messages:     FormatSamplingMessages(params.Messages),
+					MaxTokens:    params.MaxTokens,
+				},
+			})
+			if err != nil {
+				return nil, err
+			}
+			if !granted {
+				return nil, errors.New("user denied the sampling request")
+			}
+		}
+
+		slog.Debug("Running MCP sampling request", "name", name, "messages", len(params.Messages))
+		result, err := sample(ctx, params)
+		if err != nil {
+			slog.Error("MCP sampling request failed", "name", name, "error", err)
+			return nil, err
+		}
+		return result, nil
+	}
+}
+
+// FormatSamplingMessages renders sampling messages as plain text, one block
+// per message prefixed by its role. Non-text content is shown as a
+// placeholder with its MIME type.
+func FormatSamplingMessages(messages []*mcp.SamplingMessage) string {
+	blocks := make([]string, 0, len(messages))
+	for _, msg := range messages {
+		if msg == nil {
+			continue
+		}
+		var content string
+		switch c := msg.Content.(type) {
+		case *mcp.TextContent:
+			content = c.Text
+		case *mcp.ImageContent:
+			content = fmt.Sprintf("[image %s]", c.MIMEType)
+		case *mcp.AudioContent:
+			content = fmt.Sprintf("[audio %s]", c.MIMEType)
+		default:
+			content = "[unsupported content]"
+		}
+		blocks = append(blocks, fmt.Sprintf("[%s]\n%s", msg.Role, strings.TrimSpace(content)))
+	}
+	return strings.Join(blocks, "\n\n")
+}
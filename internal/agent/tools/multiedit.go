@@ -12,12 +12,14 @@ import (
 
 	"charm.land/fantasy"
 	"github.com/purpose168/crush-cn/internal/diff"
+	"github.com/purpose168/crush-cn/internal/dryrun"
 	"github.com/purpose168/crush-cn/internal/filepathext"
 	"github.com/purpose168/crush-cn/internal/filetracker"
 	"github.com/purpose168/crush-cn/internal/fsext"
 	"github.com/purpose168/crush-cn/internal/history"
 	"github.com/purpose168/crush-cn/internal/lsp"
 	"github.com/purpose168/crush-cn/internal/permission"
+	"github.com/purpose168/crush-cn/internal/vfs"
 )
 
 type MultiEditOperation struct {
@@ -27,6 +29,13 @@ type MultiEditOperation struct {
 }
 
 type MultiEditParams struct {
+	FilePath string                `json:"file_path,omitempty" description:"要修改的文件的绝对路径（使用files时省略）"`
+	Edits    []MultiEditOperation  `json:"edits,omitempty" description:"要在文件上顺序执行的编辑操作数组（使用files时省略）"`
+	Files    []MultiEditFileParams `json:"files,omitempty" description:"跨多个文件编辑时使用：每项包含一个文件的路径和要在该文件上顺序执行的编辑操作"`
+}
+
+// MultiEditFileParams 是多文件编辑中单个文件的编辑操作
+type MultiEditFileParams struct {
 	FilePath string               `json:"file_path" description:"要修改的文件的绝对路径"`
 	Edits    []MultiEditOperation `json:"edits" description:"要在文件上顺序执行的编辑操作数组"`
 }
@@ -35,12 +44,15 @@ type MultiEditPermissionsParams struct {
 	FilePath   string `json:"file_path"`
 	OldContent string `json:"old_content,omitempty"`
 	NewContent string `json:"new_content,omitempty"`
+	// Files 是多文件编辑时每个文件的更改，单文件编辑时为空
+	Files []WorkspaceEditFile `json:"files,omitempty"`
 }
 
 type FailedEdit struct {
-	Index int                `json:"index"`
-	Error string             `json:"error"`
-	Edit  MultiEditOperation `json:"edit"`
+	FilePath string             `json:"file_path,omitempty"`
+	Index    int                `json:"index"`
+	Error    string             `json:"error"`
+	Edit     MultiEditOperation `json:"edit"`
 }
 
 type MultiEditResponseMetadata struct {
@@ -50,6 +62,8 @@ type MultiEditResponseMetadata struct {
 	NewContent   string       `json:"new_content,omitempty"`
 	EditsApplied int          `json:"edits_applied"`
 	EditsFailed  []FailedEdit `json:"edits_failed,omitempty"`
+	// Files 是多文件编辑时每个文件的更改，单文件编辑时为空
+	Files []WorkspaceEditFile `json:"files,omitempty"`
 }
 
 const MultiEditToolName = "multiedit"
@@ -62,18 +76,39 @@ var multieditDescription []byte
 // permissions: 权限服务
 // files: 文件历史服务
 // filetracker: 文件跟踪服务
+// dryRun: 演练服务，开启时不修改文件而是写入补丁文件
+// fsys: 读写文件使用的文件系统，为 nil 时使用本地文件系统
 // workingDir: 工作目录
 func NewMultiEditTool(
 	lspManager *lsp.Manager,
 	permissions permission.Service,
 	files history.Service,
 	filetracker filetracker.Service,
+	dryRun dryrun.Service,
+	fsys vfs.FS,
 	workingDir string,
 ) fantasy.AgentTool {
+	fsys = vfs.OrLocal(fsys)
 	return fantasy.NewAgentTool(
 		MultiEditToolName,
 		string(multieditDescription),
 		func(ctx context.Context, params MultiEditParams, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+			if len(params.Files) > 0 {
+				if params.FilePath != "" || len(params.Edits) > 0 {
+					return fantasy.NewTextErrorResponse("file_path/edits 不能与 files 同时使用"), nil
+				}
+				editCtx := editContext{ctx, permissions, files, filetracker, dryRun, fsys, workingDir}
+				response, err := processMultiEditFiles(editCtx, params.Files, call)
+				if err != nil || response.IsError || dryRunActive(dryRun) {
+					return response, err
+				}
+				for _, file := range params.Files {
+					notifyLSPs(ctx, lspManager, filepathext.SmartJoin(workingDir, file.FilePath))
+				}
+				response.Content += getDiagnostics(filepathext.SmartJoin(workingDir, params.Files[0].FilePath), lspManager)
+				return response, nil
+			}
+
 			if params.FilePath == "" {
 				return fantasy.NewTextErrorResponse("file_path是必需的"), nil
 			}
@@ -92,7 +127,7 @@ func NewMultiEditTool(
 			var response fantasy.ToolResponse
 			var err error
 
-			editCtx := editContext{ctx, permissions, files, filetracker, workingDir}
+			editCtx := editContext{ctx, permissions, files, filetracker, dryRun, fsys, workingDir}
 			// 处理文件创建情况（第一个编辑的old_string为空）
 			if len(params.Edits) > 0 && params.Edits[0].OldString == "" {
 				response, err = processMultiEditWithCreation(editCtx, params, call)
@@ -107,6 +142,11 @@ func NewMultiEditTool(
 			if response.IsError {
 				return response, nil
 			}
+			if dryRunActive(dryRun) {
+				// 文件没有变化，无需通知LSP
+				response.Content = fmt.Sprintf("<result>\n%s\n</result>\n", response.Content)
+				return response, nil
+			}
 
 			// 通知LSP客户端有关更改
 			notifyLSPs(ctx, lspManager, params.FilePath)
@@ -145,7 +185,7 @@ func processMultiEditWithCreation(edit editContext, params MultiEditParams, call
 	}
 
 	// 检查文件是否已存在
-	if _, err := os.Stat(params.FilePath); err == nil {
+	if _, err := statFile(edit.ctx, edit.fsys, edit.dryRun, params.FilePath); err == nil {
 		return fantasy.NewTextErrorResponse(fmt.Sprintf("文件已存在: %s", params.FilePath)), nil
 	} else if !os.IsNotExist(err) {
 		return fantasy.ToolResponse{}, fmt.Errorf("访问文件失败: %w", err)
@@ -153,7 +193,7 @@ func processMultiEditWithCreation(edit editContext, params MultiEditParams, call
 
 	// 创建父目录
 	dir := filepath.Dir(params.FilePath)
-	if err := os.MkdirAll(dir, 0o755); err != nil {
+	if err := mkdirAll(edit.ctx, edit.fsys, edit.dryRun, dir); err != nil {
 		return fantasy.ToolResponse{}, fmt.Errorf("创建父目录失败: %w", err)
 	}
 
@@ -212,8 +252,26 @@ func processMultiEditWithCreation(edit editContext, params MultiEditParams, call
 		return fantasy.ToolResponse{}, permission.ErrorPermissionDenied
 	}
 
+	var message string
+	if len(failedEdits) > 0 {
+		message = fmt.Sprintf("文件已创建，应用了 %d/%d 个编辑: %s （%d 个编辑失败）", editsApplied, len(params.Edits), params.FilePath, len(failedEdits))
+	} else {
+		message = fmt.Sprintf("文件已创建，应用了 %d 个编辑: %s", len(params.Edits), params.FilePath)
+	}
+	metadata := MultiEditResponseMetadata{
+		OldContent:   "",
+		NewContent:   currentContent,
+		Additions:    additions,
+		Removals:     removals,
+		EditsApplied: editsApplied,
+		EditsFailed:  failedEdits,
+	}
+	if dryRunActive(edit.dryRun) {
+		return dryRunResponse(edit.ctx, edit.dryRun, edit.filetracker, params.FilePath, currentContent, message, metadata)
+	}
+
 	// 写入文件
-	err = os.WriteFile(params.FilePath, []byte(currentContent), 0o644)
+	err = edit.fsys.WriteFile(edit.ctx, params.FilePath, []byte(currentContent), 0o644)
 	if err != nil {
 		return fantasy.ToolResponse{}, fmt.Errorf("写入文件失败: %w", err)
 	}
@@ -231,24 +289,7 @@ func processMultiEditWithCreation(edit editContext, params MultiEditParams, call
 
 	edit.filetracker.RecordRead(edit.ctx, sessionID, params.FilePath)
 
-	var message string
-	if len(failedEdits) > 0 {
-		message = fmt.Sprintf("文件已创建，应用了 %d/%d 个编辑: %s （%d 个编辑失败）", editsApplied, len(params.Edits), params.FilePath, len(failedEdits))
-	} else {
-		message = fmt.Sprintf("文件已创建，应用了 %d 个编辑: %s", len(params.Edits), params.FilePath)
-	}
-
-	return fantasy.WithResponseMetadata(
-		fantasy.NewTextResponse(message),
-		MultiEditResponseMetadata{
-			OldContent:   "",
-			NewContent:   currentContent,
-			Additions:    additions,
-			Removals:     removals,
-			EditsApplied: editsApplied,
-			EditsFailed:  failedEdits,
-		},
-	), nil
+	return fantasy.WithResponseMetadata(fantasy.NewTextResponse(message), metadata), nil
 }
 
 // processMultiEditExistingFile 处理对现有文件的多重编辑操作
@@ -258,7 +299,7 @@ func processMultiEditWithCreation(edit editContext, params MultiEditParams, call
 // 返回工具响应
 func processMultiEditExistingFile(edit editContext, params MultiEditParams, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
 	// 验证文件存在且可读
-	fileInfo, err := os.Stat(params.FilePath)
+	fileInfo, err := statFile(edit.ctx, edit.fsys, edit.dryRun, params.FilePath)
 	if err != nil {
 		if os.IsNotExist(err) {
 			return fantasy.NewTextErrorResponse(fmt.Sprintf("文件未找到: %s", params.FilePath)), nil
@@ -291,7 +332,7 @@ func processMultiEditExistingFile(edit editContext, params MultiEditParams, call
 	}
 
 	// 读取当前文件内容
-	content, err := os.ReadFile(params.FilePath)
+	content, err := readFile(edit.ctx, edit.fsys, edit.dryRun, params.FilePath)
 	if err != nil {
 		return fantasy.ToolResponse{}, fmt.Errorf("读取文件失败: %w", err)
 	}
@@ -363,8 +404,26 @@ func processMultiEditExistingFile(edit editContext, params MultiEditParams, call
 		currentContent, _ = fsext.ToWindowsLineEndings(currentContent)
 	}
 
+	var message string
+	if len(failedEdits) > 0 {
+		message = fmt.Sprintf("已对文件应用 %d/%d 个编辑: %s （%d 个编辑失败）", editsApplied, len(params.Edits), params.FilePath, len(failedEdits))
+	} else {
+		message = fmt.Sprintf("已对文件应用 %d 个编辑: %s", len(params.Edits), params.FilePath)
+	}
+	metadata := MultiEditResponseMetadata{
+		OldContent:   oldContent,
+		NewContent:   currentContent,
+		Additions:    additions,
+		Removals:     removals,
+		EditsApplied: editsApplied,
+		EditsFailed:  failedEdits,
+	}
+	if dryRunActive(edit.dryRun) {
+		return dryRunResponse(edit.ctx, edit.dryRun, edit.filetracker, params.FilePath, currentContent, message, metadata)
+	}
+
 	// 写入更新的内容
-	err = os.WriteFile(params.FilePath, []byte(currentContent), 0o644)
+	err = edit.fsys.WriteFile(edit.ctx, params.FilePath, []byte(currentContent), 0o644)
 	if err != nil {
 		return fantasy.ToolResponse{}, fmt.Errorf("写入文件失败: %w", err)
 	}
@@ -393,26 +452,218 @@ func processMultiEditExistingFile(edit editContext, params MultiEditParams, call
 
 	edit.filetracker.RecordRead(edit.ctx, sessionID, params.FilePath)
 
-	var message string
-	if len(failedEdits) > 0 {
-		message = fmt.Sprintf("已对文件应用 %d/%d 个编辑: %s （%d 个编辑失败）", editsApplied, len(params.Edits), params.FilePath, len(failedEdits))
+	return fantasy.WithResponseMetadata(fantasy.NewTextResponse(message), metadata), nil
+}
+
+// multiEditFileChange 是多文件编辑中单个文件的预期更改
+type multiEditFileChange struct {
+	WorkspaceEditFile
+	created bool
+	isCrlf  bool
+	failed  []FailedEdit
+}
+
+// processMultiEditFiles 处理跨多个文件的多重编辑操作
+// 所有文件的编辑先在内存中计算，经用户一次性批准后再全部写入
+// edit: 编辑上下文
+// files: 每个文件的编辑操作
+// call: 工具调用信息
+// 返回工具响应
+func processMultiEditFiles(edit editContext, files []MultiEditFileParams, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+	sessionID := GetSessionFromContext(edit.ctx)
+	if sessionID == "" {
+		return fantasy.ToolResponse{}, fmt.Errorf("编辑文件需要会话ID")
+	}
+
+	var (
+		changes    []multiEditFileChange
+		meta       MultiEditResponseMetadata
+		totalEdits int
+		seen       = make(map[string]bool, len(files))
+	)
+	for _, file := range files {
+		if file.FilePath == "" {
+			return fantasy.NewTextErrorResponse("files 中的每一项都需要file_path"), nil
+		}
+		if len(file.Edits) == 0 {
+			return fantasy.NewTextErrorResponse(fmt.Sprintf("文件 %s 至少需要一个编辑操作", file.FilePath)), nil
+		}
+		file.FilePath = filepathext.SmartJoin(edit.workingDir, file.FilePath)
+		if seen[file.FilePath] {
+			return fantasy.NewTextErrorResponse(fmt.Sprintf("文件 %s 在files中出现多次，请将它的编辑合并到一项中", file.FilePath)), nil
+		}
+		seen[file.FilePath] = true
+		if err := validateEdits(file.Edits); err != nil {
+			return fantasy.NewTextErrorResponse(fmt.Sprintf("%s: %s", file.FilePath, err)), nil
+		}
+
+		change, msg, err := prepareMultiEditFile(edit, sessionID, file)
+		if err != nil {
+			return fantasy.ToolResponse{}, err
+		}
+		if msg != "" {
+			return fantasy.NewTextErrorResponse(msg), nil
+		}
+
+		totalEdits += len(file.Edits)
+		meta.EditsApplied += len(file.Edits) - len(change.failed)
+		meta.EditsFailed = append(meta.EditsFailed, change.failed...)
+		if !change.created && change.OldContent == change.NewContent {
+			continue
+		}
+		changes = append(changes, change)
+		meta.Files = append(meta.Files, change.WorkspaceEditFile)
+		meta.Additions += change.Additions
+		meta.Removals += change.Removals
+	}
+
+	if len(changes) == 0 {
+		if len(meta.EditsFailed) > 0 {
+			return fantasy.WithResponseMetadata(
+				fantasy.NewTextErrorResponse(fmt.Sprintf("未做任何更改 - 所有 %d 个编辑都失败了", len(meta.EditsFailed))),
+				MultiEditResponseMetadata{EditsFailed: meta.EditsFailed},
+			), nil
+		}
+		return fantasy.NewTextErrorResponse("未做任何更改 - 所有编辑都导致内容相同"), nil
+	}
+
+	var description string
+	if len(meta.EditsFailed) > 0 {
+		description = fmt.Sprintf("对 %d 个文件应用 %d/%d 个编辑（%d 个失败）", len(changes), meta.EditsApplied, totalEdits, len(meta.EditsFailed))
 	} else {
-		message = fmt.Sprintf("已对文件应用 %d 个编辑: %s", len(params.Edits), params.FilePath)
+		description = fmt.Sprintf("对 %d 个文件应用 %d 个编辑", len(changes), meta.EditsApplied)
+	}
+	p, err := edit.permissions.Request(edit.ctx, permission.CreatePermissionRequest{
+		SessionID:   sessionID,
+		Path:        fsext.PathOrPrefix(changes[0].FilePath, edit.workingDir),
+		ToolCallID:  call.ID,
+		ToolName:    MultiEditToolName,
+		Action:      "write",
+		Description: description,
+		Params: MultiEditPermissionsParams{
+			FilePath: changes[0].FilePath,
+			Files:    meta.Files,
+		},
+	})
+	if err != nil {
+		return fantasy.ToolResponse{}, err
+	}
+	if !p {
+		return fantasy.ToolResponse{}, permission.ErrorPermissionDenied
+	}
+
+	var output, dryRunOutput strings.Builder
+	fmt.Fprintf(&output, "已对 %d 个文件应用 %d 个编辑:\n", len(changes), meta.EditsApplied)
+	for _, change := range changes {
+		content := change.NewContent
+		if change.isCrlf {
+			content, _ = fsext.ToWindowsLineEndings(content)
+		}
+		if change.created {
+			fmt.Fprintf(&output, "- %s (已创建, +%d -%d)\n", change.FilePath, change.Additions, change.Removals)
+		} else {
+			fmt.Fprintf(&output, "- %s (+%d -%d)\n", change.FilePath, change.Additions, change.Removals)
+		}
+
+		if dryRunActive(edit.dryRun) {
+			msg, err := recordDryRun(edit.ctx, edit.dryRun, change.FilePath, content)
+			if err != nil {
+				return fantasy.ToolResponse{}, err
+			}
+			dryRunOutput.WriteString(msg + "\n")
+			edit.filetracker.RecordRead(edit.ctx, sessionID, change.FilePath)
+			continue
+		}
+
+		if change.created {
+			if err := edit.fsys.MkdirAll(edit.ctx, filepath.Dir(change.FilePath), 0o755); err != nil {
+				return fantasy.ToolResponse{}, fmt.Errorf("创建父目录失败: %w", err)
+			}
+		}
+		if err := edit.fsys.WriteFile(edit.ctx, change.FilePath, []byte(content), 0o644); err != nil {
+			return fantasy.ToolResponse{}, fmt.Errorf("写入文件 %s 失败: %w", change.FilePath, err)
+		}
+		recordFileVersion(edit.ctx, edit.files, edit.filetracker, sessionID, change.WorkspaceEditFile)
+	}
+	for _, failed := range meta.EditsFailed {
+		fmt.Fprintf(&output, "文件 %s 的编辑 %d 失败: %s\n", failed.FilePath, failed.Index, failed.Error)
 	}
 
+	text := output.String()
+	if dryRunActive(edit.dryRun) {
+		text = dryRunOutput.String() + "预期的更改：" + text
+	}
 	return fantasy.WithResponseMetadata(
-		fantasy.NewTextResponse(message),
-		MultiEditResponseMetadata{
-			OldContent:   oldContent,
-			NewContent:   currentContent,
-			Additions:    additions,
-			Removals:     removals,
-			EditsApplied: editsApplied,
-			EditsFailed:  failedEdits,
-		},
+		fantasy.NewTextResponse(fmt.Sprintf("<result>\n%s</result>\n", text)),
+		meta,
 	), nil
 }
 
+// prepareMultiEditFile 读取文件并在内存中应用编辑，返回预期的更改
+// 文件不满足编辑条件时返回告知模型的错误信息
+func prepareMultiEditFile(edit editContext, sessionID string, file MultiEditFileParams) (multiEditFileChange, string, error) {
+	change := multiEditFileChange{WorkspaceEditFile: WorkspaceEditFile{FilePath: file.FilePath}}
+
+	edits := file.Edits
+	fileInfo, err := statFile(edit.ctx, edit.fsys, edit.dryRun, file.FilePath)
+	switch {
+	case edits[0].OldString == "":
+		// 第一个编辑的old_string为空表示创建文件
+		if err == nil {
+			return change, fmt.Sprintf("文件已存在: %s", file.FilePath), nil
+		} else if !os.IsNotExist(err) {
+			return change, "", fmt.Errorf("访问文件失败: %w", err)
+		}
+		change.created = true
+		change.NewContent = edits[0].NewString
+		edits = edits[1:]
+	case err != nil:
+		if os.IsNotExist(err) {
+			return change, fmt.Sprintf("文件未找到: %s", file.FilePath), nil
+		}
+		return change, "", fmt.Errorf("访问文件失败: %w", err)
+	case fileInfo.IsDir():
+		return change, fmt.Sprintf("路径是目录，不是文件: %s", file.FilePath), nil
+	default:
+		// 检查文件在编辑前是否已被读取，且自上次读取后未被修改
+		lastRead := edit.filetracker.LastReadTime(edit.ctx, sessionID, file.FilePath)
+		if lastRead.IsZero() {
+			return change, fmt.Sprintf("编辑文件 %s 前必须先读取它。请先使用View工具", file.FilePath), nil
+		}
+		modTime := fileInfo.ModTime().Truncate(time.Second)
+		if modTime.After(lastRead) {
+			return change, fmt.Sprintf("文件 %s 自上次读取后已被修改（修改时间: %s, 上次读取: %s）",
+				file.FilePath, modTime.Format(time.RFC3339), lastRead.Format(time.RFC3339)), nil
+		}
+
+		content, err := readFile(edit.ctx, edit.fsys, edit.dryRun, file.FilePath)
+		if err != nil {
+			return change, "", fmt.Errorf("读取文件失败: %w", err)
+		}
+		change.OldContent, change.isCrlf = fsext.ToUnixLineEndings(string(content))
+		change.NewContent = change.OldContent
+	}
+
+	// 编号从文件的第一个编辑开始计算，与单文件编辑一致
+	offset := len(file.Edits) - len(edits)
+	for i, op := range edits {
+		newContent, err := applyEditToContent(change.NewContent, op)
+		if err != nil {
+			change.failed = append(change.failed, FailedEdit{
+				FilePath: file.FilePath,
+				Index:    offset + i + 1,
+				Error:    err.Error(),
+				Edit:     op,
+			})
+			continue
+		}
+		change.NewContent = newContent
+	}
+
+	_, change.Additions, change.Removals = diff.GenerateDiff(change.OldContent, change.NewContent, strings.TrimPrefix(file.FilePath, edit.workingDir))
+	return change, "", nil
+}
+
 // applyEditToContent 将编辑操作应用到内容
 // content: 原始内容
 // edit: 编辑操作
@@ -2,13 +2,17 @@ package tools
 
 import (
 	"context"
+	"encoding/json"
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
+	"charm.land/fantasy"
 	"github.com/purpose168/crush-cn/internal/history"
 	"github.com/purpose168/crush-cn/internal/permission"
 	"github.com/purpose168/crush-cn/internal/pubsub"
+	"github.com/purpose168/crush-cn/internal/vfs"
 	"github.com/stretchr/testify/require"
 )
 
@@ -26,8 +30,12 @@ func (m *mockPermissionService) Deny(req permission.PermissionRequest) {}
 
 func (m *mockPermissionService) GrantPersistent(req permission.PermissionRequest) {}
 
+func (m *mockPermissionService) GrantWindow(permission.PermissionRequest, permission.Window) {}
+
 func (m *mockPermissionService) AutoApproveSession(sessionID string) {}
 
+func (m *mockPermissionService) AllowTool(toolName string) {}
+
 func (m *mockPermissionService) SetSkipRequests(skip bool) {}
 
 func (m *mockPermissionService) SkipRequests() bool {
@@ -210,3 +218,105 @@ func TestMultiEditAllEditsFail(t *testing.T) {
 	require.Len(t, failedEdits, 2)
 	require.Equal(t, content, currentContent, "内容应该保持不变")
 }
+
+type mockFileTracker struct{}
+
+func (mockFileTracker) RecordRead(ctx context.Context, sessionID, path string) {}
+
+func (mockFileTracker) LastReadTime(ctx context.Context, sessionID, path string) time.Time {
+	return time.Now().Add(time.Minute)
+}
+
+func (mockFileTracker) ListReadFiles(ctx context.Context, sessionID string) ([]string, error) {
+	return nil, nil
+}
+
+type recordingPermissionService struct {
+	mockPermissionService
+	requests []permission.CreatePermissionRequest
+}
+
+func (m *recordingPermissionService) Request(ctx context.Context, req permission.CreatePermissionRequest) (bool, error) {
+	m.requests = append(m.requests, req)
+	return true, nil
+}
+
+func TestMultiEditFiles(t *testing.T) {
+	t.Parallel()
+
+	tmpDir := t.TempDir()
+	existing := filepath.Join(tmpDir, "a.txt")
+	require.NoError(t, os.WriteFile(existing, []byte("line 1\r\nline 2\r\n"), 0o644))
+	created := filepath.Join(tmpDir, "sub", "b.txt")
+
+	perms := &recordingPermissionService{}
+	edit := editContext{
+		ctx:         context.WithValue(t.Context(), SessionIDContextKey, "s1"),
+		permissions: perms,
+		files:       &mockHistoryService{},
+		filetracker: mockFileTracker{},
+		fsys:        vfs.Local{},
+		workingDir:  tmpDir,
+	}
+	resp, err := processMultiEditFiles(edit, []MultiEditFileParams{
+		{FilePath: "a.txt", Edits: []MultiEditOperation{
+			{OldString: "line 1", NewString: "LINE 1"},
+			{OldString: "line 99", NewString: "LINE 99"},
+		}},
+		{FilePath: created, Edits: []MultiEditOperation{
+			{NewString: "hello\n"},
+			{OldString: "hello", NewString: "world"},
+		}},
+	}, fantasy.ToolCall{ID: "call"})
+	require.NoError(t, err)
+	require.False(t, resp.IsError, resp.Content)
+
+	// 所有文件的更改通过一次权限请求批准。
+	require.Len(t, perms.requests, 1)
+	params, ok := perms.requests[0].Params.(MultiEditPermissionsParams)
+	require.True(t, ok)
+	require.Len(t, params.Files, 2)
+	require.Equal(t, existing, params.Files[0].FilePath)
+	require.Equal(t, "world\n", params.Files[1].NewContent)
+
+	content, err := os.ReadFile(existing)
+	require.NoError(t, err)
+	require.Equal(t, "LINE 1\r\nline 2\r\n", string(content))
+	content, err = os.ReadFile(created)
+	require.NoError(t, err)
+	require.Equal(t, "world\n", string(content))
+
+	var meta MultiEditResponseMetadata
+	require.NoError(t, json.Unmarshal([]byte(resp.Metadata), &meta))
+	require.Equal(t, 3, meta.EditsApplied)
+	require.Len(t, meta.EditsFailed, 1)
+	require.Equal(t, existing, meta.EditsFailed[0].FilePath)
+	require.Equal(t, 2, meta.EditsFailed[0].Index)
+	require.Len(t, meta.Files, 2)
+}
+
+func TestMultiEditFilesRejectsDuplicates(t *testing.T) {
+	t.Parallel()
+
+	tmpDir := t.TempDir()
+	path := filepath.Join(tmpDir, "a.txt")
+	require.NoError(t, os.WriteFile(path, []byte("line 1\n"), 0o644))
+
+	perms := &recordingPermissionService{}
+	edit := editContext{
+		ctx:         context.WithValue(t.Context(), SessionIDContextKey, "s1"),
+		permissions: perms,
+		files:       &mockHistoryService{},
+		filetracker: mockFileTracker{},
+		fsys:        vfs.Local{},
+		workingDir:  tmpDir,
+	}
+	resp, err := processMultiEditFiles(edit, []MultiEditFileParams{
+		{FilePath: path, Edits: []MultiEditOperation{{OldString: "line 1", NewString: "LINE 1"}}},
+		{FilePath: "a.txt", Edits: []MultiEditOperation{{OldString: "line 1", NewString: "LINE 1"}}},
+	}, fantasy.ToolCall{ID: "call"})
+	require.NoError(t, err)
+	require.True(t, resp.IsError)
+	require.Contains(t, resp.Content, "出现多次")
+	require.Empty(t, perms.requests)
+}
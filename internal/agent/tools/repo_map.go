@@ -0,0 +1,311 @@
+package tools
+
+import (
+	"bufio"
+	"cmp"
+	"context"
+	_ "embed"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"charm.land/fantasy"
+	"github.com/purpose168/crush-cn/internal/csync"
+	"github.com/purpose168/crush-cn/internal/filepathext"
+	"github.com/purpose168/crush-cn/internal/fsext"
+)
+
+type RepoMapParams struct {
+	Path string `json:"path,omitempty" description:"要生成符号大纲的目录。默认为当前工作目录。"`
+}
+
+type RepoMapResponseMetadata struct {
+	NumberOfFiles int    `json:"number_of_files"`
+	Truncated     bool   `json:"truncated"`
+	Cached        bool   `json:"cached"`
+	Revision      string `json:"revision,omitempty"`
+}
+
+const (
+	RepoMapToolName = "repo_map"
+	// maxRepoMapFiles 是扫描的最大文件数。
+	maxRepoMapFiles = 5000
+	// maxRepoMapOutput 是输出的最大字节数。
+	maxRepoMapOutput = 40000
+)
+
+//go:embed repo_map.md
+var repoMapDescription []byte
+
+// repoMapEntry 是缓存的仓库大纲。
+type repoMapEntry struct {
+	output   string
+	metadata RepoMapResponseMetadata
+}
+
+// repoMapCache 以 "目录@HEAD" 为键缓存仓库大纲。
+var repoMapCache = csync.NewMap[string, repoMapEntry]()
+
+// repoSymbol 是文件中的单个符号。
+type repoSymbol struct {
+	kind      string
+	signature string
+}
+
+func NewRepoMapTool(workingDir string) fantasy.AgentTool {
+	return fantasy.NewAgentTool(
+		RepoMapToolName,
+		string(repoMapDescription),
+		func(ctx context.Context, params RepoMapParams, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+			searchPath, err := fsext.Expand(cmp.Or(params.Path, workingDir))
+			if err != nil {
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("扩展路径错误: %v", err)), nil
+			}
+			searchPath = filepathext.SmartJoin(workingDir, searchPath)
+
+			info, err := os.Stat(searchPath)
+			if err != nil {
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("路径不存在: %s", searchPath)), nil
+			}
+			if !info.IsDir() {
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("路径不是目录: %s", searchPath)), nil
+			}
+
+			revision := gitHead(ctx, searchPath)
+			cacheKey := searchPath + "@" + revision
+			if revision != "" {
+				if entry, ok := repoMapCache.Get(cacheKey); ok {
+					metadata := entry.metadata
+					metadata.Cached = true
+					return fantasy.WithResponseMetadata(fantasy.NewTextResponse(entry.output), metadata), nil
+				}
+			}
+
+			output, metadata, err := BuildRepoMap(searchPath)
+			if err != nil {
+				return fantasy.NewTextErrorResponse(err.Error()), nil
+			}
+			metadata.Revision = revision
+			if revision != "" {
+				repoMapCache.Set(cacheKey, repoMapEntry{output: output, metadata: metadata})
+			}
+
+			return fantasy.WithResponseMetadata(fantasy.NewTextResponse(output), metadata), nil
+		})
+}
+
+// gitHead 返回目录所在 git 仓库的 HEAD 提交，如果不是 git 仓库则返回空字符串。
+func gitHead(ctx context.Context, dir string) string {
+	cmd := exec.CommandContext(ctx, "git", "rev-parse", "HEAD")
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// BuildRepoMap 生成目录的压缩符号大纲：目录 → 文件 → 导出的类型和函数。
+func BuildRepoMap(root string) (string, RepoMapResponseMetadata, error) {
+	files, truncated, err := fsext.ListDirectory(root, nil, 0, maxRepoMapFiles)
+	if err != nil {
+		return "", RepoMapResponseMetadata{}, fmt.Errorf("列出目录错误: %w", err)
+	}
+	slices.Sort(files)
+
+	var sb strings.Builder
+	var numFiles int
+	var lastDir string
+	for _, file := range files {
+		if strings.HasSuffix(file, string(filepath.Separator)) {
+			continue
+		}
+		symbols, pkg := fileSymbols(file)
+		if len(symbols) == 0 {
+			continue
+		}
+		numFiles++
+
+		rel, err := filepath.Rel(root, file)
+		if err != nil {
+			rel = file
+		}
+		rel = filepath.ToSlash(rel)
+		dir, name := filepath.ToSlash(filepath.Dir(rel)), filepath.Base(rel)
+		if dir != lastDir {
+			lastDir = dir
+			if pkg != "" {
+				fmt.Fprintf(&sb, "%s (package %s)\n", dir, pkg)
+			} else {
+				fmt.Fprintf(&sb, "%s\n", dir)
+			}
+		}
+		fmt.Fprintf(&sb, "  %s\n", name)
+		for _, s := range symbols {
+			fmt.Fprintf(&sb, "    %s %s\n", s.kind, s.signature)
+		}
+
+		if sb.Len() > maxRepoMapOutput {
+			truncated = true
+			break
+		}
+	}
+
+	metadata := RepoMapResponseMetadata{
+		NumberOfFiles: numFiles,
+		Truncated:     truncated,
+	}
+	if numFiles == 0 {
+		return "未找到可识别的符号", metadata, nil
+	}
+
+	output := sb.String()
+	if truncated {
+		output += "\n(结果已截断。使用更具体的路径查看子目录的大纲。)"
+	}
+	return output, metadata, nil
+}
+
+// fileSymbols 根据文件扩展名提取文件中的导出符号。对于 Go 文件还会返回包名。
+func fileSymbols(path string) ([]repoSymbol, string) {
+	ext := strings.ToLower(filepath.Ext(path))
+	if ext == ".go" {
+		if strings.HasSuffix(path, "_test.go") {
+			return nil, ""
+		}
+		return goSymbols(path)
+	}
+	patterns, ok := symbolPatterns[ext]
+	if !ok {
+		return nil, ""
+	}
+	return regexSymbols(path, patterns), ""
+}
+
+// goSymbols 使用 go/parser 提取 Go 文件中的导出符号。
+func goSymbols(path string) ([]repoSymbol, string) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, path, nil, parser.SkipObjectResolution)
+	if err != nil {
+		return nil, ""
+	}
+
+	var symbols []repoSymbol
+	for _, decl := range f.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			if !d.Name.IsExported() {
+				continue
+			}
+			name := d.Name.Name
+			if d.Recv != nil && len(d.Recv.List) > 0 {
+				recv := types.ExprString(d.Recv.List[0].Type)
+				if !ast.IsExported(strings.TrimLeft(baseTypeName(recv), "*")) {
+					continue
+				}
+				name = "(" + recv + ") " + name
+			}
+			sig := strings.TrimPrefix(types.ExprString(d.Type), "func")
+			symbols = append(symbols, repoSymbol{kind: "func", signature: name + sig})
+		case *ast.GenDecl:
+			for _, spec := range d.Specs {
+				switch s := spec.(type) {
+				case *ast.TypeSpec:
+					if !s.Name.IsExported() {
+						continue
+					}
+					symbols = append(symbols, repoSymbol{kind: "type", signature: s.Name.Name + " " + goTypeKind(s.Type)})
+				case *ast.ValueSpec:
+					kind := "var"
+					if d.Tok == token.CONST {
+						kind = "const"
+					}
+					for _, n := range s.Names {
+						if n.IsExported() {
+							symbols = append(symbols, repoSymbol{kind: kind, signature: n.Name})
+						}
+					}
+				}
+			}
+		}
+	}
+	return symbols, f.Name.Name
+}
+
+// baseTypeName 去除接收者类型中的泛型参数。
+func baseTypeName(recv string) string {
+	name, _, _ := strings.Cut(recv, "[")
+	return name
+}
+
+// goTypeKind 返回类型声明的简短描述。
+func goTypeKind(expr ast.Expr) string {
+	switch expr.(type) {
+	case *ast.StructType:
+		return "struct"
+	case *ast.InterfaceType:
+		return "interface"
+	case *ast.FuncType:
+		return "func"
+	default:
+		return types.ExprString(expr)
+	}
+}
+
+// symbolPattern 将正则表达式匹配映射为符号。
+type symbolPattern struct {
+	re *regexp.Regexp
+	// kindGroup 和 nameGroup 是种类和名称所在的捕获组。
+	kindGroup, nameGroup int
+}
+
+var (
+	pythonSymbolPattern = symbolPattern{regexp.MustCompile(`^(class|def|async def)\s+([A-Za-z]\w*)`), 1, 2}
+	jsSymbolPattern     = symbolPattern{regexp.MustCompile(`^export\s+(?:default\s+)?(?:declare\s+)?(?:abstract\s+)?(?:async\s+)?(function\*?|class|interface|type|const|let|enum)\s+(\w+)`), 1, 2}
+	rustSymbolPattern   = symbolPattern{regexp.MustCompile(`^pub\s+(?:async\s+)?(?:unsafe\s+)?(fn|struct|enum|trait|type|mod|const|static)\s+(\w+)`), 1, 2}
+	javaSymbolPattern   = symbolPattern{regexp.MustCompile(`^\s*public\s+(?:static\s+)?(?:final\s+)?(?:abstract\s+)?(class|interface|enum|record)\s+(\w+)`), 1, 2}
+)
+
+// symbolPatterns 是非 Go 语言的符号匹配规则，按文件扩展名索引。
+var symbolPatterns = map[string][]symbolPattern{
+	".py":   {pythonSymbolPattern},
+	".js":   {jsSymbolPattern},
+	".jsx":  {jsSymbolPattern},
+	".mjs":  {jsSymbolPattern},
+	".ts":   {jsSymbolPattern},
+	".tsx":  {jsSymbolPattern},
+	".rs":   {rustSymbolPattern},
+	".java": {javaSymbolPattern},
+}
+
+// regexSymbols 使用正则表达式逐行提取文件中的顶层符号。
+func regexSymbols(path string, patterns []symbolPattern) []repoSymbol {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	var symbols []repoSymbol
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		for _, p := range patterns {
+			m := p.re.FindStringSubmatch(line)
+			if m == nil {
+				continue
+			}
+			symbols = append(symbols, repoSymbol{kind: m[p.kindGroup], signature: m[p.nameGroup]})
+			break
+		}
+	}
+	return symbols
+}
@@ -0,0 +1,78 @@
+package tools
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildRepoMap(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	files := map[string]string{
+		"pkg/server.go": `package server
+
+type Server struct{}
+
+type handler interface{}
+
+const DefaultPort = 8080
+
+func New(addr string) (*Server, error) { return nil, nil }
+
+func (s *Server) Start() error { return nil }
+
+func helper() {}
+`,
+		"pkg/server_test.go": `package server
+
+func TestServer() {}
+`,
+		"scripts/tool.py": `class Runner:
+    def run(self):
+        pass
+
+def main():
+    pass
+
+def _private():
+    pass
+`,
+		"web/app.ts": `export function render(): void {}
+export default class App {}
+function internal() {}
+`,
+		"README.md": "# 无符号\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+
+	output, metadata, err := BuildRepoMap(dir)
+	require.NoError(t, err)
+	require.Equal(t, 3, metadata.NumberOfFiles)
+	require.False(t, metadata.Truncated)
+
+	require.Contains(t, output, "pkg (package server)")
+	require.Contains(t, output, "type Server struct")
+	require.Contains(t, output, "const DefaultPort")
+	require.Contains(t, output, "func New(addr string) (*Server, error)")
+	require.Contains(t, output, "func (*Server) Start() error")
+	require.NotContains(t, output, "handler")
+	require.NotContains(t, output, "helper")
+	require.NotContains(t, output, "server_test.go")
+
+	require.Contains(t, output, "class Runner")
+	require.Contains(t, output, "def main")
+	require.NotContains(t, output, "_private")
+
+	require.Contains(t, output, "function render")
+	require.Contains(t, output, "class App")
+	require.NotContains(t, output, "internal")
+	require.NotContains(t, output, "README.md")
+}
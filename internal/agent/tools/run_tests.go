@@ -0,0 +1,413 @@
+package tools
+
+import (
+	"bufio"
+	"bytes"
+	"cmp"
+	"context"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"charm.land/fantasy"
+	"github.com/purpose168/crush-cn/internal/filepathext"
+	"github.com/purpose168/crush-cn/internal/permission"
+)
+
+type RunTestsParams struct {
+	Path   string `json:"path,omitempty" description:"运行测试的目录（默认为当前工作目录），只运行该目录及其子目录中的测试"`
+	Runner string `json:"runner,omitempty" description:"测试框架：go、pytest 或 jest（默认自动检测）"`
+	Filter string `json:"filter,omitempty" description:"只运行名称匹配的测试（go test -run、pytest -k、jest -t）"`
+}
+
+type RunTestsPermissionsParams struct {
+	Runner  string `json:"runner"`
+	Command string `json:"command"`
+}
+
+type RunTestsResponseMetadata struct {
+	Runner    string        `json:"runner"`
+	Command   string        `json:"command"`
+	Dir       string        `json:"dir"`
+	StartTime int64         `json:"start_time"`
+	EndTime   int64         `json:"end_time"`
+	Passed    int           `json:"passed"`
+	Failed    int           `json:"failed"`
+	Skipped   int           `json:"skipped"`
+	Suites    []TestSuite   `json:"suites,omitempty"`
+	Failures  []TestFailure `json:"failures,omitempty"`
+}
+
+const (
+	RunTestsToolName = "run_tests"
+
+	// 支持的测试框架
+	TestRunnerGo     = "go"
+	TestRunnerPytest = "pytest"
+	TestRunnerJest   = "jest"
+
+	// runTestsTimeout 是一次测试运行的最长时间
+	runTestsTimeout = 10 * time.Minute
+	// maxReportedFailures 是输出中详细列出的最大失败数
+	maxReportedFailures = 20
+	// maxRawOutputLines 是没有解析出结果时输出的最大原始输出行数
+	maxRawOutputLines = 50
+)
+
+//go:embed run_tests.md
+var runTestsDescription []byte
+
+// testCommand 是要执行的测试命令
+type testCommand struct {
+	runner string
+	// root 是检测到测试框架配置的项目目录
+	root string
+	// dir 是命令的工作目录
+	dir  string
+	args []string
+	env  []string
+	// report 是测试框架写入结构化报告的文件，为空时从标准输出解析
+	report string
+}
+
+func (c testCommand) String() string {
+	return strings.Join(c.args, " ")
+}
+
+func NewRunTestsTool(permissions permission.Service, workingDir string) fantasy.AgentTool {
+	return fantasy.NewAgentTool(
+		RunTestsToolName,
+		string(runTestsDescription),
+		func(ctx context.Context, params RunTestsParams, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+			dir := filepathext.SmartJoin(sessionWorkingDir(ctx, workingDir), cmp.Or(params.Path, "."))
+			if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("目录不存在: %s", dir)), nil
+			}
+
+			runner, root, err := detectTestRunner(dir, workingDir, params.Runner)
+			if err != nil {
+				return fantasy.NewTextErrorResponse(err.Error()), nil
+			}
+
+			reportDir, err := os.MkdirTemp("", "crush-tests-*")
+			if err != nil {
+				return fantasy.ToolResponse{}, fmt.Errorf("创建临时目录失败: %w", err)
+			}
+			defer os.RemoveAll(reportDir)
+
+			tc, err := buildTestCommand(runner, root, dir, params.Filter, reportDir)
+			if err != nil {
+				return fantasy.NewTextErrorResponse(err.Error()), nil
+			}
+
+			sessionID := GetSessionFromContext(ctx)
+			if sessionID == "" {
+				return fantasy.ToolResponse{}, fmt.Errorf("运行测试需要会话 ID")
+			}
+			p, err := permissions.Request(ctx,
+				permission.CreatePermissionRequest{
+					SessionID:   sessionID,
+					Path:        tc.dir,
+					ToolCallID:  call.ID,
+					ToolName:    RunTestsToolName,
+					Action:      "execute",
+					Description: fmt.Sprintf("运行测试: %s", tc),
+					Params:      RunTestsPermissionsParams{Runner: runner, Command: tc.String()},
+				},
+			)
+			if err != nil {
+				return fantasy.ToolResponse{}, err
+			}
+			if !p {
+				return fantasy.ToolResponse{}, permission.ErrorPermissionDenied
+			}
+
+			startTime := time.Now()
+			output, err := runTestCommand(ctx, tc)
+			if err != nil {
+				return fantasy.NewTextErrorResponse(err.Error()), nil
+			}
+
+			report, err := parseTestOutput(tc, output)
+			if err != nil {
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("%s\n\n%s", err, tailLines(string(output), maxRawOutputLines))), nil
+			}
+			normalizeReport(&report, tc, workingDir)
+
+			passed, failed, skipped := report.totals()
+			metadata := RunTestsResponseMetadata{
+				Runner:    runner,
+				Command:   tc.String(),
+				Dir:       tc.dir,
+				StartTime: startTime.UnixMilli(),
+				EndTime:   time.Now().UnixMilli(),
+				Passed:    passed,
+				Failed:    failed,
+				Skipped:   skipped,
+				Suites:    report.Suites,
+				Failures:  report.Failures,
+			}
+			return fantasy.WithResponseMetadata(
+				fantasy.NewTextResponse(formatTestReport(metadata, output)),
+				metadata,
+			), nil
+		})
+}
+
+// detectTestRunner 从 dir 向上查找到 workingDir，返回测试框架和检测到其配置的项目目录。
+// runner 非空时只查找该框架的项目目录，找不到时使用 dir。
+func detectTestRunner(dir, workingDir, runner string) (string, string, error) {
+	switch runner {
+	case "", TestRunnerGo, TestRunnerPytest, TestRunnerJest:
+	default:
+		return "", "", fmt.Errorf("不支持的测试框架 %q，支持 go、pytest 和 jest", runner)
+	}
+
+	for d := dir; ; {
+		for _, candidate := range []string{TestRunnerGo, TestRunnerJest, TestRunnerPytest} {
+			if (runner == "" || runner == candidate) && hasTestRunnerConfig(d, candidate) {
+				return candidate, d, nil
+			}
+		}
+		parent := filepath.Dir(d)
+		if d == workingDir || parent == d || !strings.HasPrefix(d, workingDir) {
+			break
+		}
+		d = parent
+	}
+	if runner != "" {
+		return runner, dir, nil
+	}
+	return "", "", fmt.Errorf("无法在 %s 中检测测试框架，请通过 runner 参数指定 go、pytest 或 jest", dir)
+}
+
+// hasTestRunnerConfig 报告 dir 中是否有测试框架 runner 的项目配置
+func hasTestRunnerConfig(dir, runner string) bool {
+	read := func(name string) string {
+		data, _ := os.ReadFile(filepath.Join(dir, name))
+		return string(data)
+	}
+	exists := func(name string) bool {
+		_, err := os.Stat(filepath.Join(dir, name))
+		return err == nil
+	}
+
+	switch runner {
+	case TestRunnerGo:
+		return exists("go.mod")
+	case TestRunnerJest:
+		if exists("jest.config.js") || exists("jest.config.ts") || exists("jest.config.mjs") || exists("jest.config.cjs") {
+			return true
+		}
+		var pkg struct {
+			Jest            json.RawMessage   `json:"jest"`
+			Scripts         map[string]string `json:"scripts"`
+			Dependencies    map[string]string `json:"dependencies"`
+			DevDependencies map[string]string `json:"devDependencies"`
+		}
+		if json.Unmarshal([]byte(read("package.json")), &pkg) != nil {
+			return false
+		}
+		_, dep := pkg.Dependencies["jest"]
+		_, devDep := pkg.DevDependencies["jest"]
+		return pkg.Jest != nil || dep || devDep || strings.Contains(pkg.Scripts["test"], "jest")
+	case TestRunnerPytest:
+		return exists("pytest.ini") || exists("conftest.py") ||
+			strings.Contains(read("pyproject.toml"), "[tool.pytest") ||
+			strings.Contains(read("setup.cfg"), "[tool:pytest]") ||
+			strings.Contains(read("tox.ini"), "[pytest]")
+	}
+	return false
+}
+
+// buildTestCommand 返回在 dir 中运行 runner 测试的命令，结构化报告写入 reportDir。
+func buildTestCommand(runner, root, dir, filter, reportDir string) (testCommand, error) {
+	tc := testCommand{runner: runner, root: root, dir: dir}
+	switch runner {
+	case TestRunnerGo:
+		tc.args = []string{"go", "test", "-json"}
+		if filter != "" {
+			tc.args = append(tc.args, "-run", filter)
+		}
+		tc.args = append(tc.args, "./...")
+	case TestRunnerPytest:
+		tc.report = filepath.Join(reportDir, "junit.xml")
+		tc.args = []string{"pytest"}
+		if _, err := exec.LookPath("pytest"); err != nil {
+			tc.args = []string{"python3", "-m", "pytest"}
+		}
+		// xunit1 格式的报告包含文件和行号
+		tc.args = append(tc.args, "-q", "-o", "junit_family=xunit1", "--junitxml="+tc.report)
+		if filter != "" {
+			tc.args = append(tc.args, "-k", filter)
+		}
+	case TestRunnerJest:
+		// 在项目目录中运行以使用项目的 jest 配置，只运行 dir 中的测试
+		tc.dir = root
+		tc.report = filepath.Join(reportDir, "jest.json")
+		tc.args = []string{"npx", "--no-install", "jest", "--json", "--testLocationInResults", "--outputFile=" + tc.report}
+		tc.env = []string{"CI=true"}
+		if filter != "" {
+			tc.args = append(tc.args, "-t", filter)
+		}
+		if rel, err := filepath.Rel(root, dir); err == nil && rel != "." {
+			tc.args = append(tc.args, filepath.ToSlash(rel))
+		}
+	default:
+		return testCommand{}, fmt.Errorf("不支持的测试框架 %q", runner)
+	}
+	if _, err := exec.LookPath(tc.args[0]); err != nil {
+		return testCommand{}, fmt.Errorf("未找到 %s，无法运行 %s 测试", tc.args[0], runner)
+	}
+	return tc, nil
+}
+
+// runTestCommand 执行测试命令并返回合并的标准输出和错误输出。测试失败导致的非零退出码不视为错误。
+func runTestCommand(ctx context.Context, tc testCommand) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, runTestsTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, tc.args[0], tc.args[1:]...)
+	cmd.Dir = tc.dir
+	cmd.Env = append(os.Environ(), tc.env...)
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	err := cmd.Run()
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return nil, fmt.Errorf("测试运行超过 %s 未完成，请使用 path 或 filter 缩小范围\n\n%s", runTestsTimeout, tailLines(output.String(), maxRawOutputLines))
+	}
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	var exitErr *exec.ExitError
+	if err != nil && !errors.As(err, &exitErr) {
+		return nil, fmt.Errorf("运行 %s 失败: %w", tc, err)
+	}
+	return output.Bytes(), nil
+}
+
+// parseTestOutput 从命令输出或报告文件中解析测试结果。报告文件不存在时（例如测试无法启动）返回空结果。
+func parseTestOutput(tc testCommand, output []byte) (testReport, error) {
+	if tc.runner == TestRunnerGo {
+		return parseGoTest(output), nil
+	}
+	data, err := os.ReadFile(tc.report)
+	if os.IsNotExist(err) {
+		return testReport{}, nil
+	}
+	if err != nil {
+		return testReport{}, err
+	}
+	if tc.runner == TestRunnerPytest {
+		return parsePytestJUnit(data)
+	}
+	return parseJest(data)
+}
+
+// normalizeReport 将失败的文件和测试文件套件转换为相对于 workingDir 的路径
+func normalizeReport(report *testReport, tc testCommand, workingDir string) {
+	modulePath := goModulePath(tc.root)
+	rel := func(path string) string {
+		if path == "" {
+			return ""
+		}
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(tc.dir, path)
+		}
+		if r, err := filepath.Rel(workingDir, path); err == nil && !strings.HasPrefix(r, "..") {
+			return r
+		}
+		return path
+	}
+
+	for i, f := range report.Failures {
+		if tc.runner == TestRunnerGo {
+			// 测试输出中的文件名相对于包目录，编译错误中的文件名相对于命令的工作目录
+			if f.Name != "" && f.File != "" && !filepath.IsAbs(f.File) && modulePath != "" {
+				pkgDir := strings.TrimPrefix(strings.TrimPrefix(f.Suite, modulePath), "/")
+				f.File = filepath.Join(tc.root, filepath.FromSlash(pkgDir), f.File)
+			}
+		} else {
+			f.Suite = rel(f.Suite)
+		}
+		f.File = rel(f.File)
+		report.Failures[i] = f
+	}
+	if tc.runner != TestRunnerGo {
+		for i := range report.Suites {
+			report.Suites[i].Name = rel(report.Suites[i].Name)
+		}
+	}
+}
+
+// goModulePath 返回 dir 中 go.mod 声明的模块路径
+func goModulePath(dir string) string {
+	data, err := os.ReadFile(filepath.Join(dir, "go.mod"))
+	if err != nil {
+		return ""
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		if rest, ok := strings.CutPrefix(strings.TrimSpace(scanner.Text()), "module "); ok {
+			return strings.Trim(strings.TrimSpace(rest), `"`)
+		}
+	}
+	return ""
+}
+
+// formatTestReport 返回发送给模型的测试结果
+func formatTestReport(meta RunTestsResponseMetadata, output []byte) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Command: %s\n", meta.Command)
+	fmt.Fprintf(&b, "Directory: %s\n", meta.Dir)
+
+	if len(meta.Suites) == 0 {
+		b.WriteString("Result: no test results were reported\n\nOutput:\n")
+		b.WriteString(tailLines(string(output), maxRawOutputLines))
+		return b.String()
+	}
+
+	status := "PASS"
+	if meta.Failed > 0 {
+		status = "FAIL"
+	}
+	duration := time.Duration(meta.EndTime-meta.StartTime) * time.Millisecond
+	fmt.Fprintf(&b, "Result: %s (%d passed, %d failed, %d skipped) in %s\n", status, meta.Passed, meta.Failed, meta.Skipped, duration.Round(100*time.Millisecond))
+	if len(meta.Failures) == 0 {
+		return b.String()
+	}
+
+	b.WriteString("\nFailures:\n")
+	for i, f := range meta.Failures {
+		if i == maxReportedFailures {
+			fmt.Fprintf(&b, "\n... and %d more failures, use the filter parameter to focus on them\n", len(meta.Failures)-i)
+			break
+		}
+		name := cmp.Or(f.Name, "[setup]")
+		location := f.Suite
+		if f.File != "" {
+			location = f.File
+			if f.Line > 0 {
+				location = fmt.Sprintf("%s:%d", f.File, f.Line)
+			}
+		}
+		fmt.Fprintf(&b, "\n--- %s (%s)\n%s\n", name, location, f.Message)
+	}
+	return b.String()
+}
+
+// tailLines 返回 s 的最后 n 行
+func tailLines(s string, n int) string {
+	lines := strings.Split(strings.TrimRight(s, "\n"), "\n")
+	if len(lines) > n {
+		lines = append([]string{fmt.Sprintf("... (%d lines omitted)", len(lines)-n)}, lines[len(lines)-n:]...)
+	}
+	return strings.Join(lines, "\n")
+}
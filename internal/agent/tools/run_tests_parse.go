@@ -0,0 +1,380 @@
+package tools
+
+import (
+	"bufio"
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+// maxFailureMessageLines 是每个失败保留的最大消息行数
+const maxFailureMessageLines = 30
+
+// TestFailure 是一个失败的测试
+type TestFailure struct {
+	// Suite 是测试所属的套件：Go 包或测试文件
+	Suite string `json:"suite"`
+	// Name 是测试名称，为空时表示整个套件失败（例如编译错误）
+	Name string `json:"name,omitempty"`
+	// File 是失败所在的文件，相对于工作目录
+	File string `json:"file,omitempty"`
+	// Line 是失败所在的行号，未知时为 0
+	Line int `json:"line,omitempty"`
+	// Message 是失败消息
+	Message string `json:"message"`
+}
+
+// TestSuite 是一个测试套件的统计
+type TestSuite struct {
+	Name    string `json:"name"`
+	Passed  int    `json:"passed"`
+	Failed  int    `json:"failed"`
+	Skipped int    `json:"skipped"`
+}
+
+// testReport 是解析后的测试结果
+type testReport struct {
+	Suites   []TestSuite
+	Failures []TestFailure
+	index    map[string]int
+}
+
+// suite 返回名为 name 的套件，不存在时按出现顺序添加
+func (r *testReport) suite(name string) *TestSuite {
+	if r.index == nil {
+		r.index = make(map[string]int)
+	}
+	i, ok := r.index[name]
+	if !ok {
+		i = len(r.Suites)
+		r.index[name] = i
+		r.Suites = append(r.Suites, TestSuite{Name: name})
+	}
+	return &r.Suites[i]
+}
+
+// totals 返回所有套件的通过、失败和跳过数
+func (r *testReport) totals() (passed, failed, skipped int) {
+	for _, s := range r.Suites {
+		passed += s.Passed
+		failed += s.Failed
+		skipped += s.Skipped
+	}
+	return passed, failed, skipped
+}
+
+// fileLinePattern 匹配失败输出中的 "file.go:42" 形式的位置
+var fileLinePattern = regexp.MustCompile(`([\w./\\-]+\.(?:go|py|[cm]?[jt]sx?)):(\d+)`)
+
+// findFileLine 返回文本中第一个文件位置
+func findFileLine(text string) (string, int) {
+	m := fileLinePattern.FindStringSubmatch(text)
+	if m == nil {
+		return "", 0
+	}
+	line, _ := strconv.Atoi(m[2])
+	return m[1], line
+}
+
+// trimFailureMessage 去除 ANSI 转义序列、公共缩进和首尾空行，并限制行数
+func trimFailureMessage(msg string) string {
+	lines := strings.Split(strings.TrimRight(ansi.Strip(msg), "\n"), "\n")
+	for len(lines) > 0 && strings.TrimSpace(lines[0]) == "" {
+		lines = lines[1:]
+	}
+	indent := -1
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		n := len(line) - len(strings.TrimLeft(line, " \t"))
+		if indent < 0 || n < indent {
+			indent = n
+		}
+	}
+	for i, line := range lines {
+		if len(line) >= indent && indent > 0 {
+			lines[i] = line[indent:]
+		}
+	}
+	if len(lines) > maxFailureMessageLines {
+		omitted := len(lines) - maxFailureMessageLines
+		lines = append(lines[:maxFailureMessageLines], fmt.Sprintf("... (%d more lines)", omitted))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// goTestEvent 是 go test -json 输出的一个事件
+type goTestEvent struct {
+	Action      string
+	Package     string
+	Test        string
+	Output      string
+	ImportPath  string
+	FailedBuild string
+}
+
+// parseGoTest 解析 go test -json 的输出。非 JSON 行（例如旧版本 Go 的编译错误）作为所在包的输出。
+func parseGoTest(data []byte) testReport {
+	var report testReport
+	outputs := make(map[string]*strings.Builder)
+	output := func(key string) *strings.Builder {
+		b, ok := outputs[key]
+		if !ok {
+			b = &strings.Builder{}
+			outputs[key] = b
+		}
+		return b
+	}
+	testFailed := make(map[string]bool)
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		var ev goTestEvent
+		if len(line) == 0 || line[0] != '{' || json.Unmarshal(line, &ev) != nil {
+			output("").Write(line)
+			output("").WriteByte('\n')
+			continue
+		}
+
+		switch ev.Action {
+		case "build-output":
+			// 导入路径可能带有 " [pkg.test]" 后缀
+			pkg, _, _ := strings.Cut(ev.ImportPath, " ")
+			output("build\x00" + pkg).WriteString(ev.Output)
+		case "output":
+			output(ev.Package + "\x00" + ev.Test).WriteString(ev.Output)
+		case "pass", "skip":
+			if ev.Test == "" {
+				continue
+			}
+			if ev.Action == "pass" {
+				report.suite(ev.Package).Passed++
+			} else {
+				report.suite(ev.Package).Skipped++
+			}
+		case "fail":
+			if ev.Test != "" {
+				report.suite(ev.Package).Failed++
+				testFailed[ev.Package] = true
+				msg := goTestMessage(output(ev.Package + "\x00" + ev.Test).String())
+				file, line := findFileLine(msg)
+				report.Failures = append(report.Failures, TestFailure{
+					Suite:   ev.Package,
+					Name:    ev.Test,
+					File:    file,
+					Line:    line,
+					Message: trimFailureMessage(msg),
+				})
+				continue
+			}
+			if testFailed[ev.Package] {
+				continue
+			}
+			// 没有失败的测试但包失败了：编译错误、panic 或 TestMain 失败
+			msg := output("build\x00" + ev.Package).String()
+			if ev.FailedBuild != "" {
+				msg += output("build\x00" + ev.FailedBuild).String()
+			}
+			msg += goTestMessage(output(ev.Package + "\x00").String())
+			if strings.TrimSpace(msg) == "" {
+				msg = output("").String()
+			}
+			report.suite(ev.Package).Failed++
+			file, line := findFileLine(msg)
+			report.Failures = append(report.Failures, TestFailure{
+				Suite:   ev.Package,
+				File:    file,
+				Line:    line,
+				Message: trimFailureMessage(msg),
+			})
+		}
+	}
+
+	// 子测试失败时父测试也会失败，只保留最内层的失败
+	parents := make(map[string]bool)
+	for _, f := range report.Failures {
+		name := f.Name
+		for i := strings.LastIndex(name, "/"); i > 0; i = strings.LastIndex(name, "/") {
+			name = name[:i]
+			parents[f.Suite+"\x00"+name] = true
+		}
+	}
+	report.Failures = slices.DeleteFunc(report.Failures, func(f TestFailure) bool {
+		return f.Name != "" && parents[f.Suite+"\x00"+f.Name]
+	})
+	return report
+}
+
+// goTestMessage 从测试输出中去除 go test 自身的进度行
+func goTestMessage(out string) string {
+	var b strings.Builder
+	for line := range strings.SplitSeq(out, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "=== ") ||
+			strings.HasPrefix(trimmed, "--- FAIL") ||
+			strings.HasPrefix(trimmed, "--- PASS") ||
+			strings.HasPrefix(trimmed, "--- SKIP") ||
+			trimmed == "FAIL" || trimmed == "PASS" ||
+			strings.HasPrefix(trimmed, "FAIL\t") || strings.HasPrefix(trimmed, "ok  \t") {
+			continue
+		}
+		b.WriteString(line)
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// junitTestSuites 是 JUnit XML 报告，根元素可能是 testsuites 或单个 testsuite
+type junitTestSuites struct {
+	Suites    []junitTestSuite `xml:"testsuite"`
+	TestCases []junitTestCase  `xml:"testcase"`
+}
+
+type junitTestSuite struct {
+	TestCases []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	ClassName string        `xml:"classname,attr"`
+	Name      string        `xml:"name,attr"`
+	File      string        `xml:"file,attr"`
+	Line      int           `xml:"line,attr"`
+	Failure   *junitMessage `xml:"failure"`
+	Error     *junitMessage `xml:"error"`
+	Skipped   *junitMessage `xml:"skipped"`
+}
+
+type junitMessage struct {
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// parsePytestJUnit 解析 pytest 以 xunit1 格式生成的 JUnit XML 报告，套件为测试文件。
+func parsePytestJUnit(data []byte) (testReport, error) {
+	var doc junitTestSuites
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		return testReport{}, fmt.Errorf("解析 JUnit 报告失败: %w", err)
+	}
+	cases := doc.TestCases
+	for _, s := range doc.Suites {
+		cases = append(cases, s.TestCases...)
+	}
+
+	var report testReport
+	for _, tc := range cases {
+		suiteName := tc.File
+		if suiteName == "" {
+			// 没有 file 属性时由类名推断模块文件，收集错误的类名为空，名称是模块名
+			suiteName = strings.ReplaceAll(cmp.Or(tc.ClassName, tc.Name), ".", "/") + ".py"
+		}
+		suite := report.suite(suiteName)
+		result := tc.Failure
+		if result == nil {
+			result = tc.Error
+		}
+		switch {
+		case result != nil:
+			suite.Failed++
+			name := tc.Name
+			// 类名最后一段不是模块名时是测试类
+			if class := tc.ClassName[strings.LastIndex(tc.ClassName, ".")+1:]; class != "" && !strings.HasSuffix(strings.TrimSuffix(suiteName, ".py"), class) {
+				name = class + "::" + name
+			}
+			msg := strings.TrimSpace(result.Text)
+			if msg == "" {
+				msg = result.Message
+			}
+			line := tc.Line
+			if line > 0 {
+				// xunit1 的行号从 0 开始
+				line++
+			}
+			report.Failures = append(report.Failures, TestFailure{
+				Suite:   suiteName,
+				Name:    name,
+				File:    tc.File,
+				Line:    line,
+				Message: trimFailureMessage(msg),
+			})
+		case tc.Skipped != nil:
+			suite.Skipped++
+		default:
+			suite.Passed++
+		}
+	}
+	return report, nil
+}
+
+// jestResults 是 jest --json 的输出
+type jestResults struct {
+	TestResults []struct {
+		Name             string `json:"name"`
+		Status           string `json:"status"`
+		Message          string `json:"message"`
+		AssertionResults []struct {
+			FullName        string   `json:"fullName"`
+			Status          string   `json:"status"`
+			FailureMessages []string `json:"failureMessages"`
+			Location        *struct {
+				Line int `json:"line"`
+			} `json:"location"`
+		} `json:"assertionResults"`
+	} `json:"testResults"`
+}
+
+// parseJest 解析 jest --json --testLocationInResults 的输出，套件为测试文件。
+func parseJest(data []byte) (testReport, error) {
+	var results jestResults
+	if err := json.Unmarshal(data, &results); err != nil {
+		return testReport{}, fmt.Errorf("解析 jest 报告失败: %w", err)
+	}
+
+	var report testReport
+	for _, file := range results.TestResults {
+		suite := report.suite(file.Name)
+		for _, a := range file.AssertionResults {
+			switch a.Status {
+			case "passed":
+				suite.Passed++
+			case "failed":
+				suite.Failed++
+				failure := TestFailure{
+					Suite:   file.Name,
+					Name:    a.FullName,
+					File:    file.Name,
+					Message: trimFailureMessage(strings.Join(a.FailureMessages, "\n")),
+				}
+				if a.Location != nil {
+					failure.Line = a.Location.Line
+				}
+				report.Failures = append(report.Failures, failure)
+			default:
+				suite.Skipped++
+			}
+		}
+		// 测试文件本身运行失败（例如语法错误）时没有断言结果
+		if file.Status == "failed" && len(file.AssertionResults) == 0 {
+			suite.Failed++
+			msg := trimFailureMessage(file.Message)
+			_, line := findFileLine(msg)
+			report.Failures = append(report.Failures, TestFailure{
+				Suite:   file.Name,
+				File:    file.Name,
+				Line:    line,
+				Message: msg,
+			})
+		}
+	}
+	return report, nil
+}
@@ -0,0 +1,170 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"charm.land/fantasy"
+	"github.com/purpose168/crush-cn/internal/permission"
+	"github.com/purpose168/crush-cn/internal/pubsub"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseGoTest(t *testing.T) {
+	t.Parallel()
+
+	data := `{"Action":"start","Package":"example.com/m/a"}
+{"Action":"run","Package":"example.com/m/a","Test":"TestOK"}
+{"Action":"pass","Package":"example.com/m/a","Test":"TestOK"}
+{"Action":"run","Package":"example.com/m/a","Test":"TestTable"}
+{"Action":"run","Package":"example.com/m/a","Test":"TestTable/empty"}
+{"Action":"output","Package":"example.com/m/a","Test":"TestTable/empty","Output":"=== RUN   TestTable/empty\n"}
+{"Action":"output","Package":"example.com/m/a","Test":"TestTable/empty","Output":"    a_test.go:12: got 1, want 2\n"}
+{"Action":"output","Package":"example.com/m/a","Test":"TestTable/empty","Output":"--- FAIL: TestTable/empty (0.00s)\n"}
+{"Action":"fail","Package":"example.com/m/a","Test":"TestTable/empty"}
+{"Action":"fail","Package":"example.com/m/a","Test":"TestTable"}
+{"Action":"skip","Package":"example.com/m/a","Test":"TestSlow"}
+{"Action":"fail","Package":"example.com/m/a"}
+{"ImportPath":"example.com/m/b [example.com/m/b.test]","Action":"build-output","Output":"b/b_test.go:5:2: undefined: missing\n"}
+{"Action":"start","Package":"example.com/m/b"}
+{"Action":"output","Package":"example.com/m/b","Output":"FAIL\texample.com/m/b [build failed]\n"}
+{"Action":"fail","Package":"example.com/m/b","FailedBuild":"example.com/m/b [example.com/m/b.test]"}
+`
+	report := parseGoTest([]byte(data))
+	require.Equal(t, []TestSuite{
+		{Name: "example.com/m/a", Passed: 1, Failed: 2, Skipped: 1},
+		{Name: "example.com/m/b", Failed: 1},
+	}, report.Suites)
+	require.Equal(t, []TestFailure{
+		{Suite: "example.com/m/a", Name: "TestTable/empty", File: "a_test.go", Line: 12, Message: "a_test.go:12: got 1, want 2"},
+		{Suite: "example.com/m/b", File: "b/b_test.go", Line: 5, Message: "b/b_test.go:5:2: undefined: missing"},
+	}, report.Failures)
+}
+
+func TestParsePytestJUnit(t *testing.T) {
+	t.Parallel()
+
+	data := `<?xml version="1.0" encoding="utf-8"?>
+<testsuites><testsuite name="pytest" errors="1" failures="1" skipped="1" tests="4">
+<testcase classname="tests.test_math" name="test_add" file="tests/test_math.py" line="3" time="0.001"/>
+<testcase classname="tests.test_math.TestDiv" name="test_zero" file="tests/test_math.py" line="9" time="0.001"><failure message="assert 1 == 2">def test_zero(self):
+&gt;       assert 1 == 2
+E       assert 1 == 2</failure></testcase>
+<testcase classname="tests.test_math" name="test_later" file="tests/test_math.py" line="14"><skipped message="todo"/></testcase>
+<testcase classname="" name="tests.test_broken" time="0.000"><error message="collection failure">ImportError: No module named 'nope'</error></testcase>
+</testsuite></testsuites>`
+	report, err := parsePytestJUnit([]byte(data))
+	require.NoError(t, err)
+	require.Equal(t, []TestSuite{
+		{Name: "tests/test_math.py", Passed: 1, Failed: 1, Skipped: 1},
+		{Name: "tests/test_broken.py", Failed: 1},
+	}, report.Suites)
+	require.Len(t, report.Failures, 2)
+	require.Equal(t, "TestDiv::test_zero", report.Failures[0].Name)
+	require.Equal(t, 10, report.Failures[0].Line)
+	require.Equal(t, "def test_zero(self):\n>       assert 1 == 2\nE       assert 1 == 2", report.Failures[0].Message)
+	require.Equal(t, "ImportError: No module named 'nope'", report.Failures[1].Message)
+
+	_, err = parsePytestJUnit([]byte("not xml"))
+	require.Error(t, err)
+}
+
+func TestParseJest(t *testing.T) {
+	t.Parallel()
+
+	data := `{"testResults":[
+{"name":"/p/src/sum.test.js","status":"failed","message":"","assertionResults":[
+  {"fullName":"sum adds","status":"passed","failureMessages":[]},
+  {"fullName":"sum handles negatives","status":"failed","failureMessages":["\u001b[31mError: expect(received).toBe(expected)\u001b[39m\n    at Object.<anonymous> (/p/src/sum.test.js:9:20)"],"location":{"line":8,"column":3}},
+  {"fullName":"sum later","status":"todo","failureMessages":[]}
+]},
+{"name":"/p/src/broken.test.js","status":"failed","message":"  SyntaxError: /p/src/broken.test.js: Unexpected token (3:4)","assertionResults":[]}
+]}`
+	report, err := parseJest([]byte(data))
+	require.NoError(t, err)
+	require.Equal(t, []TestSuite{
+		{Name: "/p/src/sum.test.js", Passed: 1, Failed: 1, Skipped: 1},
+		{Name: "/p/src/broken.test.js", Failed: 1},
+	}, report.Suites)
+	require.Equal(t, []TestFailure{
+		{Suite: "/p/src/sum.test.js", Name: "sum handles negatives", File: "/p/src/sum.test.js", Line: 8, Message: "Error: expect(received).toBe(expected)\n    at Object.<anonymous> (/p/src/sum.test.js:9:20)"},
+		{Suite: "/p/src/broken.test.js", File: "/p/src/broken.test.js", Message: "SyntaxError: /p/src/broken.test.js: Unexpected token (3:4)"},
+	}, report.Failures)
+}
+
+func TestDetectTestRunner(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	sub := filepath.Join(root, "pkg", "inner")
+	require.NoError(t, os.MkdirAll(sub, 0o755))
+
+	_, _, err := detectTestRunner(sub, root, "")
+	require.Error(t, err)
+	_, _, err = detectTestRunner(sub, root, "cargo")
+	require.Error(t, err)
+
+	runner, dir, err := detectTestRunner(sub, root, TestRunnerPytest)
+	require.NoError(t, err)
+	require.Equal(t, TestRunnerPytest, runner)
+	require.Equal(t, sub, dir)
+
+	require.NoError(t, os.WriteFile(filepath.Join(root, "package.json"), []byte(`{"devDependencies":{"jest":"^29"}}`), 0o644))
+	runner, dir, err = detectTestRunner(sub, root, "")
+	require.NoError(t, err)
+	require.Equal(t, TestRunnerJest, runner)
+	require.Equal(t, root, dir)
+
+	require.NoError(t, os.WriteFile(filepath.Join(root, "pkg", "pyproject.toml"), []byte("[tool.pytest.ini_options]\n"), 0o644))
+	runner, dir, err = detectTestRunner(sub, root, "")
+	require.NoError(t, err)
+	require.Equal(t, TestRunnerPytest, runner)
+	require.Equal(t, filepath.Join(root, "pkg"), dir)
+}
+
+func TestRunTestsToolGo(t *testing.T) {
+	t.Parallel()
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go not installed")
+	}
+
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "calc"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example.com/calc\n\ngo 1.21\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "calc", "calc_test.go"), []byte(`package calc
+
+import "testing"
+
+func TestPass(t *testing.T) {}
+
+func TestFail(t *testing.T) {
+	t.Run("sub", func(t *testing.T) {
+		t.Error("boom")
+	})
+}
+`), 0o644))
+
+	permissions := &mockPermissionService{Broker: pubsub.NewBroker[permission.PermissionRequest]()}
+	tool := NewRunTestsTool(permissions, dir)
+	input, err := json.Marshal(RunTestsParams{})
+	require.NoError(t, err)
+	ctx := context.WithValue(t.Context(), SessionIDContextKey, "session")
+	resp, err := tool.Run(ctx, fantasy.ToolCall{ID: "call", Name: RunTestsToolName, Input: string(input)})
+	require.NoError(t, err)
+	require.False(t, resp.IsError, resp.Content)
+
+	var meta RunTestsResponseMetadata
+	require.NoError(t, json.Unmarshal([]byte(resp.Metadata), &meta))
+	require.Equal(t, TestRunnerGo, meta.Runner)
+	require.Equal(t, 1, meta.Passed)
+	require.Equal(t, 2, meta.Failed)
+	require.Len(t, meta.Failures, 1)
+	require.Equal(t, "TestFail/sub", meta.Failures[0].Name)
+	require.Equal(t, filepath.Join("calc", "calc_test.go"), meta.Failures[0].File)
+	require.Equal(t, 9, meta.Failures[0].Line)
+	require.Contains(t, resp.Content, "Result: FAIL (1 passed, 2 failed, 0 skipped)")
+}
@@ -0,0 +1,443 @@
+package tools
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/purpose168/crush-cn/internal/fsext"
+)
+
+const (
+	// maxIndexFiles 是索引的最大文件数。
+	maxIndexFiles = 5000
+	// maxIndexFileSize 是索引的单个文件的最大字节数。
+	maxIndexFileSize = 512 * 1024
+	// indexChunkLines 是每个代码块包含的行数。
+	indexChunkLines = 40
+	// indexChunkOverlap 是相邻代码块重叠的行数。
+	indexChunkOverlap = 8
+	// maxChunkBytes 是发送给嵌入模型的单个代码块的最大字节数。
+	maxChunkBytes = 6000
+	// embedBatchSize 是单次嵌入请求包含的最大文本数。
+	embedBatchSize = 64
+	// semanticIndexVersion 是索引文件格式的版本，格式变化时递增。
+	semanticIndexVersion = 1
+)
+
+// Embedder 将文本转换为嵌入向量。
+type Embedder interface {
+	// Embed 返回与 texts 一一对应的嵌入向量。
+	Embed(ctx context.Context, texts []string) ([][]float32, error)
+	// Model 返回嵌入模型的标识，模型变化时索引会被重建。
+	Model() string
+}
+
+// EmbeddingConfig 是兼容 OpenAI /embeddings API 的嵌入提供者配置。
+type EmbeddingConfig struct {
+	BaseURL string
+	APIKey  string
+	Model   string
+	Headers map[string]string
+}
+
+// Enabled 报告是否配置了嵌入模型。
+func (c EmbeddingConfig) Enabled() bool {
+	return c.Model != "" && c.BaseURL != ""
+}
+
+// openAIEmbedder 通过兼容 OpenAI 的 /embeddings 端点生成嵌入向量。
+type openAIEmbedder struct {
+	client *http.Client
+	cfg    EmbeddingConfig
+}
+
+// NewOpenAIEmbedder 创建使用兼容 OpenAI 的 /embeddings 端点的 [Embedder]。
+func NewOpenAIEmbedder(cfg EmbeddingConfig, client *http.Client) Embedder {
+	if client == nil {
+		client = &http.Client{Timeout: 60 * time.Second}
+	}
+	return &openAIEmbedder{client: client, cfg: cfg}
+}
+
+func (e *openAIEmbedder) Model() string {
+	return e.cfg.Model
+}
+
+func (e *openAIEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	payload, err := json.Marshal(map[string]any{
+		"model": e.cfg.Model,
+		"input": texts,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("编码请求失败: %w", err)
+	}
+
+	endpoint := strings.TrimSuffix(e.cfg.BaseURL, "/") + "/embeddings"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return nil, fmt.Errorf("创建请求失败: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "crush/1.0")
+	if e.cfg.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+e.cfg.APIKey)
+	}
+	for k, v := range e.cfg.Headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("请求嵌入失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("请求嵌入失败，状态码 %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	var result struct {
+		Data []struct {
+			Index     int       `json:"index"`
+			Embedding []float32 `json:"embedding"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("解码嵌入响应失败: %w", err)
+	}
+	if len(result.Data) != len(texts) {
+		return nil, fmt.Errorf("嵌入数量不匹配: 期望 %d，实际 %d", len(texts), len(result.Data))
+	}
+
+	vectors := make([][]float32, len(texts))
+	for _, d := range result.Data {
+		if d.Index < 0 || d.Index >= len(texts) {
+			return nil, fmt.Errorf("无效的嵌入索引: %d", d.Index)
+		}
+		vectors[d.Index] = d.Embedding
+	}
+	return vectors, nil
+}
+
+// indexChunk 是文件中被嵌入的一段连续行。
+type indexChunk struct {
+	StartLine int
+	EndLine   int
+	Vector    []float32
+}
+
+// indexedFile 是已索引文件的状态及其代码块。
+type indexedFile struct {
+	ModTime time.Time
+	Size    int64
+	Chunks  []indexChunk
+}
+
+// semanticIndexData 是持久化到磁盘的索引内容。
+type semanticIndexData struct {
+	Version int
+	Model   string
+	Files   map[string]indexedFile
+}
+
+// IndexStats 描述一次增量更新的结果。
+type IndexStats struct {
+	Files   int
+	Chunks  int
+	Updated int
+	Removed int
+	// Truncated 表示文件数超过上限，部分文件未被索引。
+	Truncated bool
+}
+
+// SemanticIndex 是工作区的磁盘嵌入索引，按文件修改时间增量更新。
+type SemanticIndex struct {
+	mu       sync.Mutex
+	root     string
+	path     string
+	embedder Embedder
+	data     *semanticIndexData
+}
+
+// NewSemanticIndex 创建 root 目录的语义索引，索引保存在 indexPath。
+// 索引在首次调用 [SemanticIndex.Update] 时才会加载或构建。
+func NewSemanticIndex(root, indexPath string, embedder Embedder) *SemanticIndex {
+	return &SemanticIndex{
+		root:     root,
+		path:     indexPath,
+		embedder: embedder,
+	}
+}
+
+// load 从磁盘加载索引。文件不存在、损坏或模型不同时返回空索引。
+func (idx *SemanticIndex) load() *semanticIndexData {
+	empty := &semanticIndexData{
+		Version: semanticIndexVersion,
+		Model:   idx.embedder.Model(),
+		Files:   map[string]indexedFile{},
+	}
+	f, err := os.Open(idx.path)
+	if err != nil {
+		return empty
+	}
+	defer f.Close()
+
+	var data semanticIndexData
+	if err := gob.NewDecoder(f).Decode(&data); err != nil {
+		return empty
+	}
+	if data.Version != semanticIndexVersion || data.Model != idx.embedder.Model() || data.Files == nil {
+		return empty
+	}
+	return &data
+}
+
+// save 将索引写入磁盘。先写入临时文件再重命名，避免中断时损坏索引。
+func (idx *SemanticIndex) save() error {
+	if err := os.MkdirAll(filepath.Dir(idx.path), 0o755); err != nil {
+		return fmt.Errorf("创建索引目录失败: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(idx.path), filepath.Base(idx.path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("创建索引文件失败: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := gob.NewEncoder(tmp).Encode(idx.data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("写入索引失败: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("写入索引失败: %w", err)
+	}
+	return os.Rename(tmp.Name(), idx.path)
+}
+
+// pendingChunk 是等待嵌入的代码块。
+type pendingChunk struct {
+	file  string
+	chunk indexChunk
+	text  string
+}
+
+// Update 将索引与工作区同步：嵌入新增或修改的文件，删除已不存在的文件。
+func (idx *SemanticIndex) Update(ctx context.Context) (IndexStats, error) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	if idx.data == nil {
+		idx.data = idx.load()
+	}
+
+	files, truncated, err := fsext.ListDirectory(idx.root, nil, 0, maxIndexFiles)
+	if err != nil {
+		return IndexStats{}, fmt.Errorf("列出目录错误: %w", err)
+	}
+
+	stats := IndexStats{Truncated: truncated}
+	seen := make(map[string]struct{}, len(files))
+	var pending []pendingChunk
+	for _, file := range files {
+		if strings.HasSuffix(file, string(filepath.Separator)) {
+			continue
+		}
+		info, err := os.Stat(file)
+		if err != nil || !info.Mode().IsRegular() || info.Size() == 0 || info.Size() > maxIndexFileSize {
+			continue
+		}
+		rel, err := filepath.Rel(idx.root, file)
+		if err != nil {
+			continue
+		}
+		rel = filepath.ToSlash(rel)
+
+		if existing, ok := idx.data.Files[rel]; ok && existing.Size == info.Size() && existing.ModTime.Equal(info.ModTime()) {
+			seen[rel] = struct{}{}
+			continue
+		}
+
+		content, err := os.ReadFile(file)
+		if err != nil || isBinaryContent(content) {
+			continue
+		}
+		seen[rel] = struct{}{}
+		stats.Updated++
+		idx.data.Files[rel] = indexedFile{ModTime: info.ModTime(), Size: info.Size()}
+		for _, c := range chunkLines(string(content)) {
+			pending = append(pending, pendingChunk{
+				file:  rel,
+				chunk: indexChunk{StartLine: c.start, EndLine: c.end},
+				text:  rel + "\n" + c.text,
+			})
+		}
+	}
+
+	for rel := range idx.data.Files {
+		if _, ok := seen[rel]; !ok {
+			delete(idx.data.Files, rel)
+			stats.Removed++
+		}
+	}
+
+	for batch := range slices.Chunk(pending, embedBatchSize) {
+		texts := make([]string, len(batch))
+		for i, p := range batch {
+			texts[i] = p.text
+		}
+		vectors, err := idx.embedder.Embed(ctx, texts)
+		if err != nil {
+			// 丢弃本次更新的文件，下次更新时会重新处理。
+			for _, p := range pending {
+				delete(idx.data.Files, p.file)
+			}
+			return stats, err
+		}
+		for i, p := range batch {
+			f := idx.data.Files[p.file]
+			p.chunk.Vector = normalize(vectors[i])
+			f.Chunks = append(f.Chunks, p.chunk)
+			idx.data.Files[p.file] = f
+		}
+	}
+
+	stats.Files = len(idx.data.Files)
+	for _, f := range idx.data.Files {
+		stats.Chunks += len(f.Chunks)
+	}
+
+	if stats.Updated > 0 || stats.Removed > 0 {
+		if err := idx.save(); err != nil {
+			return stats, err
+		}
+	}
+	return stats, nil
+}
+
+// SemanticMatch 是一条语义搜索结果。
+type SemanticMatch struct {
+	Path      string
+	StartLine int
+	EndLine   int
+	Score     float64
+}
+
+// Search 返回与 query 最相似的 limit 个代码块。prefix 非空时只搜索该相对路径下的文件。
+func (idx *SemanticIndex) Search(ctx context.Context, query, prefix string, limit int) ([]SemanticMatch, error) {
+	vectors, err := idx.embedder.Embed(ctx, []string{query})
+	if err != nil {
+		return nil, err
+	}
+	if len(vectors) != 1 {
+		return nil, errors.New("嵌入查询失败")
+	}
+	queryVector := normalize(vectors[0])
+
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	if idx.data == nil {
+		return nil, nil
+	}
+
+	prefix = strings.TrimSuffix(filepath.ToSlash(prefix), "/")
+	var matches []SemanticMatch
+	for path, f := range idx.data.Files {
+		if prefix != "" && path != prefix && !strings.HasPrefix(path, prefix+"/") {
+			continue
+		}
+		for _, c := range f.Chunks {
+			matches = append(matches, SemanticMatch{
+				Path:      path,
+				StartLine: c.StartLine,
+				EndLine:   c.EndLine,
+				Score:     dot(queryVector, c.Vector),
+			})
+		}
+	}
+	slices.SortFunc(matches, func(a, b SemanticMatch) int {
+		return cmp.Or(
+			cmp.Compare(b.Score, a.Score),
+			strings.Compare(a.Path, b.Path),
+			cmp.Compare(a.StartLine, b.StartLine),
+		)
+	})
+	if len(matches) > limit {
+		matches = matches[:limit]
+	}
+	return matches, nil
+}
+
+// lineChunk 是文件中的一段行，行号从 1 开始。
+type lineChunk struct {
+	start, end int
+	text       string
+}
+
+// chunkLines 将内容切分为相互重叠的固定行数的块，跳过只含空白的块。
+func chunkLines(content string) []lineChunk {
+	lines := strings.Split(strings.TrimRight(content, "\n"), "\n")
+	var chunks []lineChunk
+	step := indexChunkLines - indexChunkOverlap
+	for start := 0; start < len(lines); start += step {
+		end := min(start+indexChunkLines, len(lines))
+		text := strings.Join(lines[start:end], "\n")
+		if strings.TrimSpace(text) != "" {
+			if len(text) > maxChunkBytes {
+				text = strings.ToValidUTF8(text[:maxChunkBytes], "")
+			}
+			chunks = append(chunks, lineChunk{start: start + 1, end: end, text: text})
+		}
+		if end == len(lines) {
+			break
+		}
+	}
+	return chunks
+}
+
+// isBinaryContent 报告内容是否看起来是二进制数据。
+func isBinaryContent(content []byte) bool {
+	return bytes.IndexByte(content[:min(len(content), 8000)], 0) >= 0
+}
+
+// normalize 返回单位长度的向量，使点积等于余弦相似度。
+func normalize(v []float32) []float32 {
+	var sum float64
+	for _, x := range v {
+		sum += float64(x) * float64(x)
+	}
+	if sum == 0 {
+		return v
+	}
+	norm := float32(math.Sqrt(sum))
+	out := make([]float32, len(v))
+	for i, x := range v {
+		out[i] = x / norm
+	}
+	return out
+}
+
+// dot 返回两个向量的点积。维度不同时返回 0。
+func dot(a, b []float32) float64 {
+	if len(a) != len(b) {
+		return 0
+	}
+	var sum float64
+	for i := range a {
+		sum += float64(a[i]) * float64(b[i])
+	}
+	return sum
+}
@@ -0,0 +1,153 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"hash/fnv"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// wordEmbedder 是测试用的嵌入器，将文本中的单词哈希到固定维度的向量。
+type wordEmbedder struct {
+	embedded atomic.Int64
+}
+
+func (e *wordEmbedder) Model() string { return "words" }
+
+func (e *wordEmbedder) Embed(_ context.Context, texts []string) ([][]float32, error) {
+	e.embedded.Add(int64(len(texts)))
+	vectors := make([][]float32, len(texts))
+	for i, text := range texts {
+		v := make([]float32, 64)
+		for _, word := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+			return !(r >= 'a' && r <= 'z')
+		}) {
+			h := fnv.New32a()
+			h.Write([]byte(word))
+			v[h.Sum32()%64]++
+		}
+		vectors[i] = v
+	}
+	return vectors, nil
+}
+
+func TestSemanticIndex(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	write := func(name, content string) {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+	write("auth/session.go", "package auth\n\n// expire removes stale sessions\nfunc expire() {}\n")
+	write("render/color.go", "package render\n\n// paint draws colors on the canvas\nfunc paint() {}\n")
+	write("blob.bin", "binary\x00data")
+
+	embedder := &wordEmbedder{}
+	indexPath := filepath.Join(t.TempDir(), "index.gob")
+	idx := NewSemanticIndex(dir, indexPath, embedder)
+
+	stats, err := idx.Update(t.Context())
+	require.NoError(t, err)
+	require.Equal(t, 2, stats.Files)
+	require.Equal(t, 2, stats.Updated)
+	require.FileExists(t, indexPath)
+
+	matches, err := idx.Search(t.Context(), "remove stale sessions", "", 5)
+	require.NoError(t, err)
+	require.Len(t, matches, 2)
+	require.Equal(t, "auth/session.go", matches[0].Path)
+	require.Equal(t, 1, matches[0].StartLine)
+
+	matches, err = idx.Search(t.Context(), "remove stale sessions", "render", 5)
+	require.NoError(t, err)
+	require.Len(t, matches, 1)
+	require.Equal(t, "render/color.go", matches[0].Path)
+
+	t.Run("incremental update", func(t *testing.T) {
+		before := embedder.embedded.Load()
+		stats, err := idx.Update(t.Context())
+		require.NoError(t, err)
+		require.Zero(t, stats.Updated)
+		require.Equal(t, before, embedder.embedded.Load())
+
+		write("render/color.go", "package render\n\n// paint fills the canvas with colors\nfunc paint() {}\n")
+		future := time.Now().Add(time.Minute)
+		require.NoError(t, os.Chtimes(filepath.Join(dir, "render/color.go"), future, future))
+		require.NoError(t, os.Remove(filepath.Join(dir, "auth/session.go")))
+
+		stats, err = idx.Update(t.Context())
+		require.NoError(t, err)
+		require.Equal(t, 1, stats.Updated)
+		require.Equal(t, 1, stats.Removed)
+		require.Equal(t, 1, stats.Files)
+	})
+
+	t.Run("reload from disk", func(t *testing.T) {
+		reloaded := NewSemanticIndex(dir, indexPath, &wordEmbedder{})
+		stats, err := reloaded.Update(t.Context())
+		require.NoError(t, err)
+		require.Zero(t, stats.Updated)
+		require.Equal(t, 1, stats.Files)
+	})
+}
+
+func TestChunkLines(t *testing.T) {
+	t.Parallel()
+
+	lines := make([]string, 100)
+	for i := range lines {
+		lines[i] = "line"
+	}
+	chunks := chunkLines(strings.Join(lines, "\n"))
+	require.Len(t, chunks, 3)
+	require.Equal(t, 1, chunks[0].start)
+	require.Equal(t, indexChunkLines, chunks[0].end)
+	require.Equal(t, indexChunkLines-indexChunkOverlap+1, chunks[1].start)
+	require.Equal(t, 100, chunks[2].end)
+
+	require.Empty(t, chunkLines("\n\n   \n"))
+}
+
+func TestOpenAIEmbedder(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/embeddings", r.URL.Path)
+		assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+
+		var req struct {
+			Model string   `json:"model"`
+			Input []string `json:"input"`
+		}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		assert.Equal(t, "embed-small", req.Model)
+		assert.Equal(t, []string{"a", "b"}, req.Input)
+
+		// 以相反顺序返回，确保按 index 字段排列。
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"data":[{"index":1,"embedding":[0,1]},{"index":0,"embedding":[1,0]}]}`))
+	}))
+	defer server.Close()
+
+	embedder := NewOpenAIEmbedder(EmbeddingConfig{
+		BaseURL: server.URL + "/v1/",
+		APIKey:  "secret",
+		Model:   "embed-small",
+	}, server.Client())
+
+	vectors, err := embedder.Embed(t.Context(), []string{"a", "b"})
+	require.NoError(t, err)
+	require.Equal(t, [][]float32{{1, 0}, {0, 1}}, vectors)
+}
@@ -0,0 +1,151 @@
+package tools
+
+import (
+	"bufio"
+	"context"
+	_ "embed"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"charm.land/fantasy"
+	"github.com/purpose168/crush-cn/internal/csync"
+	"github.com/purpose168/crush-cn/internal/filepathext"
+	"github.com/purpose168/crush-cn/internal/fsext"
+)
+
+type SemanticSearchParams struct {
+	Query string `json:"query" description:"用自然语言描述要查找的代码，例如 \"处理会话过期的逻辑\""`
+	Path  string `json:"path,omitempty" description:"只在此目录中搜索。默认为整个工作区。"`
+	Limit int    `json:"limit,omitempty" description:"返回的最大结果数（默认 10，最大 50）"`
+}
+
+type SemanticSearchResponseMetadata struct {
+	NumberOfMatches int  `json:"number_of_matches"`
+	IndexedFiles    int  `json:"indexed_files"`
+	UpdatedFiles    int  `json:"updated_files"`
+	Truncated       bool `json:"truncated"`
+}
+
+const (
+	SemanticSearchToolName = "semantic_search"
+	// defaultSemanticResults 是默认返回的结果数。
+	defaultSemanticResults = 10
+	// maxSemanticResults 是最多返回的结果数。
+	maxSemanticResults = 50
+	// maxSnippetLines 是每个结果显示的最大行数。
+	maxSnippetLines = 12
+	// semanticIndexFile 是数据目录中索引文件的名称。
+	semanticIndexFile = "semantic_index.gob"
+)
+
+//go:embed semantic_search.md
+var semanticSearchDescription []byte
+
+// semanticIndexes 以索引文件路径和模型为键，在代理之间共享同一工作区的索引。
+var semanticIndexes = csync.NewMap[string, *SemanticIndex]()
+
+func NewSemanticSearchTool(workingDir, dataDir string, cfg EmbeddingConfig, client *http.Client) fantasy.AgentTool {
+	indexPath := filepath.Join(dataDir, semanticIndexFile)
+	index := semanticIndexes.GetOrSet(indexPath+"@"+cfg.Model, func() *SemanticIndex {
+		return NewSemanticIndex(workingDir, indexPath, NewOpenAIEmbedder(cfg, client))
+	})
+
+	return fantasy.NewAgentTool(
+		SemanticSearchToolName,
+		string(semanticSearchDescription),
+		func(ctx context.Context, params SemanticSearchParams, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+			return runSemanticSearch(ctx, index, workingDir, params)
+		})
+}
+
+// runSemanticSearch 增量更新索引并执行查询。
+func runSemanticSearch(ctx context.Context, index *SemanticIndex, workingDir string, params SemanticSearchParams) (fantasy.ToolResponse, error) {
+	query := strings.TrimSpace(params.Query)
+	if query == "" {
+		return fantasy.NewTextErrorResponse("query 是必需的"), nil
+	}
+
+	limit := params.Limit
+	if limit <= 0 {
+		limit = defaultSemanticResults
+	}
+	limit = min(limit, maxSemanticResults)
+
+	var prefix string
+	if params.Path != "" {
+		searchPath, err := fsext.Expand(params.Path)
+		if err != nil {
+			return fantasy.NewTextErrorResponse(fmt.Sprintf("扩展路径错误: %v", err)), nil
+		}
+		searchPath = filepathext.SmartJoin(workingDir, searchPath)
+		rel, err := filepath.Rel(workingDir, searchPath)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return fantasy.NewTextErrorResponse(fmt.Sprintf("路径必须位于工作目录内: %s", params.Path)), nil
+		}
+		if rel != "." {
+			prefix = rel
+		}
+	}
+
+	stats, err := index.Update(ctx)
+	if err != nil {
+		return fantasy.NewTextErrorResponse(fmt.Sprintf("更新语义索引失败: %v", err)), nil
+	}
+
+	matches, err := index.Search(ctx, query, prefix, limit)
+	if err != nil {
+		return fantasy.NewTextErrorResponse(fmt.Sprintf("语义搜索失败: %v", err)), nil
+	}
+
+	metadata := SemanticSearchResponseMetadata{
+		NumberOfMatches: len(matches),
+		IndexedFiles:    stats.Files,
+		UpdatedFiles:    stats.Updated,
+		Truncated:       stats.Truncated,
+	}
+	if len(matches) == 0 {
+		return fantasy.WithResponseMetadata(fantasy.NewTextResponse("未找到匹配的代码"), metadata), nil
+	}
+
+	var output strings.Builder
+	fmt.Fprintf(&output, "找到 %d 个相关代码片段（按相似度排序）\n", len(matches))
+	for _, m := range matches {
+		fmt.Fprintf(&output, "\n%s:%d-%d (相似度 %.3f)\n", m.Path, m.StartLine, m.EndLine, m.Score)
+		for i, line := range readLineRange(filepath.Join(workingDir, filepath.FromSlash(m.Path)), m.StartLine, m.EndLine) {
+			fmt.Fprintf(&output, "%6d|%s\n", m.StartLine+i, line)
+		}
+	}
+	if stats.Truncated {
+		fmt.Fprintf(&output, "\n(工作区文件数超过 %d，部分文件未被索引。使用 grep 或 glob 搜索其余文件。)", maxIndexFiles)
+	}
+
+	return fantasy.WithResponseMetadata(fantasy.NewTextResponse(output.String()), metadata), nil
+}
+
+// readLineRange 读取文件中从第 start 行开始、到第 end 行为止的最多 maxSnippetLines 行。
+func readLineRange(path string, start, end int) []string {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	end = min(end, start+maxSnippetLines-1)
+	var lines []string
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxIndexFileSize)
+	for n := 1; scanner.Scan() && n <= end; n++ {
+		if n < start {
+			continue
+		}
+		line := scanner.Text()
+		if len(line) > maxGrepContentWidth {
+			line = line[:maxGrepContentWidth] + "..."
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
@@ -12,6 +12,7 @@ import (
 	"time"
 
 	"charm.land/fantasy"
+	"github.com/purpose168/crush-cn/internal/network"
 )
 
 type SourcegraphParams struct {
@@ -35,15 +36,7 @@ var sourcegraphDescription []byte
 // client: HTTP客户端（如果为nil，将创建一个默认客户端）
 func NewSourcegraphTool(client *http.Client) fantasy.AgentTool {
 	if client == nil {
-		transport := http.DefaultTransport.(*http.Transport).Clone()
-		transport.MaxIdleConns = 100
-		transport.MaxIdleConnsPerHost = 10
-		transport.IdleConnTimeout = 90 * time.Second
-
-		client = &http.Client{
-			Timeout:   30 * time.Second,
-			Transport: transport,
-		}
+		client = network.NewClient(30 * time.Second)
 	}
 	return fantasy.NewParallelAgentTool(
 		SourcegraphToolName,
@@ -20,7 +20,7 @@ type TodosParams struct {
 
 type TodoItem struct {
 	Content    string `json:"content" description:"需要完成的任务（命令式形式）"`
-	Status     string `json:"status" description:"任务状态：pending（待处理）、in_progress（进行中）或 completed（已完成）"`
+	Status     string `json:"status" enum:"pending,in_progress,completed" description:"任务状态：pending（待处理）、in_progress（进行中）或 completed（已完成）"`
 	ActiveForm string `json:"active_form" description:"现在进行时形式（例如，'运行测试'）"`
 }
 
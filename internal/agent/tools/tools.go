@@ -2,6 +2,7 @@ package tools
 
 import (
 	"context"
+	"path/filepath"
 )
 
 type (
@@ -9,6 +10,7 @@ type (
 	messageIDContextKey string
 	supportsImagesKey   string
 	modelNameKey        string
+	workingDirKey       string
 )
 
 const (
@@ -20,6 +22,8 @@ const (
 	SupportsImagesContextKey supportsImagesKey = "supports_images"
 	// ModelNameContextKey 是上下文中模型名称的键。
 	ModelNameContextKey modelNameKey = "model_name"
+	// WorkingDirContextKey 是上下文中会话工作目录的键，值是相对于项目根目录的路径。
+	WorkingDirContextKey workingDirKey = "working_dir"
 )
 
 // GetSessionFromContext 从上下文中检索会话 ID。
@@ -72,3 +76,25 @@ func GetModelNameFromContext(ctx context.Context) string {
 	}
 	return s
 }
+
+// GetWorkingDirFromContext 从上下文中检索会话的工作目录，未设置时返回空字符串。
+func GetWorkingDirFromContext(ctx context.Context) string {
+	workingDir := ctx.Value(WorkingDirContextKey)
+	if workingDir == nil {
+		return ""
+	}
+	s, ok := workingDir.(string)
+	if !ok {
+		return ""
+	}
+	return s
+}
+
+// sessionWorkingDir 返回工具调用所属会话的工作目录。会话设置了工作目录时相对于项目根目录
+// workingDir 解析，否则返回 workingDir。
+func sessionWorkingDir(ctx context.Context, workingDir string) string {
+	if dir := GetWorkingDirFromContext(ctx); dir != "" {
+		return filepath.Join(workingDir, filepath.FromSlash(dir))
+	}
+	return workingDir
+}
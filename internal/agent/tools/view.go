@@ -5,6 +5,7 @@ import (
 	"context"
 	_ "embed"
 	"encoding/base64"
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -13,10 +14,12 @@ import (
 	"unicode/utf8"
 
 	"charm.land/fantasy"
+	"github.com/purpose168/crush-cn/internal/csync"
 	"github.com/purpose168/crush-cn/internal/filepathext"
 	"github.com/purpose168/crush-cn/internal/filetracker"
 	"github.com/purpose168/crush-cn/internal/lsp"
 	"github.com/purpose168/crush-cn/internal/permission"
+	"github.com/purpose168/crush-cn/internal/vfs"
 )
 
 //go:embed view.md
@@ -26,17 +29,25 @@ type ViewParams struct {
 	FilePath string `json:"file_path" description:"要读取的文件路径"`
 	Offset   int    `json:"offset,omitempty" description:"开始读取的行号（从 0 开始）"`
 	Limit    int    `json:"limit,omitempty" description:"要读取的行数（默认为 2000）"`
+	Page     string `json:"page,omitempty" description:"next 或 prev：从本会话上次查看该文件的窗口向后或向前翻一页，此时忽略 offset"`
 }
 
 type ViewPermissionsParams struct {
 	FilePath string `json:"file_path"`
 	Offset   int    `json:"offset"`
 	Limit    int    `json:"limit"`
+	Page     string `json:"page,omitempty"`
 }
 
 type ViewResponseMetadata struct {
 	FilePath string `json:"file_path"`
 	Content  string `json:"content"`
+	// Offset 是显示的第一行的行号（从 0 开始）
+	Offset int `json:"offset,omitempty"`
+	// Lines 是显示的行数
+	Lines int `json:"lines,omitempty"`
+	// TotalLines 是文件的总行数
+	TotalLines int `json:"total_lines,omitempty"`
 }
 
 const (
@@ -44,15 +55,69 @@ const (
 	MaxReadSize      = 5 * 1024 * 1024 // 5MB
 	DefaultReadLimit = 2000
 	MaxLineLength    = 2000
+
+	// ViewPageNext 和 ViewPagePrev 是 page 参数的取值，从上次查看的窗口向后或向前翻页
+	ViewPageNext = "next"
+	ViewPagePrev = "prev"
 )
 
+// viewWindow 是会话中上次查看文件时显示的窗口，用于翻页
+type viewWindow struct {
+	offset int
+	lines  int
+	total  int
+}
+
+// pageWindow 根据上次查看的窗口计算翻页后的起始行和行数。limit 为 0 时沿用上次的行数。
+func pageWindow(last viewWindow, page string, limit int) (offset, lines int, err error) {
+	if limit <= 0 {
+		limit = max(last.lines, 1)
+	}
+	switch page {
+	case ViewPageNext:
+		offset = last.offset + last.lines
+		if offset >= last.total {
+			return 0, 0, fmt.Errorf("已经到达文件末尾（共 %d 行）", last.total)
+		}
+	case ViewPagePrev:
+		if last.offset == 0 {
+			return 0, 0, errors.New("已经位于文件开头")
+		}
+		offset = max(0, last.offset-limit)
+		// 向前翻页时不与上次显示的内容重叠
+		limit = min(limit, last.offset-offset)
+	default:
+		return 0, 0, fmt.Errorf("page 只能是 %q 或 %q", ViewPageNext, ViewPagePrev)
+	}
+	return offset, limit, nil
+}
+
+// viewPosition 返回输出末尾的位置说明，文件全部显示时返回空字符串
+func viewPosition(offset, lines, total int) string {
+	if lines == 0 || (offset == 0 && lines >= total) {
+		return ""
+	}
+	var hints []string
+	if offset+lines < total {
+		hints = append(hints, fmt.Sprintf("page 为 %q 读取后面的内容", ViewPageNext))
+	}
+	if offset > 0 {
+		hints = append(hints, fmt.Sprintf("page 为 %q 读取前面的内容", ViewPagePrev))
+	}
+	return fmt.Sprintf("\n\n(显示第 %d-%d 行，共 %d 行。使用 %s)", offset+1, offset+lines, total, strings.Join(hints, "，"))
+}
+
 func NewViewTool(
 	lspManager *lsp.Manager,
 	permissions permission.Service,
 	filetracker filetracker.Service,
+	fsys vfs.FS,
 	workingDir string,
 	skillsPaths ...string,
 ) fantasy.AgentTool {
+	fsys = vfs.OrLocal(fsys)
+	// 每个会话中每个文件上次查看的窗口
+	windows := csync.NewMap[string, viewWindow]()
 	return fantasy.NewAgentTool(
 		ViewToolName,
 		string(viewDescription),
@@ -105,15 +170,21 @@ func NewViewTool(
 				}
 			}
 
+			// 技能文件总是在本地，即使文件工具在远程主机上运行
+			readFS := fsys
+			if isSkillFile {
+				readFS = vfs.Local{}
+			}
+
 			// 检查文件是否存在
-			fileInfo, err := os.Stat(filePath)
+			fileInfo, err := readFS.Stat(ctx, filePath)
 			if err != nil {
 				if os.IsNotExist(err) {
 					// 尝试提供名称相似的文件建议
 					dir := filepath.Dir(filePath)
 					base := filepath.Base(filePath)
 
-					dirEntries, dirErr := os.ReadDir(dir)
+					dirEntries, dirErr := readFS.ReadDir(ctx, dir)
 					if dirErr == nil {
 						var suggestions []string
 						for _, entry := range dirEntries {
@@ -148,6 +219,20 @@ func NewViewTool(
 					fileInfo.Size(), MaxReadSize)), nil
 			}
 
+			// 从上次查看的窗口翻页
+			windowKey := sessionID + "\x00" + filePath
+			if params.Page != "" {
+				last, ok := windows.Get(windowKey)
+				if !ok {
+					return fantasy.NewTextErrorResponse("本会话中还没有查看过该文件，请先不带 page 参数调用 view"), nil
+				}
+				offset, limit, err := pageWindow(last, params.Page, params.Limit)
+				if err != nil {
+					return fantasy.NewTextErrorResponse(err.Error()), nil
+				}
+				params.Offset, params.Limit = offset, limit
+			}
+
 			// 如果未提供限制，则设置默认限制（SKILL.md 文件无限制）
 			if params.Limit <= 0 {
 				if isSkillFile {
@@ -164,7 +249,7 @@ func NewViewTool(
 					return fantasy.NewTextErrorResponse(fmt.Sprintf("此模型 (%s) 不支持图像数据。", modelName)), nil
 				}
 
-				imageData, err := os.ReadFile(filePath)
+				imageData, err := readFS.ReadFile(ctx, filePath)
 				if err != nil {
 					return fantasy.ToolResponse{}, fmt.Errorf("读取图像文件错误: %w", err)
 				}
@@ -174,7 +259,7 @@ func NewViewTool(
 			}
 
 			// 读取文件内容
-			content, lineCount, err := readTextFile(filePath, params.Offset, params.Limit)
+			content, lineCount, err := readTextFile(ctx, readFS, filePath, params.Offset, params.Limit)
 			isValidUt8 := utf8.ValidString(content)
 			if !isValidUt8 {
 				return fantasy.NewTextErrorResponse("文件内容不是有效的 UTF-8"), nil
@@ -188,19 +273,21 @@ func NewViewTool(
 			// 格式化输出，添加行号
 			output += addLineNumbers(content, params.Offset+1)
 
-			// 如果内容被截断，添加说明
-			if lineCount > params.Offset+len(strings.Split(content, "\n")) {
-				output += fmt.Sprintf("\n\n(文件还有更多行。使用 'offset' 参数读取第 %d 行之后的内容)",
-					params.Offset+len(strings.Split(content, "\n")))
-			}
+			// 文件没有全部显示时，添加当前位置和翻页说明
+			lines := max(0, min(params.Limit, lineCount-params.Offset))
+			output += viewPosition(params.Offset, lines, lineCount)
 			output += "\n</file>\n"
+			windows.Set(windowKey, viewWindow{offset: params.Offset, lines: lines, total: lineCount})
 			output += getDiagnostics(filePath, lspManager)
 			filetracker.RecordRead(ctx, sessionID, filePath)
 			return fantasy.WithResponseMetadata(
 				fantasy.NewTextResponse(output),
 				ViewResponseMetadata{
-					FilePath: filePath,
-					Content:  content,
+					FilePath:   filePath,
+					Content:    content,
+					Offset:     params.Offset,
+					Lines:      lines,
+					TotalLines: lineCount,
 				},
 			), nil
 		})
@@ -231,8 +318,8 @@ func addLineNumbers(content string, startLine int) string {
 	return strings.Join(result, "\n")
 }
 
-func readTextFile(filePath string, offset, limit int) (string, int, error) {
-	file, err := os.Open(filePath)
+func readTextFile(ctx context.Context, fsys vfs.FS, filePath string, offset, limit int) (string, int, error) {
+	file, err := fsys.Open(ctx, filePath)
 	if err != nil {
 		return "", 0, err
 	}
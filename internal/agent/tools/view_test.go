@@ -0,0 +1,51 @@
+package tools
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPageWindow(t *testing.T) {
+	t.Parallel()
+
+	last := viewWindow{offset: 100, lines: 50, total: 500}
+
+	offset, lines, err := pageWindow(last, ViewPageNext, 0)
+	require.NoError(t, err)
+	require.Equal(t, 150, offset)
+	require.Equal(t, 50, lines)
+
+	offset, lines, err = pageWindow(last, ViewPageNext, 20)
+	require.NoError(t, err)
+	require.Equal(t, 150, offset)
+	require.Equal(t, 20, lines)
+
+	offset, lines, err = pageWindow(last, ViewPagePrev, 0)
+	require.NoError(t, err)
+	require.Equal(t, 50, offset)
+	require.Equal(t, 50, lines)
+
+	// 向前翻页不与上次显示的内容重叠
+	offset, lines, err = pageWindow(viewWindow{offset: 30, lines: 50, total: 500}, ViewPagePrev, 0)
+	require.NoError(t, err)
+	require.Equal(t, 0, offset)
+	require.Equal(t, 30, lines)
+
+	_, _, err = pageWindow(viewWindow{offset: 450, lines: 50, total: 500}, ViewPageNext, 0)
+	require.ErrorContains(t, err, "末尾")
+	_, _, err = pageWindow(viewWindow{lines: 50, total: 500}, ViewPagePrev, 0)
+	require.ErrorContains(t, err, "开头")
+	_, _, err = pageWindow(last, "last", 0)
+	require.Error(t, err)
+}
+
+func TestViewPosition(t *testing.T) {
+	t.Parallel()
+
+	require.Empty(t, viewPosition(0, 10, 10))
+	require.Empty(t, viewPosition(20, 0, 10))
+	require.Equal(t, "\n\n(显示第 1-10 行，共 30 行。使用 page 为 \"next\" 读取后面的内容)", viewPosition(0, 10, 30))
+	require.Equal(t, "\n\n(显示第 11-20 行，共 30 行。使用 page 为 \"next\" 读取后面的内容，page 为 \"prev\" 读取前面的内容)", viewPosition(10, 10, 30))
+	require.Equal(t, "\n\n(显示第 21-30 行，共 30 行。使用 page 为 \"prev\" 读取前面的内容)", viewPosition(20, 10, 30))
+}
@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"charm.land/fantasy"
+	"github.com/purpose168/crush-cn/internal/network"
 )
 
 //go:embed web_fetch.md
@@ -18,15 +19,7 @@ var webFetchToolDescription []byte
 // NewWebFetchTool 创建一个简单的网络获取工具，供子代理使用（不需要权限）。
 func NewWebFetchTool(workingDir string, client *http.Client) fantasy.AgentTool {
 	if client == nil {
-		transport := http.DefaultTransport.(*http.Transport).Clone()
-		transport.MaxIdleConns = 100
-		transport.MaxIdleConnsPerHost = 10
-		transport.IdleConnTimeout = 90 * time.Second
-
-		client = &http.Client{
-			Timeout:   30 * time.Second,
-			Transport: transport,
-		}
+		client = network.NewClient(30 * time.Second)
 	}
 
 	return fantasy.NewParallelAgentTool(
@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"charm.land/fantasy"
+	"github.com/purpose168/crush-cn/internal/network"
 )
 
 //go:embed web_search.md
@@ -16,15 +17,7 @@ var webSearchToolDescription []byte
 // NewWebSearchTool 为子代理创建网络搜索工具（无需权限）。
 func NewWebSearchTool(client *http.Client) fantasy.AgentTool {
 	if client == nil {
-		transport := http.DefaultTransport.(*http.Transport).Clone()
-		transport.MaxIdleConns = 100
-		transport.MaxIdleConnsPerHost = 10
-		transport.IdleConnTimeout = 90 * time.Second
-
-		client = &http.Client{
-			Timeout:   30 * time.Second,
-			Transport: transport,
-		}
+		client = network.NewClient(30 * time.Second)
 	}
 
 	return fantasy.NewParallelAgentTool(
@@ -50,6 +43,10 @@ func NewWebSearchTool(client *http.Client) fantasy.AgentTool {
 				return fantasy.NewTextErrorResponse("搜索失败: " + err.Error()), nil
 			}
 
-			return fantasy.NewTextResponse(formatSearchResults(results)), nil
+			var metadata WebSourcesMetadata
+			for _, result := range results {
+				metadata.Sources = append(metadata.Sources, WebSource{Title: result.Title, URL: result.Link})
+			}
+			return fantasy.WithResponseMetadata(fantasy.NewTextResponse(formatSearchResults(results)), metadata), nil
 		})
 }
@@ -0,0 +1,48 @@
+package tools
+
+import (
+	"encoding/json"
+	"slices"
+)
+
+// WebSources 返回一次网络工具调用抓取或搜索到的来源。抓取工具的来源是输入中的 URL，
+// 网络搜索和智能抓取工具的来源来自响应元数据。其他工具返回 nil
+func WebSources(toolName, input, metadata string) []WebSource {
+	switch toolName {
+	case FetchToolName, WebFetchToolName:
+		var params struct {
+			URL string `json:"url"`
+		}
+		if err := json.Unmarshal([]byte(input), &params); err != nil || params.URL == "" {
+			return nil
+		}
+		return []WebSource{{URL: params.URL}}
+	case WebSearchToolName, AgenticFetchToolName:
+		var meta WebSourcesMetadata
+		if err := json.Unmarshal([]byte(metadata), &meta); err == nil && len(meta.Sources) > 0 {
+			return meta.Sources
+		}
+		if toolName == AgenticFetchToolName {
+			return WebSources(FetchToolName, input, "")
+		}
+	}
+	return nil
+}
+
+// MergeWebSources 将来源追加到列表中，按 URL 去重，已有来源缺少标题时使用新来源的标题
+func MergeWebSources(sources []WebSource, more ...WebSource) []WebSource {
+	for _, source := range more {
+		if source.URL == "" {
+			continue
+		}
+		i := slices.IndexFunc(sources, func(s WebSource) bool { return s.URL == source.URL })
+		if i < 0 {
+			sources = append(sources, source)
+			continue
+		}
+		if sources[i].Title == "" {
+			sources[i].Title = source.Title
+		}
+	}
+	return sources
+}
@@ -0,0 +1,47 @@
+package tools
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebSources(t *testing.T) {
+	t.Parallel()
+
+	metadata, err := json.Marshal(WebSourcesMetadata{Sources: []WebSource{
+		{Title: "Go", URL: "https://go.dev"},
+		{Title: "Docs", URL: "https://go.dev/doc"},
+	}})
+	require.NoError(t, err)
+
+	require.Equal(t, []WebSource{{URL: "https://example.com"}},
+		WebSources(FetchToolName, `{"url":"https://example.com","format":"text"}`, ""))
+	require.Equal(t, []WebSource{{URL: "https://example.com"}},
+		WebSources(WebFetchToolName, `{"url":"https://example.com"}`, ""))
+	require.Len(t, WebSources(WebSearchToolName, `{"query":"go"}`, string(metadata)), 2)
+	require.Empty(t, WebSources(WebSearchToolName, `{"query":"go"}`, ""))
+
+	// 智能抓取结果来自缓存时没有元数据，退回到输入中的 URL
+	require.Equal(t, []WebSource{{URL: "https://example.com"}},
+		WebSources(AgenticFetchToolName, `{"url":"https://example.com","prompt":"p"}`, ""))
+	require.Empty(t, WebSources(AgenticFetchToolName, `{"prompt":"p"}`, ""))
+	require.Empty(t, WebSources(BashToolName, `{"command":"curl https://example.com"}`, ""))
+}
+
+func TestMergeWebSources(t *testing.T) {
+	t.Parallel()
+
+	sources := MergeWebSources(nil,
+		WebSource{URL: "https://a.example"},
+		WebSource{Title: "B", URL: "https://b.example"},
+		WebSource{Title: "A", URL: "https://a.example"},
+		WebSource{Title: "B2", URL: "https://b.example"},
+		WebSource{Title: "empty"},
+	)
+	require.Equal(t, []WebSource{
+		{Title: "A", URL: "https://a.example"},
+		{Title: "B", URL: "https://b.example"},
+	}, sources)
+}
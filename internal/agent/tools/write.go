@@ -12,6 +12,7 @@ import (
 
 	"charm.land/fantasy"
 	"github.com/purpose168/crush-cn/internal/diff"
+	"github.com/purpose168/crush-cn/internal/dryrun"
 	"github.com/purpose168/crush-cn/internal/filepathext"
 	"github.com/purpose168/crush-cn/internal/filetracker"
 	"github.com/purpose168/crush-cn/internal/fsext"
@@ -19,6 +20,7 @@ import (
 
 	"github.com/purpose168/crush-cn/internal/lsp"
 	"github.com/purpose168/crush-cn/internal/permission"
+	"github.com/purpose168/crush-cn/internal/vfs"
 )
 
 //go:embed write.md
@@ -48,8 +50,11 @@ func NewWriteTool(
 	permissions permission.Service,
 	files history.Service,
 	filetracker filetracker.Service,
+	dryRun dryrun.Service,
+	fsys vfs.FS,
 	workingDir string,
 ) fantasy.AgentTool {
+	fsys = vfs.OrLocal(fsys)
 	return fantasy.NewAgentTool(
 		WriteToolName,
 		string(writeDescription),
@@ -69,7 +74,7 @@ func NewWriteTool(
 
 			filePath := filepathext.SmartJoin(workingDir, params.FilePath)
 
-			fileInfo, err := os.Stat(filePath)
+			fileInfo, err := statFile(ctx, fsys, dryRun, filePath)
 			if err == nil {
 				if fileInfo.IsDir() {
 					return fantasy.NewTextErrorResponse(fmt.Sprintf("路径是目录，不是文件: %s", filePath)), nil
@@ -82,7 +87,7 @@ func NewWriteTool(
 						filePath, modTime.Format(time.RFC3339), lastRead.Format(time.RFC3339))), nil
 				}
 
-				oldContent, readErr := os.ReadFile(filePath)
+				oldContent, readErr := readFile(ctx, fsys, dryRun, filePath)
 				if readErr == nil && string(oldContent) == params.Content {
 					return fantasy.NewTextErrorResponse(fmt.Sprintf("文件 %s 已包含完全相同的内容。未进行任何更改。", filePath)), nil
 				}
@@ -91,13 +96,13 @@ func NewWriteTool(
 			}
 
 			dir := filepath.Dir(filePath)
-			if err = os.MkdirAll(dir, 0o755); err != nil {
+			if err = mkdirAll(ctx, fsys, dryRun, dir); err != nil {
 				return fantasy.ToolResponse{}, fmt.Errorf("创建目录错误: %w", err)
 			}
 
 			oldContent := ""
 			if fileInfo != nil && !fileInfo.IsDir() {
-				oldBytes, readErr := os.ReadFile(filePath)
+				oldBytes, readErr := readFile(ctx, fsys, dryRun, filePath)
 				if readErr == nil {
 					oldContent = string(oldBytes)
 				}
@@ -131,7 +136,22 @@ func NewWriteTool(
 				return fantasy.ToolResponse{}, permission.ErrorPermissionDenied
 			}
 
-			err = os.WriteFile(filePath, []byte(params.Content), 0o644)
+			metadata := WriteResponseMetadata{
+				Diff:      diff,
+				Additions: additions,
+				Removals:  removals,
+			}
+			if dryRunActive(dryRun) {
+				response, err := dryRunResponse(ctx, dryRun, filetracker, filePath, params.Content, "文件写入成功: "+filePath, metadata)
+				if err != nil {
+					return response, err
+				}
+				// 文件没有变化，无需通知 LSP
+				response.Content = fmt.Sprintf("<result>\n%s\n</result>", response.Content)
+				return response, nil
+			}
+
+			err = fsys.WriteFile(ctx, filePath, []byte(params.Content), 0o644)
 			if err != nil {
 				return fantasy.ToolResponse{}, fmt.Errorf("写入文件错误: %w", err)
 			}
@@ -165,12 +185,6 @@ func NewWriteTool(
 			result := fmt.Sprintf("文件写入成功: %s", filePath)
 			result = fmt.Sprintf("<result>\n%s\n</result>", result)
 			result += getDiagnostics(filePath, lspManager)
-			return fantasy.WithResponseMetadata(fantasy.NewTextResponse(result),
-				WriteResponseMetadata{
-					Diff:      diff,
-					Additions: additions,
-					Removals:  removals,
-				},
-			), nil
+			return fantasy.WithResponseMetadata(fantasy.NewTextResponse(result), metadata), nil
 		})
 }
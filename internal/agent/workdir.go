@@ -0,0 +1,20 @@
+package agent
+
+import (
+	"context"
+
+	"charm.land/fantasy"
+	"github.com/purpose168/crush-cn/internal/agent/tools"
+)
+
+// workingDirMessage 在会话设置了自己的工作目录时返回一条系统提醒，告诉模型命令和搜索默认在哪里执行。
+// 子智能体从父会话的上下文继承工作目录。
+func workingDirMessage(ctx context.Context) (fantasy.Message, bool) {
+	dir := tools.GetWorkingDirFromContext(ctx)
+	if dir == "" {
+		return fantasy.Message{}, false
+	}
+	return fantasy.NewUserMessage("<system_reminder>The user scoped this session to the `" + dir + "` directory of the project. " +
+		"Bash commands run there by default, and glob, grep, ls and run_tests default to it; relative paths passed to ls resolve against it. " +
+		"Focus on this directory unless the task requires looking elsewhere. Do not mention this reminder to the user.</system_reminder>"), true
+}
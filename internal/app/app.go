@@ -9,6 +9,7 @@ import (
 	"io"
 	"log/slog"
 	"os"
+	"path/filepath"
 	"strings"
 	"sync"
 	"time"
@@ -21,9 +22,13 @@ import (
 	"github.com/charmbracelet/x/exp/charmtone"
 	"github.com/charmbracelet/x/term"
 	"github.com/purpose168/crush-cn/internal/agent"
+	"github.com/purpose168/crush-cn/internal/agent/tools"
 	"github.com/purpose168/crush-cn/internal/agent/tools/mcp"
+	"github.com/purpose168/crush-cn/internal/budget"
+	"github.com/purpose168/crush-cn/internal/checkpoint"
 	"github.com/purpose168/crush-cn/internal/config"
 	"github.com/purpose168/crush-cn/internal/db"
+	"github.com/purpose168/crush-cn/internal/dryrun"
 	"github.com/purpose168/crush-cn/internal/event"
 	"github.com/purpose168/crush-cn/internal/filetracker"
 	"github.com/purpose168/crush-cn/internal/format"
@@ -31,6 +36,7 @@ import (
 	"github.com/purpose168/crush-cn/internal/log"
 	"github.com/purpose168/crush-cn/internal/lsp"
 	"github.com/purpose168/crush-cn/internal/message"
+	"github.com/purpose168/crush-cn/internal/outputstream"
 	"github.com/purpose168/crush-cn/internal/permission"
 	"github.com/purpose168/crush-cn/internal/pubsub"
 	"github.com/purpose168/crush-cn/internal/session"
@@ -39,6 +45,7 @@ import (
 	"github.com/purpose168/crush-cn/internal/ui/styles"
 	"github.com/purpose168/crush-cn/internal/update"
 	"github.com/purpose168/crush-cn/internal/version"
+	"github.com/purpose168/crush-cn/internal/vfs"
 )
 
 // UpdateAvailableMsg 在有新版本可用时发送。
@@ -52,8 +59,13 @@ type App struct {
 	Sessions    session.Service
 	Messages    message.Service
 	History     history.Service
+	Checkpoints checkpoint.Service
 	Permissions permission.Service
 	FileTracker filetracker.Service
+	DryRun      dryrun.Service
+	Budget      budget.Service
+	// FS 是文件工具使用的文件系统，配置了远程开发时通过 SSH 访问远程主机。
+	FS vfs.FS
 
 	AgentCoordinator agent.Coordinator
 
@@ -74,21 +86,32 @@ type App struct {
 // New 初始化一个新的应用程序实例。
 func New(ctx context.Context, conn *sql.DB, cfg *config.Config) (*App, error) {
 	q := db.New(conn)
-	sessions := session.NewService(q, conn)
+	author := cfg.User.Author()
+	sessions := session.NewService(q, conn, author)
 	messages := message.NewService(q)
-	files := history.NewService(q, conn)
+	files := history.NewService(q, conn, author)
+	var dailyBudget float64
+	if cfg.Options.Budget != nil {
+		dailyBudget = cfg.Options.Budget.DailyUSD
+	}
 	skipPermissionsRequests := cfg.Permissions != nil && cfg.Permissions.SkipRequests
 	var allowedTools []string
 	if cfg.Permissions != nil && cfg.Permissions.AllowedTools != nil {
 		allowedTools = cfg.Permissions.AllowedTools
 	}
 
+	fsys := newFileSystem(cfg)
+
 	app := &App{
 		Sessions:    sessions,
 		Messages:    messages,
 		History:     files,
+		Checkpoints: checkpoint.NewService(q, files, messages, fsys),
 		Permissions: permission.NewPermissionService(cfg.WorkingDir(), skipPermissionsRequests, allowedTools),
-		FileTracker: filetracker.NewService(q),
+		FileTracker: newFileTracker(q, cfg),
+		FS:          fsys,
+		DryRun:      dryrun.NewService(cfg.WorkingDir(), filepath.Join(cfg.Options.DataDirectory, dryrun.DirName), cfg.Options.DryRun, fsys),
+		Budget:      budget.NewService(q, dailyBudget),
 		LSPManager:  lsp.NewManager(cfg),
 
 		globalCtx: ctx,
@@ -103,7 +126,9 @@ func New(ctx context.Context, conn *sql.DB, cfg *config.Config) (*App, error) {
 	app.setupEvents()
 
 	// Check for updates in the background.
-	go app.checkForUpdates(ctx)
+	if !cfg.Options.Offline {
+		go app.checkForUpdates(ctx)
+	}
 
 	go mcp.Initialize(ctx, app.Permissions, cfg)
 
@@ -114,6 +139,10 @@ func New(ctx context.Context, conn *sql.DB, cfg *config.Config) (*App, error) {
 		mcp.Close,
 	)
 
+	if cfg.Options.OutputStream.Enabled() {
+		app.startOutputStream(ctx, cfg.Options.OutputStream)
+	}
+
 	// TODO: remove the concept of agent config, most likely.
 	if !cfg.IsConfigured() {
 		slog.Warn("未找到代理配置")
@@ -132,6 +161,38 @@ func New(ctx context.Context, conn *sql.DB, cfg *config.Config) (*App, error) {
 	return app, nil
 }
 
+// newFileSystem 返回文件工具使用的文件系统。配置了远程开发时文件操作通过 SSH 在远程主机上执行。
+func newFileSystem(cfg *config.Config) vfs.FS {
+	if !cfg.Remote.Enabled() {
+		return vfs.Local{}
+	}
+	slog.Info("已启用远程开发", "host", cfg.Remote.Host, "path", cfg.Remote.Path)
+	return vfs.NewSSH(vfs.SSHOptions{
+		Host: cfg.Remote.Host,
+		User: cfg.Remote.User,
+		Port: cfg.Remote.Port,
+	})
+}
+
+// newFileTracker 创建文件跟踪服务。远程开发时路径相对于远程项目目录记录。
+func newFileTracker(q *db.Queries, cfg *config.Config) filetracker.Service {
+	if cfg.Remote.Enabled() {
+		return filetracker.NewServiceWithRoot(q, cfg.Remote.Path)
+	}
+	return filetracker.NewService(q)
+}
+
+// startOutputStream 把助手输出实时写入配置的命名管道或 Unix 套接字。启动失败只记录日志，不影响应用运行。
+func (app *App) startOutputStream(ctx context.Context, cfg *config.OutputStream) {
+	stream, err := outputstream.New(cfg)
+	if err != nil {
+		slog.Error("启动输出流失败", "error", err)
+		return
+	}
+	go stream.Run(ctx, app.Messages.Subscribe(ctx))
+	app.cleanupFuncs = append(app.cleanupFuncs, func(context.Context) error { return stream.Close() })
+}
+
 // Config 返回应用程序配置。
 func (app *App) Config() *config.Config {
 	return app.config
@@ -144,6 +205,15 @@ func (app *App) RunNonInteractive(ctx context.Context, output io.Writer, prompt,
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
+	// 非交互模式无法确认超出预算，直接拒绝运行。
+	budgetStatus, err := app.Budget.Status(ctx)
+	if err != nil {
+		return fmt.Errorf("获取预算状态失败: %w", err)
+	}
+	if budgetStatus.NeedsConfirmation() {
+		return fmt.Errorf("今日费用 $%.2f 已达到预算 $%.2f，可以调高 options.budget.daily_usd 后重试", budgetStatus.Spent, budgetStatus.Limit)
+	}
+
 	if largeModel != "" || smallModel != "" {
 		if err := app.overrideModelsForNonInteractive(ctx, largeModel, smallModel); err != nil {
 			return fmt.Errorf("覆盖模型失败: %w", err)
@@ -165,6 +235,11 @@ func (app *App) RunNonInteractive(ctx context.Context, output io.Writer, prompt,
 	stdinTTY = term.IsTerminal(os.Stdin.Fd())
 	progress = app.config.Options.Progress == nil || *app.config.Options.Progress
 
+	// 无障碍模式下不显示动画旋转器。
+	if app.config.Options.TUI.Accessible {
+		hideSpinner = true
+	}
+
 	if !hideSpinner && stderrTTY {
 		t := styles.DefaultStyles()
 
@@ -414,8 +489,11 @@ func (app *App) setupEvents() {
 	setupSubscriber(ctx, app.serviceEventsWG, "permissions", app.Permissions.Subscribe, app.events)
 	setupSubscriber(ctx, app.serviceEventsWG, "permissions-notifications", app.Permissions.SubscribeNotifications, app.events)
 	setupSubscriber(ctx, app.serviceEventsWG, "history", app.History.Subscribe, app.events)
+	setupSubscriber(ctx, app.serviceEventsWG, "budget", app.Budget.Subscribe, app.events)
 	setupSubscriber(ctx, app.serviceEventsWG, "mcp", mcp.SubscribeEvents, app.events)
 	setupSubscriber(ctx, app.serviceEventsWG, "lsp", SubscribeLSPEvents, app.events)
+	setupSubscriber(ctx, app.serviceEventsWG, "download-progress", tools.SubscribeDownloadProgress, app.events)
+	setupSubscriber(ctx, app.serviceEventsWG, "bash-output", tools.SubscribeBashOutput, app.events)
 	cleanupFunc := func(context.Context) error {
 		cancel()
 		app.serviceEventsWG.Wait()
@@ -485,12 +563,16 @@ func (app *App) InitCoderAgent(ctx context.Context) error {
 		app.Permissions,
 		app.History,
 		app.FileTracker,
+		app.DryRun,
+		app.FS,
+		app.Budget,
 		app.LSPManager,
 	)
 	if err != nil {
 		slog.Error("创建代码代理失败", "err", err)
 		return err
 	}
+	mcp.SetSampler(app.AgentCoordinator.Sample)
 	return nil
 }
 
@@ -0,0 +1,144 @@
+// Package budget 按每次模型请求记录费用，并根据配置的每日预算判断是否需要提醒用户，
+// 或者在继续运行代理之前要求用户确认。
+package budget
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/purpose168/crush-cn/internal/db"
+	"github.com/purpose168/crush-cn/internal/pubsub"
+)
+
+// WarnRatio 是开始提醒时已花费费用占预算的比例。
+const WarnRatio = 0.8
+
+// Level 是费用相对于预算的级别。
+type Level int
+
+const (
+	// LevelOK 表示未配置预算或费用低于提醒线。
+	LevelOK Level = iota
+	// LevelWarning 表示费用达到了预算的 [WarnRatio]。
+	LevelWarning
+	// LevelExceeded 表示费用达到或超出了预算。
+	LevelExceeded
+)
+
+// Status 是今日的费用和预算。
+type Status struct {
+	// Limit 是每日预算（美元），0 表示未配置预算。
+	Limit float64
+	// Spent 是今日已花费的费用（美元）。
+	Spent float64
+	// Confirmed 表示用户已确认在超出预算后今天继续运行代理。
+	Confirmed bool
+}
+
+// Level 返回费用相对于预算的级别。
+func (s Status) Level() Level {
+	switch {
+	case s.Limit <= 0:
+		return LevelOK
+	case s.Spent >= s.Limit:
+		return LevelExceeded
+	case s.Spent >= s.Limit*WarnRatio:
+		return LevelWarning
+	default:
+		return LevelOK
+	}
+}
+
+// NeedsConfirmation 返回在运行代理之前是否需要用户确认。
+func (s Status) NeedsConfirmation() bool {
+	return s.Level() == LevelExceeded && !s.Confirmed
+}
+
+// Service 预算服务接口。每次记录费用后发布最新的 [Status]。
+type Service interface {
+	pubsub.Subscriber[Status]
+	// Record 记录一次模型请求的费用。
+	Record(ctx context.Context, sessionID, provider, model string, cost float64) error
+	// Status 返回今日的费用和预算。
+	Status(ctx context.Context) (Status, error)
+	// Confirm 记录用户确认在超出预算后继续运行代理，确认在当天结束前有效。
+	Confirm()
+}
+
+type service struct {
+	*pubsub.Broker[Status]
+	q     *db.Queries
+	limit float64
+	now   func() time.Time
+
+	mu        sync.Mutex
+	confirmed string // 用户确认超出预算的日期
+}
+
+// NewService 创建新的预算服务实例，dailyLimit 为 0 时不限制费用，但仍然记录费用。
+func NewService(q *db.Queries, dailyLimit float64) Service {
+	return &service{
+		Broker: pubsub.NewBroker[Status](),
+		q:      q,
+		limit:  max(dailyLimit, 0),
+		now:    time.Now,
+	}
+}
+
+func (s *service) Record(ctx context.Context, sessionID, provider, model string, cost float64) error {
+	if cost <= 0 {
+		return nil
+	}
+	err := s.q.CreateUsageRecord(ctx, db.CreateUsageRecordParams{
+		ID:        uuid.New().String(),
+		SessionID: sessionID,
+		Provider:  provider,
+		Model:     model,
+		Cost:      cost,
+	})
+	if err != nil {
+		return err
+	}
+	if s.limit <= 0 {
+		return nil
+	}
+	status, err := s.Status(ctx)
+	if err != nil {
+		return err
+	}
+	s.Publish(pubsub.UpdatedEvent, status)
+	return nil
+}
+
+func (s *service) Status(ctx context.Context) (Status, error) {
+	if s.limit <= 0 {
+		return Status{}, nil
+	}
+	now := s.now()
+	spent, err := s.q.GetCostSince(ctx, startOfDay(now).Unix())
+	if err != nil {
+		return Status{}, err
+	}
+	s.mu.Lock()
+	confirmed := s.confirmed == day(now)
+	s.mu.Unlock()
+	return Status{Limit: s.limit, Spent: spent, Confirmed: confirmed}, nil
+}
+
+func (s *service) Confirm() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.confirmed = day(s.now())
+}
+
+// startOfDay 返回 t 所在本地日期的零点。
+func startOfDay(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+}
+
+func day(t time.Time) string {
+	return t.Format(time.DateOnly)
+}
@@ -0,0 +1,56 @@
+package budget
+
+import (
+	"testing"
+	"time"
+
+	"github.com/purpose168/crush-cn/internal/db"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStatusLevel(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, LevelOK, Status{Spent: 100}.Level())
+	require.Equal(t, LevelOK, Status{Limit: 5, Spent: 3.99}.Level())
+	require.Equal(t, LevelWarning, Status{Limit: 5, Spent: 4}.Level())
+	require.Equal(t, LevelExceeded, Status{Limit: 5, Spent: 5}.Level())
+	require.True(t, Status{Limit: 5, Spent: 6}.NeedsConfirmation())
+	require.False(t, Status{Limit: 5, Spent: 6, Confirmed: true}.NeedsConfirmation())
+}
+
+func TestRecord(t *testing.T) {
+	t.Parallel()
+
+	conn, err := db.Connect(t.Context(), t.TempDir())
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	svc := NewService(db.New(conn), 5).(*service)
+	events := svc.Subscribe(t.Context())
+
+	require.NoError(t, svc.Record(t.Context(), "s1", "openai", "gpt", 3))
+	event := <-events
+	require.Equal(t, Status{Limit: 5, Spent: 3}, event.Payload)
+	require.NoError(t, svc.Record(t.Context(), "s2", "openai", "gpt", 2.5))
+	event = <-events
+	require.Equal(t, LevelExceeded, event.Payload.Level())
+
+	// 没有费用的请求不会被记录。
+	require.NoError(t, svc.Record(t.Context(), "s1", "ollama", "llama", 0))
+	status, err := svc.Status(t.Context())
+	require.NoError(t, err)
+	require.InDelta(t, 5.5, status.Spent, 1e-9)
+	require.True(t, status.NeedsConfirmation())
+
+	svc.Confirm()
+	status, err = svc.Status(t.Context())
+	require.NoError(t, err)
+	require.False(t, status.NeedsConfirmation())
+
+	// 第二天重新开始统计，之前的确认也不再有效。
+	svc.now = func() time.Time { return time.Now().AddDate(0, 0, 1) }
+	status, err = svc.Status(t.Context())
+	require.NoError(t, err)
+	require.Equal(t, Status{Limit: 5}, status)
+}
@@ -0,0 +1,79 @@
+// Package capability 根据 catwalk 模型元数据和模型配置判断模型支持的功能，
+// 界面据此禁用当前模型不支持的操作，而不是等到发送请求时才失败。
+package capability
+
+import (
+	"cmp"
+	"fmt"
+
+	"charm.land/catwalk/pkg/catwalk"
+	"github.com/purpose168/crush-cn/internal/config"
+)
+
+// Feature 是模型可能支持的功能。
+type Feature string
+
+const (
+	Images    Feature = "images"    // 图片输入
+	Tools     Feature = "tools"     // 工具调用
+	Reasoning Feature = "reasoning" // 推理/思考
+)
+
+// Set 是单个模型支持的功能集合。
+type Set struct {
+	model     string
+	images    bool
+	tools     bool
+	reasoning bool
+}
+
+// Of 返回模型支持的功能。catwalk 没有提供工具调用的元数据，
+// 工具调用默认视为支持，可在模型配置中通过 disable_tools 关闭。
+func Of(model catwalk.Model, selected config.SelectedModel) Set {
+	return Set{
+		model:     cmp.Or(model.Name, model.ID, selected.Model),
+		images:    model.SupportsImages,
+		tools:     !selected.DisableTools,
+		reasoning: model.CanReason,
+	}
+}
+
+// All 返回支持所有功能的集合，用于尚未选择模型的情况。
+func All() Set {
+	return Set{images: true, tools: true, reasoning: true}
+}
+
+// Supports 报告模型是否支持功能。
+func (s Set) Supports(f Feature) bool {
+	switch f {
+	case Images:
+		return s.images
+	case Tools:
+		return s.tools
+	case Reasoning:
+		return s.reasoning
+	default:
+		return false
+	}
+}
+
+// Reason 返回功能不可用的说明，模型支持该功能时返回空字符串。
+func (s Set) Reason(f Feature) string {
+	if s.Supports(f) {
+		return ""
+	}
+	model := "当前模型"
+	if s.model != "" {
+		model += " " + s.model + " "
+	}
+	switch f {
+	case Images:
+		return model + "不支持图片输入"
+	case Tools:
+		return model + "不支持工具调用"
+	case Reasoning:
+		return model + "不支持推理"
+	default:
+		return fmt.Sprintf("%s不支持 %s", model, f)
+	}
+}
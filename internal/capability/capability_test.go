@@ -0,0 +1,35 @@
+package capability
+
+import (
+	"testing"
+
+	"charm.land/catwalk/pkg/catwalk"
+	"github.com/purpose168/crush-cn/internal/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOf(t *testing.T) {
+	t.Parallel()
+
+	textOnly := Of(catwalk.Model{ID: "text-1", Name: "Text One"}, config.SelectedModel{Model: "text-1"})
+	require.False(t, textOnly.Supports(Images))
+	require.False(t, textOnly.Supports(Reasoning))
+	require.True(t, textOnly.Supports(Tools))
+	require.Equal(t, "当前模型 Text One 不支持图片输入", textOnly.Reason(Images))
+	require.Equal(t, "当前模型 Text One 不支持推理", textOnly.Reason(Reasoning))
+	require.Empty(t, textOnly.Reason(Tools))
+
+	full := Of(catwalk.Model{ID: "vision", SupportsImages: true, CanReason: true}, config.SelectedModel{DisableTools: true})
+	require.True(t, full.Supports(Images))
+	require.True(t, full.Supports(Reasoning))
+	require.False(t, full.Supports(Tools))
+	require.Equal(t, "当前模型 vision 不支持工具调用", full.Reason(Tools))
+	require.Empty(t, full.Reason(Images))
+
+	require.False(t, full.Supports(Feature("audio")))
+	require.Equal(t, "当前模型不支持图片输入", Set{}.Reason(Images))
+
+	for _, f := range []Feature{Images, Tools, Reasoning} {
+		require.True(t, All().Supports(f))
+	}
+}
@@ -0,0 +1,282 @@
+// Package checkpoint 管理会话中命名的检查点。
+// 检查点记录某一时刻工作区中会话所跟踪文件的版本以及对话所处的位置，
+// 之后可以将文件状态恢复到任意一个检查点。
+package checkpoint
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/purpose168/crush-cn/internal/db"
+	"github.com/purpose168/crush-cn/internal/history"
+	"github.com/purpose168/crush-cn/internal/message"
+	"github.com/purpose168/crush-cn/internal/vfs"
+)
+
+// Checkpoint 是会话中一个命名的快照。
+type Checkpoint struct {
+	ID        string
+	SessionID string
+	Name      string
+	// MessageID 是创建检查点时会话的最后一条消息，会话为空时为空。
+	MessageID string
+	// Files 将文件路径映射到对应的 history.File 版本 ID；
+	// 值为空表示创建检查点时该文件不存在。
+	Files     map[string]string
+	CreatedAt int64
+}
+
+// Service 检查点服务接口。
+type Service interface {
+	// Create 为会话当前的文件状态和对话位置创建一个命名检查点。
+	Create(ctx context.Context, sessionID, name string) (Checkpoint, error)
+	// List 列出会话的所有检查点，最新的在前。
+	List(ctx context.Context, sessionID string) ([]Checkpoint, error)
+	// Restore 将文件恢复到检查点时的状态，返回被修改的文件路径。
+	Restore(ctx context.Context, id string) ([]string, error)
+	// Delete 删除检查点，不影响工作区中的文件。
+	Delete(ctx context.Context, id string) error
+}
+
+type service struct {
+	q        *db.Queries
+	files    history.Service
+	messages message.Service
+	fsys     vfs.FS
+}
+
+// NewService 创建新的检查点服务实例。文件通过 fsys 读写，为 nil 时使用本地文件系统。
+func NewService(q *db.Queries, files history.Service, messages message.Service, fsys vfs.FS) Service {
+	return &service{
+		q:        q,
+		files:    files,
+		messages: messages,
+		fsys:     vfs.OrLocal(fsys),
+	}
+}
+
+func (s *service) Create(ctx context.Context, sessionID, name string) (Checkpoint, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return Checkpoint{}, errors.New("检查点名称不能为空")
+	}
+
+	tracked, err := s.trackedFiles(ctx, sessionID)
+	if err != nil {
+		return Checkpoint{}, err
+	}
+
+	// 文件可能在最后一次记录版本之后被外部修改，因此以磁盘上的内容为准，
+	// 必要时先记录一个新版本。
+	files := make(map[string]string, len(tracked))
+	for path, versions := range tracked {
+		content, err := s.fsys.ReadFile(ctx, path)
+		if errors.Is(err, os.ErrNotExist) {
+			files[path] = ""
+			continue
+		}
+		if err != nil {
+			return Checkpoint{}, fmt.Errorf("读取文件 %s 失败: %w", path, err)
+		}
+		latest := versions.latest
+		if string(content) != latest.Content {
+			latest, err = s.files.CreateVersion(ctx, sessionID, path, string(content))
+			if err != nil {
+				return Checkpoint{}, fmt.Errorf("记录文件 %s 的版本失败: %w", path, err)
+			}
+		}
+		files[path] = latest.ID
+	}
+
+	var messageID sql.NullString
+	msgs, err := s.messages.List(ctx, sessionID)
+	if err != nil {
+		return Checkpoint{}, fmt.Errorf("获取会话消息失败: %w", err)
+	}
+	if len(msgs) > 0 {
+		messageID = sql.NullString{String: msgs[len(msgs)-1].ID, Valid: true}
+	}
+
+	data, err := json.Marshal(files)
+	if err != nil {
+		return Checkpoint{}, err
+	}
+	dbCheckpoint, err := s.q.CreateCheckpoint(ctx, db.CreateCheckpointParams{
+		ID:        uuid.New().String(),
+		SessionID: sessionID,
+		Name:      name,
+		MessageID: messageID,
+		Files:     string(data),
+	})
+	if err != nil {
+		return Checkpoint{}, fmt.Errorf("保存检查点失败: %w", err)
+	}
+	return fromDBItem(dbCheckpoint)
+}
+
+func (s *service) List(ctx context.Context, sessionID string) ([]Checkpoint, error) {
+	dbCheckpoints, err := s.q.ListCheckpointsBySession(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	checkpoints := make([]Checkpoint, 0, len(dbCheckpoints))
+	for _, c := range dbCheckpoints {
+		checkpoint, err := fromDBItem(c)
+		if err != nil {
+			return nil, err
+		}
+		checkpoints = append(checkpoints, checkpoint)
+	}
+	return checkpoints, nil
+}
+
+func (s *service) Restore(ctx context.Context, id string) ([]string, error) {
+	dbCheckpoint, err := s.q.GetCheckpoint(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("获取检查点失败: %w", err)
+	}
+	checkpoint, err := fromDBItem(dbCheckpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	// target 将路径映射到期望的内容，nil 表示文件不应存在。
+	target := make(map[string]*string, len(checkpoint.Files))
+	for path, fileID := range checkpoint.Files {
+		if fileID == "" {
+			target[path] = nil
+			continue
+		}
+		file, err := s.files.Get(ctx, fileID)
+		if err != nil {
+			return nil, fmt.Errorf("获取文件 %s 的版本失败: %w", path, err)
+		}
+		target[path] = &file.Content
+	}
+
+	// 检查点之后才被跟踪的文件恢复到会话中的初始版本；
+	// 初始内容为空表示文件是在会话中新建的，因此将其删除。
+	tracked, err := s.trackedFiles(ctx, checkpoint.SessionID)
+	if err != nil {
+		return nil, err
+	}
+	for path, versions := range tracked {
+		if _, ok := target[path]; ok {
+			continue
+		}
+		if versions.initial.Content == "" {
+			target[path] = nil
+			continue
+		}
+		target[path] = &versions.initial.Content
+	}
+
+	var changed []string
+	for path, content := range target {
+		ok, err := s.restoreFile(ctx, path, content)
+		if err != nil {
+			return changed, err
+		}
+		if !ok {
+			continue
+		}
+		changed = append(changed, path)
+		// 记录恢复后的内容，使后续的编辑和检查点基于正确的版本。
+		if _, err := s.files.CreateVersion(ctx, checkpoint.SessionID, path, deref(content)); err != nil {
+			return changed, fmt.Errorf("记录文件 %s 的版本失败: %w", path, err)
+		}
+	}
+	slices.Sort(changed)
+	return changed, nil
+}
+
+func (s *service) Delete(ctx context.Context, id string) error {
+	return s.q.DeleteCheckpoint(ctx, id)
+}
+
+// fileVersions 保存会话中某个文件的初始版本和最新版本。
+type fileVersions struct {
+	initial history.File
+	latest  history.File
+}
+
+// trackedFiles 返回会话中跟踪的所有文件，以路径为键。
+func (s *service) trackedFiles(ctx context.Context, sessionID string) (map[string]fileVersions, error) {
+	files, err := s.files.ListBySession(ctx, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("获取会话文件失败: %w", err)
+	}
+	tracked := make(map[string]fileVersions)
+	for _, f := range files {
+		versions, ok := tracked[f.Path]
+		if !ok || f.Version < versions.initial.Version {
+			versions.initial = f
+		}
+		if !ok || f.Version >= versions.latest.Version {
+			versions.latest = f
+		}
+		tracked[f.Path] = versions
+	}
+	return tracked, nil
+}
+
+// restoreFile 将文件写为给定内容，content 为 nil 时删除文件。
+// 返回文件是否被修改。
+func (s *service) restoreFile(ctx context.Context, path string, content *string) (bool, error) {
+	current, err := s.fsys.ReadFile(ctx, path)
+	exists := err == nil
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return false, fmt.Errorf("读取文件 %s 失败: %w", path, err)
+	}
+
+	if content == nil {
+		if !exists {
+			return false, nil
+		}
+		if err := s.fsys.Remove(ctx, path); err != nil {
+			return false, fmt.Errorf("删除文件 %s 失败: %w", path, err)
+		}
+		return true, nil
+	}
+
+	if exists && string(current) == *content {
+		return false, nil
+	}
+	if err := s.fsys.MkdirAll(ctx, filepath.Dir(path), 0o755); err != nil {
+		return false, fmt.Errorf("创建目录失败: %w", err)
+	}
+	if err := s.fsys.WriteFile(ctx, path, []byte(*content), 0o644); err != nil {
+		return false, fmt.Errorf("写入文件 %s 失败: %w", path, err)
+	}
+	return true, nil
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+func fromDBItem(item db.Checkpoint) (Checkpoint, error) {
+	files := map[string]string{}
+	if err := json.Unmarshal([]byte(item.Files), &files); err != nil {
+		return Checkpoint{}, fmt.Errorf("解析检查点文件失败: %w", err)
+	}
+	return Checkpoint{
+		ID:        item.ID,
+		SessionID: item.SessionID,
+		Name:      item.Name,
+		MessageID: item.MessageID.String,
+		Files:     files,
+		CreatedAt: item.CreatedAt,
+	}, nil
+}
@@ -0,0 +1,91 @@
+package checkpoint
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/purpose168/crush-cn/internal/db"
+	"github.com/purpose168/crush-cn/internal/history"
+	"github.com/purpose168/crush-cn/internal/message"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateAndRestore(t *testing.T) {
+	t.Parallel()
+
+	conn, err := db.Connect(t.Context(), t.TempDir())
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	q := db.New(conn)
+	files := history.NewService(q, conn, "")
+	messages := message.NewService(q)
+	svc := NewService(q, files, messages, nil)
+
+	const sessionID = "session"
+	_, err = q.CreateSession(t.Context(), db.CreateSessionParams{ID: sessionID, Title: "Test Session"})
+	require.NoError(t, err)
+	msg, err := messages.Create(t.Context(), sessionID, message.CreateMessageParams{
+		Role:  message.User,
+		Parts: []message.ContentPart{message.TextContent{Text: "hello"}},
+	})
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	edited := filepath.Join(dir, "edited.go")
+	created := filepath.Join(dir, "created.go")
+	later := filepath.Join(dir, "later.go")
+
+	// edited.go 在会话开始前就存在，created.go 在会话中新建。
+	require.NoError(t, os.WriteFile(edited, []byte("v1"), 0o644))
+	_, err = files.Create(t.Context(), sessionID, edited, "v1")
+	require.NoError(t, err)
+	_, err = files.Create(t.Context(), sessionID, created, "")
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(created, []byte("new"), 0o644))
+
+	_, err = svc.Create(t.Context(), sessionID, "  ")
+	require.Error(t, err)
+
+	cp, err := svc.Create(t.Context(), sessionID, "first")
+	require.NoError(t, err)
+	require.Equal(t, msg.ID, cp.MessageID)
+	require.Len(t, cp.Files, 2)
+
+	// 检查点之后：修改已有文件、删除新建的文件，并新建另一个文件。
+	require.NoError(t, os.WriteFile(edited, []byte("v2"), 0o644))
+	_, err = files.CreateVersion(t.Context(), sessionID, edited, "v2")
+	require.NoError(t, err)
+	require.NoError(t, os.Remove(created))
+	_, err = files.Create(t.Context(), sessionID, later, "")
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(later, []byte("later"), 0o644))
+
+	changed, err := svc.Restore(t.Context(), cp.ID)
+	require.NoError(t, err)
+	require.Equal(t, []string{created, edited, later}, changed)
+
+	content, err := os.ReadFile(edited)
+	require.NoError(t, err)
+	require.Equal(t, "v1", string(content))
+	content, err = os.ReadFile(created)
+	require.NoError(t, err)
+	require.Equal(t, "new", string(content))
+	require.NoFileExists(t, later)
+
+	// 再次恢复不会修改任何文件。
+	changed, err = svc.Restore(t.Context(), cp.ID)
+	require.NoError(t, err)
+	require.Empty(t, changed)
+
+	list, err := svc.List(t.Context(), sessionID)
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	require.Equal(t, "first", list[0].Name)
+
+	require.NoError(t, svc.Delete(t.Context(), cp.ID))
+	list, err = svc.List(t.Context(), sessionID)
+	require.NoError(t, err)
+	require.Empty(t, list)
+}
@@ -0,0 +1,116 @@
+// Package checks 在智能体编辑文件后运行配置的检查命令（例如构建和静态检查），
+// 并把失败的检查格式化为附加在编辑工具结果中的报告。
+package checks
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/purpose168/crush-cn/internal/shell"
+	"github.com/purpose168/crush-cn/internal/tooloutput"
+)
+
+const (
+	openTag  = "<check_failures>"
+	closeTag = "</check_failures>"
+
+	// maxOutputBytes 是每项失败检查保留的输出字节数，结论通常在末尾，因此保留结尾部分
+	maxOutputBytes = 4 * 1024
+)
+
+// Failure 是一项失败的检查命令。
+type Failure struct {
+	Command  string
+	ExitCode int
+	Output   string
+}
+
+// Runner 在工作目录中依次运行检查命令。
+type Runner struct {
+	commands   []string
+	workingDir string
+	remote     shell.Runner
+}
+
+// NewRunner 创建一个新的 [Runner]。remote 不为 nil 时命令在远程主机的 workingDir 中执行。
+// 没有配置命令时返回 nil。
+func NewRunner(workingDir string, commands []string, remote shell.Runner) *Runner {
+	var cleaned []string
+	for _, command := range commands {
+		if command = strings.TrimSpace(command); command != "" {
+			cleaned = append(cleaned, command)
+		}
+	}
+	if len(cleaned) == 0 {
+		return nil
+	}
+	return &Runner{
+		commands:   cleaned,
+		workingDir: workingDir,
+		remote:     remote,
+	}
+}
+
+// Run 依次运行所有检查命令并返回失败的检查。ctx 被取消时返回 ctx 的错误。
+func (r *Runner) Run(ctx context.Context) ([]Failure, error) {
+	limit := maxOutputBytes
+	policy := tooloutput.NewPolicy(string(tooloutput.ModeTail), &limit)
+	var failures []Failure
+	for _, command := range r.commands {
+		sh := shell.NewShell(&shell.Options{WorkingDir: r.workingDir, Remote: r.remote})
+		stdout, stderr, err := sh.Exec(ctx, command)
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
+		if err == nil {
+			continue
+		}
+		output := joinOutput(stdout, stderr)
+		if output == "" {
+			output = err.Error()
+		}
+		output, _ = policy.Truncate(output)
+		failures = append(failures, Failure{
+			Command:  command,
+			ExitCode: shell.ExitCode(err),
+			Output:   output,
+		})
+	}
+	return failures, nil
+}
+
+func joinOutput(stdout, stderr string) string {
+	var parts []string
+	for _, s := range []string{stdout, stderr} {
+		if s = strings.TrimSpace(s); s != "" {
+			parts = append(parts, s)
+		}
+	}
+	return strings.Join(parts, "\n")
+}
+
+// Format 将失败的检查格式化为附加到工具结果末尾的报告，没有失败时返回空字符串。
+func Format(failures []Failure) string {
+	if len(failures) == 0 {
+		return ""
+	}
+	var sb strings.Builder
+	sb.WriteString("\n" + openTag + "\n")
+	fmt.Fprintf(&sb, "编辑后运行的 %d 项检查失败，请修复：\n", len(failures))
+	for _, f := range failures {
+		fmt.Fprintf(&sb, "\n$ %s（退出码 %d）\n%s\n", f.Command, f.ExitCode, f.Output)
+	}
+	sb.WriteString(closeTag + "\n")
+	return sb.String()
+}
+
+// Extract 返回工具结果中检查失败报告的正文，没有报告时返回空字符串。
+func Extract(content string) string {
+	_, after, ok := strings.Cut(content, openTag)
+	if !ok {
+		return ""
+	}
+	report, _, _ := strings.Cut(after, closeTag)
+	return strings.TrimSpace(report)
+}
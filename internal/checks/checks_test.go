@@ -0,0 +1,72 @@
+package checks
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewRunner(t *testing.T) {
+	t.Parallel()
+
+	require.Nil(t, NewRunner(t.TempDir(), nil, nil))
+	require.Nil(t, NewRunner(t.TempDir(), []string{" ", ""}, nil))
+	require.NotNil(t, NewRunner(t.TempDir(), []string{"true"}, nil))
+}
+
+func TestRun(t *testing.T) {
+	t.Parallel()
+
+	runner := NewRunner(t.TempDir(), []string{
+		"true",
+		"echo compiling; echo 'main.go:3: undefined: x' >&2; exit 2",
+		"exit 1",
+	}, nil)
+	failures, err := runner.Run(t.Context())
+	require.NoError(t, err)
+	require.Len(t, failures, 2)
+
+	require.Equal(t, "echo compiling; echo 'main.go:3: undefined: x' >&2; exit 2", failures[0].Command)
+	require.Equal(t, 2, failures[0].ExitCode)
+	require.Equal(t, "compiling\nmain.go:3: undefined: x", failures[0].Output)
+
+	require.Equal(t, 1, failures[1].ExitCode)
+	require.NotEmpty(t, failures[1].Output)
+}
+
+func TestRunLongOutput(t *testing.T) {
+	t.Parallel()
+
+	runner := NewRunner(t.TempDir(), []string{"for i in $(seq 1 2000); do echo line $i; done; exit 1"}, nil)
+	failures, err := runner.Run(t.Context())
+	require.NoError(t, err)
+	require.Len(t, failures, 1)
+	require.Less(t, len(failures[0].Output), maxOutputBytes+200)
+	require.True(t, strings.HasSuffix(failures[0].Output, "line 2000"))
+}
+
+func TestRunCanceled(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+	_, err := NewRunner(t.TempDir(), []string{"exit 1"}, nil).Run(ctx)
+	require.ErrorIs(t, err, context.Canceled)
+}
+
+func TestFormatExtract(t *testing.T) {
+	t.Parallel()
+
+	require.Empty(t, Format(nil))
+	require.Empty(t, Extract("<result>\nok\n</result>\n"))
+
+	report := Format([]Failure{{Command: "go build ./...", ExitCode: 1, Output: "main.go:3: undefined: x"}})
+	content := "<result>\nok\n</result>\n" + report
+	extracted := Extract(content)
+	require.Contains(t, extracted, "$ go build ./...（退出码 1）")
+	require.Contains(t, extracted, "main.go:3: undefined: x")
+	require.NotContains(t, extracted, openTag)
+	require.NotContains(t, extracted, closeTag)
+}
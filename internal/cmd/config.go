@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/purpose168/crush-cn/internal/config"
+	"github.com/spf13/cobra"
+)
+
+// configCmd 定义了 'config' 命令，用于管理配置文件
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "管理 crush 配置",
+	Long:  `管理 crush 配置文件。`,
+}
+
+// configValidateCmd 定义了 'config validate' 命令，用于校验配置文件
+var configValidateCmd = &cobra.Command{
+	Use:   "validate [文件...]",
+	Short: "校验配置文件",
+	Long: `根据配置的 JSON schema 校验配置文件，报告未知的配置项、类型错误以及配置不完整的提供商，并给出行号和列号。
+未指定文件时，校验在当前目录启动时会加载的所有配置文件。`,
+	Example: `# 校验当前项目会加载的所有配置文件
+crush config validate
+
+# 校验指定的配置文件
+crush config validate ./crush.json`,
+	Args: cobra.ArbitraryArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		paths := args
+		if len(paths) == 0 {
+			cwd, err := ResolveCwd(cmd)
+			if err != nil {
+				return err
+			}
+			paths = config.ConfigPaths(cwd)
+		}
+
+		issues, err := config.ValidateConfigFiles(paths...)
+		if err != nil {
+			return err
+		}
+
+		out := cmd.OutOrStdout()
+		for _, issue := range issues {
+			fmt.Fprintln(out, issue.String())
+		}
+
+		var errCount int
+		for _, issue := range issues {
+			if issue.Severity == config.IssueError {
+				errCount++
+			}
+		}
+		if len(issues) == 0 {
+			fmt.Fprintln(out, "配置文件有效")
+			return nil
+		}
+		fmt.Fprintf(out, "\n发现 %d 个错误，%d 个警告\n", errCount, len(issues)-errCount)
+		if errCount > 0 {
+			return fmt.Errorf("配置文件校验失败")
+		}
+		return nil
+	},
+}
+
+func init() {
+	configCmd.AddCommand(configValidateCmd)
+}
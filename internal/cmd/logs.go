@@ -15,6 +15,7 @@ import (
 	"github.com/charmbracelet/x/term"
 	"github.com/nxadm/tail"
 	"github.com/purpose168/crush-cn/internal/config"
+	"github.com/purpose168/crush-cn/internal/eventlog"
 	"github.com/spf13/cobra"
 )
 
@@ -23,7 +24,15 @@ const defaultTailLines = 1000
 var logsCmd = &cobra.Command{
 	Use:   "logs",
 	Short: "查看 crush 日志",
-	Long:  `查看 Crush 生成的日志。此命令允许您查看日志输出，用于调试和监控。`,
+	Long: `查看 Crush 生成的日志。此命令允许您查看日志输出，用于调试和监控。
+使用 --session 查看指定会话的结构化事件日志（工具调用、权限、模型请求和费用）。`,
+	Example: `
+# 查看应用日志
+crush logs
+
+# 查看会话的事件日志
+crush logs --session <会话 ID>
+  `,
 
 	RunE: func(cmd *cobra.Command, args []string) error {
 		cwd, err := cmd.Flags().GetString("cwd")
@@ -46,6 +55,11 @@ var logsCmd = &cobra.Command{
 			return fmt.Errorf("获取 tail 标志失败: %v", err)
 		}
 
+		sessionID, err := cmd.Flags().GetString("session")
+		if err != nil {
+			return fmt.Errorf("获取 session 标志失败: %v", err)
+		}
+
 		log.SetLevel(log.DebugLevel)
 		log.SetOutput(os.Stdout)
 		if !term.IsTerminal(os.Stdout.Fd()) {
@@ -56,27 +70,37 @@ var logsCmd = &cobra.Command{
 		if err != nil {
 			return fmt.Errorf("加载配置失败: %v", err)
 		}
+		printLine := printLogLine
 		logsFile := filepath.Join(cfg.Options.DataDirectory, "logs", "crush.log")
+		if sessionID != "" {
+			printLine = printEventLine
+			logsFile = eventlog.Path(filepath.Join(cfg.Options.DataDirectory, eventlog.DirName), sessionID)
+		}
 		_, err = os.Stat(logsFile)
 		if os.IsNotExist(err) {
+			if sessionID != "" {
+				log.Warn("未找到会话的事件日志。", "session", sessionID)
+				return nil
+			}
 			log.Warn("看起来您不在 crush 项目中。未找到日志。")
 			return nil
 		}
 
 		if follow {
-			return followLogs(cmd.Context(), logsFile, tailLines)
+			return followLogs(cmd.Context(), logsFile, tailLines, printLine)
 		}
 
-		return showLogs(logsFile, tailLines)
+		return showLogs(logsFile, tailLines, printLine)
 	},
 }
 
 func init() {
 	logsCmd.Flags().BoolP("follow", "f", false, "跟踪日志输出")
 	logsCmd.Flags().IntP("tail", "t", defaultTailLines, "只显示最后 N 行，默认值: 1000（出于性能考虑）")
+	logsCmd.Flags().String("session", "", "显示指定会话的结构化事件日志")
 }
 
-func followLogs(ctx context.Context, logsFile string, tailLines int) error {
+func followLogs(ctx context.Context, logsFile string, tailLines int, printLine func(string)) error {
 	t, err := tail.TailFile(logsFile, tail.Config{
 		Follow: false,
 		ReOpen: false,
@@ -99,7 +123,7 @@ func followLogs(ctx context.Context, logsFile string, tailLines int) error {
 	t.Stop()
 
 	for _, line := range lines {
-		printLogLine(line)
+		printLine(line)
 	}
 
 	if len(lines) == tailLines {
@@ -124,14 +148,14 @@ func followLogs(ctx context.Context, logsFile string, tailLines int) error {
 			if line.Err != nil {
 				continue
 			}
-			printLogLine(line.Text)
+			printLine(line.Text)
 		case <-ctx.Done():
 			return nil
 		}
 	}
 }
 
-func showLogs(logsFile string, tailLines int) error {
+func showLogs(logsFile string, tailLines int, printLine func(string)) error {
 	t, err := tail.TailFile(logsFile, tail.Config{
 		Follow:      false,
 		ReOpen:      false,
@@ -155,7 +179,7 @@ func showLogs(logsFile string, tailLines int) error {
 	}
 
 	for _, line := range lines {
-		printLogLine(line)
+		printLine(line)
 	}
 
 	if len(lines) == tailLines {
@@ -215,3 +239,39 @@ func printLogLine(lineText string) {
 		log.Info(msg, otherData...)
 	}
 }
+
+// printEventLine 打印事件日志中的一行。
+func printEventLine(lineText string) {
+	var data map[string]any
+	if err := json.Unmarshal([]byte(lineText), &data); err != nil {
+		return
+	}
+	eventType, _ := data["type"].(string)
+	var otherData []any
+	var keys []string
+	for k := range data {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	for _, k := range keys {
+		switch k {
+		case "version", "time", "type", "session_id":
+			continue
+		default:
+			otherData = append(otherData, k, data[k])
+		}
+	}
+	log.SetTimeFunction(func(_ time.Time) time.Time {
+		timeText, _ := data["time"].(string)
+		t, err := time.Parse(time.RFC3339Nano, timeText)
+		if err != nil {
+			return time.Now()
+		}
+		return t
+	})
+	if _, failed := data["error"]; failed {
+		log.Error(eventType, otherData...)
+		return
+	}
+	log.Info(eventType, otherData...)
+}
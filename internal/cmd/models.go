@@ -37,6 +37,7 @@ crush models gpt5`,
 		debug, _ := cmd.Flags().GetBool("debug")
 
 		// 初始化配置
+		applyOfflineFlag(cmd)
 		cfg, err := config.Init(cwd, dataDir, debug)
 		if err != nil {
 			return err
@@ -0,0 +1,24 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+var openCmd = &cobra.Command{
+	Use:   "open <链接>",
+	Short: "打开会话链接",
+	Long: `在交互模式下打开会话链接指向的会话，并滚动到链接中的消息。
+在聊天中选中消息后按 L 可以复制消息的链接，用于在问题跟踪系统等地方引用。
+链接的形式为 crush://session/<会话 ID>/msg/<消息 ID>，也可以写成 <会话 ID>#<消息 ID> 或只写会话 ID。`,
+	Example: `
+# 打开会话并滚动到消息
+crush open crush://session/<会话 ID>/msg/<消息 ID>
+
+# 使用简写形式
+crush open <会话 ID>#<消息 ID>
+  `,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runTUI(cmd, args[0])
+	},
+}
@@ -10,7 +10,6 @@ import (
 	"os"
 	"path/filepath"
 	"strconv"
-	"strings"
 
 	tea "charm.land/bubbletea/v2"
 	"charm.land/lipgloss/v2"
@@ -25,6 +24,7 @@ import (
 	"github.com/purpose168/crush-cn/internal/db"
 	"github.com/purpose168/crush-cn/internal/event"
 	"github.com/purpose168/crush-cn/internal/projects"
+	"github.com/purpose168/crush-cn/internal/session"
 	"github.com/purpose168/crush-cn/internal/ui/common"
 	ui "github.com/purpose168/crush-cn/internal/ui/model"
 	"github.com/purpose168/crush-cn/internal/version"
@@ -35,18 +35,25 @@ func init() {
 	rootCmd.PersistentFlags().StringP("cwd", "c", "", "当前工作目录")
 	rootCmd.PersistentFlags().StringP("data-dir", "D", "", "自定义 crush 数据目录")
 	rootCmd.PersistentFlags().BoolP("debug", "d", false, "调试")
+	rootCmd.PersistentFlags().Bool("dry-run", false, "演练模式：编辑工具不修改文件，而是把更改写入补丁文件")
+	rootCmd.PersistentFlags().Bool("offline", false, "离线模式：不从网络获取提供者元数据和更新信息，只使用缓存或内置的定义")
 	rootCmd.Flags().BoolP("help", "h", false, "帮助")
 	rootCmd.Flags().BoolP("yolo", "y", false, "自动接受所有权限（危险模式）")
 
 	rootCmd.AddCommand(
 		runCmd,
+		serveCmd,
 		dirsCmd,
 		projectsCmd,
 		updateProvidersCmd,
+		updateCmd,
+		scheduleCmd,
 		logsCmd,
 		schemaCmd,
 		loginCmd,
 		statsCmd,
+		configCmd,
+		openCmd,
 	)
 }
 
@@ -73,39 +80,73 @@ crush -v
 # 运行单个非交互式提示
 crush run "解释 Go 中 context 的使用"
 
+# 启动本地 API 服务器
+crush serve
+
+# 打开会话并滚动到链接指向的消息
+crush open crush://session/<会话 ID>/msg/<消息 ID>
+
 # 在危险模式下运行（自动接受所有权限）
 crush -y
   `,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		app, err := setupAppWithProgressBar(cmd)
-		if err != nil {
+		return runTUI(cmd, "")
+	},
+}
+
+// runTUI 启动交互式界面。link 不为空时启动后打开链接指向的会话并滚动到其中的消息。
+func runTUI(cmd *cobra.Command, link string) error {
+	var sessionID, messageID string
+	if link != "" {
+		var err error
+		if sessionID, messageID, err = session.ParseLink(link); err != nil {
 			return err
 		}
-		defer app.Shutdown()
+	}
 
-		event.AppInitialized()
+	app, err := setupAppWithProgressBar(cmd)
+	if err != nil {
+		return err
+	}
+	defer app.Shutdown()
 
-		// Set up the TUI.
-		var env uv.Environ = os.Environ()
+	if sessionID != "" {
+		if _, err := app.Sessions.Get(cmd.Context(), sessionID); err != nil {
+			return fmt.Errorf("当前项目中找不到会话 %s: %w", sessionID, err)
+		}
+	}
 
-		com := common.DefaultCommon(app)
-		model := ui.New(com)
+	event.AppInitialized()
 
-		program := tea.NewProgram(
-			model,
-			tea.WithEnvironment(env),
-			tea.WithContext(cmd.Context()),
-			tea.WithFilter(ui.MouseEventFilter), // Filter mouse events based on focus state
-		)
-		go app.Subscribe(program)
+	// Set up the TUI.
+	var env uv.Environ = os.Environ()
 
-		if _, err := program.Run(); err != nil {
-			event.Error(err)
-			slog.Error("TUI 运行错误", "error", err)
-			return errors.New("Crush 崩溃了。如果启用了指标，我们已经收到了通知。如果您想报告它，请复制上面的堆栈跟踪并在 https://github.com/purpose168/crush-cn/issues/new?template=bug.yml 打开一个问题") //nolint:staticcheck
+	com := common.DefaultCommon(app)
+	model := ui.New(com)
+	if sessionID != "" {
+		model.OpenMessage(sessionID, messageID)
+	}
+
+	program := tea.NewProgram(
+		model,
+		tea.WithEnvironment(env),
+		tea.WithContext(cmd.Context()),
+		tea.WithFilter(ui.MouseEventFilter), // Filter mouse events based on focus state
+	)
+	go app.Subscribe(program)
+
+	if _, err := program.Run(); err != nil {
+		event.Error(err)
+		slog.Error("TUI 运行错误", "error", err)
+		if errors.Is(err, tea.ErrProgramPanic) {
+			// 保存界面状态，下次启动时恢复未发送的输入
+			if dumpErr := model.SaveCrashDump(err); dumpErr != nil {
+				slog.Error("保存崩溃快照失败", "error", dumpErr)
+			}
 		}
-		return nil
-	},
+		return errors.New("Crush 崩溃了。如果启用了指标，我们已经收到了通知。如果您想报告它，请复制上面的堆栈跟踪并在 https://github.com/purpose168/crush-cn/issues/new?template=bug.yml 打开一个问题") //nolint:staticcheck
+	}
+	return nil
 }
 
 var heartbit = lipgloss.NewStyle().Foreground(charmtone.Dolly).SetString(`
@@ -155,10 +196,8 @@ func supportsProgressBar() bool {
 	if !term.IsTerminal(os.Stderr.Fd()) {
 		return false
 	}
-	termProg := os.Getenv("TERM_PROGRAM")
-	_, isWindowsTerminal := os.LookupEnv("WT_SESSION")
-
-	return isWindowsTerminal || strings.Contains(strings.ToLower(termProg), "ghostty")
+	// 在终端复用器中进度条需要透传，由 TUI 处理。
+	return common.DetectTerminal(uv.Environ(os.Environ())).NativeProgress()
 }
 
 func setupAppWithProgressBar(cmd *cobra.Command) (*app.App, error) {
@@ -177,11 +216,20 @@ func setupAppWithProgressBar(cmd *cobra.Command) (*app.App, error) {
 	return app, nil
 }
 
+// applyOfflineFlag 在加载配置之前处理 --offline 标志。离线模式与其他提供者设置一样
+// 通过环境变量 CRUSH_OFFLINE 传给配置加载。
+func applyOfflineFlag(cmd *cobra.Command) {
+	if offline, _ := cmd.Flags().GetBool("offline"); offline {
+		os.Setenv("CRUSH_OFFLINE", "1")
+	}
+}
+
 // setupApp 处理交互式和非交互式模式的通用设置逻辑。
 // 返回应用实例、配置、清理函数和任何错误。
 func setupApp(cmd *cobra.Command) (*app.App, error) {
 	debug, _ := cmd.Flags().GetBool("debug")
 	yolo, _ := cmd.Flags().GetBool("yolo")
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
 	dataDir, _ := cmd.Flags().GetString("data-dir")
 	ctx := cmd.Context()
 
@@ -190,6 +238,7 @@ func setupApp(cmd *cobra.Command) (*app.App, error) {
 		return nil, err
 	}
 
+	applyOfflineFlag(cmd)
 	cfg, err := config.Init(cwd, dataDir, debug)
 	if err != nil {
 		return nil, err
@@ -199,6 +248,7 @@ func setupApp(cmd *cobra.Command) (*app.App, error) {
 		cfg.Permissions = &config.Permissions{}
 	}
 	cfg.Permissions.SkipRequests = yolo
+	cfg.Options.DryRun = dryRun
 
 	if err := createDotCrushDir(cfg.Options.DataDirectory); err != nil {
 		return nil, err
@@ -0,0 +1,290 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"charm.land/lipgloss/v2"
+	"charm.land/lipgloss/v2/table"
+	"github.com/charmbracelet/x/term"
+	"github.com/purpose168/crush-cn/internal/schedule"
+	"github.com/spf13/cobra"
+)
+
+// schedulePollInterval 是调度器重新读取任务列表的最长间隔，使新增和删除的任务及时生效
+const schedulePollInterval = time.Minute
+
+var scheduleCmd = &cobra.Command{
+	Use:   "schedule",
+	Short: "管理定时运行的智能体任务",
+	Long: `按计划在项目目录中以非交互模式运行提示，每次运行都会保存为普通会话，之后可以在 Crush 中查看。
+任务由 'crush schedule run' 启动的调度器执行，运行时自动批准所有权限，与 'crush run' 相同。`,
+}
+
+var scheduleAddCmd = &cobra.Command{
+	Use:   "add \"<调度>: <提示>\"",
+	Short: "添加定时任务",
+	Long: `在当前项目目录中添加定时任务。调度支持以下形式：
+  hourly
+  every 30m、every 2h
+  daily at 9、daily at 9:30、daily at 6pm
+  weekdays at 9、weekends at 10
+  monday at 9、weekly on monday at 9`,
+	Example: `
+# 每天 9 点根据合并的 PR 更新 CHANGELOG
+crush schedule add "daily at 9: update CHANGELOG from merged PRs"
+
+# 每周一早上使用指定模型总结未关闭的 issue
+crush schedule add -m claude-sonnet-4 "monday at 8:30: summarize open issues"
+`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		spec, prompt, err := schedule.SplitTask(strings.Join(args, " "))
+		if err != nil {
+			return err
+		}
+		cwd, err := ResolveCwd(cmd)
+		if err != nil {
+			return err
+		}
+		cwd, err = filepath.Abs(cwd)
+		if err != nil {
+			return err
+		}
+		dataDir, _ := cmd.Flags().GetString("data-dir")
+		if dataDir != "" {
+			if dataDir, err = filepath.Abs(dataDir); err != nil {
+				return err
+			}
+		}
+		model, _ := cmd.Flags().GetString("model")
+
+		task, err := schedule.Add(schedule.Task{
+			Schedule: spec,
+			Prompt:   prompt,
+			Dir:      cwd,
+			DataDir:  dataDir,
+			Model:    model,
+		})
+		if err != nil {
+			return err
+		}
+		next, _ := task.Next()
+		cmd.Printf("已添加任务 %s，下次运行时间 %s\n", task.ID, next.Format("2006-01-02 15:04"))
+		cmd.Println("任务由调度器执行，请确保 'crush schedule run' 正在运行")
+		return nil
+	},
+}
+
+var scheduleListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "列出定时任务",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		jsonOutput, _ := cmd.Flags().GetBool("json")
+		tasks, err := schedule.List()
+		if err != nil {
+			return err
+		}
+
+		if jsonOutput {
+			data, err := json.Marshal(struct {
+				Tasks []schedule.Task `json:"tasks"`
+			}{Tasks: tasks})
+			if err != nil {
+				return err
+			}
+			cmd.Println(string(data))
+			return nil
+		}
+
+		if len(tasks) == 0 {
+			cmd.Println("没有定时任务。")
+			return nil
+		}
+
+		rows := make([][]string, 0, len(tasks))
+		for _, task := range tasks {
+			next := "无效的调度"
+			if t, err := task.Next(); err == nil {
+				next = t.Format("2006-01-02 15:04")
+			}
+			last := "从未运行"
+			if !task.LastRun.IsZero() {
+				last = task.LastRun.Local().Format("2006-01-02 15:04")
+				if task.LastError != "" {
+					last += " (失败)"
+				}
+			}
+			rows = append(rows, []string{task.ID, task.Schedule, task.Prompt, task.Dir, next, last})
+		}
+
+		if term.IsTerminal(os.Stdout.Fd()) {
+			t := table.New().
+				Border(lipgloss.RoundedBorder()).
+				StyleFunc(func(row, col int) lipgloss.Style {
+					return lipgloss.NewStyle().Padding(0, 2)
+				}).
+				Headers("ID", "调度", "提示", "目录", "下次运行", "上次运行").
+				Rows(rows...)
+			lipgloss.Println(t)
+			return nil
+		}
+
+		for _, row := range rows {
+			cmd.Println(strings.Join(row, "\t"))
+		}
+		return nil
+	},
+}
+
+var scheduleRemoveCmd = &cobra.Command{
+	Use:     "remove <ID>",
+	Aliases: []string{"rm"},
+	Short:   "删除定时任务",
+	Args:    cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := schedule.Remove(args[0]); err != nil {
+			return err
+		}
+		cmd.Printf("已删除任务 %s\n", args[0])
+		return nil
+	},
+}
+
+var scheduleRunCmd = &cobra.Command{
+	Use:   "run",
+	Short: "运行调度器",
+	Long: `在前台运行调度器，到期的任务依次以 'crush run' 执行。调度器停止期间错过的运行在下次启动时只补一次。
+使用 --once 时只运行当前到期的任务后退出，适合由系统的 cron 或 systemd 定时器调用。`,
+	Example: `
+# 在前台持续运行调度器
+crush schedule run
+
+# 由系统 cron 每 5 分钟调用一次
+*/5 * * * * crush schedule run --once
+`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		once, _ := cmd.Flags().GetBool("once")
+
+		ctx, cancel := signal.NotifyContext(cmd.Context(), os.Interrupt)
+		defer cancel()
+
+		exe, err := os.Executable()
+		if err != nil {
+			return fmt.Errorf("获取可执行文件路径失败: %w", err)
+		}
+
+		for {
+			next, err := runDueTasks(ctx, cmd, exe)
+			if err != nil {
+				return err
+			}
+			if once {
+				return nil
+			}
+
+			wait := schedulePollInterval
+			if !next.IsZero() {
+				wait = min(wait, max(0, time.Until(next)))
+			}
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-time.After(wait):
+			}
+		}
+	},
+}
+
+// runDueTasks 依次运行所有到期的任务，返回剩余任务中最早的下次运行时间。
+func runDueTasks(ctx context.Context, cmd *cobra.Command, exe string) (time.Time, error) {
+	tasks, err := schedule.List()
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	var earliest time.Time
+	for _, task := range tasks {
+		if ctx.Err() != nil {
+			return time.Time{}, nil
+		}
+		next, err := task.Next()
+		if err != nil {
+			cmd.PrintErrf("任务 %s 的调度无效: %v\n", task.ID, err)
+			continue
+		}
+		if next.After(time.Now()) {
+			if earliest.IsZero() || next.Before(earliest) {
+				earliest = next
+			}
+			continue
+		}
+
+		start := time.Now()
+		cmd.Printf("[%s] 运行任务 %s（%s）: %s\n", start.Format("2006-01-02 15:04"), task.ID, task.Dir, task.Prompt)
+		runErr := runScheduledTask(ctx, cmd.OutOrStdout(), cmd.ErrOrStderr(), exe, task)
+		if runErr != nil {
+			cmd.PrintErrf("任务 %s 运行失败: %v\n", task.ID, runErr)
+		}
+		if err := schedule.RecordRun(task.ID, start, runErr); err != nil {
+			return time.Time{}, err
+		}
+
+		task.LastRun = start
+		if next, err := task.Next(); err == nil && (earliest.IsZero() || next.Before(earliest)) {
+			earliest = next
+		}
+	}
+	return earliest, nil
+}
+
+// runScheduledTask 以 'crush run' 子进程在任务目录中运行任务，输出写入 stdout 和 stderr。
+func runScheduledTask(ctx context.Context, stdout, stderr io.Writer, exe string, task schedule.Task) error {
+	args := []string{"run", "--quiet", "--cwd", task.Dir}
+	if task.DataDir != "" {
+		args = append(args, "--data-dir", task.DataDir)
+	}
+	if task.Model != "" {
+		args = append(args, "--model", task.Model)
+	}
+	// 以 "--" 结束选项，避免以 "-" 开头的提示被解析为参数
+	args = append(args, "--", task.Prompt)
+
+	// 保留错误输出的末尾用于记录失败原因
+	var errOut bytes.Buffer
+	c := exec.CommandContext(ctx, exe, args...)
+	c.Dir = task.Dir
+	// 子进程的标准输入为空，crush run 不会等待管道输入
+	c.Stdin = nil
+	c.Stdout = stdout
+	c.Stderr = io.MultiWriter(stderr, &errOut)
+	if err := c.Run(); err != nil {
+		if msg := lastLine(errOut.String()); msg != "" {
+			return fmt.Errorf("%w: %s", err, msg)
+		}
+		return err
+	}
+	return nil
+}
+
+// lastLine 返回 s 中最后一个非空行。
+func lastLine(s string) string {
+	lines := strings.Split(strings.TrimSpace(s), "\n")
+	return strings.TrimSpace(lines[len(lines)-1])
+}
+
+func init() {
+	scheduleAddCmd.Flags().StringP("model", "m", "", "运行任务使用的模型。接受 'model' 或 'provider/model'")
+	scheduleListCmd.Flags().Bool("json", false, "以 JSON 格式输出")
+	scheduleRunCmd.Flags().Bool("once", false, "只运行当前到期的任务后退出")
+	scheduleCmd.AddCommand(scheduleAddCmd, scheduleListCmd, scheduleRemoveCmd, scheduleRunCmd)
+}
@@ -0,0 +1,106 @@
+package cmd
+
+import (
+	"cmp"
+	"crypto/rand"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"os/signal"
+	"strconv"
+
+	"github.com/purpose168/crush-cn/internal/event"
+	"github.com/purpose168/crush-cn/internal/server"
+	"github.com/spf13/cobra"
+)
+
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "启动本地 API 服务器",
+	Long: `启动本地 HTTP API 服务器，供 IDE 扩展或其他工具驱动与 TUI 相同的代理核心。
+API 提供会话列表、创建会话、提交提示、回应权限请求，以及通过 Server-Sent Events 推送的事件流。
+
+端点：
+  GET  /v1/sessions                 列出会话
+  POST /v1/sessions                 创建会话 {"title": "..."}
+  GET  /v1/sessions/{id}            获取会话
+  GET  /v1/sessions/{id}/messages   列出会话的消息
+  POST /v1/sessions/{id}/prompts    提交提示 {"prompt": "..."}
+  POST /v1/sessions/{id}/cancel     取消会话中正在运行的代理
+  GET  /v1/permissions              列出等待回应的权限请求
+  POST /v1/permissions/{id}         回应权限请求 {"action": "allow|allow_session|deny"}
+  GET  /v1/events?session_id=...    订阅事件流
+
+每个请求都需要携带 "Authorization: Bearer <令牌>"。未设置 --token 或 CRUSH_SERVER_TOKEN 时，
+启动时会生成一个随机令牌并打印一次。带有请求体的请求必须设置 "Content-Type: application/json"，
+Host 不是本地地址或 Origin 跨站的请求会被拒绝。`,
+	Example: `
+# 在默认端口启动服务器
+crush serve
+
+# 使用令牌保护 API
+crush serve --port 7777 --token secret
+
+# 创建会话并提交提示
+curl -X POST localhost:7777/v1/sessions -H "Authorization: Bearer secret"
+curl -X POST localhost:7777/v1/sessions/<会话 ID>/prompts -H "Authorization: Bearer secret" -H "Content-Type: application/json" -d '{"prompt": "解释这个项目"}'
+
+# 订阅会话的事件流
+curl -N "localhost:7777/v1/events?session_id=<会话 ID>" -H "Authorization: Bearer secret"
+  `,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		host, _ := cmd.Flags().GetString("host")
+		port, _ := cmd.Flags().GetInt("port")
+		token, _ := cmd.Flags().GetString("token")
+		token = cmp.Or(token, os.Getenv("CRUSH_SERVER_TOKEN"))
+		generated := token == ""
+		if generated {
+			token = rand.Text()
+		}
+
+		// 在 SIGINT 或 SIGTERM 信号时停止服务器。
+		ctx, cancel := signal.NotifyContext(cmd.Context(), os.Interrupt, os.Kill)
+		defer cancel()
+
+		app, err := setupApp(cmd)
+		if err != nil {
+			return err
+		}
+		defer app.Shutdown()
+
+		if !app.Config().IsConfigured() {
+			slog.Warn("No providers configured, prompts will be rejected until one is set up")
+		}
+
+		ln, err := net.Listen("tcp", net.JoinHostPort(host, strconv.Itoa(port)))
+		if err != nil {
+			return fmt.Errorf("监听端口失败: %w", err)
+		}
+
+		event.AppInitialized()
+
+		srv := server.New(ctx, server.Services{
+			Sessions:    app.Sessions,
+			Messages:    app.Messages,
+			Permissions: app.Permissions,
+			Agent:       app.AgentCoordinator,
+		}, token)
+
+		fmt.Fprintf(cmd.ErrOrStderr(), "Crush API 正在监听 http://%s\n", ln.Addr())
+		if generated {
+			fmt.Fprintf(cmd.ErrOrStderr(), "API 令牌: %s\n", token)
+		}
+		return srv.Serve(ctx, ln)
+	},
+	PostRun: func(cmd *cobra.Command, args []string) {
+		event.AppExited()
+	},
+}
+
+func init() {
+	serveCmd.Flags().String("host", "127.0.0.1", "监听的地址")
+	serveCmd.Flags().IntP("port", "p", 7777, "监听的端口")
+	serveCmd.Flags().String("token", "", "API 令牌，请求需要携带 Bearer 令牌（默认读取 CRUSH_SERVER_TOKEN，未设置时随机生成）")
+	serveCmd.Flags().BoolP("yolo", "y", false, "自动接受所有权限（危险模式）")
+}
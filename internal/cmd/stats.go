@@ -60,6 +60,10 @@ type Stats struct {
 	AvgResponseTimeMs float64            `json:"avg_response_time_ms"`
 	ToolUsage         []ToolUsage        `json:"tool_usage"`
 	HourDayHeatmap    []HourDayHeatmapPt `json:"hour_day_heatmap"`
+	ModelPerformance  []ModelPerformance `json:"model_performance"`
+	// AvgFirstTokenMs 和 AvgTokensPerSecond 是所有记录了指标的响应的平均值。
+	AvgFirstTokenMs    float64 `json:"avg_first_token_ms"`
+	AvgTokensPerSecond float64 `json:"avg_tokens_per_second"`
 }
 
 // TotalStats 包含总统计信息。
@@ -120,6 +124,16 @@ type ToolUsage struct {
 	CallCount int64  `json:"call_count"`
 }
 
+// ModelPerformance 包含按模型统计的响应性能。
+type ModelPerformance struct {
+	Model              string  `json:"model"`
+	Provider           string  `json:"provider"`
+	ResponseCount      int64   `json:"response_count"`
+	AvgLatencyMs       float64 `json:"avg_latency_ms"`
+	AvgFirstTokenMs    float64 `json:"avg_first_token_ms"`
+	AvgTokensPerSecond float64 `json:"avg_tokens_per_second"`
+}
+
 // HourDayHeatmapPt 包含小时/天热力图数据点。
 type HourDayHeatmapPt struct {
 	DayOfWeek    int   `json:"day_of_week"`
@@ -311,6 +325,38 @@ func gatherStats(ctx context.Context, conn *sql.DB) (*Stats, error) {
 		})
 	}
 
+	// 按模型响应性能。
+	performance, err := queries.GetModelPerformance(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("获取按模型响应性能: %w", err)
+	}
+	var firstTokenSum, tokensPerSecondSum float64
+	var firstTokenCount, tokensPerSecondCount int64
+	for _, p := range performance {
+		stats.ModelPerformance = append(stats.ModelPerformance, ModelPerformance{
+			Model:              p.Model,
+			Provider:           p.Provider,
+			ResponseCount:      p.ResponseCount,
+			AvgLatencyMs:       p.AvgLatencyMs.Float64,
+			AvgFirstTokenMs:    p.AvgFirstTokenMs.Float64,
+			AvgTokensPerSecond: p.AvgTokensPerSecond.Float64,
+		})
+		if p.AvgFirstTokenMs.Valid {
+			firstTokenSum += p.AvgFirstTokenMs.Float64 * float64(p.ResponseCount)
+			firstTokenCount += p.ResponseCount
+		}
+		if p.AvgTokensPerSecond.Valid {
+			tokensPerSecondSum += p.AvgTokensPerSecond.Float64 * float64(p.ResponseCount)
+			tokensPerSecondCount += p.ResponseCount
+		}
+	}
+	if firstTokenCount > 0 {
+		stats.AvgFirstTokenMs = firstTokenSum / float64(firstTokenCount)
+	}
+	if tokensPerSecondCount > 0 {
+		stats.AvgTokensPerSecond = tokensPerSecondSum / float64(tokensPerSecondCount)
+	}
+
 	return stats, nil
 }
 
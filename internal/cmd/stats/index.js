@@ -81,6 +81,16 @@ document.getElementById("avg-tokens").innerHTML =
   formatCompact(stats.total.avg_tokens_per_session);
 document.getElementById("avg-response").innerHTML =
   '<span title="Average">x̅</span> ' + formatTime(stats.avg_response_time_ms);
+document.getElementById("avg-first-token").innerHTML =
+  stats.avg_first_token_ms > 0
+    ? '<span title="Average">x̅</span> ' + formatTime(stats.avg_first_token_ms)
+    : "-";
+document.getElementById("avg-throughput").innerHTML =
+  stats.avg_tokens_per_second > 0
+    ? '<span title="Average">x̅</span> ' +
+      stats.avg_tokens_per_second.toFixed(1) +
+      " tok/s"
+    : "-";
 
 // Chart defaults
 Chart.defaults.color = colors.squid;
@@ -337,6 +347,24 @@ if (stats.usage_by_model?.length > 0) {
   });
 }
 
+// Model Performance Table
+const performanceBody = document.querySelector("#performance-table tbody");
+if (stats.model_performance?.length > 0) {
+  const fragment = document.createDocumentFragment();
+  const formatMetric = (value, format) => (value > 0 ? format(value) : "-");
+  stats.model_performance.forEach((m) => {
+    const row = document.createElement("tr");
+    row.innerHTML = `<td>${m.model}</td><td>${m.provider}</td><td>${formatNumber(
+      m.response_count,
+    )}</td><td>${formatMetric(m.avg_latency_ms, formatTime)}</td><td>${formatMetric(
+      m.avg_first_token_ms,
+      formatTime,
+    )}</td><td>${formatMetric(m.avg_tokens_per_second, (v) => v.toFixed(1))}</td>`;
+    fragment.appendChild(row);
+  });
+  performanceBody.appendChild(fragment);
+}
+
 // Daily Usage Table
 const tableBody = document.querySelector("#daily-table tbody");
 if (stats.usage_by_day?.length > 0) {
@@ -0,0 +1,136 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+
+	"github.com/purpose168/crush-cn/internal/update"
+	"github.com/purpose168/crush-cn/internal/version"
+	"github.com/spf13/cobra"
+)
+
+var updateCmd = &cobra.Command{
+	Use:   "update",
+	Short: "更新 Crush 到最新版本",
+	Long: `从 GitHub 发布页下载当前平台的最新版本，校验校验和与签名后原子地替换当前可执行文件。
+签名通过 cosign 校验，系统中没有安装 cosign 时只校验校验和。`,
+	Example: `
+# 检查并安装最新版本
+crush update
+
+# 只检查是否有新版本
+crush update --check
+
+# 没有安装 cosign 时拒绝更新
+crush update --require-signature
+`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// 不向终端输出日志
+		slog.SetDefault(slog.New(slog.DiscardHandler))
+
+		checkOnly, _ := cmd.Flags().GetBool("check")
+		requireSignature, _ := cmd.Flags().GetBool("require-signature")
+		ctx := cmd.Context()
+
+		info, err := update.Check(ctx, version.Version, update.Default)
+		if err != nil {
+			return fmt.Errorf("检查更新失败: %w", err)
+		}
+		if !info.Available() {
+			cmd.Printf("已是最新版本 %s\n", info.Current)
+			return nil
+		}
+		cmd.Printf("发现新版本 %s（当前 %s）\n", info.Latest, info.Current)
+		if checkOnly {
+			cmd.Println(info.URL)
+			return nil
+		}
+		if info.IsDevelopment() {
+			return fmt.Errorf("开发版本 %s 无法自动更新，请从源码重新构建或从 %s 下载", info.Current, info.URL)
+		}
+
+		exe, err := os.Executable()
+		if err != nil {
+			return fmt.Errorf("获取可执行文件路径失败: %w", err)
+		}
+
+		archiveName := update.PlatformArchiveName(info.Latest)
+		release := update.Release{Assets: info.Assets}
+		archiveAsset, ok := release.Asset(archiveName)
+		if !ok {
+			return fmt.Errorf("发布版本中没有当前平台的文件 %s", archiveName)
+		}
+		checksumsAsset, ok := release.Asset(update.ChecksumsName)
+		if !ok {
+			return fmt.Errorf("发布版本中没有校验和文件 %s", update.ChecksumsName)
+		}
+
+		cmd.Printf("正在下载 %s...\n", archiveName)
+		archive, err := update.Download(ctx, archiveAsset.URL)
+		if err != nil {
+			return fmt.Errorf("下载失败: %w", err)
+		}
+		checksums, err := update.Download(ctx, checksumsAsset.URL)
+		if err != nil {
+			return fmt.Errorf("下载校验和文件失败: %w", err)
+		}
+
+		if err := verifyUpdateSignature(cmd, release, checksums, requireSignature); err != nil {
+			return err
+		}
+		if err := update.VerifyChecksum(checksums, archiveName, archive); err != nil {
+			return fmt.Errorf("校验和校验失败: %w", err)
+		}
+
+		binary, err := update.ExtractBinary(archiveName, archive)
+		if err != nil {
+			return fmt.Errorf("解压失败: %w", err)
+		}
+		if err := update.Replace(exe, binary); err != nil {
+			if errors.Is(err, os.ErrPermission) {
+				return fmt.Errorf("没有权限替换 %s，请使用安装时的包管理器更新或以更高权限运行: %w", exe, err)
+			}
+			return fmt.Errorf("替换可执行文件失败: %w", err)
+		}
+
+		cmd.Printf("已更新到 %s\n", info.Latest)
+		return nil
+	},
+}
+
+// verifyUpdateSignature 校验校验和文件的签名。没有签名包或没有安装 cosign 时，
+// requireSignature 为 false 则只给出警告。
+func verifyUpdateSignature(cmd *cobra.Command, release update.Release, checksums []byte, requireSignature bool) error {
+	skip := func(reason string) error {
+		if requireSignature {
+			return fmt.Errorf("无法校验签名: %s", reason)
+		}
+		cmd.PrintErrf("警告: %s，跳过签名校验，仅校验校验和\n", reason)
+		return nil
+	}
+
+	signatureAsset, ok := release.Asset(update.SignatureName)
+	if !ok {
+		return skip("发布版本中没有签名文件 " + update.SignatureName)
+	}
+	bundle, err := update.Download(cmd.Context(), signatureAsset.URL)
+	if err != nil {
+		return fmt.Errorf("下载签名文件失败: %w", err)
+	}
+	err = update.VerifySignature(cmd.Context(), checksums, bundle)
+	if errors.Is(err, update.ErrNoCosign) {
+		return skip("未安装 cosign")
+	}
+	if err != nil {
+		return fmt.Errorf("签名校验失败: %w", err)
+	}
+	cmd.Println("签名校验通过")
+	return nil
+}
+
+func init() {
+	updateCmd.Flags().Bool("check", false, "只检查是否有新版本，不下载")
+	updateCmd.Flags().Bool("require-signature", false, "无法校验签名（例如没有安装 cosign）时拒绝更新")
+}
@@ -0,0 +1,130 @@
+// Package codeblock 从 Markdown 文本中提取可运行的围栏代码块，并按代码块的语言
+// 生成交给 bash 工具执行的命令。
+package codeblock
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Interpreter 是执行代码块的解释器。
+type Interpreter string
+
+const (
+	Shell  Interpreter = "sh"      // 直接作为 shell 命令执行
+	Python Interpreter = "python3" // 通过标准输入交给 python3 执行
+	Node   Interpreter = "node"    // 通过标准输入交给 node 执行
+)
+
+// heredocDelimiter 是将代码传给解释器时 here-document 的结束标记。
+const heredocDelimiter = "CRUSH_CODE_EOF"
+
+// languages 将代码块的语言标记映射到对应的解释器。
+var languages = map[string]Interpreter{
+	"sh":         Shell,
+	"bash":       Shell,
+	"shell":      Shell,
+	"zsh":        Shell,
+	"python":     Python,
+	"python3":    Python,
+	"py":         Python,
+	"node":       Node,
+	"js":         Node,
+	"javascript": Node,
+}
+
+// Block 是一个可运行的围栏代码块。
+type Block struct {
+	// Language 是代码块标注的语言。
+	Language string
+	// Interpreter 是执行代码块的解释器。
+	Interpreter Interpreter
+	// Code 是代码块的内容，不含围栏。
+	Code string
+}
+
+// Extract 返回 markdown 中所有可运行的围栏代码块。没有语言标记、语言无法运行、
+// 内容为空或尚未闭合的代码块会被跳过。
+func Extract(markdown string) []Block {
+	var (
+		blocks []Block
+		fence  string
+		lang   string
+		code   []string
+	)
+	for line := range strings.SplitSeq(markdown, "\n") {
+		trimmed := strings.TrimRight(strings.TrimLeft(line, " "), "\r")
+		if fence == "" {
+			if f, info, ok := openingFence(line, trimmed); ok {
+				fence = f
+				lang, _, _ = strings.Cut(strings.ToLower(strings.TrimSpace(info)), " ")
+				code = code[:0]
+			}
+			continue
+		}
+		if strings.HasPrefix(trimmed, fence) && strings.Trim(trimmed, fence[:1]) == "" {
+			interpreter, ok := languages[lang]
+			body := strings.Join(code, "\n")
+			if ok && strings.TrimSpace(body) != "" {
+				blocks = append(blocks, Block{Language: lang, Interpreter: interpreter, Code: body})
+			}
+			fence = ""
+			continue
+		}
+		code = append(code, strings.TrimRight(line, "\r"))
+	}
+	return blocks
+}
+
+// openingFence 判断 line 是否为围栏代码块的起始行，返回围栏和信息字符串。
+// 起始行最多缩进三个空格，由至少三个反引号或波浪号组成。
+func openingFence(line, trimmed string) (string, string, bool) {
+	if len(line)-len(strings.TrimLeft(line, " ")) > 3 {
+		return "", "", false
+	}
+	for _, c := range []string{"`", "~"} {
+		n := len(trimmed) - len(strings.TrimLeft(trimmed, c))
+		if n < 3 {
+			continue
+		}
+		info := trimmed[n:]
+		if c == "`" && strings.Contains(info, "`") {
+			return "", "", false
+		}
+		return strings.Repeat(c, n), info, true
+	}
+	return "", "", false
+}
+
+// Command 返回执行代码块的 shell 命令。shell 代码块原样执行，其他语言的代码
+// 通过 here-document 交给解释器的标准输入。
+func (b Block) Command() string {
+	if b.Interpreter == Shell {
+		return b.Code
+	}
+	delimiter := heredocDelimiter
+	for n := 1; containsLine(b.Code, delimiter); n++ {
+		delimiter = fmt.Sprintf("%s_%d", heredocDelimiter, n)
+	}
+	return fmt.Sprintf("%s - <<'%s'\n%s\n%s", b.Interpreter, delimiter, b.Code, delimiter)
+}
+
+// Title 返回代码块的简短标题：语言和第一行非空代码。
+func (b Block) Title() string {
+	for line := range strings.SplitSeq(b.Code, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			return fmt.Sprintf("[%s] %s", b.Language, line)
+		}
+	}
+	return fmt.Sprintf("[%s]", b.Language)
+}
+
+// containsLine 判断 text 中是否有与 line 完全相同的一行。
+func containsLine(text, line string) bool {
+	for l := range strings.SplitSeq(text, "\n") {
+		if l == line {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,40 @@
+package codeblock
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestExtract(t *testing.T) {
+	t.Parallel()
+
+	markdown := "Run this:\n\n```bash\nls -la\n```\n\n" +
+		"```go\nfmt.Println()\n```\n\n" +
+		"~~~~ Python extra\nprint('```')\n~~~~\n\n" +
+		"```\nno language\n```\n\n" +
+		"```js\nconsole.log(1)\n"
+
+	blocks := Extract(markdown)
+	require.Equal(t, []Block{
+		{Language: "bash", Interpreter: Shell, Code: "ls -la"},
+		{Language: "python", Interpreter: Python, Code: "print('```')"},
+	}, blocks)
+}
+
+func TestCommand(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, "echo hi", Block{Language: "sh", Interpreter: Shell, Code: "echo hi"}.Command())
+	require.Equal(t, "python3 - <<'CRUSH_CODE_EOF'\nprint(1)\nCRUSH_CODE_EOF",
+		Block{Language: "py", Interpreter: Python, Code: "print(1)"}.Command())
+	require.Equal(t, "node - <<'CRUSH_CODE_EOF_1'\nCRUSH_CODE_EOF\nCRUSH_CODE_EOF_1",
+		Block{Language: "js", Interpreter: Node, Code: "CRUSH_CODE_EOF"}.Command())
+}
+
+func TestTitle(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, "[python] import os", Block{Language: "python", Code: "\n  import os\nprint(os.getcwd())"}.Title())
+	require.Equal(t, "[sh]", Block{Language: "sh", Code: " "}.Title())
+}
@@ -2,6 +2,7 @@ package commands
 
 import (
 	"context"
+	"fmt"
 	"io/fs"
 	"os"
 	"path/filepath"
@@ -18,6 +19,7 @@ var namedArgPattern = regexp.MustCompile(`\$([A-Z][A-Z0-9_]*)`)
 const (
 	userCommandPrefix    = "user:"
 	projectCommandPrefix = "project:"
+	agentCommandPrefix   = "agent:"
 )
 
 // Argument 表示命令参数及其元数据。
@@ -54,7 +56,28 @@ type commandSource struct {
 // LoadCustomCommands 从多个源加载自定义命令，包括
 // XDG 配置目录、主目录和项目目录。
 func LoadCustomCommands(cfg *config.Config) ([]CustomCommand, error) {
-	return loadAll(buildCommandSources(cfg))
+	commands, err := loadAll(buildCommandSources(cfg))
+	return append(commands, agentCommands(cfg)...), err
+}
+
+// agentCommands 为配置中的每个自定义智能体生成一个命令，
+// 命令让编码智能体通过 agent 工具把任务交给该智能体。
+func agentCommands(cfg *config.Config) []CustomCommand {
+	var commands []CustomCommand
+	for _, name := range cfg.CustomAgentNames() {
+		commands = append(commands, CustomCommand{
+			ID:      agentCommandPrefix + name,
+			Name:    agentCommandPrefix + name,
+			Content: fmt.Sprintf("使用 agent 工具并将 agent 参数设为 %q，把以下任务交给该智能体完成：\n\n$TASK", name),
+			Arguments: []Argument{{
+				ID:          "TASK",
+				Title:       "任务",
+				Description: cfg.Agents[name].Description,
+				Required:    true,
+			}},
+		})
+	}
+	return commands
 }
 
 // LoadMCPPrompts 从可用的 MCP 服务器加载自定义命令。
@@ -165,11 +188,12 @@ func loadCommand(path, baseDir, prefix string) (CustomCommand, error) {
 		ID:        id,
 		Name:      id,
 		Content:   string(content),
-		Arguments: extractArgNames(string(content)),
+		Arguments: ExtractArguments(string(content)),
 	}, nil
 }
 
-func extractArgNames(content string) []Argument {
+// ExtractArguments 返回内容中按出现顺序去重的 $NAME 占位符参数。
+func ExtractArguments(content string) []Argument {
 	matches := namedArgPattern.FindAllStringSubmatch(content, -1)
 	if len(matches) == 0 {
 		return nil
@@ -0,0 +1,247 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"path/filepath"
+	"slices"
+
+	"charm.land/catwalk/pkg/catwalk"
+	"github.com/purpose168/crush-cn/internal/agent/hyper"
+	"github.com/purpose168/crush-cn/internal/csync"
+	"github.com/purpose168/crush-cn/internal/env"
+)
+
+// builderSource 是校验 [Builder] 生成的配置时在问题中显示的文件名。
+const builderSource = "<builder>"
+
+// Option 是修改 [Builder] 中配置的函数式选项。
+type Option func(*Builder)
+
+// Builder 以代码方式构造配置，供嵌入智能体核心的 Go 程序使用，无需生成 JSON 配置文件。
+// 与 [Load] 不同，Builder 不读取配置文件、不从 catwalk 获取提供商、也不配置日志和网络，
+// 这些由调用方负责。
+//
+//	cfg, err := config.NewBuilder(workingDir,
+//		config.WithProvider("local", config.ProviderConfig{...}),
+//		config.WithModel(config.SelectedModelTypeLarge, config.SelectedModel{Provider: "local", Model: "qwen"}),
+//		config.WithDisabledTools("sourcegraph"),
+//	).Build()
+type Builder struct {
+	cfg            *Config
+	workingDir     string
+	dataDir        string
+	knownProviders []catwalk.Provider
+}
+
+// NewBuilder 为 workingDir 创建一个新的 [Builder] 并应用给定的选项。
+func NewBuilder(workingDir string, opts ...Option) *Builder {
+	b := &Builder{
+		cfg: &Config{
+			Options:   &Options{TUI: &TUIOptions{}},
+			Providers: csync.NewMap[string, ProviderConfig](),
+			Models:    make(map[SelectedModelType]SelectedModel),
+			MCP:       make(MCPs),
+			LSP:       make(LSPs),
+		},
+		workingDir: workingDir,
+	}
+	return b.With(opts...)
+}
+
+// With 应用给定的选项并返回 Builder 本身，便于链式调用。
+func (b *Builder) With(opts ...Option) *Builder {
+	for _, opt := range opts {
+		opt(b)
+	}
+	return b
+}
+
+// WithDataDirectory 设置数据目录，默认使用工作目录下的 .crush。
+func WithDataDirectory(dir string) Option {
+	return func(b *Builder) {
+		b.dataDir = dir
+	}
+}
+
+// WithKnownProviders 设置已知提供商列表（通常来自 catwalk）。已知提供商只需在
+// [WithProvider] 中提供 API 密钥，其余信息从列表中补全。
+func WithKnownProviders(providers []catwalk.Provider) Option {
+	return func(b *Builder) {
+		b.knownProviders = providers
+	}
+}
+
+// WithProvider 添加或替换 id 对应的提供商配置。
+func WithProvider(id string, provider ProviderConfig) Option {
+	return func(b *Builder) {
+		b.cfg.Providers.Set(id, provider)
+	}
+}
+
+// WithModel 设置指定类型（large 或 small）使用的模型。
+func WithModel(modelType SelectedModelType, model SelectedModel) Option {
+	return func(b *Builder) {
+		b.cfg.Models[modelType] = model
+	}
+}
+
+// WithTools 设置内置工具的配置。
+func WithTools(tools Tools) Option {
+	return func(b *Builder) {
+		b.cfg.Tools = tools
+	}
+}
+
+// WithToolTimeout 设置单个工具的执行时限（秒）。
+func WithToolTimeout(toolName string, seconds int) Option {
+	return func(b *Builder) {
+		if b.cfg.Tools.Timeouts == nil {
+			b.cfg.Tools.Timeouts = make(map[string]int)
+		}
+		b.cfg.Tools.Timeouts[toolName] = seconds
+	}
+}
+
+// WithDisabledTools 禁用给定的内置工具。
+func WithDisabledTools(names ...string) Option {
+	return func(b *Builder) {
+		for _, name := range names {
+			if !slices.Contains(b.cfg.Options.DisabledTools, name) {
+				b.cfg.Options.DisabledTools = append(b.cfg.Options.DisabledTools, name)
+			}
+		}
+	}
+}
+
+// WithAllowedTools 允许给定的工具无需确认权限即可运行。
+func WithAllowedTools(names ...string) Option {
+	return func(b *Builder) {
+		if b.cfg.Permissions == nil {
+			b.cfg.Permissions = &Permissions{}
+		}
+		for _, name := range names {
+			if !slices.Contains(b.cfg.Permissions.AllowedTools, name) {
+				b.cfg.Permissions.AllowedTools = append(b.cfg.Permissions.AllowedTools, name)
+			}
+		}
+	}
+}
+
+// WithMCP 添加或替换 MCP 服务器配置。
+func WithMCP(name string, mcp MCPConfig) Option {
+	return func(b *Builder) {
+		b.cfg.MCP[name] = mcp
+	}
+}
+
+// WithLSP 添加或替换 LSP 服务器配置。
+func WithLSP(name string, lsp LSPConfig) Option {
+	return func(b *Builder) {
+		b.cfg.LSP[name] = lsp
+	}
+}
+
+// WithOptions 以回调方式修改通用选项，用于没有专门选项的设置。
+func WithOptions(fn func(*Options)) Option {
+	return func(b *Builder) {
+		fn(b.cfg.Options)
+	}
+}
+
+// Validate 检查配置是否可以构建：工作目录已设置，提供商信息完整，所选模型存在，
+// 禁用的工具名称有效，并按配置文件的 JSON schema 校验各字段。返回的错误包含全部问题。
+func (b *Builder) Validate() error {
+	var errs []error
+	if b.workingDir == "" {
+		errs = append(errs, errors.New("未设置工作目录"))
+	}
+
+	known := make(map[string]catwalk.Provider, len(b.knownProviders))
+	for _, p := range b.knownProviders {
+		known[string(p.ID)] = p
+	}
+	for _, id := range slices.Sorted(maps.Keys(b.cfg.Providers.Copy())) {
+		p, _ := b.cfg.Providers.Get(id)
+		if _, ok := known[id]; ok || p.Disable {
+			continue
+		}
+		if p.Type != "" && !slices.Contains(catwalk.KnownProviderTypes(), p.Type) && p.Type != hyper.Name {
+			errs = append(errs, fmt.Errorf("提供商 %s 的类型 %q 不受支持", id, p.Type))
+		}
+		if p.BaseURL == "" {
+			errs = append(errs, fmt.Errorf("提供商 %s 缺少 base_url", id))
+		}
+		if len(p.Models) == 0 {
+			errs = append(errs, fmt.Errorf("提供商 %s 没有模型", id))
+		}
+	}
+
+	for _, modelType := range []SelectedModelType{SelectedModelTypeLarge, SelectedModelTypeSmall} {
+		selected, ok := b.cfg.Models[modelType]
+		if !ok {
+			continue
+		}
+		if !b.hasModel(known, selected.Provider, selected.Model) {
+			errs = append(errs, fmt.Errorf("%s 模型 %s/%s 不存在", modelType, selected.Provider, selected.Model))
+		}
+	}
+
+	for _, name := range b.cfg.Options.DisabledTools {
+		if !slices.Contains(allToolNames(), name) {
+			errs = append(errs, fmt.Errorf("未知的内置工具 %q", name))
+		}
+	}
+
+	data, err := json.Marshal(b.cfg)
+	if err != nil {
+		return fmt.Errorf("序列化配置失败: %w", err)
+	}
+	for _, issue := range validateConfigBytes(builderSource, data) {
+		if issue.Severity == IssueError {
+			errs = append(errs, fmt.Errorf("%s: %s", issue.Path, issue.Message))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// hasModel 报告提供商是否提供指定模型，已知提供商的模型列表可被配置覆盖。
+func (b *Builder) hasModel(known map[string]catwalk.Provider, providerID, modelID string) bool {
+	models := known[providerID].Models
+	if p, ok := b.cfg.Providers.Get(providerID); ok && len(p.Models) > 0 {
+		models = p.Models
+	}
+	return slices.ContainsFunc(models, func(m catwalk.Model) bool {
+		return m.ID == modelID
+	})
+}
+
+// Build 校验并返回配置。返回的配置已完成提供商、模型和智能体的设置，可以直接用于创建应用。
+// 运行时修改（例如切换模型）会写入数据目录下的 crush.json，而不是用户的全局配置。
+func (b *Builder) Build() (*Config, error) {
+	if err := b.Validate(); err != nil {
+		return nil, fmt.Errorf("配置无效: %w", err)
+	}
+
+	cfg := b.cfg
+	cfg.setDefaults(b.workingDir, b.dataDir)
+	cfg.dataConfigDir = filepath.Join(cfg.Options.DataDirectory, appName+".json")
+	cfg.knownProviders = b.knownProviders
+
+	env := env.New()
+	resolver := NewShellVariableResolver(env)
+	cfg.resolver = resolver
+	if err := cfg.configureProviders(env, resolver, cfg.knownProviders); err != nil {
+		return nil, fmt.Errorf("配置提供商失败: %w", err)
+	}
+	if !cfg.IsConfigured() {
+		return nil, errors.New("没有可用的提供商")
+	}
+	if err := cfg.configureSelectedModels(cfg.knownProviders); err != nil {
+		return nil, fmt.Errorf("配置选定的模型失败: %w", err)
+	}
+	cfg.SetupAgents()
+	return cfg, nil
+}
@@ -0,0 +1,99 @@
+package config
+
+import (
+	"testing"
+
+	"charm.land/catwalk/pkg/catwalk"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuilder(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	local := ProviderConfig{
+		Type:    catwalk.TypeOpenAICompat,
+		BaseURL: "http://localhost:11434/v1",
+		Models: []catwalk.Model{
+			{ID: "qwen", Name: "Qwen", DefaultMaxTokens: 4096},
+			{ID: "llama", Name: "Llama", DefaultMaxTokens: 2048},
+		},
+	}
+
+	cfg, err := NewBuilder(dir,
+		WithProvider("local", local),
+		WithModel(SelectedModelTypeLarge, SelectedModel{Provider: "local", Model: "qwen"}),
+		WithModel(SelectedModelTypeSmall, SelectedModel{Provider: "local", Model: "llama"}),
+		WithDisabledTools("sourcegraph", "sourcegraph"),
+		WithAllowedTools("view"),
+		WithToolTimeout("bash", 60),
+	).Build()
+	require.NoError(t, err)
+
+	require.Equal(t, dir, cfg.WorkingDir())
+	require.Equal(t, "qwen", cfg.Models[SelectedModelTypeLarge].Model)
+	require.Equal(t, int64(4096), cfg.Models[SelectedModelTypeLarge].MaxTokens)
+	require.Equal(t, "llama", cfg.Models[SelectedModelTypeSmall].Model)
+	require.Equal(t, []string{"sourcegraph"}, cfg.Options.DisabledTools)
+	require.Equal(t, []string{"view"}, cfg.Permissions.AllowedTools)
+	require.Equal(t, 60, cfg.Tools.Timeouts["bash"])
+	require.NotContains(t, cfg.Agents[AgentCoder].AllowedTools, "sourcegraph")
+
+	provider, ok := cfg.Providers.Get("local")
+	require.True(t, ok)
+	require.Equal(t, "local", provider.ID)
+}
+
+func TestBuilderValidate(t *testing.T) {
+	t.Parallel()
+
+	b := NewBuilder("",
+		WithProvider("local", ProviderConfig{Type: "unknown"}),
+		WithModel(SelectedModelTypeLarge, SelectedModel{Provider: "local", Model: "missing"}),
+		WithDisabledTools("nope"),
+		WithOptions(func(o *Options) {
+			o.TUI.DiffMode = "sideways"
+		}),
+	)
+	err := b.Validate()
+	require.Error(t, err)
+	for _, msg := range []string{
+		"未设置工作目录",
+		`提供商 local 的类型 "unknown" 不受支持`,
+		"提供商 local 缺少 base_url",
+		"提供商 local 没有模型",
+		"large 模型 local/missing 不存在",
+		`未知的内置工具 "nope"`,
+		"options.tui.diff_mode",
+	} {
+		require.ErrorContains(t, err, msg)
+	}
+
+	_, err = b.Build()
+	require.Error(t, err)
+}
+
+func TestBuilderKnownProvider(t *testing.T) {
+	t.Parallel()
+
+	known := []catwalk.Provider{{
+		ID:                  "openai",
+		Name:                "OpenAI",
+		Type:                catwalk.TypeOpenAI,
+		APIEndpoint:         "https://api.openai.com/v1",
+		DefaultLargeModelID: "gpt-large",
+		DefaultSmallModelID: "gpt-small",
+		Models: []catwalk.Model{
+			{ID: "gpt-large", DefaultMaxTokens: 8192},
+			{ID: "gpt-small", DefaultMaxTokens: 1024},
+		},
+	}}
+
+	cfg, err := NewBuilder(t.TempDir(),
+		WithKnownProviders(known),
+		WithProvider("openai", ProviderConfig{APIKey: "sk-test"}),
+	).Build()
+	require.NoError(t, err)
+	require.Equal(t, "gpt-large", cfg.Models[SelectedModelTypeLarge].Model)
+	require.Equal(t, "gpt-small", cfg.Models[SelectedModelTypeSmall].Model)
+}
@@ -26,6 +26,7 @@ type catwalkSync struct {
 	cache      cache[[]catwalk.Provider] // 缓存接口
 	client     catwalkClient             // Catwalk 客户端
 	autoupdate bool                      // 是否启用自动更新
+	policy     cachePolicy               // 缓存使用策略
 	init       atomic.Bool               // 初始化状态标志
 }
 
@@ -57,11 +58,28 @@ func (s *catwalkSync) Get(ctx context.Context) ([]catwalk.Provider, error) {
 		}
 
 		cached, etag, cachedErr := s.cache.Get()
-		if len(cached) == 0 || cachedErr != nil {
+		hasCache := len(cached) > 0 && cachedErr == nil
+		if !hasCache {
 			// 如果缓存文件为空，默认使用嵌入的提供商
 			cached = embedded.GetAll()
 		}
 
+		if s.policy.offline {
+			slog.Info("离线模式，使用缓存的 Catwalk 提供商", "cached", hasCache)
+			s.result = cached
+			return
+		}
+		if hasCache && s.cache.Fresh(s.policy.ttl) {
+			slog.Info("缓存的 Catwalk 提供商未过期，跳过更新")
+			s.result = cached
+			return
+		}
+		if hasCache {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, cachedFetchTimeout)
+			defer cancel()
+		}
+
 		slog.Info("从 Catwalk 获取提供商")
 		result, err := s.client.GetProviders(ctx, etag)
 		if errors.Is(err, context.DeadlineExceeded) {
@@ -6,6 +6,7 @@ import (
 	"errors"
 	"os"
 	"testing"
+	"time"
 
 	"charm.land/catwalk/pkg/catwalk"
 	"github.com/stretchr/testify/require"
@@ -249,3 +250,62 @@ func TestCatwalkSync_GetCalledMultipleTimesUsesOnce(t *testing.T) {
 	// 由于 sync.Once 的存在，客户端应该只被调用一次
 	require.Equal(t, 1, client.callCount) // 验证客户端只被调用了一次
 }
+
+// TestCatwalkSync_GetCachePolicy 测试离线模式和未过期的缓存不会访问网络
+func TestCatwalkSync_GetCachePolicy(t *testing.T) {
+	t.Parallel()
+
+	writeCache := func(t *testing.T, modTime time.Time) string {
+		t.Helper()
+		path := t.TempDir() + "/providers.json"
+		data, err := json.Marshal([]catwalk.Provider{{Name: "Cached Provider", ID: "cached"}})
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(path, data, 0o644))
+		require.NoError(t, os.Chtimes(path, modTime, modTime))
+		return path
+	}
+
+	tests := []struct {
+		name      string
+		modTime   time.Time
+		policy    cachePolicy
+		wantName  string
+		wantCalls int
+	}{
+		{"offline", time.Now().Add(-48 * time.Hour), cachePolicy{offline: true}, "Cached Provider", 0},
+		{"fresh cache", time.Now().Add(-time.Hour), cachePolicy{ttl: 24 * time.Hour}, "Cached Provider", 0},
+		{"stale cache", time.Now().Add(-48 * time.Hour), cachePolicy{ttl: 24 * time.Hour}, "Fresh Provider", 1},
+		{"no ttl", time.Now(), cachePolicy{}, "Fresh Provider", 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			client := &mockCatwalkClient{providers: []catwalk.Provider{{Name: "Fresh Provider", ID: "fresh"}}}
+			syncer := &catwalkSync{}
+			syncer.Init(client, writeCache(t, tt.modTime), true)
+			syncer.policy = tt.policy
+
+			providers, err := syncer.Get(t.Context())
+			require.NoError(t, err)
+			require.Len(t, providers, 1)
+			require.Equal(t, tt.wantName, providers[0].Name)
+			require.Equal(t, tt.wantCalls, client.callCount)
+		})
+	}
+}
+
+// TestCatwalkSync_GetOfflineWithoutCache 测试离线模式下没有缓存时使用嵌入的提供者
+func TestCatwalkSync_GetOfflineWithoutCache(t *testing.T) {
+	t.Parallel()
+
+	client := &mockCatwalkClient{}
+	syncer := &catwalkSync{}
+	syncer.Init(client, t.TempDir()+"/providers.json", true)
+	syncer.policy = cachePolicy{offline: true}
+
+	providers, err := syncer.Get(t.Context())
+	require.NoError(t, err)
+	require.NotEmpty(t, providers)
+	require.Zero(t, client.callCount)
+}
@@ -3,13 +3,16 @@ package config
 import (
 	"cmp"
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"maps"
 	"net/http"
 	"net/url"
 	"os"
+	"path"
 	"path/filepath"
+	"regexp"
 	"slices"
 	"strings"
 	"time"
@@ -19,9 +22,11 @@ import (
 	hyperp "github.com/purpose168/crush-cn/internal/agent/hyper"
 	"github.com/purpose168/crush-cn/internal/csync"
 	"github.com/purpose168/crush-cn/internal/env"
+	"github.com/purpose168/crush-cn/internal/home"
 	"github.com/purpose168/crush-cn/internal/oauth"
 	"github.com/purpose168/crush-cn/internal/oauth/copilot"
 	"github.com/purpose168/crush-cn/internal/oauth/hyper"
+	"github.com/purpose168/crush-cn/internal/sandbox"
 	"github.com/tidwall/gjson"
 	"github.com/tidwall/sjson"
 )
@@ -63,6 +68,9 @@ const (
 	SelectedModelTypeSmall SelectedModelType = "small"
 )
 
+// ContextStrategyCompress 表示在每次请求前使用小模型压缩过时的工具输出和较早的助手消息。
+const ContextStrategyCompress = "compress"
+
 const (
 	AgentCoder string = "coder"
 	AgentTask  string = "task"
@@ -92,6 +100,12 @@ type SelectedModel struct {
 
 	// 覆盖提供者特定的选项。
 	ProviderOptions map[string]any `json:"provider_options,omitempty" jsonschema:"description=Additional provider-specific options for the model"`
+
+	// 标记模型不支持工具调用，catwalk 未提供这项元数据。
+	DisableTools bool `json:"disable_tools,omitempty" jsonschema:"description=Mark the model as not supporting tool calls; requests are sent without tools and tool-dependent actions are disabled"`
+
+	// 上下文策略，适用于上下文窗口较小的模型。
+	ContextStrategy string `json:"context_strategy,omitempty" jsonschema:"description=How to keep the conversation within the context window; compress summarizes stale tool outputs and older assistant messages with the small model before each request,enum=compress"`
 }
 
 type ProviderConfig struct {
@@ -122,6 +136,20 @@ type ProviderConfig struct {
 
 	ProviderOptions map[string]any `json:"provider_options,omitempty" jsonschema:"description=Additional provider-specific options for this provider"`
 
+	// 通过 OpenAI Responses API 请求支持的模型，仅适用于 openai-compat 提供者（openai 和 azure 总是使用）。
+	UseResponsesAPI bool `json:"use_responses_api,omitempty" jsonschema:"description=Use the OpenAI Responses API for models that support it; only applies to openai-compat providers since openai and azure always use it,default=false"`
+	// 要求模型严格按照工具参数结构生成调用参数，仅在使用 Responses API 时生效。
+	StrictTools bool `json:"strict_tools,omitempty" jsonschema:"description=Ask the model to follow tool parameter schemas strictly (structured outputs) to avoid malformed tool call arguments; only applies to models served through the Responses API,default=false"`
+
+	// 为上下文窗口较小的模型精简工具参数结构以节省令牌。
+	CompactTools *CompactTools `json:"compact_tools,omitempty" jsonschema:"description=Shrink tool definitions sent to models of this provider with small context windows to save tokens"`
+
+	// 提供者端的提示缓存设置。
+	PromptCache *PromptCache `json:"prompt_cache,omitempty" jsonschema:"description=Provider-side prompt caching settings"`
+
+	// 发往提供者的请求的客户端限速。
+	RateLimit *RateLimit `json:"rate_limit,omitempty" jsonschema:"description=Client-side rate limit for requests to this provider"`
+
 	// 用于向提供者传递额外参数。
 	ExtraParams map[string]string `json:"-"`
 
@@ -129,6 +157,59 @@ type ProviderConfig struct {
 	Models []catwalk.Model `json:"models,omitempty" jsonschema:"description=List of models available from this provider"`
 }
 
+// CompactTools 配置工具定义的精简。上下文窗口不超过阈值的模型收到精简后的工具定义：
+// 描述只保留第一段并限制长度，移除参数示例；可选参数较多的工具把可选参数合并为一个 options 对象，
+// 调用工具前再展开为原来的参数。
+type CompactTools struct {
+	// 上下文窗口不超过该值的模型使用精简的工具定义，0 表示所有模型都使用。
+	MaxContextWindow int64 `json:"max_context_window,omitempty" jsonschema:"description=Compact tool definitions for models whose context window is at most this many tokens (0 for every model of the provider),minimum=0,example=32000"`
+	// 工具和参数描述的最大字符数。
+	MaxDescriptionLength int `json:"max_description_length,omitempty" jsonschema:"description=Maximum length in characters of tool and parameter descriptions,minimum=0,default=200"`
+	// 可选参数超过该数量的工具把可选参数合并为一个 options 对象，0 表示不合并。
+	MergeOptionalAbove int `json:"merge_optional_above,omitempty" jsonschema:"description=Merge the optional parameters of tools with more than this many optional parameters into a single options object (0 to never merge); ignored when strict_tools is enabled,minimum=0,example=3"`
+}
+
+// RateLimit 限制发往提供者的请求。同一提供者的所有代理（包括子代理）共享限额。
+type RateLimit struct {
+	// 每分钟最多发出的请求数，0 表示不限制。
+	RPM int `json:"rpm,omitempty" jsonschema:"description=Maximum number of requests per minute (0 for no limit),minimum=0,example=60"`
+	// 同时进行的最大请求数，0 表示不限制。
+	Concurrent int `json:"concurrent,omitempty" jsonschema:"description=Maximum number of concurrent requests (0 for no limit),minimum=0,example=2"`
+}
+
+// PromptCache 配置提供者端的提示缓存。Anthropic（包括 Bedrock 和 Vercel）默认在工具定义、
+// 系统提示的静态部分、包含上下文文件的完整系统提示和最近的消息上设置缓存断点；OpenAI 自动缓存
+// 相同的请求前缀，可以额外按会话发送缓存键以提高命中率；Gemini 把系统提示和工具定义放入显式
+// 上下文缓存，并把较大的附件上传到文件 API 后按引用发送。
+type PromptCache struct {
+	// 不设置缓存断点，也不使用 Gemini 的上下文缓存和文件 API。
+	Disabled bool `json:"disabled,omitempty" jsonschema:"description=Do not mark cache breakpoints in requests to Anthropic-style providers and do not use Gemini context caching or the Gemini Files API,default=false"`
+	// 将会话 ID 作为 OpenAI 的 prompt_cache_key 发送。
+	SessionKey bool `json:"session_key,omitempty" jsonschema:"description=Send the session ID as the OpenAI prompt_cache_key so requests of the same session are routed to the same cache,default=false"`
+	// Gemini 上下文缓存的有效期，使用 Go 的时长格式。
+	TTL string `json:"ttl,omitempty" jsonschema:"description=Lifetime of the Gemini context caches holding the system prompt and tool definitions as a Go duration,example=30m,example=2h,default=1h"`
+}
+
+// CacheTTL 返回 Gemini 上下文缓存的有效期，未设置时返回 0 表示使用默认值。
+func (pc *PromptCache) CacheTTL() (time.Duration, error) {
+	if pc == nil || pc.TTL == "" {
+		return 0, nil
+	}
+	ttl, err := time.ParseDuration(pc.TTL)
+	if err != nil {
+		return 0, fmt.Errorf("无效的 prompt_cache.ttl %q: %w", pc.TTL, err)
+	}
+	if ttl < time.Minute {
+		return 0, fmt.Errorf("prompt_cache.ttl 不能小于 1 分钟: %s", pc.TTL)
+	}
+	return ttl, nil
+}
+
+// CacheDisabled 返回是否禁用了提示缓存断点。
+func (pc *ProviderConfig) CacheDisabled() bool {
+	return pc.PromptCache != nil && pc.PromptCache.Disabled
+}
+
 // ToProvider 将 [ProviderConfig] 转换为 [catwalk.Provider]。
 func (pc *ProviderConfig) ToProvider() catwalk.Provider {
 	// 将配置提供者转换为 provider.Provider 格式
@@ -171,6 +252,15 @@ const (
 	MCPHttp  MCPType = "http"
 )
 
+// MCPSampling 控制 MCP 服务器能否通过 Crush 向配置的模型请求补全（MCP 采样）。
+type MCPSampling string
+
+const (
+	MCPSamplingAsk   MCPSampling = "ask"   // 每次请求都需要用户确认（默认）
+	MCPSamplingAllow MCPSampling = "allow" // 无需确认直接请求模型
+	MCPSamplingDeny  MCPSampling = "deny"  // 不向服务器提供采样能力
+)
+
 type MCPConfig struct {
 	Command       string            `json:"command,omitempty" jsonschema:"description=Command to execute for stdio MCP servers,example=npx"`
 	Env           map[string]string `json:"env,omitempty" jsonschema:"description=Environment variables to set for the MCP server"`
@@ -180,9 +270,21 @@ type MCPConfig struct {
 	Disabled      bool              `json:"disabled,omitempty" jsonschema:"description=Whether this MCP server is disabled,default=false"`
 	DisabledTools []string          `json:"disabled_tools,omitempty" jsonschema:"description=List of tools from this MCP server to disable,example=get-library-doc"`
 	Timeout       int               `json:"timeout,omitempty" jsonschema:"description=Timeout in seconds for MCP server connections,default=15,example=30,example=60,example=120"`
+	Sampling      MCPSampling       `json:"sampling,omitempty" jsonschema:"description=Whether the server may request completions from the configured model: ask prompts for every request; allow runs them without confirmation; deny disables sampling,enum=ask,enum=allow,enum=deny,default=ask"`
 
 	// TODO: 也许可以使其能够从环境变量获取值
 	Headers map[string]string `json:"headers,omitempty" jsonschema:"description=HTTP headers for HTTP/SSE MCP servers"`
+
+	// 需要 OAuth 授权的 HTTP/SSE MCP 服务器的客户端设置。
+	OAuth *MCPOAuthConfig `json:"oauth,omitempty" jsonschema:"description=OAuth client settings for HTTP/SSE MCP servers that require authorization"`
+}
+
+// MCPOAuthConfig 配置 MCP 服务器的 OAuth 客户端。未设置客户端 ID 时，Crush 会向授权服务器动态注册客户端。
+// 获取的令牌保存在数据目录中，不会写入配置文件。
+type MCPOAuthConfig struct {
+	ClientID     string   `json:"client_id,omitempty" jsonschema:"description=OAuth client ID; registered dynamically when empty"`
+	ClientSecret string   `json:"client_secret,omitempty" jsonschema:"description=OAuth client secret for confidential clients; supports $VAR and $(command) syntax,example=$MCP_CLIENT_SECRET"`
+	Scopes       []string `json:"scopes,omitempty" jsonschema:"description=OAuth scopes to request; defaults to the scopes advertised by the server,example=read,example=write"`
 }
 
 type LSPConfig struct {
@@ -200,26 +302,108 @@ type LSPConfig struct {
 type TUIOptions struct {
 	CompactMode bool   `json:"compact_mode,omitempty" jsonschema:"description=Enable compact mode for the TUI interface,default=false"`
 	DiffMode    string `json:"diff_mode,omitempty" jsonschema:"description=Diff mode for the TUI interface,enum=unified,enum=split"`
+	Layout      string `json:"layout,omitempty" jsonschema:"description=Layout of the chat on wide terminals: sidebar shows session info on the right while panel shows the todo plan and prompt queue and modified files instead,enum=sidebar,enum=panel,default=sidebar"`
 	// 这里我们可以在以后添加主题或任何 TUI 相关的选项
 	//
 
 	Completions Completions `json:"completions,omitzero" jsonschema:"description=Completions UI options"`
 	Transparent *bool       `json:"transparent,omitempty" jsonschema:"description=Enable transparent background for the TUI interface,default=false"`
+	Accessible  bool        `json:"accessible,omitempty" jsonschema:"description=Enable accessibility mode: disables animations and uses plain line-oriented status text suitable for screen readers,default=false"`
+	StatusBar   []string    `json:"status_bar,omitempty" jsonschema:"description=Widgets to show on the right of the status bar in the given order,enum=clock,enum=git_branch,enum=model,enum=tokens,enum=queue,enum=budget,example=model,example=tokens"`
+	Markdown    Markdown    `json:"markdown,omitzero" jsonschema:"description=Typography options for markdown in the chat"`
+}
+
+// TUI 布局
+const (
+	// TUILayoutSidebar 在聊天右侧显示会话信息侧边栏。
+	TUILayoutSidebar = "sidebar"
+	// TUILayoutPanel 在聊天右侧显示任务计划、提示队列和已修改文件的实时面板。
+	TUILayoutPanel = "panel"
+)
+
+// Markdown 定义聊天中 Markdown 的排版选项。
+type Markdown struct {
+	MaxWidth    int  `json:"max_width,omitempty" jsonschema:"description=Maximum line width of user and assistant messages; 0 uses the default of 120 columns,default=120,example=100"`
+	CodeWrap    bool `json:"code_wrap,omitempty" jsonschema:"description=Hard-wrap long lines in code blocks instead of letting them overflow,default=false"`
+	Hyphenation bool `json:"hyphenation,omitempty" jsonschema:"description=Break words that are longer than a line with a trailing hyphen,default=false"`
 }
 
 // Completions 定义补全 UI 的选项。
 type Completions struct {
-	MaxDepth *int `json:"max_depth,omitempty" jsonschema:"description=Maximum depth for the ls tool,default=0,example=10"`
-	MaxItems *int `json:"max_items,omitempty" jsonschema:"description=Maximum number of items to return for the ls tool,default=1000,example=100"`
+	MaxDepth *int              `json:"max_depth,omitempty" jsonschema:"description=Maximum depth for the ls tool,default=0,example=10"`
+	MaxItems *int              `json:"max_items,omitempty" jsonschema:"description=Maximum number of items to return for the ls tool,default=1000,example=100"`
+	Entries  []CompletionEntry `json:"entries,omitempty" jsonschema:"description=Custom entries listed in their own section of the @ completion; entries from the global and project configs are combined"`
 }
 
 func (c Completions) Limits() (depth, items int) {
 	return ptrValOr(c.MaxDepth, 0), ptrValOr(c.MaxItems, 0)
 }
 
+// CustomEntries 返回有效的自定义补全条目。全局配置和项目配置中的条目会合并，
+// 同名条目以后出现的（项目配置中的）为准，并保持首次出现的位置。
+func (c Completions) CustomEntries() []CompletionEntry {
+	var entries []CompletionEntry
+	index := make(map[string]int)
+	for _, entry := range c.Entries {
+		if entry.Name == "" || entry.Text == "" {
+			continue
+		}
+		if entry.Kind == "" {
+			entry.Kind = CompletionKindSnippet
+		}
+		if i, ok := index[entry.Name]; ok {
+			entries[i] = entry
+			continue
+		}
+		index[entry.Name] = len(entries)
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// CompletionKind 决定自定义补全条目被选中时的插入方式。
+type CompletionKind string
+
+const (
+	// CompletionKindSnippet 在编辑器中插入条目名称，并将文本作为附件添加。
+	CompletionKindSnippet CompletionKind = "snippet"
+	// CompletionKindURL 在编辑器中插入链接。
+	CompletionKindURL CompletionKind = "url"
+	// CompletionKindPrompt 在编辑器中插入提示词文本。
+	CompletionKindPrompt CompletionKind = "prompt"
+)
+
+// CompletionEntry 是 @ 补全中的自定义条目。
+type CompletionEntry struct {
+	Name        string         `json:"name" jsonschema:"required,description=Name shown in the completion popup and used for filtering,example=review"`
+	Kind        CompletionKind `json:"kind,omitempty" jsonschema:"description=How the entry is inserted: snippet attaches the text to the message, url and prompt insert the text into the editor,enum=snippet,enum=url,enum=prompt,default=snippet"`
+	Text        string         `json:"text" jsonschema:"required,description=Snippet text, URL or prompt text,example=https://go.dev/doc/effective_go"`
+	Description string         `json:"description,omitempty" jsonschema:"description=Short description shown next to the name"`
+}
+
 type Permissions struct {
 	AllowedTools []string `json:"allowed_tools,omitempty" jsonschema:"description=List of tools that don't require permission prompts,example=bash,example=view"` // 不需要权限提示的工具
-	SkipRequests bool     `json:"-"`                                                                                                                              // 自动接受所有权限（YOLO 模式）
+	// 权限对话框中「限时允许」的分钟数和次数，任一用尽后恢复请求权限
+	WindowMinutes *int `json:"window_minutes,omitempty" jsonschema:"description=Minutes a tool stays allowed in the session after choosing the time-boxed option in the permission dialog; 0 limits the grant by window_uses only,default=10,example=30"`
+	WindowUses    int  `json:"window_uses,omitempty" jsonschema:"description=Number of further calls of the tool allowed by the time-boxed option in the permission dialog; 0 means no limit on the number of calls,default=0,example=5"`
+	SkipRequests  bool `json:"-"` // 自动接受所有权限（YOLO 模式）
+}
+
+// defaultPermissionWindowMinutes 是「限时允许」默认的分钟数。
+const defaultPermissionWindowMinutes = 10
+
+// Window 返回权限对话框中「限时允许」的时长和次数，时长为 0 表示不限时间，次数为 0 表示不限次数。
+// 两者都为 0 时使用默认的 10 分钟。
+func (p *Permissions) Window() (time.Duration, int) {
+	if p == nil {
+		return defaultPermissionWindowMinutes * time.Minute, 0
+	}
+	minutes := max(0, ptrValOr(p.WindowMinutes, defaultPermissionWindowMinutes))
+	uses := max(0, p.WindowUses)
+	if minutes == 0 && uses == 0 {
+		minutes = defaultPermissionWindowMinutes
+	}
+	return time.Duration(minutes) * time.Minute, uses
 }
 
 type TrailerStyle string
@@ -246,21 +430,223 @@ func (Attribution) JSONSchemaExtend(schema *jsonschema.Schema) {
 }
 
 type Options struct {
-	ContextPaths              []string     `json:"context_paths,omitempty" jsonschema:"description=Paths to files containing context information for the AI,example=.cursorrules,example=CRUSH.md"`
-	SkillsPaths               []string     `json:"skills_paths,omitempty" jsonschema:"description=Paths to directories containing Agent Skills (folders with SKILL.md files),example=~/.config/crush/skills,example=./skills"`
-	TUI                       *TUIOptions  `json:"tui,omitempty" jsonschema:"description=Terminal user interface options"`
-	Debug                     bool         `json:"debug,omitempty" jsonschema:"description=Enable debug logging,default=false"`
-	DebugLSP                  bool         `json:"debug_lsp,omitempty" jsonschema:"description=Enable debug logging for LSP servers,default=false"`
-	DisableAutoSummarize      bool         `json:"disable_auto_summarize,omitempty" jsonschema:"description=Disable automatic conversation summarization,default=false"`
-	DataDirectory             string       `json:"data_directory,omitempty" jsonschema:"description=Directory for storing application data (relative to working directory),default=.crush,example=.crush"` // 相对于当前工作目录
-	DisabledTools             []string     `json:"disabled_tools,omitempty" jsonschema:"description=List of built-in tools to disable and hide from the agent,example=bash,example=sourcegraph"`
-	DisableProviderAutoUpdate bool         `json:"disable_provider_auto_update,omitempty" jsonschema:"description=Disable providers auto-update,default=false"`
-	DisableDefaultProviders   bool         `json:"disable_default_providers,omitempty" jsonschema:"description=Ignore all default/embedded providers. When enabled, providers must be fully specified in the config file with base_url, models, and api_key - no merging with defaults occurs,default=false"`
-	Attribution               *Attribution `json:"attribution,omitempty" jsonschema:"description=Attribution settings for generated content"`
-	DisableMetrics            bool         `json:"disable_metrics,omitempty" jsonschema:"description=Disable sending metrics,default=false"`
-	InitializeAs              string       `json:"initialize_as,omitempty" jsonschema:"description=Name of the context file to create/update during project initialization,default=AGENTS.md,example=AGENTS.md,example=CRUSH.md,example=CLAUDE.md,example=docs/LLMs.md"`
-	AutoLSP                   *bool        `json:"auto_lsp,omitempty" jsonschema:"description=Automatically setup LSPs based on root markers,default=true"`
-	Progress                  *bool        `json:"progress,omitempty" jsonschema:"description=Show indeterminate progress updates during long operations,default=true"`
+	ContextPaths              []string      `json:"context_paths,omitempty" jsonschema:"description=Paths to files containing context information for the AI,example=.cursorrules,example=CRUSH.md"`
+	SkillsPaths               []string      `json:"skills_paths,omitempty" jsonschema:"description=Paths to directories containing Agent Skills (folders with SKILL.md files),example=~/.config/crush/skills,example=./skills"`
+	TUI                       *TUIOptions   `json:"tui,omitempty" jsonschema:"description=Terminal user interface options"`
+	Debug                     bool          `json:"debug,omitempty" jsonschema:"description=Enable debug logging,default=false"`
+	DebugLSP                  bool          `json:"debug_lsp,omitempty" jsonschema:"description=Enable debug logging for LSP servers,default=false"`
+	DisableAutoSummarize      bool          `json:"disable_auto_summarize,omitempty" jsonschema:"description=Disable automatic conversation summarization,default=false"`
+	DisableAutoTitle          bool          `json:"disable_auto_title,omitempty" jsonschema:"description=Disable automatic session title generation with the small model,default=false"`
+	DataDirectory             string        `json:"data_directory,omitempty" jsonschema:"description=Directory for storing application data (relative to working directory),default=.crush,example=.crush"` // 相对于当前工作目录
+	DisabledTools             []string      `json:"disabled_tools,omitempty" jsonschema:"description=List of built-in tools to disable and hide from the agent,example=bash,example=sourcegraph"`
+	DisableProviderAutoUpdate bool          `json:"disable_provider_auto_update,omitempty" jsonschema:"description=Disable providers auto-update,default=false"`
+	DisableDefaultProviders   bool          `json:"disable_default_providers,omitempty" jsonschema:"description=Ignore all default/embedded providers. When enabled, providers must be fully specified in the config file with base_url, models, and api_key - no merging with defaults occurs,default=false"`
+	Offline                   bool          `json:"offline,omitempty" jsonschema:"description=Never fetch provider metadata or update information over the network; use the cached or embedded provider definitions only,default=false"`
+	ProviderCacheTTL          *int          `json:"provider_cache_ttl,omitempty" jsonschema:"description=Hours the cached provider metadata is used without checking for updates; 0 checks on every start,default=24,example=0"`
+	Attribution               *Attribution  `json:"attribution,omitempty" jsonschema:"description=Attribution settings for generated content"`
+	DisableMetrics            bool          `json:"disable_metrics,omitempty" jsonschema:"description=Disable sending metrics,default=false"`
+	InitializeAs              string        `json:"initialize_as,omitempty" jsonschema:"description=Name of the context file to create/update during project initialization,default=AGENTS.md,example=AGENTS.md,example=CRUSH.md,example=CLAUDE.md,example=docs/LLMs.md"`
+	AutoLSP                   *bool         `json:"auto_lsp,omitempty" jsonschema:"description=Automatically setup LSPs based on root markers,default=true"`
+	Progress                  *bool         `json:"progress,omitempty" jsonschema:"description=Show indeterminate progress updates during long operations,default=true"`
+	Redaction                 *Redaction    `json:"redaction,omitempty" jsonschema:"description=Scrub secrets from tool output and attachments before they are sent to the model"`
+	DiscardReasoning          bool          `json:"discard_reasoning,omitempty" jsonschema:"description=Do not keep model reasoning traces in the session history; traces are shown while streaming and removed when the run finishes,default=false"`
+	Retention                 *Retention    `json:"retention,omitempty" jsonschema:"description=Automatic cleanup policy for old sessions; archived sessions are never cleaned up"`
+	Voice                     *Voice        `json:"voice,omitempty" jsonschema:"description=Voice input: record with a command and transcribe with a Whisper-compatible API"`
+	Budget                    *Budget       `json:"budget,omitempty" jsonschema:"description=Spending limit for model requests; warns at 80% and asks for confirmation before running the agent once it is reached"`
+	Network                   *Network      `json:"network,omitempty" jsonschema:"description=Proxy and TLS settings for all outbound HTTP requests, including providers, fetch tools and MCP servers"`
+	Checks                    []string      `json:"checks,omitempty" jsonschema:"description=Commands run in the background after each edit or write tool; failures are attached to the tool result so the agent sees them,example=go build ./...,example=golangci-lint run"`
+	Shell                     string        `json:"shell,omitempty" jsonschema:"description=Shell used by the bash tool; powershell runs commands with pwsh or Windows PowerShell and translates common POSIX idioms,enum=posix,enum=powershell,default=posix"`
+	WorkspaceRoots            []string      `json:"workspace_roots,omitempty" jsonschema:"description=Additional directories that belong to the workspace; reported to MCP servers as roots together with the working directory,example=../shared,example=~/notes"`
+	PromptLint                bool          `json:"prompt_lint,omitempty" jsonschema:"description=Check prompts for vague wording before sending and offer to rewrite them with the small model,default=false"`
+	Images                    *Images       `json:"images,omitempty" jsonschema:"description=Preprocessing of image attachments: large images are downscaled and re-encoded before sending and the original is kept on disk"`
+	Sandbox                   *Sandbox      `json:"sandbox,omitempty" jsonschema:"description=Run bash tool commands inside a container or bubblewrap namespace with only the workspace mounted"`
+	ToolRepair                *ToolRepair   `json:"tool_repair,omitempty" jsonschema:"description=Automatic correction of tool call arguments that are not valid JSON before the error is returned to the model"`
+	OutputStream              *OutputStream `json:"output_stream,omitempty" jsonschema:"description=Mirror assistant output to a named pipe or Unix socket as it streams so that external tools can consume it in real time"`
+	DryRun                    bool          `json:"-"` // 演练模式：编辑工具不修改文件，只生成补丁（通过 --dry-run 设置）
+}
+
+// Retention 配置旧会话的自动清理策略。清理会删除会话及其消息和文件历史，
+// 执行前需要用户确认。已归档的会话不受影响。
+type Retention struct {
+	// 最多保留的未归档会话数，0 表示不限制。
+	MaxSessions int `json:"max_sessions,omitempty" jsonschema:"description=Maximum number of unarchived sessions to keep (0 for no limit),minimum=0,example=100"`
+	// 会话最后一次更新后保留的天数，0 表示不限制。
+	MaxAgeDays int `json:"max_age_days,omitempty" jsonschema:"description=Delete sessions not updated for this many days (0 for no limit),minimum=0,example=30"`
+}
+
+// Enabled 报告是否配置了任何清理限制。
+func (r *Retention) Enabled() bool {
+	return r != nil && (r.MaxSessions > 0 || r.MaxAgeDays > 0)
+}
+
+// Voice 配置语音输入。录音命令应持续录音直到收到中断信号，
+// 然后将音频写入参数中 {file} 占位符所指的文件。
+type Voice struct {
+	Command  string   `json:"command,omitempty" jsonschema:"description=Command that records audio until interrupted,default=sox,example=ffmpeg"`
+	Args     []string `json:"args,omitempty" jsonschema:"description=Arguments for the record command; {file} is replaced with the output audio path,example=-d,example={file}"`
+	BaseURL  string   `json:"base_url,omitempty" jsonschema:"description=Base URL of an OpenAI Whisper-compatible transcription API,default=https://api.openai.com/v1"`
+	APIKey   string   `json:"api_key,omitempty" jsonschema:"description=API key for the transcription API,default=$OPENAI_API_KEY,example=$OPENAI_API_KEY"`
+	Model    string   `json:"model,omitempty" jsonschema:"description=Transcription model,default=whisper-1"`
+	Language string   `json:"language,omitempty" jsonschema:"description=Language of the speech as an ISO-639-1 code,example=zh,example=en"`
+}
+
+// Images 配置图片附件的预处理。超过最大尺寸的图片在附加时按比例缩小并重新编码，
+// 原图保存在数据目录的 attachments 子目录中（附加的是磁盘上的文件时直接引用原文件）。
+type Images struct {
+	MaxDimension int  `json:"max_dimension,omitempty" jsonschema:"description=Maximum length in pixels of the longest image edge; larger images are downscaled,default=1568,example=1024"`
+	Quality      int  `json:"quality,omitempty" jsonschema:"description=JPEG quality (1-100) used when re-encoding opaque images,maximum=100,default=85"`
+	Disabled     bool `json:"disabled,omitempty" jsonschema:"description=Attach images unchanged without downscaling or re-encoding,default=false"`
+}
+
+// Budget 配置模型请求的费用上限，费用按每次请求记录并按本地日期统计。
+type Budget struct {
+	DailyUSD float64 `json:"daily_usd,omitempty" jsonschema:"description=Maximum spend per day in US dollars; 0 disables the budget,minimum=0,example=5"`
+}
+
+// Network 配置所有出站 HTTP 请求的代理和 TLS 设置，适用于需要通过企业代理访问网络的环境。
+type Network struct {
+	Proxy              string `json:"proxy,omitempty" jsonschema:"description=Proxy URL for all outbound HTTP requests; NO_PROXY is still honored. Defaults to the HTTP_PROXY and HTTPS_PROXY environment variables,example=http://proxy.example.com:8080,example=socks5://127.0.0.1:1080"`
+	CABundle           string `json:"ca_bundle,omitempty" jsonschema:"description=Path to a PEM file with additional CA certificates to trust,example=~/certs/corp-ca.pem"`
+	InsecureSkipVerify bool   `json:"insecure_skip_verify,omitempty" jsonschema:"description=Skip TLS certificate verification; only use for troubleshooting,default=false"`
+}
+
+// Sandbox 配置 bash 工具的安全模式：命令在容器（docker、podman）或 bubblewrap 命名空间中执行，
+// 只有工作区目录可写，防止失控的命令修改或删除主机上的其他文件。
+type Sandbox struct {
+	Engine  string `json:"engine" jsonschema:"required,description=Sandbox engine; bubblewrap is only available on Linux,enum=docker,enum=podman,enum=bubblewrap"`
+	Image   string `json:"image,omitempty" jsonschema:"description=Container image used by docker and podman; it must provide sh and the tools the agent needs,example=golang:1.25,example=node:22"`
+	Network bool   `json:"network,omitempty" jsonschema:"description=Allow network access inside the sandbox,default=false"`
+}
+
+// Enabled 报告是否配置了沙箱
+func (s *Sandbox) Enabled() bool {
+	return s != nil && s.Engine != ""
+}
+
+// validate 校验沙箱配置，未配置时不做任何检查
+func (s *Sandbox) validate() error {
+	if s == nil {
+		return nil
+	}
+	if !slices.Contains(sandbox.Engines, s.Engine) {
+		return fmt.Errorf("sandbox.engine 必须是 %s 之一: %q", strings.Join(sandbox.Engines, "、"), s.Engine)
+	}
+	if !sandbox.Supported(s.Engine) {
+		return fmt.Errorf("当前平台不支持沙箱引擎 %s", s.Engine)
+	}
+	if s.Engine != sandbox.EngineBubblewrap && s.Image == "" {
+		return fmt.Errorf("使用 %s 时需要设置 sandbox.image", s.Engine)
+	}
+	return nil
+}
+
+// defaultToolRepairAttempts 和 maxToolRepairAttempts 是请求模型修正工具参数的默认次数和上限。
+const (
+	defaultToolRepairAttempts = 2
+	maxToolRepairAttempts     = 5
+)
+
+// ToolRepair 配置工具参数的自动修正。模型生成的工具参数不是有效的 JSON 时，先在本地修正常见的格式问题，
+// 仍然无效时把解析错误交给模型重新生成参数，多次失败后才把错误返回给代理。
+type ToolRepair struct {
+	MaxAttempts *int              `json:"max_attempts,omitempty" jsonschema:"description=Maximum number of times a model is asked to fix malformed tool arguments; 0 only applies the local fixes,minimum=0,maximum=5,default=2"`
+	Model       SelectedModelType `json:"model,omitempty" jsonschema:"description=Model that fixes malformed tool arguments; the small model is cheaper and faster,enum=large,enum=small,default=large"`
+}
+
+// Attempts 返回请求模型修正工具参数的最多次数，未配置时为 2。
+func (r *ToolRepair) Attempts() int {
+	if r == nil {
+		return defaultToolRepairAttempts
+	}
+	return min(max(0, ptrValOr(r.MaxAttempts, defaultToolRepairAttempts)), maxToolRepairAttempts)
+}
+
+// UseSmallModel 报告是否使用小模型修正工具参数。
+func (r *ToolRepair) UseSmallModel() bool {
+	return r != nil && r.Model == SelectedModelTypeSmall
+}
+
+// 输出流的格式
+const (
+	// OutputStreamFormatJSONL 每行写入一个 JSON 事件，包含会话、消息和事件类型。
+	OutputStreamFormatJSONL = "jsonl"
+	// OutputStreamFormatText 只写入助手回复的文本，每条回复之后空一行。
+	OutputStreamFormatText = "text"
+)
+
+// OutputStream 配置把助手输出实时写入命名管道或 Unix 套接字，供语音合成、通知和日志收集等外部工具读取。
+// 没有读取方时输出会被丢弃，不会阻塞代理。
+type OutputStream struct {
+	FIFO      string `json:"fifo,omitempty" jsonschema:"description=Path of a named pipe to write to; created if it does not exist (not supported on Windows),example=/tmp/crush.fifo"`
+	Socket    string `json:"socket,omitempty" jsonschema:"description=Path of a Unix socket to listen on; every connected client receives the output,example=/tmp/crush.sock"`
+	Format    string `json:"format,omitempty" jsonschema:"description=Output format: jsonl writes one JSON event per line while text writes only the reply text,enum=jsonl,enum=text,default=jsonl"`
+	Reasoning bool   `json:"reasoning,omitempty" jsonschema:"description=Also stream model reasoning; only used with the jsonl format,default=false"`
+}
+
+// Enabled 报告是否配置了命名管道或 Unix 套接字。
+func (o *OutputStream) Enabled() bool {
+	return o != nil && (o.FIFO != "" || o.Socket != "")
+}
+
+// Remote 配置远程开发：文件和 shell 工具通过 SSH 在远程主机的项目目录中执行，
+// 连接使用系统的 ssh 命令，因此 ~/.ssh/config 中的别名、密钥和跳板机设置都会生效。
+type Remote struct {
+	Host string `json:"host" jsonschema:"required,description=Remote host name or an alias from ~/.ssh/config,example=devbox"`
+	User string `json:"user,omitempty" jsonschema:"description=Remote user name; defaults to the ssh configuration,example=ubuntu"`
+	Port int    `json:"port,omitempty" jsonschema:"description=SSH port; defaults to the ssh configuration,example=22"`
+	Path string `json:"path" jsonschema:"required,description=Absolute path of the project directory on the remote host,example=/home/ubuntu/project"`
+}
+
+// Enabled 报告是否配置了远程开发
+func (r *Remote) Enabled() bool {
+	return r != nil && r.Host != ""
+}
+
+// validate 校验远程开发配置，未配置时不做任何检查
+func (r *Remote) validate() error {
+	if r == nil {
+		return nil
+	}
+	if r.Host == "" {
+		return errors.New("需要设置 remote.host")
+	}
+	if !path.IsAbs(r.Path) {
+		return fmt.Errorf("remote.path 必须是远程主机上的绝对路径: %q", r.Path)
+	}
+	if r.Port < 0 || r.Port > 65535 {
+		return fmt.Errorf("remote.port 无效: %d", r.Port)
+	}
+	return nil
+}
+
+// User 是使用 Crush 的人的身份。多人在同一仓库中使用 Crush 时，会话、文件历史
+// 和提交尾注中会记录作者，通常在全局配置中设置。
+type User struct {
+	Name  string `json:"name,omitempty" jsonschema:"description=Your name,example=Ada Lovelace"`
+	Email string `json:"email,omitempty" jsonschema:"description=Your email address,example=ada@example.com"`
+}
+
+// Author 返回 "Name <email>" 格式的作者，未配置时返回空字符串
+func (u *User) Author() string {
+	if u == nil {
+		return ""
+	}
+	name := strings.TrimSpace(u.Name)
+	email := strings.TrimSpace(u.Email)
+	switch {
+	case name != "" && email != "":
+		return fmt.Sprintf("%s <%s>", name, email)
+	case email != "":
+		return "<" + email + ">"
+	default:
+		return name
+	}
+}
+
+// Redaction 配置发送给模型前的敏感信息脱敏。内置规则覆盖常见的云服务密钥、私钥和访问令牌。
+type Redaction struct {
+	Disabled bool              `json:"disabled,omitempty" jsonschema:"description=Disable secret redaction,default=false"`
+	Patterns map[string]string `json:"patterns,omitempty" jsonschema:"description=Additional regular expressions to redact keyed by rule name"`
 }
 
 type MCPs map[string]MCPConfig
@@ -347,10 +733,36 @@ type Agent struct {
 
 	// 覆盖此智能体的上下文路径
 	ContextPaths []string `json:"context_paths,omitempty"`
+
+	// 自定义智能体的系统提示，为空时使用内置提示
+	SystemPrompt string `json:"system_prompt,omitempty"`
 }
 
+// CustomAgent 是用户在配置中定义的子智能体，可以通过 agent 工具或 agent:<名称> 命令调用。
+type CustomAgent struct {
+	Description  string              `json:"description,omitempty" jsonschema:"description=What the agent is for; shown to the coder agent when it picks a sub-agent,example=Reviews diffs for bugs and style issues"`
+	SystemPrompt string              `json:"system_prompt" jsonschema:"required,description=System prompt for the agent"`
+	Model        SelectedModelType   `json:"model,omitempty" jsonschema:"description=The model type to use for this agent,enum=large,enum=small,default=large"`
+	AllowedTools []string            `json:"allowed_tools,omitempty" jsonschema:"description=Tools the agent may use; defaults to the read-only tools,example=view,example=grep,example=bash"`
+	AllowedMCP   map[string][]string `json:"allowed_mcp,omitempty" jsonschema:"description=MCP servers (and optionally their tools) the agent may use; defaults to none"`
+	Disabled     bool                `json:"disabled,omitempty" jsonschema:"description=Whether this agent is disabled,default=false"`
+}
+
+// customAgentNamePattern 限制自定义智能体的名称，使其可以用作命令 ID 和工具参数。
+var customAgentNamePattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
 type Tools struct {
-	Ls ToolLs `json:"ls,omitempty"`
+	Ls             ToolLs             `json:"ls,omitempty"`
+	IssueFetch     ToolIssueFetch     `json:"issue_fetch,omitempty"`
+	Fetch          ToolFetch          `json:"fetch,omitempty"`
+	SemanticSearch ToolSemanticSearch `json:"semantic_search,omitempty"`
+	Output         ToolOutput         `json:"output,omitempty"`
+	Timeouts       map[string]int     `json:"timeouts,omitempty" jsonschema:"description=Maximum seconds a tool may run before it is canceled keyed by tool name; time spent waiting for permission is not counted,example={\"bash\":120,\"fetch\":30,\"agent\":600}"`
+}
+
+// Timeout 返回工具的执行时限，未配置时返回 0，表示不限制。
+func (t Tools) Timeout(toolName string) time.Duration {
+	return time.Duration(max(t.Timeouts[toolName], 0)) * time.Second
 }
 
 type ToolLs struct {
@@ -362,6 +774,69 @@ func (t ToolLs) Limits() (depth, items int) {
 	return ptrValOr(t.MaxDepth, 0), ptrValOr(t.MaxItems, 0)
 }
 
+// ToolIssueFetch 保存 issue_fetch 工具访问各平台 REST API 所用的令牌。
+// 令牌支持 $ENV 形式的变量引用。GitLab 令牌只发送给 gitlab.com 和 GitLabHosts 中的主机。
+type ToolIssueFetch struct {
+	GitHubToken      string   `json:"github_token,omitempty" jsonschema:"description=Token for the GitHub REST API,example=$GITHUB_TOKEN"`
+	GitLabToken      string   `json:"gitlab_token,omitempty" jsonschema:"description=Token for the GitLab REST API,example=$GITLAB_TOKEN"`
+	GitLabHosts      []string `json:"gitlab_hosts,omitempty" jsonschema:"description=Self-hosted GitLab hosts that may receive the GitLab token in addition to gitlab.com,example=gitlab.example.com"`
+	AzureDevOpsToken string   `json:"azure_devops_token,omitempty" jsonschema:"description=Personal access token for the Azure DevOps REST API,example=$AZURE_DEVOPS_TOKEN"`
+}
+
+// ToolFetch 配置 fetch 和 agentic_fetch 工具的磁盘 HTTP 缓存。
+type ToolFetch struct {
+	DisableCache bool `json:"disable_cache,omitempty" jsonschema:"description=Disable the disk cache for fetched URLs,default=false"`
+	CacheTTL     *int `json:"cache_ttl,omitempty" jsonschema:"description=Seconds a fetched URL stays cached; overrides the server's Cache-Control when set,example=86400"`
+}
+
+// defaultProviderCacheTTL 是缓存的提供者元数据免于检查更新的默认小时数。
+const defaultProviderCacheTTL = 24
+
+// ProviderCacheDuration 返回缓存的提供者元数据免于检查更新的时长，为 0 时每次启动都检查更新。
+func (o Options) ProviderCacheDuration() time.Duration {
+	return time.Duration(max(0, ptrValOr(o.ProviderCacheTTL, defaultProviderCacheTTL))) * time.Hour
+}
+
+// CacheDuration 返回配置的缓存时长，未配置时返回 0，表示遵循响应的 Cache-Control。
+func (t ToolFetch) CacheDuration() time.Duration {
+	return time.Duration(ptrValOr(t.CacheTTL, 0)) * time.Second
+}
+
+// ToolOutput 配置工具结果写入会话前的截断策略。超出限制的完整输出会保存到数据目录中，
+// 可在界面中展开查看。
+type ToolOutput struct {
+	Mode     string                     `json:"mode,omitempty" jsonschema:"description=Which part of an oversized tool result to keep,enum=head,enum=tail,enum=summary,default=summary"`
+	MaxBytes *int                       `json:"max_bytes,omitempty" jsonschema:"description=Maximum bytes of a tool result kept in the conversation; 0 disables truncation,default=51200,example=20000"`
+	Tools    map[string]ToolOutputLimit `json:"tools,omitempty" jsonschema:"description=Per-tool overrides keyed by tool name"`
+}
+
+// ToolOutputLimit 覆盖单个工具的截断策略，未设置的字段沿用 [ToolOutput] 的值。
+type ToolOutputLimit struct {
+	Mode     string `json:"mode,omitempty" jsonschema:"description=Which part of an oversized tool result to keep,enum=head,enum=tail,enum=summary"`
+	MaxBytes *int   `json:"max_bytes,omitempty" jsonschema:"description=Maximum bytes of the tool result kept in the conversation; 0 disables truncation,example=100000"`
+}
+
+// Limit 返回工具的截断模式和字节上限，工具的覆盖配置优先。未配置时返回零值。
+func (t ToolOutput) Limit(toolName string) (mode string, maxBytes *int) {
+	mode, maxBytes = t.Mode, t.MaxBytes
+	if override, ok := t.Tools[toolName]; ok {
+		mode = cmp.Or(override.Mode, mode)
+		if override.MaxBytes != nil {
+			maxBytes = override.MaxBytes
+		}
+	}
+	return mode, maxBytes
+}
+
+// ToolSemanticSearch 配置 semantic_search 工具使用的嵌入模型。
+// 嵌入端点需兼容 OpenAI 的 /embeddings API。未配置模型时该工具不可用。
+type ToolSemanticSearch struct {
+	Provider string `json:"provider,omitempty" jsonschema:"description=ID of a configured provider whose base_url and api_key are used for embeddings,example=openai"`
+	Model    string `json:"model,omitempty" jsonschema:"description=Embedding model ID,example=text-embedding-3-small,example=nomic-embed-text"`
+	BaseURL  string `json:"base_url,omitempty" jsonschema:"description=Base URL of an OpenAI-compatible embeddings API; overrides the provider's base_url,format=uri,example=http://localhost:11434/v1"`
+	APIKey   string `json:"api_key,omitempty" jsonschema:"description=API key for the embeddings API; overrides the provider's api_key,example=$OPENAI_API_KEY"`
+}
+
 // Config 保存 crush 的配置。
 type Config struct {
 	Schema string `json:"$schema,omitempty"`
@@ -385,6 +860,12 @@ type Config struct {
 
 	Tools Tools `json:"tools,omitempty" jsonschema:"description=Tool configurations"`
 
+	CustomAgents map[string]CustomAgent `json:"agents,omitempty" jsonschema:"description=Custom sub-agents keyed by name"`
+
+	Remote *Remote `json:"remote,omitempty" jsonschema:"description=Run file and shell tools on a remote host over SSH"`
+
+	User *User `json:"user,omitempty" jsonschema:"description=Your identity recorded as the author of sessions; file history entries and commits"`
+
 	Agents map[string]Agent `json:"-"`
 
 	// 内部字段
@@ -393,12 +874,35 @@ type Config struct {
 	resolver       VariableResolver
 	dataConfigDir  string             `json:"-"`
 	knownProviders []catwalk.Provider `json:"-"`
+	// validationIssues 是启动时校验配置文件发现的问题
+	validationIssues []ValidationIssue
 }
 
 func (c *Config) WorkingDir() string {
 	return c.workingDir
 }
 
+// WorkspaceRoots 返回工作区的根目录：工作目录以及 options.workspace_roots 中的目录。
+// 相对路径相对于工作目录解析，结果为去重后的绝对路径。
+func (c *Config) WorkspaceRoots() []string {
+	roots := []string{c.workingDir}
+	for _, dir := range c.Options.WorkspaceRoots {
+		if dir = c.ResolveWorkspaceRoot(dir); !slices.Contains(roots, dir) {
+			roots = append(roots, dir)
+		}
+	}
+	return roots
+}
+
+// ResolveWorkspaceRoot 展开 ~ 并将相对路径相对于工作目录解析为绝对路径。
+func (c *Config) ResolveWorkspaceRoot(dir string) string {
+	dir = home.Long(dir)
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(c.workingDir, dir)
+	}
+	return filepath.Clean(dir)
+}
+
 func (c *Config) EnabledProviders() []ProviderConfig {
 	var enabled []ProviderConfig
 	for p := range c.Providers.Seq() {
@@ -468,6 +972,24 @@ func (c *Config) SetCompactMode(enabled bool) error {
 	return c.SetConfigField("options.tui.compact_mode", enabled)
 }
 
+// SetTUILayout 设置宽屏下聊天的布局并持久化。
+func (c *Config) SetTUILayout(layout string) error {
+	if c.Options == nil {
+		c.Options = &Options{}
+	}
+	c.Options.TUI.Layout = layout
+	return c.SetConfigField("options.tui.layout", layout)
+}
+
+// SetLSP 在配置中添加或替换指定名称的LSP并持久化。
+func (c *Config) SetLSP(name string, lsp LSPConfig) error {
+	if c.LSP == nil {
+		c.LSP = LSPs{}
+	}
+	c.LSP[name] = lsp
+	return c.SetConfigField("lsp."+name, lsp)
+}
+
 func (c *Config) Resolve(key string) (string, error) {
 	if c.resolver == nil {
 		return "", fmt.Errorf("未配置变量解析器")
@@ -648,6 +1170,31 @@ func (c *Config) SetProviderAPIKey(providerID string, apiKey any) error {
 
 const maxRecentModelsPerType = 5
 
+// AllowTool 将工具加入 permissions.allowed_tools 并持久化到数据目录的配置文件，
+// 之后调用该工具不再需要确认。MCP 工具使用 mcp_<服务器>_<工具> 形式的名称。
+func (c *Config) AllowTool(name string) error {
+	if c.Permissions == nil {
+		c.Permissions = &Permissions{}
+	}
+	if !slices.Contains(c.Permissions.AllowedTools, name) {
+		c.Permissions.AllowedTools = append(c.Permissions.AllowedTools, name)
+	}
+
+	data, err := os.ReadFile(c.dataConfigDir)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("读取配置文件失败: %w", err)
+	}
+	for _, tool := range gjson.GetBytes(data, "permissions.allowed_tools").Array() {
+		if tool.String() == name {
+			return nil
+		}
+	}
+	if err := c.SetConfigField("permissions.allowed_tools.-1", name); err != nil {
+		return fmt.Errorf("持久化允许的工具失败: %w", err)
+	}
+	return nil
+}
+
 func (c *Config) recordRecentModel(modelType SelectedModelType, model SelectedModel) error {
 	if model.Provider == "" || model.Model == "" {
 		return nil
@@ -700,12 +1247,22 @@ func allToolNames() []string {
 		"multiedit",
 		"lsp_diagnostics",
 		"lsp_references",
+		"lsp_symbols",
+		"lsp_rename",
+		"lsp_code_action",
 		"lsp_restart",
 		"fetch",
 		"agentic_fetch",
+		"issue_fetch",
+		"git_status",
+		"git_diff",
+		"git_commit",
 		"glob",
 		"grep",
 		"ls",
+		"repo_map",
+		"run_tests",
+		"semantic_search",
 		"sourcegraph",
 		"todos",
 		"view",
@@ -724,7 +1281,7 @@ func resolveAllowedTools(allTools []string, disabledTools []string) []string {
 }
 
 func resolveReadOnlyTools(tools []string) []string {
-	readOnlyTools := []string{"glob", "grep", "ls", "sourcegraph", "view"}
+	readOnlyTools := []string{"git_diff", "git_status", "glob", "grep", "ls", "repo_map", "semantic_search", "sourcegraph", "view"}
 	// 过滤以仅包含在 allowedtools 中的工具（包含模式）
 	return filterSlice(tools, readOnlyTools, true)
 }
@@ -765,13 +1322,64 @@ func (c *Config) SetupAgents() {
 			AllowedMCP: map[string][]string{},
 		},
 	}
+	for _, name := range slices.Sorted(maps.Keys(c.CustomAgents)) {
+		custom := c.CustomAgents[name]
+		if custom.Disabled {
+			continue
+		}
+		if _, ok := agents[name]; ok || !customAgentNamePattern.MatchString(name) {
+			slog.Warn("忽略名称无效的自定义智能体", "name", name)
+			continue
+		}
+		agents[name] = custom.agent(name, allowedTools, c.Options.ContextPaths)
+	}
 	c.Agents = agents
 }
 
+// CustomAgentNames 返回已启用的自定义智能体名称，按名称排序。
+func (c *Config) CustomAgentNames() []string {
+	var names []string
+	for name := range c.Agents {
+		if name != AgentCoder && name != AgentTask {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+	return names
+}
+
+// agent 将自定义智能体转换为 [Agent]。未配置工具时使用只读工具，
+// 子智能体不能再调用 agent 工具。
+func (a CustomAgent) agent(name string, allowedTools, contextPaths []string) Agent {
+	tools := resolveReadOnlyTools(allowedTools)
+	if a.AllowedTools != nil {
+		tools = filterSlice(allowedTools, a.AllowedTools, true)
+	}
+	allowedMCP := a.AllowedMCP
+	if allowedMCP == nil {
+		allowedMCP = map[string][]string{}
+	}
+	return Agent{
+		ID:           name,
+		Name:         name,
+		Description:  a.Description,
+		Model:        cmp.Or(a.Model, SelectedModelTypeLarge),
+		ContextPaths: contextPaths,
+		AllowedTools: slices.DeleteFunc(tools, func(tool string) bool { return tool == "agent" }),
+		AllowedMCP:   allowedMCP,
+		SystemPrompt: a.SystemPrompt,
+	}
+}
+
 func (c *Config) Resolver() VariableResolver {
 	return c.resolver
 }
 
+// ValidationIssues 返回加载配置时校验配置文件发现的问题。
+func (c *Config) ValidationIssues() []ValidationIssue {
+	return c.validationIssues
+}
+
 func (c *ProviderConfig) TestConnection(resolver VariableResolver) error {
 	var (
 		providerID = catwalk.InferenceProvider(c.ID)
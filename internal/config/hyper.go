@@ -31,6 +31,7 @@ type hyperSync struct {
 	cache      cache[catwalk.Provider] // 缓存管理器
 	client     hyperClient             // Hyper 客户端
 	autoupdate bool                    // 是否启用自动更新
+	policy     cachePolicy             // 缓存使用策略
 	init       atomic.Bool             // 标记是否已初始化
 }
 
@@ -58,11 +59,28 @@ func (s *hyperSync) Get(ctx context.Context) (catwalk.Provider, error) {
 		}
 
 		cached, etag, cachedErr := s.cache.Get()
-		if cached.ID == "" || cachedErr != nil {
+		hasCache := cached.ID != "" && cachedErr == nil
+		if !hasCache {
 			// 如果缓存文件为空，则默认使用内置提供商
 			cached = hyper.Embedded()
 		}
 
+		if s.policy.offline {
+			slog.Info("离线模式，使用缓存的 Hyper 提供商", "cached", hasCache)
+			s.result = cached
+			return
+		}
+		if hasCache && s.cache.Fresh(s.policy.ttl) {
+			slog.Info("缓存的 Hyper 提供商未过期，跳过更新")
+			s.result = cached
+			return
+		}
+		if hasCache {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, cachedFetchTimeout)
+			defer cancel()
+		}
+
 		slog.Info("正在获取 Hyper 提供商")
 		result, err := s.client.Get(ctx, etag)
 		if errors.Is(err, context.DeadlineExceeded) {
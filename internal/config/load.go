@@ -24,6 +24,7 @@ import (
 	"github.com/purpose168/crush-cn/internal/fsext"
 	"github.com/purpose168/crush-cn/internal/home"
 	"github.com/purpose168/crush-cn/internal/log"
+	"github.com/purpose168/crush-cn/internal/network"
 	"github.com/qjebbs/go-jsons"
 )
 
@@ -35,6 +36,10 @@ func Load(workingDir, dataDir string, debug bool) (*Config, error) {
 
 	cfg, err := loadFromConfigPaths(configPaths)
 	if err != nil {
+		// 尽量给出带有行列信息的具体问题，而不是仅返回解码错误。
+		if issues, verr := ValidateConfigFiles(configPaths...); verr == nil && HasValidationErrors(issues) {
+			return nil, fmt.Errorf("从路径 %v 加载配置失败:\n%s", configPaths, formatValidationIssues(issues))
+		}
 		return nil, fmt.Errorf("从路径 %v 加载配置失败: %w", configPaths, err)
 	}
 
@@ -52,6 +57,29 @@ func Load(workingDir, dataDir string, debug bool) (*Config, error) {
 		cfg.Options.Debug,
 	)
 
+	// 在请求 catwalk 等网络服务之前应用网络设置
+	if err := setupNetwork(cfg.Options.Network); err != nil {
+		return nil, fmt.Errorf("配置网络失败: %w", err)
+	}
+
+	if err := cfg.Remote.validate(); err != nil {
+		return nil, fmt.Errorf("远程开发配置无效: %w", err)
+	}
+	if err := cfg.Options.Sandbox.validate(); err != nil {
+		return nil, fmt.Errorf("沙箱配置无效: %w", err)
+	}
+	if cfg.Remote.Enabled() && cfg.Options.Sandbox.Enabled() {
+		slog.Warn("远程开发时命令在远程主机上执行，沙箱配置不生效")
+	}
+
+	cfg.validationIssues, err = ValidateConfigFiles(configPaths...)
+	if err != nil {
+		slog.Warn("校验配置文件失败", "error", err)
+	}
+	for _, issue := range cfg.validationIssues {
+		slog.Warn("配置文件存在问题", "file", issue.File, "line", issue.Line, "column", issue.Column, "path", issue.Path, "message", issue.Message, "severity", issue.Severity.String())
+	}
+
 	if !isInsideWorktree() {
 		const depth = 2
 		const items = 100
@@ -201,6 +229,8 @@ func (c *Config) configureProviders(env env.Env, resolver VariableResolver, know
 			SystemPromptPrefix: config.SystemPromptPrefix,
 			ExtraHeaders:       headers,
 			ExtraBody:          config.ExtraBody,
+			RateLimit:          config.RateLimit,
+			PromptCache:        config.PromptCache,
 			ExtraParams:        make(map[string]string),
 			Models:             p.Models,
 		}
@@ -385,6 +415,10 @@ func (c *Config) setDefaults(workingDir, dataDir string) {
 		c.Options.DisableProviderAutoUpdate, _ = strconv.ParseBool(str)
 	}
 
+	if str, ok := os.LookupEnv("CRUSH_OFFLINE"); ok {
+		c.Options.Offline, _ = strconv.ParseBool(str)
+	}
+
 	if str, ok := os.LookupEnv("CRUSH_DISABLE_DEFAULT_PROVIDERS"); ok {
 		c.Options.DisableDefaultProviders, _ = strconv.ParseBool(str)
 	}
@@ -791,3 +825,15 @@ func GlobalSkillsDirs() []string {
 }
 
 func isAppleTerminal() bool { return os.Getenv("TERM_PROGRAM") == "Apple_Terminal" }
+
+// setupNetwork 按配置设置所有出站 HTTP 请求使用的代理和 CA 证书
+func setupNetwork(n *Network) error {
+	if n == nil {
+		return network.Setup(network.Options{})
+	}
+	return network.Setup(network.Options{
+		Proxy:              n.Proxy,
+		CABundle:           home.Long(n.CABundle),
+		InsecureSkipVerify: n.InsecureSkipVerify,
+	})
+}
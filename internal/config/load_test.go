@@ -6,6 +6,7 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"charm.land/catwalk/pkg/catwalk"
 	"github.com/purpose168/crush-cn/internal/csync"
@@ -493,7 +494,7 @@ func TestConfig_setupAgentsWithNoDisabledTools(t *testing.T) {
 
 	taskAgent, ok := cfg.Agents[AgentTask]
 	require.True(t, ok)
-	assert.Equal(t, []string{"glob", "grep", "ls", "sourcegraph", "view"}, taskAgent.AllowedTools)
+	assert.Equal(t, []string{"git_status", "git_diff", "glob", "grep", "ls", "repo_map", "semantic_search", "sourcegraph", "view"}, taskAgent.AllowedTools)
 }
 
 // TestConfig_setupAgentsWithDisabledTools 测试在有禁用工具的情况下设置代理
@@ -512,11 +513,11 @@ func TestConfig_setupAgentsWithDisabledTools(t *testing.T) {
 	coderAgent, ok := cfg.Agents[AgentCoder]
 	require.True(t, ok)
 
-	assert.Equal(t, []string{"agent", "bash", "job_output", "job_kill", "multiedit", "lsp_diagnostics", "lsp_references", "lsp_restart", "fetch", "agentic_fetch", "glob", "ls", "sourcegraph", "todos", "view", "write", "list_mcp_resources", "read_mcp_resource"}, coderAgent.AllowedTools)
+	assert.Equal(t, []string{"agent", "bash", "job_output", "job_kill", "multiedit", "lsp_diagnostics", "lsp_references", "lsp_symbols", "lsp_rename", "lsp_code_action", "lsp_restart", "fetch", "agentic_fetch", "issue_fetch", "git_status", "git_diff", "git_commit", "glob", "ls", "repo_map", "run_tests", "semantic_search", "sourcegraph", "todos", "view", "write", "list_mcp_resources", "read_mcp_resource"}, coderAgent.AllowedTools)
 
 	taskAgent, ok := cfg.Agents[AgentTask]
 	require.True(t, ok)
-	assert.Equal(t, []string{"glob", "ls", "sourcegraph", "view"}, taskAgent.AllowedTools)
+	assert.Equal(t, []string{"git_status", "git_diff", "glob", "ls", "repo_map", "semantic_search", "sourcegraph", "view"}, taskAgent.AllowedTools)
 }
 
 // TestConfig_setupAgentsWithEveryReadOnlyToolDisabled 测试在所有只读工具都被禁用的情况下设置代理
@@ -524,9 +525,13 @@ func TestConfig_setupAgentsWithEveryReadOnlyToolDisabled(t *testing.T) {
 	cfg := &Config{
 		Options: &Options{
 			DisabledTools: []string{
+				"git_diff",
+				"git_status",
 				"glob",
 				"grep",
 				"ls",
+				"repo_map",
+				"semantic_search",
 				"sourcegraph",
 				"view",
 			},
@@ -536,13 +541,55 @@ func TestConfig_setupAgentsWithEveryReadOnlyToolDisabled(t *testing.T) {
 	cfg.SetupAgents()
 	coderAgent, ok := cfg.Agents[AgentCoder]
 	require.True(t, ok)
-	assert.Equal(t, []string{"agent", "bash", "job_output", "job_kill", "download", "edit", "multiedit", "lsp_diagnostics", "lsp_references", "lsp_restart", "fetch", "agentic_fetch", "todos", "write", "list_mcp_resources", "read_mcp_resource"}, coderAgent.AllowedTools)
+	assert.Equal(t, []string{"agent", "bash", "job_output", "job_kill", "download", "edit", "multiedit", "lsp_diagnostics", "lsp_references", "lsp_symbols", "lsp_rename", "lsp_code_action", "lsp_restart", "fetch", "agentic_fetch", "issue_fetch", "git_commit", "run_tests", "todos", "write", "list_mcp_resources", "read_mcp_resource"}, coderAgent.AllowedTools)
 
 	taskAgent, ok := cfg.Agents[AgentTask]
 	require.True(t, ok)
 	assert.Len(t, taskAgent.AllowedTools, 0)
 }
 
+// TestConfig_setupAgentsWithCustomAgents 测试设置配置中定义的自定义代理
+func TestConfig_setupAgentsWithCustomAgents(t *testing.T) {
+	cfg := &Config{
+		Options: &Options{
+			DisabledTools: []string{"bash"},
+		},
+		CustomAgents: map[string]CustomAgent{
+			"reviewer": {
+				Description:  "Reviews diffs",
+				SystemPrompt: "You review code.",
+			},
+			"tester": {
+				SystemPrompt: "You write tests.",
+				Model:        SelectedModelTypeSmall,
+				AllowedTools: []string{"agent", "bash", "view", "write"},
+			},
+			"disabled": {SystemPrompt: "x", Disabled: true},
+			"bad name": {SystemPrompt: "x"},
+			AgentCoder: {SystemPrompt: "x"},
+		},
+	}
+
+	cfg.SetupAgents()
+	assert.Equal(t, []string{"reviewer", "tester"}, cfg.CustomAgentNames())
+
+	reviewer, ok := cfg.Agents["reviewer"]
+	require.True(t, ok)
+	assert.Equal(t, cfg.Agents[AgentTask].AllowedTools, reviewer.AllowedTools)
+	assert.Equal(t, SelectedModelTypeLarge, reviewer.Model)
+	assert.Equal(t, "Reviews diffs", reviewer.Description)
+	assert.Equal(t, "You review code.", reviewer.SystemPrompt)
+	assert.Empty(t, reviewer.AllowedMCP)
+	assert.NotNil(t, reviewer.AllowedMCP)
+
+	tester, ok := cfg.Agents["tester"]
+	require.True(t, ok)
+	assert.Equal(t, []string{"view", "write"}, tester.AllowedTools)
+	assert.Equal(t, SelectedModelTypeSmall, tester.Model)
+
+	assert.Equal(t, "Coder", cfg.Agents[AgentCoder].Name)
+}
+
 // TestConfig_configureProvidersWithDisabledProvider 测试配置禁用的提供商
 func TestConfig_configureProvidersWithDisabledProvider(t *testing.T) {
 	knownProviders := []catwalk.Provider{
@@ -1492,3 +1539,118 @@ func TestConfig_configureSelectedModels(t *testing.T) {
 		require.Equal(t, int64(100), large.MaxTokens)
 	})
 }
+
+func TestRemoteValidate(t *testing.T) {
+	t.Parallel()
+
+	var remote *Remote
+	require.False(t, remote.Enabled())
+	require.NoError(t, remote.validate())
+
+	remote = &Remote{Host: "devbox", Path: "/home/dev/project"}
+	require.True(t, remote.Enabled())
+	require.NoError(t, remote.validate())
+
+	require.ErrorContains(t, (&Remote{Path: "/srv"}).validate(), "remote.host")
+	require.ErrorContains(t, (&Remote{Host: "devbox", Path: "project"}).validate(), "remote.path")
+	require.ErrorContains(t, (&Remote{Host: "devbox", Path: "/srv", Port: 70000}).validate(), "remote.port")
+}
+
+func TestSandboxValidate(t *testing.T) {
+	t.Parallel()
+
+	var sb *Sandbox
+	require.False(t, sb.Enabled())
+	require.NoError(t, sb.validate())
+
+	sb = &Sandbox{Engine: "docker", Image: "golang:1.25"}
+	require.True(t, sb.Enabled())
+	require.NoError(t, sb.validate())
+
+	require.ErrorContains(t, (&Sandbox{Engine: "chroot"}).validate(), "sandbox.engine")
+	require.ErrorContains(t, (&Sandbox{Engine: "podman"}).validate(), "sandbox.image")
+}
+
+func TestPromptCacheTTL(t *testing.T) {
+	t.Parallel()
+
+	var pc *PromptCache
+	ttl, err := pc.CacheTTL()
+	require.NoError(t, err)
+	require.Zero(t, ttl)
+
+	ttl, err = (&PromptCache{TTL: "30m"}).CacheTTL()
+	require.NoError(t, err)
+	require.Equal(t, 30*time.Minute, ttl)
+
+	_, err = (&PromptCache{TTL: "soon"}).CacheTTL()
+	require.ErrorContains(t, err, "prompt_cache.ttl")
+	_, err = (&PromptCache{TTL: "10s"}).CacheTTL()
+	require.ErrorContains(t, err, "1 分钟")
+}
+
+func TestPermissionsWindow(t *testing.T) {
+	t.Parallel()
+
+	zero, thirty := 0, 30
+	var p *Permissions
+	d, uses := p.Window()
+	require.Equal(t, 10*time.Minute, d)
+	require.Zero(t, uses)
+
+	d, uses = (&Permissions{WindowMinutes: &zero, WindowUses: 5}).Window()
+	require.Zero(t, d)
+	require.Equal(t, 5, uses)
+
+	d, uses = (&Permissions{WindowMinutes: &thirty, WindowUses: 3}).Window()
+	require.Equal(t, 30*time.Minute, d)
+	require.Equal(t, 3, uses)
+
+	// 时间和次数都不限制时使用默认值
+	d, _ = (&Permissions{WindowMinutes: &zero}).Window()
+	require.Equal(t, 10*time.Minute, d)
+}
+
+func TestToolRepairAttempts(t *testing.T) {
+	t.Parallel()
+
+	zero, ten := 0, 10
+	var r *ToolRepair
+	require.Equal(t, 2, r.Attempts())
+	require.False(t, r.UseSmallModel())
+	require.Zero(t, (&ToolRepair{MaxAttempts: &zero}).Attempts())
+	require.Equal(t, 5, (&ToolRepair{MaxAttempts: &ten}).Attempts())
+	require.True(t, (&ToolRepair{Model: SelectedModelTypeSmall}).UseSmallModel())
+}
+
+func TestUserAuthor(t *testing.T) {
+	t.Parallel()
+
+	var user *User
+	require.Empty(t, user.Author())
+	require.Equal(t, "Ada Lovelace <ada@example.com>", (&User{Name: " Ada Lovelace ", Email: "ada@example.com"}).Author())
+	require.Equal(t, "Ada Lovelace", (&User{Name: "Ada Lovelace"}).Author())
+	require.Equal(t, "<ada@example.com>", (&User{Email: "ada@example.com"}).Author())
+}
+
+func TestCompletionsCustomEntries(t *testing.T) {
+	t.Parallel()
+
+	global := []byte(`{"options": {"tui": {"completions": {"entries": [
+		{"name": "review", "kind": "prompt", "text": "Review the staged changes"},
+		{"name": "style", "text": "Use tabs"},
+		{"name": "empty"}
+	]}}}}`)
+	project := []byte(`{"options": {"tui": {"completions": {"entries": [
+		{"name": "docs", "kind": "url", "text": "https://go.dev/doc"},
+		{"name": "review", "kind": "prompt", "text": "Review against the project checklist"}
+	]}}}}`)
+
+	cfg, err := loadFromBytes([][]byte{global, project})
+	require.NoError(t, err)
+	require.Equal(t, []CompletionEntry{
+		{Name: "review", Kind: CompletionKindPrompt, Text: "Review against the project checklist"},
+		{Name: "style", Kind: CompletionKindSnippet, Text: "Use tabs"},
+		{Name: "docs", Kind: CompletionKindURL, Text: "https://go.dev/doc"},
+	}, cfg.Options.TUI.Completions.CustomEntries())
+}
@@ -0,0 +1,175 @@
+package config
+
+import (
+	"encoding/json"
+	"log/slog"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/purpose168/crush-cn/internal/csync"
+)
+
+// DirectoryConfig 是子目录中的 crush.json 或 .crush.json 可以覆盖的配置。智能体操作
+// 子目录中的文件时，子目录及其上级目录（直到工作目录，不含工作目录）中的覆盖配置依次
+// 叠加在根配置之上：
+//
+//   - 目录越深优先级越高；同一目录中 crush.json 优先于 .crush.json，与根配置相同。
+//   - options.disabled_tools 由最深的设置了该字段的覆盖配置决定，空列表表示重新启用所有工具。
+//     根配置中禁用的工具已从智能体中移除，不能在子目录中重新启用。
+//   - lsp.<name>.disabled 由最深的设置了该字段的覆盖配置决定。
+//   - lsp.<name>.options 按键逐层合并到根配置的选项上，深层的值覆盖浅层的值。
+//
+// 覆盖配置中的其他字段会被忽略。
+type DirectoryConfig struct {
+	LSP     map[string]DirectoryLSP `json:"lsp,omitempty"`
+	Options DirectoryOptions        `json:"options,omitzero"`
+
+	// Files 是按优先级从低到高排列的已应用覆盖配置文件
+	Files []string `json:"-"`
+	// DisabledToolsFile 是决定 options.disabled_tools 的覆盖配置文件
+	DisabledToolsFile string `json:"-"`
+}
+
+// DirectoryLSP 是子目录中可以覆盖的 LSP 配置。
+type DirectoryLSP struct {
+	Disabled *bool          `json:"disabled,omitempty"`
+	Options  map[string]any `json:"options,omitempty"`
+}
+
+// DirectoryOptions 是子目录中可以覆盖的选项。
+type DirectoryOptions struct {
+	DisabledTools []string `json:"disabled_tools"`
+}
+
+// overlayFile 是缓存的覆盖配置文件内容，文件修改后重新读取。
+type overlayFile struct {
+	modTime time.Time
+	size    int64
+	cfg     *DirectoryConfig
+}
+
+// overlayCache 按文件路径缓存解析后的覆盖配置，不存在的文件记录为 nil 配置。
+var overlayCache = csync.NewMap[string, overlayFile]()
+
+// ToolDisabled 返回覆盖配置是否禁用了指定的工具。
+func (d DirectoryConfig) ToolDisabled(name string) bool {
+	return slices.Contains(d.Options.DisabledTools, name)
+}
+
+// LSPDisabled 返回覆盖配置是否禁用了指定的 LSP。
+func (d DirectoryConfig) LSPDisabled(name string) bool {
+	lsp, ok := d.LSP[name]
+	return ok && lsp.Disabled != nil && *lsp.Disabled
+}
+
+// DirectoryConfig 返回 path 所在目录的覆盖配置。path 可以是文件或目录，相对路径相对于
+// 工作目录解析。工作目录之外的路径和远程开发时没有覆盖配置。
+func (c *Config) DirectoryConfig(path string) DirectoryConfig {
+	var result DirectoryConfig
+	if c.workingDir == "" || c.Remote.Enabled() {
+		return result
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(c.workingDir, path)
+	}
+	dir := filepath.Clean(path)
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		dir = filepath.Dir(dir)
+	}
+	rel, err := filepath.Rel(c.workingDir, dir)
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return result
+	}
+
+	// 从工作目录的下一级开始向下叠加，深层目录的配置后应用
+	current := c.workingDir
+	for part := range strings.SplitSeq(rel, string(filepath.Separator)) {
+		current = filepath.Join(current, part)
+		for _, name := range []string{"." + appName + ".json", appName + ".json"} {
+			file := filepath.Join(current, name)
+			if overlay := loadOverlay(file); overlay != nil {
+				result.apply(overlay)
+				result.Files = append(result.Files, file)
+				if overlay.Options.DisabledTools != nil {
+					result.DisabledToolsFile = file
+				}
+			}
+		}
+	}
+	for name, lsp := range result.LSP {
+		if base, ok := c.LSP[name]; ok && len(lsp.Options) > 0 {
+			lsp.Options = mergeOptions(base.Options, lsp.Options)
+			result.LSP[name] = lsp
+		}
+	}
+	return result
+}
+
+// apply 将优先级更高的覆盖配置叠加到 d 上。
+func (d *DirectoryConfig) apply(overlay *DirectoryConfig) {
+	if overlay.Options.DisabledTools != nil {
+		d.Options.DisabledTools = slices.Clone(overlay.Options.DisabledTools)
+	}
+	for name, lsp := range overlay.LSP {
+		if d.LSP == nil {
+			d.LSP = make(map[string]DirectoryLSP)
+		}
+		current := d.LSP[name]
+		if lsp.Disabled != nil {
+			current.Disabled = lsp.Disabled
+		}
+		if len(lsp.Options) > 0 {
+			current.Options = mergeOptions(current.Options, lsp.Options)
+		}
+		d.LSP[name] = current
+	}
+}
+
+// loadOverlay 读取覆盖配置文件，文件不存在或无法解析时返回 nil。
+func loadOverlay(file string) *DirectoryConfig {
+	info, err := os.Stat(file)
+	if err != nil || info.IsDir() {
+		return nil
+	}
+	if cached, ok := overlayCache.Get(file); ok && cached.modTime.Equal(info.ModTime()) && cached.size == info.Size() {
+		return cached.cfg
+	}
+
+	entry := overlayFile{modTime: info.ModTime(), size: info.Size()}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		slog.Warn("读取子目录配置失败", "file", file, "error", err)
+		return nil
+	}
+	var overlay DirectoryConfig
+	if err := json.Unmarshal(data, &overlay); err != nil {
+		slog.Warn("解析子目录配置失败，已忽略", "file", file, "error", err)
+	} else {
+		slog.Debug("已加载子目录配置", "file", file)
+		entry.cfg = &overlay
+	}
+	overlayCache.Set(file, entry)
+	return entry.cfg
+}
+
+// mergeOptions 返回将 overlay 逐键合并到 base 上的新映射，嵌套映射递归合并。
+func mergeOptions(base, overlay map[string]any) map[string]any {
+	result := maps.Clone(base)
+	if result == nil {
+		result = make(map[string]any, len(overlay))
+	}
+	for key, value := range overlay {
+		baseMap, baseOK := result[key].(map[string]any)
+		overlayMap, overlayOK := value.(map[string]any)
+		if baseOK && overlayOK {
+			result[key] = mergeOptions(baseMap, overlayMap)
+			continue
+		}
+		result[key] = value
+	}
+	return result
+}
@@ -0,0 +1,53 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDirectoryConfig(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	frontend := filepath.Join(dir, "frontend")
+	legacy := filepath.Join(frontend, "legacy")
+	require.NoError(t, os.MkdirAll(legacy, 0o755))
+	writeFile := func(path, content string) {
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+	writeFile(filepath.Join(frontend, ".crush.json"), `{"options": {"disabled_tools": ["write"]}}`)
+	writeFile(filepath.Join(frontend, "crush.json"), `{
+		"options": {"disabled_tools": ["bash", "edit"]},
+		"lsp": {"gopls": {"disabled": true}, "typescript": {"options": {"format": {"indent": 4}}}}
+	}`)
+	writeFile(filepath.Join(legacy, "crush.json"), `{
+		"options": {"disabled_tools": []},
+		"lsp": {"gopls": {"disabled": false}, "typescript": {"options": {"format": {"quotes": "single"}}}}
+	}`)
+
+	cfg := &Config{LSP: LSPs{"typescript": {Options: map[string]any{"format": map[string]any{"indent": 2, "semicolons": true}}}}}
+	cfg.setDefaults(dir, "")
+
+	root := cfg.DirectoryConfig(filepath.Join(dir, "main.go"))
+	require.Empty(t, root.Files)
+	require.False(t, root.ToolDisabled("bash"))
+
+	front := cfg.DirectoryConfig("frontend/app.ts")
+	require.Equal(t, []string{filepath.Join(frontend, ".crush.json"), filepath.Join(frontend, "crush.json")}, front.Files)
+	require.True(t, front.ToolDisabled("bash"))
+	require.Equal(t, filepath.Join(frontend, "crush.json"), front.DisabledToolsFile)
+	require.False(t, front.ToolDisabled("write"), "crush.json takes precedence over .crush.json")
+	require.True(t, front.LSPDisabled("gopls"))
+	require.Equal(t, map[string]any{"format": map[string]any{"indent": float64(4), "semicolons": true}}, front.LSP["typescript"].Options)
+
+	old := cfg.DirectoryConfig(legacy)
+	require.Len(t, old.Files, 3)
+	require.False(t, old.ToolDisabled("bash"), "an empty list re-enables tools")
+	require.False(t, old.LSPDisabled("gopls"))
+	require.Equal(t, map[string]any{"format": map[string]any{"indent": float64(4), "semicolons": true, "quotes": "single"}}, old.LSP["typescript"].Options)
+
+	require.Empty(t, cfg.DirectoryConfig(filepath.Dir(dir)).Files)
+}
@@ -0,0 +1,27 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAllowTool(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	cfg := &Config{}
+	cfg.setDefaults(dir, "")
+	cfg.dataConfigDir = filepath.Join(dir, "config.json")
+	require.NoError(t, os.WriteFile(cfg.dataConfigDir, []byte(`{"permissions":{"allowed_tools":["view"]}}`), 0o600))
+
+	require.NoError(t, cfg.AllowTool("mcp_github_list_issues"))
+	require.NoError(t, cfg.AllowTool("mcp_github_list_issues"))
+
+	require.Equal(t, []string{"mcp_github_list_issues"}, cfg.Permissions.AllowedTools)
+	perms, ok := readConfigJSON(t, cfg.dataConfigDir)["permissions"].(map[string]any)
+	require.True(t, ok)
+	require.Equal(t, []any{"view", "mcp_github_list_issues"}, perms["allowed_tools"])
+}
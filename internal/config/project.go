@@ -0,0 +1,57 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// ProjectConfigPath 返回工作目录中的项目配置文件路径。已存在的 crush.json 或
+// .crush.json 优先，否则返回 .crush.json。
+func (c *Config) ProjectConfigPath() string {
+	for _, name := range []string{appName + ".json", "." + appName + ".json"} {
+		path := filepath.Join(c.WorkingDir(), name)
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	return filepath.Join(c.WorkingDir(), "."+appName+".json")
+}
+
+// SetProjectConfigField 在项目配置文件中设置指定字段，文件不存在时会创建。
+func (c *Config) SetProjectConfigField(key string, value any) error {
+	path := c.ProjectConfigPath()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			return fmt.Errorf("读取项目配置文件失败: %w", err)
+		}
+		data = []byte("{}")
+	}
+
+	newValue, err := sjson.Set(string(data), key, value)
+	if err != nil {
+		return fmt.Errorf("设置项目配置字段 %s 失败: %w", key, err)
+	}
+	if err := os.WriteFile(path, []byte(newValue), 0o644); err != nil {
+		return fmt.Errorf("写入项目配置文件失败: %w", err)
+	}
+	return nil
+}
+
+// AddProjectConfigValue 将值追加到项目配置文件中的数组字段，值已存在时不做修改。
+func (c *Config) AddProjectConfigValue(key, value string) error {
+	data, err := os.ReadFile(c.ProjectConfigPath())
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("读取项目配置文件失败: %w", err)
+	}
+	for _, v := range gjson.GetBytes(data, key).Array() {
+		if v.String() == value {
+			return nil
+		}
+	}
+	return c.SetProjectConfigField(key+".-1", value)
+}
@@ -0,0 +1,53 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestProjectConfigPath(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	cfg := &Config{}
+	cfg.setDefaults(dir, "")
+	require.Equal(t, filepath.Join(dir, ".crush.json"), cfg.ProjectConfigPath())
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "crush.json"), []byte("{}"), 0o644))
+	require.Equal(t, filepath.Join(dir, "crush.json"), cfg.ProjectConfigPath())
+}
+
+func TestAddProjectConfigValue(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	cfg := &Config{}
+	cfg.setDefaults(dir, "")
+
+	require.NoError(t, cfg.AddProjectConfigValue("options.disabled_tools", "sourcegraph"))
+	require.NoError(t, cfg.AddProjectConfigValue("options.disabled_tools", "sourcegraph"))
+	require.NoError(t, cfg.AddProjectConfigValue("options.disabled_tools", "fetch"))
+	require.NoError(t, cfg.SetProjectConfigField("lsp.gopls", map[string]any{"command": "gopls"}))
+
+	data := readConfigJSON(t, filepath.Join(dir, ".crush.json"))
+	options, ok := data["options"].(map[string]any)
+	require.True(t, ok)
+	require.Equal(t, []any{"sourcegraph", "fetch"}, options["disabled_tools"])
+	require.Equal(t, map[string]any{"gopls": map[string]any{"command": "gopls"}}, data["lsp"])
+}
+
+func TestWorkspaceRoots(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	cfg := &Config{}
+	cfg.setDefaults(dir, "")
+	require.Equal(t, []string{dir}, cfg.WorkspaceRoots())
+
+	shared := filepath.Join(filepath.Dir(dir), "shared")
+	cfg.Options.WorkspaceRoots = []string{"../shared", shared, ".", "/srv/data/"}
+	require.Equal(t, []string{dir, shared, "/srv/data"}, cfg.WorkspaceRoots())
+}
@@ -151,6 +151,16 @@ func UpdateHyper(pathOrURL string) error {
 	return nil
 }
 
+// cachedFetchTimeout 是已有缓存可回退时等待远程提供者元数据的最长时间，
+// 避免网络不可达时长时间阻塞启动
+const cachedFetchTimeout = 5 * time.Second
+
+// cachePolicy 控制同步器何时使用缓存而不访问网络
+type cachePolicy struct {
+	offline bool          // 离线模式：从不访问网络，只使用缓存或内置的定义
+	ttl     time.Duration // 缓存在 ttl 内写入时直接使用，不检查更新
+}
+
 // 全局同步器实例
 var (
 	catwalkSyncer = &catwalkSync{} // Catwalk 提供者同步器
@@ -171,6 +181,7 @@ func Providers(cfg *Config) ([]catwalk.Provider, error) {
 		var errs []error
 		providers := csync.NewSlice[catwalk.Provider]()      // 使用并发安全的切片收集提供者
 		autoupdate := !cfg.Options.DisableProviderAutoUpdate // 自动更新标志
+		policy := cachePolicy{offline: cfg.Options.Offline, ttl: cfg.Options.ProviderCacheDuration()}
 
 		// 设置 45 秒超时的上下文
 		ctx, cancel := context.WithTimeout(context.Background(), 45*time.Second)
@@ -182,11 +193,12 @@ func Providers(cfg *Config) ([]catwalk.Provider, error) {
 			client := catwalk.NewWithURL(catwalkURL)
 			path := cachePathFor("providers")
 			catwalkSyncer.Init(client, path, autoupdate)
+			catwalkSyncer.policy = policy
 
 			items, err := catwalkSyncer.Get(ctx)
 			if err != nil {
 				catwalkURL := fmt.Sprintf("%s/v2/providers", cmp.Or(os.Getenv("CATWALK_URL"), defaultCatwalkURL))
-				errs = append(errs, fmt.Errorf("Crush 无法从 %s 获取更新的提供者列表。考虑设置 CRUSH_DISABLE_PROVIDER_AUTO_UPDATE=1 以使用此 Crush 版本发布时捆绑的嵌入提供者。您也可以手动更新提供者。更多信息请参见 crush update-providers --help。\n\n原因: %w", catwalkURL, providerErr)) //nolint:staticcheck
+				errs = append(errs, fmt.Errorf("Crush 无法从 %s 获取更新的提供者列表。考虑使用 --offline 标志只使用缓存的提供者，或设置 CRUSH_DISABLE_PROVIDER_AUTO_UPDATE=1 以使用此 Crush 版本发布时捆绑的嵌入提供者。您也可以手动更新提供者。更多信息请参见 crush update-providers --help。\n\n原因: %w", catwalkURL, providerErr)) //nolint:staticcheck
 				return
 			}
 			providers.Append(items...)
@@ -199,6 +211,7 @@ func Providers(cfg *Config) ([]catwalk.Provider, error) {
 			}
 			path := cachePathFor("hyper")
 			hyperSyncer.Init(realHyperClient{baseURL: hyper.BaseURL()}, path, autoupdate)
+			hyperSyncer.policy = policy
 
 			item, err := hyperSyncer.Get(ctx)
 			if err != nil {
@@ -247,6 +260,15 @@ func (c cache[T]) Get() (T, string, error) {
 	return v, etag.Of(data), nil
 }
 
+// Fresh 报告缓存文件是否在 ttl 内写入，ttl 为 0 或缓存文件不存在时返回 false
+func (c cache[T]) Fresh(ttl time.Duration) bool {
+	if ttl <= 0 {
+		return false
+	}
+	info, err := os.Stat(c.path)
+	return err == nil && time.Since(info.ModTime()) < ttl
+}
+
 // Store 将数据保存到缓存文件
 func (c cache[T]) Store(v T) error {
 	slog.Info("将提供者数据保存到磁盘", "path", c.path)
@@ -0,0 +1,549 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+
+	"charm.land/catwalk/pkg/embedded"
+	"github.com/invopop/jsonschema"
+	"github.com/purpose168/crush-cn/internal/agent/hyper"
+)
+
+// IssueSeverity 表示配置问题的严重程度。
+type IssueSeverity int
+
+const (
+	// IssueError 表示配置项会被忽略或导致加载失败。
+	IssueError IssueSeverity = iota
+	// IssueWarning 表示配置可以使用，但可能与预期不符。
+	IssueWarning
+)
+
+// String 返回严重程度的可读名称。
+func (s IssueSeverity) String() string {
+	if s == IssueWarning {
+		return "警告"
+	}
+	return "错误"
+}
+
+// ValidationIssue 描述配置文件中的一个问题。
+type ValidationIssue struct {
+	File     string
+	Line     int
+	Column   int
+	Path     string // 以点分隔的配置路径，例如 options.tui.diff_mode
+	Message  string
+	Severity IssueSeverity
+}
+
+// String 以 "文件:行:列: 严重程度: 路径: 信息" 的格式返回问题描述。
+func (i ValidationIssue) String() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%s:%d:%d: %s: ", i.File, i.Line, i.Column, i.Severity)
+	if i.Path != "" {
+		sb.WriteString(i.Path)
+		sb.WriteString(": ")
+	}
+	sb.WriteString(i.Message)
+	return sb.String()
+}
+
+// HasValidationErrors 报告 issues 中是否包含错误级别的问题。
+func HasValidationErrors(issues []ValidationIssue) bool {
+	return slices.ContainsFunc(issues, func(i ValidationIssue) bool {
+		return i.Severity == IssueError
+	})
+}
+
+// formatValidationIssues 将问题列表格式化为每行一个问题的文本。
+func formatValidationIssues(issues []ValidationIssue) string {
+	lines := make([]string, len(issues))
+	for i, issue := range issues {
+		lines[i] = "  " + issue.String()
+	}
+	return strings.Join(lines, "\n")
+}
+
+// ConfigPaths 返回在 workingDir 中启动时会加载的配置文件路径，按优先级从低到高排列。
+func ConfigPaths(workingDir string) []string {
+	return lookupConfigs(workingDir)
+}
+
+// ValidateConfigFiles 校验给定的配置文件。不存在或为空的文件会被跳过。
+func ValidateConfigFiles(paths ...string) ([]ValidationIssue, error) {
+	var issues []ValidationIssue
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, fmt.Errorf("读取配置文件 %s 失败: %w", path, err)
+		}
+		if len(bytes.TrimSpace(data)) == 0 {
+			continue
+		}
+		issues = append(issues, validateConfigBytes(path, data)...)
+	}
+	return issues, nil
+}
+
+// configSchema 返回从 [Config] 反射生成的 JSON schema，与 `crush schema` 的输出一致。
+var configSchema = sync.OnceValue(func() *jsonschema.Schema {
+	return new(jsonschema.Reflector).Reflect(&Config{})
+})
+
+// validateConfigBytes 校验单个配置文件的内容。
+func validateConfigBytes(file string, data []byte) []ValidationIssue {
+	v := &validator{file: file, data: data, root: configSchema()}
+
+	root, err := parseJSONNode(data)
+	if err != nil {
+		var offset int64
+		var syntaxErr *json.SyntaxError
+		var typeErr *json.UnmarshalTypeError
+		switch {
+		case errors.As(err, &syntaxErr):
+			offset = syntaxErr.Offset
+		case errors.As(err, &typeErr):
+			offset = typeErr.Offset
+		case errors.Is(err, io.ErrUnexpectedEOF), errors.Is(err, io.EOF):
+			offset = int64(len(data))
+			err = errors.New("文件意外结束")
+		}
+		v.add(offset, "", IssueError, "JSON 语法错误：%v", err)
+		return v.issues
+	}
+
+	v.validate(root, v.root, "")
+	if root.kind == '{' {
+		v.checkProviders(root)
+		v.checkSelectedModels(root)
+	}
+	return v.issues
+}
+
+// jsonNode 是带有源文件位置的 JSON 值。
+type jsonNode struct {
+	offset int64
+	// kind 是 '{'、'['、'"'（字符串）、'0'（数字）、'b'（布尔）或 'n'（null）
+	kind   byte
+	value  any
+	fields []jsonField
+	items  []*jsonNode
+}
+
+// jsonField 是对象中的一个键值对。
+type jsonField struct {
+	key    string
+	offset int64
+	value  *jsonNode
+}
+
+// field 返回对象中指定键的值，不存在时返回 nil。
+func (n *jsonNode) field(key string) *jsonNode {
+	for _, f := range n.fields {
+		if f.key == key {
+			return f.value
+		}
+	}
+	return nil
+}
+
+// str 返回字符串节点的值，非字符串节点返回空字符串。
+func (n *jsonNode) str() string {
+	if n == nil {
+		return ""
+	}
+	s, _ := n.value.(string)
+	return s
+}
+
+// kindName 返回节点类型的可读名称。
+func (n *jsonNode) kindName() string {
+	switch n.kind {
+	case '{':
+		return "object"
+	case '[':
+		return "array"
+	case '"':
+		return "string"
+	case '0':
+		return "number"
+	case 'b':
+		return "boolean"
+	default:
+		return "null"
+	}
+}
+
+// parseJSONNode 解析整个文档，并记录每个键和值的起始位置。
+func parseJSONNode(data []byte) (*jsonNode, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	node, err := parseNextNode(dec, data)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, &json.SyntaxError{Offset: skipJSONSpace(data, dec.InputOffset())}
+	}
+	return node, nil
+}
+
+func parseNextNode(dec *json.Decoder, data []byte) (*jsonNode, error) {
+	node := &jsonNode{offset: skipJSONSpace(data, dec.InputOffset())}
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch t := tok.(type) {
+	case json.Delim:
+		node.kind = byte(t)
+		for dec.More() {
+			if t == '{' {
+				keyOffset := skipJSONSpace(data, dec.InputOffset())
+				keyTok, err := dec.Token()
+				if err != nil {
+					return nil, err
+				}
+				key, _ := keyTok.(string)
+				value, err := parseNextNode(dec, data)
+				if err != nil {
+					return nil, err
+				}
+				node.fields = append(node.fields, jsonField{key: key, offset: keyOffset, value: value})
+				continue
+			}
+			item, err := parseNextNode(dec, data)
+			if err != nil {
+				return nil, err
+			}
+			node.items = append(node.items, item)
+		}
+		// 读取结束分隔符。
+		if _, err := dec.Token(); err != nil {
+			return nil, err
+		}
+	case string:
+		node.kind, node.value = '"', t
+	case json.Number:
+		node.kind, node.value = '0', t
+	case bool:
+		node.kind, node.value = 'b', t
+	default:
+		node.kind = 'n'
+	}
+	return node, nil
+}
+
+// skipJSONSpace 从 offset 开始跳过空白和分隔符，返回下一个记号的位置。
+func skipJSONSpace(data []byte, offset int64) int64 {
+	for offset < int64(len(data)) {
+		switch data[offset] {
+		case ' ', '\t', '\r', '\n', ',', ':':
+			offset++
+		default:
+			return offset
+		}
+	}
+	return offset
+}
+
+// validator 根据 JSON schema 校验解析后的配置文件。
+type validator struct {
+	file   string
+	data   []byte
+	root   *jsonschema.Schema
+	issues []ValidationIssue
+}
+
+// add 在 offset 处记录一个问题。
+func (v *validator) add(offset int64, path string, severity IssueSeverity, format string, args ...any) {
+	line, col := lineColumn(v.data, offset)
+	v.issues = append(v.issues, ValidationIssue{
+		File:     v.file,
+		Line:     line,
+		Column:   col,
+		Path:     path,
+		Message:  fmt.Sprintf(format, args...),
+		Severity: severity,
+	})
+}
+
+// resolve 解析 schema 中的 $ref 引用。
+func (v *validator) resolve(s *jsonschema.Schema) *jsonschema.Schema {
+	for s != nil && s.Ref != "" {
+		name, ok := strings.CutPrefix(s.Ref, "#/$defs/")
+		if !ok {
+			return nil
+		}
+		s = v.root.Definitions[name]
+	}
+	return s
+}
+
+// validate 递归校验 node 是否符合 schema。
+func (v *validator) validate(node *jsonNode, schema *jsonschema.Schema, path string) {
+	schema = v.resolve(schema)
+	if schema == nil || schema == jsonschema.TrueSchema {
+		return
+	}
+
+	if schema.Deprecated {
+		v.add(node.offset, path, IssueWarning, "该配置项已弃用：%s", schema.Description)
+	}
+
+	if alternatives := append(slices.Clone(schema.AnyOf), schema.OneOf...); len(alternatives) > 0 {
+		if !slices.ContainsFunc(alternatives, func(s *jsonschema.Schema) bool { return v.matches(node, s) }) {
+			v.add(node.offset, path, IssueError, "值不符合任何允许的格式")
+			return
+		}
+	}
+
+	if !typeMatches(node, schema.Type) {
+		v.add(node.offset, path, IssueError, "类型错误：应为 %s，实际为 %s", schema.Type, node.kindName())
+		return
+	}
+
+	if len(schema.Enum) > 0 && !enumContains(schema.Enum, node) {
+		allowed := make([]string, len(schema.Enum))
+		for i, e := range schema.Enum {
+			allowed[i] = fmt.Sprint(e)
+		}
+		v.add(node.offset, path, IssueError, "无效的值 %s，允许的值：%s", formatNodeValue(node), strings.Join(allowed, ", "))
+	}
+
+	switch node.kind {
+	case '{':
+		v.validateObject(node, schema, path)
+	case '[':
+		for i, item := range node.items {
+			v.validate(item, schema.Items, fmt.Sprintf("%s[%d]", path, i))
+		}
+	}
+}
+
+// validateObject 校验对象的每个字段，报告未知和重复的键。
+func (v *validator) validateObject(node *jsonNode, schema *jsonschema.Schema, path string) {
+	seen := make(map[string]bool, len(node.fields))
+	for _, f := range node.fields {
+		fieldPath := joinConfigPath(path, f.key)
+		if seen[f.key] {
+			v.add(f.offset, fieldPath, IssueWarning, "重复的键，只有最后一个值生效")
+		}
+		seen[f.key] = true
+
+		if schema.Properties != nil {
+			if prop, ok := schema.Properties.Get(f.key); ok {
+				v.validate(f.value, prop, fieldPath)
+				continue
+			}
+		}
+		switch schema.AdditionalProperties {
+		case nil:
+		case jsonschema.FalseSchema:
+			msg := fmt.Sprintf("未知的配置项 %q，将被忽略", f.key)
+			if suggestion := closestKey(f.key, schema); suggestion != "" {
+				msg += fmt.Sprintf("，是否想写 %q？", suggestion)
+			}
+			v.add(f.offset, fieldPath, IssueError, "%s", msg)
+		default:
+			v.validate(f.value, schema.AdditionalProperties, fieldPath)
+		}
+	}
+}
+
+// matches 报告 node 是否符合 schema，不记录问题。
+func (v *validator) matches(node *jsonNode, schema *jsonschema.Schema) bool {
+	sub := &validator{file: v.file, data: v.data, root: v.root}
+	sub.validate(node, schema, "")
+	return !HasValidationErrors(sub.issues)
+}
+
+// checkProviders 检查 providers 中会在加载时被跳过的提供者配置。
+func (v *validator) checkProviders(root *jsonNode) {
+	providers := root.field("providers")
+	if providers == nil || providers.kind != '{' {
+		return
+	}
+	known := knownProviderIDs()
+	for _, f := range providers.fields {
+		p := f.value
+		path := joinConfigPath("providers", f.key)
+		if p.kind != '{' {
+			continue
+		}
+		if disable := p.field("disable"); disable != nil && disable.value == true {
+			continue
+		}
+
+		if models := p.field("models"); models != nil && models.kind == '[' {
+			ids := make(map[string]bool)
+			for i, m := range models.items {
+				if m.kind != '{' {
+					continue
+				}
+				modelPath := fmt.Sprintf("%s.models[%d]", path, i)
+				id := m.field("id").str()
+				if id == "" {
+					v.add(m.offset, modelPath, IssueError, "模型缺少 id")
+					continue
+				}
+				if ids[id] {
+					v.add(m.offset, modelPath, IssueWarning, "重复的模型 %q，只有第一个生效", id)
+				}
+				ids[id] = true
+			}
+		}
+
+		if known[f.key] {
+			continue
+		}
+		// 自定义提供者，类型由 schema 校验。
+		if p.field("base_url").str() == "" {
+			v.add(f.offset, path, IssueError, "自定义提供者必须设置 base_url，否则将被跳过")
+		}
+		if models := p.field("models"); models == nil || len(models.items) == 0 {
+			v.add(f.offset, path, IssueError, "自定义提供者至少需要一个模型，否则将被跳过")
+		}
+	}
+}
+
+// checkSelectedModels 检查 models 中引用的提供者是否存在。
+func (v *validator) checkSelectedModels(root *jsonNode) {
+	models := root.field("models")
+	if models == nil || models.kind != '{' {
+		return
+	}
+	known := knownProviderIDs()
+	if providers := root.field("providers"); providers != nil {
+		for _, f := range providers.fields {
+			known[f.key] = true
+		}
+	}
+	for _, f := range models.fields {
+		provider := f.value.field("provider")
+		if f.value.kind != '{' || provider == nil || provider.str() == "" {
+			continue
+		}
+		if !known[provider.str()] {
+			v.add(provider.offset, joinConfigPath("models", f.key)+".provider", IssueWarning,
+				"未知的提供者 %q，请确认它已在 providers 中配置", provider.str())
+		}
+	}
+}
+
+// knownProviderIDs 返回内置提供者的 ID 集合。
+func knownProviderIDs() map[string]bool {
+	known := map[string]bool{hyper.Name: true}
+	for _, p := range embedded.GetAll() {
+		known[string(p.ID)] = true
+	}
+	return known
+}
+
+// typeMatches 报告 node 是否为 schema 类型 typ。typ 为空时接受任何值。
+func typeMatches(node *jsonNode, typ string) bool {
+	switch typ {
+	case "":
+		return true
+	case "object":
+		return node.kind == '{'
+	case "array":
+		return node.kind == '['
+	case "string":
+		return node.kind == '"'
+	case "boolean":
+		return node.kind == 'b'
+	case "null":
+		return node.kind == 'n'
+	case "number":
+		return node.kind == '0'
+	case "integer":
+		if node.kind != '0' {
+			return false
+		}
+		_, err := node.value.(json.Number).Int64()
+		return err == nil
+	}
+	return true
+}
+
+// enumContains 报告 node 的值是否在 enum 中。
+func enumContains(enum []any, node *jsonNode) bool {
+	for _, e := range enum {
+		if fmt.Sprint(e) == fmt.Sprint(node.value) {
+			return true
+		}
+	}
+	return false
+}
+
+// formatNodeValue 返回节点值的简短表示。
+func formatNodeValue(node *jsonNode) string {
+	if node.kind == '"' {
+		return fmt.Sprintf("%q", node.value)
+	}
+	return fmt.Sprint(node.value)
+}
+
+// joinConfigPath 将键追加到以点分隔的配置路径。
+func joinConfigPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// closestKey 返回 schema 中与 key 最接近的属性名，差异过大时返回空字符串。
+func closestKey(key string, schema *jsonschema.Schema) string {
+	if schema.Properties == nil {
+		return ""
+	}
+	best, bestDist := "", len(key)/2+1
+	for pair := schema.Properties.Oldest(); pair != nil; pair = pair.Next() {
+		if d := editDistance(strings.ToLower(key), strings.ToLower(pair.Key)); d < bestDist {
+			best, bestDist = pair.Key, d
+		}
+	}
+	return best
+}
+
+// editDistance 计算两个字符串之间的 Levenshtein 距离。
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
+
+// lineColumn 将字节偏移量转换为从 1 开始的行号和列号（按字符计）。
+func lineColumn(data []byte, offset int64) (int, int) {
+	offset = min(max(0, offset), int64(len(data)))
+	before := data[:offset]
+	line := bytes.Count(before, []byte("\n")) + 1
+	lineStart := bytes.LastIndexByte(before, '\n') + 1
+	return line, len([]rune(string(before[lineStart:]))) + 1
+}
@@ -0,0 +1,133 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateConfigBytes(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		data     string
+		path     string
+		line     int
+		column   int
+		severity IssueSeverity
+		contains string
+	}{
+		{
+			name: "未知的键并给出建议",
+			data: `{
+  "options": {
+    "debugg": true
+  }
+}`,
+			path:     "options.debugg",
+			line:     3,
+			column:   5,
+			severity: IssueError,
+			contains: `"debug"`,
+		},
+		{
+			name:     "类型错误",
+			data:     `{"options": {"debug": "yes"}}`,
+			path:     "options.debug",
+			line:     1,
+			column:   23,
+			severity: IssueError,
+			contains: "boolean",
+		},
+		{
+			name:     "枚举值错误",
+			data:     `{"options": {"tui": {"diff_mode": "inline"}}}`,
+			path:     "options.tui.diff_mode",
+			line:     1,
+			column:   35,
+			severity: IssueError,
+			contains: "unified",
+		},
+		{
+			name: "语法错误",
+			data: `{
+  "options": {,
+}`,
+			line:     2,
+			column:   15,
+			severity: IssueError,
+			contains: "JSON 语法错误",
+		},
+		{
+			name:     "自定义提供商缺少 base_url",
+			data:     `{"providers": {"my-llm": {"type": "openai-compat", "models": [{"id": "m1"}]}}}`,
+			path:     "providers.my-llm",
+			line:     1,
+			column:   16,
+			severity: IssueError,
+			contains: "base_url",
+		},
+		{
+			name:     "重复的键",
+			data:     `{"options": {"debug": true, "debug": false}}`,
+			path:     "options.debug",
+			line:     1,
+			column:   29,
+			severity: IssueWarning,
+			contains: "重复",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			issues := validateConfigBytes("crush.json", []byte(tt.data))
+			require.Len(t, issues, 1, "%v", issues)
+			issue := issues[0]
+			require.Equal(t, tt.path, issue.Path)
+			require.Equal(t, tt.line, issue.Line)
+			require.Equal(t, tt.column, issue.Column)
+			require.Equal(t, tt.severity, issue.Severity)
+			require.Contains(t, issue.Message, tt.contains)
+		})
+	}
+}
+
+func TestValidateConfigBytes_Valid(t *testing.T) {
+	t.Parallel()
+
+	data := `{
+  "$schema": "https://charm.land/crush.json",
+  "models": {"large": {"model": "gpt-4o", "provider": "openai"}},
+  "providers": {
+    "openai": {"api_key": "$OPENAI_API_KEY"},
+    "local": {
+      "type": "openai-compat",
+      "base_url": "http://localhost:11434/v1",
+      "models": [{"id": "llama3", "name": "Llama 3", "context_window": 8192}]
+    }
+  },
+  "lsp": {"go": {"command": "gopls"}},
+  "options": {"debug": true, "tui": {"compact_mode": true}}
+}`
+	require.Empty(t, validateConfigBytes("crush.json", []byte(data)))
+}
+
+func TestValidateConfigFiles(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	good := filepath.Join(dir, "good.json")
+	bad := filepath.Join(dir, "bad.json")
+	require.NoError(t, os.WriteFile(good, []byte(`{"options": {"debug": true}}`), 0o644))
+	require.NoError(t, os.WriteFile(bad, []byte(`{"optoins": {}}`), 0o644))
+
+	issues, err := ValidateConfigFiles(good, bad, filepath.Join(dir, "missing.json"))
+	require.NoError(t, err)
+	require.Len(t, issues, 1)
+	require.Equal(t, bad, issues[0].File)
+	require.True(t, HasValidationErrors(issues))
+	require.Equal(t, bad+`:1:2: 错误: optoins: 未知的配置项 "optoins"，将被忽略，是否想写 "options"？`, issues[0].String())
+}
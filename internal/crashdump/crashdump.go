@@ -0,0 +1,66 @@
+// Package crashdump 在界面因 panic 退出时将状态快照（当前会话、排队的提示和未发送的输入）
+// 保存到数据目录，下次启动时读取快照以恢复未发送的输入。
+package crashdump
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// FileName 是数据目录中快照文件的名称。
+const FileName = "crash.json"
+
+// Dump 是界面崩溃时的状态快照。
+type Dump struct {
+	Time      time.Time `json:"time"`
+	Error     string    `json:"error,omitempty"`      // 程序退出时的错误
+	SessionID string    `json:"session_id,omitempty"` // 当前打开的会话
+	Queued    []string  `json:"queued,omitempty"`     // 当前会话中排队的提示
+	Draft     string    `json:"draft,omitempty"`      // 编辑器中未发送的文本
+}
+
+// Save 将快照写入数据目录，覆盖之前的快照。
+func Save(dataDir string, dump Dump) error {
+	data, err := json.MarshalIndent(dump, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化崩溃快照失败: %w", err)
+	}
+	if err := os.MkdirAll(dataDir, 0o700); err != nil {
+		return fmt.Errorf("创建数据目录失败: %w", err)
+	}
+	// 先写入临时文件再重命名，避免留下不完整的快照
+	path := filepath.Join(dataDir, FileName)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("写入崩溃快照失败: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("写入崩溃快照失败: %w", err)
+	}
+	return nil
+}
+
+// Take 读取并删除数据目录中的快照。没有快照时返回 false。
+func Take(dataDir string) (Dump, bool, error) {
+	path := filepath.Join(dataDir, FileName)
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return Dump{}, false, nil
+	}
+	if err != nil {
+		return Dump{}, false, fmt.Errorf("读取崩溃快照失败: %w", err)
+	}
+	// 无论能否解析都删除快照，避免每次启动都重复提示
+	if err := os.Remove(path); err != nil {
+		return Dump{}, false, fmt.Errorf("删除崩溃快照失败: %w", err)
+	}
+	var dump Dump
+	if err := json.Unmarshal(data, &dump); err != nil {
+		return Dump{}, false, fmt.Errorf("解析崩溃快照失败: %w", err)
+	}
+	return dump, true, nil
+}
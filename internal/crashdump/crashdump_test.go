@@ -0,0 +1,55 @@
+package crashdump
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSaveTake(t *testing.T) {
+	t.Parallel()
+
+	dir := filepath.Join(t.TempDir(), ".crush")
+	_, ok, err := Take(dir)
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	dump := Dump{
+		Time:      time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC),
+		Error:     "program experienced a panic",
+		SessionID: "session-1",
+		Queued:    []string{"run the tests"},
+		Draft:     "a long prompt\nwith several lines",
+	}
+	require.NoError(t, Save(dir, dump))
+
+	info, err := os.Stat(filepath.Join(dir, FileName))
+	require.NoError(t, err)
+	if os.PathSeparator == '/' {
+		require.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+	}
+
+	got, ok, err := Take(dir)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, dump, got)
+
+	// 快照只恢复一次
+	_, ok, err = Take(dir)
+	require.NoError(t, err)
+	require.False(t, ok)
+}
+
+func TestTakeInvalid(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, FileName), []byte("{"), 0o600))
+	_, ok, err := Take(dir)
+	require.Error(t, err)
+	require.False(t, ok)
+	require.NoFileExists(t, filepath.Join(dir, FileName))
+}
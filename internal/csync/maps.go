@@ -53,6 +53,17 @@ func (m *Map[K, V]) Set(key K, value V) {
 	m.inner[key] = value
 }
 
+// SetIfAbsent 仅在键不存在时设置值，返回是否设置成功。检查和设置在同一把锁下完成。
+func (m *Map[K, V]) SetIfAbsent(key K, value V) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.inner[key]; ok {
+		return false
+	}
+	m.inner[key] = value
+	return true
+}
+
 // Del 从映射中删除指定的键。
 func (m *Map[K, V]) Del(key K) {
 	m.mu.Lock()
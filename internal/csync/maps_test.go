@@ -117,6 +117,18 @@ func TestMap_GetOrSet(t *testing.T) {
 	require.Equal(t, 1, m.Len())
 }
 
+func TestMap_SetIfAbsent(t *testing.T) {
+	t.Parallel()
+
+	m := NewMap[string, int]()
+
+	require.True(t, m.SetIfAbsent("key1", 42))
+	require.False(t, m.SetIfAbsent("key1", 99999))
+	value, ok := m.Get("key1")
+	require.True(t, ok)
+	require.Equal(t, 42, value)
+}
+
 func TestMap_Get(t *testing.T) {
 	t.Parallel()
 
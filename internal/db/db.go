@@ -33,6 +33,9 @@ func New(db DBTX) *Queries {
 func Prepare(ctx context.Context, db DBTX) (*Queries, error) {
 	q := Queries{db: db}
 	var err error
+	if q.createCheckpointStmt, err = db.PrepareContext(ctx, createCheckpoint); err != nil {
+		return nil, fmt.Errorf("准备查询 CreateCheckpoint 时出错: %w", err)
+	}
 	if q.createFileStmt, err = db.PrepareContext(ctx, createFile); err != nil {
 		return nil, fmt.Errorf("准备查询 CreateFile 时出错: %w", err)
 	}
@@ -42,6 +45,12 @@ func Prepare(ctx context.Context, db DBTX) (*Queries, error) {
 	if q.createSessionStmt, err = db.PrepareContext(ctx, createSession); err != nil {
 		return nil, fmt.Errorf("准备查询 CreateSession 时出错: %w", err)
 	}
+	if q.createUsageRecordStmt, err = db.PrepareContext(ctx, createUsageRecord); err != nil {
+		return nil, fmt.Errorf("准备查询 CreateUsageRecord 时出错: %w", err)
+	}
+	if q.deleteCheckpointStmt, err = db.PrepareContext(ctx, deleteCheckpoint); err != nil {
+		return nil, fmt.Errorf("准备查询 DeleteCheckpoint 时出错: %w", err)
+	}
 	if q.deleteFileStmt, err = db.PrepareContext(ctx, deleteFile); err != nil {
 		return nil, fmt.Errorf("准备查询 DeleteFile 时出错: %w", err)
 	}
@@ -51,6 +60,12 @@ func Prepare(ctx context.Context, db DBTX) (*Queries, error) {
 	if q.deleteSessionStmt, err = db.PrepareContext(ctx, deleteSession); err != nil {
 		return nil, fmt.Errorf("准备查询 DeleteSession 时出错: %w", err)
 	}
+	if q.deleteSessionDescendantsStmt, err = db.PrepareContext(ctx, deleteSessionDescendants); err != nil {
+		return nil, fmt.Errorf("准备查询 DeleteSessionDescendants 时出错: %w", err)
+	}
+	if q.deleteSessionRunStmt, err = db.PrepareContext(ctx, deleteSessionRun); err != nil {
+		return nil, fmt.Errorf("准备查询 DeleteSessionRun 时出错: %w", err)
+	}
 	if q.deleteSessionFilesStmt, err = db.PrepareContext(ctx, deleteSessionFiles); err != nil {
 		return nil, fmt.Errorf("准备查询 DeleteSessionFiles 时出错: %w", err)
 	}
@@ -60,6 +75,12 @@ func Prepare(ctx context.Context, db DBTX) (*Queries, error) {
 	if q.getAverageResponseTimeStmt, err = db.PrepareContext(ctx, getAverageResponseTime); err != nil {
 		return nil, fmt.Errorf("准备查询 GetAverageResponseTime 时出错: %w", err)
 	}
+	if q.getCheckpointStmt, err = db.PrepareContext(ctx, getCheckpoint); err != nil {
+		return nil, fmt.Errorf("准备查询 GetCheckpoint 时出错: %w", err)
+	}
+	if q.getCostSinceStmt, err = db.PrepareContext(ctx, getCostSince); err != nil {
+		return nil, fmt.Errorf("准备查询 GetCostSince 时出错: %w", err)
+	}
 	if q.getFileStmt, err = db.PrepareContext(ctx, getFile); err != nil {
 		return nil, fmt.Errorf("准备查询 GetFile 时出错: %w", err)
 	}
@@ -75,6 +96,9 @@ func Prepare(ctx context.Context, db DBTX) (*Queries, error) {
 	if q.getMessageStmt, err = db.PrepareContext(ctx, getMessage); err != nil {
 		return nil, fmt.Errorf("准备查询 GetMessage 时出错: %w", err)
 	}
+	if q.getModelPerformanceStmt, err = db.PrepareContext(ctx, getModelPerformance); err != nil {
+		return nil, fmt.Errorf("准备查询 GetModelPerformance 时出错: %w", err)
+	}
 	if q.getRecentActivityStmt, err = db.PrepareContext(ctx, getRecentActivity); err != nil {
 		return nil, fmt.Errorf("准备查询 GetRecentActivity 时出错: %w", err)
 	}
@@ -102,6 +126,12 @@ func Prepare(ctx context.Context, db DBTX) (*Queries, error) {
 	if q.listAllUserMessagesStmt, err = db.PrepareContext(ctx, listAllUserMessages); err != nil {
 		return nil, fmt.Errorf("准备查询 ListAllUserMessages 时出错: %w", err)
 	}
+	if q.listArchivedSessionsStmt, err = db.PrepareContext(ctx, listArchivedSessions); err != nil {
+		return nil, fmt.Errorf("准备查询 ListArchivedSessions 时出错: %w", err)
+	}
+	if q.listCheckpointsBySessionStmt, err = db.PrepareContext(ctx, listCheckpointsBySession); err != nil {
+		return nil, fmt.Errorf("准备查询 ListCheckpointsBySession 时出错: %w", err)
+	}
 	if q.listFilesByPathStmt, err = db.PrepareContext(ctx, listFilesByPath); err != nil {
 		return nil, fmt.Errorf("准备查询 ListFilesByPath 时出错: %w", err)
 	}
@@ -120,6 +150,9 @@ func Prepare(ctx context.Context, db DBTX) (*Queries, error) {
 	if q.listSessionReadFilesStmt, err = db.PrepareContext(ctx, listSessionReadFiles); err != nil {
 		return nil, fmt.Errorf("准备查询 ListSessionReadFiles 时出错: %w", err)
 	}
+	if q.listSessionRunsStmt, err = db.PrepareContext(ctx, listSessionRuns); err != nil {
+		return nil, fmt.Errorf("准备查询 ListSessionRuns 时出错: %w", err)
+	}
 	if q.listSessionsStmt, err = db.PrepareContext(ctx, listSessions); err != nil {
 		return nil, fmt.Errorf("准备查询 ListSessions 时出错: %w", err)
 	}
@@ -129,15 +162,48 @@ func Prepare(ctx context.Context, db DBTX) (*Queries, error) {
 	if q.recordFileReadStmt, err = db.PrepareContext(ctx, recordFileRead); err != nil {
 		return nil, fmt.Errorf("准备查询 RecordFileRead 时出错: %w", err)
 	}
+	if q.startSessionRunStmt, err = db.PrepareContext(ctx, startSessionRun); err != nil {
+		return nil, fmt.Errorf("准备查询 StartSessionRun 时出错: %w", err)
+	}
 	if q.updateMessageStmt, err = db.PrepareContext(ctx, updateMessage); err != nil {
 		return nil, fmt.Errorf("准备查询 UpdateMessage 时出错: %w", err)
 	}
 	if q.updateSessionStmt, err = db.PrepareContext(ctx, updateSession); err != nil {
 		return nil, fmt.Errorf("准备查询 UpdateSession 时出错: %w", err)
 	}
+	if q.updateSessionArchivedAtStmt, err = db.PrepareContext(ctx, updateSessionArchivedAt); err != nil {
+		return nil, fmt.Errorf("准备查询 UpdateSessionArchivedAt 时出错: %w", err)
+	}
+	if q.updateSessionBookmarksStmt, err = db.PrepareContext(ctx, updateSessionBookmarks); err != nil {
+		return nil, fmt.Errorf("准备查询 UpdateSessionBookmarks 时出错: %w", err)
+	}
+	if q.updateSessionEnvStmt, err = db.PrepareContext(ctx, updateSessionEnv); err != nil {
+		return nil, fmt.Errorf("准备查询 UpdateSessionEnv 时出错: %w", err)
+	}
+	if q.updateSessionPinnedFilesStmt, err = db.PrepareContext(ctx, updateSessionPinnedFiles); err != nil {
+		return nil, fmt.Errorf("准备查询 UpdateSessionPinnedFiles 时出错: %w", err)
+	}
+	if q.updateSessionPlanModeStmt, err = db.PrepareContext(ctx, updateSessionPlanMode); err != nil {
+		return nil, fmt.Errorf("准备查询 UpdateSessionPlanMode 时出错: %w", err)
+	}
+	if q.updateSessionRunQueueStmt, err = db.PrepareContext(ctx, updateSessionRunQueue); err != nil {
+		return nil, fmt.Errorf("准备查询 UpdateSessionRunQueue 时出错: %w", err)
+	}
+	if q.updateSessionTagsStmt, err = db.PrepareContext(ctx, updateSessionTags); err != nil {
+		return nil, fmt.Errorf("准备查询 UpdateSessionTags 时出错: %w", err)
+	}
 	if q.updateSessionTitleAndUsageStmt, err = db.PrepareContext(ctx, updateSessionTitleAndUsage); err != nil {
 		return nil, fmt.Errorf("准备查询 UpdateSessionTitleAndUsage 时出错: %w", err)
 	}
+	if q.updateSessionTodosStmt, err = db.PrepareContext(ctx, updateSessionTodos); err != nil {
+		return nil, fmt.Errorf("准备查询 UpdateSessionTodos 时出错: %w", err)
+	}
+	if q.updateSessionVariantsStmt, err = db.PrepareContext(ctx, updateSessionVariants); err != nil {
+		return nil, fmt.Errorf("准备查询 UpdateSessionVariants 时出错: %w", err)
+	}
+	if q.updateSessionWorkingDirStmt, err = db.PrepareContext(ctx, updateSessionWorkingDir); err != nil {
+		return nil, fmt.Errorf("准备查询 UpdateSessionWorkingDir 时出错: %w", err)
+	}
 	return &q, nil
 }
 
@@ -145,6 +211,11 @@ func Prepare(ctx context.Context, db DBTX) (*Queries, error) {
 // 返回值: 关闭过程中遇到的第一个错误（如果有）
 func (q *Queries) Close() error {
 	var err error
+	if q.createCheckpointStmt != nil {
+		if cerr := q.createCheckpointStmt.Close(); cerr != nil {
+			err = fmt.Errorf("关闭 createCheckpointStmt 时出错: %w", cerr)
+		}
+	}
 	if q.createFileStmt != nil {
 		if cerr := q.createFileStmt.Close(); cerr != nil {
 			err = fmt.Errorf("关闭 createFileStmt 时出错: %w", cerr)
@@ -160,6 +231,16 @@ func (q *Queries) Close() error {
 			err = fmt.Errorf("关闭 createSessionStmt 时出错: %w", cerr)
 		}
 	}
+	if q.createUsageRecordStmt != nil {
+		if cerr := q.createUsageRecordStmt.Close(); cerr != nil {
+			err = fmt.Errorf("关闭 createUsageRecordStmt 时出错: %w", cerr)
+		}
+	}
+	if q.deleteCheckpointStmt != nil {
+		if cerr := q.deleteCheckpointStmt.Close(); cerr != nil {
+			err = fmt.Errorf("关闭 deleteCheckpointStmt 时出错: %w", cerr)
+		}
+	}
 	if q.deleteFileStmt != nil {
 		if cerr := q.deleteFileStmt.Close(); cerr != nil {
 			err = fmt.Errorf("关闭 deleteFileStmt 时出错: %w", cerr)
@@ -175,6 +256,16 @@ func (q *Queries) Close() error {
 			err = fmt.Errorf("关闭 deleteSessionStmt 时出错: %w", cerr)
 		}
 	}
+	if q.deleteSessionDescendantsStmt != nil {
+		if cerr := q.deleteSessionDescendantsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("关闭 deleteSessionDescendantsStmt 时出错: %w", cerr)
+		}
+	}
+	if q.deleteSessionRunStmt != nil {
+		if cerr := q.deleteSessionRunStmt.Close(); cerr != nil {
+			err = fmt.Errorf("关闭 deleteSessionRunStmt 时出错: %w", cerr)
+		}
+	}
 	if q.deleteSessionFilesStmt != nil {
 		if cerr := q.deleteSessionFilesStmt.Close(); cerr != nil {
 			err = fmt.Errorf("关闭 deleteSessionFilesStmt 时出错: %w", cerr)
@@ -190,6 +281,16 @@ func (q *Queries) Close() error {
 			err = fmt.Errorf("关闭 getAverageResponseTimeStmt 时出错: %w", cerr)
 		}
 	}
+	if q.getCheckpointStmt != nil {
+		if cerr := q.getCheckpointStmt.Close(); cerr != nil {
+			err = fmt.Errorf("关闭 getCheckpointStmt 时出错: %w", cerr)
+		}
+	}
+	if q.getCostSinceStmt != nil {
+		if cerr := q.getCostSinceStmt.Close(); cerr != nil {
+			err = fmt.Errorf("关闭 getCostSinceStmt 时出错: %w", cerr)
+		}
+	}
 	if q.getFileStmt != nil {
 		if cerr := q.getFileStmt.Close(); cerr != nil {
 			err = fmt.Errorf("关闭 getFileStmt 时出错: %w", cerr)
@@ -215,6 +316,11 @@ func (q *Queries) Close() error {
 			err = fmt.Errorf("关闭 getMessageStmt 时出错: %w", cerr)
 		}
 	}
+	if q.getModelPerformanceStmt != nil {
+		if cerr := q.getModelPerformanceStmt.Close(); cerr != nil {
+			err = fmt.Errorf("关闭 getModelPerformanceStmt 时出错: %w", cerr)
+		}
+	}
 	if q.getRecentActivityStmt != nil {
 		if cerr := q.getRecentActivityStmt.Close(); cerr != nil {
 			err = fmt.Errorf("关闭 getRecentActivityStmt 时出错: %w", cerr)
@@ -260,6 +366,16 @@ func (q *Queries) Close() error {
 			err = fmt.Errorf("关闭 listAllUserMessagesStmt 时出错: %w", cerr)
 		}
 	}
+	if q.listArchivedSessionsStmt != nil {
+		if cerr := q.listArchivedSessionsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("关闭 listArchivedSessionsStmt 时出错: %w", cerr)
+		}
+	}
+	if q.listCheckpointsBySessionStmt != nil {
+		if cerr := q.listCheckpointsBySessionStmt.Close(); cerr != nil {
+			err = fmt.Errorf("关闭 listCheckpointsBySessionStmt 时出错: %w", cerr)
+		}
+	}
 	if q.listFilesByPathStmt != nil {
 		if cerr := q.listFilesByPathStmt.Close(); cerr != nil {
 			err = fmt.Errorf("关闭 listFilesByPathStmt 时出错: %w", cerr)
@@ -290,6 +406,11 @@ func (q *Queries) Close() error {
 			err = fmt.Errorf("关闭 listSessionReadFilesStmt 时出错: %w", cerr)
 		}
 	}
+	if q.listSessionRunsStmt != nil {
+		if cerr := q.listSessionRunsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("关闭 listSessionRunsStmt 时出错: %w", cerr)
+		}
+	}
 	if q.listSessionsStmt != nil {
 		if cerr := q.listSessionsStmt.Close(); cerr != nil {
 			err = fmt.Errorf("关闭 listSessionsStmt 时出错: %w", cerr)
@@ -305,6 +426,11 @@ func (q *Queries) Close() error {
 			err = fmt.Errorf("关闭 recordFileReadStmt 时出错: %w", cerr)
 		}
 	}
+	if q.startSessionRunStmt != nil {
+		if cerr := q.startSessionRunStmt.Close(); cerr != nil {
+			err = fmt.Errorf("关闭 startSessionRunStmt 时出错: %w", cerr)
+		}
+	}
 	if q.updateMessageStmt != nil {
 		if cerr := q.updateMessageStmt.Close(); cerr != nil {
 			err = fmt.Errorf("关闭 updateMessageStmt 时出错: %w", cerr)
@@ -315,11 +441,61 @@ func (q *Queries) Close() error {
 			err = fmt.Errorf("关闭 updateSessionStmt 时出错: %w", cerr)
 		}
 	}
+	if q.updateSessionArchivedAtStmt != nil {
+		if cerr := q.updateSessionArchivedAtStmt.Close(); cerr != nil {
+			err = fmt.Errorf("关闭 updateSessionArchivedAtStmt 时出错: %w", cerr)
+		}
+	}
+	if q.updateSessionBookmarksStmt != nil {
+		if cerr := q.updateSessionBookmarksStmt.Close(); cerr != nil {
+			err = fmt.Errorf("关闭 updateSessionBookmarksStmt 时出错: %w", cerr)
+		}
+	}
+	if q.updateSessionEnvStmt != nil {
+		if cerr := q.updateSessionEnvStmt.Close(); cerr != nil {
+			err = fmt.Errorf("关闭 updateSessionEnvStmt 时出错: %w", cerr)
+		}
+	}
+	if q.updateSessionPinnedFilesStmt != nil {
+		if cerr := q.updateSessionPinnedFilesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("关闭 updateSessionPinnedFilesStmt 时出错: %w", cerr)
+		}
+	}
+	if q.updateSessionPlanModeStmt != nil {
+		if cerr := q.updateSessionPlanModeStmt.Close(); cerr != nil {
+			err = fmt.Errorf("关闭 updateSessionPlanModeStmt 时出错: %w", cerr)
+		}
+	}
+	if q.updateSessionRunQueueStmt != nil {
+		if cerr := q.updateSessionRunQueueStmt.Close(); cerr != nil {
+			err = fmt.Errorf("关闭 updateSessionRunQueueStmt 时出错: %w", cerr)
+		}
+	}
+	if q.updateSessionTagsStmt != nil {
+		if cerr := q.updateSessionTagsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("关闭 updateSessionTagsStmt 时出错: %w", cerr)
+		}
+	}
 	if q.updateSessionTitleAndUsageStmt != nil {
 		if cerr := q.updateSessionTitleAndUsageStmt.Close(); cerr != nil {
 			err = fmt.Errorf("关闭 updateSessionTitleAndUsageStmt 时出错: %w", cerr)
 		}
 	}
+	if q.updateSessionTodosStmt != nil {
+		if cerr := q.updateSessionTodosStmt.Close(); cerr != nil {
+			err = fmt.Errorf("关闭 updateSessionTodosStmt 时出错: %w", cerr)
+		}
+	}
+	if q.updateSessionVariantsStmt != nil {
+		if cerr := q.updateSessionVariantsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("关闭 updateSessionVariantsStmt 时出错: %w", cerr)
+		}
+	}
+	if q.updateSessionWorkingDirStmt != nil {
+		if cerr := q.updateSessionWorkingDirStmt.Close(); cerr != nil {
+			err = fmt.Errorf("关闭 updateSessionWorkingDirStmt 时出错: %w", cerr)
+		}
+	}
 	return err
 }
 
@@ -379,20 +555,28 @@ func (q *Queries) queryRow(ctx context.Context, stmt *sql.Stmt, query string, ar
 type Queries struct {
 	db                             DBTX      // 数据库连接对象，实现了 DBTX 接口
 	tx                             *sql.Tx   // 数据库事务对象（可选）
+	createCheckpointStmt           *sql.Stmt // 创建检查点的预编译语句
 	createFileStmt                 *sql.Stmt // 创建文件的预编译语句
 	createMessageStmt              *sql.Stmt // 创建消息的预编译语句
 	createSessionStmt              *sql.Stmt // 创建会话的预编译语句
+	createUsageRecordStmt          *sql.Stmt // 创建费用记录的预编译语句
+	deleteCheckpointStmt           *sql.Stmt // 删除检查点的预编译语句
 	deleteFileStmt                 *sql.Stmt // 删除文件的预编译语句
 	deleteMessageStmt              *sql.Stmt // 删除消息的预编译语句
 	deleteSessionStmt              *sql.Stmt // 删除会话的预编译语句
+	deleteSessionDescendantsStmt   *sql.Stmt // 删除会话所有子孙会话的预编译语句
+	deleteSessionRunStmt           *sql.Stmt // 删除会话运行记录的预编译语句
 	deleteSessionFilesStmt         *sql.Stmt // 删除会话文件的预编译语句
 	deleteSessionMessagesStmt      *sql.Stmt // 删除会话消息的预编译语句
 	getAverageResponseTimeStmt     *sql.Stmt // 获取平均响应时间的预编译语句
+	getCheckpointStmt              *sql.Stmt // 获取检查点的预编译语句
+	getCostSinceStmt               *sql.Stmt // 统计费用的预编译语句
 	getFileStmt                    *sql.Stmt // 获取文件的预编译语句
 	getFileByPathAndSessionStmt    *sql.Stmt // 根据路径和会话获取文件的预编译语句
 	getFileReadStmt                *sql.Stmt // 获取文件读取记录的预编译语句
 	getHourDayHeatmapStmt          *sql.Stmt // 获取小时-日期热力图的预编译语句
 	getMessageStmt                 *sql.Stmt // 获取消息的预编译语句
+	getModelPerformanceStmt        *sql.Stmt // 按模型获取响应性能的预编译语句
 	getRecentActivityStmt          *sql.Stmt // 获取最近活动的预编译语句
 	getSessionByIDStmt             *sql.Stmt // 根据ID获取会话的预编译语句
 	getToolUsageStmt               *sql.Stmt // 获取工具使用情况的预编译语句
@@ -402,18 +586,32 @@ type Queries struct {
 	getUsageByHourStmt             *sql.Stmt // 按小时获取使用情况的预编译语句
 	getUsageByModelStmt            *sql.Stmt // 按模型获取使用情况的预编译语句
 	listAllUserMessagesStmt        *sql.Stmt // 列出所有用户消息的预编译语句
+	listArchivedSessionsStmt       *sql.Stmt // 列出已归档会话的预编译语句
+	listCheckpointsBySessionStmt   *sql.Stmt // 按会话列出检查点的预编译语句
 	listFilesByPathStmt            *sql.Stmt // 按路径列出文件的预编译语句
 	listFilesBySessionStmt         *sql.Stmt // 按会话列出文件的预编译语句
 	listLatestSessionFilesStmt     *sql.Stmt // 列出最新会话文件的预编译语句
 	listMessagesBySessionStmt      *sql.Stmt // 按会话列出消息的预编译语句
 	listNewFilesStmt               *sql.Stmt // 列出新文件的预编译语句
 	listSessionReadFilesStmt       *sql.Stmt // 列出会话已读文件的预编译语句
+	listSessionRunsStmt            *sql.Stmt // 列出会话运行记录的预编译语句
 	listSessionsStmt               *sql.Stmt // 列出会话的预编译语句
 	listUserMessagesBySessionStmt  *sql.Stmt // 按会话列出用户消息的预编译语句
 	recordFileReadStmt             *sql.Stmt // 记录文件读取的预编译语句
+	startSessionRunStmt            *sql.Stmt // 记录会话运行开始的预编译语句
 	updateMessageStmt              *sql.Stmt // 更新消息的预编译语句
 	updateSessionStmt              *sql.Stmt // 更新会话的预编译语句
+	updateSessionArchivedAtStmt    *sql.Stmt // 更新会话归档时间的预编译语句
+	updateSessionBookmarksStmt     *sql.Stmt // 更新会话书签的预编译语句
+	updateSessionEnvStmt           *sql.Stmt // 更新会话环境变量的预编译语句
+	updateSessionPinnedFilesStmt   *sql.Stmt // 更新会话固定文件的预编译语句
+	updateSessionPlanModeStmt      *sql.Stmt // 更新会话计划模式的预编译语句
+	updateSessionRunQueueStmt      *sql.Stmt // 更新会话运行排队提示的预编译语句
+	updateSessionTagsStmt          *sql.Stmt // 更新会话标签的预编译语句
 	updateSessionTitleAndUsageStmt *sql.Stmt // 更新会话标题和使用情况的预编译语句
+	updateSessionTodosStmt         *sql.Stmt // 更新会话待办事项的预编译语句
+	updateSessionVariantsStmt      *sql.Stmt // 更新会话备选回复版本的预编译语句
+	updateSessionWorkingDirStmt    *sql.Stmt // 更新会话工作目录的预编译语句
 }
 
 // WithTx 创建并返回一个与指定事务关联的新的 Queries 实例
@@ -424,20 +622,28 @@ func (q *Queries) WithTx(tx *sql.Tx) *Queries {
 	return &Queries{
 		db:                             tx,
 		tx:                             tx,
+		createCheckpointStmt:           q.createCheckpointStmt,
 		createFileStmt:                 q.createFileStmt,
 		createMessageStmt:              q.createMessageStmt,
 		createSessionStmt:              q.createSessionStmt,
+		createUsageRecordStmt:          q.createUsageRecordStmt,
+		deleteCheckpointStmt:           q.deleteCheckpointStmt,
 		deleteFileStmt:                 q.deleteFileStmt,
 		deleteMessageStmt:              q.deleteMessageStmt,
 		deleteSessionStmt:              q.deleteSessionStmt,
+		deleteSessionDescendantsStmt:   q.deleteSessionDescendantsStmt,
+		deleteSessionRunStmt:           q.deleteSessionRunStmt,
 		deleteSessionFilesStmt:         q.deleteSessionFilesStmt,
 		deleteSessionMessagesStmt:      q.deleteSessionMessagesStmt,
 		getAverageResponseTimeStmt:     q.getAverageResponseTimeStmt,
+		getCheckpointStmt:              q.getCheckpointStmt,
+		getCostSinceStmt:               q.getCostSinceStmt,
 		getFileStmt:                    q.getFileStmt,
 		getFileByPathAndSessionStmt:    q.getFileByPathAndSessionStmt,
 		getFileReadStmt:                q.getFileReadStmt,
 		getHourDayHeatmapStmt:          q.getHourDayHeatmapStmt,
 		getMessageStmt:                 q.getMessageStmt,
+		getModelPerformanceStmt:        q.getModelPerformanceStmt,
 		getRecentActivityStmt:          q.getRecentActivityStmt,
 		getSessionByIDStmt:             q.getSessionByIDStmt,
 		getToolUsageStmt:               q.getToolUsageStmt,
@@ -447,17 +653,31 @@ func (q *Queries) WithTx(tx *sql.Tx) *Queries {
 		getUsageByHourStmt:             q.getUsageByHourStmt,
 		getUsageByModelStmt:            q.getUsageByModelStmt,
 		listAllUserMessagesStmt:        q.listAllUserMessagesStmt,
+		listArchivedSessionsStmt:       q.listArchivedSessionsStmt,
+		listCheckpointsBySessionStmt:   q.listCheckpointsBySessionStmt,
 		listFilesByPathStmt:            q.listFilesByPathStmt,
 		listFilesBySessionStmt:         q.listFilesBySessionStmt,
 		listLatestSessionFilesStmt:     q.listLatestSessionFilesStmt,
 		listMessagesBySessionStmt:      q.listMessagesBySessionStmt,
 		listNewFilesStmt:               q.listNewFilesStmt,
 		listSessionReadFilesStmt:       q.listSessionReadFilesStmt,
+		listSessionRunsStmt:            q.listSessionRunsStmt,
 		listSessionsStmt:               q.listSessionsStmt,
 		listUserMessagesBySessionStmt:  q.listUserMessagesBySessionStmt,
 		recordFileReadStmt:             q.recordFileReadStmt,
+		startSessionRunStmt:            q.startSessionRunStmt,
 		updateMessageStmt:              q.updateMessageStmt,
 		updateSessionStmt:              q.updateSessionStmt,
+		updateSessionArchivedAtStmt:    q.updateSessionArchivedAtStmt,
+		updateSessionBookmarksStmt:     q.updateSessionBookmarksStmt,
+		updateSessionEnvStmt:           q.updateSessionEnvStmt,
+		updateSessionPinnedFilesStmt:   q.updateSessionPinnedFilesStmt,
+		updateSessionPlanModeStmt:      q.updateSessionPlanModeStmt,
+		updateSessionRunQueueStmt:      q.updateSessionRunQueueStmt,
+		updateSessionTagsStmt:          q.updateSessionTagsStmt,
 		updateSessionTitleAndUsageStmt: q.updateSessionTitleAndUsageStmt,
+		updateSessionTodosStmt:         q.updateSessionTodosStmt,
+		updateSessionVariantsStmt:      q.updateSessionVariantsStmt,
+		updateSessionWorkingDirStmt:    q.updateSessionWorkingDirStmt,
 	}
 }
@@ -27,12 +27,13 @@ INSERT INTO files (
     path,
     content,
     version,
+    author,
     created_at,
     updated_at
 ) VALUES (
-    ?, ?, ?, ?, ?, strftime('%s', 'now'), strftime('%s', 'now')
+    ?, ?, ?, ?, ?, ?, strftime('%s', 'now'), strftime('%s', 'now')
 )
-RETURNING id, session_id, path, content, version, created_at, updated_at
+RETURNING id, session_id, path, content, version, created_at, updated_at, author
 `
 
 // CreateFileParams 创建文件参数结构体
@@ -43,6 +44,7 @@ type CreateFileParams struct {
 	Path      string `json:"path"`       // 文件路径
 	Content   string `json:"content"`    // 文件内容
 	Version   int64  `json:"version"`    // 文件版本号
+	Author    string `json:"author"`     // 做出修改的用户
 }
 
 // CreateFile 创建文件
@@ -60,6 +62,7 @@ func (q *Queries) CreateFile(ctx context.Context, arg CreateFileParams) (File, e
 		arg.Path,
 		arg.Content,
 		arg.Version,
+		arg.Author,
 	)
 	var i File
 	err := row.Scan(
@@ -70,6 +73,7 @@ func (q *Queries) CreateFile(ctx context.Context, arg CreateFileParams) (File, e
 		&i.Version,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Author,
 	)
 	return i, err
 }
@@ -115,7 +119,7 @@ func (q *Queries) DeleteSessionFiles(ctx context.Context, sessionID string) erro
 // getFile 获取文件的SQL语句
 // 功能：根据文件ID从files表中查询单个文件记录
 const getFile = `-- name: GetFile :one
-SELECT id, session_id, path, content, version, created_at, updated_at
+SELECT id, session_id, path, content, version, created_at, updated_at, author
 FROM files
 WHERE id = ? LIMIT 1
 `
@@ -139,6 +143,7 @@ func (q *Queries) GetFile(ctx context.Context, id string) (File, error) {
 		&i.Version,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Author,
 	)
 	return i, err
 }
@@ -147,7 +152,7 @@ func (q *Queries) GetFile(ctx context.Context, id string) (File, error) {
 // 功能：根据文件路径和会话ID查询文件，返回最新版本的文件记录
 // 排序规则：按版本号降序、创建时间降序排序，取第一条
 const getFileByPathAndSession = `-- name: GetFileByPathAndSession :one
-SELECT id, session_id, path, content, version, created_at, updated_at
+SELECT id, session_id, path, content, version, created_at, updated_at, author
 FROM files
 WHERE path = ? AND session_id = ?
 ORDER BY version DESC, created_at DESC
@@ -180,6 +185,7 @@ func (q *Queries) GetFileByPathAndSession(ctx context.Context, arg GetFileByPath
 		&i.Version,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Author,
 	)
 	return i, err
 }
@@ -188,7 +194,7 @@ func (q *Queries) GetFileByPathAndSession(ctx context.Context, arg GetFileByPath
 // 功能：根据文件路径查询所有版本的文件记录
 // 排序规则：按版本号降序、创建时间降序排序
 const listFilesByPath = `-- name: ListFilesByPath :many
-SELECT id, session_id, path, content, version, created_at, updated_at
+SELECT id, session_id, path, content, version, created_at, updated_at, author
 FROM files
 WHERE path = ?
 ORDER BY version DESC, created_at DESC
@@ -219,6 +225,7 @@ func (q *Queries) ListFilesByPath(ctx context.Context, path string) ([]File, err
 			&i.Version,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.Author,
 		); err != nil {
 			return nil, err
 		}
@@ -237,7 +244,7 @@ func (q *Queries) ListFilesByPath(ctx context.Context, path string) ([]File, err
 // 功能：根据会话ID查询该会话下的所有文件记录
 // 排序规则：按版本号升序、创建时间升序排序
 const listFilesBySession = `-- name: ListFilesBySession :many
-SELECT id, session_id, path, content, version, created_at, updated_at
+SELECT id, session_id, path, content, version, created_at, updated_at, author
 FROM files
 WHERE session_id = ?
 ORDER BY version ASC, created_at ASC
@@ -268,6 +275,7 @@ func (q *Queries) ListFilesBySession(ctx context.Context, sessionID string) ([]F
 			&i.Version,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.Author,
 		); err != nil {
 			return nil, err
 		}
@@ -286,7 +294,7 @@ func (q *Queries) ListFilesBySession(ctx context.Context, sessionID string) ([]F
 // 功能：查询指定会话下每个路径的最新版本文件记录
 // 实现方式：通过子查询找出每个路径的最大版本和最大创建时间，然后与主表关联
 const listLatestSessionFiles = `-- name: ListLatestSessionFiles :many
-SELECT f.id, f.session_id, f.path, f.content, f.version, f.created_at, f.updated_at
+SELECT f.id, f.session_id, f.path, f.content, f.version, f.created_at, f.updated_at, f.author
 FROM files f
 INNER JOIN (
     SELECT path, MAX(version) as max_version, MAX(created_at) as max_created_at
@@ -322,6 +330,7 @@ func (q *Queries) ListLatestSessionFiles(ctx context.Context, sessionID string)
 			&i.Version,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.Author,
 		); err != nil {
 			return nil, err
 		}
@@ -340,7 +349,7 @@ func (q *Queries) ListLatestSessionFiles(ctx context.Context, sessionID string)
 // 功能：查询所有标记为新文件的记录（is_new = 1）
 // 排序规则：按版本号降序、创建时间降序排序
 const listNewFiles = `-- name: ListNewFiles :many
-SELECT id, session_id, path, content, version, created_at, updated_at
+SELECT id, session_id, path, content, version, created_at, updated_at, author
 FROM files
 WHERE is_new = 1
 ORDER BY version DESC, created_at DESC
@@ -370,6 +379,7 @@ func (q *Queries) ListNewFiles(ctx context.Context) ([]File, error) {
 			&i.Version,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.Author,
 		); err != nil {
 			return nil, err
 		}
@@ -0,0 +1,19 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS usage_records (
+    id TEXT PRIMARY KEY,
+    session_id TEXT NOT NULL,  -- Not a foreign key: spending is kept after the session is deleted
+    provider TEXT NOT NULL,
+    model TEXT NOT NULL,
+    cost REAL NOT NULL DEFAULT 0.0 CHECK (cost >= 0.0),
+    created_at INTEGER NOT NULL  -- Unix timestamp in seconds
+);
+
+CREATE INDEX IF NOT EXISTS idx_usage_records_created_at ON usage_records (created_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_usage_records_created_at;
+DROP TABLE IF EXISTS usage_records;
+-- +goose StatementEnd
@@ -0,0 +1,16 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS session_runs (
+    session_id TEXT PRIMARY KEY,
+    prompt TEXT NOT NULL,  -- Prompt of the run in progress
+    queued TEXT NOT NULL DEFAULT '[]',  -- JSON array of prompts queued behind the run
+    pid INTEGER NOT NULL,  -- Process running the agent
+    started_at INTEGER NOT NULL,  -- Unix timestamp in seconds
+    FOREIGN KEY (session_id) REFERENCES sessions (id) ON DELETE CASCADE
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS session_runs;
+-- +goose StatementEnd
@@ -8,6 +8,17 @@ import (
 	"database/sql"
 )
 
+// Checkpoint 表示检查点记录的结构体
+// 用于保存会话中某一时刻的文件版本和对话位置
+type Checkpoint struct {
+	ID        string         `json:"id"`         // 检查点唯一标识符
+	SessionID string         `json:"session_id"` // 所属会话的ID
+	Name      string         `json:"name"`       // 检查点名称
+	MessageID sql.NullString `json:"message_id"` // 创建检查点时对话的最后一条消息ID
+	Files     string         `json:"files"`      // 文件路径到文件版本ID的映射（JSON格式）
+	CreatedAt int64          `json:"created_at"` // 创建时间戳（Unix时间戳）
+}
+
 // File 表示文件记录的结构体
 // 用于存储会话中的文件信息，包括文件路径、内容和版本等
 type File struct {
@@ -18,6 +29,7 @@ type File struct {
 	Version   int64  `json:"version"`    // 文件版本号
 	CreatedAt int64  `json:"created_at"` // 创建时间戳（Unix时间戳）
 	UpdatedAt int64  `json:"updated_at"` // 更新时间戳（Unix时间戳）
+	Author    string `json:"author"`     // 做出修改的用户，格式为 "Name <email>"
 }
 
 // Message 表示消息记录的结构体
@@ -57,4 +69,34 @@ type Session struct {
 	CreatedAt        int64          `json:"created_at"`         // 创建时间戳（Unix时间戳）
 	SummaryMessageID sql.NullString `json:"summary_message_id"` // 摘要消息的ID
 	Todos            sql.NullString `json:"todos"`              // 待办事项列表（JSON格式）
+	PinnedFiles      sql.NullString `json:"pinned_files"`       // 固定的上下文文件路径列表（JSON格式）
+	ArchivedAt       sql.NullInt64  `json:"archived_at"`        // 归档时间戳（Unix时间戳），未归档时为空
+	Env              sql.NullString `json:"env"`                // 会话级环境变量（JSON格式）
+	PlanMode         string         `json:"plan_mode"`          // 计划模式状态，为空表示未启用
+	Author           string         `json:"author"`             // 创建会话的用户，格式为 "Name <email>"
+	WorkingDir       string         `json:"working_dir"`        // 会话的工作目录，相对于项目根目录，为空表示项目根目录
+	Bookmarks        sql.NullString `json:"bookmarks"`          // 会话中的书签列表（JSON格式）
+	Tags             sql.NullString `json:"tags"`               // 会话标签列表（JSON格式）
+	Variants         sql.NullString `json:"variants"`           // 最后一轮对话的备选回复版本列表（JSON格式）
+}
+
+// SessionRun 表示会话中正在进行的智能体运行
+// 进程退出后仍然存在的记录表示运行被中断
+type SessionRun struct {
+	SessionID string `json:"session_id"` // 所属会话的ID
+	Prompt    string `json:"prompt"`     // 正在运行的提示
+	Queued    string `json:"queued"`     // 排队的提示列表（JSON格式）
+	Pid       int64  `json:"pid"`        // 运行智能体的进程ID
+	StartedAt int64  `json:"started_at"` // 开始时间戳（Unix时间戳）
+}
+
+// UsageRecord 表示一次模型请求的费用记录
+// 用于按时间统计费用，会话删除后仍然保留
+type UsageRecord struct {
+	ID        string  `json:"id"`         // 费用记录唯一标识符
+	SessionID string  `json:"session_id"` // 产生费用的会话ID
+	Provider  string  `json:"provider"`   // 提供者ID
+	Model     string  `json:"model"`      // 模型ID
+	Cost      float64 `json:"cost"`       // 本次请求的费用（美元）
+	CreatedAt int64   `json:"created_at"` // 创建时间戳（Unix时间戳）
 }
@@ -6,28 +6,43 @@ package db
 
 import (
 	"context"
+	"database/sql"
 )
 
 // Querier 定义了数据库查询接口，包含所有数据库操作方法
 type Querier interface {
+	// CreateCheckpoint 创建新检查点记录
+	CreateCheckpoint(ctx context.Context, arg CreateCheckpointParams) (Checkpoint, error)
 	// CreateFile 创建新文件记录
 	CreateFile(ctx context.Context, arg CreateFileParams) (File, error)
 	// CreateMessage 创建新消息记录
 	CreateMessage(ctx context.Context, arg CreateMessageParams) (Message, error)
 	// CreateSession 创建新会话记录
 	CreateSession(ctx context.Context, arg CreateSessionParams) (Session, error)
+	// CreateUsageRecord 创建一次请求的费用记录
+	CreateUsageRecord(ctx context.Context, arg CreateUsageRecordParams) error
+	// DeleteCheckpoint 根据ID删除检查点记录
+	DeleteCheckpoint(ctx context.Context, id string) error
 	// DeleteFile 根据ID删除文件记录
 	DeleteFile(ctx context.Context, id string) error
 	// DeleteMessage 根据ID删除消息记录
 	DeleteMessage(ctx context.Context, id string) error
 	// DeleteSession 根据ID删除会话记录
 	DeleteSession(ctx context.Context, id string) error
+	// DeleteSessionRun 删除会话的运行记录
+	DeleteSessionRun(ctx context.Context, sessionID string) error
+	// DeleteSessionDescendants 删除会话的所有子孙会话
+	DeleteSessionDescendants(ctx context.Context, parentSessionID sql.NullString) error
 	// DeleteSessionFiles 删除指定会话的所有关联文件
 	DeleteSessionFiles(ctx context.Context, sessionID string) error
 	// DeleteSessionMessages 删除指定会话的所有关联消息
 	DeleteSessionMessages(ctx context.Context, sessionID string) error
 	// GetAverageResponseTime 获取平均响应时间（毫秒）
 	GetAverageResponseTime(ctx context.Context) (int64, error)
+	// GetCheckpoint 根据ID获取检查点记录
+	GetCheckpoint(ctx context.Context, id string) (Checkpoint, error)
+	// GetCostSince 统计指定时间（Unix 秒）之后所有请求的费用总和
+	GetCostSince(ctx context.Context, createdAt int64) (float64, error)
 	// GetFile 根据ID获取文件记录
 	GetFile(ctx context.Context, id string) (File, error)
 	// GetFileByPathAndSession 根据文件路径和会话ID获取文件记录
@@ -38,6 +53,8 @@ type Querier interface {
 	GetHourDayHeatmap(ctx context.Context) ([]GetHourDayHeatmapRow, error)
 	// GetMessage 根据ID获取消息记录
 	GetMessage(ctx context.Context, id string) (Message, error)
+	// GetModelPerformance 按模型获取响应性能（延迟、首令牌时间和生成速度）
+	GetModelPerformance(ctx context.Context) ([]GetModelPerformanceRow, error)
 	// GetRecentActivity 获取最近的活动记录
 	GetRecentActivity(ctx context.Context) ([]GetRecentActivityRow, error)
 	// GetSessionByID 根据ID获取会话记录
@@ -56,6 +73,10 @@ type Querier interface {
 	GetUsageByModel(ctx context.Context) ([]GetUsageByModelRow, error)
 	// ListAllUserMessages 列出所有用户消息
 	ListAllUserMessages(ctx context.Context) ([]Message, error)
+	// ListArchivedSessions 列出所有已归档的根会话
+	ListArchivedSessions(ctx context.Context) ([]Session, error)
+	// ListCheckpointsBySession 列出指定会话的检查点，最新的排在前面
+	ListCheckpointsBySession(ctx context.Context, sessionID string) ([]Checkpoint, error)
 	// ListFilesByPath 根据路径列出文件
 	ListFilesByPath(ctx context.Context, path string) ([]File, error)
 	// ListFilesBySession 列出指定会话的所有文件
@@ -68,18 +89,42 @@ type Querier interface {
 	ListNewFiles(ctx context.Context) ([]File, error)
 	// ListSessionReadFiles 列出指定会话已读取的文件
 	ListSessionReadFiles(ctx context.Context, sessionID string) ([]ReadFile, error)
+	// ListSessionRuns 列出未归档根会话的运行记录
+	ListSessionRuns(ctx context.Context) ([]SessionRun, error)
 	// ListSessions 列出所有会话
 	ListSessions(ctx context.Context) ([]Session, error)
 	// ListUserMessagesBySession 列出指定会话的用户消息
 	ListUserMessagesBySession(ctx context.Context, sessionID string) ([]Message, error)
 	// RecordFileRead 记录文件读取操作
 	RecordFileRead(ctx context.Context, arg RecordFileReadParams) error
+	// StartSessionRun 记录会话开始运行
+	StartSessionRun(ctx context.Context, arg StartSessionRunParams) error
 	// UpdateMessage 更新消息记录
 	UpdateMessage(ctx context.Context, arg UpdateMessageParams) error
 	// UpdateSession 更新会话记录
 	UpdateSession(ctx context.Context, arg UpdateSessionParams) (Session, error)
+	// UpdateSessionArchivedAt 更新会话的归档时间
+	UpdateSessionArchivedAt(ctx context.Context, arg UpdateSessionArchivedAtParams) (Session, error)
+	// UpdateSessionBookmarks 更新会话的书签列表
+	UpdateSessionBookmarks(ctx context.Context, arg UpdateSessionBookmarksParams) (Session, error)
+	// UpdateSessionEnv 更新会话的环境变量
+	UpdateSessionEnv(ctx context.Context, arg UpdateSessionEnvParams) (Session, error)
+	// UpdateSessionPinnedFiles 更新会话的固定文件列表
+	UpdateSessionPinnedFiles(ctx context.Context, arg UpdateSessionPinnedFilesParams) (Session, error)
+	// UpdateSessionPlanMode 更新会话的计划模式状态
+	UpdateSessionPlanMode(ctx context.Context, arg UpdateSessionPlanModeParams) (Session, error)
+	// UpdateSessionRunQueue 更新会话运行记录中排队的提示
+	UpdateSessionRunQueue(ctx context.Context, arg UpdateSessionRunQueueParams) error
+	// UpdateSessionTags 更新会话的标签列表
+	UpdateSessionTags(ctx context.Context, arg UpdateSessionTagsParams) (Session, error)
 	// UpdateSessionTitleAndUsage 更新会话标题和使用统计
 	UpdateSessionTitleAndUsage(ctx context.Context, arg UpdateSessionTitleAndUsageParams) error
+	// UpdateSessionTodos 更新会话的待办事项列表
+	UpdateSessionTodos(ctx context.Context, arg UpdateSessionTodosParams) (Session, error)
+	// UpdateSessionVariants 更新会话的备选回复版本列表
+	UpdateSessionVariants(ctx context.Context, arg UpdateSessionVariantsParams) (Session, error)
+	// UpdateSessionWorkingDir 更新会话的工作目录
+	UpdateSessionWorkingDir(ctx context.Context, arg UpdateSessionWorkingDirParams) (Session, error)
 }
 
 // 确保 Queries 类型实现了 Querier 接口
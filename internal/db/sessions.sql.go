@@ -20,6 +20,7 @@ INSERT INTO sessions (
     completion_tokens,
     cost,
     summary_message_id,
+    author,
     updated_at,
     created_at
 ) VALUES (
@@ -31,9 +32,10 @@ INSERT INTO sessions (
     ?,
     ?,
     null,
+    ?,
     strftime('%s', 'now'),
     strftime('%s', 'now')
-) RETURNING id, parent_session_id, title, message_count, prompt_tokens, completion_tokens, cost, updated_at, created_at, summary_message_id, todos
+) RETURNING id, parent_session_id, title, message_count, prompt_tokens, completion_tokens, cost, updated_at, created_at, summary_message_id, todos, pinned_files, archived_at, env, plan_mode, author, working_dir, bookmarks, tags, variants
 `
 
 // CreateSessionParams 创建会话参数结构体
@@ -45,6 +47,7 @@ type CreateSessionParams struct {
 	PromptTokens     int64          `json:"prompt_tokens"`     // 提示词令牌数
 	CompletionTokens int64          `json:"completion_tokens"` // 完成令牌数
 	Cost             float64        `json:"cost"`              // 成本
+	Author           string         `json:"author"`            // 会话作者
 }
 
 // CreateSession 创建新会话
@@ -64,6 +67,7 @@ func (q *Queries) CreateSession(ctx context.Context, arg CreateSessionParams) (S
 		arg.PromptTokens,
 		arg.CompletionTokens,
 		arg.Cost,
+		arg.Author,
 	)
 	var i Session
 	err := row.Scan(
@@ -78,6 +82,15 @@ func (q *Queries) CreateSession(ctx context.Context, arg CreateSessionParams) (S
 		&i.CreatedAt,
 		&i.SummaryMessageID,
 		&i.Todos,
+		&i.PinnedFiles,
+		&i.ArchivedAt,
+		&i.Env,
+		&i.PlanMode,
+		&i.Author,
+		&i.WorkingDir,
+		&i.Bookmarks,
+		&i.Tags,
+		&i.Variants,
 	)
 	return i, err
 }
@@ -99,8 +112,32 @@ func (q *Queries) DeleteSession(ctx context.Context, id string) error {
 	return err
 }
 
+const deleteSessionDescendants = `-- 名称: DeleteSessionDescendants :exec
+DELETE FROM sessions
+WHERE id IN (
+    WITH RECURSIVE descendants(id) AS (
+        SELECT s.id FROM sessions s WHERE s.parent_session_id = ?
+        UNION
+        SELECT s.id FROM sessions s JOIN descendants d ON s.parent_session_id = d.id
+    )
+    SELECT id FROM descendants
+)
+`
+
+// DeleteSessionDescendants 删除会话的所有子孙会话（如子代理和标题生成会话）
+// 参数:
+//   - ctx: 上下文
+//   - parentSessionID: 父会话ID
+//
+// 返回:
+//   - error: 错误信息
+func (q *Queries) DeleteSessionDescendants(ctx context.Context, parentSessionID sql.NullString) error {
+	_, err := q.exec(ctx, q.deleteSessionDescendantsStmt, deleteSessionDescendants, parentSessionID)
+	return err
+}
+
 const getSessionByID = `-- 名称: GetSessionByID :one
-SELECT id, parent_session_id, title, message_count, prompt_tokens, completion_tokens, cost, updated_at, created_at, summary_message_id, todos
+SELECT id, parent_session_id, title, message_count, prompt_tokens, completion_tokens, cost, updated_at, created_at, summary_message_id, todos, pinned_files, archived_at, env, plan_mode, author, working_dir, bookmarks, tags, variants
 FROM sessions
 WHERE id = ? LIMIT 1
 `
@@ -128,18 +165,87 @@ func (q *Queries) GetSessionByID(ctx context.Context, id string) (Session, error
 		&i.CreatedAt,
 		&i.SummaryMessageID,
 		&i.Todos,
+		&i.PinnedFiles,
+		&i.ArchivedAt,
+		&i.Env,
+		&i.PlanMode,
+		&i.Author,
+		&i.WorkingDir,
+		&i.Bookmarks,
+		&i.Tags,
+		&i.Variants,
 	)
 	return i, err
 }
 
+const listArchivedSessions = `-- 名称: ListArchivedSessions :many
+SELECT id, parent_session_id, title, message_count, prompt_tokens, completion_tokens, cost, updated_at, created_at, summary_message_id, todos, pinned_files, archived_at, env, plan_mode, author, working_dir, bookmarks, tags, variants
+FROM sessions
+WHERE parent_session_id is NULL
+  AND archived_at IS NOT NULL
+ORDER BY archived_at DESC
+`
+
+// ListArchivedSessions 获取所有已归档的根会话列表，最近归档的排在前面
+// 参数:
+//   - ctx: 上下文
+//
+// 返回:
+//   - []Session: 会话列表
+//   - error: 错误信息
+func (q *Queries) ListArchivedSessions(ctx context.Context) ([]Session, error) {
+	rows, err := q.query(ctx, q.listArchivedSessionsStmt, listArchivedSessions)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Session{}
+	for rows.Next() {
+		var i Session
+		if err := rows.Scan(
+			&i.ID,
+			&i.ParentSessionID,
+			&i.Title,
+			&i.MessageCount,
+			&i.PromptTokens,
+			&i.CompletionTokens,
+			&i.Cost,
+			&i.UpdatedAt,
+			&i.CreatedAt,
+			&i.SummaryMessageID,
+			&i.Todos,
+			&i.PinnedFiles,
+			&i.ArchivedAt,
+			&i.Env,
+			&i.PlanMode,
+			&i.Author,
+			&i.WorkingDir,
+			&i.Bookmarks,
+			&i.Tags,
+			&i.Variants,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listSessions = `-- 名称: ListSessions :many
-SELECT id, parent_session_id, title, message_count, prompt_tokens, completion_tokens, cost, updated_at, created_at, summary_message_id, todos
+SELECT id, parent_session_id, title, message_count, prompt_tokens, completion_tokens, cost, updated_at, created_at, summary_message_id, todos, pinned_files, archived_at, env, plan_mode, author, working_dir, bookmarks, tags, variants
 FROM sessions
 WHERE parent_session_id is NULL
+  AND archived_at IS NULL
 ORDER BY updated_at DESC
 `
 
-// ListSessions 获取所有根会话列表（不包含子会话）
+// ListSessions 获取所有未归档的根会话列表（不包含子会话）
 // 参数:
 //   - ctx: 上下文
 //
@@ -167,6 +273,15 @@ func (q *Queries) ListSessions(ctx context.Context) ([]Session, error) {
 			&i.CreatedAt,
 			&i.SummaryMessageID,
 			&i.Todos,
+			&i.PinnedFiles,
+			&i.ArchivedAt,
+			&i.Env,
+			&i.PlanMode,
+			&i.Author,
+			&i.WorkingDir,
+			&i.Bookmarks,
+			&i.Tags,
+			&i.Variants,
 		); err != nil {
 			return nil, err
 		}
@@ -191,7 +306,7 @@ SET
     cost = ?,
     todos = ?
 WHERE id = ?
-RETURNING id, parent_session_id, title, message_count, prompt_tokens, completion_tokens, cost, updated_at, created_at, summary_message_id, todos
+RETURNING id, parent_session_id, title, message_count, prompt_tokens, completion_tokens, cost, updated_at, created_at, summary_message_id, todos, pinned_files, archived_at, env, plan_mode, author, working_dir, bookmarks, tags, variants
 `
 
 // UpdateSessionParams 更新会话参数结构体
@@ -236,6 +351,315 @@ func (q *Queries) UpdateSession(ctx context.Context, arg UpdateSessionParams) (S
 		&i.CreatedAt,
 		&i.SummaryMessageID,
 		&i.Todos,
+		&i.PinnedFiles,
+		&i.ArchivedAt,
+		&i.Env,
+		&i.PlanMode,
+		&i.Author,
+		&i.WorkingDir,
+		&i.Bookmarks,
+		&i.Tags,
+		&i.Variants,
+	)
+	return i, err
+}
+
+const updateSessionArchivedAt = `-- 名称: UpdateSessionArchivedAt :one
+UPDATE sessions
+SET
+    archived_at = ?
+WHERE id = ?
+RETURNING id, parent_session_id, title, message_count, prompt_tokens, completion_tokens, cost, updated_at, created_at, summary_message_id, todos, pinned_files, archived_at, env, plan_mode, author, working_dir, bookmarks, tags, variants
+`
+
+// UpdateSessionArchivedAtParams 更新会话归档时间参数结构体
+type UpdateSessionArchivedAtParams struct {
+	ArchivedAt sql.NullInt64 `json:"archived_at"` // 归档时间戳，为空表示取消归档
+	ID         string        `json:"id"`          // 会话ID
+}
+
+// UpdateSessionArchivedAt 仅更新会话的归档时间
+// 参数:
+//   - ctx: 上下文
+//   - arg: 更新会话归档时间参数
+//
+// 返回:
+//   - Session: 更新后的会话对象
+//   - error: 错误信息
+func (q *Queries) UpdateSessionArchivedAt(ctx context.Context, arg UpdateSessionArchivedAtParams) (Session, error) {
+	row := q.queryRow(ctx, q.updateSessionArchivedAtStmt, updateSessionArchivedAt, arg.ArchivedAt, arg.ID)
+	var i Session
+	err := row.Scan(
+		&i.ID,
+		&i.ParentSessionID,
+		&i.Title,
+		&i.MessageCount,
+		&i.PromptTokens,
+		&i.CompletionTokens,
+		&i.Cost,
+		&i.UpdatedAt,
+		&i.CreatedAt,
+		&i.SummaryMessageID,
+		&i.Todos,
+		&i.PinnedFiles,
+		&i.ArchivedAt,
+		&i.Env,
+		&i.PlanMode,
+		&i.Author,
+		&i.WorkingDir,
+		&i.Bookmarks,
+		&i.Tags,
+		&i.Variants,
+	)
+	return i, err
+}
+
+const updateSessionBookmarks = `-- 名称: UpdateSessionBookmarks :one
+UPDATE sessions
+SET
+    bookmarks = ?
+WHERE id = ?
+RETURNING id, parent_session_id, title, message_count, prompt_tokens, completion_tokens, cost, updated_at, created_at, summary_message_id, todos, pinned_files, archived_at, env, plan_mode, author, working_dir, bookmarks, tags, variants
+`
+
+// UpdateSessionBookmarksParams 更新会话书签参数结构体
+type UpdateSessionBookmarksParams struct {
+	Bookmarks sql.NullString `json:"bookmarks"` // 书签列表（JSON格式）
+	ID        string         `json:"id"`        // 会话ID
+}
+
+// UpdateSessionBookmarks 仅更新会话的书签列表
+// 参数:
+//   - ctx: 上下文
+//   - arg: 更新会话书签参数
+//
+// 返回:
+//   - Session: 更新后的会话对象
+//   - error: 错误信息
+func (q *Queries) UpdateSessionBookmarks(ctx context.Context, arg UpdateSessionBookmarksParams) (Session, error) {
+	row := q.queryRow(ctx, q.updateSessionBookmarksStmt, updateSessionBookmarks, arg.Bookmarks, arg.ID)
+	var i Session
+	err := row.Scan(
+		&i.ID,
+		&i.ParentSessionID,
+		&i.Title,
+		&i.MessageCount,
+		&i.PromptTokens,
+		&i.CompletionTokens,
+		&i.Cost,
+		&i.UpdatedAt,
+		&i.CreatedAt,
+		&i.SummaryMessageID,
+		&i.Todos,
+		&i.PinnedFiles,
+		&i.ArchivedAt,
+		&i.Env,
+		&i.PlanMode,
+		&i.Author,
+		&i.WorkingDir,
+		&i.Bookmarks,
+		&i.Tags,
+		&i.Variants,
+	)
+	return i, err
+}
+
+const updateSessionEnv = `-- 名称: UpdateSessionEnv :one
+UPDATE sessions
+SET
+    env = ?
+WHERE id = ?
+RETURNING id, parent_session_id, title, message_count, prompt_tokens, completion_tokens, cost, updated_at, created_at, summary_message_id, todos, pinned_files, archived_at, env, plan_mode, author, working_dir, bookmarks, tags, variants
+`
+
+// UpdateSessionEnvParams 更新会话环境变量参数结构体
+type UpdateSessionEnvParams struct {
+	Env sql.NullString `json:"env"` // 会话的环境变量
+	ID  string         `json:"id"`  // 会话ID
+}
+
+// UpdateSessionEnv 仅更新会话的环境变量
+// 参数:
+//   - ctx: 上下文
+//   - arg: 更新会话环境变量参数
+//
+// 返回:
+//   - Session: 更新后的会话对象
+//   - error: 错误信息
+func (q *Queries) UpdateSessionEnv(ctx context.Context, arg UpdateSessionEnvParams) (Session, error) {
+	row := q.queryRow(ctx, q.updateSessionEnvStmt, updateSessionEnv, arg.Env, arg.ID)
+	var i Session
+	err := row.Scan(
+		&i.ID,
+		&i.ParentSessionID,
+		&i.Title,
+		&i.MessageCount,
+		&i.PromptTokens,
+		&i.CompletionTokens,
+		&i.Cost,
+		&i.UpdatedAt,
+		&i.CreatedAt,
+		&i.SummaryMessageID,
+		&i.Todos,
+		&i.PinnedFiles,
+		&i.ArchivedAt,
+		&i.Env,
+		&i.PlanMode,
+		&i.Author,
+		&i.WorkingDir,
+		&i.Bookmarks,
+		&i.Tags,
+		&i.Variants,
+	)
+	return i, err
+}
+
+const updateSessionPinnedFiles = `-- 名称: UpdateSessionPinnedFiles :one
+UPDATE sessions
+SET
+    pinned_files = ?
+WHERE id = ?
+RETURNING id, parent_session_id, title, message_count, prompt_tokens, completion_tokens, cost, updated_at, created_at, summary_message_id, todos, pinned_files, archived_at, env, plan_mode, author, working_dir, bookmarks, tags, variants
+`
+
+// UpdateSessionPinnedFilesParams 更新会话固定文件参数结构体
+type UpdateSessionPinnedFilesParams struct {
+	PinnedFiles sql.NullString `json:"pinned_files"` // 固定的上下文文件
+	ID          string         `json:"id"`           // 会话ID
+}
+
+// UpdateSessionPinnedFiles 仅更新会话的固定文件列表
+// 参数:
+//   - ctx: 上下文
+//   - arg: 更新会话固定文件参数
+//
+// 返回:
+//   - Session: 更新后的会话对象
+//   - error: 错误信息
+func (q *Queries) UpdateSessionPinnedFiles(ctx context.Context, arg UpdateSessionPinnedFilesParams) (Session, error) {
+	row := q.queryRow(ctx, q.updateSessionPinnedFilesStmt, updateSessionPinnedFiles, arg.PinnedFiles, arg.ID)
+	var i Session
+	err := row.Scan(
+		&i.ID,
+		&i.ParentSessionID,
+		&i.Title,
+		&i.MessageCount,
+		&i.PromptTokens,
+		&i.CompletionTokens,
+		&i.Cost,
+		&i.UpdatedAt,
+		&i.CreatedAt,
+		&i.SummaryMessageID,
+		&i.Todos,
+		&i.PinnedFiles,
+		&i.ArchivedAt,
+		&i.Env,
+		&i.PlanMode,
+		&i.Author,
+		&i.WorkingDir,
+		&i.Bookmarks,
+		&i.Tags,
+		&i.Variants,
+	)
+	return i, err
+}
+
+const updateSessionPlanMode = `-- 名称: UpdateSessionPlanMode :one
+UPDATE sessions
+SET
+    plan_mode = ?
+WHERE id = ?
+RETURNING id, parent_session_id, title, message_count, prompt_tokens, completion_tokens, cost, updated_at, created_at, summary_message_id, todos, pinned_files, archived_at, env, plan_mode, author, working_dir, bookmarks, tags, variants
+`
+
+// UpdateSessionPlanModeParams 更新会话计划模式参数结构体
+type UpdateSessionPlanModeParams struct {
+	PlanMode string `json:"plan_mode"` // 计划模式状态
+	ID       string `json:"id"`        // 会话ID
+}
+
+// UpdateSessionPlanMode 仅更新会话的计划模式状态
+// 参数:
+//   - ctx: 上下文
+//   - arg: 更新会话计划模式参数
+//
+// 返回:
+//   - Session: 更新后的会话对象
+//   - error: 错误信息
+func (q *Queries) UpdateSessionPlanMode(ctx context.Context, arg UpdateSessionPlanModeParams) (Session, error) {
+	row := q.queryRow(ctx, q.updateSessionPlanModeStmt, updateSessionPlanMode, arg.PlanMode, arg.ID)
+	var i Session
+	err := row.Scan(
+		&i.ID,
+		&i.ParentSessionID,
+		&i.Title,
+		&i.MessageCount,
+		&i.PromptTokens,
+		&i.CompletionTokens,
+		&i.Cost,
+		&i.UpdatedAt,
+		&i.CreatedAt,
+		&i.SummaryMessageID,
+		&i.Todos,
+		&i.PinnedFiles,
+		&i.ArchivedAt,
+		&i.Env,
+		&i.PlanMode,
+		&i.Author,
+		&i.WorkingDir,
+		&i.Bookmarks,
+		&i.Tags,
+		&i.Variants,
+	)
+	return i, err
+}
+
+const updateSessionTags = `-- 名称: UpdateSessionTags :one
+UPDATE sessions
+SET
+    tags = ?
+WHERE id = ?
+RETURNING id, parent_session_id, title, message_count, prompt_tokens, completion_tokens, cost, updated_at, created_at, summary_message_id, todos, pinned_files, archived_at, env, plan_mode, author, working_dir, bookmarks, tags, variants
+`
+
+// UpdateSessionTagsParams 更新会话标签参数结构体
+type UpdateSessionTagsParams struct {
+	Tags sql.NullString `json:"tags"` // 标签列表（JSON格式）
+	ID   string         `json:"id"`   // 会话ID
+}
+
+// UpdateSessionTags 仅更新会话的标签列表
+// 参数:
+//   - ctx: 上下文
+//   - arg: 更新会话标签参数
+//
+// 返回:
+//   - Session: 更新后的会话对象
+//   - error: 错误信息
+func (q *Queries) UpdateSessionTags(ctx context.Context, arg UpdateSessionTagsParams) (Session, error) {
+	row := q.queryRow(ctx, q.updateSessionTagsStmt, updateSessionTags, arg.Tags, arg.ID)
+	var i Session
+	err := row.Scan(
+		&i.ID,
+		&i.ParentSessionID,
+		&i.Title,
+		&i.MessageCount,
+		&i.PromptTokens,
+		&i.CompletionTokens,
+		&i.Cost,
+		&i.UpdatedAt,
+		&i.CreatedAt,
+		&i.SummaryMessageID,
+		&i.Todos,
+		&i.PinnedFiles,
+		&i.ArchivedAt,
+		&i.Env,
+		&i.PlanMode,
+		&i.Author,
+		&i.WorkingDir,
+		&i.Bookmarks,
+		&i.Tags,
+		&i.Variants,
 	)
 	return i, err
 }
@@ -276,3 +700,153 @@ func (q *Queries) UpdateSessionTitleAndUsage(ctx context.Context, arg UpdateSess
 	)
 	return err
 }
+
+const updateSessionTodos = `-- 名称: UpdateSessionTodos :one
+UPDATE sessions
+SET
+    todos = ?
+WHERE id = ?
+RETURNING id, parent_session_id, title, message_count, prompt_tokens, completion_tokens, cost, updated_at, created_at, summary_message_id, todos, pinned_files, archived_at, env, plan_mode, author, working_dir, bookmarks, tags, variants
+`
+
+// UpdateSessionTodosParams 更新会话待办事项参数结构体
+type UpdateSessionTodosParams struct {
+	Todos sql.NullString `json:"todos"` // 待办事项列表（JSON格式）
+	ID    string         `json:"id"`    // 会话ID
+}
+
+// UpdateSessionTodos 仅更新会话的待办事项列表
+// 参数:
+//   - ctx: 上下文
+//   - arg: 更新会话待办事项参数
+//
+// 返回:
+//   - Session: 更新后的会话对象
+//   - error: 错误信息
+func (q *Queries) UpdateSessionTodos(ctx context.Context, arg UpdateSessionTodosParams) (Session, error) {
+	row := q.queryRow(ctx, q.updateSessionTodosStmt, updateSessionTodos, arg.Todos, arg.ID)
+	var i Session
+	err := row.Scan(
+		&i.ID,
+		&i.ParentSessionID,
+		&i.Title,
+		&i.MessageCount,
+		&i.PromptTokens,
+		&i.CompletionTokens,
+		&i.Cost,
+		&i.UpdatedAt,
+		&i.CreatedAt,
+		&i.SummaryMessageID,
+		&i.Todos,
+		&i.PinnedFiles,
+		&i.ArchivedAt,
+		&i.Env,
+		&i.PlanMode,
+		&i.Author,
+		&i.WorkingDir,
+		&i.Bookmarks,
+		&i.Tags,
+		&i.Variants,
+	)
+	return i, err
+}
+
+const updateSessionVariants = `-- 名称: UpdateSessionVariants :one
+UPDATE sessions
+SET
+    variants = ?
+WHERE id = ?
+RETURNING id, parent_session_id, title, message_count, prompt_tokens, completion_tokens, cost, updated_at, created_at, summary_message_id, todos, pinned_files, archived_at, env, plan_mode, author, working_dir, bookmarks, tags, variants
+`
+
+// UpdateSessionVariantsParams 更新会话备选回复版本参数结构体
+type UpdateSessionVariantsParams struct {
+	Variants sql.NullString `json:"variants"` // 备选回复版本列表（JSON格式）
+	ID       string         `json:"id"`       // 会话ID
+}
+
+// UpdateSessionVariants 仅更新会话的备选回复版本列表
+// 参数:
+//   - ctx: 上下文
+//   - arg: 更新会话备选回复版本参数
+//
+// 返回:
+//   - Session: 更新后的会话对象
+//   - error: 错误信息
+func (q *Queries) UpdateSessionVariants(ctx context.Context, arg UpdateSessionVariantsParams) (Session, error) {
+	row := q.queryRow(ctx, q.updateSessionVariantsStmt, updateSessionVariants, arg.Variants, arg.ID)
+	var i Session
+	err := row.Scan(
+		&i.ID,
+		&i.ParentSessionID,
+		&i.Title,
+		&i.MessageCount,
+		&i.PromptTokens,
+		&i.CompletionTokens,
+		&i.Cost,
+		&i.UpdatedAt,
+		&i.CreatedAt,
+		&i.SummaryMessageID,
+		&i.Todos,
+		&i.PinnedFiles,
+		&i.ArchivedAt,
+		&i.Env,
+		&i.PlanMode,
+		&i.Author,
+		&i.WorkingDir,
+		&i.Bookmarks,
+		&i.Tags,
+		&i.Variants,
+	)
+	return i, err
+}
+
+const updateSessionWorkingDir = `-- 名称: UpdateSessionWorkingDir :one
+UPDATE sessions
+SET
+    working_dir = ?
+WHERE id = ?
+RETURNING id, parent_session_id, title, message_count, prompt_tokens, completion_tokens, cost, updated_at, created_at, summary_message_id, todos, pinned_files, archived_at, env, plan_mode, author, working_dir, bookmarks, tags, variants
+`
+
+// UpdateSessionWorkingDirParams 更新会话工作目录参数结构体
+type UpdateSessionWorkingDirParams struct {
+	WorkingDir string `json:"working_dir"` // 会话的工作目录，相对于项目根目录
+	ID         string `json:"id"`          // 会话ID
+}
+
+// UpdateSessionWorkingDir 仅更新会话的工作目录
+// 参数:
+//   - ctx: 上下文
+//   - arg: 更新会话工作目录参数
+//
+// 返回:
+//   - Session: 更新后的会话对象
+//   - error: 错误信息
+func (q *Queries) UpdateSessionWorkingDir(ctx context.Context, arg UpdateSessionWorkingDirParams) (Session, error) {
+	row := q.queryRow(ctx, q.updateSessionWorkingDirStmt, updateSessionWorkingDir, arg.WorkingDir, arg.ID)
+	var i Session
+	err := row.Scan(
+		&i.ID,
+		&i.ParentSessionID,
+		&i.Title,
+		&i.MessageCount,
+		&i.PromptTokens,
+		&i.CompletionTokens,
+		&i.Cost,
+		&i.UpdatedAt,
+		&i.CreatedAt,
+		&i.SummaryMessageID,
+		&i.Todos,
+		&i.PinnedFiles,
+		&i.ArchivedAt,
+		&i.Env,
+		&i.PlanMode,
+		&i.Author,
+		&i.WorkingDir,
+		&i.Bookmarks,
+		&i.Tags,
+		&i.Variants,
+	)
+	return i, err
+}
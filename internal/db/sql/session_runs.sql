@@ -0,0 +1,31 @@
+-- name: StartSessionRun :exec
+INSERT INTO session_runs (
+    session_id,
+    prompt,
+    queued,
+    pid,
+    started_at
+) VALUES (
+    ?, ?, ?, ?, strftime('%s', 'now')
+) ON CONFLICT(session_id) DO UPDATE SET
+    prompt = excluded.prompt,
+    queued = excluded.queued,
+    pid = excluded.pid,
+    started_at = excluded.started_at;
+
+-- name: UpdateSessionRunQueue :exec
+UPDATE session_runs
+SET queued = ?
+WHERE session_id = ?;
+
+-- name: DeleteSessionRun :exec
+DELETE FROM session_runs
+WHERE session_id = ?;
+
+-- name: ListSessionRuns :many
+SELECT session_runs.*
+FROM session_runs
+JOIN sessions ON sessions.id = session_runs.session_id
+WHERE sessions.parent_session_id IS NULL
+  AND sessions.archived_at IS NULL
+ORDER BY session_runs.started_at DESC;
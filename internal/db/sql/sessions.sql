@@ -8,6 +8,7 @@ INSERT INTO sessions (
     completion_tokens,
     cost,
     summary_message_id,
+    author,
     updated_at,
     created_at
 ) VALUES (
@@ -19,6 +20,7 @@ INSERT INTO sessions (
     ?,
     ?,
     null,
+    ?,
     strftime('%s', 'now'),
     strftime('%s', 'now')
 ) RETURNING *;
@@ -32,8 +34,16 @@ WHERE id = ? LIMIT 1;
 SELECT *
 FROM sessions
 WHERE parent_session_id is NULL
+  AND archived_at IS NULL
 ORDER BY updated_at DESC;
 
+-- name: ListArchivedSessions :many
+SELECT *
+FROM sessions
+WHERE parent_session_id is NULL
+  AND archived_at IS NOT NULL
+ORDER BY archived_at DESC;
+
 -- name: UpdateSession :one
 UPDATE sessions
 SET
@@ -59,3 +69,77 @@ WHERE id = ?;
 -- name: DeleteSession :exec
 DELETE FROM sessions
 WHERE id = ?;
+
+-- name: DeleteSessionDescendants :exec
+DELETE FROM sessions
+WHERE id IN (
+    WITH RECURSIVE descendants(id) AS (
+        SELECT s.id FROM sessions s WHERE s.parent_session_id = ?
+        UNION
+        SELECT s.id FROM sessions s JOIN descendants d ON s.parent_session_id = d.id
+    )
+    SELECT id FROM descendants
+);
+
+-- name: UpdateSessionPinnedFiles :one
+UPDATE sessions
+SET
+    pinned_files = ?
+WHERE id = ?
+RETURNING *;
+
+-- name: UpdateSessionEnv :one
+UPDATE sessions
+SET
+    env = ?
+WHERE id = ?
+RETURNING *;
+
+-- name: UpdateSessionPlanMode :one
+UPDATE sessions
+SET
+    plan_mode = ?
+WHERE id = ?
+RETURNING *;
+
+-- name: UpdateSessionTags :one
+UPDATE sessions
+SET
+    tags = ?
+WHERE id = ?
+RETURNING *;
+
+-- name: UpdateSessionTodos :one
+UPDATE sessions
+SET
+    todos = ?
+WHERE id = ?
+RETURNING *;
+
+-- name: UpdateSessionVariants :one
+UPDATE sessions
+SET
+    variants = ?
+WHERE id = ?
+RETURNING *;
+
+-- name: UpdateSessionWorkingDir :one
+UPDATE sessions
+SET
+    working_dir = ?
+WHERE id = ?
+RETURNING *;
+
+-- name: UpdateSessionArchivedAt :one
+UPDATE sessions
+SET
+    archived_at = ?
+WHERE id = ?
+RETURNING *;
+
+-- name: UpdateSessionBookmarks :one
+UPDATE sessions
+SET
+    bookmarks = ?
+WHERE id = ?
+RETURNING *;
@@ -82,6 +82,21 @@ WHERE json_extract(value, '$.type') = 'tool_call'
 GROUP BY tool_name
 ORDER BY call_count DESC;
 
+-- name: GetModelPerformance :many
+SELECT
+    COALESCE(model, 'unknown') as model,
+    COALESCE(provider, 'unknown') as provider,
+    COUNT(*) as response_count,
+    AVG(json_extract(value, '$.data.metrics.latency_ms')) as avg_latency_ms,
+    AVG(json_extract(value, '$.data.metrics.first_token_ms')) as avg_first_token_ms,
+    AVG(json_extract(value, '$.data.metrics.tokens_per_second')) as avg_tokens_per_second
+FROM messages, json_each(parts)
+WHERE role = 'assistant'
+  AND json_extract(value, '$.type') = 'finish'
+  AND json_extract(value, '$.data.metrics') IS NOT NULL
+GROUP BY model, provider
+ORDER BY response_count DESC;
+
 -- name: GetHourDayHeatmap :many
 SELECT
     CAST(strftime('%w', created_at, 'unixepoch') AS INTEGER) as day_of_week,
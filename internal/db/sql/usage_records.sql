@@ -0,0 +1,16 @@
+-- name: CreateUsageRecord :exec
+INSERT INTO usage_records (
+    id,
+    session_id,
+    provider,
+    model,
+    cost,
+    created_at
+) VALUES (
+    ?, ?, ?, ?, ?, strftime('%s', 'now')
+);
+
+-- name: GetCostSince :one
+SELECT CAST(COALESCE(SUM(cost), 0.0) AS REAL) AS cost
+FROM usage_records
+WHERE created_at >= ?;
@@ -75,6 +75,66 @@ func (q *Queries) GetHourDayHeatmap(ctx context.Context) ([]GetHourDayHeatmapRow
 	return items, nil
 }
 
+// getModelPerformance 按模型获取响应性能的SQL查询
+// 统计助手消息结束部分中记录的延迟、首令牌时间和生成速度
+const getModelPerformance = `-- name: GetModelPerformance :many
+SELECT
+    COALESCE(model, 'unknown') as model,
+    COALESCE(provider, 'unknown') as provider,
+    COUNT(*) as response_count,
+    AVG(json_extract(value, '$.data.metrics.latency_ms')) as avg_latency_ms,
+    AVG(json_extract(value, '$.data.metrics.first_token_ms')) as avg_first_token_ms,
+    AVG(json_extract(value, '$.data.metrics.tokens_per_second')) as avg_tokens_per_second
+FROM messages, json_each(parts)
+WHERE role = 'assistant'
+  AND json_extract(value, '$.type') = 'finish'
+  AND json_extract(value, '$.data.metrics') IS NOT NULL
+GROUP BY model, provider
+ORDER BY response_count DESC
+`
+
+// GetModelPerformanceRow 模型响应性能查询结果行
+type GetModelPerformanceRow struct {
+	Model              string          `json:"model"`                 // 模型名称
+	Provider           string          `json:"provider"`              // 提供商
+	ResponseCount      int64           `json:"response_count"`        // 记录了指标的响应数量
+	AvgLatencyMs       sql.NullFloat64 `json:"avg_latency_ms"`        // 平均提供商延迟（毫秒）
+	AvgFirstTokenMs    sql.NullFloat64 `json:"avg_first_token_ms"`    // 平均首令牌时间（毫秒）
+	AvgTokensPerSecond sql.NullFloat64 `json:"avg_tokens_per_second"` // 平均每秒生成令牌数
+}
+
+// GetModelPerformance 按模型获取响应性能
+// 返回各模型的平均延迟、首令牌时间和生成速度
+func (q *Queries) GetModelPerformance(ctx context.Context) ([]GetModelPerformanceRow, error) {
+	rows, err := q.query(ctx, q.getModelPerformanceStmt, getModelPerformance)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []GetModelPerformanceRow{}
+	for rows.Next() {
+		var i GetModelPerformanceRow
+		if err := rows.Scan(
+			&i.Model,
+			&i.Provider,
+			&i.ResponseCount,
+			&i.AvgLatencyMs,
+			&i.AvgFirstTokenMs,
+			&i.AvgTokensPerSecond,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 // getRecentActivity 获取最近活动统计的SQL查询
 // 统计最近30天内每天的会话数、总令牌数和成本
 const getRecentActivity = `-- name: GetRecentActivity :many
@@ -0,0 +1,164 @@
+// Package dryrun 实现编辑类工具的演练模式：工具照常计算并展示差异，但不修改文件系统，
+// 而是把预期的更改写入补丁文件，用户之后可以使用 git apply 应用。
+package dryrun
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/aymanbagabas/go-udiff"
+	"github.com/purpose168/crush-cn/internal/vfs"
+)
+
+// DirName 是数据目录下存放补丁文件的子目录名。
+const DirName = "dry-run"
+
+// Service 记录演练模式下工具本应写入的更改。
+type Service interface {
+	// Enabled 报告演练模式是否开启。
+	Enabled() bool
+	// SetEnabled 开启或关闭演练模式。关闭后已记录的更改仍保留在补丁文件中。
+	SetEnabled(enabled bool)
+	// Pending 返回会话中 path 的预期内容。文件没有被演练修改过时返回 false。
+	Pending(sessionID, path string) (string, bool)
+	// Record 记录会话中 path 的预期内容，重新生成补丁文件并返回其路径。
+	Record(sessionID, path, content string) (string, error)
+	// PatchPath 返回会话的补丁文件路径。
+	PatchPath(sessionID string) string
+}
+
+// change 是一个文件的原始内容和预期内容。
+type change struct {
+	original string
+	existed  bool
+	content  string
+}
+
+type service struct {
+	workingDir string
+	dir        string
+	fsys       vfs.FS
+	enabled    atomic.Bool
+
+	mu       sync.Mutex
+	sessions map[string]map[string]*change
+}
+
+// NewService 创建演练服务。补丁文件写入 dir，补丁中的路径相对于 workingDir。
+// 被修改文件的原始内容从 fsys 读取，为 nil 时使用本地文件系统。
+func NewService(workingDir, dir string, enabled bool, fsys vfs.FS) Service {
+	s := &service{
+		workingDir: workingDir,
+		dir:        dir,
+		fsys:       vfs.OrLocal(fsys),
+		sessions:   make(map[string]map[string]*change),
+	}
+	s.enabled.Store(enabled)
+	return s
+}
+
+func (s *service) Enabled() bool {
+	return s.enabled.Load()
+}
+
+func (s *service) SetEnabled(enabled bool) {
+	s.enabled.Store(enabled)
+}
+
+func (s *service) Pending(sessionID, path string) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c, ok := s.sessions[sessionID][filepath.Clean(path)]
+	if !ok {
+		return "", false
+	}
+	return c.content, true
+}
+
+func (s *service) Record(sessionID, path, content string) (string, error) {
+	path = filepath.Clean(path)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	changes, ok := s.sessions[sessionID]
+	if !ok {
+		changes = make(map[string]*change)
+		s.sessions[sessionID] = changes
+	}
+	c, ok := changes[path]
+	if !ok {
+		// 第一次修改该文件时记录磁盘上的原始内容，补丁始终相对于它生成。
+		c = &change{}
+		data, err := s.fsys.ReadFile(context.Background(), path)
+		switch {
+		case err == nil:
+			c.original, c.existed = string(data), true
+		case !errors.Is(err, os.ErrNotExist):
+			return "", fmt.Errorf("读取文件 %s 失败: %w", path, err)
+		}
+		changes[path] = c
+	}
+	c.content = content
+
+	patchPath := s.PatchPath(sessionID)
+	patch := s.patch(changes)
+	if patch == "" {
+		if err := os.Remove(patchPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return "", fmt.Errorf("删除补丁文件失败: %w", err)
+		}
+		return patchPath, nil
+	}
+	if err := os.MkdirAll(s.dir, 0o755); err != nil {
+		return "", fmt.Errorf("创建补丁目录失败: %w", err)
+	}
+	if err := os.WriteFile(patchPath, []byte(patch), 0o644); err != nil {
+		return "", fmt.Errorf("写入补丁文件失败: %w", err)
+	}
+	return patchPath, nil
+}
+
+func (s *service) PatchPath(sessionID string) string {
+	return filepath.Join(s.dir, sessionID+".patch")
+}
+
+// patch 按路径排序生成可用 git apply 应用的补丁，相同的更改总是生成相同的补丁。
+func (s *service) patch(changes map[string]*change) string {
+	paths := make([]string, 0, len(changes))
+	for path := range changes {
+		paths = append(paths, path)
+	}
+	slices.Sort(paths)
+
+	var sb strings.Builder
+	for _, path := range paths {
+		c := changes[path]
+		if c.existed && c.original == c.content {
+			continue
+		}
+		name := s.relPath(path)
+		fmt.Fprintf(&sb, "diff --git a/%s b/%s\n", name, name)
+		if c.existed {
+			sb.WriteString(udiff.Unified("a/"+name, "b/"+name, c.original, c.content))
+		} else {
+			sb.WriteString("new file mode 100644\n")
+			sb.WriteString(udiff.Unified("/dev/null", "b/"+name, "", c.content))
+		}
+	}
+	return sb.String()
+}
+
+// relPath 返回相对于工作目录、使用正斜杠的路径。
+func (s *service) relPath(path string) string {
+	if rel, err := filepath.Rel(s.workingDir, path); err == nil {
+		path = rel
+	}
+	return filepath.ToSlash(path)
+}
@@ -0,0 +1,92 @@
+package dryrun
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecord(t *testing.T) {
+	t.Parallel()
+
+	workingDir := t.TempDir()
+	existing := filepath.Join(workingDir, "main.go")
+	require.NoError(t, os.WriteFile(existing, []byte("package main\n\nfunc main() {}\n"), 0o644))
+	created := filepath.Join(workingDir, "pkg", "util.go")
+
+	s := NewService(workingDir, filepath.Join(t.TempDir(), DirName), true, nil)
+
+	_, ok := s.Pending("s1", existing)
+	require.False(t, ok)
+
+	patchPath, err := s.Record("s1", created, "package pkg\n")
+	require.NoError(t, err)
+	require.Equal(t, s.PatchPath("s1"), patchPath)
+	_, err = s.Record("s1", existing, "package main\n\nfunc main() {\n\tprintln(1)\n}\n")
+	require.NoError(t, err)
+	_, err = s.Record("s1", existing, "package main\n\nfunc main() {\n\tprintln(2)\n}\n")
+	require.NoError(t, err)
+
+	// 文件系统保持不变。
+	data, err := os.ReadFile(existing)
+	require.NoError(t, err)
+	require.Equal(t, "package main\n\nfunc main() {}\n", string(data))
+	require.NoFileExists(t, created)
+
+	content, ok := s.Pending("s1", existing)
+	require.True(t, ok)
+	require.Contains(t, content, "println(2)")
+	_, ok = s.Pending("s2", existing)
+	require.False(t, ok)
+
+	patch, err := os.ReadFile(patchPath)
+	require.NoError(t, err)
+	require.Equal(t, `diff --git a/main.go b/main.go
+--- a/main.go
++++ b/main.go
+@@ -1,3 +1,5 @@
+ package main
+ 
+-func main() {}
++func main() {
++	println(2)
++}
+diff --git a/pkg/util.go b/pkg/util.go
+new file mode 100644
+--- /dev/null
++++ b/pkg/util.go
+@@ -0,0 +1 @@
++package pkg
+`, string(patch))
+
+	if _, err := exec.LookPath("git"); err == nil {
+		cmd := exec.Command("git", "apply", patchPath)
+		cmd.Dir = workingDir
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(out))
+		data, err = os.ReadFile(existing)
+		require.NoError(t, err)
+		require.Contains(t, string(data), "println(2)")
+		require.FileExists(t, created)
+	}
+}
+
+func TestRecordRevertRemovesPatch(t *testing.T) {
+	t.Parallel()
+
+	workingDir := t.TempDir()
+	path := filepath.Join(workingDir, "a.txt")
+	require.NoError(t, os.WriteFile(path, []byte("a\n"), 0o644))
+
+	s := NewService(workingDir, t.TempDir(), true, nil)
+	patchPath, err := s.Record("s1", path, "b\n")
+	require.NoError(t, err)
+	require.FileExists(t, patchPath)
+
+	_, err = s.Record("s1", path, "a\n")
+	require.NoError(t, err)
+	require.NoFileExists(t, patchPath)
+}
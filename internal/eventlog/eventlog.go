@@ -0,0 +1,180 @@
+// Package eventlog 将代理运行中的结构化事件以 JSONL 格式追加写入每个会话的日志文件，
+// 供外部工具审计代理行为。
+//
+// 每行是一个 [Event] 的 JSON 编码。字段只会新增不会删除或改变含义；不兼容的变更会递增
+// [SchemaVersion]。
+package eventlog
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// SchemaVersion 是事件格式的版本。
+	SchemaVersion = 1
+	// DirName 是数据目录中存放事件日志的目录名。
+	DirName = "events"
+)
+
+// Type 是事件的类型。
+type Type string
+
+const (
+	// TypeRunStarted 在代理开始处理一条提示时记录。
+	TypeRunStarted Type = "run_started"
+	// TypeRunFinished 在代理处理完一条提示时记录，失败时包含 Error。
+	TypeRunFinished Type = "run_finished"
+	// TypeModelRequest 在每次向模型发送请求前记录。
+	TypeModelRequest Type = "model_request"
+	// TypeModelResponse 在每次模型响应完成时记录，包含用量和费用。
+	TypeModelResponse Type = "model_response"
+	// TypeToolCall 在模型调用工具时记录。
+	TypeToolCall Type = "tool_call"
+	// TypeToolResult 在工具返回结果时记录。
+	TypeToolResult Type = "tool_result"
+	// TypePermissionRequested 在工具请求权限时记录。
+	TypePermissionRequested Type = "permission_requested"
+	// TypePermissionResolved 在权限请求被批准或拒绝时记录。
+	TypePermissionResolved Type = "permission_resolved"
+	// TypeRedaction 在工具结果或附件中的敏感信息被脱敏时记录。
+	TypeRedaction Type = "redaction"
+)
+
+// Usage 是一次模型响应的令牌用量。
+type Usage struct {
+	InputTokens         int64 `json:"input_tokens"`
+	OutputTokens        int64 `json:"output_tokens"`
+	CacheCreationTokens int64 `json:"cache_creation_tokens"`
+	CacheReadTokens     int64 `json:"cache_read_tokens"`
+	ReasoningTokens     int64 `json:"reasoning_tokens"`
+}
+
+// Event 是事件日志中的一条记录。除 Version、Time、SessionID 和 Type 外，
+// 其余字段仅在与事件类型相关时出现。
+type Event struct {
+	Version   int       `json:"version"`
+	Time      time.Time `json:"time"`
+	SessionID string    `json:"session_id"`
+	Type      Type      `json:"type"`
+
+	Provider string `json:"provider,omitempty"`
+	Model    string `json:"model,omitempty"`
+	// Messages 是 model_request 中发送给模型的消息数。
+	Messages     int      `json:"messages,omitempty"`
+	FinishReason string   `json:"finish_reason,omitempty"`
+	Usage        *Usage   `json:"usage,omitempty"`
+	Cost         *float64 `json:"cost,omitempty"`
+
+	ToolCallID string `json:"tool_call_id,omitempty"`
+	ToolName   string `json:"tool_name,omitempty"`
+	// Input 是工具调用的 JSON 参数。
+	Input string `json:"input,omitempty"`
+	// OutputBytes 是工具结果内容的字节数。
+	OutputBytes int  `json:"output_bytes,omitempty"`
+	IsError     bool `json:"is_error,omitempty"`
+
+	Action  string `json:"action,omitempty"`
+	Path    string `json:"path,omitempty"`
+	Granted *bool  `json:"granted,omitempty"`
+
+	// Redactions 是 redaction 中每条脱敏规则的匹配次数。
+	Redactions map[string]int `json:"redactions,omitempty"`
+
+	DurationMS int64  `json:"duration_ms,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+// Logger 将事件追加写入 dir 中每个会话的 JSONL 文件。nil Logger 会忽略所有事件。
+type Logger struct {
+	dir string
+	mu  sync.Mutex
+}
+
+// New 创建将事件写入 dir 的 [Logger]。目录在首次写入时创建。
+func New(dir string) *Logger {
+	return &Logger{dir: dir}
+}
+
+// Log 追加一条事件。写入失败只记录日志，不会影响代理运行。
+func (l *Logger) Log(e Event) {
+	if l == nil || e.SessionID == "" {
+		return
+	}
+	e.Version = SchemaVersion
+	if e.Time.IsZero() {
+		e.Time = time.Now().UTC()
+	}
+
+	data, err := json.Marshal(e)
+	if err != nil {
+		slog.Error("Failed to encode event", "error", err)
+		return
+	}
+	data = append(data, '\n')
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if err := l.append(e.SessionID, data); err != nil {
+		slog.Error("Failed to write event log", "session_id", e.SessionID, "error", err)
+	}
+}
+
+func (l *Logger) append(sessionID string, data []byte) error {
+	if err := os.MkdirAll(l.dir, 0o700); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(Path(l.dir, sessionID), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Path 返回 dir 中会话事件日志文件的路径。
+func Path(dir, sessionID string) string {
+	name := strings.NewReplacer("/", "_", "\\", "_", "..", "_").Replace(sessionID)
+	return filepath.Join(dir, name+".jsonl")
+}
+
+// ErrNotFound 表示会话没有事件日志。
+var ErrNotFound = errors.New("未找到会话的事件日志")
+
+// Read 读取会话的所有事件。无法解析的行会被跳过。
+func Read(dir, sessionID string) ([]Event, error) {
+	f, err := os.Open(Path(dir, sessionID))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("打开事件日志失败: %w", err)
+	}
+	defer f.Close()
+
+	var events []Event
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var e Event
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			continue
+		}
+		events = append(events, e)
+	}
+	if err := scanner.Err(); err != nil {
+		return events, fmt.Errorf("读取事件日志失败: %w", err)
+	}
+	return events, nil
+}
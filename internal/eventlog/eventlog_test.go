@@ -0,0 +1,85 @@
+package eventlog
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/purpose168/crush-cn/internal/permission"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogAndRead(t *testing.T) {
+	t.Parallel()
+
+	dir := filepath.Join(t.TempDir(), DirName)
+	l := New(dir)
+	cost := 0.25
+	l.Log(Event{SessionID: "s1", Type: TypeRunStarted, Provider: "openai", Model: "gpt-4o"})
+	l.Log(Event{SessionID: "s1", Type: TypeToolCall, ToolCallID: "call-1", ToolName: "bash", Input: `{"command":"ls"}`})
+	l.Log(Event{SessionID: "s1", Type: TypeModelResponse, Usage: &Usage{InputTokens: 10, OutputTokens: 5}, Cost: &cost})
+	l.Log(Event{SessionID: "s2", Type: TypeRunStarted})
+	l.Log(Event{Type: TypeRunStarted})
+
+	events, err := Read(dir, "s1")
+	require.NoError(t, err)
+	require.Len(t, events, 3)
+	for _, e := range events {
+		require.Equal(t, SchemaVersion, e.Version)
+		require.Equal(t, "s1", e.SessionID)
+		require.False(t, e.Time.IsZero())
+	}
+	require.Equal(t, TypeToolCall, events[1].Type)
+	require.Equal(t, `{"command":"ls"}`, events[1].Input)
+	require.Equal(t, int64(10), events[2].Usage.InputTokens)
+	require.Equal(t, 0.25, *events[2].Cost)
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+
+	_, err = Read(dir, "missing")
+	require.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestPathSanitizesSessionID(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, filepath.Join("events", "____etc_passwd.jsonl"), Path("events", "../../etc/passwd"))
+}
+
+type fakePermissions struct {
+	permission.Service
+	granted bool
+}
+
+func (f fakePermissions) Request(context.Context, permission.CreatePermissionRequest) (bool, error) {
+	return f.granted, nil
+}
+
+func TestWrapPermissions(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	svc := WrapPermissions(fakePermissions{granted: false}, New(dir))
+	granted, err := svc.Request(t.Context(), permission.CreatePermissionRequest{
+		SessionID:  "s1",
+		ToolCallID: "call-1",
+		ToolName:   "bash",
+		Action:     "execute",
+		Path:       "/tmp",
+	})
+	require.NoError(t, err)
+	require.False(t, granted)
+
+	events, err := Read(dir, "s1")
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	require.Equal(t, TypePermissionRequested, events[0].Type)
+	require.Nil(t, events[0].Granted)
+	require.Equal(t, TypePermissionResolved, events[1].Type)
+	require.NotNil(t, events[1].Granted)
+	require.False(t, *events[1].Granted)
+	require.Equal(t, "bash", events[1].ToolName)
+}
@@ -0,0 +1,49 @@
+package eventlog
+
+import (
+	"context"
+
+	"github.com/purpose168/crush-cn/internal/permission"
+)
+
+// permissionService 包装 [permission.Service]，记录每个权限请求及其结果。
+type permissionService struct {
+	permission.Service
+	log *Logger
+}
+
+// WrapPermissions 返回记录权限请求到 log 的 [permission.Service]。
+func WrapPermissions(svc permission.Service, log *Logger) permission.Service {
+	if log == nil {
+		return svc
+	}
+	return &permissionService{Service: svc, log: log}
+}
+
+func (s *permissionService) Request(ctx context.Context, opts permission.CreatePermissionRequest) (bool, error) {
+	s.log.Log(Event{
+		SessionID:  opts.SessionID,
+		Type:       TypePermissionRequested,
+		ToolCallID: opts.ToolCallID,
+		ToolName:   opts.ToolName,
+		Action:     opts.Action,
+		Path:       opts.Path,
+	})
+
+	granted, err := s.Service.Request(ctx, opts)
+
+	resolved := Event{
+		SessionID:  opts.SessionID,
+		Type:       TypePermissionResolved,
+		ToolCallID: opts.ToolCallID,
+		ToolName:   opts.ToolName,
+		Action:     opts.Action,
+		Path:       opts.Path,
+		Granted:    &granted,
+	}
+	if err != nil {
+		resolved.Error = err.Error()
+	}
+	s.log.Log(resolved)
+	return granted, err
+}
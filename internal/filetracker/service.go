@@ -26,19 +26,36 @@ type Service interface {
 }
 
 type service struct {
-	q *db.Queries
+	q    *db.Queries
+	root string
 }
 
-// NewService creates a new file tracker service.
+// NewService creates a new file tracker service. Paths are stored relative
+// to the process working directory.
 func NewService(q *db.Queries) Service {
 	return &service{q: q}
 }
 
+// NewServiceWithRoot creates a file tracker service that stores paths
+// relative to root instead of the process working directory. It is used
+// when the tracked files live on a remote host.
+func NewServiceWithRoot(q *db.Queries, root string) Service {
+	return &service{q: q, root: root}
+}
+
+// basepath returns the directory stored paths are relative to.
+func (s *service) basepath() (string, error) {
+	if s.root != "" {
+		return s.root, nil
+	}
+	return os.Getwd()
+}
+
 // RecordRead records when a file was read.
 func (s *service) RecordRead(ctx context.Context, sessionID, path string) {
 	if err := s.q.RecordFileRead(ctx, db.RecordFileReadParams{
 		SessionID: sessionID,
-		Path:      relpath(path),
+		Path:      s.relpath(path),
 	}); err != nil {
 		slog.Error("Error recording file read", "error", err, "file", path)
 	}
@@ -49,7 +66,7 @@ func (s *service) RecordRead(ctx context.Context, sessionID, path string) {
 func (s *service) LastReadTime(ctx context.Context, sessionID, path string) time.Time {
 	readFile, err := s.q.GetFileRead(ctx, db.GetFileReadParams{
 		SessionID: sessionID,
-		Path:      relpath(path),
+		Path:      s.relpath(path),
 	})
 	if err != nil {
 		return time.Time{}
@@ -58,9 +75,9 @@ func (s *service) LastReadTime(ctx context.Context, sessionID, path string) time
 	return time.Unix(readFile.ReadAt, 0)
 }
 
-func relpath(path string) string {
+func (s *service) relpath(path string) string {
 	path = filepath.Clean(path)
-	basepath, err := os.Getwd()
+	basepath, err := s.basepath()
 	if err != nil {
 		slog.Warn("Error getting basepath", "error", err)
 		return path
@@ -80,7 +97,7 @@ func (s *service) ListReadFiles(ctx context.Context, sessionID string) ([]string
 		return nil, fmt.Errorf("listing read files: %w", err)
 	}
 
-	basepath, err := os.Getwd()
+	basepath, err := s.basepath()
 	if err != nil {
 		return nil, fmt.Errorf("getting working directory: %w", err)
 	}
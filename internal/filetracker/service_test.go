@@ -114,3 +114,23 @@ func TestService_RecordRead_DifferentPaths(t *testing.T) {
 	lastRead2 := env.svc.LastReadTime(env.ctx, sessionID, path2)
 	require.True(t, lastRead2.IsZero(), "path2 should not be recorded")
 }
+
+func TestService_WithRoot(t *testing.T) {
+	env := setupTest(t)
+	svc := NewServiceWithRoot(env.q, "/srv/project")
+
+	sessionID := "test-session-root"
+	env.createSession(t, sessionID)
+
+	svc.RecordRead(env.ctx, sessionID, "/srv/project/internal/main.go")
+
+	readFiles, err := env.q.ListSessionReadFiles(env.ctx, sessionID)
+	require.NoError(t, err)
+	require.Len(t, readFiles, 1)
+	require.Equal(t, "internal/main.go", readFiles[0].Path)
+
+	paths, err := svc.ListReadFiles(env.ctx, sessionID)
+	require.NoError(t, err)
+	require.Equal(t, []string{"/srv/project/internal/main.go"}, paths)
+	require.False(t, svc.LastReadTime(env.ctx, sessionID, "/srv/project/internal/main.go").IsZero())
+}
@@ -0,0 +1,530 @@
+// Package gemini 为 Gemini API 的模型请求提供文件 API 和上下文缓存支持。
+//
+// [Transport] 拦截 generateContent 请求：较大的内联附件上传到文件 API 后以文件 URI 引用，
+// 系统指令和工具定义放入显式上下文缓存，后续轮次只发送缓存名称。上传的文件和缓存按内容哈希复用，
+// 长会话中重复的大块上下文只上传一次，既减少请求体积也按缓存价格计费。
+package gemini
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultTTL 是上下文缓存的默认有效期，与 Gemini API 的默认值相同
+	DefaultTTL = time.Hour
+
+	// MinUploadSize 是上传到文件 API 的内联附件的最小字节数，更小的附件仍然内联发送
+	MinUploadSize = 256 << 10
+
+	// fileExpiryMargin 是文件到期前停止引用它的余量，到期前重新上传
+	fileExpiryMargin = time.Hour
+	// cacheExpiryMargin 是缓存到期前停止使用它的余量，避免请求途中缓存过期
+	cacheExpiryMargin = time.Minute
+	// fileActiveTimeout 是等待上传的文件处理完成的最长时间
+	fileActiveTimeout = 10 * time.Second
+	// filePollInterval 是查询文件处理状态的间隔
+	filePollInterval = 500 * time.Millisecond
+)
+
+// Transport 是 Gemini API 的 HTTP 传输层。它重写 generateContent 和 streamGenerateContent
+// 请求以使用文件 API 和上下文缓存，其他请求原样转发。上传文件或创建缓存失败时回退为原始请求；
+// 重写后的请求因引用的文件或缓存失效而被拒绝时，丢弃这些引用并用原始请求重试一次。
+type Transport struct {
+	// Base 是实际发送请求的传输层，为 nil 时使用 [http.DefaultTransport]
+	Base http.RoundTripper
+	// TTL 是创建的上下文缓存的有效期，为 0 时使用 [DefaultTTL]
+	TTL time.Duration
+	// Cache 保存上传的文件和创建的缓存，为 nil 时使用 Transport 自己的 Cache
+	Cache *Cache
+
+	once sync.Once
+}
+
+// Cache 保存上传的文件和创建的上下文缓存。同一提供者的所有 [Transport] 应共享一个 Cache，
+// 使文件和缓存在编码代理、子代理和重建后的模型间复用。
+type Cache struct {
+	mu       sync.Mutex
+	files    map[string]uploadedFile
+	contexts map[string]cachedContent
+}
+
+// NewCache 创建一个空的 [Cache]。
+func NewCache() *Cache {
+	return &Cache{
+		files:    make(map[string]uploadedFile),
+		contexts: make(map[string]cachedContent),
+	}
+}
+
+// uploadedFile 是上传到文件 API 的文件
+type uploadedFile struct {
+	URI      string
+	MIMEType string
+	Expires  time.Time
+}
+
+// cachedContent 是创建的上下文缓存。Name 为空表示创建失败（例如内容少于模型的最小缓存令牌数），
+// 在 Expires 之前不再尝试
+type cachedContent struct {
+	Name    string
+	Expires time.Time
+}
+
+// RoundTrip 实现 [http.RoundTripper] 接口。
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !isGenerateRequest(req) {
+		return t.base().RoundTrip(req)
+	}
+
+	original, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	ep, ok := parseEndpoint(req)
+	if !ok {
+		return t.base().RoundTrip(withBody(req, original))
+	}
+
+	rewritten, used := t.rewrite(req.Context(), ep, req.Header, original)
+	if len(used.files) == 0 && used.cache == "" {
+		return t.base().RoundTrip(withBody(req, original))
+	}
+
+	resp, err := t.base().RoundTrip(withBody(req, rewritten))
+	if err != nil || !isRejected(resp.StatusCode) {
+		return resp, err
+	}
+
+	// 引用的文件或缓存可能已被删除或过期，丢弃它们并发送原始请求
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 4<<10))
+	resp.Body.Close()
+	slog.Warn("Gemini 拒绝了引用文件或缓存的请求，改用原始请求重试", "status", resp.StatusCode, "body", string(body))
+	t.forget(used)
+	return t.base().RoundTrip(withBody(req, original))
+}
+
+func (t *Transport) base() http.RoundTripper {
+	if t.Base != nil {
+		return t.Base
+	}
+	return http.DefaultTransport
+}
+
+func (t *Transport) cache() *Cache {
+	t.once.Do(func() {
+		if t.Cache == nil {
+			t.Cache = NewCache()
+		}
+	})
+	return t.Cache
+}
+
+func (t *Transport) ttl() time.Duration {
+	if t.TTL > 0 {
+		return t.TTL
+	}
+	return DefaultTTL
+}
+
+// endpoint 描述一个 generateContent 请求的目标
+type endpoint struct {
+	// root 是协议和主机，例如 https://generativelanguage.googleapis.com
+	root string
+	// version 是 API 版本路径，例如 /v1beta
+	version string
+	// model 是模型资源名称，例如 models/gemini-2.5-pro
+	model string
+	// query 是原始请求中的 API 密钥参数，使用请求头认证时为空
+	query string
+}
+
+func (e endpoint) url(path string) string {
+	return e.root + e.version + "/" + path + e.query
+}
+
+func (e endpoint) uploadURL() string {
+	return e.root + "/upload" + e.version + "/files" + e.query
+}
+
+// isGenerateRequest 报告请求是否是生成内容的请求。
+func isGenerateRequest(req *http.Request) bool {
+	if req.Method != http.MethodPost || req.Body == nil {
+		return false
+	}
+	return strings.HasSuffix(req.URL.Path, ":generateContent") ||
+		strings.HasSuffix(req.URL.Path, ":streamGenerateContent")
+}
+
+// parseEndpoint 从形如 /v1beta/models/{model}:generateContent 的路径中解析请求目标。
+// Vertex AI 的路径包含项目和区域，它的文件需要存放在 Cloud Storage 中，因此不做处理。
+func parseEndpoint(req *http.Request) (endpoint, bool) {
+	path := req.URL.Path
+	idx := strings.Index(path, "/models/")
+	if idx < 0 || strings.Contains(path[:idx], "/projects/") {
+		return endpoint{}, false
+	}
+	model, _, ok := strings.Cut(path[idx+1:], ":")
+	if !ok {
+		return endpoint{}, false
+	}
+	ep := endpoint{
+		root:    req.URL.Scheme + "://" + req.URL.Host,
+		version: path[:idx],
+		model:   model,
+	}
+	if key := req.URL.Query().Get("key"); key != "" {
+		ep.query = "?" + url.Values{"key": {key}}.Encode()
+	}
+	return ep, true
+}
+
+// isRejected 报告响应状态是否可能由失效的文件或缓存引用导致。
+func isRejected(status int) bool {
+	return status == http.StatusBadRequest || status == http.StatusForbidden || status == http.StatusNotFound
+}
+
+func withBody(req *http.Request, body []byte) *http.Request {
+	clone := req.Clone(req.Context())
+	clone.Body = io.NopCloser(bytes.NewReader(body))
+	clone.ContentLength = int64(len(body))
+	clone.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(body)), nil
+	}
+	return clone
+}
+
+// references 是重写后的请求引用的文件和缓存的键
+type references struct {
+	files []string
+	cache string
+}
+
+// content 是请求中的一条内容。部分使用原始 JSON 保存，以保留重写时不关心的字段
+type content struct {
+	Role  string                       `json:"role,omitempty"`
+	Parts []map[string]json.RawMessage `json:"parts"`
+}
+
+type blob struct {
+	MIMEType string `json:"mimeType"`
+	Data     []byte `json:"data"`
+}
+
+type fileData struct {
+	MIMEType string `json:"mimeType"`
+	FileURI  string `json:"fileUri"`
+}
+
+// rewrite 把请求中的大附件替换为文件引用，并把系统指令和工具定义替换为上下文缓存。
+// 没有可以重写的内容或重写失败时返回原始请求体和空的 references。
+func (t *Transport) rewrite(ctx context.Context, ep endpoint, header http.Header, body []byte) ([]byte, references) {
+	var request map[string]json.RawMessage
+	if err := json.Unmarshal(body, &request); err != nil {
+		return body, references{}
+	}
+
+	var used references
+	if raw, ok := request["contents"]; ok {
+		var contents []content
+		if err := json.Unmarshal(raw, &contents); err == nil {
+			used.files = t.uploadParts(ctx, ep, header, contents)
+			if len(used.files) > 0 {
+				if data, err := json.Marshal(contents); err == nil {
+					request["contents"] = data
+				} else {
+					used.files = nil
+				}
+			}
+		}
+	}
+
+	if _, ok := request["cachedContent"]; !ok {
+		if key, name := t.contextCache(ctx, ep, header, request); name != "" {
+			delete(request, "systemInstruction")
+			delete(request, "tools")
+			delete(request, "toolConfig")
+			request["cachedContent"], _ = json.Marshal(name)
+			used.cache = key
+		}
+	}
+
+	if len(used.files) == 0 && used.cache == "" {
+		return body, references{}
+	}
+	data, err := json.Marshal(request)
+	if err != nil {
+		return body, references{}
+	}
+	return data, used
+}
+
+// uploadParts 把超过 [MinUploadSize] 的内联数据上传到文件 API 并替换为文件引用，返回引用的文件键。
+func (t *Transport) uploadParts(ctx context.Context, ep endpoint, header http.Header, contents []content) []string {
+	var keys []string
+	for _, c := range contents {
+		for _, part := range c.Parts {
+			raw, ok := part["inlineData"]
+			if !ok || len(raw) < MinUploadSize {
+				continue
+			}
+			var b blob
+			if err := json.Unmarshal(raw, &b); err != nil || len(b.Data) < MinUploadSize {
+				continue
+			}
+			key := hashKey(ep.root, b.MIMEType, string(b.Data))
+			file, err := t.file(ctx, ep, header, key, b)
+			if err != nil {
+				slog.Warn("上传附件到 Gemini 文件 API 失败，改为内联发送", "mime_type", b.MIMEType, "size", len(b.Data), "error", err)
+				continue
+			}
+			data, err := json.Marshal(fileData{MIMEType: file.MIMEType, FileURI: file.URI})
+			if err != nil {
+				continue
+			}
+			delete(part, "inlineData")
+			part["fileData"] = data
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// file 返回已上传的文件，没有或即将过期时重新上传。
+func (t *Transport) file(ctx context.Context, ep endpoint, header http.Header, key string, b blob) (uploadedFile, error) {
+	c := t.cache()
+	c.mu.Lock()
+	file, ok := c.files[key]
+	c.mu.Unlock()
+	if ok && time.Until(file.Expires) > fileExpiryMargin {
+		return file, nil
+	}
+
+	file, err := t.upload(ctx, ep, header, b)
+	if err != nil {
+		return uploadedFile{}, err
+	}
+	c.mu.Lock()
+	c.files[key] = file
+	c.mu.Unlock()
+	slog.Debug("已上传附件到 Gemini 文件 API", "uri", file.URI, "size", len(b.Data))
+	return file, nil
+}
+
+// fileResource 是文件 API 返回的文件
+type fileResource struct {
+	Name           string    `json:"name"`
+	URI            string    `json:"uri"`
+	MIMEType       string    `json:"mimeType"`
+	State          string    `json:"state"`
+	ExpirationTime time.Time `json:"expirationTime"`
+}
+
+// upload 使用可恢复上传协议上传文件，并等待文件处理完成。
+func (t *Transport) upload(ctx context.Context, ep endpoint, header http.Header, b blob) (uploadedFile, error) {
+	meta, err := json.Marshal(map[string]any{"file": map[string]string{"displayName": "crush-attachment"}})
+	if err != nil {
+		return uploadedFile{}, err
+	}
+	start := newRequest(ctx, http.MethodPost, ep.uploadURL(), header, meta)
+	start.Header.Set("Content-Type", "application/json")
+	start.Header.Set("X-Goog-Upload-Protocol", "resumable")
+	start.Header.Set("X-Goog-Upload-Command", "start")
+	start.Header.Set("X-Goog-Upload-Header-Content-Length", fmt.Sprint(len(b.Data)))
+	start.Header.Set("X-Goog-Upload-Header-Content-Type", b.MIMEType)
+	resp, err := t.base().RoundTrip(start)
+	if err != nil {
+		return uploadedFile{}, err
+	}
+	uploadURL := resp.Header.Get("X-Goog-Upload-URL")
+	if err := checkResponse(resp, nil); err != nil {
+		return uploadedFile{}, err
+	}
+	if uploadURL == "" {
+		return uploadedFile{}, errors.New("响应中缺少上传地址")
+	}
+
+	finish := newRequest(ctx, http.MethodPost, uploadURL, header, b.Data)
+	finish.Header.Set("X-Goog-Upload-Offset", "0")
+	finish.Header.Set("X-Goog-Upload-Command", "upload, finalize")
+	resp, err = t.base().RoundTrip(finish)
+	if err != nil {
+		return uploadedFile{}, err
+	}
+	var result struct {
+		File fileResource `json:"file"`
+	}
+	if err := checkResponse(resp, &result); err != nil {
+		return uploadedFile{}, err
+	}
+
+	file := result.File
+	deadline := time.Now().Add(fileActiveTimeout)
+	for file.State == "PROCESSING" {
+		if time.Now().After(deadline) {
+			return uploadedFile{}, fmt.Errorf("文件 %s 处理超时", file.Name)
+		}
+		select {
+		case <-ctx.Done():
+			return uploadedFile{}, ctx.Err()
+		case <-time.After(filePollInterval):
+		}
+		resp, err := t.base().RoundTrip(newRequest(ctx, http.MethodGet, ep.url(file.Name), header, nil))
+		if err != nil {
+			return uploadedFile{}, err
+		}
+		if err := checkResponse(resp, &file); err != nil {
+			return uploadedFile{}, err
+		}
+	}
+	if file.State != "" && file.State != "ACTIVE" {
+		return uploadedFile{}, fmt.Errorf("文件 %s 处于 %s 状态", file.Name, file.State)
+	}
+	if file.URI == "" {
+		return uploadedFile{}, errors.New("响应中缺少文件 URI")
+	}
+	expires := file.ExpirationTime
+	if expires.IsZero() {
+		// 文件 API 保存文件 48 小时
+		expires = time.Now().Add(48 * time.Hour)
+	}
+	return uploadedFile{
+		URI:      file.URI,
+		MIMEType: cmp.Or(file.MIMEType, b.MIMEType),
+		Expires:  expires,
+	}, nil
+}
+
+// contextCache 返回请求的系统指令和工具定义对应的上下文缓存名称，没有时创建缓存。
+// 返回的键用于在缓存失效时丢弃它；无法使用缓存时名称为空。
+func (t *Transport) contextCache(ctx context.Context, ep endpoint, header http.Header, request map[string]json.RawMessage) (string, string) {
+	system, tools, toolConfig := request["systemInstruction"], request["tools"], request["toolConfig"]
+	if len(system) == 0 && len(tools) == 0 {
+		return "", ""
+	}
+	key := hashKey(ep.root, ep.model, string(system), string(tools), string(toolConfig))
+
+	c := t.cache()
+	c.mu.Lock()
+	cached, ok := c.contexts[key]
+	c.mu.Unlock()
+	if ok && time.Until(cached.Expires) > cacheExpiryMargin {
+		return key, cached.Name
+	}
+
+	ttl := t.ttl()
+	cached, err := t.createCache(ctx, ep, header, system, tools, toolConfig, ttl)
+	if err != nil {
+		// 内容少于模型的最小缓存令牌数等情况下创建会失败，在有效期内不再尝试
+		slog.Debug("创建 Gemini 上下文缓存失败", "model", ep.model, "error", err)
+		cached = cachedContent{Expires: time.Now().Add(ttl)}
+	} else {
+		slog.Debug("已创建 Gemini 上下文缓存", "model", ep.model, "name", cached.Name, "ttl", ttl)
+	}
+	c.mu.Lock()
+	c.contexts[key] = cached
+	c.mu.Unlock()
+	return key, cached.Name
+}
+
+func (t *Transport) createCache(ctx context.Context, ep endpoint, header http.Header, system, tools, toolConfig json.RawMessage, ttl time.Duration) (cachedContent, error) {
+	request := map[string]any{
+		"model": ep.model,
+		"ttl":   fmt.Sprintf("%ds", int64(ttl.Seconds())),
+	}
+	if len(system) > 0 {
+		request["systemInstruction"] = system
+	}
+	if len(tools) > 0 {
+		request["tools"] = tools
+	}
+	if len(toolConfig) > 0 {
+		request["toolConfig"] = toolConfig
+	}
+	body, err := json.Marshal(request)
+	if err != nil {
+		return cachedContent{}, err
+	}
+	req := newRequest(ctx, http.MethodPost, ep.url("cachedContents"), header, body)
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := t.base().RoundTrip(req)
+	if err != nil {
+		return cachedContent{}, err
+	}
+	var result struct {
+		Name       string    `json:"name"`
+		ExpireTime time.Time `json:"expireTime"`
+	}
+	if err := checkResponse(resp, &result); err != nil {
+		return cachedContent{}, err
+	}
+	if result.Name == "" {
+		return cachedContent{}, errors.New("响应中缺少缓存名称")
+	}
+	expires := result.ExpireTime
+	if expires.IsZero() {
+		expires = time.Now().Add(ttl)
+	}
+	return cachedContent{Name: result.Name, Expires: expires}, nil
+}
+
+// forget 丢弃请求引用的文件和缓存，下次请求时重新上传或创建。
+func (t *Transport) forget(used references) {
+	c := t.cache()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, key := range used.files {
+		delete(c.files, key)
+	}
+	if used.cache != "" {
+		// 缓存失效后可能是模型不支持显式缓存，在有效期内不再尝试
+		c.contexts[used.cache] = cachedContent{Expires: time.Now().Add(t.ttl())}
+	}
+}
+
+// newRequest 创建一个发往 Gemini API 的请求，沿用原始请求的认证头和自定义头。
+func newRequest(ctx context.Context, method, target string, header http.Header, body []byte) *http.Request {
+	req, _ := http.NewRequestWithContext(ctx, method, target, bytes.NewReader(body))
+	req.Header = header.Clone()
+	req.Header.Del("Content-Type")
+	req.Header.Del("Content-Length")
+	req.Header.Del("Accept")
+	req.ContentLength = int64(len(body))
+	return req
+}
+
+// checkResponse 检查响应状态并把响应体解码到 v 中，然后关闭响应体。
+func checkResponse(resp *http.Response, v any) error {
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4<<10))
+		return fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	if v == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+func hashKey(parts ...string) string {
+	h := sha256.New()
+	for _, part := range parts {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
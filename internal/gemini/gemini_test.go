@@ -0,0 +1,163 @@
+package gemini
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// fakeAPI 是一个最小的 Gemini API，记录收到的生成请求
+type fakeAPI struct {
+	mu          sync.Mutex
+	uploads     int
+	caches      int
+	rejectCache bool
+	generated   []map[string]json.RawMessage
+}
+
+func (f *fakeAPI) handler(t *testing.T) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /upload/v1beta/files", func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "test-key", r.Header.Get("x-goog-api-key"))
+		require.Equal(t, "start", r.Header.Get("X-Goog-Upload-Command"))
+		w.Header().Set("X-Goog-Upload-URL", "http://"+r.Host+"/upload-session")
+	})
+	mux.HandleFunc("POST /upload-session", func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		require.Len(t, data, MinUploadSize)
+		f.mu.Lock()
+		f.uploads++
+		f.mu.Unlock()
+		_, _ = io.WriteString(w, `{"file":{"name":"files/abc","uri":"https://files/abc","mimeType":"image/png","state":"ACTIVE"}}`)
+	})
+	mux.HandleFunc("POST /v1beta/cachedContents", func(w http.ResponseWriter, r *http.Request) {
+		var req map[string]any
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		require.Equal(t, "models/gemini-test", req["model"])
+		require.Equal(t, "1800s", req["ttl"])
+		require.Contains(t, req, "systemInstruction")
+		require.Contains(t, req, "tools")
+		f.mu.Lock()
+		f.caches++
+		f.mu.Unlock()
+		_, _ = io.WriteString(w, `{"name":"cachedContents/xyz"}`)
+	})
+	mux.HandleFunc("POST /v1beta/models/gemini-test:generateContent", func(w http.ResponseWriter, r *http.Request) {
+		var req map[string]json.RawMessage
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		f.mu.Lock()
+		f.generated = append(f.generated, req)
+		reject := f.rejectCache
+		f.mu.Unlock()
+		if _, ok := req["cachedContent"]; ok && reject {
+			http.Error(w, "cached content not found", http.StatusNotFound)
+			return
+		}
+		_, _ = io.WriteString(w, `{}`)
+	})
+	return mux
+}
+
+func generateBody(t *testing.T) []byte {
+	t.Helper()
+	body, err := json.Marshal(map[string]any{
+		"systemInstruction": map[string]any{"parts": []any{map[string]any{"text": "You are a coding agent."}}},
+		"tools":             []any{map[string]any{"functionDeclarations": []any{map[string]any{"name": "view"}}}},
+		"contents": []any{map[string]any{
+			"role": "user",
+			"parts": []any{
+				map[string]any{"text": "what is in this image?"},
+				map[string]any{"inlineData": map[string]any{"mimeType": "image/png", "data": bytes.Repeat([]byte{1}, MinUploadSize)}},
+			},
+		}},
+	})
+	require.NoError(t, err)
+	return body
+}
+
+func post(t *testing.T, client *http.Client, url string, body []byte) {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	require.NoError(t, err)
+	req.Header.Set("x-goog-api-key", "test-key")
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestTransport_UploadsFilesAndCachesContext(t *testing.T) {
+	t.Parallel()
+
+	api := &fakeAPI{}
+	server := httptest.NewServer(api.handler(t))
+	t.Cleanup(server.Close)
+
+	client := &http.Client{Transport: &Transport{TTL: 30 * time.Minute}}
+	url := server.URL + "/v1beta/models/gemini-test:generateContent"
+	post(t, client, url, generateBody(t))
+	post(t, client, url, generateBody(t))
+
+	require.Equal(t, 1, api.uploads, "相同的附件只上传一次")
+	require.Equal(t, 1, api.caches, "相同的系统指令和工具只缓存一次")
+	require.Len(t, api.generated, 2)
+	for _, req := range api.generated {
+		require.NotContains(t, req, "systemInstruction")
+		require.NotContains(t, req, "tools")
+		require.JSONEq(t, `"cachedContents/xyz"`, string(req["cachedContent"]))
+		contents := string(req["contents"])
+		require.Contains(t, contents, `"fileUri":"https://files/abc"`)
+		require.NotContains(t, contents, "inlineData")
+		require.Contains(t, contents, "what is in this image?")
+	}
+}
+
+func TestTransport_RetriesWithoutRejectedCache(t *testing.T) {
+	t.Parallel()
+
+	api := &fakeAPI{rejectCache: true}
+	server := httptest.NewServer(api.handler(t))
+	t.Cleanup(server.Close)
+
+	client := &http.Client{Transport: &Transport{TTL: 30 * time.Minute}}
+	url := server.URL + "/v1beta/models/gemini-test:generateContent"
+	post(t, client, url, generateBody(t))
+	require.Len(t, api.generated, 2)
+	require.Contains(t, api.generated[1], "systemInstruction")
+	require.Contains(t, string(api.generated[1]["contents"]), "inlineData")
+
+	// 被拒绝的缓存在有效期内不再使用
+	post(t, client, url, generateBody(t))
+	require.Equal(t, 1, api.caches)
+	require.Len(t, api.generated, 3)
+	require.NotContains(t, api.generated[2], "cachedContent")
+	require.Contains(t, api.generated[2], "systemInstruction")
+}
+
+func TestParseEndpoint(t *testing.T) {
+	t.Parallel()
+
+	req := httptest.NewRequest(http.MethodPost, "https://generativelanguage.googleapis.com/v1beta/models/gemini-2.5-pro:streamGenerateContent?alt=sse", strings.NewReader("{}"))
+	require.True(t, isGenerateRequest(req))
+	ep, ok := parseEndpoint(req)
+	require.True(t, ok)
+	require.Equal(t, "models/gemini-2.5-pro", ep.model)
+	require.Equal(t, "https://generativelanguage.googleapis.com/v1beta/cachedContents", ep.url("cachedContents"))
+	require.Equal(t, "https://generativelanguage.googleapis.com/upload/v1beta/files", ep.uploadURL())
+
+	req = httptest.NewRequest(http.MethodPost, "https://us-central1-aiplatform.googleapis.com/v1beta1/projects/p/locations/us-central1/publishers/google/models/gemini-2.5-pro:generateContent", strings.NewReader("{}"))
+	_, ok = parseEndpoint(req)
+	require.False(t, ok, "Vertex AI 请求不做处理")
+
+	req = httptest.NewRequest(http.MethodGet, "https://generativelanguage.googleapis.com/v1beta/models", nil)
+	require.False(t, isGenerateRequest(req))
+}
@@ -0,0 +1,72 @@
+package history
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/aymanbagabas/go-udiff"
+)
+
+// FileChange 是会话中一个文件从初始版本到最新版本的更改
+type FileChange struct {
+	Path   string // 文件路径
+	Before string // 初始版本内容
+	After  string // 最新版本内容
+	// New 表示文件由会话创建：初始版本为空内容
+	New bool
+}
+
+// SessionChanges 将会话的所有文件版本按路径合并为初始版本与最新版本之间的更改，
+// 跳过内容未变化的文件，结果按路径排序
+func SessionChanges(files []File) []FileChange {
+	first := make(map[string]File)
+	latest := make(map[string]File)
+	for _, f := range files {
+		if v, ok := first[f.Path]; !ok || f.Version < v.Version {
+			first[f.Path] = f
+		}
+		if v, ok := latest[f.Path]; !ok || f.Version > v.Version {
+			latest[f.Path] = f
+		}
+	}
+
+	changes := make([]FileChange, 0, len(first))
+	for path, f := range first {
+		after := latest[path].Content
+		if f.Content == after {
+			continue
+		}
+		changes = append(changes, FileChange{
+			Path:   path,
+			Before: f.Content,
+			After:  after,
+			New:    f.Version == InitialVersion && f.Content == "",
+		})
+	}
+	slices.SortFunc(changes, func(a, b FileChange) int {
+		return strings.Compare(a.Path, b.Path)
+	})
+	return changes
+}
+
+// Patch 将更改生成为可用 git apply 应用的补丁，路径相对于 workingDir
+func Patch(changes []FileChange, workingDir string) string {
+	var sb strings.Builder
+	for _, c := range changes {
+		name := c.Path
+		if rel, err := filepath.Rel(workingDir, name); err == nil {
+			name = rel
+		}
+		name = filepath.ToSlash(name)
+		fmt.Fprintf(&sb, "diff --git a/%s b/%s\n", name, name)
+		if c.New {
+			sb.WriteString("new file mode 100644\n")
+			sb.WriteString(udiff.Unified("/dev/null", "b/"+name, "", c.After))
+			continue
+		}
+		sb.WriteString(udiff.Unified("a/"+name, "b/"+name, c.Before, c.After))
+	}
+	return sb.String()
+}
@@ -0,0 +1,58 @@
+package history
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSessionChanges(t *testing.T) {
+	t.Parallel()
+
+	workingDir := t.TempDir()
+	mainPath := filepath.Join(workingDir, "main.go")
+	utilPath := filepath.Join(workingDir, "pkg", "util.go")
+	readmePath := filepath.Join(workingDir, "README.md")
+
+	files := []File{
+		{Path: mainPath, Version: 2, Content: "package main\n\nfunc main() {\n\tprintln(2)\n}\n"},
+		{Path: utilPath, Version: 0, Content: ""},
+		{Path: mainPath, Version: 0, Content: "package main\n\nfunc main() {}\n"},
+		{Path: readmePath, Version: 0, Content: "# demo\n"},
+		{Path: utilPath, Version: 1, Content: "package pkg\n"},
+		{Path: mainPath, Version: 1, Content: "package main\n\nfunc main() {\n\tprintln(1)\n}\n"},
+		{Path: readmePath, Version: 1, Content: "# demo\n"},
+	}
+
+	changes := SessionChanges(files)
+	require.Equal(t, []FileChange{
+		{Path: mainPath, Before: "package main\n\nfunc main() {}\n", After: "package main\n\nfunc main() {\n\tprintln(2)\n}\n"},
+		{Path: utilPath, Before: "", After: "package pkg\n", New: true},
+	}, changes)
+
+	require.Equal(t, `diff --git a/main.go b/main.go
+--- a/main.go
++++ b/main.go
+@@ -1,3 +1,5 @@
+ package main
+ 
+-func main() {}
++func main() {
++	println(2)
++}
+diff --git a/pkg/util.go b/pkg/util.go
+new file mode 100644
+--- /dev/null
++++ b/pkg/util.go
+@@ -0,0 +1 @@
++package pkg
+`, Patch(changes, workingDir))
+}
+
+func TestSessionChangesEmpty(t *testing.T) {
+	t.Parallel()
+
+	require.Empty(t, SessionChanges(nil))
+	require.Empty(t, Patch(nil, t.TempDir()))
+}
@@ -25,6 +25,7 @@ type File struct {
 	Version   int64  // 版本号
 	CreatedAt int64  // 创建时间戳
 	UpdatedAt int64  // 更新时间戳
+	Author    string // 做出修改的用户，格式为 "Name <email>"，未配置用户身份时为空
 }
 
 // Service 文件服务接口，管理会话的文件版本和历史记录
@@ -55,14 +56,16 @@ type service struct {
 	*pubsub.Broker[File]             // 发布订阅代理
 	db                   *sql.DB     // 数据库连接
 	q                    *db.Queries // 数据库查询对象
+	author               string      // 记录为文件版本作者的用户
 }
 
-// NewService 创建新的文件服务实例
-func NewService(q *db.Queries, db *sql.DB) Service {
+// NewService 创建新的文件服务实例，author 是记录在每个文件版本中的作者，可以为空
+func NewService(q *db.Queries, db *sql.DB, author string) Service {
 	return &service{
 		Broker: pubsub.NewBroker[File](),
 		q:      q,
 		db:     db,
+		author: author,
 	}
 }
 
@@ -118,6 +121,7 @@ func (s *service) createWithVersion(ctx context.Context, sessionID, path, conten
 			Path:      path,
 			Content:   content,
 			Version:   version,
+			Author:    s.author,
 		})
 		if txErr != nil {
 			// 回滚事务
@@ -233,5 +237,6 @@ func (s *service) fromDBItem(item db.File) File {
 		Version:   item.Version,
 		CreatedAt: item.CreatedAt,
 		UpdatedAt: item.UpdatedAt,
+		Author:    item.Author,
 	}
 }
@@ -0,0 +1,273 @@
+// Package httpcache 为抓取类工具提供基于磁盘的 HTTP 响应缓存。响应的新鲜期遵循
+// Cache-Control 和 Expires 头，也可以通过配置的 TTL 统一覆盖，使同一 URL 在会话内
+// 和跨会话的重复抓取直接命中本地缓存。
+package httpcache
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// DirName 是数据目录中存放缓存条目的目录名。
+	DirName = "fetch_cache"
+	// DefaultTTL 是响应未声明新鲜期时使用的缓存时长。
+	DefaultTTL = time.Hour
+	// MaxEntrySize 是单个缓存响应体的最大字节数，更大的响应不会被缓存。
+	MaxEntrySize = 5 * 1024 * 1024
+	// Header 是命中缓存的响应上附加的头，值为 "HIT"。
+	Header = "X-Crush-Cache"
+)
+
+// Cache 是以键的 SHA-256 为文件名的磁盘缓存。每个条目的第一行是过期时间，其余为数据。
+// nil Cache 的所有查询都不命中，写入被忽略。
+type Cache struct {
+	dir string
+	ttl time.Duration
+	now func() time.Time
+}
+
+// New 创建存放在 dir 中的缓存。ttl 大于 0 时覆盖响应头声明的新鲜期。
+func New(dir string, ttl time.Duration) *Cache {
+	return &Cache{dir: dir, ttl: ttl, now: time.Now}
+}
+
+// Get 返回键对应的未过期数据及其过期时间。过期的条目会被删除。
+func (c *Cache) Get(key string) ([]byte, time.Time, bool) {
+	if c == nil {
+		return nil, time.Time{}, false
+	}
+	data, err := os.ReadFile(c.path(key))
+	if err != nil {
+		return nil, time.Time{}, false
+	}
+	line, payload, ok := bytes.Cut(data, []byte("\n"))
+	if !ok {
+		return nil, time.Time{}, false
+	}
+	expires, ok := c.parseExpires(key, string(line))
+	if !ok {
+		return nil, time.Time{}, false
+	}
+	return payload, expires, true
+}
+
+// Expires 返回键对应的未过期条目的过期时间，不读取条目数据。
+func (c *Cache) Expires(key string) (time.Time, bool) {
+	if c == nil {
+		return time.Time{}, false
+	}
+	f, err := os.Open(c.path(key))
+	if err != nil {
+		return time.Time{}, false
+	}
+	defer f.Close()
+	line, err := bufio.NewReader(f).ReadString('\n')
+	if err != nil {
+		return time.Time{}, false
+	}
+	return c.parseExpires(key, strings.TrimSuffix(line, "\n"))
+}
+
+// Put 保存键对应的数据，直到 expires 之前有效。
+func (c *Cache) Put(key string, data []byte, expires time.Time) error {
+	if c == nil {
+		return nil
+	}
+	if err := os.MkdirAll(c.dir, 0o700); err != nil {
+		return fmt.Errorf("创建缓存目录失败: %w", err)
+	}
+	tmp, err := os.CreateTemp(c.dir, "entry-*.tmp")
+	if err != nil {
+		return fmt.Errorf("创建缓存文件失败: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := fmt.Fprintf(tmp, "%d\n", expires.Unix()); err == nil {
+		_, err = tmp.Write(data)
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("写入缓存文件失败: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), c.path(key)); err != nil {
+		return fmt.Errorf("保存缓存文件失败: %w", err)
+	}
+	return nil
+}
+
+// parseExpires 解析条目的过期时间行，条目已过期或损坏时删除它。
+func (c *Cache) parseExpires(key, line string) (time.Time, bool) {
+	sec, err := strconv.ParseInt(line, 10, 64)
+	if err != nil || !c.now().Before(time.Unix(sec, 0)) {
+		_ = os.Remove(c.path(key))
+		return time.Time{}, false
+	}
+	return time.Unix(sec, 0), true
+}
+
+// path 返回键对应的缓存文件路径。
+func (c *Cache) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(c.dir, hex.EncodeToString(sum[:]))
+}
+
+// ResponseKey 返回 GET rawURL 的响应在缓存中的键。
+func ResponseKey(rawURL string) string {
+	if u, err := url.Parse(rawURL); err == nil {
+		u.Fragment = ""
+		rawURL = u.String()
+	}
+	return "GET " + rawURL
+}
+
+// Lifetime 返回响应头允许缓存的时长。no-store 总是禁止缓存；配置了 TTL 时使用 TTL，
+// 否则依次使用 max-age 和 Expires，都未声明时使用 [DefaultTTL]。
+func (c *Cache) Lifetime(h http.Header) (time.Duration, bool) {
+	directives := parseCacheControl(h.Get("Cache-Control"))
+	if _, ok := directives["no-store"]; ok || h.Get("Vary") == "*" {
+		return 0, false
+	}
+	if c.ttl > 0 {
+		return c.ttl, true
+	}
+	if _, ok := directives["no-cache"]; ok {
+		return 0, false
+	}
+
+	var lifetime time.Duration
+	if maxAge, ok := directives["max-age"]; ok {
+		sec, err := strconv.Atoi(maxAge)
+		if err != nil {
+			return 0, false
+		}
+		lifetime = time.Duration(sec) * time.Second
+		if age, err := strconv.Atoi(h.Get("Age")); err == nil {
+			lifetime -= time.Duration(age) * time.Second
+		}
+	} else if expires := h.Get("Expires"); expires != "" {
+		t, err := http.ParseTime(expires)
+		if err != nil {
+			return 0, false
+		}
+		date, err := http.ParseTime(h.Get("Date"))
+		if err != nil {
+			date = c.now()
+		}
+		lifetime = t.Sub(date)
+	} else {
+		lifetime = DefaultTTL
+	}
+	return lifetime, lifetime > 0
+}
+
+// parseCacheControl 将 Cache-Control 头解析为小写指令到值的映射。
+func parseCacheControl(header string) map[string]string {
+	directives := make(map[string]string)
+	for part := range strings.SplitSeq(header, ",") {
+		name, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+		if name == "" {
+			continue
+		}
+		directives[strings.ToLower(name)] = strings.Trim(value, `"`)
+	}
+	return directives
+}
+
+// Transport 是缓存 GET 请求成功响应的 [http.RoundTripper]。
+type Transport struct {
+	// Cache 保存响应，为 nil 时所有请求直接交给 Base。
+	Cache *Cache
+	// Base 执行实际的请求，为 nil 时使用 [http.DefaultTransport]。
+	Base http.RoundTripper
+}
+
+// RoundTrip 实现 [http.RoundTripper]。
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if t.Cache == nil || !cacheableRequest(req) {
+		return base.RoundTrip(req)
+	}
+
+	key := ResponseKey(req.URL.String())
+	if data, _, ok := t.Cache.Get(key); ok {
+		resp, err := http.ReadResponse(bufio.NewReader(bytes.NewReader(data)), req)
+		if err == nil {
+			resp.Header.Set(Header, "HIT")
+			return resp, nil
+		}
+	}
+
+	resp, err := base.RoundTrip(req)
+	if err != nil || resp.StatusCode != http.StatusOK {
+		return resp, err
+	}
+	lifetime, ok := t.Cache.Lifetime(resp.Header)
+	if !ok {
+		return resp, nil
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, MaxEntrySize+1))
+	if err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+	if len(body) > MaxEntrySize {
+		resp.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(body), resp.Body), resp.Body}
+		return resp, nil
+	}
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	if data, err := encodeResponse(resp, body); err == nil {
+		_ = t.Cache.Put(key, data, t.Cache.now().Add(lifetime))
+	}
+	return resp, nil
+}
+
+// cacheableRequest 判断请求的响应是否可以缓存。带凭据或范围的请求不缓存。
+func cacheableRequest(req *http.Request) bool {
+	if req.Method != http.MethodGet || req.Header.Get("Authorization") != "" || req.Header.Get("Range") != "" {
+		return false
+	}
+	_, noStore := parseCacheControl(req.Header.Get("Cache-Control"))["no-store"]
+	return !noStore
+}
+
+// encodeResponse 将响应序列化为 HTTP/1.1 报文。
+func encodeResponse(resp *http.Response, body []byte) ([]byte, error) {
+	stored := &http.Response{
+		Status:        resp.Status,
+		StatusCode:    resp.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        resp.Header.Clone(),
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+	}
+	stored.Header.Del("Transfer-Encoding")
+	var buf bytes.Buffer
+	if err := stored.Write(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
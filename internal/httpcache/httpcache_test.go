@@ -0,0 +1,114 @@
+package httpcache
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLifetime(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name   string
+		ttl    time.Duration
+		header http.Header
+		want   time.Duration
+		ok     bool
+	}{
+		{"default", 0, http.Header{}, DefaultTTL, true},
+		{"max-age", 0, http.Header{"Cache-Control": {"public, max-age=600"}}, 10 * time.Minute, true},
+		{"max-age minus age", 0, http.Header{"Cache-Control": {"max-age=600"}, "Age": {"100"}}, 500 * time.Second, true},
+		{"max-age zero", 0, http.Header{"Cache-Control": {"max-age=0"}}, 0, false},
+		{"no-cache", 0, http.Header{"Cache-Control": {"no-cache"}}, 0, false},
+		{"no-store", 0, http.Header{"Cache-Control": {"No-Store"}}, 0, false},
+		{"expires", 0, http.Header{
+			"Date":    {now.Format(http.TimeFormat)},
+			"Expires": {now.Add(2 * time.Hour).Format(http.TimeFormat)},
+		}, 2 * time.Hour, true},
+		{"ttl overrides no-cache", time.Minute, http.Header{"Cache-Control": {"no-cache, max-age=0"}}, time.Minute, true},
+		{"ttl keeps no-store", time.Minute, http.Header{"Cache-Control": {"no-store"}}, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			c := New(t.TempDir(), tt.ttl)
+			c.now = func() time.Time { return now }
+			got, ok := c.Lifetime(tt.header)
+			require.Equal(t, tt.ok, ok)
+			if ok {
+				require.Equal(t, tt.want, got)
+			}
+		})
+	}
+}
+
+func TestCacheExpiry(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	c := New(t.TempDir(), 0)
+	c.now = func() time.Time { return now }
+
+	require.NoError(t, c.Put("key", []byte("value\nmore"), now.Add(time.Minute)))
+	data, _, ok := c.Get("key")
+	require.True(t, ok)
+	require.Equal(t, "value\nmore", string(data))
+	_, ok = c.Expires("key")
+	require.True(t, ok)
+
+	now = now.Add(2 * time.Minute)
+	_, _, ok = c.Get("key")
+	require.False(t, ok)
+	require.NoFileExists(t, c.path("key"))
+
+	var nilCache *Cache
+	require.NoError(t, nilCache.Put("key", nil, now))
+	_, _, ok = nilCache.Get("key")
+	require.False(t, ok)
+}
+
+func TestTransport(t *testing.T) {
+	t.Parallel()
+
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		if r.URL.Path == "/private" {
+			w.Header().Set("Cache-Control", "no-store")
+		}
+		w.Header().Set("Content-Type", "text/plain")
+		_, _ = io.WriteString(w, "hello "+r.URL.Path)
+	}))
+	t.Cleanup(srv.Close)
+
+	client := &http.Client{Transport: &Transport{Cache: New(t.TempDir(), 0)}}
+	get := func(path string) (string, string) {
+		resp, err := client.Get(srv.URL + path)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		require.Equal(t, "text/plain", resp.Header.Get("Content-Type"))
+		return string(body), resp.Header.Get(Header)
+	}
+
+	body, cache := get("/docs")
+	require.Equal(t, "hello /docs", body)
+	require.Empty(t, cache)
+	body, cache = get("/docs#section")
+	require.Equal(t, "hello /docs", body)
+	require.Equal(t, "HIT", cache)
+	require.Equal(t, int32(1), hits.Load())
+
+	get("/private")
+	_, cache = get("/private")
+	require.Empty(t, cache)
+	require.Equal(t, int32(3), hits.Load())
+}
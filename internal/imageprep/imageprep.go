@@ -0,0 +1,168 @@
+// Package imageprep 在图片附件发送给模型前对其进行预处理：缩小超过最大尺寸的图片，
+// 并重新编码为体积更小的格式，原图保留在磁盘上以供查阅。
+package imageprep
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/disintegration/imaging"
+	"github.com/purpose168/crush-cn/internal/message"
+)
+
+const (
+	// DefaultMaxDimension 是默认的最长边（像素），与主流提供者内部缩放的尺寸一致。
+	DefaultMaxDimension = 1568
+	// DefaultQuality 是默认的 JPEG 编码质量。
+	DefaultQuality = 85
+	// OriginalsDir 是数据目录下保存原图的子目录名。
+	OriginalsDir = "attachments"
+
+	// minDimension 是为满足大小限制而逐步缩小时允许的最小最长边。
+	minDimension = 256
+)
+
+// Options 配置图片预处理。
+type Options struct {
+	// MaxDimension 是最长边的最大像素数，0 表示使用 [DefaultMaxDimension]。
+	MaxDimension int
+	// Quality 是 JPEG 编码质量（1-100），0 表示使用 [DefaultQuality]。
+	Quality int
+	// MaxBytes 是处理后图片的最大字节数，超出时继续缩小图片，0 表示不限制。
+	MaxBytes int64
+	// OriginalsDir 是保存原图的目录，为空时不保存原图。
+	OriginalsDir string
+}
+
+// Result 是预处理的结果。
+type Result struct {
+	Attachment message.Attachment
+	// Changed 表示图片被缩小或重新编码。
+	Changed bool
+	// Original 是原图在磁盘上的路径，图片未被修改时为空。
+	Original string
+}
+
+// Process 按 opts 预处理图片附件。非图片附件，或尺寸和大小都在限制内的图片原样返回；
+// 否则图片按比例缩小到最长边不超过最大尺寸，不透明的图片编码为 JPEG，带透明通道的编码为 PNG。
+func Process(att message.Attachment, opts Options) (Result, error) {
+	if !att.IsImage() {
+		return Result{Attachment: att}, nil
+	}
+	maxDim := opts.MaxDimension
+	if maxDim <= 0 {
+		maxDim = DefaultMaxDimension
+	}
+	quality := opts.Quality
+	if quality <= 0 || quality > 100 {
+		quality = DefaultQuality
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(att.Content))
+	if err != nil {
+		return Result{Attachment: att}, fmt.Errorf("解码图片失败: %w", err)
+	}
+	bounds := img.Bounds()
+	longest := max(bounds.Dx(), bounds.Dy())
+	oversized := opts.MaxBytes > 0 && int64(len(att.Content)) > opts.MaxBytes
+	if longest <= maxDim && !oversized {
+		return Result{Attachment: att}, nil
+	}
+
+	opaque := isOpaque(img)
+	dim := min(longest, maxDim)
+	var content []byte
+	for {
+		resized := img
+		if longest > dim {
+			resized = imaging.Fit(img, dim, dim, imaging.Lanczos)
+		}
+		content, err = encode(resized, opaque, quality)
+		if err != nil {
+			return Result{Attachment: att}, err
+		}
+		if opts.MaxBytes <= 0 || int64(len(content)) <= opts.MaxBytes {
+			break
+		}
+		dim = dim * 3 / 4
+		if dim < minDimension {
+			return Result{Attachment: att}, errors.New("图片压缩后仍超过大小限制")
+		}
+	}
+
+	original, err := saveOriginal(att, opts.OriginalsDir)
+	if err != nil {
+		return Result{Attachment: att}, err
+	}
+
+	processed := att
+	processed.Content = content
+	processed.MimeType = "image/png"
+	ext := ".png"
+	if opaque {
+		processed.MimeType = "image/jpeg"
+		ext = ".jpg"
+	}
+	processed.FileName = strings.TrimSuffix(att.FileName, filepath.Ext(att.FileName)) + ext
+	if original != "" {
+		processed.FilePath = original
+	}
+	return Result{Attachment: processed, Changed: true, Original: original}, nil
+}
+
+// encode 将图片编码为 JPEG（不透明）或 PNG（带透明通道）。
+func encode(img image.Image, opaque bool, quality int) ([]byte, error) {
+	var buf bytes.Buffer
+	var err error
+	if opaque {
+		err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: quality})
+	} else {
+		err = (&png.Encoder{CompressionLevel: png.BestCompression}).Encode(&buf, img)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("编码图片失败: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// isOpaque 报告图片是否不含透明像素。
+func isOpaque(img image.Image) bool {
+	if o, ok := img.(interface{ Opaque() bool }); ok {
+		return o.Opaque()
+	}
+	return false
+}
+
+// saveOriginal 保留原图并返回其路径。附件本身就是磁盘上的文件时直接返回该路径，
+// 否则（例如从剪贴板粘贴的图片）按内容哈希写入 dir。
+func saveOriginal(att message.Attachment, dir string) (string, error) {
+	if att.FilePath != "" && filepath.IsAbs(att.FilePath) {
+		if info, err := os.Stat(att.FilePath); err == nil && info.Mode().IsRegular() {
+			return att.FilePath, nil
+		}
+	}
+	if dir == "" {
+		return "", nil
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", fmt.Errorf("创建原图目录失败: %w", err)
+	}
+	sum := sha256.Sum256(att.Content)
+	path := filepath.Join(dir, hex.EncodeToString(sum[:6])+"_"+filepath.Base(att.FileName))
+	if _, err := os.Stat(path); err == nil {
+		return path, nil
+	}
+	if err := os.WriteFile(path, att.Content, 0o644); err != nil {
+		return "", fmt.Errorf("保存原图失败: %w", err)
+	}
+	return path, nil
+}
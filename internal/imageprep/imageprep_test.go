@@ -0,0 +1,106 @@
+package imageprep
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/purpose168/crush-cn/internal/message"
+	"github.com/stretchr/testify/require"
+)
+
+func pngAttachment(t *testing.T, width, height int, alpha uint8) message.Attachment {
+	t.Helper()
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	rng := rand.New(rand.NewPCG(1, 2))
+	for y := range height {
+		for x := range width {
+			img.SetNRGBA(x, y, color.NRGBA{R: uint8(rng.IntN(256)), G: uint8(x), B: uint8(y), A: alpha})
+		}
+	}
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, img))
+	return message.Attachment{
+		FilePath: "paste_1.png",
+		FileName: "paste_1.png",
+		MimeType: "image/png",
+		Content:  buf.Bytes(),
+	}
+}
+
+func decodeSize(t *testing.T, content []byte) (int, int) {
+	t.Helper()
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(content))
+	require.NoError(t, err)
+	return cfg.Width, cfg.Height
+}
+
+func TestProcessDownscalesLargeImage(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	att := pngAttachment(t, 800, 400, 255)
+	res, err := Process(att, Options{MaxDimension: 200, Quality: 80, OriginalsDir: dir})
+	require.NoError(t, err)
+	require.True(t, res.Changed)
+	require.Equal(t, "image/jpeg", res.Attachment.MimeType)
+	require.Equal(t, "paste_1.jpg", res.Attachment.FileName)
+	require.Less(t, len(res.Attachment.Content), len(att.Content))
+
+	width, height := decodeSize(t, res.Attachment.Content)
+	require.Equal(t, 200, width)
+	require.Equal(t, 100, height)
+
+	require.Equal(t, filepath.Dir(res.Original), dir)
+	require.Equal(t, res.Original, res.Attachment.FilePath)
+	saved, err := os.ReadFile(res.Original)
+	require.NoError(t, err)
+	require.Equal(t, att.Content, saved)
+}
+
+func TestProcessKeepsTransparency(t *testing.T) {
+	t.Parallel()
+
+	res, err := Process(pngAttachment(t, 300, 300, 128), Options{MaxDimension: 100})
+	require.NoError(t, err)
+	require.True(t, res.Changed)
+	require.Equal(t, "image/png", res.Attachment.MimeType)
+	require.Empty(t, res.Original)
+	require.Equal(t, "paste_1.png", res.Attachment.FilePath)
+}
+
+func TestProcessShrinksToMaxBytes(t *testing.T) {
+	t.Parallel()
+
+	att := pngAttachment(t, 600, 600, 255)
+	res, err := Process(att, Options{MaxBytes: 64 * 1024})
+	require.NoError(t, err)
+	require.True(t, res.Changed)
+	require.LessOrEqual(t, len(res.Attachment.Content), 64*1024)
+
+	width, _ := decodeSize(t, res.Attachment.Content)
+	require.Less(t, width, 600)
+}
+
+func TestProcessUnchanged(t *testing.T) {
+	t.Parallel()
+
+	att := pngAttachment(t, 50, 50, 255)
+	res, err := Process(att, Options{OriginalsDir: t.TempDir()})
+	require.NoError(t, err)
+	require.False(t, res.Changed)
+	require.Equal(t, att, res.Attachment)
+
+	text := message.Attachment{FileName: "a.txt", MimeType: "text/plain", Content: []byte("hi")}
+	res, err = Process(text, Options{MaxBytes: 1})
+	require.NoError(t, err)
+	require.Equal(t, text, res.Attachment)
+
+	_, err = Process(message.Attachment{MimeType: "image/png", Content: []byte("nope")}, Options{})
+	require.Error(t, err)
+}
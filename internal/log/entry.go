@@ -0,0 +1,113 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Entry 是日志文件中的一条日志。
+type Entry struct {
+	Time    time.Time
+	Level   slog.Level
+	Message string
+	Source  string // 文件:行号
+	Attrs   []Attr // 按键排序
+}
+
+// Attr 是日志条目中的一个属性，非字符串值以 JSON 表示。
+type Attr struct {
+	Key   string
+	Value string
+}
+
+// String 以 "消息 键=值 ..." 的格式返回日志条目，不包含时间和级别。
+func (e Entry) String() string {
+	var sb strings.Builder
+	sb.WriteString(e.Message)
+	for _, a := range e.Attrs {
+		fmt.Fprintf(&sb, " %s=%s", a.Key, a.Value)
+	}
+	return sb.String()
+}
+
+// ParseEntry 解析一行 JSON 格式的日志，不是有效日志时返回 false。
+func ParseEntry(line []byte) (Entry, bool) {
+	var data map[string]json.RawMessage
+	if err := json.Unmarshal(line, &data); err != nil {
+		return Entry{}, false
+	}
+	var e Entry
+	for key, raw := range data {
+		switch key {
+		case slog.TimeKey:
+			var ts string
+			if json.Unmarshal(raw, &ts) == nil {
+				e.Time, _ = time.Parse(time.RFC3339Nano, ts)
+			}
+		case slog.LevelKey:
+			var level string
+			if json.Unmarshal(raw, &level) == nil {
+				_ = e.Level.UnmarshalText([]byte(level))
+			}
+		case slog.MessageKey:
+			_ = json.Unmarshal(raw, &e.Message)
+		case slog.SourceKey:
+			var source slog.Source
+			if json.Unmarshal(raw, &source) == nil {
+				e.Source = fmt.Sprintf("%s:%d", source.File, source.Line)
+			}
+		default:
+			value := string(raw)
+			var s string
+			if json.Unmarshal(raw, &s) == nil {
+				value = s
+			}
+			e.Attrs = append(e.Attrs, Attr{Key: key, Value: value})
+		}
+	}
+	slices.SortFunc(e.Attrs, func(a, b Attr) int {
+		return strings.Compare(a.Key, b.Key)
+	})
+	return e, true
+}
+
+// ReadEntries 从 offset 开始读取日志文件中的完整日志行，返回解析出的条目和下次读取的偏移量。
+// 文件比 offset 小时（日志已轮转）从头读取。
+func ReadEntries(path string, offset int64) ([]Entry, int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, offset, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, offset, err
+	}
+	if info.Size() < offset {
+		offset = 0
+	}
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return nil, offset, err
+	}
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return nil, offset, err
+	}
+	// 最后一行可能仍在写入，留到下次读取
+	end := bytes.LastIndexByte(data, '\n') + 1
+	var entries []Entry
+	for line := range bytes.SplitSeq(data[:end], []byte("\n")) {
+		if e, ok := ParseEntry(line); ok {
+			entries = append(entries, e)
+		}
+	}
+	return entries, offset + int64(end), nil
+}
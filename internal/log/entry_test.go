@@ -0,0 +1,50 @@
+package log
+
+import (
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadEntries(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "crush.log")
+	content := `{"time":"2026-01-02T15:04:05.123Z","level":"INFO","source":{"function":"main.main","file":"/src/main.go","line":12},"msg":"启动","version":"1.0"}
+not json
+{"time":"2026-01-02T15:04:06Z","level":"ERROR","msg":"MCP 连接失败","name":"github","retry":{"count":2}}
+{"time":"2026-01-02T15:04:07Z","level":"WARN","msg":"partial`
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+
+	entries, offset, err := ReadEntries(path, 0)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	require.Equal(t, slog.LevelInfo, entries[0].Level)
+	require.Equal(t, "/src/main.go:12", entries[0].Source)
+	require.Equal(t, "启动 version=1.0", entries[0].String())
+	require.Equal(t, slog.LevelError, entries[1].Level)
+	require.Equal(t, `MCP 连接失败 name=github retry={"count":2}`, entries[1].String())
+
+	// 未写完的最后一行在写完后读取
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0o644)
+	require.NoError(t, err)
+	_, err = f.WriteString(`"}` + "\n")
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	entries, _, err = ReadEntries(path, offset)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	require.Equal(t, slog.LevelWarn, entries[0].Level)
+	require.Equal(t, "partial", entries[0].Message)
+
+	// 日志轮转后从头读取
+	require.NoError(t, os.WriteFile(path, []byte(`{"level":"DEBUG","msg":"new"}`+"\n"), 0o644))
+	entries, _, err = ReadEntries(path, offset)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	require.Equal(t, "new", entries[0].Message)
+}
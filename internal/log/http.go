@@ -12,11 +12,17 @@ import (
 
 // NewHTTPClient 创建一个带有请求/响应日志记录功能的HTTP客户端
 // 当调试模式开启时，会自动记录所有HTTP请求和响应的详细信息
+// 参数:
+//   - base: 实际执行请求的传输层，为 nil 时使用 http.DefaultTransport
+//
 // 返回值: 配置了日志记录的HTTP客户端实例
-func NewHTTPClient() *http.Client {
+func NewHTTPClient(base http.RoundTripper) *http.Client {
+	if base == nil {
+		base = http.DefaultTransport
+	}
 	return &http.Client{
 		Transport: &HTTPRoundTripLogger{
-			Transport: http.DefaultTransport,
+			Transport: base,
 		},
 	}
 }
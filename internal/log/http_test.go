@@ -23,7 +23,7 @@ func TestHTTPRoundTripLogger(t *testing.T) {
 	defer server.Close()
 
 	// 创建带有日志记录功能的HTTP客户端
-	client := NewHTTPClient()
+	client := NewHTTPClient(nil)
 
 	// 构造测试请求
 	req, err := http.NewRequestWithContext(
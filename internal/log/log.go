@@ -16,6 +16,8 @@ import (
 var (
 	initOnce    sync.Once   // 确保初始化只执行一次
 	initialized atomic.Bool // 标记日志系统是否已初始化
+	runFile     string      // 日志文件路径
+	runOffset   int64       // 本次运行开始时日志文件的大小
 )
 
 // Setup 初始化日志系统
@@ -24,6 +26,11 @@ var (
 //   - debug: 是否启用调试模式（调试模式会输出更详细的日志）
 func Setup(logFile string, debug bool) {
 	initOnce.Do(func() {
+		runFile = logFile
+		if info, err := os.Stat(logFile); err == nil {
+			runOffset = info.Size()
+		}
+
 		// 创建日志轮转器，用于管理日志文件的大小和备份数量
 		logRotator := &lumberjack.Logger{
 			Filename:   logFile, // 日志文件名
@@ -57,6 +64,15 @@ func Initialized() bool {
 	return initialized.Load()
 }
 
+// File 返回日志文件路径和本次运行开始时的文件大小，从该偏移量开始即为本次运行的日志。
+// 日志系统未初始化时返回空路径。
+func File() (path string, offset int64) {
+	if !initialized.Load() {
+		return "", 0
+	}
+	return runFile, runOffset
+}
+
 // RecoverPanic 恢复panic并记录错误信息
 // 该函数应在defer语句中调用，用于捕获并处理panic
 // 参数:
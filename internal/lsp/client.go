@@ -44,6 +44,17 @@ type Client struct {
 	// 此LSP客户端的配置
 	config config.LSPConfig
 
+	// 解析变量后的服务器命令
+	command string
+
+	// 服务器进程信息缓存
+	process          ProcessInfo
+	processCheckedAt time.Time
+	processMu        sync.Mutex
+
+	// 返回路径所在子目录的覆盖配置，未设置时不应用覆盖配置
+	directoryConfig func(path string) config.DirectoryConfig
+
 	// 原始上下文和解析器，用于重新创建客户端
 	ctx      context.Context
 	resolver config.VariableResolver
@@ -160,10 +171,11 @@ func (c *Client) createPowernapClient() error {
 	if err != nil {
 		return fmt.Errorf("无效的LSP命令: %w", err)
 	}
+	c.command = home.Long(command)
 
 	// 构建客户端配置
 	clientConfig := powernap.ClientConfig{
-		Command:     home.Long(command),
+		Command:     c.command,
 		Args:        c.config.Args,
 		RootURI:     rootURI,
 		Environment: maps.Clone(c.config.Env),
@@ -189,7 +201,7 @@ func (c *Client) createPowernapClient() error {
 // registerHandlers 注册标准的LSP通知和请求处理器
 func (c *Client) registerHandlers() {
 	c.RegisterServerRequestHandler("workspace/applyEdit", HandleApplyEdit)
-	c.RegisterServerRequestHandler("workspace/configuration", HandleWorkspaceConfiguration)
+	c.RegisterServerRequestHandler("workspace/configuration", c.handleWorkspaceConfiguration)
 	c.RegisterServerRequestHandler("client/registerCapability", HandleRegisterCapability)
 	c.RegisterNotificationHandler("window/showMessage", func(ctx context.Context, method string, params json.RawMessage) {
 		if c.debug {
@@ -280,6 +292,16 @@ func (c *Client) GetName() string {
 	return c.name
 }
 
+// Config 返回此LSP客户端的配置
+func (c *Client) Config() config.LSPConfig {
+	return c.config
+}
+
+// CommandLine 返回启动语言服务器的命令及其参数
+func (c *Client) CommandLine() []string {
+	return append([]string{c.command}, c.config.Args...)
+}
+
 // SetDiagnosticsCallback 设置诊断信息变更的回调函数
 func (c *Client) SetDiagnosticsCallback(callback func(name string, count int)) {
 	c.onDiagnosticsChanged = callback
@@ -348,9 +370,19 @@ func (c *Client) HandlesFile(path string) bool {
 		slog.Debug("文件在工作区外", "name", c.name, "file", path, "workDir", c.workDir)
 		return false
 	}
+	if c.directoryConfig != nil && c.directoryConfig(absPath).LSPDisabled(c.name) {
+		slog.Debug("LSP在文件所在子目录中被禁用", "name", c.name, "file", path)
+		return false
+	}
 	return handlesFiletype(c.name, c.fileTypes, path)
 }
 
+// SetDirectoryConfig 设置获取子目录覆盖配置的函数。覆盖配置可以在子目录中禁用此LSP，
+// 或为子目录中的文件提供不同的服务器设置
+func (c *Client) SetDirectoryConfig(fn func(path string) config.DirectoryConfig) {
+	c.directoryConfig = fn
+}
+
 // OpenFile 在LSP服务器中打开文件
 func (c *Client) OpenFile(ctx context.Context, filepath string) error {
 	if !c.HandlesFile(filepath) {
@@ -0,0 +1,198 @@
+package lsp
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/purpose168/crush-cn/internal/config"
+)
+
+// knownServer 描述一种语言常用的LSP服务器及其安装方式
+type knownServer struct {
+	language    string
+	name        string
+	command     string
+	args        []string
+	fileTypes   []string
+	rootMarkers []string
+	// install 是安装服务器的命令，为空表示需要用户手动安装
+	install []string
+}
+
+// knownServers 是引导流程中可建议的LSP服务器，按检测顺序排列
+var knownServers = []knownServer{
+	{
+		language:    "Go",
+		name:        "gopls",
+		command:     "gopls",
+		fileTypes:   []string{"go", "mod", "sum", "work"},
+		rootMarkers: []string{"go.mod", "go.work"},
+		install:     []string{"go", "install", "golang.org/x/tools/gopls@latest"},
+	},
+	{
+		language:    "TypeScript/JavaScript",
+		name:        "typescript-language-server",
+		command:     "typescript-language-server",
+		args:        []string{"--stdio"},
+		fileTypes:   []string{"ts", "tsx", "js", "jsx", "mjs", "cjs"},
+		rootMarkers: []string{"tsconfig.json", "jsconfig.json", "package.json"},
+		install:     []string{"npm", "install", "-g", "typescript-language-server", "typescript"},
+	},
+	{
+		language:    "Python",
+		name:        "pyright",
+		command:     "pyright-langserver",
+		args:        []string{"--stdio"},
+		fileTypes:   []string{"py", "pyi"},
+		rootMarkers: []string{"pyproject.toml", "setup.py", "setup.cfg", "requirements.txt", "Pipfile"},
+		install:     []string{"npm", "install", "-g", "pyright"},
+	},
+	{
+		language:    "Rust",
+		name:        "rust-analyzer",
+		command:     "rust-analyzer",
+		fileTypes:   []string{"rs"},
+		rootMarkers: []string{"Cargo.toml"},
+		install:     []string{"rustup", "component", "add", "rust-analyzer"},
+	},
+	{
+		language:    "C/C++",
+		name:        "clangd",
+		command:     "clangd",
+		fileTypes:   []string{"c", "h", "cc", "cpp", "cxx", "hpp", "hh"},
+		rootMarkers: []string{"compile_commands.json", "compile_flags.txt", ".clangd", "CMakeLists.txt"},
+	},
+	{
+		language:    "Ruby",
+		name:        "solargraph",
+		command:     "solargraph",
+		args:        []string{"stdio"},
+		fileTypes:   []string{"rb"},
+		rootMarkers: []string{"Gemfile"},
+		install:     []string{"gem", "install", "solargraph"},
+	},
+	{
+		language:    "PHP",
+		name:        "intelephense",
+		command:     "intelephense",
+		args:        []string{"--stdio"},
+		fileTypes:   []string{"php"},
+		rootMarkers: []string{"composer.json"},
+		install:     []string{"npm", "install", "-g", "intelephense"},
+	},
+	{
+		language:    "Zig",
+		name:        "zls",
+		command:     "zls",
+		fileTypes:   []string{"zig", "zon"},
+		rootMarkers: []string{"build.zig"},
+	},
+	{
+		language:    "Lua",
+		name:        "lua-language-server",
+		command:     "lua-language-server",
+		fileTypes:   []string{"lua"},
+		rootMarkers: []string{".luarc.json", ".luarc.jsonc", ".stylua.toml"},
+	},
+}
+
+// Suggestion 是根据项目根标记为项目建议的LSP服务器
+type Suggestion struct {
+	Language string // 语言名称
+	Name     string // 写入 lsp 配置时使用的名称
+	Marker   string // 检测到的根标记
+	// Installed 表示服务器命令已在 PATH 中
+	Installed bool
+
+	server knownServer
+}
+
+// DetectServers 根据工作目录中的根标记检测项目语言并返回建议的LSP服务器，
+// 已在 configured 中配置（包括已禁用）的服务器会被忽略
+func DetectServers(workingDir string, configured map[string]config.LSPConfig) []Suggestion {
+	var suggestions []Suggestion
+	for _, server := range knownServers {
+		if isConfigured(server, configured) {
+			continue
+		}
+		for _, marker := range server.rootMarkers {
+			if _, err := os.Stat(filepath.Join(workingDir, marker)); err != nil {
+				continue
+			}
+			_, err := exec.LookPath(server.command)
+			suggestions = append(suggestions, Suggestion{
+				Language:  server.language,
+				Name:      server.name,
+				Marker:    marker,
+				Installed: err == nil,
+				server:    server,
+			})
+			break
+		}
+	}
+	return suggestions
+}
+
+// isConfigured 检查服务器是否已按名称或命令出现在配置中
+func isConfigured(server knownServer, configured map[string]config.LSPConfig) bool {
+	for name, cfg := range configured {
+		if name == server.name || name == server.command || cfg.Command == server.command {
+			return true
+		}
+	}
+	return false
+}
+
+// Command 返回启动服务器的命令行
+func (s Suggestion) Command() string {
+	return strings.Join(append([]string{s.server.command}, s.server.args...), " ")
+}
+
+// InstallCommand 返回安装服务器的命令行，无法自动安装时返回空字符串
+func (s Suggestion) InstallCommand() string {
+	return strings.Join(s.server.install, " ")
+}
+
+// CanInstall 报告服务器是否可以自动安装，即定义了安装命令且安装工具在 PATH 中
+func (s Suggestion) CanInstall() bool {
+	if len(s.server.install) == 0 {
+		return false
+	}
+	_, err := exec.LookPath(s.server.install[0])
+	return err == nil
+}
+
+// Install 运行安装命令并检查服务器命令随后是否可用
+func (s Suggestion) Install(ctx context.Context) error {
+	if len(s.server.install) == 0 {
+		return fmt.Errorf("%s 需要手动安装", s.Name)
+	}
+	cmd := exec.CommandContext(ctx, s.server.install[0], s.server.install[1:]...)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("运行 %q 失败: %w: %s", s.InstallCommand(), err, lastLine(string(out)))
+	}
+	if _, err := exec.LookPath(s.server.command); err != nil {
+		return fmt.Errorf("已安装 %s，但在 PATH 中找不到 %s", s.Name, s.server.command)
+	}
+	return nil
+}
+
+// Config 返回写入 lsp 配置的服务器配置
+func (s Suggestion) Config() config.LSPConfig {
+	return config.LSPConfig{
+		Command:     s.server.command,
+		Args:        s.server.args,
+		FileTypes:   s.server.fileTypes,
+		RootMarkers: s.server.rootMarkers,
+	}
+}
+
+// lastLine 返回输出中最后一个非空行，用于在错误中显示简短原因
+func lastLine(out string) string {
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	return strings.TrimSpace(lines[len(lines)-1])
+}
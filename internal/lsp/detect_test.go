@@ -0,0 +1,40 @@
+package lsp
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/purpose168/crush-cn/internal/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetectServers(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	for _, name := range []string{"go.mod", "Cargo.toml", "README.md"} {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), nil, 0o644))
+	}
+
+	suggestions := DetectServers(dir, nil)
+	require.Len(t, suggestions, 2)
+	require.Equal(t, "gopls", suggestions[0].Name)
+	require.Equal(t, "go.mod", suggestions[0].Marker)
+	require.Equal(t, "rust-analyzer", suggestions[1].Name)
+	require.Equal(t, "rustup component add rust-analyzer", suggestions[1].InstallCommand())
+
+	cfg := suggestions[0].Config()
+	require.Equal(t, "gopls", cfg.Command)
+	require.Contains(t, cfg.RootMarkers, "go.mod")
+
+	t.Run("skips configured servers", func(t *testing.T) {
+		t.Parallel()
+
+		suggestions := DetectServers(dir, map[string]config.LSPConfig{
+			"go":   {Command: "gopls"},
+			"rust": {Disabled: true, Command: "rust-analyzer"},
+		})
+		require.Empty(t, suggestions)
+	})
+}
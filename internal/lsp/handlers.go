@@ -4,15 +4,56 @@ import (
 	"context"
 	"encoding/json"
 	"log/slog"
+	"strings"
 
 	"github.com/charmbracelet/x/powernap/pkg/lsp/protocol"
 	"github.com/purpose168/crush-cn/internal/lsp/util"
 )
 
-// HandleWorkspaceConfiguration 处理工作区配置请求
-// 返回空配置映射，让LSP服务器使用默认配置
-func HandleWorkspaceConfiguration(_ context.Context, _ string, params json.RawMessage) (any, error) {
-	return []map[string]any{{}}, nil
+// handleWorkspaceConfiguration 处理工作区配置请求
+// 作用域位于设置了LSP选项的子目录时返回合并后的选项，否则返回空配置映射，让LSP服务器使用默认配置
+func (c *Client) handleWorkspaceConfiguration(_ context.Context, _ string, params json.RawMessage) (any, error) {
+	var configParams protocol.ConfigurationParams
+	if err := json.Unmarshal(params, &configParams); err != nil || len(configParams.Items) == 0 {
+		return []map[string]any{{}}, nil
+	}
+	result := make([]any, len(configParams.Items))
+	for i, item := range configParams.Items {
+		result[i] = map[string]any{}
+		if c.directoryConfig == nil || item.ScopeURI == nil {
+			continue
+		}
+		path, err := protocol.DocumentURI(*item.ScopeURI).Path()
+		if err != nil {
+			continue
+		}
+		options := c.directoryConfig(path).LSP[c.name].Options
+		if len(options) == 0 {
+			continue
+		}
+		if section, ok := configurationSection(options, item.Section); ok {
+			result[i] = section
+		}
+	}
+	return result, nil
+}
+
+// configurationSection 返回选项中以点分隔的配置节，section 为空时返回全部选项
+func configurationSection(options map[string]any, section string) (any, bool) {
+	if section == "" {
+		return options, true
+	}
+	var current any = options
+	for key := range strings.SplitSeq(section, ".") {
+		m, ok := current.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		if current, ok = m[key]; !ok {
+			return nil, false
+		}
+	}
+	return current, true
 }
 
 // HandleRegisterCapability 处理能力注册请求
@@ -0,0 +1,26 @@
+package lsp
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigurationSection(t *testing.T) {
+	t.Parallel()
+
+	options := map[string]any{"typescript": map[string]any{"format": map[string]any{"indentSize": 4}}}
+
+	section, ok := configurationSection(options, "typescript.format")
+	require.True(t, ok)
+	require.Equal(t, map[string]any{"indentSize": 4}, section)
+
+	section, ok = configurationSection(options, "")
+	require.True(t, ok)
+	require.Equal(t, options, section)
+
+	_, ok = configurationSection(options, "typescript.format.indentSize.value")
+	require.False(t, ok)
+	_, ok = configurationSection(options, "javascript")
+	require.False(t, ok)
+}
@@ -5,6 +5,7 @@ import (
 	"cmp"
 	"context"
 	"errors"
+	"fmt"
 	"io"
 	"log/slog"
 	"os/exec"
@@ -37,24 +38,7 @@ func NewManager(cfg *config.Config) *Manager {
 
 	// 将用户配置的LSP合并到管理器中
 	for name, clientConfig := range cfg.LSP {
-		if clientConfig.Disabled {
-			slog.Debug("LSP已被用户配置禁用", "name", name)
-			manager.RemoveServer(name)
-			continue
-		}
-
-		// 技巧：用户可能在配置中使用命令名而不是实际名称
-		// 查找并使用正确的名称
-		actualName := resolveServerName(manager, name)
-		manager.AddServer(actualName, &powernapconfig.ServerConfig{
-			Command:     clientConfig.Command,
-			Args:        clientConfig.Args,
-			Environment: clientConfig.Env,
-			FileTypes:   clientConfig.FileTypes,
-			RootMarkers: clientConfig.RootMarkers,
-			InitOptions: clientConfig.InitOptions,
-			Settings:    clientConfig.Options,
-		})
+		mergeUserServer(manager, name, clientConfig)
 	}
 
 	return &Manager{
@@ -64,6 +48,35 @@ func NewManager(cfg *config.Config) *Manager {
 	}
 }
 
+// mergeUserServer 将用户配置的LSP合并到powernap配置管理器中
+func mergeUserServer(manager *powernapconfig.Manager, name string, clientConfig config.LSPConfig) {
+	if clientConfig.Disabled {
+		slog.Debug("LSP已被用户配置禁用", "name", name)
+		manager.RemoveServer(name)
+		return
+	}
+
+	// 技巧：用户可能在配置中使用命令名而不是实际名称
+	// 查找并使用正确的名称
+	actualName := resolveServerName(manager, name)
+	manager.AddServer(actualName, &powernapconfig.ServerConfig{
+		Command:     clientConfig.Command,
+		Args:        clientConfig.Args,
+		Environment: clientConfig.Env,
+		FileTypes:   clientConfig.FileTypes,
+		RootMarkers: clientConfig.RootMarkers,
+		InitOptions: clientConfig.InitOptions,
+		Settings:    clientConfig.Options,
+	})
+}
+
+// Configure 在运行时添加或禁用用户配置的LSP，使其无需重启即可生效
+func (m *Manager) Configure(name string, clientConfig config.LSPConfig) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	mergeUserServer(m.manager, name, clientConfig)
+}
+
 // Clients 返回LSP客户端映射
 func (m *Manager) Clients() *csync.Map[string, *Client] {
 	return m.clients
@@ -80,6 +93,11 @@ func (s *Manager) SetCallback(cb func(name string, client *Client)) {
 // Start 启动能够处理给定文件路径的LSP服务器
 // 如果适当的LSP已在运行，则此操作为空操作
 func (s *Manager) Start(ctx context.Context, filePath string) {
+	// 远程开发时文件在远程主机上，本地的LSP服务器无法读取
+	if s.cfg.Remote.Enabled() {
+		return
+	}
+
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
@@ -88,6 +106,10 @@ func (s *Manager) Start(ctx context.Context, filePath string) {
 		if !handles(server, filePath, s.cfg.WorkingDir()) {
 			continue
 		}
+		if s.cfg.DirectoryConfig(filePath).LSPDisabled(name) {
+			slog.Debug("LSP在文件所在子目录中被禁用，跳过", "name", name, "file", filePath)
+			continue
+		}
 		wg.Go(func() {
 			s.startServer(ctx, name, server)
 		})
@@ -156,6 +178,7 @@ func (s *Manager) startServer(ctx context.Context, name string, server *powernap
 		slog.Error("创建LSP客户端失败", "name", name, "error", err)
 		return
 	}
+	client.SetDirectoryConfig(s.cfg.DirectoryConfig)
 	s.callback(name, client)
 
 	defer func() {
@@ -263,6 +286,43 @@ func handles(server *powernapconfig.ServerConfig, filePath, workDir string) bool
 		hasRootMarkers(workDir, server.RootMarkers)
 }
 
+// Restart 重启指定的LSP客户端，已停止的客户端会重新启动
+func (s *Manager) Restart(name string) error {
+	client, ok := s.clients.Get(name)
+	if !ok {
+		return fmt.Errorf("未找到LSP客户端 '%s'", name)
+	}
+	client.SetServerState(StateStarting)
+	s.callback(name, client)
+	defer func() { s.callback(name, client) }()
+	return client.Restart()
+}
+
+// Stop 停止指定的LSP客户端。客户端保留在映射中，之后可以通过[Manager.Restart]重新启动，
+// 再次打开它处理的文件时也会重新启动
+func (s *Manager) Stop(ctx context.Context, name string) error {
+	client, ok := s.clients.Get(name)
+	if !ok {
+		return fmt.Errorf("未找到LSP客户端 '%s'", name)
+	}
+	defer func() { s.callback(name, client) }()
+	err := client.Close(ctx)
+	client.SetServerState(StateStopped)
+	if err != nil && !isClosedError(err) {
+		return err
+	}
+	slog.Debug("已停止LSP客户端", "name", name)
+	return nil
+}
+
+// isClosedError 报告关闭客户端时的错误是否只是因为服务器已经退出
+func isClosedError(err error) bool {
+	return errors.Is(err, io.EOF) ||
+		errors.Is(err, context.Canceled) ||
+		errors.Is(err, jsonrpc2.ErrClosed) ||
+		err.Error() == "signal: killed"
+}
+
 // KillAll 强制终止所有LSP客户端
 //
 // 这通常比[Manager.StopAll]更快，因为它不等待服务器优雅退出，
@@ -293,11 +353,7 @@ func (s *Manager) StopAll(ctx context.Context) {
 	for name, client := range s.clients.Seq2() {
 		wg.Go(func() {
 			defer func() { s.callback(name, client) }()
-			if err := client.Close(ctx); err != nil &&
-				!errors.Is(err, io.EOF) &&
-				!errors.Is(err, context.Canceled) &&
-				!errors.Is(err, jsonrpc2.ErrClosed) &&
-				err.Error() != "signal: killed" {
+			if err := client.Close(ctx); err != nil && !isClosedError(err) {
 				slog.Warn("停止LSP客户端失败", "name", name, "error", err)
 			}
 			client.SetServerState(StateStopped)
@@ -0,0 +1,80 @@
+package lsp
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/purpose168/crush-cn/internal/log"
+)
+
+const (
+	// processInfoTTL 是服务器进程信息的缓存时间，避免每次渲染都扫描进程表
+	processInfoTTL = 2 * time.Second
+	// stderrMaxBytes 是返回的服务器标准错误输出的最大字节数，超出时只保留末尾
+	stderrMaxBytes = 64 << 10
+	// serverStderrMessage 是 powernap 记录语言服务器标准错误输出时使用的日志消息
+	serverStderrMessage = "Language server stderr"
+)
+
+// ProcessInfo 是语言服务器进程的运行信息
+type ProcessInfo struct {
+	PID    int    // 进程ID
+	Memory uint64 // 常驻内存字节数
+}
+
+// Process 返回语言服务器进程的PID和内存占用，结果缓存[processInfoTTL]。
+// 服务器未运行或当前平台无法获取进程信息时返回false
+func (c *Client) Process() (ProcessInfo, bool) {
+	c.processMu.Lock()
+	defer c.processMu.Unlock()
+	if time.Since(c.processCheckedAt) >= processInfoTTL {
+		c.process, _ = findProcess(c.CommandLine())
+		c.processCheckedAt = time.Now()
+	}
+	return c.process, c.process.PID != 0
+}
+
+// Stderr 返回本次运行中语言服务器写入标准错误的内容。powernap 将服务器的标准错误输出
+// 记录到日志中，因此这里从日志文件中读取
+func (c *Client) Stderr() (string, error) {
+	path, offset := log.File()
+	if path == "" {
+		return "", errors.New("日志未初始化")
+	}
+	entries, _, err := log.ReadEntries(path, offset)
+	if err != nil {
+		return "", err
+	}
+	return serverStderr(entries, c.command), nil
+}
+
+// serverStderr 拼接 entries 中 command 的标准错误输出，超出[stderrMaxBytes]时只保留末尾
+func serverStderr(entries []log.Entry, command string) string {
+	var sb strings.Builder
+	for _, e := range entries {
+		if e.Message != serverStderrMessage {
+			continue
+		}
+		var cmd, output string
+		for _, a := range e.Attrs {
+			switch a.Key {
+			case "command":
+				cmd = a.Value
+			case "output":
+				output = a.Value
+			}
+		}
+		if cmd == command {
+			sb.WriteString(output)
+		}
+	}
+	stderr := sb.String()
+	if len(stderr) > stderrMaxBytes {
+		stderr = stderr[len(stderr)-stderrMaxBytes:]
+		if i := strings.IndexByte(stderr, '\n'); i >= 0 {
+			stderr = stderr[i+1:]
+		}
+	}
+	return stderr
+}
@@ -0,0 +1,72 @@
+package lsp
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// findProcess 在当前进程的子进程中查找命令行为 argv 的进程
+func findProcess(argv []string) (ProcessInfo, bool) {
+	entries, err := os.ReadDir("/proc")
+	if err != nil {
+		return ProcessInfo{}, false
+	}
+	self := os.Getpid()
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil {
+			continue
+		}
+		dir := filepath.Join("/proc", entry.Name())
+		if parentPID(dir) != self {
+			continue
+		}
+		cmdline, err := os.ReadFile(filepath.Join(dir, "cmdline"))
+		if err != nil {
+			continue
+		}
+		args := strings.Split(string(bytes.TrimSuffix(cmdline, []byte{0})), "\x00")
+		if !slices.Equal(args, argv) {
+			continue
+		}
+		return ProcessInfo{PID: pid, Memory: residentMemory(dir)}, true
+	}
+	return ProcessInfo{}, false
+}
+
+// parentPID 返回 /proc/<pid>/stat 中记录的父进程ID
+func parentPID(dir string) int {
+	stat, err := os.ReadFile(filepath.Join(dir, "stat"))
+	if err != nil {
+		return 0
+	}
+	// 进程名可能包含空格和括号，字段从最后一个右括号之后开始
+	i := bytes.LastIndexByte(stat, ')')
+	if i < 0 {
+		return 0
+	}
+	fields := strings.Fields(string(stat[i+1:]))
+	if len(fields) < 2 {
+		return 0
+	}
+	ppid, _ := strconv.Atoi(fields[1])
+	return ppid
+}
+
+// residentMemory 返回 /proc/<pid>/statm 中记录的常驻内存字节数
+func residentMemory(dir string) uint64 {
+	statm, err := os.ReadFile(filepath.Join(dir, "statm"))
+	if err != nil {
+		return 0
+	}
+	fields := strings.Fields(string(statm))
+	if len(fields) < 2 {
+		return 0
+	}
+	pages, _ := strconv.ParseUint(fields[1], 10, 64)
+	return pages * uint64(os.Getpagesize())
+}
@@ -0,0 +1,8 @@
+//go:build !linux
+
+package lsp
+
+// findProcess 在当前平台上无法获取进程信息
+func findProcess([]string) (ProcessInfo, bool) {
+	return ProcessInfo{}, false
+}
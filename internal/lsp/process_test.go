@@ -0,0 +1,50 @@
+package lsp
+
+import (
+	"os/exec"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/purpose168/crush-cn/internal/log"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindProcess(t *testing.T) {
+	t.Parallel()
+	if runtime.GOOS != "linux" {
+		t.Skip("进程信息只在 Linux 上可用")
+	}
+
+	cmd := exec.Command("sleep", "30")
+	require.NoError(t, cmd.Start())
+	t.Cleanup(func() {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+	})
+
+	info, ok := findProcess([]string{"sleep", "30"})
+	require.True(t, ok)
+	require.Equal(t, cmd.Process.Pid, info.PID)
+	require.NotZero(t, info.Memory)
+
+	_, ok = findProcess([]string{"sleep", "31"})
+	require.False(t, ok)
+}
+
+func TestServerStderr(t *testing.T) {
+	t.Parallel()
+
+	entries := []log.Entry{
+		{Message: serverStderrMessage, Attrs: []log.Attr{{Key: "command", Value: "gopls"}, {Key: "output", Value: "first\n"}}},
+		{Message: serverStderrMessage, Attrs: []log.Attr{{Key: "command", Value: "rust-analyzer"}, {Key: "output", Value: "other\n"}}},
+		{Message: "something else", Attrs: []log.Attr{{Key: "command", Value: "gopls"}, {Key: "output", Value: "ignored\n"}}},
+		{Message: serverStderrMessage, Attrs: []log.Attr{{Key: "command", Value: "gopls"}, {Key: "output", Value: "second\n"}}},
+	}
+	require.Equal(t, "first\nsecond\n", serverStderr(entries, "gopls"))
+
+	long := strings.Repeat("line\n", stderrMaxBytes/5+10)
+	stderr := serverStderr([]log.Entry{{Message: serverStderrMessage, Attrs: []log.Attr{{Key: "command", Value: "gopls"}, {Key: "output", Value: long}}}}, "gopls")
+	require.LessOrEqual(t, len(stderr), stderrMaxBytes)
+	require.True(t, strings.HasPrefix(stderr, "line\n"))
+}
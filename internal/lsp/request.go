@@ -0,0 +1,195 @@
+package lsp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"unsafe"
+
+	powernap "github.com/charmbracelet/x/powernap/pkg/lsp"
+	"github.com/charmbracelet/x/powernap/pkg/lsp/protocol"
+	"github.com/charmbracelet/x/powernap/pkg/transport"
+)
+
+// LSP 请求方法，powernap 没有为这些请求提供对应的方法
+const (
+	methodTextDocumentRename     = "textDocument/rename"
+	methodTextDocumentCodeAction = "textDocument/codeAction"
+	methodCodeActionResolve      = "codeAction/resolve"
+	methodTextDocumentHover      = "textDocument/hover"
+	methodWorkspaceSymbol        = "workspace/symbol"
+)
+
+// Rename 请求将给定位置的符号重命名为 newName，返回需要应用的工作区编辑
+// 注意: line和character从1开始计数，character以UTF-16代码单元计
+func (c *Client) Rename(ctx context.Context, filepath string, line, character int, newName string) (*protocol.WorkspaceEdit, error) {
+	if err := c.OpenFileOnDemand(ctx, filepath); err != nil {
+		return nil, err
+	}
+	params := protocol.RenameParams{
+		TextDocument: protocol.TextDocumentIdentifier{URI: protocol.URIFromPath(filepath)},
+		Position: protocol.Position{
+			Line:      uint32(line - 1),      //nolint:gosec
+			Character: uint32(character - 1), //nolint:gosec
+		},
+		NewName: newName,
+	}
+	var result *protocol.WorkspaceEdit
+	if err := c.call(ctx, methodTextDocumentRename, params, &result); err != nil {
+		return nil, fmt.Errorf("重命名请求失败: %w", err)
+	}
+	return result, nil
+}
+
+// CodeActions 返回文件中 rng 范围内可用的代码操作。only 不为空时只请求这些类型的操作。
+// 与范围重叠的诊断信息会一并发送，以便服务器提供快速修复
+func (c *Client) CodeActions(ctx context.Context, filepath string, rng protocol.Range, only []protocol.CodeActionKind) ([]protocol.CodeAction, error) {
+	if err := c.OpenFileOnDemand(ctx, filepath); err != nil {
+		return nil, err
+	}
+	uri := protocol.URIFromPath(filepath)
+	diagnostics := []protocol.Diagnostic{}
+	for _, diag := range c.GetFileDiagnostics(uri) {
+		if diag.Range.Start.Line <= rng.End.Line && diag.Range.End.Line >= rng.Start.Line {
+			diagnostics = append(diagnostics, diag)
+		}
+	}
+	params := protocol.CodeActionParams{
+		TextDocument: protocol.TextDocumentIdentifier{URI: uri},
+		Range:        rng,
+		Context: protocol.CodeActionContext{
+			Diagnostics: diagnostics,
+			Only:        only,
+		},
+	}
+	var result []json.RawMessage
+	if err := c.call(ctx, methodTextDocumentCodeAction, params, &result); err != nil {
+		return nil, fmt.Errorf("代码操作请求失败: %w", err)
+	}
+
+	// 结果中的每一项可能是 CodeAction，也可能是只包含命令的 Command
+	actions := make([]protocol.CodeAction, 0, len(result))
+	for _, raw := range result {
+		var command protocol.Command
+		if err := json.Unmarshal(raw, &command); err == nil && command.Command != "" {
+			actions = append(actions, protocol.CodeAction{Title: command.Title, Command: &command})
+			continue
+		}
+		var action protocol.CodeAction
+		if err := json.Unmarshal(raw, &action); err != nil {
+			return nil, fmt.Errorf("解析代码操作失败: %w", err)
+		}
+		actions = append(actions, action)
+	}
+	return actions, nil
+}
+
+// ResolveCodeAction 请求服务器补全代码操作的编辑。服务器通常只在解析时才计算编辑
+func (c *Client) ResolveCodeAction(ctx context.Context, action protocol.CodeAction) (protocol.CodeAction, error) {
+	var result protocol.CodeAction
+	if err := c.call(ctx, methodCodeActionResolve, action, &result); err != nil {
+		return action, fmt.Errorf("解析代码操作失败: %w", err)
+	}
+	return result, nil
+}
+
+// WorkspaceSymbols 搜索工作区中与 query 匹配的符号。服务器也可能返回 WorkspaceSymbol，
+// 其位置可以不含范围，此时范围为零值
+func (c *Client) WorkspaceSymbols(ctx context.Context, query string) ([]protocol.SymbolInformation, error) {
+	params := protocol.WorkspaceSymbolParams{Query: query}
+	var result []protocol.SymbolInformation
+	if err := c.call(ctx, methodWorkspaceSymbol, params, &result); err != nil {
+		return nil, fmt.Errorf("工作区符号请求失败: %w", err)
+	}
+	return result, nil
+}
+
+// Hover 返回给定位置的悬停信息（Markdown 格式），没有悬停信息时返回空字符串
+// 注意: line和character从1开始计数，character以UTF-16代码单元计
+func (c *Client) Hover(ctx context.Context, filepath string, line, character int) (string, error) {
+	if err := c.OpenFileOnDemand(ctx, filepath); err != nil {
+		return "", err
+	}
+	params := protocol.HoverParams{
+		TextDocumentPositionParams: protocol.TextDocumentPositionParams{
+			TextDocument: protocol.TextDocumentIdentifier{URI: protocol.URIFromPath(filepath)},
+			Position: protocol.Position{
+				Line:      uint32(line - 1),      //nolint:gosec
+				Character: uint32(character - 1), //nolint:gosec
+			},
+		},
+	}
+	// 旧版服务器的 contents 可能是 MarkedString 或其数组，powernap 的 Hover 只支持 MarkupContent
+	var result *struct {
+		Contents json.RawMessage `json:"contents"`
+	}
+	if err := c.call(ctx, methodTextDocumentHover, params, &result); err != nil {
+		return "", fmt.Errorf("悬停请求失败: %w", err)
+	}
+	if result == nil {
+		return "", nil
+	}
+	return hoverText(result.Contents), nil
+}
+
+// hoverText 将悬停内容转换为 Markdown。内容可能是 MarkupContent、
+// MarkedString（字符串或带语言的代码块）或 MarkedString 数组
+func hoverText(raw json.RawMessage) string {
+	var text string
+	if err := json.Unmarshal(raw, &text); err == nil {
+		return strings.TrimSpace(text)
+	}
+	var parts []json.RawMessage
+	if err := json.Unmarshal(raw, &parts); err == nil {
+		texts := make([]string, 0, len(parts))
+		for _, part := range parts {
+			if text := hoverText(part); text != "" {
+				texts = append(texts, text)
+			}
+		}
+		return strings.Join(texts, "\n\n")
+	}
+	var content struct {
+		Kind     string `json:"kind"`
+		Language string `json:"language"`
+		Value    string `json:"value"`
+	}
+	if err := json.Unmarshal(raw, &content); err != nil {
+		return ""
+	}
+	value := strings.TrimSpace(content.Value)
+	switch {
+	case value == "":
+		return ""
+	case content.Language != "":
+		return "```" + content.Language + "\n" + value + "\n```"
+	case content.Kind == string(protocol.PlainText):
+		return "```\n" + value + "\n```"
+	default:
+		return value
+	}
+}
+
+// call 向语言服务器发送 method 请求并将结果解码到 result 中
+func (c *Client) call(ctx context.Context, method string, params, result any) error {
+	conn := powernapConn(c.client)
+	if conn == nil {
+		return fmt.Errorf("LSP客户端不支持 %s 请求", method)
+	}
+	return conn.Call(ctx, method, params, result)
+}
+
+// powernapConn 返回 powernap 客户端的 JSON-RPC 连接。powernap 没有提供发送任意请求的
+// 方法，因此这里读取其未导出的连接字段；字段不存在时返回 nil
+func powernapConn(client *powernap.Client) *transport.Connection {
+	if client == nil {
+		return nil
+	}
+	field := reflect.ValueOf(client).Elem().FieldByName("conn")
+	if !field.IsValid() || field.Type() != reflect.TypeFor[*transport.Connection]() {
+		return nil
+	}
+	return reflect.NewAt(field.Type(), unsafe.Pointer(field.UnsafeAddr())).Elem().Interface().(*transport.Connection)
+}
@@ -0,0 +1,47 @@
+package lsp
+
+import (
+	"testing"
+
+	"github.com/purpose168/crush-cn/internal/config"
+	"github.com/purpose168/crush-cn/internal/env"
+	"github.com/stretchr/testify/require"
+)
+
+// TestPowernapConn 确保仍然可以取得 powernap 客户端的连接，升级 powernap 后字段改名时会失败
+func TestPowernapConn(t *testing.T) {
+	require.Nil(t, powernapConn(nil))
+
+	cfg := config.LSPConfig{Command: "echo", FileTypes: []string{"go"}}
+	client, err := New(t.Context(), "test", cfg, config.NewEnvironmentVariableResolver(env.NewFromMap(nil)), false)
+	if err != nil {
+		t.Skipf("使用虚拟命令创建Powernap客户端失败: %v", err)
+	}
+	t.Cleanup(client.Kill)
+
+	require.NotNil(t, powernapConn(client.client))
+}
+
+func TestHoverText(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		raw  string
+		want string
+	}{
+		{"markup", `{"kind":"markdown","value":"**Foo** does things\n"}`, "**Foo** does things"},
+		{"plaintext", `{"kind":"plaintext","value":"func Foo()"}`, "```\nfunc Foo()\n```"},
+		{"string", `"Foo docs"`, "Foo docs"},
+		{"marked with language", `{"language":"go","value":"func Foo()"}`, "```go\nfunc Foo()\n```"},
+		{"array", `[{"language":"go","value":"func Foo()"},"","Foo docs"]`, "```go\nfunc Foo()\n```\n\nFoo docs"},
+		{"empty", `{"kind":"markdown","value":""}`, ""},
+		{"invalid", `42`, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			require.Equal(t, tt.want, hoverText([]byte(tt.raw)))
+		})
+	}
+}
@@ -0,0 +1,128 @@
+package lsp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/charmbracelet/x/powernap/pkg/lsp/protocol"
+)
+
+// WorkspaceSymbol 是语言服务器在工作区中找到的符号
+type WorkspaceSymbol struct {
+	LSP       string              // 返回该符号的语言服务器名称
+	Name      string              // 符号名称
+	Kind      protocol.SymbolKind // 符号类型
+	Container string              // 包含该符号的符号名称，例如方法所属的类型
+	Path      string              // 符号所在文件，在工作目录内时为相对路径
+	Line      int                 // 符号所在行，从1开始
+	Character int                 // 符号所在列，从1开始，以UTF-16代码单元计
+}
+
+// Location 返回符号的 path:line 位置
+func (s WorkspaceSymbol) Location() string {
+	return fmt.Sprintf("%s:%d", s.Path, s.Line)
+}
+
+// ReadyClients 返回已就绪的语言服务器，按名称排序
+func (s *Manager) ReadyClients() []*Client {
+	var clients []*Client
+	for client := range s.Clients().Seq() {
+		if client.GetServerState() == StateReady {
+			clients = append(clients, client)
+		}
+	}
+	slices.SortFunc(clients, func(a, b *Client) int {
+		return strings.Compare(a.GetName(), b.GetName())
+	})
+	return clients
+}
+
+// SearchWorkspaceSymbols 在 clients 中并发搜索与 query 匹配的工作区符号，结果按 clients 的顺序合并，
+// 每个语言服务器内保持其返回的顺序。limit 大于0时最多返回 limit 个符号。
+// 部分语言服务器失败时仍返回其余的符号，同时返回合并后的错误。
+func SearchWorkspaceSymbols(ctx context.Context, clients []*Client, query, workingDir string, limit int) ([]WorkspaceSymbol, error) {
+	results := make([][]WorkspaceSymbol, len(clients))
+	errs := make([]error, len(clients))
+	var wg sync.WaitGroup
+	for i, client := range clients {
+		wg.Go(func() {
+			symbols, err := client.WorkspaceSymbols(ctx, query)
+			if err != nil {
+				errs[i] = fmt.Errorf("%s: %w", client.GetName(), err)
+				return
+			}
+			results[i] = workspaceSymbols(client.GetName(), workingDir, symbols)
+		})
+	}
+	wg.Wait()
+
+	symbols := slices.Concat(results...)
+	if limit > 0 {
+		symbols = symbols[:min(len(symbols), limit)]
+	}
+	return symbols, errors.Join(errs...)
+}
+
+// workspaceSymbols 将语言服务器返回的符号转换为 [WorkspaceSymbol]，工作目录内的路径转换为相对路径
+func workspaceSymbols(name, workingDir string, infos []protocol.SymbolInformation) []WorkspaceSymbol {
+	symbols := make([]WorkspaceSymbol, 0, len(infos))
+	for _, info := range infos {
+		path, err := info.Location.URI.Path()
+		if err != nil {
+			continue
+		}
+		if rel, err := filepath.Rel(workingDir, path); err == nil && !strings.HasPrefix(rel, "..") {
+			path = rel
+		}
+		start := info.Location.Range.Start
+		symbols = append(symbols, WorkspaceSymbol{
+			LSP:       name,
+			Name:      info.Name,
+			Kind:      info.Kind,
+			Container: info.ContainerName,
+			Path:      path,
+			Line:      int(start.Line) + 1,
+			Character: int(start.Character) + 1,
+		})
+	}
+	return symbols
+}
+
+var symbolKindNames = map[protocol.SymbolKind]string{
+	protocol.File:          "file",
+	protocol.Module:        "module",
+	protocol.Namespace:     "namespace",
+	protocol.Package:       "package",
+	protocol.Class:         "class",
+	protocol.Method:        "method",
+	protocol.Property:      "property",
+	protocol.Field:         "field",
+	protocol.Constructor:   "constructor",
+	protocol.Enum:          "enum",
+	protocol.Interface:     "interface",
+	protocol.Function:      "function",
+	protocol.Variable:      "variable",
+	protocol.Constant:      "constant",
+	protocol.String:        "string",
+	protocol.Number:        "number",
+	protocol.Boolean:       "boolean",
+	protocol.Array:         "array",
+	protocol.Object:        "object",
+	protocol.Key:           "key",
+	protocol.Null:          "null",
+	protocol.EnumMember:    "enum member",
+	protocol.Struct:        "struct",
+	protocol.Event:         "event",
+	protocol.Operator:      "operator",
+	protocol.TypeParameter: "type parameter",
+}
+
+// SymbolKindName 返回符号类型的名称，例如 "function"，未知类型返回空字符串
+func SymbolKindName(kind protocol.SymbolKind) string {
+	return symbolKindNames[kind]
+}
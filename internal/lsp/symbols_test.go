@@ -0,0 +1,58 @@
+package lsp
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/charmbracelet/x/powernap/pkg/lsp/protocol"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWorkspaceSymbols(t *testing.T) {
+	t.Parallel()
+
+	workingDir := t.TempDir()
+	outside := filepath.Join(filepath.Dir(workingDir), "other", "lib.go")
+	symbol := func(name string, kind protocol.SymbolKind, path string, line, char uint32) protocol.SymbolInformation {
+		return protocol.SymbolInformation{
+			Name: name,
+			Kind: kind,
+			Location: protocol.Location{
+				URI:   protocol.URIFromPath(path),
+				Range: protocol.Range{Start: protocol.Position{Line: line, Character: char}},
+			},
+		}
+	}
+	method := symbol("Run", protocol.Method, filepath.Join(workingDir, "internal", "app.go"), 41, 17)
+	method.ContainerName = "App"
+
+	symbols := workspaceSymbols("gopls", workingDir, []protocol.SymbolInformation{
+		method,
+		symbol("Helper", protocol.Function, outside, 0, 5),
+		{Name: "Broken", Location: protocol.Location{URI: "not-a-uri"}},
+	})
+	require.Equal(t, []WorkspaceSymbol{
+		{
+			LSP:       "gopls",
+			Name:      "Run",
+			Kind:      protocol.Method,
+			Container: "App",
+			Path:      filepath.Join("internal", "app.go"),
+			Line:      42,
+			Character: 18,
+		},
+		{
+			LSP:       "gopls",
+			Name:      "Helper",
+			Kind:      protocol.Function,
+			Path:      outside,
+			Line:      1,
+			Character: 6,
+		},
+	}, symbols)
+	require.Equal(t, filepath.Join("internal", "app.go")+":42", symbols[0].Location())
+
+	require.Equal(t, "method", SymbolKindName(protocol.Method))
+	require.Equal(t, "enum member", SymbolKindName(protocol.EnumMember))
+	require.Empty(t, SymbolKindName(0))
+}
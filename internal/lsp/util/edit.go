@@ -1,7 +1,6 @@
 package util
 
 import (
-	"bytes"
 	"fmt"
 	"os"
 	"sort"
@@ -28,25 +27,44 @@ func applyTextEdits(uri protocol.DocumentURI, edits []protocol.TextEdit) error {
 		return fmt.Errorf("读取文件失败: %w", err)
 	}
 
+	newContent, err := editContent(string(content), edits)
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(path, []byte(newContent), 0o644); err != nil {
+		return fmt.Errorf("写入文件失败: %w", err)
+	}
+
+	return nil
+}
+
+// editContent 将文本编辑应用到内容并返回编辑后的内容
+// 参数:
+//   - content: 原始内容
+//   - edits: 要应用的文本编辑列表
+//
+// 返回值: 编辑后的内容和可能的错误
+func editContent(content string, edits []protocol.TextEdit) (string, error) {
 	// 检测换行符风格
 	var lineEnding string
-	if bytes.Contains(content, []byte("\r\n")) {
+	if strings.Contains(content, "\r\n") {
 		lineEnding = "\r\n"
 	} else {
 		lineEnding = "\n"
 	}
 
 	// 跟踪文件是否以换行符结尾
-	endsWithNewline := len(content) > 0 && bytes.HasSuffix(content, []byte(lineEnding))
+	endsWithNewline := len(content) > 0 && strings.HasSuffix(content, lineEnding)
 
 	// 按换行符分割成行（不包含换行符）
-	lines := strings.Split(string(content), lineEnding)
+	lines := strings.Split(content, lineEnding)
 
 	// 检查重叠的编辑
 	for i, edit1 := range edits {
 		for j := i + 1; j < len(edits); j++ {
 			if rangesOverlap(edit1.Range, edits[j].Range) {
-				return fmt.Errorf("检测到编辑%d和%d之间有重叠", i, j)
+				return "", fmt.Errorf("检测到编辑%d和%d之间有重叠", i, j)
 			}
 		}
 	}
@@ -65,7 +83,7 @@ func applyTextEdits(uri protocol.DocumentURI, edits []protocol.TextEdit) error {
 	for _, edit := range sortedEdits {
 		newLines, err := applyTextEdit(lines, edit)
 		if err != nil {
-			return fmt.Errorf("应用编辑失败: %w", err)
+			return "", fmt.Errorf("应用编辑失败: %w", err)
 		}
 		lines = newLines
 	}
@@ -84,11 +102,7 @@ func applyTextEdits(uri protocol.DocumentURI, edits []protocol.TextEdit) error {
 		newContent.WriteString(lineEnding)
 	}
 
-	if err := os.WriteFile(path, []byte(newContent.String()), 0o644); err != nil {
-		return fmt.Errorf("写入文件失败: %w", err)
-	}
-
-	return nil
+	return newContent.String(), nil
 }
 
 // applyTextEdit 将单个文本编辑应用到行列表
@@ -289,3 +303,173 @@ func rangesOverlap(r1, r2 protocol.Range) bool {
 	}
 	return true
 }
+
+// FileChange 描述工作区编辑对单个文件的更改
+type FileChange struct {
+	Path       string // 更改后的文件路径
+	OldPath    string // 文件被重命名时的原路径
+	OldContent string // 更改前的内容，新建的文件为空
+	NewContent string // 更改后的内容，删除的文件为空
+	Created    bool   // 文件由编辑新建
+	Deleted    bool   // 文件被编辑删除
+}
+
+// PreviewWorkspaceEdit 计算工作区编辑对每个文件的更改，但不修改文件系统
+// 参数:
+//   - edit: 工作区编辑对象
+//
+// 返回值: 按首次涉及的顺序排列的文件更改，以及计算时发生的错误
+func PreviewWorkspaceEdit(edit protocol.WorkspaceEdit) ([]FileChange, error) {
+	p := &editPreview{files: make(map[string]*FileChange)}
+
+	uris := make([]protocol.DocumentURI, 0, len(edit.Changes))
+	for uri := range edit.Changes {
+		uris = append(uris, uri)
+	}
+	sort.Slice(uris, func(i, j int) bool { return uris[i] < uris[j] })
+	for _, uri := range uris {
+		if err := p.editText(uri, edit.Changes[uri]); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, change := range edit.DocumentChanges {
+		if err := p.apply(change); err != nil {
+			return nil, err
+		}
+	}
+
+	var changes []FileChange
+	for _, f := range p.order {
+		if !f.Created && !f.Deleted && f.OldPath == "" && f.OldContent == f.NewContent {
+			continue
+		}
+		changes = append(changes, *f)
+	}
+	return changes, nil
+}
+
+// editPreview 保存预览工作区编辑时每个文件的当前状态
+type editPreview struct {
+	order []*FileChange
+	files map[string]*FileChange
+}
+
+// file 返回路径对应的文件状态，首次涉及时从磁盘读取内容
+func (p *editPreview) file(path string) (*FileChange, error) {
+	if f, ok := p.files[path]; ok {
+		if f.Deleted {
+			return nil, fmt.Errorf("文件已被删除: %s", path)
+		}
+		return f, nil
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取文件失败: %w", err)
+	}
+	f := &FileChange{Path: path, OldContent: string(content), NewContent: string(content)}
+	p.add(f)
+	return f, nil
+}
+
+func (p *editPreview) add(f *FileChange) {
+	p.files[f.Path] = f
+	p.order = append(p.order, f)
+}
+
+// exists 判断路径在已预览的编辑之后是否存在
+func (p *editPreview) exists(path string) bool {
+	if f, ok := p.files[path]; ok {
+		return !f.Deleted
+	}
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+func (p *editPreview) editText(uri protocol.DocumentURI, edits []protocol.TextEdit) error {
+	path, err := uri.Path()
+	if err != nil {
+		return fmt.Errorf("无效的URI: %w", err)
+	}
+	f, err := p.file(path)
+	if err != nil {
+		return err
+	}
+	f.NewContent, err = editContent(f.NewContent, edits)
+	return err
+}
+
+func (p *editPreview) apply(change protocol.DocumentChange) error {
+	switch {
+	case change.CreateFile != nil:
+		path, err := change.CreateFile.URI.Path()
+		if err != nil {
+			return fmt.Errorf("无效的URI: %w", err)
+		}
+		opts := change.CreateFile.Options
+		if p.exists(path) {
+			if opts != nil && opts.IgnoreIfExists && !opts.Overwrite {
+				return nil
+			}
+			f, err := p.file(path)
+			if err != nil {
+				return err
+			}
+			f.NewContent = ""
+			return nil
+		}
+		p.add(&FileChange{Path: path, Created: true})
+	case change.DeleteFile != nil:
+		path, err := change.DeleteFile.URI.Path()
+		if err != nil {
+			return fmt.Errorf("无效的URI: %w", err)
+		}
+		f, ok := p.files[path]
+		if !ok {
+			f = &FileChange{Path: path}
+			if content, err := os.ReadFile(path); err == nil {
+				f.OldContent = string(content)
+			}
+			p.add(f)
+		}
+		f.Deleted = true
+		f.NewContent = ""
+	case change.RenameFile != nil:
+		oldPath, err := change.RenameFile.OldURI.Path()
+		if err != nil {
+			return err
+		}
+		newPath, err := change.RenameFile.NewURI.Path()
+		if err != nil {
+			return err
+		}
+		opts := change.RenameFile.Options
+		if p.exists(newPath) && (opts == nil || !opts.Overwrite) {
+			if opts != nil && opts.IgnoreIfExists {
+				return nil
+			}
+			return fmt.Errorf("目标文件已存在且不允许覆盖: %s", newPath)
+		}
+		f, err := p.file(oldPath)
+		if err != nil {
+			return err
+		}
+		delete(p.files, oldPath)
+		if f.OldPath == "" && !f.Created {
+			f.OldPath = oldPath
+		}
+		f.Path = newPath
+		p.files[newPath] = f
+	case change.TextDocumentEdit != nil:
+		textEdits := make([]protocol.TextEdit, len(change.TextDocumentEdit.Edits))
+		for i, edit := range change.TextDocumentEdit.Edits {
+			var err error
+			textEdits[i], err = edit.AsTextEdit()
+			if err != nil {
+				return fmt.Errorf("无效的编辑类型: %w", err)
+			}
+		}
+		return p.editText(change.TextDocumentEdit.TextDocument.URI, textEdits)
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package util
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/charmbracelet/x/powernap/pkg/lsp/protocol"
+	"github.com/stretchr/testify/require"
+)
+
+func textEdit(line, start, end uint32, text string) protocol.TextEdit {
+	return protocol.TextEdit{
+		Range: protocol.Range{
+			Start: protocol.Position{Line: line, Character: start},
+			End:   protocol.Position{Line: line, Character: end},
+		},
+		NewText: text,
+	}
+}
+
+func TestPreviewWorkspaceEdit(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	a := filepath.Join(dir, "a.go")
+	b := filepath.Join(dir, "b.go")
+	c := filepath.Join(dir, "c.go")
+	require.NoError(t, os.WriteFile(a, []byte("func foo() {}\n"), 0o644))
+	require.NoError(t, os.WriteFile(b, []byte("x := foo()\r\ny := foo()\r\n"), 0o644))
+
+	edit := protocol.WorkspaceEdit{
+		Changes: map[protocol.DocumentURI][]protocol.TextEdit{
+			protocol.URIFromPath(b): {textEdit(0, 5, 8, "bar"), textEdit(1, 5, 8, "bar")},
+		},
+		DocumentChanges: []protocol.DocumentChange{
+			{TextDocumentEdit: &protocol.TextDocumentEdit{
+				TextDocument: protocol.OptionalVersionedTextDocumentIdentifier{
+					TextDocumentIdentifier: protocol.TextDocumentIdentifier{URI: protocol.URIFromPath(a)},
+				},
+				Edits: []protocol.Or_TextDocumentEdit_edits_Elem{{Value: textEdit(0, 5, 8, "bar")}},
+			}},
+			{RenameFile: &protocol.RenameFile{OldURI: protocol.URIFromPath(a), NewURI: protocol.URIFromPath(c)}},
+		},
+	}
+
+	changes, err := PreviewWorkspaceEdit(edit)
+	require.NoError(t, err)
+	require.Equal(t, []FileChange{
+		{Path: b, OldContent: "x := foo()\r\ny := foo()\r\n", NewContent: "x := bar()\r\ny := bar()\r\n"},
+		{Path: c, OldPath: a, OldContent: "func foo() {}\n", NewContent: "func bar() {}\n"},
+	}, changes)
+
+	// 预览不会修改文件系统
+	content, err := os.ReadFile(a)
+	require.NoError(t, err)
+	require.Equal(t, "func foo() {}\n", string(content))
+
+	require.NoError(t, ApplyWorkspaceEdit(edit))
+	for _, change := range changes {
+		content, err := os.ReadFile(change.Path)
+		require.NoError(t, err)
+		require.Equal(t, change.NewContent, string(content))
+	}
+}
+
+func TestPreviewWorkspaceEditCreateAndDelete(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	old := filepath.Join(dir, "old.txt")
+	created := filepath.Join(dir, "new.txt")
+	require.NoError(t, os.WriteFile(old, []byte("bye\n"), 0o644))
+
+	changes, err := PreviewWorkspaceEdit(protocol.WorkspaceEdit{
+		DocumentChanges: []protocol.DocumentChange{
+			{CreateFile: &protocol.CreateFile{URI: protocol.URIFromPath(created)}},
+			{TextDocumentEdit: &protocol.TextDocumentEdit{
+				TextDocument: protocol.OptionalVersionedTextDocumentIdentifier{
+					TextDocumentIdentifier: protocol.TextDocumentIdentifier{URI: protocol.URIFromPath(created)},
+				},
+				Edits: []protocol.Or_TextDocumentEdit_edits_Elem{{Value: textEdit(0, 0, 0, "hello")}},
+			}},
+			{DeleteFile: &protocol.DeleteFile{URI: protocol.URIFromPath(old)}},
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, []FileChange{
+		{Path: created, NewContent: "hello", Created: true},
+		{Path: old, OldContent: "bye\n", Deleted: true},
+	}, changes)
+}
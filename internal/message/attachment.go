@@ -1,10 +1,26 @@
 package message
 
 import (
+	"bytes"
+	"image"
+	_ "image/gif"  // 注册 GIF 解码器以读取图片尺寸
+	_ "image/jpeg" // 注册 JPEG 解码器以读取图片尺寸
+	_ "image/png"  // 注册 PNG 解码器以读取图片尺寸
 	"slices"
 	"strings"
 )
 
+const (
+	// bytesPerToken 是估算文本令牌数时每个令牌对应的平均字节数
+	bytesPerToken = 4
+	// pixelsPerToken 是估算图片令牌数时每个令牌对应的像素数
+	pixelsPerToken = 750
+	// maxImageEdge 是提供者缩放图片后的最长边（像素）
+	maxImageEdge = 1568
+	// unknownImageTokens 是无法读取尺寸的图片的估算令牌数
+	unknownImageTokens = 1600
+)
+
 // Attachment 表示消息附件的结构体
 // 用于存储附件的文件信息、MIME类型和内容数据
 type Attachment struct {
@@ -22,6 +38,23 @@ func (a Attachment) IsText() bool { return strings.HasPrefix(a.MimeType, "text/"
 // 返回值：如果附件的 MIME 类型以 "image/" 开头则返回 true，否则返回 false
 func (a Attachment) IsImage() bool { return strings.HasPrefix(a.MimeType, "image/") }
 
+// EstimateTokens 粗略估算附件发送给模型时占用的令牌数。文本按每 4 字节一个令牌计算，
+// 图片按缩放到最长边 1568 像素后每 750 像素一个令牌计算，不同提供者的实际计费会有差异。
+func (a Attachment) EstimateTokens() int {
+	if !a.IsImage() {
+		return (len(a.Content) + bytesPerToken - 1) / bytesPerToken
+	}
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(a.Content))
+	if err != nil || cfg.Width == 0 || cfg.Height == 0 {
+		return unknownImageTokens
+	}
+	width, height := float64(cfg.Width), float64(cfg.Height)
+	if scale := maxImageEdge / max(width, height); scale < 1 {
+		width, height = width*scale, height*scale
+	}
+	return max(1, int(width*height)/pixelsPerToken)
+}
+
 // ContainsTextAttachment 检查附件列表中是否包含文本类型的附件
 // 参数：
 //   - attachments: 附件切片，需要检查的附件列表
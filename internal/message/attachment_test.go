@@ -0,0 +1,34 @@
+package message
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAttachmentEstimateTokens(t *testing.T) {
+	t.Parallel()
+
+	encode := func(width, height int) []byte {
+		var buf bytes.Buffer
+		require.NoError(t, png.Encode(&buf, image.NewGray(image.Rect(0, 0, width, height))))
+		return buf.Bytes()
+	}
+
+	text := Attachment{MimeType: "text/plain", Content: []byte(strings.Repeat("x", 10))}
+	require.Equal(t, 3, text.EstimateTokens())
+
+	small := Attachment{MimeType: "image/png", Content: encode(300, 250)}
+	require.Equal(t, 100, small.EstimateTokens())
+
+	// 超过最长边的图片先按比例缩放
+	large := Attachment{MimeType: "image/png", Content: encode(3136, 1568)}
+	require.Equal(t, 1568*784/750, large.EstimateTokens())
+
+	broken := Attachment{MimeType: "image/png", Content: []byte("not an image")}
+	require.Equal(t, unknownImageTokens, broken.EstimateTokens())
+}
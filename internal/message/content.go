@@ -186,11 +186,33 @@ type Finish struct {
 	Message string `json:"message,omitempty"`
 	// Details 包含结束详情
 	Details string `json:"details,omitempty"`
+	// Metrics 包含生成该消息时测得的流式响应指标
+	Metrics *ResponseMetrics `json:"metrics,omitempty"`
 }
 
 // isPart 实现 ContentPart 接口
 func (Finish) isPart() {}
 
+// ResponseMetrics 表示一次模型请求的流式响应指标
+type ResponseMetrics struct {
+	// LatencyMs 是从发送请求到提供商开始响应的毫秒数
+	LatencyMs int64 `json:"latency_ms,omitempty"`
+	// FirstTokenMs 是从发送请求到收到第一个生成令牌的毫秒数
+	FirstTokenMs int64 `json:"first_token_ms,omitempty"`
+	// InputTokens 是请求的输入令牌数，包括读取的缓存令牌
+	InputTokens int64 `json:"input_tokens,omitempty"`
+	// OutputTokens 是生成的令牌数
+	OutputTokens int64 `json:"output_tokens,omitempty"`
+	// ReasoningTokens 是生成令牌中用于推理的令牌数，提供商未上报时为 0
+	ReasoningTokens int64 `json:"reasoning_tokens,omitempty"`
+	// CacheReadTokens 是从提供商的提示缓存中读取的输入令牌数
+	CacheReadTokens int64 `json:"cache_read_tokens,omitempty"`
+	// CacheCreationTokens 是写入提供商的提示缓存的输入令牌数
+	CacheCreationTokens int64 `json:"cache_creation_tokens,omitempty"`
+	// TokensPerSecond 是从第一个令牌到响应结束期间每秒生成的令牌数
+	TokensPerSecond float64 `json:"tokens_per_second,omitempty"`
+}
+
 // Message 表示一条完整的消息
 type Message struct {
 	// ID 包含消息的唯一标识符
@@ -427,6 +449,30 @@ func (m *Message) FinishThinking() {
 	}
 }
 
+// DiscardReasoning 删除推理文本和签名，只保留推理的起止时间。
+// 返回消息中是否有被删除的推理内容
+func (m *Message) DiscardReasoning() bool {
+	for i, part := range m.Parts {
+		if c, ok := part.(ReasoningContent); ok {
+			if c.Thinking == "" && c.Signature == "" && c.ThoughtSignature == "" && c.ResponsesData == nil {
+				return false
+			}
+			m.Parts[i] = ReasoningContent{
+				StartedAt:  c.StartedAt,
+				FinishedAt: c.FinishedAt,
+			}
+			return true
+		}
+	}
+	return false
+}
+
+// ReasoningDiscarded 报告消息的推理内容是否已被删除
+func (m *Message) ReasoningDiscarded() bool {
+	reasoning := m.ReasoningContent()
+	return reasoning.StartedAt != 0 && reasoning.Thinking == "" && m.IsFinished()
+}
+
 // ThinkingDuration 返回推理持续时间
 // 如果推理未开始，返回 0；如果推理未结束，使用当前时间计算
 func (m *Message) ThinkingDuration() time.Duration {
@@ -542,6 +588,18 @@ func (m *Message) AddFinish(reason FinishReason, message, details string) {
 	m.Parts = append(m.Parts, Finish{Reason: reason, Time: time.Now().Unix(), Message: message, Details: details})
 }
 
+// SetResponseMetrics 在消息的结束部分记录流式响应指标
+// 消息还没有结束部分时不做任何操作
+func (m *Message) SetResponseMetrics(metrics ResponseMetrics) {
+	for i, part := range m.Parts {
+		if finish, ok := part.(Finish); ok {
+			finish.Metrics = &metrics
+			m.Parts[i] = finish
+			return
+		}
+	}
+}
+
 // AddImageURL 添加图片 URL 到消息
 func (m *Message) AddImageURL(url, detail string) {
 	m.Parts = append(m.Parts, ImageURLContent{URL: url, Detail: detail})
@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"strings"
 	"testing"
+
+	"github.com/stretchr/testify/require"
 )
 
 // makeTestAttachments 创建指定数量的测试附件
@@ -58,3 +60,29 @@ func BenchmarkPromptWithTextAttachments(b *testing.B) {
 		})
 	}
 }
+
+func TestDiscardReasoning(t *testing.T) {
+	t.Parallel()
+
+	msg := Message{Role: Assistant}
+	require.False(t, msg.DiscardReasoning())
+
+	msg.AppendReasoningContent("let me think")
+	msg.AppendReasoningSignature("sig")
+	msg.FinishThinking()
+	msg.AppendContent("answer")
+	msg.AddFinish(FinishReasonEndTurn, "", "")
+	started := msg.ReasoningContent().StartedAt
+	require.False(t, msg.ReasoningDiscarded())
+
+	require.True(t, msg.DiscardReasoning())
+	reasoning := msg.ReasoningContent()
+	require.Empty(t, reasoning.Thinking)
+	require.Empty(t, reasoning.Signature)
+	require.Equal(t, started, reasoning.StartedAt)
+	require.NotZero(t, reasoning.FinishedAt)
+	require.True(t, msg.ReasoningDiscarded())
+	require.Equal(t, "answer", msg.Content().Text)
+
+	require.False(t, msg.DiscardReasoning())
+}
@@ -0,0 +1,136 @@
+// Package network 统一构建出站 HTTP 请求使用的传输层，支持代理和自定义 CA 证书。
+//
+// Setup 会把配置好的传输层设置为 [http.DefaultTransport]，因此未指定传输层的客户端，
+// 包括第三方 SDK 创建的客户端，都会使用相同的代理和证书设置。
+// 模型提供者通过 ForProvider 获取共享的传输层，以复用连接并缓存 DNS 解析结果。
+package network
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+
+	"golang.org/x/net/http/httpproxy"
+)
+
+// Options 是出站 HTTP 请求的网络设置
+type Options struct {
+	// Proxy 是所有请求使用的代理地址，例如 http://proxy:8080 或 socks5://127.0.0.1:1080。
+	// 为空时使用 HTTP_PROXY、HTTPS_PROXY 和 NO_PROXY 环境变量
+	Proxy string
+	// CABundle 是 PEM 格式的 CA 证书文件，证书会添加到系统证书池中
+	CABundle string
+	// InsecureSkipVerify 跳过 TLS 证书校验，仅用于排查问题
+	InsecureSkipVerify bool
+}
+
+var (
+	// base 是程序启动时的默认传输层，每次 Setup 都基于它构建，避免设置叠加
+	base = http.DefaultTransport.(*http.Transport)
+
+	mu      sync.Mutex
+	current = base
+)
+
+// Setup 按 opts 构建传输层并设置为 [http.DefaultTransport]。可以多次调用，后一次的设置生效
+func Setup(opts Options) error {
+	transport, err := NewTransport(opts)
+	if err != nil {
+		return err
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	current = transport
+	http.DefaultTransport = transport
+	generation.Add(1)
+	return nil
+}
+
+// NewTransport 按 opts 构建传输层，不影响 [http.DefaultTransport]
+func NewTransport(opts Options) (*http.Transport, error) {
+	transport := base.Clone()
+	if opts.Proxy != "" {
+		// httpproxy 会忽略无法解析的代理地址，这里提前校验以便在启动时报告错误
+		if u, err := url.Parse(opts.Proxy); err != nil || u.Scheme == "" || u.Host == "" {
+			return nil, fmt.Errorf("代理地址 %q 无效，需要包含协议和主机，例如 http://proxy:8080", opts.Proxy)
+		}
+		proxy := (&httpproxy.Config{
+			HTTPProxy:  opts.Proxy,
+			HTTPSProxy: opts.Proxy,
+			NoProxy:    noProxyFromEnvironment(),
+		}).ProxyFunc()
+		transport.Proxy = func(req *http.Request) (*url.URL, error) {
+			return proxy(req.URL)
+		}
+	}
+
+	if opts.CABundle != "" || opts.InsecureSkipVerify {
+		tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+		if transport.TLSClientConfig != nil {
+			tlsConfig = transport.TLSClientConfig.Clone()
+		}
+		if opts.CABundle != "" {
+			pool, err := loadCABundle(opts.CABundle)
+			if err != nil {
+				return nil, err
+			}
+			tlsConfig.RootCAs = pool
+		}
+		if opts.InsecureSkipVerify {
+			slog.Warn("已禁用 TLS 证书校验，出站请求可能被中间人攻击")
+			tlsConfig.InsecureSkipVerify = true //nolint:gosec
+		}
+		transport.TLSClientConfig = tlsConfig
+	}
+	return transport, nil
+}
+
+// Transport 返回当前网络设置的传输层副本，调用方可以修改它的连接池等设置
+func Transport() *http.Transport {
+	mu.Lock()
+	defer mu.Unlock()
+	return current.Clone()
+}
+
+// NewClient 返回使用当前网络设置的 HTTP 客户端，适合频繁请求多个主机的抓取类工具
+func NewClient(timeout time.Duration) *http.Client {
+	transport := Transport()
+	transport.MaxIdleConns = 100
+	transport.MaxIdleConnsPerHost = 10
+	transport.IdleConnTimeout = 90 * time.Second
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+	}
+}
+
+// loadCABundle 读取 PEM 格式的 CA 证书并添加到系统证书池中
+func loadCABundle(path string) (*x509.CertPool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取 CA 证书文件失败: %w", err)
+	}
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		slog.Warn("加载系统证书池失败，仅使用配置的 CA 证书", "error", err)
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("CA 证书文件 %s 中没有有效的 PEM 证书", path)
+	}
+	return pool, nil
+}
+
+// noProxyFromEnvironment 返回 NO_PROXY 环境变量，配置的代理仍然遵循它
+func noProxyFromEnvironment() string {
+	if v := os.Getenv("NO_PROXY"); v != "" {
+		return v
+	}
+	return os.Getenv("no_proxy")
+}
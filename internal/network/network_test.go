@@ -0,0 +1,91 @@
+package network
+
+import (
+	"encoding/pem"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func get(t *testing.T, transport *http.Transport, url string) string {
+	t.Helper()
+	resp, err := (&http.Client{Transport: transport}).Get(url)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return string(body)
+}
+
+func TestNewTransport(t *testing.T) {
+	t.Parallel()
+
+	tlsServer := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, "ok")
+	}))
+	t.Cleanup(tlsServer.Close)
+
+	t.Run("default rejects unknown CA", func(t *testing.T) {
+		t.Parallel()
+		transport, err := NewTransport(Options{})
+		require.NoError(t, err)
+		_, err = (&http.Client{Transport: transport}).Get(tlsServer.URL)
+		require.Error(t, err)
+	})
+
+	t.Run("ca bundle", func(t *testing.T) {
+		t.Parallel()
+		path := filepath.Join(t.TempDir(), "ca.pem")
+		cert := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: tlsServer.Certificate().Raw})
+		require.NoError(t, os.WriteFile(path, cert, 0o600))
+
+		transport, err := NewTransport(Options{CABundle: path})
+		require.NoError(t, err)
+		require.Equal(t, "ok", get(t, transport, tlsServer.URL))
+	})
+
+	t.Run("invalid ca bundle", func(t *testing.T) {
+		t.Parallel()
+		path := filepath.Join(t.TempDir(), "ca.pem")
+		require.NoError(t, os.WriteFile(path, []byte("not a certificate"), 0o600))
+
+		_, err := NewTransport(Options{CABundle: path})
+		require.ErrorContains(t, err, "没有有效的 PEM 证书")
+
+		_, err = NewTransport(Options{CABundle: filepath.Join(t.TempDir(), "missing.pem")})
+		require.ErrorContains(t, err, "读取 CA 证书文件失败")
+	})
+
+	t.Run("insecure skip verify", func(t *testing.T) {
+		t.Parallel()
+		transport, err := NewTransport(Options{InsecureSkipVerify: true})
+		require.NoError(t, err)
+		require.Equal(t, "ok", get(t, transport, tlsServer.URL))
+	})
+
+	t.Run("proxy", func(t *testing.T) {
+		t.Parallel()
+		proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// 代理收到的是完整的目标地址
+			_, _ = io.WriteString(w, "proxied "+r.URL.String())
+		}))
+		t.Cleanup(proxy.Close)
+
+		transport, err := NewTransport(Options{Proxy: proxy.URL})
+		require.NoError(t, err)
+		require.Equal(t, "proxied http://example.invalid/path", get(t, transport, "http://example.invalid/path"))
+	})
+
+	t.Run("invalid proxy", func(t *testing.T) {
+		t.Parallel()
+		_, err := NewTransport(Options{Proxy: "http://[::1"})
+		require.ErrorContains(t, err, "代理地址")
+		_, err = NewTransport(Options{Proxy: "proxy.example.com"})
+		require.ErrorContains(t, err, "代理地址")
+	})
+}
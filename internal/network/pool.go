@@ -0,0 +1,290 @@
+package network
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// 提供者传输层的连接池设置。模型请求集中在少数几个主机上，并且常常间隔数十秒，
+// 因此每个主机保留更多空闲连接，空闲超时也比默认值更长，以减少重新握手
+const (
+	providerMaxIdleConns        = 100
+	providerMaxIdleConnsPerHost = 16
+	providerIdleConnTimeout     = 5 * time.Minute
+	providerTLSHandshakeTimeout = 10 * time.Second
+	providerDialTimeout         = 30 * time.Second
+	providerKeepAlive           = 30 * time.Second
+
+	// dnsCacheTTL 是解析结果的缓存时长
+	dnsCacheTTL = 5 * time.Minute
+)
+
+// ConnStats 是一个提供者传输层的连接复用统计
+type ConnStats struct {
+	Provider string
+	// Requests 是发出的请求数
+	Requests int64
+	// ReusedConns 是复用已有连接（包括 HTTP/2 多路复用）的请求数
+	ReusedConns int64
+	// NewConns 是新建连接的请求数
+	NewConns int64
+	// HTTP2 是使用 HTTP/2 的响应数
+	HTTP2 int64
+	// TLSHandshakes 是完成的 TLS 握手次数，TLSHandshakeTime 是握手的累计耗时
+	TLSHandshakes    int64
+	TLSHandshakeTime time.Duration
+	// DNSHits 和 DNSMisses 是拨号时 DNS 缓存的命中和未命中次数
+	DNSHits   int64
+	DNSMisses int64
+	// LastRequest 是最近一次请求的时间
+	LastRequest time.Time
+}
+
+// ReuseRate 返回复用连接的请求占比，没有请求时返回 0
+func (s ConnStats) ReuseRate() float64 {
+	total := s.ReusedConns + s.NewConns
+	if total == 0 {
+		return 0
+	}
+	return float64(s.ReusedConns) / float64(total)
+}
+
+// AvgTLSHandshake 返回 TLS 握手的平均耗时
+func (s ConnStats) AvgTLSHandshake() time.Duration {
+	if s.TLSHandshakes == 0 {
+		return 0
+	}
+	return s.TLSHandshakeTime / time.Duration(s.TLSHandshakes)
+}
+
+// ProviderTransport 是提供者共享的传输层。它启用 HTTP/2、保留较大的空闲连接池并缓存 DNS 解析结果，
+// 同一提供者的编码代理、子代理和重建后的模型都复用同一个连接池
+type ProviderTransport struct {
+	provider   string
+	transport  *http.Transport
+	generation uint64
+
+	requests, reused, newConns, http2 atomic.Int64
+	tlsHandshakes, tlsNanos           atomic.Int64
+	dnsHits, dnsMisses                atomic.Int64
+	lastRequest                       atomic.Int64
+}
+
+var (
+	providerMu         sync.Mutex
+	providerTransports = map[string]*ProviderTransport{}
+	// generation 在每次 Setup 后递增，使提供者传输层按新的代理和证书设置重建
+	generation atomic.Uint64
+
+	sharedDNS = newDNSCache(dnsCacheTTL)
+)
+
+// ForProvider 返回提供者共享的传输层。网络设置变化后会重建传输层，统计保持累计
+func ForProvider(provider string) *ProviderTransport {
+	providerMu.Lock()
+	defer providerMu.Unlock()
+	gen := generation.Load()
+	old, ok := providerTransports[provider]
+	if ok && old.generation == gen {
+		return old
+	}
+
+	t := newProviderTransport(provider, gen)
+	if ok {
+		old.transport.CloseIdleConnections()
+		t.copyStats(old)
+	}
+	providerTransports[provider] = t
+	return t
+}
+
+// ProviderStats 返回所有提供者传输层的连接统计，按提供者排序
+func ProviderStats() []ConnStats {
+	providerMu.Lock()
+	defer providerMu.Unlock()
+	stats := make([]ConnStats, 0, len(providerTransports))
+	for _, t := range providerTransports {
+		stats = append(stats, t.Stats())
+	}
+	slices.SortFunc(stats, func(a, b ConnStats) int {
+		return strings.Compare(a.Provider, b.Provider)
+	})
+	return stats
+}
+
+// newProviderTransport 基于当前网络设置构建调优后的传输层
+func newProviderTransport(provider string, gen uint64) *ProviderTransport {
+	t := &ProviderTransport{provider: provider, generation: gen}
+	transport := Transport()
+	transport.ForceAttemptHTTP2 = true
+	transport.MaxIdleConns = providerMaxIdleConns
+	transport.MaxIdleConnsPerHost = providerMaxIdleConnsPerHost
+	transport.IdleConnTimeout = providerIdleConnTimeout
+	transport.TLSHandshakeTimeout = providerTLSHandshakeTimeout
+	transport.DialContext = t.dialContext
+	t.transport = transport
+	return t
+}
+
+// dialContext 使用缓存的 DNS 解析结果拨号，缓存的地址全部不可用时丢弃缓存
+func (t *ProviderTransport) dialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	dialer := &net.Dialer{Timeout: providerDialTimeout, KeepAlive: providerKeepAlive}
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil || net.ParseIP(host) != nil {
+		return dialer.DialContext(ctx, network, addr)
+	}
+
+	ips, hit, err := sharedDNS.lookup(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	if hit {
+		t.dnsHits.Add(1)
+	} else {
+		t.dnsMisses.Add(1)
+	}
+
+	var firstErr error
+	for _, ip := range ips {
+		conn, err := dialer.DialContext(ctx, network, net.JoinHostPort(ip, port))
+		if err == nil {
+			return conn, nil
+		}
+		if firstErr == nil {
+			firstErr = err
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	sharedDNS.forget(host)
+	return nil, firstErr
+}
+
+// RoundTrip 实现 [http.RoundTripper]，通过 httptrace 记录连接是否被复用
+func (t *ProviderTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.requests.Add(1)
+	t.lastRequest.Store(time.Now().UnixNano())
+
+	var tlsStart time.Time
+	trace := &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			if info.Reused {
+				t.reused.Add(1)
+			} else {
+				t.newConns.Add(1)
+			}
+		},
+		TLSHandshakeStart: func() {
+			tlsStart = time.Now()
+		},
+		TLSHandshakeDone: func(_ tls.ConnectionState, err error) {
+			if err == nil && !tlsStart.IsZero() {
+				t.tlsHandshakes.Add(1)
+				t.tlsNanos.Add(int64(time.Since(tlsStart)))
+			}
+		},
+	}
+	req = req.WithContext(httptrace.WithClientTrace(req.Context(), trace))
+
+	resp, err := t.transport.RoundTrip(req)
+	if err == nil && resp.ProtoMajor == 2 {
+		t.http2.Add(1)
+	}
+	return resp, err
+}
+
+// CloseIdleConnections 关闭空闲连接，http.Client.CloseIdleConnections 会调用它
+func (t *ProviderTransport) CloseIdleConnections() {
+	t.transport.CloseIdleConnections()
+}
+
+// Stats 返回传输层的连接统计
+func (t *ProviderTransport) Stats() ConnStats {
+	stats := ConnStats{
+		Provider:         t.provider,
+		Requests:         t.requests.Load(),
+		ReusedConns:      t.reused.Load(),
+		NewConns:         t.newConns.Load(),
+		HTTP2:            t.http2.Load(),
+		TLSHandshakes:    t.tlsHandshakes.Load(),
+		TLSHandshakeTime: time.Duration(t.tlsNanos.Load()),
+		DNSHits:          t.dnsHits.Load(),
+		DNSMisses:        t.dnsMisses.Load(),
+	}
+	if last := t.lastRequest.Load(); last != 0 {
+		stats.LastRequest = time.Unix(0, last)
+	}
+	return stats
+}
+
+// copyStats 从重建前的传输层继承累计统计
+func (t *ProviderTransport) copyStats(old *ProviderTransport) {
+	t.requests.Store(old.requests.Load())
+	t.reused.Store(old.reused.Load())
+	t.newConns.Store(old.newConns.Load())
+	t.http2.Store(old.http2.Load())
+	t.tlsHandshakes.Store(old.tlsHandshakes.Load())
+	t.tlsNanos.Store(old.tlsNanos.Load())
+	t.dnsHits.Store(old.dnsHits.Load())
+	t.dnsMisses.Store(old.dnsMisses.Load())
+	t.lastRequest.Store(old.lastRequest.Load())
+}
+
+// dnsCache 缓存主机名的解析结果，避免每次新建连接都重新解析
+type dnsCache struct {
+	ttl     time.Duration
+	resolve func(ctx context.Context, host string) ([]string, error)
+	now     func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]dnsEntry
+}
+
+type dnsEntry struct {
+	addrs   []string
+	expires time.Time
+}
+
+func newDNSCache(ttl time.Duration) *dnsCache {
+	return &dnsCache{
+		ttl:     ttl,
+		resolve: net.DefaultResolver.LookupHost,
+		now:     time.Now,
+		entries: map[string]dnsEntry{},
+	}
+}
+
+// lookup 返回主机的地址以及是否命中缓存
+func (c *dnsCache) lookup(ctx context.Context, host string) ([]string, bool, error) {
+	c.mu.Lock()
+	entry, ok := c.entries[host]
+	c.mu.Unlock()
+	if ok && c.now().Before(entry.expires) {
+		return entry.addrs, true, nil
+	}
+
+	addrs, err := c.resolve(ctx, host)
+	if err != nil {
+		return nil, false, err
+	}
+	c.mu.Lock()
+	c.entries[host] = dnsEntry{addrs: addrs, expires: c.now().Add(c.ttl)}
+	c.mu.Unlock()
+	return addrs, false, nil
+}
+
+// forget 丢弃主机的缓存结果
+func (c *dnsCache) forget(host string) {
+	c.mu.Lock()
+	delete(c.entries, host)
+	c.mu.Unlock()
+}
@@ -0,0 +1,122 @@
+package network
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestProviderTransport_ReusesConnections(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, "ok")
+	}))
+	t.Cleanup(server.Close)
+
+	transport := newProviderTransport("test-reuse", 0)
+
+	client := &http.Client{Transport: transport}
+	for range 3 {
+		resp, err := client.Get(server.URL)
+		require.NoError(t, err)
+		_, _ = io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+	}
+
+	stats := transport.Stats()
+	require.Equal(t, "test-reuse", stats.Provider)
+	require.EqualValues(t, 3, stats.Requests)
+	require.EqualValues(t, 1, stats.NewConns)
+	require.EqualValues(t, 2, stats.ReusedConns)
+	require.InDelta(t, 2.0/3.0, stats.ReuseRate(), 0.001)
+	require.False(t, stats.LastRequest.IsZero())
+}
+
+func TestForProvider(t *testing.T) {
+	t.Parallel()
+
+	transport := ForProvider("test-shared")
+	require.Same(t, transport, ForProvider("test-shared"))
+	require.NotSame(t, transport, ForProvider("test-other"))
+
+	var found bool
+	for _, s := range ProviderStats() {
+		found = found || s.Provider == "test-shared"
+	}
+	require.True(t, found)
+}
+
+func TestProviderTransport_CachesDNS(t *testing.T) {
+	t.Parallel()
+
+	// 每个请求后关闭连接，使第二个请求重新拨号
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Connection", "close")
+		_, _ = io.WriteString(w, "ok")
+	}))
+	t.Cleanup(server.Close)
+	url := strings.Replace(server.URL, "127.0.0.1", "localhost", 1)
+
+	transport := newProviderTransport("test-dns", 0)
+	client := &http.Client{Transport: transport}
+	for range 2 {
+		resp, err := client.Get(url)
+		require.NoError(t, err)
+		resp.Body.Close()
+	}
+
+	stats := transport.Stats()
+	require.EqualValues(t, 2, stats.NewConns)
+	require.EqualValues(t, 2, stats.DNSHits+stats.DNSMisses)
+	require.GreaterOrEqual(t, stats.DNSHits, int64(1))
+}
+
+func TestDNSCache(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	var calls int
+	cache := newDNSCache(time.Minute)
+	cache.now = func() time.Time { return now }
+	cache.resolve = func(_ context.Context, host string) ([]string, error) {
+		calls++
+		if host == "bad.example" {
+			return nil, errors.New("no such host")
+		}
+		return []string{"10.0.0.1"}, nil
+	}
+
+	addrs, hit, err := cache.lookup(t.Context(), "api.example")
+	require.NoError(t, err)
+	require.False(t, hit)
+	require.Equal(t, []string{"10.0.0.1"}, addrs)
+
+	_, hit, err = cache.lookup(t.Context(), "api.example")
+	require.NoError(t, err)
+	require.True(t, hit)
+	require.Equal(t, 1, calls)
+
+	now = now.Add(2 * time.Minute)
+	_, hit, err = cache.lookup(t.Context(), "api.example")
+	require.NoError(t, err)
+	require.False(t, hit)
+	require.Equal(t, 2, calls)
+
+	cache.forget("api.example")
+	_, hit, _ = cache.lookup(t.Context(), "api.example")
+	require.False(t, hit)
+
+	_, _, err = cache.lookup(t.Context(), "bad.example")
+	require.Error(t, err)
+	_, _, err = cache.lookup(t.Context(), "bad.example")
+	require.Error(t, err)
+	require.Equal(t, 5, calls)
+}
@@ -24,6 +24,7 @@ var assistantRolePattern = regexp.MustCompile(`"role"\s*:\s*"assistant"`)
 // 参数：
 //   - isSubAgent: 布尔值，指示当前是否为子代理模式
 //   - debug: 布尔值，指示是否启用调试模式
+//   - base: 实际执行请求的传输层，为 nil 时使用 http.DefaultTransport
 //
 // 返回：
 //   - *http.Client: 配置了自定义传输层的 HTTP 客户端
@@ -32,17 +33,21 @@ var assistantRolePattern = regexp.MustCompile(`"role"\s*:\s*"assistant"`)
 //   - 自定义传输层会检查请求体中是否包含助手消息
 //   - 根据消息历史自动设置 X-Initiator 头部为 "user" 或 "agent"
 //   - 在子代理模式下，始终将 X-Initiator 设置为 "agent"
-func NewClient(isSubAgent, debug bool) *http.Client {
+func NewClient(isSubAgent, debug bool, base http.RoundTripper) *http.Client {
+	if base == nil {
+		base = http.DefaultTransport
+	}
 	return &http.Client{
-		Transport: &initiatorTransport{debug: debug, isSubAgent: isSubAgent},
+		Transport: &initiatorTransport{debug: debug, isSubAgent: isSubAgent, base: base},
 	}
 }
 
 // initiatorTransport 自定义 HTTP 传输层结构体
 // 实现了 http.RoundTripper 接口，用于拦截和修改 HTTP 请求
 type initiatorTransport struct {
-	debug      bool // 是否启用调试模式
-	isSubAgent bool // 是否为子代理模式
+	debug      bool              // 是否启用调试模式
+	isSubAgent bool              // 是否为子代理模式
+	base       http.RoundTripper // 实际执行请求的传输层
 }
 
 // RoundTrip 实现 http.RoundTripper 接口，处理 HTTP 请求的往返过程
@@ -123,8 +128,8 @@ func (t *initiatorTransport) RoundTrip(req *http.Request) (*http.Response, error
 func (t *initiatorTransport) roundTrip(req *http.Request) (*http.Response, error) {
 	if t.debug {
 		// 调试模式：使用自定义 HTTP 客户端的传输层（可能包含日志记录等功能）
-		return log.NewHTTPClient().Transport.RoundTrip(req)
+		return log.NewHTTPClient(t.base).Transport.RoundTrip(req)
 	}
-	// 正常模式：使用默认传输层
-	return http.DefaultTransport.RoundTrip(req)
+	// 正常模式：直接使用底层传输层
+	return t.base.RoundTrip(req)
 }
@@ -0,0 +1,157 @@
+// Package mcpauth 实现 HTTP MCP 服务器的 OAuth 授权
+// 该包负责发现授权服务器元数据、动态注册客户端，通过设备码流程或浏览器（授权码 + PKCE）流程
+// 获取令牌，并在令牌过期时刷新令牌
+package mcpauth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// ServerMetadata 表示授权服务器元数据（RFC 8414）中用到的字段
+type ServerMetadata struct {
+	// Issuer 是授权服务器的标识符
+	Issuer string `json:"issuer"`
+	// AuthorizationEndpoint 是浏览器流程的授权端点
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	// TokenEndpoint 是令牌端点
+	TokenEndpoint string `json:"token_endpoint"`
+	// DeviceAuthorizationEndpoint 是设备码端点，授权服务器不支持设备码流程时为空
+	DeviceAuthorizationEndpoint string `json:"device_authorization_endpoint,omitempty"`
+	// RegistrationEndpoint 是动态客户端注册端点，不支持动态注册时为空
+	RegistrationEndpoint string `json:"registration_endpoint,omitempty"`
+	// ScopesSupported 是授权服务器支持的权限范围
+	ScopesSupported []string `json:"scopes_supported,omitempty"`
+}
+
+// resourceMetadata 表示受保护资源元数据（RFC 9728）中用到的字段
+type resourceMetadata struct {
+	AuthorizationServers []string `json:"authorization_servers"`
+	ScopesSupported      []string `json:"scopes_supported"`
+}
+
+// resourceMetadataRe 匹配 WWW-Authenticate 头中的 resource_metadata 参数
+var resourceMetadataRe = regexp.MustCompile(`resource_metadata="?([^",\s]+)"?`)
+
+// ResourceMetadataURL 从 401 响应的 WWW-Authenticate 头中提取受保护资源元数据的地址
+// 响应中没有该参数时返回空字符串
+func ResourceMetadataURL(header http.Header) string {
+	for _, value := range header.Values("WWW-Authenticate") {
+		if match := resourceMetadataRe.FindStringSubmatch(value); match != nil {
+			return match[1]
+		}
+	}
+	return ""
+}
+
+// Discover 发现 MCP 服务器使用的授权服务器及其元数据
+// 优先使用 401 响应中给出的受保护资源元数据地址，为空时尝试服务器的 well-known 地址
+// 服务器不提供受保护资源元数据时，把服务器本身视为授权服务器；授权服务器也不提供元数据时，
+// 使用 MCP 规范中约定的默认端点
+//
+// 参数:
+//   - ctx: 上下文，用于控制请求超时和取消
+//   - client: 发送请求使用的 HTTP 客户端
+//   - serverURL: MCP 服务器地址
+//   - resourceMetadataURL: 受保护资源元数据地址，可以为空
+//
+// 返回值:
+//   - *ServerMetadata: 授权服务器元数据
+//   - error: 错误信息，如果服务器地址无效则返回错误
+func Discover(ctx context.Context, client *http.Client, serverURL, resourceMetadataURL string) (*ServerMetadata, error) {
+	server, err := url.Parse(serverURL)
+	if err != nil || server.Host == "" {
+		return nil, fmt.Errorf("无效的 MCP 服务器地址: %s", serverURL)
+	}
+
+	// 查找受保护资源元数据，确定授权服务器
+	candidates := []string{resourceMetadataURL}
+	if resourceMetadataURL == "" {
+		candidates = wellKnownURLs(server, "oauth-protected-resource")
+	}
+	issuer := origin(server)
+	var scopes []string
+	for _, candidate := range candidates {
+		var prm resourceMetadata
+		if err := getJSON(ctx, client, candidate, &prm); err != nil || len(prm.AuthorizationServers) == 0 {
+			continue
+		}
+		issuer = prm.AuthorizationServers[0]
+		scopes = prm.ScopesSupported
+		break
+	}
+
+	issuerURL, err := url.Parse(issuer)
+	if err != nil || issuerURL.Host == "" {
+		return nil, fmt.Errorf("无效的授权服务器地址: %s", issuer)
+	}
+
+	// 查找授权服务器元数据，同时兼容 OpenID Connect 发现地址
+	candidates = append(
+		wellKnownURLs(issuerURL, "oauth-authorization-server"),
+		wellKnownURLs(issuerURL, "openid-configuration")...,
+	)
+	if path := strings.TrimSuffix(issuerURL.Path, "/"); path != "" {
+		candidates = append(candidates, origin(issuerURL)+path+"/.well-known/openid-configuration")
+	}
+	for _, candidate := range candidates {
+		var meta ServerMetadata
+		if err := getJSON(ctx, client, candidate, &meta); err != nil || meta.TokenEndpoint == "" {
+			continue
+		}
+		if len(meta.ScopesSupported) == 0 {
+			meta.ScopesSupported = scopes
+		}
+		return &meta, nil
+	}
+
+	// 授权服务器没有提供元数据，使用默认端点
+	base := origin(issuerURL)
+	return &ServerMetadata{
+		Issuer:                base,
+		AuthorizationEndpoint: base + "/authorize",
+		TokenEndpoint:         base + "/token",
+		RegistrationEndpoint:  base + "/register",
+		ScopesSupported:       scopes,
+	}, nil
+}
+
+// wellKnownURLs 返回 u 的 well-known 元数据地址，带路径的地址优先
+func wellKnownURLs(u *url.URL, name string) []string {
+	base := origin(u) + "/.well-known/" + name
+	if path := strings.TrimSuffix(u.Path, "/"); path != "" {
+		return []string{base + path, base}
+	}
+	return []string{base}
+}
+
+// origin 返回 u 的协议和主机部分
+func origin(u *url.URL) string {
+	return u.Scheme + "://" + u.Host
+}
+
+// getJSON 发送 GET 请求并将 JSON 响应解析到 v 中
+func getJSON(ctx context.Context, client *http.Client, rawURL string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("请求 %s 失败: %s", rawURL, resp.Status)
+	}
+	return json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(v)
+}
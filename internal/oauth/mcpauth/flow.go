@@ -0,0 +1,447 @@
+package mcpauth
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/purpose168/crush-cn/internal/oauth"
+)
+
+const (
+	// clientName 是动态注册客户端时使用的名称
+	clientName = "Crush"
+	// callbackPath 是浏览器流程中本地回调的路径
+	callbackPath = "/callback"
+	// browserFlowExpiresIn 是浏览器流程等待用户授权的时长（秒）
+	browserFlowExpiresIn = 300
+	// deviceCodeExpiresIn 是授权服务器没有给出有效期时设备码的默认有效期（秒）
+	deviceCodeExpiresIn = 900
+	// deviceCodeGrantType 是设备码流程的授权类型
+	deviceCodeGrantType = "urn:ietf:params:oauth:grant-type:device_code"
+)
+
+// TokenError 表示令牌端点返回的 OAuth 错误
+type TokenError struct {
+	Code        string `json:"error"`
+	Description string `json:"error_description"`
+}
+
+// Error 实现 error 接口
+func (e *TokenError) Error() string {
+	if e.Description != "" {
+		return fmt.Sprintf("授权失败: %s (%s)", e.Code, e.Description)
+	}
+	return fmt.Sprintf("授权失败: %s", e.Code)
+}
+
+// Authorization 表示一次进行中的授权
+// 设备码流程中 UserCode 非空，用户需要在 VerificationURL 中输入它；
+// 浏览器流程中 UserCode 为空，VerificationURL 是授权页面地址，用户授权后浏览器会重定向回本地回调
+type Authorization struct {
+	// UserCode 是设备码流程中用户需要输入的验证码
+	UserCode string
+	// VerificationURL 是用户需要访问的页面地址
+	VerificationURL string
+	// ExpiresIn 表示授权的有效期（秒）
+	ExpiresIn int
+	// Interval 表示设备码流程中轮询的间隔时间（秒）
+	Interval int
+
+	wait  func(ctx context.Context) (*oauth.Token, error)
+	close func()
+}
+
+// Wait 等待用户完成授权并返回令牌，超过有效期时返回错误
+func (a *Authorization) Wait(ctx context.Context) (*oauth.Token, error) {
+	defer a.Close()
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(a.ExpiresIn)*time.Second)
+	defer cancel()
+	token, err := a.wait(ctx)
+	if errors.Is(err, context.DeadlineExceeded) {
+		return nil, fmt.Errorf("授权超时")
+	}
+	return token, err
+}
+
+// Close 放弃授权并释放本地回调等资源
+func (a *Authorization) Close() {
+	if a.close != nil {
+		a.close()
+	}
+}
+
+// Authorize 开始授权
+// 授权服务器支持设备码流程时使用设备码流程，否则使用浏览器流程
+// creds 中没有客户端 ID 时会向授权服务器动态注册客户端，注册结果和令牌端点写回 creds，
+// 调用方应在授权成功后连同令牌一起保存
+//
+// 参数:
+//   - ctx: 上下文，用于控制发现和注册请求的超时和取消
+//   - client: 发送请求使用的 HTTP 客户端
+//   - resource: MCP 服务器地址，作为令牌的目标资源（RFC 8707）
+//   - meta: 授权服务器元数据
+//   - creds: 客户端凭据
+//   - scopes: 请求的权限范围，为空时使用授权服务器声明支持的范围
+//
+// 返回值:
+//   - *Authorization: 进行中的授权
+//   - error: 错误信息，如果无法开始授权则返回错误
+func Authorize(ctx context.Context, client *http.Client, resource string, meta *ServerMetadata, creds *Credentials, scopes []string) (*Authorization, error) {
+	if len(scopes) == 0 {
+		scopes = meta.ScopesSupported
+	}
+	creds.TokenEndpoint = meta.TokenEndpoint
+	if meta.DeviceAuthorizationEndpoint != "" {
+		return authorizeDevice(ctx, client, resource, meta, creds, scopes)
+	}
+	return authorizeBrowser(ctx, client, resource, meta, creds, scopes)
+}
+
+// authorizeDevice 使用设备码流程（RFC 8628）开始授权
+func authorizeDevice(ctx context.Context, client *http.Client, resource string, meta *ServerMetadata, creds *Credentials, scopes []string) (*Authorization, error) {
+	if err := ensureClient(ctx, client, meta, creds, nil); err != nil {
+		return nil, err
+	}
+
+	data := url.Values{}
+	data.Set("client_id", creds.ClientID)
+	setIfNotEmpty(data, "scope", strings.Join(scopes, " "))
+	setIfNotEmpty(data, "resource", resource)
+
+	var dc struct {
+		DeviceCode              string `json:"device_code"`
+		UserCode                string `json:"user_code"`
+		VerificationURI         string `json:"verification_uri"`
+		VerificationURIComplete string `json:"verification_uri_complete"`
+		ExpiresIn               int    `json:"expires_in"`
+		Interval                int    `json:"interval"`
+	}
+	if err := postForm(ctx, client, meta.DeviceAuthorizationEndpoint, data, &dc); err != nil {
+		return nil, fmt.Errorf("设备码请求失败: %w", err)
+	}
+
+	interval := max(dc.Interval, 5)
+	verificationURL := dc.VerificationURI
+	if dc.VerificationURIComplete != "" {
+		verificationURL = dc.VerificationURIComplete
+	}
+	return &Authorization{
+		UserCode:        dc.UserCode,
+		VerificationURL: verificationURL,
+		ExpiresIn:       cmp.Or(dc.ExpiresIn, deviceCodeExpiresIn),
+		Interval:        interval,
+		wait: func(ctx context.Context) (*oauth.Token, error) {
+			data := url.Values{}
+			data.Set("grant_type", deviceCodeGrantType)
+			data.Set("device_code", dc.DeviceCode)
+			setIfNotEmpty(data, "resource", resource)
+			return pollForToken(ctx, client, *creds, data, interval)
+		},
+	}, nil
+}
+
+// pollForToken 按间隔轮询令牌端点，直到用户完成授权或上下文结束
+func pollForToken(ctx context.Context, client *http.Client, creds Credentials, data url.Values, interval int) (*oauth.Token, error) {
+	ticker := time.NewTicker(time.Duration(interval) * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-ticker.C:
+		}
+
+		token, err := requestToken(ctx, client, creds, data)
+		var tokenErr *TokenError
+		if errors.As(err, &tokenErr) {
+			switch tokenErr.Code {
+			case "authorization_pending":
+				// 授权待定，继续轮询
+				continue
+			case "slow_down":
+				// 收到减速指令，增加轮询间隔 5 秒
+				interval += 5
+				ticker.Reset(time.Duration(interval) * time.Second)
+				continue
+			}
+		}
+		return token, err
+	}
+}
+
+// authorizeBrowser 使用授权码 + PKCE 流程开始授权，授权结果通过本地回调接收
+func authorizeBrowser(ctx context.Context, client *http.Client, resource string, meta *ServerMetadata, creds *Credentials, scopes []string) (*Authorization, error) {
+	if meta.AuthorizationEndpoint == "" {
+		return nil, fmt.Errorf("授权服务器既不支持设备码流程也没有授权端点")
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, fmt.Errorf("启动本地回调失败: %w", err)
+	}
+	redirectURI := fmt.Sprintf("http://%s%s", listener.Addr().String(), callbackPath)
+
+	if err := ensureClient(ctx, client, meta, creds, []string{redirectURI}); err != nil {
+		listener.Close()
+		return nil, err
+	}
+
+	verifier := randomString()
+	challenge := sha256.Sum256([]byte(verifier))
+	state := randomString()
+
+	authURL, err := url.Parse(meta.AuthorizationEndpoint)
+	if err != nil {
+		listener.Close()
+		return nil, fmt.Errorf("无效的授权端点: %w", err)
+	}
+	query := authURL.Query()
+	query.Set("response_type", "code")
+	query.Set("client_id", creds.ClientID)
+	query.Set("redirect_uri", redirectURI)
+	query.Set("code_challenge", base64.RawURLEncoding.EncodeToString(challenge[:]))
+	query.Set("code_challenge_method", "S256")
+	query.Set("state", state)
+	setIfNotEmpty(query, "scope", strings.Join(scopes, " "))
+	setIfNotEmpty(query, "resource", resource)
+	authURL.RawQuery = query.Encode()
+
+	type callbackResult struct {
+		code string
+		err  error
+	}
+	results := make(chan callbackResult, 1)
+	server := &http.Server{
+		ReadHeaderTimeout: 10 * time.Second,
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != callbackPath {
+				http.NotFound(w, r)
+				return
+			}
+			query := r.URL.Query()
+			var result callbackResult
+			switch {
+			case query.Get("state") != state:
+				result.err = fmt.Errorf("授权回调的 state 不匹配")
+			case query.Get("error") != "":
+				result.err = &TokenError{Code: query.Get("error"), Description: query.Get("error_description")}
+			case query.Get("code") == "":
+				result.err = fmt.Errorf("授权回调中缺少授权码")
+			default:
+				result.code = query.Get("code")
+			}
+			if result.err != nil {
+				http.Error(w, "授权失败，请返回 Crush 查看详情。", http.StatusBadRequest)
+			} else {
+				w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+				fmt.Fprintln(w, "授权完成，可以关闭此页面并返回 Crush。")
+			}
+			select {
+			case results <- result:
+			default:
+			}
+		}),
+	}
+	go server.Serve(listener) //nolint:errcheck
+
+	return &Authorization{
+		VerificationURL: authURL.String(),
+		ExpiresIn:       browserFlowExpiresIn,
+		wait: func(ctx context.Context) (*oauth.Token, error) {
+			var result callbackResult
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case result = <-results:
+			}
+			if result.err != nil {
+				return nil, result.err
+			}
+
+			data := url.Values{}
+			data.Set("grant_type", "authorization_code")
+			data.Set("code", result.code)
+			data.Set("redirect_uri", redirectURI)
+			data.Set("code_verifier", verifier)
+			setIfNotEmpty(data, "resource", resource)
+			return requestToken(ctx, client, *creds, data)
+		},
+		close: func() {
+			server.Close()
+		},
+	}, nil
+}
+
+// ensureClient 在 creds 中没有客户端 ID 时向授权服务器动态注册客户端（RFC 7591）
+func ensureClient(ctx context.Context, client *http.Client, meta *ServerMetadata, creds *Credentials, redirectURIs []string) error {
+	if creds.ClientID != "" {
+		return nil
+	}
+	if meta.RegistrationEndpoint == "" {
+		return fmt.Errorf("授权服务器不支持动态注册客户端，请在配置中设置 oauth.client_id")
+	}
+
+	grantTypes := []string{"authorization_code", "refresh_token"}
+	if len(redirectURIs) == 0 {
+		grantTypes = []string{deviceCodeGrantType, "refresh_token"}
+	}
+	body, err := json.Marshal(map[string]any{
+		"client_name":                clientName,
+		"redirect_uris":              redirectURIs,
+		"grant_types":                grantTypes,
+		"response_types":             []string{"code"},
+		"token_endpoint_auth_method": "none",
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, meta.RegistrationEndpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("注册客户端失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return fmt.Errorf("注册客户端失败: %s - %s", resp.Status, string(respBody))
+	}
+
+	var registration struct {
+		ClientID     string `json:"client_id"`
+		ClientSecret string `json:"client_secret"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&registration); err != nil {
+		return fmt.Errorf("解析客户端注册响应失败: %w", err)
+	}
+	if registration.ClientID == "" {
+		return fmt.Errorf("客户端注册响应中缺少 client_id")
+	}
+	creds.ClientID = registration.ClientID
+	creds.ClientSecret = registration.ClientSecret
+	return nil
+}
+
+// Refresh 使用刷新令牌获取新的访问令牌
+// 授权服务器没有返回新的刷新令牌时沿用原来的刷新令牌
+//
+// 参数:
+//   - ctx: 上下文，用于控制请求超时和取消
+//   - client: 发送请求使用的 HTTP 客户端
+//   - creds: 包含令牌端点和刷新令牌的凭据
+//   - resource: MCP 服务器地址，作为令牌的目标资源
+//
+// 返回值:
+//   - *oauth.Token: 新的令牌
+//   - error: 错误信息，如果刷新失败则返回错误
+func Refresh(ctx context.Context, client *http.Client, creds Credentials, resource string) (*oauth.Token, error) {
+	if creds.Token == nil || creds.Token.RefreshToken == "" {
+		return nil, fmt.Errorf("没有可用的刷新令牌")
+	}
+
+	data := url.Values{}
+	data.Set("grant_type", "refresh_token")
+	data.Set("refresh_token", creds.Token.RefreshToken)
+	setIfNotEmpty(data, "resource", resource)
+
+	token, err := requestToken(ctx, client, creds, data)
+	if err != nil {
+		return nil, err
+	}
+	if token.RefreshToken == "" {
+		token.RefreshToken = creds.Token.RefreshToken
+	}
+	return token, nil
+}
+
+// requestToken 向令牌端点请求令牌，并附带客户端身份
+func requestToken(ctx context.Context, client *http.Client, creds Credentials, data url.Values) (*oauth.Token, error) {
+	data.Set("client_id", creds.ClientID)
+	setIfNotEmpty(data, "client_secret", creds.ClientSecret)
+
+	var result struct {
+		AccessToken  string `json:"access_token"`
+		RefreshToken string `json:"refresh_token"`
+		ExpiresIn    int    `json:"expires_in"`
+	}
+	if err := postForm(ctx, client, creds.TokenEndpoint, data, &result); err != nil {
+		return nil, err
+	}
+	if result.AccessToken == "" {
+		return nil, fmt.Errorf("令牌响应中缺少 access_token")
+	}
+
+	token := &oauth.Token{
+		AccessToken:  result.AccessToken,
+		RefreshToken: result.RefreshToken,
+		ExpiresIn:    result.ExpiresIn,
+	}
+	if token.ExpiresIn > 0 {
+		token.SetExpiresAt()
+	}
+	return token, nil
+}
+
+// postForm 发送表单 POST 请求并将 JSON 响应解析到 v 中
+// 响应中包含 OAuth 错误时返回 [*TokenError]
+func postForm(ctx context.Context, client *http.Client, endpoint string, data url.Values, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(data.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+
+	var tokenErr TokenError
+	if json.Unmarshal(body, &tokenErr) == nil && tokenErr.Code != "" {
+		return &tokenErr
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s - %s", resp.Status, string(body))
+	}
+	return json.Unmarshal(body, v)
+}
+
+// setIfNotEmpty 仅在 value 非空时设置参数
+func setIfNotEmpty(values url.Values, key, value string) {
+	if value != "" {
+		values.Set(key, value)
+	}
+}
+
+// randomString 返回用于 state 和 PKCE 验证码的随机字符串，长度满足 PKCE 要求的 43 个字符以上
+func randomString() string {
+	return rand.Text() + rand.Text()
+}
@@ -0,0 +1,179 @@
+package mcpauth
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/purpose168/crush-cn/internal/oauth"
+	"github.com/stretchr/testify/require"
+)
+
+// newAuthServer 启动一个同时充当 MCP 服务器和授权服务器的测试服务器
+func newAuthServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	var challenge string
+	mux := http.NewServeMux()
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	writeJSON := func(w http.ResponseWriter, v any) {
+		w.Header().Set("Content-Type", "application/json")
+		require.NoError(t, json.NewEncoder(w).Encode(v))
+	}
+	mux.HandleFunc("/mcp", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("WWW-Authenticate", `Bearer resource_metadata="`+srv.URL+`/prm"`)
+		w.WriteHeader(http.StatusUnauthorized)
+	})
+	mux.HandleFunc("/prm", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]any{
+			"authorization_servers": []string{srv.URL + "/issuer"},
+			"scopes_supported":      []string{"mcp"},
+		})
+	})
+	mux.HandleFunc("/.well-known/oauth-authorization-server/issuer", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, ServerMetadata{
+			Issuer:                srv.URL + "/issuer",
+			AuthorizationEndpoint: srv.URL + "/authorize",
+			TokenEndpoint:         srv.URL + "/token",
+			RegistrationEndpoint:  srv.URL + "/register",
+		})
+	})
+	mux.HandleFunc("/register", func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			RedirectURIs []string `json:"redirect_uris"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		require.Len(t, req.RedirectURIs, 1)
+		w.WriteHeader(http.StatusCreated)
+		writeJSON(w, map[string]string{"client_id": "registered"})
+	})
+	mux.HandleFunc("/authorize", func(w http.ResponseWriter, r *http.Request) {
+		challenge = r.URL.Query().Get("code_challenge")
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		require.Equal(t, "registered", r.Form.Get("client_id"))
+		require.Equal(t, srv.URL+"/mcp", r.Form.Get("resource"))
+		switch r.Form.Get("grant_type") {
+		case "authorization_code":
+			sum := sha256.Sum256([]byte(r.Form.Get("code_verifier")))
+			if r.Form.Get("code") != "code" || base64.RawURLEncoding.EncodeToString(sum[:]) != challenge {
+				w.WriteHeader(http.StatusBadRequest)
+				writeJSON(w, map[string]string{"error": "invalid_grant"})
+				return
+			}
+			writeJSON(w, map[string]any{"access_token": "access", "refresh_token": "refresh", "expires_in": 3600})
+		case "refresh_token":
+			require.Equal(t, "refresh", r.Form.Get("refresh_token"))
+			writeJSON(w, map[string]any{"access_token": "refreshed", "expires_in": 3600})
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			writeJSON(w, map[string]string{"error": "unsupported_grant_type"})
+		}
+	})
+	return srv
+}
+
+func TestBrowserAuthorization(t *testing.T) {
+	t.Parallel()
+
+	srv := newAuthServer(t)
+	client := srv.Client()
+	resource := srv.URL + "/mcp"
+
+	resp, err := client.Get(resource)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	resourceMetadata := ResourceMetadataURL(resp.Header)
+	require.Equal(t, srv.URL+"/prm", resourceMetadata)
+
+	meta, err := Discover(t.Context(), client, resource, resourceMetadata)
+	require.NoError(t, err)
+	require.Equal(t, srv.URL+"/token", meta.TokenEndpoint)
+	require.Equal(t, []string{"mcp"}, meta.ScopesSupported)
+
+	var creds Credentials
+	auth, err := Authorize(t.Context(), client, resource, meta, &creds, nil)
+	require.NoError(t, err)
+	require.Empty(t, auth.UserCode)
+	require.Equal(t, "registered", creds.ClientID)
+	require.Equal(t, srv.URL+"/token", creds.TokenEndpoint)
+
+	// 模拟浏览器：打开授权页面，然后带着授权码重定向回本地回调。
+	authURL, err := url.Parse(auth.VerificationURL)
+	require.NoError(t, err)
+	require.Equal(t, "mcp", authURL.Query().Get("scope"))
+	resp, err = client.Get(auth.VerificationURL)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	callback, err := url.Parse(authURL.Query().Get("redirect_uri"))
+	require.NoError(t, err)
+	callback.RawQuery = url.Values{
+		"code":  {"code"},
+		"state": {authURL.Query().Get("state")},
+	}.Encode()
+	resp, err = http.Get(callback.String())
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	token, err := auth.Wait(t.Context())
+	require.NoError(t, err)
+	require.Equal(t, "access", token.AccessToken)
+	require.Equal(t, "refresh", token.RefreshToken)
+
+	creds.Token = token
+	refreshed, err := Refresh(t.Context(), client, creds, resource)
+	require.NoError(t, err)
+	require.Equal(t, "refreshed", refreshed.AccessToken)
+	require.Equal(t, "refresh", refreshed.RefreshToken, "refresh token is kept when not rotated")
+}
+
+func TestDiscoverFallback(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.NotFoundHandler())
+	t.Cleanup(srv.Close)
+
+	meta, err := Discover(t.Context(), srv.Client(), srv.URL+"/mcp", "")
+	require.NoError(t, err)
+	require.Equal(t, srv.URL+"/authorize", meta.AuthorizationEndpoint)
+	require.Equal(t, srv.URL+"/token", meta.TokenEndpoint)
+	require.Equal(t, srv.URL+"/register", meta.RegistrationEndpoint)
+}
+
+func TestStore(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "mcp-oauth.json")
+	store := NewStore(path)
+	_, ok := store.Load("https://example.com/mcp")
+	require.False(t, ok)
+
+	expired := &oauth.Token{
+		AccessToken:  "access",
+		RefreshToken: "refresh",
+		ExpiresIn:    3600,
+		ExpiresAt:    time.Now().Add(-time.Minute).Unix(),
+	}
+	creds := Credentials{ClientID: "client", TokenEndpoint: "https://example.com/token", Token: expired}
+	require.NoError(t, store.Save("https://example.com/mcp", creds))
+
+	loaded, ok := NewStore(path).Load("https://example.com/mcp")
+	require.True(t, ok)
+	require.Equal(t, creds, loaded)
+	require.True(t, loaded.NeedsRefresh())
+
+	loaded.Token.ExpiresIn = 0
+	require.False(t, loaded.NeedsRefresh(), "tokens without a lifetime never expire")
+}
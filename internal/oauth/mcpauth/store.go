@@ -0,0 +1,96 @@
+package mcpauth
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/purpose168/crush-cn/internal/oauth"
+)
+
+// Credentials 表示一个 MCP 服务器的 OAuth 凭据
+type Credentials struct {
+	// ClientID 是配置的或动态注册得到的客户端 ID
+	ClientID string `json:"client_id,omitempty"`
+	// ClientSecret 是机密客户端的密钥，公共客户端为空
+	ClientSecret string `json:"client_secret,omitempty"`
+	// TokenEndpoint 是颁发令牌的端点，刷新令牌时无需重新发现
+	TokenEndpoint string `json:"token_endpoint,omitempty"`
+	// Token 是当前的令牌
+	Token *oauth.Token `json:"token,omitempty"`
+}
+
+// NeedsRefresh 报告令牌是否已过期或即将过期且可以刷新
+// 没有给出有效期的令牌视为永不过期
+func (c Credentials) NeedsRefresh() bool {
+	return c.Token != nil &&
+		c.Token.RefreshToken != "" &&
+		c.TokenEndpoint != "" &&
+		c.Token.ExpiresIn > 0 &&
+		c.Token.IsExpired()
+}
+
+// Store 将各 MCP 服务器的凭据按服务器地址保存在一个 JSON 文件中
+// 文件内容在首次访问时读取并缓存在内存中
+type Store struct {
+	path string
+
+	mu          sync.Mutex
+	loaded      bool
+	credentials map[string]Credentials
+}
+
+// NewStore 创建使用 path 作为存储文件的凭据存储
+func NewStore(path string) *Store {
+	return &Store{path: path}
+}
+
+// Load 返回 serverURL 对应的凭据
+func (s *Store) Load(serverURL string) (Credentials, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.load()
+	creds, ok := s.credentials[serverURL]
+	return creds, ok
+}
+
+// Save 保存 serverURL 对应的凭据并写入磁盘
+func (s *Store) Save(serverURL string, creds Credentials) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.load()
+	s.credentials[serverURL] = creds
+
+	data, err := json.MarshalIndent(s.credentials, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o700); err != nil {
+		return fmt.Errorf("创建凭据目录失败: %w", err)
+	}
+	if err := os.WriteFile(s.path, data, 0o600); err != nil {
+		return fmt.Errorf("写入凭据文件失败: %w", err)
+	}
+	return nil
+}
+
+// load 在首次访问时从磁盘读取凭据，读取失败时从空白状态开始
+func (s *Store) load() {
+	if s.loaded {
+		return
+	}
+	s.loaded = true
+	s.credentials = make(map[string]Credentials)
+
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		return
+	}
+	if err := json.Unmarshal(data, &s.credentials); err != nil {
+		s.credentials = make(map[string]Credentials)
+	}
+}
@@ -0,0 +1,69 @@
+package outputstream
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+)
+
+// fifoSink 写入命名管道。没有读取方时打开会失败，输出被丢弃；每次写入前重新尝试打开，
+// 因此读取方可以随时连接和断开。
+type fifoSink struct {
+	path string
+
+	mu     sync.Mutex
+	file   *os.File
+	closed bool
+}
+
+func newFIFOSink(path string) (*fifoSink, error) {
+	info, err := os.Stat(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		if err := createFIFO(path); err != nil {
+			return nil, fmt.Errorf("创建命名管道 %s 失败: %w", path, err)
+		}
+	case err != nil:
+		return nil, err
+	case info.Mode()&os.ModeNamedPipe == 0:
+		return nil, fmt.Errorf("%s 不是命名管道", path)
+	}
+	return &fifoSink{path: path}, nil
+}
+
+func (f *fifoSink) Write(data []byte) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.closed {
+		return
+	}
+	if f.file == nil {
+		file, err := openFIFO(f.path)
+		if err != nil {
+			// 没有读取方
+			return
+		}
+		f.file = file
+	}
+	_ = f.file.SetWriteDeadline(time.Now().Add(writeTimeout))
+	if _, err := f.file.Write(data); err != nil {
+		slog.Debug("Output stream reader disconnected", "path", f.path, "error", err)
+		_ = f.file.Close()
+		f.file = nil
+	}
+}
+
+func (f *fifoSink) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.closed = true
+	if f.file == nil {
+		return nil
+	}
+	err := f.file.Close()
+	f.file = nil
+	return err
+}
@@ -0,0 +1,17 @@
+//go:build !windows
+
+package outputstream
+
+import (
+	"os"
+	"syscall"
+)
+
+func createFIFO(path string) error {
+	return syscall.Mkfifo(path, 0o600)
+}
+
+// openFIFO 以非阻塞方式打开命名管道的写端，没有读取方时立即返回 ENXIO 错误。
+func openFIFO(path string) (*os.File, error) {
+	return os.OpenFile(path, os.O_WRONLY|syscall.O_NONBLOCK, 0)
+}
@@ -0,0 +1,18 @@
+//go:build windows
+
+package outputstream
+
+import (
+	"errors"
+	"os"
+)
+
+var errFIFOUnsupported = errors.New("Windows 不支持命名管道输出，请改用 socket") //nolint:staticcheck
+
+func createFIFO(string) error {
+	return errFIFOUnsupported
+}
+
+func openFIFO(string) (*os.File, error) {
+	return nil, errFIFOUnsupported
+}
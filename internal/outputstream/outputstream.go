@@ -0,0 +1,195 @@
+// Package outputstream 把助手输出在生成时实时写入命名管道或 Unix 套接字，供语音合成、通知和
+// 日志收集等外部工具读取，不需要启动 API 服务器。
+//
+// jsonl 格式下每行是一个 [Event] 的 JSON 编码；text 格式只写入回复文本，每条回复之后空一行。
+// 没有读取方或读取方跟不上时输出会被丢弃，不会阻塞代理。
+package outputstream
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/purpose168/crush-cn/internal/config"
+	"github.com/purpose168/crush-cn/internal/home"
+	"github.com/purpose168/crush-cn/internal/message"
+	"github.com/purpose168/crush-cn/internal/pubsub"
+)
+
+// writeTimeout 是一次写入等待读取方的最长时间，超时的读取方会被断开。
+const writeTimeout = time.Second
+
+// Type 是事件的类型。
+type Type string
+
+const (
+	// TypeText 是回复文本的增量。
+	TypeText Type = "text"
+	// TypeReasoning 是模型推理的增量，仅在配置了 reasoning 时写入。
+	TypeReasoning Type = "reasoning"
+	// TypeFinished 在一条助手消息结束时写入。
+	TypeFinished Type = "finished"
+)
+
+// Event 是 jsonl 格式中的一行。
+type Event struct {
+	Time      time.Time `json:"time"`
+	SessionID string    `json:"session_id"`
+	MessageID string    `json:"message_id"`
+	Type      Type      `json:"type"`
+	Model     string    `json:"model,omitempty"`
+	// Text 是 text 和 reasoning 事件中新生成的文本。
+	Text string `json:"text,omitempty"`
+	// FinishReason 是 finished 事件中消息结束的原因。
+	FinishReason string `json:"finish_reason,omitempty"`
+}
+
+// sink 是输出的目的地。写入失败由 sink 自行处理，不会返回给调用方。
+type sink interface {
+	Write(data []byte)
+	Close() error
+}
+
+// progress 记录一条消息已经写出的内容长度。
+type progress struct {
+	text      int
+	reasoning int
+	finished  bool
+}
+
+// Stream 把助手消息的更新转换为增量输出并写入所有 sink。
+type Stream struct {
+	format    string
+	reasoning bool
+	sinks     []sink
+	messages  map[string]*progress
+}
+
+// New 按配置创建命名管道和 Unix 套接字并返回 [Stream]。
+func New(cfg *config.OutputStream) (*Stream, error) {
+	s := &Stream{
+		format:    cfg.Format,
+		reasoning: cfg.Reasoning,
+		messages:  make(map[string]*progress),
+	}
+	if s.format == "" {
+		s.format = config.OutputStreamFormatJSONL
+	}
+	if cfg.FIFO != "" {
+		fifo, err := newFIFOSink(home.Long(cfg.FIFO))
+		if err != nil {
+			return nil, err
+		}
+		s.sinks = append(s.sinks, fifo)
+	}
+	if cfg.Socket != "" {
+		socket, err := newSocketSink(home.Long(cfg.Socket))
+		if err != nil {
+			_ = s.Close()
+			return nil, err
+		}
+		s.sinks = append(s.sinks, socket)
+	}
+	return s, nil
+}
+
+// Run 处理消息事件，直到 ctx 被取消或事件通道关闭。
+func (s *Stream) Run(ctx context.Context, events <-chan pubsub.Event[message.Message]) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			switch event.Type {
+			case pubsub.CreatedEvent, pubsub.UpdatedEvent:
+				s.handle(event.Payload)
+			case pubsub.DeletedEvent:
+				delete(s.messages, event.Payload.ID)
+			}
+		}
+	}
+}
+
+// Close 关闭所有 sink。
+func (s *Stream) Close() error {
+	var errs []error
+	for _, sink := range s.sinks {
+		errs = append(errs, sink.Close())
+	}
+	return errors.Join(errs...)
+}
+
+// handle 写出助手消息自上次更新以来新生成的内容。消息事件可能被合并或丢弃，因此按已写出的长度计算增量。
+func (s *Stream) handle(msg message.Message) {
+	if msg.Role != message.Assistant {
+		return
+	}
+	p, ok := s.messages[msg.ID]
+	if !ok {
+		p = &progress{}
+		s.messages[msg.ID] = p
+	}
+	if p.finished {
+		return
+	}
+
+	if s.reasoning && s.format == config.OutputStreamFormatJSONL {
+		if delta, ok := advance(&p.reasoning, msg.ReasoningContent().Thinking); ok {
+			s.writeEvent(msg, Event{Type: TypeReasoning, Text: delta})
+		}
+	}
+	if delta, ok := advance(&p.text, msg.Content().Text); ok {
+		if s.format == config.OutputStreamFormatText {
+			s.write([]byte(delta))
+		} else {
+			s.writeEvent(msg, Event{Type: TypeText, Text: delta})
+		}
+	}
+
+	if !msg.IsFinished() {
+		return
+	}
+	p.finished = true
+	if s.format == config.OutputStreamFormatText {
+		if p.text > 0 {
+			s.write([]byte("\n\n"))
+		}
+		return
+	}
+	s.writeEvent(msg, Event{Type: TypeFinished, FinishReason: string(msg.FinishReason())})
+}
+
+// advance 返回 content 中超出已写出长度 *written 的部分并更新长度。内容变短时（例如消息被替换）只更新长度。
+func advance(written *int, content string) (string, bool) {
+	if len(content) <= *written {
+		*written = len(content)
+		return "", false
+	}
+	delta := content[*written:]
+	*written = len(content)
+	return delta, true
+}
+
+func (s *Stream) writeEvent(msg message.Message, e Event) {
+	e.Time = time.Now().UTC()
+	e.SessionID = msg.SessionID
+	e.MessageID = msg.ID
+	e.Model = msg.Model
+	data, err := json.Marshal(e)
+	if err != nil {
+		slog.Error("Failed to encode output stream event", "error", err)
+		return
+	}
+	s.write(append(data, '\n'))
+}
+
+func (s *Stream) write(data []byte) {
+	for _, sink := range s.sinks {
+		sink.Write(data)
+	}
+}
@@ -0,0 +1,109 @@
+package outputstream
+
+import (
+	"bufio"
+	"encoding/json"
+	"net"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/purpose168/crush-cn/internal/config"
+	"github.com/purpose168/crush-cn/internal/message"
+	"github.com/stretchr/testify/require"
+)
+
+type bufferSink struct {
+	strings.Builder
+}
+
+func (b *bufferSink) Write(data []byte) { b.Builder.Write(data) }
+func (b *bufferSink) Close() error      { return nil }
+
+func assistantMessage(text, reasoning string, finished bool) message.Message {
+	msg := message.Message{ID: "m1", SessionID: "s1", Role: message.Assistant, Model: "model"}
+	if reasoning != "" {
+		msg.AppendReasoningContent(reasoning)
+	}
+	if text != "" {
+		msg.AppendContent(text)
+	}
+	if finished {
+		msg.AddFinish(message.FinishReasonEndTurn, "", "")
+	}
+	return msg
+}
+
+func TestStreamText(t *testing.T) {
+	t.Parallel()
+
+	out := &bufferSink{}
+	s := &Stream{format: config.OutputStreamFormatText, sinks: []sink{out}, messages: map[string]*progress{}}
+	s.handle(message.Message{ID: "u1", Role: message.User, Parts: []message.ContentPart{message.TextContent{Text: "问题"}}})
+	s.handle(assistantMessage("", "思考", false))
+	s.handle(assistantMessage("你好", "思考", false))
+	s.handle(assistantMessage("你好，世界", "思考", false))
+	s.handle(assistantMessage("你好，世界", "思考", true))
+	// 结束之后的更新不再写出
+	s.handle(assistantMessage("你好，世界", "思考", true))
+
+	require.Equal(t, "你好，世界\n\n", out.String())
+}
+
+func TestStreamJSONL(t *testing.T) {
+	t.Parallel()
+
+	out := &bufferSink{}
+	s := &Stream{format: config.OutputStreamFormatJSONL, reasoning: true, sinks: []sink{out}, messages: map[string]*progress{}}
+	s.handle(assistantMessage("", "思考", false))
+	s.handle(assistantMessage("你好", "思考", false))
+	s.handle(assistantMessage("你好，世界", "思考", true))
+
+	var events []Event
+	for line := range strings.Lines(out.String()) {
+		var e Event
+		require.NoError(t, json.Unmarshal([]byte(line), &e))
+		require.Equal(t, "s1", e.SessionID)
+		require.Equal(t, "m1", e.MessageID)
+		require.Equal(t, "model", e.Model)
+		require.False(t, e.Time.IsZero())
+		e.Time, e.SessionID, e.MessageID, e.Model = time.Time{}, "", "", ""
+		events = append(events, e)
+	}
+	require.Equal(t, []Event{
+		{Type: TypeReasoning, Text: "思考"},
+		{Type: TypeText, Text: "你好"},
+		{Type: TypeText, Text: "，世界"},
+		{Type: TypeFinished, FinishReason: string(message.FinishReasonEndTurn)},
+	}, events)
+}
+
+func TestSocketSink(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "out.sock")
+	s, err := New(&config.OutputStream{Socket: path, Format: config.OutputStreamFormatText})
+	require.NoError(t, err)
+	defer s.Close()
+
+	conn, err := net.Dial("unix", path)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	socket := s.sinks[0].(*socketSink)
+	require.Eventually(t, func() bool {
+		socket.mu.Lock()
+		defer socket.mu.Unlock()
+		return len(socket.conns) == 1
+	}, writeTimeout, 10*time.Millisecond)
+
+	s.handle(assistantMessage("你好", "", true))
+	line, err := bufio.NewReader(conn).ReadString('\n')
+	require.NoError(t, err)
+	require.Equal(t, "你好\n", line)
+
+	// 同一个套接字不能被监听两次
+	_, err = New(&config.OutputStream{Socket: path})
+	require.Error(t, err)
+}
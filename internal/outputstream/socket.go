@@ -0,0 +1,85 @@
+package outputstream
+
+import (
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"sync"
+	"time"
+)
+
+// socketSink 在 Unix 套接字上监听，把输出写入所有已连接的客户端。
+type socketSink struct {
+	path     string
+	listener net.Listener
+
+	mu     sync.Mutex
+	conns  map[net.Conn]struct{}
+	closed bool
+}
+
+func newSocketSink(path string) (*socketSink, error) {
+	if info, err := os.Lstat(path); err == nil && info.Mode()&os.ModeSocket != 0 {
+		if conn, err := net.Dial("unix", path); err == nil {
+			_ = conn.Close()
+			return nil, fmt.Errorf("套接字 %s 正在被其他进程使用", path)
+		}
+		// 上次没有正常退出时残留的套接字文件
+		_ = os.Remove(path)
+	}
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, fmt.Errorf("监听套接字 %s 失败: %w", path, err)
+	}
+	s := &socketSink{
+		path:     path,
+		listener: listener,
+		conns:    make(map[net.Conn]struct{}),
+	}
+	go s.accept()
+	return s, nil
+}
+
+func (s *socketSink) accept() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		s.mu.Lock()
+		if s.closed {
+			s.mu.Unlock()
+			_ = conn.Close()
+			return
+		}
+		s.conns[conn] = struct{}{}
+		s.mu.Unlock()
+	}
+}
+
+func (s *socketSink) Write(data []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for conn := range s.conns {
+		_ = conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+		if _, err := conn.Write(data); err != nil {
+			slog.Debug("Output stream client disconnected", "path", s.path, "error", err)
+			_ = conn.Close()
+			delete(s.conns, conn)
+		}
+	}
+}
+
+// Close 停止监听并断开所有客户端，套接字文件随监听器一起删除。
+func (s *socketSink) Close() error {
+	err := s.listener.Close()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	for conn := range s.conns {
+		_ = conn.Close()
+		delete(s.conns, conn)
+	}
+	return err
+}
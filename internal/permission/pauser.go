@@ -0,0 +1,35 @@
+package permission
+
+import "context"
+
+// Pauser 是在等待用户确认权限期间需要暂停的计时，例如工具的执行时限。
+type Pauser interface {
+	Pause()
+	Resume()
+}
+
+type pauserKey struct{}
+
+// WithPauser 返回携带 p 的上下文，[Service.Request] 在等待用户确认期间会暂停 p。
+// 上下文中已有的 Pauser（例如外层智能体工具的时限）会一并暂停。
+func WithPauser(ctx context.Context, p Pauser) context.Context {
+	if parent, ok := ctx.Value(pauserKey{}).(Pauser); ok {
+		p = pausers{parent, p}
+	}
+	return context.WithValue(ctx, pauserKey{}, p)
+}
+
+// pausers 同时暂停和恢复多个 Pauser。
+type pausers []Pauser
+
+func (ps pausers) Pause() {
+	for _, p := range ps {
+		p.Pause()
+	}
+}
+
+func (ps pausers) Resume() {
+	for _, p := range ps {
+		p.Resume()
+	}
+}
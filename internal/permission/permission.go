@@ -7,6 +7,7 @@ import (
 	"path/filepath"
 	"slices"
 	"sync"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/purpose168/crush-cn/internal/csync"
@@ -45,7 +46,11 @@ type PermissionRequest struct {
 type Service interface {
 	pubsub.Subscriber[PermissionRequest]
 	GrantPersistent(permission PermissionRequest)
+	// AllowTool 将工具加入允许列表，之后调用该工具不再请求权限
+	AllowTool(toolName string)
 	Grant(permission PermissionRequest)
+	// GrantWindow 允许权限请求，并在一段时间或若干次调用内自动允许同一会话中的该工具
+	GrantWindow(permission PermissionRequest, window Window)
 	Deny(permission PermissionRequest)
 	Request(ctx context.Context, opts CreatePermissionRequest) (bool, error)
 	AutoApproveSession(sessionID string)
@@ -66,6 +71,10 @@ type permissionService struct {
 	autoApproveSessionsMu sync.RWMutex
 	skip                  bool
 	allowedTools          []string
+	allowedToolsMu        sync.RWMutex
+	windows               map[windowKey]*activeWindow
+	windowsMu             sync.Mutex
+	now                   func() time.Time
 
 	// 用于确保一次只处理一个请求
 	requestMu       sync.Mutex
@@ -134,6 +143,12 @@ func (s *permissionService) Request(ctx context.Context, opts CreatePermissionRe
 		return true, nil
 	}
 
+	// 等待用户确认的时间不计入工具的执行时限
+	if p, ok := ctx.Value(pauserKey{}).(Pauser); ok {
+		p.Pause()
+		defer p.Resume()
+	}
+
 	// 通知 UI 已请求权限
 	s.notificationBroker.Publish(pubsub.CreatedEvent, PermissionNotification{
 		ToolCallID: opts.ToolCallID,
@@ -143,7 +158,10 @@ func (s *permissionService) Request(ctx context.Context, opts CreatePermissionRe
 
 	// 检查工具/操作组合是否在允许列表中
 	commandKey := opts.ToolName + ":" + opts.Action
-	if slices.Contains(s.allowedTools, commandKey) || slices.Contains(s.allowedTools, opts.ToolName) {
+	s.allowedToolsMu.RLock()
+	allowed := slices.Contains(s.allowedTools, commandKey) || slices.Contains(s.allowedTools, opts.ToolName)
+	s.allowedToolsMu.RUnlock()
+	if allowed {
 		return true, nil
 	}
 
@@ -151,7 +169,7 @@ func (s *permissionService) Request(ctx context.Context, opts CreatePermissionRe
 	autoApprove := s.autoApproveSessions[opts.SessionID]
 	s.autoApproveSessionsMu.RUnlock()
 
-	if autoApprove {
+	if autoApprove || s.useWindow(opts.SessionID, opts.ToolName) {
 		s.notificationBroker.Publish(pubsub.CreatedEvent, PermissionNotification{
 			ToolCallID: opts.ToolCallID,
 			Granted:    true,
@@ -215,6 +233,15 @@ func (s *permissionService) Request(ctx context.Context, opts CreatePermissionRe
 	}
 }
 
+func (s *permissionService) AllowTool(toolName string) {
+	s.allowedToolsMu.Lock()
+	if !slices.Contains(s.allowedTools, toolName) {
+		// 允许列表可能与配置共享底层数组，追加时总是重新分配
+		s.allowedTools = append(slices.Clip(s.allowedTools), toolName)
+	}
+	s.allowedToolsMu.Unlock()
+}
+
 func (s *permissionService) AutoApproveSession(sessionID string) {
 	s.autoApproveSessionsMu.Lock()
 	s.autoApproveSessions[sessionID] = true
@@ -243,5 +270,7 @@ func NewPermissionService(workingDir string, skip bool, allowedTools []string) S
 		skip:                skip,
 		allowedTools:        allowedTools,
 		pendingRequests:     csync.NewMap[string, chan bool](),
+		windows:             make(map[windowKey]*activeWindow),
+		now:                 time.Now,
 	}
 }
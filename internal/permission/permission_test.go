@@ -3,6 +3,7 @@ package permission
 import (
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -254,3 +255,74 @@ func TestPermissionService_SequentialProperties(t *testing.T) {
 		assert.True(t, result, "重复的请求由于持久权限应该自动批准")
 	})
 }
+
+// TestPermissionService_AllowTool 测试运行时加入允许列表的工具不再请求权限
+func TestPermissionService_AllowTool(t *testing.T) {
+	t.Parallel()
+
+	service := NewPermissionService("/tmp", false, nil)
+	service.AllowTool("mcp_github_list_issues")
+	service.AllowTool("mcp_github_list_issues")
+
+	granted, err := service.Request(t.Context(), CreatePermissionRequest{
+		SessionID: "session1",
+		ToolName:  "mcp_github_list_issues",
+		Action:    "execute",
+		Path:      "/tmp",
+	})
+	require.NoError(t, err)
+	require.True(t, granted)
+	require.Equal(t, []string{"mcp_github_list_issues"}, service.(*permissionService).allowedTools)
+}
+
+// TestPermissionService_GrantWindow 测试临时授权在时间或次数用尽后恢复请求权限
+func TestPermissionService_GrantWindow(t *testing.T) {
+	t.Parallel()
+
+	service := NewPermissionService("/tmp", false, nil).(*permissionService)
+	now := time.Now()
+	service.now = func() time.Time { return now }
+
+	req := CreatePermissionRequest{
+		SessionID: "session1",
+		ToolName:  "edit",
+		Action:    "write",
+		Path:      "/tmp/a.go",
+	}
+	events := service.Subscribe(t.Context())
+	grantNext := func(window Window) {
+		event := <-events
+		service.GrantWindow(event.Payload, window)
+	}
+
+	// 第一次请求由用户允许 10 分钟或 2 次
+	go grantNext(Window{Duration: 10 * time.Minute, Uses: 2})
+	granted, err := service.Request(t.Context(), req)
+	require.NoError(t, err)
+	require.True(t, granted)
+
+	// 之后的两次调用自动允许，其他文件和操作同样生效
+	for _, path := range []string{"/tmp/b.go", "/tmp/c.go"} {
+		req.Path = path
+		require.True(t, service.useWindow(req.SessionID, req.ToolName))
+	}
+	require.False(t, service.useWindow(req.SessionID, req.ToolName), "次数用尽后恢复请求权限")
+	require.False(t, service.useWindow("session2", req.ToolName), "临时授权只在同一会话中生效")
+
+	// 只限时间的授权在到期后失效
+	go grantNext(Window{Duration: time.Minute})
+	granted, err = service.Request(t.Context(), req)
+	require.NoError(t, err)
+	require.True(t, granted)
+	require.True(t, service.useWindow(req.SessionID, req.ToolName))
+	now = now.Add(time.Minute)
+	require.False(t, service.useWindow(req.SessionID, req.ToolName))
+}
+
+func TestWindowString(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, "10 分钟", Window{Duration: 10 * time.Minute}.String())
+	require.Equal(t, "5 次", Window{Uses: 5}.String())
+	require.Equal(t, "30s或 3 次", Window{Duration: 30 * time.Second, Uses: 3}.String())
+}
@@ -0,0 +1,85 @@
+package permission
+
+import (
+	"fmt"
+	"time"
+)
+
+// DefaultWindowDuration 是未配置时临时授权的有效时间
+const DefaultWindowDuration = 10 * time.Minute
+
+// Window 是临时授权的范围：在 Duration 内或接下来的 Uses 次调用中自动允许同一会话中的工具，
+// 两者都设置时任一用尽即恢复请求权限。两者都为零时不授权。
+type Window struct {
+	Duration time.Duration
+	Uses     int
+}
+
+// String 返回授权范围的描述，例如「10 分钟」或「5 次」。
+func (w Window) String() string {
+	var duration string
+	if w.Duration > 0 {
+		if w.Duration%time.Minute == 0 {
+			duration = fmt.Sprintf("%d 分钟", int(w.Duration/time.Minute))
+		} else {
+			duration = w.Duration.String()
+		}
+	}
+	switch {
+	case duration != "" && w.Uses > 0:
+		return fmt.Sprintf("%s或 %d 次", duration, w.Uses)
+	case duration != "":
+		return duration
+	default:
+		return fmt.Sprintf("%d 次", w.Uses)
+	}
+}
+
+type windowKey struct {
+	sessionID string
+	toolName  string
+}
+
+// activeWindow 是生效中的临时授权
+type activeWindow struct {
+	expiresAt time.Time // 零值表示不限时间
+	remaining int       // 剩余次数，0 表示不限次数
+}
+
+// GrantWindow 允许权限请求，并在 window 范围内自动允许同一会话中该工具之后的调用。
+// 再次授予时覆盖之前的范围。
+func (s *permissionService) GrantWindow(permission PermissionRequest, window Window) {
+	if window.Duration > 0 || window.Uses > 0 {
+		active := &activeWindow{remaining: window.Uses}
+		if window.Duration > 0 {
+			active.expiresAt = s.now().Add(window.Duration)
+		}
+		s.windowsMu.Lock()
+		s.windows[windowKey{permission.SessionID, permission.ToolName}] = active
+		s.windowsMu.Unlock()
+	}
+	s.Grant(permission)
+}
+
+// useWindow 报告工具调用是否在生效中的临时授权范围内，是则消耗一次次数。
+// 过期或次数用尽的授权会被删除，之后恢复请求权限。
+func (s *permissionService) useWindow(sessionID, toolName string) bool {
+	key := windowKey{sessionID, toolName}
+	s.windowsMu.Lock()
+	defer s.windowsMu.Unlock()
+	w, ok := s.windows[key]
+	if !ok {
+		return false
+	}
+	if !w.expiresAt.IsZero() && !s.now().Before(w.expiresAt) {
+		delete(s.windows, key)
+		return false
+	}
+	if w.remaining > 0 {
+		w.remaining--
+		if w.remaining == 0 {
+			delete(s.windows, key)
+		}
+	}
+	return true
+}
@@ -0,0 +1,186 @@
+package projectinfo
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"go/build/constraint"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"golang.org/x/mod/modfile"
+)
+
+// maxGoFiles 限制扫描构建标签时读取的 Go 文件数，避免在大型仓库中耗时过长
+const maxGoFiles = 5000
+
+// GoModule 是从 go.mod 和源文件中分析出的 Go 模块信息
+type GoModule struct {
+	Path      string
+	GoVersion string
+	Deps      []string // 直接依赖的模块路径
+	Tools     []string // go.mod 中的 tool 指令
+	BuildTags []string // 源文件 //go:build 约束中出现的自定义标签
+	Lint      string   // 检测到的 golangci-lint 配置文件
+}
+
+// analyzeGo 分析 dir 中的 go.mod，不是 Go 模块时返回 nil
+func analyzeGo(dir string) *GoModule {
+	data, err := os.ReadFile(filepath.Join(dir, "go.mod"))
+	if err != nil {
+		return nil
+	}
+	// ParseLax 会忽略 tool 等指令，只在严格解析失败时使用
+	f, err := modfile.Parse("go.mod", data, nil)
+	if err != nil {
+		if f, err = modfile.ParseLax("go.mod", data, nil); err != nil {
+			return nil
+		}
+	}
+	mod := &GoModule{}
+	if f.Module != nil {
+		mod.Path = f.Module.Mod.Path
+	}
+	if f.Go != nil {
+		mod.GoVersion = f.Go.Version
+	}
+	for _, req := range f.Require {
+		if !req.Indirect {
+			mod.Deps = append(mod.Deps, req.Mod.Path)
+		}
+	}
+	for _, tool := range f.Tool {
+		mod.Tools = append(mod.Tools, tool.Path)
+	}
+	mod.BuildTags = goBuildTags(dir)
+	for _, name := range []string{".golangci.yml", ".golangci.yaml", ".golangci.toml", ".golangci.json"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+			mod.Lint = name
+			break
+		}
+	}
+	return mod
+}
+
+func (m *GoModule) commands() []Command {
+	commands := []Command{
+		{Kind: KindBuild, Command: "go build ./...", Source: "go.mod"},
+		{Kind: KindTest, Command: "go test ./...", Source: "go.mod"},
+		{Kind: KindLint, Command: "go vet ./...", Source: "go.mod"},
+	}
+	if m.Lint != "" {
+		commands = append(commands, Command{Kind: KindLint, Command: "golangci-lint run", Source: m.Lint})
+	}
+	return commands
+}
+
+func (m *GoModule) facts() []string {
+	facts := []string{fmt.Sprintf("Go module `%s`", m.Path)}
+	if m.GoVersion != "" {
+		facts[0] += fmt.Sprintf(" (go %s)", m.GoVersion)
+	}
+	if len(m.Deps) > 0 {
+		facts = append(facts, "Direct Go dependencies: "+listed(m.Deps))
+	}
+	if len(m.Tools) > 0 {
+		facts = append(facts, "Go tools (run with `go tool <name>`): "+listed(m.Tools))
+	}
+	if len(m.BuildTags) > 0 {
+		facts = append(facts, "Custom build tags (pass with `-tags`): "+listed(m.BuildTags))
+	}
+	return facts
+}
+
+// errStopWalk 在读取的文件数达到上限时结束遍历
+var errStopWalk = errors.New("stop walk")
+
+// goBuildTags 收集 dir 下 Go 文件构建约束中的自定义标签，忽略操作系统、架构和 Go 版本标签
+func goBuildTags(dir string) []string {
+	tags := map[string]struct{}{}
+	files := 0
+	_ = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		name := d.Name()
+		if d.IsDir() {
+			if path != dir && (strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_") ||
+				name == "vendor" || name == "testdata" || name == "node_modules") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasSuffix(name, ".go") {
+			return nil
+		}
+		if files++; files > maxGoFiles {
+			return errStopWalk
+		}
+		for _, tag := range fileBuildTags(path) {
+			tags[tag] = struct{}{}
+		}
+		return nil
+	})
+
+	result := make([]string, 0, len(tags))
+	for tag := range tags {
+		result = append(result, tag)
+	}
+	slices.Sort(result)
+	return result
+}
+
+// fileBuildTags 读取文件 package 语句之前的 //go:build 约束中的自定义标签
+func fileBuildTags(path string) []string {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	var tags []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "package ") {
+			break
+		}
+		if !constraint.IsGoBuild(line) {
+			continue
+		}
+		expr, err := constraint.Parse(line)
+		if err != nil {
+			continue
+		}
+		// Eval 会访问表达式中的每个标签
+		expr.Eval(func(tag string) bool {
+			if !isStandardTag(tag) {
+				tags = append(tags, tag)
+			}
+			return false
+		})
+	}
+	return tags
+}
+
+// isStandardTag 报告标签是否由 Go 工具链自动设置
+func isStandardTag(tag string) bool {
+	if strings.HasPrefix(tag, "go1.") {
+		return true
+	}
+	return slices.Contains(standardTags, tag)
+}
+
+var standardTags = []string{
+	// 工具链和特殊标签
+	"cgo", "gc", "gccgo", "ignore", "unix",
+	// GOOS
+	"aix", "android", "darwin", "dragonfly", "freebsd", "hurd", "illumos", "ios", "js",
+	"linux", "nacl", "netbsd", "openbsd", "plan9", "solaris", "wasip1", "windows", "zos",
+	// GOARCH
+	"386", "amd64", "arm", "arm64", "loong64", "mips", "mips64", "mips64le", "mipsle",
+	"ppc64", "ppc64le", "riscv64", "s390x", "wasm",
+}
@@ -0,0 +1,93 @@
+package projectinfo
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Makefile 是从 Makefile 中分析出的目标
+type Makefile struct {
+	Name    string // Makefile 的文件名
+	Targets []MakeTarget
+}
+
+// MakeTarget 是 Makefile 中的一个目标
+type MakeTarget struct {
+	Name   string
+	Recipe []string // 目标的命令，去掉了开头的 @ 和 -
+}
+
+// makefileNames 是 make 默认查找的文件名，按查找顺序排列
+var makefileNames = []string{"GNUmakefile", "makefile", "Makefile"}
+
+// targetRe 匹配规则行的目标部分，排除 := 和 ::= 赋值
+var targetRe = regexp.MustCompile(`^([A-Za-z0-9][A-Za-z0-9_./ -]*?)\s*::?(?:[^=:]|$)`)
+
+// analyzeMakefile 分析 dir 中的 Makefile，不存在或没有目标时返回 nil
+func analyzeMakefile(dir string) *Makefile {
+	for _, name := range makefileNames {
+		f, err := os.Open(filepath.Join(dir, name))
+		if err != nil {
+			continue
+		}
+		defer f.Close()
+
+		mk := &Makefile{Name: name}
+		var current *MakeTarget
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			line := scanner.Text()
+			if strings.HasPrefix(line, "\t") {
+				if current != nil {
+					cmd := strings.TrimLeft(strings.TrimSpace(line), "@-+")
+					current.Recipe = append(current.Recipe, strings.TrimSpace(cmd))
+				}
+				continue
+			}
+			current = nil
+			m := targetRe.FindStringSubmatch(line)
+			if m == nil || strings.Contains(m[1], "%") {
+				continue
+			}
+			for target := range strings.FieldsSeq(m[1]) {
+				if slices.ContainsFunc(mk.Targets, func(t MakeTarget) bool { return t.Name == target }) {
+					continue
+				}
+				mk.Targets = append(mk.Targets, MakeTarget{Name: target})
+			}
+			// 只为单目标规则记录命令
+			if fields := strings.Fields(m[1]); len(fields) == 1 {
+				for i := range mk.Targets {
+					if mk.Targets[i].Name == fields[0] {
+						current = &mk.Targets[i]
+					}
+				}
+			}
+		}
+		if len(mk.Targets) == 0 {
+			return nil
+		}
+		return mk
+	}
+	return nil
+}
+
+func (m *Makefile) commands() []Command {
+	commands := make([]Command, 0, len(m.Targets))
+	for _, t := range m.Targets {
+		c := Command{
+			Kind:    classify(t.Name),
+			Command: "make " + t.Name,
+			Source:  m.Name,
+		}
+		if len(t.Recipe) == 1 {
+			c.Detail = t.Recipe[0]
+		}
+		commands = append(commands, c)
+	}
+	return commands
+}
@@ -0,0 +1,96 @@
+package projectinfo
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// NodePackage 是从 package.json 中分析出的 Node.js 包信息
+type NodePackage struct {
+	Name           string
+	PackageManager string            // npm、pnpm、yarn 或 bun
+	Scripts        map[string]string // 脚本名到命令的映射
+}
+
+// lockFiles 将锁文件映射到对应的包管理器，按检测顺序排列
+var lockFiles = []struct {
+	name    string
+	manager string
+}{
+	{"pnpm-lock.yaml", "pnpm"},
+	{"yarn.lock", "yarn"},
+	{"bun.lock", "bun"},
+	{"bun.lockb", "bun"},
+	{"package-lock.json", "npm"},
+}
+
+// analyzeNode 分析 dir 中的 package.json，不存在或无法解析时返回 nil
+func analyzeNode(dir string) *NodePackage {
+	data, err := os.ReadFile(filepath.Join(dir, "package.json"))
+	if err != nil {
+		return nil
+	}
+	var pkg struct {
+		Name           string            `json:"name"`
+		PackageManager string            `json:"packageManager"`
+		Scripts        map[string]string `json:"scripts"`
+	}
+	if err := json.Unmarshal(data, &pkg); err != nil {
+		return nil
+	}
+
+	// packageManager 字段形如 "pnpm@9.1.0"，优先于锁文件
+	manager, _, _ := strings.Cut(pkg.PackageManager, "@")
+	if manager == "" {
+		manager = "npm"
+		for _, lock := range lockFiles {
+			if _, err := os.Stat(filepath.Join(dir, lock.name)); err == nil {
+				manager = lock.manager
+				break
+			}
+		}
+	}
+	return &NodePackage{
+		Name:           pkg.Name,
+		PackageManager: manager,
+		Scripts:        pkg.Scripts,
+	}
+}
+
+func (p *NodePackage) commands() []Command {
+	names := make([]string, 0, len(p.Scripts))
+	for name := range p.Scripts {
+		// pre 和 post 钩子随主脚本自动执行
+		if base, ok := strings.CutPrefix(name, "pre"); ok && p.Scripts[base] != "" {
+			continue
+		}
+		if base, ok := strings.CutPrefix(name, "post"); ok && p.Scripts[base] != "" {
+			continue
+		}
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	commands := make([]Command, 0, len(names))
+	for _, name := range names {
+		commands = append(commands, Command{
+			Kind:    classify(name),
+			Command: fmt.Sprintf("%s run %s", p.PackageManager, name),
+			Source:  "package.json",
+			Detail:  p.Scripts[name],
+		})
+	}
+	return commands
+}
+
+func (p *NodePackage) facts() []string {
+	fact := fmt.Sprintf("Node.js package managed with %s", p.PackageManager)
+	if p.Name != "" {
+		fact = fmt.Sprintf("Node.js package `%s` managed with %s", p.Name, p.PackageManager)
+	}
+	return []string{fact}
+}
@@ -0,0 +1,162 @@
+// Package projectinfo 分析项目的构建配置，为项目初始化提供可靠的构建、测试和检查命令。
+// 分析器只读取配置文件，不执行任何命令。
+package projectinfo
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Kind 是命令的用途
+type Kind int
+
+const (
+	KindBuild Kind = iota
+	KindTest
+	KindLint
+	KindOther
+)
+
+// String 返回命令用途在生成的文档中使用的标题
+func (k Kind) String() string {
+	switch k {
+	case KindBuild:
+		return "Build"
+	case KindTest:
+		return "Test"
+	case KindLint:
+		return "Lint"
+	default:
+		return "Other"
+	}
+}
+
+// maxListed 限制报告中列出的依赖和其他命令的数量，避免提示过长
+const maxListed = 20
+
+// Command 是从项目配置中发现的命令
+type Command struct {
+	Kind    Kind
+	Command string // 在项目根目录执行的命令
+	Source  string // 发现该命令的文件
+	Detail  string // 命令实际执行的内容，例如 package.json 中的脚本
+}
+
+// Report 是项目分析的结果，未检测到的部分为 nil
+type Report struct {
+	Go       *GoModule
+	Node     *NodePackage
+	Make     *Makefile
+	Commands []Command
+}
+
+// Empty 报告是否没有发现任何项目配置
+func (r Report) Empty() bool {
+	return r.Go == nil && r.Node == nil && r.Make == nil
+}
+
+// Analyze 分析 dir 中的项目配置。无法解析的文件会被忽略，分析尽力而为。
+func Analyze(dir string) Report {
+	var r Report
+	// 项目自定义的脚本和目标优先于语言的默认命令
+	if mk := analyzeMakefile(dir); mk != nil {
+		r.Make = mk
+		r.addCommands(mk.commands())
+	}
+	if pkg := analyzeNode(dir); pkg != nil {
+		r.Node = pkg
+		r.addCommands(pkg.commands())
+	}
+	if mod := analyzeGo(dir); mod != nil {
+		r.Go = mod
+		r.addCommands(mod.commands())
+	}
+	return r
+}
+
+// addCommands 添加命令，跳过重复的命令
+func (r *Report) addCommands(commands []Command) {
+	for _, c := range commands {
+		if slices.ContainsFunc(r.Commands, func(e Command) bool { return e.Command == c.Command }) {
+			continue
+		}
+		r.Commands = append(r.Commands, c)
+	}
+}
+
+// Markdown 将报告渲染为 "How to build/test/lint" 文档章节，没有发现项目配置时返回空字符串
+func (r Report) Markdown() string {
+	if r.Empty() {
+		return ""
+	}
+	var sb strings.Builder
+	sb.WriteString("## How to build/test/lint\n")
+	for _, kind := range []Kind{KindBuild, KindTest, KindLint, KindOther} {
+		var commands []Command
+		for _, c := range r.Commands {
+			if c.Kind == kind {
+				commands = append(commands, c)
+			}
+		}
+		if len(commands) == 0 {
+			continue
+		}
+		fmt.Fprintf(&sb, "\n### %s\n\n", kind)
+		for i, c := range commands {
+			if kind == KindOther && i == maxListed {
+				fmt.Fprintf(&sb, "- ... and %d more\n", len(commands)-maxListed)
+				break
+			}
+			fmt.Fprintf(&sb, "- `%s`", c.Command)
+			if c.Detail != "" && c.Detail != c.Command {
+				fmt.Fprintf(&sb, " → `%s`", c.Detail)
+			}
+			fmt.Fprintf(&sb, " (%s)\n", c.Source)
+		}
+	}
+
+	var facts []string
+	if r.Go != nil {
+		facts = append(facts, r.Go.facts()...)
+	}
+	if r.Node != nil {
+		facts = append(facts, r.Node.facts()...)
+	}
+	if len(facts) > 0 {
+		sb.WriteString("\n### Project facts\n\n")
+		for _, fact := range facts {
+			fmt.Fprintf(&sb, "- %s\n", fact)
+		}
+	}
+	return sb.String()
+}
+
+// classify 根据脚本或目标名推断命令用途
+func classify(name string) Kind {
+	words := strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return r == ':' || r == '-' || r == '_' || r == '.' || r == '/'
+	})
+	for _, kind := range []Kind{KindTest, KindLint, KindBuild} {
+		for _, word := range words {
+			if slices.Contains(kindWords[kind], word) {
+				return kind
+			}
+		}
+	}
+	return KindOther
+}
+
+var kindWords = map[Kind][]string{
+	KindBuild: {"build", "compile", "dist", "bundle", "install"},
+	KindTest:  {"test", "tests", "spec", "e2e", "coverage", "cover", "bench", "benchmark"},
+	KindLint:  {"lint", "fmt", "format", "vet", "check", "typecheck", "tsc", "prettier", "eslint"},
+}
+
+// listed 将名称列表格式化为逗号分隔的文本，超过 maxListed 时省略其余部分
+func listed(names []string) string {
+	if len(names) <= maxListed {
+		return strings.Join(names, ", ")
+	}
+	return fmt.Sprintf("%s and %d more", strings.Join(names[:maxListed], ", "), len(names)-maxListed)
+}
@@ -0,0 +1,181 @@
+package projectinfo
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+}
+
+func TestAnalyzeGo(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"go.mod": `module example.com/app
+
+go 1.25
+
+require (
+	github.com/stretchr/testify v1.11.1
+	golang.org/x/sys v0.40.0 // indirect
+)
+
+tool golang.org/x/tools/cmd/stringer
+`,
+		".golangci.yml":            "version: 2\n",
+		"main.go":                  "package main\n",
+		"db/sqlite_cgo.go":         "//go:build cgo && sqlite_fts5\n\npackage db\n",
+		"e2e/e2e_test.go":          "//go:build integration || (linux && !windows)\n\npackage e2e\n",
+		"old/old.go":               "//go:build go1.21\n\npackage old\n",
+		"testdata/ignored.go":      "//go:build fixture\n\npackage testdata\n",
+		"vendor/dep/dep.go":        "//go:build vendored\n\npackage dep\n",
+		"after/after.go":           "package after\n\n//go:build notatag\n",
+		"cmd/tool/tool_windows.go": "package main\n",
+	})
+
+	r := Analyze(dir)
+	require.NotNil(t, r.Go)
+	require.Nil(t, r.Node)
+	require.Nil(t, r.Make)
+	require.Equal(t, "example.com/app", r.Go.Path)
+	require.Equal(t, "1.25", r.Go.GoVersion)
+	require.Equal(t, []string{"github.com/stretchr/testify"}, r.Go.Deps)
+	require.Equal(t, []string{"golang.org/x/tools/cmd/stringer"}, r.Go.Tools)
+	require.Equal(t, []string{"integration", "sqlite_fts5"}, r.Go.BuildTags)
+	require.Equal(t, []Command{
+		{Kind: KindBuild, Command: "go build ./...", Source: "go.mod"},
+		{Kind: KindTest, Command: "go test ./...", Source: "go.mod"},
+		{Kind: KindLint, Command: "go vet ./...", Source: "go.mod"},
+		{Kind: KindLint, Command: "golangci-lint run", Source: ".golangci.yml"},
+	}, r.Commands)
+}
+
+func TestAnalyzeNode(t *testing.T) {
+	t.Parallel()
+
+	t.Run("lock file", func(t *testing.T) {
+		t.Parallel()
+		dir := t.TempDir()
+		writeFiles(t, dir, map[string]string{
+			"package.json": `{
+  "name": "web",
+  "scripts": {
+    "build": "vite build",
+    "pretest": "node setup.js",
+    "test": "vitest",
+    "lint": "eslint .",
+    "dev": "vite",
+    "test:e2e": "playwright test"
+  }
+}`,
+			"pnpm-lock.yaml": "",
+		})
+
+		r := Analyze(dir)
+		require.NotNil(t, r.Node)
+		require.Equal(t, "web", r.Node.Name)
+		require.Equal(t, "pnpm", r.Node.PackageManager)
+		require.Equal(t, []Command{
+			{Kind: KindBuild, Command: "pnpm run build", Source: "package.json", Detail: "vite build"},
+			{Kind: KindOther, Command: "pnpm run dev", Source: "package.json", Detail: "vite"},
+			{Kind: KindLint, Command: "pnpm run lint", Source: "package.json", Detail: "eslint ."},
+			{Kind: KindTest, Command: "pnpm run test", Source: "package.json", Detail: "vitest"},
+			{Kind: KindTest, Command: "pnpm run test:e2e", Source: "package.json", Detail: "playwright test"},
+		}, r.Commands)
+	})
+
+	t.Run("package manager field", func(t *testing.T) {
+		t.Parallel()
+		dir := t.TempDir()
+		writeFiles(t, dir, map[string]string{
+			"package.json":      `{"packageManager": "yarn@4.1.0", "scripts": {"test": "jest"}}`,
+			"package-lock.json": "{}",
+		})
+
+		r := Analyze(dir)
+		require.Equal(t, "yarn", r.Node.PackageManager)
+		require.Equal(t, "yarn run test", r.Commands[0].Command)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		t.Parallel()
+		dir := t.TempDir()
+		writeFiles(t, dir, map[string]string{"package.json": "{"})
+		require.True(t, Analyze(dir).Empty())
+	})
+}
+
+func TestAnalyzeMakefile(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"Makefile": `GO ?= go
+VERSION := $(shell git describe)
+SHELL ::= /bin/sh
+
+.PHONY: build test lint
+
+build:
+	@$(GO) build -o bin/app ./cmd/app
+
+test: build
+	$(GO) test ./...
+	-rm -f coverage.out
+
+lint fmt:
+	golangci-lint run
+
+%.o: %.c
+	cc -c $<
+
+release:: build
+	goreleaser release
+`,
+	})
+
+	r := Analyze(dir)
+	require.NotNil(t, r.Make)
+	require.Equal(t, "Makefile", r.Make.Name)
+	require.Equal(t, []Command{
+		{Kind: KindBuild, Command: "make build", Source: "Makefile", Detail: "$(GO) build -o bin/app ./cmd/app"},
+		{Kind: KindTest, Command: "make test", Source: "Makefile"},
+		{Kind: KindLint, Command: "make lint", Source: "Makefile"},
+		{Kind: KindLint, Command: "make fmt", Source: "Makefile"},
+		{Kind: KindOther, Command: "make release", Source: "Makefile", Detail: "goreleaser release"},
+	}, r.Commands)
+}
+
+func TestMarkdown(t *testing.T) {
+	t.Parallel()
+
+	require.Empty(t, Analyze(t.TempDir()).Markdown())
+
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"go.mod":   "module example.com/app\n\ngo 1.25\n",
+		"Makefile": "test:\n\tgo test -race ./...\n",
+	})
+	require.Equal(t, "## How to build/test/lint\n"+
+		"\n### Build\n\n"+
+		"- `go build ./...` (go.mod)\n"+
+		"\n### Test\n\n"+
+		"- `make test` → `go test -race ./...` (Makefile)\n"+
+		"- `go test ./...` (go.mod)\n"+
+		"\n### Lint\n\n"+
+		"- `go vet ./...` (go.mod)\n"+
+		"\n### Project facts\n\n"+
+		"- Go module `example.com/app` (go 1.25)\n",
+		Analyze(dir).Markdown())
+}
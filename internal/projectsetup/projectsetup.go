@@ -0,0 +1,192 @@
+// Package projectsetup 检查工作区并为项目建议合适的默认配置，例如为私有代码禁用
+// sourcegraph、为检测到的语言启用 LSP、添加上下文文件。选中的建议写入项目配置。
+package projectsetup
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/purpose168/crush-cn/internal/config"
+	"github.com/purpose168/crush-cn/internal/lsp"
+)
+
+// FlagFilename 是数据目录中标记项目设置已完成的文件名。
+const FlagFilename = "project_setup"
+
+// gitRemoteTimeout 是读取 git 远程地址的最长时间。
+const gitRemoteTimeout = 2 * time.Second
+
+// Kind 是建议的类型。
+type Kind uint8
+
+const (
+	// DisableTool 建议禁用内置工具。
+	DisableTool Kind = iota
+	// EnableLSP 建议为检测到的语言启用 LSP 服务器。
+	EnableLSP
+	// ContextPath 建议将文件添加到上下文路径。
+	ContextPath
+)
+
+// Suggestion 是一条建议的项目配置。
+type Suggestion struct {
+	Kind   Kind
+	Name   string // 工具名称、LSP 名称或上下文路径
+	Reason string // 向用户说明的建议原因
+	// LSP 是 EnableLSP 建议写入的服务器配置
+	LSP config.LSPConfig
+}
+
+// publicHosts 是 sourcegraph 索引的公共代码托管平台。
+var publicHosts = []string{"github.com", "gitlab.com", "bitbucket.org", "codeberg.org"}
+
+// contextCandidates 是常见的项目说明文件，不在默认上下文路径中。
+var contextCandidates = []string{
+	"CONTRIBUTING.md",
+	"ARCHITECTURE.md",
+	"docs/ARCHITECTURE.md",
+	"DEVELOPMENT.md",
+	"CONVENTIONS.md",
+	".windsurfrules",
+	".clinerules",
+}
+
+// Detect 检查工作目录并返回建议的项目配置，已生效的配置不会再被建议。
+func Detect(cfg *config.Config) []Suggestion {
+	var suggestions []Suggestion
+	if s, ok := detectSourcegraph(cfg); ok {
+		suggestions = append(suggestions, s)
+	}
+	for _, s := range lsp.DetectServers(cfg.WorkingDir(), cfg.LSP) {
+		// 未安装的服务器交给 LSP 配置对话框处理
+		if !s.Installed {
+			continue
+		}
+		suggestions = append(suggestions, Suggestion{
+			Kind:   EnableLSP,
+			Name:   s.Name,
+			Reason: fmt.Sprintf("检测到 %s 项目 (%s)", s.Language, s.Marker),
+			LSP:    s.Config(),
+		})
+	}
+	for _, path := range detectContextPaths(cfg.WorkingDir(), cfg.Options.ContextPaths) {
+		suggestions = append(suggestions, Suggestion{
+			Kind:   ContextPath,
+			Name:   path,
+			Reason: "项目说明文件",
+		})
+	}
+	return suggestions
+}
+
+// detectSourcegraph 在代码不托管于公共平台时建议禁用 sourcegraph，因为它只能搜索公共代码。
+func detectSourcegraph(cfg *config.Config) (Suggestion, bool) {
+	if slices.Contains(cfg.Options.DisabledTools, "sourcegraph") {
+		return Suggestion{}, false
+	}
+	remote := gitRemote(cfg.WorkingDir())
+	if isPublicRemote(remote) {
+		return Suggestion{}, false
+	}
+	reason := "没有 git 远程仓库，sourcegraph 只能搜索公共代码"
+	if remote != "" {
+		reason = "远程仓库不在公共托管平台，sourcegraph 只能搜索公共代码"
+	}
+	return Suggestion{Kind: DisableTool, Name: "sourcegraph", Reason: reason}, true
+}
+
+// gitRemote 返回 origin 远程地址，不是 git 仓库或没有 origin 时返回空字符串。
+func gitRemote(dir string) string {
+	ctx, cancel := context.WithTimeout(context.Background(), gitRemoteTimeout)
+	defer cancel()
+	out, err := exec.CommandContext(ctx, "git", "-C", dir, "remote", "get-url", "origin").Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// isPublicRemote 报告 git 远程地址是否指向公共代码托管平台。
+func isPublicRemote(remote string) bool {
+	if remote == "" {
+		return false
+	}
+	var host string
+	if u, err := url.Parse(remote); err == nil && u.Host != "" {
+		host = u.Hostname()
+	} else if _, rest, ok := strings.Cut(remote, "@"); ok {
+		// scp 风格的地址：git@github.com:owner/repo.git
+		host, _, _ = strings.Cut(rest, ":")
+	}
+	return slices.Contains(publicHosts, strings.ToLower(host))
+}
+
+// detectContextPaths 返回工作目录中存在但尚未加入上下文路径的项目说明文件。
+func detectContextPaths(dir string, contextPaths []string) []string {
+	var paths []string
+	for _, path := range contextCandidates {
+		if slices.Contains(contextPaths, path) {
+			continue
+		}
+		if info, err := os.Stat(filepath.Join(dir, path)); err != nil || info.IsDir() {
+			continue
+		}
+		paths = append(paths, path)
+	}
+	return paths
+}
+
+// Apply 将建议写入项目配置并更新内存中的配置。调用方负责重建智能体工具和配置 LSP 管理器。
+func Apply(cfg *config.Config, suggestions []Suggestion) error {
+	for _, s := range suggestions {
+		switch s.Kind {
+		case DisableTool:
+			if err := cfg.AddProjectConfigValue("options.disabled_tools", s.Name); err != nil {
+				return err
+			}
+			if !slices.Contains(cfg.Options.DisabledTools, s.Name) {
+				cfg.Options.DisabledTools = append(cfg.Options.DisabledTools, s.Name)
+			}
+		case EnableLSP:
+			if err := cfg.SetProjectConfigField("lsp."+s.Name, s.LSP); err != nil {
+				return err
+			}
+			if cfg.LSP == nil {
+				cfg.LSP = config.LSPs{}
+			}
+			cfg.LSP[s.Name] = s.LSP
+		case ContextPath:
+			if err := cfg.AddProjectConfigValue("options.context_paths", s.Name); err != nil {
+				return err
+			}
+			if !slices.Contains(cfg.Options.ContextPaths, s.Name) {
+				cfg.Options.ContextPaths = append(cfg.Options.ContextPaths, s.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// NeedsSetup 报告项目是否尚未完成一次性设置。
+func NeedsSetup(cfg *config.Config) bool {
+	_, err := os.Stat(filepath.Join(cfg.Options.DataDirectory, FlagFilename))
+	return os.IsNotExist(err)
+}
+
+// MarkDone 标记项目已完成一次性设置，之后启动时不再提示。
+func MarkDone(cfg *config.Config) error {
+	if err := os.MkdirAll(cfg.Options.DataDirectory, 0o755); err != nil {
+		return fmt.Errorf("创建数据目录失败: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(cfg.Options.DataDirectory, FlagFilename), nil, 0o644); err != nil {
+		return fmt.Errorf("创建项目设置标志文件失败: %w", err)
+	}
+	return nil
+}
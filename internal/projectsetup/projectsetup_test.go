@@ -0,0 +1,47 @@
+package projectsetup
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/purpose168/crush-cn/internal/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsPublicRemote(t *testing.T) {
+	t.Parallel()
+
+	for remote, want := range map[string]bool{
+		"":                                       false,
+		"https://github.com/purpose168/crush-cn": true,
+		"git@github.com:purpose168/crush-cn.git": true,
+		"ssh://git@GitLab.com/group/project.git": true,
+		"https://git.example.com/team/repo.git":  false,
+		"git@git.internal:team/repo.git":         false,
+		"/srv/git/repo.git":                      false,
+	} {
+		require.Equal(t, want, isPublicRemote(remote), remote)
+	}
+}
+
+func TestDetectContextPaths(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "docs"), 0o755))
+	for _, name := range []string{"CONTRIBUTING.md", "docs/ARCHITECTURE.md", ".clinerules", "README.md"} {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), nil, 0o644))
+	}
+
+	require.Equal(t, []string{"CONTRIBUTING.md", "docs/ARCHITECTURE.md"}, detectContextPaths(dir, []string{".clinerules"}))
+}
+
+func TestMarkDone(t *testing.T) {
+	t.Parallel()
+
+	cfg := &config.Config{Options: &config.Options{DataDirectory: filepath.Join(t.TempDir(), ".crush")}}
+	require.True(t, NeedsSetup(cfg))
+	require.NoError(t, MarkDone(cfg))
+	require.False(t, NeedsSetup(cfg))
+}
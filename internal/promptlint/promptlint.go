@@ -0,0 +1,149 @@
+// Package promptlint 在发送前检查提示中常见的含糊写法，例如没有引用任何文件、
+// 范围不明确或在新会话中使用指代词。检查只基于启发式规则，结果仅作为提醒。
+package promptlint
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// Kind 是提示问题的类型。
+type Kind string
+
+const (
+	TooShort          Kind = "too_short"          // 提示过短
+	VagueScope        Kind = "vague_scope"        // 范围不明确
+	NoReference       Kind = "no_reference"       // 要求修改代码却没有引用具体位置
+	DanglingReference Kind = "dangling_reference" // 新会话中指代不存在的上下文
+)
+
+// minLength 是不被视为过短的最少字符数（不含空白）。
+const minLength = 8
+
+// Issue 是提示中发现的一个问题。
+type Issue struct {
+	Kind    Kind
+	Message string
+}
+
+// Context 描述提示所在的会话，影响部分规则的判断。
+type Context struct {
+	// HasHistory 表示会话中已有消息，指代词可能引用之前的内容。
+	HasHistory bool
+	// HasAttachments 表示提示带有附件，附件本身即是引用。
+	HasAttachments bool
+}
+
+var (
+	// referencePattern 匹配文件路径、反引号代码、@ 引用、驼峰或下划线标识符、函数调用和行号。
+	referencePattern = regexp.MustCompile("`[^`]+`" +
+		`|@\S+` +
+		`|\w+/\w+` +
+		`|\b\w+\.[A-Za-z][A-Za-z0-9]{0,5}\b` +
+		`|\b[a-z]+[A-Z]\w*` +
+		`|\b[A-Z][a-z0-9]+[A-Z]\w*` +
+		`|\b\w+_\w+\b` +
+		`|\w+\(\)` +
+		`|:\d+\b`)
+
+	// actionTerms 是要求修改代码的动词，出现时需要说明修改的位置。
+	actionTerms = []string{
+		"fix", "add", "change", "refactor", "implement", "update", "improve", "optimize", "rewrite", "remove", "clean up",
+		"修复", "添加", "修改", "重构", "实现", "更新", "优化", "改进", "重写", "删除", "清理", "改一下",
+	}
+
+	// vagueScopeTerms 是没有划定范围的说法。
+	vagueScopeTerms = []string{
+		"everything", "everywhere", "somewhere", "something", "stuff", "all the code", "whole project", "whole codebase", "etc",
+		"所有代码", "全部代码", "整个项目", "所有地方", "到处", "某个地方", "某些地方", "之类的", "等等",
+	}
+
+	// danglingTerms 是开头指代之前内容的词，新会话中无从解析。
+	danglingTerms = []string{
+		"it", "this", "that", "these", "those", "same", "again", "also",
+		"它", "这个", "那个", "这些", "那些", "上面", "刚才", "同样", "再",
+	}
+)
+
+// Lint 检查提示并返回发现的问题，没有问题时返回 nil。
+func Lint(prompt string, ctx Context) []Issue {
+	prompt = strings.TrimSpace(prompt)
+	if prompt == "" {
+		return nil
+	}
+	lower := strings.ToLower(prompt)
+	hasReference := ctx.HasAttachments || referencePattern.MatchString(prompt)
+
+	var issues []Issue
+	if utf8.RuneCountInString(strings.Join(strings.Fields(prompt), "")) < minLength && !hasReference {
+		issues = append(issues, Issue{Kind: TooShort, Message: "提示过短，代理可能无法理解你的意图"})
+	}
+	if term, ok := findTerm(lower, vagueScopeTerms, containsTerm); ok {
+		issues = append(issues, Issue{
+			Kind:    VagueScope,
+			Message: fmt.Sprintf("范围不明确：“%s”没有说明涉及哪些文件或模块", term),
+		})
+	}
+	if !hasReference {
+		if _, ok := findTerm(lower, actionTerms, containsTerm); ok {
+			issues = append(issues, Issue{Kind: NoReference, Message: "要求修改代码，但没有引用具体的文件、函数或错误信息"})
+		}
+	}
+	if !ctx.HasHistory {
+		if term, ok := findTerm(lower, danglingTerms, hasPrefixTerm); ok {
+			issues = append(issues, Issue{
+				Kind:    DanglingReference,
+				Message: fmt.Sprintf("“%s”指代的内容不在当前会话中", term),
+			})
+		}
+	}
+	return issues
+}
+
+// findTerm 返回 text 中第一个满足 match 的词。
+func findTerm(text string, terms []string, match func(text, term string) bool) (string, bool) {
+	for _, term := range terms {
+		if match(text, term) {
+			return term, true
+		}
+	}
+	return "", false
+}
+
+// containsTerm 报告 text 是否包含 term。英文词按整词匹配，中文词按子串匹配。
+func containsTerm(text, term string) bool {
+	for i := 0; ; {
+		idx := strings.Index(text[i:], term)
+		if idx < 0 {
+			return false
+		}
+		start, end := i+idx, i+idx+len(term)
+		if !isASCIIWord(term) || (!wordByteAt(text, start-1) && !wordByteAt(text, end)) {
+			return true
+		}
+		i = end
+	}
+}
+
+// hasPrefixTerm 报告 text 是否以 term 开头。英文词需要后接非单词字符。
+func hasPrefixTerm(text, term string) bool {
+	if !strings.HasPrefix(text, term) {
+		return false
+	}
+	return !isASCIIWord(term) || !wordByteAt(text, len(term))
+}
+
+func isASCIIWord(s string) bool {
+	return s != "" && s[0] < utf8.RuneSelf
+}
+
+// wordByteAt 报告 text[i] 是否为 ASCII 字母、数字或下划线，越界时返回 false。
+func wordByteAt(text string, i int) bool {
+	if i < 0 || i >= len(text) {
+		return false
+	}
+	c := text[i]
+	return c == '_' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
@@ -0,0 +1,55 @@
+package promptlint
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func kinds(issues []Issue) []Kind {
+	var result []Kind
+	for _, issue := range issues {
+		result = append(result, issue.Kind)
+	}
+	return result
+}
+
+func TestLint(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		prompt string
+		ctx    Context
+		want   []Kind
+	}{
+		{"empty", "  ", Context{}, nil},
+		{"specific", "Fix the nil pointer in internal/agent/agent.go when the session has no messages", Context{}, nil},
+		{"identifier", "重构 `loadPinnedFiles`，跳过二进制文件", Context{}, nil},
+		{"question", "What does this project do and how is it structured?", Context{HasHistory: true}, nil},
+		{"too short", "fix it", Context{HasHistory: true}, []Kind{TooShort, NoReference}},
+		{"vague scope", "Clean up everything and improve the code quality", Context{}, []Kind{VagueScope, NoReference}},
+		{"vague chinese", "优化一下整个项目的性能", Context{}, []Kind{VagueScope, NoReference}},
+		{"dangling", "It still fails when I run the tests from the root", Context{}, []Kind{DanglingReference}},
+		{"dangling with history", "It still fails when I run the tests from the root", Context{HasHistory: true}, nil},
+		{"dangling chinese", "这个还是不对，请修复", Context{}, []Kind{NoReference, DanglingReference}},
+		{"attachment", "fix this", Context{HasAttachments: true, HasHistory: true}, nil},
+		{"whole word", "Add an etcd client to main.go", Context{}, nil},
+		{"prefix word", "Items in the list render twice in the sidebar", Context{}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			require.Equal(t, tt.want, kinds(Lint(tt.prompt, tt.ctx)))
+		})
+	}
+}
+
+func TestLintMessages(t *testing.T) {
+	t.Parallel()
+
+	issues := Lint("Those tests fail everywhere", Context{})
+	require.Len(t, issues, 2)
+	require.Contains(t, issues[0].Message, "everywhere")
+	require.Contains(t, issues[1].Message, "those")
+}
@@ -0,0 +1,117 @@
+// Package ratelimit 为发往模型提供者的请求提供客户端限速，限制每分钟的请求数和同时
+// 进行的请求数，避免在限制严格的端点上触发封禁。同一提供者的所有代理共享一个限速器。
+package ratelimit
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// Limiter 限制请求的速率和并发数。nil Limiter 不做任何限制。
+type Limiter struct {
+	rpm        int
+	concurrent int
+	rate       *rate.Limiter
+	slots      chan struct{}
+}
+
+// New 创建每分钟最多 rpm 个请求、同时最多 concurrent 个请求的限速器。
+// 小于等于 0 的值表示不限制该项，两项都不限制时返回 nil。
+func New(rpm, concurrent int) *Limiter {
+	if rpm <= 0 && concurrent <= 0 {
+		return nil
+	}
+	l := &Limiter{rpm: rpm, concurrent: concurrent}
+	if rpm > 0 {
+		// 突发量为 1，使请求在一分钟内均匀分布
+		l.rate = rate.NewLimiter(rate.Every(time.Minute/time.Duration(rpm)), 1)
+	}
+	if concurrent > 0 {
+		l.slots = make(chan struct{}, concurrent)
+	}
+	return l
+}
+
+// Matches 判断限速器是否使用给定的限制创建。
+func (l *Limiter) Matches(rpm, concurrent int) bool {
+	if l == nil {
+		return rpm <= 0 && concurrent <= 0
+	}
+	return l.rpm == max(rpm, 0) && l.concurrent == max(concurrent, 0)
+}
+
+// Acquire 等待直到可以发出新的请求，返回请求结束时必须调用的释放函数。
+// ctx 被取消时返回其错误。
+func (l *Limiter) Acquire(ctx context.Context) (release func(), err error) {
+	if l == nil {
+		return func() {}, nil
+	}
+	release = func() {}
+	if l.slots != nil {
+		select {
+		case l.slots <- struct{}{}:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		release = sync.OnceFunc(func() { <-l.slots })
+	}
+	if l.rate != nil {
+		if err := l.rate.Wait(ctx); err != nil {
+			release()
+			return nil, err
+		}
+	}
+	return release, nil
+}
+
+// Transport 是对请求限速的 [http.RoundTripper]。并发名额一直占用到响应体被关闭，
+// 因此流式响应在整个流结束前都计入并发数。
+type Transport struct {
+	// Limiter 限制请求，为 nil 时所有请求直接交给 Base。
+	Limiter *Limiter
+	// Base 执行实际的请求，为 nil 时使用 [http.DefaultTransport]。
+	Base http.RoundTripper
+}
+
+// RoundTrip 实现 [http.RoundTripper]。
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if t.Limiter == nil {
+		return base.RoundTrip(req)
+	}
+
+	release, err := t.Limiter.Acquire(req.Context())
+	if err != nil {
+		if req.Body != nil {
+			req.Body.Close()
+		}
+		return nil, err
+	}
+	resp, err := base.RoundTrip(req)
+	if err != nil {
+		release()
+		return resp, err
+	}
+	resp.Body = &releaseBody{ReadCloser: resp.Body, release: release}
+	return resp, nil
+}
+
+// releaseBody 在响应体关闭时释放并发名额。
+type releaseBody struct {
+	io.ReadCloser
+	release func()
+}
+
+func (b *releaseBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.release()
+	return err
+}
@@ -0,0 +1,108 @@
+package ratelimit
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+
+	require.Nil(t, New(0, 0))
+	require.Nil(t, New(-1, 0))
+	require.True(t, New(0, 0).Matches(0, -1))
+
+	l := New(60, 2)
+	require.True(t, l.Matches(60, 2))
+	require.False(t, l.Matches(60, 3))
+	require.False(t, l.Matches(0, 0))
+	require.True(t, New(0, 2).Matches(-1, 2))
+}
+
+func TestLimiterConcurrency(t *testing.T) {
+	t.Parallel()
+
+	l := New(0, 1)
+	release, err := l.Acquire(t.Context())
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(t.Context(), 20*time.Millisecond)
+	defer cancel()
+	_, err = l.Acquire(ctx)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+
+	release()
+	release()
+	release, err = l.Acquire(t.Context())
+	require.NoError(t, err)
+	release()
+}
+
+func TestLimiterRate(t *testing.T) {
+	t.Parallel()
+
+	l := New(1, 0)
+	release, err := l.Acquire(t.Context())
+	require.NoError(t, err)
+	release()
+
+	ctx, cancel := context.WithTimeout(t.Context(), 50*time.Millisecond)
+	defer cancel()
+	_, err = l.Acquire(ctx)
+	require.Error(t, err)
+}
+
+func TestTransportHoldsSlotUntilBodyClosed(t *testing.T) {
+	t.Parallel()
+
+	var inFlight, peak atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		if n > peak.Load() {
+			peak.Store(n)
+		}
+		time.Sleep(10 * time.Millisecond)
+		w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	client := &http.Client{Transport: &Transport{Limiter: New(0, 1)}}
+	resp, err := client.Get(srv.URL)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(t.Context(), 50*time.Millisecond)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL, nil)
+	require.NoError(t, err)
+	_, err = client.Do(req)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.Equal(t, "ok", string(body))
+	require.NoError(t, resp.Body.Close())
+
+	done := make(chan struct{})
+	for range 3 {
+		go func() {
+			defer func() { done <- struct{}{} }()
+			resp, err := client.Get(srv.URL)
+			if err == nil {
+				io.Copy(io.Discard, resp.Body)
+				resp.Body.Close()
+			}
+		}()
+	}
+	for range 3 {
+		<-done
+	}
+	require.Equal(t, int32(1), peak.Load())
+}
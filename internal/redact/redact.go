@@ -0,0 +1,123 @@
+// Package redact 在内容发送给模型前扫描并替换其中的敏感信息，
+// 例如云服务密钥、私钥和访问令牌，并按会话记录脱敏情况。
+package redact
+
+import (
+	"cmp"
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"sync"
+)
+
+// Rule 是一条脱敏规则。
+type Rule struct {
+	Name    string
+	Pattern *regexp.Regexp
+}
+
+// builtinRules 是默认启用的规则，尽量只匹配格式明确的凭据以避免误报。
+var builtinRules = []Rule{
+	{"private_key", regexp.MustCompile(`-----BEGIN [A-Z0-9 ]*PRIVATE KEY( BLOCK)?-----[\s\S]*?-----END [A-Z0-9 ]*PRIVATE KEY( BLOCK)?-----`)},
+	{"aws_access_key", regexp.MustCompile(`\b(AKIA|ASIA)[0-9A-Z]{16}\b`)},
+	{"aws_secret_key", regexp.MustCompile(`(?i)aws_?secret_?access_?key["']?\s*[:=]\s*["']?[A-Za-z0-9/+=]{40}\b`)},
+	{"github_token", regexp.MustCompile(`\b(gh[pousr]_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{22,})\b`)},
+	{"gitlab_token", regexp.MustCompile(`\bglpat-[A-Za-z0-9_-]{20,}\b`)},
+	{"slack_token", regexp.MustCompile(`\bxox[abposr]-[A-Za-z0-9-]{10,}\b`)},
+	{"anthropic_key", regexp.MustCompile(`\bsk-ant-[A-Za-z0-9_-]{20,}`)},
+	{"openai_key", regexp.MustCompile(`\bsk-(proj-)?[A-Za-z0-9_-]{32,}`)},
+	{"google_api_key", regexp.MustCompile(`\bAIza[0-9A-Za-z_-]{35}\b`)},
+	{"stripe_key", regexp.MustCompile(`\b[rs]k_live_[A-Za-z0-9]{20,}\b`)},
+	{"jwt", regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{10,}\.eyJ[A-Za-z0-9_-]{10,}\.[A-Za-z0-9_-]{10,}`)},
+}
+
+// Placeholder 返回替换规则匹配内容的占位符。
+func Placeholder(rule string) string {
+	return "[REDACTED:" + rule + "]"
+}
+
+// Finding 统计一个来源中某条规则的匹配次数。
+type Finding struct {
+	Rule   string `json:"rule"`
+	Source string `json:"source"`
+	Count  int    `json:"count"`
+}
+
+// Redactor 按规则替换敏感信息，并按会话累计脱敏报告。
+type Redactor struct {
+	rules []Rule
+
+	mu      sync.Mutex
+	reports map[string]map[[2]string]int
+}
+
+// New 创建使用内置规则和 extra 中附加规则的 [Redactor]。extra 的键是规则名，值是正则表达式。
+func New(extra map[string]string) (*Redactor, error) {
+	rules := slices.Clone(builtinRules)
+	for _, name := range slices.Sorted(maps.Keys(extra)) {
+		re, err := regexp.Compile(extra[name])
+		if err != nil {
+			return nil, fmt.Errorf("脱敏规则 %q 无效: %w", name, err)
+		}
+		rules = append(rules, Rule{Name: name, Pattern: re})
+	}
+	return &Redactor{rules: rules, reports: make(map[string]map[[2]string]int)}, nil
+}
+
+// Redact 替换 s 中所有匹配规则的内容，返回替换后的字符串和每条规则的匹配次数。
+// nil Redactor 原样返回 s。
+func (r *Redactor) Redact(s string) (string, map[string]int) {
+	if r == nil || s == "" {
+		return s, nil
+	}
+	var counts map[string]int
+	for _, rule := range r.rules {
+		n := 0
+		s = rule.Pattern.ReplaceAllStringFunc(s, func(string) string {
+			n++
+			return Placeholder(rule.Name)
+		})
+		if n > 0 {
+			if counts == nil {
+				counts = make(map[string]int)
+			}
+			counts[rule.Name] += n
+		}
+	}
+	return s, counts
+}
+
+// Record 将来源 source 中的匹配次数计入会话的报告。
+func (r *Redactor) Record(sessionID, source string, counts map[string]int) {
+	if r == nil || len(counts) == 0 {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	report, ok := r.reports[sessionID]
+	if !ok {
+		report = make(map[[2]string]int)
+		r.reports[sessionID] = report
+	}
+	for rule, n := range counts {
+		report[[2]string{rule, source}] += n
+	}
+}
+
+// Report 返回会话的脱敏报告，按规则和来源排序。
+func (r *Redactor) Report(sessionID string) []Finding {
+	if r == nil {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	findings := make([]Finding, 0, len(r.reports[sessionID]))
+	for key, n := range r.reports[sessionID] {
+		findings = append(findings, Finding{Rule: key[0], Source: key[1], Count: n})
+	}
+	slices.SortFunc(findings, func(a, b Finding) int {
+		return cmp.Or(cmp.Compare(a.Rule, b.Rule), cmp.Compare(a.Source, b.Source))
+	})
+	return findings
+}
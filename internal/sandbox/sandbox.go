@@ -0,0 +1,199 @@
+// Package sandbox 在隔离环境中执行 bash 工具的命令，保护主机不受失控命令的影响。
+//
+// 支持的引擎：
+//   - docker、podman：每条命令在一个新容器中执行，工作区以相同路径挂载为可写目录
+//   - bubblewrap：通过 bwrap 在新的命名空间中执行，主机文件系统只读，只有工作区可写
+//
+// 默认禁用网络。每条命令都在独立的 shell 中执行，cd 和导出的环境变量不会保留到下一条命令。
+package sandbox
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"time"
+)
+
+// 支持的沙箱引擎
+const (
+	EngineDocker     = "docker"
+	EnginePodman     = "podman"
+	EngineBubblewrap = "bubblewrap"
+)
+
+// Engines 是所有支持的沙箱引擎
+var Engines = []string{EngineDocker, EnginePodman, EngineBubblewrap}
+
+// Options 是沙箱的设置
+type Options struct {
+	// Engine 是沙箱引擎，见 [Engines]
+	Engine string
+	// Image 是 docker 和 podman 使用的容器镜像
+	Image string
+	// Network 为 true 时允许沙箱访问网络
+	Network bool
+	// Workspace 是挂载到沙箱中的工作区目录，沙箱内外路径相同
+	Workspace string
+}
+
+// Runner 在沙箱中执行 shell 命令，实现 [shell.Runner] 接口。
+type Runner struct {
+	opts    Options
+	program string
+	uid     int
+	gid     int
+}
+
+// New 创建一个沙箱执行器。引擎程序在执行命令时才查找，找不到时命令失败而不会回退到主机上执行。
+func New(opts Options) *Runner {
+	program := opts.Engine
+	if opts.Engine == EngineBubblewrap {
+		program = "bwrap"
+	}
+	return &Runner{
+		opts:    opts,
+		program: program,
+		uid:     os.Getuid(),
+		gid:     os.Getgid(),
+	}
+}
+
+// Engine 返回沙箱引擎
+func (r *Runner) Engine() string {
+	return r.opts.Engine
+}
+
+// Network 报告沙箱是否允许访问网络
+func (r *Runner) Network() bool {
+	return r.opts.Network
+}
+
+// Run 在沙箱的 dir 目录中执行命令，dir 为空时使用工作区目录，不在工作区中时返回错误。
+// env 中 KEY=VALUE 形式的变量会设置到沙箱中。命令以非零状态退出时返回 [*exec.ExitError]。
+func (r *Runner) Run(ctx context.Context, dir string, env []string, command string, stdin io.Reader, stdout, stderr io.Writer) error {
+	if !slices.Contains(Engines, r.opts.Engine) {
+		return fmt.Errorf("不支持的沙箱引擎: %q", r.opts.Engine)
+	}
+	program, err := exec.LookPath(r.program)
+	if err != nil {
+		return fmt.Errorf("沙箱引擎 %s 不可用: %w", r.opts.Engine, err)
+	}
+	if dir == "" {
+		dir = r.opts.Workspace
+	}
+	if !r.inWorkspace(dir) {
+		return fmt.Errorf("工作目录 %s 不在沙箱工作区 %s 中，沙箱中只能访问工作区", dir, r.opts.Workspace)
+	}
+
+	var args []string
+	var name string
+	if r.opts.Engine == EngineBubblewrap {
+		args = r.bubblewrapArgs(dir, env, command)
+	} else {
+		name = containerName()
+		args = r.containerArgs(name, dir, env, command, stdin != nil)
+	}
+
+	cmd := exec.CommandContext(ctx, program, args...)
+	cmd.Stdin = stdin
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	if name != "" {
+		// 终止 docker run 客户端不会停止容器，取消时先停止容器
+		cmd.Cancel = func() error {
+			_ = exec.Command(program, "kill", name).Run()
+			return cmd.Process.Kill()
+		}
+	}
+	// 命令启动的后台进程可能继续持有输出管道，取消后不再无限等待。
+	cmd.WaitDelay = time.Second
+	return cmd.Run()
+}
+
+// inWorkspace 报告 dir 是否是工作区或工作区中的目录
+func (r *Runner) inWorkspace(dir string) bool {
+	rel, err := filepath.Rel(r.opts.Workspace, dir)
+	if err != nil || !filepath.IsAbs(dir) {
+		return false
+	}
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// containerArgs 返回在新容器中执行命令的 docker 或 podman 参数
+func (r *Runner) containerArgs(name, dir string, env []string, command string, interactive bool) []string {
+	args := []string{"run", "--rm", "--name", name, "--security-opt", "no-new-privileges"}
+	if interactive {
+		args = append(args, "-i")
+	}
+	if !r.opts.Network {
+		args = append(args, "--network", "none")
+	}
+	// 以当前用户运行，避免在工作区中留下 root 所有的文件
+	if r.uid >= 0 {
+		if r.opts.Engine == EnginePodman {
+			args = append(args, "--userns", "keep-id")
+		} else {
+			args = append(args, "--user", fmt.Sprintf("%d:%d", r.uid, r.gid))
+		}
+	}
+	// 只传递带值的变量，-e KEY 会读取主机上的同名变量
+	for _, kv := range env {
+		if key, _, ok := strings.Cut(kv, "="); ok && key != "" {
+			args = append(args, "-e", kv)
+		}
+	}
+	args = append(args,
+		"-v", r.opts.Workspace+":"+r.opts.Workspace,
+		"-w", dir,
+		r.opts.Image,
+		"sh", "-c", command,
+	)
+	return args
+}
+
+// bubblewrapArgs 返回在新命名空间中执行命令的 bwrap 参数。主机文件系统只读挂载，
+// /tmp 是空的临时目录，只有工作区可写。
+func (r *Runner) bubblewrapArgs(dir string, env []string, command string) []string {
+	args := []string{
+		"--ro-bind", "/", "/",
+		"--dev", "/dev",
+		"--proc", "/proc",
+		"--tmpfs", "/tmp",
+		"--bind", r.opts.Workspace, r.opts.Workspace,
+		"--unshare-all",
+	}
+	if r.opts.Network {
+		args = append(args, "--share-net")
+	}
+	for _, kv := range env {
+		if key, value, ok := strings.Cut(kv, "="); ok && key != "" {
+			args = append(args, "--setenv", key, value)
+		}
+	}
+	return append(args,
+		"--die-with-parent",
+		"--new-session",
+		"--chdir", dir,
+		"--", "sh", "-c", command,
+	)
+}
+
+// containerName 返回一个唯一的容器名称，用于在取消时停止容器
+func containerName() string {
+	var b [6]byte
+	_, _ = rand.Read(b[:])
+	return "crush-sandbox-" + hex.EncodeToString(b[:])
+}
+
+// Supported 报告当前平台是否支持 bubblewrap 引擎
+func Supported(engine string) bool {
+	return engine != EngineBubblewrap || runtime.GOOS == "linux"
+}
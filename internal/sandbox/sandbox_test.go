@@ -0,0 +1,117 @@
+package sandbox
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestContainerArgs(t *testing.T) {
+	t.Parallel()
+
+	r := New(Options{Engine: EngineDocker, Image: "golang:1.25", Workspace: "/src/app"})
+	r.uid, r.gid = 1000, 100
+	require.Equal(t, []string{
+		"run", "--rm", "--name", "crush-sandbox-test", "--security-opt", "no-new-privileges",
+		"--network", "none",
+		"--user", "1000:100",
+		"-e", "GOFLAGS=-mod=mod",
+		"-e", "EMPTY=",
+		"-v", "/src/app:/src/app",
+		"-w", "/src/app/pkg",
+		"golang:1.25",
+		"sh", "-c", "go test ./...",
+	}, r.containerArgs("crush-sandbox-test", "/src/app/pkg", []string{"GOFLAGS=-mod=mod", "HOME", "EMPTY="}, "go test ./...", false))
+
+	r = New(Options{Engine: EnginePodman, Image: "node:22", Network: true, Workspace: "/src/app"})
+	r.uid, r.gid = 1000, 100
+	require.Equal(t, []string{
+		"run", "--rm", "--name", "n", "--security-opt", "no-new-privileges",
+		"-i",
+		"--userns", "keep-id",
+		"-v", "/src/app:/src/app",
+		"-w", "/src/app",
+		"node:22",
+		"sh", "-c", "npm test",
+	}, r.containerArgs("n", "/src/app", nil, "npm test", true))
+}
+
+func TestBubblewrapArgs(t *testing.T) {
+	t.Parallel()
+
+	r := New(Options{Engine: EngineBubblewrap, Workspace: "/src/app"})
+	require.Equal(t, "bwrap", r.program)
+	require.Equal(t, []string{
+		"--ro-bind", "/", "/",
+		"--dev", "/dev",
+		"--proc", "/proc",
+		"--tmpfs", "/tmp",
+		"--bind", "/src/app", "/src/app",
+		"--unshare-all",
+		"--setenv", "CC", "clang",
+		"--setenv", "CFLAGS", "-O2 -g",
+		"--die-with-parent",
+		"--new-session",
+		"--chdir", "/src/app",
+		"--", "sh", "-c", "make",
+	}, r.bubblewrapArgs("/src/app", []string{"CC=clang", "CFLAGS=-O2 -g", "PATH"}, "make"))
+
+	r.opts.Network = true
+	require.Contains(t, r.bubblewrapArgs("/src/app", nil, "make"), "--share-net")
+}
+
+func TestRun(t *testing.T) {
+	t.Parallel()
+	if runtime.GOOS == "windows" {
+		t.Skip("需要 POSIX sh")
+	}
+
+	// 用执行最后一个参数的脚本代替 bwrap，并在目标目录中执行
+	program := filepath.Join(t.TempDir(), "bwrap")
+	script := "#!/bin/sh\nwhile [ \"$1\" != \"--chdir\" ]; do shift; done\ncd \"$2\" || exit 1\neval \"last=\\${$#}\"\nexec sh -c \"$last\"\n"
+	require.NoError(t, os.WriteFile(program, []byte(script), 0o755))
+	workspace := t.TempDir()
+
+	r := New(Options{Engine: EngineBubblewrap, Workspace: workspace})
+	r.program = program
+
+	var stdout bytes.Buffer
+	require.NoError(t, r.Run(t.Context(), "", nil, "pwd", nil, &stdout, nil))
+	resolved, err := filepath.EvalSymlinks(workspace)
+	require.NoError(t, err)
+	require.Contains(t, []string{workspace, resolved}, strings.TrimSpace(stdout.String()))
+
+	err = r.Run(t.Context(), "", nil, "exit 3", nil, nil, nil)
+	var exitErr *exec.ExitError
+	require.True(t, errors.As(err, &exitErr))
+	require.Equal(t, 3, exitErr.ExitCode())
+
+	// 工作区之外的目录在启动沙箱前被拒绝
+	for _, dir := range []string{filepath.Dir(workspace), workspace + "-other", "relative"} {
+		err = r.Run(t.Context(), dir, nil, "pwd", nil, nil, nil)
+		require.ErrorContains(t, err, "不在沙箱工作区", dir)
+	}
+	sub := filepath.Join(workspace, "sub")
+	require.NoError(t, os.Mkdir(sub, 0o755))
+	require.NoError(t, r.Run(t.Context(), sub, nil, "true", nil, nil, nil))
+}
+
+func TestRunMissingEngine(t *testing.T) {
+	t.Parallel()
+
+	r := New(Options{Engine: EnginePodman, Image: "alpine", Workspace: t.TempDir()})
+	r.program = "crush-missing-sandbox-engine"
+	err := r.Run(context.Background(), "", nil, "true", nil, nil, nil)
+	require.ErrorContains(t, err, "沙箱引擎 podman 不可用")
+
+	err = New(Options{Engine: "chroot"}).Run(context.Background(), "", nil, "true", nil, nil, nil)
+	require.ErrorContains(t, err, "不支持的沙箱引擎")
+}
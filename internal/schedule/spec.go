@@ -0,0 +1,166 @@
+// Package schedule 提供定时运行的智能体任务：解析调度表达式，并在全局数据目录中保存任务列表。
+package schedule
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// minInterval 是固定间隔调度的最小间隔
+const minInterval = time.Minute
+
+// weekdayNames 将星期名称映射到 [time.Weekday]
+var weekdayNames = map[string]time.Weekday{
+	"sunday": time.Sunday, "sun": time.Sunday,
+	"monday": time.Monday, "mon": time.Monday,
+	"tuesday": time.Tuesday, "tue": time.Tuesday,
+	"wednesday": time.Wednesday, "wed": time.Wednesday,
+	"thursday": time.Thursday, "thu": time.Thursday,
+	"friday": time.Friday, "fri": time.Friday,
+	"saturday": time.Saturday, "sat": time.Saturday,
+}
+
+// Spec 是解析后的调度表达式。Every 非零时按固定间隔运行，否则在 Days 中的日期的 Hour:Minute 运行。
+type Spec struct {
+	Every  time.Duration
+	Hour   int
+	Minute int
+	Days   [7]bool // 按 time.Weekday 索引
+}
+
+// Parse 解析调度表达式，支持以下形式（不区分大小写）：
+//
+//	hourly
+//	every 30m / every 2h
+//	daily at 9 / daily at 9:30 / daily at 6pm
+//	weekdays at 9 / weekends at 10
+//	monday at 9 / every monday at 9 / weekly on monday at 9
+func Parse(s string) (Spec, error) {
+	fields := strings.Fields(strings.ToLower(s))
+	if len(fields) == 0 {
+		return Spec{}, fmt.Errorf("调度表达式为空")
+	}
+
+	if len(fields) == 1 && fields[0] == "hourly" {
+		return Spec{Every: time.Hour}, nil
+	}
+	if len(fields) == 2 && fields[0] == "every" {
+		if d, err := time.ParseDuration(fields[1]); err == nil {
+			if d < minInterval {
+				return Spec{}, fmt.Errorf("间隔不能小于 %s", minInterval)
+			}
+			return Spec{Every: d}, nil
+		}
+	}
+
+	// 日期部分和时间部分以 "at" 分隔
+	at := -1
+	for i, f := range fields {
+		if f == "at" {
+			at = i
+			break
+		}
+	}
+	if at < 1 || at != len(fields)-2 {
+		return Spec{}, fmt.Errorf("无法识别的调度表达式 %q，示例: daily at 9、weekdays at 9:30、every 2h", s)
+	}
+
+	var spec Spec
+	var err error
+	spec.Hour, spec.Minute, err = parseClock(fields[at+1])
+	if err != nil {
+		return Spec{}, err
+	}
+
+	days := fields[:at]
+	switch {
+	case len(days) >= 2 && days[0] == "weekly" && days[1] == "on":
+		days = days[2:]
+	case days[0] == "every":
+		days = days[1:]
+	}
+	if len(days) != 1 {
+		return Spec{}, fmt.Errorf("无法识别的调度日期 %q", strings.Join(fields[:at], " "))
+	}
+	switch day := days[0]; day {
+	case "daily", "day":
+		for i := range spec.Days {
+			spec.Days[i] = true
+		}
+	case "weekdays", "weekday":
+		for d := time.Monday; d <= time.Friday; d++ {
+			spec.Days[d] = true
+		}
+	case "weekends", "weekend":
+		spec.Days[time.Saturday] = true
+		spec.Days[time.Sunday] = true
+	default:
+		wd, ok := weekdayNames[strings.TrimSuffix(day, "s")]
+		if !ok {
+			wd, ok = weekdayNames[day]
+		}
+		if !ok {
+			return Spec{}, fmt.Errorf("无法识别的调度日期 %q", day)
+		}
+		spec.Days[wd] = true
+	}
+	return spec, nil
+}
+
+// parseClock 解析 9、9:30、09:30、6pm、6:30pm 形式的时间
+func parseClock(s string) (hour, minute int, err error) {
+	clock := s
+	offset := 0
+	switch {
+	case strings.HasSuffix(clock, "am"):
+		clock = strings.TrimSuffix(clock, "am")
+	case strings.HasSuffix(clock, "pm"):
+		clock = strings.TrimSuffix(clock, "pm")
+		offset = 12
+	}
+	h, m, hasMinute := strings.Cut(clock, ":")
+	hour, err = strconv.Atoi(h)
+	if err == nil && hasMinute {
+		minute, err = strconv.Atoi(m)
+	}
+	if err != nil || minute < 0 || minute > 59 || hour < 0 || hour > 23 ||
+		(clock != s && (hour < 1 || hour > 12)) {
+		return 0, 0, fmt.Errorf("无效的时间 %q", s)
+	}
+	if clock != s {
+		hour = hour%12 + offset
+	}
+	return hour, minute, nil
+}
+
+// Next 返回 after 之后下一次运行的时间。固定间隔的调度从 after 开始计算间隔。
+func (s Spec) Next(after time.Time) time.Time {
+	if s.Every > 0 {
+		return after.Add(s.Every)
+	}
+	next := time.Date(after.Year(), after.Month(), after.Day(), s.Hour, s.Minute, 0, 0, after.Location())
+	for i := 0; i < 8; i++ {
+		if next.After(after) && s.Days[next.Weekday()] {
+			return next
+		}
+		next = next.AddDate(0, 0, 1)
+	}
+	return time.Time{}
+}
+
+// SplitTask 将 "<调度>: <提示>" 形式的任务拆分为调度表达式和提示。
+// 调度中的时间可以包含冒号，因此以第一个冒号加空白作为分隔。
+func SplitTask(s string) (spec, prompt string, err error) {
+	for i := 0; i < len(s)-1; i++ {
+		if s[i] == ':' && (s[i+1] == ' ' || s[i+1] == '\t' || s[i+1] == '\n') {
+			spec, prompt = strings.TrimSpace(s[:i]), strings.TrimSpace(s[i+1:])
+			break
+		}
+	}
+	if spec == "" || prompt == "" {
+		return "", "", fmt.Errorf("任务格式应为 \"<调度>: <提示>\"，例如 \"daily at 9: update CHANGELOG from merged PRs\"")
+	}
+	return spec, prompt, nil
+}
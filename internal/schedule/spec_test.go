@@ -0,0 +1,93 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+
+	spec, err := Parse("hourly")
+	require.NoError(t, err)
+	require.Equal(t, time.Hour, spec.Every)
+
+	spec, err = Parse("every 30m")
+	require.NoError(t, err)
+	require.Equal(t, 30*time.Minute, spec.Every)
+
+	spec, err = Parse("Daily at 9:30")
+	require.NoError(t, err)
+	require.Equal(t, 9, spec.Hour)
+	require.Equal(t, 30, spec.Minute)
+	require.Equal(t, [7]bool{true, true, true, true, true, true, true}, spec.Days)
+
+	spec, err = Parse("weekdays at 6pm")
+	require.NoError(t, err)
+	require.Equal(t, 18, spec.Hour)
+	require.False(t, spec.Days[time.Saturday])
+	require.True(t, spec.Days[time.Friday])
+
+	for _, s := range []string{"monday at 9", "every monday at 9", "weekly on mon at 9", "mondays at 9"} {
+		spec, err = Parse(s)
+		require.NoError(t, err, s)
+		require.Equal(t, [7]bool{time.Monday: true}, spec.Days, s)
+	}
+
+	spec, err = Parse("daily at 12am")
+	require.NoError(t, err)
+	require.Equal(t, 0, spec.Hour)
+
+	for _, s := range []string{"", "sometimes", "every 10s", "daily at 25", "daily at 9:75", "daily at 13pm", "someday at 9", "daily 9"} {
+		_, err = Parse(s)
+		require.Error(t, err, s)
+	}
+}
+
+func TestSpecNext(t *testing.T) {
+	t.Parallel()
+
+	// 2026-01-02 是星期五
+	friday := time.Date(2026, 1, 2, 10, 0, 0, 0, time.UTC)
+
+	spec, err := Parse("daily at 9")
+	require.NoError(t, err)
+	require.Equal(t, time.Date(2026, 1, 3, 9, 0, 0, 0, time.UTC), spec.Next(friday))
+
+	spec, err = Parse("daily at 11")
+	require.NoError(t, err)
+	require.Equal(t, time.Date(2026, 1, 2, 11, 0, 0, 0, time.UTC), spec.Next(friday))
+
+	spec, err = Parse("weekdays at 9")
+	require.NoError(t, err)
+	require.Equal(t, time.Date(2026, 1, 5, 9, 0, 0, 0, time.UTC), spec.Next(friday))
+
+	spec, err = Parse("friday at 10")
+	require.NoError(t, err)
+	require.Equal(t, time.Date(2026, 1, 9, 10, 0, 0, 0, time.UTC), spec.Next(friday))
+
+	spec, err = Parse("every 2h")
+	require.NoError(t, err)
+	require.Equal(t, friday.Add(2*time.Hour), spec.Next(friday))
+}
+
+func TestSplitTask(t *testing.T) {
+	t.Parallel()
+
+	spec, prompt, err := SplitTask("daily at 9: update CHANGELOG from merged PRs")
+	require.NoError(t, err)
+	require.Equal(t, "daily at 9", spec)
+	require.Equal(t, "update CHANGELOG from merged PRs", prompt)
+
+	spec, prompt, err = SplitTask("weekdays at 9:30: summarize: open issues")
+	require.NoError(t, err)
+	require.Equal(t, "weekdays at 9:30", spec)
+	require.Equal(t, "summarize: open issues", prompt)
+
+	_, _, err = SplitTask("daily at 9")
+	require.Error(t, err)
+	_, _, err = SplitTask(": no schedule")
+	require.Error(t, err)
+}
@@ -0,0 +1,154 @@
+package schedule
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/purpose168/crush-cn/internal/config"
+)
+
+// tasksFileName 是任务列表文件的名称
+const tasksFileName = "schedules.json"
+
+// Task 是一个定时运行的智能体任务
+type Task struct {
+	// ID 是任务的短标识符
+	ID string `json:"id"`
+	// Schedule 是调度表达式，见 [Parse]
+	Schedule string `json:"schedule"`
+	// Prompt 是每次运行时发送给智能体的提示
+	Prompt string `json:"prompt"`
+	// Dir 是运行任务的项目目录
+	Dir string `json:"dir"`
+	// DataDir 是自定义的数据目录，为空时使用项目的默认数据目录
+	DataDir string `json:"data_dir,omitempty"`
+	// Model 是运行任务使用的模型，为空时使用配置中的模型
+	Model string `json:"model,omitempty"`
+	// CreatedAt 是任务的创建时间
+	CreatedAt time.Time `json:"created_at"`
+	// LastRun 是最近一次运行的开始时间
+	LastRun time.Time `json:"last_run,omitzero"`
+	// LastError 是最近一次运行失败的原因，成功时为空
+	LastError string `json:"last_error,omitempty"`
+}
+
+// Next 返回任务下一次运行的时间。从未运行过的任务从创建时间开始计算，
+// 因此调度器停止期间错过的运行只会补一次。
+func (t Task) Next() (time.Time, error) {
+	spec, err := Parse(t.Schedule)
+	if err != nil {
+		return time.Time{}, err
+	}
+	base := t.LastRun
+	if base.IsZero() {
+		base = t.CreatedAt
+	}
+	return spec.Next(base.Local()), nil
+}
+
+// mu 保护任务列表文件的读写
+var mu sync.Mutex
+
+// tasksFilePath 返回任务列表文件的路径，与 projects.json 位于同一目录
+func tasksFilePath() string {
+	return filepath.Join(filepath.Dir(config.GlobalConfigData()), tasksFileName)
+}
+
+// List 返回所有任务，按创建时间排序
+func List() ([]Task, error) {
+	mu.Lock()
+	defer mu.Unlock()
+	return load()
+}
+
+// Add 校验调度表达式并添加任务，返回添加后的任务
+func Add(task Task) (Task, error) {
+	if _, err := Parse(task.Schedule); err != nil {
+		return Task{}, err
+	}
+	if strings.TrimSpace(task.Prompt) == "" {
+		return Task{}, fmt.Errorf("提示不能为空")
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	tasks, err := load()
+	if err != nil {
+		return Task{}, err
+	}
+	task.ID = uuid.NewString()[:8]
+	if task.CreatedAt.IsZero() {
+		task.CreatedAt = time.Now().UTC()
+	}
+	tasks = append(tasks, task)
+	return task, save(tasks)
+}
+
+// Remove 删除 ID 为 id 的任务
+func Remove(id string) error {
+	mu.Lock()
+	defer mu.Unlock()
+	tasks, err := load()
+	if err != nil {
+		return err
+	}
+	i := slices.IndexFunc(tasks, func(t Task) bool { return t.ID == id })
+	if i < 0 {
+		return fmt.Errorf("未找到任务 %s", id)
+	}
+	return save(slices.Delete(tasks, i, i+1))
+}
+
+// RecordRun 记录任务在 start 开始的一次运行及其结果。任务已被删除时不做任何事。
+func RecordRun(id string, start time.Time, runErr error) error {
+	mu.Lock()
+	defer mu.Unlock()
+	tasks, err := load()
+	if err != nil {
+		return err
+	}
+	i := slices.IndexFunc(tasks, func(t Task) bool { return t.ID == id })
+	if i < 0 {
+		return nil
+	}
+	tasks[i].LastRun = start.UTC()
+	tasks[i].LastError = ""
+	if runErr != nil {
+		tasks[i].LastError = runErr.Error()
+	}
+	return save(tasks)
+}
+
+func load() ([]Task, error) {
+	data, err := os.ReadFile(tasksFilePath())
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var tasks []Task
+	if err := json.Unmarshal(data, &tasks); err != nil {
+		return nil, fmt.Errorf("解析 %s 失败: %w", tasksFileName, err)
+	}
+	return tasks, nil
+}
+
+func save(tasks []Task) error {
+	path := tasksFilePath()
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(tasks, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o600)
+}
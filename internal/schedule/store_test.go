@@ -0,0 +1,48 @@
+package schedule
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStore(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_DATA_HOME", dir)
+	t.Setenv("CRUSH_GLOBAL_DATA", filepath.Join(dir, "crush"))
+
+	tasks, err := List()
+	require.NoError(t, err)
+	require.Empty(t, tasks)
+
+	_, err = Add(Task{Schedule: "sometimes", Prompt: "hello", Dir: "/project"})
+	require.Error(t, err)
+
+	created := time.Date(2026, 1, 2, 10, 0, 0, 0, time.Local)
+	task, err := Add(Task{Schedule: "daily at 9", Prompt: "update CHANGELOG", Dir: "/project", CreatedAt: created})
+	require.NoError(t, err)
+	require.Len(t, task.ID, 8)
+
+	next, err := task.Next()
+	require.NoError(t, err)
+	require.Equal(t, time.Date(2026, 1, 3, 9, 0, 0, 0, time.Local), next)
+
+	start := time.Date(2026, 1, 3, 9, 0, 5, 0, time.Local)
+	require.NoError(t, RecordRun(task.ID, start, errors.New("exit status 1")))
+	tasks, err = List()
+	require.NoError(t, err)
+	require.Len(t, tasks, 1)
+	require.Equal(t, "exit status 1", tasks[0].LastError)
+	next, err = tasks[0].Next()
+	require.NoError(t, err)
+	require.Equal(t, time.Date(2026, 1, 4, 9, 0, 0, 0, time.Local), next)
+
+	require.Error(t, Remove("missing"))
+	require.NoError(t, Remove(task.ID))
+	tasks, err = List()
+	require.NoError(t, err)
+	require.Empty(t, tasks)
+}
@@ -0,0 +1,111 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/purpose168/crush-cn/internal/pubsub"
+)
+
+// 事件流中的事件名。会话和消息事件的名称为 "session." 或 "message." 加上
+// pubsub 事件类型，例如 "message.updated"。
+const (
+	EventPermissionRequested = "permission.requested"
+	EventPermissionResolved  = "permission.resolved"
+)
+
+// streamEvents 以 Server-Sent Events 推送会话、消息和权限事件。
+// 指定 session_id 时只推送该会话及其子会话的事件。
+func (s *Server) streamEvents(w http.ResponseWriter, r *http.Request) {
+	rc := http.NewResponseController(w)
+	sessionID := r.URL.Query().Get("session_id")
+	ctx := r.Context()
+
+	sessions := s.svc.Sessions.Subscribe(ctx)
+	messages := s.svc.Messages.Subscribe(ctx)
+	permissions := s.svc.Permissions.Subscribe(ctx)
+	notifications := s.svc.Permissions.SubscribeNotifications(ctx)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	if err := rc.Flush(); err != nil {
+		return
+	}
+
+	// 指定会话时，只转发本连接推送过的权限请求的处理结果
+	toolCalls := make(map[string]bool)
+	keepAlive := time.NewTicker(keepAliveInterval)
+	defer keepAlive.Stop()
+
+	for {
+		var (
+			name    string
+			payload any
+		)
+		select {
+		case <-ctx.Done():
+			return
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil || rc.Flush() != nil {
+				return
+			}
+			continue
+		case event, ok := <-sessions:
+			if !ok {
+				return
+			}
+			sess := event.Payload
+			if sessionID != "" && sess.ID != sessionID && sess.ParentSessionID != sessionID {
+				continue
+			}
+			name, payload = eventName("session", event.Type), newSession(sess)
+		case event, ok := <-messages:
+			if !ok {
+				return
+			}
+			if sessionID != "" && event.Payload.SessionID != sessionID {
+				continue
+			}
+			name, payload = eventName("message", event.Type), newMessage(event.Payload)
+		case event, ok := <-permissions:
+			if !ok {
+				return
+			}
+			if sessionID != "" && event.Payload.SessionID != sessionID {
+				continue
+			}
+			toolCalls[event.Payload.ToolCallID] = true
+			name, payload = EventPermissionRequested, event.Payload
+		case event, ok := <-notifications:
+			if !ok {
+				return
+			}
+			notification := event.Payload
+			if !notification.Granted && !notification.Denied {
+				continue
+			}
+			if sessionID != "" && !toolCalls[notification.ToolCallID] {
+				continue
+			}
+			delete(toolCalls, notification.ToolCallID)
+			name, payload = EventPermissionResolved, notification
+		}
+
+		data, err := json.Marshal(payload)
+		if err != nil {
+			continue
+		}
+		if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", name, data); err != nil || rc.Flush() != nil {
+			return
+		}
+	}
+}
+
+// eventName 返回资源事件在事件流中的名称。
+func eventName(resource string, typ pubsub.EventType) string {
+	return resource + "." + string(typ)
+}
@@ -0,0 +1,409 @@
+// Package server 实现 crush serve 模式的本地 HTTP API。IDE 扩展等外部工具可以通过它
+// 列出和创建会话、提交提示、回应权限请求，并以 Server-Sent Events 的形式接收事件。
+// API 与 TUI 使用同一组服务，事件直接订阅自各服务的 pubsub 代理。
+package server
+
+import (
+	"cmp"
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/purpose168/crush-cn/internal/agent"
+	"github.com/purpose168/crush-cn/internal/csync"
+	"github.com/purpose168/crush-cn/internal/message"
+	"github.com/purpose168/crush-cn/internal/permission"
+	"github.com/purpose168/crush-cn/internal/session"
+)
+
+const (
+	// defaultSessionTitle 是创建会话时未提供标题使用的标题。
+	defaultSessionTitle = "新会话"
+	// keepAliveInterval 是事件流发送保活注释的间隔。
+	keepAliveInterval = 15 * time.Second
+	// maxRequestBodySize 是请求体的最大字节数。
+	maxRequestBodySize = 1 << 20
+)
+
+// Services 是 API 驱动的应用核心。
+type Services struct {
+	Sessions    session.Service
+	Messages    message.Service
+	Permissions permission.Service
+	// Agent 为 nil 表示尚未配置提供商，此时提交提示会返回错误。
+	Agent agent.Coordinator
+}
+
+// Server 是 crush serve 的 HTTP API 服务器。
+type Server struct {
+	svc   Services
+	token string
+	// ctx 是代理运行使用的上下文，代理运行不随提交提示的请求结束而取消。
+	ctx     context.Context
+	pending *csync.Map[string, permission.PermissionRequest]
+	handler http.Handler
+	// remote 表示服务器监听在非本地地址上，此时也接受以 IP 地址作为 Host 的请求。
+	remote bool
+}
+
+// New 创建服务器，并开始跟踪等待回应的权限请求直到 ctx 结束。token 非空时每个请求都
+// 必须携带 "Authorization: Bearer <token>"。无论是否设置令牌，Host 不是本地地址或
+// Origin 跨站的请求都会被拒绝，以防网页通过 DNS 重绑定或跨站请求驱动代理。
+func New(ctx context.Context, svc Services, token string) *Server {
+	s := &Server{
+		svc:     svc,
+		token:   token,
+		ctx:     ctx,
+		pending: csync.NewMap[string, permission.PermissionRequest](),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v1/sessions", s.listSessions)
+	mux.HandleFunc("POST /v1/sessions", s.createSession)
+	mux.HandleFunc("GET /v1/sessions/{id}", s.getSession)
+	mux.HandleFunc("GET /v1/sessions/{id}/messages", s.listMessages)
+	mux.HandleFunc("POST /v1/sessions/{id}/prompts", s.postPrompt)
+	mux.HandleFunc("POST /v1/sessions/{id}/cancel", s.cancelSession)
+	mux.HandleFunc("GET /v1/permissions", s.listPermissions)
+	mux.HandleFunc("POST /v1/permissions/{id}", s.respondPermission)
+	mux.HandleFunc("GET /v1/events", s.streamEvents)
+	s.handler = s.checkOrigin(s.authenticate(mux))
+
+	s.trackPermissions(ctx)
+	return s
+}
+
+// Handler 返回服务器的 [http.Handler]。
+func (s *Server) Handler() http.Handler {
+	return s.handler
+}
+
+// Serve 在 ln 上提供 API，直到 ctx 结束后关闭服务器。
+func (s *Server) Serve(ctx context.Context, ln net.Listener) error {
+	if addr, ok := ln.Addr().(*net.TCPAddr); ok && !addr.IP.IsLoopback() {
+		s.remote = true
+	}
+	srv := &http.Server{
+		Handler:           s.handler,
+		ReadHeaderTimeout: 10 * time.Second,
+		BaseContext:       func(net.Listener) context.Context { return ctx },
+	}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = srv.Shutdown(shutdownCtx)
+	}()
+	if err := srv.Serve(ln); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// authenticate 在配置了令牌时校验请求的 Bearer 令牌。
+func (s *Server) authenticate(next http.Handler) http.Handler {
+	if s.token == "" {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(s.token)) != 1 {
+			writeError(w, http.StatusUnauthorized, "令牌无效")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// checkOrigin 拒绝 Host 不可信或 Origin 与请求来源不一致的请求。Host 必须是本地地址，
+// 服务器监听在非本地地址上时也可以是 IP 地址，但不能是可被重绑定的域名。
+func (s *Server) checkOrigin(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !s.allowedHost(r.Host) {
+			writeError(w, http.StatusForbidden, "不允许的 Host")
+			return
+		}
+		if origin := r.Header.Get("Origin"); origin != "" {
+			u, err := url.Parse(origin)
+			if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host != r.Host {
+				writeError(w, http.StatusForbidden, "不允许跨站请求")
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// allowedHost 判断请求的 Host 是否可信。
+func (s *Server) allowedHost(hostport string) bool {
+	host, _, err := net.SplitHostPort(hostport)
+	if err != nil {
+		host = hostport
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(strings.Trim(host, "[]"))
+	if ip == nil {
+		return false
+	}
+	return ip.IsLoopback() || s.remote
+}
+
+// trackPermissions 记录等待回应的权限请求，在请求被批准或拒绝后移除。
+func (s *Server) trackPermissions(ctx context.Context) {
+	requests := s.svc.Permissions.Subscribe(ctx)
+	notifications := s.svc.Permissions.SubscribeNotifications(ctx)
+	go func() {
+		for {
+			select {
+			case event, ok := <-requests:
+				if !ok {
+					return
+				}
+				s.pending.Set(event.Payload.ID, event.Payload)
+			case event, ok := <-notifications:
+				if !ok {
+					return
+				}
+				if !event.Payload.Granted && !event.Payload.Denied {
+					continue
+				}
+				for id, req := range s.pending.Seq2() {
+					if req.ToolCallID == event.Payload.ToolCallID {
+						s.pending.Del(id)
+					}
+				}
+			}
+		}
+	}()
+}
+
+func (s *Server) listSessions(w http.ResponseWriter, r *http.Request) {
+	sessions, err := s.svc.Sessions.List(r.Context())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Sprintf("列出会话失败: %v", err))
+		return
+	}
+	out := make([]Session, 0, len(sessions))
+	for _, sess := range sessions {
+		out = append(out, newSession(sess))
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+// CreateSessionRequest 是创建会话的请求体。
+type CreateSessionRequest struct {
+	Title string `json:"title,omitempty"`
+}
+
+func (s *Server) createSession(w http.ResponseWriter, r *http.Request) {
+	var req CreateSessionRequest
+	if !readJSON(w, r, &req) {
+		return
+	}
+	sess, err := s.svc.Sessions.Create(r.Context(), cmp.Or(strings.TrimSpace(req.Title), defaultSessionTitle))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Sprintf("创建会话失败: %v", err))
+		return
+	}
+	writeJSON(w, http.StatusCreated, newSession(sess))
+}
+
+func (s *Server) getSession(w http.ResponseWriter, r *http.Request) {
+	sess, ok := s.session(w, r)
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusOK, newSession(sess))
+}
+
+func (s *Server) listMessages(w http.ResponseWriter, r *http.Request) {
+	sess, ok := s.session(w, r)
+	if !ok {
+		return
+	}
+	msgs, err := s.svc.Messages.List(r.Context(), sess.ID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Sprintf("列出消息失败: %v", err))
+		return
+	}
+	// 备选回复版本中的消息不属于当前对话
+	hidden := sess.HiddenMessages()
+	out := make([]Message, 0, len(msgs))
+	for _, msg := range msgs {
+		if hidden[msg.ID] {
+			continue
+		}
+		out = append(out, newMessage(msg))
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+// PromptRequest 是提交提示的请求体。
+type PromptRequest struct {
+	Prompt string `json:"prompt"`
+}
+
+// PromptResponse 是提交提示的响应。会话忙碌时提示进入队列，Queued 为 true。
+type PromptResponse struct {
+	SessionID string `json:"session_id"`
+	Queued    bool   `json:"queued"`
+}
+
+func (s *Server) postPrompt(w http.ResponseWriter, r *http.Request) {
+	var req PromptRequest
+	if !readJSON(w, r, &req) {
+		return
+	}
+	if strings.TrimSpace(req.Prompt) == "" {
+		writeError(w, http.StatusBadRequest, "提示不能为空")
+		return
+	}
+	if s.svc.Agent == nil {
+		writeError(w, http.StatusServiceUnavailable, "代理未配置，请先运行 crush 设置提供商")
+		return
+	}
+	sess, ok := s.session(w, r)
+	if !ok {
+		return
+	}
+
+	queued := s.svc.Agent.IsSessionBusy(sess.ID)
+	go func() {
+		_, err := s.svc.Agent.Run(s.ctx, sess.ID, req.Prompt)
+		if err != nil && !errors.Is(err, context.Canceled) && !errors.Is(err, agent.ErrRequestCancelled) {
+			slog.Error("API agent run failed", "session_id", sess.ID, "error", err)
+		}
+	}()
+	writeJSON(w, http.StatusAccepted, PromptResponse{SessionID: sess.ID, Queued: queued})
+}
+
+func (s *Server) cancelSession(w http.ResponseWriter, r *http.Request) {
+	sess, ok := s.session(w, r)
+	if !ok {
+		return
+	}
+	if s.svc.Agent != nil {
+		s.svc.Agent.Cancel(sess.ID)
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) listPermissions(w http.ResponseWriter, r *http.Request) {
+	sessionID := r.URL.Query().Get("session_id")
+	out := make([]permission.PermissionRequest, 0, s.pending.Len())
+	for req := range s.pending.Seq() {
+		if sessionID == "" || req.SessionID == sessionID {
+			out = append(out, req)
+		}
+	}
+	slices.SortFunc(out, func(a, b permission.PermissionRequest) int {
+		return strings.Compare(a.ID, b.ID)
+	})
+	writeJSON(w, http.StatusOK, out)
+}
+
+// 权限回应的操作。
+const (
+	PermissionAllow        = "allow"
+	PermissionAllowSession = "allow_session"
+	PermissionAllowWindow  = "allow_window"
+	PermissionDeny         = "deny"
+)
+
+// PermissionResponseRequest 是回应权限请求的请求体。操作为 allow_window 时，Minutes 和 Uses
+// 限定同一会话中该工具自动允许的时间和次数，都未设置时允许 10 分钟。
+type PermissionResponseRequest struct {
+	Action  string `json:"action"`
+	Minutes int    `json:"minutes,omitempty"`
+	Uses    int    `json:"uses,omitempty"`
+}
+
+func (s *Server) respondPermission(w http.ResponseWriter, r *http.Request) {
+	var req PermissionResponseRequest
+	if !readJSON(w, r, &req) {
+		return
+	}
+	id := r.PathValue("id")
+	perm, ok := s.pending.Get(id)
+	if !ok {
+		writeError(w, http.StatusNotFound, "权限请求不存在或已处理")
+		return
+	}
+	switch req.Action {
+	case PermissionAllow:
+		s.svc.Permissions.Grant(perm)
+	case PermissionAllowSession:
+		s.svc.Permissions.GrantPersistent(perm)
+	case PermissionAllowWindow:
+		if req.Minutes < 0 || req.Uses < 0 {
+			writeError(w, http.StatusBadRequest, "minutes 和 uses 不能为负数")
+			return
+		}
+		window := permission.Window{Duration: time.Duration(req.Minutes) * time.Minute, Uses: req.Uses}
+		if window.Duration == 0 && window.Uses == 0 {
+			window.Duration = permission.DefaultWindowDuration
+		}
+		s.svc.Permissions.GrantWindow(perm, window)
+	case PermissionDeny:
+		s.svc.Permissions.Deny(perm)
+	default:
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("未知的操作 %q，可用的操作: allow, allow_session, allow_window, deny", req.Action))
+		return
+	}
+	s.pending.Del(id)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// session 返回路径中 id 对应的会话，不存在时写入 404 响应。
+func (s *Server) session(w http.ResponseWriter, r *http.Request) (session.Session, bool) {
+	sess, err := s.svc.Sessions.Get(r.Context(), r.PathValue("id"))
+	if err != nil {
+		writeError(w, http.StatusNotFound, "会话不存在")
+		return session.Session{}, false
+	}
+	return sess, true
+}
+
+// readJSON 解析 JSON 请求体，空请求体视为空对象。请求体非空时 Content-Type 必须是
+// application/json，使浏览器无法以不经预检的简单请求提交。解析失败时写入 400 响应。
+func readJSON(w http.ResponseWriter, r *http.Request, v any) bool {
+	if r.ContentLength != 0 {
+		if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType != "application/json" {
+			writeError(w, http.StatusUnsupportedMediaType, "Content-Type 必须是 application/json")
+			return false
+		}
+	}
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestBodySize))
+	if err := dec.Decode(v); err != nil && !errors.Is(err, io.EOF) {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("请求体无效: %v", err))
+		return false
+	}
+	return true
+}
+
+// writeJSON 写入 JSON 响应。
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		slog.Debug("Failed to write API response", "error", err)
+	}
+}
+
+// writeError 写入 {"error": "..."} 形式的错误响应。
+func writeError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, map[string]string{"error": msg})
+}
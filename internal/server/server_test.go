@@ -0,0 +1,215 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"charm.land/fantasy"
+	"github.com/purpose168/crush-cn/internal/agent"
+	"github.com/purpose168/crush-cn/internal/db"
+	"github.com/purpose168/crush-cn/internal/message"
+	"github.com/purpose168/crush-cn/internal/permission"
+	"github.com/purpose168/crush-cn/internal/session"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeCoordinator struct {
+	agent.Coordinator
+	prompts chan string
+}
+
+func (f *fakeCoordinator) Run(_ context.Context, sessionID, prompt string, _ ...message.Attachment) (*fantasy.AgentResult, error) {
+	f.prompts <- sessionID + ":" + prompt
+	return &fantasy.AgentResult{}, nil
+}
+
+func (f *fakeCoordinator) IsSessionBusy(string) bool { return false }
+
+type testServer struct {
+	url   string
+	svc   Services
+	agent *fakeCoordinator
+}
+
+func newTestServer(t *testing.T, token string) testServer {
+	t.Helper()
+
+	conn, err := db.Connect(t.Context(), t.TempDir())
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	q := db.New(conn)
+	fake := &fakeCoordinator{prompts: make(chan string, 1)}
+	svc := Services{
+		Sessions:    session.NewService(q, conn, ""),
+		Messages:    message.NewService(q),
+		Permissions: permission.NewPermissionService(t.TempDir(), false, nil),
+		Agent:       fake,
+	}
+	srv := httptest.NewServer(New(t.Context(), svc, token).Handler())
+	t.Cleanup(srv.Close)
+	return testServer{url: srv.URL, svc: svc, agent: fake}
+}
+
+func (ts testServer) do(t *testing.T, method, path, body string, out any) int {
+	t.Helper()
+	req, err := http.NewRequestWithContext(t.Context(), method, ts.url+path, strings.NewReader(body))
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer secret")
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	if out != nil {
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(out))
+	}
+	return resp.StatusCode
+}
+
+func TestServerSessions(t *testing.T) {
+	t.Parallel()
+	ts := newTestServer(t, "secret")
+
+	resp, err := http.Get(ts.url + "/v1/sessions")
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	var created Session
+	require.Equal(t, http.StatusCreated, ts.do(t, http.MethodPost, "/v1/sessions", `{"title":"API"}`, &created))
+	require.Equal(t, "API", created.Title)
+
+	var sessions []Session
+	require.Equal(t, http.StatusOK, ts.do(t, http.MethodGet, "/v1/sessions", "", &sessions))
+	require.Len(t, sessions, 1)
+	require.Equal(t, created.ID, sessions[0].ID)
+
+	require.Equal(t, http.StatusNotFound, ts.do(t, http.MethodGet, "/v1/sessions/missing", "", nil))
+	require.Equal(t, http.StatusBadRequest, ts.do(t, http.MethodPost, "/v1/sessions/"+created.ID+"/prompts", `{"prompt":" "}`, nil))
+
+	var prompt PromptResponse
+	require.Equal(t, http.StatusAccepted, ts.do(t, http.MethodPost, "/v1/sessions/"+created.ID+"/prompts", `{"prompt":"hello"}`, &prompt))
+	require.Equal(t, created.ID, prompt.SessionID)
+	require.Equal(t, created.ID+":hello", <-ts.agent.prompts)
+}
+
+func TestServerRejectsCrossSiteRequests(t *testing.T) {
+	t.Parallel()
+	ts := newTestServer(t, "")
+
+	sess, err := ts.svc.Sessions.Create(t.Context(), "cross-site")
+	require.NoError(t, err)
+	path := ts.url + "/v1/sessions/" + sess.ID + "/prompts"
+
+	post := func(contentType, host, origin string) int {
+		req, err := http.NewRequestWithContext(t.Context(), http.MethodPost, path, strings.NewReader(`{"prompt":"hello"}`))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", contentType)
+		if host != "" {
+			req.Host = host
+		}
+		if origin != "" {
+			req.Header.Set("Origin", origin)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	require.Equal(t, http.StatusUnsupportedMediaType, post("text/plain", "", ""))
+	require.Equal(t, http.StatusForbidden, post("application/json", "", "https://evil.example"))
+	require.Equal(t, http.StatusForbidden, post("application/json", "evil.example:7777", ""))
+	require.Equal(t, http.StatusAccepted, post("application/json; charset=utf-8", "", ts.url))
+	require.Equal(t, sess.ID+":hello", <-ts.agent.prompts)
+}
+
+func TestServerEvents(t *testing.T) {
+	t.Parallel()
+	ts := newTestServer(t, "")
+
+	sess, err := ts.svc.Sessions.Create(t.Context(), "events")
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(t.Context(), 10*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, ts.url+"/v1/events?session_id="+sess.ID, nil)
+	require.NoError(t, err)
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	_, err = ts.svc.Messages.Create(t.Context(), sess.ID, message.CreateMessageParams{
+		Role:  message.User,
+		Parts: []message.ContentPart{message.TextContent{Text: "hi"}},
+	})
+	require.NoError(t, err)
+
+	scanner := bufio.NewScanner(resp.Body)
+	require.True(t, scanner.Scan())
+	require.Equal(t, "event: message.created", scanner.Text())
+	require.True(t, scanner.Scan())
+	var msg Message
+	require.NoError(t, json.Unmarshal([]byte(strings.TrimPrefix(scanner.Text(), "data: ")), &msg))
+	require.Equal(t, "hi", msg.Text)
+	require.Equal(t, message.User, msg.Role)
+}
+
+func TestServerPermissions(t *testing.T) {
+	t.Parallel()
+	ts := newTestServer(t, "")
+
+	granted := make(chan bool, 1)
+	go func() {
+		ok, _ := ts.svc.Permissions.Request(t.Context(), permission.CreatePermissionRequest{
+			SessionID:  "s1",
+			ToolCallID: "call-1",
+			ToolName:   "bash",
+			Action:     "execute",
+			Path:       t.TempDir(),
+		})
+		granted <- ok
+	}()
+
+	var pending []permission.PermissionRequest
+	require.Eventually(t, func() bool {
+		pending = nil
+		ts.do(t, http.MethodGet, "/v1/permissions?session_id=s1", "", &pending)
+		return len(pending) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+
+	require.Equal(t, http.StatusBadRequest, ts.do(t, http.MethodPost, "/v1/permissions/"+pending[0].ID, `{"action":"maybe"}`, nil))
+	require.Equal(t, http.StatusNoContent, ts.do(t, http.MethodPost, "/v1/permissions/"+pending[0].ID, `{"action":"allow"}`, nil))
+	require.True(t, <-granted)
+	require.Equal(t, http.StatusNotFound, ts.do(t, http.MethodPost, "/v1/permissions/"+pending[0].ID, `{"action":"allow"}`, nil))
+
+	// 限时允许后，同一会话中该工具的下一次调用不再请求权限
+	request := func() {
+		ok, _ := ts.svc.Permissions.Request(t.Context(), permission.CreatePermissionRequest{
+			SessionID: "s1",
+			ToolName:  "bash",
+			Action:    "execute",
+			Path:      t.TempDir(),
+		})
+		granted <- ok
+	}
+	go request()
+	require.Eventually(t, func() bool {
+		pending = nil
+		ts.do(t, http.MethodGet, "/v1/permissions?session_id=s1", "", &pending)
+		return len(pending) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+	require.Equal(t, http.StatusNoContent, ts.do(t, http.MethodPost, "/v1/permissions/"+pending[0].ID, `{"action":"allow_window","uses":1}`, nil))
+	require.True(t, <-granted)
+	go request()
+	require.True(t, <-granted)
+}
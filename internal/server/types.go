@@ -0,0 +1,86 @@
+package server
+
+import (
+	"github.com/purpose168/crush-cn/internal/message"
+	"github.com/purpose168/crush-cn/internal/session"
+)
+
+// Session 是 API 返回的会话。
+type Session struct {
+	ID               string             `json:"id"`
+	ParentSessionID  string             `json:"parent_session_id,omitempty"`
+	Title            string             `json:"title"`
+	MessageCount     int64              `json:"message_count"`
+	PromptTokens     int64              `json:"prompt_tokens"`
+	CompletionTokens int64              `json:"completion_tokens"`
+	Cost             float64            `json:"cost"`
+	Todos            []session.Todo     `json:"todos,omitempty"`
+	PinnedFiles      []string           `json:"pinned_files,omitempty"`
+	Env              map[string]string  `json:"env,omitempty"`
+	PlanMode         session.PlanMode   `json:"plan_mode,omitempty"`
+	Author           string             `json:"author,omitempty"`
+	WorkingDir       string             `json:"working_dir,omitempty"`
+	Bookmarks        []session.Bookmark `json:"bookmarks,omitempty"`
+	Tags             []string           `json:"tags,omitempty"`
+	Variants         []session.Variant  `json:"variants,omitempty"`
+	CreatedAt        int64              `json:"created_at"`
+	UpdatedAt        int64              `json:"updated_at"`
+	ArchivedAt       int64              `json:"archived_at,omitempty"`
+}
+
+func newSession(s session.Session) Session {
+	return Session{
+		ID:               s.ID,
+		ParentSessionID:  s.ParentSessionID,
+		Title:            s.Title,
+		MessageCount:     s.MessageCount,
+		PromptTokens:     s.PromptTokens,
+		CompletionTokens: s.CompletionTokens,
+		Cost:             s.Cost,
+		Todos:            s.Todos,
+		PinnedFiles:      s.PinnedFiles,
+		Env:              s.Env,
+		PlanMode:         s.PlanMode,
+		Author:           s.Author,
+		WorkingDir:       s.WorkingDir,
+		Bookmarks:        s.Bookmarks,
+		Tags:             s.Tags,
+		Variants:         s.Variants,
+		CreatedAt:        s.CreatedAt,
+		UpdatedAt:        s.UpdatedAt,
+		ArchivedAt:       s.ArchivedAt,
+	}
+}
+
+// Message 是 API 返回的消息。助手消息在生成过程中会多次以完整内容更新。
+type Message struct {
+	ID           string               `json:"id"`
+	SessionID    string               `json:"session_id"`
+	Role         message.MessageRole  `json:"role"`
+	Text         string               `json:"text,omitempty"`
+	Reasoning    string               `json:"reasoning,omitempty"`
+	ToolCalls    []message.ToolCall   `json:"tool_calls,omitempty"`
+	ToolResults  []message.ToolResult `json:"tool_results,omitempty"`
+	FinishReason message.FinishReason `json:"finish_reason,omitempty"`
+	Model        string               `json:"model,omitempty"`
+	Provider     string               `json:"provider,omitempty"`
+	CreatedAt    int64                `json:"created_at"`
+	UpdatedAt    int64                `json:"updated_at"`
+}
+
+func newMessage(m message.Message) Message {
+	return Message{
+		ID:           m.ID,
+		SessionID:    m.SessionID,
+		Role:         m.Role,
+		Text:         m.Content().String(),
+		Reasoning:    m.ReasoningContent().Thinking,
+		ToolCalls:    m.ToolCalls(),
+		ToolResults:  m.ToolResults(),
+		FinishReason: m.FinishReason(),
+		Model:        m.Model,
+		Provider:     m.Provider,
+		CreatedAt:    m.CreatedAt,
+		UpdatedAt:    m.UpdatedAt,
+	}
+}
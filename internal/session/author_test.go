@@ -0,0 +1,28 @@
+package session
+
+import (
+	"testing"
+
+	"github.com/purpose168/crush-cn/internal/db"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServiceAuthor(t *testing.T) {
+	t.Parallel()
+
+	conn, err := db.Connect(t.Context(), t.TempDir())
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	svc := NewService(db.New(conn), conn, "Ada Lovelace <ada@example.com>")
+
+	sess, err := svc.Create(t.Context(), "shared")
+	require.NoError(t, err)
+	require.Equal(t, "Ada Lovelace <ada@example.com>", sess.Author)
+
+	got, err := svc.Get(t.Context(), sess.ID)
+	require.NoError(t, err)
+	require.Equal(t, "Ada Lovelace", got.AuthorName())
+
+	require.Equal(t, "ada@example.com", Session{Author: "<ada@example.com>"}.AuthorName())
+	require.Empty(t, Session{}.AuthorName())
+}
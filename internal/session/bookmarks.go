@@ -0,0 +1,70 @@
+package session
+
+import (
+	"encoding/json"
+	"slices"
+)
+
+// Bookmark 是会话中一个聊天项目的书签，用于在较长的会话中快速跳转。
+type Bookmark struct {
+	// ItemID 是被标记的聊天项目 ID，即消息 ID 或工具调用 ID。
+	ItemID string `json:"item_id"`
+	// Label 是用户填写的标签，可以为空。
+	Label string `json:"label,omitempty"`
+	// Preview 是添加书签时项目内容的第一行，项目不在聊天中时仍可显示。
+	Preview string `json:"preview,omitempty"`
+	// CreatedAt 是添加书签的时间（Unix 时间戳）。
+	CreatedAt int64 `json:"created_at"`
+}
+
+// Title 返回书签的显示标题，没有标签时使用内容预览。
+func (b Bookmark) Title() string {
+	if b.Label != "" {
+		return b.Label
+	}
+	return b.Preview
+}
+
+// Bookmark 返回聊天项目的书签，项目没有书签时返回 false。
+func (s Session) Bookmark(itemID string) (Bookmark, bool) {
+	i := slices.IndexFunc(s.Bookmarks, func(b Bookmark) bool { return b.ItemID == itemID })
+	if i < 0 {
+		return Bookmark{}, false
+	}
+	return s.Bookmarks[i], true
+}
+
+// AddBookmark 返回添加书签后的书签列表。同一项目已有书签时替换原书签，不修改会话本身。
+func (s Session) AddBookmark(b Bookmark) []Bookmark {
+	bookmarks := s.RemoveBookmark(b.ItemID)
+	return append(bookmarks, b)
+}
+
+// RemoveBookmark 返回移除项目书签后的书签列表，不修改会话本身。
+func (s Session) RemoveBookmark(itemID string) []Bookmark {
+	return slices.DeleteFunc(slices.Clone(s.Bookmarks), func(b Bookmark) bool {
+		return b.ItemID == itemID
+	})
+}
+
+func marshalBookmarks(bookmarks []Bookmark) (string, error) {
+	if len(bookmarks) == 0 {
+		return "", nil
+	}
+	data, err := json.Marshal(bookmarks)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+func unmarshalBookmarks(data string) ([]Bookmark, error) {
+	if data == "" {
+		return nil, nil
+	}
+	var bookmarks []Bookmark
+	if err := json.Unmarshal([]byte(data), &bookmarks); err != nil {
+		return nil, err
+	}
+	return bookmarks, nil
+}
@@ -0,0 +1,66 @@
+package session
+
+import (
+	"testing"
+
+	"github.com/purpose168/crush-cn/internal/db"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSessionBookmarks(t *testing.T) {
+	t.Parallel()
+
+	var sess Session
+	sess.Bookmarks = sess.AddBookmark(Bookmark{ItemID: "msg-1", Preview: "Refactor the parser"})
+	sess.Bookmarks = sess.AddBookmark(Bookmark{ItemID: "tool-1", Label: "failing test"})
+	require.Len(t, sess.Bookmarks, 2)
+
+	b, ok := sess.Bookmark("msg-1")
+	require.True(t, ok)
+	require.Equal(t, "Refactor the parser", b.Title())
+	b, ok = sess.Bookmark("tool-1")
+	require.True(t, ok)
+	require.Equal(t, "failing test", b.Title())
+
+	// 重复添加同一项目会替换原书签并移到末尾
+	sess.Bookmarks = sess.AddBookmark(Bookmark{ItemID: "msg-1", Label: "plan"})
+	require.Len(t, sess.Bookmarks, 2)
+	require.Equal(t, "tool-1", sess.Bookmarks[0].ItemID)
+	require.Equal(t, "plan", sess.Bookmarks[1].Label)
+
+	remaining := sess.RemoveBookmark("tool-1")
+	require.Len(t, remaining, 1)
+	require.Len(t, sess.Bookmarks, 2, "RemoveBookmark 不应修改会话本身")
+	_, ok = sess.Bookmark("missing")
+	require.False(t, ok)
+}
+
+func TestServiceSetBookmarks(t *testing.T) {
+	t.Parallel()
+
+	conn, err := db.Connect(t.Context(), t.TempDir())
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	svc := NewService(db.New(conn), conn, "")
+
+	sess, err := svc.Create(t.Context(), "long session")
+	require.NoError(t, err)
+	require.Empty(t, sess.Bookmarks)
+
+	bookmarks := []Bookmark{{ItemID: "msg-1", Label: "design", Preview: "Let's split the package", CreatedAt: 42}}
+	updated, err := svc.SetBookmarks(t.Context(), sess.ID, bookmarks)
+	require.NoError(t, err)
+	require.Equal(t, bookmarks, updated.Bookmarks)
+
+	// 保存会话的其他字段不会覆盖书签
+	updated.Title = "renamed"
+	_, err = svc.Save(t.Context(), updated)
+	require.NoError(t, err)
+	got, err := svc.Get(t.Context(), sess.ID)
+	require.NoError(t, err)
+	require.Equal(t, bookmarks, got.Bookmarks)
+
+	cleared, err := svc.SetBookmarks(t.Context(), sess.ID, nil)
+	require.NoError(t, err)
+	require.Empty(t, cleared.Bookmarks)
+}
@@ -0,0 +1,42 @@
+package session
+
+import (
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+var envKeyPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// ParseEnv 解析每行一个 KEY=VALUE 的环境变量文本。空行和以 # 开头的行会被忽略，
+// 同名变量以最后一个为准。
+func ParseEnv(text string) (map[string]string, error) {
+	env := make(map[string]string)
+	for i, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		if !ok {
+			return nil, fmt.Errorf("第 %d 行缺少 \"=\": %s", i+1, line)
+		}
+		if !envKeyPattern.MatchString(key) {
+			return nil, fmt.Errorf("第 %d 行的变量名无效: %q", i+1, key)
+		}
+		env[key] = strings.TrimSpace(value)
+	}
+	return env, nil
+}
+
+// FormatEnv 将环境变量按名称排序格式化为每行一个 KEY=VALUE 的文本，是 [ParseEnv] 的逆操作。
+func FormatEnv(env map[string]string) string {
+	lines := make([]string, 0, len(env))
+	for _, key := range slices.Sorted(maps.Keys(env)) {
+		lines = append(lines, key+"="+env[key])
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,63 @@
+package session
+
+import (
+	"testing"
+
+	"github.com/purpose168/crush-cn/internal/db"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseEnv(t *testing.T) {
+	t.Parallel()
+
+	env, err := ParseEnv("# build flags\nGOFLAGS=-tags=integration -count=1\n\n NODE_ENV = test \nGOFLAGS=-race\nEMPTY=")
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{
+		"GOFLAGS":  "-race",
+		"NODE_ENV": "test",
+		"EMPTY":    "",
+	}, env)
+	require.Equal(t, "EMPTY=\nGOFLAGS=-race\nNODE_ENV=test", FormatEnv(env))
+
+	roundTrip, err := ParseEnv(FormatEnv(env))
+	require.NoError(t, err)
+	require.Equal(t, env, roundTrip)
+
+	_, err = ParseEnv("GOFLAGS=-race\nNODE_ENV")
+	require.ErrorContains(t, err, "第 2 行")
+	_, err = ParseEnv("1BAD=x")
+	require.ErrorContains(t, err, "变量名无效")
+}
+
+func TestServiceSetEnv(t *testing.T) {
+	t.Parallel()
+
+	conn, err := db.Connect(t.Context(), t.TempDir())
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	svc := NewService(db.New(conn), conn, "")
+
+	sess, err := svc.Create(t.Context(), "env")
+	require.NoError(t, err)
+	require.Empty(t, sess.Env)
+
+	updated, err := svc.SetEnv(t.Context(), sess.ID, map[string]string{"NODE_ENV": "test"})
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"NODE_ENV": "test"}, updated.Env)
+
+	got, err := svc.Get(t.Context(), sess.ID)
+	require.NoError(t, err)
+	require.Equal(t, updated.Env, got.Env)
+
+	// 保存会话的其他字段不会覆盖环境变量
+	got.Title = "renamed"
+	_, err = svc.Save(t.Context(), got)
+	require.NoError(t, err)
+	got, err = svc.Get(t.Context(), sess.ID)
+	require.NoError(t, err)
+	require.Equal(t, updated.Env, got.Env)
+
+	cleared, err := svc.SetEnv(t.Context(), sess.ID, nil)
+	require.NoError(t, err)
+	require.Empty(t, cleared.Env)
+}
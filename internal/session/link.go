@@ -0,0 +1,43 @@
+package session
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// linkPrefix 是会话深层链接的前缀，完整形式为 crush://session/<会话 ID>/msg/<消息 ID>。
+const linkPrefix = "crush://session/"
+
+// MessageLink 返回指向会话中某条消息的深层链接，messageID 为空时链接指向会话本身。
+// 链接可以用 crush open 打开，界面会滚动到该消息。
+func MessageLink(sessionID, messageID string) string {
+	if messageID == "" {
+		return linkPrefix + sessionID
+	}
+	return linkPrefix + sessionID + "/msg/" + messageID
+}
+
+// ParseLink 解析会话链接，返回会话 ID 和消息 ID。除了 [MessageLink] 生成的链接，
+// 还接受 <会话 ID>#<消息 ID> 和单独的会话 ID。链接中没有消息时消息 ID 为空。
+func ParseLink(link string) (sessionID, messageID string, err error) {
+	link = strings.TrimSpace(link)
+	if rest, ok := strings.CutPrefix(link, linkPrefix); ok {
+		sessionID, messageID, ok = strings.Cut(rest, "/msg/")
+		if (ok && messageID == "") || strings.Contains(sessionID, "/") || strings.Contains(messageID, "/") {
+			return "", "", fmt.Errorf("无效的会话链接: %s", link)
+		}
+	} else if strings.Contains(link, "://") {
+		return "", "", fmt.Errorf("无效的会话链接: %s", link)
+	} else {
+		var ok bool
+		sessionID, messageID, ok = strings.Cut(link, "#")
+		if ok && messageID == "" {
+			return "", "", fmt.Errorf("无效的会话链接: %s", link)
+		}
+	}
+	if sessionID == "" {
+		return "", "", errors.New("会话链接中缺少会话 ID")
+	}
+	return sessionID, messageID, nil
+}
@@ -0,0 +1,44 @@
+package session
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLink(t *testing.T) {
+	t.Parallel()
+
+	for _, link := range []string{
+		MessageLink("s1", "m1"),
+		"crush://session/s1/msg/m1",
+		"s1#m1",
+		" s1#m1\n",
+	} {
+		sessionID, messageID, err := ParseLink(link)
+		require.NoError(t, err, link)
+		require.Equal(t, "s1", sessionID, link)
+		require.Equal(t, "m1", messageID, link)
+	}
+
+	for _, link := range []string{MessageLink("s1", ""), "s1"} {
+		sessionID, messageID, err := ParseLink(link)
+		require.NoError(t, err, link)
+		require.Equal(t, "s1", sessionID, link)
+		require.Empty(t, messageID, link)
+	}
+
+	for _, link := range []string{
+		"",
+		"crush://session/",
+		"crush://session/s1/msg/",
+		"crush://session/s1/other/m1",
+		"crush://session/s1/msg/m1/extra",
+		"https://example.com/s1",
+		"s1#",
+		"#m1",
+	} {
+		_, _, err := ParseLink(link)
+		require.Error(t, err, link)
+	}
+}
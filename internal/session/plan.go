@@ -0,0 +1,86 @@
+package session
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// PlanMode 是会话的计划模式状态。计划模式下智能体必须先用待办事项写出编号的计划，
+// 用户批准后才能使用修改文件或执行命令的工具。
+type PlanMode string
+
+const (
+	// PlanModeOff 表示未启用计划模式
+	PlanModeOff PlanMode = ""
+	// PlanModePlanning 表示正在制定计划或等待用户批准，修改类工具被禁止
+	PlanModePlanning PlanMode = "planning"
+	// PlanModeApproved 表示用户已批准计划，智能体可以执行。计划全部完成后回到 [PlanModePlanning]
+	PlanModeApproved PlanMode = "approved"
+)
+
+// Enabled 报告是否启用了计划模式。
+func (m PlanMode) Enabled() bool {
+	return m != PlanModeOff
+}
+
+// PlanAwaitingApproval 报告会话是否有等待用户批准的计划。
+func (s Session) PlanAwaitingApproval() bool {
+	return s.PlanMode == PlanModePlanning && len(s.Todos) > 0
+}
+
+var (
+	planNumberPattern   = regexp.MustCompile(`^(\d+[.)]|[-*])\s*`)
+	planCheckboxPattern = regexp.MustCompile(`^\[([ xX~])\]\s*`)
+)
+
+// FormatPlan 将待办事项格式化为每行一项的编号清单，例如 "1. [ ] 运行测试"。
+// [x] 表示已完成，[~] 表示进行中。
+func FormatPlan(todos []Todo) string {
+	lines := make([]string, len(todos))
+	for i, todo := range todos {
+		mark := " "
+		switch todo.Status {
+		case TodoStatusCompleted:
+			mark = "x"
+		case TodoStatusInProgress:
+			mark = "~"
+		}
+		lines[i] = fmt.Sprintf("%d. [%s] %s", i+1, mark, todo.Content)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// ParsePlan 解析 [FormatPlan] 格式的清单，编号和复选框都可以省略，空行会被忽略。
+// 内容与 previous 中某项相同的待办事项保留其进行时形式。
+func ParsePlan(text string, previous []Todo) ([]Todo, error) {
+	activeForms := make(map[string]string, len(previous))
+	for _, todo := range previous {
+		activeForms[todo.Content] = todo.ActiveForm
+	}
+
+	var todos []Todo
+	for line := range strings.SplitSeq(text, "\n") {
+		line = planNumberPattern.ReplaceAllString(strings.TrimSpace(line), "")
+		status := TodoStatusPending
+		if m := planCheckboxPattern.FindStringSubmatch(line); m != nil {
+			switch m[1] {
+			case "x", "X":
+				status = TodoStatusCompleted
+			case "~":
+				status = TodoStatusInProgress
+			}
+			line = line[len(m[0]):]
+		}
+		content := strings.TrimSpace(line)
+		if content == "" {
+			continue
+		}
+		todos = append(todos, Todo{Content: content, Status: status, ActiveForm: activeForms[content]})
+	}
+	if len(todos) == 0 {
+		return nil, errors.New("计划中没有步骤")
+	}
+	return todos, nil
+}
@@ -0,0 +1,61 @@
+package session
+
+import (
+	"testing"
+
+	"github.com/purpose168/crush-cn/internal/db"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePlan(t *testing.T) {
+	t.Parallel()
+
+	previous := []Todo{{Content: "Run the tests", Status: TodoStatusPending, ActiveForm: "Running the tests"}}
+	todos, err := ParsePlan("1. [x] Read the config\n\n2) [~] Update the parser\n- Run the tests\n  Add docs  ", previous)
+	require.NoError(t, err)
+	require.Equal(t, []Todo{
+		{Content: "Read the config", Status: TodoStatusCompleted},
+		{Content: "Update the parser", Status: TodoStatusInProgress},
+		{Content: "Run the tests", Status: TodoStatusPending, ActiveForm: "Running the tests"},
+		{Content: "Add docs", Status: TodoStatusPending},
+	}, todos)
+	require.Equal(t, "1. [x] Read the config\n2. [~] Update the parser\n3. [ ] Run the tests\n4. [ ] Add docs", FormatPlan(todos))
+
+	roundTrip, err := ParsePlan(FormatPlan(todos), todos)
+	require.NoError(t, err)
+	require.Equal(t, todos, roundTrip)
+
+	_, err = ParsePlan("\n1. [ ] \n", nil)
+	require.Error(t, err)
+}
+
+func TestServiceSetPlanMode(t *testing.T) {
+	t.Parallel()
+
+	conn, err := db.Connect(t.Context(), t.TempDir())
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	svc := NewService(db.New(conn), conn, "")
+
+	sess, err := svc.Create(t.Context(), "plan")
+	require.NoError(t, err)
+	require.False(t, sess.PlanMode.Enabled())
+
+	sess, err = svc.SetPlanMode(t.Context(), sess.ID, PlanModePlanning)
+	require.NoError(t, err)
+	require.Equal(t, PlanModePlanning, sess.PlanMode)
+	require.False(t, sess.PlanAwaitingApproval())
+
+	sess, err = svc.SetTodos(t.Context(), sess.ID, []Todo{{Content: "Update the parser", Status: TodoStatusPending}})
+	require.NoError(t, err)
+	require.True(t, sess.PlanAwaitingApproval())
+
+	// 保存会话的其他字段不会覆盖计划模式
+	sess.Title = "renamed"
+	_, err = svc.Save(t.Context(), sess)
+	require.NoError(t, err)
+	got, err := svc.Get(t.Context(), sess.ID)
+	require.NoError(t, err)
+	require.Equal(t, PlanModePlanning, got.PlanMode)
+	require.Len(t, got.Todos, 1)
+}
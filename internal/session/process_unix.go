@@ -0,0 +1,23 @@
+//go:build !windows
+
+package session
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+// processRunning 报告 pid 对应的进程是否仍在运行
+func processRunning(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	err = p.Signal(syscall.Signal(0))
+	// EPERM 表示进程存在但属于其他用户
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
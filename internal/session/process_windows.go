@@ -0,0 +1,18 @@
+//go:build windows
+
+package session
+
+import "os"
+
+// processRunning 报告 pid 对应的进程是否仍在运行。Windows 上打开不存在的进程会失败。
+func processRunning(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	_ = p.Release()
+	return true
+}
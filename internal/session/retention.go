@@ -0,0 +1,44 @@
+package session
+
+import "time"
+
+// RetentionPolicy 决定哪些旧会话应被清理。零值表示不清理任何会话。
+type RetentionPolicy struct {
+	// MaxSessions 是最多保留的未归档会话数，0 表示不限制。
+	MaxSessions int
+	// MaxAge 是会话最后一次更新后保留的时长，0 表示不限制。
+	MaxAge time.Duration
+}
+
+// Enabled 报告策略是否会清理会话。
+func (p RetentionPolicy) Enabled() bool {
+	return p.MaxSessions > 0 || p.MaxAge > 0
+}
+
+// Expired 返回 sessions 中超出保留策略的会话，顺序与 sessions 相同。sessions 需按更新时间
+// 从新到旧排列，与 [Service.List] 的顺序一致。已归档的会话永远不会被清理，也不计入
+// MaxSessions；keep 返回 true 的会话（例如当前打开或正在运行的会话）计入 MaxSessions，
+// 但不会被清理。keep 可以为 nil。
+func (p RetentionPolicy) Expired(sessions []Session, now time.Time, keep func(Session) bool) []Session {
+	if !p.Enabled() {
+		return nil
+	}
+	var expired []Session
+	rank := 0
+	for _, s := range sessions {
+		if s.IsArchived() {
+			continue
+		}
+		rank++
+		tooMany := p.MaxSessions > 0 && rank > p.MaxSessions
+		tooOld := p.MaxAge > 0 && now.Sub(time.Unix(s.UpdatedAt, 0)) > p.MaxAge
+		if !tooMany && !tooOld {
+			continue
+		}
+		if keep != nil && keep(s) {
+			continue
+		}
+		expired = append(expired, s)
+	}
+	return expired
+}
@@ -0,0 +1,99 @@
+package session
+
+import (
+	"testing"
+	"time"
+
+	"github.com/purpose168/crush-cn/internal/db"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRetentionPolicyExpired(t *testing.T) {
+	t.Parallel()
+
+	now := time.Unix(1_700_000_000, 0)
+	day := int64(24 * time.Hour / time.Second)
+	sessions := []Session{
+		{ID: "a", UpdatedAt: now.Unix()},
+		{ID: "b", UpdatedAt: now.Unix() - day, ArchivedAt: now.Unix()},
+		{ID: "c", UpdatedAt: now.Unix() - 2*day},
+		{ID: "d", UpdatedAt: now.Unix() - 10*day},
+		{ID: "e", UpdatedAt: now.Unix() - 40*day},
+	}
+	ids := func(sessions []Session) []string {
+		var out []string
+		for _, s := range sessions {
+			out = append(out, s.ID)
+		}
+		return out
+	}
+
+	t.Run("disabled", func(t *testing.T) {
+		t.Parallel()
+		require.Empty(t, RetentionPolicy{}.Expired(sessions, now, nil))
+	})
+
+	t.Run("max sessions skips archived", func(t *testing.T) {
+		t.Parallel()
+		expired := RetentionPolicy{MaxSessions: 2}.Expired(sessions, now, nil)
+		require.Equal(t, []string{"d", "e"}, ids(expired))
+	})
+
+	t.Run("max age", func(t *testing.T) {
+		t.Parallel()
+		expired := RetentionPolicy{MaxAge: 7 * 24 * time.Hour}.Expired(sessions, now, nil)
+		require.Equal(t, []string{"d", "e"}, ids(expired))
+	})
+
+	t.Run("kept sessions count but are not expired", func(t *testing.T) {
+		t.Parallel()
+		keep := func(s Session) bool { return s.ID == "d" }
+		expired := RetentionPolicy{MaxSessions: 1}.Expired(sessions, now, keep)
+		require.Equal(t, []string{"c", "e"}, ids(expired))
+	})
+}
+
+func TestServiceArchiveAndDelete(t *testing.T) {
+	t.Parallel()
+
+	conn, err := db.Connect(t.Context(), t.TempDir())
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	q := db.New(conn)
+	svc := NewService(q, conn, "")
+
+	parent, err := svc.Create(t.Context(), "parent")
+	require.NoError(t, err)
+	other, err := svc.Create(t.Context(), "other")
+	require.NoError(t, err)
+	child, err := svc.CreateTaskSession(t.Context(), "call-1", parent.ID, "task")
+	require.NoError(t, err)
+	_, err = svc.CreateTaskSession(t.Context(), "call-2", child.ID, "nested")
+	require.NoError(t, err)
+
+	archived, err := svc.Archive(t.Context(), other.ID)
+	require.NoError(t, err)
+	require.True(t, archived.IsArchived())
+
+	listed, err := svc.List(t.Context())
+	require.NoError(t, err)
+	require.Len(t, listed, 1)
+	require.Equal(t, parent.ID, listed[0].ID)
+
+	archivedList, err := svc.ListArchived(t.Context())
+	require.NoError(t, err)
+	require.Len(t, archivedList, 1)
+	require.Equal(t, other.ID, archivedList[0].ID)
+
+	unarchived, err := svc.Unarchive(t.Context(), other.ID)
+	require.NoError(t, err)
+	require.False(t, unarchived.IsArchived())
+
+	require.NoError(t, svc.Delete(t.Context(), parent.ID))
+	for _, id := range []string{parent.ID, "call-1", "call-2"} {
+		_, err := svc.Get(t.Context(), id)
+		require.Error(t, err, id)
+	}
+	_, err = svc.Get(t.Context(), other.ID)
+	require.NoError(t, err)
+}
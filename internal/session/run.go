@@ -0,0 +1,104 @@
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/purpose168/crush-cn/internal/db"
+)
+
+// InterruptedRunMaxAge 是提示恢复被中断运行的时限，更早开始的运行不再提示。
+const InterruptedRunMaxAge = 7 * 24 * time.Hour
+
+// Run 是会话中正在进行的智能体运行。运行正常结束或被用户取消时记录会被删除，
+// 进程退出后仍然存在的记录表示运行被中断。
+type Run struct {
+	SessionID string
+	// Prompt 是被中断的提示
+	Prompt string
+	// Queued 是排在被中断运行之后的提示
+	Queued []string
+	// PID 是运行智能体的进程
+	PID       int
+	StartedAt int64
+}
+
+// StartRun 记录会话开始运行 prompt，覆盖会话之前的运行记录。
+func (s *service) StartRun(ctx context.Context, sessionID, prompt string, queued []string) error {
+	queuedJSON, err := marshalQueued(queued)
+	if err != nil {
+		return err
+	}
+	return s.q.StartSessionRun(ctx, db.StartSessionRunParams{
+		SessionID: sessionID,
+		Prompt:    prompt,
+		Queued:    queuedJSON,
+		Pid:       int64(os.Getpid()),
+	})
+}
+
+// SetRunQueue 更新会话运行记录中排队的提示。会话没有运行记录时不做任何事。
+func (s *service) SetRunQueue(ctx context.Context, sessionID string, queued []string) error {
+	queuedJSON, err := marshalQueued(queued)
+	if err != nil {
+		return err
+	}
+	return s.q.UpdateSessionRunQueue(ctx, db.UpdateSessionRunQueueParams{
+		SessionID: sessionID,
+		Queued:    queuedJSON,
+	})
+}
+
+// FinishRun 删除会话的运行记录。
+func (s *service) FinishRun(ctx context.Context, sessionID string) error {
+	return s.q.DeleteSessionRun(ctx, sessionID)
+}
+
+// InterruptedRun 返回最近一次被中断的运行。仍在其他进程（例如同一项目中另一个
+// 正在运行的实例）中进行的运行，以及超过 [InterruptedRunMaxAge] 的运行会被跳过。
+func (s *service) InterruptedRun(ctx context.Context) (Run, bool, error) {
+	dbRuns, err := s.q.ListSessionRuns(ctx)
+	if err != nil {
+		return Run{}, false, err
+	}
+	now := time.Now()
+	for _, item := range dbRuns {
+		run := fromDBRun(item)
+		if now.Sub(time.Unix(run.StartedAt, 0)) > InterruptedRunMaxAge {
+			continue
+		}
+		if run.PID == os.Getpid() || processRunning(run.PID) {
+			continue
+		}
+		return run, true, nil
+	}
+	return Run{}, false, nil
+}
+
+func fromDBRun(item db.SessionRun) Run {
+	var queued []string
+	if err := json.Unmarshal([]byte(item.Queued), &queued); err != nil {
+		slog.Error("Failed to unmarshal queued prompts", "session_id", item.SessionID, "error", err)
+	}
+	return Run{
+		SessionID: item.SessionID,
+		Prompt:    item.Prompt,
+		Queued:    queued,
+		PID:       int(item.Pid),
+		StartedAt: item.StartedAt,
+	}
+}
+
+func marshalQueued(queued []string) (string, error) {
+	if len(queued) == 0 {
+		return "[]", nil
+	}
+	data, err := json.Marshal(queued)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
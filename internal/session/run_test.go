@@ -0,0 +1,75 @@
+package session
+
+import (
+	"os"
+	"testing"
+
+	"github.com/purpose168/crush-cn/internal/db"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServiceInterruptedRun(t *testing.T) {
+	t.Parallel()
+
+	conn, err := db.Connect(t.Context(), t.TempDir())
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	q := db.New(conn)
+	svc := NewService(q, conn, "")
+
+	sess, err := svc.Create(t.Context(), "busy")
+	require.NoError(t, err)
+
+	_, ok, err := svc.InterruptedRun(t.Context())
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	// 当前进程中的运行没有被中断
+	require.NoError(t, svc.StartRun(t.Context(), sess.ID, "fix the bug", nil))
+	require.NoError(t, svc.SetRunQueue(t.Context(), sess.ID, []string{"then add tests"}))
+	_, ok, err = svc.InterruptedRun(t.Context())
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	// 模拟已经退出的进程留下的记录
+	_, err = conn.ExecContext(t.Context(), "UPDATE session_runs SET pid = ? WHERE session_id = ?", exitedPID(t), sess.ID)
+	require.NoError(t, err)
+	run, ok, err := svc.InterruptedRun(t.Context())
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, sess.ID, run.SessionID)
+	require.Equal(t, "fix the bug", run.Prompt)
+	require.Equal(t, []string{"then add tests"}, run.Queued)
+
+	_, err = svc.Archive(t.Context(), sess.ID)
+	require.NoError(t, err)
+	_, ok, err = svc.InterruptedRun(t.Context())
+	require.NoError(t, err)
+	require.False(t, ok)
+	_, err = svc.Unarchive(t.Context(), sess.ID)
+	require.NoError(t, err)
+
+	require.NoError(t, svc.FinishRun(t.Context(), sess.ID))
+	_, ok, err = svc.InterruptedRun(t.Context())
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	// 删除会话时一起删除运行记录
+	require.NoError(t, svc.StartRun(t.Context(), sess.ID, "again", nil))
+	require.NoError(t, svc.Delete(t.Context(), sess.ID))
+	runs, err := q.ListSessionRuns(t.Context())
+	require.NoError(t, err)
+	require.Empty(t, runs)
+}
+
+// exitedPID 返回一个已经退出的进程的 PID
+func exitedPID(t *testing.T) int {
+	t.Helper()
+	exe, err := os.Executable()
+	require.NoError(t, err)
+	p, err := os.StartProcess(exe, []string{exe, "-test.run=^$"}, &os.ProcAttr{})
+	require.NoError(t, err)
+	_, err = p.Wait()
+	require.NoError(t, err)
+	return p.Pid
+}
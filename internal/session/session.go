@@ -6,7 +6,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"log/slog"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/purpose168/crush-cn/internal/db"
@@ -38,8 +40,27 @@ type Session struct {
 	SummaryMessageID string
 	Cost             float64
 	Todos            []Todo
+	PinnedFiles      []string
 	CreatedAt        int64
 	UpdatedAt        int64
+	// ArchivedAt 是会话的归档时间（Unix 时间戳），未归档时为 0。
+	// 归档的会话不会出现在会话列表中，但仍保留在磁盘上。
+	ArchivedAt int64
+	// Env 是注入到每次 bash 工具调用的环境变量，覆盖同名的系统环境变量。
+	Env map[string]string
+	// PlanMode 是会话的计划模式状态。
+	PlanMode PlanMode
+	// Author 是创建会话的用户，格式为 "Name <email>"，未配置用户身份时为空。
+	Author string
+	// WorkingDir 是会话的工作目录，相对于项目根目录，为空时使用项目根目录。
+	// 影响 bash 工具的工作目录以及 glob、grep、ls 等工具的默认路径。
+	WorkingDir string
+	// Bookmarks 是用户在聊天中标记的书签，按添加顺序排列。
+	Bookmarks []Bookmark
+	// Tags 是会话的标签（例如 bug、refactor），用于在会话列表中筛选，均为小写。
+	Tags []string
+	// Variants 是最后一轮对话的备选回复版本，由重新生成回复产生。
+	Variants []Variant
 }
 
 type Service interface {
@@ -49,10 +70,27 @@ type Service interface {
 	CreateTaskSession(ctx context.Context, toolCallID, parentSessionID, title string) (Session, error)
 	Get(ctx context.Context, id string) (Session, error)
 	List(ctx context.Context) ([]Session, error)
+	ListArchived(ctx context.Context) ([]Session, error)
 	Save(ctx context.Context, session Session) (Session, error)
 	UpdateTitleAndUsage(ctx context.Context, sessionID, title string, promptTokens, completionTokens int64, cost float64) error
+	SetPinnedFiles(ctx context.Context, sessionID string, paths []string) (Session, error)
+	SetEnv(ctx context.Context, sessionID string, env map[string]string) (Session, error)
+	SetPlanMode(ctx context.Context, sessionID string, mode PlanMode) (Session, error)
+	SetWorkingDir(ctx context.Context, sessionID, dir string) (Session, error)
+	SetTodos(ctx context.Context, sessionID string, todos []Todo) (Session, error)
+	SetBookmarks(ctx context.Context, sessionID string, bookmarks []Bookmark) (Session, error)
+	SetTags(ctx context.Context, sessionID string, tags []string) (Session, error)
+	SetVariants(ctx context.Context, sessionID string, variants []Variant) (Session, error)
+	Archive(ctx context.Context, id string) (Session, error)
+	Unarchive(ctx context.Context, id string) (Session, error)
 	Delete(ctx context.Context, id string) error
 
+	// 运行记录，用于在启动时发现被中断的运行
+	StartRun(ctx context.Context, sessionID, prompt string, queued []string) error
+	SetRunQueue(ctx context.Context, sessionID string, queued []string) error
+	FinishRun(ctx context.Context, sessionID string) error
+	InterruptedRun(ctx context.Context) (Run, bool, error)
+
 	// 代理工具会话管理
 	CreateAgentToolSessionID(messageID, toolCallID string) string
 	ParseAgentToolSessionID(sessionID string) (messageID string, toolCallID string, ok bool)
@@ -63,12 +101,15 @@ type service struct {
 	*pubsub.Broker[Session]
 	db *sql.DB
 	q  *db.Queries
+	// author 记录为新会话的作者
+	author string
 }
 
 func (s *service) Create(ctx context.Context, title string) (Session, error) {
 	dbSession, err := s.q.CreateSession(ctx, db.CreateSessionParams{
-		ID:    uuid.New().String(),
-		Title: title,
+		ID:     uuid.New().String(),
+		Title:  title,
+		Author: s.author,
 	})
 	if err != nil {
 		return Session{}, err
@@ -84,6 +125,7 @@ func (s *service) CreateTaskSession(ctx context.Context, toolCallID, parentSessi
 		ID:              toolCallID,
 		ParentSessionID: sql.NullString{String: parentSessionID, Valid: true},
 		Title:           title,
+		Author:          s.author,
 	})
 	if err != nil {
 		return Session{}, err
@@ -98,6 +140,7 @@ func (s *service) CreateTitleSession(ctx context.Context, parentSessionID string
 		ID:              "title-" + parentSessionID,
 		ParentSessionID: sql.NullString{String: parentSessionID, Valid: true},
 		Title:           "Generate a title",
+		Author:          s.author,
 	})
 	if err != nil {
 		return Session{}, err
@@ -126,6 +169,11 @@ func (s *service) Delete(ctx context.Context, id string) error {
 	if err = qtx.DeleteSessionFiles(ctx, dbSession.ID); err != nil {
 		return fmt.Errorf("deleting session files: %w", err)
 	}
+	// 子代理和标题生成会话不会单独出现在会话列表中，随父会话一起删除。
+	// 它们的消息和文件历史通过外键级联删除。
+	if err = qtx.DeleteSessionDescendants(ctx, sql.NullString{String: dbSession.ID, Valid: true}); err != nil {
+		return fmt.Errorf("deleting child sessions: %w", err)
+	}
 	if err = qtx.DeleteSession(ctx, dbSession.ID); err != nil {
 		return fmt.Errorf("deleting session: %w", err)
 	}
@@ -188,16 +236,207 @@ func (s *service) UpdateTitleAndUsage(ctx context.Context, sessionID, title stri
 	})
 }
 
+// SetPinnedFiles 仅更新会话的固定文件列表，避免与智能体并发保存会话时互相覆盖。
+func (s *service) SetPinnedFiles(ctx context.Context, sessionID string, paths []string) (Session, error) {
+	pinnedJSON, err := marshalPinnedFiles(paths)
+	if err != nil {
+		return Session{}, err
+	}
+	dbSession, err := s.q.UpdateSessionPinnedFiles(ctx, db.UpdateSessionPinnedFilesParams{
+		ID: sessionID,
+		PinnedFiles: sql.NullString{
+			String: pinnedJSON,
+			Valid:  pinnedJSON != "",
+		},
+	})
+	if err != nil {
+		return Session{}, err
+	}
+	session := s.fromDBItem(dbSession)
+	s.Publish(pubsub.UpdatedEvent, session)
+	return session, nil
+}
+
+// SetEnv 仅更新会话的环境变量，避免与智能体并发保存会话时互相覆盖。
+func (s *service) SetEnv(ctx context.Context, sessionID string, env map[string]string) (Session, error) {
+	envJSON, err := marshalEnv(env)
+	if err != nil {
+		return Session{}, err
+	}
+	dbSession, err := s.q.UpdateSessionEnv(ctx, db.UpdateSessionEnvParams{
+		ID: sessionID,
+		Env: sql.NullString{
+			String: envJSON,
+			Valid:  envJSON != "",
+		},
+	})
+	if err != nil {
+		return Session{}, err
+	}
+	session := s.fromDBItem(dbSession)
+	s.Publish(pubsub.UpdatedEvent, session)
+	return session, nil
+}
+
+// SetPlanMode 仅更新会话的计划模式状态。
+func (s *service) SetPlanMode(ctx context.Context, sessionID string, mode PlanMode) (Session, error) {
+	dbSession, err := s.q.UpdateSessionPlanMode(ctx, db.UpdateSessionPlanModeParams{
+		ID:       sessionID,
+		PlanMode: string(mode),
+	})
+	if err != nil {
+		return Session{}, err
+	}
+	session := s.fromDBItem(dbSession)
+	s.Publish(pubsub.UpdatedEvent, session)
+	return session, nil
+}
+
+// SetWorkingDir 仅更新会话的工作目录，dir 是相对于项目根目录的路径，见 [ResolveWorkingDir]。
+func (s *service) SetWorkingDir(ctx context.Context, sessionID, dir string) (Session, error) {
+	dbSession, err := s.q.UpdateSessionWorkingDir(ctx, db.UpdateSessionWorkingDirParams{
+		ID:         sessionID,
+		WorkingDir: dir,
+	})
+	if err != nil {
+		return Session{}, err
+	}
+	session := s.fromDBItem(dbSession)
+	s.Publish(pubsub.UpdatedEvent, session)
+	return session, nil
+}
+
+// SetTodos 仅更新会话的待办事项，例如用户编辑了计划。
+func (s *service) SetTodos(ctx context.Context, sessionID string, todos []Todo) (Session, error) {
+	todosJSON, err := marshalTodos(todos)
+	if err != nil {
+		return Session{}, err
+	}
+	dbSession, err := s.q.UpdateSessionTodos(ctx, db.UpdateSessionTodosParams{
+		ID: sessionID,
+		Todos: sql.NullString{
+			String: todosJSON,
+			Valid:  todosJSON != "",
+		},
+	})
+	if err != nil {
+		return Session{}, err
+	}
+	session := s.fromDBItem(dbSession)
+	s.Publish(pubsub.UpdatedEvent, session)
+	return session, nil
+}
+
+// SetBookmarks 仅更新会话的书签列表，避免与智能体并发保存会话时互相覆盖。
+func (s *service) SetBookmarks(ctx context.Context, sessionID string, bookmarks []Bookmark) (Session, error) {
+	bookmarksJSON, err := marshalBookmarks(bookmarks)
+	if err != nil {
+		return Session{}, err
+	}
+	dbSession, err := s.q.UpdateSessionBookmarks(ctx, db.UpdateSessionBookmarksParams{
+		ID: sessionID,
+		Bookmarks: sql.NullString{
+			String: bookmarksJSON,
+			Valid:  bookmarksJSON != "",
+		},
+	})
+	if err != nil {
+		return Session{}, err
+	}
+	session := s.fromDBItem(dbSession)
+	s.Publish(pubsub.UpdatedEvent, session)
+	return session, nil
+}
+
+// SetTags 仅更新会话的标签，避免与智能体并发保存会话时互相覆盖。
+func (s *service) SetTags(ctx context.Context, sessionID string, tags []string) (Session, error) {
+	tagsJSON, err := marshalTags(tags)
+	if err != nil {
+		return Session{}, err
+	}
+	dbSession, err := s.q.UpdateSessionTags(ctx, db.UpdateSessionTagsParams{
+		ID: sessionID,
+		Tags: sql.NullString{
+			String: tagsJSON,
+			Valid:  tagsJSON != "",
+		},
+	})
+	if err != nil {
+		return Session{}, err
+	}
+	session := s.fromDBItem(dbSession)
+	s.Publish(pubsub.UpdatedEvent, session)
+	return session, nil
+}
+
+// SetVariants 仅更新会话的备选回复版本，避免与智能体并发保存会话时互相覆盖。
+func (s *service) SetVariants(ctx context.Context, sessionID string, variants []Variant) (Session, error) {
+	variantsJSON, err := marshalVariants(variants)
+	if err != nil {
+		return Session{}, err
+	}
+	dbSession, err := s.q.UpdateSessionVariants(ctx, db.UpdateSessionVariantsParams{
+		ID: sessionID,
+		Variants: sql.NullString{
+			String: variantsJSON,
+			Valid:  variantsJSON != "",
+		},
+	})
+	if err != nil {
+		return Session{}, err
+	}
+	session := s.fromDBItem(dbSession)
+	s.Publish(pubsub.UpdatedEvent, session)
+	return session, nil
+}
+
+// Archive 归档会话。归档的会话不再出现在 [Service.List] 中，也不会被保留策略清理。
+func (s *service) Archive(ctx context.Context, id string) (Session, error) {
+	return s.setArchivedAt(ctx, id, sql.NullInt64{Int64: time.Now().Unix(), Valid: true})
+}
+
+// Unarchive 取消归档会话，使其重新出现在会话列表中。
+func (s *service) Unarchive(ctx context.Context, id string) (Session, error) {
+	return s.setArchivedAt(ctx, id, sql.NullInt64{})
+}
+
+func (s *service) setArchivedAt(ctx context.Context, id string, archivedAt sql.NullInt64) (Session, error) {
+	dbSession, err := s.q.UpdateSessionArchivedAt(ctx, db.UpdateSessionArchivedAtParams{
+		ID:         id,
+		ArchivedAt: archivedAt,
+	})
+	if err != nil {
+		return Session{}, err
+	}
+	session := s.fromDBItem(dbSession)
+	s.Publish(pubsub.UpdatedEvent, session)
+	return session, nil
+}
+
+// List 返回未归档的根会话，最近更新的排在前面。
 func (s *service) List(ctx context.Context) ([]Session, error) {
 	dbSessions, err := s.q.ListSessions(ctx)
 	if err != nil {
 		return nil, err
 	}
-	sessions := make([]Session, len(dbSessions))
-	for i, dbSession := range dbSessions {
-		sessions[i] = s.fromDBItem(dbSession)
+	return s.fromDBItems(dbSessions), nil
+}
+
+// ListArchived 返回已归档的根会话，最近归档的排在前面。
+func (s *service) ListArchived(ctx context.Context) ([]Session, error) {
+	dbSessions, err := s.q.ListArchivedSessions(ctx)
+	if err != nil {
+		return nil, err
 	}
-	return sessions, nil
+	return s.fromDBItems(dbSessions), nil
+}
+
+func (s service) fromDBItems(items []db.Session) []Session {
+	sessions := make([]Session, len(items))
+	for i, item := range items {
+		sessions[i] = s.fromDBItem(item)
+	}
+	return sessions
 }
 
 func (s service) fromDBItem(item db.Session) Session {
@@ -205,6 +444,26 @@ func (s service) fromDBItem(item db.Session) Session {
 	if err != nil {
 		slog.Error("Failed to unmarshal todos", "session_id", item.ID, "error", err)
 	}
+	pinnedFiles, err := unmarshalPinnedFiles(item.PinnedFiles.String)
+	if err != nil {
+		slog.Error("Failed to unmarshal pinned files", "session_id", item.ID, "error", err)
+	}
+	env, err := unmarshalEnv(item.Env.String)
+	if err != nil {
+		slog.Error("Failed to unmarshal session env", "session_id", item.ID, "error", err)
+	}
+	bookmarks, err := unmarshalBookmarks(item.Bookmarks.String)
+	if err != nil {
+		slog.Error("Failed to unmarshal bookmarks", "session_id", item.ID, "error", err)
+	}
+	tags, err := unmarshalTags(item.Tags.String)
+	if err != nil {
+		slog.Error("Failed to unmarshal tags", "session_id", item.ID, "error", err)
+	}
+	variants, err := unmarshalVariants(item.Variants.String)
+	if err != nil {
+		slog.Error("Failed to unmarshal variants", "session_id", item.ID, "error", err)
+	}
 	return Session{
 		ID:               item.ID,
 		ParentSessionID:  item.ParentSessionID.String,
@@ -215,8 +474,17 @@ func (s service) fromDBItem(item db.Session) Session {
 		SummaryMessageID: item.SummaryMessageID.String,
 		Cost:             item.Cost,
 		Todos:            todos,
+		PinnedFiles:      pinnedFiles,
+		Env:              env,
+		PlanMode:         PlanMode(item.PlanMode),
+		Author:           item.Author,
+		WorkingDir:       item.WorkingDir,
+		Bookmarks:        bookmarks,
+		Tags:             tags,
+		Variants:         variants,
 		CreatedAt:        item.CreatedAt,
 		UpdatedAt:        item.UpdatedAt,
+		ArchivedAt:       item.ArchivedAt.Int64,
 	}
 }
 
@@ -242,12 +510,85 @@ func unmarshalTodos(data string) ([]Todo, error) {
 	return todos, nil
 }
 
-func NewService(q *db.Queries, conn *sql.DB) Service {
+func marshalPinnedFiles(paths []string) (string, error) {
+	if len(paths) == 0 {
+		return "", nil
+	}
+	data, err := json.Marshal(paths)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+func unmarshalPinnedFiles(data string) ([]string, error) {
+	if data == "" {
+		return nil, nil
+	}
+	var paths []string
+	if err := json.Unmarshal([]byte(data), &paths); err != nil {
+		return nil, err
+	}
+	return paths, nil
+}
+
+func marshalEnv(env map[string]string) (string, error) {
+	if len(env) == 0 {
+		return "", nil
+	}
+	data, err := json.Marshal(env)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+func unmarshalEnv(data string) (map[string]string, error) {
+	if data == "" {
+		return nil, nil
+	}
+	var env map[string]string
+	if err := json.Unmarshal([]byte(data), &env); err != nil {
+		return nil, err
+	}
+	return env, nil
+}
+
+// IsArchived 报告会话是否已归档
+func (s Session) IsArchived() bool {
+	return s.ArchivedAt != 0
+}
+
+// AuthorName 返回会话作者的名字，没有名字时返回邮箱地址。
+func (s Session) AuthorName() string {
+	name, email, _ := strings.Cut(s.Author, "<")
+	if name = strings.TrimSpace(name); name != "" {
+		return name
+	}
+	return strings.TrimSuffix(email, ">")
+}
+
+// IsPinned 报告文件是否已固定到会话上下文中
+func (s Session) IsPinned(path string) bool {
+	return slices.Contains(s.PinnedFiles, path)
+}
+
+// TogglePin 返回固定或取消固定 path 后的文件列表，以及 path 是否处于固定状态
+func (s Session) TogglePin(path string) ([]string, bool) {
+	if i := slices.Index(s.PinnedFiles, path); i >= 0 {
+		return slices.Delete(slices.Clone(s.PinnedFiles), i, i+1), false
+	}
+	return append(slices.Clone(s.PinnedFiles), path), true
+}
+
+// author 是新会话记录的作者，格式为 "Name <email>"，可以为空。
+func NewService(q *db.Queries, conn *sql.DB, author string) Service {
 	broker := pubsub.NewBroker[Session]()
 	return &service{
 		Broker: broker,
 		db:     conn,
 		q:      q,
+		author: author,
 	}
 }
 
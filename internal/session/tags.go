@@ -0,0 +1,91 @@
+package session
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// maxTagLength 是单个标签的最大字符数
+const maxTagLength = 32
+
+// ParseTags 解析以逗号或空白分隔的标签文本。标签不区分大小写，统一转换为小写，
+// 开头的 # 会被去掉，重复的标签只保留第一个。
+func ParseTags(text string) ([]string, error) {
+	fields := strings.FieldsFunc(text, func(r rune) bool {
+		return r == ',' || r == '，' || unicode.IsSpace(r)
+	})
+	var tags []string
+	for _, field := range fields {
+		tag := strings.ToLower(strings.TrimPrefix(field, "#"))
+		if tag == "" {
+			continue
+		}
+		if len([]rune(tag)) > maxTagLength {
+			return nil, fmt.Errorf("标签过长（最多 %d 个字符）: %q", maxTagLength, tag)
+		}
+		if strings.ContainsAny(tag, "#\"'") {
+			return nil, fmt.Errorf("标签包含无效字符: %q", tag)
+		}
+		if !slices.Contains(tags, tag) {
+			tags = append(tags, tag)
+		}
+	}
+	return tags, nil
+}
+
+// FormatTags 将标签格式化为以逗号分隔的文本，是 [ParseTags] 的逆操作。
+func FormatTags(tags []string) string {
+	return strings.Join(tags, ", ")
+}
+
+// HasTag 报告会话是否带有标签，不区分大小写
+func (s Session) HasTag(tag string) bool {
+	tag = strings.ToLower(tag)
+	return slices.Contains(s.Tags, tag)
+}
+
+// AllTags 返回会话中用到的所有标签，按使用次数从多到少排列，次数相同时按名称排列。
+func AllTags(sessions []Session) []string {
+	counts := make(map[string]int)
+	var tags []string
+	for _, s := range sessions {
+		for _, tag := range s.Tags {
+			if counts[tag] == 0 {
+				tags = append(tags, tag)
+			}
+			counts[tag]++
+		}
+	}
+	slices.SortFunc(tags, func(a, b string) int {
+		if counts[a] != counts[b] {
+			return counts[b] - counts[a]
+		}
+		return strings.Compare(a, b)
+	})
+	return tags
+}
+
+func marshalTags(tags []string) (string, error) {
+	if len(tags) == 0 {
+		return "", nil
+	}
+	data, err := json.Marshal(tags)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+func unmarshalTags(data string) ([]string, error) {
+	if data == "" {
+		return nil, nil
+	}
+	var tags []string
+	if err := json.Unmarshal([]byte(data), &tags); err != nil {
+		return nil, err
+	}
+	return tags, nil
+}
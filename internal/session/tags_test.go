@@ -0,0 +1,70 @@
+package session
+
+import (
+	"testing"
+
+	"github.com/purpose168/crush-cn/internal/db"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTags(t *testing.T) {
+	t.Parallel()
+
+	tags, err := ParseTags(" Bug, #refactor spike，bug ")
+	require.NoError(t, err)
+	require.Equal(t, []string{"bug", "refactor", "spike"}, tags)
+	require.Equal(t, "bug, refactor, spike", FormatTags(tags))
+
+	tags, err = ParseTags("  ,, ")
+	require.NoError(t, err)
+	require.Empty(t, tags)
+
+	_, err = ParseTags("a#b")
+	require.Error(t, err)
+	_, err = ParseTags("this-tag-is-definitely-much-too-long-to-keep")
+	require.Error(t, err)
+}
+
+func TestAllTags(t *testing.T) {
+	t.Parallel()
+
+	sessions := []Session{
+		{Tags: []string{"spike", "bug"}},
+		{Tags: []string{"bug"}},
+		{},
+		{Tags: []string{"refactor"}},
+	}
+	require.Equal(t, []string{"bug", "refactor", "spike"}, AllTags(sessions))
+	require.True(t, sessions[0].HasTag("BUG"))
+	require.False(t, sessions[2].HasTag("bug"))
+}
+
+func TestServiceSetTags(t *testing.T) {
+	t.Parallel()
+
+	conn, err := db.Connect(t.Context(), t.TempDir())
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	svc := NewService(db.New(conn), conn, "")
+
+	sess, err := svc.Create(t.Context(), "flaky test")
+	require.NoError(t, err)
+	require.Empty(t, sess.Tags)
+
+	updated, err := svc.SetTags(t.Context(), sess.ID, []string{"bug", "spike"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"bug", "spike"}, updated.Tags)
+
+	// 保存会话的其他字段不会覆盖标签
+	updated.Title = "renamed"
+	_, err = svc.Save(t.Context(), updated)
+	require.NoError(t, err)
+	list, err := svc.List(t.Context())
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	require.Equal(t, []string{"bug", "spike"}, list[0].Tags)
+
+	cleared, err := svc.SetTags(t.Context(), sess.ID, nil)
+	require.NoError(t, err)
+	require.Empty(t, cleared.Tags)
+}
@@ -0,0 +1,69 @@
+package session
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+)
+
+// Variant 是最后一轮对话的一个备选回复版本，由用户消息及其后的所有消息组成。
+// 重新生成回复时，原来的版本保存为备选版本：它的消息保留在数据库中，但不发送给模型，
+// 也不显示在聊天中，用户比较后可以换回任意一个版本。
+type Variant struct {
+	// MessageIDs 是该版本的消息 ID，第一条是用户消息。
+	MessageIDs []string `json:"message_ids"`
+	// Model 和 Provider 是生成该版本回复的模型。
+	Model    string `json:"model,omitempty"`
+	Provider string `json:"provider,omitempty"`
+	// Preview 是回复内容的第一行，用于在版本列表中区分各个版本。
+	Preview string `json:"preview,omitempty"`
+	// CreatedAt 是该版本的生成时间（Unix 时间戳）。
+	CreatedAt int64 `json:"created_at"`
+}
+
+// HiddenMessages 返回备选版本中所有消息的 ID 集合，这些消息不属于当前的对话。
+func (s Session) HiddenMessages() map[string]bool {
+	if len(s.Variants) == 0 {
+		return nil
+	}
+	hidden := make(map[string]bool)
+	for _, v := range s.Variants {
+		for _, id := range v.MessageIDs {
+			hidden[id] = true
+		}
+	}
+	return hidden
+}
+
+// SwapVariant 用当前版本替换第 index 个备选版本，返回新的备选版本列表和被换回的版本。
+func SwapVariant(variants []Variant, index int, current Variant) ([]Variant, Variant, error) {
+	if index < 0 || index >= len(variants) {
+		return nil, Variant{}, fmt.Errorf("回复版本不存在: %d", index)
+	}
+	kept := variants[index]
+	variants = slices.Clone(variants)
+	variants[index] = current
+	return variants, kept, nil
+}
+
+func marshalVariants(variants []Variant) (string, error) {
+	if len(variants) == 0 {
+		return "", nil
+	}
+	data, err := json.Marshal(variants)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+func unmarshalVariants(data string) ([]Variant, error) {
+	if data == "" {
+		return nil, nil
+	}
+	var variants []Variant
+	if err := json.Unmarshal([]byte(data), &variants); err != nil {
+		return nil, err
+	}
+	return variants, nil
+}
@@ -0,0 +1,61 @@
+package session
+
+import (
+	"testing"
+
+	"github.com/purpose168/crush-cn/internal/db"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSwapVariant(t *testing.T) {
+	t.Parallel()
+
+	variants := []Variant{
+		{MessageIDs: []string{"u1", "a1"}, Model: "gpt"},
+		{MessageIDs: []string{"u2", "a2"}, Model: "claude"},
+	}
+	current := Variant{MessageIDs: []string{"u3", "a3"}, Model: "gemini"}
+
+	swapped, kept, err := SwapVariant(variants, 1, current)
+	require.NoError(t, err)
+	require.Equal(t, "claude", kept.Model)
+	require.Equal(t, []string{"gpt", "gemini"}, []string{swapped[0].Model, swapped[1].Model})
+	require.Equal(t, "claude", variants[1].Model, "不修改原列表")
+
+	_, _, err = SwapVariant(variants, 2, current)
+	require.Error(t, err)
+
+	s := Session{Variants: swapped}
+	require.Equal(t, map[string]bool{"u1": true, "a1": true, "u3": true, "a3": true}, s.HiddenMessages())
+	require.Nil(t, Session{}.HiddenMessages())
+}
+
+func TestServiceSetVariants(t *testing.T) {
+	t.Parallel()
+
+	conn, err := db.Connect(t.Context(), t.TempDir())
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	svc := NewService(db.New(conn), conn, "")
+
+	sess, err := svc.Create(t.Context(), "regenerate")
+	require.NoError(t, err)
+	require.Empty(t, sess.Variants)
+
+	variants := []Variant{{MessageIDs: []string{"u1", "a1"}, Model: "gpt", Provider: "openai", Preview: "hello", CreatedAt: 42}}
+	updated, err := svc.SetVariants(t.Context(), sess.ID, variants)
+	require.NoError(t, err)
+	require.Equal(t, variants, updated.Variants)
+
+	// 保存会话的其他字段不会覆盖备选版本
+	updated.Title = "renamed"
+	_, err = svc.Save(t.Context(), updated)
+	require.NoError(t, err)
+	got, err := svc.Get(t.Context(), sess.ID)
+	require.NoError(t, err)
+	require.Equal(t, variants, got.Variants)
+
+	cleared, err := svc.SetVariants(t.Context(), sess.ID, nil)
+	require.NoError(t, err)
+	require.Empty(t, cleared.Variants)
+}
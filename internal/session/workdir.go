@@ -0,0 +1,35 @@
+package session
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// ResolveWorkingDir 校验会话工作目录并返回相对于项目根目录 root 的路径。dir 可以是绝对路径
+// 或相对于 root 的路径，必须位于 root 之内。dir 为空或等于 root 时返回空字符串，表示使用项目根目录。
+func ResolveWorkingDir(root, dir string) (string, error) {
+	dir = strings.TrimSpace(dir)
+	if dir == "" {
+		return "", nil
+	}
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(root, dir)
+	}
+	rel, err := filepath.Rel(root, filepath.Clean(dir))
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("工作目录必须位于项目目录 %s 之内", root)
+	}
+	if rel == "." {
+		return "", nil
+	}
+	return filepath.ToSlash(rel), nil
+}
+
+// Dir 返回会话的工作目录：设置了 WorkingDir 时相对于项目根目录 root 解析，否则返回 root。
+func (s Session) Dir(root string) string {
+	if s.WorkingDir == "" {
+		return root
+	}
+	return filepath.Join(root, filepath.FromSlash(s.WorkingDir))
+}
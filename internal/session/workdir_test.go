@@ -0,0 +1,66 @@
+package session
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/purpose168/crush-cn/internal/db"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveWorkingDir(t *testing.T) {
+	t.Parallel()
+
+	root := filepath.Join(t.TempDir(), "repo")
+
+	for dir, want := range map[string]string{
+		"":                                     "",
+		" . ":                                  "",
+		root:                                   "",
+		"packages/api":                         "packages/api",
+		"./packages/api/":                      "packages/api",
+		filepath.Join(root, "packages", "web"): "packages/web",
+		"packages/../tools":                    "tools",
+	} {
+		got, err := ResolveWorkingDir(root, dir)
+		require.NoError(t, err, dir)
+		require.Equal(t, want, got, dir)
+	}
+
+	for _, dir := range []string{"..", "../other", filepath.Dir(root)} {
+		_, err := ResolveWorkingDir(root, dir)
+		require.Error(t, err, dir)
+	}
+
+	require.Equal(t, root, Session{}.Dir(root))
+	require.Equal(t, filepath.Join(root, "packages", "api"), Session{WorkingDir: "packages/api"}.Dir(root))
+}
+
+func TestServiceSetWorkingDir(t *testing.T) {
+	t.Parallel()
+
+	conn, err := db.Connect(t.Context(), t.TempDir())
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	svc := NewService(db.New(conn), conn, "")
+
+	sess, err := svc.Create(t.Context(), "monorepo")
+	require.NoError(t, err)
+	require.Empty(t, sess.WorkingDir)
+
+	updated, err := svc.SetWorkingDir(t.Context(), sess.ID, "packages/api")
+	require.NoError(t, err)
+	require.Equal(t, "packages/api", updated.WorkingDir)
+
+	// 保存会话的其他字段不会覆盖工作目录
+	updated.Title = "renamed"
+	_, err = svc.Save(t.Context(), updated)
+	require.NoError(t, err)
+	got, err := svc.Get(t.Context(), sess.ID)
+	require.NoError(t, err)
+	require.Equal(t, "packages/api", got.WorkingDir)
+
+	cleared, err := svc.SetWorkingDir(t.Context(), sess.ID, "")
+	require.NoError(t, err)
+	require.Empty(t, cleared.WorkingDir)
+}
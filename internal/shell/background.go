@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"io"
 	"slices"
 	"sync"
 	"sync/atomic"
@@ -17,6 +18,8 @@ const (
 	MaxBackgroundJobs = 50
 	// CompletedJobRetentionMinutes 是在自动清理之前保留已完成任务的时长（8小时）
 	CompletedJobRetentionMinutes = 8 * 60
+	// maxTailBytes 是为实时输出保留的最近输出字节数
+	maxTailBytes = 16 * 1024
 )
 
 // syncBuffer 是 bytes.Buffer 的线程安全包装器
@@ -46,6 +49,50 @@ func (sb *syncBuffer) String() string {
 	return sb.buf.String()
 }
 
+// tailBuffer 是线程安全的写入器，只保留最近写入的 maxTailBytes 字节。
+// 标准输出和标准错误都写入同一个 tailBuffer，因此保留了两者的交错顺序。
+type tailBuffer struct {
+	buf []byte
+	// written 是累计写入的字节数，用于判断输出是否有变化
+	written int64
+	mu      sync.RWMutex
+}
+
+// Write 追加数据，超出 maxTailBytes 时丢弃最旧的部分
+func (tb *tailBuffer) Write(p []byte) (n int, err error) {
+	tb.mu.Lock()
+	defer tb.mu.Unlock()
+	tb.written += int64(len(p))
+	tb.buf = append(tb.buf, p...)
+	if over := len(tb.buf) - maxTailBytes; over > 0 {
+		tb.buf = append(tb.buf[:0], tb.buf[over:]...)
+	}
+	return len(p), nil
+}
+
+// lines 返回最后 n 行以及累计写入的字节数。被截断的第一行会被丢弃
+func (tb *tailBuffer) lines(n int) (string, int64) {
+	tb.mu.RLock()
+	defer tb.mu.RUnlock()
+	data := bytes.TrimRight(tb.buf, "\n")
+	truncated := tb.written > int64(len(tb.buf))
+	for i := len(data) - 1; i >= 0; i-- {
+		if data[i] != '\n' {
+			continue
+		}
+		if n--; n == 0 {
+			return string(data[i+1:]), tb.written
+		}
+	}
+	if truncated {
+		// 缓冲区开头可能是半行
+		if i := bytes.IndexByte(data, '\n'); i >= 0 {
+			data = data[i+1:]
+		}
+	}
+	return string(data), tb.written
+}
+
 // BackgroundShell 表示在后台运行的 shell
 type BackgroundShell struct {
 	ID          string             // 任务唯一标识符
@@ -57,6 +104,7 @@ type BackgroundShell struct {
 	cancel      context.CancelFunc // 取消函数
 	stdout      *syncBuffer        // 标准输出缓冲区
 	stderr      *syncBuffer        // 标准错误输出缓冲区
+	tail        *tailBuffer        // 标准输出和标准错误交错的最近输出
 	done        chan struct{}      // 完成信号通道
 	exitErr     error              // 退出错误
 	completedAt int64              // 任务完成的 Unix 时间戳（0 表示仍在运行）
@@ -90,6 +138,14 @@ func GetBackgroundShellManager() *BackgroundShellManager {
 
 // Start 使用给定命令创建并启动一个新的后台 shell
 func (m *BackgroundShellManager) Start(ctx context.Context, workingDir string, blockFuncs []BlockFunc, command string, description string) (*BackgroundShell, error) {
+	return m.StartWithOptions(ctx, &Options{
+		WorkingDir: workingDir,
+		BlockFuncs: blockFuncs,
+	}, command, description)
+}
+
+// StartWithOptions 使用给定的 shell 选项创建并启动一个新的后台 shell
+func (m *BackgroundShellManager) StartWithOptions(ctx context.Context, opts *Options, command string, description string) (*BackgroundShell, error) {
 	// 检查任务数量限制
 	if m.shells.Len() >= MaxBackgroundJobs {
 		return nil, fmt.Errorf("已达到最大后台任务数（%d）。请终止或等待某些任务完成", MaxBackgroundJobs)
@@ -97,10 +153,8 @@ func (m *BackgroundShellManager) Start(ctx context.Context, workingDir string, b
 
 	id := fmt.Sprintf("%03X", idCounter.Add(1))
 
-	shell := NewShell(&Options{
-		WorkingDir: workingDir,
-		BlockFuncs: blockFuncs,
-	})
+	shell := NewShell(opts)
+	workingDir := shell.GetWorkingDir()
 
 	shellCtx, cancel := context.WithCancel(ctx)
 
@@ -114,6 +168,7 @@ func (m *BackgroundShellManager) Start(ctx context.Context, workingDir string, b
 		cancel:      cancel,
 		stdout:      &syncBuffer{},
 		stderr:      &syncBuffer{},
+		tail:        &tailBuffer{},
 		done:        make(chan struct{}),
 	}
 
@@ -123,7 +178,9 @@ func (m *BackgroundShellManager) Start(ctx context.Context, workingDir string, b
 	go func() {
 		defer close(bgShell.done)
 
-		err := shell.ExecStream(shellCtx, command, bgShell.stdout, bgShell.stderr)
+		stdout := io.MultiWriter(bgShell.stdout, bgShell.tail)
+		stderr := io.MultiWriter(bgShell.stderr, bgShell.tail)
+		err := shell.ExecStream(shellCtx, command, stdout, stderr)
 
 		bgShell.exitErr = err
 		atomic.StoreInt64(&bgShell.completedAt, time.Now().Unix())
@@ -228,6 +285,12 @@ func (bs *BackgroundShell) GetOutput() (stdout string, stderr string, done bool,
 	}
 }
 
+// Tail 返回最近输出的最后 n 行（标准输出和标准错误按写入顺序交错），以及累计输出的字节数。
+// 调用者可以比较字节数来判断输出自上次调用以来是否有变化。
+func (bs *BackgroundShell) Tail(n int) (string, int64) {
+	return bs.tail.lines(n)
+}
+
 // IsDone 检查后台 shell 是否已完成执行
 func (bs *BackgroundShell) IsDone() bool {
 	select {
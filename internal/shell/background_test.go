@@ -139,6 +139,34 @@ func TestBackgroundShell_IsDone(t *testing.T) {
 	manager.Kill(bgShell.ID)
 }
 
+func TestBackgroundShell_Tail(t *testing.T) {
+	t.Parallel()
+
+	manager := newBackgroundShellManager()
+	bgShell, err := manager.Start(t.Context(), t.TempDir(), nil, "echo one; echo two >&2; echo three; echo four", "")
+	require.NoError(t, err)
+	bgShell.Wait()
+
+	tail, written := bgShell.Tail(2)
+	require.Equal(t, "three\nfour", tail)
+	require.Equal(t, int64(len("one\ntwo\nthree\nfour\n")), written)
+
+	tail, _ = bgShell.Tail(10)
+	require.Equal(t, "one\ntwo\nthree\nfour", tail)
+}
+
+func TestTailBuffer_DropsPartialLine(t *testing.T) {
+	t.Parallel()
+
+	var tb tailBuffer
+	_, _ = tb.Write([]byte(strings.Repeat("x", maxTailBytes)))
+	_, _ = tb.Write([]byte("\nlast\n"))
+
+	tail, written := tb.lines(5)
+	require.Equal(t, "last", tail)
+	require.Equal(t, int64(maxTailBytes+6), written)
+}
+
 func TestBackgroundShell_WithBlockFuncs(t *testing.T) {
 	t.Parallel()
 
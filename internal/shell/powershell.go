@@ -0,0 +1,527 @@
+package shell
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"mvdan.cc/sh/v3/interp"
+)
+
+// powerShellExecutables 是按优先级查找的 PowerShell 可执行文件。
+// pwsh 是跨平台的 PowerShell 7+，powershell 是 Windows 自带的 Windows PowerShell 5.1。
+var powerShellExecutables = []string{"pwsh", "powershell"}
+
+// powerShellState 是命令执行结束时 PowerShell 写出的工作目录和环境变量，
+// 用于在多次执行之间保持状态。
+type powerShellState struct {
+	Cwd string            `json:"cwd"`
+	Env map[string]string `json:"env"`
+}
+
+// powerShellScript 是包装用户命令的脚本模板。%[1]s 是命令，%[2]s 是状态文件路径。
+// 命令执行后脚本会写出工作目录和环境变量，并把命令的成功状态转换为进程退出码。
+const powerShellScript = `$ErrorActionPreference = 'Continue'
+$ProgressPreference = 'SilentlyContinue'
+[Console]::OutputEncoding = [System.Text.Encoding]::UTF8
+$OutputEncoding = [System.Text.Encoding]::UTF8
+$global:LASTEXITCODE = 0
+$__crushExit = 0
+try {
+%[1]s
+$__crushOk = $?
+if ($null -ne $__crushChainOk) { $__crushOk = $__crushChainOk }
+if ($__crushOk) { $__crushExit = 0 } elseif ($global:LASTEXITCODE) { $__crushExit = $global:LASTEXITCODE } else { $__crushExit = 1 }
+} catch {
+[Console]::Error.WriteLine($_.ToString())
+$__crushExit = 1
+} finally {
+@{ cwd = (Get-Location).ProviderPath; env = [Environment]::GetEnvironmentVariables() } | ConvertTo-Json -Compress | Set-Content -LiteralPath %[2]s -Encoding UTF8
+}
+exit $__crushExit
+`
+
+// findPowerShell 返回可用的 PowerShell 可执行文件路径。
+func findPowerShell() (string, error) {
+	for _, name := range powerShellExecutables {
+		if path, err := exec.LookPath(name); err == nil {
+			return path, nil
+		}
+	}
+	return "", errors.New("未找到 PowerShell，请安装 pwsh 或改用 posix shell")
+}
+
+// execPowerShell 使用 PowerShell 执行命令。每次执行都是独立的 PowerShell 进程，
+// 工作目录和环境变量在执行结束后读回，以模拟持久化的 shell 会话。
+func (s *Shell) execPowerShell(ctx context.Context, command string, stdout, stderr io.Writer) error {
+	if err := s.checkPowerShellBlocked(command); err != nil {
+		return err
+	}
+
+	exe, err := findPowerShell()
+	if err != nil {
+		return err
+	}
+
+	dir, err := os.MkdirTemp("", "crush-pwsh-*")
+	if err != nil {
+		return fmt.Errorf("无法创建临时目录: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	statePath := filepath.Join(dir, "state.json")
+	scriptPath := filepath.Join(dir, "command.ps1")
+	script := fmt.Sprintf(powerShellScript, translatePOSIX(command), quotePowerShell(statePath))
+	// Windows PowerShell 5.1 只有在文件带 BOM 时才按 UTF-8 读取脚本。
+	if err := os.WriteFile(scriptPath, append([]byte("\ufeff"), script...), 0o600); err != nil {
+		return fmt.Errorf("无法写入脚本: %w", err)
+	}
+
+	cmd := exec.CommandContext(ctx, exe, "-NoLogo", "-NoProfile", "-NonInteractive", "-ExecutionPolicy", "Bypass", "-File", scriptPath)
+	cmd.Dir = s.cwd
+	cmd.Env = s.env
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	// 命令启动的子进程可能继续持有输出管道，取消后不再无限等待。
+	cmd.WaitDelay = time.Second
+
+	runErr := cmd.Run()
+	s.updateShellFromPowerShell(statePath)
+	s.logger.InfoPersist("命令执行完成", "command", command, "err", runErr)
+
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return ctxErr
+	}
+	var exitErr *exec.ExitError
+	if errors.As(runErr, &exitErr) {
+		return interp.ExitStatus(exitErr.ExitCode())
+	}
+	return runErr
+}
+
+// updateShellFromPowerShell 从状态文件更新工作目录和环境变量。读取失败时保持原状态。
+func (s *Shell) updateShellFromPowerShell(statePath string) {
+	data, err := os.ReadFile(statePath)
+	if err != nil {
+		return
+	}
+	var state powerShellState
+	if err := json.Unmarshal(bytes.TrimPrefix(data, []byte("\ufeff")), &state); err != nil {
+		return
+	}
+	if state.Cwd != "" {
+		s.cwd = state.Cwd
+	}
+	if len(state.Env) > 0 {
+		s.env = s.env[:0]
+		for _, name := range slices.Sorted(maps.Keys(state.Env)) {
+			s.env = append(s.env, name+"="+state.Env[name])
+		}
+	}
+}
+
+// checkPowerShellBlocked 检查命令中的每个管道段是否被阻止函数拦截。PowerShell
+// 不区分命令名的大小写，因此也会用小写的命令名再检查一次。
+func (s *Shell) checkPowerShellBlocked(command string) error {
+	segments, _ := splitChain(command)
+	for _, segment := range segments {
+		for _, part := range splitTopLevel(segment, '|') {
+			args := commandArgs(part)
+			if len(args) == 0 {
+				continue
+			}
+			lower := append([]string{strings.ToLower(args[0])}, args[1:]...)
+			for _, blockFunc := range s.blockFuncs {
+				if blockFunc(args) || blockFunc(lower) {
+					return fmt.Errorf("出于安全原因,不允许执行该命令: %q", args[0])
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// commandArgs 返回命令段去掉引号后的参数，跳过调用运算符 & 和 PowerShell 变量赋值。
+func commandArgs(segment string) []string {
+	words := splitWords(segment)
+	if len(words) > 0 && words[0] == "&" {
+		words = words[1:]
+	}
+	if len(words) == 0 || strings.HasPrefix(words[0], "$") {
+		return nil
+	}
+	args := make([]string, len(words))
+	for i, w := range words {
+		args[i] = unquote(w)
+	}
+	return args
+}
+
+// translatePOSIX 把 POSIX shell 的常见写法转换为 PowerShell 的等价写法，让习惯
+// bash 的模型在 PowerShell 中也能得到预期结果。它处理 && 和 ||（Windows PowerShell
+// 5.1 不支持），以及 export、unset、rm -rf、mkdir -p、touch、which 等命令。
+// 无法识别的命令原样保留。
+func translatePOSIX(command string) string {
+	segments, ops := splitChain(strings.TrimRight(command, " \t\r\n;"))
+	if len(segments) == 0 {
+		return command
+	}
+
+	var sb strings.Builder
+	chained := false
+	for i, segment := range segments {
+		translated := translateSegment(segment)
+		var op string
+		if i < len(ops) {
+			op = ops[i]
+		}
+		nextChained := op == "&&" || op == "||"
+		switch {
+		case chained:
+			// 在链中，segment 的执行取决于前一步的状态，由上一轮写出的 if 包裹。
+			sb.WriteString(strings.TrimLeft(translated, " \t"))
+			sb.WriteString("; $__crushStep = $? }")
+		case nextChained:
+			sb.WriteString(translated)
+			sb.WriteString("; $__crushStep = $?")
+		default:
+			sb.WriteString(translated)
+		}
+		switch op {
+		case "&&":
+			sb.WriteString("; if ($__crushStep) { ")
+		case "||":
+			sb.WriteString("; if (-not $__crushStep) { ")
+		case "":
+			if chained || nextChained {
+				// 链是最后一条语句时，用它的状态作为整个命令的结果。
+				sb.WriteString("; $__crushChainOk = $__crushStep")
+			}
+		default:
+			sb.WriteString(op)
+		}
+		chained = nextChained
+	}
+	return sb.String()
+}
+
+// translateSegment 转换单个简单命令。只转换管道中的第一个命令。
+func translateSegment(segment string) string {
+	leading := segment[:len(segment)-len(strings.TrimLeft(segment, " \t"))]
+	body := strings.TrimSpace(segment)
+	if body == "" {
+		return segment
+	}
+	pipeline := splitTopLevel(body, '|')
+	words := splitWords(pipeline[0])
+	if len(words) == 0 {
+		return segment
+	}
+	translated, ok := translateCommand(words)
+	if !ok {
+		return leading + replaceDevNull(body)
+	}
+	pipeline[0] = translated
+	return leading + replaceDevNull(strings.Join(pipeline, "|"))
+}
+
+// translateCommand 转换命令，words 保留引号。ok 为 false 表示不需要转换。
+func translateCommand(words []string) (string, bool) {
+	name, args := words[0], words[1:]
+	flags, operands := splitPOSIXFlags(args)
+	switch name {
+	case "export":
+		var parts []string
+		for _, arg := range operands {
+			key, value, found := strings.Cut(arg, "=")
+			if !found {
+				continue
+			}
+			parts = append(parts, fmt.Sprintf("$env:%s = %s", key, powerShellValue(value)))
+		}
+		if len(parts) == 0 {
+			return "", false
+		}
+		return strings.Join(parts, "; "), true
+	case "unset":
+		if len(operands) == 0 {
+			return "", false
+		}
+		var parts []string
+		for _, arg := range operands {
+			parts = append(parts, fmt.Sprintf("Remove-Item -LiteralPath Env:%s -ErrorAction SilentlyContinue", arg))
+		}
+		return strings.Join(parts, "; "), true
+	case "rm":
+		if len(flags) == 0 || len(operands) == 0 {
+			return "", false
+		}
+		cmd := "Remove-Item"
+		if flags.has('r', 'R') {
+			cmd += " -Recurse"
+		}
+		if flags.has('f') {
+			cmd += " -Force -ErrorAction SilentlyContinue"
+		}
+		return cmd + " -Path " + strings.Join(operands, ", "), true
+	case "mkdir":
+		if !flags.has('p') || len(operands) == 0 {
+			return "", false
+		}
+		return "New-Item -ItemType Directory -Force -Path " + strings.Join(operands, ", ") + " | Out-Null", true
+	case "cp":
+		if len(flags) == 0 || len(operands) < 2 {
+			return "", false
+		}
+		cmd := "Copy-Item"
+		if flags.has('r', 'R') {
+			cmd += " -Recurse"
+		}
+		if flags.has('f') {
+			cmd += " -Force"
+		}
+		last := len(operands) - 1
+		return cmd + " -Path " + strings.Join(operands[:last], ", ") + " -Destination " + operands[last], true
+	case "ls":
+		if len(flags) == 0 {
+			return "", false
+		}
+		cmd := "Get-ChildItem"
+		if flags.has('a') {
+			cmd += " -Force"
+		}
+		if flags.has('R') {
+			cmd += " -Recurse"
+		}
+		if len(operands) > 0 {
+			cmd += " -Path " + strings.Join(operands, ", ")
+		}
+		return cmd, true
+	case "touch":
+		if len(operands) == 0 {
+			return "", false
+		}
+		return "@(" + strings.Join(operands, ", ") + ") | ForEach-Object { if (Test-Path -LiteralPath $_) { (Get-Item -LiteralPath $_).LastWriteTime = Get-Date } else { New-Item -ItemType File -Path $_ | Out-Null } }", true
+	case "which":
+		if len(operands) == 0 {
+			return "", false
+		}
+		return "(Get-Command " + strings.Join(operands, ", ") + " -ErrorAction Stop).Source", true
+	}
+	return "", false
+}
+
+// posixFlags 是 POSIX 风格的短选项字母集合。
+type posixFlags string
+
+func (f posixFlags) has(letters ...byte) bool {
+	for _, l := range letters {
+		if strings.IndexByte(string(f), l) >= 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// splitPOSIXFlags 把参数拆分为短选项字母和操作数。"--" 之后的参数都视为操作数。
+func splitPOSIXFlags(args []string) (posixFlags, []string) {
+	var flags strings.Builder
+	var operands []string
+	for i, arg := range args {
+		if arg == "--" {
+			operands = append(operands, args[i+1:]...)
+			break
+		}
+		if len(arg) > 1 && arg[0] == '-' && arg[1] != '-' {
+			flags.WriteString(arg[1:])
+			continue
+		}
+		if strings.HasPrefix(arg, "--") {
+			switch arg {
+			case "--recursive":
+				flags.WriteByte('r')
+			case "--force":
+				flags.WriteByte('f')
+			case "--parents":
+				flags.WriteByte('p')
+			case "--all":
+				flags.WriteByte('a')
+			}
+			continue
+		}
+		operands = append(operands, arg)
+	}
+	return posixFlags(flags.String()), operands
+}
+
+// powerShellValue 把 POSIX 的赋值右值转换为 PowerShell 字符串字面量。
+// 带引号的值保持原样，PowerShell 对单双引号的处理与 POSIX 基本一致。
+func powerShellValue(value string) string {
+	if value == "" {
+		return "''"
+	}
+	if value[0] == '\'' || value[0] == '"' {
+		return value
+	}
+	if strings.Contains(value, "$") {
+		return `"` + value + `"`
+	}
+	return quotePowerShell(value)
+}
+
+// quotePowerShell 返回 s 的 PowerShell 单引号字面量。
+func quotePowerShell(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+// replaceDevNull 把重定向到 /dev/null 的写法替换为 $null。
+func replaceDevNull(segment string) string {
+	if !strings.Contains(segment, "/dev/null") {
+		return segment
+	}
+	for _, redirect := range []string{"&>", "2>", ">"} {
+		segment = strings.ReplaceAll(segment, redirect+" /dev/null", redirect+" $null")
+		segment = strings.ReplaceAll(segment, redirect+"/dev/null", redirect+"$null")
+	}
+	// PowerShell 不支持 &>，等价写法是 *>。
+	return strings.ReplaceAll(segment, "&> $null", "*> $null")
+}
+
+// splitChain 按顶层的 ;、换行、&& 和 || 拆分命令。引号、括号、花括号和 here-string
+// 中的分隔符不会拆分。ops[i] 是 segments[i] 之后的分隔符。
+func splitChain(command string) (segments []string, ops []string) {
+	var (
+		start, depth int
+		quote        byte
+	)
+	for i := 0; i < len(command); i++ {
+		c := command[i]
+		switch {
+		case quote != 0:
+			if c == '`' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+			continue
+		case c == '`':
+			i++
+			continue
+		case c == '\'' || c == '"':
+			if i > 0 && command[i-1] == '@' && i+1 < len(command) && (command[i+1] == '\n' || command[i+1] == '\r') {
+				// here-string 以独占一行的 '@ 或 "@ 结束。
+				if end := strings.Index(command[i:], "\n"+string(c)+"@"); end >= 0 {
+					i += end + 2
+					continue
+				}
+			}
+			quote = c
+			continue
+		case c == '(' || c == '{' || c == '[':
+			depth++
+			continue
+		case c == ')' || c == '}' || c == ']':
+			depth = max(0, depth-1)
+			continue
+		case depth > 0:
+			continue
+		}
+
+		var op string
+		switch {
+		case c == ';' || c == '\n':
+			op = string(c)
+		case (c == '&' || c == '|') && i+1 < len(command) && command[i+1] == c:
+			op = command[i : i+2]
+		default:
+			continue
+		}
+		segments = append(segments, command[start:i])
+		ops = append(ops, op)
+		i += len(op) - 1
+		start = i + 1
+	}
+	segments = append(segments, command[start:])
+	return segments, ops
+}
+
+// splitTopLevel 按不在引号或括号中的 sep 拆分 s。
+func splitTopLevel(s string, sep byte) []string {
+	var (
+		parts        []string
+		start, depth int
+		quote        byte
+	)
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '`':
+			i++
+		case c == '\'' || c == '"':
+			quote = c
+		case c == '(' || c == '{' || c == '[':
+			depth++
+		case c == ')' || c == '}' || c == ']':
+			depth = max(0, depth-1)
+		case c == sep && depth == 0:
+			// || 已由 splitChain 处理，这里只拆分单个 |。
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, s[start:])
+}
+
+// splitWords 按空白拆分命令段，保留单词中的引号。
+func splitWords(s string) []string {
+	var (
+		words []string
+		word  strings.Builder
+		quote byte
+	)
+	flush := func() {
+		if word.Len() > 0 {
+			words = append(words, word.String())
+			word.Reset()
+		}
+	}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == ' ' || c == '\t' || c == '\r' || c == '\n':
+			flush()
+			continue
+		}
+		word.WriteByte(c)
+	}
+	flush()
+	return words
+}
+
+// unquote 去掉单词首尾匹配的引号。
+func unquote(word string) string {
+	if len(word) >= 2 && (word[0] == '\'' || word[0] == '"') && word[len(word)-1] == word[0] {
+		return word[1 : len(word)-1]
+	}
+	return word
+}
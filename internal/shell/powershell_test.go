@@ -0,0 +1,131 @@
+package shell
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseShellType(t *testing.T) {
+	t.Parallel()
+
+	for name, want := range map[string]ShellType{
+		"":           ShellTypePOSIX,
+		"posix":      ShellTypePOSIX,
+		"PowerShell": ShellTypePowerShell,
+		"pwsh":       ShellTypePowerShell,
+	} {
+		got, err := ParseShellType(name)
+		require.NoError(t, err, name)
+		require.Equal(t, want, got, name)
+	}
+
+	_, err := ParseShellType("fish")
+	require.Error(t, err)
+}
+
+func TestTranslatePOSIX(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		command string
+		want    string
+	}{
+		{
+			name:    "原样保留 PowerShell 命令",
+			command: "Get-ChildItem -Force | Select-Object Name",
+			want:    "Get-ChildItem -Force | Select-Object Name",
+		},
+		{
+			name:    "export",
+			command: "export FOO=bar BAZ=\"$HOME/x\"",
+			want:    "$env:FOO = 'bar'; $env:BAZ = \"$HOME/x\"",
+		},
+		{
+			name:    "rm -rf",
+			command: "rm -rf build dist",
+			want:    "Remove-Item -Recurse -Force -ErrorAction SilentlyContinue -Path build, dist",
+		},
+		{
+			name:    "mkdir -p",
+			command: "mkdir -p a/b",
+			want:    "New-Item -ItemType Directory -Force -Path a/b | Out-Null",
+		},
+		{
+			name:    "重定向到 /dev/null",
+			command: "go build ./... 2>/dev/null",
+			want:    "go build ./... 2>$null",
+		},
+		{
+			name:    "&& 链",
+			command: "go build ./... && go test ./...",
+			want:    "go build ./...; $__crushStep = $?; if ($__crushStep) { go test ./...; $__crushStep = $? }; $__crushChainOk = $__crushStep",
+		},
+		{
+			name:    "|| 后接独立命令",
+			command: "which go || echo missing; echo done",
+			want:    "(Get-Command go -ErrorAction Stop).Source; $__crushStep = $?; if (-not $__crushStep) { echo missing; $__crushStep = $? }; echo done",
+		},
+		{
+			name:    "引号和花括号中的分隔符",
+			command: "echo 'a && b'; if ($x) { a; b }",
+			want:    "echo 'a && b'; if ($x) { a; b }",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			require.Equal(t, tt.want, translatePOSIX(tt.command))
+		})
+	}
+}
+
+func TestPowerShellBlocking(t *testing.T) {
+	t.Parallel()
+
+	sh := NewShell(&Options{
+		WorkingDir: t.TempDir(),
+		Type:       ShellTypePowerShell,
+		BlockFuncs: []BlockFunc{
+			CommandsBlocker([]string{"curl", "invoke-webrequest"}),
+			ArgumentsBlocker("npm", []string{"install"}, []string{"-g"}),
+		},
+	})
+
+	for _, command := range []string{
+		"curl https://example.com",
+		"echo hi; Invoke-WebRequest https://example.com",
+		"Get-Content x | & 'curl' -d @-",
+		"npm install -g typescript",
+	} {
+		_, _, err := sh.Exec(t.Context(), command)
+		require.ErrorContains(t, err, "不允许执行", command)
+	}
+}
+
+func TestPowerShellExec(t *testing.T) {
+	t.Parallel()
+	if _, err := findPowerShell(); err != nil {
+		t.Skip("未安装 PowerShell")
+	}
+
+	dir := t.TempDir()
+	sh := NewShell(&Options{WorkingDir: dir, Type: ShellTypePowerShell})
+
+	stdout, _, err := sh.Exec(t.Context(), "mkdir -p sub && cd sub && export GREETING=hello")
+	require.NoError(t, err, stdout)
+	require.Equal(t, filepath.Join(dir, "sub"), sh.GetWorkingDir())
+
+	stdout, _, err = sh.Exec(t.Context(), "Write-Output $env:GREETING")
+	require.NoError(t, err)
+	require.Equal(t, "hello", strings.TrimSpace(stdout))
+
+	_, _, err = sh.Exec(t.Context(), "exit 3")
+	require.Equal(t, 3, ExitCode(err))
+
+	_, _, err = sh.Exec(t.Context(), "Get-Item does-not-exist && Write-Output unreachable")
+	require.Equal(t, 1, ExitCode(err))
+}
@@ -0,0 +1,104 @@
+package shell
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+
+	"mvdan.cc/sh/v3/interp"
+	"mvdan.cc/sh/v3/syntax"
+)
+
+// Runner 在其他主机或隔离环境中执行命令，例如通过 SSH 连接的远程开发机或容器沙箱。
+type Runner interface {
+	// Run 在 dir 目录中执行命令，env 中 KEY=VALUE 形式的变量追加到对方的环境变量中。
+	// 命令以非零状态退出时返回 [*exec.ExitError]。
+	Run(ctx context.Context, dir string, env []string, command string, stdin io.Reader, stdout, stderr io.Writer) error
+}
+
+// execRemote 使用远程执行器执行命令。每次执行都是独立的远程 shell 进程，
+// 命令总是在 shell 的工作目录中开始执行，shell 的环境变量追加到远程主机的环境变量中。
+func (s *Shell) execRemote(ctx context.Context, command string, stdout, stderr io.Writer) error {
+	if err := s.checkBlocked(command); err != nil {
+		return err
+	}
+
+	runErr := s.remote.Run(ctx, s.cwd, s.env, command, nil, stdout, stderr)
+	s.logger.InfoPersist("命令执行完成", "command", command, "err", runErr)
+
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return ctxErr
+	}
+	var exitErr *exec.ExitError
+	if errors.As(runErr, &exitErr) {
+		return interp.ExitStatus(exitErr.ExitCode())
+	}
+	return runErr
+}
+
+// checkBlocked 在执行前检查命令中的每个简单命令是否被阻止函数拦截。
+// 远程命令不经过本地解释器，无法在执行时拦截。
+func (s *Shell) checkBlocked(command string) error {
+	file, err := syntax.NewParser().Parse(strings.NewReader(command), "")
+	if err != nil {
+		return fmt.Errorf("无法解析命令: %w", err)
+	}
+
+	var blocked error
+	syntax.Walk(file, func(node syntax.Node) bool {
+		if blocked != nil {
+			return false
+		}
+		call, ok := node.(*syntax.CallExpr)
+		if !ok || len(call.Args) == 0 {
+			return true
+		}
+		args := make([]string, len(call.Args))
+		for i, word := range call.Args {
+			args[i] = wordLiteral(word)
+		}
+		for _, blockFunc := range s.blockFuncs {
+			if blockFunc(args) {
+				blocked = fmt.Errorf("出于安全原因,不允许执行该命令: %q", args[0])
+				return false
+			}
+		}
+		return true
+	})
+	return blocked
+}
+
+// wordLiteral 返回单词去掉引号后的字面值，包含变量展开等动态部分时返回原始文本。
+func wordLiteral(word *syntax.Word) string {
+	var sb strings.Builder
+	for _, part := range word.Parts {
+		switch p := part.(type) {
+		case *syntax.Lit:
+			sb.WriteString(p.Value)
+		case *syntax.SglQuoted:
+			sb.WriteString(p.Value)
+		case *syntax.DblQuoted:
+			if len(p.Parts) != 1 {
+				return printWord(word)
+			}
+			lit, ok := p.Parts[0].(*syntax.Lit)
+			if !ok {
+				return printWord(word)
+			}
+			sb.WriteString(lit.Value)
+		default:
+			return printWord(word)
+		}
+	}
+	return sb.String()
+}
+
+// printWord 返回单词在源码中的文本
+func printWord(word *syntax.Word) string {
+	var sb strings.Builder
+	_ = syntax.NewPrinter().Print(&sb, word)
+	return sb.String()
+}
@@ -0,0 +1,79 @@
+package shell
+
+import (
+	"context"
+	"io"
+	"os"
+	"os/exec"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// localRunner 在本地执行命令，模拟远程主机
+type localRunner struct {
+	dirs []string
+	envs [][]string
+}
+
+func (r *localRunner) Run(ctx context.Context, dir string, env []string, command string, stdin io.Reader, stdout, stderr io.Writer) error {
+	r.dirs = append(r.dirs, dir)
+	r.envs = append(r.envs, env)
+	cmd := exec.CommandContext(ctx, "sh", "-c", command)
+	cmd.Env = append(os.Environ(), env...)
+	cmd.Stdin = stdin
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	return cmd.Run()
+}
+
+func TestRemoteShell(t *testing.T) {
+	t.Parallel()
+
+	runner := &localRunner{}
+	sh := NewShell(&Options{
+		WorkingDir: "/srv/project",
+		Remote:     runner,
+		BlockFuncs: []BlockFunc{CommandsBlocker([]string{"curl"})},
+	})
+
+	stdout, _, err := sh.Exec(t.Context(), "echo remote")
+	require.NoError(t, err)
+	require.Equal(t, "remote\n", stdout)
+	require.Equal(t, []string{"/srv/project"}, runner.dirs)
+
+	_, _, err = sh.Exec(t.Context(), "exit 7")
+	require.Equal(t, 7, ExitCode(err))
+
+	// 远程目录不在本地验证
+	require.NoError(t, sh.SetWorkingDir("/srv/other"))
+	require.Equal(t, "/srv/other", sh.GetWorkingDir())
+
+	for _, command := range []string{"curl example.com", "echo ok && 'curl' x", "echo $(curl x)", "true | \"curl\""} {
+		_, _, err = sh.Exec(t.Context(), command)
+		require.ErrorContains(t, err, "不允许执行该命令", command)
+	}
+	require.Len(t, runner.dirs, 2)
+}
+
+func TestRemoteShellEnv(t *testing.T) {
+	t.Parallel()
+
+	runner := &localRunner{}
+	sh := NewShell(&Options{
+		WorkingDir: "/srv/project",
+		Remote:     runner,
+		ExtraEnv:   map[string]string{"STAGE": "dev"},
+	})
+
+	// 会话环境变量传给远程主机，本机的环境变量不会带过去
+	stdout, _, err := sh.Exec(t.Context(), `printf %s "$STAGE"`)
+	require.NoError(t, err)
+	require.Equal(t, "dev", stdout)
+	require.Equal(t, [][]string{{"STAGE=dev"}}, runner.envs)
+
+	sh.SetEnv("STAGE", "prod")
+	_, _, err = sh.Exec(t.Context(), "true")
+	require.NoError(t, err)
+	require.Equal(t, []string{"STAGE=prod"}, runner.envs[1])
+}
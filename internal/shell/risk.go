@@ -0,0 +1,389 @@
+package shell
+
+import (
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+
+	"mvdan.cc/sh/v3/syntax"
+)
+
+// RiskLevel 是命令的风险等级
+type RiskLevel int
+
+const (
+	// RiskNone 表示没有发现风险
+	RiskNone RiskLevel = iota
+	// RiskMedium 表示命令需要额外注意，例如以管理员权限执行
+	RiskMedium
+	// RiskHigh 表示命令可能造成不可恢复的损失或执行未经检查的代码
+	RiskHigh
+)
+
+// RiskCategory 是命令风险的类别
+type RiskCategory string
+
+const (
+	// RiskDestructive 表示命令会删除或覆盖数据，例如 rm -rf 和强制推送
+	RiskDestructive RiskCategory = "destructive"
+	// RiskNetwork 表示命令会执行从网络下载的代码，例如 curl | sh
+	RiskNetwork RiskCategory = "network"
+	// RiskPrivileged 表示命令以管理员权限执行，例如 sudo
+	RiskPrivileged RiskCategory = "privileged"
+)
+
+// CommandRisk 是在命令中发现的一项风险
+type CommandRisk struct {
+	Category RiskCategory
+	Level    RiskLevel
+	// Explanation 是面向用户的风险说明
+	Explanation string
+}
+
+// maxRiskTargets 是风险说明中列出的最大目标数
+const maxRiskTargets = 3
+
+// 包装其他命令的命令，分析时跳过它们分析被包装的命令
+var wrapperCommands = []string{"env", "nohup", "time", "command", "exec", "xargs", "nice", "timeout"}
+
+// 以管理员权限执行其他命令的命令
+var privilegedCommands = []string{"sudo", "doas", "su", "pkexec"}
+
+// 从网络下载内容的命令
+var downloadCommands = []string{"curl", "wget", "fetch"}
+
+// 执行脚本的解释器
+var interpreterCommands = []string{"sh", "bash", "zsh", "dash", "ksh", "fish", "python", "python3", "perl", "ruby", "node"}
+
+// AnalyzeCommand 解析 shell 命令并返回其中发现的风险，按出现顺序排列且不重复。
+// 命令无法按 POSIX shell 语法解析时返回 nil。
+func AnalyzeCommand(command string) []CommandRisk {
+	file, err := syntax.NewParser().Parse(strings.NewReader(command), "")
+	if err != nil {
+		return nil
+	}
+
+	var risks []CommandRisk
+	add := func(r CommandRisk) {
+		if !slices.Contains(risks, r) {
+			risks = append(risks, r)
+		}
+	}
+	syntax.Walk(file, func(node syntax.Node) bool {
+		switch n := node.(type) {
+		case *syntax.CallExpr:
+			for _, r := range analyzeArgs(callArgs(n)) {
+				add(r)
+			}
+			if name, _ := unwrapCommand(callArgs(n)); slices.Contains(interpreterCommands, name) && downloadsInSubst(n) {
+				add(remoteScriptRisk())
+			}
+		case *syntax.BinaryCmd:
+			if n.Op != syntax.Pipe && n.Op != syntax.PipeAll {
+				break
+			}
+			name, _ := unwrapCommand(stmtArgs(n.Y))
+			if slices.Contains(interpreterCommands, name) && containsDownload(n.X) {
+				add(remoteScriptRisk())
+			}
+		}
+		return true
+	})
+	return risks
+}
+
+// MaxRiskLevel 返回风险中的最高等级，没有风险时返回 [RiskNone]
+func MaxRiskLevel(risks []CommandRisk) RiskLevel {
+	level := RiskNone
+	for _, r := range risks {
+		level = max(level, r.Level)
+	}
+	return level
+}
+
+// remoteScriptRisk 返回执行网络脚本的风险
+func remoteScriptRisk() CommandRisk {
+	return CommandRisk{
+		Category:    RiskNetwork,
+		Level:       RiskHigh,
+		Explanation: "从网络下载脚本并直接执行，脚本内容未经检查",
+	}
+}
+
+// analyzeArgs 分析一个简单命令的参数，包括被 sudo 等命令包装的命令
+func analyzeArgs(args []string) []CommandRisk {
+	var risks []CommandRisk
+	for len(args) > 0 {
+		name := path.Base(args[0])
+		if slices.Contains(privilegedCommands, name) {
+			target := "命令"
+			if _, rest := unwrapCommand(args); len(rest) > 0 {
+				target = path.Base(rest[0])
+			}
+			risks = append(risks, CommandRisk{
+				Category:    RiskPrivileged,
+				Level:       RiskMedium,
+				Explanation: fmt.Sprintf("以管理员（root）权限执行 %s", target),
+			})
+		}
+		if slices.Contains(privilegedCommands, name) || slices.Contains(wrapperCommands, name) {
+			args = skipWrapper(args)
+			continue
+		}
+		if r, ok := destructiveRisk(name, args[1:]); ok {
+			risks = append(risks, r)
+		}
+		break
+	}
+	return risks
+}
+
+// destructiveRisk 检查命令是否会删除或覆盖数据
+func destructiveRisk(name string, args []string) (CommandRisk, bool) {
+	flags, targets := splitFlags(args)
+	explanation := ""
+	switch {
+	case name == "rm" && (hasFlag(flags, 'r', 'R') || slices.Contains(flags, "--recursive")):
+		explanation = "递归删除 " + formatTargets(targets) + "，删除后无法恢复"
+		if hasFlag(flags, 'f') || slices.Contains(flags, "--force") {
+			explanation = "递归强制删除 " + formatTargets(targets) + "，删除后无法恢复"
+		}
+	case name == "git":
+		explanation = gitRisk(args)
+	case name == "find" && (slices.Contains(args, "-delete") || findExecRemoves(args)):
+		explanation = "删除 find 匹配的所有文件"
+	case name == "dd":
+		for _, arg := range args {
+			if out, ok := strings.CutPrefix(arg, "of="); ok {
+				explanation = "直接写入 " + out + "，可能覆盖整个文件或磁盘"
+			}
+		}
+	case name == "mkfs" || strings.HasPrefix(name, "mkfs."):
+		explanation = "格式化文件系统，会清除设备上的所有数据"
+	case name == "shred":
+		explanation = "不可恢复地覆盖 " + formatTargets(targets) + " 的内容"
+	}
+	if explanation == "" {
+		return CommandRisk{}, false
+	}
+	return CommandRisk{Category: RiskDestructive, Level: RiskHigh, Explanation: explanation}, true
+}
+
+// gitRisk 返回危险 git 子命令的说明，不危险时返回空字符串
+func gitRisk(args []string) string {
+	// 跳过 -C 目录、-c 键=值等全局选项
+	for len(args) > 0 && strings.HasPrefix(args[0], "-") {
+		if (args[0] == "-C" || args[0] == "-c") && len(args) > 1 {
+			args = args[1:]
+		}
+		args = args[1:]
+	}
+	if len(args) == 0 {
+		return ""
+	}
+	flags, rest := splitFlags(args[1:])
+	switch args[0] {
+	case "push":
+		force := hasFlag(flags, 'f') || slices.ContainsFunc(flags, func(f string) bool {
+			return f == "--force" || strings.HasPrefix(f, "--force-with-lease") || f == "--mirror" || f == "--delete"
+		})
+		if force || slices.ContainsFunc(rest, func(r string) bool { return strings.HasPrefix(r, "+") }) {
+			return "强制推送会覆盖或删除远程分支上的提交"
+		}
+	case "reset":
+		if slices.Contains(flags, "--hard") {
+			return "丢弃工作区和暂存区中所有未提交的更改"
+		}
+	case "clean":
+		if hasFlag(flags, 'f') || slices.Contains(flags, "--force") {
+			return "删除所有未跟踪的文件"
+		}
+	case "branch":
+		if slices.Contains(flags, "-D") || (hasFlag(flags, 'd') && (hasFlag(flags, 'f') || slices.Contains(flags, "--force"))) {
+			return "强制删除分支，未合并的提交可能丢失"
+		}
+	case "checkout", "restore":
+		if slices.Contains(rest, ".") && (args[0] == "restore" || slices.Contains(args, "--")) {
+			return "丢弃工作区中所有未提交的更改"
+		}
+	}
+	return ""
+}
+
+// findExecRemoves 报告 find 的 -exec 或 -execdir 是否执行 rm
+func findExecRemoves(args []string) bool {
+	for i, arg := range args[:max(0, len(args)-1)] {
+		if (arg == "-exec" || arg == "-execdir") && path.Base(args[i+1]) == "rm" {
+			return true
+		}
+	}
+	return false
+}
+
+// splitFlags 将参数分为选项和其他参数，"--" 之后的参数都不是选项
+func splitFlags(args []string) (flags, rest []string) {
+	for i, arg := range args {
+		if arg == "--" {
+			return flags, append(rest, args[i+1:]...)
+		}
+		if strings.HasPrefix(arg, "-") && arg != "-" {
+			flags = append(flags, arg)
+		} else {
+			rest = append(rest, arg)
+		}
+	}
+	return flags, rest
+}
+
+// hasFlag 报告选项中是否包含任一短选项，支持 -rf 这样的组合写法
+func hasFlag(flags []string, short ...rune) bool {
+	for _, f := range flags {
+		if strings.HasPrefix(f, "--") {
+			continue
+		}
+		if strings.ContainsAny(f[1:], string(short)) {
+			return true
+		}
+	}
+	return false
+}
+
+// formatTargets 格式化命令的目标，最多列出 maxRiskTargets 个
+func formatTargets(targets []string) string {
+	switch {
+	case len(targets) == 0:
+		return "文件"
+	case len(targets) > maxRiskTargets:
+		return fmt.Sprintf("%s 等 %d 个目标", strings.Join(targets[:maxRiskTargets], " "), len(targets))
+	default:
+		return strings.Join(targets, " ")
+	}
+}
+
+// unwrapCommand 跳过 sudo、env 等包装命令，返回实际执行的命令名和它的参数（包括命令名）
+func unwrapCommand(args []string) (string, []string) {
+	for len(args) > 0 {
+		name := path.Base(args[0])
+		if !slices.Contains(privilegedCommands, name) && !slices.Contains(wrapperCommands, name) {
+			return name, args
+		}
+		args = skipWrapper(args)
+	}
+	return "", nil
+}
+
+// skipWrapper 跳过包装命令本身以及它的选项和环境变量赋值，返回被包装的命令
+func skipWrapper(args []string) []string {
+	name := path.Base(args[0])
+	args = args[1:]
+	if name == "su" {
+		// su 只通过 -c 执行命令，分析命令字符串中的词
+		if i := slices.Index(args, "-c"); i >= 0 && i+1 < len(args) {
+			return strings.Fields(args[i+1])
+		}
+		return nil
+	}
+	for len(args) > 0 {
+		arg := args[0]
+		switch {
+		case (name == "sudo" || name == "doas") && (arg == "-u" || arg == "-g") && len(args) > 1:
+			args = args[2:]
+		case strings.HasPrefix(arg, "-"),
+			name == "env" && strings.Contains(arg, "="),
+			name == "timeout" && isNumber(strings.TrimRight(arg, "smhd")):
+			args = args[1:]
+		default:
+			return args
+		}
+	}
+	return nil
+}
+
+// isNumber 报告字符串是否为非负整数或小数
+func isNumber(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if (r < '0' || r > '9') && r != '.' {
+			return false
+		}
+	}
+	return true
+}
+
+// containsDownload 报告语法树中是否有下载命令
+func containsDownload(node syntax.Node) bool {
+	found := false
+	syntax.Walk(node, func(n syntax.Node) bool {
+		if call, ok := n.(*syntax.CallExpr); ok {
+			if name, _ := unwrapCommand(callArgs(call)); slices.Contains(downloadCommands, name) {
+				found = true
+			}
+		}
+		return !found
+	})
+	return found
+}
+
+// downloadsInSubst 报告命令参数中的命令替换或进程替换是否包含下载命令，例如 bash <(curl ...)
+func downloadsInSubst(call *syntax.CallExpr) bool {
+	for _, w := range call.Args {
+		for _, part := range w.Parts {
+			switch p := part.(type) {
+			case *syntax.CmdSubst, *syntax.ProcSubst:
+				if containsDownload(p) {
+					return true
+				}
+			case *syntax.DblQuoted:
+				for _, inner := range p.Parts {
+					if cs, ok := inner.(*syntax.CmdSubst); ok && containsDownload(cs) {
+						return true
+					}
+				}
+			}
+		}
+	}
+	return false
+}
+
+// stmtArgs 返回语句中简单命令的参数，不是简单命令时返回 nil
+func stmtArgs(stmt *syntax.Stmt) []string {
+	if stmt == nil {
+		return nil
+	}
+	if call, ok := stmt.Cmd.(*syntax.CallExpr); ok {
+		return callArgs(call)
+	}
+	return nil
+}
+
+// callArgs 返回命令参数的字面值，非字面部分（例如变量展开）被忽略
+func callArgs(call *syntax.CallExpr) []string {
+	args := make([]string, 0, len(call.Args))
+	for _, w := range call.Args {
+		args = append(args, wordText(w))
+	}
+	return args
+}
+
+// wordText 返回单词中字面部分和引号中字面部分的拼接
+func wordText(w *syntax.Word) string {
+	var b strings.Builder
+	for _, part := range w.Parts {
+		switch p := part.(type) {
+		case *syntax.Lit:
+			b.WriteString(p.Value)
+		case *syntax.SglQuoted:
+			b.WriteString(p.Value)
+		case *syntax.DblQuoted:
+			for _, inner := range p.Parts {
+				if lit, ok := inner.(*syntax.Lit); ok {
+					b.WriteString(lit.Value)
+				}
+			}
+		}
+	}
+	return b.String()
+}
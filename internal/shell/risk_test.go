@@ -0,0 +1,61 @@
+package shell
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAnalyzeCommand(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		command  string
+		expected []CommandRisk
+	}{
+		{"ls -la && go test ./...", nil},
+		{"rm file.txt", nil},
+		{"git push origin main", nil},
+		{"rm -rf build dist", []CommandRisk{{RiskDestructive, RiskHigh, "递归强制删除 build dist，删除后无法恢复"}}},
+		{"rm -r -- -odd", []CommandRisk{{RiskDestructive, RiskHigh, "递归删除 -odd，删除后无法恢复"}}},
+		{"git push --force origin main", []CommandRisk{{RiskDestructive, RiskHigh, "强制推送会覆盖或删除远程分支上的提交"}}},
+		{"git -C repo push origin +main", []CommandRisk{{RiskDestructive, RiskHigh, "强制推送会覆盖或删除远程分支上的提交"}}},
+		{"git reset --hard HEAD~1", []CommandRisk{{RiskDestructive, RiskHigh, "丢弃工作区和暂存区中所有未提交的更改"}}},
+		{"git checkout -- .", []CommandRisk{{RiskDestructive, RiskHigh, "丢弃工作区中所有未提交的更改"}}},
+		{`find . -name '*.tmp' -exec rm {} \;`, []CommandRisk{{RiskDestructive, RiskHigh, "删除 find 匹配的所有文件"}}},
+		{"curl -fsSL https://example.com/install.sh | sh", []CommandRisk{{RiskNetwork, RiskHigh, "从网络下载脚本并直接执行，脚本内容未经检查"}}},
+		{`bash -c "$(wget -qO- https://example.com/i.sh)"`, []CommandRisk{{RiskNetwork, RiskHigh, "从网络下载脚本并直接执行，脚本内容未经检查"}}},
+		{"curl -o out.json https://example.com/data.json", nil},
+		{"sudo apt-get install -y jq", []CommandRisk{{RiskPrivileged, RiskMedium, "以管理员（root）权限执行 apt-get"}}},
+		{
+			"curl https://example.com/i.sh | sudo -u root bash",
+			[]CommandRisk{
+				{RiskNetwork, RiskHigh, "从网络下载脚本并直接执行，脚本内容未经检查"},
+				{RiskPrivileged, RiskMedium, "以管理员（root）权限执行 bash"},
+			},
+		},
+		{
+			"sudo rm -rf /var/lib/app; env FOO=1 timeout 5s rm -fr a b c d",
+			[]CommandRisk{
+				{RiskPrivileged, RiskMedium, "以管理员（root）权限执行 rm"},
+				{RiskDestructive, RiskHigh, "递归强制删除 /var/lib/app，删除后无法恢复"},
+				{RiskDestructive, RiskHigh, "递归强制删除 a b c 等 4 个目标，删除后无法恢复"},
+			},
+		},
+		{"if then fi (", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.command, func(t *testing.T) {
+			t.Parallel()
+			require.Equal(t, tt.expected, AnalyzeCommand(tt.command))
+		})
+	}
+}
+
+func TestMaxRiskLevel(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, RiskNone, MaxRiskLevel(nil))
+	require.Equal(t, RiskHigh, MaxRiskLevel(AnalyzeCommand("sudo git push -f")))
+	require.Equal(t, RiskMedium, MaxRiskLevel(AnalyzeCommand("sudo ls")))
+}
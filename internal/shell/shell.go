@@ -4,8 +4,10 @@
 // 每次 shell 执行都是相互独立的。
 //
 // WINDOWS 兼容性:
-// 本实现即使在 Windows 上也提供 POSIX shell 仿真(mvdan.cc/sh/v3)。
+// 默认实现即使在 Windows 上也提供 POSIX shell 仿真(mvdan.cc/sh/v3)。
 // 命令应使用正斜杠(/)作为路径分隔符,以确保在所有平台上正常工作。
+// 也可以选择 PowerShell 后端,它通过 pwsh 或 Windows PowerShell 执行命令,
+// 并转换常见的 POSIX 写法。
 package shell
 
 import (
@@ -14,6 +16,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"maps"
 	"os"
 	"slices"
 	"strings"
@@ -35,6 +38,18 @@ const (
 	ShellTypePowerShell                  // PowerShell
 )
 
+// ParseShellType 根据配置中的名称返回 shell 类型。空字符串表示默认的 POSIX shell。
+func ParseShellType(name string) (ShellType, error) {
+	switch strings.ToLower(name) {
+	case "", "posix", "sh", "bash":
+		return ShellTypePOSIX, nil
+	case "powershell", "pwsh":
+		return ShellTypePowerShell, nil
+	default:
+		return ShellTypePOSIX, fmt.Errorf("不支持的 shell 类型: %q", name)
+	}
+}
+
 // Logger 接口用于可选的日志记录
 type Logger interface {
 	InfoPersist(msg string, keysAndValues ...any)
@@ -55,14 +70,20 @@ type Shell struct {
 	mu         sync.Mutex  // 互斥锁,用于保护并发访问
 	logger     Logger      // 日志记录器
 	blockFuncs []BlockFunc // 命令阻止函数列表
+	shellType  ShellType   // shell 类型
+	remote     Runner      // 远程执行器,非空时命令在远程主机上执行
 }
 
 // Options 用于创建新的 shell 实例的配置选项
 type Options struct {
 	WorkingDir string      // 工作目录
-	Env        []string    // 环境变量
+	Env        []string    // 环境变量,远程执行时追加到远程主机的环境变量中
 	Logger     Logger      // 日志记录器
 	BlockFuncs []BlockFunc // 命令阻止函数列表
+	Type       ShellType   // shell 类型,默认为 POSIX shell
+	Remote     Runner      // 远程执行器,设置后命令在远程主机的 WorkingDir 中执行
+	// ExtraEnv 是在 Env 基础上追加或覆盖的环境变量
+	ExtraEnv map[string]string
 }
 
 // NewShell 使用给定的选项创建一个新的 shell 实例
@@ -78,8 +99,9 @@ func NewShell(opts *Options) *Shell {
 	}
 
 	env := opts.Env
-	if env == nil {
-		// 如果未指定环境变量,使用系统环境变量
+	if env == nil && opts.Remote == nil {
+		// 如果未指定环境变量,使用系统环境变量。远程执行时使用远程主机的环境变量,
+		// 不把本机的环境变量带过去
 		env = os.Environ()
 	}
 
@@ -89,12 +111,28 @@ func NewShell(opts *Options) *Shell {
 		logger = noopLogger{}
 	}
 
-	return &Shell{
+	if len(opts.ExtraEnv) > 0 {
+		// 复制一份,避免 SetEnv 修改调用方传入的切片
+		env = slices.Clone(env)
+	}
+
+	s := &Shell{
 		cwd:        cwd,
 		env:        env,
 		logger:     logger,
 		blockFuncs: opts.BlockFuncs,
+		shellType:  opts.Type,
+		remote:     opts.Remote,
+	}
+	for _, key := range slices.Sorted(maps.Keys(opts.ExtraEnv)) {
+		s.SetEnv(key, opts.ExtraEnv[key])
 	}
+	return s
+}
+
+// Type 返回 shell 类型
+func (s *Shell) Type() ShellType {
+	return s.shellType
 }
 
 // Exec 在 shell 中执行命令,返回标准输出、标准错误和错误信息
@@ -125,7 +163,11 @@ func (s *Shell) SetWorkingDir(dir string) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	// 验证目录是否存在
+	// 验证目录是否存在,远程目录无法在本地验证
+	if s.remote != nil {
+		s.cwd = dir
+		return nil
+	}
 	if _, err := os.Stat(dir); err != nil {
 		return fmt.Errorf("目录不存在: %w", err)
 	}
@@ -265,6 +307,13 @@ func (s *Shell) updateShellFromRunner(runner *interp.Runner) {
 
 // execCommon 是执行命令的共享实现
 func (s *Shell) execCommon(ctx context.Context, command string, stdout, stderr io.Writer) error {
+	if s.remote != nil {
+		return s.execRemote(ctx, command, stdout, stderr)
+	}
+	if s.shellType == ShellTypePowerShell {
+		return s.execPowerShell(ctx, command, stdout, stderr)
+	}
+
 	line, err := syntax.NewParser().Parse(strings.NewReader(command), "")
 	if err != nil {
 		return fmt.Errorf("无法解析命令: %w", err)
@@ -98,6 +98,25 @@ func TestRunContinuity(t *testing.T) {
 	}
 }
 
+func TestExtraEnv(t *testing.T) {
+	env := []string{"FOO=system", "KEEP=1"}
+	shell := NewShell(&Options{
+		WorkingDir: t.TempDir(),
+		Env:        env,
+		ExtraEnv:   map[string]string{"FOO": "session", "NODE_ENV": "test"},
+	})
+	out, _, err := shell.Exec(t.Context(), "echo $FOO $KEEP $NODE_ENV")
+	if err != nil {
+		t.Fatalf("执行echo命令失败: %v", err)
+	}
+	if out != "session 1 test\n" {
+		t.Fatalf("预期输出 %q，但得到 %q", "session 1 test\n", out)
+	}
+	if env[0] != "FOO=system" {
+		t.Fatalf("传入的环境变量被修改: %q", env[0])
+	}
+}
+
 func TestCrossPlatformExecution(t *testing.T) {
 	shell := NewShell(&Options{WorkingDir: "."})
 	ctx, cancel := context.WithTimeout(t.Context(), 5*time.Second)
@@ -0,0 +1,107 @@
+// Package snippets 管理保存在全局配置目录中的可复用提示词片段。
+// 每个片段是 snippets 目录下的一个 markdown 文件，文件名即片段名称，
+// 内容中的 $NAME 占位符在插入时由用户填写。
+package snippets
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/purpose168/crush-cn/internal/commands"
+	"github.com/purpose168/crush-cn/internal/config"
+)
+
+const fileExt = ".md"
+
+// Snippet 表示一个已保存的提示词片段。
+type Snippet struct {
+	Name      string
+	Content   string
+	Arguments []commands.Argument
+}
+
+// Preview 返回片段内容的第一行非空文本，用于在补全列表中显示。
+func (s Snippet) Preview() string {
+	for line := range strings.SplitSeq(s.Content, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			return line
+		}
+	}
+	return ""
+}
+
+// Dir 返回全局配置目录下保存片段的目录。
+func Dir() string {
+	return filepath.Join(filepath.Dir(config.GlobalConfig()), "snippets")
+}
+
+// ValidateName 检查片段名称能否用作文件名。
+func ValidateName(name string) error {
+	switch {
+	case strings.TrimSpace(name) == "":
+		return errors.New("片段名称不能为空")
+	case strings.ContainsAny(name, `/\`):
+		return errors.New("片段名称不能包含路径分隔符")
+	case strings.HasPrefix(name, "."):
+		return errors.New("片段名称不能以 . 开头")
+	}
+	return nil
+}
+
+// Load 读取目录中的所有片段并按名称排序，目录不存在时返回空列表。
+func Load(dir string) ([]Snippet, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取片段目录失败: %w", err)
+	}
+
+	var snippets []Snippet
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.EqualFold(filepath.Ext(entry.Name()), fileExt) {
+			continue
+		}
+		content, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			continue // 跳过无法读取的文件
+		}
+		snippets = append(snippets, newSnippet(strings.TrimSuffix(entry.Name(), filepath.Ext(entry.Name())), string(content)))
+	}
+	slices.SortFunc(snippets, func(a, b Snippet) int {
+		return strings.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name))
+	})
+	return snippets, nil
+}
+
+// Save 将片段写入目录，同名片段会被覆盖。
+func Save(dir, name, content string) (Snippet, error) {
+	name = strings.TrimSpace(name)
+	if err := ValidateName(name); err != nil {
+		return Snippet{}, err
+	}
+	if strings.TrimSpace(content) == "" {
+		return Snippet{}, errors.New("片段内容不能为空")
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return Snippet{}, fmt.Errorf("创建片段目录失败: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, name+fileExt), []byte(content), 0o644); err != nil {
+		return Snippet{}, fmt.Errorf("保存片段失败: %w", err)
+	}
+	return newSnippet(name, content), nil
+}
+
+func newSnippet(name, content string) Snippet {
+	return Snippet{
+		Name:      name,
+		Content:   content,
+		Arguments: commands.ExtractArguments(content),
+	}
+}
@@ -0,0 +1,68 @@
+package snippets
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoad_MissingDir(t *testing.T) {
+	t.Parallel()
+
+	snippets, err := Load(filepath.Join(t.TempDir(), "missing"))
+	require.NoError(t, err)
+	require.Empty(t, snippets)
+}
+
+func TestSaveAndLoad(t *testing.T) {
+	t.Parallel()
+
+	dir := filepath.Join(t.TempDir(), "snippets")
+	_, err := Save(dir, "review", "Review $FILE for $FOCUS issues.\nBe brief about $FILE.")
+	require.NoError(t, err)
+	_, err = Save(dir, "Explain", "\n  Explain this code.\n")
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("ignored"), 0o644))
+
+	snippets, err := Load(dir)
+	require.NoError(t, err)
+	require.Len(t, snippets, 2)
+
+	require.Equal(t, "Explain", snippets[0].Name)
+	require.Equal(t, "Explain this code.", snippets[0].Preview())
+	require.Empty(t, snippets[0].Arguments)
+
+	require.Equal(t, "review", snippets[1].Name)
+	require.Len(t, snippets[1].Arguments, 2)
+	require.Equal(t, "FILE", snippets[1].Arguments[0].ID)
+	require.Equal(t, "FOCUS", snippets[1].Arguments[1].ID)
+}
+
+func TestSave_Overwrites(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	_, err := Save(dir, "fix", "old")
+	require.NoError(t, err)
+	_, err = Save(dir, "fix", "new")
+	require.NoError(t, err)
+
+	snippets, err := Load(dir)
+	require.NoError(t, err)
+	require.Len(t, snippets, 1)
+	require.Equal(t, "new", snippets[0].Content)
+}
+
+func TestSave_Invalid(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	for _, name := range []string{"", "  ", "a/b", `a\b`, ".hidden"} {
+		_, err := Save(dir, name, "content")
+		require.Error(t, err, name)
+	}
+	_, err := Save(dir, "empty", " \n")
+	require.Error(t, err)
+}
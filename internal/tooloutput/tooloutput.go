@@ -0,0 +1,152 @@
+// Package tooloutput 在工具结果写入消息前按策略截断过长的输出，
+// 并将完整输出保存到数据目录中的溢出文件，供界面展开查看。
+package tooloutput
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// DirName 是数据目录中存放溢出文件的目录名。
+	DirName = "tool_outputs"
+	// DefaultMaxBytes 是未配置时工具结果保留的最大字节数。
+	DefaultMaxBytes = 50 * 1024
+)
+
+// Mode 决定超出限制时保留输出的哪一部分。
+type Mode string
+
+const (
+	// ModeHead 保留输出开头。
+	ModeHead Mode = "head"
+	// ModeTail 保留输出结尾，适用于构建和测试日志等结论在末尾的输出。
+	ModeTail Mode = "tail"
+	// ModeSummary 保留开头和结尾，并说明中间省略的行数。
+	ModeSummary Mode = "summary"
+)
+
+// Policy 是单个工具的截断策略。MaxBytes 小于等于 0 时不截断。
+type Policy struct {
+	Mode     Mode
+	MaxBytes int
+}
+
+// NewPolicy 根据配置值创建策略。mode 为空或未知时使用 [ModeSummary]，
+// maxBytes 为 nil 时使用 [DefaultMaxBytes]。
+func NewPolicy(mode string, maxBytes *int) Policy {
+	p := Policy{Mode: Mode(mode), MaxBytes: DefaultMaxBytes}
+	if p.Mode != ModeHead && p.Mode != ModeTail {
+		p.Mode = ModeSummary
+	}
+	if maxBytes != nil {
+		p.MaxBytes = *maxBytes
+	}
+	return p
+}
+
+// Truncate 按策略截断 content，返回截断后的内容以及是否发生了截断。
+// 截断只发生在 UTF-8 字符边界上，并尽量落在行边界上。
+func (p Policy) Truncate(content string) (string, bool) {
+	if p.MaxBytes <= 0 || len(content) <= p.MaxBytes {
+		return content, false
+	}
+
+	switch p.Mode {
+	case ModeHead:
+		head := cutHead(content, p.MaxBytes)
+		return head + omitted(content[len(head):]), true
+	case ModeTail:
+		tail := cutTail(content, p.MaxBytes)
+		return omitted(content[:len(content)-len(tail)]) + tail, true
+	default:
+		head := cutHead(content, p.MaxBytes/2)
+		tail := cutTail(content[len(head):], p.MaxBytes-len(head))
+		return head + omitted(content[len(head):len(content)-len(tail)]) + tail, true
+	}
+}
+
+// cutHead 返回 s 开头最多 n 字节的内容。
+func cutHead(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	head := s[:n]
+	if i := strings.LastIndexByte(head, '\n'); i > len(head)/2 {
+		head = head[:i+1]
+	}
+	return head
+}
+
+// cutTail 返回 s 结尾最多 n 字节的内容。
+func cutTail(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	start := len(s) - n
+	for start < len(s) && !utf8.RuneStart(s[start]) {
+		start++
+	}
+	tail := s[start:]
+	if s[start-1] == '\n' {
+		return tail
+	}
+	if i := strings.IndexByte(tail, '\n'); i >= 0 && i < len(tail)/2 {
+		tail = tail[i+1:]
+	}
+	return tail
+}
+
+// omitted 返回说明省略内容的占位行。
+func omitted(s string) string {
+	n := strings.Count(s, "\n")
+	if !strings.HasSuffix(s, "\n") {
+		n++
+	}
+	return fmt.Sprintf("\n\n... [省略了 %d 行，%d 字节] ...\n\n", n, len(s))
+}
+
+// noticeRe 匹配 [Notice] 生成的说明，用于找回溢出文件路径。
+var noticeRe = regexp.MustCompile(`\[完整输出（\d+ 字节）已保存到 (.+?)，可使用 view 工具读取\]\s*$`)
+
+// Notice 返回附加在截断结果末尾的说明，告知模型和用户完整输出的位置。
+func Notice(path string, size int) string {
+	return fmt.Sprintf("\n\n[完整输出（%d 字节）已保存到 %s，可使用 view 工具读取]", size, path)
+}
+
+// SpillPath 从截断后的工具结果中解析溢出文件路径。
+func SpillPath(content string) (string, bool) {
+	m := noticeRe.FindStringSubmatch(content)
+	if m == nil {
+		return "", false
+	}
+	return m[1], true
+}
+
+// Path 返回会话中工具调用的溢出文件路径。
+func Path(dir, sessionID, toolCallID string) string {
+	return filepath.Join(dir, sanitize(sessionID), sanitize(toolCallID)+".txt")
+}
+
+// Save 将工具调用的完整输出写入溢出文件并返回其路径。
+func Save(dir, sessionID, toolCallID, content string) (string, error) {
+	path := Path(dir, sessionID, toolCallID)
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return "", fmt.Errorf("创建工具输出目录失败: %w", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		return "", fmt.Errorf("写入工具输出失败: %w", err)
+	}
+	return path, nil
+}
+
+func sanitize(s string) string {
+	return strings.NewReplacer("/", "_", "\\", "_", "..", "_").Replace(s)
+}
@@ -0,0 +1,92 @@
+package tooloutput
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func lines(n int) string {
+	var sb strings.Builder
+	for i := range n {
+		sb.WriteString(strings.Repeat("x", 9))
+		sb.WriteByte(byte('0' + i%10))
+		sb.WriteByte('\n')
+	}
+	return sb.String()
+}
+
+func TestTruncate(t *testing.T) {
+	t.Parallel()
+
+	content := lines(100) // 1100 字节
+
+	t.Run("within limit", func(t *testing.T) {
+		t.Parallel()
+		out, truncated := Policy{Mode: ModeHead, MaxBytes: 2000}.Truncate(content)
+		require.False(t, truncated)
+		require.Equal(t, content, out)
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		t.Parallel()
+		out, truncated := Policy{Mode: ModeHead}.Truncate(content)
+		require.False(t, truncated)
+		require.Equal(t, content, out)
+	})
+
+	t.Run("head", func(t *testing.T) {
+		t.Parallel()
+		out, truncated := Policy{Mode: ModeHead, MaxBytes: 115}.Truncate(content)
+		require.True(t, truncated)
+		require.True(t, strings.HasPrefix(out, lines(10)))
+		require.Contains(t, out, "[省略了 90 行，990 字节]")
+	})
+
+	t.Run("tail", func(t *testing.T) {
+		t.Parallel()
+		out, truncated := Policy{Mode: ModeTail, MaxBytes: 115}.Truncate(content)
+		require.True(t, truncated)
+		require.True(t, strings.HasSuffix(out, "xxxxxxxxx9\n"))
+		require.Contains(t, out, "[省略了 90 行，990 字节]")
+	})
+
+	t.Run("summary", func(t *testing.T) {
+		t.Parallel()
+		out, truncated := Policy{Mode: ModeSummary, MaxBytes: 220}.Truncate(content)
+		require.True(t, truncated)
+		require.True(t, strings.HasPrefix(out, lines(10)))
+		require.True(t, strings.HasSuffix(out, "xxxxxxxxx9\n"))
+		require.Contains(t, out, "[省略了 80 行，880 字节]")
+	})
+
+	t.Run("utf8 boundary", func(t *testing.T) {
+		t.Parallel()
+		out, truncated := Policy{Mode: ModeHead, MaxBytes: 4}.Truncate("中文内容")
+		require.True(t, truncated)
+		require.True(t, strings.HasPrefix(out, "中\n"))
+	})
+}
+
+func TestSaveAndSpillPath(t *testing.T) {
+	t.Parallel()
+
+	dir := filepath.Join(t.TempDir(), DirName)
+	path, err := Save(dir, "../s1", "call-1", "full output")
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join(dir, "__s1", "call-1.txt"), path)
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, "full output", string(data))
+
+	got, ok := SpillPath("head" + Notice(path, 11))
+	require.True(t, ok)
+	require.Equal(t, path, got)
+
+	_, ok = SpillPath("plain output")
+	require.False(t, ok)
+}
@@ -53,6 +53,20 @@ var (
 	ellipsisFrames = []string{".", "..", "...", ""}
 )
 
+// reducedMotion 为 true 时，所有动画都渲染为静态文本且不再调度帧。
+var reducedMotion atomic.Bool
+
+// SetReducedMotion 启用或禁用减少动态效果模式。启用后动画不再旋转或
+// 渐变，适用于屏幕阅读器和对动态效果敏感的用户。
+func SetReducedMotion(enabled bool) {
+	reducedMotion.Store(enabled)
+}
+
+// ReducedMotion 报告是否启用了减少动态效果模式。
+func ReducedMotion() bool {
+	return reducedMotion.Load()
+}
+
 // 内部 ID 管理。用于动画期间确保帧消息仅被发送它们的旋转器组件接收。
 var lastID int64
 
@@ -102,6 +116,7 @@ type Anim struct {
 	width            int
 	cyclingCharWidth int
 	label            *csync.Slice[string]
+	labelText        string
 	labelWidth       int
 	labelColor       color.Color
 	startTime        time.Time
@@ -140,6 +155,7 @@ func New(opts Settings) *Anim {
 	a.startTime = time.Now()
 	a.cyclingCharWidth = opts.Size
 	a.labelColor = opts.LabelColor
+	a.labelText = opts.Label
 
 	// 首先检查缓存
 	cacheKey := settingsHash(opts)
@@ -255,6 +271,7 @@ func New(opts Settings) *Anim {
 
 // SetLabel 更新标签文本并重新渲染它。
 func (a *Anim) SetLabel(newLabel string) {
+	a.labelText = newLabel
 	a.labelWidth = lipgloss.Width(newLabel)
 
 	// 更新总宽度
@@ -312,14 +329,17 @@ func (a *Anim) Width() (w int) {
 	return w
 }
 
-// Start 启动动画。
+// Start 启动动画。在减少动态效果模式下不会调度任何帧。
 func (a *Anim) Start() tea.Cmd {
+	if reducedMotion.Load() {
+		return nil
+	}
 	return a.Step()
 }
 
 // Animate 将动画推进到下一步。
 func (a *Anim) Animate(msg StepMsg) tea.Cmd {
-	if msg.ID != a.id {
+	if msg.ID != a.id || reducedMotion.Load() {
 		return nil
 	}
 
@@ -342,6 +362,10 @@ func (a *Anim) Animate(msg StepMsg) tea.Cmd {
 
 // Render 渲染动画的当前状态。
 func (a *Anim) Render() string {
+	if reducedMotion.Load() {
+		return a.renderStatic()
+	}
+
 	var b strings.Builder
 	step := int(a.step.Load())
 	for i := range a.width {
@@ -373,6 +397,15 @@ func (a *Anim) Render() string {
 	return b.String()
 }
 
+// renderStatic 将动画渲染为不含渐变和打乱字符的纯文本。
+func (a *Anim) renderStatic() string {
+	text := "..."
+	if a.labelText != "" {
+		text = a.labelText + text
+	}
+	return lipgloss.NewStyle().Foreground(a.labelColor).Render(text)
+}
+
 // Step 是一个命令，用于触发动画的下一步。
 func (a *Anim) Step() tea.Cmd {
 	return tea.Tick(time.Second/time.Duration(fps), func(t time.Time) tea.Msg {
@@ -57,6 +57,14 @@ func (m *Attachments) List() []message.Attachment { return m.list }
 // Reset 清空附件列表
 func (m *Attachments) Reset() { m.list = nil }
 
+// Set 替换附件列表，用于在附件管理对话框中重新排序或删除附件后同步
+// 参数:
+//   - list: 新的附件列表
+func (m *Attachments) Set(list []message.Attachment) {
+	m.list = list
+	m.deleting = false
+}
+
 // Update 处理消息更新，包括添加附件和键盘交互
 // 参数:
 //   - msg: 接收到的消息，可以是附件消息或键盘消息
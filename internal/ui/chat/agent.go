@@ -146,7 +146,11 @@ func (r *AgentToolRenderContext) RenderTool(sty *styles.Styles, width int, opts
 	prompt = strings.ReplaceAll(prompt, "\n", " ")
 
 	// 构建工具头部
-	header := toolHeader(sty, opts.Status, "Agent", cappedWidth, opts.Compact)
+	var headerParams []string
+	if params.Agent != "" {
+		headerParams = append(headerParams, params.Agent)
+	}
+	header := toolHeader(sty, opts.Status, "Agent", cappedWidth, opts.Compact, headerParams...)
 	if opts.Compact {
 		return header
 	}
@@ -188,7 +192,7 @@ func (r *AgentToolRenderContext) RenderTool(sty *styles.Styles, width int, opts
 
 	// 如果工具仍在运行，显示动画
 	if !opts.HasResult() && !opts.IsCanceled() {
-		parts = append(parts, "", opts.Anim.Render())
+		parts = append(parts, "", opts.Anim.Render()+toolCountdown(sty, opts.Remaining))
 	}
 
 	result := lipgloss.JoinVertical(lipgloss.Left, parts...)
@@ -7,6 +7,7 @@ import (
 	tea "charm.land/bubbletea/v2"
 	"charm.land/lipgloss/v2"
 	"github.com/charmbracelet/x/ansi"
+	"github.com/purpose168/crush-cn/internal/codeblock"
 	"github.com/purpose168/crush-cn/internal/message"
 	"github.com/purpose168/crush-cn/internal/ui/anim"
 	"github.com/purpose168/crush-cn/internal/ui/common"
@@ -78,7 +79,7 @@ func (a *AssistantMessageItem) ID() string {
 
 // RawRender 实现 [MessageItem] 接口。
 func (a *AssistantMessageItem) RawRender(width int) string {
-	cappedWidth := cappedMessageWidth(width)
+	cappedWidth := markdownWidth(width)
 
 	var spinner string
 	if a.isSpinning() {
@@ -121,12 +122,14 @@ func (a *AssistantMessageItem) renderMessageContent(width int) string {
 	// 如果消息包含推理内容，则首先添加
 	if thinking != "" {
 		messageParts = append(messageParts, a.renderThinking(a.message.ReasoningContent().Thinking, width))
+	} else if a.message.ReasoningDiscarded() {
+		messageParts = append(messageParts, a.renderDiscardedThinking())
 	}
 
 	// 然后添加主要内容
 	if content != "" {
 		// 在思考内容和主要内容之间添加间隔
-		if thinking != "" {
+		if len(messageParts) > 0 {
 			messageParts = append(messageParts, "")
 		}
 		messageParts = append(messageParts, a.renderMarkdown(content, width))
@@ -187,14 +190,20 @@ func (a *AssistantMessageItem) renderThinking(thinking string, width int) string
 	return result
 }
 
+// renderDiscardedThinking 渲染推理内容按配置没有保存时的提示。
+func (a *AssistantMessageItem) renderDiscardedThinking() string {
+	footer := a.sty.Chat.Message.ThinkingFooterTitle.Render("推理内容未保存")
+	if duration := a.message.ThinkingDuration(); duration > 0 {
+		footer = a.sty.Chat.Message.ThinkingFooterTitle.Render("思考用时 ") +
+			a.sty.Chat.Message.ThinkingFooterDuration.Render(duration.String()) +
+			a.sty.Chat.Message.ThinkingFooterTitle.Render("，推理内容未保存")
+	}
+	return footer
+}
+
 // renderMarkdown 将内容渲染为 Markdown 格式。
 func (a *AssistantMessageItem) renderMarkdown(content string, width int) string {
-	renderer := common.MarkdownRenderer(a.sty, width)
-	result, err := renderer.Render(content)
-	if err != nil {
-		return content
-	}
-	return strings.TrimSuffix(result, "\n")
+	return renderMarkdown(a.sty, content, width)
 }
 
 func (a *AssistantMessageItem) renderSpinning() string {
@@ -242,6 +251,33 @@ func (a *AssistantMessageItem) ToggleExpanded() {
 	a.clearCache()
 }
 
+// ReasoningTrace 实现 ReasoningViewable 接口。
+func (a *AssistantMessageItem) ReasoningTrace() (ReasoningTrace, bool) {
+	reasoning := a.message.ReasoningContent()
+	if strings.TrimSpace(reasoning.Thinking) == "" && reasoning.StartedAt == 0 {
+		return ReasoningTrace{}, false
+	}
+	trace := ReasoningTrace{
+		ID:        a.message.ID,
+		Text:      strings.TrimSpace(reasoning.Thinking),
+		Duration:  a.message.ThinkingDuration(),
+		Streaming: a.message.IsThinking(),
+		Discarded: a.message.ReasoningDiscarded(),
+	}
+	if finish := a.message.FinishPart(); finish != nil && finish.Metrics != nil {
+		trace.ReasoningTokens = finish.Metrics.ReasoningTokens
+	}
+	return trace, true
+}
+
+// CodeBlocks 实现 CodeRunnable 接口。
+func (a *AssistantMessageItem) CodeBlocks() []codeblock.Block {
+	if !a.message.IsFinished() {
+		return nil
+	}
+	return codeblock.Extract(a.message.Content().Text)
+}
+
 // HandleMouseClick 实现 MouseClickable 接口。
 func (a *AssistantMessageItem) HandleMouseClick(btn ansi.MouseButton, x, y int) bool {
 	if btn != ansi.MouseLeft {
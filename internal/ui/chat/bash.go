@@ -20,6 +20,8 @@ import (
 // BashToolMessageItem 是表示 bash 工具调用的消息项。
 type BashToolMessageItem struct {
 	*baseToolMessageItem
+
+	tail string // 命令运行中时的最近输出
 }
 
 var _ ToolMessageItem = (*BashToolMessageItem)(nil)
@@ -31,11 +33,21 @@ func NewBashToolMessageItem(
 	result *message.ToolResult,
 	canceled bool,
 ) ToolMessageItem {
-	return newBaseToolMessageItem(sty, toolCall, result, &BashToolRenderContext{}, canceled)
+	t := &BashToolMessageItem{}
+	t.baseToolMessageItem = newBaseToolMessageItem(sty, toolCall, result, &BashToolRenderContext{bash: t}, canceled)
+	return t
+}
+
+// SetOutput 更新命令运行中时的最近输出。
+func (b *BashToolMessageItem) SetOutput(tail string) {
+	b.tail = tail
+	b.clearCache()
 }
 
 // BashToolRenderContext 渲染 bash 工具消息。
-type BashToolRenderContext struct{}
+type BashToolRenderContext struct {
+	bash *BashToolMessageItem
+}
 
 // RenderTool 实现 [ToolRenderer] 接口。
 func (b *BashToolRenderContext) RenderTool(sty *styles.Styles, width int, opts *ToolRenderOpts) string {
@@ -81,6 +93,14 @@ func (b *BashToolRenderContext) RenderTool(sty *styles.Styles, width int, opts *
 		return header
 	}
 
+	// 命令运行中时显示最近的输出，而不是等待提示
+	if tail := b.bash.tail; opts.Status == ToolStatusRunning && tail != "" {
+		bodyWidth := cappedWidth - toolBodyLeftPaddingTotal
+		waiting := sty.Tool.StateWaiting.Render("运行中...") + toolCountdown(sty, opts.Remaining)
+		body := sty.Tool.Body.Render(toolOutputPlainContent(sty, tail, bodyWidth, true))
+		return joinToolParts(header, body+"\n\n"+sty.Tool.Body.Render(waiting))
+	}
+
 	// 如果存在早期状态内容，返回头部和早期状态
 	if earlyState, ok := toolEarlyStateContent(sty, opts, cappedWidth); ok {
 		return joinToolParts(header, earlyState)
@@ -0,0 +1,154 @@
+package chat
+
+import (
+	"fmt"
+	"strings"
+
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/purpose168/crush-cn/internal/agent/tools"
+	"github.com/purpose168/crush-cn/internal/message"
+	"github.com/purpose168/crush-cn/internal/ui/common"
+	"github.com/purpose168/crush-cn/internal/ui/styles"
+)
+
+// citationsCollapsedCount 是来源列表折叠时显示的来源数。
+const citationsCollapsedCount = 3
+
+// CitationsID 返回来源脚注项目的稳定 ID。
+func CitationsID(messageID string) string {
+	return fmt.Sprintf("%s:citations", messageID)
+}
+
+// CollectCitations 按调用顺序收集一个回合中网络工具抓取和搜索到的来源，按 URL 去重。
+// msgs 是用户消息之后直到回合结束的所有消息，失败的工具调用不计入。
+func CollectCitations(msgs []*message.Message) []tools.WebSource {
+	var sources []tools.WebSource
+	toolCalls := map[string]message.ToolCall{}
+	for _, msg := range msgs {
+		switch msg.Role {
+		case message.Assistant:
+			for _, tc := range msg.ToolCalls() {
+				toolCalls[tc.ID] = tc
+			}
+		case message.Tool:
+			for _, result := range msg.ToolResults() {
+				tc, ok := toolCalls[result.ToolCallID]
+				if !ok || result.IsError {
+					continue
+				}
+				sources = tools.MergeWebSources(sources, tools.WebSources(tc.Name, tc.Input, result.Metadata)...)
+			}
+		}
+	}
+	return sources
+}
+
+// CitationsItem 在助手回合结束后以编号脚注列出本回合抓取和搜索到的来源，
+// 来源较多时默认折叠，点击或按空格键展开。
+type CitationsItem struct {
+	*cachedMessageItem
+	*focusableMessageItem
+
+	id       string
+	sources  []tools.WebSource
+	sty      *styles.Styles
+	expanded bool
+}
+
+var (
+	_ Expandable      = (*CitationsItem)(nil)
+	_ KeyEventHandler = (*CitationsItem)(nil)
+)
+
+// NewCitationsItem 为以 messageID 结束的回合创建一个新的 CitationsItem。
+func NewCitationsItem(sty *styles.Styles, messageID string, sources []tools.WebSource) MessageItem {
+	return &CitationsItem{
+		cachedMessageItem:    &cachedMessageItem{},
+		focusableMessageItem: &focusableMessageItem{},
+		id:                   CitationsID(messageID),
+		sources:              sources,
+		sty:                  sty,
+	}
+}
+
+// ID 实现 MessageItem 接口。
+func (c *CitationsItem) ID() string {
+	return c.id
+}
+
+// RawRender 实现 MessageItem 接口。
+func (c *CitationsItem) RawRender(width int) string {
+	innerWidth := max(0, width-MessageLeftPaddingTotal)
+	content, _, ok := c.getCachedRender(innerWidth)
+	if !ok {
+		content = c.renderContent(innerWidth)
+		height := lipgloss.Height(content)
+		c.setCachedRender(content, innerWidth, height)
+	}
+	return content
+}
+
+// Render 实现 MessageItem 接口。
+func (c *CitationsItem) Render(width int) string {
+	style := c.sty.Chat.Message.AssistantBlurred
+	if c.focused {
+		style = c.sty.Chat.Message.AssistantFocused
+	}
+	return style.Render(c.RawRender(width))
+}
+
+func (c *CitationsItem) renderContent(width int) string {
+	sty := c.sty
+	icon := sty.Chat.Message.AssistantInfoIcon.Render(styles.CitationsIcon)
+	header := icon + " " + sty.Chat.Message.AssistantInfoMetrics.Render(fmt.Sprintf("来源 (%d)", len(c.sources)))
+	lines := []string{common.Section(sty, header, width)}
+
+	for i, source := range c.sources {
+		if !c.expanded && i == citationsCollapsedCount {
+			lines = append(lines, sty.Subtle.Render(fmt.Sprintf(
+				"  … 还有 %d 个来源 [点击或按空格键展开]", len(c.sources)-citationsCollapsedCount)))
+			break
+		}
+		number := sty.Subtle.Render(fmt.Sprintf("[%d] ", i+1))
+		line := "  " + number
+		if source.Title != "" {
+			line += sty.Base.Render(source.Title) + " "
+		}
+		line += sty.Muted.Render(source.URL)
+		lines = append(lines, ansi.Truncate(line, width, "…"))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// ToggleExpanded 实现 Expandable 接口。
+func (c *CitationsItem) ToggleExpanded() bool {
+	if len(c.sources) <= citationsCollapsedCount {
+		return false
+	}
+	c.expanded = !c.expanded
+	c.clearCache()
+	return c.expanded
+}
+
+// HandleMouseClick 实现 MouseClickable 接口，展开状态由 ToggleExpanded 切换。
+func (c *CitationsItem) HandleMouseClick(btn ansi.MouseButton, x, y int) bool {
+	return btn == ansi.MouseLeft
+}
+
+// HandleKeyEvent 实现 KeyEventHandler 接口，按 c 或 y 将来源列表复制为 Markdown。
+func (c *CitationsItem) HandleKeyEvent(key tea.KeyMsg) (bool, tea.Cmd) {
+	if k := key.String(); k == "c" || k == "y" {
+		var sb strings.Builder
+		for i, source := range c.sources {
+			fmt.Fprintf(&sb, "[%d]: %s", i+1, source.URL)
+			if source.Title != "" {
+				fmt.Fprintf(&sb, " %q", source.Title)
+			}
+			sb.WriteString("\n")
+		}
+		return true, common.CopyToClipboard(sb.String(), "来源已复制到剪贴板")
+	}
+	return false, nil
+}
@@ -0,0 +1,70 @@
+package chat
+
+// codeScrollStep 是水平滚动代码输出时每次移动的列数。
+const codeScrollStep = 8
+
+// CodeWrap 是代码输出中超出宽度的长行的显示方式。
+type CodeWrap int
+
+const (
+	// CodeWrapTruncate 截断长行并以 "…" 结尾
+	CodeWrapTruncate CodeWrap = iota
+	// CodeWrapSoft 将长行折到下一行显示
+	CodeWrapSoft
+	// CodeWrapScroll 截断长行，可以左右滚动查看被截断的部分
+	CodeWrapScroll
+)
+
+// String 返回显示方式的名称。
+func (w CodeWrap) String() string {
+	switch w {
+	case CodeWrapSoft:
+		return "软换行"
+	case CodeWrapScroll:
+		return "水平滚动"
+	default:
+		return "截断"
+	}
+}
+
+// CodeView 是工具项中代码输出的显示状态。
+type CodeView struct {
+	// Wrap 是长行的显示方式
+	Wrap CodeWrap
+	// ScrollX 是水平滚动模式下向右滚动的列数
+	ScrollX int
+	// Overflow 表示上次渲染的代码输出中是否有超出宽度的行，由渲染函数设置
+	Overflow bool
+}
+
+// CodeViewable 是可以切换代码输出中长行显示方式的消息项接口。
+type CodeViewable interface {
+	// CycleCodeWrap 依次切换截断、软换行和水平滚动，返回新的显示方式。
+	// 代码输出中没有超出宽度的行时不切换并返回 false。
+	CycleCodeWrap() (CodeWrap, bool)
+	// ScrollCode 在水平滚动模式下按 delta 步左右滚动代码输出，不处于该模式时返回 false。
+	ScrollCode(delta int) bool
+}
+
+var _ CodeViewable = (*baseToolMessageItem)(nil)
+
+// CycleCodeWrap 实现 [CodeViewable] 接口。
+func (t *baseToolMessageItem) CycleCodeWrap() (CodeWrap, bool) {
+	if !t.codeView.Overflow && t.codeView.Wrap == CodeWrapTruncate {
+		return t.codeView.Wrap, false
+	}
+	t.codeView.Wrap = (t.codeView.Wrap + 1) % (CodeWrapScroll + 1)
+	t.codeView.ScrollX = 0
+	t.clearCache()
+	return t.codeView.Wrap, true
+}
+
+// ScrollCode 实现 [CodeViewable] 接口。
+func (t *baseToolMessageItem) ScrollCode(delta int) bool {
+	if t.codeView.Wrap != CodeWrapScroll {
+		return false
+	}
+	t.codeView.ScrollX = max(0, t.codeView.ScrollX+delta*codeScrollStep)
+	t.clearCache()
+	return true
+}
@@ -75,7 +75,7 @@ func (f *FetchToolRenderContext) RenderTool(sty *styles.Styles, width int, opts
 
 	// 根据格式确定文件扩展名以进行语法高亮
 	file := getFileExtensionForFormat(params.Format)
-	body := toolOutputCodeContent(sty, file, opts.Result.Content, 0, cappedWidth, opts.ExpandedContent)
+	body := toolOutputCodeContent(sty, file, opts.Result.Content, 0, cappedWidth, opts.ExpandedContent, &opts.CodeView)
 	return joinToolParts(header, body)
 }
 
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"strings"
 
+	"charm.land/lipgloss/v2"
 	"github.com/purpose168/crush-cn/internal/agent/tools"
 	"github.com/purpose168/crush-cn/internal/fsext"
 	"github.com/purpose168/crush-cn/internal/message"
@@ -51,14 +52,30 @@ func (v *ViewToolRenderContext) RenderTool(sty *styles.Styles, width int, opts *
 		return toolErrorContent(sty, &message.ToolResult{Content: "无效参数"}, cappedWidth)
 	}
 
+	// 结果的元数据中包含实际显示的窗口和文件的总行数
+	var meta tools.ViewResponseMetadata
+	if opts.HasResult() && opts.Result.Metadata != "" {
+		_ = json.Unmarshal([]byte(opts.Result.Metadata), &meta)
+	}
+
 	// 构建工具参数显示列表
 	file := fsext.PrettyPath(params.FilePath)
 	toolParams := []string{file}
-	if params.Limit != 0 {
-		toolParams = append(toolParams, "limit", fmt.Sprintf("%d", params.Limit))
-	}
-	if params.Offset != 0 {
-		toolParams = append(toolParams, "offset", fmt.Sprintf("%d", params.Offset))
+	switch {
+	case meta.TotalLines > 0:
+		// 文件没有全部显示时显示当前位置
+		if meta.Offset > 0 || meta.Lines < meta.TotalLines {
+			toolParams = append(toolParams, "行", fmt.Sprintf("%d-%d/%d", meta.Offset+1, meta.Offset+meta.Lines, meta.TotalLines))
+		}
+	case params.Page != "":
+		toolParams = append(toolParams, "page", params.Page)
+	default:
+		if params.Limit != 0 {
+			toolParams = append(toolParams, "limit", fmt.Sprintf("%d", params.Limit))
+		}
+		if params.Offset != 0 {
+			toolParams = append(toolParams, "offset", fmt.Sprintf("%d", params.Offset))
+		}
 	}
 
 	// 生成工具头部信息
@@ -79,14 +96,13 @@ func (v *ViewToolRenderContext) RenderTool(sty *styles.Styles, width int, opts *
 
 	// 处理图片内容
 	if opts.Result.Data != "" && strings.HasPrefix(opts.Result.MIMEType, "image/") {
-		body := toolOutputImageContent(sty, opts.Result.Data, opts.Result.MIMEType)
+		body := toolOutputImageContent(sty, opts.Result.Data, opts.Result.MIMEType, opts.Thumbnail, cappedWidth)
 		return joinToolParts(header, body)
 	}
 
 	// 优先从元数据中获取内容（包含实际的文件内容）
-	var meta tools.ViewResponseMetadata
 	content := opts.Result.Content
-	if err := json.Unmarshal([]byte(opts.Result.Metadata), &meta); err == nil && meta.Content != "" {
+	if meta.Content != "" {
 		content = meta.Content
 	}
 
@@ -94,8 +110,14 @@ func (v *ViewToolRenderContext) RenderTool(sty *styles.Styles, width int, opts *
 		return header
 	}
 
+	// 翻页时实际的起始行只记录在元数据中
+	offset := params.Offset
+	if meta.TotalLines > 0 {
+		offset = meta.Offset
+	}
+
 	// 渲染代码内容并进行语法高亮
-	body := toolOutputCodeContent(sty, params.FilePath, content, params.Offset, cappedWidth, opts.ExpandedContent)
+	body := toolOutputCodeContent(sty, params.FilePath, content, offset, cappedWidth, opts.ExpandedContent, &opts.CodeView)
 	return joinToolParts(header, body)
 }
 
@@ -157,8 +179,8 @@ func (w *WriteToolRenderContext) RenderTool(sty *styles.Styles, width int, opts
 	}
 
 	// 渲染代码内容并进行语法高亮
-	body := toolOutputCodeContent(sty, params.FilePath, params.Content, 0, cappedWidth, opts.ExpandedContent)
-	return joinToolParts(header, body)
+	body := toolOutputCodeContent(sty, params.FilePath, params.Content, 0, cappedWidth, opts.ExpandedContent, &opts.CodeView)
+	return withCheckFailures(sty, opts, cappedWidth, joinToolParts(header, body))
 }
 
 // -----------------------------------------------------------------------------
@@ -226,7 +248,7 @@ func (e *EditToolRenderContext) RenderTool(sty *styles.Styles, width int, opts *
 
 	// 渲染差异对比内容
 	body := toolOutputDiffContent(sty, file, meta.OldContent, meta.NewContent, width, opts.ExpandedContent)
-	return joinToolParts(header, body)
+	return withCheckFailures(sty, opts, width, joinToolParts(header, body))
 }
 
 // -----------------------------------------------------------------------------
@@ -266,6 +288,16 @@ func (m *MultiEditToolRenderContext) RenderTool(sty *styles.Styles, width int, o
 		return toolErrorContent(sty, &message.ToolResult{Content: "无效参数"}, width)
 	}
 
+	// 跨文件编辑按文件分别显示差异
+	if len(params.Files) > 0 {
+		totalEdits := 0
+		for _, f := range params.Files {
+			totalEdits += len(f.Edits)
+		}
+		toolParams := []string{fmt.Sprintf("%d 个文件", len(params.Files)), "edits", fmt.Sprintf("%d", totalEdits)}
+		return withCheckFailures(sty, opts, width, renderWorkspaceEditTool(sty, "Multi-Edit", width, opts, toolParams))
+	}
+
 	// 构建工具参数显示列表
 	file := fsext.PrettyPath(params.FilePath)
 	toolParams := []string{file}
@@ -300,7 +332,7 @@ func (m *MultiEditToolRenderContext) RenderTool(sty *styles.Styles, width int, o
 
 	// 渲染差异对比内容，并可选显示失败编辑的提示
 	body := toolOutputMultiEditDiffContent(sty, file, meta, len(params.Edits), width, opts.ExpandedContent)
-	return joinToolParts(header, body)
+	return withCheckFailures(sty, opts, width, joinToolParts(header, body))
 }
 
 // -----------------------------------------------------------------------------
@@ -310,6 +342,8 @@ func (m *MultiEditToolRenderContext) RenderTool(sty *styles.Styles, width int, o
 // DownloadToolMessageItem 表示下载工具调用的消息项。
 type DownloadToolMessageItem struct {
 	*baseToolMessageItem
+
+	progress *tools.DownloadProgress // 下载进行中时的最新进度
 }
 
 var _ ToolMessageItem = (*DownloadToolMessageItem)(nil)
@@ -321,11 +355,21 @@ func NewDownloadToolMessageItem(
 	result *message.ToolResult,
 	canceled bool,
 ) ToolMessageItem {
-	return newBaseToolMessageItem(sty, toolCall, result, &DownloadToolRenderContext{}, canceled)
+	t := &DownloadToolMessageItem{}
+	t.baseToolMessageItem = newBaseToolMessageItem(sty, toolCall, result, &DownloadToolRenderContext{download: t}, canceled)
+	return t
+}
+
+// SetProgress 更新下载进度。
+func (d *DownloadToolMessageItem) SetProgress(progress tools.DownloadProgress) {
+	d.progress = &progress
+	d.clearCache()
 }
 
 // DownloadToolRenderContext 渲染下载工具消息。
-type DownloadToolRenderContext struct{}
+type DownloadToolRenderContext struct {
+	download *DownloadToolMessageItem
+}
 
 // RenderTool 实现 [ToolRenderer] 接口。
 func (d *DownloadToolRenderContext) RenderTool(sty *styles.Styles, width int, opts *ToolRenderOpts) string {
@@ -351,6 +395,9 @@ func (d *DownloadToolRenderContext) RenderTool(sty *styles.Styles, width int, op
 	if params.Timeout != 0 {
 		toolParams = append(toolParams, "timeout", formatTimeout(params.Timeout))
 	}
+	if params.Resume {
+		toolParams = append(toolParams, "resume", "true")
+	}
 
 	// 生成工具头部信息
 	header := toolHeader(sty, opts.Status, "Download", cappedWidth, opts.Compact, toolParams...)
@@ -358,6 +405,12 @@ func (d *DownloadToolRenderContext) RenderTool(sty *styles.Styles, width int, op
 		return header
 	}
 
+	// 下载进行中时显示进度，而不是等待提示
+	if progress := d.download.progress; opts.Status == ToolStatusRunning && progress != nil {
+		bodyWidth := cappedWidth - toolBodyLeftPaddingTotal
+		return joinToolParts(header, sty.Tool.Body.Render(downloadProgressContent(sty, *progress, bodyWidth)))
+	}
+
 	// 检查是否有早期状态内容（如错误或取消状态）
 	if earlyState, ok := toolEarlyStateContent(sty, opts, cappedWidth); ok {
 		return joinToolParts(header, earlyState)
@@ -373,3 +426,26 @@ func (d *DownloadToolRenderContext) RenderTool(sty *styles.Styles, width int, op
 	body := sty.Tool.Body.Render(toolOutputPlainContent(sty, opts.Result.Content, bodyWidth, opts.ExpandedContent))
 	return joinToolParts(header, body)
 }
+
+// downloadProgressBarWidth 是下载进度条的最大宽度。
+const downloadProgressBarWidth = 30
+
+// downloadProgressContent 渲染下载进度。知道文件总大小时显示进度条和百分比，
+// 否则只显示已下载的大小。
+func downloadProgressContent(sty *styles.Styles, progress tools.DownloadProgress, width int) string {
+	downloaded := formatSize(int(progress.Downloaded))
+	if progress.Total <= 0 {
+		return sty.Tool.StateWaiting.Render(fmt.Sprintf("已下载 %s...", downloaded))
+	}
+
+	ratio := min(1, float64(progress.Downloaded)/float64(progress.Total))
+	text := fmt.Sprintf(" %3.0f%% %s / %s", ratio*100, downloaded, formatSize(int(progress.Total)))
+	barWidth := min(downloadProgressBarWidth, width-lipgloss.Width(text))
+	if barWidth <= 0 {
+		return sty.Tool.StateWaiting.Render(strings.TrimSpace(text))
+	}
+	filled := int(ratio * float64(barWidth))
+	bar := sty.Tool.ProgressFilled.Render(strings.Repeat("█", filled)) +
+		sty.Tool.ProgressEmpty.Render(strings.Repeat("░", barWidth-filled))
+	return bar + sty.Tool.StateWaiting.Render(text)
+}
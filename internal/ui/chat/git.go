@@ -0,0 +1,250 @@
+package chat
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/purpose168/crush-cn/internal/agent/tools"
+	"github.com/purpose168/crush-cn/internal/fsext"
+	"github.com/purpose168/crush-cn/internal/message"
+	"github.com/purpose168/crush-cn/internal/ui/styles"
+)
+
+// -----------------------------------------------------------------------------
+// Git 状态工具
+// -----------------------------------------------------------------------------
+
+// GitStatusToolMessageItem 是表示 git_status 工具调用的消息项。
+type GitStatusToolMessageItem struct {
+	*baseToolMessageItem
+}
+
+var _ ToolMessageItem = (*GitStatusToolMessageItem)(nil)
+
+// NewGitStatusToolMessageItem 创建一个新的 [GitStatusToolMessageItem]。
+func NewGitStatusToolMessageItem(
+	sty *styles.Styles,
+	toolCall message.ToolCall,
+	result *message.ToolResult,
+	canceled bool,
+) ToolMessageItem {
+	return newBaseToolMessageItem(sty, toolCall, result, &GitStatusToolRenderContext{}, canceled)
+}
+
+// GitStatusToolRenderContext 渲染 git_status 工具消息。
+type GitStatusToolRenderContext struct{}
+
+// RenderTool 实现 [ToolRenderer] 接口。
+func (g *GitStatusToolRenderContext) RenderTool(sty *styles.Styles, width int, opts *ToolRenderOpts) string {
+	cappedWidth := cappedMessageWidth(width)
+	if opts.IsPending() {
+		return pendingTool(sty, "Git 状态", opts.Anim)
+	}
+
+	var params tools.GitStatusParams
+	_ = json.Unmarshal([]byte(opts.ToolCall.Input), &params)
+
+	var meta tools.GitStatusResponseMetadata
+	if opts.HasResult() {
+		_ = json.Unmarshal([]byte(opts.Result.Metadata), &meta)
+	}
+
+	// 优先显示元数据中的分支和变更数量
+	toolParams := []string{fsext.PrettyPath(cmp.Or(meta.Root, params.Path, "."))}
+	if meta.Branch != "" {
+		toolParams = append(toolParams, "分支", meta.Branch)
+	}
+	if meta.Linked {
+		toolParams = append(toolParams, "工作树", "链接")
+	}
+	if opts.HasResult() && meta.Root != "" {
+		toolParams = append(toolParams, "变更", fmt.Sprintf("%d", meta.Changes))
+	}
+
+	header := toolHeader(sty, opts.Status, "Git 状态", cappedWidth, opts.Compact, toolParams...)
+	if opts.Compact {
+		return header
+	}
+
+	if earlyState, ok := toolEarlyStateContent(sty, opts, cappedWidth); ok {
+		return joinToolParts(header, earlyState)
+	}
+
+	if opts.HasEmptyResult() {
+		return header
+	}
+
+	bodyWidth := cappedWidth - toolBodyLeftPaddingTotal
+	body := sty.Tool.Body.Render(toolOutputPlainContent(sty, opts.Result.Content, bodyWidth, opts.ExpandedContent))
+	return joinToolParts(header, body)
+}
+
+// -----------------------------------------------------------------------------
+// Git 差异工具
+// -----------------------------------------------------------------------------
+
+// GitDiffToolMessageItem 是表示 git_diff 工具调用的消息项。
+type GitDiffToolMessageItem struct {
+	*baseToolMessageItem
+}
+
+var _ ToolMessageItem = (*GitDiffToolMessageItem)(nil)
+
+// NewGitDiffToolMessageItem 创建一个新的 [GitDiffToolMessageItem]。
+func NewGitDiffToolMessageItem(
+	sty *styles.Styles,
+	toolCall message.ToolCall,
+	result *message.ToolResult,
+	canceled bool,
+) ToolMessageItem {
+	return newBaseToolMessageItem(sty, toolCall, result, &GitDiffToolRenderContext{}, canceled)
+}
+
+// GitDiffToolRenderContext 渲染 git_diff 工具消息，复用编辑工具的差异视图。
+type GitDiffToolRenderContext struct{}
+
+// RenderTool 实现 [ToolRenderer] 接口。
+func (g *GitDiffToolRenderContext) RenderTool(sty *styles.Styles, width int, opts *ToolRenderOpts) string {
+	if opts.IsPending() {
+		return pendingTool(sty, "Git 差异", opts.Anim)
+	}
+
+	var params tools.GitDiffParams
+	_ = json.Unmarshal([]byte(opts.ToolCall.Input), &params)
+
+	var meta tools.GitDiffResponseMetadata
+	if opts.HasResult() {
+		_ = json.Unmarshal([]byte(opts.Result.Metadata), &meta)
+	}
+
+	var toolParams []string
+	switch {
+	case params.Staged:
+		toolParams = append(toolParams, "已暂存")
+	case params.Ref != "":
+		toolParams = append(toolParams, params.Ref)
+	default:
+		toolParams = append(toolParams, "工作区")
+	}
+	if len(params.Files) > 0 {
+		toolParams = append(toolParams, "文件", strings.Join(params.Files, ", "))
+	}
+	if len(meta.Files) > 0 {
+		toolParams = append(toolParams, "变更", fmt.Sprintf("%d 个文件 +%d -%d", len(meta.Files), meta.Additions, meta.Removals))
+	}
+
+	header := toolHeader(sty, opts.Status, "Git 差异", width, opts.Compact, toolParams...)
+	if opts.Compact {
+		return header
+	}
+
+	if earlyState, ok := toolEarlyStateContent(sty, opts, width); ok {
+		return joinToolParts(header, earlyState)
+	}
+
+	if opts.HasEmptyResult() {
+		return header
+	}
+
+	// 没有可渲染的文件内容时（例如旧版本的结果），显示原始差异文本
+	bodyWidth := width - toolBodyLeftPaddingTotal
+	var rendered, skipped []string
+	for _, f := range meta.Files {
+		if f.Binary || (f.OldContent == "" && f.NewContent == "") {
+			skipped = append(skipped, f.Path)
+			continue
+		}
+		if len(rendered) > 0 && !opts.ExpandedContent {
+			skipped = append(skipped, f.Path)
+			continue
+		}
+		path := fsext.PrettyPath(filepath.Join(meta.Root, f.Path))
+		rendered = append(rendered, toolOutputDiffContent(sty, path, f.OldContent, f.NewContent, width, opts.ExpandedContent))
+	}
+	if len(rendered) == 0 {
+		body := sty.Tool.Body.Render(toolOutputPlainContent(sty, opts.Result.Content, bodyWidth, opts.ExpandedContent))
+		return joinToolParts(header, body)
+	}
+	if len(skipped) > 0 {
+		note := fmt.Sprintf("另有 %d 个文件的更改未显示: %s", len(skipped), strings.Join(skipped, ", "))
+		rendered = append(rendered, sty.Tool.Body.Render(sty.Subtle.Width(bodyWidth).Render(note)))
+	}
+	return joinToolParts(header, strings.Join(rendered, "\n\n"))
+}
+
+// -----------------------------------------------------------------------------
+// Git 提交工具
+// -----------------------------------------------------------------------------
+
+// GitCommitToolMessageItem 是表示 git_commit 工具调用的消息项。
+type GitCommitToolMessageItem struct {
+	*baseToolMessageItem
+}
+
+var _ ToolMessageItem = (*GitCommitToolMessageItem)(nil)
+
+// NewGitCommitToolMessageItem 创建一个新的 [GitCommitToolMessageItem]。
+func NewGitCommitToolMessageItem(
+	sty *styles.Styles,
+	toolCall message.ToolCall,
+	result *message.ToolResult,
+	canceled bool,
+) ToolMessageItem {
+	return newBaseToolMessageItem(sty, toolCall, result, &GitCommitToolRenderContext{}, canceled)
+}
+
+// GitCommitToolRenderContext 渲染 git_commit 工具消息。
+type GitCommitToolRenderContext struct{}
+
+// RenderTool 实现 [ToolRenderer] 接口。
+func (g *GitCommitToolRenderContext) RenderTool(sty *styles.Styles, width int, opts *ToolRenderOpts) string {
+	cappedWidth := cappedMessageWidth(width)
+	if opts.IsPending() {
+		return pendingTool(sty, "Git 提交", opts.Anim)
+	}
+
+	var params tools.GitCommitParams
+	_ = json.Unmarshal([]byte(opts.ToolCall.Input), &params)
+
+	var meta tools.GitCommitResponseMetadata
+	if opts.HasResult() {
+		_ = json.Unmarshal([]byte(opts.Result.Metadata), &meta)
+	}
+
+	subject, _, _ := strings.Cut(strings.TrimSpace(params.Message), "\n")
+	toolParams := []string{subject}
+	if meta.Hash != "" {
+		toolParams = append(toolParams, "提交", meta.Hash)
+	}
+	if meta.Branch != "" {
+		toolParams = append(toolParams, "分支", meta.Branch)
+	}
+
+	header := toolHeader(sty, opts.Status, "Git 提交", cappedWidth, opts.Compact, toolParams...)
+	if opts.Compact {
+		return header
+	}
+
+	if earlyState, ok := toolEarlyStateContent(sty, opts, cappedWidth); ok {
+		return joinToolParts(header, earlyState)
+	}
+
+	if opts.HasEmptyResult() {
+		return header
+	}
+
+	// 显示最终的提交信息（包括署名）和提交的文件
+	content := opts.Result.Content
+	if meta.Message != "" {
+		content = strings.TrimSpace(meta.Message)
+		if len(meta.Files) > 0 {
+			content += "\n\n" + strings.Join(meta.Files, "\n")
+		}
+	}
+	bodyWidth := cappedWidth - toolBodyLeftPaddingTotal
+	body := sty.Tool.Body.Render(toolOutputPlainContent(sty, content, bodyWidth, opts.ExpandedContent))
+	return joinToolParts(header, body)
+}
@@ -0,0 +1,109 @@
+package chat
+
+import (
+	"hash/maphash"
+	"sync"
+	"sync/atomic"
+
+	tea "charm.land/bubbletea/v2"
+	lru "github.com/hashicorp/golang-lru/v2"
+	"github.com/purpose168/crush-cn/internal/ui/styles"
+)
+
+const (
+	// highlightCacheSize 是缓存的代码块高亮结果数量。
+	highlightCacheSize = 64
+	// backgroundHighlightLines 是在后台高亮的最少行数，高亮完成前显示未高亮的内容。
+	backgroundHighlightLines = 500
+)
+
+// HighlightReadyMsg 在后台语法高亮完成后发送，收到后重新渲染聊天即可显示高亮结果。
+type HighlightReadyMsg struct{}
+
+// WaitHighlight 返回等待下一次后台语法高亮完成的命令，处理 [HighlightReadyMsg] 后需要再次调用。
+func WaitHighlight() tea.Cmd {
+	return func() tea.Msg {
+		<-highlights.ready
+		return HighlightReadyMsg{}
+	}
+}
+
+// highlightKey 标识一次代码块高亮的全部输入。高亮结果与宽度和长行的显示方式无关，
+// 调整窗口大小或切换换行模式时不需要重新高亮。
+type highlightKey struct {
+	sty      *styles.Styles
+	content  uint64
+	path     string
+	expanded bool
+}
+
+// highlightCache 缓存代码块的语法高亮结果，避免展开和折叠时重新高亮。
+type highlightCache struct {
+	seed    maphash.Seed
+	entries *lru.Cache[highlightKey, string]
+
+	mu      sync.Mutex
+	pending map[highlightKey]struct{}
+	// generation 在每次后台高亮完成后递增，渲染缓存据此判断是否过期
+	generation atomic.Uint64
+	ready      chan struct{}
+}
+
+var highlights = newHighlightCache(highlightCacheSize)
+
+func newHighlightCache(size int) *highlightCache {
+	entries, _ := lru.New[highlightKey, string](size)
+	return &highlightCache{
+		seed:    maphash.MakeSeed(),
+		entries: entries,
+		pending: make(map[highlightKey]struct{}),
+		ready:   make(chan struct{}, 1),
+	}
+}
+
+// key 返回代码块高亮的缓存键。
+func (c *highlightCache) key(sty *styles.Styles, path, content string, expanded bool) highlightKey {
+	return highlightKey{
+		sty:      sty,
+		content:  maphash.String(c.seed, content),
+		path:     path,
+		expanded: expanded,
+	}
+}
+
+// render 返回缓存的高亮结果，未命中时调用 render 高亮并缓存。超过
+// [backgroundHighlightLines] 行的内容在后台高亮，先返回未高亮的内容。
+func (c *highlightCache) render(key highlightKey, lines int, render func(highlight bool) string) string {
+	if out, ok := c.entries.Get(key); ok {
+		return out
+	}
+	if lines < backgroundHighlightLines {
+		out := render(true)
+		c.entries.Add(key, out)
+		return out
+	}
+
+	c.mu.Lock()
+	if _, ok := c.pending[key]; !ok {
+		c.pending[key] = struct{}{}
+		go c.highlightInBackground(key, render)
+	}
+	c.mu.Unlock()
+	return render(false)
+}
+
+// highlightInBackground 高亮代码块并通知界面重新渲染。
+func (c *highlightCache) highlightInBackground(key highlightKey, render func(highlight bool) string) {
+	out := render(true)
+	c.entries.Add(key, out)
+
+	c.mu.Lock()
+	delete(c.pending, key)
+	c.mu.Unlock()
+
+	c.generation.Add(1)
+	select {
+	case c.ready <- struct{}{}:
+	default:
+	}
+}
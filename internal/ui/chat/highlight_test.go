@@ -0,0 +1,45 @@
+package chat
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHighlightCache(t *testing.T) {
+	t.Parallel()
+
+	c := newHighlightCache(2)
+	var calls atomic.Int32
+	render := func(highlight bool) string {
+		calls.Add(1)
+		if highlight {
+			return "highlighted"
+		}
+		return "plain"
+	}
+
+	// 小代码块同步高亮，之后命中缓存不再调用 render。
+	small := c.key(nil, "main.go", "package main", false)
+	require.Equal(t, "highlighted", c.render(small, 1, render))
+	require.Equal(t, "highlighted", c.render(small, 1, render))
+	require.Equal(t, int32(1), calls.Load())
+
+	// 展开状态不同的同一内容使用不同的缓存项。
+	require.NotEqual(t, small, c.key(nil, "main.go", "package main", true))
+
+	// 大代码块先返回未高亮的内容，后台高亮完成后递增 generation 并通知界面。
+	large := c.key(nil, "big.go", "large", false)
+	generation := c.generation.Load()
+	require.Equal(t, "plain", c.render(large, backgroundHighlightLines, render))
+	select {
+	case <-c.ready:
+	case <-time.After(5 * time.Second):
+		t.Fatal("后台高亮未完成")
+	}
+	require.Equal(t, generation+1, c.generation.Load())
+	require.Equal(t, "highlighted", c.render(large, backgroundHighlightLines, render))
+	require.Equal(t, int32(3), calls.Load())
+}
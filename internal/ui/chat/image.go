@@ -0,0 +1,102 @@
+package chat
+
+import (
+	"log/slog"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/purpose168/crush-cn/internal/ui/common"
+	fimage "github.com/purpose168/crush-cn/internal/ui/image"
+)
+
+// 缩略图的最大尺寸（单元格）。
+const (
+	thumbnailMaxCols = 48
+	thumbnailMaxRows = 12
+)
+
+// ImageData 是消息项中包含的图像。
+type ImageData struct {
+	// ID 是包含图像的消息项的 ID。
+	ID string
+	// Title 是查看图像时显示的标题。
+	Title string
+	// Data 是 base64 编码的图像数据。
+	Data string
+	// MIMEType 是图像的 MIME 类型。
+	MIMEType string
+}
+
+// ImageViewable 是包含可查看图像的项目的接口。
+type ImageViewable interface {
+	// Image 返回项目中的图像，没有图像时返回 false。
+	Image() (ImageData, bool)
+}
+
+// Thumbnailable 是可以内联显示图像缩略图的项目的接口。
+type Thumbnailable interface {
+	MessageItem
+	ImageViewable
+	// LoadThumbnail 返回解码并向终端传输缩略图的命令。缩略图已加载
+	// 或无需加载时返回 nil。
+	LoadThumbnail(settings ImageSettings) tea.Cmd
+	// SetThumbnail 在缩略图传输完成后更新项目。
+	SetThumbnail(msg ThumbnailReadyMsg)
+}
+
+// ImageSettings 描述在聊天中渲染内联图像的方式。
+type ImageSettings struct {
+	Encoding fimage.Encoding
+	CellSize fimage.CellSize
+	Tmux     bool
+}
+
+// NewImageSettings 根据终端能力创建 [ImageSettings]。iTerm2 和 Sixel 需要
+// 按屏幕坐标绘制图像，无法随聊天列表滚动，因此缩略图回退为半块字符。
+func NewImageSettings(caps *common.Capabilities) ImageSettings {
+	enc := fimage.Negotiate(caps.ImageSupport())
+	if enc == fimage.EncodingITerm2 || enc == fimage.EncodingSixel {
+		enc = fimage.EncodingHalfBlocks
+	}
+	cw, ch := caps.CellSize()
+	_, tmux := caps.Env.LookupEnv("TMUX")
+	return ImageSettings{
+		Encoding: enc,
+		CellSize: fimage.CellSize{Width: cw, Height: ch},
+		Tmux:     tmux,
+	}
+}
+
+// ThumbnailReadyMsg 在缩略图传输到终端后发送。Cols 为零表示图像无法解码。
+type ThumbnailReadyMsg struct {
+	// ID 是包含图像的消息项的 ID。
+	ID       string
+	ImageID  string
+	Encoding fimage.Encoding
+	Cols     int
+	Rows     int
+}
+
+// loadThumbnailCmd 返回解码图像、传输缩略图并发送 [ThumbnailReadyMsg] 的命令。
+func loadThumbnailCmd(id string, img ImageData, settings ImageSettings) tea.Cmd {
+	return func() tea.Msg {
+		decoded, err := fimage.DecodeBase64(img.Data)
+		if err != nil {
+			slog.Warn("无法解码工具结果中的图像", "id", id, "mime_type", img.MIMEType, "error", err)
+			return ThumbnailReadyMsg{ID: id}
+		}
+		imageID := "thumbnail-" + id
+		cols, rows := fimage.FitCells(decoded.Bounds().Size(), settings.CellSize, thumbnailMaxCols, thumbnailMaxRows)
+		ready := ThumbnailReadyMsg{
+			ID:       id,
+			ImageID:  imageID,
+			Encoding: settings.Encoding,
+			Cols:     cols,
+			Rows:     rows,
+		}
+		seq := tea.Sequence(
+			settings.Encoding.Transmit(imageID, decoded, settings.CellSize, cols, rows, settings.Tmux),
+			func() tea.Msg { return ready },
+		)
+		return seq()
+	}
+}
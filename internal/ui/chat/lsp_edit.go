@@ -0,0 +1,151 @@
+package chat
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/purpose168/crush-cn/internal/agent/tools"
+	"github.com/purpose168/crush-cn/internal/fsext"
+	"github.com/purpose168/crush-cn/internal/message"
+	"github.com/purpose168/crush-cn/internal/ui/styles"
+)
+
+// -----------------------------------------------------------------------------
+// LSP 重命名工具
+// -----------------------------------------------------------------------------
+
+// LSPRenameToolMessageItem 是表示 lsp_rename 工具调用的消息项。
+type LSPRenameToolMessageItem struct {
+	*baseToolMessageItem
+}
+
+var _ ToolMessageItem = (*LSPRenameToolMessageItem)(nil)
+
+// NewLSPRenameToolMessageItem 创建一个新的 [LSPRenameToolMessageItem]。
+func NewLSPRenameToolMessageItem(
+	sty *styles.Styles,
+	toolCall message.ToolCall,
+	result *message.ToolResult,
+	canceled bool,
+) ToolMessageItem {
+	return newBaseToolMessageItem(sty, toolCall, result, &LSPRenameToolRenderContext{}, canceled)
+}
+
+// LSPRenameToolRenderContext 渲染 lsp_rename 工具消息。
+type LSPRenameToolRenderContext struct{}
+
+// RenderTool 实现 [ToolRenderer] 接口。
+func (r *LSPRenameToolRenderContext) RenderTool(sty *styles.Styles, width int, opts *ToolRenderOpts) string {
+	if opts.IsPending() {
+		return pendingTool(sty, "重命名", opts.Anim)
+	}
+
+	var params tools.LSPRenameParams
+	_ = json.Unmarshal([]byte(opts.ToolCall.Input), &params)
+
+	toolParams := []string{fmt.Sprintf("%s %s %s", params.Symbol, styles.ArrowRightIcon, params.NewName)}
+	if params.FilePath != "" {
+		toolParams = append(toolParams, "文件", fsext.PrettyPath(params.FilePath))
+	}
+	return renderWorkspaceEditTool(sty, "重命名", width, opts, toolParams)
+}
+
+// -----------------------------------------------------------------------------
+// LSP 代码操作工具
+// -----------------------------------------------------------------------------
+
+// LSPCodeActionToolMessageItem 是表示 lsp_code_action 工具调用的消息项。
+type LSPCodeActionToolMessageItem struct {
+	*baseToolMessageItem
+}
+
+var _ ToolMessageItem = (*LSPCodeActionToolMessageItem)(nil)
+
+// NewLSPCodeActionToolMessageItem 创建一个新的 [LSPCodeActionToolMessageItem]。
+func NewLSPCodeActionToolMessageItem(
+	sty *styles.Styles,
+	toolCall message.ToolCall,
+	result *message.ToolResult,
+	canceled bool,
+) ToolMessageItem {
+	return newBaseToolMessageItem(sty, toolCall, result, &LSPCodeActionToolRenderContext{}, canceled)
+}
+
+// LSPCodeActionToolRenderContext 渲染 lsp_code_action 工具消息。
+type LSPCodeActionToolRenderContext struct{}
+
+// RenderTool 实现 [ToolRenderer] 接口。
+func (c *LSPCodeActionToolRenderContext) RenderTool(sty *styles.Styles, width int, opts *ToolRenderOpts) string {
+	if opts.IsPending() {
+		return pendingTool(sty, "代码操作", opts.Anim)
+	}
+
+	var params tools.LSPCodeActionParams
+	_ = json.Unmarshal([]byte(opts.ToolCall.Input), &params)
+
+	location := fsext.PrettyPath(params.FilePath)
+	if params.Line > 0 {
+		location = fmt.Sprintf("%s:%d", location, params.Line)
+		if params.EndLine > params.Line {
+			location = fmt.Sprintf("%s-%d", location, params.EndLine)
+		}
+	}
+	toolParams := []string{location}
+	if params.Kind != "" {
+		toolParams = append(toolParams, "类型", params.Kind)
+	}
+	if params.Apply != "" {
+		toolParams = append(toolParams, "应用", params.Apply)
+	}
+	return renderWorkspaceEditTool(sty, "代码操作", width, opts, toolParams)
+}
+
+// renderWorkspaceEditTool 渲染应用 LSP 工作区编辑的工具消息，结果中包含文件更改时
+// 显示每个文件的差异，否则显示工具的文本输出。
+func renderWorkspaceEditTool(sty *styles.Styles, name string, width int, opts *ToolRenderOpts, toolParams []string) string {
+	var meta tools.WorkspaceEditResponseMetadata
+	if opts.HasResult() {
+		_ = json.Unmarshal([]byte(opts.Result.Metadata), &meta)
+	}
+	if len(meta.Files) > 0 {
+		toolParams = append(toolParams, "变更", fmt.Sprintf("%d 个文件 +%d -%d", len(meta.Files), meta.Additions, meta.Removals))
+	}
+
+	cappedWidth := cappedMessageWidth(width)
+	header := toolHeader(sty, opts.Status, name, cappedWidth, opts.Compact, toolParams...)
+	if opts.Compact {
+		return header
+	}
+
+	if earlyState, ok := toolEarlyStateContent(sty, opts, cappedWidth); ok {
+		return joinToolParts(header, earlyState)
+	}
+
+	if opts.HasEmptyResult() {
+		return header
+	}
+
+	if len(meta.Files) == 0 {
+		bodyWidth := cappedWidth - toolBodyLeftPaddingTotal
+		body := sty.Tool.Body.Render(toolOutputPlainContent(sty, opts.Result.Content, bodyWidth, opts.ExpandedContent))
+		return joinToolParts(header, body)
+	}
+
+	// 未展开时只显示第一个文件的差异
+	bodyWidth := width - toolBodyLeftPaddingTotal
+	var rendered, skipped []string
+	for _, f := range meta.Files {
+		path := fsext.PrettyPath(f.FilePath)
+		if len(rendered) > 0 && !opts.ExpandedContent {
+			skipped = append(skipped, path)
+			continue
+		}
+		rendered = append(rendered, toolOutputDiffContent(sty, path, f.OldContent, f.NewContent, width, opts.ExpandedContent))
+	}
+	if len(skipped) > 0 {
+		note := fmt.Sprintf("另有 %d 个文件的更改未显示: %s", len(skipped), strings.Join(skipped, ", "))
+		rendered = append(rendered, sty.Tool.Body.Render(sty.Subtle.Width(bodyWidth).Render(note)))
+	}
+	return joinToolParts(header, strings.Join(rendered, "\n\n"))
+}
@@ -0,0 +1,63 @@
+package chat
+
+import (
+	"encoding/json"
+	"strconv"
+
+	"github.com/purpose168/crush-cn/internal/agent/tools"
+	"github.com/purpose168/crush-cn/internal/message"
+	"github.com/purpose168/crush-cn/internal/ui/styles"
+)
+
+// LSPSymbolsToolMessageItem 是表示工作区符号搜索工具调用的消息项。
+type LSPSymbolsToolMessageItem struct {
+	*baseToolMessageItem
+}
+
+var _ ToolMessageItem = (*LSPSymbolsToolMessageItem)(nil)
+
+// NewLSPSymbolsToolMessageItem 创建一个新的 [LSPSymbolsToolMessageItem]。
+func NewLSPSymbolsToolMessageItem(
+	sty *styles.Styles,
+	toolCall message.ToolCall,
+	result *message.ToolResult,
+	canceled bool,
+) ToolMessageItem {
+	return newBaseToolMessageItem(sty, toolCall, result, &LSPSymbolsToolRenderContext{}, canceled)
+}
+
+// LSPSymbolsToolRenderContext 渲染工作区符号搜索工具消息。
+type LSPSymbolsToolRenderContext struct{}
+
+// RenderTool 实现 [ToolRenderer] 接口。
+func (s *LSPSymbolsToolRenderContext) RenderTool(sty *styles.Styles, width int, opts *ToolRenderOpts) string {
+	cappedWidth := cappedMessageWidth(width)
+	if opts.IsPending() {
+		return pendingTool(sty, "搜索符号", opts.Anim)
+	}
+
+	var params tools.LSPSymbolsParams
+	_ = json.Unmarshal([]byte(opts.ToolCall.Input), &params)
+
+	toolParams := []string{params.Query}
+	if params.Limit > 0 {
+		toolParams = append(toolParams, "数量", strconv.Itoa(params.Limit))
+	}
+
+	header := toolHeader(sty, opts.Status, "搜索符号", cappedWidth, opts.Compact, toolParams...)
+	if opts.Compact {
+		return header
+	}
+
+	if earlyState, ok := toolEarlyStateContent(sty, opts, cappedWidth); ok {
+		return joinToolParts(header, earlyState)
+	}
+
+	if opts.HasEmptyResult() {
+		return header
+	}
+
+	bodyWidth := cappedWidth - toolBodyLeftPaddingTotal
+	body := sty.Tool.Body.Render(toolOutputPlainContent(sty, opts.Result.Content, bodyWidth, opts.ExpandedContent))
+	return joinToolParts(header, body)
+}
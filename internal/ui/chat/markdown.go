@@ -0,0 +1,112 @@
+package chat
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/charmbracelet/x/ansi"
+	"github.com/purpose168/crush-cn/internal/config"
+	"github.com/purpose168/crush-cn/internal/ui/common"
+	"github.com/purpose168/crush-cn/internal/ui/styles"
+)
+
+// markdownOptions 是聊天 Markdown 的排版选项，由 [SetMarkdownOptions] 在启动时设置。
+var markdownOptions config.Markdown
+
+// SetMarkdownOptions 设置用户和助手消息的 Markdown 排版选项。
+func SetMarkdownOptions(opts config.Markdown) {
+	markdownOptions = opts
+}
+
+// markdownWidth 返回用户和助手消息的宽度，配置了 max_width 时以其代替默认的最大宽度。
+func markdownWidth(availableWidth int) int {
+	if markdownOptions.MaxWidth > 0 {
+		return min(availableWidth-MessageLeftPaddingTotal, markdownOptions.MaxWidth)
+	}
+	return cappedMessageWidth(availableWidth)
+}
+
+// renderMarkdown 按排版选项渲染 Markdown，渲染失败时返回原文。
+func renderMarkdown(sty *styles.Styles, content string, width int) string {
+	if markdownOptions.CodeWrap || markdownOptions.Hyphenation {
+		var margin int
+		if m := sty.Markdown.CodeBlock.Margin; m != nil {
+			margin = int(*m)
+		}
+		content = prepareMarkdown(content, width, width-2*margin)
+	}
+	result, err := common.MarkdownRenderer(sty, width).Render(content)
+	if err != nil {
+		return content
+	}
+	return strings.TrimSuffix(result, "\n")
+}
+
+// prepareMarkdown 在渲染前调整 Markdown 源文本：按 codeWidth 折行代码块中过长的行，
+// 并将正文中比 width 更长的单词断开，在行尾加上连字符。
+func prepareMarkdown(content string, width, codeWidth int) string {
+	lines := strings.Split(content, "\n")
+	var fence string
+	for i, line := range lines {
+		trimmed := strings.TrimLeft(line, " ")
+		indent := line[:len(line)-len(trimmed)]
+		switch {
+		case fence == "" && (strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~")):
+			fence = trimmed[:3]
+		case fence != "" && strings.HasPrefix(trimmed, fence):
+			fence = ""
+		case fence != "":
+			if markdownOptions.CodeWrap {
+				lines[i] = wrapCodeLine(line, indent, codeWidth-len(indent))
+			}
+		case markdownOptions.Hyphenation:
+			lines[i] = hyphenate(line, width)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// wrapCodeLine 将代码块中超过 width 的行折成多行，每行保留代码块的缩进。
+func wrapCodeLine(line, indent string, width int) string {
+	line = strings.ReplaceAll(line, "\t", "    ")
+	if width <= 0 || ansi.StringWidth(line) <= width+len(indent) {
+		return line
+	}
+	wrapped := strings.Split(ansi.Hardwrap(strings.TrimPrefix(line, indent), width, true), "\n")
+	return indent + strings.Join(wrapped, "\n"+indent)
+}
+
+// hyphenate 将行中比 width 更长的单词断成多段，除最后一段外每段以连字符结尾。
+// 行内代码、链接和路径等不是纯单词的内容保持不变。
+func hyphenate(line string, width int) string {
+	if width < 2 || ansi.StringWidth(line) <= width {
+		return line
+	}
+	words := strings.Split(line, " ")
+	for i, word := range words {
+		if ansi.StringWidth(word) <= width || !isPlainWord(word) {
+			continue
+		}
+		var parts []string
+		for ansi.StringWidth(word) > width {
+			head := ansi.Truncate(word, width-1, "")
+			if head == "" {
+				break
+			}
+			parts = append(parts, head+"-")
+			word = word[len(head):]
+		}
+		words[i] = strings.Join(append(parts, word), " ")
+	}
+	return strings.Join(words, " ")
+}
+
+// isPlainWord 报告单词是否只包含字母、数字和常见的连接符。
+func isPlainWord(word string) bool {
+	for _, r := range word {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && !strings.ContainsRune("-_.,;!?'\"", r) {
+			return false
+		}
+	}
+	return true
+}
@@ -93,6 +93,12 @@ func (b *MCPToolRenderContext) RenderTool(sty *styles.Styles, width int, opts *T
 	}
 
 	bodyWidth := cappedWidth - toolBodyLeftPaddingTotal
+	// 处理图像数据
+	if opts.Result.Data != "" && strings.HasPrefix(opts.Result.MIMEType, "image/") {
+		body := toolOutputImageContent(sty, opts.Result.Data, opts.Result.MIMEType, opts.Thumbnail, cappedWidth)
+		return joinToolParts(header, body)
+	}
+
 	// 检查结果是否为 JSON 格式
 	var result json.RawMessage
 	var body string
@@ -101,14 +107,14 @@ func (b *MCPToolRenderContext) RenderTool(sty *styles.Styles, width int, opts *T
 		prettyResult, err := json.MarshalIndent(result, "", "  ")
 		if err == nil {
 			// 成功格式化 JSON，以代码块形式显示
-			body = sty.Tool.Body.Render(toolOutputCodeContent(sty, "result.json", string(prettyResult), 0, bodyWidth, opts.ExpandedContent))
+			body = sty.Tool.Body.Render(toolOutputCodeContent(sty, "result.json", string(prettyResult), 0, bodyWidth, opts.ExpandedContent, &opts.CodeView))
 		} else {
 			// JSON 格式化失败，以纯文本形式显示
 			body = sty.Tool.Body.Render(toolOutputPlainContent(sty, opts.Result.Content, bodyWidth, opts.ExpandedContent))
 		}
 	} else if looksLikeMarkdown(opts.Result.Content) {
 		// 如果内容看起来像 Markdown，以 Markdown 格式显示
-		body = sty.Tool.Body.Render(toolOutputCodeContent(sty, "result.md", opts.Result.Content, 0, bodyWidth, opts.ExpandedContent))
+		body = sty.Tool.Body.Render(toolOutputCodeContent(sty, "result.md", opts.Result.Content, 0, bodyWidth, opts.ExpandedContent, &opts.CodeView))
 	} else {
 		// 其他情况以纯文本形式显示
 		body = sty.Tool.Body.Render(toolOutputPlainContent(sty, opts.Result.Content, bodyWidth, opts.ExpandedContent))
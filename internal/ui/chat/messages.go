@@ -9,6 +9,7 @@ import (
 	tea "charm.land/bubbletea/v2"
 	"charm.land/catwalk/pkg/catwalk"
 	"charm.land/lipgloss/v2"
+	"github.com/purpose168/crush-cn/internal/codeblock"
 	"github.com/purpose168/crush-cn/internal/config"
 	"github.com/purpose168/crush-cn/internal/message"
 	"github.com/purpose168/crush-cn/internal/ui/anim"
@@ -48,6 +49,12 @@ type KeyEventHandler interface {
 	HandleKeyEvent(key tea.KeyMsg) (bool, tea.Cmd)
 }
 
+// CodeRunnable 是包含可运行代码块的项目的接口。
+type CodeRunnable interface {
+	// CodeBlocks 返回项目中可运行的代码块。
+	CodeBlocks() []codeblock.Block
+}
+
 // MessageItem 表示可在 UI 中显示并可作为 [list.List] 一部分的 [message.Message] 项目，
 // 通过唯一 ID 进行标识。
 type MessageItem interface {
@@ -244,9 +251,45 @@ func (a *AssistantInfoItem) renderContent(width int) string {
 	}
 	provider := a.sty.Chat.Message.AssistantInfoProvider.Render(fmt.Sprintf("通过 %s", providerName))
 	assistant := fmt.Sprintf("%s %s %s %s", icon, modelFormatted, provider, infoMsg)
+	if metrics := formatResponseMetrics(finishData.Metrics); metrics != "" {
+		assistant += " " + a.sty.Chat.Message.AssistantInfoMetrics.Render(metrics)
+	}
 	return common.Section(a.sty, assistant, width)
 }
 
+// formatResponseMetrics 格式化首令牌时间、生成速度、提供商延迟和提示缓存命中情况，没有指标时返回空字符串。
+func formatResponseMetrics(metrics *message.ResponseMetrics) string {
+	if metrics == nil {
+		return ""
+	}
+	var parts []string
+	if metrics.FirstTokenMs > 0 {
+		parts = append(parts, "首令牌 "+formatMillis(metrics.FirstTokenMs))
+	}
+	if metrics.TokensPerSecond > 0 {
+		parts = append(parts, fmt.Sprintf("%.1f 令牌/秒", metrics.TokensPerSecond))
+	}
+	if metrics.LatencyMs > 0 {
+		parts = append(parts, "延迟 "+formatMillis(metrics.LatencyMs))
+	}
+	// InputTokens 已包含读取的缓存令牌，写入缓存的令牌另外计算
+	if total := metrics.InputTokens + metrics.CacheCreationTokens; total > 0 && metrics.CacheReadTokens+metrics.CacheCreationTokens > 0 {
+		parts = append(parts, fmt.Sprintf("缓存命中 %d%%", metrics.CacheReadTokens*100/total))
+	}
+	if metrics.CacheCreationTokens > 0 {
+		parts = append(parts, "缓存写入 "+formatTokenCount(metrics.CacheCreationTokens))
+	}
+	return strings.Join(parts, " · ")
+}
+
+// formatMillis 将毫秒数格式化为易读的时长，例如 "850ms" 或 "1.2s"。
+func formatMillis(ms int64) string {
+	if ms < 1000 {
+		return fmt.Sprintf("%dms", ms)
+	}
+	return fmt.Sprintf("%.1fs", float64(ms)/1000)
+}
+
 // cappedMessageWidth 返回消息内容的最大宽度以确保可读性。
 func cappedMessageWidth(availableWidth int) int {
 	return min(availableWidth-MessageLeftPaddingTotal, maxTextWidth)
@@ -0,0 +1,25 @@
+package chat
+
+import "time"
+
+// ReasoningTrace 是助手消息的推理过程，在推理查看器中显示。
+type ReasoningTrace struct {
+	// ID 是助手消息的 ID。
+	ID string
+	// Text 是推理文本。
+	Text string
+	// Duration 是推理用时，推理尚未开始时为 0。
+	Duration time.Duration
+	// ReasoningTokens 是提供商上报的推理令牌数，未上报时为 0。
+	ReasoningTokens int64
+	// Streaming 表示推理仍在进行。
+	Streaming bool
+	// Discarded 表示推理文本按配置没有保存。
+	Discarded bool
+}
+
+// ReasoningViewable 是包含推理过程的项目的接口。
+type ReasoningViewable interface {
+	// ReasoningTrace 返回项目的推理过程，没有推理时返回 false。
+	ReasoningTrace() (ReasoningTrace, bool)
+}
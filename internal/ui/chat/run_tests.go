@@ -0,0 +1,182 @@
+package chat
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"charm.land/lipgloss/v2"
+	"charm.land/lipgloss/v2/tree"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/purpose168/crush-cn/internal/agent/tools"
+	"github.com/purpose168/crush-cn/internal/fsext"
+	"github.com/purpose168/crush-cn/internal/message"
+	"github.com/purpose168/crush-cn/internal/ui/styles"
+)
+
+// -----------------------------------------------------------------------------
+// 运行测试工具
+// -----------------------------------------------------------------------------
+
+// maxCollapsedTestFailures 是折叠时每个套件显示的最大失败数
+const maxCollapsedTestFailures = 3
+
+// RunTestsToolMessageItem 是表示 run_tests 工具调用的消息项。
+type RunTestsToolMessageItem struct {
+	*baseToolMessageItem
+}
+
+var _ ToolMessageItem = (*RunTestsToolMessageItem)(nil)
+
+// NewRunTestsToolMessageItem 创建一个新的 [RunTestsToolMessageItem]。
+func NewRunTestsToolMessageItem(
+	sty *styles.Styles,
+	toolCall message.ToolCall,
+	result *message.ToolResult,
+	canceled bool,
+) ToolMessageItem {
+	return newBaseToolMessageItem(sty, toolCall, result, &RunTestsToolRenderContext{}, canceled)
+}
+
+// RunTestsToolRenderContext 渲染 run_tests 工具消息，以树的形式显示每个套件的通过和失败情况。
+type RunTestsToolRenderContext struct{}
+
+// RenderTool 实现 [ToolRenderer] 接口。
+func (r *RunTestsToolRenderContext) RenderTool(sty *styles.Styles, width int, opts *ToolRenderOpts) string {
+	cappedWidth := cappedMessageWidth(width)
+	if opts.IsPending() {
+		return pendingTool(sty, "运行测试", opts.Anim)
+	}
+
+	var params tools.RunTestsParams
+	_ = json.Unmarshal([]byte(opts.ToolCall.Input), &params)
+
+	var meta tools.RunTestsResponseMetadata
+	if opts.HasResult() {
+		_ = json.Unmarshal([]byte(opts.Result.Metadata), &meta)
+	}
+
+	toolParams := []string{fsext.PrettyPath(cmp.Or(meta.Dir, params.Path, "."))}
+	if runner := cmp.Or(meta.Runner, params.Runner); runner != "" {
+		toolParams = append(toolParams, "框架", runner)
+	}
+	if params.Filter != "" {
+		toolParams = append(toolParams, "过滤", params.Filter)
+	}
+
+	header := toolHeader(sty, opts.Status, "运行测试", cappedWidth, opts.Compact, toolParams...)
+	if opts.Compact {
+		return header
+	}
+
+	if earlyState, ok := toolEarlyStateContent(sty, opts, cappedWidth); ok {
+		return joinToolParts(header, earlyState)
+	}
+
+	if opts.HasEmptyResult() {
+		return header
+	}
+
+	bodyWidth := cappedWidth - toolBodyLeftPaddingTotal
+	// 没有解析出测试结果时显示原始输出
+	if len(meta.Suites) == 0 {
+		body := sty.Tool.Body.Render(toolOutputPlainContent(sty, opts.Result.Content, bodyWidth, opts.ExpandedContent))
+		return joinToolParts(header, body)
+	}
+
+	body := sty.Tool.Body.Render(renderTestTree(sty, meta, bodyWidth, opts.ExpandedContent))
+	return joinToolParts(header, body)
+}
+
+// renderTestTree 渲染测试结果树：根节点是汇总，子节点是套件，失败的套件下列出失败的测试。
+// 折叠时全部通过的套件合并为一行，每个套件最多显示 maxCollapsedTestFailures 个失败。
+func renderTestTree(sty *styles.Styles, meta tools.RunTestsResponseMetadata, width int, expanded bool) string {
+	summary := []string{fmt.Sprintf("%s %d 通过", sty.Tool.IconSuccess.String(), meta.Passed)}
+	if meta.Failed > 0 {
+		summary = append(summary, fmt.Sprintf("%s %d 失败", sty.Tool.IconError.String(), meta.Failed))
+	}
+	if meta.Skipped > 0 {
+		summary = append(summary, sty.Subtle.Render(fmt.Sprintf("%d 跳过", meta.Skipped)))
+	}
+	if meta.EndTime > meta.StartTime {
+		seconds := float64(meta.EndTime-meta.StartTime) / 1000
+		summary = append(summary, sty.Subtle.Render(fmt.Sprintf("%.1f 秒", seconds)))
+	}
+
+	root := tree.Root(strings.Join(summary, "  ")).
+		Enumerator(tree.RoundedEnumerator).
+		EnumeratorStyle(sty.Subtle).
+		IndenterStyle(sty.Subtle)
+
+	failures := make(map[string][]tools.TestFailure)
+	for _, f := range meta.Failures {
+		failures[f.Suite] = append(failures[f.Suite], f)
+	}
+
+	// 树的每一层缩进 4 列
+	suiteWidth := width - 4
+	passedSuites := 0
+	for _, suite := range meta.Suites {
+		if suite.Failed == 0 && !expanded {
+			passedSuites++
+			continue
+		}
+		icon := sty.Tool.IconSuccess.String()
+		counts := fmt.Sprintf("%d/%d", suite.Passed, suite.Passed+suite.Failed)
+		if suite.Failed > 0 {
+			icon = sty.Tool.IconError.String()
+		}
+		if suite.Skipped > 0 {
+			counts += fmt.Sprintf("，%d 跳过", suite.Skipped)
+		}
+		line := fmt.Sprintf("%s %s %s", icon, fsext.PrettyPath(suite.Name), sty.Subtle.Render(counts))
+		node := tree.Root(ansi.Truncate(line, suiteWidth, "…"))
+
+		suiteFailures := failures[suite.Name]
+		for i, f := range suiteFailures {
+			if i == maxCollapsedTestFailures && !expanded {
+				node.Child(sty.Subtle.Render(fmt.Sprintf("… 另有 %d 个失败", len(suiteFailures)-i)))
+				break
+			}
+			node.Child(renderTestFailure(sty, f, suiteWidth-4))
+		}
+		root.Child(node)
+	}
+	if passedSuites > 0 {
+		root.Child(fmt.Sprintf("%s %s", sty.Tool.IconSuccess.String(), sty.Subtle.Render(fmt.Sprintf("%d 个套件全部通过", passedSuites))))
+	}
+	return root.String()
+}
+
+// renderTestFailure 渲染一个失败的测试：名称和位置，下一行是失败消息的第一行。
+func renderTestFailure(sty *styles.Styles, f tools.TestFailure, width int) string {
+	name := cmp.Or(f.Name, "[编译或加载失败]")
+	location := fsext.PrettyPath(f.File)
+	if f.Line > 0 {
+		location = fmt.Sprintf("%s:%d", location, f.Line)
+	}
+	line := fmt.Sprintf("%s %s", sty.Tool.IconError.String(), name)
+	if f.File != "" {
+		line += " " + sty.Subtle.Render(location)
+	}
+	lines := []string{ansi.Truncate(line, width, "…")}
+
+	msg := firstTestMessageLine(f)
+	if msg != "" {
+		lines = append(lines, sty.Subtle.Render(ansi.Truncate("  "+msg, width, "…")))
+	}
+	return lipgloss.JoinVertical(lipgloss.Left, lines...)
+}
+
+// firstTestMessageLine 返回失败消息中第一个有意义的行，跳过只包含位置的行。
+func firstTestMessageLine(f tools.TestFailure) string {
+	for line := range strings.SplitSeq(f.Message, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "Error Trace:") || strings.HasPrefix(line, "def ") {
+			continue
+		}
+		return line
+	}
+	return ""
+}
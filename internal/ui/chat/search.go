@@ -2,6 +2,7 @@ package chat
 
 import (
 	"encoding/json"
+	"fmt"
 
 	"github.com/purpose168/crush-cn/internal/agent/tools"
 	"github.com/purpose168/crush-cn/internal/fsext"
@@ -193,6 +194,134 @@ func (l *LSToolRenderContext) RenderTool(sty *styles.Styles, width int, opts *To
 	return joinToolParts(header, body)
 }
 
+// -----------------------------------------------------------------------------
+// Repo Map 工具
+// -----------------------------------------------------------------------------
+
+// RepoMapToolMessageItem 是表示 repo_map 工具调用的消息项。
+type RepoMapToolMessageItem struct {
+	*baseToolMessageItem
+}
+
+var _ ToolMessageItem = (*RepoMapToolMessageItem)(nil)
+
+// NewRepoMapToolMessageItem 创建一个新的 [RepoMapToolMessageItem]。
+func NewRepoMapToolMessageItem(
+	sty *styles.Styles,
+	toolCall message.ToolCall,
+	result *message.ToolResult,
+	canceled bool,
+) ToolMessageItem {
+	return newBaseToolMessageItem(sty, toolCall, result, &RepoMapToolRenderContext{}, canceled)
+}
+
+// RepoMapToolRenderContext 渲染 repo_map 工具消息。
+type RepoMapToolRenderContext struct{}
+
+// RenderTool 实现 [ToolRenderer] 接口。
+func (r *RepoMapToolRenderContext) RenderTool(sty *styles.Styles, width int, opts *ToolRenderOpts) string {
+	cappedWidth := cappedMessageWidth(width)
+	if opts.IsPending() {
+		return pendingTool(sty, "Repo Map", opts.Anim)
+	}
+
+	var params tools.RepoMapParams
+	if err := json.Unmarshal([]byte(opts.ToolCall.Input), &params); err != nil {
+		return toolErrorContent(sty, &message.ToolResult{Content: "无效参数"}, cappedWidth)
+	}
+
+	path := params.Path
+	if path == "" {
+		path = "."
+	}
+	toolParams := []string{fsext.PrettyPath(path)}
+	if opts.HasResult() {
+		var meta tools.RepoMapResponseMetadata
+		if err := json.Unmarshal([]byte(opts.Result.Metadata), &meta); err == nil && meta.Cached {
+			toolParams = append(toolParams, "cached", "true")
+		}
+	}
+
+	header := toolHeader(sty, opts.Status, "Repo Map", cappedWidth, opts.Compact, toolParams...)
+	if opts.Compact {
+		return header
+	}
+
+	if earlyState, ok := toolEarlyStateContent(sty, opts, cappedWidth); ok {
+		return joinToolParts(header, earlyState)
+	}
+
+	if opts.HasEmptyResult() {
+		return header
+	}
+
+	bodyWidth := cappedWidth - toolBodyLeftPaddingTotal
+	body := sty.Tool.Body.Render(toolOutputPlainContent(sty, opts.Result.Content, bodyWidth, opts.ExpandedContent))
+	return joinToolParts(header, body)
+}
+
+// -----------------------------------------------------------------------------
+// Semantic Search 工具
+// -----------------------------------------------------------------------------
+
+// SemanticSearchToolMessageItem 是表示 semantic_search 工具调用的消息项。
+type SemanticSearchToolMessageItem struct {
+	*baseToolMessageItem
+}
+
+var _ ToolMessageItem = (*SemanticSearchToolMessageItem)(nil)
+
+// NewSemanticSearchToolMessageItem 创建一个新的 [SemanticSearchToolMessageItem]。
+func NewSemanticSearchToolMessageItem(
+	sty *styles.Styles,
+	toolCall message.ToolCall,
+	result *message.ToolResult,
+	canceled bool,
+) ToolMessageItem {
+	return newBaseToolMessageItem(sty, toolCall, result, &SemanticSearchToolRenderContext{}, canceled)
+}
+
+// SemanticSearchToolRenderContext 渲染 semantic_search 工具消息。
+type SemanticSearchToolRenderContext struct{}
+
+// RenderTool 实现 [ToolRenderer] 接口。
+func (s *SemanticSearchToolRenderContext) RenderTool(sty *styles.Styles, width int, opts *ToolRenderOpts) string {
+	cappedWidth := cappedMessageWidth(width)
+	if opts.IsPending() {
+		return pendingTool(sty, "Semantic Search", opts.Anim)
+	}
+
+	var params tools.SemanticSearchParams
+	if err := json.Unmarshal([]byte(opts.ToolCall.Input), &params); err != nil {
+		return toolErrorContent(sty, &message.ToolResult{Content: "无效参数"}, cappedWidth)
+	}
+
+	toolParams := []string{params.Query}
+	if params.Path != "" {
+		toolParams = append(toolParams, "path", fsext.PrettyPath(params.Path))
+	}
+	if params.Limit > 0 {
+		toolParams = append(toolParams, "limit", fmt.Sprintf("%d", params.Limit))
+	}
+
+	header := toolHeader(sty, opts.Status, "Semantic Search", cappedWidth, opts.Compact, toolParams...)
+	if opts.Compact {
+		return header
+	}
+
+	if earlyState, ok := toolEarlyStateContent(sty, opts, cappedWidth); ok {
+		return joinToolParts(header, earlyState)
+	}
+
+	if opts.HasEmptyResult() {
+		return header
+	}
+
+	bodyWidth := cappedWidth - toolBodyLeftPaddingTotal
+	body := sty.Tool.Body.Render(toolOutputPlainContent(sty, opts.Result.Content, bodyWidth, opts.ExpandedContent))
+	return joinToolParts(header, body)
+}
+
 // -----------------------------------------------------------------------------
 // Sourcegraph 工具
 // -----------------------------------------------------------------------------
@@ -0,0 +1,63 @@
+package chat
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/purpose168/crush-cn/internal/message"
+	"github.com/purpose168/crush-cn/internal/ui/styles"
+)
+
+// FailedTool 是执行失败的工具调用，用于提供重试、打开文件等后续操作。
+type FailedTool struct {
+	// Call 是失败的工具调用。
+	Call message.ToolCall
+	// Error 是工具返回的错误信息。
+	Error string
+	// FilePath 是工具操作的文件，工具参数中没有文件时为空。
+	FilePath string
+}
+
+// ToolErrorActionable 是可能包含失败工具调用的项目的接口。
+type ToolErrorActionable interface {
+	// FailedTool 返回失败的工具调用，工具未失败时返回 false。
+	FailedTool() (FailedTool, bool)
+}
+
+var _ ToolErrorActionable = (*baseToolMessageItem)(nil)
+
+// FailedTool 实现 ToolErrorActionable 接口。
+func (t *baseToolMessageItem) FailedTool() (FailedTool, bool) {
+	if t.computeStatus() != ToolStatusError || t.result == nil {
+		return FailedTool{}, false
+	}
+	return FailedTool{
+		Call:     t.toolCall,
+		Error:    strings.TrimSpace(t.result.Content),
+		FilePath: toolFilePath(t.toolCall),
+	}, true
+}
+
+// toolFilePath 返回工具调用参数中的 file_path。
+func toolFilePath(call message.ToolCall) string {
+	var params struct {
+		FilePath string `json:"file_path"`
+	}
+	if err := json.Unmarshal([]byte(call.Input), &params); err != nil {
+		return ""
+	}
+	return strings.TrimSpace(params.FilePath)
+}
+
+// toolErrorActions 渲染失败工具下方的操作提示行。
+func toolErrorActions(sty *styles.Styles, failed FailedTool) string {
+	actions := [][2]string{{"r", "重试"}}
+	if failed.FilePath != "" {
+		actions = append(actions, [2]string{"o", "打开文件"}, [2]string{"e", "重新读取文件后重试"})
+	}
+	parts := make([]string, 0, len(actions))
+	for _, action := range actions {
+		parts = append(parts, sty.Pills.HelpKey.Render(action[0])+" "+sty.Pills.HelpText.Render(action[1]))
+	}
+	return sty.Tool.Body.Render(strings.Join(parts, sty.Pills.HelpText.Render(" · ")))
+}
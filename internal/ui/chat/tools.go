@@ -3,6 +3,8 @@ package chat
 import (
 	"encoding/json"
 	"fmt"
+	"log/slog"
+	"os"
 	"path/filepath"
 	"strings"
 	"time"
@@ -13,10 +15,12 @@ import (
 	"github.com/charmbracelet/x/ansi"
 	"github.com/purpose168/crush-cn/internal/agent"
 	"github.com/purpose168/crush-cn/internal/agent/tools"
+	"github.com/purpose168/crush-cn/internal/checks"
 	"github.com/purpose168/crush-cn/internal/diff"
 	"github.com/purpose168/crush-cn/internal/fsext"
 	"github.com/purpose168/crush-cn/internal/message"
 	"github.com/purpose168/crush-cn/internal/stringext"
+	"github.com/purpose168/crush-cn/internal/tooloutput"
 	"github.com/purpose168/crush-cn/internal/ui/anim"
 	"github.com/purpose168/crush-cn/internal/ui/common"
 	"github.com/purpose168/crush-cn/internal/ui/styles"
@@ -52,6 +56,13 @@ type ToolMessageItem interface {
 	Status() ToolStatus
 }
 
+// Timeoutable 是有执行时限的工具项接口，临近时限时工具项会显示倒计时
+type Timeoutable interface {
+	SetTimeout(timeout time.Duration)
+	// HasDeadline 返回工具是否正在有时限地运行
+	HasDeadline() bool
+}
+
 // Compactable 是可以在紧凑模式下渲染的工具项接口
 // 当启用紧凑模式时，工具渲染为紧凑的单行标题
 type Compactable interface {
@@ -96,6 +107,12 @@ type ToolRenderOpts struct {
 	Compact         bool
 	IsSpinning      bool
 	Status          ToolStatus
+	// Thumbnail 是已渲染的图像缩略图，结果不含图像或缩略图尚未加载时为空
+	Thumbnail string
+	// Remaining 是临近执行时限时的剩余时间，为 0 时不显示倒计时
+	Remaining time.Duration
+	// CodeView 是代码输出中长行的显示状态，渲染代码输出时会更新
+	CodeView CodeView
 }
 
 // IsPending 返回工具调用是否仍在等待中（未完成且未取消）
@@ -153,9 +170,28 @@ type baseToolMessageItem struct {
 	sty             *styles.Styles
 	anim            *anim.Anim
 	expandedContent bool
-}
-
-var _ Expandable = (*baseToolMessageItem)(nil)
+	// fullResult 是从溢出文件读取的未截断结果，在首次展开被截断的结果时加载
+	fullResult *message.ToolResult
+	// thumbnail 是结果中图像的已渲染缩略图
+	thumbnail          string
+	thumbnailRequested bool
+	// highlightGeneration 是缓存渲染结果时后台高亮的完成次数，变化后需要重新渲染以显示高亮结果
+	highlightGeneration uint64
+	// timeout 是工具的执行时限，0 表示不限制
+	timeout time.Duration
+	// runStarted 是工具开始执行或等待权限后恢复执行的时间，
+	// elapsed 是此前已经执行的时间，等待权限的时间不计入时限
+	runStarted time.Time
+	elapsed    time.Duration
+	// codeView 是代码输出中长行的显示状态
+	codeView CodeView
+}
+
+var (
+	_ Expandable    = (*baseToolMessageItem)(nil)
+	_ Thumbnailable = (*baseToolMessageItem)(nil)
+	_ Timeoutable   = (*baseToolMessageItem)(nil)
+)
 
 // newBaseToolMessageItem 是基础工具消息项的内部构造函数
 func newBaseToolMessageItem(
@@ -166,7 +202,8 @@ func newBaseToolMessageItem(
 	canceled bool,
 ) *baseToolMessageItem {
 	// 目前只为 diff 工具使用全宽显示（据我所知）
-	hasCappedWidth := toolCall.Name != tools.EditToolName && toolCall.Name != tools.MultiEditToolName
+	hasCappedWidth := toolCall.Name != tools.EditToolName && toolCall.Name != tools.MultiEditToolName && toolCall.Name != tools.GitDiffToolName &&
+		toolCall.Name != tools.LSPRenameToolName && toolCall.Name != tools.LSPCodeActionToolName
 
 	status := ToolStatusRunning
 	if canceled {
@@ -184,6 +221,9 @@ func newBaseToolMessageItem(
 		status:                   status,
 		hasCappedWidth:           hasCappedWidth,
 	}
+	if toolCall.Finished && result == nil && !canceled {
+		t.runStarted = time.Now()
+	}
 	t.anim = anim.New(anim.Settings{
 		ID:          toolCall.ID,
 		Size:        15,
@@ -229,6 +269,12 @@ func NewToolMessageItem(
 		item = NewGrepToolMessageItem(sty, toolCall, result, canceled)
 	case tools.LSToolName:
 		item = NewLSToolMessageItem(sty, toolCall, result, canceled)
+	case tools.RepoMapToolName:
+		item = NewRepoMapToolMessageItem(sty, toolCall, result, canceled)
+	case tools.RunTestsToolName:
+		item = NewRunTestsToolMessageItem(sty, toolCall, result, canceled)
+	case tools.SemanticSearchToolName:
+		item = NewSemanticSearchToolMessageItem(sty, toolCall, result, canceled)
 	case tools.DownloadToolName:
 		item = NewDownloadToolMessageItem(sty, toolCall, result, canceled)
 	case tools.FetchToolName:
@@ -249,8 +295,20 @@ func NewToolMessageItem(
 		item = NewTodosToolMessageItem(sty, toolCall, result, canceled)
 	case tools.ReferencesToolName:
 		item = NewReferencesToolMessageItem(sty, toolCall, result, canceled)
+	case tools.LSPSymbolsToolName:
+		item = NewLSPSymbolsToolMessageItem(sty, toolCall, result, canceled)
 	case tools.LSPRestartToolName:
 		item = NewLSPRestartToolMessageItem(sty, toolCall, result, canceled)
+	case tools.LSPRenameToolName:
+		item = NewLSPRenameToolMessageItem(sty, toolCall, result, canceled)
+	case tools.LSPCodeActionToolName:
+		item = NewLSPCodeActionToolMessageItem(sty, toolCall, result, canceled)
+	case tools.GitStatusToolName:
+		item = NewGitStatusToolMessageItem(sty, toolCall, result, canceled)
+	case tools.GitDiffToolName:
+		item = NewGitDiffToolMessageItem(sty, toolCall, result, canceled)
+	case tools.GitCommitToolName:
+		item = NewGitCommitToolMessageItem(sty, toolCall, result, canceled)
 	default:
 		if strings.HasPrefix(toolCall.Name, "mcp_") {
 			item = NewMCPToolMessageItem(sty, toolCall, result, canceled)
@@ -297,17 +355,32 @@ func (t *baseToolMessageItem) RawRender(width int) string {
 	}
 
 	content, height, ok := t.getCachedRender(toolItemWidth)
-	// 如果正在旋转或没有缓存，则重新渲染
-	if !ok || t.isSpinning() {
-		content = t.toolRenderer.RenderTool(t.sty, toolItemWidth, &ToolRenderOpts{
+	// 如果正在旋转、显示倒计时、没有缓存或后台高亮已完成，则重新渲染
+	remaining := t.countdown()
+	generation := highlights.generation.Load()
+	if !ok || t.isSpinning() || remaining > 0 || t.highlightGeneration != generation {
+		t.highlightGeneration = generation
+		result := t.result
+		if t.expandedContent && t.fullResult != nil {
+			result = t.fullResult
+		}
+		opts := &ToolRenderOpts{
 			ToolCall:        t.toolCall,
-			Result:          t.result,
+			Result:          result,
 			Anim:            t.anim,
 			ExpandedContent: t.expandedContent,
 			Compact:         t.isCompact,
 			IsSpinning:      t.isSpinning(),
 			Status:          t.computeStatus(),
-		})
+			Thumbnail:       t.thumbnail,
+			Remaining:       remaining,
+			CodeView:        CodeView{Wrap: t.codeView.Wrap, ScrollX: t.codeView.ScrollX},
+		}
+		content = t.toolRenderer.RenderTool(t.sty, toolItemWidth, opts)
+		t.codeView = opts.CodeView
+		if failed, ok := t.FailedTool(); ok && !t.isCompact {
+			content += "\n" + toolErrorActions(t.sty, failed)
+		}
 		height = lipgloss.Height(content)
 		// 缓存渲染的内容
 		t.setCachedRender(content, toolItemWidth, height)
@@ -337,6 +410,9 @@ func (t *baseToolMessageItem) ToolCall() message.ToolCall {
 
 // SetToolCall 设置与此消息项关联的工具调用
 func (t *baseToolMessageItem) SetToolCall(tc message.ToolCall) {
+	if tc.Finished && t.runStarted.IsZero() {
+		t.runStarted = time.Now()
+	}
 	t.toolCall = tc
 	t.clearCache()
 }
@@ -344,6 +420,9 @@ func (t *baseToolMessageItem) SetToolCall(tc message.ToolCall) {
 // SetResult 设置与此消息项关联的工具结果
 func (t *baseToolMessageItem) SetResult(res *message.ToolResult) {
 	t.result = res
+	t.fullResult = nil
+	t.thumbnail = ""
+	t.thumbnailRequested = false
 	t.clearCache()
 }
 
@@ -359,10 +438,40 @@ func (t *baseToolMessageItem) SetMessageID(id string) {
 
 // SetStatus 设置工具状态
 func (t *baseToolMessageItem) SetStatus(status ToolStatus) {
+	switch {
+	case status == ToolStatusAwaitingPermission && t.status == ToolStatusRunning && !t.runStarted.IsZero():
+		t.elapsed += time.Since(t.runStarted)
+	case status == ToolStatusRunning && t.status == ToolStatusAwaitingPermission:
+		t.runStarted = time.Now()
+	}
 	t.status = status
 	t.clearCache()
 }
 
+// SetTimeout 实现 [Timeoutable] 接口
+func (t *baseToolMessageItem) SetTimeout(timeout time.Duration) {
+	t.timeout = timeout
+}
+
+// HasDeadline 实现 [Timeoutable] 接口，等待权限时时限暂停但仍视为有时限
+func (t *baseToolMessageItem) HasDeadline() bool {
+	return t.timeout > 0 && !t.runStarted.IsZero() && t.result == nil && t.status != ToolStatusCanceled
+}
+
+// countdown 返回临近执行时限时的剩余时间，未临近时限时返回 0。
+// 剩余时间不超过时限的三分之一（最多 30 秒）时开始倒计时。
+func (t *baseToolMessageItem) countdown() time.Duration {
+	if !t.HasDeadline() || t.status != ToolStatusRunning {
+		return 0
+	}
+	remaining := t.timeout - t.elapsed - time.Since(t.runStarted)
+	if remaining > min(t.timeout/3, 30*time.Second) {
+		return 0
+	}
+	// 时限已到但结果尚未返回时，保持显示最后一秒
+	return max(remaining, time.Second)
+}
+
 // Status 返回当前工具状态
 func (t *baseToolMessageItem) Status() ToolStatus {
 	return t.status
@@ -399,10 +508,68 @@ func (t *baseToolMessageItem) SetSpinningFunc(fn SpinningFunc) {
 // ToggleExpanded 切换思考框的展开状态
 func (t *baseToolMessageItem) ToggleExpanded() bool {
 	t.expandedContent = !t.expandedContent
+	if t.expandedContent && t.fullResult == nil {
+		t.loadFullResult()
+	}
 	t.clearCache()
 	return t.expandedContent
 }
 
+// loadFullResult 在结果被截断时从溢出文件读取完整输出
+func (t *baseToolMessageItem) loadFullResult() {
+	if t.result == nil {
+		return
+	}
+	path, ok := tooloutput.SpillPath(t.result.Content)
+	if !ok {
+		return
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		slog.Warn("Failed to read full tool output", "path", path, "error", err)
+		return
+	}
+	full := *t.result
+	full.Content = string(data)
+	t.fullResult = &full
+}
+
+// Image 实现 [ImageViewable] 接口
+func (t *baseToolMessageItem) Image() (ImageData, bool) {
+	if t.result == nil || t.result.Data == "" || !strings.HasPrefix(t.result.MIMEType, "image/") {
+		return ImageData{}, false
+	}
+	return ImageData{
+		ID:       t.toolCall.ID,
+		Title:    genericPrettyName(t.toolCall.Name) + " 图像",
+		Data:     t.result.Data,
+		MIMEType: t.result.MIMEType,
+	}, true
+}
+
+// LoadThumbnail 实现 [Thumbnailable] 接口
+func (t *baseToolMessageItem) LoadThumbnail(settings ImageSettings) tea.Cmd {
+	if t.isCompact || t.thumbnailRequested {
+		return nil
+	}
+	img, ok := t.Image()
+	if !ok {
+		return nil
+	}
+	t.thumbnailRequested = true
+	return loadThumbnailCmd(t.toolCall.ID, img, settings)
+}
+
+// SetThumbnail 实现 [Thumbnailable] 接口
+func (t *baseToolMessageItem) SetThumbnail(msg ThumbnailReadyMsg) {
+	if msg.Cols == 0 || msg.Rows == 0 {
+		return
+	}
+	// 立即渲染并保存缩略图，这样即使图像缓存被清除也能继续显示
+	t.thumbnail = msg.Encoding.Render(msg.ImageID, msg.Cols, msg.Rows)
+	t.clearCache()
+}
+
 // HandleMouseClick 实现 MouseClickable
 func (t *baseToolMessageItem) HandleMouseClick(btn ansi.MouseButton, x, y int) bool {
 	return btn == ansi.MouseLeft
@@ -442,7 +609,7 @@ func toolEarlyStateContent(sty *styles.Styles, opts *ToolRenderOpts, width int)
 	case ToolStatusAwaitingPermission:
 		msg = sty.Tool.StateWaiting.Render("正在请求权限...")
 	case ToolStatusRunning:
-		msg = sty.Tool.StateWaiting.Render("等待工具响应...")
+		msg = sty.Tool.StateWaiting.Render("等待工具响应...") + toolCountdown(sty, opts.Remaining)
 	default:
 		return "", false
 	}
@@ -461,6 +628,29 @@ func toolErrorContent(sty *styles.Styles, result *message.ToolResult, width int)
 	return fmt.Sprintf("%s %s", errTag, sty.Tool.ErrorMessage.Render(errContent))
 }
 
+// withCheckFailures 在编辑工具的内容后追加编辑后检查失败的报告
+func withCheckFailures(sty *styles.Styles, opts *ToolRenderOpts, width int, content string) string {
+	if opts.Compact || !opts.HasResult() {
+		return content
+	}
+	report := checks.Extract(opts.Result.Content)
+	if report == "" {
+		return content
+	}
+	tag := sty.Tool.Body.Render(sty.Tool.ErrorTag.Render("检查失败"))
+	output := sty.Tool.Body.Render(toolOutputPlainContent(sty, report, width-toolBodyLeftPaddingTotal, opts.ExpandedContent))
+	return strings.Join([]string{content, "", tag, output}, "\n")
+}
+
+// toolCountdown 渲染临近执行时限时的倒计时，remaining 为 0 时返回空字符串
+func toolCountdown(sty *styles.Styles, remaining time.Duration) string {
+	if remaining <= 0 {
+		return ""
+	}
+	secs := int((remaining + time.Second - 1) / time.Second)
+	return " " + sty.Tool.StateTimeout.Render(fmt.Sprintf("%d 秒后超时", secs))
+}
+
 // toolIcon 返回工具调用的状态图标
 // toolIcon 根据工具调用的状态返回状态图标
 func toolIcon(sty *styles.Styles, status ToolStatus) string {
@@ -559,8 +749,9 @@ func toolOutputPlainContent(sty *styles.Styles, content string, width int, expan
 	return strings.Join(out, "\n")
 }
 
-// toolOutputCodeContent 渲染代码，支持语法高亮和行号
-func toolOutputCodeContent(sty *styles.Styles, path, content string, offset, width int, expanded bool) string {
+// toolOutputCodeContent 渲染代码，支持语法高亮和行号。高亮结果会被缓存，较长的代码在后台高亮。
+// 超出宽度的长行按 view 的模式截断、软换行或水平滚动，渲染时会更新 view 的 Overflow 并限制 ScrollX 的范围。
+func toolOutputCodeContent(sty *styles.Styles, path, content string, offset, width int, expanded bool, view *CodeView) string {
 	content = stringext.NormalizeSpace(content)
 
 	lines := strings.Split(content, "\n")
@@ -575,9 +766,16 @@ func toolOutputCodeContent(sty *styles.Styles, path, content string, offset, wid
 		displayLines = lines[:maxLines]
 	}
 
-	bg := sty.Tool.ContentCodeBg
-	highlighted, _ := common.SyntaxHighlight(sty, strings.Join(displayLines, "\n"), path, bg)
-	highlightedLines := strings.Split(highlighted, "\n")
+	key := highlights.key(sty, path, content, expanded)
+	code := highlights.render(key, len(displayLines), func(highlight bool) string {
+		code := strings.Join(displayLines, "\n")
+		if !highlight {
+			return code
+		}
+		highlighted, _ := common.SyntaxHighlight(sty, code, path, sty.Tool.ContentCodeBg)
+		return highlighted
+	})
+	highlightedLines := strings.Split(code, "\n")
 
 	// 计算行号宽度
 	maxLineNumber := len(displayLines) + offset
@@ -586,19 +784,46 @@ func toolOutputCodeContent(sty *styles.Styles, path, content string, offset, wid
 
 	bodyWidth := width - toolBodyLeftPaddingTotal
 	codeWidth := bodyWidth - maxDigits
+	// 截断时考虑将要添加的填充
+	lineWidth := max(1, codeWidth-sty.Tool.ContentCodeLine.GetHorizontalPadding())
+
+	if view == nil {
+		view = &CodeView{}
+	}
+	widest := 0
+	for _, ln := range highlightedLines {
+		widest = max(widest, ansi.StringWidth(ln))
+	}
+	view.Overflow = widest > lineWidth
+	view.ScrollX = max(0, min(view.ScrollX, widest-lineWidth+1))
 
 	var out []string
 	for i, ln := range highlightedLines {
 		lineNum := sty.Tool.ContentLineNumber.Render(fmt.Sprintf(numFmt, i+1+offset))
 
-		// 截断时考虑将要添加的填充
-		ln = ansi.Truncate(ln, codeWidth-sty.Tool.ContentCodeLine.GetHorizontalPadding(), "…")
-
-		codeLine := sty.Tool.ContentCodeLine.
-			Width(codeWidth).
-			Render(ln)
+		var segments []string
+		switch view.Wrap {
+		case CodeWrapSoft:
+			segments = strings.Split(lipgloss.Wrap(ln, lineWidth, ""), "\n")
+		case CodeWrapScroll:
+			if view.ScrollX > 0 {
+				ln = ansi.TruncateLeft(ln, view.ScrollX, "…")
+			}
+			segments = []string{ansi.Truncate(ln, lineWidth, "…")}
+		default:
+			segments = []string{ansi.Truncate(ln, lineWidth, "…")}
+		}
 
-		out = append(out, lipgloss.JoinHorizontal(lipgloss.Left, lineNum, codeLine))
+		for j, segment := range segments {
+			// 软换行的后续行不显示行号
+			if j > 0 {
+				lineNum = sty.Tool.ContentLineNumber.Render(strings.Repeat(" ", maxDigits))
+			}
+			codeLine := sty.Tool.ContentCodeLine.
+				Width(codeWidth).
+				Render(segment)
+			out = append(out, lipgloss.JoinHorizontal(lipgloss.Left, lineNum, codeLine))
+		}
 	}
 
 	// 如有需要添加截断消息
@@ -612,8 +837,8 @@ func toolOutputCodeContent(sty *styles.Styles, path, content string, offset, wid
 	return sty.Tool.Body.Render(strings.Join(out, "\n"))
 }
 
-// toolOutputImageContent 渲染图像数据及大小信息
-func toolOutputImageContent(sty *styles.Styles, data, mediaType string) string {
+// toolOutputImageContent 渲染图像数据及大小信息，宽度足够时在下方显示缩略图
+func toolOutputImageContent(sty *styles.Styles, data, mediaType, thumbnail string, width int) string {
 	dataSize := len(data) * 3 / 4
 	sizeStr := formatSize(dataSize)
 
@@ -622,7 +847,11 @@ func toolOutputImageContent(sty *styles.Styles, data, mediaType string) string {
 	typeStyled := sty.Base.Render(mediaType)
 	sizeStyled := sty.Subtle.Render(sizeStr)
 
-	return sty.Tool.Body.Render(fmt.Sprintf("%s %s %s %s", loaded, arrow, typeStyled, sizeStyled))
+	info := fmt.Sprintf("%s %s %s %s", loaded, arrow, typeStyled, sizeStyled)
+	if thumbnail != "" && lipgloss.Width(thumbnail) <= width-toolBodyLeftPaddingTotal {
+		info = lipgloss.JoinVertical(lipgloss.Left, info, thumbnail)
+	}
+	return sty.Tool.Body.Render(info)
 }
 
 // getDigits 返回数字的位数
@@ -863,6 +1092,9 @@ func (t *baseToolMessageItem) formatParametersForCopy() string {
 			if params.Offset > 0 {
 				parts = append(parts, fmt.Sprintf("**偏移：** %d", params.Offset))
 			}
+			if params.Page != "" {
+				parts = append(parts, fmt.Sprintf("**翻页：** %s", params.Page))
+			}
 			return strings.Join(parts, "\n")
 		}
 	case tools.EditToolName:
@@ -948,6 +1180,45 @@ func (t *baseToolMessageItem) formatParametersForCopy() string {
 			}
 			return fmt.Sprintf("**路径：** %s", fsext.PrettyPath(path))
 		}
+	case tools.IssueFetchToolName:
+		var params tools.IssueFetchParams
+		if json.Unmarshal([]byte(t.toolCall.Input), &params) == nil {
+			return fmt.Sprintf("**议题：** %s", params.Reference)
+		}
+	case tools.SemanticSearchToolName:
+		var params tools.SemanticSearchParams
+		if json.Unmarshal([]byte(t.toolCall.Input), &params) == nil {
+			parts := []string{fmt.Sprintf("**查询：** %s", params.Query)}
+			if params.Path != "" {
+				parts = append(parts, fmt.Sprintf("**路径：** %s", fsext.PrettyPath(params.Path)))
+			}
+			return strings.Join(parts, "\n")
+		}
+	case tools.RepoMapToolName:
+		var params tools.RepoMapParams
+		if json.Unmarshal([]byte(t.toolCall.Input), &params) == nil {
+			path := params.Path
+			if path == "" {
+				path = "."
+			}
+			return fmt.Sprintf("**路径：** %s", fsext.PrettyPath(path))
+		}
+	case tools.RunTestsToolName:
+		var params tools.RunTestsParams
+		if json.Unmarshal([]byte(t.toolCall.Input), &params) == nil {
+			path := params.Path
+			if path == "" {
+				path = "."
+			}
+			parts := []string{fmt.Sprintf("**路径：** %s", fsext.PrettyPath(path))}
+			if params.Runner != "" {
+				parts = append(parts, fmt.Sprintf("**框架：** %s", params.Runner))
+			}
+			if params.Filter != "" {
+				parts = append(parts, fmt.Sprintf("**过滤：** %s", params.Filter))
+			}
+			return strings.Join(parts, "\n")
+		}
 	case tools.DownloadToolName:
 		var params tools.DownloadParams
 		if json.Unmarshal([]byte(t.toolCall.Input), &params) == nil {
@@ -974,9 +1245,17 @@ func (t *baseToolMessageItem) formatParametersForCopy() string {
 		}
 	case tools.DiagnosticsToolName:
 		return "**项目：** 诊断"
+	case tools.GitCommitToolName:
+		var params tools.GitCommitParams
+		if json.Unmarshal([]byte(t.toolCall.Input), &params) == nil {
+			return fmt.Sprintf("**提交信息：**\n%s", params.Message)
+		}
 	case agent.AgentToolName:
 		var params agent.AgentParams
 		if json.Unmarshal([]byte(t.toolCall.Input), &params) == nil {
+			if params.Agent != "" {
+				return fmt.Sprintf("**代理：** %s\n**任务：**\n%s", params.Agent, params.Prompt)
+			}
 			return fmt.Sprintf("**任务：**\n%s", params.Prompt)
 		}
 	}
@@ -1029,8 +1308,10 @@ func (t *baseToolMessageItem) formatResultForCopy() string {
 		return t.formatWebFetchResultForCopy()
 	case agent.AgentToolName:
 		return t.formatAgentResultForCopy()
-	case tools.DownloadToolName, tools.GrepToolName, tools.GlobToolName, tools.LSToolName, tools.SourcegraphToolName, tools.DiagnosticsToolName, tools.TodosToolName:
+	case tools.DownloadToolName, tools.GrepToolName, tools.GlobToolName, tools.LSToolName, tools.RepoMapToolName, tools.RunTestsToolName, tools.SemanticSearchToolName, tools.SourcegraphToolName, tools.DiagnosticsToolName, tools.TodosToolName, tools.GitStatusToolName, tools.GitCommitToolName:
 		return fmt.Sprintf("```\n%s\n```", t.result.Content)
+	case tools.GitDiffToolName:
+		return fmt.Sprintf("```diff\n%s\n```", t.result.Content)
 	default:
 		return t.result.Content
 	}
@@ -1176,6 +1457,17 @@ func (t *baseToolMessageItem) formatMultiEditResultForCopy() string {
 	json.Unmarshal([]byte(t.toolCall.Input), &params)
 
 	var result strings.Builder
+	for i, f := range meta.Files {
+		if i > 0 {
+			result.WriteString("\n\n")
+		}
+		fileName := fsext.PrettyPath(f.FilePath)
+		diffContent, additions, removals := diff.GenerateDiff(f.OldContent, f.NewContent, fileName)
+		fmt.Fprintf(&result, "%s 变更：+%d -%d\n", fileName, additions, removals)
+		result.WriteString("```diff\n")
+		result.WriteString(diffContent)
+		result.WriteString("\n```")
+	}
 	if meta.OldContent != "" || meta.NewContent != "" {
 		fileName := params.FilePath
 		if fileName != "" {
@@ -1363,6 +1655,8 @@ func prettifyToolName(name string) string {
 		return "获取"
 	case tools.AgenticFetchToolName:
 		return "智能获取"
+	case tools.IssueFetchToolName:
+		return "获取议题"
 	case tools.WebFetchToolName:
 		return "获取"
 	case tools.WebSearchToolName:
@@ -1373,6 +1667,12 @@ func prettifyToolName(name string) string {
 		return "Grep"
 	case tools.LSToolName:
 		return "列表"
+	case tools.RepoMapToolName:
+		return "仓库地图"
+	case tools.RunTestsToolName:
+		return "运行测试"
+	case tools.SemanticSearchToolName:
+		return "语义搜索"
 	case tools.SourcegraphToolName:
 		return "Sourcegraph"
 	case tools.TodosToolName:
@@ -1381,6 +1681,16 @@ func prettifyToolName(name string) string {
 		return "查看"
 	case tools.WriteToolName:
 		return "写入"
+	case tools.GitStatusToolName:
+		return "Git 状态"
+	case tools.GitDiffToolName:
+		return "Git 差异"
+	case tools.GitCommitToolName:
+		return "Git 提交"
+	case tools.LSPRenameToolName:
+		return "重命名"
+	case tools.LSPCodeActionToolName:
+		return "代码操作"
 	default:
 		return genericPrettyName(name)
 	}
@@ -0,0 +1,228 @@
+package chat
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"charm.land/lipgloss/v2"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/purpose168/crush-cn/internal/agent/tools"
+	"github.com/purpose168/crush-cn/internal/fsext"
+	"github.com/purpose168/crush-cn/internal/message"
+	"github.com/purpose168/crush-cn/internal/ui/common"
+	"github.com/purpose168/crush-cn/internal/ui/styles"
+)
+
+// turnSummaryMaxFiles 是回合摘要中最多列出的文件数。
+const turnSummaryMaxFiles = 8
+
+// TurnSummaryID 返回回合摘要项目的稳定 ID。
+func TurnSummaryID(messageID string) string {
+	return fmt.Sprintf("%s:turn-summary", messageID)
+}
+
+// TurnFile 是回合中被修改的文件及其增删行数。
+type TurnFile struct {
+	Path      string
+	Additions int
+	Removals  int
+}
+
+// TurnSummary 汇总一个助手回合中修改的文件、执行的命令和使用的令牌。
+type TurnSummary struct {
+	Files        []TurnFile
+	Commands     int
+	ToolCalls    int
+	InputTokens  int64
+	OutputTokens int64
+}
+
+// Additions 返回回合中所有文件的新增行数。
+func (s TurnSummary) Additions() int {
+	total := 0
+	for _, f := range s.Files {
+		total += f.Additions
+	}
+	return total
+}
+
+// Removals 返回回合中所有文件的删除行数。
+func (s TurnSummary) Removals() int {
+	total := 0
+	for _, f := range s.Files {
+		total += f.Removals
+	}
+	return total
+}
+
+// editToolNames 是会修改文件并在结果元数据中报告增删行数的工具。
+var editToolNames = []string{
+	tools.EditToolName,
+	tools.WriteToolName,
+	tools.MultiEditToolName,
+	tools.LSPRenameToolName,
+	tools.LSPCodeActionToolName,
+}
+
+// SummarizeTurn 汇总一个回合的消息，msgs 是用户消息之后直到回合结束的所有消息。
+func SummarizeTurn(msgs []*message.Message) TurnSummary {
+	var summary TurnSummary
+	toolCalls := map[string]message.ToolCall{}
+	for _, msg := range msgs {
+		switch msg.Role {
+		case message.Assistant:
+			for _, tc := range msg.ToolCalls() {
+				toolCalls[tc.ID] = tc
+			}
+			if finish := msg.FinishPart(); finish != nil && finish.Metrics != nil {
+				summary.InputTokens += finish.Metrics.InputTokens
+				summary.OutputTokens += finish.Metrics.OutputTokens
+			}
+		case message.Tool:
+			for _, result := range msg.ToolResults() {
+				tc, ok := toolCalls[result.ToolCallID]
+				if !ok {
+					continue
+				}
+				summary.ToolCalls++
+				if result.IsError {
+					continue
+				}
+				switch {
+				case tc.Name == tools.BashToolName:
+					summary.Commands++
+				case slices.Contains(editToolNames, tc.Name):
+					summary.addEdit(tc, result)
+				}
+			}
+		}
+	}
+	return summary
+}
+
+// addEdit 记录编辑工具修改的文件。多文件编辑的元数据列出每个文件，单文件编辑的路径来自工具输入。
+func (s *TurnSummary) addEdit(tc message.ToolCall, result message.ToolResult) {
+	var meta struct {
+		Additions int                       `json:"additions"`
+		Removals  int                       `json:"removals"`
+		Files     []tools.WorkspaceEditFile `json:"files"`
+	}
+	if err := json.Unmarshal([]byte(result.Metadata), &meta); err != nil {
+		return
+	}
+	if len(meta.Files) > 0 {
+		for _, f := range meta.Files {
+			s.addFile(f.FilePath, f.Additions, f.Removals)
+		}
+		return
+	}
+	var params struct {
+		FilePath string `json:"file_path"`
+	}
+	if err := json.Unmarshal([]byte(tc.Input), &params); err != nil || params.FilePath == "" {
+		return
+	}
+	s.addFile(params.FilePath, meta.Additions, meta.Removals)
+}
+
+// addFile 累加文件的增删行数，同一文件多次修改时合并为一项。
+func (s *TurnSummary) addFile(path string, additions, removals int) {
+	for i := range s.Files {
+		if s.Files[i].Path == path {
+			s.Files[i].Additions += additions
+			s.Files[i].Removals += removals
+			return
+		}
+	}
+	s.Files = append(s.Files, TurnFile{Path: path, Additions: additions, Removals: removals})
+}
+
+// TurnSummaryItem 在助手回合结束后渲染本回合修改的文件、执行的命令和使用的令牌。
+type TurnSummaryItem struct {
+	*cachedMessageItem
+
+	id      string
+	summary TurnSummary
+	sty     *styles.Styles
+}
+
+// NewTurnSummaryItem 为以 messageID 结束的回合创建一个新的 TurnSummaryItem。
+func NewTurnSummaryItem(sty *styles.Styles, messageID string, summary TurnSummary) MessageItem {
+	return &TurnSummaryItem{
+		cachedMessageItem: &cachedMessageItem{},
+		id:                TurnSummaryID(messageID),
+		summary:           summary,
+		sty:               sty,
+	}
+}
+
+// ID 实现 MessageItem 接口。
+func (t *TurnSummaryItem) ID() string {
+	return t.id
+}
+
+// RawRender 实现 MessageItem 接口。
+func (t *TurnSummaryItem) RawRender(width int) string {
+	innerWidth := max(0, width-MessageLeftPaddingTotal)
+	content, _, ok := t.getCachedRender(innerWidth)
+	if !ok {
+		content = t.renderContent(innerWidth)
+		height := lipgloss.Height(content)
+		t.setCachedRender(content, innerWidth, height)
+	}
+	return content
+}
+
+// Render 实现 MessageItem 接口。
+func (t *TurnSummaryItem) Render(width int) string {
+	return t.sty.Chat.Message.SectionHeader.Render(t.RawRender(width))
+}
+
+func (t *TurnSummaryItem) renderContent(width int) string {
+	s := t.summary
+	sty := t.sty
+
+	var parts []string
+	if len(s.Files) > 0 {
+		parts = append(parts, fmt.Sprintf("%d 个文件 %s %s", len(s.Files),
+			sty.Files.Additions.Render(fmt.Sprintf("+%d", s.Additions())),
+			sty.Files.Deletions.Render(fmt.Sprintf("-%d", s.Removals()))))
+	}
+	if s.Commands > 0 {
+		parts = append(parts, fmt.Sprintf("%d 条命令", s.Commands))
+	}
+	parts = append(parts, fmt.Sprintf("%d 次工具调用", s.ToolCalls))
+	if s.InputTokens > 0 || s.OutputTokens > 0 {
+		parts = append(parts, fmt.Sprintf("↑%s ↓%s 令牌", formatTokenCount(s.InputTokens), formatTokenCount(s.OutputTokens)))
+	}
+	icon := sty.Chat.Message.AssistantInfoIcon.Render(styles.TurnSummaryIcon)
+	header := icon + " " + sty.Chat.Message.AssistantInfoMetrics.Render("本回合 ") + strings.Join(parts, sty.Subtle.Render(" · "))
+	lines := []string{common.Section(sty, header, width)}
+
+	for i, f := range s.Files {
+		if i == turnSummaryMaxFiles {
+			lines = append(lines, sty.Subtle.Render(fmt.Sprintf("  … 还有 %d 个文件", len(s.Files)-turnSummaryMaxFiles)))
+			break
+		}
+		stats := sty.Files.Additions.Render(fmt.Sprintf("+%d", f.Additions)) + " " +
+			sty.Files.Deletions.Render(fmt.Sprintf("-%d", f.Removals))
+		pathWidth := max(0, width-lipgloss.Width(stats)-3)
+		path := sty.Files.Path.Render(ansi.Truncate(fsext.PrettyPath(f.Path), pathWidth, "…"))
+		lines = append(lines, "  "+path+" "+stats)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// formatTokenCount 将令牌数格式化为易读的形式，例如 "850"、"12.3K" 或 "1.2M"。
+func formatTokenCount(tokens int64) string {
+	switch {
+	case tokens >= 1_000_000:
+		return strings.Replace(fmt.Sprintf("%.1fM", float64(tokens)/1_000_000), ".0M", "M", 1)
+	case tokens >= 1_000:
+		return strings.Replace(fmt.Sprintf("%.1fK", float64(tokens)/1_000), ".0K", "K", 1)
+	default:
+		return fmt.Sprintf("%d", tokens)
+	}
+}
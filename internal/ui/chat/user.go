@@ -37,7 +37,7 @@ func NewUserMessageItem(sty *styles.Styles, message *message.Message, attachment
 // RawRender 实现 [MessageItem] 接口，渲染原始消息内容。
 func (m *UserMessageItem) RawRender(width int) string {
 	// 计算限制后的消息宽度
-	cappedWidth := cappedMessageWidth(width)
+	cappedWidth := markdownWidth(width)
 
 	// 尝试从缓存获取已渲染的内容
 	content, height, ok := m.getCachedRender(cappedWidth)
@@ -46,20 +46,8 @@ func (m *UserMessageItem) RawRender(width int) string {
 		return m.renderHighlighted(content, cappedWidth, height)
 	}
 
-	// 创建 Markdown 渲染器
-	renderer := common.MarkdownRenderer(m.sty, cappedWidth)
-
-	// 获取消息文本内容并去除首尾空白
-	msgContent := strings.TrimSpace(m.message.Content().Text)
-	// 渲染 Markdown 内容
-	result, err := renderer.Render(msgContent)
-	if err != nil {
-		// 渲染失败时使用原始文本
-		content = msgContent
-	} else {
-		// 移除末尾的换行符
-		content = strings.TrimSuffix(result, "\n")
-	}
+	// 获取消息文本内容并去除首尾空白，按排版选项渲染 Markdown
+	content = renderMarkdown(m.sty, strings.TrimSpace(m.message.Content().Text), cappedWidth)
 
 	// 如果消息包含二进制内容（附件），则渲染附件
 	if len(m.message.BinaryContent()) > 0 {
@@ -9,6 +9,7 @@ import (
 	uv "github.com/charmbracelet/ultraviolet"
 	"github.com/charmbracelet/x/ansi"
 	xstrings "github.com/charmbracelet/x/exp/strings"
+	fimage "github.com/purpose168/crush-cn/internal/ui/image"
 )
 
 // Capabilities 定义支持的不同终端能力。
@@ -27,6 +28,8 @@ type Capabilities struct {
 	KittyGraphics bool
 	// SixelGraphics 指示终端是否支持 Sixel 图形。
 	SixelGraphics bool
+	// ITerm2Graphics 指示终端是否支持 iTerm2 内联图像协议。
+	ITerm2Graphics bool
 	// Env 是终端环境变量。
 	Env uv.Environ
 	// TerminalVersion 是终端版本字符串。
@@ -40,6 +43,9 @@ func (c *Capabilities) Update(msg any) {
 	switch m := msg.(type) {
 	case tea.EnvMsg:
 		c.Env = uv.Environ(m)
+		if supportsITerm2Images(c.Env) {
+			c.ITerm2Graphics = true
+		}
 	case tea.ColorProfileMsg:
 		c.Profile = m.Profile
 	case tea.WindowSizeMsg:
@@ -56,6 +62,9 @@ func (c *Capabilities) Update(msg any) {
 		}
 	case tea.TerminalVersionMsg:
 		c.TerminalVersion = m.Name
+		if xstrings.ContainsAnyOf(strings.ToLower(m.Name), iterm2Terminals...) {
+			c.ITerm2Graphics = true
+		}
 	case uv.ModeReportEvent:
 		switch m.Mode {
 		case ansi.ModeFocusEvent:
@@ -101,6 +110,20 @@ func (c Capabilities) SupportsSixelGraphics() bool {
 	return c.SixelGraphics
 }
 
+// SupportsITerm2Graphics 如果终端支持 iTerm2 内联图像则返回 true。
+func (c Capabilities) SupportsITerm2Graphics() bool {
+	return c.ITerm2Graphics
+}
+
+// ImageSupport 返回用于协商图像编码的终端图像协议支持情况。
+func (c Capabilities) ImageSupport() fimage.Support {
+	return fimage.Support{
+		Kitty:  c.KittyGraphics,
+		ITerm2: c.ITerm2Graphics,
+		Sixel:  c.SixelGraphics,
+	}
+}
+
 // CellSize 返回单个终端单元格的像素大小。
 func (c Capabilities) CellSize() (width, height int) {
 	if c.Columns == 0 || c.Rows == 0 {
@@ -113,6 +136,18 @@ func modeSupported(v ansi.ModeSetting) bool {
 	return v.IsSet() || v.IsReset()
 }
 
+// iterm2Terminals 定义支持 iTerm2 内联图像协议的终端。
+var iterm2Terminals = []string{"iterm", "wezterm", "mintty", "konsole"}
+
+// supportsITerm2Images 根据环境变量判断终端是否支持 iTerm2 内联图像。
+func supportsITerm2Images(env uv.Environ) bool {
+	if env.Getenv("LC_TERMINAL") == "iTerm2" {
+		return true
+	}
+	termProg := strings.ToLower(env.Getenv("TERM_PROGRAM"))
+	return termProg != "" && xstrings.ContainsAnyOf(termProg, iterm2Terminals...)
+}
+
 // kittyTerminals 定义支持查询能力的终端。
 var kittyTerminals = []string{"alacritty", "ghostty", "kitty", "rio", "wezterm"}
 
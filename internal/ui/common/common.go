@@ -17,6 +17,10 @@ import (
 // MaxAttachmentSize 定义文件附件的最大允许大小（5 MB）。
 const MaxAttachmentSize = int64(5 * 1024 * 1024)
 
+// MaxImageSourceSize 定义图片附件在预处理前允许的最大大小（50 MB），
+// 图片经过缩小和重新编码后仍需满足 [MaxAttachmentSize]。
+const MaxImageSourceSize = int64(50 * 1024 * 1024)
+
 // AllowedImageTypes 定义允许的图像文件类型。
 var AllowedImageTypes = []string{".jpg", ".jpeg", ".png"}
 
@@ -0,0 +1,154 @@
+package common
+
+import (
+	"strings"
+	"time"
+
+	tea "charm.land/bubbletea/v2"
+	uv "github.com/charmbracelet/ultraviolet"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// Multiplexer 是运行 Crush 的终端复用器。
+type Multiplexer int
+
+const (
+	// MultiplexerNone 表示直接运行在终端中。
+	MultiplexerNone Multiplexer = iota
+	// MultiplexerTmux 表示运行在 tmux 中。
+	MultiplexerTmux
+	// MultiplexerScreen 表示运行在 GNU screen 中。
+	MultiplexerScreen
+)
+
+// progressRefreshInterval 是忙碌时重新发送进度条的间隔，防止终端因超时隐藏它。
+const progressRefreshInterval = 5 * time.Second
+
+// TerminalIntegration 根据终端环境决定如何向终端和终端复用器报告窗口标题与智能体忙碌状态。
+type TerminalIntegration struct {
+	// Multiplexer 是检测到的终端复用器。
+	Multiplexer Multiplexer
+	// Progress 指示（复用器外层的）终端支持 OSC 9;4 进度条，
+	// 包括 Windows Terminal、Ghostty 和 ConEmu。
+	Progress bool
+
+	lastTitle    string
+	lastBusy     bool
+	lastProgress time.Time
+}
+
+// DetectTerminal 根据环境变量检测终端集成方式。
+func DetectTerminal(env uv.Environ) TerminalIntegration {
+	var t TerminalIntegration
+	t.detect(env)
+	return t
+}
+
+// Update 根据给定消息更新检测结果。
+func (t *TerminalIntegration) Update(msg any) {
+	switch m := msg.(type) {
+	case tea.EnvMsg:
+		t.detect(uv.Environ(m))
+	case tea.TerminalVersionMsg:
+		// 在复用器中 XTVERSION 由复用器本身应答，无法据此判断外层终端。
+		if t.Multiplexer == MultiplexerNone && strings.Contains(strings.ToLower(m.Name), "ghostty") {
+			t.Progress = true
+		}
+	}
+}
+
+func (t *TerminalIntegration) detect(env uv.Environ) {
+	_, isTmux := env.LookupEnv("TMUX")
+	_, isScreen := env.LookupEnv("STY")
+	switch {
+	case isTmux:
+		t.Multiplexer = MultiplexerTmux
+	case isScreen || strings.HasPrefix(env.Getenv("TERM"), "screen"):
+		t.Multiplexer = MultiplexerScreen
+	default:
+		t.Multiplexer = MultiplexerNone
+	}
+
+	// 这些变量由外层终端设置，并会被复用器中的进程继承。
+	_, isWindowsTerminal := env.LookupEnv("WT_SESSION")
+	_, isGhostty := env.LookupEnv("GHOSTTY_RESOURCES_DIR")
+	isConEmu := env.Getenv("ConEmuANSI") == "ON"
+	if _, ok := env.LookupEnv("ConEmuPID"); ok {
+		isConEmu = true
+	}
+	t.Progress = t.Progress || isWindowsTerminal || isGhostty || isConEmu ||
+		strings.Contains(strings.ToLower(env.Getenv("TERM_PROGRAM")), "ghostty") ||
+		env.Getenv("TERM") == "xterm-ghostty"
+}
+
+// NativeProgress 报告进度条是否可以直接通过 [tea.View] 发送给终端。
+// 在复用器中进度条需要经由 [TerminalIntegration.StatusCmd] 透传。
+func (t TerminalIntegration) NativeProgress() bool {
+	return t.Progress && t.Multiplexer == MultiplexerNone
+}
+
+// Title 返回窗口标题，忙碌时加上标记以便在复用器状态栏中可见。
+func (t TerminalIntegration) Title(title string, busy bool) string {
+	if busy {
+		return "● " + title
+	}
+	return title
+}
+
+// StatusCmd 返回向复用器报告标题和忙碌状态的命令。标题通过 screen 风格的
+// 窗口名转义序列设置（tmux 需要开启 allow-rename），进度条通过复用器透传发送给
+// 外层终端（tmux 需要开启 allow-passthrough）。状态未变化时返回 nil。
+func (t *TerminalIntegration) StatusCmd(title string, busy, progress bool) tea.Cmd {
+	if t.Multiplexer == MultiplexerNone {
+		return nil
+	}
+	title = t.Title(title, busy)
+	progress = progress && t.Progress
+	refresh := progress && busy && time.Since(t.lastProgress) >= progressRefreshInterval
+	if title == t.lastTitle && busy == t.lastBusy && !refresh {
+		return nil
+	}
+
+	var sb strings.Builder
+	if title != t.lastTitle {
+		sb.WriteString(setWindowName(title))
+	}
+	if progress && (busy != t.lastBusy || refresh) {
+		seq := ansi.ResetProgressBar
+		if busy {
+			seq = ansi.SetIndeterminateProgressBar
+			t.lastProgress = time.Now()
+		}
+		sb.WriteString(t.passthrough(seq))
+	}
+	t.lastTitle = title
+	t.lastBusy = busy
+	if sb.Len() == 0 {
+		return nil
+	}
+	return tea.Raw(sb.String())
+}
+
+// ResetCmd 返回在退出时清除透传的进度条的命令。
+func (t *TerminalIntegration) ResetCmd() tea.Cmd {
+	if t.Multiplexer == MultiplexerNone || !t.Progress || !t.lastBusy {
+		return nil
+	}
+	t.lastBusy = false
+	return tea.Raw(t.passthrough(ansi.ResetProgressBar))
+}
+
+func (t TerminalIntegration) passthrough(seq string) string {
+	switch t.Multiplexer {
+	case MultiplexerTmux:
+		return ansi.TmuxPassthrough(seq)
+	case MultiplexerScreen:
+		return ansi.ScreenPassthrough(seq, 0)
+	}
+	return seq
+}
+
+// setWindowName 返回设置 screen 和 tmux 窗口名的转义序列。
+func setWindowName(name string) string {
+	return "\x1bk" + name + "\x1b\\"
+}
@@ -1,5 +1,5 @@
 // Package completions 提供补全弹出组件的实现
-// 该包实现了一个可过滤的补全列表,支持文件路径和 MCP 资源的补全功能
+// 该包实现了一个可过滤的补全列表,支持文件路径、文件行范围、MCP 资源、自定义条目、LSP 符号和提示词片段的补全功能
 package completions
 
 import (
@@ -14,6 +14,7 @@ import (
 	"github.com/charmbracelet/x/ansi"
 	"github.com/charmbracelet/x/exp/ordered"
 	"github.com/purpose168/crush-cn/internal/agent/tools/mcp"
+	"github.com/purpose168/crush-cn/internal/config"
 	"github.com/purpose168/crush-cn/internal/fsext"
 	"github.com/purpose168/crush-cn/internal/ui/list"
 )
@@ -36,10 +37,11 @@ type SelectionMsg[T any] struct {
 type ClosedMsg struct{}
 
 // CompletionItemsLoadedMsg 在补全项目加载完成时发送的消息
-// 包含从文件系统和 MCP 资源加载的补全项目
+// 包含从文件系统和 MCP 资源加载的补全项目，以及配置中的自定义条目
 type CompletionItemsLoadedMsg struct {
 	Files     []FileCompletionValue     // 文件补全项目列表
 	Resources []ResourceCompletionValue // MCP 资源补全项目列表
+	Custom    []CustomCompletionValue   // 自定义补全条目列表
 }
 
 // Completions 表示补全弹出组件
@@ -50,14 +52,17 @@ type Completions struct {
 	height int // 弹出窗口高度
 
 	// 组件状态
-	open  bool   // 补全窗口是否打开
-	query string // 当前过滤查询字符串
+	open   bool   // 补全窗口是否打开
+	query  string // 当前过滤查询字符串
+	detail string // 选中项的文档，显示在弹出窗口旁的面板中
 
 	// 按键绑定
 	keyMap KeyMap
 
 	// 列表组件
 	list *list.FilterableList
+	// mentions 是最近一次 SetItems 设置的文件、MCP 资源和自定义条目，添加符号时保留
+	mentions []list.FilterableItem
 
 	// 样式定义
 	normalStyle  lipgloss.Style // 普通状态样式
@@ -114,11 +119,12 @@ func (c *Completions) KeyMap() KeyMap {
 // 参数:
 //   - depth: 文件系统遍历深度
 //   - limit: 文件数量限制
+//   - entries: 配置中的自定义补全条目
 //
 // 返回一个命令,用于异步加载补全项目
-func (c *Completions) Open(depth, limit int) tea.Cmd {
+func (c *Completions) Open(depth, limit int, entries []config.CompletionEntry) tea.Cmd {
 	return func() tea.Msg {
-		var msg CompletionItemsLoadedMsg
+		msg := CompletionItemsLoadedMsg{Custom: customValues(entries)}
 		var wg sync.WaitGroup
 		// 并发加载文件
 		wg.Go(func() {
@@ -133,14 +139,28 @@ func (c *Completions) Open(depth, limit int) tea.Cmd {
 	}
 }
 
-// SetItems 设置文件和 MCP 资源并重建合并列表
+// SetItems 设置文件、MCP 资源和自定义条目并重建合并列表
 // 参数:
 //   - files: 文件补全项目列表
 //   - resources: MCP 资源补全项目列表
-func (c *Completions) SetItems(files []FileCompletionValue, resources []ResourceCompletionValue) {
-	items := make([]list.FilterableItem, 0, len(files)+len(resources))
+//   - custom: 自定义补全条目列表，作为单独的分区显示在最前面
+func (c *Completions) SetItems(files []FileCompletionValue, resources []ResourceCompletionValue, custom []CustomCompletionValue) {
+	items := make([]list.FilterableItem, 0, len(custom)+len(files)+len(resources))
 
-	// 首先添加文件项目
+	// 首先添加自定义条目
+	for _, value := range custom {
+		item := NewCompletionItem(
+			value.label(),
+			value,
+			c.normalStyle,
+			c.focusedStyle,
+			c.matchStyle,
+		)
+		item.section = sectionCustom
+		items = append(items, item)
+	}
+
+	// 然后添加文件项目
 	for _, file := range files {
 		item := NewCompletionItem(
 			file.Path,
@@ -149,6 +169,7 @@ func (c *Completions) SetItems(files []FileCompletionValue, resources []Resource
 			c.focusedStyle,
 			c.matchStyle,
 		)
+		item.section = sectionFiles
 		items = append(items, item)
 	}
 
@@ -161,13 +182,57 @@ func (c *Completions) SetItems(files []FileCompletionValue, resources []Resource
 			c.focusedStyle,
 			c.matchStyle,
 		)
+		item.section = sectionFiles
 		items = append(items, item)
 	}
 
-	c.open = true
 	c.query = ""
+	c.mentions = items
+	c.setItems(items)
+}
+
+// SetMentionSymbols 在文件、MCP 资源和自定义条目之后显示 LSP 符号，保留当前的过滤查询
+// 符号按查询从语言服务器异步加载，每次加载替换之前的符号
+func (c *Completions) SetMentionSymbols(symbols []SymbolCompletionValue) {
+	items := slices.Clip(c.mentions)
+	for _, item := range c.symbolItems(symbols) {
+		item.section = sectionSymbols
+		items = append(items, item)
+	}
+	c.setItems(items)
+}
+
+// SetSymbols 设置 LSP 符号补全项目，保留当前的过滤查询
+// 符号按查询从语言服务器异步加载，因此每次查询变化都会替换整个列表
+func (c *Completions) SetSymbols(symbols []SymbolCompletionValue) {
+	items := make([]list.FilterableItem, 0, len(symbols))
+	for _, item := range c.symbolItems(symbols) {
+		items = append(items, item)
+	}
+	c.setItems(items)
+}
+
+// symbolItems 为 LSP 符号创建补全项目，显示符号名称和位置
+func (c *Completions) symbolItems(symbols []SymbolCompletionValue) []*CompletionItem {
+	items := make([]*CompletionItem, 0, len(symbols))
+	for _, symbol := range symbols {
+		items = append(items, NewCompletionItem(
+			symbol.Name+"  "+symbol.Location(),
+			symbol,
+			c.normalStyle,
+			c.focusedStyle,
+			c.matchStyle,
+		))
+	}
+	return items
+}
+
+// setItems 打开补全窗口并替换列表项目
+func (c *Completions) setItems(items []list.FilterableItem) {
+	c.open = true
+	c.detail = ""
 	c.list.SetItems(items...)
-	c.list.SetFilter("")
+	c.list.SetFilter(c.query)
 	c.list.Focus()
 
 	c.width = maxWidth
@@ -182,6 +247,32 @@ func (c *Completions) SetItems(files []FileCompletionValue, resources []Resource
 // Close 关闭补全弹出窗口
 func (c *Completions) Close() {
 	c.open = false
+	c.query = ""
+	c.detail = ""
+}
+
+// SelectedValue 返回当前选中项目的值,没有选中项目时返回 nil
+func (c *Completions) SelectedValue() any {
+	items := c.list.FilteredItems()
+	selected := c.list.Selected()
+	if selected < 0 || selected >= len(items) {
+		return nil
+	}
+	item, ok := items[selected].(*CompletionItem)
+	if !ok {
+		return nil
+	}
+	return item.Value()
+}
+
+// SetDetail 设置选中项目的文档
+func (c *Completions) SetDetail(detail string) {
+	c.detail = detail
+}
+
+// Detail 返回选中项目的文档,没有文档时返回空字符串
+func (c *Completions) Detail() string {
+	return c.detail
 }
 
 // Filter 使用给定查询过滤补全项目
@@ -329,6 +420,26 @@ func (c *Completions) selectCurrent(keepOpen bool) tea.Msg {
 			Value:    item,
 			KeepOpen: keepOpen,
 		}
+	case SymbolCompletionValue:
+		return SelectionMsg[SymbolCompletionValue]{
+			Value:    item,
+			KeepOpen: keepOpen,
+		}
+	case LineRangeCompletionValue:
+		return SelectionMsg[LineRangeCompletionValue]{
+			Value:    item,
+			KeepOpen: keepOpen,
+		}
+	case CustomCompletionValue:
+		return SelectionMsg[CustomCompletionValue]{
+			Value:    item,
+			KeepOpen: keepOpen,
+		}
+	case SnippetCompletionValue:
+		return SelectionMsg[SnippetCompletionValue]{
+			Value:    item,
+			KeepOpen: keepOpen,
+		}
 	default:
 		return nil
 	}
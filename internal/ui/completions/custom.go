@@ -0,0 +1,62 @@
+package completions
+
+import (
+	"github.com/purpose168/crush-cn/internal/config"
+)
+
+// 自定义补全条目的图标，按插入方式区分
+const (
+	snippetIcon = "≡"
+	urlIcon     = "↗"
+	promptIcon  = "»"
+)
+
+// 补全列表的分区，过滤后自定义条目排在文件和 MCP 资源之前，LSP 符号排在最后
+const (
+	sectionCustom = iota
+	sectionFiles
+	sectionSymbols
+)
+
+// CustomCompletionValue 表示配置中的自定义补全条目
+type CustomCompletionValue struct {
+	Name        string                // 条目名称
+	Kind        config.CompletionKind // 插入方式
+	Text        string                // 片段文本、链接或提示词
+	Description string                // 显示在名称旁的说明
+}
+
+// Icon 返回条目插入方式对应的图标
+func (v CustomCompletionValue) Icon() string {
+	switch v.Kind {
+	case config.CompletionKindURL:
+		return urlIcon
+	case config.CompletionKindPrompt:
+		return promptIcon
+	default:
+		return snippetIcon
+	}
+}
+
+// label 返回补全列表中显示的文本
+func (v CustomCompletionValue) label() string {
+	label := v.Icon() + " " + v.Name
+	if v.Description != "" {
+		label += "  " + v.Description
+	}
+	return label
+}
+
+// customValues 将配置中的自定义条目转换为补全值
+func customValues(entries []config.CompletionEntry) []CustomCompletionValue {
+	values := make([]CustomCompletionValue, 0, len(entries))
+	for _, entry := range entries {
+		values = append(values, CustomCompletionValue{
+			Name:        entry.Name,
+			Kind:        entry.Kind,
+			Text:        entry.Text,
+			Description: entry.Description,
+		})
+	}
+	return values
+}
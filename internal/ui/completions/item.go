@@ -1,6 +1,8 @@
 package completions
 
 import (
+	"fmt"
+
 	"charm.land/lipgloss/v2"
 	"github.com/charmbracelet/x/ansi"
 	"github.com/purpose168/crush-cn/internal/ui/list"
@@ -23,6 +25,21 @@ type ResourceCompletionValue struct {
 	MIMEType string // 资源的 MIME 类型
 }
 
+// SymbolCompletionValue 表示 LSP 工作区符号补全值
+// 位置从1开始计数，用于请求符号的悬停文档
+type SymbolCompletionValue struct {
+	LSP       string // 返回该符号的语言服务器名称
+	Name      string // 符号名称
+	Path      string // 符号所在文件相对于工作目录的路径
+	Line      int    // 符号所在行
+	Character int    // 符号所在列（UTF-16 代码单元）
+}
+
+// Location 返回符号的 path:line 位置
+func (s SymbolCompletionValue) Location() string {
+	return fmt.Sprintf("%s:%d", s.Path, s.Line)
+}
+
 // CompletionItem 表示补全列表中的一个项目
 // 实现了 list.Item, list.FilterableItem, list.MatchSettable 和 list.Focusable 接口
 type CompletionItem struct {
@@ -30,6 +47,7 @@ type CompletionItem struct {
 	value   any            // 项目值(可以是 FileCompletionValue 或 ResourceCompletionValue)
 	match   fuzzy.Match    // 模糊匹配结果
 	focused bool           // 是否获得焦点
+	section int            // 所属分区，过滤后同一分区的项目保持在一起
 	cache   map[int]string // 渲染缓存,按宽度缓存渲染结果
 
 	// 样式定义
@@ -67,6 +85,11 @@ func (c *CompletionItem) Value() any {
 	return c.value
 }
 
+// Section 实现 [list.Sectioned] 接口
+func (c *CompletionItem) Section() int {
+	return c.section
+}
+
 // Filter 实现 [list.FilterableItem] 接口
 // 返回用于过滤的字符串
 func (c *CompletionItem) Filter() string {
@@ -231,4 +254,5 @@ var (
 	_ list.FilterableItem = (*CompletionItem)(nil) // 可过滤项目接口
 	_ list.MatchSettable  = (*CompletionItem)(nil) // 可设置匹配接口
 	_ list.Focusable      = (*CompletionItem)(nil) // 可聚焦接口
+	_ list.Sectioned      = (*CompletionItem)(nil) // 分区接口
 )
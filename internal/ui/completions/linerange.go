@@ -0,0 +1,113 @@
+package completions
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/purpose168/crush-cn/internal/ui/list"
+)
+
+const (
+	// RangeStepMinLines 是显示行范围选择步骤的最小文件行数，更短的文件直接附加全文
+	RangeStepMinLines = 200
+	// RangeContextLines 是附加行范围时在前后额外包含的行数
+	RangeContextLines = 5
+
+	rangeChunkLines = 200 // 预设行范围的大小
+	maxRangeChunks  = 50  // 最多列出的预设行范围数
+)
+
+// LineRangeCompletionValue 表示文件的行范围补全值
+// Start 为 0 表示整个文件，行号从1开始计数且包含 End
+type LineRangeCompletionValue struct {
+	Path  string // 文件路径
+	Start int    // 起始行
+	End   int    // 结束行
+}
+
+// Whole 返回是否选择了整个文件
+func (r LineRangeCompletionValue) Whole() bool {
+	return r.Start == 0
+}
+
+// Mention 返回插入到文本区域中的引用，例如 "main.go:120-180"
+func (r LineRangeCompletionValue) Mention() string {
+	if r.Whole() {
+		return r.Path
+	}
+	return fmt.Sprintf("%s:%d-%d", r.Path, r.Start, r.End)
+}
+
+// ParseLineRange 解析 "120-180" 或 "120" 形式的行范围，行号从1开始
+func ParseLineRange(s string) (start, end int, ok bool) {
+	from, to, found := strings.Cut(s, "-")
+	start, err := strconv.Atoi(from)
+	if err != nil || start < 1 {
+		return 0, 0, false
+	}
+	if !found {
+		return start, start, true
+	}
+	end, err = strconv.Atoi(to)
+	if err != nil || end < start {
+		return 0, 0, false
+	}
+	return start, end, true
+}
+
+// SplitMention 把 "path:120-180" 形式的文件引用拆分为路径和行范围文本。
+// 冒号后不是行范围的字符时返回完整的引用，适用于正在输入的范围，例如 "path:120-"
+func SplitMention(mention string) (path, lineRange string) {
+	idx := strings.LastIndexByte(mention, ':')
+	if idx < 0 {
+		return mention, ""
+	}
+	suffix := mention[idx+1:]
+	if strings.Trim(suffix, "0123456789-") != "" {
+		return mention, ""
+	}
+	return mention[:idx], suffix
+}
+
+// SetLineRanges 显示文件的行范围选择步骤。lineRange 是用户在冒号后输入的范围，
+// 有效时作为第一个选项，其后是整个文件和按固定大小划分的预设范围
+func (c *Completions) SetLineRanges(path string, lines int, lineRange string) {
+	var values []LineRangeCompletionValue
+	if start, end, ok := ParseLineRange(lineRange); ok && start <= lines {
+		values = append(values, LineRangeCompletionValue{Path: path, Start: start, End: min(end, lines)})
+	}
+	values = append(values, LineRangeCompletionValue{Path: path})
+	for start := 1; start <= lines && len(values) < maxRangeChunks; start += rangeChunkLines {
+		values = append(values, LineRangeCompletionValue{Path: path, Start: start, End: min(start+rangeChunkLines-1, lines)})
+	}
+
+	items := make([]list.FilterableItem, 0, len(values))
+	for _, value := range values {
+		text := fmt.Sprintf("第 %d-%d 行", value.Start, value.End)
+		if value.Whole() {
+			text = fmt.Sprintf("整个文件（%d 行）", lines)
+		}
+		items = append(items, NewCompletionItem(text, value, c.normalStyle, c.focusedStyle, c.matchStyle))
+	}
+	// 行范围不参与模糊过滤
+	c.query = ""
+	c.setItems(items)
+}
+
+// ExcerptLines 返回 content 中 start 到 end 行及前后 context 行的内容，每行带有行号，
+// 以及实际包含的起止行。start 超出文件行数时返回 false
+func ExcerptLines(content string, start, end, context int) (excerpt string, from, to int, ok bool) {
+	lines := strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+	if start < 1 || start > len(lines) {
+		return "", 0, 0, false
+	}
+	from = max(1, start-context)
+	to = min(len(lines), max(start, end)+context)
+
+	var sb strings.Builder
+	for i := from; i <= to; i++ {
+		fmt.Fprintf(&sb, "%6d|%s\n", i, strings.TrimSuffix(lines[i-1], "\r"))
+	}
+	return sb.String(), from, to, true
+}
@@ -0,0 +1,107 @@
+package completions
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLineRange(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		in         string
+		start, end int
+		ok         bool
+	}{
+		{name: "范围", in: "120-180", start: 120, end: 180, ok: true},
+		{name: "单行", in: "42", start: 42, end: 42, ok: true},
+		{name: "起止相同", in: "7-7", start: 7, end: 7, ok: true},
+		{name: "起始大于结束", in: "180-120"},
+		{name: "未写结束", in: "120-"},
+		{name: "未写起始", in: "-120"},
+		{name: "行号为零", in: "0-10"},
+		{name: "空字符串", in: ""},
+		{name: "非数字", in: "a-b"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			start, end, ok := ParseLineRange(tt.in)
+			require.Equal(t, tt.ok, ok)
+			require.Equal(t, tt.start, start)
+			require.Equal(t, tt.end, end)
+		})
+	}
+}
+
+func TestSplitMention(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		in        string
+		path      string
+		lineRange string
+	}{
+		{name: "没有范围", in: "main.go", path: "main.go"},
+		{name: "带范围", in: "main.go:120-180", path: "main.go", lineRange: "120-180"},
+		{name: "正在输入范围", in: "main.go:120-", path: "main.go", lineRange: "120-"},
+		{name: "只有冒号", in: "main.go:", path: "main.go"},
+		{name: "路径包含冒号", in: "docs/a:b.md", path: "docs/a:b.md"},
+		{name: "Windows 盘符", in: `C:\src\main.go`, path: `C:\src\main.go`},
+		{name: "Windows 盘符带范围", in: `C:\src\main.go:10-20`, path: `C:\src\main.go`, lineRange: "10-20"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			path, lineRange := SplitMention(tt.in)
+			require.Equal(t, tt.path, path)
+			require.Equal(t, tt.lineRange, lineRange)
+		})
+	}
+}
+
+func TestExcerptLines(t *testing.T) {
+	t.Parallel()
+
+	content := "one\ntwo\r\nthree\nfour\nfive\n"
+	tests := []struct {
+		name       string
+		start, end int
+		context    int
+		excerpt    string
+		from, to   int
+		ok         bool
+	}{
+		{
+			name: "带上下文", start: 3, end: 3, context: 1,
+			excerpt: "     2|two\n     3|three\n     4|four\n", from: 2, to: 4, ok: true,
+		},
+		{
+			name: "上下文在文件边界截断", start: 1, end: 5, context: 3,
+			excerpt: "     1|one\n     2|two\n     3|three\n     4|four\n     5|five\n", from: 1, to: 5, ok: true,
+		},
+		{
+			name: "结束超出文件", start: 5, end: 100, context: 0,
+			excerpt: "     5|five\n", from: 5, to: 5, ok: true,
+		},
+		{
+			name: "结束小于起始时只取起始行", start: 4, end: 2, context: 0,
+			excerpt: "     4|four\n", from: 4, to: 4, ok: true,
+		},
+		{name: "起始超出文件", start: 6, end: 8, context: 5},
+		{name: "起始为零", start: 0, end: 2, context: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			excerpt, from, to, ok := ExcerptLines(content, tt.start, tt.end, tt.context)
+			require.Equal(t, tt.ok, ok)
+			require.Equal(t, tt.excerpt, excerpt)
+			require.Equal(t, tt.from, from)
+			require.Equal(t, tt.to, to)
+		})
+	}
+}
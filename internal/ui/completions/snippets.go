@@ -0,0 +1,55 @@
+package completions
+
+import (
+	tea "charm.land/bubbletea/v2"
+	"github.com/purpose168/crush-cn/internal/snippets"
+	"github.com/purpose168/crush-cn/internal/ui/list"
+)
+
+// SnippetCompletionValue 表示片段库中的提示词片段
+type SnippetCompletionValue struct {
+	snippets.Snippet
+}
+
+// label 返回补全列表中显示的文本
+func (v SnippetCompletionValue) label() string {
+	label := snippetIcon + " " + v.Name
+	if preview := v.Preview(); preview != "" {
+		label += "  " + preview
+	}
+	return label
+}
+
+// SnippetsLoadedMsg 在片段库加载完成时发送的消息
+type SnippetsLoadedMsg struct {
+	Snippets []SnippetCompletionValue
+	Err      error
+}
+
+// LoadSnippets 返回一个从片段目录异步加载片段的命令
+func LoadSnippets(dir string) tea.Cmd {
+	return func() tea.Msg {
+		loaded, err := snippets.Load(dir)
+		values := make([]SnippetCompletionValue, 0, len(loaded))
+		for _, snippet := range loaded {
+			values = append(values, SnippetCompletionValue{Snippet: snippet})
+		}
+		return SnippetsLoadedMsg{Snippets: values, Err: err}
+	}
+}
+
+// SetSnippets 设置片段补全项目，保留当前的过滤查询
+func (c *Completions) SetSnippets(values []SnippetCompletionValue) {
+	items := make([]list.FilterableItem, 0, len(values))
+	for _, value := range values {
+		item := NewCompletionItem(
+			value.label(),
+			value,
+			c.normalStyle,
+			c.focusedStyle,
+			c.matchStyle,
+		)
+		items = append(items, item)
+	}
+	c.setItems(items)
+}
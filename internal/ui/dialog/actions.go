@@ -8,11 +8,14 @@ import (
 
 	tea "charm.land/bubbletea/v2"
 	"charm.land/catwalk/pkg/catwalk"
+	"github.com/purpose168/crush-cn/internal/codeblock"
 	"github.com/purpose168/crush-cn/internal/commands"
 	"github.com/purpose168/crush-cn/internal/config"
+	"github.com/purpose168/crush-cn/internal/history"
 	"github.com/purpose168/crush-cn/internal/message"
 	"github.com/purpose168/crush-cn/internal/oauth"
 	"github.com/purpose168/crush-cn/internal/permission"
+	"github.com/purpose168/crush-cn/internal/projectsetup"
 	"github.com/purpose168/crush-cn/internal/session"
 	"github.com/purpose168/crush-cn/internal/ui/common"
 	"github.com/purpose168/crush-cn/internal/ui/util"
@@ -47,14 +50,218 @@ type (
 	ActionNewSession        struct{}
 	ActionToggleHelp        struct{}
 	ActionToggleCompactMode struct{}
+	ActionCycleLayout       struct{}
 	ActionToggleThinking    struct{}
 	ActionExternalEditor    struct{}
 	ActionToggleYoloMode    struct{}
+	ActionToggleDryRun      struct{}
+	// ActionTogglePlanMode 是一个切换当前会话计划模式的消息。
+	ActionTogglePlanMode struct{}
 	// ActionInitializeProject 是一个初始化项目的消息。
 	ActionInitializeProject struct{}
 	ActionSummarize         struct {
 		SessionID string
 	}
+	// ActionPasteAttach 是一个将粘贴的内容作为附件添加的消息。
+	ActionPasteAttach struct {
+		Content string
+	}
+	// ActionPasteInsert 是一个将粘贴的内容作为文本插入编辑器的消息。
+	ActionPasteInsert struct {
+		Content string
+	}
+	// ActionRedactionReport 是一个显示会话脱敏报告的消息。
+	ActionRedactionReport struct {
+		SessionID string
+	}
+	// ActionSetPinnedFiles 是一个更新会话固定文件列表的消息。
+	ActionSetPinnedFiles struct {
+		SessionID string
+		Paths     []string
+	}
+	// ActionAddBookmark 是一个为聊天项目添加书签的消息。Label 为空时在填写标签后再次发送。
+	ActionAddBookmark struct {
+		SessionID string
+		ItemID    string
+		Preview   string
+		Label     string
+		Labeled   bool
+	}
+	// ActionSetBookmarks 是一个更新会话书签列表的消息。
+	ActionSetBookmarks struct {
+		SessionID string
+		Bookmarks []session.Bookmark
+	}
+	// ActionJumpToBookmark 是一个选中并滚动到书签项目的消息。
+	ActionJumpToBookmark struct {
+		ItemID string
+	}
+	// ActionRegenerate 是一个重新生成最后一轮回复的消息。Model 不为空时先切换到该模型。
+	ActionRegenerate struct {
+		SessionID string
+		Model     *config.SelectedModel
+	}
+	// ActionKeepVariant 是一个将最后一轮对话换成备选回复版本的消息。
+	ActionKeepVariant struct {
+		SessionID string
+		Index     int
+	}
+	// ActionDeleteVariant 是一个删除备选回复版本的消息。
+	ActionDeleteVariant struct {
+		SessionID string
+		Index     int
+	}
+	// ActionSetSessionEnv 是一个更新会话环境变量的消息。
+	ActionSetSessionEnv struct {
+		SessionID string
+		Env       map[string]string
+	}
+	// ActionSetSessionWorkingDir 是一个更新会话工作目录的消息。Dir 相对于项目根目录，为空时使用项目根目录。
+	ActionSetSessionWorkingDir struct {
+		SessionID string
+		Dir       string
+	}
+	// ActionOpenSessionTags 是一个打开会话标签对话框的消息。
+	ActionOpenSessionTags struct {
+		SessionID string
+		Tags      []string
+	}
+	// ActionSetSessionTags 是一个更新会话标签的消息。
+	ActionSetSessionTags struct {
+		SessionID string
+		Tags      []string
+	}
+	// ActionSetTodos 是一个保存用户编辑后的待办事项的消息。
+	ActionSetTodos struct {
+		SessionID string
+		Todos     []session.Todo
+	}
+	// ActionApprovePlan 是一个批准（可能经过编辑的）计划并开始执行的消息。
+	ActionApprovePlan struct {
+		SessionID string
+		Todos     []session.Todo
+		// Edited 表示用户是否修改了智能体写出的计划
+		Edited bool
+	}
+	// ActionSetWorkspaceRoots 是一个更新工作区附加目录的消息。
+	ActionSetWorkspaceRoots struct {
+		Roots []string
+	}
+	// ActionCreateCheckpoint 是一个为会话创建命名检查点的消息。
+	ActionCreateCheckpoint struct {
+		SessionID string
+		Name      string
+	}
+	// ActionRestoreCheckpoint 是一个将文件恢复到检查点的消息。
+	ActionRestoreCheckpoint struct {
+		SessionID string
+		ID        string
+		Name      string
+	}
+	// ActionNewTab 是一个打开新会话标签页的消息。
+	ActionNewTab struct{}
+	// ActionCloseTab 是一个关闭当前会话标签页的消息。
+	ActionCloseTab struct{}
+	// ActionCleanupSessions 是一个按保留策略检查并清理旧会话的消息。
+	ActionCleanupSessions struct{}
+	// ActionPruneSessions 是一个表示用户已确认删除超出保留策略的会话的消息。
+	ActionPruneSessions struct {
+		Sessions []session.Session
+	}
+	// ActionConfirmBudget 是一个表示用户已确认在超出预算后继续运行代理的消息。
+	ActionConfirmBudget struct {
+		Content     string
+		Attachments []message.Attachment
+	}
+	// ActionDeclineBudget 是一个表示用户在超出预算后取消运行代理的消息，
+	// 未发送的提示会交还给编辑器。
+	ActionDeclineBudget struct {
+		Content     string
+		Attachments []message.Attachment
+	}
+	// ActionSendPrompt 是一个表示用户在提示建议对话框中选择发送提示的消息，
+	// 发送时不再检查提示。
+	ActionSendPrompt struct {
+		Content     string
+		Attachments []message.Attachment
+	}
+	// ActionEnhancePrompt 是一个请求小模型结合会话上下文改写提示的消息。
+	ActionEnhancePrompt struct {
+		Content string
+	}
+	// ActionSetAttachments 是一个在附件管理对话框中重新排序或删除附件后同步编辑器附件的消息。
+	ActionSetAttachments struct {
+		Attachments []message.Attachment
+	}
+	// ActionViewAttachment 是一个以完整尺寸查看图片附件的消息。
+	ActionViewAttachment struct {
+		Attachment message.Attachment
+	}
+	// ActionEditPrompt 是一个将提示交还给编辑器继续修改的消息。
+	ActionEditPrompt struct {
+		Content     string
+		Attachments []message.Attachment
+	}
+	// ActionJumpToPrompt 是一个跳转到之前发送的相同提示的回答的消息，
+	// 未发送的提示会交还给编辑器。
+	ActionJumpToPrompt struct {
+		MessageID   string
+		Content     string
+		Attachments []message.Attachment
+	}
+	// ActionSessionDiff 是一个显示会话中全部文件更改的消息。
+	ActionSessionDiff struct {
+		SessionID string
+	}
+	// ActionExportSessionPatch 是一个将会话中的文件更改导出为补丁文件的消息。
+	ActionExportSessionPatch struct {
+		SessionID string
+		Changes   []history.FileChange
+	}
+	// ActionReplaySession 是一个逐条回放会话的消息。
+	ActionReplaySession struct {
+		SessionID string
+	}
+	// ActionResumeSession 是一个打开上次退出时被中断的会话的消息。
+	// Restart 为 true 时重新运行被中断的提示和排队的提示。
+	ActionResumeSession struct {
+		Session session.Session
+		Run     session.Run
+		Restart bool
+	}
+	// ActionDiscardRun 是一个放弃被中断的运行并开始新会话的消息。
+	ActionDiscardRun struct {
+		SessionID string
+	}
+	// ActionAskAboutSelection 是一个将选中文本连同指令发送给智能体的消息。
+	ActionAskAboutSelection struct {
+		Content string
+	}
+	// ActionExportSelectionImage 是一个将选中文本渲染为代码卡片图片的消息。
+	ActionExportSelectionImage struct {
+		Content string
+		Copy    bool
+	}
+	// ActionRestartLSP 是一个重启语言服务器的消息。
+	ActionRestartLSP struct {
+		Name string
+	}
+	// ActionStopLSP 是一个停止语言服务器的消息。
+	ActionStopLSP struct {
+		Name string
+	}
+	// ActionRunCodeBlock 是一个运行助手消息中代码块的消息。
+	ActionRunCodeBlock struct {
+		Block codeblock.Block
+	}
+	// ActionDetectProjectSetup 是一个重新检查工作区并显示项目设置建议的消息。
+	ActionDetectProjectSetup struct{}
+	// ActionApplyProjectSetup 是一个将选中的项目设置建议写入项目配置的消息。
+	ActionApplyProjectSetup struct {
+		Suggestions []projectsetup.Suggestion
+	}
+	// ActionSkipProjectSetup 是一个跳过项目设置建议且不再提示的消息。
+	ActionSkipProjectSetup struct{}
 	// ActionSelectReasoningEffort 是一个表示已选择推理强度的消息。
 	ActionSelectReasoningEffort struct {
 		Effort string
@@ -62,6 +269,8 @@ type (
 	ActionPermissionResponse struct {
 		Permission permission.PermissionRequest
 		Action     PermissionAction
+		// Window 是 Action 为 [PermissionAllowWindow] 时临时授权的范围。
+		Window permission.Window
 	}
 	// ActionRunCustomCommand 是一个运行自定义命令的消息。
 	ActionRunCustomCommand struct {
@@ -69,6 +278,17 @@ type (
 		Arguments []commands.Argument
 		Args      map[string]string // 实际参数值
 	}
+	// ActionSaveSnippet 是一个把输入框内容保存为片段的消息。
+	ActionSaveSnippet struct {
+		Content string
+		Name    string
+	}
+	// ActionInsertSnippet 是一个在填写占位符后插入片段的消息。
+	ActionInsertSnippet struct {
+		Content   string
+		Arguments []commands.Argument
+		Args      map[string]string // 实际参数值
+	}
 	// ActionRunMCPPrompt 是一个运行自定义命令的消息。
 	ActionRunMCPPrompt struct {
 		Title       string
@@ -107,6 +327,16 @@ type (
 	ActionOAuthErrored struct {
 		Error error
 	}
+
+	// ActionAuthenticateMCP 是一个打开 MCP 服务器认证对话框的消息。
+	ActionAuthenticateMCP struct {
+		Name string
+	}
+
+	// ActionMCPAuthenticated 在 MCP 服务器认证成功并保存令牌后发送。
+	ActionMCPAuthenticated struct {
+		Name string
+	}
 )
 
 // ActionCmd 表示一个携带 [tea.Cmd] 的操作，该命令将被传递到 Bubble Tea 程序循环。
@@ -126,7 +356,7 @@ func (a ActionFilePickerSelected) Cmd() tea.Cmd {
 		return nil
 	}
 	return func() tea.Msg {
-		isFileLarge, err := common.IsFileTooBig(path, common.MaxAttachmentSize)
+		isFileLarge, err := common.IsFileTooBig(path, common.MaxImageSourceSize)
 		if err != nil {
 			return util.InfoMsg{
 				Type: util.InfoTypeError,
@@ -136,7 +366,7 @@ func (a ActionFilePickerSelected) Cmd() tea.Cmd {
 		if isFileLarge {
 			return util.InfoMsg{
 				Type: util.InfoTypeError,
-				Msg:  "文件过大，最大 5MB",
+				Msg:  "文件过大，最大 50MB",
 			}
 		}
 
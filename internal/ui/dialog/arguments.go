@@ -22,6 +22,12 @@ import (
 // ArgumentsID 是参数对话框的标识符。
 const ArgumentsID = "arguments"
 
+// SnippetNameArg 是保存片段时名称参数的标识符。
+const SnippetNameArg = "NAME"
+
+// BookmarkLabelArg 是添加书签时标签参数的标识符。
+const BookmarkLabelArg = "LABEL"
+
 // 参数对话框的尺寸。
 const (
 	maxInputWidth        = 120
@@ -217,6 +223,16 @@ func (a *Arguments) HandleMsg(msg tea.Msg) Action {
 				case ActionRunMCPPrompt:
 					action.Args = args
 					return action
+				case ActionInsertSnippet:
+					action.Args = args
+					return action
+				case ActionSaveSnippet:
+					action.Name = args[SnippetNameArg]
+					return action
+				case ActionAddBookmark:
+					action.Label = strings.TrimSpace(args[BookmarkLabelArg])
+					action.Labeled = true
+					return action
 				}
 			}
 			a.focusInput(a.focused + 1)
@@ -0,0 +1,297 @@
+package dialog
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"charm.land/bubbles/v2/help"
+	"charm.land/bubbles/v2/key"
+	tea "charm.land/bubbletea/v2"
+	uv "github.com/charmbracelet/ultraviolet"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/dustin/go-humanize"
+	"github.com/purpose168/crush-cn/internal/message"
+	"github.com/purpose168/crush-cn/internal/ui/common"
+	"github.com/purpose168/crush-cn/internal/ui/list"
+	"github.com/purpose168/crush-cn/internal/ui/styles"
+)
+
+const (
+	// AttachmentManagerID 是附件管理对话框的标识符。
+	AttachmentManagerID = "attachment_manager"
+	// attachmentManagerListHeight 是附件列表显示的最大行数。
+	attachmentManagerListHeight = 8
+	// attachmentPreviewLines 是文本附件预览显示的最大行数。
+	attachmentPreviewLines = 10
+)
+
+// AttachmentManager 是管理待发送消息附件的对话框。它列出所有附件的大小和估算的令牌数，
+// 预览选中的附件，并支持重新排序和删除。每次修改都会立即同步到编辑器。
+type AttachmentManager struct {
+	com         *common.Common
+	help        help.Model
+	list        *list.List
+	attachments []message.Attachment
+
+	keyMap struct {
+		Next     key.Binding
+		Previous key.Binding
+		UpDown   key.Binding
+		MoveUp   key.Binding
+		MoveDown key.Binding
+		Move     key.Binding
+		View     key.Binding
+		Delete   key.Binding
+		Close    key.Binding
+	}
+}
+
+var _ Dialog = (*AttachmentManager)(nil)
+
+// NewAttachmentManager 为待发送消息的附件创建一个新的 [AttachmentManager] 对话框。
+func NewAttachmentManager(com *common.Common, attachments []message.Attachment) *AttachmentManager {
+	a := &AttachmentManager{com: com, attachments: slices.Clone(attachments)}
+
+	a.help = help.New()
+	a.help.Styles = com.Styles.DialogHelpStyles()
+
+	a.list = list.NewList()
+	a.list.Focus()
+
+	a.keyMap.Next = key.NewBinding(
+		key.WithKeys("down", "ctrl+n"),
+		key.WithHelp("↓", "下一项"),
+	)
+	a.keyMap.Previous = key.NewBinding(
+		key.WithKeys("up", "ctrl+p"),
+		key.WithHelp("↑", "上一项"),
+	)
+	a.keyMap.UpDown = key.NewBinding(
+		key.WithKeys("up", "down"),
+		key.WithHelp("↑↓", "选择"),
+	)
+	a.keyMap.MoveUp = key.NewBinding(
+		key.WithKeys("shift+up", "ctrl+k"),
+		key.WithHelp("shift+↑", "上移"),
+	)
+	a.keyMap.MoveDown = key.NewBinding(
+		key.WithKeys("shift+down", "ctrl+j"),
+		key.WithHelp("shift+↓", "下移"),
+	)
+	a.keyMap.Move = key.NewBinding(
+		key.WithKeys("shift+up", "shift+down"),
+		key.WithHelp("shift+↑↓", "移动"),
+	)
+	a.keyMap.View = key.NewBinding(
+		key.WithKeys("enter"),
+		key.WithHelp("enter", "查看图片"),
+	)
+	a.keyMap.Delete = key.NewBinding(
+		key.WithKeys("ctrl+x", "delete", "backspace"),
+		key.WithHelp("ctrl+x", "删除"),
+	)
+	a.keyMap.Close = CloseKey
+
+	a.setItems()
+	a.list.SetSelected(0)
+	return a
+}
+
+// ID 实现 Dialog 接口。
+func (a *AttachmentManager) ID() string {
+	return AttachmentManagerID
+}
+
+// HandleMsg 实现 Dialog 接口。
+func (a *AttachmentManager) HandleMsg(msg tea.Msg) Action {
+	keyMsg, ok := msg.(tea.KeyPressMsg)
+	if !ok {
+		return nil
+	}
+	switch {
+	case key.Matches(keyMsg, a.keyMap.Close):
+		return ActionClose{}
+	case key.Matches(keyMsg, a.keyMap.MoveUp):
+		return a.move(-1)
+	case key.Matches(keyMsg, a.keyMap.MoveDown):
+		return a.move(1)
+	case key.Matches(keyMsg, a.keyMap.Previous):
+		if a.list.IsSelectedFirst() {
+			a.list.SelectLast()
+			a.list.ScrollToBottom()
+			break
+		}
+		a.list.SelectPrev()
+		a.list.ScrollToSelected()
+	case key.Matches(keyMsg, a.keyMap.Next):
+		if a.list.IsSelectedLast() {
+			a.list.SelectFirst()
+			a.list.ScrollToTop()
+			break
+		}
+		a.list.SelectNext()
+		a.list.ScrollToSelected()
+	case key.Matches(keyMsg, a.keyMap.View):
+		if att, ok := a.selected(); ok && att.IsImage() {
+			return ActionViewAttachment{Attachment: att}
+		}
+	case key.Matches(keyMsg, a.keyMap.Delete):
+		idx := a.list.Selected()
+		if idx < 0 || idx >= len(a.attachments) {
+			break
+		}
+		a.attachments = slices.Delete(a.attachments, idx, idx+1)
+		a.setItems()
+		a.list.SetSelected(min(idx, len(a.attachments)-1))
+		a.list.ScrollToSelected()
+		return ActionSetAttachments{Attachments: slices.Clone(a.attachments)}
+	}
+	return nil
+}
+
+// selected 返回选中的附件。
+func (a *AttachmentManager) selected() (message.Attachment, bool) {
+	idx := a.list.Selected()
+	if idx < 0 || idx >= len(a.attachments) {
+		return message.Attachment{}, false
+	}
+	return a.attachments[idx], true
+}
+
+// move 将选中的附件上移 (-1) 或下移 (1)。
+func (a *AttachmentManager) move(delta int) Action {
+	from := a.list.Selected()
+	to := from + delta
+	if from < 0 || to < 0 || to >= len(a.attachments) {
+		return nil
+	}
+	a.attachments[from], a.attachments[to] = a.attachments[to], a.attachments[from]
+	a.setItems()
+	a.list.SetSelected(to)
+	a.list.ScrollToSelected()
+	return ActionSetAttachments{Attachments: slices.Clone(a.attachments)}
+}
+
+// setItems 根据当前附件重建列表项。
+func (a *AttachmentManager) setItems() {
+	items := make([]list.Item, len(a.attachments))
+	for i, att := range a.attachments {
+		items[i] = &AttachmentItem{index: i, attachment: att, t: a.com.Styles}
+	}
+	a.list.SetItems(items...)
+}
+
+// Draw 实现 [Dialog] 接口。
+func (a *AttachmentManager) Draw(scr uv.Screen, area uv.Rectangle) *tea.Cursor {
+	t := a.com.Styles
+	width := max(0, min(defaultDialogMaxWidth, area.Dx()))
+	innerWidth := width - t.Dialog.View.GetHorizontalFrameSize() - 2
+
+	var size, tokens int
+	for _, att := range a.attachments {
+		size += len(att.Content)
+		tokens += att.EstimateTokens()
+	}
+
+	rc := NewRenderContext(t, width)
+	rc.Gap = 1
+	rc.Title = fmt.Sprintf("附件 (%d)", len(a.attachments))
+	rc.TitleInfo = t.Subtle.Render(fmt.Sprintf(" %s · ≈%s 令牌", humanize.Bytes(uint64(size)), humanize.Comma(int64(tokens))))
+
+	a.list.SetSize(innerWidth, min(len(a.attachments), attachmentManagerListHeight))
+	rc.AddPart(t.Dialog.List.Height(a.list.Height()).Render(a.list.Render()))
+	if att, ok := a.selected(); ok {
+		rc.AddPart(a.renderPreview(att, innerWidth))
+	}
+
+	a.help.SetWidth(innerWidth)
+	rc.Help = a.help.View(a)
+
+	DrawCenter(scr, area, rc.Render())
+	return nil
+}
+
+// renderPreview 渲染选中附件的预览：文本附件显示开头几行，图片附件显示尺寸。
+func (a *AttachmentManager) renderPreview(att message.Attachment, width int) string {
+	t := a.com.Styles
+	if att.IsImage() {
+		info := att.MimeType
+		if cfg, _, err := image.DecodeConfig(bytes.NewReader(att.Content)); err == nil {
+			info = fmt.Sprintf("%d×%d · %s", cfg.Width, cfg.Height, att.MimeType)
+		}
+		return t.Subtle.Width(width).Render(info + " · 按 enter 查看图片")
+	}
+
+	lines := strings.Split(strings.TrimRight(string(att.Content), "\n"), "\n")
+	more := len(lines) - attachmentPreviewLines
+	lines = lines[:min(len(lines), attachmentPreviewLines)]
+	for i, line := range lines {
+		line = strings.ReplaceAll(line, "\t", "    ")
+		lines[i] = ansi.Truncate(ansi.Strip(line), width, "…")
+	}
+	preview := t.Base.Render(strings.Join(lines, "\n"))
+	if more > 0 {
+		preview += "\n" + t.Subtle.Render(fmt.Sprintf("… 还有 %d 行", more))
+	}
+	return preview
+}
+
+// ShortHelp 实现 [help.KeyMap] 接口。
+func (a *AttachmentManager) ShortHelp() []key.Binding {
+	binds := []key.Binding{a.keyMap.UpDown, a.keyMap.Move}
+	if att, ok := a.selected(); ok && att.IsImage() {
+		binds = append(binds, a.keyMap.View)
+	}
+	return append(binds, a.keyMap.Delete, a.keyMap.Close)
+}
+
+// FullHelp 实现 [help.KeyMap] 接口。
+func (a *AttachmentManager) FullHelp() [][]key.Binding {
+	return [][]key.Binding{a.ShortHelp()}
+}
+
+// AttachmentItem 表示附件管理对话框中的单个附件。
+type AttachmentItem struct {
+	index      int
+	attachment message.Attachment
+	t          *styles.Styles
+	cache      map[int]string
+	focused    bool
+}
+
+var (
+	_ list.Item      = (*AttachmentItem)(nil)
+	_ list.Focusable = (*AttachmentItem)(nil)
+)
+
+// SetFocused 设置附件项目的焦点状态。
+func (a *AttachmentItem) SetFocused(focused bool) {
+	if a.focused != focused {
+		a.cache = nil
+	}
+	a.focused = focused
+}
+
+// Render 返回附件项目的字符串表示。
+func (a *AttachmentItem) Render(width int) string {
+	if a.cache == nil {
+		a.cache = make(map[int]string)
+	}
+	itemStyles := ListItemStyles{
+		ItemBlurred:     a.t.Dialog.NormalItem,
+		ItemFocused:     a.t.Dialog.SelectedItem,
+		InfoTextBlurred: a.t.Subtle,
+		InfoTextFocused: a.t.Base,
+	}
+	kind := "文本"
+	if a.attachment.IsImage() {
+		kind = "图片"
+	}
+	title := fmt.Sprintf("%d. [%s] %s", a.index+1, kind, filepath.Base(a.attachment.FileName))
+	info := fmt.Sprintf("%s · ≈%s 令牌", humanize.Bytes(uint64(len(a.attachment.Content))), humanize.Comma(int64(a.attachment.EstimateTokens())))
+	return renderItem(itemStyles, title, info, a.focused, width, a.cache, nil)
+}
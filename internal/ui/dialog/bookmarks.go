@@ -0,0 +1,241 @@
+package dialog
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"charm.land/bubbles/v2/help"
+	"charm.land/bubbles/v2/key"
+	tea "charm.land/bubbletea/v2"
+	uv "github.com/charmbracelet/ultraviolet"
+	"github.com/dustin/go-humanize"
+	"github.com/purpose168/crush-cn/internal/session"
+	"github.com/purpose168/crush-cn/internal/ui/common"
+	"github.com/purpose168/crush-cn/internal/ui/list"
+	"github.com/purpose168/crush-cn/internal/ui/styles"
+	"github.com/purpose168/crush-cn/internal/ui/util"
+)
+
+// BookmarksID 是书签对话框的标识符。
+const BookmarksID = "bookmarks"
+
+// BookmarkEntry 是书签对话框中的一个书签。Missing 表示书签项目已不在聊天中，
+// 例如消息被删除或会话被总结。
+type BookmarkEntry struct {
+	session.Bookmark
+	Missing bool
+}
+
+// Bookmarks 是列出会话书签的对话框，选中书签后跳转到对应的聊天项目。
+type Bookmarks struct {
+	com       *common.Common
+	help      help.Model
+	list      *list.List
+	sessionID string
+	entries   []BookmarkEntry
+
+	keyMap struct {
+		Next     key.Binding
+		Previous key.Binding
+		UpDown   key.Binding
+		Jump     key.Binding
+		Delete   key.Binding
+		Close    key.Binding
+	}
+}
+
+var _ Dialog = (*Bookmarks)(nil)
+
+// NewBookmarks 创建一个新的 [Bookmarks] 对话框。entries 按在聊天中的位置排列。
+func NewBookmarks(com *common.Common, sessionID string, entries []BookmarkEntry) *Bookmarks {
+	b := &Bookmarks{
+		com:       com,
+		sessionID: sessionID,
+		entries:   slices.Clone(entries),
+	}
+
+	help := help.New()
+	help.Styles = com.Styles.DialogHelpStyles()
+	b.help = help
+
+	b.list = list.NewList()
+	b.list.Focus()
+
+	b.keyMap.Next = key.NewBinding(
+		key.WithKeys("down", "ctrl+n"),
+		key.WithHelp("↓", "下一项"),
+	)
+	b.keyMap.Previous = key.NewBinding(
+		key.WithKeys("up", "ctrl+p"),
+		key.WithHelp("↑", "上一项"),
+	)
+	b.keyMap.UpDown = key.NewBinding(
+		key.WithKeys("up", "down"),
+		key.WithHelp("↑↓", "选择"),
+	)
+	b.keyMap.Jump = key.NewBinding(
+		key.WithKeys("enter"),
+		key.WithHelp("enter", "跳转"),
+	)
+	b.keyMap.Delete = key.NewBinding(
+		key.WithKeys("ctrl+x", "delete"),
+		key.WithHelp("ctrl+x", "删除"),
+	)
+	b.keyMap.Close = CloseKey
+
+	b.setItems()
+	b.list.SetSelected(0)
+	return b
+}
+
+// ID 实现 Dialog 接口。
+func (b *Bookmarks) ID() string {
+	return BookmarksID
+}
+
+// HandleMsg 实现 Dialog 接口。
+func (b *Bookmarks) HandleMsg(msg tea.Msg) Action {
+	keyMsg, ok := msg.(tea.KeyPressMsg)
+	if !ok {
+		return nil
+	}
+	switch {
+	case key.Matches(keyMsg, b.keyMap.Close):
+		return ActionClose{}
+	case key.Matches(keyMsg, b.keyMap.Previous):
+		if b.list.IsSelectedFirst() {
+			b.list.SelectLast()
+			b.list.ScrollToBottom()
+			break
+		}
+		b.list.SelectPrev()
+		b.list.ScrollToSelected()
+	case key.Matches(keyMsg, b.keyMap.Next):
+		if b.list.IsSelectedLast() {
+			b.list.SelectFirst()
+			b.list.ScrollToTop()
+			break
+		}
+		b.list.SelectNext()
+		b.list.ScrollToSelected()
+	case key.Matches(keyMsg, b.keyMap.Jump):
+		idx := b.list.Selected()
+		if idx < 0 || idx >= len(b.entries) {
+			break
+		}
+		if b.entries[idx].Missing {
+			return ActionCmd{util.ReportWarn("书签项目已不在聊天中")}
+		}
+		return ActionJumpToBookmark{ItemID: b.entries[idx].ItemID}
+	case key.Matches(keyMsg, b.keyMap.Delete):
+		idx := b.list.Selected()
+		if idx < 0 || idx >= len(b.entries) {
+			break
+		}
+		b.entries = slices.Delete(b.entries, idx, idx+1)
+		b.setItems()
+		bookmarks := make([]session.Bookmark, len(b.entries))
+		for i, entry := range b.entries {
+			bookmarks[i] = entry.Bookmark
+		}
+		return ActionSetBookmarks{SessionID: b.sessionID, Bookmarks: bookmarks}
+	}
+	return nil
+}
+
+// setItems 根据当前的书签重建列表项，保持选中位置不变。
+func (b *Bookmarks) setItems() {
+	selected := b.list.Selected()
+	items := make([]list.Item, len(b.entries))
+	for i, entry := range b.entries {
+		items[i] = &BookmarkItem{entry: entry, t: b.com.Styles}
+	}
+	b.list.SetItems(items...)
+	b.list.SetSelected(min(max(selected, 0), len(items)-1))
+}
+
+// Draw 实现 [Dialog] 接口。
+func (b *Bookmarks) Draw(scr uv.Screen, area uv.Rectangle) *tea.Cursor {
+	t := b.com.Styles
+	width := max(0, min(defaultDialogMaxWidth, area.Dx()))
+	height := max(0, min(defaultDialogHeight, area.Dy()))
+	innerWidth := width - t.Dialog.View.GetHorizontalFrameSize() - 2
+	heightOffset := t.Dialog.Title.GetVerticalFrameSize() + titleContentHeight +
+		t.Dialog.HelpView.GetVerticalFrameSize() +
+		t.Dialog.View.GetVerticalFrameSize()
+
+	rc := NewRenderContext(t, width)
+	rc.Title = fmt.Sprintf("书签 (%d)", len(b.entries))
+
+	if len(b.entries) == 0 {
+		rc.AddPart(t.Subtle.Render("没有书签，在聊天中按 m 添加"))
+	} else {
+		b.list.SetSize(innerWidth, max(0, height-heightOffset))
+		listView := t.Dialog.List.Height(b.list.Height()).Render(b.list.Render())
+		rc.AddPart(listView)
+	}
+	b.help.SetWidth(innerWidth)
+	rc.Help = b.help.View(b)
+
+	DrawCenter(scr, area, rc.Render())
+	return nil
+}
+
+// ShortHelp 实现 [help.KeyMap] 接口。
+func (b *Bookmarks) ShortHelp() []key.Binding {
+	return []key.Binding{
+		b.keyMap.UpDown,
+		b.keyMap.Jump,
+		b.keyMap.Delete,
+		b.keyMap.Close,
+	}
+}
+
+// FullHelp 实现 [help.KeyMap] 接口。
+func (b *Bookmarks) FullHelp() [][]key.Binding {
+	return [][]key.Binding{b.ShortHelp()}
+}
+
+// BookmarkItem 表示书签对话框中的单个书签。
+type BookmarkItem struct {
+	entry   BookmarkEntry
+	t       *styles.Styles
+	cache   map[int]string
+	focused bool
+}
+
+var (
+	_ list.Item      = (*BookmarkItem)(nil)
+	_ list.Focusable = (*BookmarkItem)(nil)
+)
+
+// SetFocused 设置书签项目的焦点状态。
+func (b *BookmarkItem) SetFocused(focused bool) {
+	if b.focused != focused {
+		b.cache = nil
+	}
+	b.focused = focused
+}
+
+// Render 返回书签项目的字符串表示。
+func (b *BookmarkItem) Render(width int) string {
+	if b.cache == nil {
+		b.cache = make(map[int]string)
+	}
+	itemStyles := ListItemStyles{
+		ItemBlurred:     b.t.Dialog.NormalItem,
+		ItemFocused:     b.t.Dialog.SelectedItem,
+		InfoTextBlurred: b.t.Subtle,
+		InfoTextFocused: b.t.Base,
+	}
+	title := b.entry.Title()
+	if b.entry.Label != "" && b.entry.Preview != "" {
+		title += " · " + b.entry.Preview
+	}
+	info := humanize.Time(time.Unix(b.entry.CreatedAt, 0))
+	if b.entry.Missing {
+		info = "不在聊天中"
+	}
+	return renderItem(itemStyles, styles.BookmarkIcon+" "+title, info, b.focused, width, b.cache, nil)
+}
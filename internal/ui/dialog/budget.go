@@ -0,0 +1,146 @@
+package dialog
+
+import (
+	"fmt"
+
+	"charm.land/bubbles/v2/key"
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+	uv "github.com/charmbracelet/ultraviolet"
+	"github.com/purpose168/crush-cn/internal/budget"
+	"github.com/purpose168/crush-cn/internal/message"
+	"github.com/purpose168/crush-cn/internal/ui/common"
+)
+
+// BudgetID 是超出预算确认对话框的标识符。
+const BudgetID = "budget"
+
+// Budget 是今日费用达到预算后，在运行代理之前要求用户确认的对话框。
+// 它保存待发送的提示，确认后发送，取消时交还给编辑器。
+type Budget struct {
+	com         *common.Common
+	status      budget.Status
+	content     string
+	attachments []message.Attachment
+	selectedNo  bool
+	keyMap      struct {
+		LeftRight,
+		EnterSpace,
+		Yes,
+		No,
+		Tab,
+		Close key.Binding
+	}
+}
+
+var _ Dialog = (*Budget)(nil)
+
+// NewBudget 创建一个新的超出预算确认对话框。
+func NewBudget(com *common.Common, status budget.Status, content string, attachments []message.Attachment) *Budget {
+	b := &Budget{
+		com:         com,
+		status:      status,
+		content:     content,
+		attachments: attachments,
+		selectedNo:  true,
+	}
+	b.keyMap.LeftRight = key.NewBinding(
+		key.WithKeys("left", "right"),
+		key.WithHelp("←/→", "切换选项"),
+	)
+	b.keyMap.EnterSpace = key.NewBinding(
+		key.WithKeys("enter", " "),
+		key.WithHelp("enter/space", "确认"),
+	)
+	b.keyMap.Yes = key.NewBinding(
+		key.WithKeys("y", "Y"),
+		key.WithHelp("y/Y", "继续"),
+	)
+	b.keyMap.No = key.NewBinding(
+		key.WithKeys("n", "N"),
+		key.WithHelp("n/N", "取消"),
+	)
+	b.keyMap.Tab = key.NewBinding(
+		key.WithKeys("tab"),
+		key.WithHelp("tab", "切换选项"),
+	)
+	b.keyMap.Close = CloseKey
+	return b
+}
+
+// ID 实现 [Model] 接口。
+func (*Budget) ID() string {
+	return BudgetID
+}
+
+// HandleMsg 实现 [Model] 接口。
+func (b *Budget) HandleMsg(msg tea.Msg) Action {
+	keyMsg, ok := msg.(tea.KeyPressMsg)
+	if !ok {
+		return nil
+	}
+	switch {
+	case key.Matches(keyMsg, b.keyMap.LeftRight, b.keyMap.Tab):
+		b.selectedNo = !b.selectedNo
+	case key.Matches(keyMsg, b.keyMap.EnterSpace):
+		if !b.selectedNo {
+			return b.confirm()
+		}
+		return b.decline()
+	case key.Matches(keyMsg, b.keyMap.Yes):
+		return b.confirm()
+	case key.Matches(keyMsg, b.keyMap.No, b.keyMap.Close):
+		return b.decline()
+	}
+	return nil
+}
+
+func (b *Budget) confirm() Action {
+	return ActionConfirmBudget{Content: b.content, Attachments: b.attachments}
+}
+
+func (b *Budget) decline() Action {
+	return ActionDeclineBudget{Content: b.content, Attachments: b.attachments}
+}
+
+// Draw 实现 [Dialog] 接口。
+func (b *Budget) Draw(scr uv.Screen, area uv.Rectangle) *tea.Cursor {
+	question := fmt.Sprintf(
+		"今日费用 $%.2f 已达到每日预算 $%.2f。\n仍要继续运行代理吗？确认后今天不再询问。",
+		b.status.Spent, b.status.Limit,
+	)
+	baseStyle := b.com.Styles.Base
+	buttonOpts := []common.ButtonOpts{
+		{Text: "继续", Selected: !b.selectedNo, Padding: 3},
+		{Text: "取消", Selected: b.selectedNo, Padding: 3},
+	}
+	buttons := common.ButtonGroup(b.com.Styles, buttonOpts, " ")
+	content := baseStyle.Render(
+		lipgloss.JoinVertical(
+			lipgloss.Center,
+			question,
+			"",
+			buttons,
+		),
+	)
+
+	view := b.com.Styles.BorderFocus.Render(content)
+	DrawCenter(scr, area, view)
+	return nil
+}
+
+// ShortHelp 实现 [help.KeyMap] 接口。
+func (b *Budget) ShortHelp() []key.Binding {
+	return []key.Binding{
+		b.keyMap.LeftRight,
+		b.keyMap.EnterSpace,
+	}
+}
+
+// FullHelp 实现 [help.KeyMap] 接口。
+func (b *Budget) FullHelp() [][]key.Binding {
+	return [][]key.Binding{
+		{b.keyMap.LeftRight, b.keyMap.EnterSpace, b.keyMap.Yes, b.keyMap.No},
+		{b.keyMap.Tab, b.keyMap.Close},
+	}
+}
@@ -0,0 +1,348 @@
+package dialog
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"charm.land/bubbles/v2/help"
+	"charm.land/bubbles/v2/key"
+	"charm.land/bubbles/v2/textinput"
+	tea "charm.land/bubbletea/v2"
+	uv "github.com/charmbracelet/ultraviolet"
+	"github.com/dustin/go-humanize"
+	"github.com/purpose168/crush-cn/internal/checkpoint"
+	"github.com/purpose168/crush-cn/internal/ui/common"
+	"github.com/purpose168/crush-cn/internal/ui/list"
+	"github.com/purpose168/crush-cn/internal/ui/styles"
+	"github.com/purpose168/crush-cn/internal/ui/util"
+)
+
+// CheckpointsID 是检查点对话框的标识符。
+const CheckpointsID = "checkpoints"
+
+type checkpointsMode uint8
+
+// 检查点对话框可以处于的模式
+const (
+	checkpointsModeNormal checkpointsMode = iota
+	checkpointsModeRestoring
+	checkpointsModeDeleting
+)
+
+// Checkpoints 是一个用于创建、恢复和删除会话检查点的对话框。
+type Checkpoints struct {
+	com         *common.Common
+	help        help.Model
+	list        *list.List
+	input       textinput.Model
+	sessionID   string
+	checkpoints []checkpoint.Checkpoint
+	mode        checkpointsMode
+
+	keyMap struct {
+		Create        key.Binding
+		Restore       key.Binding
+		Next          key.Binding
+		Previous      key.Binding
+		UpDown        key.Binding
+		Delete        key.Binding
+		ConfirmAction key.Binding
+		CancelAction  key.Binding
+		Close         key.Binding
+	}
+}
+
+var _ Dialog = (*Checkpoints)(nil)
+
+// NewCheckpoints 创建一个新的 [Checkpoints] 对话框。
+func NewCheckpoints(com *common.Common, sessionID string) (*Checkpoints, error) {
+	c := &Checkpoints{
+		com:       com,
+		sessionID: sessionID,
+	}
+	checkpoints, err := com.App.Checkpoints.List(context.TODO(), sessionID)
+	if err != nil {
+		return nil, err
+	}
+	c.checkpoints = checkpoints
+
+	help := help.New()
+	help.Styles = com.Styles.DialogHelpStyles()
+	c.help = help
+
+	c.list = list.NewList()
+	c.list.Focus()
+
+	c.input = textinput.New()
+	c.input.SetVirtualCursor(false)
+	c.input.Placeholder = "输入名称以创建检查点"
+	c.input.SetStyles(com.Styles.TextInput)
+	c.input.Focus()
+
+	c.keyMap.Create = key.NewBinding(
+		key.WithKeys("enter"),
+		key.WithHelp("enter", "创建"),
+	)
+	c.keyMap.Restore = key.NewBinding(
+		key.WithKeys("enter", "ctrl+y"),
+		key.WithHelp("enter", "恢复"),
+	)
+	c.keyMap.Next = key.NewBinding(
+		key.WithKeys("down", "ctrl+n"),
+		key.WithHelp("↓", "下一项"),
+	)
+	c.keyMap.Previous = key.NewBinding(
+		key.WithKeys("up", "ctrl+p"),
+		key.WithHelp("↑", "上一项"),
+	)
+	c.keyMap.UpDown = key.NewBinding(
+		key.WithKeys("up", "down"),
+		key.WithHelp("↑↓", "选择"),
+	)
+	c.keyMap.Delete = key.NewBinding(
+		key.WithKeys("ctrl+x"),
+		key.WithHelp("ctrl+x", "删除"),
+	)
+	c.keyMap.ConfirmAction = key.NewBinding(
+		key.WithKeys("y"),
+		key.WithHelp("y", "确认"),
+	)
+	c.keyMap.CancelAction = key.NewBinding(
+		key.WithKeys("n", "esc"),
+		key.WithHelp("n", "取消"),
+	)
+	c.keyMap.Close = CloseKey
+
+	c.setItems()
+	c.list.SetSelected(0)
+	return c, nil
+}
+
+// ID 实现 Dialog 接口。
+func (c *Checkpoints) ID() string {
+	return CheckpointsID
+}
+
+// HandleMsg 实现 Dialog 接口。
+func (c *Checkpoints) HandleMsg(msg tea.Msg) Action {
+	keyMsg, ok := msg.(tea.KeyPressMsg)
+	if !ok {
+		return nil
+	}
+
+	if c.mode != checkpointsModeNormal {
+		switch {
+		case key.Matches(keyMsg, c.keyMap.ConfirmAction):
+			return c.confirm()
+		case key.Matches(keyMsg, c.keyMap.CancelAction):
+			c.setMode(checkpointsModeNormal)
+		}
+		return nil
+	}
+
+	switch {
+	case key.Matches(keyMsg, c.keyMap.Close):
+		return ActionClose{}
+	case key.Matches(keyMsg, c.keyMap.Previous):
+		if c.list.IsSelectedFirst() {
+			c.list.SelectLast()
+			c.list.ScrollToBottom()
+			break
+		}
+		c.list.SelectPrev()
+		c.list.ScrollToSelected()
+	case key.Matches(keyMsg, c.keyMap.Next):
+		if c.list.IsSelectedLast() {
+			c.list.SelectFirst()
+			c.list.ScrollToTop()
+			break
+		}
+		c.list.SelectNext()
+		c.list.ScrollToSelected()
+	case key.Matches(keyMsg, c.keyMap.Delete):
+		if c.selectedCheckpoint() != nil {
+			c.setMode(checkpointsModeDeleting)
+		}
+	case key.Matches(keyMsg, c.keyMap.Create, c.keyMap.Restore):
+		// 输入了名称时创建检查点，否则恢复选中的检查点。
+		if name := strings.TrimSpace(c.input.Value()); name != "" {
+			return ActionCreateCheckpoint{SessionID: c.sessionID, Name: name}
+		}
+		if c.selectedCheckpoint() != nil {
+			c.setMode(checkpointsModeRestoring)
+		}
+	default:
+		var cmd tea.Cmd
+		c.input, cmd = c.input.Update(keyMsg)
+		return ActionCmd{cmd}
+	}
+	return nil
+}
+
+// confirm 执行当前等待确认的恢复或删除操作。
+func (c *Checkpoints) confirm() Action {
+	mode := c.mode
+	c.setMode(checkpointsModeNormal)
+	cp := c.selectedCheckpoint()
+	if cp == nil {
+		return nil
+	}
+
+	if mode == checkpointsModeRestoring {
+		return ActionRestoreCheckpoint{SessionID: c.sessionID, ID: cp.ID, Name: cp.Name}
+	}
+
+	id := cp.ID
+	c.checkpoints = slices.DeleteFunc(c.checkpoints, func(existing checkpoint.Checkpoint) bool {
+		return existing.ID == id
+	})
+	c.setItems()
+	return ActionCmd{func() tea.Msg {
+		if err := c.com.App.Checkpoints.Delete(context.TODO(), id); err != nil {
+			return util.NewErrorMsg(err)
+		}
+		return nil
+	}}
+}
+
+func (c *Checkpoints) setMode(mode checkpointsMode) {
+	c.mode = mode
+	c.setItems()
+}
+
+func (c *Checkpoints) selectedCheckpoint() *checkpoint.Checkpoint {
+	idx := c.list.Selected()
+	if idx < 0 || idx >= len(c.checkpoints) {
+		return nil
+	}
+	return &c.checkpoints[idx]
+}
+
+// setItems 根据当前的检查点和模式重建列表项，保持选中位置不变。
+func (c *Checkpoints) setItems() {
+	selected := c.list.Selected()
+	items := make([]list.Item, len(c.checkpoints))
+	for i, cp := range c.checkpoints {
+		items[i] = &CheckpointItem{
+			checkpoint: cp,
+			mode:       c.mode,
+			t:          c.com.Styles,
+		}
+	}
+	c.list.SetItems(items...)
+	c.list.SetSelected(max(min(selected, len(items)-1), 0))
+}
+
+// Cursor 返回相对于对话框的光标位置。
+func (c *Checkpoints) Cursor() *tea.Cursor {
+	return InputCursor(c.com.Styles, c.input.Cursor())
+}
+
+// Draw 实现 [Dialog] 接口。
+func (c *Checkpoints) Draw(scr uv.Screen, area uv.Rectangle) *tea.Cursor {
+	t := c.com.Styles
+	width := max(0, min(defaultDialogMaxWidth, area.Dx()))
+	height := max(0, min(defaultDialogHeight, area.Dy()))
+	innerWidth := width - t.Dialog.View.GetHorizontalFrameSize() - 2
+	heightOffset := t.Dialog.Title.GetVerticalFrameSize() + titleContentHeight +
+		t.Dialog.InputPrompt.GetVerticalFrameSize() + inputContentHeight +
+		t.Dialog.HelpView.GetVerticalFrameSize() +
+		t.Dialog.View.GetVerticalFrameSize()
+	c.input.SetWidth(max(0, innerWidth-t.Dialog.InputPrompt.GetHorizontalFrameSize()-1)) // (1) 光标填充
+	c.list.SetSize(innerWidth, max(0, height-heightOffset))
+	c.help.SetWidth(innerWidth)
+
+	var cur *tea.Cursor
+	rc := NewRenderContext(t, width)
+	rc.Title = "检查点"
+	switch c.mode {
+	case checkpointsModeRestoring, checkpointsModeDeleting:
+		rc.TitleStyle = t.Dialog.Sessions.DeletingTitle
+		rc.TitleGradientFromColor = t.Dialog.Sessions.DeletingTitleGradientFromColor
+		rc.TitleGradientToColor = t.Dialog.Sessions.DeletingTitleGradientToColor
+		rc.ViewStyle = t.Dialog.Sessions.DeletingView
+		message := "删除此检查点？"
+		if c.mode == checkpointsModeRestoring {
+			message = "将文件恢复到此检查点？当前的更改会被覆盖。"
+		}
+		rc.AddPart(t.Dialog.Sessions.DeletingMessage.Render(message))
+	default:
+		rc.AddPart(t.Dialog.InputPrompt.Render(c.input.View()))
+		cur = c.Cursor()
+	}
+	if len(c.checkpoints) == 0 {
+		rc.AddPart(t.Subtle.Render("还没有检查点"))
+	} else {
+		rc.AddPart(t.Dialog.List.Height(c.list.Height()).Render(c.list.Render()))
+	}
+	rc.Help = c.help.View(c)
+
+	DrawCenterCursor(scr, area, rc.Render(), cur)
+	return cur
+}
+
+// ShortHelp 实现 [help.KeyMap] 接口。
+func (c *Checkpoints) ShortHelp() []key.Binding {
+	if c.mode != checkpointsModeNormal {
+		return []key.Binding{
+			c.keyMap.ConfirmAction,
+			c.keyMap.CancelAction,
+		}
+	}
+	bindings := []key.Binding{c.keyMap.UpDown}
+	if c.input.Value() != "" {
+		bindings = append(bindings, c.keyMap.Create)
+	} else {
+		bindings = append(bindings, c.keyMap.Restore)
+	}
+	return append(bindings, c.keyMap.Delete, c.keyMap.Close)
+}
+
+// FullHelp 实现 [help.KeyMap] 接口。
+func (c *Checkpoints) FullHelp() [][]key.Binding {
+	return [][]key.Binding{c.ShortHelp()}
+}
+
+// CheckpointItem 表示检查点对话框中的单个检查点。
+type CheckpointItem struct {
+	checkpoint checkpoint.Checkpoint
+	mode       checkpointsMode
+	t          *styles.Styles
+	cache      map[int]string
+	focused    bool
+}
+
+var (
+	_ list.Item      = (*CheckpointItem)(nil)
+	_ list.Focusable = (*CheckpointItem)(nil)
+)
+
+// SetFocused 设置检查点项目的焦点状态。
+func (c *CheckpointItem) SetFocused(focused bool) {
+	if c.focused != focused {
+		c.cache = nil
+	}
+	c.focused = focused
+}
+
+// Render 返回检查点项目的字符串表示。
+func (c *CheckpointItem) Render(width int) string {
+	if c.cache == nil {
+		c.cache = make(map[int]string)
+	}
+	itemStyles := ListItemStyles{
+		ItemBlurred:     c.t.Dialog.NormalItem,
+		ItemFocused:     c.t.Dialog.SelectedItem,
+		InfoTextBlurred: c.t.Subtle,
+		InfoTextFocused: c.t.Base,
+	}
+	if c.mode != checkpointsModeNormal {
+		itemStyles.ItemBlurred = c.t.Dialog.Sessions.DeletingItemBlurred
+		itemStyles.ItemFocused = c.t.Dialog.Sessions.DeletingItemFocused
+	}
+	info := fmt.Sprintf("%d 个文件 · %s", len(c.checkpoint.Files), humanize.Time(time.Unix(c.checkpoint.CreatedAt, 0)))
+	return renderItem(itemStyles, c.checkpoint.Name, info, c.focused, width, c.cache, nil)
+}
@@ -0,0 +1,170 @@
+package dialog
+
+import (
+	"strings"
+
+	"charm.land/bubbles/v2/help"
+	"charm.land/bubbles/v2/key"
+	tea "charm.land/bubbletea/v2"
+	uv "github.com/charmbracelet/ultraviolet"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/purpose168/crush-cn/internal/codeblock"
+	"github.com/purpose168/crush-cn/internal/ui/common"
+	"github.com/purpose168/crush-cn/internal/ui/list"
+)
+
+const (
+	// CodeBlocksID 是选择要运行的代码块对话框的标识符。
+	CodeBlocksID = "code_blocks"
+	// codeBlocksMaxItems 是列表最多同时显示的代码块数。
+	codeBlocksMaxItems = 9
+	// codeBlocksPreviewLines 是选中代码块预览的最大行数。
+	codeBlocksPreviewLines = 8
+)
+
+// CodeBlocks 是在助手消息包含多个可运行代码块时选择要运行哪一个的对话框。
+type CodeBlocks struct {
+	com    *common.Common
+	help   help.Model
+	list   *list.List
+	blocks []codeblock.Block
+
+	keyMap struct {
+		Next     key.Binding
+		Previous key.Binding
+		UpDown   key.Binding
+		Select   key.Binding
+		Close    key.Binding
+	}
+}
+
+var _ Dialog = (*CodeBlocks)(nil)
+
+// NewCodeBlocks 创建一个列出 blocks 的 [CodeBlocks] 对话框。
+func NewCodeBlocks(com *common.Common, blocks []codeblock.Block) *CodeBlocks {
+	c := &CodeBlocks{com: com, blocks: blocks}
+
+	help := help.New()
+	help.Styles = com.Styles.DialogHelpStyles()
+	c.help = help
+
+	c.list = list.NewList()
+	c.list.Focus()
+	items := make([]list.Item, len(blocks))
+	for i, block := range blocks {
+		items[i] = &SelectionActionItem{
+			title: block.Title(),
+			index: i + 1,
+			t:     com.Styles,
+		}
+	}
+	c.list.SetItems(items...)
+	c.list.SetSelected(0)
+
+	c.keyMap.Next = key.NewBinding(
+		key.WithKeys("down", "ctrl+n"),
+		key.WithHelp("↓", "下一项"),
+	)
+	c.keyMap.Previous = key.NewBinding(
+		key.WithKeys("up", "ctrl+p"),
+		key.WithHelp("↑", "上一项"),
+	)
+	c.keyMap.UpDown = key.NewBinding(
+		key.WithKeys("up", "down"),
+		key.WithHelp("↑↓", "选择"),
+	)
+	c.keyMap.Select = key.NewBinding(
+		key.WithKeys("enter", "ctrl+y"),
+		key.WithHelp("enter", "运行"),
+	)
+	c.keyMap.Close = CloseKey
+	return c
+}
+
+// ID 实现 Dialog 接口。
+func (c *CodeBlocks) ID() string {
+	return CodeBlocksID
+}
+
+// HandleMsg 实现 Dialog 接口。
+func (c *CodeBlocks) HandleMsg(msg tea.Msg) Action {
+	keyMsg, ok := msg.(tea.KeyPressMsg)
+	if !ok {
+		return nil
+	}
+	switch {
+	case key.Matches(keyMsg, c.keyMap.Close):
+		return ActionClose{}
+	case key.Matches(keyMsg, c.keyMap.Previous):
+		if c.list.IsSelectedFirst() {
+			c.list.SelectLast()
+			break
+		}
+		c.list.SelectPrev()
+	case key.Matches(keyMsg, c.keyMap.Next):
+		if c.list.IsSelectedLast() {
+			c.list.SelectFirst()
+			break
+		}
+		c.list.SelectNext()
+	case key.Matches(keyMsg, c.keyMap.Select):
+		return c.action(c.list.Selected())
+	default:
+		// 数字键直接运行对应的代码块
+		if n := keyMsg.String(); len(n) == 1 && n[0] >= '1' && n[0] <= '9' {
+			return c.action(int(n[0] - '1'))
+		}
+	}
+	return nil
+}
+
+// action 返回运行第 idx 个代码块的操作。
+func (c *CodeBlocks) action(idx int) Action {
+	if idx < 0 || idx >= len(c.blocks) {
+		return nil
+	}
+	return ActionRunCodeBlock{Block: c.blocks[idx]}
+}
+
+// Draw 实现 [Dialog] 接口。
+func (c *CodeBlocks) Draw(scr uv.Screen, area uv.Rectangle) *tea.Cursor {
+	t := c.com.Styles
+	width := max(0, min(defaultDialogMaxWidth, area.Dx()))
+	innerWidth := width - t.Dialog.View.GetHorizontalFrameSize() - 2
+
+	rc := NewRenderContext(t, width)
+	rc.Title = "运行代码块"
+
+	c.list.SetSize(innerWidth, min(len(c.blocks), codeBlocksMaxItems))
+	c.help.SetWidth(innerWidth)
+	rc.AddPart(t.Dialog.List.Height(c.list.Height()).Render(c.list.Render()))
+
+	if idx := c.list.Selected(); idx >= 0 && idx < len(c.blocks) {
+		lines := strings.Split(c.blocks[idx].Code, "\n")
+		if len(lines) > codeBlocksPreviewLines {
+			lines = append(lines[:codeBlocksPreviewLines], "…")
+		}
+		for i, line := range lines {
+			lines[i] = ansi.Truncate(strings.ReplaceAll(line, "\t", "    "), innerWidth, "…")
+		}
+		rc.AddPart(t.Subtle.Render(strings.Join(lines, "\n")))
+	}
+	rc.Help = c.help.View(c)
+
+	DrawCenter(scr, area, rc.Render())
+	return nil
+}
+
+// ShortHelp 实现 [help.KeyMap] 接口。
+func (c *CodeBlocks) ShortHelp() []key.Binding {
+	return []key.Binding{
+		c.keyMap.UpDown,
+		c.keyMap.Select,
+		c.keyMap.Close,
+	}
+}
+
+// FullHelp 实现 [help.KeyMap] 接口。
+func (c *CodeBlocks) FullHelp() [][]key.Binding {
+	return [][]key.Binding{c.ShortHelp()}
+}
@@ -10,6 +10,8 @@ import (
 	"charm.land/bubbles/v2/textinput"
 	tea "charm.land/bubbletea/v2"
 	uv "github.com/charmbracelet/ultraviolet"
+	"github.com/purpose168/crush-cn/internal/agent/tools/mcp"
+	"github.com/purpose168/crush-cn/internal/capability"
 	"github.com/purpose168/crush-cn/internal/commands"
 	"github.com/purpose168/crush-cn/internal/config"
 	"github.com/purpose168/crush-cn/internal/ui/common"
@@ -52,6 +54,7 @@ type Commands struct {
 	}
 
 	sessionID string // 对于非会话特定命令可以为空
+	tabCount  int    // 打开的会话标签页数量
 	selected  CommandType
 
 	spinner spinner.Model
@@ -237,6 +240,14 @@ func commandsRadioView(sty *styles.Styles, selected CommandType, hasUserCmds boo
 	return strings.Join(parts, " ")
 }
 
+// SetTabCount 设置打开的会话标签页数量，用于决定是否显示标签页相关命令。
+func (c *Commands) SetTabCount(n int) {
+	c.tabCount = n
+	if c.selected == SystemCommands {
+		c.setCommandItems(c.selected)
+	}
+}
+
 // Draw 实现 [Dialog] 接口。
 func (c *Commands) Draw(scr uv.Screen, area uv.Rectangle) *tea.Cursor {
 	t := c.com.Styles
@@ -386,20 +397,51 @@ func (c *Commands) setCommandItems(commandType CommandType) {
 func (c *Commands) defaultCommands() []*CommandItem {
 	commands := []*CommandItem{
 		NewCommandItem(c.com.Styles, "new_session", "新建会话", "ctrl+n", ActionNewSession{}),
+		NewCommandItem(c.com.Styles, "new_tab", "新建标签页", "ctrl+t", ActionNewTab{}),
 		NewCommandItem(c.com.Styles, "switch_session", "会话", "ctrl+s", ActionOpenDialog{SessionsID}),
 		NewCommandItem(c.com.Styles, "switch_model", "切换模型", "ctrl+l", ActionOpenDialog{ModelsID}),
 	}
 
+	// 仅在打开了多个标签页时显示关闭标签页命令
+	if c.tabCount > 1 {
+		commands = append(commands, NewCommandItem(c.com.Styles, "close_tab", "关闭标签页", "", ActionCloseTab{}))
+	}
+
 	// 仅在有活动会话时显示摘要命令
 	if c.sessionID != "" {
 		commands = append(commands, NewCommandItem(c.com.Styles, "summarize", "摘要会话", "", ActionSummarize{SessionID: c.sessionID}))
+		commands = append(commands, NewCommandItem(c.com.Styles, "replay_session", "回放会话", "", ActionReplaySession{SessionID: c.sessionID}))
+		commands = append(commands, NewCommandItem(c.com.Styles, "session_diff", "会话差异", "", ActionSessionDiff{SessionID: c.sessionID}))
+		commands = append(commands, NewCommandItem(c.com.Styles, "pinned_files", "管理固定的文件", "", ActionOpenDialog{PinnedFilesID}))
+		commands = append(commands, NewCommandItem(c.com.Styles, "bookmarks", "书签", "", ActionOpenDialog{BookmarksID}))
+		commands = append(commands, NewCommandItem(c.com.Styles, "regenerate", "重新生成回复", "R", ActionOpenDialog{RegenerateID}))
+		commands = append(commands, NewCommandItem(c.com.Styles, "reply_variants", "回复版本", "V", ActionOpenDialog{ReplyVariantsID}))
+		commands = append(commands, NewCommandItem(c.com.Styles, "todos", "编辑待办事项", "ctrl+b", ActionOpenDialog{TodosID}))
+		commands = append(commands, NewCommandItem(c.com.Styles, "session_env", "会话环境变量", "", ActionOpenDialog{SessionEnvID}))
+		commands = append(commands, NewCommandItem(c.com.Styles, "session_settings", "会话设置", "", ActionOpenDialog{SessionSettingsID}))
+		commands = append(commands, NewCommandItem(c.com.Styles, "session_tags", "会话标签", "", ActionOpenDialog{SessionTagsID}))
+		commands = append(commands, NewCommandItem(c.com.Styles, "checkpoints", "检查点", "", ActionOpenDialog{CheckpointsID}))
+		commands = append(commands, NewCommandItem(c.com.Styles, "redaction_report", "脱敏报告", "", ActionRedactionReport{SessionID: c.sessionID}))
 	}
 
-	// 为支持推理的模型添加推理切换
+	// 仅在当前会话有排队提示时显示队列管理命令
+	if c.sessionID != "" && c.com.App.AgentCoordinator != nil &&
+		c.com.App.AgentCoordinator.QueuedPrompts(c.sessionID) > 0 {
+		commands = append(commands, NewCommandItem(c.com.Styles, "manage_queue", "管理排队的提示", "", ActionOpenDialog{QueueID}))
+	}
+
+	// 为支持推理的模型添加推理切换，不支持的模型显示不可用的切换及原因
 	cfg := c.com.Config()
+	caps := capability.All()
 	if agentCfg, ok := cfg.Agents[config.AgentCoder]; ok {
 		providerCfg := cfg.GetProviderForModel(agentCfg.Model)
 		model := cfg.GetModelByType(agentCfg.Model)
+		if model != nil {
+			caps = capability.Of(*model, cfg.Models[agentCfg.Model])
+		}
+		if providerCfg != nil && model != nil && !model.CanReason {
+			commands = append(commands, NewCommandItem(c.com.Styles, "toggle_thinking", "切换思考模式", "", ActionToggleThinking{}).Disable(caps.Reason(capability.Reasoning)))
+		}
 		if providerCfg != nil && model != nil && model.CanReason {
 			selectedModel := cfg.Models[agentCfg.Model]
 
@@ -423,16 +465,13 @@ func (c *Commands) defaultCommands() []*CommandItem {
 	// 仅在窗口宽度大于紧凑断点（120）时显示切换紧凑模式命令
 	if c.windowWidth >= sidebarCompactModeBreakpoint && c.sessionID != "" {
 		commands = append(commands, NewCommandItem(c.com.Styles, "toggle_sidebar", "切换侧边栏", "", ActionToggleCompactMode{}))
+		commands = append(commands, NewCommandItem(c.com.Styles, "cycle_layout", "切换布局", "alt+l", ActionCycleLayout{}))
 	}
 	if c.sessionID != "" {
-		cfg := c.com.Config()
-		agentCfg := cfg.Agents[config.AgentCoder]
-		model := cfg.GetModelByType(agentCfg.Model)
-		if model != nil && model.SupportsImages {
-			commands = append(commands, NewCommandItem(c.com.Styles, "file_picker", "打开文件选择器", "ctrl+f", ActionOpenDialog{
-				// TODO: 传入文件选择器对话框 ID
-			}))
-		}
+		filePicker := NewCommandItem(c.com.Styles, "file_picker", "打开文件选择器", "ctrl+f", ActionOpenDialog{
+			// TODO: 传入文件选择器对话框 ID
+		})
+		commands = append(commands, filePicker.Disable(caps.Reason(capability.Images)))
 	}
 
 	// 如果 $EDITOR 可用，则添加外部编辑器命令
@@ -441,10 +480,43 @@ func (c *Commands) defaultCommands() []*CommandItem {
 		commands = append(commands, NewCommandItem(c.com.Styles, "open_external_editor", "打开外部编辑器", "ctrl+o", ActionExternalEditor{}))
 	}
 
+	commands = append(commands, NewCommandItem(c.com.Styles, "save_snippet", "保存为片段", "", ActionSaveSnippet{}))
+
+	// 仅在检测到尚未配置的 LSP 服务器时显示 LSP 配置命令
+	if len(DetectLSPSuggestions(cfg)) > 0 {
+		commands = append(commands, NewCommandItem(c.com.Styles, "setup_lsp", "配置 LSP", "", ActionOpenDialog{LSPSetupID}))
+	}
+	commands = append(commands, NewCommandItem(c.com.Styles, "project_setup", "项目设置建议", "", ActionDetectProjectSetup{}))
+	commands = append(commands, NewCommandItem(c.com.Styles, "lsp_servers", "语言服务器", "", ActionOpenDialog{LSPServersID}))
+	commands = append(commands, NewCommandItem(c.com.Styles, "logs", "查看日志", "", ActionOpenDialog{LogsID}))
+	if cfg.Options.Debug {
+		commands = append(commands, NewCommandItem(c.com.Styles, "provider_connections", "提供者连接", "", ActionOpenDialog{ConnectionsID}))
+	}
+	commands = append(commands, NewCommandItem(c.com.Styles, "workspace_roots", "工作区目录", "", ActionOpenDialog{WorkspaceRootsID}))
+
+	// 为需要认证的 MCP 服务器显示认证命令
+	for _, m := range cfg.MCP.Sorted() {
+		if state, ok := mcp.GetState(m.Name); ok && state.State == mcp.StateUnauthorized {
+			commands = append(commands, NewCommandItem(c.com.Styles, "authenticate_mcp_"+m.Name, "认证 MCP 服务器："+m.Name, "", ActionAuthenticateMCP{Name: m.Name}))
+		}
+	}
+
+	// 仅在配置了会话保留策略时显示清理命令
+	if cfg.Options.Retention.Enabled() {
+		commands = append(commands, NewCommandItem(c.com.Styles, "cleanup_sessions", "清理旧会话", "", ActionCleanupSessions{}))
+	}
+
+	dryRunStatus := "启用"
+	if c.com.App.DryRun.Enabled() {
+		dryRunStatus = "禁用"
+	}
+
 	return append(commands,
 		NewCommandItem(c.com.Styles, "toggle_yolo", "切换 Yolo 模式", "", ActionToggleYoloMode{}),
+		NewCommandItem(c.com.Styles, "toggle_dry_run", dryRunStatus+" 演练模式", "", ActionToggleDryRun{}),
+		NewCommandItem(c.com.Styles, "toggle_plan_mode", "切换计划模式", "", ActionTogglePlanMode{}),
 		NewCommandItem(c.com.Styles, "toggle_help", "切换帮助", "ctrl+g", ActionToggleHelp{}),
-		NewCommandItem(c.com.Styles, "init", "初始化项目", "", ActionInitializeProject{}),
+		NewCommandItem(c.com.Styles, "init", "初始化项目", "", ActionInitializeProject{}).Disable(caps.Reason(capability.Tools)),
 		NewCommandItem(c.com.Styles, "quit", "退出", "ctrl+c", tea.QuitMsg{}),
 	)
 }
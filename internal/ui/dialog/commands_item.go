@@ -2,6 +2,7 @@ package dialog
 
 import (
 	"github.com/purpose168/crush-cn/internal/ui/styles"
+	"github.com/purpose168/crush-cn/internal/ui/util"
 	"github.com/sahilm/fuzzy"
 )
 
@@ -11,6 +12,7 @@ type CommandItem struct {
 	title    string
 	shortcut string
 	action   Action
+	disabled string // 命令不可用的原因
 	t        *styles.Styles
 	m        fuzzy.Match
 	cache    map[int]string
@@ -30,6 +32,13 @@ func NewCommandItem(t *styles.Styles, id, title, shortcut string, action Action)
 	}
 }
 
+// Disable 将命令标记为不可用。列表中以弱化样式显示原因，选择时只提示原因而不执行操作。
+func (c *CommandItem) Disable(reason string) *CommandItem {
+	c.disabled = reason
+	c.cache = nil
+	return c
+}
+
 // Filter 实现 ListItem 接口。
 func (c *CommandItem) Filter() string {
 	return c.title
@@ -56,6 +65,9 @@ func (c *CommandItem) SetMatch(m fuzzy.Match) {
 
 // Action 返回与命令项目关联的操作。
 func (c *CommandItem) Action() Action {
+	if c.disabled != "" {
+		return ActionCmd{util.ReportWarn(c.disabled)}
+	}
 	return c.action
 }
 
@@ -72,5 +84,12 @@ func (c *CommandItem) Render(width int) string {
 		InfoTextBlurred: c.t.Base,
 		InfoTextFocused: c.t.Base,
 	}
-	return renderItem(styles, c.title, c.shortcut, c.focused, width, c.cache, &c.m)
+	info := c.shortcut
+	if c.disabled != "" {
+		styles.ItemBlurred = styles.ItemBlurred.Foreground(c.t.FgSubtle)
+		styles.InfoTextBlurred = c.t.Subtle
+		styles.InfoTextFocused = c.t.Subtle
+		info = c.disabled
+	}
+	return renderItem(styles, c.title, info, c.focused, width, c.cache, &c.m)
 }
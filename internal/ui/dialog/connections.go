@@ -0,0 +1,114 @@
+package dialog
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"charm.land/bubbles/v2/help"
+	"charm.land/bubbles/v2/key"
+	tea "charm.land/bubbletea/v2"
+	uv "github.com/charmbracelet/ultraviolet"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/dustin/go-humanize"
+	"github.com/purpose168/crush-cn/internal/network"
+	"github.com/purpose168/crush-cn/internal/ui/common"
+)
+
+const (
+	// ConnectionsID 是提供者连接统计对话框的标识符。
+	ConnectionsID = "connections"
+	// connectionsMaxWidth 是对话框的最大宽度。
+	connectionsMaxWidth = 100
+)
+
+// Connections 是显示每个提供者传输层连接复用情况的调试对话框，
+// 用于确认请求是否复用了连接、是否使用 HTTP/2 以及 DNS 缓存是否生效。
+type Connections struct {
+	com   *common.Common
+	help  help.Model
+	stats []network.ConnStats
+
+	keyMap struct {
+		Close key.Binding
+	}
+}
+
+var _ Dialog = (*Connections)(nil)
+
+// NewConnections 创建一个新的 [Connections] 对话框。
+func NewConnections(com *common.Common) *Connections {
+	c := &Connections{com: com}
+	c.help = help.New()
+	c.help.Styles = com.Styles.DialogHelpStyles()
+	c.keyMap.Close = CloseKey
+	c.Refresh()
+	return c
+}
+
+// ID 实现 Dialog 接口。
+func (c *Connections) ID() string {
+	return ConnectionsID
+}
+
+// Refresh 重新读取连接统计。
+func (c *Connections) Refresh() {
+	c.stats = network.ProviderStats()
+}
+
+// HandleMsg 实现 Dialog 接口。
+func (c *Connections) HandleMsg(msg tea.Msg) Action {
+	if keyMsg, ok := msg.(tea.KeyPressMsg); ok && key.Matches(keyMsg, c.keyMap.Close) {
+		return ActionClose{}
+	}
+	return nil
+}
+
+// connectionSummary 返回提供者连接统计的描述。
+func connectionSummary(s network.ConnStats) []string {
+	lines := []string{
+		fmt.Sprintf("请求 %d · 复用 %d (%.0f%%) · 新建连接 %d · HTTP/2 %d",
+			s.Requests, s.ReusedConns, s.ReuseRate()*100, s.NewConns, s.HTTP2),
+		fmt.Sprintf("TLS 握手 %d 次，平均 %s · DNS 缓存命中 %d / %d",
+			s.TLSHandshakes, s.AvgTLSHandshake().Round(time.Millisecond), s.DNSHits, s.DNSHits+s.DNSMisses),
+	}
+	if !s.LastRequest.IsZero() {
+		lines = append(lines, "最近请求 "+humanize.Time(s.LastRequest))
+	}
+	return lines
+}
+
+// Draw 实现 [Dialog] 接口。
+func (c *Connections) Draw(scr uv.Screen, area uv.Rectangle) *tea.Cursor {
+	t := c.com.Styles
+	width := max(0, min(connectionsMaxWidth, area.Dx()*9/10))
+	innerWidth := width - t.Dialog.View.GetHorizontalFrameSize() - 2
+	c.help.SetWidth(innerWidth)
+
+	rc := NewRenderContext(t, width)
+	rc.Title = "提供者连接"
+	if len(c.stats) == 0 {
+		rc.AddPart(t.Subtle.Render("还没有发往提供者的请求"))
+	}
+	for _, s := range c.stats {
+		lines := connectionSummary(s)
+		for i, line := range lines {
+			lines[i] = ansi.Truncate(line, innerWidth, "…")
+		}
+		rc.AddPart(t.Base.Bold(true).Render(s.Provider) + "\n" + t.Subtle.Render(strings.Join(lines, "\n")))
+	}
+	rc.Help = c.help.View(c)
+
+	DrawCenter(scr, area, rc.Render())
+	return nil
+}
+
+// ShortHelp 实现 [help.KeyMap] 接口。
+func (c *Connections) ShortHelp() []key.Binding {
+	return []key.Binding{c.keyMap.Close}
+}
+
+// FullHelp 实现 [help.KeyMap] 接口。
+func (c *Connections) FullHelp() [][]key.Binding {
+	return [][]key.Binding{c.ShortHelp()}
+}
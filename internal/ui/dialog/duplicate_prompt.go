@@ -0,0 +1,180 @@
+package dialog
+
+import (
+	"fmt"
+	"time"
+
+	"charm.land/bubbles/v2/key"
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+	uv "github.com/charmbracelet/ultraviolet"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/purpose168/crush-cn/internal/message"
+	"github.com/purpose168/crush-cn/internal/ui/common"
+)
+
+// DuplicatePromptID 是重复提示确认对话框的标识符。
+const DuplicatePromptID = "duplicate_prompt"
+
+// duplicatePromptPreviewWidth 是对话框中提示预览的最大宽度。
+const duplicatePromptPreviewWidth = 50
+
+// duplicatePromptChoice 是重复提示确认对话框中的选项。
+type duplicatePromptChoice int
+
+const (
+	duplicatePromptResend duplicatePromptChoice = iota
+	duplicatePromptJump
+	duplicatePromptCancel
+	duplicatePromptChoices
+)
+
+// DuplicatePrompt 是发送与会话中之前的提示相同的提示时要求用户确认的对话框。
+// 它保存待发送的提示，可以重新发送、跳转到之前的回答或取消并交还给编辑器。
+type DuplicatePrompt struct {
+	com         *common.Common
+	messageID   string
+	sentAt      time.Time
+	content     string
+	attachments []message.Attachment
+	selected    duplicatePromptChoice
+	keyMap      struct {
+		LeftRight,
+		Tab,
+		Enter,
+		Resend,
+		Jump,
+		Close key.Binding
+	}
+}
+
+var _ Dialog = (*DuplicatePrompt)(nil)
+
+// NewDuplicatePrompt 创建一个新的重复提示确认对话框。messageID 是之前发送的相同提示的消息。
+func NewDuplicatePrompt(com *common.Common, messageID string, sentAt time.Time, content string, attachments []message.Attachment) *DuplicatePrompt {
+	d := &DuplicatePrompt{
+		com:         com,
+		messageID:   messageID,
+		sentAt:      sentAt,
+		content:     content,
+		attachments: attachments,
+		selected:    duplicatePromptJump,
+	}
+	d.keyMap.LeftRight = key.NewBinding(
+		key.WithKeys("left", "right"),
+		key.WithHelp("←/→", "切换选项"),
+	)
+	d.keyMap.Tab = key.NewBinding(
+		key.WithKeys("tab"),
+		key.WithHelp("tab", "切换选项"),
+	)
+	d.keyMap.Enter = key.NewBinding(
+		key.WithKeys("enter", " "),
+		key.WithHelp("enter/space", "确认"),
+	)
+	d.keyMap.Resend = key.NewBinding(
+		key.WithKeys("r", "R"),
+		key.WithHelp("r", "重新发送"),
+	)
+	d.keyMap.Jump = key.NewBinding(
+		key.WithKeys("j", "J"),
+		key.WithHelp("j", "跳转到回答"),
+	)
+	d.keyMap.Close = CloseKey
+	return d
+}
+
+// ID 实现 [Dialog] 接口。
+func (*DuplicatePrompt) ID() string {
+	return DuplicatePromptID
+}
+
+// HandleMsg 实现 [Dialog] 接口。
+func (d *DuplicatePrompt) HandleMsg(msg tea.Msg) Action {
+	keyMsg, ok := msg.(tea.KeyPressMsg)
+	if !ok {
+		return nil
+	}
+	switch {
+	case keyMsg.String() == "left":
+		d.selected = (d.selected + duplicatePromptChoices - 1) % duplicatePromptChoices
+	case key.Matches(keyMsg, d.keyMap.LeftRight, d.keyMap.Tab):
+		d.selected = (d.selected + 1) % duplicatePromptChoices
+	case key.Matches(keyMsg, d.keyMap.Enter):
+		return d.choose(d.selected)
+	case key.Matches(keyMsg, d.keyMap.Resend):
+		return d.choose(duplicatePromptResend)
+	case key.Matches(keyMsg, d.keyMap.Jump):
+		return d.choose(duplicatePromptJump)
+	case key.Matches(keyMsg, d.keyMap.Close):
+		return d.choose(duplicatePromptCancel)
+	}
+	return nil
+}
+
+// choose 返回选项对应的操作。
+func (d *DuplicatePrompt) choose(choice duplicatePromptChoice) Action {
+	switch choice {
+	case duplicatePromptResend:
+		return ActionSendPrompt{Content: d.content, Attachments: d.attachments}
+	case duplicatePromptJump:
+		return ActionJumpToPrompt{MessageID: d.messageID, Content: d.content, Attachments: d.attachments}
+	default:
+		return ActionEditPrompt{Content: d.content, Attachments: d.attachments}
+	}
+}
+
+// sentAtText 返回之前发送提示的时间，今天发送的只显示时分。
+func (d *DuplicatePrompt) sentAtText() string {
+	now := time.Now()
+	switch {
+	case d.sentAt.YearDay() == now.YearDay() && d.sentAt.Year() == now.Year():
+		return d.sentAt.Format("15:04")
+	case d.sentAt.Year() == now.Year():
+		return d.sentAt.Format("01-02 15:04")
+	default:
+		return d.sentAt.Format("2006-01-02 15:04")
+	}
+}
+
+// Draw 实现 [Dialog] 接口。
+func (d *DuplicatePrompt) Draw(scr uv.Screen, area uv.Rectangle) *tea.Cursor {
+	t := d.com.Styles
+	question := fmt.Sprintf("这条提示已在 %s 发送过。", d.sentAtText())
+	preview := t.Subtle.Render(ansi.Truncate(firstLine(d.content), duplicatePromptPreviewWidth, "…"))
+	buttonOpts := []common.ButtonOpts{
+		{Text: "重新发送", Selected: d.selected == duplicatePromptResend},
+		{Text: "跳转到回答", Selected: d.selected == duplicatePromptJump},
+		{Text: "取消", Selected: d.selected == duplicatePromptCancel},
+	}
+	buttons := common.ButtonGroup(t, buttonOpts, " ")
+	content := t.Base.Render(
+		lipgloss.JoinVertical(
+			lipgloss.Center,
+			question,
+			preview,
+			"",
+			buttons,
+		),
+	)
+
+	view := t.BorderFocus.Render(content)
+	DrawCenter(scr, area, view)
+	return nil
+}
+
+// ShortHelp 实现 [help.KeyMap] 接口。
+func (d *DuplicatePrompt) ShortHelp() []key.Binding {
+	return []key.Binding{
+		d.keyMap.LeftRight,
+		d.keyMap.Enter,
+	}
+}
+
+// FullHelp 实现 [help.KeyMap] 接口。
+func (d *DuplicatePrompt) FullHelp() [][]key.Binding {
+	return [][]key.Binding{
+		{d.keyMap.LeftRight, d.keyMap.Enter, d.keyMap.Resend, d.keyMap.Jump},
+		{d.keyMap.Tab, d.keyMap.Close},
+	}
+}
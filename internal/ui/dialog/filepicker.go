@@ -29,11 +29,13 @@ type FilePicker struct {
 
 	imgEnc                      fimage.Encoding
 	imgPrevWidth, imgPrevHeight int
+	imgPrevX, imgPrevY          int
 	cellSizeW, cellSizeH        int
 
 	fp              filepicker.Model
 	help            help.Model
-	previewingImage bool // 指示是否正在预览图像
+	previewingImage bool   // 指示是否正在预览图像
+	paintedPath     string // 最近一次通过内联协议绘制的图像路径
 	isTmux          bool
 
 	km struct {
@@ -113,9 +115,7 @@ func NewFilePicker(com *common.Common) (*FilePicker, tea.Cmd) {
 // SetImageCapabilities 设置 [FilePicker] 的图像功能。
 func (f *FilePicker) SetImageCapabilities(caps *common.Capabilities) {
 	if caps != nil {
-		if caps.SupportsKittyGraphics() {
-			f.imgEnc = fimage.EncodingKitty
-		}
+		f.imgEnc = fimage.Negotiate(caps.ImageSupport())
 		f.cellSizeW, f.cellSizeH = caps.CellSize()
 		_, f.isTmux = caps.Env.LookupEnv("TMUX")
 	}
@@ -199,8 +199,11 @@ func (f *FilePicker) HandleMsg(msg tea.Msg) Action {
 						f.previewingImage = true
 						return nil
 					},
+					f.paintImage(selFile),
 				))
 			}
+		} else if allowed && selFile != f.paintedPath {
+			cmds = append(cmds, f.paintImage(selFile))
 		}
 	}
 	if cmd != nil {
@@ -251,10 +254,37 @@ func (f *FilePicker) Draw(scr uv.Screen, area uv.Rectangle) *tea.Cursor {
 
 	view := rc.Render()
 
+	// 记录预览区域在屏幕上的位置，供内联图像协议绘制使用。
+	vw, vh := lipgloss.Size(view)
+	center := common.CenterRect(area, vw, vh)
+	dialogStyle, prevStyle := t.Dialog.View, t.Dialog.ImagePreview
+	f.imgPrevX = center.Min.X +
+		dialogStyle.GetMarginLeft() + dialogStyle.GetBorderLeftSize() + dialogStyle.GetPaddingLeft() +
+		prevStyle.GetMarginLeft() + prevStyle.GetBorderLeftSize() + prevStyle.GetPaddingLeft()
+	f.imgPrevY = center.Min.Y +
+		dialogStyle.GetMarginTop() + dialogStyle.GetBorderTopSize() + dialogStyle.GetPaddingTop() +
+		t.Dialog.Title.GetVerticalFrameSize() + titleContentHeight + rc.Gap +
+		prevStyle.GetMarginTop() + prevStyle.GetBorderTopSize() + prevStyle.GetPaddingTop()
+
 	DrawCenter(scr, area, view)
 	return nil
 }
 
+// paintImage 返回一个命令，使用内联图像协议（iTerm2 或 Sixel）在预览
+// 区域绘制图像。其他编码不需要额外绘制，因此返回 nil。
+func (f *FilePicker) paintImage(path string) tea.Cmd {
+	f.paintedPath = path
+	// 命令在其他 goroutine 中执行，而 Draw 会更新预览区域，因此在这里复制一份。
+	enc, isTmux := f.imgEnc, f.isTmux
+	width, height, x, y := f.imgPrevWidth, f.imgPrevHeight, f.imgPrevX, f.imgPrevY
+	return func() tea.Msg {
+		if cmd := enc.Paint(path, width, height, x, y, isTmux); cmd != nil {
+			return cmd()
+		}
+		return nil
+	}
+}
+
 var (
 	imagePreviewCache = map[string]string{}
 	imagePreviewMutex sync.RWMutex
@@ -0,0 +1,209 @@
+package dialog
+
+import (
+	"fmt"
+	"strings"
+
+	"charm.land/bubbles/v2/help"
+	"charm.land/bubbles/v2/key"
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+	uv "github.com/charmbracelet/ultraviolet"
+	"github.com/purpose168/crush-cn/internal/ui/common"
+	fimage "github.com/purpose168/crush-cn/internal/ui/image"
+)
+
+// ImageViewerID 是图像查看对话框的标识符。
+const ImageViewerID = "image_viewer"
+
+// imageViewerMinWidth 是图像查看对话框的最小宽度，保证标题和帮助可见。
+const imageViewerMinWidth = 40
+
+// imageViewerReadyMsg 在图像传输到终端后发送。
+type imageViewerReadyMsg struct {
+	id  string
+	err error
+}
+
+// ImageViewer 是以原始尺寸（受终端大小限制）显示图像的覆盖对话框。
+type ImageViewer struct {
+	com  *common.Common
+	help help.Model
+
+	id        string
+	title     string
+	mediaType string
+	data      string
+
+	enc        fimage.Encoding
+	cellSize   fimage.CellSize
+	isTmux     bool
+	cols, rows int
+	imgX, imgY int
+	ready      bool
+	err        error
+
+	keyMap struct {
+		Close key.Binding
+	}
+}
+
+var _ Dialog = (*ImageViewer)(nil)
+
+// NewImageViewer 创建一个新的 [ImageViewer] 对话框。id 用于缓存已传输的图像，
+// data 是 base64 编码的图像数据。返回的命令在后台解码并传输图像。
+func NewImageViewer(com *common.Common, caps *common.Capabilities, id, title, data, mediaType string) (*ImageViewer, tea.Cmd) {
+	v := &ImageViewer{
+		com:       com,
+		id:        "viewer-" + id,
+		title:     title,
+		mediaType: mediaType,
+		data:      data,
+	}
+
+	v.help = help.New()
+	v.help.Styles = com.Styles.DialogHelpStyles()
+	v.keyMap.Close = key.NewBinding(
+		key.WithKeys("esc", "alt+esc", "q", "enter"),
+		key.WithHelp("esc", "关闭"),
+	)
+
+	v.enc = fimage.Negotiate(caps.ImageSupport())
+	cw, ch := caps.CellSize()
+	v.cellSize = fimage.CellSize{Width: cw, Height: ch}
+	_, v.isTmux = caps.Env.LookupEnv("TMUX")
+
+	// 只读取图像头部来确定布局，完整解码在命令中进行。
+	size, err := fimage.DecodeBase64Size(data)
+	if err != nil {
+		v.err = err
+		return v, nil
+	}
+	maxCols, maxRows := v.maxImageSize(caps.Columns, caps.Rows)
+	v.cols, v.rows = fimage.FitCells(size, v.cellSize, maxCols, maxRows)
+	return v, v.transmit()
+}
+
+// maxImageSize 返回终端中可用于显示图像的最大单元格数。
+func (v *ImageViewer) maxImageSize(columns, rows int) (int, int) {
+	if columns == 0 || rows == 0 {
+		columns, rows = 80, 24
+	}
+	t := v.com.Styles
+	frameW := t.Dialog.View.GetHorizontalFrameSize() + t.Dialog.ImagePreview.GetHorizontalFrameSize()
+	frameH := t.Dialog.View.GetVerticalFrameSize() + t.Dialog.ImagePreview.GetVerticalFrameSize() +
+		t.Dialog.Title.GetVerticalFrameSize() + titleContentHeight +
+		t.Dialog.HelpView.GetVerticalFrameSize() + 1 + // 帮助行
+		2 // 内容部分之间的间隙
+	return max(1, columns-frameW-2), max(1, rows-frameH-2)
+}
+
+// transmit 返回解码图像并将其传输到终端的命令。
+func (v *ImageViewer) transmit() tea.Cmd {
+	id, data, enc, cs, cols, rows, tmux := v.id, v.data, v.enc, v.cellSize, v.cols, v.rows, v.isTmux
+	return func() tea.Msg {
+		img, err := fimage.DecodeBase64(data)
+		if err != nil {
+			return imageViewerReadyMsg{id: id, err: err}
+		}
+		return tea.Sequence(
+			enc.Transmit(id, img, cs, cols, rows, tmux),
+			func() tea.Msg { return imageViewerReadyMsg{id: id} },
+		)()
+	}
+}
+
+// ID 实现 [Dialog] 接口。
+func (v *ImageViewer) ID() string {
+	return ImageViewerID
+}
+
+// HandleMsg 实现 [Dialog] 接口。
+func (v *ImageViewer) HandleMsg(msg tea.Msg) Action {
+	switch msg := msg.(type) {
+	case imageViewerReadyMsg:
+		if msg.id != v.id {
+			break
+		}
+		if msg.err != nil {
+			v.err = msg.err
+			break
+		}
+		v.ready = true
+		// 此时对话框已至少绘制过一次，图像位置已知。
+		return ActionCmd{v.enc.Paint(v.id, v.cols, v.rows, v.imgX, v.imgY, v.isTmux)}
+	case tea.KeyPressMsg:
+		if key.Matches(msg, v.keyMap.Close) {
+			return ActionClose{}
+		}
+	}
+	return nil
+}
+
+// Draw 实现 [Dialog] 接口。
+func (v *ImageViewer) Draw(scr uv.Screen, area uv.Rectangle) *tea.Cursor {
+	t := v.com.Styles
+	prevStyle := t.Dialog.ImagePreview
+	width := max(imageViewerMinWidth, v.cols+prevStyle.GetHorizontalFrameSize()+t.Dialog.View.GetHorizontalFrameSize())
+	width = min(width, area.Dx())
+	innerWidth := width - t.Dialog.View.GetHorizontalFrameSize()
+
+	rc := NewRenderContext(t, width)
+	rc.Gap = 1
+	rc.Title = v.title
+	rc.TitleInfo = t.Subtle.Render(v.mediaType)
+
+	var body string
+	switch {
+	case v.err != nil:
+		body = t.Subtle.Render(fmt.Sprintf("无法显示图像：%v", v.err))
+	case v.ready:
+		body = v.enc.Render(v.id, v.cols, v.rows)
+	default:
+		body = loadingPlaceholder(v.cols, v.rows)
+	}
+	rc.AddPart(prevStyle.Align(lipgloss.Center).Width(innerWidth).Render(body))
+	rc.Help = v.help.View(v)
+
+	view := rc.Render()
+
+	// 记录图像在屏幕上的位置，供内联图像协议绘制使用。
+	vw, vh := lipgloss.Size(view)
+	center := common.CenterRect(area, vw, vh)
+	dialogStyle := t.Dialog.View
+	contentWidth := innerWidth - prevStyle.GetHorizontalFrameSize()
+	v.imgX = center.Min.X +
+		dialogStyle.GetMarginLeft() + dialogStyle.GetBorderLeftSize() + dialogStyle.GetPaddingLeft() +
+		prevStyle.GetMarginLeft() + prevStyle.GetBorderLeftSize() + prevStyle.GetPaddingLeft() +
+		max(0, contentWidth-v.cols)/2
+	v.imgY = center.Min.Y +
+		dialogStyle.GetMarginTop() + dialogStyle.GetBorderTopSize() + dialogStyle.GetPaddingTop() +
+		t.Dialog.Title.GetVerticalFrameSize() + titleContentHeight + rc.Gap +
+		prevStyle.GetMarginTop() + prevStyle.GetBorderTopSize() + prevStyle.GetPaddingTop()
+
+	DrawCenter(scr, area, view)
+	return nil
+}
+
+// loadingPlaceholder 返回图像加载期间显示的占位区域。
+func loadingPlaceholder(cols, rows int) string {
+	if cols <= 0 || rows <= 0 {
+		return ""
+	}
+	lines := make([]string, rows)
+	for i := range lines {
+		lines[i] = strings.Repeat(" ", cols)
+	}
+	lines[rows/2] = lipgloss.PlaceHorizontal(cols, lipgloss.Center, "加载中…")
+	return strings.Join(lines, "\n")
+}
+
+// ShortHelp 实现 [help.KeyMap] 接口。
+func (v *ImageViewer) ShortHelp() []key.Binding {
+	return []key.Binding{v.keyMap.Close}
+}
+
+// FullHelp 实现 [help.KeyMap] 接口。
+func (v *ImageViewer) FullHelp() [][]key.Binding {
+	return [][]key.Binding{v.ShortHelp()}
+}
@@ -0,0 +1,293 @@
+package dialog
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"charm.land/bubbles/v2/help"
+	"charm.land/bubbles/v2/key"
+	"charm.land/bubbles/v2/textinput"
+	tea "charm.land/bubbletea/v2"
+	uv "github.com/charmbracelet/ultraviolet"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/purpose168/crush-cn/internal/home"
+	"github.com/purpose168/crush-cn/internal/log"
+	"github.com/purpose168/crush-cn/internal/ui/common"
+)
+
+// LogsID 是日志查看对话框的标识符。
+const LogsID = "logs"
+
+const (
+	// logsMaxEntries 是日志查看对话框保留的最大日志条目数，超出时丢弃最早的条目。
+	logsMaxEntries = 5000
+	// logsMaxWidth 是日志查看对话框的最大宽度，日志行较长，比其他对话框更宽。
+	logsMaxWidth = 140
+)
+
+// logLevels 是日志查看对话框可以切换的最低日志级别。
+var logLevels = []slog.Level{slog.LevelDebug, slog.LevelInfo, slog.LevelWarn, slog.LevelError}
+
+// Logs 是实时查看本次运行日志的对话框，支持按级别过滤和搜索。
+// 停留在底部时会跟随新写入的日志。
+type Logs struct {
+	com    *common.Common
+	help   help.Model
+	input  textinput.Model
+	path   string
+	offset int64 // 下次读取日志文件的偏移量
+	err    error
+
+	entries   []log.Entry
+	level     int      // logLevels 中的索引
+	lines     []string // 过滤并按 wrapWidth 换行后的日志行
+	wrapWidth int
+	scroll    int // 第一行可见行
+	height    int // 上次绘制时的可见行数
+	follow    bool
+
+	keyMap struct {
+		Next     key.Binding
+		Previous key.Binding
+		UpDown   key.Binding
+		PageDown key.Binding
+		PageUp   key.Binding
+		Level    key.Binding
+		Close    key.Binding
+	}
+}
+
+var _ Dialog = (*Logs)(nil)
+
+// NewLogs 创建一个新的 [Logs] 对话框，从 offset 开始读取日志文件 path。
+func NewLogs(com *common.Common, path string, offset int64) *Logs {
+	l := &Logs{
+		com:    com,
+		path:   path,
+		offset: offset,
+		follow: true,
+	}
+
+	l.help = help.New()
+	l.help.Styles = com.Styles.DialogHelpStyles()
+
+	l.input = textinput.New()
+	l.input.SetVirtualCursor(false)
+	l.input.Placeholder = "搜索日志"
+	l.input.SetStyles(com.Styles.TextInput)
+	l.input.Focus()
+
+	l.keyMap.Next = key.NewBinding(
+		key.WithKeys("down", "ctrl+j"),
+		key.WithHelp("↓", "向下滚动"),
+	)
+	l.keyMap.Previous = key.NewBinding(
+		key.WithKeys("up", "ctrl+k"),
+		key.WithHelp("↑", "向上滚动"),
+	)
+	l.keyMap.UpDown = key.NewBinding(
+		key.WithKeys("up", "down"),
+		key.WithHelp("↑↓", "滚动"),
+	)
+	l.keyMap.PageDown = key.NewBinding(
+		key.WithKeys("pgdown"),
+		key.WithHelp("pgdn", "下一页"),
+	)
+	l.keyMap.PageUp = key.NewBinding(
+		key.WithKeys("pgup"),
+		key.WithHelp("pgup", "上一页"),
+	)
+	l.keyMap.Level = key.NewBinding(
+		key.WithKeys("tab"),
+		key.WithHelp("tab", "级别"),
+	)
+	l.keyMap.Close = CloseKey
+
+	l.Refresh()
+	return l
+}
+
+// ID 实现 Dialog 接口。
+func (l *Logs) ID() string {
+	return LogsID
+}
+
+// Refresh 读取日志文件中新写入的日志。
+func (l *Logs) Refresh() {
+	entries, offset, err := log.ReadEntries(l.path, l.offset)
+	l.err = err
+	l.offset = offset
+	if len(entries) == 0 {
+		return
+	}
+	l.entries = append(l.entries, entries...)
+	if n := len(l.entries) - logsMaxEntries; n > 0 {
+		l.entries = l.entries[n:]
+	}
+	l.lines = nil
+	l.build(l.wrapWidth)
+}
+
+// HandleMsg 实现 Dialog 接口。
+func (l *Logs) HandleMsg(msg tea.Msg) Action {
+	keyMsg, ok := msg.(tea.KeyPressMsg)
+	if !ok {
+		return nil
+	}
+	switch {
+	case key.Matches(keyMsg, l.keyMap.Close):
+		return ActionClose{}
+	case key.Matches(keyMsg, l.keyMap.Previous):
+		l.scrollBy(-1)
+	case key.Matches(keyMsg, l.keyMap.Next):
+		l.scrollBy(1)
+	case key.Matches(keyMsg, l.keyMap.PageUp):
+		l.scrollBy(-max(1, l.height-1))
+	case key.Matches(keyMsg, l.keyMap.PageDown):
+		l.scrollBy(max(1, l.height-1))
+	case key.Matches(keyMsg, l.keyMap.Level):
+		l.level = (l.level + 1) % len(logLevels)
+		l.rebuild()
+	default:
+		before := l.input.Value()
+		var cmd tea.Cmd
+		l.input, cmd = l.input.Update(keyMsg)
+		if l.input.Value() != before {
+			l.rebuild()
+		}
+		return ActionCmd{cmd}
+	}
+	return nil
+}
+
+// rebuild 在过滤条件变化后重新生成日志行并滚动到底部。
+func (l *Logs) rebuild() {
+	l.lines = nil
+	l.build(l.wrapWidth)
+	l.follow = true
+	l.scroll = l.maxScroll()
+}
+
+func (l *Logs) scrollBy(n int) {
+	l.scroll = min(max(0, l.scroll+n), l.maxScroll())
+	l.follow = l.scroll >= l.maxScroll()
+}
+
+func (l *Logs) maxScroll() int {
+	return max(0, len(l.lines)-l.height)
+}
+
+// matches 报告日志条目是否满足级别和搜索条件，搜索不区分大小写。
+func (l *Logs) matches(e log.Entry) bool {
+	if e.Level < logLevels[l.level] {
+		return false
+	}
+	query := strings.ToLower(strings.TrimSpace(l.input.Value()))
+	return query == "" || strings.Contains(strings.ToLower(e.String()), query) ||
+		strings.Contains(strings.ToLower(e.Source), query)
+}
+
+// build 过滤日志条目并按宽度换行，宽度不变且已生成时不做任何事。
+func (l *Logs) build(width int) {
+	if width <= 0 || (width == l.wrapWidth && l.lines != nil) {
+		return
+	}
+	l.wrapWidth = width
+	l.lines = []string{}
+	for _, e := range l.entries {
+		if !l.matches(e) {
+			continue
+		}
+		wrapped := ansi.Wrap(l.renderEntry(e), width, "")
+		l.lines = append(l.lines, strings.Split(wrapped, "\n")...)
+	}
+	if l.follow {
+		l.scroll = l.maxScroll()
+	}
+}
+
+// renderEntry 渲染一条日志：时间、按级别着色的级别和消息内容。
+func (l *Logs) renderEntry(e log.Entry) string {
+	t := l.com.Styles
+	levelStyle := t.Subtle
+	switch {
+	case e.Level >= slog.LevelError:
+		levelStyle = t.Base.Foreground(t.Error)
+	case e.Level >= slog.LevelWarn:
+		levelStyle = t.Base.Foreground(t.Warning)
+	case e.Level >= slog.LevelInfo:
+		levelStyle = t.Base.Foreground(t.Info)
+	}
+	return fmt.Sprintf("%s %s %s",
+		t.Subtle.Render(e.Time.Local().Format("15:04:05")),
+		levelStyle.Render(fmt.Sprintf("%-5s", e.Level)),
+		t.Base.Render(strings.ReplaceAll(e.String(), "\n", " ")),
+	)
+}
+
+// summary 返回级别过滤条件、匹配的条目数和日志文件路径。
+func (l *Logs) summary() string {
+	if l.err != nil {
+		return "读取日志失败: " + l.err.Error()
+	}
+	var count int
+	for _, e := range l.entries {
+		if l.matches(e) {
+			count++
+		}
+	}
+	return fmt.Sprintf("%s 及以上 · %d 条 · %s", logLevels[l.level], count, home.Short(l.path))
+}
+
+// Cursor 返回相对于对话框的光标位置。
+func (l *Logs) Cursor() *tea.Cursor {
+	return InputCursor(l.com.Styles, l.input.Cursor())
+}
+
+// Draw 实现 [Dialog] 接口。
+func (l *Logs) Draw(scr uv.Screen, area uv.Rectangle) *tea.Cursor {
+	t := l.com.Styles
+	width := max(0, min(logsMaxWidth, area.Dx()*9/10))
+	height := max(0, area.Dy()*3/4)
+	innerWidth := width - t.Dialog.View.GetHorizontalFrameSize() - 2
+	heightOffset := t.Dialog.Title.GetVerticalFrameSize() + titleContentHeight +
+		t.Dialog.InputPrompt.GetVerticalFrameSize() + inputContentHeight +
+		1 + // 摘要行
+		t.Dialog.HelpView.GetVerticalFrameSize() +
+		t.Dialog.View.GetVerticalFrameSize()
+	l.input.SetWidth(max(0, innerWidth-t.Dialog.InputPrompt.GetHorizontalFrameSize()-1)) // (1) 光标填充
+	l.help.SetWidth(innerWidth)
+	l.height = max(1, height-heightOffset)
+	l.build(max(1, innerWidth))
+	if l.follow {
+		l.scroll = l.maxScroll()
+	}
+	l.scroll = min(l.scroll, l.maxScroll())
+
+	rc := NewRenderContext(t, width)
+	rc.Title = "日志"
+	rc.AddPart(t.Dialog.InputPrompt.Render(l.input.View()))
+	rc.AddPart(t.Subtle.Render(ansi.Truncate(l.summary(), innerWidth, "…")))
+
+	body := t.Subtle.Render("没有日志")
+	if len(l.lines) > 0 {
+		body = strings.Join(l.lines[l.scroll:min(len(l.lines), l.scroll+l.height)], "\n")
+	}
+	rc.AddPart(t.Dialog.List.Height(l.height).Render(body))
+	rc.Help = l.help.View(l)
+
+	cur := l.Cursor()
+	DrawCenterCursor(scr, area, rc.Render(), cur)
+	return cur
+}
+
+// ShortHelp 实现 [help.KeyMap] 接口。
+func (l *Logs) ShortHelp() []key.Binding {
+	return []key.Binding{l.keyMap.UpDown, l.keyMap.PageDown, l.keyMap.Level, l.keyMap.Close}
+}
+
+// FullHelp 实现 [help.KeyMap] 接口。
+func (l *Logs) FullHelp() [][]key.Binding {
+	return [][]key.Binding{l.ShortHelp()}
+}
@@ -0,0 +1,354 @@
+package dialog
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"charm.land/bubbles/v2/help"
+	"charm.land/bubbles/v2/key"
+	tea "charm.land/bubbletea/v2"
+	uv "github.com/charmbracelet/ultraviolet"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/dustin/go-humanize"
+	"github.com/purpose168/crush-cn/internal/app"
+	"github.com/purpose168/crush-cn/internal/lsp"
+	"github.com/purpose168/crush-cn/internal/ui/common"
+	"github.com/purpose168/crush-cn/internal/ui/list"
+	"github.com/purpose168/crush-cn/internal/ui/styles"
+	"github.com/purpose168/crush-cn/internal/ui/util"
+)
+
+const (
+	// LSPServersID 是管理语言服务器对话框的标识符。
+	LSPServersID = "lsp_servers"
+	// lspServersMaxItems 是列表最多同时显示的服务器数。
+	lspServersMaxItems = 10
+	// lspServersDetailHeight 是标准错误输出和初始化选项视图的最大行数。
+	lspServersDetailHeight = 20
+	// lspServersMaxWidth 是对话框的最大宽度，标准错误输出的行较长，比其他对话框更宽。
+	lspServersMaxWidth = 120
+)
+
+// LSPServers 是列出语言服务器的状态、PID 和内存占用的对话框，可以重启或停止服务器，
+// 查看服务器的标准错误输出和初始化选项。
+type LSPServers struct {
+	com     *common.Common
+	help    help.Model
+	list    *list.List
+	servers []app.LSPClientInfo
+
+	// 详情视图，detailTitle 为空时显示服务器列表
+	detailTitle string
+	detail      []string
+	scroll      int
+
+	keyMap struct {
+		Next        key.Binding
+		Previous    key.Binding
+		UpDown      key.Binding
+		Restart     key.Binding
+		Stop        key.Binding
+		Stderr      key.Binding
+		InitOptions key.Binding
+		Back        key.Binding
+		Close       key.Binding
+	}
+}
+
+var _ Dialog = (*LSPServers)(nil)
+
+// NewLSPServers 创建一个新的 [LSPServers] 对话框。
+func NewLSPServers(com *common.Common) *LSPServers {
+	l := &LSPServers{com: com}
+
+	l.help = help.New()
+	l.help.Styles = com.Styles.DialogHelpStyles()
+
+	l.list = list.NewList()
+	l.list.Focus()
+
+	l.keyMap.Next = key.NewBinding(
+		key.WithKeys("down", "ctrl+n"),
+		key.WithHelp("↓", "下一项"),
+	)
+	l.keyMap.Previous = key.NewBinding(
+		key.WithKeys("up", "ctrl+p"),
+		key.WithHelp("↑", "上一项"),
+	)
+	l.keyMap.UpDown = key.NewBinding(
+		key.WithKeys("up", "down"),
+		key.WithHelp("↑↓", "选择"),
+	)
+	l.keyMap.Restart = key.NewBinding(
+		key.WithKeys("r"),
+		key.WithHelp("r", "重启"),
+	)
+	l.keyMap.Stop = key.NewBinding(
+		key.WithKeys("s"),
+		key.WithHelp("s", "停止"),
+	)
+	l.keyMap.Stderr = key.NewBinding(
+		key.WithKeys("e"),
+		key.WithHelp("e", "错误输出"),
+	)
+	l.keyMap.InitOptions = key.NewBinding(
+		key.WithKeys("i"),
+		key.WithHelp("i", "初始化选项"),
+	)
+	l.keyMap.Back = key.NewBinding(
+		key.WithKeys("esc"),
+		key.WithHelp("esc", "返回"),
+	)
+	l.keyMap.Close = CloseKey
+
+	l.Refresh()
+	return l
+}
+
+// ID 实现 Dialog 接口。
+func (l *LSPServers) ID() string {
+	return LSPServersID
+}
+
+// Refresh 重新读取语言服务器的状态和进程信息，保持当前选中的服务器。
+func (l *LSPServers) Refresh() {
+	selected := l.selected()
+	l.servers = slices.SortedFunc(maps.Values(app.GetLSPStates()), func(a, b app.LSPClientInfo) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	items := make([]list.Item, len(l.servers))
+	index := 0
+	for i, server := range l.servers {
+		items[i] = &LSPServerItem{
+			title: server.Name,
+			info:  lspServerSummary(server),
+			t:     l.com.Styles,
+		}
+		if server.Name == selected.Name {
+			index = i
+		}
+	}
+	l.list.SetItems(items...)
+	l.list.SetSelected(index)
+}
+
+// selected 返回当前选中的服务器。
+func (l *LSPServers) selected() app.LSPClientInfo {
+	idx := l.list.Selected()
+	if idx < 0 || idx >= len(l.servers) {
+		return app.LSPClientInfo{}
+	}
+	return l.servers[idx]
+}
+
+// lspServerSummary 返回服务器的状态、PID 和内存占用。
+func lspServerSummary(server app.LSPClientInfo) string {
+	parts := []string{lspStateText(server.State)}
+	if server.Client != nil && (server.State == lsp.StateReady || server.State == lsp.StateError) {
+		if proc, ok := server.Client.Process(); ok {
+			parts = append(parts, fmt.Sprintf("PID %d", proc.PID), humanize.Bytes(proc.Memory))
+		}
+	}
+	return strings.Join(parts, " · ")
+}
+
+// lspStateText 返回服务器状态的描述。
+func lspStateText(state lsp.ServerState) string {
+	switch state {
+	case lsp.StateStopped:
+		return "已停止"
+	case lsp.StateStarting:
+		return "启动中"
+	case lsp.StateReady:
+		return "就绪"
+	case lsp.StateError:
+		return "错误"
+	case lsp.StateDisabled:
+		return "已禁用"
+	}
+	return "未知"
+}
+
+// HandleMsg 实现 Dialog 接口。
+func (l *LSPServers) HandleMsg(msg tea.Msg) Action {
+	keyMsg, ok := msg.(tea.KeyPressMsg)
+	if !ok {
+		return nil
+	}
+	if l.detailTitle != "" {
+		switch {
+		case key.Matches(keyMsg, l.keyMap.Back):
+			l.detailTitle = ""
+			l.detail = nil
+		case key.Matches(keyMsg, l.keyMap.Previous):
+			l.scroll = max(0, l.scroll-1)
+		case key.Matches(keyMsg, l.keyMap.Next):
+			l.scroll = min(l.scroll+1, max(0, len(l.detail)-lspServersDetailHeight))
+		}
+		return nil
+	}
+
+	switch {
+	case key.Matches(keyMsg, l.keyMap.Close):
+		return ActionClose{}
+	case key.Matches(keyMsg, l.keyMap.Previous):
+		if l.list.IsSelectedFirst() {
+			l.list.SelectLast()
+			break
+		}
+		l.list.SelectPrev()
+	case key.Matches(keyMsg, l.keyMap.Next):
+		if l.list.IsSelectedLast() {
+			l.list.SelectFirst()
+			break
+		}
+		l.list.SelectNext()
+	case key.Matches(keyMsg, l.keyMap.Restart):
+		if server := l.selected(); server.Client != nil {
+			return ActionRestartLSP{Name: server.Name}
+		}
+	case key.Matches(keyMsg, l.keyMap.Stop):
+		if server := l.selected(); server.Client != nil && server.State != lsp.StateStopped {
+			return ActionStopLSP{Name: server.Name}
+		}
+	case key.Matches(keyMsg, l.keyMap.Stderr):
+		if server := l.selected(); server.Client != nil {
+			stderr, err := server.Client.Stderr()
+			if err != nil {
+				return ActionCmd{util.ReportError(err)}
+			}
+			if stderr == "" {
+				stderr = "没有标准错误输出"
+			}
+			l.showDetail(server.Name+" 标准错误输出", stderr, true)
+		}
+	case key.Matches(keyMsg, l.keyMap.InitOptions):
+		if server := l.selected(); server.Client != nil {
+			l.showDetail(server.Name+" 初始化选项", lspInitOptions(server.Client.Config().InitOptions, server.Client.Config().Options), false)
+		}
+	}
+	return nil
+}
+
+// showDetail 显示详情视图，tail 为 true 时滚动到末尾。
+func (l *LSPServers) showDetail(title, content string, tail bool) {
+	l.detailTitle = title
+	l.detail = strings.Split(strings.TrimRight(strings.ReplaceAll(ansi.Strip(content), "\t", "    "), "\n"), "\n")
+	l.scroll = 0
+	if tail {
+		l.scroll = max(0, len(l.detail)-lspServersDetailHeight)
+	}
+}
+
+// lspInitOptions 以 JSON 格式返回服务器的初始化选项和设置。
+func lspInitOptions(initOptions, settings map[string]any) string {
+	format := func(v map[string]any) string {
+		if len(v) == 0 {
+			return "无"
+		}
+		data, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			return err.Error()
+		}
+		return string(data)
+	}
+	return fmt.Sprintf("initializationOptions:\n%s\n\nsettings:\n%s", format(initOptions), format(settings))
+}
+
+// Draw 实现 [Dialog] 接口。
+func (l *LSPServers) Draw(scr uv.Screen, area uv.Rectangle) *tea.Cursor {
+	t := l.com.Styles
+	width := max(0, min(lspServersMaxWidth, area.Dx()*9/10))
+	innerWidth := width - t.Dialog.View.GetHorizontalFrameSize() - 2
+	l.help.SetWidth(innerWidth)
+
+	rc := NewRenderContext(t, width)
+	switch {
+	case l.detailTitle != "":
+		rc.Title = l.detailTitle
+		end := min(len(l.detail), l.scroll+lspServersDetailHeight)
+		lines := make([]string, 0, end-l.scroll)
+		for _, line := range l.detail[l.scroll:end] {
+			lines = append(lines, ansi.Truncate(line, innerWidth, "…"))
+		}
+		rc.AddPart(t.Base.Render(strings.Join(lines, "\n")))
+	case len(l.servers) == 0:
+		rc.Title = "语言服务器"
+		rc.AddPart(t.Subtle.Render("没有已启动的语言服务器"))
+	default:
+		rc.Title = "语言服务器"
+		l.list.SetSize(innerWidth, min(len(l.servers), lspServersMaxItems))
+		rc.AddPart(t.Dialog.List.Height(l.list.Height()).Render(l.list.Render()))
+		if server := l.selected(); server.Client != nil {
+			command := ansi.Truncate(strings.Join(server.Client.CommandLine(), " "), innerWidth, "…")
+			rc.AddPart(t.Subtle.Render(command))
+		}
+		if server := l.selected(); server.Error != nil {
+			rc.AddPart(t.Subtle.Render(ansi.Truncate("错误: "+server.Error.Error(), innerWidth, "…")))
+		}
+	}
+	rc.Help = l.help.View(l)
+
+	DrawCenter(scr, area, rc.Render())
+	return nil
+}
+
+// ShortHelp 实现 [help.KeyMap] 接口。
+func (l *LSPServers) ShortHelp() []key.Binding {
+	if l.detailTitle != "" {
+		return []key.Binding{l.keyMap.UpDown, l.keyMap.Back}
+	}
+	return []key.Binding{
+		l.keyMap.UpDown,
+		l.keyMap.Restart,
+		l.keyMap.Stop,
+		l.keyMap.Stderr,
+		l.keyMap.InitOptions,
+		l.keyMap.Close,
+	}
+}
+
+// FullHelp 实现 [help.KeyMap] 接口。
+func (l *LSPServers) FullHelp() [][]key.Binding {
+	return [][]key.Binding{l.ShortHelp()}
+}
+
+// LSPServerItem 表示语言服务器对话框中的单个服务器。
+type LSPServerItem struct {
+	title   string
+	info    string
+	t       *styles.Styles
+	cache   map[int]string
+	focused bool
+}
+
+var (
+	_ list.Item      = (*LSPServerItem)(nil)
+	_ list.Focusable = (*LSPServerItem)(nil)
+)
+
+// SetFocused 设置服务器项目的焦点状态。
+func (s *LSPServerItem) SetFocused(focused bool) {
+	if s.focused != focused {
+		s.cache = nil
+	}
+	s.focused = focused
+}
+
+// Render 返回服务器项目的字符串表示。
+func (s *LSPServerItem) Render(width int) string {
+	if s.cache == nil {
+		s.cache = make(map[int]string)
+	}
+	itemStyles := ListItemStyles{
+		ItemBlurred:     s.t.Dialog.NormalItem,
+		ItemFocused:     s.t.Dialog.SelectedItem,
+		InfoTextBlurred: s.t.Subtle,
+		InfoTextFocused: s.t.Base,
+	}
+	return renderItem(itemStyles, s.title, s.info, s.focused, width, s.cache, nil)
+}
@@ -0,0 +1,377 @@
+package dialog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"charm.land/bubbles/v2/help"
+	"charm.land/bubbles/v2/key"
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+	uv "github.com/charmbracelet/ultraviolet"
+	"github.com/purpose168/crush-cn/internal/config"
+	"github.com/purpose168/crush-cn/internal/lsp"
+	"github.com/purpose168/crush-cn/internal/ui/common"
+	"github.com/purpose168/crush-cn/internal/ui/list"
+	"github.com/purpose168/crush-cn/internal/ui/styles"
+	"github.com/purpose168/crush-cn/internal/ui/util"
+)
+
+const (
+	// LSPSetupID 是 LSP 配置对话框的标识符。
+	LSPSetupID = "lsp_setup"
+	// lspInstallTimeout 是安装单个 LSP 服务器的最长时间。
+	lspInstallTimeout = 5 * time.Minute
+)
+
+// lspSetupState 是单个建议的 LSP 服务器的处理状态。
+type lspSetupState uint8
+
+const (
+	lspSetupPending lspSetupState = iota
+	lspSetupInstalling
+	lspSetupEnabled
+	lspSetupSkipped
+	lspSetupFailed
+)
+
+// lspInstallResultMsg 在 LSP 服务器安装完成时发送。
+type lspInstallResultMsg struct {
+	name string
+	err  error
+}
+
+// LSPSetup 是一个根据项目语言建议 LSP 服务器的对话框，可以逐个安装、启用或跳过，
+// 结果写入 lsp 配置。
+type LSPSetup struct {
+	com   *common.Common
+	help  help.Model
+	list  *list.List
+	items []*LSPSetupItem
+
+	keyMap struct {
+		Next     key.Binding
+		Previous key.Binding
+		UpDown   key.Binding
+		Enable   key.Binding
+		Skip     key.Binding
+		Close    key.Binding
+	}
+}
+
+var _ Dialog = (*LSPSetup)(nil)
+
+// DetectLSPSuggestions 返回当前项目可配置的 LSP 服务器建议。
+func DetectLSPSuggestions(cfg *config.Config) []lsp.Suggestion {
+	return lsp.DetectServers(cfg.WorkingDir(), cfg.LSP)
+}
+
+// NewLSPSetup 创建一个新的 [LSPSetup] 对话框。
+func NewLSPSetup(com *common.Common) (*LSPSetup, error) {
+	suggestions := DetectLSPSuggestions(com.Config())
+	if len(suggestions) == 0 {
+		return nil, errors.New("未检测到需要配置的 LSP 服务器")
+	}
+
+	d := &LSPSetup{com: com}
+
+	help := help.New()
+	help.Styles = com.Styles.DialogHelpStyles()
+	d.help = help
+
+	d.list = list.NewList()
+	d.list.Focus()
+
+	d.keyMap.Next = key.NewBinding(
+		key.WithKeys("down", "ctrl+n"),
+		key.WithHelp("↓", "下一项"),
+	)
+	d.keyMap.Previous = key.NewBinding(
+		key.WithKeys("up", "ctrl+p"),
+		key.WithHelp("↑", "上一项"),
+	)
+	d.keyMap.UpDown = key.NewBinding(
+		key.WithKeys("up", "down"),
+		key.WithHelp("↑↓", "选择"),
+	)
+	d.keyMap.Enable = key.NewBinding(
+		key.WithKeys("enter", "ctrl+y"),
+		key.WithHelp("enter", "安装/启用"),
+	)
+	d.keyMap.Skip = key.NewBinding(
+		key.WithKeys("s", "ctrl+x"),
+		key.WithHelp("s", "跳过"),
+	)
+	d.keyMap.Close = CloseKey
+
+	items := make([]list.Item, len(suggestions))
+	for i, s := range suggestions {
+		item := &LSPSetupItem{suggestion: s, t: com.Styles}
+		d.items = append(d.items, item)
+		items[i] = item
+	}
+	d.list.SetItems(items...)
+	d.list.SetSelected(0)
+
+	return d, nil
+}
+
+// ID 实现 Dialog 接口。
+func (d *LSPSetup) ID() string {
+	return LSPSetupID
+}
+
+// HandleMsg 实现 Dialog 接口。
+func (d *LSPSetup) HandleMsg(msg tea.Msg) Action {
+	switch msg := msg.(type) {
+	case lspInstallResultMsg:
+		return d.installed(msg)
+	case tea.KeyPressMsg:
+		switch {
+		case key.Matches(msg, d.keyMap.Close):
+			return ActionClose{}
+		case key.Matches(msg, d.keyMap.Previous):
+			if d.list.IsSelectedFirst() {
+				d.list.SelectLast()
+				d.list.ScrollToBottom()
+				break
+			}
+			d.list.SelectPrev()
+			d.list.ScrollToSelected()
+		case key.Matches(msg, d.keyMap.Next):
+			if d.list.IsSelectedLast() {
+				d.list.SelectFirst()
+				d.list.ScrollToTop()
+				break
+			}
+			d.list.SelectNext()
+			d.list.ScrollToSelected()
+		case key.Matches(msg, d.keyMap.Enable):
+			return d.enableSelected()
+		case key.Matches(msg, d.keyMap.Skip):
+			return d.skipSelected()
+		}
+	}
+	return nil
+}
+
+// selectedItem 返回当前选中的项目。
+func (d *LSPSetup) selectedItem() *LSPSetupItem {
+	idx := d.list.Selected()
+	if idx < 0 || idx >= len(d.items) {
+		return nil
+	}
+	return d.items[idx]
+}
+
+// enableSelected 启用选中的 LSP 服务器，未安装时先安装。
+func (d *LSPSetup) enableSelected() Action {
+	item := d.selectedItem()
+	if item == nil || item.state == lspSetupInstalling || item.state == lspSetupEnabled {
+		return nil
+	}
+	s := item.suggestion
+	if s.Installed {
+		return d.enable(item)
+	}
+	if !s.CanInstall() {
+		if cmd := s.InstallCommand(); cmd != "" {
+			return ActionCmd{util.ReportWarn(fmt.Sprintf("无法自动安装 %s，请手动运行: %s", s.Name, cmd))}
+		}
+		return ActionCmd{util.ReportWarn(fmt.Sprintf("请手动安装 %s 后重试", s.Name))}
+	}
+
+	item.setState(lspSetupInstalling)
+	return ActionCmd{func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), lspInstallTimeout)
+		defer cancel()
+		return lspInstallResultMsg{name: s.Name, err: s.Install(ctx)}
+	}}
+}
+
+// installed 处理安装结果，成功时启用服务器。
+func (d *LSPSetup) installed(msg lspInstallResultMsg) Action {
+	for _, item := range d.items {
+		if item.suggestion.Name != msg.name {
+			continue
+		}
+		if msg.err != nil {
+			item.setState(lspSetupFailed)
+			return ActionCmd{util.ReportError(msg.err)}
+		}
+		item.suggestion.Installed = true
+		return d.enable(item)
+	}
+	return nil
+}
+
+// enable 将服务器写入 lsp 配置并立即生效。
+func (d *LSPSetup) enable(item *LSPSetupItem) Action {
+	s := item.suggestion
+	if err := d.configure(s.Name, s.Config()); err != nil {
+		return ActionCmd{util.ReportError(err)}
+	}
+	item.setState(lspSetupEnabled)
+	return d.next(util.ReportInfo(fmt.Sprintf("已启用 %s", s.Name)))
+}
+
+// skipSelected 在 lsp 配置中禁用选中的服务器，使其不再被建议或自动启动。
+func (d *LSPSetup) skipSelected() Action {
+	item := d.selectedItem()
+	if item == nil || item.state == lspSetupInstalling || item.state == lspSetupEnabled {
+		return nil
+	}
+	if err := d.configure(item.suggestion.Name, config.LSPConfig{Disabled: true}); err != nil {
+		return ActionCmd{util.ReportError(err)}
+	}
+	item.setState(lspSetupSkipped)
+	return d.next(nil)
+}
+
+// configure 持久化 LSP 配置并通知 LSP 管理器。
+func (d *LSPSetup) configure(name string, cfg config.LSPConfig) error {
+	if err := d.com.Config().SetLSP(name, cfg); err != nil {
+		return fmt.Errorf("保存 LSP 配置失败: %w", err)
+	}
+	if d.com.App.LSPManager != nil {
+		d.com.App.LSPManager.Configure(name, cfg)
+	}
+	return nil
+}
+
+// next 选中下一个待处理的项目，并返回 cmd（如果有）。
+func (d *LSPSetup) next(cmd tea.Cmd) Action {
+	for i, item := range d.items {
+		if item.state == lspSetupPending || item.state == lspSetupFailed {
+			d.list.SetSelected(i)
+			d.list.ScrollToSelected()
+			break
+		}
+	}
+	if cmd == nil {
+		return nil
+	}
+	return ActionCmd{cmd}
+}
+
+// Draw 实现 [Dialog] 接口。
+func (d *LSPSetup) Draw(scr uv.Screen, area uv.Rectangle) *tea.Cursor {
+	t := d.com.Styles
+	width := max(0, min(defaultDialogMaxWidth, area.Dx()))
+	height := max(0, min(defaultDialogHeight, area.Dy()))
+	innerWidth := width - t.Dialog.View.GetHorizontalFrameSize() - 2
+	heightOffset := t.Dialog.Title.GetVerticalFrameSize() + titleContentHeight +
+		t.Dialog.HelpView.GetVerticalFrameSize() +
+		t.Dialog.View.GetVerticalFrameSize()
+
+	rc := NewRenderContext(t, width)
+	rc.Title = "配置 LSP"
+
+	desc := t.Subtle.Width(innerWidth).Render("LSP 为代理提供诊断和引用信息。根据项目文件检测到以下语言：")
+	var detail string
+	if item := d.selectedItem(); item != nil {
+		detail = "命令: " + item.suggestion.Command()
+		if !item.suggestion.Installed {
+			if cmd := item.suggestion.InstallCommand(); cmd != "" {
+				detail += "\n安装: " + cmd
+			} else {
+				detail += "\n需要手动安装"
+			}
+		}
+		detail = t.Subtle.Width(innerWidth).Render(detail)
+	}
+	heightOffset += lipgloss.Height(desc) + lipgloss.Height(detail)
+
+	d.list.SetSize(innerWidth, max(0, min(len(d.items), height-heightOffset)))
+	d.help.SetWidth(innerWidth)
+
+	rc.AddPart(desc)
+	rc.AddPart(t.Dialog.List.Height(d.list.Height()).Render(d.list.Render()))
+	if detail != "" {
+		rc.AddPart(detail)
+	}
+	rc.Help = d.help.View(d)
+
+	view := rc.Render()
+
+	DrawCenter(scr, area, view)
+	return nil
+}
+
+// ShortHelp 实现 [help.KeyMap] 接口。
+func (d *LSPSetup) ShortHelp() []key.Binding {
+	return []key.Binding{
+		d.keyMap.UpDown,
+		d.keyMap.Enable,
+		d.keyMap.Skip,
+		d.keyMap.Close,
+	}
+}
+
+// FullHelp 实现 [help.KeyMap] 接口。
+func (d *LSPSetup) FullHelp() [][]key.Binding {
+	return [][]key.Binding{d.ShortHelp()}
+}
+
+// LSPSetupItem 表示 LSP 配置对话框中的单个建议服务器。
+type LSPSetupItem struct {
+	suggestion lsp.Suggestion
+	state      lspSetupState
+	t          *styles.Styles
+	cache      map[int]string
+	focused    bool
+}
+
+var (
+	_ list.Item      = (*LSPSetupItem)(nil)
+	_ list.Focusable = (*LSPSetupItem)(nil)
+)
+
+// setState 更新项目状态并清除渲染缓存。
+func (i *LSPSetupItem) setState(state lspSetupState) {
+	i.state = state
+	i.cache = nil
+}
+
+// SetFocused 设置项目的焦点状态。
+func (i *LSPSetupItem) SetFocused(focused bool) {
+	if i.focused != focused {
+		i.cache = nil
+	}
+	i.focused = focused
+}
+
+// status 返回项目的状态文本。
+func (i *LSPSetupItem) status() string {
+	switch i.state {
+	case lspSetupInstalling:
+		return "安装中…"
+	case lspSetupEnabled:
+		return "已启用"
+	case lspSetupSkipped:
+		return "已跳过"
+	case lspSetupFailed:
+		return "安装失败"
+	}
+	if i.suggestion.Installed {
+		return "已安装"
+	}
+	return "未安装"
+}
+
+// Render 返回项目的字符串表示。
+func (i *LSPSetupItem) Render(width int) string {
+	if i.cache == nil {
+		i.cache = make(map[int]string)
+	}
+	styles := ListItemStyles{
+		ItemBlurred:     i.t.Dialog.NormalItem,
+		ItemFocused:     i.t.Dialog.SelectedItem,
+		InfoTextBlurred: i.t.Subtle,
+		InfoTextFocused: i.t.Base,
+	}
+	title := fmt.Sprintf("%s · %s", i.suggestion.Language, i.suggestion.Name)
+	return renderItem(styles, title, i.status(), i.focused, width, i.cache, nil)
+}
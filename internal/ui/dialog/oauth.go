@@ -26,6 +26,11 @@ type OAuthProvider interface {
 	stopPolling() tea.Msg
 }
 
+// oauthTokenSaver 由不属于模型提供者的 OAuth 流程实现，用于自行保存令牌并决定完成后的操作。
+type oauthTokenSaver interface {
+	saveToken(token *oauth.Token) Action
+}
+
 // OAuthState 表示设备流程的当前状态。
 type OAuthState int
 
@@ -253,6 +258,10 @@ func (m *OAuth) innerDialogContent() string {
 			)
 
 	case OAuthStateDisplay:
+		if m.userCode == "" {
+			return m.browserContent()
+		}
+
 		instructions := lipgloss.NewStyle().
 			Margin(0, 1).
 			Width(m.width - 2).
@@ -318,6 +327,51 @@ func (m *OAuth) innerDialogContent() string {
 	}
 }
 
+// browserContent 渲染不需要输入代码的浏览器授权流程。
+func (m *OAuth) browserContent() string {
+	var (
+		t            = m.com.Styles
+		whiteStyle   = lipgloss.NewStyle().Foreground(t.White)
+		primaryStyle = lipgloss.NewStyle().Foreground(t.Primary)
+		greenStyle   = lipgloss.NewStyle().Foreground(t.GreenLight)
+		linkStyle    = lipgloss.NewStyle().Foreground(t.GreenDark).Underline(true)
+		mutedStyle   = lipgloss.NewStyle().Foreground(t.FgMuted)
+	)
+
+	instructions := lipgloss.NewStyle().
+		Margin(0, 1).
+		Width(m.width - 2).
+		Render(
+			whiteStyle.Render("按 ") +
+				primaryStyle.Render("enter") +
+				whiteStyle.Render(" 在浏览器中打开授权页面，授权后会自动返回。"),
+		)
+
+	link := linkStyle.Hyperlink(m.verificationURL, "id=oauth-verify").Render(m.verificationURL)
+	url := mutedStyle.
+		Margin(0, 1).
+		Width(m.width - 2).
+		Render("浏览器没有打开？请访问\n" + link)
+
+	waiting := lipgloss.NewStyle().
+		Margin(0, 1).
+		Width(m.width - 2).
+		Render(
+			greenStyle.Render(m.spinner.View()) + mutedStyle.Render("正在等待授权..."),
+		)
+
+	return lipgloss.JoinVertical(
+		lipgloss.Left,
+		"",
+		instructions,
+		"",
+		url,
+		"",
+		waiting,
+		"",
+	)
+}
+
 // FullHelp 返回完整的帮助视图。
 func (m *OAuth) FullHelp() [][]key.Binding {
 	return [][]key.Binding{m.ShortHelp()}
@@ -338,6 +392,13 @@ func (m *OAuth) ShortHelp() []key.Binding {
 		}
 
 	default:
+		if m.State == OAuthStateDisplay && m.userCode == "" {
+			return []key.Binding{
+				key.NewBinding(key.WithKeys("c"), key.WithHelp("c", "复制链接")),
+				key.NewBinding(key.WithKeys("enter", "ctrl+y"), key.WithHelp("enter", "打开浏览器")),
+				m.keyMap.Close,
+			}
+		}
 		return []key.Binding{
 			m.keyMap.Copy,
 			m.keyMap.Submit,
@@ -350,6 +411,12 @@ func (d *OAuth) copyCode() tea.Cmd {
 	if d.State != OAuthStateDisplay {
 		return nil
 	}
+	if d.userCode == "" {
+		return tea.Sequence(
+			tea.SetClipboard(d.verificationURL),
+			util.ReportInfo("链接已复制到剪贴板"),
+		)
+	}
 	return tea.Sequence(
 		tea.SetClipboard(d.userCode),
 		util.ReportInfo("代码已复制到剪贴板"),
@@ -360,6 +427,14 @@ func (d *OAuth) copyCodeAndOpenURL() tea.Cmd {
 	if d.State != OAuthStateDisplay {
 		return nil
 	}
+	if d.userCode == "" {
+		return func() tea.Msg {
+			if err := browser.OpenURL(d.verificationURL); err != nil {
+				return ActionOAuthErrored{fmt.Errorf("无法打开浏览器: %w", err)}
+			}
+			return nil
+		}
+	}
 	return tea.Sequence(
 		tea.SetClipboard(d.userCode),
 		func() tea.Msg {
@@ -373,6 +448,10 @@ func (d *OAuth) copyCodeAndOpenURL() tea.Cmd {
 }
 
 func (m *OAuth) saveKeyAndContinue() Action {
+	if saver, ok := m.oAuthProvider.(oauthTokenSaver); ok {
+		return saver.saveToken(m.token)
+	}
+
 	cfg := m.com.Config()
 
 	err := cfg.SetProviderAPIKey(string(m.provider.ID), m.token)
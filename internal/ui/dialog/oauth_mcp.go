@@ -0,0 +1,90 @@
+package dialog
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	tea "charm.land/bubbletea/v2"
+	"charm.land/catwalk/pkg/catwalk"
+	"github.com/purpose168/crush-cn/internal/agent/tools/mcp"
+	"github.com/purpose168/crush-cn/internal/config"
+	"github.com/purpose168/crush-cn/internal/oauth"
+	"github.com/purpose168/crush-cn/internal/ui/common"
+	"github.com/purpose168/crush-cn/internal/ui/util"
+)
+
+// NewOAuthMCP 创建 MCP 服务器的 OAuth 认证对话框。
+func NewOAuthMCP(com *common.Common, server string) (*OAuth, tea.Cmd) {
+	return newOAuth(com, false, catwalk.Provider{}, config.SelectedModel{}, "", &OAuthMCP{
+		server: server,
+		cfg:    com.Config(),
+	})
+}
+
+// OAuthMCP 通过 MCP 服务器的授权服务器进行认证，支持设备码流程和浏览器流程。
+type OAuthMCP struct {
+	server     string
+	cfg        *config.Config
+	auth       *mcp.Authorization
+	cancelFunc func()
+}
+
+var (
+	_ OAuthProvider   = (*OAuthMCP)(nil)
+	_ oauthTokenSaver = (*OAuthMCP)(nil)
+)
+
+func (m *OAuthMCP) name() string {
+	return fmt.Sprintf("MCP 服务器 %s", m.server)
+}
+
+func (m *OAuthMCP) initiateAuth() tea.Msg {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	auth, err := mcp.Authorize(ctx, m.cfg, m.server)
+	if err != nil {
+		return ActionOAuthErrored{Error: fmt.Errorf("无法启动认证: %w", err)}
+	}
+
+	m.auth = auth
+
+	return ActionInitiateOAuth{
+		UserCode:        auth.UserCode,
+		VerificationURL: auth.VerificationURL,
+		ExpiresIn:       auth.ExpiresIn,
+		Interval:        auth.Interval,
+	}
+}
+
+func (m *OAuthMCP) startPolling(deviceCode string, expiresIn int) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithCancel(context.Background())
+		m.cancelFunc = cancel
+
+		token, err := m.auth.Wait(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil // 已取消，不报告错误。
+			}
+			return ActionOAuthErrored{Error: err}
+		}
+
+		return ActionCompleteOAuth{Token: token}
+	}
+}
+
+func (m *OAuthMCP) stopPolling() tea.Msg {
+	if m.cancelFunc != nil {
+		m.cancelFunc()
+	}
+	return nil
+}
+
+func (m *OAuthMCP) saveToken(token *oauth.Token) Action {
+	if err := m.auth.Save(token); err != nil {
+		return ActionCmd{util.ReportError(fmt.Errorf("无法保存令牌: %w", err))}
+	}
+	return ActionMCPAuthenticated{Name: m.server}
+}
@@ -0,0 +1,209 @@
+package dialog
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"charm.land/bubbles/v2/help"
+	"charm.land/bubbles/v2/key"
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+	uv "github.com/charmbracelet/ultraviolet"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/dustin/go-humanize"
+	"github.com/purpose168/crush-cn/internal/ui/common"
+)
+
+const (
+	// PastePreviewID 是粘贴预览对话框的标识符。
+	PastePreviewID = "paste_preview"
+	// pastePreviewLines 是预览中显示的最大行数。
+	pastePreviewLines = 8
+)
+
+// pastePreviewChoice 是粘贴预览对话框中的选项。
+type pastePreviewChoice int
+
+const (
+	pasteChoiceAttach pastePreviewChoice = iota
+	pasteChoiceInsert
+	pasteChoiceCancel
+)
+
+// PastePreview 是粘贴多行内容时显示的对话框，预览内容的开头、大小和类型，
+// 由用户选择作为附件、作为文本插入或取消。
+type PastePreview struct {
+	com      *common.Common
+	help     help.Model
+	content  string
+	lines    int
+	kind     string
+	selected pastePreviewChoice
+
+	keyMap struct {
+		LeftRight key.Binding
+		Tab       key.Binding
+		Select    key.Binding
+		Attach    key.Binding
+		Insert    key.Binding
+		Close     key.Binding
+	}
+}
+
+var _ Dialog = (*PastePreview)(nil)
+
+// NewPastePreview 为粘贴的内容创建预览对话框。
+func NewPastePreview(com *common.Common, content string) *PastePreview {
+	p := &PastePreview{
+		com:     com,
+		content: content,
+		lines:   strings.Count(content, "\n") + 1,
+		kind:    detectPasteKind(content),
+	}
+
+	p.help = help.New()
+	p.help.Styles = com.Styles.DialogHelpStyles()
+
+	p.keyMap.LeftRight = key.NewBinding(
+		key.WithKeys("left", "right"),
+		key.WithHelp("←/→", "切换选项"),
+	)
+	p.keyMap.Tab = key.NewBinding(
+		key.WithKeys("tab", "shift+tab"),
+		key.WithHelp("tab", "切换选项"),
+	)
+	p.keyMap.Select = key.NewBinding(
+		key.WithKeys("enter", "ctrl+y"),
+		key.WithHelp("enter", "确认"),
+	)
+	p.keyMap.Attach = key.NewBinding(
+		key.WithKeys("a"),
+		key.WithHelp("a", "作为附件"),
+	)
+	p.keyMap.Insert = key.NewBinding(
+		key.WithKeys("i"),
+		key.WithHelp("i", "作为文本插入"),
+	)
+	p.keyMap.Close = CloseKey
+	return p
+}
+
+// ID 实现 [Dialog] 接口。
+func (*PastePreview) ID() string {
+	return PastePreviewID
+}
+
+// HandleMsg 实现 [Dialog] 接口。
+func (p *PastePreview) HandleMsg(msg tea.Msg) Action {
+	keyMsg, ok := msg.(tea.KeyPressMsg)
+	if !ok {
+		return nil
+	}
+	switch {
+	case key.Matches(keyMsg, p.keyMap.Close):
+		return ActionClose{}
+	case key.Matches(keyMsg, p.keyMap.Attach):
+		return ActionPasteAttach{Content: p.content}
+	case key.Matches(keyMsg, p.keyMap.Insert):
+		return ActionPasteInsert{Content: p.content}
+	case key.Matches(keyMsg, p.keyMap.LeftRight, p.keyMap.Tab):
+		step := pastePreviewChoice(1)
+		if keyMsg.String() == "left" || keyMsg.String() == "shift+tab" {
+			step = 2
+		}
+		p.selected = (p.selected + step) % 3
+	case key.Matches(keyMsg, p.keyMap.Select):
+		switch p.selected {
+		case pasteChoiceAttach:
+			return ActionPasteAttach{Content: p.content}
+		case pasteChoiceInsert:
+			return ActionPasteInsert{Content: p.content}
+		default:
+			return ActionClose{}
+		}
+	}
+	return nil
+}
+
+// Draw 实现 [Dialog] 接口。
+func (p *PastePreview) Draw(scr uv.Screen, area uv.Rectangle) *tea.Cursor {
+	t := p.com.Styles
+	width := max(0, min(defaultDialogMaxWidth, area.Dx()))
+	innerWidth := width - t.Dialog.View.GetHorizontalFrameSize() - 2
+
+	rc := NewRenderContext(t, width)
+	rc.Title = "粘贴预览"
+
+	info := fmt.Sprintf("%d 行 · %s · %s", p.lines, humanize.Bytes(uint64(len(p.content))), p.kind)
+	rc.AddPart(t.Subtle.Width(innerWidth).Render(info))
+
+	previewLines := strings.Split(p.content, "\n")
+	more := len(previewLines) - pastePreviewLines
+	previewLines = previewLines[:min(len(previewLines), pastePreviewLines)]
+	for i, line := range previewLines {
+		line = strings.ReplaceAll(line, "\t", "    ")
+		previewLines[i] = ansi.Truncate(ansi.Strip(line), innerWidth, "…")
+	}
+	preview := t.Base.Render(strings.Join(previewLines, "\n"))
+	if more > 0 {
+		preview += "\n" + t.Subtle.Render(fmt.Sprintf("… 还有 %d 行", more))
+	}
+	rc.AddPart(preview)
+
+	buttons := common.ButtonGroup(t, []common.ButtonOpts{
+		{Text: "作为附件", Selected: p.selected == pasteChoiceAttach, Padding: 2},
+		{Text: "作为文本插入", Selected: p.selected == pasteChoiceInsert, Padding: 2},
+		{Text: "取消", Selected: p.selected == pasteChoiceCancel, Padding: 2},
+	}, " ")
+	rc.AddPart(lipgloss.PlaceHorizontal(innerWidth, lipgloss.Center, buttons))
+
+	p.help.SetWidth(innerWidth)
+	rc.Help = p.help.View(p)
+
+	DrawCenter(scr, area, rc.Render())
+	return nil
+}
+
+// ShortHelp 实现 [help.KeyMap] 接口。
+func (p *PastePreview) ShortHelp() []key.Binding {
+	return []key.Binding{
+		p.keyMap.LeftRight,
+		p.keyMap.Select,
+		p.keyMap.Attach,
+		p.keyMap.Insert,
+		p.keyMap.Close,
+	}
+}
+
+// FullHelp 实现 [help.KeyMap] 接口。
+func (p *PastePreview) FullHelp() [][]key.Binding {
+	return [][]key.Binding{p.ShortHelp()}
+}
+
+// detectPasteKind 根据内容推测粘贴内容的类型，用于在预览中显示。
+func detectPasteKind(content string) string {
+	trimmed := strings.TrimSpace(content)
+	switch {
+	case json.Valid([]byte(trimmed)) && (strings.HasPrefix(trimmed, "{") || strings.HasPrefix(trimmed, "[")):
+		return "JSON"
+	case strings.HasPrefix(trimmed, "diff --git") || strings.HasPrefix(trimmed, "--- ") && strings.Contains(trimmed, "\n+++ "):
+		return "Diff"
+	case strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "# "):
+		return "Markdown"
+	}
+	mimeType := http.DetectContentType([]byte(content[:min(512, len(content))]))
+	if mediaType, _, ok := strings.Cut(mimeType, ";"); ok {
+		mimeType = mediaType
+	}
+	switch mimeType {
+	case "text/plain":
+		return "纯文本"
+	case "text/html":
+		return "HTML"
+	case "text/xml":
+		return "XML"
+	}
+	return mimeType
+}
@@ -1,8 +1,11 @@
 package dialog
 
 import (
+	"cmp"
 	"encoding/json"
 	"fmt"
+	"maps"
+	"slices"
 	"strings"
 
 	"charm.land/bubbles/v2/help"
@@ -11,9 +14,12 @@ import (
 	tea "charm.land/bubbletea/v2"
 	"charm.land/lipgloss/v2"
 	uv "github.com/charmbracelet/ultraviolet"
+	"github.com/charmbracelet/x/ansi"
 	"github.com/purpose168/crush-cn/internal/agent/tools"
+	"github.com/purpose168/crush-cn/internal/agent/tools/mcp"
 	"github.com/purpose168/crush-cn/internal/fsext"
 	"github.com/purpose168/crush-cn/internal/permission"
+	"github.com/purpose168/crush-cn/internal/shell"
 	"github.com/purpose168/crush-cn/internal/stringext"
 	"github.com/purpose168/crush-cn/internal/ui/common"
 	"github.com/purpose168/crush-cn/internal/ui/styles"
@@ -28,6 +34,8 @@ type PermissionAction string
 const (
 	PermissionAllow           PermissionAction = "allow"
 	PermissionAllowForSession PermissionAction = "allow_session"
+	PermissionAllowWindow     PermissionAction = "allow_window" // 在一段时间或若干次调用内允许该工具
+	PermissionAllowAlways     PermissionAction = "allow_always" // 将 MCP 工具加入 permissions.allowed_tools，或始终允许 MCP 服务器的采样请求
 	PermissionDeny            PermissionAction = "deny"
 )
 
@@ -51,6 +59,8 @@ const (
 	minWindowWidth = 77
 	// minWindowHeight 是强制全屏之前的最小窗口高度。
 	minWindowHeight = 20
+	// maxExpandedFiles 是多文件差异默认全部展开的最大文件数。
+	maxExpandedFiles = 3
 )
 
 // Permissions 表示一个用于权限请求的对话框。
@@ -61,7 +71,11 @@ type Permissions struct {
 	fullscreen   bool // 当对话框全屏时为 true
 
 	permission     permission.PermissionRequest
-	selectedOption int // 0: 允许, 1: 允许本次会话, 2: 拒绝
+	selectedOption int // 在 options() 中的索引
+	// window 是「限时允许」的授权范围，零值时不提供该选项
+	window permission.Window
+	// risks 是 bash 命令中发现的风险
+	risks []shell.CommandRisk
 
 	viewport      viewport.Model
 	viewportDirty bool // 当视口内容需要重新渲染时为 true
@@ -74,6 +88,12 @@ type Permissions struct {
 	unifiedDiffContent   string
 	splitDiffContent     string
 
+	// 多文件差异的分组视图状态。
+	fileCursor   int          // 当前选中的文件
+	collapsed    map[int]bool // 已折叠的文件
+	fileLines    []int        // 每个文件标题在渲染内容中的行号
+	scrollToFile bool         // 下次渲染后滚动到选中的文件
+
 	help   help.Model
 	keyMap permissionsKeyMap
 }
@@ -85,6 +105,8 @@ type permissionsKeyMap struct {
 	Select           key.Binding
 	Allow            key.Binding
 	AllowSession     key.Binding
+	AllowWindow      key.Binding
+	AllowAlways      key.Binding
 	Deny             key.Binding
 	Close            key.Binding
 	ToggleDiffMode   key.Binding
@@ -95,6 +117,11 @@ type permissionsKeyMap struct {
 	ScrollRight      key.Binding
 	Choose           key.Binding
 	Scroll           key.Binding
+	PrevFile         key.Binding
+	NextFile         key.Binding
+	SelectFile       key.Binding
+	ToggleFile       key.Binding
+	ToggleAllFiles   key.Binding
 }
 
 func defaultPermissionsKeyMap() permissionsKeyMap {
@@ -123,6 +150,14 @@ func defaultPermissionsKeyMap() permissionsKeyMap {
 			key.WithKeys("s", "S", "ctrl+s"),
 			key.WithHelp("s", "允许本次会话"),
 		),
+		AllowWindow: key.NewBinding(
+			key.WithKeys("m", "M"),
+			key.WithHelp("m", "限时允许"),
+		),
+		AllowAlways: key.NewBinding(
+			key.WithKeys("w", "W"),
+			key.WithHelp("w", "始终允许此工具"),
+		),
 		Deny: key.NewBinding(
 			key.WithKeys("d", "D"),
 			key.WithHelp("d", "拒绝"),
@@ -160,6 +195,26 @@ func defaultPermissionsKeyMap() permissionsKeyMap {
 			key.WithKeys("shift+left", "shift+down", "shift+up", "shift+right"),
 			key.WithHelp("shift+←↓↑→", "滚动"),
 		),
+		PrevFile: key.NewBinding(
+			key.WithKeys("up", "k"),
+			key.WithHelp("↑", "上一个文件"),
+		),
+		NextFile: key.NewBinding(
+			key.WithKeys("down", "j"),
+			key.WithHelp("↓", "下一个文件"),
+		),
+		SelectFile: key.NewBinding(
+			key.WithKeys("up", "down"),
+			key.WithHelp("↑/↓", "选择文件"),
+		),
+		ToggleFile: key.NewBinding(
+			key.WithKeys("space"),
+			key.WithHelp("space", "展开/折叠"),
+		),
+		ToggleAllFiles: key.NewBinding(
+			key.WithKeys("e"),
+			key.WithHelp("e", "全部展开/折叠"),
+		),
 	}
 }
 
@@ -175,6 +230,13 @@ func WithDiffMode(split bool) PermissionsOption {
 	}
 }
 
+// WithWindow 提供「限时允许」选项，在 window 范围内自动允许同一会话中该工具之后的调用。
+func WithWindow(window permission.Window) PermissionsOption {
+	return func(p *Permissions) {
+		p.window = window
+	}
+}
+
 // NewPermissions 创建一个新的权限对话框。
 func NewPermissions(com *common.Common, perm permission.PermissionRequest, opts ...PermissionsOption) *Permissions {
 	h := help.New()
@@ -203,6 +265,17 @@ func NewPermissions(com *common.Common, perm permission.PermissionRequest, opts
 		viewport:       vp,
 		help:           h,
 		keyMap:         km,
+		collapsed:      make(map[int]bool),
+	}
+	if params, ok := perm.Params.(tools.BashPermissionsParams); ok {
+		p.risks = shell.AnalyzeCommand(params.Command)
+	}
+
+	// 文件较多时默认只展开第一个文件，便于先浏览文件列表。
+	if files := p.groupedFiles(); len(files) > maxExpandedFiles {
+		for i := 1; i < len(files); i++ {
+			p.collapsed[i] = true
+		}
 	}
 
 	for _, opt := range opts {
@@ -233,16 +306,25 @@ func (p *Permissions) HandleMsg(msg tea.Msg) Action {
 			// Escape 拒绝权限请求。
 			return p.respond(PermissionDeny)
 		case key.Matches(msg, p.keyMap.Right), key.Matches(msg, p.keyMap.Tab):
-			p.selectedOption = (p.selectedOption + 1) % 3
+			p.selectedOption = (p.selectedOption + 1) % len(p.options())
 		case key.Matches(msg, p.keyMap.Left):
-			// 加 2 而不是减 1 以避免负模运算。
-			p.selectedOption = (p.selectedOption + 2) % 3
+			// 加 len-1 而不是减 1 以避免负模运算。
+			n := len(p.options())
+			p.selectedOption = (p.selectedOption + n - 1) % n
 		case key.Matches(msg, p.keyMap.Select):
 			return p.selectCurrentOption()
 		case key.Matches(msg, p.keyMap.Allow):
 			return p.respond(PermissionAllow)
 		case key.Matches(msg, p.keyMap.AllowSession):
 			return p.respond(PermissionAllowForSession)
+		case key.Matches(msg, p.keyMap.AllowWindow):
+			if p.hasWindow() {
+				return p.respond(PermissionAllowWindow)
+			}
+		case key.Matches(msg, p.keyMap.AllowAlways):
+			if p.isMCP() || p.isSampling() {
+				return p.respond(PermissionAllowAlways)
+			}
 		case key.Matches(msg, p.keyMap.Deny):
 			return p.respond(PermissionDeny)
 		case key.Matches(msg, p.keyMap.ToggleDiffMode):
@@ -255,6 +337,24 @@ func (p *Permissions) HandleMsg(msg tea.Msg) Action {
 			if p.hasDiffView() {
 				p.fullscreen = !p.fullscreen
 			}
+		case key.Matches(msg, p.keyMap.PrevFile):
+			if len(p.groupedFiles()) > 0 {
+				p.selectFile(p.fileCursor - 1)
+			}
+		case key.Matches(msg, p.keyMap.NextFile):
+			if len(p.groupedFiles()) > 0 {
+				p.selectFile(p.fileCursor + 1)
+			}
+		case key.Matches(msg, p.keyMap.ToggleFile):
+			if len(p.groupedFiles()) > 0 {
+				p.collapsed[p.fileCursor] = !p.collapsed[p.fileCursor]
+				p.viewportDirty = true
+				p.scrollToFile = true
+			}
+		case key.Matches(msg, p.keyMap.ToggleAllFiles):
+			if files := p.groupedFiles(); len(files) > 0 {
+				p.toggleAllFiles(len(files))
+			}
 		case key.Matches(msg, p.keyMap.ScrollDown):
 			p.viewport, _ = p.viewport.Update(msg)
 		case key.Matches(msg, p.keyMap.ScrollUp):
@@ -297,31 +397,96 @@ func (p *Permissions) HandleMsg(msg tea.Msg) Action {
 }
 
 func (p *Permissions) selectCurrentOption() tea.Msg {
-	switch p.selectedOption {
-	case 0:
-		return p.respond(PermissionAllow)
-	case 1:
-		return p.respond(PermissionAllowForSession)
-	default:
-		return p.respond(PermissionDeny)
+	return p.respond(p.options()[p.selectedOption])
+}
+
+// options 返回对话框中的选项，配置了临时授权时提供「限时允许」，MCP 工具额外提供「始终允许」。
+func (p *Permissions) options() []PermissionAction {
+	options := []PermissionAction{PermissionAllow, PermissionAllowForSession}
+	if p.hasWindow() {
+		options = append(options, PermissionAllowWindow)
 	}
+	if p.isMCP() || p.isSampling() {
+		options = append(options, PermissionAllowAlways)
+	}
+	return append(options, PermissionDeny)
+}
+
+// hasWindow 报告是否提供「限时允许」选项。
+func (p *Permissions) hasWindow() bool {
+	return p.window.Duration > 0 || p.window.Uses > 0
+}
+
+// isMCP 报告权限请求是否来自 MCP 工具。
+func (p *Permissions) isMCP() bool {
+	_, ok := p.permission.Params.(tools.MCPPermissionsParams)
+	return ok
+}
+
+// isSampling 报告权限请求是否是 MCP 服务器的采样请求。
+func (p *Permissions) isSampling() bool {
+	_, ok := p.permission.Params.(mcp.SamplingPermissionsParams)
+	return ok
 }
 
 func (p *Permissions) respond(action PermissionAction) tea.Msg {
 	return ActionPermissionResponse{
 		Permission: p.permission,
 		Action:     action,
+		Window:     p.window,
 	}
 }
 
 func (p *Permissions) hasDiffView() bool {
 	switch p.permission.ToolName {
-	case tools.EditToolName, tools.WriteToolName, tools.MultiEditToolName:
+	case tools.EditToolName, tools.WriteToolName, tools.MultiEditToolName,
+		tools.LSPRenameToolName, tools.LSPCodeActionToolName:
 		return true
 	}
 	return false
 }
 
+// groupedFiles 返回多文件更改中的每个文件，按文件分组显示差异。
+// 单文件更改返回 nil。
+func (p *Permissions) groupedFiles() []tools.WorkspaceEditFile {
+	switch params := p.permission.Params.(type) {
+	case tools.MultiEditPermissionsParams:
+		return params.Files
+	case tools.WorkspaceEditPermissionsParams:
+		return params.Files
+	}
+	return nil
+}
+
+// selectFile 选中分组视图中的第 i 个文件，并滚动使其可见。
+func (p *Permissions) selectFile(i int) {
+	p.fileCursor = max(0, min(i, len(p.groupedFiles())-1))
+	p.viewportDirty = true
+	p.scrollToFile = true
+}
+
+// toggleAllFiles 在全部展开和全部折叠之间切换。
+func (p *Permissions) toggleAllFiles(count int) {
+	collapse := !p.allCollapsed(count)
+	clear(p.collapsed)
+	if collapse {
+		for i := range count {
+			p.collapsed[i] = true
+		}
+	}
+	p.viewportDirty = true
+	p.scrollToFile = true
+}
+
+func (p *Permissions) allCollapsed(count int) bool {
+	for i := range count {
+		if !p.collapsed[i] {
+			return false
+		}
+	}
+	return true
+}
+
 func (p *Permissions) isSplitMode() bool {
 	if p.diffSplitMode != nil {
 		return *p.diffSplitMode
@@ -419,6 +584,14 @@ func (p *Permissions) Draw(scr uv.Screen, area uv.Rectangle) *tea.Cursor {
 		p.viewportWidth = p.viewport.Width()
 		p.viewportDirty = false
 	}
+	if p.scrollToFile && p.fileCursor < len(p.fileLines) {
+		// 选中的文件标题不在可见范围内时，将其滚动到顶部。
+		line := p.fileLines[p.fileCursor]
+		if line < p.viewport.YOffset() || line >= p.viewport.YOffset()+availableHeight {
+			p.viewport.SetYOffset(line)
+		}
+		p.scrollToFile = false
+	}
 	content = p.viewport.View()
 	if needsScrollbar {
 		scrollbar = common.Scrollbar(t, availableHeight, p.viewport.TotalLineCount(), availableHeight, p.viewport.YOffset())
@@ -458,12 +631,22 @@ func (p *Permissions) renderHeader(contentWidth int) string {
 		if params, ok := p.permission.Params.(tools.BashPermissionsParams); ok {
 			lines = append(lines, p.renderKeyValue("描述", params.Description, contentWidth))
 		}
+		if len(p.risks) > 0 {
+			lines = append(lines, "")
+			for _, risk := range p.risks {
+				lines = append(lines, p.renderRisk(risk, contentWidth))
+			}
+		}
 	case tools.DownloadToolName:
 		if params, ok := p.permission.Params.(tools.DownloadPermissionsParams); ok {
 			lines = append(lines, p.renderKeyValue("URL", params.URL, contentWidth))
 			lines = append(lines, p.renderKeyValue("文件", fsext.PrettyPath(params.FilePath), contentWidth))
 		}
 	case tools.EditToolName, tools.WriteToolName, tools.MultiEditToolName, tools.ViewToolName:
+		if files := p.groupedFiles(); len(files) > 0 {
+			lines = append(lines, p.renderKeyValue("文件", fmt.Sprintf("%d 个文件将被更改", len(files)), contentWidth))
+			break
+		}
 		var filePath string
 		switch params := p.permission.Params.(type) {
 		case tools.EditPermissionsParams:
@@ -478,10 +661,41 @@ func (p *Permissions) renderHeader(contentWidth int) string {
 		if filePath != "" {
 			lines = append(lines, p.renderKeyValue("文件", fsext.PrettyPath(filePath), contentWidth))
 		}
+	case tools.LSPRenameToolName, tools.LSPCodeActionToolName:
+		lines = append(lines, p.renderKeyValue("描述", p.permission.Description, contentWidth))
+		if params, ok := p.permission.Params.(tools.WorkspaceEditPermissionsParams); ok {
+			lines = append(lines, p.renderKeyValue("文件", fmt.Sprintf("%d 个文件将被更改", len(params.Files)), contentWidth))
+		}
 	case tools.LSToolName:
 		if params, ok := p.permission.Params.(tools.LSPermissionsParams); ok {
 			lines = append(lines, p.renderKeyValue("目录", fsext.PrettyPath(params.Path), contentWidth))
 		}
+	case tools.IssueFetchToolName:
+		if params, ok := p.permission.Params.(tools.IssueFetchPermissionsParams); ok {
+			lines = append(lines, p.renderKeyValue("议题", params.Reference, contentWidth))
+		}
+	case tools.GitCommitToolName:
+		if params, ok := p.permission.Params.(tools.GitCommitPermissionsParams); ok {
+			lines = append(lines, p.renderKeyValue("分支", cmp.Or(params.Branch, "(分离 HEAD)"), contentWidth))
+		}
+	case tools.RunTestsToolName:
+		if params, ok := p.permission.Params.(tools.RunTestsPermissionsParams); ok {
+			lines = append(lines, p.renderKeyValue("框架", params.Runner, contentWidth))
+		}
+	case mcp.SamplingToolName:
+		if params, ok := p.permission.Params.(mcp.SamplingPermissionsParams); ok {
+			lines = append(lines, p.renderKeyValue("说明", "服务器请求使用当前模型生成回复", contentWidth))
+			if params.MaxTokens > 0 {
+				lines = append(lines, p.renderKeyValue("最大令牌", fmt.Sprint(params.MaxTokens), contentWidth))
+			}
+		}
+	default:
+		if params, ok := p.permission.Params.(tools.MCPPermissionsParams); ok {
+			lines = append(lines, p.renderKeyValue("服务器", params.MCPName, contentWidth))
+			if desc, _, _ := strings.Cut(strings.TrimSpace(params.Description), "\n"); desc != "" {
+				lines = append(lines, p.renderKeyValue("说明", ansi.Truncate(desc, contentWidth-lipgloss.Width("说明")-1, "…"), contentWidth))
+			}
+		}
 	}
 
 	return lipgloss.JoinVertical(lipgloss.Left, lines...)
@@ -498,11 +712,34 @@ func (p *Permissions) renderKeyValue(key, value string, width int) string {
 	return lipgloss.JoinHorizontal(lipgloss.Left, keyStr, valueStr)
 }
 
+// riskLabels 是风险类别在徽章中显示的名称。
+var riskLabels = map[shell.RiskCategory]string{
+	shell.RiskDestructive: "破坏性",
+	shell.RiskNetwork:     "网络脚本",
+	shell.RiskPrivileged:  "提权",
+}
+
+// renderRisk 渲染一项命令风险：按风险等级着色的徽章和风险说明。
+func (p *Permissions) renderRisk(risk shell.CommandRisk, width int) string {
+	t := p.com.Styles
+	badgeStyle := t.Dialog.RiskMedium
+	if risk.Level == shell.RiskHigh {
+		badgeStyle = t.Dialog.RiskHigh
+	}
+	badge := badgeStyle.Render(riskLabels[risk.Category])
+	explanation := t.Base.Width(max(0, width-lipgloss.Width(badge))).Render(" " + risk.Explanation)
+	return lipgloss.JoinHorizontal(lipgloss.Top, badge, explanation)
+}
+
 func (p *Permissions) renderToolName(width int) string {
 	toolName := p.permission.ToolName
 
 	// 检查这是否是 MCP 工具（格式：mcp_<mcpname>_<toolname>）。
-	if strings.HasPrefix(toolName, "mcp_") {
+	if params, ok := p.permission.Params.(tools.MCPPermissionsParams); ok {
+		toolName = fmt.Sprintf("%s %s %s", prettyName(params.MCPName), styles.ArrowRightIcon, params.ToolName)
+	} else if params, ok := p.permission.Params.(mcp.SamplingPermissionsParams); ok {
+		toolName = fmt.Sprintf("%s %s 采样", prettyName(params.MCPName), styles.ArrowRightIcon)
+	} else if strings.HasPrefix(toolName, "mcp_") {
 		parts := strings.SplitN(toolName, "_", 3)
 		if len(parts) == 3 {
 			mcpName := prettyName(parts[1])
@@ -531,6 +768,8 @@ func (p *Permissions) renderContent(width int) string {
 		return p.renderWriteContent(width)
 	case tools.MultiEditToolName:
 		return p.renderMultiEditContent(width)
+	case tools.LSPRenameToolName, tools.LSPCodeActionToolName:
+		return p.renderWorkspaceEditContent(width)
 	case tools.DownloadToolName:
 		return p.renderDownloadContent(width)
 	case tools.FetchToolName:
@@ -541,7 +780,16 @@ func (p *Permissions) renderContent(width int) string {
 		return p.renderViewContent(width)
 	case tools.LSToolName:
 		return p.renderLSContent(width)
+	case tools.GitCommitToolName:
+		return p.renderGitCommitContent(width)
+	case tools.RunTestsToolName:
+		return p.renderRunTestsContent(width)
+	case mcp.SamplingToolName:
+		return p.renderSamplingContent(width)
 	default:
+		if p.isMCP() {
+			return p.renderMCPContent(width)
+		}
 		return p.renderDefaultContent(width)
 	}
 }
@@ -576,10 +824,81 @@ func (p *Permissions) renderMultiEditContent(contentWidth int) string {
 	if !ok {
 		return ""
 	}
+	if len(params.Files) > 0 {
+		return p.renderGroupedDiff(params.Files, contentWidth)
+	}
 	return p.renderDiff(params.FilePath, params.OldContent, params.NewContent, contentWidth)
 }
 
+// renderWorkspaceEditContent 按文件分组渲染 LSP 工作区编辑的差异。
+func (p *Permissions) renderWorkspaceEditContent(contentWidth int) string {
+	params, ok := p.permission.Params.(tools.WorkspaceEditPermissionsParams)
+	if !ok {
+		return ""
+	}
+	return p.renderGroupedDiff(params.Files, contentWidth)
+}
+
+// renderGroupedDiff 将多文件更改渲染为按文件分组的树：每个文件一行标题，
+// 展开的文件在标题下方显示其差异。
+func (p *Permissions) renderGroupedDiff(files []tools.WorkspaceEditFile, contentWidth int) string {
+	t := p.com.Styles
+	const diffIndent = 2
+	return p.cachedDiff(func(split bool) string {
+		parts := make([]string, 0, len(files)*2)
+		p.fileLines = p.fileLines[:0]
+		line := 0
+		for i, file := range files {
+			oldPath := cmp.Or(file.OldPath, file.FilePath)
+
+			icon := styles.ExpandedIcon
+			if p.collapsed[i] {
+				icon = styles.CollapsedIcon
+			}
+			title := icon + " " + fsext.PrettyPath(file.FilePath)
+			if oldPath != file.FilePath {
+				title = icon + " " + fsext.PrettyPath(oldPath) + " " + styles.ArrowRightIcon + " " + fsext.PrettyPath(file.FilePath)
+			}
+
+			var header string
+			if i == p.fileCursor {
+				stats := fmt.Sprintf("+%d -%d", file.Additions, file.Removals)
+				if file.Deleted {
+					stats = "已删除"
+				}
+				header = t.Dialog.SelectedItem.Width(contentWidth).Render(title + "  " + stats)
+			} else {
+				stats := t.Files.Additions.Render(fmt.Sprintf("+%d", file.Additions)) + " " +
+					t.Files.Deletions.Render(fmt.Sprintf("-%d", file.Removals))
+				if file.Deleted {
+					stats = t.Files.Deletions.Render("已删除")
+				}
+				header = t.Dialog.NormalItem.Width(contentWidth).Render(t.Files.Path.Render(title) + "  " + stats)
+			}
+			p.fileLines = append(p.fileLines, line)
+			parts = append(parts, header)
+			line += lipgloss.Height(header)
+
+			if p.collapsed[i] {
+				continue
+			}
+			diff := p.formatDiff(oldPath, file.FilePath, file.OldContent, file.NewContent, contentWidth-diffIndent, split)
+			diff = lipgloss.NewStyle().PaddingLeft(diffIndent).Render(diff)
+			parts = append(parts, diff)
+			line += lipgloss.Height(diff)
+		}
+		return strings.Join(parts, "\n")
+	})
+}
+
 func (p *Permissions) renderDiff(filePath, oldContent, newContent string, contentWidth int) string {
+	return p.cachedDiff(func(split bool) string {
+		return p.formatDiff(filePath, filePath, oldContent, newContent, contentWidth, split)
+	})
+}
+
+// cachedDiff 返回缓存的差异内容，视口需要重新渲染时使用 render 重新生成。
+func (p *Permissions) cachedDiff(render func(split bool) string) string {
 	if !p.viewportDirty {
 		if p.isSplitMode() {
 			return p.splitDiffContent
@@ -587,25 +906,24 @@ func (p *Permissions) renderDiff(filePath, oldContent, newContent string, conten
 		return p.unifiedDiffContent
 	}
 
-	isSplitMode := p.isSplitMode()
+	if p.isSplitMode() {
+		p.splitDiffContent = render(true)
+		return p.splitDiffContent
+	}
+	p.unifiedDiffContent = render(false)
+	return p.unifiedDiffContent
+}
+
+func (p *Permissions) formatDiff(oldPath, newPath, oldContent, newContent string, contentWidth int, split bool) string {
 	formatter := common.DiffFormatter(p.com.Styles).
-		Before(fsext.PrettyPath(filePath), oldContent).
-		After(fsext.PrettyPath(filePath), newContent).
+		Before(fsext.PrettyPath(oldPath), oldContent).
+		After(fsext.PrettyPath(newPath), newContent).
 		XOffset(p.diffXOffset).
 		Width(contentWidth)
-
-	var result string
-	if isSplitMode {
-		formatter = formatter.Split()
-		p.splitDiffContent = formatter.String()
-		result = p.splitDiffContent
-	} else {
-		formatter = formatter.Unified()
-		p.unifiedDiffContent = formatter.String()
-		result = p.unifiedDiffContent
+	if split {
+		return formatter.Split().String()
 	}
-
-	return result
+	return formatter.Unified().String()
 }
 
 func (p *Permissions) renderDownloadContent(width int) string {
@@ -618,6 +936,12 @@ func (p *Permissions) renderDownloadContent(width int) string {
 	if params.Timeout > 0 {
 		content += fmt.Sprintf("\n超时: %ds", params.Timeout)
 	}
+	if params.SHA256 != "" {
+		content += fmt.Sprintf("\nSHA-256: %s", params.SHA256)
+	}
+	if params.Resume {
+		content += "\n继续之前中断的下载"
+	}
 
 	return p.renderContentPanel(content, width)
 }
@@ -678,6 +1002,42 @@ func (p *Permissions) renderLSContent(width int) string {
 	return p.renderContentPanel(content, width)
 }
 
+func (p *Permissions) renderGitCommitContent(width int) string {
+	params, ok := p.permission.Params.(tools.GitCommitPermissionsParams)
+	if !ok {
+		return ""
+	}
+
+	content := strings.TrimSpace(params.Message)
+	content += fmt.Sprintf("\n\n将提交 %d 个文件:\n%s", len(params.Files), strings.Join(params.Files, "\n"))
+	return p.renderContentPanel(content, width)
+}
+
+// renderRunTestsContent 渲染要执行的测试命令。
+func (p *Permissions) renderRunTestsContent(width int) string {
+	params, ok := p.permission.Params.(tools.RunTestsPermissionsParams)
+	if !ok {
+		return ""
+	}
+	return p.renderContentPanel(params.Command, width)
+}
+
+// renderSamplingContent 渲染 MCP 采样请求的系统提示和消息。
+func (p *Permissions) renderSamplingContent(width int) string {
+	t := p.com.Styles
+	params, ok := p.permission.Params.(mcp.SamplingPermissionsParams)
+	if !ok {
+		return ""
+	}
+
+	var content string
+	if params.SystemPrompt != "" {
+		content = t.Muted.Render("系统提示:") + "\n" + strings.TrimSpace(params.SystemPrompt) + "\n\n"
+	}
+	content += t.Muted.Render("消息:") + "\n" + params.Messages
+	return p.renderContentPanel(content, width)
+}
+
 func (p *Permissions) renderDefaultContent(width int) string {
 	t := p.com.Styles
 	var content string
@@ -723,6 +1083,61 @@ func (p *Permissions) renderDefaultContent(width int) string {
 	return p.renderContentPanel(strings.TrimSpace(content), width)
 }
 
+// renderMCPContent 按参数名逐项渲染 MCP 工具调用的参数：单行的字符串和数字等标量直接显示，
+// 多行文本、对象和数组缩进显示在参数名下方。参数不是 JSON 对象时按原样格式化显示。
+func (p *Permissions) renderMCPContent(width int) string {
+	t := p.com.Styles
+	params, ok := p.permission.Params.(tools.MCPPermissionsParams)
+	if !ok {
+		return ""
+	}
+
+	if strings.TrimSpace(params.Input) == "" {
+		return p.renderContentPanel(t.Subtle.Render("（无参数）"), width)
+	}
+	dec := json.NewDecoder(strings.NewReader(params.Input))
+	dec.UseNumber()
+	var args map[string]any
+	if err := dec.Decode(&args); err != nil {
+		return p.renderContentPanel(params.Input, width)
+	}
+	if len(args) == 0 {
+		return p.renderContentPanel(t.Subtle.Render("（无参数）"), width)
+	}
+
+	const indent = "  "
+	lines := make([]string, 0, len(args))
+	for _, name := range slices.Sorted(maps.Keys(args)) {
+		label := t.Muted.Render(name + ":")
+		switch value := args[name].(type) {
+		case string:
+			if !strings.Contains(value, "\n") {
+				lines = append(lines, label+" "+value)
+				continue
+			}
+			lines = append(lines, label)
+			for line := range strings.SplitSeq(value, "\n") {
+				lines = append(lines, indent+line)
+			}
+		case map[string]any, []any:
+			b, err := json.MarshalIndent(value, indent, "  ")
+			if err != nil {
+				continue
+			}
+			content := indent + string(b)
+			if highlighted, err := common.SyntaxHighlight(t, content, "params.json", t.BgSubtle); err == nil {
+				content = highlighted
+			}
+			lines = append(lines, label, content)
+		case nil:
+			lines = append(lines, label+" null")
+		default:
+			lines = append(lines, label+" "+fmt.Sprint(value))
+		}
+	}
+	return p.renderContentPanel(strings.Join(lines, "\n"), width)
+}
+
 // renderContentPanel 在具有全宽度的面板中渲染内容。
 func (p *Permissions) renderContentPanel(content string, width int) string {
 	panelStyle := p.com.Styles.Dialog.ContentPanel
@@ -730,10 +1145,22 @@ func (p *Permissions) renderContentPanel(content string, width int) string {
 }
 
 func (p *Permissions) renderButtons(contentWidth int) string {
-	buttons := []common.ButtonOpts{
-		{Text: "允许", UnderlineIndex: 0, Selected: p.selectedOption == 0},
-		{Text: "允许本次会话", UnderlineIndex: 10, Selected: p.selectedOption == 1},
-		{Text: "拒绝", UnderlineIndex: 0, Selected: p.selectedOption == 2},
+	var buttons []common.ButtonOpts
+	for i, action := range p.options() {
+		button := common.ButtonOpts{Selected: p.selectedOption == i}
+		switch action {
+		case PermissionAllow:
+			button.Text, button.UnderlineIndex = "允许", 0
+		case PermissionAllowForSession:
+			button.Text, button.UnderlineIndex = "允许本次会话", 10
+		case PermissionAllowWindow:
+			button.Text, button.UnderlineIndex = "允许 "+p.window.String(), -1
+		case PermissionAllowAlways:
+			button.Text, button.UnderlineIndex = "始终允许", -1
+		case PermissionDeny:
+			button.Text, button.UnderlineIndex = "拒绝", 0
+		}
+		buttons = append(buttons, button)
 	}
 
 	content := common.ButtonGroup(p.com.Styles, buttons, "  ")
@@ -781,6 +1208,18 @@ func (p *Permissions) ShortHelp() []key.Binding {
 		)
 	}
 
+	if p.isMCP() {
+		bindings = append(bindings, p.keyMap.AllowAlways)
+	}
+
+	if len(p.groupedFiles()) > 0 {
+		bindings = append(bindings,
+			p.keyMap.SelectFile,
+			p.keyMap.ToggleFile,
+			p.keyMap.ToggleAllFiles,
+		)
+	}
+
 	return bindings
 }
 
@@ -0,0 +1,231 @@
+package dialog
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+
+	"charm.land/bubbles/v2/help"
+	"charm.land/bubbles/v2/key"
+	tea "charm.land/bubbletea/v2"
+	uv "github.com/charmbracelet/ultraviolet"
+	"github.com/purpose168/crush-cn/internal/fsext"
+	"github.com/purpose168/crush-cn/internal/ui/common"
+	"github.com/purpose168/crush-cn/internal/ui/list"
+	"github.com/purpose168/crush-cn/internal/ui/styles"
+)
+
+// PinnedFilesID 是固定文件管理对话框的标识符。
+const PinnedFilesID = "pinned_files"
+
+// PinnedFiles 是一个用于固定或取消固定会话文件的对话框。固定的文件会在每次请求时
+// 重新发送给智能体，即使会话被总结也会保留在上下文中。
+type PinnedFiles struct {
+	com       *common.Common
+	help      help.Model
+	list      *list.List
+	sessionID string
+	paths     []string
+	pinned    []string
+
+	keyMap struct {
+		Next     key.Binding
+		Previous key.Binding
+		UpDown   key.Binding
+		Toggle   key.Binding
+		Close    key.Binding
+	}
+}
+
+var _ Dialog = (*PinnedFiles)(nil)
+
+// NewPinnedFiles 创建一个新的 [PinnedFiles] 对话框。paths 是可供固定的会话文件，
+// pinned 是当前已固定的文件。
+func NewPinnedFiles(com *common.Common, sessionID string, paths, pinned []string) *PinnedFiles {
+	p := &PinnedFiles{
+		com:       com,
+		sessionID: sessionID,
+		pinned:    slices.Clone(pinned),
+	}
+
+	// 已固定的文件排在前面，并保证即使不在会话文件中也能取消固定。
+	for _, path := range slices.Concat(pinned, paths) {
+		if !slices.Contains(p.paths, path) {
+			p.paths = append(p.paths, path)
+		}
+	}
+
+	help := help.New()
+	help.Styles = com.Styles.DialogHelpStyles()
+	p.help = help
+
+	p.list = list.NewList()
+	p.list.Focus()
+
+	p.keyMap.Next = key.NewBinding(
+		key.WithKeys("down", "ctrl+n"),
+		key.WithHelp("↓", "下一项"),
+	)
+	p.keyMap.Previous = key.NewBinding(
+		key.WithKeys("up", "ctrl+p"),
+		key.WithHelp("↑", "上一项"),
+	)
+	p.keyMap.UpDown = key.NewBinding(
+		key.WithKeys("up", "down"),
+		key.WithHelp("↑↓", "选择"),
+	)
+	p.keyMap.Toggle = key.NewBinding(
+		key.WithKeys("enter", "space"),
+		key.WithHelp("enter", "固定/取消固定"),
+	)
+	p.keyMap.Close = CloseKey
+
+	p.setItems()
+	p.list.SetSelected(0)
+	return p
+}
+
+// ID 实现 Dialog 接口。
+func (p *PinnedFiles) ID() string {
+	return PinnedFilesID
+}
+
+// HandleMsg 实现 Dialog 接口。
+func (p *PinnedFiles) HandleMsg(msg tea.Msg) Action {
+	keyMsg, ok := msg.(tea.KeyPressMsg)
+	if !ok {
+		return nil
+	}
+	switch {
+	case key.Matches(keyMsg, p.keyMap.Close):
+		return ActionClose{}
+	case key.Matches(keyMsg, p.keyMap.Previous):
+		if p.list.IsSelectedFirst() {
+			p.list.SelectLast()
+			p.list.ScrollToBottom()
+			break
+		}
+		p.list.SelectPrev()
+		p.list.ScrollToSelected()
+	case key.Matches(keyMsg, p.keyMap.Next):
+		if p.list.IsSelectedLast() {
+			p.list.SelectFirst()
+			p.list.ScrollToTop()
+			break
+		}
+		p.list.SelectNext()
+		p.list.ScrollToSelected()
+	case key.Matches(keyMsg, p.keyMap.Toggle):
+		idx := p.list.Selected()
+		if idx < 0 || idx >= len(p.paths) {
+			break
+		}
+		path := p.paths[idx]
+		if i := slices.Index(p.pinned, path); i >= 0 {
+			p.pinned = slices.Delete(p.pinned, i, i+1)
+		} else {
+			p.pinned = append(p.pinned, path)
+		}
+		p.setItems()
+		return ActionSetPinnedFiles{SessionID: p.sessionID, Paths: slices.Clone(p.pinned)}
+	}
+	return nil
+}
+
+// setItems 根据当前的固定状态重建列表项，保持选中位置不变。
+func (p *PinnedFiles) setItems() {
+	cwd := p.com.Config().WorkingDir()
+	selected := p.list.Selected()
+	items := make([]list.Item, len(p.paths))
+	for i, path := range p.paths {
+		display := path
+		if rel, err := filepath.Rel(cwd, path); err == nil {
+			display = rel
+		}
+		items[i] = &PinnedFileItem{
+			path:   fsext.DirTrim(display, 3),
+			pinned: slices.Contains(p.pinned, path),
+			t:      p.com.Styles,
+		}
+	}
+	p.list.SetItems(items...)
+	p.list.SetSelected(max(selected, 0))
+}
+
+// Draw 实现 [Dialog] 接口。
+func (p *PinnedFiles) Draw(scr uv.Screen, area uv.Rectangle) *tea.Cursor {
+	t := p.com.Styles
+	width := max(0, min(defaultDialogMaxWidth, area.Dx()))
+	height := max(0, min(defaultDialogHeight, area.Dy()))
+	innerWidth := width - t.Dialog.View.GetHorizontalFrameSize() - 2
+	heightOffset := t.Dialog.Title.GetVerticalFrameSize() + titleContentHeight +
+		t.Dialog.HelpView.GetVerticalFrameSize() +
+		t.Dialog.View.GetVerticalFrameSize()
+
+	rc := NewRenderContext(t, width)
+	rc.Title = fmt.Sprintf("固定的文件 (%d/%d)", len(p.pinned), len(p.paths))
+
+	p.list.SetSize(innerWidth, max(0, height-heightOffset))
+	p.help.SetWidth(innerWidth)
+
+	listView := t.Dialog.List.Height(p.list.Height()).Render(p.list.Render())
+	rc.AddPart(listView)
+	rc.Help = p.help.View(p)
+
+	DrawCenter(scr, area, rc.Render())
+	return nil
+}
+
+// ShortHelp 实现 [help.KeyMap] 接口。
+func (p *PinnedFiles) ShortHelp() []key.Binding {
+	return []key.Binding{
+		p.keyMap.UpDown,
+		p.keyMap.Toggle,
+		p.keyMap.Close,
+	}
+}
+
+// FullHelp 实现 [help.KeyMap] 接口。
+func (p *PinnedFiles) FullHelp() [][]key.Binding {
+	return [][]key.Binding{p.ShortHelp()}
+}
+
+// PinnedFileItem 表示固定文件对话框中的单个文件。
+type PinnedFileItem struct {
+	path    string
+	pinned  bool
+	t       *styles.Styles
+	cache   map[int]string
+	focused bool
+}
+
+var (
+	_ list.Item      = (*PinnedFileItem)(nil)
+	_ list.Focusable = (*PinnedFileItem)(nil)
+)
+
+// SetFocused 设置文件项目的焦点状态。
+func (p *PinnedFileItem) SetFocused(focused bool) {
+	if p.focused != focused {
+		p.cache = nil
+	}
+	p.focused = focused
+}
+
+// Render 返回文件项目的字符串表示。
+func (p *PinnedFileItem) Render(width int) string {
+	if p.cache == nil {
+		p.cache = make(map[int]string)
+	}
+	itemStyles := ListItemStyles{
+		ItemBlurred:     p.t.Dialog.NormalItem,
+		ItemFocused:     p.t.Dialog.SelectedItem,
+		InfoTextBlurred: p.t.Subtle,
+		InfoTextFocused: p.t.Base,
+	}
+	icon, info := styles.RadioOff, ""
+	if p.pinned {
+		icon, info = styles.RadioOn, "已固定"
+	}
+	return renderItem(itemStyles, icon+" "+p.path, info, p.focused, width, p.cache, nil)
+}
@@ -0,0 +1,145 @@
+package dialog
+
+import (
+	"charm.land/bubbles/v2/help"
+	"charm.land/bubbles/v2/key"
+	"charm.land/bubbles/v2/textarea"
+	tea "charm.land/bubbletea/v2"
+	uv "github.com/charmbracelet/ultraviolet"
+	"github.com/purpose168/crush-cn/internal/session"
+	"github.com/purpose168/crush-cn/internal/ui/common"
+)
+
+const (
+	// PlanID 是计划审阅对话框的标识符。
+	PlanID = "plan"
+	// planEditorHeight 是计划编辑区域的高度。
+	planEditorHeight = 12
+)
+
+// Plan 是审阅计划模式下智能体写出的计划的对话框。计划以编号清单显示，
+// 用户可以增删或修改步骤，批准后智能体开始执行。
+type Plan struct {
+	com       *common.Common
+	help      help.Model
+	editor    textarea.Model
+	sessionID string
+	todos     []session.Todo
+	// original 是打开对话框时的计划文本，用于判断用户是否修改了计划
+	original string
+	// err 是上次批准时的解析错误
+	err error
+
+	keyMap struct {
+		Approve key.Binding
+		Newline key.Binding
+		Close   key.Binding
+	}
+}
+
+var _ Dialog = (*Plan)(nil)
+
+// NewPlan 使用会话当前的待办事项创建一个新的 [Plan] 对话框。
+func NewPlan(com *common.Common, sessionID string, todos []session.Todo) (*Plan, tea.Cmd) {
+	s := &Plan{com: com, sessionID: sessionID, todos: todos, original: session.FormatPlan(todos)}
+
+	help := help.New()
+	help.Styles = com.Styles.DialogHelpStyles()
+	s.help = help
+
+	s.editor = textarea.New()
+	s.editor.SetStyles(com.Styles.TextArea)
+	s.editor.ShowLineNumbers = false
+	s.editor.CharLimit = -1
+	s.editor.SetVirtualCursor(false)
+	s.editor.SetHeight(planEditorHeight)
+	s.editor.Placeholder = "1. [ ] 第一步"
+	s.editor.SetValue(s.original)
+	s.editor.MoveToEnd()
+
+	s.keyMap.Approve = key.NewBinding(
+		key.WithKeys("ctrl+s"),
+		key.WithHelp("ctrl+s", "批准并执行"),
+	)
+	s.keyMap.Newline = key.NewBinding(
+		key.WithKeys("enter"),
+		key.WithHelp("enter", "换行"),
+	)
+	s.keyMap.Close = CloseKey
+
+	return s, s.editor.Focus()
+}
+
+// ID 实现 Dialog 接口。
+func (s *Plan) ID() string {
+	return PlanID
+}
+
+// HandleMsg 实现 Dialog 接口。
+func (s *Plan) HandleMsg(msg tea.Msg) Action {
+	keyMsg, ok := msg.(tea.KeyPressMsg)
+	if !ok {
+		return nil
+	}
+
+	switch {
+	case key.Matches(keyMsg, s.keyMap.Close):
+		return ActionClose{}
+	case key.Matches(keyMsg, s.keyMap.Approve):
+		todos, err := session.ParsePlan(s.editor.Value(), s.todos)
+		if err != nil {
+			s.err = err
+			return nil
+		}
+		edited := session.FormatPlan(todos) != s.original
+		return ActionApprovePlan{SessionID: s.sessionID, Todos: todos, Edited: edited}
+	case key.Matches(keyMsg, s.keyMap.Newline):
+		s.editor.InsertRune('\n')
+	default:
+		var cmd tea.Cmd
+		s.editor, cmd = s.editor.Update(keyMsg)
+		return ActionCmd{cmd}
+	}
+	return nil
+}
+
+// Draw 实现 [Dialog] 接口。
+func (s *Plan) Draw(scr uv.Screen, area uv.Rectangle) *tea.Cursor {
+	t := s.com.Styles
+	width := max(0, min(defaultDialogMaxWidth, area.Dx()))
+	innerWidth := width - t.Dialog.View.GetHorizontalFrameSize() - 2
+
+	rc := NewRenderContext(t, width)
+	rc.Title = "审阅计划"
+
+	editorWidth := max(0, innerWidth-t.Dialog.InputPrompt.GetHorizontalFrameSize()-1)
+	s.editor.SetWidth(editorWidth)
+	rc.AddPart(t.Dialog.InputPrompt.Render(s.editor.View()))
+	hint := t.Subtle.Width(editorWidth).Render("每行一个步骤，[x] 表示已完成，[~] 表示进行中。按 esc 关闭后可以在对话中要求智能体修改计划。")
+	if s.err != nil {
+		hint = t.Dialog.TitleError.Width(editorWidth).Render(s.err.Error())
+	}
+	rc.AddPart(t.Dialog.InputPrompt.Render(hint))
+
+	s.help.SetWidth(innerWidth)
+	rc.Help = s.help.View(s)
+
+	cur := InputCursor(t, s.editor.Cursor())
+	view := rc.Render()
+	DrawCenterCursor(scr, area, view, cur)
+	return cur
+}
+
+// ShortHelp 实现 [help.KeyMap] 接口。
+func (s *Plan) ShortHelp() []key.Binding {
+	return []key.Binding{
+		s.keyMap.Approve,
+		s.keyMap.Newline,
+		s.keyMap.Close,
+	}
+}
+
+// FullHelp 实现 [help.KeyMap] 接口。
+func (s *Plan) FullHelp() [][]key.Binding {
+	return [][]key.Binding{s.ShortHelp()}
+}
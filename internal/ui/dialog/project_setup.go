@@ -0,0 +1,232 @@
+package dialog
+
+import (
+	"charm.land/bubbles/v2/help"
+	"charm.land/bubbles/v2/key"
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+	uv "github.com/charmbracelet/ultraviolet"
+	"github.com/purpose168/crush-cn/internal/projectsetup"
+	"github.com/purpose168/crush-cn/internal/ui/common"
+	"github.com/purpose168/crush-cn/internal/ui/list"
+	"github.com/purpose168/crush-cn/internal/ui/styles"
+)
+
+// ProjectSetupID 是项目设置建议对话框的标识符。
+const ProjectSetupID = "project_setup"
+
+// ProjectSetup 是一个显示根据工作区检测到的项目设置建议的对话框。所有建议默认选中，
+// 用户可以逐个取消，确认后选中的建议写入项目配置。
+type ProjectSetup struct {
+	com   *common.Common
+	help  help.Model
+	list  *list.List
+	items []*ProjectSetupItem
+
+	keyMap struct {
+		Next     key.Binding
+		Previous key.Binding
+		UpDown   key.Binding
+		Toggle   key.Binding
+		Apply    key.Binding
+		Close    key.Binding
+	}
+}
+
+var _ Dialog = (*ProjectSetup)(nil)
+
+// NewProjectSetup 为检测到的建议创建一个新的 [ProjectSetup] 对话框。
+func NewProjectSetup(com *common.Common, suggestions []projectsetup.Suggestion) *ProjectSetup {
+	d := &ProjectSetup{com: com}
+
+	d.help = help.New()
+	d.help.Styles = com.Styles.DialogHelpStyles()
+
+	d.list = list.NewList()
+	d.list.Focus()
+
+	d.keyMap.Next = key.NewBinding(
+		key.WithKeys("down", "ctrl+n"),
+		key.WithHelp("↓", "下一项"),
+	)
+	d.keyMap.Previous = key.NewBinding(
+		key.WithKeys("up", "ctrl+p"),
+		key.WithHelp("↑", "上一项"),
+	)
+	d.keyMap.UpDown = key.NewBinding(
+		key.WithKeys("up", "down"),
+		key.WithHelp("↑↓", "选择"),
+	)
+	d.keyMap.Toggle = key.NewBinding(
+		key.WithKeys("space"),
+		key.WithHelp("space", "选中/取消"),
+	)
+	d.keyMap.Apply = key.NewBinding(
+		key.WithKeys("enter", "ctrl+y"),
+		key.WithHelp("enter", "应用"),
+	)
+	d.keyMap.Close = key.NewBinding(
+		key.WithKeys("esc", "alt+esc"),
+		key.WithHelp("esc", "跳过"),
+	)
+
+	items := make([]list.Item, len(suggestions))
+	for i, s := range suggestions {
+		item := &ProjectSetupItem{suggestion: s, checked: true, t: com.Styles}
+		d.items = append(d.items, item)
+		items[i] = item
+	}
+	d.list.SetItems(items...)
+	d.list.SetSelected(0)
+	return d
+}
+
+// ID 实现 Dialog 接口。
+func (d *ProjectSetup) ID() string {
+	return ProjectSetupID
+}
+
+// HandleMsg 实现 Dialog 接口。
+func (d *ProjectSetup) HandleMsg(msg tea.Msg) Action {
+	keyMsg, ok := msg.(tea.KeyPressMsg)
+	if !ok {
+		return nil
+	}
+	switch {
+	case key.Matches(keyMsg, d.keyMap.Close):
+		return ActionSkipProjectSetup{}
+	case key.Matches(keyMsg, d.keyMap.Previous):
+		if d.list.IsSelectedFirst() {
+			d.list.SelectLast()
+			d.list.ScrollToBottom()
+			break
+		}
+		d.list.SelectPrev()
+		d.list.ScrollToSelected()
+	case key.Matches(keyMsg, d.keyMap.Next):
+		if d.list.IsSelectedLast() {
+			d.list.SelectFirst()
+			d.list.ScrollToTop()
+			break
+		}
+		d.list.SelectNext()
+		d.list.ScrollToSelected()
+	case key.Matches(keyMsg, d.keyMap.Toggle):
+		if idx := d.list.Selected(); idx >= 0 && idx < len(d.items) {
+			d.items[idx].toggle()
+		}
+	case key.Matches(keyMsg, d.keyMap.Apply):
+		var selected []projectsetup.Suggestion
+		for _, item := range d.items {
+			if item.checked {
+				selected = append(selected, item.suggestion)
+			}
+		}
+		return ActionApplyProjectSetup{Suggestions: selected}
+	}
+	return nil
+}
+
+// Draw 实现 [Dialog] 接口。
+func (d *ProjectSetup) Draw(scr uv.Screen, area uv.Rectangle) *tea.Cursor {
+	t := d.com.Styles
+	width := max(0, min(defaultDialogMaxWidth, area.Dx()))
+	height := max(0, min(defaultDialogHeight, area.Dy()))
+	innerWidth := width - t.Dialog.View.GetHorizontalFrameSize() - 2
+	heightOffset := t.Dialog.Title.GetVerticalFrameSize() + titleContentHeight +
+		t.Dialog.HelpView.GetVerticalFrameSize() +
+		t.Dialog.View.GetVerticalFrameSize()
+
+	rc := NewRenderContext(t, width)
+	rc.Title = "项目设置建议"
+
+	desc := t.Subtle.Width(innerWidth).Render("根据工作区检测到以下建议，选中的项目将写入 " + d.com.Config().ProjectConfigPath() + "：")
+	var detail string
+	if idx := d.list.Selected(); idx >= 0 && idx < len(d.items) {
+		detail = t.Subtle.Width(innerWidth).Render(d.items[idx].suggestion.Reason)
+	}
+	heightOffset += lipgloss.Height(desc) + lipgloss.Height(detail)
+
+	d.list.SetSize(innerWidth, max(0, min(len(d.items), height-heightOffset)))
+	d.help.SetWidth(innerWidth)
+
+	rc.AddPart(desc)
+	rc.AddPart(t.Dialog.List.Height(d.list.Height()).Render(d.list.Render()))
+	if detail != "" {
+		rc.AddPart(detail)
+	}
+	rc.Help = d.help.View(d)
+
+	DrawCenter(scr, area, rc.Render())
+	return nil
+}
+
+// ShortHelp 实现 [help.KeyMap] 接口。
+func (d *ProjectSetup) ShortHelp() []key.Binding {
+	return []key.Binding{
+		d.keyMap.UpDown,
+		d.keyMap.Toggle,
+		d.keyMap.Apply,
+		d.keyMap.Close,
+	}
+}
+
+// FullHelp 实现 [help.KeyMap] 接口。
+func (d *ProjectSetup) FullHelp() [][]key.Binding {
+	return [][]key.Binding{d.ShortHelp()}
+}
+
+// ProjectSetupItem 表示项目设置建议对话框中的单条建议。
+type ProjectSetupItem struct {
+	suggestion projectsetup.Suggestion
+	checked    bool
+	t          *styles.Styles
+	cache      map[int]string
+	focused    bool
+}
+
+var (
+	_ list.Item      = (*ProjectSetupItem)(nil)
+	_ list.Focusable = (*ProjectSetupItem)(nil)
+)
+
+// toggle 切换建议的选中状态并清除渲染缓存。
+func (i *ProjectSetupItem) toggle() {
+	i.checked = !i.checked
+	i.cache = nil
+}
+
+// SetFocused 设置项目的焦点状态。
+func (i *ProjectSetupItem) SetFocused(focused bool) {
+	if i.focused != focused {
+		i.cache = nil
+	}
+	i.focused = focused
+}
+
+// Render 返回项目的字符串表示。
+func (i *ProjectSetupItem) Render(width int) string {
+	if i.cache == nil {
+		i.cache = make(map[int]string)
+	}
+	itemStyles := ListItemStyles{
+		ItemBlurred:     i.t.Dialog.NormalItem,
+		ItemFocused:     i.t.Dialog.SelectedItem,
+		InfoTextBlurred: i.t.Subtle,
+		InfoTextFocused: i.t.Base,
+	}
+	check := "[ ] "
+	if i.checked {
+		check = "[x] "
+	}
+	var title, info string
+	switch i.suggestion.Kind {
+	case projectsetup.DisableTool:
+		title, info = "禁用工具 "+i.suggestion.Name, "工具"
+	case projectsetup.EnableLSP:
+		title, info = "启用 LSP "+i.suggestion.Name, "LSP"
+	case projectsetup.ContextPath:
+		title, info = "添加上下文 "+i.suggestion.Name, "上下文"
+	}
+	return renderItem(itemStyles, check+title, info, i.focused, width, i.cache, nil)
+}
@@ -0,0 +1,216 @@
+package dialog
+
+import (
+	"strings"
+
+	"charm.land/bubbles/v2/help"
+	"charm.land/bubbles/v2/key"
+	"charm.land/bubbles/v2/spinner"
+	tea "charm.land/bubbletea/v2"
+	uv "github.com/charmbracelet/ultraviolet"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/purpose168/crush-cn/internal/message"
+	"github.com/purpose168/crush-cn/internal/promptlint"
+	"github.com/purpose168/crush-cn/internal/ui/common"
+)
+
+const (
+	// PromptLintID 是提示建议对话框的标识符。
+	PromptLintID = "prompt_lint"
+	// promptLintPreviewLines 是提示和改写结果显示的最大行数。
+	promptLintPreviewLines = 12
+)
+
+// PromptLint 是发送前发现提示含糊时显示的对话框。它列出发现的问题，
+// 用户可以仍然发送、返回编辑，或让小模型结合会话上下文改写提示并预览改写结果。
+type PromptLint struct {
+	com         *common.Common
+	help        help.Model
+	spinner     spinner.Model
+	content     string
+	attachments []message.Attachment
+	issues      []promptlint.Issue
+	// rewritten 是改写后的提示，为空表示尚未改写
+	rewritten string
+	loading   bool
+	// err 是上次改写失败的错误
+	err error
+
+	keyMap struct {
+		Send         key.Binding
+		Enhance      key.Binding
+		SendRewrite  key.Binding
+		SendOriginal key.Binding
+		EditRewrite  key.Binding
+		Close        key.Binding
+	}
+}
+
+var (
+	_ Dialog        = (*PromptLint)(nil)
+	_ LoadingDialog = (*PromptLint)(nil)
+)
+
+// NewPromptLint 为待发送的提示和发现的问题创建提示建议对话框。
+func NewPromptLint(com *common.Common, content string, attachments []message.Attachment, issues []promptlint.Issue) *PromptLint {
+	p := &PromptLint{
+		com:         com,
+		content:     content,
+		attachments: attachments,
+		issues:      issues,
+	}
+
+	p.help = help.New()
+	p.help.Styles = com.Styles.DialogHelpStyles()
+
+	p.spinner = spinner.New()
+	p.spinner.Spinner = spinner.Dot
+	p.spinner.Style = com.Styles.Dialog.Spinner
+
+	p.keyMap.Send = key.NewBinding(
+		key.WithKeys("enter"),
+		key.WithHelp("enter", "仍然发送"),
+	)
+	p.keyMap.Enhance = key.NewBinding(
+		key.WithKeys("e"),
+		key.WithHelp("e", "改写"),
+	)
+	p.keyMap.SendRewrite = key.NewBinding(
+		key.WithKeys("enter"),
+		key.WithHelp("enter", "发送改写"),
+	)
+	p.keyMap.SendOriginal = key.NewBinding(
+		key.WithKeys("o"),
+		key.WithHelp("o", "发送原文"),
+	)
+	p.keyMap.EditRewrite = key.NewBinding(
+		key.WithKeys("r"),
+		key.WithHelp("r", "编辑改写"),
+	)
+	p.keyMap.Close = key.NewBinding(
+		key.WithKeys("esc", "alt+esc"),
+		key.WithHelp("esc", "返回编辑"),
+	)
+	return p
+}
+
+// ID 实现 [Dialog] 接口。
+func (*PromptLint) ID() string {
+	return PromptLintID
+}
+
+// SetRewritten 设置改写结果并结束加载状态，改写失败时显示错误。
+func (p *PromptLint) SetRewritten(rewritten string, err error) {
+	p.loading = false
+	p.err = err
+	if err == nil {
+		p.rewritten = rewritten
+	}
+}
+
+// HandleMsg 实现 [Dialog] 接口。
+func (p *PromptLint) HandleMsg(msg tea.Msg) Action {
+	switch msg := msg.(type) {
+	case spinner.TickMsg:
+		if p.loading {
+			var cmd tea.Cmd
+			p.spinner, cmd = p.spinner.Update(msg)
+			return ActionCmd{Cmd: cmd}
+		}
+	case tea.KeyPressMsg:
+		switch {
+		case key.Matches(msg, p.keyMap.Close):
+			return ActionEditPrompt{Content: p.content, Attachments: p.attachments}
+		case p.loading:
+		case p.rewritten != "":
+			switch {
+			case key.Matches(msg, p.keyMap.SendRewrite):
+				return ActionSendPrompt{Content: p.rewritten, Attachments: p.attachments}
+			case key.Matches(msg, p.keyMap.SendOriginal):
+				return ActionSendPrompt{Content: p.content, Attachments: p.attachments}
+			case key.Matches(msg, p.keyMap.EditRewrite):
+				return ActionEditPrompt{Content: p.rewritten, Attachments: p.attachments}
+			}
+		case key.Matches(msg, p.keyMap.Send):
+			return ActionSendPrompt{Content: p.content, Attachments: p.attachments}
+		case key.Matches(msg, p.keyMap.Enhance):
+			return ActionEnhancePrompt{Content: p.content}
+		}
+	}
+	return nil
+}
+
+// Draw 实现 [Dialog] 接口。
+func (p *PromptLint) Draw(scr uv.Screen, area uv.Rectangle) *tea.Cursor {
+	t := p.com.Styles
+	width := max(0, min(defaultDialogMaxWidth, area.Dx()))
+	innerWidth := width - t.Dialog.View.GetHorizontalFrameSize() - 2
+
+	rc := NewRenderContext(t, width)
+	rc.Title = "提示建议"
+
+	rc.AddPart(p.renderPreview(p.content, innerWidth))
+	if p.rewritten == "" {
+		issues := make([]string, 0, len(p.issues))
+		for _, issue := range p.issues {
+			issues = append(issues, "• "+issue.Message)
+		}
+		rc.AddPart(t.Subtle.Width(innerWidth).Render(strings.Join(issues, "\n")))
+	} else {
+		rc.AddPart(t.Subtle.Render("改写后："))
+		rc.AddPart(p.renderPreview(p.rewritten, innerWidth))
+	}
+	if p.err != nil {
+		rc.AddPart(t.Dialog.TitleError.Width(innerWidth).Render("改写失败：" + p.err.Error()))
+	}
+
+	p.help.SetWidth(innerWidth)
+	rc.Help = p.help.View(p)
+	if p.loading {
+		rc.Help = p.spinner.View() + " 正在改写提示..."
+	}
+
+	DrawCenter(scr, area, rc.Render())
+	return nil
+}
+
+// renderPreview 渲染提示的开头，超出 [promptLintPreviewLines] 的行会被省略。
+func (p *PromptLint) renderPreview(text string, width int) string {
+	t := p.com.Styles
+	lines := strings.Split(ansi.Wordwrap(ansi.Strip(text), width, ""), "\n")
+	more := len(lines) - promptLintPreviewLines
+	lines = lines[:min(len(lines), promptLintPreviewLines)]
+	preview := t.Base.Render(strings.Join(lines, "\n"))
+	if more > 0 {
+		preview += "\n" + t.Subtle.Render("… 还有更多内容")
+	}
+	return preview
+}
+
+// StartLoading 实现 [LoadingDialog] 接口。
+func (p *PromptLint) StartLoading() tea.Cmd {
+	if p.loading {
+		return nil
+	}
+	p.loading = true
+	p.err = nil
+	return p.spinner.Tick
+}
+
+// StopLoading 实现 [LoadingDialog] 接口。
+func (p *PromptLint) StopLoading() {
+	p.loading = false
+}
+
+// ShortHelp 实现 [help.KeyMap] 接口。
+func (p *PromptLint) ShortHelp() []key.Binding {
+	if p.rewritten != "" {
+		return []key.Binding{p.keyMap.SendRewrite, p.keyMap.SendOriginal, p.keyMap.EditRewrite, p.keyMap.Close}
+	}
+	return []key.Binding{p.keyMap.Send, p.keyMap.Enhance, p.keyMap.Close}
+}
+
+// FullHelp 实现 [help.KeyMap] 接口。
+func (p *PromptLint) FullHelp() [][]key.Binding {
+	return [][]key.Binding{p.ShortHelp()}
+}
@@ -0,0 +1,396 @@
+package dialog
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"charm.land/bubbles/v2/help"
+	"charm.land/bubbles/v2/key"
+	"charm.land/bubbles/v2/textarea"
+	tea "charm.land/bubbletea/v2"
+	uv "github.com/charmbracelet/ultraviolet"
+	"github.com/purpose168/crush-cn/internal/ui/common"
+	"github.com/purpose168/crush-cn/internal/ui/list"
+	"github.com/purpose168/crush-cn/internal/ui/styles"
+	"github.com/purpose168/crush-cn/internal/ui/util"
+)
+
+const (
+	// QueueID 是提示队列管理对话框的标识符。
+	QueueID = "queue"
+	// queueEditorHeight 是编辑排队提示时文本区域的高度。
+	queueEditorHeight = 5
+)
+
+// errQueueChanged 表示在操作期间队列已被智能体修改。
+var errQueueChanged = errors.New("队列已变化，请重试")
+
+type queueMode uint8
+
+// 队列对话框可以处于的可能模式
+const (
+	queueModeNormal queueMode = iota
+	queueModeEditing
+)
+
+// Queue 是一个用于查看、重新排序、编辑和删除排队提示的对话框。
+type Queue struct {
+	com       *common.Common
+	help      help.Model
+	list      *list.List
+	editor    textarea.Model
+	sessionID string
+	prompts   []string
+
+	queueMode queueMode
+	// editing 是正在编辑的提示的原始内容，用于检测队列是否已变化。
+	editing string
+
+	keyMap struct {
+		Next        key.Binding
+		Previous    key.Binding
+		UpDown      key.Binding
+		MoveUp      key.Binding
+		MoveDown    key.Binding
+		Move        key.Binding
+		Edit        key.Binding
+		Delete      key.Binding
+		ConfirmEdit key.Binding
+		CancelEdit  key.Binding
+		Newline     key.Binding
+		Close       key.Binding
+	}
+}
+
+var _ Dialog = (*Queue)(nil)
+
+// NewQueue 为给定会话创建一个新的 [Queue] 对话框。
+func NewQueue(com *common.Common, sessionID string) (*Queue, error) {
+	if com.App.AgentCoordinator == nil {
+		return nil, errors.New("智能体未配置")
+	}
+
+	q := &Queue{com: com, sessionID: sessionID}
+
+	help := help.New()
+	help.Styles = com.Styles.DialogHelpStyles()
+	q.help = help
+
+	q.list = list.NewList()
+	q.list.Focus()
+
+	q.editor = textarea.New()
+	q.editor.SetStyles(com.Styles.TextArea)
+	q.editor.ShowLineNumbers = false
+	q.editor.CharLimit = -1
+	q.editor.SetVirtualCursor(false)
+	q.editor.SetHeight(queueEditorHeight)
+
+	q.keyMap.Next = key.NewBinding(
+		key.WithKeys("down", "ctrl+n"),
+		key.WithHelp("↓", "下一项"),
+	)
+	q.keyMap.Previous = key.NewBinding(
+		key.WithKeys("up", "ctrl+p"),
+		key.WithHelp("↑", "上一项"),
+	)
+	q.keyMap.UpDown = key.NewBinding(
+		key.WithKeys("up", "down"),
+		key.WithHelp("↑↓", "选择"),
+	)
+	q.keyMap.MoveUp = key.NewBinding(
+		key.WithKeys("shift+up", "ctrl+k"),
+		key.WithHelp("shift+↑", "上移"),
+	)
+	q.keyMap.MoveDown = key.NewBinding(
+		key.WithKeys("shift+down", "ctrl+j"),
+		key.WithHelp("shift+↓", "下移"),
+	)
+	q.keyMap.Move = key.NewBinding(
+		key.WithKeys("shift+up", "shift+down"),
+		key.WithHelp("shift+↑↓", "移动"),
+	)
+	q.keyMap.Edit = key.NewBinding(
+		key.WithKeys("enter", "ctrl+e"),
+		key.WithHelp("enter", "编辑"),
+	)
+	q.keyMap.Delete = key.NewBinding(
+		key.WithKeys("ctrl+x", "delete"),
+		key.WithHelp("ctrl+x", "删除"),
+	)
+	q.keyMap.ConfirmEdit = key.NewBinding(
+		key.WithKeys("enter"),
+		key.WithHelp("enter", "保存"),
+	)
+	q.keyMap.CancelEdit = key.NewBinding(
+		key.WithKeys("esc"),
+		key.WithHelp("esc", "取消"),
+	)
+	q.keyMap.Newline = key.NewBinding(
+		key.WithKeys("shift+enter", "ctrl+j"),
+		key.WithHelp("ctrl+j", "换行"),
+	)
+	q.keyMap.Close = CloseKey
+
+	q.reload()
+	if len(q.prompts) == 0 {
+		return nil, errors.New("队列中没有提示")
+	}
+	q.list.SetSelected(0)
+
+	return q, nil
+}
+
+// ID 实现 Dialog 接口。
+func (q *Queue) ID() string {
+	return QueueID
+}
+
+// HandleMsg 实现 Dialog 接口。
+func (q *Queue) HandleMsg(msg tea.Msg) Action {
+	keyMsg, ok := msg.(tea.KeyPressMsg)
+	if !ok {
+		return nil
+	}
+
+	if q.queueMode == queueModeEditing {
+		switch {
+		case key.Matches(keyMsg, q.keyMap.Newline):
+			q.editor.InsertRune('\n')
+		case key.Matches(keyMsg, q.keyMap.ConfirmEdit):
+			return q.confirmEdit()
+		case key.Matches(keyMsg, q.keyMap.CancelEdit):
+			q.queueMode = queueModeNormal
+			q.editor.Blur()
+		default:
+			var cmd tea.Cmd
+			q.editor, cmd = q.editor.Update(keyMsg)
+			return ActionCmd{cmd}
+		}
+		return nil
+	}
+
+	q.reload()
+	switch {
+	case key.Matches(keyMsg, q.keyMap.Close):
+		return ActionClose{}
+	case key.Matches(keyMsg, q.keyMap.MoveUp):
+		return q.move(-1)
+	case key.Matches(keyMsg, q.keyMap.MoveDown):
+		return q.move(1)
+	case key.Matches(keyMsg, q.keyMap.Previous):
+		if q.list.IsSelectedFirst() {
+			q.list.SelectLast()
+			q.list.ScrollToBottom()
+			break
+		}
+		q.list.SelectPrev()
+		q.list.ScrollToSelected()
+	case key.Matches(keyMsg, q.keyMap.Next):
+		if q.list.IsSelectedLast() {
+			q.list.SelectFirst()
+			q.list.ScrollToTop()
+			break
+		}
+		q.list.SelectNext()
+		q.list.ScrollToSelected()
+	case key.Matches(keyMsg, q.keyMap.Edit):
+		idx := q.list.Selected()
+		if idx < 0 || idx >= len(q.prompts) {
+			break
+		}
+		q.queueMode = queueModeEditing
+		q.editing = q.prompts[idx]
+		q.editor.SetValue(q.editing)
+		q.editor.MoveToEnd()
+		return ActionCmd{q.editor.Focus()}
+	case key.Matches(keyMsg, q.keyMap.Delete):
+		return q.remove()
+	}
+	return nil
+}
+
+// reload 从智能体重新加载排队的提示。如果队列未变化则不做任何事。
+func (q *Queue) reload() {
+	prompts := q.com.App.AgentCoordinator.QueuedPromptsList(q.sessionID)
+	if slices.Equal(prompts, q.prompts) {
+		return
+	}
+	q.prompts = prompts
+
+	selected := q.list.Selected()
+	items := make([]list.Item, len(prompts))
+	for i, p := range prompts {
+		items[i] = &QueueItem{index: i, prompt: p, t: q.com.Styles}
+	}
+	q.list.SetItems(items...)
+	q.list.SetSelected(min(max(selected, 0), len(prompts)-1))
+}
+
+// move 将选中的提示上移 (-1) 或下移 (1)。
+func (q *Queue) move(delta int) Action {
+	from := q.list.Selected()
+	to := from + delta
+	if from < 0 || to < 0 || to >= len(q.prompts) {
+		return nil
+	}
+	if err := q.com.App.AgentCoordinator.MoveQueuedPrompt(q.sessionID, from, to); err != nil {
+		return ActionCmd{util.ReportError(err)}
+	}
+	q.reload()
+	q.list.SetSelected(to)
+	q.list.ScrollToSelected()
+	return nil
+}
+
+// remove 删除选中的提示。
+func (q *Queue) remove() Action {
+	idx := q.list.Selected()
+	if idx < 0 || idx >= len(q.prompts) {
+		return nil
+	}
+	if err := q.com.App.AgentCoordinator.RemoveQueuedPrompt(q.sessionID, idx); err != nil {
+		return ActionCmd{util.ReportError(err)}
+	}
+	q.reload()
+	if len(q.prompts) == 0 {
+		return ActionClose{}
+	}
+	q.list.ScrollToSelected()
+	return nil
+}
+
+// confirmEdit 保存正在编辑的提示。
+func (q *Queue) confirmEdit() Action {
+	q.queueMode = queueModeNormal
+	q.editor.Blur()
+
+	prompt := strings.TrimSpace(q.editor.Value())
+	if prompt == "" {
+		return nil
+	}
+
+	idx := q.list.Selected()
+	current := q.com.App.AgentCoordinator.QueuedPromptsList(q.sessionID)
+	if idx < 0 || idx >= len(current) || current[idx] != q.editing {
+		q.reload()
+		return ActionCmd{util.ReportWarn(errQueueChanged.Error())}
+	}
+	if err := q.com.App.AgentCoordinator.EditQueuedPrompt(q.sessionID, idx, prompt); err != nil {
+		return ActionCmd{util.ReportError(err)}
+	}
+	q.reload()
+	return nil
+}
+
+// Draw 实现 [Dialog] 接口。
+func (q *Queue) Draw(scr uv.Screen, area uv.Rectangle) *tea.Cursor {
+	if q.queueMode == queueModeNormal {
+		// 智能体可能在对话框打开期间消费了队列中的提示。
+		q.reload()
+	}
+
+	t := q.com.Styles
+	width := max(0, min(defaultDialogMaxWidth, area.Dx()))
+	height := max(0, min(defaultDialogHeight, area.Dy()))
+	innerWidth := width - t.Dialog.View.GetHorizontalFrameSize() - 2
+	heightOffset := t.Dialog.Title.GetVerticalFrameSize() + titleContentHeight +
+		t.Dialog.HelpView.GetVerticalFrameSize() +
+		t.Dialog.View.GetVerticalFrameSize()
+
+	rc := NewRenderContext(t, width)
+	rc.Title = fmt.Sprintf("排队的提示 (%d)", len(q.prompts))
+
+	var cur *tea.Cursor
+	if q.queueMode == queueModeEditing {
+		q.editor.SetWidth(max(0, innerWidth-t.Dialog.InputPrompt.GetHorizontalFrameSize()-1))
+		rc.AddPart(t.Dialog.InputPrompt.Render(q.editor.View()))
+		cur = InputCursor(t, q.editor.Cursor())
+		heightOffset += t.Dialog.InputPrompt.GetVerticalFrameSize() + queueEditorHeight
+	}
+
+	q.list.SetSize(innerWidth, max(0, height-heightOffset))
+	q.help.SetWidth(innerWidth)
+
+	listView := t.Dialog.List.Height(q.list.Height()).Render(q.list.Render())
+	rc.AddPart(listView)
+	rc.Help = q.help.View(q)
+
+	view := rc.Render()
+
+	DrawCenterCursor(scr, area, view, cur)
+	return cur
+}
+
+// ShortHelp 实现 [help.KeyMap] 接口。
+func (q *Queue) ShortHelp() []key.Binding {
+	if q.queueMode == queueModeEditing {
+		return []key.Binding{
+			q.keyMap.ConfirmEdit,
+			q.keyMap.Newline,
+			q.keyMap.CancelEdit,
+		}
+	}
+	return []key.Binding{
+		q.keyMap.UpDown,
+		q.keyMap.Move,
+		q.keyMap.Edit,
+		q.keyMap.Delete,
+		q.keyMap.Close,
+	}
+}
+
+// FullHelp 实现 [help.KeyMap] 接口。
+func (q *Queue) FullHelp() [][]key.Binding {
+	m := [][]key.Binding{}
+	slice := q.ShortHelp()
+	for i := 0; i < len(slice); i += 4 {
+		end := min(i+4, len(slice))
+		m = append(m, slice[i:end])
+	}
+	return m
+}
+
+// QueueItem 表示队列对话框中的单个排队提示。
+type QueueItem struct {
+	index   int
+	prompt  string
+	t       *styles.Styles
+	cache   map[int]string
+	focused bool
+}
+
+var (
+	_ list.Item      = (*QueueItem)(nil)
+	_ list.Focusable = (*QueueItem)(nil)
+)
+
+// SetFocused 设置队列项目的焦点状态。
+func (q *QueueItem) SetFocused(focused bool) {
+	if q.focused != focused {
+		q.cache = nil
+	}
+	q.focused = focused
+}
+
+// Render 返回队列项目的字符串表示。
+func (q *QueueItem) Render(width int) string {
+	if q.cache == nil {
+		q.cache = make(map[int]string)
+	}
+	styles := ListItemStyles{
+		ItemBlurred:     q.t.Dialog.NormalItem,
+		ItemFocused:     q.t.Dialog.SelectedItem,
+		InfoTextBlurred: q.t.Subtle,
+		InfoTextFocused: q.t.Base,
+	}
+	// 多行提示只显示第一行。
+	title, _, multiline := strings.Cut(q.prompt, "\n")
+	if multiline {
+		title += " …"
+	}
+	info := fmt.Sprintf("#%d", q.index+1)
+	return renderItem(styles, title, info, q.focused, width, q.cache, nil)
+}
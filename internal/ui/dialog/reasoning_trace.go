@@ -0,0 +1,315 @@
+package dialog
+
+import (
+	"cmp"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"charm.land/bubbles/v2/help"
+	"charm.land/bubbles/v2/key"
+	"charm.land/bubbles/v2/textinput"
+	tea "charm.land/bubbletea/v2"
+	uv "github.com/charmbracelet/ultraviolet"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/purpose168/crush-cn/internal/ui/chat"
+	"github.com/purpose168/crush-cn/internal/ui/common"
+)
+
+// ReasoningTraceID 是推理过程查看对话框的标识符。
+const ReasoningTraceID = "reasoning_trace"
+
+// ReasoningTrace 是查看助手消息完整推理过程的对话框，支持在推理文本中搜索。
+type ReasoningTrace struct {
+	com   *common.Common
+	help  help.Model
+	input textinput.Model
+	trace chat.ReasoningTrace
+
+	lines     []string // 按 wrapWidth 换行后的推理文本
+	wrapWidth int
+	offset    int // 第一行可见行
+	height    int // 上次绘制时的可见行数
+	matches   []int
+	current   int
+
+	keyMap struct {
+		Next      key.Binding
+		Previous  key.Binding
+		UpDown    key.Binding
+		PageDown  key.Binding
+		PageUp    key.Binding
+		NextMatch key.Binding
+		PrevMatch key.Binding
+		Close     key.Binding
+	}
+}
+
+var _ Dialog = (*ReasoningTrace)(nil)
+
+// NewReasoningTrace 创建一个新的 [ReasoningTrace] 对话框。
+func NewReasoningTrace(com *common.Common, trace chat.ReasoningTrace) *ReasoningTrace {
+	r := &ReasoningTrace{
+		com:   com,
+		trace: trace,
+	}
+
+	help := help.New()
+	help.Styles = com.Styles.DialogHelpStyles()
+	r.help = help
+
+	r.input = textinput.New()
+	r.input.SetVirtualCursor(false)
+	r.input.Placeholder = "搜索推理过程"
+	r.input.SetStyles(com.Styles.TextInput)
+	r.input.Focus()
+
+	r.keyMap.Next = key.NewBinding(
+		key.WithKeys("down", "ctrl+j"),
+		key.WithHelp("↓", "向下滚动"),
+	)
+	r.keyMap.Previous = key.NewBinding(
+		key.WithKeys("up", "ctrl+k"),
+		key.WithHelp("↑", "向上滚动"),
+	)
+	r.keyMap.UpDown = key.NewBinding(
+		key.WithKeys("up", "down"),
+		key.WithHelp("↑↓", "滚动"),
+	)
+	r.keyMap.PageDown = key.NewBinding(
+		key.WithKeys("pgdown"),
+		key.WithHelp("pgdn", "下一页"),
+	)
+	r.keyMap.PageUp = key.NewBinding(
+		key.WithKeys("pgup"),
+		key.WithHelp("pgup", "上一页"),
+	)
+	r.keyMap.NextMatch = key.NewBinding(
+		key.WithKeys("enter", "ctrl+n"),
+		key.WithHelp("enter", "下一处"),
+	)
+	r.keyMap.PrevMatch = key.NewBinding(
+		key.WithKeys("shift+enter", "ctrl+p"),
+		key.WithHelp("ctrl+p", "上一处"),
+	)
+	r.keyMap.Close = CloseKey
+	return r
+}
+
+// ID 实现 Dialog 接口。
+func (r *ReasoningTrace) ID() string {
+	return ReasoningTraceID
+}
+
+// MessageID 返回正在查看的助手消息的 ID。
+func (r *ReasoningTrace) MessageID() string {
+	return r.trace.ID
+}
+
+// SetTrace 在推理仍在进行时更新显示的推理过程，保持滚动位置和搜索。
+func (r *ReasoningTrace) SetTrace(trace chat.ReasoningTrace) {
+	atBottom := r.offset >= r.maxOffset()
+	r.trace = trace
+	r.lines = nil
+	r.wrap(cmp.Or(r.wrapWidth, defaultDialogMaxWidth))
+	if atBottom && len(r.matches) == 0 {
+		r.offset = r.maxOffset()
+	}
+}
+
+// HandleMsg 实现 Dialog 接口。
+func (r *ReasoningTrace) HandleMsg(msg tea.Msg) Action {
+	keyMsg, ok := msg.(tea.KeyPressMsg)
+	if !ok {
+		return nil
+	}
+	switch {
+	case key.Matches(keyMsg, r.keyMap.Close):
+		return ActionClose{}
+	case key.Matches(keyMsg, r.keyMap.Previous):
+		r.scrollBy(-1)
+	case key.Matches(keyMsg, r.keyMap.Next):
+		r.scrollBy(1)
+	case key.Matches(keyMsg, r.keyMap.PageUp):
+		r.scrollBy(-max(1, r.height-1))
+	case key.Matches(keyMsg, r.keyMap.PageDown):
+		r.scrollBy(max(1, r.height-1))
+	case key.Matches(keyMsg, r.keyMap.NextMatch):
+		r.jumpToMatch(r.current + 1)
+	case key.Matches(keyMsg, r.keyMap.PrevMatch):
+		r.jumpToMatch(r.current - 1)
+	default:
+		before := r.input.Value()
+		var cmd tea.Cmd
+		r.input, cmd = r.input.Update(keyMsg)
+		if r.input.Value() != before {
+			r.search()
+			r.jumpToMatch(0)
+		}
+		return ActionCmd{cmd}
+	}
+	return nil
+}
+
+// search 查找包含搜索词的行，不区分大小写
+func (r *ReasoningTrace) search() {
+	r.matches = r.matches[:0]
+	query := strings.ToLower(strings.TrimSpace(r.input.Value()))
+	if query == "" {
+		return
+	}
+	for i, line := range r.lines {
+		if strings.Contains(strings.ToLower(line), query) {
+			r.matches = append(r.matches, i)
+		}
+	}
+	r.current = min(r.current, max(0, len(r.matches)-1))
+}
+
+// jumpToMatch 滚动到第 i 处匹配，超出范围时循环
+func (r *ReasoningTrace) jumpToMatch(i int) {
+	if len(r.matches) == 0 {
+		return
+	}
+	r.current = (i%len(r.matches) + len(r.matches)) % len(r.matches)
+	line := r.matches[r.current]
+	if line < r.offset || line >= r.offset+r.height {
+		r.offset = min(max(0, line-r.height/2), r.maxOffset())
+	}
+}
+
+func (r *ReasoningTrace) scrollBy(n int) {
+	r.offset = min(max(0, r.offset+n), r.maxOffset())
+}
+
+func (r *ReasoningTrace) maxOffset() int {
+	return max(0, len(r.lines)-r.height)
+}
+
+// wrap 按宽度对推理文本换行，宽度不变时不做任何事
+func (r *ReasoningTrace) wrap(width int) {
+	if width == r.wrapWidth && r.lines != nil {
+		return
+	}
+	r.wrapWidth = width
+	r.lines = r.lines[:0]
+	for line := range strings.SplitSeq(r.trace.Text, "\n") {
+		wrapped := ansi.Wrap(strings.TrimRight(line, "\r"), width, "")
+		r.lines = append(r.lines, strings.Split(wrapped, "\n")...)
+	}
+	r.search()
+}
+
+// summary 返回推理用时、令牌数和搜索结果
+func (r *ReasoningTrace) summary() string {
+	var parts []string
+	switch {
+	case r.trace.Streaming:
+		parts = append(parts, "推理中")
+	case r.trace.Duration > 0:
+		parts = append(parts, "用时 "+r.trace.Duration.String())
+	}
+	if r.trace.ReasoningTokens > 0 {
+		parts = append(parts, fmt.Sprintf("%d 个推理令牌", r.trace.ReasoningTokens))
+	} else if !r.trace.Discarded {
+		parts = append(parts, fmt.Sprintf("%d 个字符", utf8.RuneCountInString(r.trace.Text)))
+	}
+	if strings.TrimSpace(r.input.Value()) != "" {
+		if len(r.matches) == 0 {
+			parts = append(parts, "无匹配")
+		} else {
+			parts = append(parts, fmt.Sprintf("第 %d/%d 处", r.current+1, len(r.matches)))
+		}
+	}
+	return strings.Join(parts, " · ")
+}
+
+// renderLine 渲染一行推理文本，高亮其中的搜索词
+func (r *ReasoningTrace) renderLine(i int) string {
+	t := r.com.Styles
+	line := r.lines[i]
+	query := strings.TrimSpace(r.input.Value())
+	if query == "" {
+		return t.Base.Render(line)
+	}
+	// 当前匹配所在行整行高亮，其他匹配只高亮搜索词
+	if len(r.matches) > 0 && r.matches[r.current] == i {
+		return t.TextSelection.Render(line)
+	}
+	lower := strings.ToLower(line)
+	needle := strings.ToLower(query)
+	var sb strings.Builder
+	for {
+		idx := strings.Index(lower, needle)
+		if idx < 0 || len(lower) != len(line) {
+			sb.WriteString(t.Base.Render(line))
+			break
+		}
+		sb.WriteString(t.Base.Render(line[:idx]))
+		sb.WriteString(t.Completions.Match.Render(line[idx : idx+len(needle)]))
+		line, lower = line[idx+len(needle):], lower[idx+len(needle):]
+	}
+	return sb.String()
+}
+
+// Cursor 返回相对于对话框的光标位置。
+func (r *ReasoningTrace) Cursor() *tea.Cursor {
+	return InputCursor(r.com.Styles, r.input.Cursor())
+}
+
+// Draw 实现 [Dialog] 接口。
+func (r *ReasoningTrace) Draw(scr uv.Screen, area uv.Rectangle) *tea.Cursor {
+	t := r.com.Styles
+	width := max(0, min(defaultDialogMaxWidth, area.Dx()))
+	height := max(0, area.Dy()*3/4)
+	innerWidth := width - t.Dialog.View.GetHorizontalFrameSize() - 2
+	heightOffset := t.Dialog.Title.GetVerticalFrameSize() + titleContentHeight +
+		t.Dialog.InputPrompt.GetVerticalFrameSize() + inputContentHeight +
+		1 + // 摘要行
+		t.Dialog.HelpView.GetVerticalFrameSize() +
+		t.Dialog.View.GetVerticalFrameSize()
+	r.input.SetWidth(max(0, innerWidth-t.Dialog.InputPrompt.GetHorizontalFrameSize()-1)) // (1) 光标填充
+	r.help.SetWidth(innerWidth)
+	r.wrap(max(1, innerWidth))
+	r.height = max(1, height-heightOffset)
+	r.offset = min(r.offset, r.maxOffset())
+
+	rc := NewRenderContext(t, width)
+	rc.Title = "推理过程"
+	rc.AddPart(t.Dialog.InputPrompt.Render(r.input.View()))
+	rc.AddPart(t.Subtle.Render(ansi.Truncate(r.summary(), innerWidth, "…")))
+
+	var body string
+	switch {
+	case r.trace.Discarded:
+		body = t.Subtle.Render("推理内容未保存（已启用 discard_reasoning）")
+	case strings.TrimSpace(r.trace.Text) == "":
+		body = t.Subtle.Render("没有推理内容")
+	default:
+		visible := make([]string, 0, r.height)
+		for i := r.offset; i < min(len(r.lines), r.offset+r.height); i++ {
+			visible = append(visible, r.renderLine(i))
+		}
+		body = strings.Join(visible, "\n")
+	}
+	rc.AddPart(t.Dialog.List.Height(r.height).Render(body))
+	rc.Help = r.help.View(r)
+
+	cur := r.Cursor()
+	DrawCenterCursor(scr, area, rc.Render(), cur)
+	return cur
+}
+
+// ShortHelp 实现 [help.KeyMap] 接口。
+func (r *ReasoningTrace) ShortHelp() []key.Binding {
+	bindings := []key.Binding{r.keyMap.UpDown, r.keyMap.PageDown}
+	if len(r.matches) > 0 {
+		bindings = append(bindings, r.keyMap.NextMatch, r.keyMap.PrevMatch)
+	}
+	return append(bindings, r.keyMap.Close)
+}
+
+// FullHelp 实现 [help.KeyMap] 接口。
+func (r *ReasoningTrace) FullHelp() [][]key.Binding {
+	return [][]key.Binding{r.ShortHelp()}
+}
@@ -0,0 +1,204 @@
+package dialog
+
+import (
+	"charm.land/bubbles/v2/help"
+	"charm.land/bubbles/v2/key"
+	tea "charm.land/bubbletea/v2"
+	uv "github.com/charmbracelet/ultraviolet"
+	"github.com/purpose168/crush-cn/internal/config"
+	"github.com/purpose168/crush-cn/internal/ui/common"
+	"github.com/purpose168/crush-cn/internal/ui/list"
+	"github.com/purpose168/crush-cn/internal/ui/styles"
+)
+
+// RegenerateID 是重新生成回复对话框的标识符。
+const RegenerateID = "regenerate"
+
+// RegenerateModel 是重新生成回复时可选的模型。
+type RegenerateModel struct {
+	Model config.SelectedModel
+	// Name 和 Provider 是模型和提供商的显示名称。
+	Name     string
+	Provider string
+	// Current 表示这是当前使用的模型。
+	Current bool
+}
+
+// Regenerate 是重新生成最后一轮回复的快速模型选择对话框，列出当前模型和最近使用的模型。
+type Regenerate struct {
+	com       *common.Common
+	help      help.Model
+	list      *list.List
+	sessionID string
+	models    []RegenerateModel
+
+	keyMap struct {
+		Next     key.Binding
+		Previous key.Binding
+		UpDown   key.Binding
+		Select   key.Binding
+		Close    key.Binding
+	}
+}
+
+var _ Dialog = (*Regenerate)(nil)
+
+// NewRegenerate 创建一个新的 [Regenerate] 对话框。models 的第一项通常是当前模型。
+func NewRegenerate(com *common.Common, sessionID string, models []RegenerateModel) *Regenerate {
+	r := &Regenerate{
+		com:       com,
+		sessionID: sessionID,
+		models:    models,
+	}
+
+	help := help.New()
+	help.Styles = com.Styles.DialogHelpStyles()
+	r.help = help
+
+	r.list = list.NewList()
+	r.list.Focus()
+
+	r.keyMap.Next = key.NewBinding(
+		key.WithKeys("down", "ctrl+n"),
+		key.WithHelp("↓", "下一项"),
+	)
+	r.keyMap.Previous = key.NewBinding(
+		key.WithKeys("up", "ctrl+p"),
+		key.WithHelp("↑", "上一项"),
+	)
+	r.keyMap.UpDown = key.NewBinding(
+		key.WithKeys("up", "down"),
+		key.WithHelp("↑↓", "选择模型"),
+	)
+	r.keyMap.Select = key.NewBinding(
+		key.WithKeys("enter"),
+		key.WithHelp("enter", "重新生成"),
+	)
+	r.keyMap.Close = CloseKey
+
+	items := make([]list.Item, len(models))
+	for i, model := range models {
+		items[i] = &RegenerateItem{model: model, t: com.Styles}
+	}
+	r.list.SetItems(items...)
+	r.list.SetSelected(0)
+	return r
+}
+
+// ID 实现 Dialog 接口。
+func (r *Regenerate) ID() string {
+	return RegenerateID
+}
+
+// HandleMsg 实现 Dialog 接口。
+func (r *Regenerate) HandleMsg(msg tea.Msg) Action {
+	keyMsg, ok := msg.(tea.KeyPressMsg)
+	if !ok {
+		return nil
+	}
+	switch {
+	case key.Matches(keyMsg, r.keyMap.Close):
+		return ActionClose{}
+	case key.Matches(keyMsg, r.keyMap.Previous):
+		if r.list.IsSelectedFirst() {
+			r.list.SelectLast()
+			r.list.ScrollToBottom()
+			break
+		}
+		r.list.SelectPrev()
+		r.list.ScrollToSelected()
+	case key.Matches(keyMsg, r.keyMap.Next):
+		if r.list.IsSelectedLast() {
+			r.list.SelectFirst()
+			r.list.ScrollToTop()
+			break
+		}
+		r.list.SelectNext()
+		r.list.ScrollToSelected()
+	case key.Matches(keyMsg, r.keyMap.Select):
+		idx := r.list.Selected()
+		if idx < 0 || idx >= len(r.models) {
+			break
+		}
+		if r.models[idx].Current {
+			return ActionRegenerate{SessionID: r.sessionID}
+		}
+		model := r.models[idx].Model
+		return ActionRegenerate{SessionID: r.sessionID, Model: &model}
+	}
+	return nil
+}
+
+// Draw 实现 [Dialog] 接口。
+func (r *Regenerate) Draw(scr uv.Screen, area uv.Rectangle) *tea.Cursor {
+	t := r.com.Styles
+	width := max(0, min(defaultDialogMaxWidth, area.Dx()))
+	height := max(0, min(defaultDialogHeight, area.Dy()))
+	innerWidth := width - t.Dialog.View.GetHorizontalFrameSize() - 2
+	heightOffset := t.Dialog.Title.GetVerticalFrameSize() + titleContentHeight +
+		t.Dialog.HelpView.GetVerticalFrameSize() +
+		t.Dialog.View.GetVerticalFrameSize()
+
+	rc := NewRenderContext(t, width)
+	rc.Title = "重新生成回复"
+	r.list.SetSize(innerWidth, max(0, min(len(r.models), height-heightOffset)))
+	rc.AddPart(t.Dialog.List.Height(r.list.Height()).Render(r.list.Render()))
+	r.help.SetWidth(innerWidth)
+	rc.Help = r.help.View(r)
+
+	DrawCenter(scr, area, rc.Render())
+	return nil
+}
+
+// ShortHelp 实现 [help.KeyMap] 接口。
+func (r *Regenerate) ShortHelp() []key.Binding {
+	return []key.Binding{
+		r.keyMap.UpDown,
+		r.keyMap.Select,
+		r.keyMap.Close,
+	}
+}
+
+// FullHelp 实现 [help.KeyMap] 接口。
+func (r *Regenerate) FullHelp() [][]key.Binding {
+	return [][]key.Binding{r.ShortHelp()}
+}
+
+// RegenerateItem 表示重新生成回复对话框中的单个模型。
+type RegenerateItem struct {
+	model   RegenerateModel
+	t       *styles.Styles
+	cache   map[int]string
+	focused bool
+}
+
+var (
+	_ list.Item      = (*RegenerateItem)(nil)
+	_ list.Focusable = (*RegenerateItem)(nil)
+)
+
+// SetFocused 设置模型项目的焦点状态。
+func (r *RegenerateItem) SetFocused(focused bool) {
+	if r.focused != focused {
+		r.cache = nil
+	}
+	r.focused = focused
+}
+
+// Render 返回模型项目的字符串表示。
+func (r *RegenerateItem) Render(width int) string {
+	if r.cache == nil {
+		r.cache = make(map[int]string)
+	}
+	itemStyles := ListItemStyles{
+		ItemBlurred:     r.t.Dialog.NormalItem,
+		ItemFocused:     r.t.Dialog.SelectedItem,
+		InfoTextBlurred: r.t.Subtle,
+		InfoTextFocused: r.t.Base,
+	}
+	info := r.model.Provider
+	if r.model.Current {
+		info = "当前 · " + info
+	}
+	return renderItem(itemStyles, r.model.Name, info, r.focused, width, r.cache, nil)
+}
@@ -0,0 +1,247 @@
+package dialog
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"charm.land/bubbles/v2/help"
+	"charm.land/bubbles/v2/key"
+	tea "charm.land/bubbletea/v2"
+	uv "github.com/charmbracelet/ultraviolet"
+	"github.com/dustin/go-humanize"
+	"github.com/purpose168/crush-cn/internal/session"
+	"github.com/purpose168/crush-cn/internal/ui/common"
+	"github.com/purpose168/crush-cn/internal/ui/list"
+	"github.com/purpose168/crush-cn/internal/ui/styles"
+	"github.com/purpose168/crush-cn/internal/ui/util"
+)
+
+// ReplyVariantsID 是回复版本对话框的标识符。
+const ReplyVariantsID = "reply_variants"
+
+// ReplyVariant 是回复版本对话框中的一个版本。
+type ReplyVariant struct {
+	session.Variant
+	// Index 是版本在 [session.Session.Variants] 中的位置，当前版本为 -1。
+	Index int
+	// ModelName 是生成该版本的模型的显示名称。
+	ModelName string
+}
+
+// Current 报告这是否是聊天中显示的当前版本。
+func (v ReplyVariant) Current() bool {
+	return v.Index < 0
+}
+
+// ReplyVariants 是比较最后一轮对话各个回复版本的对话框，选中的版本替换聊天中的当前版本。
+type ReplyVariants struct {
+	com       *common.Common
+	help      help.Model
+	list      *list.List
+	sessionID string
+	variants  []ReplyVariant
+
+	keyMap struct {
+		Next     key.Binding
+		Previous key.Binding
+		UpDown   key.Binding
+		Keep     key.Binding
+		Delete   key.Binding
+		Close    key.Binding
+	}
+}
+
+var _ Dialog = (*ReplyVariants)(nil)
+
+// NewReplyVariants 创建一个新的 [ReplyVariants] 对话框。
+func NewReplyVariants(com *common.Common, sessionID string, variants []ReplyVariant) *ReplyVariants {
+	v := &ReplyVariants{
+		com:       com,
+		sessionID: sessionID,
+		variants:  slices.Clone(variants),
+	}
+
+	help := help.New()
+	help.Styles = com.Styles.DialogHelpStyles()
+	v.help = help
+
+	v.list = list.NewList()
+	v.list.Focus()
+
+	v.keyMap.Next = key.NewBinding(
+		key.WithKeys("down", "ctrl+n"),
+		key.WithHelp("↓", "下一项"),
+	)
+	v.keyMap.Previous = key.NewBinding(
+		key.WithKeys("up", "ctrl+p"),
+		key.WithHelp("↑", "上一项"),
+	)
+	v.keyMap.UpDown = key.NewBinding(
+		key.WithKeys("up", "down"),
+		key.WithHelp("↑↓", "选择"),
+	)
+	v.keyMap.Keep = key.NewBinding(
+		key.WithKeys("enter"),
+		key.WithHelp("enter", "使用此版本"),
+	)
+	v.keyMap.Delete = key.NewBinding(
+		key.WithKeys("ctrl+x", "delete"),
+		key.WithHelp("ctrl+x", "删除"),
+	)
+	v.keyMap.Close = CloseKey
+
+	v.setItems()
+	v.list.SetSelected(0)
+	return v
+}
+
+// ID 实现 Dialog 接口。
+func (v *ReplyVariants) ID() string {
+	return ReplyVariantsID
+}
+
+// HandleMsg 实现 Dialog 接口。
+func (v *ReplyVariants) HandleMsg(msg tea.Msg) Action {
+	keyMsg, ok := msg.(tea.KeyPressMsg)
+	if !ok {
+		return nil
+	}
+	switch {
+	case key.Matches(keyMsg, v.keyMap.Close):
+		return ActionClose{}
+	case key.Matches(keyMsg, v.keyMap.Previous):
+		if v.list.IsSelectedFirst() {
+			v.list.SelectLast()
+			v.list.ScrollToBottom()
+			break
+		}
+		v.list.SelectPrev()
+		v.list.ScrollToSelected()
+	case key.Matches(keyMsg, v.keyMap.Next):
+		if v.list.IsSelectedLast() {
+			v.list.SelectFirst()
+			v.list.ScrollToTop()
+			break
+		}
+		v.list.SelectNext()
+		v.list.ScrollToSelected()
+	case key.Matches(keyMsg, v.keyMap.Keep):
+		idx := v.list.Selected()
+		if idx < 0 || idx >= len(v.variants) {
+			break
+		}
+		if v.variants[idx].Current() {
+			return ActionClose{}
+		}
+		return ActionKeepVariant{SessionID: v.sessionID, Index: v.variants[idx].Index}
+	case key.Matches(keyMsg, v.keyMap.Delete):
+		idx := v.list.Selected()
+		if idx < 0 || idx >= len(v.variants) {
+			break
+		}
+		deleted := v.variants[idx]
+		if deleted.Current() {
+			return ActionCmd{util.ReportWarn("不能删除当前版本")}
+		}
+		v.variants = slices.Delete(v.variants, idx, idx+1)
+		for i := range v.variants {
+			if v.variants[i].Index > deleted.Index {
+				v.variants[i].Index--
+			}
+		}
+		v.setItems()
+		return ActionDeleteVariant{SessionID: v.sessionID, Index: deleted.Index}
+	}
+	return nil
+}
+
+// setItems 根据当前的版本重建列表项，保持选中位置不变。
+func (v *ReplyVariants) setItems() {
+	selected := v.list.Selected()
+	items := make([]list.Item, len(v.variants))
+	for i, variant := range v.variants {
+		items[i] = &ReplyVariantItem{variant: variant, t: v.com.Styles}
+	}
+	v.list.SetItems(items...)
+	v.list.SetSelected(min(max(selected, 0), len(items)-1))
+}
+
+// Draw 实现 [Dialog] 接口。
+func (v *ReplyVariants) Draw(scr uv.Screen, area uv.Rectangle) *tea.Cursor {
+	t := v.com.Styles
+	width := max(0, min(defaultDialogMaxWidth, area.Dx()))
+	height := max(0, min(defaultDialogHeight, area.Dy()))
+	innerWidth := width - t.Dialog.View.GetHorizontalFrameSize() - 2
+	heightOffset := t.Dialog.Title.GetVerticalFrameSize() + titleContentHeight +
+		t.Dialog.HelpView.GetVerticalFrameSize() +
+		t.Dialog.View.GetVerticalFrameSize()
+
+	rc := NewRenderContext(t, width)
+	rc.Title = fmt.Sprintf("回复版本 (%d)", len(v.variants))
+	v.list.SetSize(innerWidth, max(0, min(len(v.variants), height-heightOffset)))
+	rc.AddPart(t.Dialog.List.Height(v.list.Height()).Render(v.list.Render()))
+	v.help.SetWidth(innerWidth)
+	rc.Help = v.help.View(v)
+
+	DrawCenter(scr, area, rc.Render())
+	return nil
+}
+
+// ShortHelp 实现 [help.KeyMap] 接口。
+func (v *ReplyVariants) ShortHelp() []key.Binding {
+	return []key.Binding{
+		v.keyMap.UpDown,
+		v.keyMap.Keep,
+		v.keyMap.Delete,
+		v.keyMap.Close,
+	}
+}
+
+// FullHelp 实现 [help.KeyMap] 接口。
+func (v *ReplyVariants) FullHelp() [][]key.Binding {
+	return [][]key.Binding{v.ShortHelp()}
+}
+
+// ReplyVariantItem 表示回复版本对话框中的单个版本。
+type ReplyVariantItem struct {
+	variant ReplyVariant
+	t       *styles.Styles
+	cache   map[int]string
+	focused bool
+}
+
+var (
+	_ list.Item      = (*ReplyVariantItem)(nil)
+	_ list.Focusable = (*ReplyVariantItem)(nil)
+)
+
+// SetFocused 设置版本项目的焦点状态。
+func (v *ReplyVariantItem) SetFocused(focused bool) {
+	if v.focused != focused {
+		v.cache = nil
+	}
+	v.focused = focused
+}
+
+// Render 返回版本项目的字符串表示。
+func (v *ReplyVariantItem) Render(width int) string {
+	if v.cache == nil {
+		v.cache = make(map[int]string)
+	}
+	itemStyles := ListItemStyles{
+		ItemBlurred:     v.t.Dialog.NormalItem,
+		ItemFocused:     v.t.Dialog.SelectedItem,
+		InfoTextBlurred: v.t.Subtle,
+		InfoTextFocused: v.t.Base,
+	}
+	title := v.variant.Preview
+	if title == "" {
+		title = "（没有回复）"
+	}
+	info := v.variant.ModelName + " · " + humanize.Time(time.Unix(v.variant.CreatedAt, 0))
+	if v.variant.Current() {
+		info = "当前 · " + info
+	}
+	return renderItem(itemStyles, title, info, v.focused, width, v.cache, nil)
+}
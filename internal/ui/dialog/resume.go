@@ -0,0 +1,181 @@
+package dialog
+
+import (
+	"fmt"
+	"strings"
+
+	"charm.land/bubbles/v2/key"
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+	uv "github.com/charmbracelet/ultraviolet"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/purpose168/crush-cn/internal/session"
+	"github.com/purpose168/crush-cn/internal/ui/common"
+)
+
+// ResumeID 是恢复被中断运行对话框的标识符。
+const ResumeID = "resume"
+
+// resumeChoice 是恢复对话框中的选项
+type resumeChoice int
+
+const (
+	resumeReopen resumeChoice = iota
+	resumeRestart
+	resumeFresh
+)
+
+// Resume 是启动时发现上次退出时智能体仍在运行的会话后显示的对话框。用户可以
+// 重新打开该会话、重新运行被中断的提示，或者开始新会话。
+type Resume struct {
+	com      *common.Common
+	session  session.Session
+	run      session.Run
+	selected resumeChoice
+	keyMap   struct {
+		LeftRight,
+		EnterSpace,
+		Reopen,
+		Restart,
+		Fresh,
+		Tab,
+		Close key.Binding
+	}
+}
+
+var _ Dialog = (*Resume)(nil)
+
+// NewResume 创建一个新的恢复对话框。
+func NewResume(com *common.Common, sess session.Session, run session.Run) *Resume {
+	r := &Resume{
+		com:     com,
+		session: sess,
+		run:     run,
+	}
+	r.keyMap.LeftRight = key.NewBinding(
+		key.WithKeys("left", "right"),
+		key.WithHelp("←/→", "切换选项"),
+	)
+	r.keyMap.EnterSpace = key.NewBinding(
+		key.WithKeys("enter", " "),
+		key.WithHelp("enter/space", "确认"),
+	)
+	r.keyMap.Reopen = key.NewBinding(
+		key.WithKeys("o", "O"),
+		key.WithHelp("o", "打开会话"),
+	)
+	r.keyMap.Restart = key.NewBinding(
+		key.WithKeys("r", "R"),
+		key.WithHelp("r", "重新运行"),
+	)
+	r.keyMap.Fresh = key.NewBinding(
+		key.WithKeys("n", "N"),
+		key.WithHelp("n", "新会话"),
+	)
+	r.keyMap.Tab = key.NewBinding(
+		key.WithKeys("tab"),
+		key.WithHelp("tab", "切换选项"),
+	)
+	r.keyMap.Close = CloseKey
+	return r
+}
+
+// ID 实现 [Model] 接口。
+func (*Resume) ID() string {
+	return ResumeID
+}
+
+// HandleMsg 实现 [Model] 接口。
+func (r *Resume) HandleMsg(msg tea.Msg) Action {
+	keyMsg, ok := msg.(tea.KeyPressMsg)
+	if !ok {
+		return nil
+	}
+	switch {
+	case key.Matches(keyMsg, r.keyMap.LeftRight, r.keyMap.Tab):
+		if keyMsg.String() == "left" {
+			r.selected = (r.selected + resumeFresh) % (resumeFresh + 1)
+		} else {
+			r.selected = (r.selected + 1) % (resumeFresh + 1)
+		}
+	case key.Matches(keyMsg, r.keyMap.EnterSpace):
+		return r.choose(r.selected)
+	case key.Matches(keyMsg, r.keyMap.Reopen):
+		return r.choose(resumeReopen)
+	case key.Matches(keyMsg, r.keyMap.Restart):
+		return r.choose(resumeRestart)
+	case key.Matches(keyMsg, r.keyMap.Fresh, r.keyMap.Close):
+		return r.choose(resumeFresh)
+	}
+	return nil
+}
+
+func (r *Resume) choose(choice resumeChoice) Action {
+	switch choice {
+	case resumeReopen:
+		return ActionResumeSession{Session: r.session, Run: r.run}
+	case resumeRestart:
+		return ActionResumeSession{Session: r.session, Run: r.run, Restart: true}
+	default:
+		return ActionDiscardRun{SessionID: r.run.SessionID}
+	}
+}
+
+// Draw 实现 [Dialog] 接口。
+func (r *Resume) Draw(scr uv.Screen, area uv.Rectangle) *tea.Cursor {
+	t := r.com.Styles
+	width := max(0, min(defaultDialogMaxWidth, area.Dx()-t.BorderFocus.GetHorizontalFrameSize()))
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "上次退出时，智能体仍在会话「%s」中运行。\n\n", r.session.Title)
+	sb.WriteString("被中断的提示：\n")
+	sb.WriteString(t.Subtle.Render(ansi.Truncate(firstLine(r.run.Prompt), width, "…")))
+	if n := len(r.run.Queued); n > 0 {
+		fmt.Fprintf(&sb, "\n另有 %d 条排队的提示", n)
+	}
+	question := lipgloss.NewStyle().Width(width).Render(sb.String())
+
+	buttonOpts := []common.ButtonOpts{
+		{Text: "打开会话", Selected: r.selected == resumeReopen, Padding: 2},
+		{Text: "重新运行", Selected: r.selected == resumeRestart, Padding: 2},
+		{Text: "新会话", Selected: r.selected == resumeFresh, Padding: 2},
+	}
+	buttons := common.ButtonGroup(t, buttonOpts, " ")
+	content := t.Base.Render(
+		lipgloss.JoinVertical(
+			lipgloss.Center,
+			question,
+			"",
+			buttons,
+		),
+	)
+
+	view := t.BorderFocus.Render(content)
+	DrawCenter(scr, area, view)
+	return nil
+}
+
+// firstLine 返回文本的第一行，多行文本以省略号结尾
+func firstLine(s string) string {
+	line, rest, found := strings.Cut(strings.TrimSpace(s), "\n")
+	if found && strings.TrimSpace(rest) != "" {
+		return line + " …"
+	}
+	return line
+}
+
+// ShortHelp 实现 [help.KeyMap] 接口。
+func (r *Resume) ShortHelp() []key.Binding {
+	return []key.Binding{
+		r.keyMap.LeftRight,
+		r.keyMap.EnterSpace,
+	}
+}
+
+// FullHelp 实现 [help.KeyMap] 接口。
+func (r *Resume) FullHelp() [][]key.Binding {
+	return [][]key.Binding{
+		{r.keyMap.LeftRight, r.keyMap.EnterSpace, r.keyMap.Reopen, r.keyMap.Restart, r.keyMap.Fresh},
+		{r.keyMap.Tab, r.keyMap.Close},
+	}
+}
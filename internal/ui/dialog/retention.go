@@ -0,0 +1,159 @@
+package dialog
+
+import (
+	"fmt"
+
+	"charm.land/bubbles/v2/help"
+	"charm.land/bubbles/v2/key"
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+	uv "github.com/charmbracelet/ultraviolet"
+	"github.com/purpose168/crush-cn/internal/session"
+	"github.com/purpose168/crush-cn/internal/ui/common"
+	"github.com/purpose168/crush-cn/internal/ui/list"
+)
+
+// RetentionID 是会话清理确认对话框的标识符。
+const RetentionID = "retention"
+
+// Retention 是清理超出保留策略的会话前的确认对话框。它列出将被删除的会话，
+// 用户确认后才会删除。
+type Retention struct {
+	com      *common.Common
+	help     help.Model
+	list     *list.FilterableList
+	sessions []session.Session
+
+	keyMap struct {
+		Next     key.Binding
+		Previous key.Binding
+		UpDown   key.Binding
+		Confirm  key.Binding
+		Cancel   key.Binding
+	}
+}
+
+var _ Dialog = (*Retention)(nil)
+
+// NewRetention 创建一个新的 [Retention] 对话框。sessions 是将被删除的会话。
+func NewRetention(com *common.Common, sessions []session.Session) *Retention {
+	r := &Retention{
+		com:      com,
+		sessions: sessions,
+	}
+
+	help := help.New()
+	help.Styles = com.Styles.DialogHelpStyles()
+	r.help = help
+
+	r.list = list.NewFilterableList(sessionItems(com.Styles, sessionsModeDeleting, sessionsSortRecent, sessions...)...)
+	r.list.Focus()
+	r.list.SetSelected(0)
+
+	r.keyMap.Next = key.NewBinding(
+		key.WithKeys("down", "ctrl+n"),
+		key.WithHelp("↓", "下一项"),
+	)
+	r.keyMap.Previous = key.NewBinding(
+		key.WithKeys("up", "ctrl+p"),
+		key.WithHelp("↑", "上一项"),
+	)
+	r.keyMap.UpDown = key.NewBinding(
+		key.WithKeys("up", "down"),
+		key.WithHelp("↑↓", "浏览"),
+	)
+	r.keyMap.Confirm = key.NewBinding(
+		key.WithKeys("y"),
+		key.WithHelp("y", "删除"),
+	)
+	r.keyMap.Cancel = key.NewBinding(
+		key.WithKeys("n", "esc"),
+		key.WithHelp("n", "取消"),
+	)
+	return r
+}
+
+// ID 实现 Dialog 接口。
+func (r *Retention) ID() string {
+	return RetentionID
+}
+
+// HandleMsg 实现 Dialog 接口。
+func (r *Retention) HandleMsg(msg tea.Msg) Action {
+	keyMsg, ok := msg.(tea.KeyPressMsg)
+	if !ok {
+		return nil
+	}
+	switch {
+	case key.Matches(keyMsg, r.keyMap.Cancel):
+		return ActionClose{}
+	case key.Matches(keyMsg, r.keyMap.Confirm):
+		return ActionPruneSessions{Sessions: r.sessions}
+	case key.Matches(keyMsg, r.keyMap.Previous):
+		if r.list.IsSelectedFirst() {
+			r.list.SelectLast()
+			r.list.ScrollToBottom()
+			break
+		}
+		r.list.SelectPrev()
+		r.list.ScrollToSelected()
+	case key.Matches(keyMsg, r.keyMap.Next):
+		if r.list.IsSelectedLast() {
+			r.list.SelectFirst()
+			r.list.ScrollToTop()
+			break
+		}
+		r.list.SelectNext()
+		r.list.ScrollToSelected()
+	}
+	return nil
+}
+
+// Draw 实现 [Dialog] 接口。
+func (r *Retention) Draw(scr uv.Screen, area uv.Rectangle) *tea.Cursor {
+	t := r.com.Styles
+	width := max(0, min(defaultDialogMaxWidth, area.Dx()))
+	height := max(0, min(defaultDialogHeight, area.Dy()))
+	innerWidth := width - t.Dialog.View.GetHorizontalFrameSize() - 2
+
+	rc := NewRenderContext(t, width)
+	rc.Title = "清理旧会话"
+	rc.TitleStyle = t.Dialog.Sessions.DeletingTitle
+	rc.TitleGradientFromColor = t.Dialog.Sessions.DeletingTitleGradientFromColor
+	rc.TitleGradientToColor = t.Dialog.Sessions.DeletingTitleGradientToColor
+	rc.ViewStyle = t.Dialog.Sessions.DeletingView
+
+	message := t.Dialog.Sessions.DeletingMessage.Width(innerWidth).Render(fmt.Sprintf(
+		"以下 %d 个会话超出了保留策略，将连同消息和文件历史一起删除，且无法恢复。需要保留的会话可以先在会话列表中归档。",
+		len(r.sessions),
+	))
+	rc.AddPart(message)
+
+	heightOffset := t.Dialog.Title.GetVerticalFrameSize() + titleContentHeight +
+		lipgloss.Height(message) +
+		t.Dialog.HelpView.GetVerticalFrameSize() +
+		t.Dialog.View.GetVerticalFrameSize()
+	r.list.SetSize(innerWidth, max(0, height-heightOffset))
+	r.help.SetWidth(innerWidth)
+
+	listView := t.Dialog.List.Height(r.list.Height()).Render(r.list.Render())
+	rc.AddPart(listView)
+	rc.Help = r.help.View(r)
+
+	DrawCenter(scr, area, rc.Render())
+	return nil
+}
+
+// ShortHelp 实现 [help.KeyMap] 接口。
+func (r *Retention) ShortHelp() []key.Binding {
+	return []key.Binding{
+		r.keyMap.UpDown,
+		r.keyMap.Confirm,
+		r.keyMap.Cancel,
+	}
+}
+
+// FullHelp 实现 [help.KeyMap] 接口。
+func (r *Retention) FullHelp() [][]key.Binding {
+	return [][]key.Binding{r.ShortHelp()}
+}
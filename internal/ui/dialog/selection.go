@@ -0,0 +1,228 @@
+package dialog
+
+import (
+	"fmt"
+	"strings"
+
+	"charm.land/bubbles/v2/help"
+	"charm.land/bubbles/v2/key"
+	tea "charm.land/bubbletea/v2"
+	uv "github.com/charmbracelet/ultraviolet"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/purpose168/crush-cn/internal/ui/common"
+	"github.com/purpose168/crush-cn/internal/ui/list"
+	"github.com/purpose168/crush-cn/internal/ui/styles"
+)
+
+// SelectionActionsID 是选中文本操作对话框的标识符。
+const SelectionActionsID = "selection_actions"
+
+// selectionAction 是对选中文本执行的预设指令。instruction 为空时将选中文本导出为图片，
+// copy 表示同时把图片复制到剪贴板。
+type selectionAction struct {
+	title       string
+	instruction string
+	copy        bool
+}
+
+var selectionActions = []selectionAction{
+	{title: "解释", instruction: "Explain the following excerpt from this session. Describe what it does or means and point out anything notable."},
+	{title: "重构", instruction: "Refactor the following code from this session. If it comes from a file in this project, find the file and apply the changes there."},
+	{title: "编写测试", instruction: "Write tests for the following code from this session, following the existing test conventions of this project."},
+	{title: "导出为图片"},
+	{title: "导出为图片并复制到剪贴板", copy: true},
+}
+
+// SelectionActions 是一个将聊天中选中的文本连同预设指令发送给智能体或导出为图片的小菜单。
+type SelectionActions struct {
+	com       *common.Common
+	help      help.Model
+	list      *list.List
+	selection string
+
+	keyMap struct {
+		Next     key.Binding
+		Previous key.Binding
+		UpDown   key.Binding
+		Select   key.Binding
+		Close    key.Binding
+	}
+}
+
+var _ Dialog = (*SelectionActions)(nil)
+
+// NewSelectionActions 创建一个针对 selection 的 [SelectionActions] 对话框。
+func NewSelectionActions(com *common.Common, selection string) *SelectionActions {
+	s := &SelectionActions{com: com, selection: selection}
+
+	help := help.New()
+	help.Styles = com.Styles.DialogHelpStyles()
+	s.help = help
+
+	s.list = list.NewList()
+	s.list.Focus()
+	items := make([]list.Item, len(selectionActions))
+	for i, action := range selectionActions {
+		items[i] = &SelectionActionItem{
+			title: action.title,
+			index: i + 1,
+			t:     com.Styles,
+		}
+	}
+	s.list.SetItems(items...)
+	s.list.SetSelected(0)
+
+	s.keyMap.Next = key.NewBinding(
+		key.WithKeys("down", "ctrl+n"),
+		key.WithHelp("↓", "下一项"),
+	)
+	s.keyMap.Previous = key.NewBinding(
+		key.WithKeys("up", "ctrl+p"),
+		key.WithHelp("↑", "上一项"),
+	)
+	s.keyMap.UpDown = key.NewBinding(
+		key.WithKeys("up", "down"),
+		key.WithHelp("↑↓", "选择"),
+	)
+	s.keyMap.Select = key.NewBinding(
+		key.WithKeys("enter", "ctrl+y"),
+		key.WithHelp("enter", "确认"),
+	)
+	s.keyMap.Close = CloseKey
+	return s
+}
+
+// ID 实现 Dialog 接口。
+func (s *SelectionActions) ID() string {
+	return SelectionActionsID
+}
+
+// HandleMsg 实现 Dialog 接口。
+func (s *SelectionActions) HandleMsg(msg tea.Msg) Action {
+	keyMsg, ok := msg.(tea.KeyPressMsg)
+	if !ok {
+		return nil
+	}
+	switch {
+	case key.Matches(keyMsg, s.keyMap.Close):
+		return ActionClose{}
+	case key.Matches(keyMsg, s.keyMap.Previous):
+		if s.list.IsSelectedFirst() {
+			s.list.SelectLast()
+			break
+		}
+		s.list.SelectPrev()
+	case key.Matches(keyMsg, s.keyMap.Next):
+		if s.list.IsSelectedLast() {
+			s.list.SelectFirst()
+			break
+		}
+		s.list.SelectNext()
+	case key.Matches(keyMsg, s.keyMap.Select):
+		return s.action(s.list.Selected())
+	default:
+		// 数字键直接选择对应的指令
+		if n := keyMsg.String(); len(n) == 1 && n[0] >= '1' && n[0] <= '9' {
+			return s.action(int(n[0] - '1'))
+		}
+	}
+	return nil
+}
+
+// action 返回执行第 idx 个指令的操作。
+func (s *SelectionActions) action(idx int) Action {
+	if idx < 0 || idx >= len(selectionActions) {
+		return nil
+	}
+	action := selectionActions[idx]
+	if action.instruction == "" {
+		return ActionExportSelectionImage{
+			Content: s.selection,
+			Copy:    action.copy,
+		}
+	}
+	return ActionAskAboutSelection{
+		Content: SelectionPrompt(action.instruction, s.selection),
+	}
+}
+
+// SelectionPrompt 返回将 selection 放入代码块后附加在 instruction 之后的提示。
+func SelectionPrompt(instruction, selection string) string {
+	fence := "```"
+	for strings.Contains(selection, fence) {
+		fence += "`"
+	}
+	return fmt.Sprintf("%s\n\n%s\n%s\n%s", instruction, fence, selection, fence)
+}
+
+// Draw 实现 [Dialog] 接口。
+func (s *SelectionActions) Draw(scr uv.Screen, area uv.Rectangle) *tea.Cursor {
+	t := s.com.Styles
+	width := max(0, min(defaultDialogMaxWidth, area.Dx()))
+	innerWidth := width - t.Dialog.View.GetHorizontalFrameSize() - 2
+
+	rc := NewRenderContext(t, width)
+	rc.Title = "选中文本"
+
+	firstLine, _, _ := strings.Cut(s.selection, "\n")
+	preview := ansi.Truncate(strings.TrimSpace(firstLine), innerWidth, "…")
+	rc.AddPart(t.Subtle.Render(preview))
+
+	s.list.SetSize(innerWidth, len(selectionActions))
+	s.help.SetWidth(innerWidth)
+	rc.AddPart(t.Dialog.List.Height(s.list.Height()).Render(s.list.Render()))
+	rc.Help = s.help.View(s)
+
+	DrawCenter(scr, area, rc.Render())
+	return nil
+}
+
+// ShortHelp 实现 [help.KeyMap] 接口。
+func (s *SelectionActions) ShortHelp() []key.Binding {
+	return []key.Binding{
+		s.keyMap.UpDown,
+		s.keyMap.Select,
+		s.keyMap.Close,
+	}
+}
+
+// FullHelp 实现 [help.KeyMap] 接口。
+func (s *SelectionActions) FullHelp() [][]key.Binding {
+	return [][]key.Binding{s.ShortHelp()}
+}
+
+// SelectionActionItem 表示选中文本操作对话框中的单个指令。
+type SelectionActionItem struct {
+	title   string
+	index   int
+	t       *styles.Styles
+	cache   map[int]string
+	focused bool
+}
+
+var (
+	_ list.Item      = (*SelectionActionItem)(nil)
+	_ list.Focusable = (*SelectionActionItem)(nil)
+)
+
+// SetFocused 设置指令项目的焦点状态。
+func (s *SelectionActionItem) SetFocused(focused bool) {
+	if s.focused != focused {
+		s.cache = nil
+	}
+	s.focused = focused
+}
+
+// Render 返回指令项目的字符串表示。
+func (s *SelectionActionItem) Render(width int) string {
+	if s.cache == nil {
+		s.cache = make(map[int]string)
+	}
+	itemStyles := ListItemStyles{
+		ItemBlurred:     s.t.Dialog.NormalItem,
+		ItemFocused:     s.t.Dialog.SelectedItem,
+		InfoTextBlurred: s.t.Subtle,
+		InfoTextFocused: s.t.Base,
+	}
+	return renderItem(itemStyles, s.title, fmt.Sprint(s.index), s.focused, width, s.cache, nil)
+}
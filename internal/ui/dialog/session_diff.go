@@ -0,0 +1,380 @@
+package dialog
+
+import (
+	"fmt"
+	"strings"
+
+	"charm.land/bubbles/v2/help"
+	"charm.land/bubbles/v2/key"
+	"charm.land/bubbles/v2/viewport"
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+	uv "github.com/charmbracelet/ultraviolet"
+	"github.com/purpose168/crush-cn/internal/diff"
+	"github.com/purpose168/crush-cn/internal/fsext"
+	"github.com/purpose168/crush-cn/internal/history"
+	"github.com/purpose168/crush-cn/internal/ui/common"
+	"github.com/purpose168/crush-cn/internal/ui/styles"
+)
+
+// SessionDiffID 是会话差异对话框的标识符。
+const SessionDiffID = "session_diff"
+
+// sessionDiffFile 是会话差异中的一个文件及其增删行数。
+type sessionDiffFile struct {
+	change    history.FileChange
+	additions int
+	deletions int
+}
+
+// SessionDiff 是显示智能体在会话中对文件所做全部更改的对话框。每个文件显示
+// 初始版本与最新版本之间的差异，按文件分组，可以折叠，并可导出为补丁文件。
+type SessionDiff struct {
+	com       *common.Common
+	sessionID string
+	files     []sessionDiffFile
+
+	viewport      viewport.Model
+	viewportDirty bool
+	split         bool
+	xOffset       int
+
+	fileCursor   int
+	collapsed    map[int]bool
+	fileLines    []int
+	scrollToFile bool
+
+	help   help.Model
+	keyMap struct {
+		PrevFile       key.Binding
+		NextFile       key.Binding
+		SelectFile     key.Binding
+		ToggleFile     key.Binding
+		ToggleAllFiles key.Binding
+		ToggleDiffMode key.Binding
+		ScrollUp       key.Binding
+		ScrollDown     key.Binding
+		ScrollLeft     key.Binding
+		ScrollRight    key.Binding
+		Scroll         key.Binding
+		Export         key.Binding
+		Close          key.Binding
+	}
+}
+
+var _ Dialog = (*SessionDiff)(nil)
+
+// NewSessionDiff 为会话的文件更改创建一个新的 [SessionDiff] 对话框。
+func NewSessionDiff(com *common.Common, sessionID string, changes []history.FileChange) *SessionDiff {
+	s := &SessionDiff{
+		com:           com,
+		sessionID:     sessionID,
+		split:         true,
+		collapsed:     make(map[int]bool),
+		viewportDirty: true,
+	}
+	for _, c := range changes {
+		_, additions, deletions := diff.GenerateDiff(c.Before, c.After, c.Path)
+		s.files = append(s.files, sessionDiffFile{change: c, additions: additions, deletions: deletions})
+	}
+	// 文件较多时默认只展开第一个文件，便于先浏览文件列表。
+	if len(s.files) > maxExpandedFiles {
+		for i := 1; i < len(s.files); i++ {
+			s.collapsed[i] = true
+		}
+	}
+
+	s.help = help.New()
+	s.help.Styles = com.Styles.DialogHelpStyles()
+
+	s.keyMap.PrevFile = key.NewBinding(
+		key.WithKeys("up", "k"),
+		key.WithHelp("↑", "上一个文件"),
+	)
+	s.keyMap.NextFile = key.NewBinding(
+		key.WithKeys("down", "j"),
+		key.WithHelp("↓", "下一个文件"),
+	)
+	s.keyMap.SelectFile = key.NewBinding(
+		key.WithKeys("up", "down"),
+		key.WithHelp("↑/↓", "选择文件"),
+	)
+	s.keyMap.ToggleFile = key.NewBinding(
+		key.WithKeys("space"),
+		key.WithHelp("space", "展开/折叠"),
+	)
+	s.keyMap.ToggleAllFiles = key.NewBinding(
+		key.WithKeys("e"),
+		key.WithHelp("e", "全部展开/折叠"),
+	)
+	s.keyMap.ToggleDiffMode = key.NewBinding(
+		key.WithKeys("t"),
+		key.WithHelp("t", "切换差异视图"),
+	)
+	s.keyMap.ScrollUp = key.NewBinding(
+		key.WithKeys("shift+up", "K"),
+		key.WithHelp("shift+↑", "向上滚动"),
+	)
+	s.keyMap.ScrollDown = key.NewBinding(
+		key.WithKeys("shift+down", "J"),
+		key.WithHelp("shift+↓", "向下滚动"),
+	)
+	s.keyMap.ScrollLeft = key.NewBinding(
+		key.WithKeys("shift+left", "H"),
+		key.WithHelp("shift+←", "向左滚动"),
+	)
+	s.keyMap.ScrollRight = key.NewBinding(
+		key.WithKeys("shift+right", "L"),
+		key.WithHelp("shift+→", "向右滚动"),
+	)
+	s.keyMap.Scroll = key.NewBinding(
+		key.WithKeys("shift+left", "shift+down", "shift+up", "shift+right"),
+		key.WithHelp("shift+←↓↑→", "滚动"),
+	)
+	s.keyMap.Export = key.NewBinding(
+		key.WithKeys("x"),
+		key.WithHelp("x", "导出补丁"),
+	)
+	s.keyMap.Close = CloseKey
+
+	s.viewport = viewport.New()
+	s.viewport.KeyMap = viewport.KeyMap{
+		Up:    s.keyMap.ScrollUp,
+		Down:  s.keyMap.ScrollDown,
+		Left:  key.NewBinding(key.WithDisabled()),
+		Right: key.NewBinding(key.WithDisabled()),
+		// 禁用其他视口键以避免与对话框快捷键冲突。
+		PageUp:       key.NewBinding(key.WithDisabled()),
+		PageDown:     key.NewBinding(key.WithDisabled()),
+		HalfPageUp:   key.NewBinding(key.WithDisabled()),
+		HalfPageDown: key.NewBinding(key.WithDisabled()),
+	}
+	return s
+}
+
+// ID 实现 [Dialog] 接口。
+func (*SessionDiff) ID() string {
+	return SessionDiffID
+}
+
+// HandleMsg 实现 [Dialog] 接口。
+func (s *SessionDiff) HandleMsg(msg tea.Msg) Action {
+	switch msg := msg.(type) {
+	case tea.KeyPressMsg:
+		switch {
+		case key.Matches(msg, s.keyMap.Close):
+			return ActionClose{}
+		case key.Matches(msg, s.keyMap.Export):
+			return ActionExportSessionPatch{SessionID: s.sessionID, Changes: s.changes()}
+		case key.Matches(msg, s.keyMap.PrevFile):
+			s.selectFile(s.fileCursor - 1)
+		case key.Matches(msg, s.keyMap.NextFile):
+			s.selectFile(s.fileCursor + 1)
+		case key.Matches(msg, s.keyMap.ToggleFile):
+			s.collapsed[s.fileCursor] = !s.collapsed[s.fileCursor]
+			s.viewportDirty = true
+			s.scrollToFile = true
+		case key.Matches(msg, s.keyMap.ToggleAllFiles):
+			s.toggleAllFiles()
+		case key.Matches(msg, s.keyMap.ToggleDiffMode):
+			s.split = !s.split
+			s.viewportDirty = true
+		case key.Matches(msg, s.keyMap.ScrollLeft):
+			s.scrollLeft()
+		case key.Matches(msg, s.keyMap.ScrollRight):
+			s.scrollRight()
+		case key.Matches(msg, s.keyMap.ScrollUp), key.Matches(msg, s.keyMap.ScrollDown):
+			s.viewport, _ = s.viewport.Update(msg)
+		}
+	case tea.MouseWheelMsg:
+		switch msg.Button {
+		case tea.MouseWheelLeft:
+			s.scrollLeft()
+		case tea.MouseWheelRight:
+			s.scrollRight()
+		default:
+			s.viewport, _ = s.viewport.Update(msg)
+		}
+	}
+	return nil
+}
+
+// changes 返回对话框中的所有文件更改。
+func (s *SessionDiff) changes() []history.FileChange {
+	changes := make([]history.FileChange, len(s.files))
+	for i, f := range s.files {
+		changes[i] = f.change
+	}
+	return changes
+}
+
+// selectFile 选中第 i 个文件，并滚动使其可见。
+func (s *SessionDiff) selectFile(i int) {
+	s.fileCursor = max(0, min(i, len(s.files)-1))
+	s.viewportDirty = true
+	s.scrollToFile = true
+}
+
+// toggleAllFiles 在全部展开和全部折叠之间切换。
+func (s *SessionDiff) toggleAllFiles() {
+	collapse := false
+	for i := range s.files {
+		if !s.collapsed[i] {
+			collapse = true
+			break
+		}
+	}
+	clear(s.collapsed)
+	if collapse {
+		for i := range s.files {
+			s.collapsed[i] = true
+		}
+	}
+	s.viewportDirty = true
+	s.scrollToFile = true
+}
+
+func (s *SessionDiff) scrollLeft() {
+	s.xOffset = max(0, s.xOffset-horizontalScrollStep)
+	s.viewportDirty = true
+}
+
+func (s *SessionDiff) scrollRight() {
+	s.xOffset += horizontalScrollStep
+	s.viewportDirty = true
+}
+
+// Draw 实现 [Dialog] 接口。
+func (s *SessionDiff) Draw(scr uv.Screen, area uv.Rectangle) *tea.Cursor {
+	t := s.com.Styles
+	width, height := area.Dx(), area.Dy()
+	if width > minWindowWidth && height > minWindowHeight {
+		width = min(int(float64(width)*diffSizeRatio), diffMaxWidth)
+		height = int(float64(height) * diffSizeRatio)
+	}
+	contentWidth := width - t.Dialog.View.GetHorizontalFrameSize() - 2
+
+	var additions, deletions int
+	for _, f := range s.files {
+		additions += f.additions
+		deletions += f.deletions
+	}
+
+	rc := NewRenderContext(t, width)
+	rc.Gap = 1
+	rc.Title = "会话差异"
+	rc.TitleInfo = t.Subtle.Render(fmt.Sprintf(" %d 个文件 ", len(s.files))) +
+		t.Files.Additions.Render(fmt.Sprintf("+%d", additions)) + " " +
+		t.Files.Deletions.Render(fmt.Sprintf("-%d", deletions))
+
+	s.help.SetWidth(contentWidth)
+	rc.Help = s.help.View(s)
+
+	// 标题、帮助和两处间隙之外的高度留给差异视图。
+	fixedHeight := t.Dialog.View.GetVerticalFrameSize() + t.Dialog.Title.GetVerticalFrameSize() +
+		t.Dialog.HelpView.GetVerticalFrameSize() + 4
+	viewportHeight := max(3, height-fixedHeight)
+	viewportWidth := contentWidth - 1 // 为滚动条预留空间。
+
+	if s.viewport.Width() != viewportWidth {
+		s.viewportDirty = true
+	}
+	s.viewport.SetWidth(viewportWidth)
+	s.viewport.SetHeight(viewportHeight)
+	if s.viewportDirty {
+		s.viewport.SetContent(s.renderFiles(viewportWidth))
+		s.viewportDirty = false
+	}
+	if s.scrollToFile && s.fileCursor < len(s.fileLines) {
+		// 选中的文件标题不在可见范围内时，将其滚动到顶部。
+		line := s.fileLines[s.fileCursor]
+		if line < s.viewport.YOffset() || line >= s.viewport.YOffset()+viewportHeight {
+			s.viewport.SetYOffset(line)
+		}
+		s.scrollToFile = false
+	}
+
+	content := s.viewport.View()
+	if scrollbar := common.Scrollbar(t, viewportHeight, s.viewport.TotalLineCount(), viewportHeight, s.viewport.YOffset()); scrollbar != "" {
+		content = lipgloss.JoinHorizontal(lipgloss.Top, content, scrollbar)
+	}
+	rc.AddPart(content)
+
+	DrawCenter(scr, area, rc.Render())
+	return nil
+}
+
+// renderFiles 将所有文件渲染为按文件分组的树：每个文件一行标题，
+// 展开的文件在标题下方显示其差异。
+func (s *SessionDiff) renderFiles(width int) string {
+	t := s.com.Styles
+	const diffIndent = 2
+	parts := make([]string, 0, len(s.files)*2)
+	s.fileLines = s.fileLines[:0]
+	line := 0
+	for i, f := range s.files {
+		icon := styles.ExpandedIcon
+		if s.collapsed[i] {
+			icon = styles.CollapsedIcon
+		}
+		title := icon + " " + fsext.PrettyPath(f.change.Path)
+
+		var header string
+		if i == s.fileCursor {
+			stats := fmt.Sprintf("+%d -%d", f.additions, f.deletions)
+			if f.change.New {
+				stats = "新文件  " + stats
+			}
+			header = t.Dialog.SelectedItem.Width(width).Render(title + "  " + stats)
+		} else {
+			stats := t.Files.Additions.Render(fmt.Sprintf("+%d", f.additions)) + " " +
+				t.Files.Deletions.Render(fmt.Sprintf("-%d", f.deletions))
+			if f.change.New {
+				stats = t.Subtle.Render("新文件") + "  " + stats
+			}
+			header = t.Dialog.NormalItem.Width(width).Render(t.Files.Path.Render(title) + "  " + stats)
+		}
+		s.fileLines = append(s.fileLines, line)
+		parts = append(parts, header)
+		line += lipgloss.Height(header)
+
+		if s.collapsed[i] {
+			continue
+		}
+		formatter := common.DiffFormatter(t).
+			Before(fsext.PrettyPath(f.change.Path), f.change.Before).
+			After(fsext.PrettyPath(f.change.Path), f.change.After).
+			XOffset(s.xOffset).
+			Width(width - diffIndent)
+		var rendered string
+		if s.split {
+			rendered = formatter.Split().String()
+		} else {
+			rendered = formatter.Unified().String()
+		}
+		rendered = lipgloss.NewStyle().PaddingLeft(diffIndent).Render(rendered)
+		parts = append(parts, rendered)
+		line += lipgloss.Height(rendered)
+	}
+	return strings.Join(parts, "\n")
+}
+
+// ShortHelp 实现 [help.KeyMap] 接口。
+func (s *SessionDiff) ShortHelp() []key.Binding {
+	return []key.Binding{
+		s.keyMap.SelectFile,
+		s.keyMap.ToggleFile,
+		s.keyMap.ToggleDiffMode,
+		s.keyMap.Scroll,
+		s.keyMap.Export,
+		s.keyMap.Close,
+	}
+}
+
+// FullHelp 实现 [help.KeyMap] 接口。
+func (s *SessionDiff) FullHelp() [][]key.Binding {
+	return [][]key.Binding{
+		{s.keyMap.SelectFile, s.keyMap.ToggleFile, s.keyMap.ToggleAllFiles},
+		{s.keyMap.ToggleDiffMode, s.keyMap.Scroll, s.keyMap.Export, s.keyMap.Close},
+	}
+}
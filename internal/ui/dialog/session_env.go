@@ -0,0 +1,141 @@
+package dialog
+
+import (
+	"charm.land/bubbles/v2/help"
+	"charm.land/bubbles/v2/key"
+	"charm.land/bubbles/v2/textarea"
+	tea "charm.land/bubbletea/v2"
+	uv "github.com/charmbracelet/ultraviolet"
+	"github.com/purpose168/crush-cn/internal/session"
+	"github.com/purpose168/crush-cn/internal/ui/common"
+)
+
+const (
+	// SessionEnvID 是会话环境变量编辑对话框的标识符。
+	SessionEnvID = "session_env"
+	// sessionEnvEditorHeight 是环境变量编辑区域的高度。
+	sessionEnvEditorHeight = 10
+)
+
+// SessionEnv 是编辑会话环境变量的对话框。每行一个 KEY=VALUE，保存后
+// 注入到该会话中每次 bash 工具调用。
+type SessionEnv struct {
+	com       *common.Common
+	help      help.Model
+	editor    textarea.Model
+	sessionID string
+	// err 是上次保存时的解析错误
+	err error
+
+	keyMap struct {
+		Save    key.Binding
+		Newline key.Binding
+		Close   key.Binding
+	}
+}
+
+var _ Dialog = (*SessionEnv)(nil)
+
+// NewSessionEnv 使用会话当前的环境变量创建一个新的 [SessionEnv] 对话框。
+func NewSessionEnv(com *common.Common, sessionID string, env map[string]string) (*SessionEnv, tea.Cmd) {
+	s := &SessionEnv{com: com, sessionID: sessionID}
+
+	help := help.New()
+	help.Styles = com.Styles.DialogHelpStyles()
+	s.help = help
+
+	s.editor = textarea.New()
+	s.editor.SetStyles(com.Styles.TextArea)
+	s.editor.ShowLineNumbers = false
+	s.editor.CharLimit = -1
+	s.editor.SetVirtualCursor(false)
+	s.editor.SetHeight(sessionEnvEditorHeight)
+	s.editor.Placeholder = "GOFLAGS=-count=1\nNODE_ENV=test"
+	s.editor.SetValue(session.FormatEnv(env))
+	s.editor.MoveToEnd()
+
+	s.keyMap.Save = key.NewBinding(
+		key.WithKeys("ctrl+s"),
+		key.WithHelp("ctrl+s", "保存"),
+	)
+	s.keyMap.Newline = key.NewBinding(
+		key.WithKeys("enter"),
+		key.WithHelp("enter", "换行"),
+	)
+	s.keyMap.Close = CloseKey
+
+	return s, s.editor.Focus()
+}
+
+// ID 实现 Dialog 接口。
+func (s *SessionEnv) ID() string {
+	return SessionEnvID
+}
+
+// HandleMsg 实现 Dialog 接口。
+func (s *SessionEnv) HandleMsg(msg tea.Msg) Action {
+	keyMsg, ok := msg.(tea.KeyPressMsg)
+	if !ok {
+		return nil
+	}
+
+	switch {
+	case key.Matches(keyMsg, s.keyMap.Close):
+		return ActionClose{}
+	case key.Matches(keyMsg, s.keyMap.Save):
+		env, err := session.ParseEnv(s.editor.Value())
+		if err != nil {
+			s.err = err
+			return nil
+		}
+		return ActionSetSessionEnv{SessionID: s.sessionID, Env: env}
+	case key.Matches(keyMsg, s.keyMap.Newline):
+		s.editor.InsertRune('\n')
+	default:
+		var cmd tea.Cmd
+		s.editor, cmd = s.editor.Update(keyMsg)
+		return ActionCmd{cmd}
+	}
+	return nil
+}
+
+// Draw 实现 [Dialog] 接口。
+func (s *SessionEnv) Draw(scr uv.Screen, area uv.Rectangle) *tea.Cursor {
+	t := s.com.Styles
+	width := max(0, min(defaultDialogMaxWidth, area.Dx()))
+	innerWidth := width - t.Dialog.View.GetHorizontalFrameSize() - 2
+
+	rc := NewRenderContext(t, width)
+	rc.Title = "会话环境变量"
+
+	editorWidth := max(0, innerWidth-t.Dialog.InputPrompt.GetHorizontalFrameSize()-1)
+	s.editor.SetWidth(editorWidth)
+	rc.AddPart(t.Dialog.InputPrompt.Render(s.editor.View()))
+	hint := t.Subtle.Width(editorWidth).Render("每行一个 KEY=VALUE，以 # 开头的行为注释。")
+	if s.err != nil {
+		hint = t.Dialog.TitleError.Width(editorWidth).Render(s.err.Error())
+	}
+	rc.AddPart(t.Dialog.InputPrompt.Render(hint))
+
+	s.help.SetWidth(innerWidth)
+	rc.Help = s.help.View(s)
+
+	cur := InputCursor(t, s.editor.Cursor())
+	view := rc.Render()
+	DrawCenterCursor(scr, area, view, cur)
+	return cur
+}
+
+// ShortHelp 实现 [help.KeyMap] 接口。
+func (s *SessionEnv) ShortHelp() []key.Binding {
+	return []key.Binding{
+		s.keyMap.Save,
+		s.keyMap.Newline,
+		s.keyMap.Close,
+	}
+}
+
+// FullHelp 实现 [help.KeyMap] 接口。
+func (s *SessionEnv) FullHelp() [][]key.Binding {
+	return [][]key.Binding{s.ShortHelp()}
+}
@@ -0,0 +1,156 @@
+package dialog
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"charm.land/bubbles/v2/help"
+	"charm.land/bubbles/v2/key"
+	"charm.land/bubbles/v2/textinput"
+	tea "charm.land/bubbletea/v2"
+	uv "github.com/charmbracelet/ultraviolet"
+	"github.com/purpose168/crush-cn/internal/session"
+	"github.com/purpose168/crush-cn/internal/ui/common"
+)
+
+// SessionSettingsID 是会话设置对话框的标识符。
+const SessionSettingsID = "session_settings"
+
+// SessionSettings 是编辑会话设置的对话框。目前可以设置会话的工作目录，
+// 它是项目中的一个子目录（例如 monorepo 中的某个包），bash 工具在其中执行，
+// glob、grep 和 ls 默认在其中搜索。
+type SessionSettings struct {
+	com       *common.Common
+	help      help.Model
+	input     textinput.Model
+	sessionID string
+	// root 是项目根目录，工作目录相对于它
+	root string
+	// checkExists 表示是否检查目录在本地存在，远程开发时为 false
+	checkExists bool
+	// err 是上次保存时的校验错误
+	err error
+
+	keyMap struct {
+		Save  key.Binding
+		Close key.Binding
+	}
+}
+
+var _ Dialog = (*SessionSettings)(nil)
+
+// NewSessionSettings 使用会话当前的工作目录创建一个新的 [SessionSettings] 对话框。
+func NewSessionSettings(com *common.Common, sessionID, root, workingDir string, checkExists bool) (*SessionSettings, tea.Cmd) {
+	s := &SessionSettings{
+		com:         com,
+		sessionID:   sessionID,
+		root:        root,
+		checkExists: checkExists,
+	}
+
+	help := help.New()
+	help.Styles = com.Styles.DialogHelpStyles()
+	s.help = help
+
+	s.input = textinput.New()
+	s.input.SetVirtualCursor(false)
+	s.input.SetStyles(com.Styles.TextInput)
+	s.input.Placeholder = "packages/api"
+	s.input.SetValue(workingDir)
+	s.input.CursorEnd()
+
+	s.keyMap.Save = key.NewBinding(
+		key.WithKeys("enter"),
+		key.WithHelp("enter", "保存"),
+	)
+	s.keyMap.Close = CloseKey
+
+	return s, s.input.Focus()
+}
+
+// ID 实现 Dialog 接口。
+func (s *SessionSettings) ID() string {
+	return SessionSettingsID
+}
+
+// HandleMsg 实现 Dialog 接口。
+func (s *SessionSettings) HandleMsg(msg tea.Msg) Action {
+	keyMsg, ok := msg.(tea.KeyPressMsg)
+	if !ok {
+		return nil
+	}
+
+	switch {
+	case key.Matches(keyMsg, s.keyMap.Close):
+		return ActionClose{}
+	case key.Matches(keyMsg, s.keyMap.Save):
+		dir, err := s.validate(s.input.Value())
+		if err != nil {
+			s.err = err
+			return nil
+		}
+		return ActionSetSessionWorkingDir{SessionID: s.sessionID, Dir: dir}
+	default:
+		var cmd tea.Cmd
+		s.input, cmd = s.input.Update(keyMsg)
+		s.err = nil
+		return ActionCmd{cmd}
+	}
+}
+
+// validate 将输入解析为相对于项目根目录的路径，并在本地项目中检查它是一个目录。
+func (s *SessionSettings) validate(value string) (string, error) {
+	dir, err := session.ResolveWorkingDir(s.root, value)
+	if err != nil || dir == "" || !s.checkExists {
+		return dir, err
+	}
+	info, err := os.Stat(filepath.Join(s.root, filepath.FromSlash(dir)))
+	if err != nil {
+		return "", fmt.Errorf("目录 %s 不存在", dir)
+	}
+	if !info.IsDir() {
+		return "", fmt.Errorf("%s 不是目录", dir)
+	}
+	return dir, nil
+}
+
+// Draw 实现 [Dialog] 接口。
+func (s *SessionSettings) Draw(scr uv.Screen, area uv.Rectangle) *tea.Cursor {
+	t := s.com.Styles
+	width := max(0, min(defaultDialogMaxWidth, area.Dx()))
+	innerWidth := width - t.Dialog.View.GetHorizontalFrameSize() - 2
+
+	rc := NewRenderContext(t, width)
+	rc.Title = "会话设置"
+
+	inputWidth := max(0, innerWidth-t.Dialog.InputPrompt.GetHorizontalFrameSize()-1)
+	s.input.SetWidth(inputWidth)
+	rc.AddPart(t.Dialog.InputPrompt.Render(s.input.View()))
+	hint := t.Subtle.Width(inputWidth).Render("工作目录相对于项目根目录，留空使用项目根目录。bash 在此目录中执行，glob、grep、ls 和 run_tests 默认在此目录中搜索。")
+	if s.err != nil {
+		hint = t.Dialog.TitleError.Width(inputWidth).Render(s.err.Error())
+	}
+	rc.AddPart(t.Dialog.InputPrompt.Render(hint))
+
+	s.help.SetWidth(innerWidth)
+	rc.Help = s.help.View(s)
+
+	cur := InputCursor(t, s.input.Cursor())
+	view := rc.Render()
+	DrawCenterCursor(scr, area, view, cur)
+	return cur
+}
+
+// ShortHelp 实现 [help.KeyMap] 接口。
+func (s *SessionSettings) ShortHelp() []key.Binding {
+	return []key.Binding{
+		s.keyMap.Save,
+		s.keyMap.Close,
+	}
+}
+
+// FullHelp 实现 [help.KeyMap] 接口。
+func (s *SessionSettings) FullHelp() [][]key.Binding {
+	return [][]key.Binding{s.ShortHelp()}
+}
@@ -0,0 +1,142 @@
+package dialog
+
+import (
+	"strings"
+
+	"charm.land/bubbles/v2/help"
+	"charm.land/bubbles/v2/key"
+	"charm.land/bubbles/v2/textinput"
+	tea "charm.land/bubbletea/v2"
+	uv "github.com/charmbracelet/ultraviolet"
+	"github.com/purpose168/crush-cn/internal/session"
+	"github.com/purpose168/crush-cn/internal/ui/common"
+)
+
+// SessionTagsID 是会话标签对话框的标识符。
+const SessionTagsID = "session_tags"
+
+// maxKnownTags 是提示中列出的已有标签的最大数量
+const maxKnownTags = 8
+
+// SessionTags 是编辑会话标签（例如 bug、refactor、spike）的对话框。
+// 标签以逗号或空格分隔，可以在会话列表中用 #标签 筛选。
+type SessionTags struct {
+	com       *common.Common
+	help      help.Model
+	input     textinput.Model
+	sessionID string
+	// known 是其他会话中已使用的标签，按使用次数排列
+	known []string
+	// err 是上次保存时的解析错误
+	err error
+
+	keyMap struct {
+		Save  key.Binding
+		Close key.Binding
+	}
+}
+
+var _ Dialog = (*SessionTags)(nil)
+
+// NewSessionTags 使用会话当前的标签创建一个新的 [SessionTags] 对话框。
+func NewSessionTags(com *common.Common, sessionID string, tags, known []string) (*SessionTags, tea.Cmd) {
+	s := &SessionTags{
+		com:       com,
+		sessionID: sessionID,
+		known:     known,
+	}
+
+	help := help.New()
+	help.Styles = com.Styles.DialogHelpStyles()
+	s.help = help
+
+	s.input = textinput.New()
+	s.input.SetVirtualCursor(false)
+	s.input.SetStyles(com.Styles.TextInput)
+	s.input.Placeholder = "bug, refactor, spike"
+	s.input.SetValue(session.FormatTags(tags))
+	s.input.CursorEnd()
+
+	s.keyMap.Save = key.NewBinding(
+		key.WithKeys("enter"),
+		key.WithHelp("enter", "保存"),
+	)
+	s.keyMap.Close = CloseKey
+
+	return s, s.input.Focus()
+}
+
+// ID 实现 Dialog 接口。
+func (s *SessionTags) ID() string {
+	return SessionTagsID
+}
+
+// HandleMsg 实现 Dialog 接口。
+func (s *SessionTags) HandleMsg(msg tea.Msg) Action {
+	keyMsg, ok := msg.(tea.KeyPressMsg)
+	if !ok {
+		return nil
+	}
+
+	switch {
+	case key.Matches(keyMsg, s.keyMap.Close):
+		return ActionClose{}
+	case key.Matches(keyMsg, s.keyMap.Save):
+		tags, err := session.ParseTags(s.input.Value())
+		if err != nil {
+			s.err = err
+			return nil
+		}
+		return ActionSetSessionTags{SessionID: s.sessionID, Tags: tags}
+	default:
+		var cmd tea.Cmd
+		s.input, cmd = s.input.Update(keyMsg)
+		s.err = nil
+		return ActionCmd{cmd}
+	}
+}
+
+// Draw 实现 [Dialog] 接口。
+func (s *SessionTags) Draw(scr uv.Screen, area uv.Rectangle) *tea.Cursor {
+	t := s.com.Styles
+	width := max(0, min(defaultDialogMaxWidth, area.Dx()))
+	innerWidth := width - t.Dialog.View.GetHorizontalFrameSize() - 2
+
+	rc := NewRenderContext(t, width)
+	rc.Title = "会话标签"
+
+	inputWidth := max(0, innerWidth-t.Dialog.InputPrompt.GetHorizontalFrameSize()-1)
+	s.input.SetWidth(inputWidth)
+	rc.AddPart(t.Dialog.InputPrompt.Render(s.input.View()))
+	hintText := "以逗号或空格分隔，留空清除标签。在会话列表中输入 #标签 按标签筛选。"
+	if len(s.known) > 0 {
+		known := s.known[:min(len(s.known), maxKnownTags)]
+		hintText += "\n已有标签：" + strings.Join(known, ", ")
+	}
+	hint := t.Subtle.Width(inputWidth).Render(hintText)
+	if s.err != nil {
+		hint = t.Dialog.TitleError.Width(inputWidth).Render(s.err.Error())
+	}
+	rc.AddPart(t.Dialog.InputPrompt.Render(hint))
+
+	s.help.SetWidth(innerWidth)
+	rc.Help = s.help.View(s)
+
+	cur := InputCursor(t, s.input.Cursor())
+	view := rc.Render()
+	DrawCenterCursor(scr, area, view, cur)
+	return cur
+}
+
+// ShortHelp 实现 [help.KeyMap] 接口。
+func (s *SessionTags) ShortHelp() []key.Binding {
+	return []key.Binding{
+		s.keyMap.Save,
+		s.keyMap.Close,
+	}
+}
+
+// FullHelp 实现 [help.KeyMap] 接口。
+func (s *SessionTags) FullHelp() [][]key.Binding {
+	return [][]key.Binding{s.ShortHelp()}
+}
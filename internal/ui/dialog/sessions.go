@@ -1,7 +1,9 @@
 package dialog
 
 import (
+	"cmp"
 	"context"
+	"slices"
 	"strings"
 
 	"charm.land/bubbles/v2/help"
@@ -28,6 +30,28 @@ const (
 	sessionsModeUpdating
 )
 
+// sessionsSort 是会话列表的排序方式
+type sessionsSort uint8
+
+// 会话列表可用的排序方式，按切换顺序排列
+const (
+	sessionsSortRecent sessionsSort = iota
+	sessionsSortCost
+	sessionsSortLength
+)
+
+// String 返回排序方式的显示名称。
+func (s sessionsSort) String() string {
+	switch s {
+	case sessionsSortCost:
+		return "费用"
+	case sessionsSortLength:
+		return "长度"
+	default:
+		return "最近"
+	}
+}
+
 // Session 是一个会话选择器对话框。
 type Session struct {
 	com                *common.Common
@@ -38,6 +62,10 @@ type Session struct {
 	sessions           []session.Session
 
 	sessionsMode sessionsMode
+	// showArchived 为 true 时列出已归档的会话
+	showArchived bool
+	// sort 是会话列表当前的排序方式
+	sort sessionsSort
 
 	keyMap struct {
 		Select        key.Binding
@@ -46,6 +74,10 @@ type Session struct {
 		UpDown        key.Binding
 		Delete        key.Binding
 		Rename        key.Binding
+		Archive       key.Binding
+		ShowArchived  key.Binding
+		Tags          key.Binding
+		Sort          key.Binding
 		ConfirmRename key.Binding
 		CancelRename  key.Binding
 		ConfirmDelete key.Binding
@@ -61,13 +93,11 @@ func NewSessions(com *common.Common, selectedSessionID string) (*Session, error)
 	s := new(Session)
 	s.sessionsMode = sessionsModeNormal
 	s.com = com
-	sessions, err := com.App.Sessions.List(context.TODO())
-	if err != nil {
+	if err := s.loadSessions(); err != nil {
 		return nil, err
 	}
 
-	s.sessions = sessions
-	for i, sess := range sessions {
+	for i, sess := range s.sessions {
 		if sess.ID == selectedSessionID {
 			s.selectedSessionInx = i
 			break
@@ -78,13 +108,13 @@ func NewSessions(com *common.Common, selectedSessionID string) (*Session, error)
 	help.Styles = com.Styles.DialogHelpStyles()
 
 	s.help = help
-	s.list = list.NewFilterableList(sessionItems(com.Styles, sessionsModeNormal, sessions...)...)
+	s.list = list.NewFilterableList(sessionItems(com.Styles, sessionsModeNormal, s.sort, s.sessions...)...)
 	s.list.Focus()
 	s.list.SetSelected(s.selectedSessionInx)
 
 	s.input = textinput.New()
 	s.input.SetVirtualCursor(false)
-	s.input.Placeholder = "输入会话名称"
+	s.input.Placeholder = "输入会话名称，#标签 按标签筛选"
 	s.input.SetStyles(com.Styles.TextInput)
 	s.input.Focus()
 
@@ -112,6 +142,21 @@ func NewSessions(com *common.Common, selectedSessionID string) (*Session, error)
 		key.WithKeys("ctrl+r"),
 		key.WithHelp("ctrl+r", "重命名"),
 	)
+	s.keyMap.Archive = key.NewBinding(
+		key.WithKeys("ctrl+a"),
+	)
+	s.keyMap.ShowArchived = key.NewBinding(
+		key.WithKeys("ctrl+t"),
+	)
+	s.updateArchiveHelp()
+	s.keyMap.Tags = key.NewBinding(
+		key.WithKeys("ctrl+e"),
+		key.WithHelp("ctrl+e", "标签"),
+	)
+	s.keyMap.Sort = key.NewBinding(
+		key.WithKeys("ctrl+o"),
+	)
+	s.updateSortHelp()
 	s.keyMap.ConfirmRename = key.NewBinding(
 		key.WithKeys("enter"),
 		key.WithHelp("enter", "确认"),
@@ -147,23 +192,23 @@ func (s *Session) HandleMsg(msg tea.Msg) Action {
 			switch {
 			case key.Matches(msg, s.keyMap.ConfirmDelete):
 				action := s.confirmDeleteSession()
-				s.list.SetItems(sessionItems(s.com.Styles, sessionsModeNormal, s.sessions...)...)
+				s.setItems(sessionsModeNormal)
 				s.list.SelectFirst()
 				s.list.ScrollToSelected()
 				return action
 			case key.Matches(msg, s.keyMap.CancelDelete):
 				s.sessionsMode = sessionsModeNormal
-				s.list.SetItems(sessionItems(s.com.Styles, sessionsModeNormal, s.sessions...)...)
+				s.setItems(sessionsModeNormal)
 			}
 		case sessionsModeUpdating:
 			switch {
 			case key.Matches(msg, s.keyMap.ConfirmRename):
 				action := s.confirmRenameSession()
-				s.list.SetItems(sessionItems(s.com.Styles, sessionsModeNormal, s.sessions...)...)
+				s.setItems(sessionsModeNormal)
 				return action
 			case key.Matches(msg, s.keyMap.CancelRename):
 				s.sessionsMode = sessionsModeNormal
-				s.list.SetItems(sessionItems(s.com.Styles, sessionsModeNormal, s.sessions...)...)
+				s.setItems(sessionsModeNormal)
 			default:
 				item := s.list.SelectedItem()
 				if item == nil {
@@ -179,13 +224,35 @@ func (s *Session) HandleMsg(msg tea.Msg) Action {
 				return ActionClose{}
 			case key.Matches(msg, s.keyMap.Rename):
 				s.sessionsMode = sessionsModeUpdating
-				s.list.SetItems(sessionItems(s.com.Styles, sessionsModeUpdating, s.sessions...)...)
+				s.setItems(sessionsModeUpdating)
+			case key.Matches(msg, s.keyMap.Archive):
+				return s.toggleArchiveSession()
+			case key.Matches(msg, s.keyMap.Tags):
+				if item := s.selectedSessionItem(); item != nil {
+					return ActionOpenSessionTags{SessionID: item.ID(), Tags: item.Tags}
+				}
+			case key.Matches(msg, s.keyMap.Sort):
+				s.sort = (s.sort + 1) % (sessionsSortLength + 1)
+				s.updateSortHelp()
+				s.setItems(sessionsModeNormal)
+				s.list.SelectFirst()
+				s.list.ScrollToTop()
+			case key.Matches(msg, s.keyMap.ShowArchived):
+				s.showArchived = !s.showArchived
+				s.updateArchiveHelp()
+				if err := s.loadSessions(); err != nil {
+					return ActionCmd{util.ReportError(err)}
+				}
+				s.input.Reset()
+				s.setItems(sessionsModeNormal)
+				s.list.SelectFirst()
+				s.list.ScrollToTop()
 			case key.Matches(msg, s.keyMap.Delete):
 				if s.isCurrentSessionBusy() {
 					return ActionCmd{util.ReportWarn("智能体正忙，请稍候...")}
 				}
 				s.sessionsMode = sessionsModeDeleting
-				s.list.SetItems(sessionItems(s.com.Styles, sessionsModeDeleting, s.sessions...)...)
+				s.setItems(sessionsModeDeleting)
 			case key.Matches(msg, s.keyMap.Previous):
 				s.list.Focus()
 				if s.list.IsSelectedFirst() {
@@ -212,8 +279,7 @@ func (s *Session) HandleMsg(msg tea.Msg) Action {
 			default:
 				var cmd tea.Cmd
 				s.input, cmd = s.input.Update(msg)
-				value := s.input.Value()
-				s.list.SetFilter(value)
+				s.setItems(sessionsModeNormal)
 				s.list.ScrollToTop()
 				s.list.SetSelected(0)
 				return ActionCmd{cmd}
@@ -253,6 +319,12 @@ func (s *Session) Draw(scr uv.Screen, area uv.Rectangle) *tea.Cursor {
 	var cur *tea.Cursor
 	rc := NewRenderContext(t, width)
 	rc.Title = "会话"
+	if s.showArchived {
+		rc.Title = "已归档会话"
+	}
+	if s.sort != sessionsSortRecent {
+		rc.Title += "（按" + s.sort.String() + "）"
+	}
 	switch s.sessionsMode {
 	case sessionsModeDeleting:
 		rc.TitleStyle = t.Dialog.Sessions.DeletingTitle
@@ -320,6 +392,130 @@ func (s *Session) Draw(scr uv.Screen, area uv.Rectangle) *tea.Cursor {
 	return cur
 }
 
+// loadSessions 根据当前视图加载未归档或已归档的会话。
+func (s *Session) loadSessions() error {
+	var (
+		sessions []session.Session
+		err      error
+	)
+	if s.showArchived {
+		sessions, err = s.com.App.Sessions.ListArchived(context.TODO())
+	} else {
+		sessions, err = s.com.App.Sessions.List(context.TODO())
+	}
+	if err != nil {
+		return err
+	}
+	s.sessions = sessions
+	return nil
+}
+
+// updateArchiveHelp 根据当前视图更新归档相关按键的帮助文本。
+func (s *Session) updateArchiveHelp() {
+	if s.showArchived {
+		s.keyMap.Archive.SetHelp("ctrl+a", "恢复")
+		s.keyMap.ShowArchived.SetHelp("ctrl+t", "活动会话")
+		return
+	}
+	s.keyMap.Archive.SetHelp("ctrl+a", "归档")
+	s.keyMap.ShowArchived.SetHelp("ctrl+t", "已归档")
+}
+
+// updateSortHelp 根据当前排序方式更新排序按键的帮助文本。
+func (s *Session) updateSortHelp() {
+	s.keyMap.Sort.SetHelp("ctrl+o", "排序："+s.sort.String())
+}
+
+// setItems 按标签筛选和排序方式重建列表项目，并对会话标题应用搜索输入中的模糊查询。
+func (s *Session) setItems(mode sessionsMode) {
+	tags, query := parseSessionsQuery(s.input.Value())
+	sessions := filterSessions(s.sessions, tags)
+	sortSessions(sessions, s.sort)
+	s.list.SetItems(sessionItems(s.com.Styles, mode, s.sort, sessions...)...)
+	s.list.SetFilter(query)
+}
+
+// SetSessionTags 更新列表中会话的标签，用于标签对话框保存后刷新列表。
+func (s *Session) SetSessionTags(sessionID string, tags []string) {
+	for i, sess := range s.sessions {
+		if sess.ID == sessionID {
+			s.sessions[i].Tags = tags
+			break
+		}
+	}
+	s.setItems(s.sessionsMode)
+	s.list.ScrollToSelected()
+}
+
+// parseSessionsQuery 将搜索输入拆分为以 # 开头的标签和匹配会话标题的模糊查询。
+func parseSessionsQuery(value string) (tags []string, query string) {
+	var words []string
+	for field := range strings.FieldsSeq(value) {
+		if tag, ok := strings.CutPrefix(field, "#"); ok {
+			if tag != "" {
+				tags = append(tags, strings.ToLower(tag))
+			}
+			continue
+		}
+		words = append(words, field)
+	}
+	return tags, strings.Join(words, " ")
+}
+
+// filterSessions 返回带有所有给定标签的会话，不修改原切片。
+func filterSessions(sessions []session.Session, tags []string) []session.Session {
+	filtered := make([]session.Session, 0, len(sessions))
+	for _, sess := range sessions {
+		if !slices.ContainsFunc(tags, func(tag string) bool { return !sess.HasTag(tag) }) {
+			filtered = append(filtered, sess)
+		}
+	}
+	return filtered
+}
+
+// sortSessions 按排序方式从大到小对会话排序。会话服务已按最近更新时间返回会话，
+// 因此排序是稳定的，值相同的会话保持最近更新的在前。
+func sortSessions(sessions []session.Session, sort sessionsSort) {
+	switch sort {
+	case sessionsSortCost:
+		slices.SortStableFunc(sessions, func(a, b session.Session) int {
+			return cmp.Compare(b.Cost, a.Cost)
+		})
+	case sessionsSortLength:
+		slices.SortStableFunc(sessions, func(a, b session.Session) int {
+			return cmp.Compare(b.MessageCount, a.MessageCount)
+		})
+	}
+}
+
+// toggleArchiveSession 归档选中的会话，在已归档视图中则将其恢复。
+func (s *Session) toggleArchiveSession() Action {
+	sessionItem := s.selectedSessionItem()
+	if sessionItem == nil {
+		return nil
+	}
+	id := sessionItem.ID()
+	s.removeSession(id)
+	s.setItems(sessionsModeNormal)
+	s.list.ScrollToSelected()
+	return ActionCmd{s.archiveSessionCmd(id, !s.showArchived)}
+}
+
+func (s *Session) archiveSessionCmd(id string, archive bool) tea.Cmd {
+	return func() tea.Msg {
+		if archive {
+			if _, err := s.com.App.Sessions.Archive(context.TODO(), id); err != nil {
+				return util.NewErrorMsg(err)
+			}
+			return util.NewInfoMsg("会话已归档")
+		}
+		if _, err := s.com.App.Sessions.Unarchive(context.TODO(), id); err != nil {
+			return util.NewErrorMsg(err)
+		}
+		return util.NewInfoMsg("会话已恢复")
+	}
+}
+
 func (s *Session) selectedSessionItem() *SessionItem {
 	if item := s.list.SelectedItem(); item != nil {
 		return item.(*SessionItem)
@@ -425,6 +621,8 @@ func (s *Session) ShortHelp() []key.Binding {
 		return []key.Binding{
 			s.keyMap.UpDown,
 			s.keyMap.Rename,
+			s.keyMap.Archive,
+			s.keyMap.Tags,
 			s.keyMap.Delete,
 			s.keyMap.Select,
 			s.keyMap.Close,
@@ -438,6 +636,10 @@ func (s *Session) FullHelp() [][]key.Binding {
 	slice := []key.Binding{
 		s.keyMap.UpDown,
 		s.keyMap.Rename,
+		s.keyMap.Archive,
+		s.keyMap.ShowArchived,
+		s.keyMap.Tags,
+		s.keyMap.Sort,
 		s.keyMap.Delete,
 		s.keyMap.Select,
 		s.keyMap.Close,
@@ -32,10 +32,13 @@ type SessionItem struct {
 	session.Session
 	t                *styles.Styles
 	sessionsMode     sessionsMode
+	sort             sessionsSort
 	m                fuzzy.Match
 	cache            map[int]string
 	updateTitleInput textinput.Model
 	focused          bool
+	// showAuthor 表示是否在时间前显示会话作者，数据目录由多人共享时启用
+	showAuthor bool
 }
 
 var _ ListItem = &SessionItem{}
@@ -75,7 +78,21 @@ func (s *SessionItem) Cursor() *tea.Cursor {
 
 // Render 返回会话项目的字符串表示。
 func (s *SessionItem) Render(width int) string {
-	info := humanize.Time(time.Unix(s.UpdatedAt, 0))
+	var info string
+	switch s.sort {
+	case sessionsSortCost:
+		info = fmt.Sprintf("$%.2f", s.Cost)
+	case sessionsSortLength:
+		info = fmt.Sprintf("%d 条消息", s.MessageCount)
+	default:
+		info = humanize.Time(time.Unix(s.UpdatedAt, 0))
+	}
+	if len(s.Tags) > 0 {
+		info = "#" + strings.Join(s.Tags, " #") + " · " + info
+	}
+	if author := s.AuthorName(); s.showAuthor && author != "" {
+		info = author + " · " + info
+	}
 	styles := ListItemStyles{
 		ItemBlurred:     s.t.Dialog.NormalItem,
 		ItemFocused:     s.t.Dialog.SelectedItem,
@@ -183,10 +200,11 @@ func (s *SessionItem) SetFocused(focused bool) {
 
 // sessionItems 接受一个[session.Session]切片并将它们转换为
 // [ListItem]切片。
-func sessionItems(t *styles.Styles, mode sessionsMode, sessions ...session.Session) []list.FilterableItem {
+func sessionItems(t *styles.Styles, mode sessionsMode, sort sessionsSort, sessions ...session.Session) []list.FilterableItem {
 	items := make([]list.FilterableItem, len(sessions))
+	showAuthor := hasMultipleAuthors(sessions)
 	for i, s := range sessions {
-		item := &SessionItem{Session: s, t: t, sessionsMode: mode}
+		item := &SessionItem{Session: s, t: t, sessionsMode: mode, sort: sort, showAuthor: showAuthor}
 		if mode == sessionsModeUpdating {
 			item.updateTitleInput = textinput.New()
 			item.updateTitleInput.SetVirtualCursor(false)
@@ -201,6 +219,22 @@ func sessionItems(t *styles.Styles, mode sessionsMode, sessions ...session.Sessi
 	return items
 }
 
+// hasMultipleAuthors 报告会话是否来自不止一个作者，即数据目录由多人共享。
+func hasMultipleAuthors(sessions []session.Session) bool {
+	var first string
+	for _, s := range sessions {
+		if s.Author == "" {
+			continue
+		}
+		if first == "" {
+			first = s.Author
+		} else if s.Author != first {
+			return true
+		}
+	}
+	return false
+}
+
 func matchedRanges(in []int) [][2]int {
 	if len(in) == 0 {
 		return [][2]int{}
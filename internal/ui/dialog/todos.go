@@ -0,0 +1,402 @@
+package dialog
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"charm.land/bubbles/v2/help"
+	"charm.land/bubbles/v2/key"
+	"charm.land/bubbles/v2/textinput"
+	tea "charm.land/bubbletea/v2"
+	uv "github.com/charmbracelet/ultraviolet"
+	"github.com/purpose168/crush-cn/internal/session"
+	"github.com/purpose168/crush-cn/internal/ui/common"
+	"github.com/purpose168/crush-cn/internal/ui/list"
+	"github.com/purpose168/crush-cn/internal/ui/styles"
+)
+
+// TodosID 是待办事项编辑对话框的标识符。
+const TodosID = "todos"
+
+type todosMode uint8
+
+// 待办事项对话框可以处于的可能模式
+const (
+	todosModeNormal todosMode = iota
+	// todosModeEditing 表示正在修改选中的待办事项
+	todosModeEditing
+	// todosModeAdding 表示正在选中项之后添加新的待办事项
+	todosModeAdding
+)
+
+// Todos 是编辑会话待办事项的对话框。用户可以勾选、重新排序、添加、修改和删除待办事项，
+// 保存后写入会话，智能体在下一轮中会看到更新后的列表。
+type Todos struct {
+	com       *common.Common
+	help      help.Model
+	list      *list.List
+	input     textinput.Model
+	sessionID string
+	todos     []session.Todo
+	// original 是打开对话框时的待办事项，用于判断是否有未保存的修改
+	original []session.Todo
+
+	mode todosMode
+
+	keyMap struct {
+		Next        key.Binding
+		Previous    key.Binding
+		UpDown      key.Binding
+		MoveUp      key.Binding
+		MoveDown    key.Binding
+		Move        key.Binding
+		Toggle      key.Binding
+		Add         key.Binding
+		Edit        key.Binding
+		Delete      key.Binding
+		Save        key.Binding
+		ConfirmEdit key.Binding
+		CancelEdit  key.Binding
+		Close       key.Binding
+	}
+}
+
+var _ Dialog = (*Todos)(nil)
+
+// NewTodos 使用会话当前的待办事项创建一个新的 [Todos] 对话框。
+func NewTodos(com *common.Common, sessionID string, todos []session.Todo) *Todos {
+	d := &Todos{
+		com:       com,
+		sessionID: sessionID,
+		todos:     slices.Clone(todos),
+		original:  slices.Clone(todos),
+	}
+
+	help := help.New()
+	help.Styles = com.Styles.DialogHelpStyles()
+	d.help = help
+
+	d.list = list.NewList()
+	d.list.Focus()
+
+	d.input = textinput.New()
+	d.input.SetVirtualCursor(false)
+	d.input.SetStyles(com.Styles.TextInput)
+	d.input.Placeholder = "待办事项内容"
+
+	d.keyMap.Next = key.NewBinding(
+		key.WithKeys("down", "ctrl+n"),
+		key.WithHelp("↓", "下一项"),
+	)
+	d.keyMap.Previous = key.NewBinding(
+		key.WithKeys("up", "ctrl+p"),
+		key.WithHelp("↑", "上一项"),
+	)
+	d.keyMap.UpDown = key.NewBinding(
+		key.WithKeys("up", "down"),
+		key.WithHelp("↑↓", "选择"),
+	)
+	d.keyMap.MoveUp = key.NewBinding(
+		key.WithKeys("shift+up", "ctrl+k"),
+		key.WithHelp("shift+↑", "上移"),
+	)
+	d.keyMap.MoveDown = key.NewBinding(
+		key.WithKeys("shift+down", "ctrl+j"),
+		key.WithHelp("shift+↓", "下移"),
+	)
+	d.keyMap.Move = key.NewBinding(
+		key.WithKeys("shift+up", "shift+down"),
+		key.WithHelp("shift+↑↓", "移动"),
+	)
+	d.keyMap.Toggle = key.NewBinding(
+		key.WithKeys("space"),
+		key.WithHelp("space", "完成"),
+	)
+	d.keyMap.Add = key.NewBinding(
+		key.WithKeys("ctrl+a"),
+		key.WithHelp("ctrl+a", "添加"),
+	)
+	d.keyMap.Edit = key.NewBinding(
+		key.WithKeys("enter", "ctrl+e"),
+		key.WithHelp("enter", "编辑"),
+	)
+	d.keyMap.Delete = key.NewBinding(
+		key.WithKeys("ctrl+x", "delete"),
+		key.WithHelp("ctrl+x", "删除"),
+	)
+	d.keyMap.Save = key.NewBinding(
+		key.WithKeys("ctrl+s"),
+		key.WithHelp("ctrl+s", "保存"),
+	)
+	d.keyMap.ConfirmEdit = key.NewBinding(
+		key.WithKeys("enter"),
+		key.WithHelp("enter", "确定"),
+	)
+	d.keyMap.CancelEdit = key.NewBinding(
+		key.WithKeys("esc"),
+		key.WithHelp("esc", "取消"),
+	)
+	d.keyMap.Close = CloseKey
+
+	d.refresh(0)
+	return d
+}
+
+// ID 实现 Dialog 接口。
+func (d *Todos) ID() string {
+	return TodosID
+}
+
+// HandleMsg 实现 Dialog 接口。
+func (d *Todos) HandleMsg(msg tea.Msg) Action {
+	keyMsg, ok := msg.(tea.KeyPressMsg)
+	if !ok {
+		return nil
+	}
+
+	if d.mode != todosModeNormal {
+		switch {
+		case key.Matches(keyMsg, d.keyMap.ConfirmEdit):
+			d.confirmInput()
+		case key.Matches(keyMsg, d.keyMap.CancelEdit):
+			d.mode = todosModeNormal
+			d.input.Blur()
+		default:
+			var cmd tea.Cmd
+			d.input, cmd = d.input.Update(keyMsg)
+			return ActionCmd{cmd}
+		}
+		return nil
+	}
+
+	idx := d.list.Selected()
+	switch {
+	case key.Matches(keyMsg, d.keyMap.Close):
+		return ActionClose{}
+	case key.Matches(keyMsg, d.keyMap.Save):
+		return ActionSetTodos{SessionID: d.sessionID, Todos: d.todos}
+	case key.Matches(keyMsg, d.keyMap.MoveUp):
+		d.move(idx, -1)
+	case key.Matches(keyMsg, d.keyMap.MoveDown):
+		d.move(idx, 1)
+	case key.Matches(keyMsg, d.keyMap.Previous):
+		if d.list.IsSelectedFirst() {
+			d.list.SelectLast()
+			d.list.ScrollToBottom()
+			break
+		}
+		d.list.SelectPrev()
+		d.list.ScrollToSelected()
+	case key.Matches(keyMsg, d.keyMap.Next):
+		if d.list.IsSelectedLast() {
+			d.list.SelectFirst()
+			d.list.ScrollToTop()
+			break
+		}
+		d.list.SelectNext()
+		d.list.ScrollToSelected()
+	case key.Matches(keyMsg, d.keyMap.Toggle):
+		if idx < 0 || idx >= len(d.todos) {
+			break
+		}
+		if d.todos[idx].Status == session.TodoStatusCompleted {
+			d.todos[idx].Status = session.TodoStatusPending
+		} else {
+			d.todos[idx].Status = session.TodoStatusCompleted
+		}
+		d.refresh(idx)
+	case key.Matches(keyMsg, d.keyMap.Add):
+		d.mode = todosModeAdding
+		d.input.SetValue("")
+		return ActionCmd{d.input.Focus()}
+	case key.Matches(keyMsg, d.keyMap.Edit):
+		if idx < 0 || idx >= len(d.todos) {
+			break
+		}
+		d.mode = todosModeEditing
+		d.input.SetValue(d.todos[idx].Content)
+		d.input.CursorEnd()
+		return ActionCmd{d.input.Focus()}
+	case key.Matches(keyMsg, d.keyMap.Delete):
+		if idx < 0 || idx >= len(d.todos) {
+			break
+		}
+		d.todos = slices.Delete(d.todos, idx, idx+1)
+		d.refresh(min(idx, len(d.todos)-1))
+	}
+	return nil
+}
+
+// confirmInput 保存正在编辑或添加的待办事项，内容为空时忽略。
+func (d *Todos) confirmInput() {
+	mode := d.mode
+	d.mode = todosModeNormal
+	d.input.Blur()
+
+	content := strings.TrimSpace(d.input.Value())
+	if content == "" {
+		return
+	}
+	idx := d.list.Selected()
+	if mode == todosModeEditing {
+		if idx < 0 || idx >= len(d.todos) {
+			return
+		}
+		// 进行中描述可能已与新内容不符
+		d.todos[idx].Content = content
+		d.todos[idx].ActiveForm = ""
+		d.refresh(idx)
+		return
+	}
+	idx = min(idx+1, len(d.todos))
+	d.todos = slices.Insert(d.todos, idx, session.Todo{Content: content, Status: session.TodoStatusPending})
+	d.refresh(idx)
+}
+
+// move 将待办事项上移 (-1) 或下移 (1)。
+func (d *Todos) move(idx, delta int) {
+	to := idx + delta
+	if idx < 0 || to < 0 || to >= len(d.todos) {
+		return
+	}
+	d.todos[idx], d.todos[to] = d.todos[to], d.todos[idx]
+	d.refresh(to)
+}
+
+// refresh 根据待办事项重建列表并选中第 selected 项。
+func (d *Todos) refresh(selected int) {
+	items := make([]list.Item, len(d.todos))
+	for i, todo := range d.todos {
+		items[i] = &TodoItem{todo: todo, t: d.com.Styles}
+	}
+	d.list.SetItems(items...)
+	if len(items) > 0 {
+		d.list.SetSelected(max(selected, 0))
+		d.list.ScrollToSelected()
+	}
+}
+
+// modified 报告待办事项是否有未保存的修改。
+func (d *Todos) modified() bool {
+	return !slices.Equal(d.todos, d.original)
+}
+
+// Draw 实现 [Dialog] 接口。
+func (d *Todos) Draw(scr uv.Screen, area uv.Rectangle) *tea.Cursor {
+	t := d.com.Styles
+	width := max(0, min(defaultDialogMaxWidth, area.Dx()))
+	height := max(0, min(defaultDialogHeight, area.Dy()))
+	innerWidth := width - t.Dialog.View.GetHorizontalFrameSize() - 2
+	heightOffset := t.Dialog.Title.GetVerticalFrameSize() + titleContentHeight +
+		t.Dialog.HelpView.GetVerticalFrameSize() +
+		t.Dialog.View.GetVerticalFrameSize()
+
+	completed := 0
+	for _, todo := range d.todos {
+		if todo.Status == session.TodoStatusCompleted {
+			completed++
+		}
+	}
+	rc := NewRenderContext(t, width)
+	rc.Title = fmt.Sprintf("待办事项 (%d/%d)", completed, len(d.todos))
+	if d.modified() {
+		rc.Title += " *"
+	}
+
+	var cur *tea.Cursor
+	if d.mode != todosModeNormal {
+		d.input.SetWidth(max(0, innerWidth-t.Dialog.InputPrompt.GetHorizontalFrameSize()-1))
+		rc.AddPart(t.Dialog.InputPrompt.Render(d.input.View()))
+		cur = InputCursor(t, d.input.Cursor())
+		heightOffset += t.Dialog.InputPrompt.GetVerticalFrameSize() + 1
+	}
+
+	d.list.SetSize(innerWidth, max(0, height-heightOffset))
+	d.help.SetWidth(innerWidth)
+
+	if len(d.todos) == 0 {
+		rc.AddPart(t.Dialog.List.Render(t.Subtle.Render("没有待办事项，按 ctrl+a 添加。")))
+	} else {
+		rc.AddPart(t.Dialog.List.Height(d.list.Height()).Render(d.list.Render()))
+	}
+	rc.Help = d.help.View(d)
+
+	view := rc.Render()
+	DrawCenterCursor(scr, area, view, cur)
+	return cur
+}
+
+// ShortHelp 实现 [help.KeyMap] 接口。
+func (d *Todos) ShortHelp() []key.Binding {
+	if d.mode != todosModeNormal {
+		return []key.Binding{
+			d.keyMap.ConfirmEdit,
+			d.keyMap.CancelEdit,
+		}
+	}
+	return []key.Binding{
+		d.keyMap.UpDown,
+		d.keyMap.Toggle,
+		d.keyMap.Move,
+		d.keyMap.Add,
+		d.keyMap.Edit,
+		d.keyMap.Delete,
+		d.keyMap.Save,
+		d.keyMap.Close,
+	}
+}
+
+// FullHelp 实现 [help.KeyMap] 接口。
+func (d *Todos) FullHelp() [][]key.Binding {
+	m := [][]key.Binding{}
+	slice := d.ShortHelp()
+	for i := 0; i < len(slice); i += 4 {
+		end := min(i+4, len(slice))
+		m = append(m, slice[i:end])
+	}
+	return m
+}
+
+// TodoItem 表示待办事项对话框中的单个待办事项。
+type TodoItem struct {
+	todo    session.Todo
+	t       *styles.Styles
+	cache   map[int]string
+	focused bool
+}
+
+var (
+	_ list.Item      = (*TodoItem)(nil)
+	_ list.Focusable = (*TodoItem)(nil)
+)
+
+// SetFocused 设置待办事项的焦点状态。
+func (i *TodoItem) SetFocused(focused bool) {
+	if i.focused != focused {
+		i.cache = nil
+	}
+	i.focused = focused
+}
+
+// Render 返回待办事项的字符串表示。
+func (i *TodoItem) Render(width int) string {
+	if i.cache == nil {
+		i.cache = make(map[int]string)
+	}
+	itemStyles := ListItemStyles{
+		ItemBlurred:     i.t.Dialog.NormalItem,
+		ItemFocused:     i.t.Dialog.SelectedItem,
+		InfoTextBlurred: i.t.Subtle,
+		InfoTextFocused: i.t.Base,
+	}
+	icon, info := styles.TodoPendingIcon, "待处理"
+	switch i.todo.Status {
+	case session.TodoStatusCompleted:
+		icon, info = styles.TodoCompletedIcon, "已完成"
+	case session.TodoStatusInProgress:
+		icon, info = styles.ArrowRightIcon, "进行中"
+	}
+	title := icon + " " + i.todo.Content
+	return renderItem(itemStyles, title, info, i.focused, width, i.cache, nil)
+}
@@ -0,0 +1,168 @@
+package dialog
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"charm.land/bubbles/v2/help"
+	"charm.land/bubbles/v2/key"
+	"charm.land/bubbles/v2/textarea"
+	tea "charm.land/bubbletea/v2"
+	uv "github.com/charmbracelet/ultraviolet"
+	"github.com/purpose168/crush-cn/internal/fsext"
+	"github.com/purpose168/crush-cn/internal/ui/common"
+)
+
+const (
+	// WorkspaceRootsID 是工作区目录编辑对话框的标识符。
+	WorkspaceRootsID = "workspace_roots"
+	// workspaceRootsEditorHeight 是目录编辑区域的高度。
+	workspaceRootsEditorHeight = 8
+)
+
+// WorkspaceRoots 是编辑工作区附加目录的对话框。每行一个目录，保存后写入项目配置，
+// 并作为根目录通知已连接的 MCP 服务器。
+type WorkspaceRoots struct {
+	com    *common.Common
+	help   help.Model
+	editor textarea.Model
+	// err 是上次保存时的校验错误
+	err error
+
+	keyMap struct {
+		Save    key.Binding
+		Newline key.Binding
+		Close   key.Binding
+	}
+}
+
+var _ Dialog = (*WorkspaceRoots)(nil)
+
+// NewWorkspaceRoots 使用配置中当前的附加目录创建一个新的 [WorkspaceRoots] 对话框。
+func NewWorkspaceRoots(com *common.Common) (*WorkspaceRoots, tea.Cmd) {
+	w := &WorkspaceRoots{com: com}
+
+	help := help.New()
+	help.Styles = com.Styles.DialogHelpStyles()
+	w.help = help
+
+	w.editor = textarea.New()
+	w.editor.SetStyles(com.Styles.TextArea)
+	w.editor.ShowLineNumbers = false
+	w.editor.CharLimit = -1
+	w.editor.SetVirtualCursor(false)
+	w.editor.SetHeight(workspaceRootsEditorHeight)
+	w.editor.Placeholder = "../shared\n~/notes"
+	w.editor.SetValue(strings.Join(com.Config().Options.WorkspaceRoots, "\n"))
+	w.editor.MoveToEnd()
+
+	w.keyMap.Save = key.NewBinding(
+		key.WithKeys("ctrl+s"),
+		key.WithHelp("ctrl+s", "保存"),
+	)
+	w.keyMap.Newline = key.NewBinding(
+		key.WithKeys("enter"),
+		key.WithHelp("enter", "换行"),
+	)
+	w.keyMap.Close = CloseKey
+
+	return w, w.editor.Focus()
+}
+
+// ID 实现 Dialog 接口。
+func (w *WorkspaceRoots) ID() string {
+	return WorkspaceRootsID
+}
+
+// HandleMsg 实现 Dialog 接口。
+func (w *WorkspaceRoots) HandleMsg(msg tea.Msg) Action {
+	keyMsg, ok := msg.(tea.KeyPressMsg)
+	if !ok {
+		return nil
+	}
+
+	switch {
+	case key.Matches(keyMsg, w.keyMap.Close):
+		return ActionClose{}
+	case key.Matches(keyMsg, w.keyMap.Save):
+		roots, err := w.parse()
+		if err != nil {
+			w.err = err
+			return nil
+		}
+		return ActionSetWorkspaceRoots{Roots: roots}
+	case key.Matches(keyMsg, w.keyMap.Newline):
+		w.editor.InsertRune('\n')
+	default:
+		var cmd tea.Cmd
+		w.editor, cmd = w.editor.Update(keyMsg)
+		return ActionCmd{cmd}
+	}
+	return nil
+}
+
+// parse 返回编辑区域中的目录，跳过空行和以 # 开头的注释行，并检查每个目录都存在。
+func (w *WorkspaceRoots) parse() ([]string, error) {
+	cfg := w.com.Config()
+	var roots []string
+	for line := range strings.SplitSeq(w.editor.Value(), "\n") {
+		dir := strings.TrimSpace(line)
+		if dir == "" || strings.HasPrefix(dir, "#") {
+			continue
+		}
+		info, err := os.Stat(cfg.ResolveWorkspaceRoot(dir))
+		if err != nil {
+			return nil, fmt.Errorf("目录不存在: %s", dir)
+		}
+		if !info.IsDir() {
+			return nil, fmt.Errorf("不是目录: %s", dir)
+		}
+		roots = append(roots, dir)
+	}
+	return roots, nil
+}
+
+// Draw 实现 [Dialog] 接口。
+func (w *WorkspaceRoots) Draw(scr uv.Screen, area uv.Rectangle) *tea.Cursor {
+	t := w.com.Styles
+	width := max(0, min(defaultDialogMaxWidth, area.Dx()))
+	innerWidth := width - t.Dialog.View.GetHorizontalFrameSize() - 2
+
+	rc := NewRenderContext(t, width)
+	rc.Title = "工作区目录"
+
+	editorWidth := max(0, innerWidth-t.Dialog.InputPrompt.GetHorizontalFrameSize()-1)
+	w.editor.SetWidth(editorWidth)
+	rc.AddPart(t.Dialog.InputPrompt.Render(w.editor.View()))
+	hint := t.Subtle.Width(editorWidth).Render(fmt.Sprintf(
+		"每行一个附加目录，相对路径相对于工作目录（%s）。保存后通知已连接的 MCP 服务器。",
+		fsext.PrettyPath(w.com.Config().WorkingDir()),
+	))
+	if w.err != nil {
+		hint = t.Dialog.TitleError.Width(editorWidth).Render(w.err.Error())
+	}
+	rc.AddPart(t.Dialog.InputPrompt.Render(hint))
+
+	w.help.SetWidth(innerWidth)
+	rc.Help = w.help.View(w)
+
+	cur := InputCursor(t, w.editor.Cursor())
+	view := rc.Render()
+	DrawCenterCursor(scr, area, view, cur)
+	return cur
+}
+
+// ShortHelp 实现 [help.KeyMap] 接口。
+func (w *WorkspaceRoots) ShortHelp() []key.Binding {
+	return []key.Binding{
+		w.keyMap.Save,
+		w.keyMap.Newline,
+		w.keyMap.Close,
+	}
+}
+
+// FullHelp 实现 [help.KeyMap] 接口。
+func (w *WorkspaceRoots) FullHelp() [][]key.Binding {
+	return [][]key.Binding{w.ShortHelp()}
+}
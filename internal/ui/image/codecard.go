@@ -0,0 +1,247 @@
+package image
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"strings"
+
+	"github.com/alecthomas/chroma/v2"
+	"github.com/alecthomas/chroma/v2/lexers"
+	"github.com/clipperhouse/displaywidth"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/gomono"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/math/fixed"
+)
+
+const (
+	// codeCardFontSize 是代码卡片的字号，按两倍尺寸渲染以便在高分屏上清晰显示
+	codeCardFontSize = 28
+	// codeCardMargin 是卡片外的留白
+	codeCardMargin = 64
+	// codeCardPadding 是卡片内代码周围的留白
+	codeCardPadding = 40
+	// codeCardTitleBar 是卡片标题栏的高度
+	codeCardTitleBar = 64
+	// codeCardRadius 是卡片圆角的半径
+	codeCardRadius = 20
+	// codeCardTabWidth 是制表符展开的空格数
+	codeCardTabWidth = 4
+	// codeCardMaxLines 和 codeCardMaxColumns 限制卡片的大小，超出的部分被截断
+	codeCardMaxLines   = 300
+	codeCardMaxColumns = 160
+)
+
+// codeCardButtons 是标题栏中窗口按钮的颜色
+var codeCardButtons = []color.Color{
+	color.RGBA{0xff, 0x5f, 0x56, 0xff},
+	color.RGBA{0xff, 0xbd, 0x2e, 0xff},
+	color.RGBA{0x27, 0xc9, 0x3f, 0xff},
+}
+
+// CodeCard 描述把代码渲染为图片时卡片的外观，类似 silicon 生成的代码截图。
+type CodeCard struct {
+	// Style 是语法高亮的配色，卡片背景和默认文字颜色取自其中的 Background 项
+	Style *chroma.Style
+	// Margin 是卡片周围的背景色
+	Margin color.Color
+	// Title 显示在标题栏中，可以为空
+	Title string
+	// FileName 用于选择语法高亮的语言，为空时根据内容猜测
+	FileName string
+}
+
+// Render 将 source 渲染为代码卡片并返回 PNG 数据。
+func (c CodeCard) Render(source string) ([]byte, error) {
+	face, err := codeCardFace()
+	if err != nil {
+		return nil, err
+	}
+	defer face.Close()
+
+	lines, err := c.tokenize(codeCardSource(source))
+	if err != nil {
+		return nil, err
+	}
+
+	metrics := face.Metrics()
+	advance, _ := face.GlyphAdvance('M')
+	cellWidth := advance.Ceil()
+	lineHeight := (metrics.Height.Ceil() * 6) / 5
+	columns := 1
+	for _, line := range lines {
+		width := 0
+		for _, token := range line {
+			width += displaywidth.String(token.Value)
+		}
+		columns = max(columns, min(width, codeCardMaxColumns))
+	}
+
+	cardWidth := max(columns*cellWidth+2*codeCardPadding, 3*codeCardTitleBar)
+	cardHeight := codeCardTitleBar + len(lines)*lineHeight + codeCardPadding
+	img := image.NewRGBA(image.Rect(0, 0, cardWidth+2*codeCardMargin, cardHeight+2*codeCardMargin))
+	draw.Draw(img, img.Bounds(), image.NewUniform(c.Margin), image.Point{}, draw.Src)
+
+	background := c.Style.Get(chroma.Background)
+	cardColor := chromaColor(background.Background, color.Black)
+	textColor := chromaColor(background.Colour, color.White)
+	card := image.Rect(codeCardMargin, codeCardMargin, codeCardMargin+cardWidth, codeCardMargin+cardHeight)
+	fillRoundedRect(img, card, codeCardRadius, cardColor)
+
+	// 标题栏中的窗口按钮和标题
+	buttonRadius := codeCardTitleBar / 6
+	for i, button := range codeCardButtons {
+		center := image.Pt(card.Min.X+codeCardPadding/2+buttonRadius+i*3*buttonRadius, card.Min.Y+codeCardTitleBar/2)
+		fillCircle(img, center, buttonRadius, button)
+	}
+	d := &font.Drawer{Dst: img, Face: face}
+	baseline := func(top int) fixed.Int26_6 {
+		return fixed.I(top + (lineHeight+metrics.Ascent.Ceil()-metrics.Descent.Ceil())/2)
+	}
+	if c.Title != "" {
+		r, g, b, _ := textColor.RGBA()
+		d.Src = image.NewUniform(color.RGBA{uint8(r >> 8), uint8(g >> 8), uint8(b >> 8), 0x99})
+		title := truncateColumns(c.Title, codeCardMaxColumns/2)
+		width := d.MeasureString(title).Ceil()
+		d.Dot = fixed.Point26_6{
+			X: fixed.I(card.Min.X + (cardWidth-width)/2),
+			Y: baseline(card.Min.Y + (codeCardTitleBar-lineHeight)/2),
+		}
+		d.DrawString(title)
+	}
+
+	// 代码
+	for i, line := range lines {
+		top := card.Min.Y + codeCardTitleBar + i*lineHeight
+		column := 0
+		for _, token := range line {
+			entry := c.Style.Get(token.Type)
+			d.Src = image.NewUniform(chromaColor(entry.Colour, textColor))
+			for _, r := range token.Value {
+				w := displaywidth.Rune(r)
+				if column+w > codeCardMaxColumns {
+					break
+				}
+				d.Dot = fixed.Point26_6{X: fixed.I(card.Min.X + codeCardPadding + column*cellWidth), Y: baseline(top)}
+				d.DrawString(string(r))
+				column += w
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// tokenize 对代码做语法高亮并按行拆分标记。
+func (c CodeCard) tokenize(source string) ([][]chroma.Token, error) {
+	lexer := lexers.Match(c.FileName)
+	if lexer == nil {
+		lexer = lexers.Analyse(source)
+	}
+	if lexer == nil {
+		lexer = lexers.Fallback
+	}
+	it, err := chroma.Coalesce(lexer).Tokenise(nil, source)
+	if err != nil {
+		return nil, err
+	}
+	lines := chroma.SplitTokensIntoLines(it.Tokens())
+	for i, line := range lines {
+		for j := range line {
+			line[j].Value = strings.TrimRight(line[j].Value, "\n")
+		}
+		lines[i] = line
+	}
+	if len(lines) > codeCardMaxLines {
+		lines = lines[:codeCardMaxLines]
+	}
+	return lines, nil
+}
+
+// codeCardSource 展开制表符、去掉公共缩进和首尾空行。
+func codeCardSource(source string) string {
+	source = strings.ReplaceAll(source, "\r\n", "\n")
+	source = strings.ReplaceAll(source, "\t", strings.Repeat(" ", codeCardTabWidth))
+	lines := strings.Split(strings.Trim(source, "\n"), "\n")
+	indent := -1
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		n := len(line) - len(strings.TrimLeft(line, " "))
+		if indent < 0 || n < indent {
+			indent = n
+		}
+	}
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line[min(max(indent, 0), len(line)):], " ")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// codeCardFace 返回代码卡片使用的等宽字体。
+func codeCardFace() (font.Face, error) {
+	f, err := opentype.Parse(gomono.TTF)
+	if err != nil {
+		return nil, err
+	}
+	return opentype.NewFace(f, &opentype.FaceOptions{
+		Size:    codeCardFontSize,
+		DPI:     72,
+		Hinting: font.HintingFull,
+	})
+}
+
+// chromaColor 将 chroma 颜色转换为 [color.Color]，未设置时返回 fallback。
+func chromaColor(c chroma.Colour, fallback color.Color) color.Color {
+	if !c.IsSet() {
+		return fallback
+	}
+	return color.RGBA{c.Red(), c.Green(), c.Blue(), 0xff}
+}
+
+// truncateColumns 将 s 截断到最多 n 列。
+func truncateColumns(s string, n int) string {
+	if displaywidth.String(s) <= n {
+		return s
+	}
+	width := 0
+	for i, r := range s {
+		width += displaywidth.Rune(r)
+		if width > n-1 {
+			return s[:i] + "…"
+		}
+	}
+	return s
+}
+
+// fillRoundedRect 用 c 填充圆角半径为 radius 的矩形 r。
+func fillRoundedRect(img draw.Image, r image.Rectangle, radius int, c color.Color) {
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			cx := min(max(x, r.Min.X+radius), r.Max.X-radius-1)
+			cy := min(max(y, r.Min.Y+radius), r.Max.Y-radius-1)
+			if dx, dy := x-cx, y-cy; dx*dx+dy*dy <= radius*radius {
+				img.Set(x, y, c)
+			}
+		}
+	}
+}
+
+// fillCircle 用 c 填充圆心为 center、半径为 radius 的圆。
+func fillCircle(img draw.Image, center image.Point, radius int, c color.Color) {
+	for y := -radius; y <= radius; y++ {
+		for x := -radius; x <= radius; x++ {
+			if x*x+y*y <= radius*radius {
+				img.Set(center.X+x, center.Y+y, c)
+			}
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package image
+
+import (
+	"bytes"
+	"image/color"
+	"image/png"
+	"testing"
+
+	"github.com/alecthomas/chroma/v2/styles"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCodeCardRender(t *testing.T) {
+	t.Parallel()
+
+	card := CodeCard{
+		Style:    styles.Get("dracula"),
+		Margin:   color.RGBA{0x6b, 0x50, 0xff, 0xff},
+		Title:    "Crush",
+		FileName: "main.go",
+	}
+	short, err := card.Render("func main() {}")
+	require.NoError(t, err)
+	long, err := card.Render("func main() {\n\tfmt.Println(\"hello, world\")\n}")
+	require.NoError(t, err)
+
+	shortImg, err := png.Decode(bytes.NewReader(short))
+	require.NoError(t, err)
+	longImg, err := png.Decode(bytes.NewReader(long))
+	require.NoError(t, err)
+
+	require.Greater(t, longImg.Bounds().Dx(), shortImg.Bounds().Dx())
+	require.Greater(t, longImg.Bounds().Dy(), shortImg.Bounds().Dy())
+
+	// 左上角是卡片外的背景色
+	r, g, b, _ := shortImg.At(0, 0).RGBA()
+	require.Equal(t, [3]uint32{0x6b, 0x50, 0xff}, [3]uint32{r >> 8, g >> 8, b >> 8})
+}
+
+func TestCodeCardSource(t *testing.T) {
+	t.Parallel()
+
+	source := "\n\t\tif ok {\r\n\t\t\treturn  \r\n\n\t\t}\n\n"
+	require.Equal(t, "if ok {\n    return\n\n}", codeCardSource(source))
+}
+
+func TestTruncateColumns(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, "hello", truncateColumns("hello", 5))
+	require.Equal(t, "hel…", truncateColumns("hello", 4))
+	require.Equal(t, "你…", truncateColumns("你好世界", 4))
+}
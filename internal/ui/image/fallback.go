@@ -0,0 +1,172 @@
+package image
+
+import (
+	"bytes"
+	"encoding/base64"
+	"image"
+	"image/color"
+	"image/png"
+	"log/slog"
+	"strings"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/charmbracelet/x/ansi/iterm2"
+	"github.com/charmbracelet/x/ansi/sixel"
+)
+
+// defaultCellSize 是终端未报告像素尺寸时假定的单元格大小。
+var defaultCellSize = CellSize{Width: 10, Height: 20}
+
+// Support 描述终端支持的图像协议。
+type Support struct {
+	Kitty  bool
+	ITerm2 bool
+	Sixel  bool
+}
+
+// Negotiate 根据终端能力选择最佳的图像编码。回退顺序为
+// Kitty → iTerm2 → Sixel → Unicode 半块字符。
+func Negotiate(s Support) Encoding {
+	switch {
+	case s.Kitty:
+		return EncodingKitty
+	case s.ITerm2:
+		return EncodingITerm2
+	case s.Sixel:
+		return EncodingSixel
+	default:
+		return EncodingHalfBlocks
+	}
+}
+
+// isInline 报告编码是否通过直接写入终端的转义序列显示图像。
+func (e Encoding) isInline() bool {
+	return e == EncodingITerm2 || e == EncodingSixel
+}
+
+// Paint 返回一个命令，在屏幕坐标 (x, y) 处绘制已传输的图像。
+// 仅适用于 iTerm2 和 Sixel 编码；其他编码通过 [Encoding.Render]
+// 直接渲染，因此返回 nil。
+func (e Encoding) Paint(id string, cols, rows, x, y int, tmux bool) tea.Cmd {
+	if !e.isInline() {
+		return nil
+	}
+
+	key := imageKey{id: id, cols: cols, rows: rows}
+	cachedMutex.RLock()
+	cached, ok := cachedImages[key]
+	cachedMutex.RUnlock()
+	if !ok {
+		return nil
+	}
+
+	var seq string
+	var err error
+	switch e {
+	case EncodingITerm2:
+		seq, err = encodeITerm2(id, cached.img, cols, rows)
+	case EncodingSixel:
+		seq, err = encodeSixel(cached.img)
+	}
+	if err != nil {
+		slog.Error("Failed to encode inline image", "encoding", e, "err", err)
+		return nil
+	}
+	if tmux {
+		seq = ansi.TmuxPassthrough(seq)
+	}
+
+	var sb strings.Builder
+	sb.WriteString(ansi.SaveCursor)
+	sb.WriteString(ansi.CursorPosition(x+1, y+1))
+	sb.WriteString(seq)
+	sb.WriteString(ansi.RestoreCursor)
+	return tea.Raw(sb.String())
+}
+
+// encodeITerm2 使用 iTerm2 内联图像协议编码图像。
+func encodeITerm2(name string, img image.Image, cols, rows int) (string, error) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return "", err
+	}
+	content := base64.StdEncoding.EncodeToString(buf.Bytes())
+	return ansi.ITerm2(iterm2.File{
+		Name:            base64.StdEncoding.EncodeToString([]byte(name)),
+		Size:            int64(buf.Len()),
+		Width:           iterm2.Cells(cols),
+		Height:          iterm2.Cells(rows),
+		Inline:          true,
+		DoNotMoveCursor: true,
+		Content:         []byte(content),
+	}), nil
+}
+
+// encodeSixel 使用 Sixel 图形协议编码图像。
+func encodeSixel(img image.Image) (string, error) {
+	var buf bytes.Buffer
+	var enc sixel.Encoder
+	if err := enc.Encode(&buf, img); err != nil {
+		return "", err
+	}
+	return ansi.SixelGraphics(0, 1, 0, buf.Bytes()), nil
+}
+
+// blankPlaceholder 返回一个由空格组成的 cols×rows 区域。
+func blankPlaceholder(cols, rows int) string {
+	if cols <= 0 || rows <= 0 {
+		return ""
+	}
+	line := strings.Repeat(" ", cols)
+	lines := make([]string, rows)
+	for i := range lines {
+		lines[i] = line
+	}
+	return strings.Join(lines, "\n")
+}
+
+// renderHalfBlocks 使用 Unicode 上半块字符渲染图像预览。每个单元格
+// 表示两个垂直像素：前景色为上方像素，背景色为下方像素。
+func renderHalfBlocks(img image.Image, cols, rows int) string {
+	if img == nil || cols <= 0 || rows <= 0 {
+		return ""
+	}
+
+	bounds := img.Bounds()
+	if bounds.Dx() == 0 || bounds.Dy() == 0 {
+		return ""
+	}
+
+	// 在保持纵横比的前提下计算目标尺寸，一个单元格约为两个像素高。
+	w, h := cols, rows*2
+	if bounds.Dx()*h > bounds.Dy()*w {
+		h = max(1, bounds.Dy()*w/bounds.Dx())
+	} else {
+		w = max(1, bounds.Dx()*h/bounds.Dy())
+	}
+
+	sample := func(x, y int) color.Color {
+		sx := bounds.Min.X + x*bounds.Dx()/w
+		sy := bounds.Min.Y + y*bounds.Dy()/h
+		return img.At(sx, sy)
+	}
+
+	var buf bytes.Buffer
+	for y := 0; y < h; y += 2 {
+		for x := range w {
+			top := sample(x, y)
+			style := ansi.NewStyle().ForegroundColor(top)
+			if y+1 < h {
+				style = style.BackgroundColor(sample(x, y+1))
+			}
+			buf.WriteString(style.String())
+			buf.WriteRune('▀')
+		}
+		buf.WriteString(ansi.ResetStyle)
+		if y+2 < h {
+			buf.WriteByte('\n')
+		}
+	}
+	return buf.String()
+}
@@ -2,12 +2,17 @@ package image
 
 import (
 	"bytes"
+	"encoding/base64"
 	"fmt"
 	"hash/fnv"
 	"image"
 	"image/color"
+	_ "image/gif"  // register GIF format
+	_ "image/jpeg" // register JPEG format
+	_ "image/png"  // register PNG format
 	"io"
 	"log/slog"
+	"math"
 	"strings"
 	"sync"
 
@@ -31,8 +36,27 @@ type Encoding byte
 const (
 	EncodingBlocks Encoding = iota
 	EncodingKitty
+	EncodingITerm2
+	EncodingSixel
+	EncodingHalfBlocks
 )
 
+// String 返回编码的可读名称。
+func (e Encoding) String() string {
+	switch e {
+	case EncodingKitty:
+		return "kitty"
+	case EncodingITerm2:
+		return "iterm2"
+	case EncodingSixel:
+		return "sixel"
+	case EncodingHalfBlocks:
+		return "halfblocks"
+	default:
+		return "blocks"
+	}
+}
+
 type imageKey struct {
 	id   string
 	cols int
@@ -110,6 +134,41 @@ func fitImage(id string, img image.Image, cs CellSize, cols, rows int) image.Ima
 	return img
 }
 
+// DecodeBase64 解码 base64 编码的图像数据。
+func DecodeBase64(data string) (image.Image, error) {
+	img, _, err := image.Decode(base64.NewDecoder(base64.StdEncoding, strings.NewReader(data)))
+	return img, err
+}
+
+// DecodeBase64Size 只读取图像头部，返回 base64 编码图像的像素尺寸。
+func DecodeBase64Size(data string) (image.Point, error) {
+	cfg, _, err := image.DecodeConfig(base64.NewDecoder(base64.StdEncoding, strings.NewReader(data)))
+	if err != nil {
+		return image.Point{}, err
+	}
+	return image.Pt(cfg.Width, cfg.Height), nil
+}
+
+// FitCells 计算在不超过 maxCols×maxRows 个单元格的区域内显示 size 像素大小
+// 的图像所需的列数和行数，同时保持纵横比。小图像不会被放大。单元格大小
+// 未知时使用默认值。
+func FitCells(size image.Point, cs CellSize, maxCols, maxRows int) (cols, rows int) {
+	if size.X <= 0 || size.Y <= 0 || maxCols <= 0 || maxRows <= 0 {
+		return 0, 0
+	}
+	if cs.Width == 0 || cs.Height == 0 {
+		cs = defaultCellSize
+	}
+
+	scale := min(1,
+		float64(maxCols*cs.Width)/float64(size.X),
+		float64(maxRows*cs.Height)/float64(size.Y),
+	)
+	cols = int(math.Ceil(float64(size.X) * scale / float64(cs.Width)))
+	rows = int(math.Ceil(float64(size.Y) * scale / float64(cs.Height)))
+	return min(max(1, cols), maxCols), min(max(1, rows), maxRows)
+}
+
 // HasTransmitted 检查具有给定ID的图像是否已经传输到终端。
 func HasTransmitted(id string, cols, rows int) bool {
 	key := imageKey{id: id, cols: cols, rows: rows}
@@ -137,6 +196,15 @@ func (e Encoding) Transmit(id string, img image.Image, cs CellSize, cols, rows i
 	}
 
 	cmd := func() tea.Msg {
+		if e.isInline() {
+			// 内联协议需要按像素尺寸缩放后的图像，因此在单元格大小
+			// 未知时使用默认值。
+			if cs.Width == 0 || cs.Height == 0 {
+				cs = defaultCellSize
+			}
+			fitImage(id, img, cs, cols, rows)
+			return TransmittedMsg{ID: key.ID()}
+		}
 		if e != EncodingKitty {
 			cachedMutex.Lock()
 			cachedImages[key] = cachedImage{
@@ -278,6 +346,14 @@ func (e Encoding) Render(id string, cols, rows int) string {
 
 		return buf.String()
 
+	case EncodingHalfBlocks:
+		return renderHalfBlocks(img, cols, rows)
+
+	case EncodingITerm2, EncodingSixel:
+		// 图像本身由 [Encoding.Paint] 绘制，这里只保留占位区域，
+		// 以免渲染器覆盖图像所在的单元格。
+		return blankPlaceholder(cols, rows)
+
 	default:
 		return ""
 	}
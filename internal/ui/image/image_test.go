@@ -1,7 +1,11 @@
 package image
 
 import (
+	"bytes"
+	"encoding/base64"
 	"image"
+	"image/png"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -44,3 +48,84 @@ func TestResetIdempotent(t *testing.T) {
 
 	require.Equal(t, 0, length)
 }
+
+func TestNegotiate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		support Support
+		want    Encoding
+	}{
+		{"kitty", Support{Kitty: true, ITerm2: true, Sixel: true}, EncodingKitty},
+		{"iterm2", Support{ITerm2: true, Sixel: true}, EncodingITerm2},
+		{"sixel", Support{Sixel: true}, EncodingSixel},
+		{"fallback", Support{}, EncodingHalfBlocks},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			require.Equal(t, tt.want, Negotiate(tt.support))
+		})
+	}
+}
+
+func TestRenderHalfBlocks(t *testing.T) {
+	t.Parallel()
+
+	img := image.NewRGBA(image.Rect(0, 0, 4, 4))
+	out := renderHalfBlocks(img, 4, 2)
+	lines := strings.Split(out, "\n")
+	require.Len(t, lines, 2)
+	for _, line := range lines {
+		require.Equal(t, 4, strings.Count(line, "▀"))
+	}
+
+	require.Empty(t, renderHalfBlocks(nil, 4, 2))
+	require.Empty(t, renderHalfBlocks(img, 0, 0))
+}
+
+func TestFitCells(t *testing.T) {
+	t.Parallel()
+
+	cs := CellSize{Width: 10, Height: 20}
+	tests := []struct {
+		name       string
+		size       image.Point
+		cs         CellSize
+		cols, rows int
+	}{
+		{"宽图像受列数限制", image.Pt(1000, 200), cs, 40, 4},
+		{"高图像受行数限制", image.Pt(200, 1000), cs, 4, 10},
+		{"小图像不放大", image.Pt(50, 40), cs, 5, 2},
+		{"未知单元格大小", image.Pt(50, 40), CellSize{}, 5, 2},
+		{"空图像", image.Pt(0, 0), cs, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			cols, rows := FitCells(tt.size, tt.cs, 40, 10)
+			require.Equal(t, tt.cols, cols)
+			require.Equal(t, tt.rows, rows)
+		})
+	}
+}
+
+func TestDecodeBase64(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 30, 20))))
+	data := base64.StdEncoding.EncodeToString(buf.Bytes())
+
+	size, err := DecodeBase64Size(data)
+	require.NoError(t, err)
+	require.Equal(t, image.Pt(30, 20), size)
+
+	img, err := DecodeBase64(data)
+	require.NoError(t, err)
+	require.Equal(t, image.Pt(30, 20), img.Bounds().Size())
+
+	_, err = DecodeBase64Size("bm90IGFuIGltYWdl")
+	require.Error(t, err)
+}
@@ -1,6 +1,9 @@
 package list
 
 import (
+	"cmp"
+	"slices"
+
 	"github.com/sahilm/fuzzy"
 )
 
@@ -17,6 +20,12 @@ type MatchSettable interface {
 	SetMatch(fuzzy.Match)
 }
 
+// Sectioned 是属于某个分区的项目。过滤后同一分区的项目保持在一起，
+// 分区按编号升序排列，分区内保持匹配得分的顺序。
+type Sectioned interface {
+	Section() int
+}
+
 // FilterableList 是一个列表，它接受可以通过可设置查询进行过滤的可过滤项目。
 type FilterableList struct {
 	*List
@@ -113,10 +122,21 @@ func (f *FilterableList) FilteredItems() []Item {
 		}
 		matchedItems = append(matchedItems, item)
 	}
+	slices.SortStableFunc(matchedItems, func(a, b Item) int {
+		return cmp.Compare(itemSection(a), itemSection(b))
+	})
 
 	return matchedItems
 }
 
+// itemSection 返回项目的分区，未实现 [Sectioned] 的项目属于分区 0
+func itemSection(item Item) int {
+	if s, ok := item.(Sectioned); ok {
+		return s.Section()
+	}
+	return 0
+}
+
 // Render 渲染可过滤列表。
 func (f *FilterableList) Render() string {
 	f.List.SetItems(f.FilteredItems()...)
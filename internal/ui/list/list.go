@@ -175,6 +175,18 @@ func (l *List) ScrollToIndex(index int) {
 	l.offsetLine = 0
 }
 
+// Offset 返回视口中第一个可见项目的索引，以及该项目滚动出视图的行数。
+func (l *List) Offset() (idx, line int) {
+	return l.offsetIdx, l.offsetLine
+}
+
+// SetOffset 将列表滚动到给定的项目索引，并再向下滚动 line 行。
+// 滚动不会超过列表底部。
+func (l *List) SetOffset(idx, line int) {
+	l.ScrollToIndex(idx)
+	l.ScrollBy(line)
+}
+
 // ScrollBy 按给定的行数滚动列表。
 func (l *List) ScrollBy(lines int) {
 	if len(l.items) == 0 || lines == 0 {
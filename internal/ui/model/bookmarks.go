@@ -0,0 +1,112 @@
+package model
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"time"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/purpose168/crush-cn/internal/commands"
+	"github.com/purpose168/crush-cn/internal/session"
+	"github.com/purpose168/crush-cn/internal/ui/dialog"
+	"github.com/purpose168/crush-cn/internal/ui/util"
+)
+
+// toggleSelectedBookmark 为选中的聊天项目添加或移除书签。添加时先打开标签输入框，标签可以留空。
+func (m *UI) toggleSelectedBookmark() tea.Cmd {
+	if !m.hasSession() {
+		return nil
+	}
+	itemID := m.chat.SelectedItemID()
+	if itemID == "" {
+		return nil
+	}
+	if _, ok := m.session.Bookmark(itemID); ok {
+		return tea.Sequence(
+			m.setBookmarks(m.session.ID, m.session.RemoveBookmark(itemID)),
+			util.ReportInfo("已移除书签"),
+		)
+	}
+	m.dialog.OpenDialog(dialog.NewArguments(
+		m.com,
+		"添加书签",
+		"在命令面板的「书签」中可以跳转到书签。",
+		[]commands.Argument{{ID: dialog.BookmarkLabelArg, Title: "标签（可选）"}},
+		dialog.ActionAddBookmark{
+			SessionID: m.session.ID,
+			ItemID:    itemID,
+			Preview:   m.chat.ItemPreview(itemID),
+		},
+	))
+	return nil
+}
+
+// addBookmark 返回为聊天项目添加书签的命令。
+func (m *UI) addBookmark(action dialog.ActionAddBookmark) tea.Cmd {
+	if m.session == nil || m.session.ID != action.SessionID {
+		return nil
+	}
+	bookmarks := m.session.AddBookmark(session.Bookmark{
+		ItemID:    action.ItemID,
+		Label:     action.Label,
+		Preview:   action.Preview,
+		CreatedAt: time.Now().Unix(),
+	})
+	return tea.Sequence(
+		m.setBookmarks(action.SessionID, bookmarks),
+		util.ReportInfo("已添加书签"),
+	)
+}
+
+// setBookmarks 返回更新会话书签列表的命令。更新后的会话通过会话事件同步到界面。
+func (m *UI) setBookmarks(sessionID string, bookmarks []session.Bookmark) tea.Cmd {
+	return func() tea.Msg {
+		if _, err := m.com.App.Sessions.SetBookmarks(context.Background(), sessionID, bookmarks); err != nil {
+			return util.ReportError(err)()
+		}
+		return nil
+	}
+}
+
+// openBookmarksDialog 打开当前会话的书签对话框，书签按在聊天中的位置排列，
+// 不在聊天中的书签排在最后。
+func (m *UI) openBookmarksDialog() tea.Cmd {
+	if m.dialog.ContainsDialog(dialog.BookmarksID) {
+		// 带到前面
+		m.dialog.BringToFront(dialog.BookmarksID)
+		return nil
+	}
+
+	if m.session == nil {
+		return util.ReportWarn("没有活动会话")
+	}
+
+	entries := make([]dialog.BookmarkEntry, len(m.session.Bookmarks))
+	positions := make(map[string]int, len(entries))
+	for i, b := range m.session.Bookmarks {
+		idx, ok := m.chat.ItemIndex(b.ItemID)
+		if !ok {
+			idx = m.chat.Len()
+		}
+		positions[b.ItemID] = idx
+		entries[i] = dialog.BookmarkEntry{Bookmark: b, Missing: !ok}
+	}
+	slices.SortStableFunc(entries, func(a, b dialog.BookmarkEntry) int {
+		return cmp.Compare(positions[a.ItemID], positions[b.ItemID])
+	})
+
+	m.dialog.OpenDialog(dialog.NewBookmarks(m.com, m.session.ID, entries))
+	return nil
+}
+
+// jumpToBookmark 选中并滚动到书签项目。
+func (m *UI) jumpToBookmark(itemID string) tea.Cmd {
+	if !m.chat.SelectItem(itemID) {
+		return util.ReportWarn("书签项目已不在聊天中")
+	}
+	m.setState(m.state, uiFocusMain)
+	m.textarea.Blur()
+	m.chat.Focus()
+	return m.chat.ScrollToSelectedAndAnimate()
+}
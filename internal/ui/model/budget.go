@@ -0,0 +1,74 @@
+package model
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/purpose168/crush-cn/internal/budget"
+	"github.com/purpose168/crush-cn/internal/message"
+	"github.com/purpose168/crush-cn/internal/ui/dialog"
+	"github.com/purpose168/crush-cn/internal/ui/util"
+)
+
+// budgetLoadedMsg 携带启动时读取的今日费用和预算。
+type budgetLoadedMsg struct {
+	status budget.Status
+}
+
+// loadBudget 读取今日费用和预算。
+func (m *UI) loadBudget() tea.Cmd {
+	return func() tea.Msg {
+		status, err := m.com.App.Budget.Status(context.Background())
+		if err != nil {
+			slog.Error("读取预算状态失败", "error", err)
+			return nil
+		}
+		return budgetLoadedMsg{status: status}
+	}
+}
+
+// updateBudget 更新今日费用，费用首次达到提醒线或预算时提示用户。
+func (m *UI) updateBudget(status budget.Status) tea.Cmd {
+	prev := m.budget.Level()
+	m.budget = status
+	if status.Level() <= prev {
+		return nil
+	}
+	switch status.Level() {
+	case budget.LevelWarning:
+		return util.ReportWarn(fmt.Sprintf("今日费用 $%.2f 已达到每日预算 $%.2f 的 %d%%", status.Spent, status.Limit, int(budget.WarnRatio*100)))
+	case budget.LevelExceeded:
+		return util.ReportWarn(fmt.Sprintf("今日费用 $%.2f 已达到每日预算 $%.2f，继续运行代理前需要确认", status.Spent, status.Limit))
+	}
+	return nil
+}
+
+// confirmBudget 在超出预算且用户尚未确认时打开确认对话框，返回是否需要等待确认。
+// 待发送的提示由对话框保存，确认后重新发送。
+func (m *UI) confirmBudget(content string, attachments []message.Attachment) bool {
+	status, err := m.com.App.Budget.Status(context.Background())
+	if err != nil {
+		slog.Error("读取预算状态失败", "error", err)
+		return false
+	}
+	m.budget = status
+	if !status.NeedsConfirmation() {
+		return false
+	}
+	m.dialog.OpenDialog(dialog.NewBudget(m.com, status, content, attachments))
+	return true
+}
+
+// restorePrompt 将未发送的提示交还给编辑器，编辑器中已有内容时不覆盖。
+func (m *UI) restorePrompt(content string, attachments []message.Attachment) {
+	if m.textarea.Value() != "" || len(m.attachments.List()) > 0 {
+		return
+	}
+	m.textarea.SetValue(content)
+	m.textarea.MoveToEnd()
+	for _, a := range attachments {
+		m.attachments.Update(a)
+	}
+}
@@ -0,0 +1,46 @@
+package model
+
+import (
+	"charm.land/bubbles/v2/key"
+	tea "charm.land/bubbletea/v2"
+	"github.com/purpose168/crush-cn/internal/capability"
+	"github.com/purpose168/crush-cn/internal/message"
+	"github.com/purpose168/crush-cn/internal/ui/util"
+)
+
+// modelCapabilities 返回当前大模型支持的功能，尚未选择模型时视为全部支持。
+func (m *UI) modelCapabilities() capability.Set {
+	model := m.selectedLargeModel()
+	if model == nil {
+		return capability.All()
+	}
+	return capability.Of(model.CatwalkCfg, model.ModelCfg)
+}
+
+// gatedBinding 在当前模型不支持功能时返回帮助文本带有提示的按键绑定副本。
+func (m *UI) gatedBinding(b key.Binding, f capability.Feature) key.Binding {
+	if m.modelCapabilities().Supports(f) {
+		return b
+	}
+	help := b.Help()
+	b.SetHelp(help.Key, help.Desc+"（模型不支持）")
+	return b
+}
+
+// requireCapability 在当前模型不支持功能时返回说明原因的警告，支持时返回 nil。
+func (m *UI) requireCapability(f capability.Feature) tea.Cmd {
+	if reason := m.modelCapabilities().Reason(f); reason != "" {
+		return util.ReportWarn(reason)
+	}
+	return nil
+}
+
+// rejectUnsupportedAttachment 在当前模型不支持图片时拒绝图片附件并返回警告。
+func (m *UI) rejectUnsupportedAttachment(msg tea.Msg) (tea.Cmd, bool) {
+	att, ok := msg.(message.Attachment)
+	if !ok || att.IsText() {
+		return nil, false
+	}
+	cmd := m.requireCapability(capability.Images)
+	return cmd, cmd != nil
+}
@@ -10,6 +10,7 @@ import (
 	"github.com/charmbracelet/x/ansi"
 	"github.com/clipperhouse/displaywidth"
 	"github.com/clipperhouse/uax29/v2/words"
+	"github.com/purpose168/crush-cn/internal/codeblock"
 	"github.com/purpose168/crush-cn/internal/ui/anim"
 	"github.com/purpose168/crush-cn/internal/ui/chat"
 	"github.com/purpose168/crush-cn/internal/ui/common"
@@ -58,6 +59,15 @@ type Chat struct {
 	pendingClickID int // 每次点击递增，使旧的待处理点击失效
 }
 
+// chatPosition 记录聊天的滚动位置和选中项，用于切换回会话时恢复。
+// 位置以消息项ID记录，离开会话期间追加的新消息不影响恢复。
+type chatPosition struct {
+	offsetID   string // 视口中第一个可见项的ID
+	offsetLine int    // 第一个可见项滚动出视图的行数
+	selectedID string // 选中项的ID
+	atBottom   bool   // 离开时是否停留在底部
+}
+
 // NewChat 创建一个新的[Chat]实例，用于处理聊天交互和消息
 func NewChat(com *common.Common) *Chat {
 	c := &Chat{
@@ -136,6 +146,16 @@ func (m *Chat) AppendMessages(msgs ...chat.MessageItem) {
 	m.list.AppendItems(items...)
 }
 
+// HasToolDeadline 返回是否有工具正在有时限地运行
+func (m *Chat) HasToolDeadline() bool {
+	for i := range m.list.Len() {
+		if item, ok := m.list.ItemAt(i).(chat.Timeoutable); ok && item.HasDeadline() {
+			return true
+		}
+	}
+	return false
+}
+
 // UpdateNestedToolIDs 更新容器内嵌套工具的ID映射
 // 在修改嵌套工具后调用此方法，以确保动画正常工作
 func (m *Chat) UpdateNestedToolIDs(containerID string) {
@@ -252,6 +272,38 @@ func (m *Chat) ScrollByAndAnimate(lines int) tea.Cmd {
 	return m.RestartPausedVisibleAnimations()
 }
 
+// Position 返回当前的滚动位置和选中项
+func (m *Chat) Position() chatPosition {
+	idx, line := m.list.Offset()
+	pos := chatPosition{offsetLine: line, atBottom: m.list.AtBottom()}
+	if item, ok := m.list.ItemAt(idx).(chat.Identifiable); ok {
+		pos.offsetID = item.ID()
+	}
+	if item, ok := m.list.SelectedItem().(chat.Identifiable); ok {
+		pos.selectedID = item.ID()
+	}
+	return pos
+}
+
+// RestorePositionAndAnimate 恢复之前记录的滚动位置和选中项，并返回一个命令以重新启动现在可见的任何暂停动画。
+// 离开时停留在底部或记录的消息项已不存在时保持当前位置，返回 nil
+func (m *Chat) RestorePositionAndAnimate(pos chatPosition) tea.Cmd {
+	if pos.atBottom {
+		return nil
+	}
+	idx, ok := m.idInxMap[pos.offsetID]
+	if !ok {
+		return nil
+	}
+	m.list.SetOffset(idx, pos.offsetLine)
+	if selected, ok := m.idInxMap[pos.selectedID]; ok {
+		m.SetSelected(selected)
+	} else {
+		m.list.SelectFirstInView()
+	}
+	return m.RestartPausedVisibleAnimations()
+}
+
 // ScrollToSelectedAndAnimate 将聊天视图滚动到选中项，并返回一个命令以重新启动现在可见的任何暂停动画
 func (m *Chat) ScrollToSelectedAndAnimate() tea.Cmd {
 	m.list.ScrollToSelected()
@@ -425,6 +477,178 @@ func (m *Chat) MessageItem(id string) chat.MessageItem {
 	return item
 }
 
+// SelectAnswer 选中给定消息之后的第一条可选消息，即对该消息的回答；没有回答时选中消息本身。
+// 消息不在聊天中时返回false。
+func (m *Chat) SelectAnswer(id string) bool {
+	idx, ok := m.idInxMap[id]
+	if !ok {
+		return false
+	}
+	for i := idx + 1; i < m.list.Len(); i++ {
+		if m.isSelectable(i) {
+			m.SetSelected(i)
+			return true
+		}
+	}
+	m.SetSelected(idx)
+	return true
+}
+
+// bookmarkPreviewWidth 是生成书签内容预览时的渲染宽度
+const bookmarkPreviewWidth = 120
+
+// SelectedItemID 返回选中项目的 ID，没有选中项目时返回空字符串
+func (m *Chat) SelectedItemID() string {
+	if item, ok := m.list.SelectedItem().(chat.MessageItem); ok {
+		return item.ID()
+	}
+	return ""
+}
+
+// ItemIndex 返回具有给定ID的项目在聊天列表中的位置
+func (m *Chat) ItemIndex(id string) (int, bool) {
+	idx, ok := m.idInxMap[id]
+	return idx, ok
+}
+
+// SelectItem 选中具有给定ID的项目，项目不在聊天中时返回false
+func (m *Chat) SelectItem(id string) bool {
+	idx, ok := m.idInxMap[id]
+	if !ok {
+		return false
+	}
+	m.SetSelected(idx)
+	return true
+}
+
+// SelectedMessageID 返回选中项目所属消息的 ID：工具调用返回包含它的消息，没有选中项目时返回空字符串
+func (m *Chat) SelectedMessageID() string {
+	switch item := m.list.SelectedItem().(type) {
+	case chat.ToolMessageItem:
+		return item.MessageID()
+	case chat.MessageItem:
+		return item.ID()
+	}
+	return ""
+}
+
+// SelectMessage 选中给定消息；消息只包含工具调用时选中其中的第一个工具调用。消息不在聊天中时返回false
+func (m *Chat) SelectMessage(id string) bool {
+	if m.SelectItem(id) {
+		return true
+	}
+	for i := range m.list.Len() {
+		if item, ok := m.list.ItemAt(i).(chat.ToolMessageItem); ok && item.MessageID() == id {
+			m.SetSelected(i)
+			return true
+		}
+	}
+	return false
+}
+
+// ItemPreview 返回项目渲染内容中的第一个非空行，用作书签的内容预览
+func (m *Chat) ItemPreview(id string) string {
+	item := m.MessageItem(id)
+	if item == nil {
+		return ""
+	}
+	for line := range strings.SplitSeq(ansi.Strip(item.RawRender(bookmarkPreviewWidth)), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			return line
+		}
+	}
+	return ""
+}
+
+// LoadThumbnails 返回为给定消息项中的图像加载缩略图的命令
+func (m *Chat) LoadThumbnails(settings chat.ImageSettings, items ...chat.MessageItem) tea.Cmd {
+	var cmds []tea.Cmd
+	for _, item := range items {
+		if t, ok := item.(chat.Thumbnailable); ok {
+			if cmd := t.LoadThumbnail(settings); cmd != nil {
+				cmds = append(cmds, cmd)
+			}
+		}
+	}
+	return tea.Batch(cmds...)
+}
+
+// SetThumbnail 在缩略图传输完成后更新对应的消息项
+func (m *Chat) SetThumbnail(msg chat.ThumbnailReadyMsg) {
+	item := m.MessageItem(msg.ID)
+	// 嵌套工具的ID映射到其容器，这里只更新ID完全匹配的项
+	if item == nil || item.ID() != msg.ID {
+		return
+	}
+	t, ok := item.(chat.Thumbnailable)
+	if !ok {
+		return
+	}
+	atBottom := m.list.AtBottom()
+	t.SetThumbnail(msg)
+	if atBottom {
+		m.list.ScrollToBottom()
+	}
+}
+
+// SelectedImage 返回选中消息项中的图像，如果没有则返回false
+func (m *Chat) SelectedImage() (chat.ImageData, bool) {
+	if viewable, ok := m.list.SelectedItem().(chat.ImageViewable); ok {
+		return viewable.Image()
+	}
+	return chat.ImageData{}, false
+}
+
+// SelectedReasoningTrace 返回选中消息项的推理过程，选中的项目没有推理时返回 false
+func (m *Chat) SelectedReasoningTrace() (chat.ReasoningTrace, bool) {
+	if viewable, ok := m.list.SelectedItem().(chat.ReasoningViewable); ok {
+		return viewable.ReasoningTrace()
+	}
+	return chat.ReasoningTrace{}, false
+}
+
+// SelectedFailedTool 返回选中项中失败的工具调用，选中的项目不是失败的工具时返回 false
+func (m *Chat) SelectedFailedTool() (chat.FailedTool, bool) {
+	if actionable, ok := m.list.SelectedItem().(chat.ToolErrorActionable); ok {
+		return actionable.FailedTool()
+	}
+	return chat.FailedTool{}, false
+}
+
+// SelectedCodeBlocks 返回选中消息项中可运行的代码块
+func (m *Chat) SelectedCodeBlocks() []codeblock.Block {
+	if runnable, ok := m.list.SelectedItem().(chat.CodeRunnable); ok {
+		return runnable.CodeBlocks()
+	}
+	return nil
+}
+
+// ReasoningTrace 返回指定消息的推理过程，消息不存在或没有推理时返回 false
+func (m *Chat) ReasoningTrace(id string) (chat.ReasoningTrace, bool) {
+	if viewable, ok := m.MessageItem(id).(chat.ReasoningViewable); ok {
+		return viewable.ReasoningTrace()
+	}
+	return chat.ReasoningTrace{}, false
+}
+
+// CycleCodeWrapSelectedItem 切换选中消息项中代码输出长行的显示方式，
+// 选中项没有超出宽度的代码行时返回 false
+func (m *Chat) CycleCodeWrapSelectedItem() (chat.CodeWrap, bool) {
+	if viewable, ok := m.list.SelectedItem().(chat.CodeViewable); ok {
+		return viewable.CycleCodeWrap()
+	}
+	return chat.CodeWrapTruncate, false
+}
+
+// ScrollCodeSelectedItem 在水平滚动模式下左右滚动选中消息项的代码输出，
+// 选中项不处于水平滚动模式时返回 false
+func (m *Chat) ScrollCodeSelectedItem(delta int) bool {
+	if viewable, ok := m.list.SelectedItem().(chat.CodeViewable); ok {
+		return viewable.ScrollCode(delta)
+	}
+	return false
+}
+
 // ToggleExpandedSelectedItem 如果选中的消息项可展开，则切换其展开状态
 func (m *Chat) ToggleExpandedSelectedItem() {
 	if expandable, ok := m.list.SelectedItem().(chat.Expandable); ok {
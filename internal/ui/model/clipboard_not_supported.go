@@ -11,3 +11,11 @@ package model
 func readClipboard(clipboardFormat) ([]byte, error) {
 	return nil, errClipboardPlatformUnsupported
 }
+
+// writeClipboard 写入剪贴板内容
+// 参数：clipboardFormat - 剪贴板格式，[]byte - 要写入的内容
+// 返回：可能的错误
+// 该平台不支持剪贴板操作，返回errClipboardPlatformUnsupported错误
+func writeClipboard(clipboardFormat, []byte) error {
+	return errClipboardPlatformUnsupported
+}
@@ -20,3 +20,19 @@ func readClipboard(f clipboardFormat) ([]byte, error) {
 	}
 	return nil, errClipboardUnknownFormat
 }
+
+// writeClipboard 写入剪贴板内容
+// 参数：f - 剪贴板格式，data - 要写入的内容
+// 返回：可能的错误
+// 如果格式未知，返回errClipboardUnknownFormat错误
+func writeClipboard(f clipboardFormat, data []byte) error {
+	switch f {
+	case clipboardFormatText:
+		_, err := nativeclipboard.Text.Write(data)
+		return err
+	case clipboardFormatImage:
+		_, err := nativeclipboard.Image.Write(data)
+		return err
+	}
+	return errClipboardUnknownFormat
+}
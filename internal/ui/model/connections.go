@@ -0,0 +1,41 @@
+package model
+
+import (
+	"time"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/purpose168/crush-cn/internal/ui/dialog"
+)
+
+// connectionsRefreshInterval 是提供者连接对话框刷新统计的间隔。
+const connectionsRefreshInterval = 2 * time.Second
+
+// connectionsTickMsg 在需要刷新提供者连接对话框时发送。
+type connectionsTickMsg struct{}
+
+// connectionsTick 返回在刷新间隔后发送 [connectionsTickMsg] 的命令。
+func connectionsTick() tea.Cmd {
+	return tea.Tick(connectionsRefreshInterval, func(time.Time) tea.Msg {
+		return connectionsTickMsg{}
+	})
+}
+
+// openConnectionsDialog 打开提供者连接统计对话框
+func (m *UI) openConnectionsDialog() tea.Cmd {
+	if m.dialog.ContainsDialog(dialog.ConnectionsID) {
+		m.dialog.BringToFront(dialog.ConnectionsID)
+		return nil
+	}
+	m.dialog.OpenDialog(dialog.NewConnections(m.com))
+	return connectionsTick()
+}
+
+// handleConnectionsTick 在对话框打开时刷新统计并安排下一次刷新，对话框关闭后停止。
+func (m *UI) handleConnectionsTick() tea.Cmd {
+	d, ok := m.dialog.Dialog(dialog.ConnectionsID).(*dialog.Connections)
+	if !ok {
+		return nil
+	}
+	d.Refresh()
+	return connectionsTick()
+}
@@ -0,0 +1,69 @@
+package model
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/purpose168/crush-cn/internal/crashdump"
+	"github.com/purpose168/crush-cn/internal/ui/util"
+)
+
+// crashDraftMsg 携带上次崩溃前编辑器中未发送的文本。
+type crashDraftMsg struct {
+	draft string
+}
+
+// SaveCrashDump 在程序因 panic 退出后将界面状态快照保存到数据目录。
+// panic 可能发生在更新状态的中途，读取状态时再次 panic 只会返回错误。
+func (m *UI) SaveCrashDump(cause error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("读取界面状态失败: %v", r)
+		}
+	}()
+
+	dump := crashdump.Dump{
+		Time:  time.Now(),
+		Draft: m.textarea.Value(),
+	}
+	if cause != nil {
+		dump.Error = cause.Error()
+	}
+	if m.hasSession() {
+		dump.SessionID = m.session.ID
+		if m.com.App.AgentCoordinator != nil {
+			dump.Queued = m.com.App.AgentCoordinator.QueuedPromptsList(m.session.ID)
+		}
+	}
+	return crashdump.Save(m.com.Config().Options.DataDirectory, dump)
+}
+
+// restoreCrashDraft 返回读取上次崩溃时保存的快照的命令，快照中有未发送的文本时恢复到编辑器
+func (m *UI) restoreCrashDraft() tea.Cmd {
+	dataDir := m.com.Config().Options.DataDirectory
+	return func() tea.Msg {
+		dump, ok, err := crashdump.Take(dataDir)
+		if err != nil {
+			slog.Error("读取崩溃快照失败", "error", err)
+			return nil
+		}
+		if !ok || strings.TrimSpace(dump.Draft) == "" {
+			return nil
+		}
+		slog.Info("恢复崩溃前未发送的输入", "session_id", dump.SessionID, "time", dump.Time)
+		return crashDraftMsg{draft: dump.Draft}
+	}
+}
+
+// applyCrashDraft 将崩溃前未发送的文本放回编辑器，编辑器中已有文本时不覆盖
+func (m *UI) applyCrashDraft(draft string) tea.Cmd {
+	if m.textarea.Value() != "" {
+		return nil
+	}
+	m.textarea.SetValue(draft)
+	m.textarea.MoveToEnd()
+	return util.ReportInfo("已恢复上次崩溃前未发送的输入")
+}
@@ -0,0 +1,43 @@
+package model
+
+import (
+	"strings"
+	"time"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/purpose168/crush-cn/internal/message"
+	"github.com/purpose168/crush-cn/internal/ui/dialog"
+)
+
+// confirmDuplicatePrompt 在提示与当前会话中之前发送的提示相同时打开确认对话框，
+// 返回是否需要等待用户选择。待发送的提示由对话框保存。
+func (m *UI) confirmDuplicatePrompt(content string, attachments []message.Attachment) bool {
+	if !m.hasSession() || content == "" {
+		return false
+	}
+	// 提示历史按时间倒序排列，第一条匹配即最近一次发送
+	for _, prev := range m.promptHistory.sent {
+		if strings.TrimSpace(prev.text) != content {
+			continue
+		}
+		// 只提示仍在聊天中的消息，以便跳转到回答
+		if m.chat.MessageItem(prev.messageID) == nil {
+			return false
+		}
+		sentAt := time.Unix(prev.createdAt, 0)
+		m.dialog.OpenDialog(dialog.NewDuplicatePrompt(m.com, prev.messageID, sentAt, content, attachments))
+		return true
+	}
+	return false
+}
+
+// jumpToAnswer 选中并滚动到之前发送的提示的回答。
+func (m *UI) jumpToAnswer(messageID string) tea.Cmd {
+	if !m.chat.SelectAnswer(messageID) {
+		return nil
+	}
+	m.setState(m.state, uiFocusMain)
+	m.textarea.Blur()
+	m.chat.Focus()
+	return m.chat.ScrollToSelectedAndAnimate()
+}
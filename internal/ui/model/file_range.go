@@ -0,0 +1,112 @@
+package model
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/purpose168/crush-cn/internal/agent/tools"
+	"github.com/purpose168/crush-cn/internal/message"
+	"github.com/purpose168/crush-cn/internal/ui/completions"
+	"github.com/purpose168/crush-cn/internal/ui/util"
+)
+
+// selectFileCompletion 处理文件补全的选择。查询中带有行范围时直接附加该范围；
+// 较长的文本文件进入行范围选择步骤；其他情况附加整个文件。
+// 返回补全窗口是否应保持打开。
+func (m *UI) selectFileCompletion(path string, keepOpen bool) (tea.Cmd, bool) {
+	_, lineRange := completions.SplitMention(m.completionsQuery)
+	if start, end, ok := completions.ParseLineRange(lineRange); ok {
+		value := completions.LineRangeCompletionValue{Path: path, Start: start, End: end}
+		return m.insertLineRangeCompletion(value), false
+	}
+	if keepOpen {
+		return m.insertFileCompletion(path), true
+	}
+
+	lines, ok := textLineCount(path)
+	if !ok || lines < completions.RangeStepMinLines {
+		return m.insertFileCompletion(path), false
+	}
+	m.startRangeStep(path, lines)
+	return nil, true
+}
+
+// startRangeStep 将文本区域中的@query替换为@path:，并在补全窗口中列出行范围
+func (m *UI) startRangeStep(path string, lines int) {
+	value := m.textarea.Value()
+	if m.completionsStartIndex > len(value) {
+		return
+	}
+	word := m.textareaWord()
+	endIdx := min(m.completionsStartIndex+len(word), len(value))
+	mention := "@" + path + ":"
+	m.textarea.SetValue(value[:m.completionsStartIndex] + mention + value[endIdx:])
+	m.textarea.MoveToEnd()
+
+	m.completionsQuery = mention[1:]
+	m.completionsRangePath = path
+	m.completionsRangeLines = lines
+	m.completions.SetLineRanges(path, lines, "")
+}
+
+// updateRangeStep 根据冒号后输入的行范围更新选项，删除冒号时关闭补全
+func (m *UI) updateRangeStep(query string) {
+	lineRange, ok := strings.CutPrefix(query, m.completionsRangePath+":")
+	if !ok {
+		m.closeCompletions()
+		return
+	}
+	m.completionsQuery = query
+	m.completions.SetLineRanges(m.completionsRangePath, m.completionsRangeLines, lineRange)
+}
+
+// insertLineRangeCompletion 将文件引用插入到文本区域中，并将指定的行范围
+// 连同前后几行作为附件添加，行范围为整个文件时与普通文件补全相同
+func (m *UI) insertLineRangeCompletion(value completions.LineRangeCompletionValue) tea.Cmd {
+	if value.Whole() {
+		return m.insertFileCompletion(value.Path)
+	}
+	if !m.insertCompletionText(value.Mention()) {
+		return nil
+	}
+
+	return func() tea.Msg {
+		content, err := os.ReadFile(value.Path)
+		if err != nil {
+			// 如果失败，让LLM稍后处理
+			return nil
+		}
+		excerpt, from, to, ok := completions.ExcerptLines(string(content), value.Start, value.End, completions.RangeContextLines)
+		if !ok {
+			return util.NewWarnMsg(fmt.Sprintf("%s 没有第 %d 行", value.Path, value.Start))
+		}
+		lineRange := fmt.Sprintf(":%d-%d", from, to)
+		return message.Attachment{
+			FilePath: value.Path + lineRange,
+			FileName: filepath.Base(value.Path) + lineRange,
+			MimeType: "text/plain; charset=utf-8",
+			Content:  []byte(excerpt),
+		}
+	}
+}
+
+// textLineCount 返回文本文件的行数，文件无法读取、过大或不是文本时返回 false
+func textLineCount(path string) (int, bool) {
+	info, err := os.Stat(path)
+	if err != nil || info.IsDir() || info.Size() > tools.MaxReadSize {
+		return 0, false
+	}
+	content, err := os.ReadFile(path)
+	if err != nil || !strings.HasPrefix(mimeOf(content), "text/") {
+		return 0, false
+	}
+	lines := bytes.Count(content, []byte("\n"))
+	if len(content) > 0 && content[len(content)-1] != '\n' {
+		lines++
+	}
+	return lines, true
+}
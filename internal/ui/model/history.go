@@ -12,6 +12,15 @@ import (
 // promptHistoryLoadedMsg 当提示历史加载完成时发送的消息类型。
 type promptHistoryLoadedMsg struct {
 	messages []string
+	// sent 是当前会话中已发送的提示，用于检测重复提示
+	sent []sentPrompt
+}
+
+// sentPrompt 是会话中已发送的一条提示。
+type sentPrompt struct {
+	messageID string
+	text      string
+	createdAt int64
 }
 
 // loadPromptHistory 加载用户消息以供历史导航使用。
@@ -32,12 +41,18 @@ func (m *UI) loadPromptHistory() tea.Cmd {
 		}
 
 		texts := make([]string, 0, len(messages))
+		var sent []sentPrompt
 		for _, msg := range messages {
-			if text := msg.Content().Text; text != "" {
-				texts = append(texts, text)
+			text := msg.Content().Text
+			if text == "" {
+				continue
+			}
+			texts = append(texts, text)
+			if m.session != nil {
+				sent = append(sent, sentPrompt{messageID: msg.ID, text: text, createdAt: msg.CreatedAt})
 			}
 		}
-		return promptHistoryLoadedMsg{messages: texts}
+		return promptHistoryLoadedMsg{messages: texts, sent: sent}
 	}
 }
 
@@ -0,0 +1,62 @@
+package model
+
+import (
+	"log/slog"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/purpose168/crush-cn/internal/imageprep"
+	"github.com/purpose168/crush-cn/internal/message"
+	"github.com/purpose168/crush-cn/internal/ui/common"
+	"github.com/purpose168/crush-cn/internal/ui/util"
+)
+
+// imagePreparedMsg 携带预处理完成、可以加入附件列表的图片附件。
+type imagePreparedMsg struct {
+	att message.Attachment
+}
+
+// isImagePath 报告路径的扩展名是否是支持的图像格式。
+func isImagePath(path string) bool {
+	return slices.Contains(common.AllowedImageTypes, strings.ToLower(filepath.Ext(path)))
+}
+
+// prepareImageAttachment 在 msg 是图片附件时返回在后台缩小并重新编码图片的命令，
+// 处理后的图片仍超过附件大小限制时报告错误。msg 不是图片附件时返回 nil。
+func (m *UI) prepareImageAttachment(msg tea.Msg) tea.Cmd {
+	att, ok := msg.(message.Attachment)
+	if !ok || !att.IsImage() {
+		return nil
+	}
+	cfg := m.com.Config().Options
+	opts := imageprep.Options{
+		MaxBytes:     common.MaxAttachmentSize,
+		OriginalsDir: filepath.Join(cfg.DataDirectory, imageprep.OriginalsDir),
+	}
+	disabled := false
+	if cfg.Images != nil {
+		opts.MaxDimension = cfg.Images.MaxDimension
+		opts.Quality = cfg.Images.Quality
+		disabled = cfg.Images.Disabled
+	}
+	return func() tea.Msg {
+		if !disabled {
+			res, err := imageprep.Process(att, opts)
+			if err != nil {
+				slog.Warn("图片预处理失败，使用原图", "file", att.FileName, "error", err)
+			} else if res.Changed {
+				slog.Debug("已缩小图片附件", "file", att.FileName, "original", res.Original, "before", len(att.Content), "after", len(res.Attachment.Content))
+			}
+			att = res.Attachment
+		}
+		if int64(len(att.Content)) > common.MaxAttachmentSize {
+			return util.InfoMsg{
+				Type: util.InfoTypeError,
+				Msg:  "图片过大，最大5MB",
+			}
+		}
+		return imagePreparedMsg{att: att}
+	}
+}
@@ -9,6 +9,7 @@ type KeyMap struct {
 		AddFile     key.Binding // 添加文件
 		SendMessage key.Binding // 发送消息
 		OpenEditor  key.Binding // 打开编辑器
+		Voice       key.Binding // 语音输入
 		Newline     key.Binding // 换行
 		AddImage    key.Binding // 添加图片
 		PasteImage  key.Binding // 粘贴图片
@@ -19,6 +20,7 @@ type KeyMap struct {
 		AttachmentDeleteMode key.Binding // 附件删除模式
 		Escape               key.Binding // 退出
 		DeleteAllAttachments key.Binding // 删除所有附件
+		ManageAttachments    key.Binding // 管理附件
 
 		// History navigation 历史记录导航
 		HistoryPrev key.Binding // 上一条历史记录
@@ -49,7 +51,37 @@ type KeyMap struct {
 		End            key.Binding // 末页
 		Copy           key.Binding // 复制
 		ClearHighlight key.Binding // 清除高亮
+		AskSelection   key.Binding // 询问所选内容
 		Expand         key.Binding // 展开
+		ViewImage      key.Binding // 查看图像
+		ViewReasoning  key.Binding // 查看推理过程
+		RunCode        key.Binding // 运行代码块
+		RereadFile     key.Binding // 让智能体重新读取失败工具的文件
+		Bookmark       key.Binding // 为选中项目添加或移除书签
+		Regenerate     key.Binding // 重新生成最后一轮回复
+		Variants       key.Binding // 比较最后一轮回复的各个版本
+		CopyLink       key.Binding // 复制选中消息的深层链接
+		CodeWrap       key.Binding // 切换代码长行的显示方式
+		CodeLeft       key.Binding // 代码向左滚动
+		CodeRight      key.Binding // 代码向右滚动
+		ReviewPlan     key.Binding // 审阅计划模式下等待批准的计划
+		EditTodos      key.Binding // 编辑会话的待办事项
+	}
+
+	// Tabs 会话标签页相关按键映射
+	Tabs struct {
+		New    key.Binding // 新建标签页
+		Switch key.Binding // 切换到第 N 个标签页
+	}
+
+	// Replay 会话回放相关按键映射
+	Replay struct {
+		Prev  key.Binding // 上一条消息
+		Next  key.Binding // 下一条消息
+		First key.Binding // 第一条消息
+		Last  key.Binding // 最后一条消息
+		Play  key.Binding // 播放/暂停
+		Exit  key.Binding // 退出回放
 	}
 
 	// Initialize 初始化相关按键映射
@@ -67,6 +99,7 @@ type KeyMap struct {
 	Models   key.Binding // 模型
 	Suspend  key.Binding // 挂起
 	Sessions key.Binding // 会话
+	Layout   key.Binding // 切换布局
 	Tab      key.Binding // 切换焦点
 }
 
@@ -97,6 +130,10 @@ func DefaultKeyMap() KeyMap {
 			key.WithKeys("ctrl+s"),
 			key.WithHelp("ctrl+s", "会话"),
 		),
+		Layout: key.NewBinding(
+			key.WithKeys("alt+l"),
+			key.WithHelp("alt+l", "布局"),
+		),
 		Tab: key.NewBinding(
 			key.WithKeys("tab"),
 			key.WithHelp("tab", "切换焦点"),
@@ -115,6 +152,10 @@ func DefaultKeyMap() KeyMap {
 		key.WithKeys("ctrl+o"),
 		key.WithHelp("ctrl+o", "打开编辑器"),
 	)
+	km.Editor.Voice = key.NewBinding(
+		key.WithKeys("alt+v"),
+		key.WithHelp("alt+v", "语音输入"),
+	)
 	km.Editor.Newline = key.NewBinding(
 		key.WithKeys("shift+enter", "ctrl+j"),
 		// "ctrl+j" 是许多编辑器中常见的换行快捷键。如果
@@ -150,6 +191,10 @@ func DefaultKeyMap() KeyMap {
 		key.WithKeys("r"),
 		key.WithHelp("ctrl+r+r", "删除所有附件"),
 	)
+	km.Editor.ManageAttachments = key.NewBinding(
+		key.WithKeys("alt+a"),
+		key.WithHelp("alt+a", "管理附件"),
+	)
 	km.Editor.HistoryPrev = key.NewBinding(
 		key.WithKeys("up"),
 	)
@@ -246,10 +291,103 @@ func DefaultKeyMap() KeyMap {
 		key.WithKeys("esc", "alt+esc"),
 		key.WithHelp("esc", "清除选择"),
 	)
+	km.Chat.AskSelection = key.NewBinding(
+		key.WithKeys("ctrl+x"),
+		key.WithHelp("ctrl+x", "询问所选内容"),
+	)
 	km.Chat.Expand = key.NewBinding(
 		key.WithKeys("space"),
 		key.WithHelp("space", "展开/折叠"),
 	)
+	km.Chat.ViewImage = key.NewBinding(
+		key.WithKeys("o"),
+		key.WithHelp("o", "查看图像"),
+	)
+	km.Chat.ViewReasoning = key.NewBinding(
+		key.WithKeys("t"),
+		key.WithHelp("t", "查看推理"),
+	)
+	km.Chat.RunCode = key.NewBinding(
+		key.WithKeys("r"),
+		key.WithHelp("r", "运行代码块/重试"),
+	)
+	km.Chat.RereadFile = key.NewBinding(
+		key.WithKeys("e"),
+		key.WithHelp("e", "重新读取文件"),
+	)
+	km.Chat.Bookmark = key.NewBinding(
+		key.WithKeys("m"),
+		key.WithHelp("m", "书签"),
+	)
+	km.Chat.Regenerate = key.NewBinding(
+		key.WithKeys("R"),
+		key.WithHelp("R", "重新生成"),
+	)
+	km.Chat.Variants = key.NewBinding(
+		key.WithKeys("V"),
+		key.WithHelp("V", "回复版本"),
+	)
+	km.Chat.CopyLink = key.NewBinding(
+		key.WithKeys("L"),
+		key.WithHelp("L", "复制链接"),
+	)
+	km.Chat.CodeWrap = key.NewBinding(
+		key.WithKeys("w"),
+		key.WithHelp("w", "截断/换行/滚动"),
+	)
+	km.Chat.CodeLeft = key.NewBinding(
+		key.WithKeys("left", "h"),
+		key.WithHelp("←/→", "滚动代码"),
+	)
+	km.Chat.CodeRight = key.NewBinding(
+		key.WithKeys("right", "l"),
+		key.WithHelp("←/→", "滚动代码"),
+	)
+	km.Chat.ReviewPlan = key.NewBinding(
+		key.WithKeys("ctrl+b"),
+		key.WithHelp("ctrl+b", "审阅计划"),
+	)
+	// 与审阅计划共用按键，没有等待批准的计划时编辑待办事项
+	km.Chat.EditTodos = key.NewBinding(
+		key.WithKeys("ctrl+b"),
+		key.WithHelp("ctrl+b", "编辑待办"),
+	)
+	km.Tabs.New = key.NewBinding(
+		key.WithKeys("ctrl+t"),
+		key.WithHelp("ctrl+t", "新建标签页"),
+	)
+	// 许多终端不会上报 ctrl+数字，因此同时接受 alt+数字
+	km.Tabs.Switch = key.NewBinding(
+		key.WithKeys(
+			"ctrl+1", "ctrl+2", "ctrl+3", "ctrl+4", "ctrl+5", "ctrl+6", "ctrl+7", "ctrl+8", "ctrl+9",
+			"alt+1", "alt+2", "alt+3", "alt+4", "alt+5", "alt+6", "alt+7", "alt+8", "alt+9",
+		),
+		key.WithHelp("ctrl+1-9", "切换标签页"),
+	)
+	km.Replay.Prev = key.NewBinding(
+		key.WithKeys("left", "h"),
+		key.WithHelp("←/→", "逐条"),
+	)
+	km.Replay.Next = key.NewBinding(
+		key.WithKeys("right", "l"),
+		key.WithHelp("←/→", "逐条"),
+	)
+	km.Replay.First = key.NewBinding(
+		key.WithKeys("g", "home"),
+		key.WithHelp("g/G", "开头/结尾"),
+	)
+	km.Replay.Last = key.NewBinding(
+		key.WithKeys("G", "end"),
+		key.WithHelp("g/G", "开头/结尾"),
+	)
+	km.Replay.Play = key.NewBinding(
+		key.WithKeys("space"),
+		key.WithHelp("space", "播放/暂停"),
+	)
+	km.Replay.Exit = key.NewBinding(
+		key.WithKeys("esc", "alt+esc", "q"),
+		key.WithHelp("esc", "退出回放"),
+	)
 	km.Initialize.Yes = key.NewBinding(
 		key.WithKeys("y", "Y"),
 		key.WithHelp("y", "是"),
@@ -0,0 +1,48 @@
+package model
+
+import (
+	tea "charm.land/bubbletea/v2"
+	"github.com/purpose168/crush-cn/internal/session"
+	"github.com/purpose168/crush-cn/internal/ui/common"
+	"github.com/purpose168/crush-cn/internal/ui/util"
+)
+
+// linkTarget 是会话链接指向的会话和消息。
+type linkTarget struct {
+	sessionID string
+	messageID string
+}
+
+// OpenMessage 设置启动后要打开的会话，会话加载后滚动到给定消息。messageID 为空时只打开会话。
+// 必须在程序启动前调用。
+func (m *UI) OpenMessage(sessionID, messageID string) {
+	m.linkTarget = linkTarget{sessionID: sessionID, messageID: messageID}
+}
+
+// jumpToLinkTarget 选中并滚动到会话链接指向的消息。
+func (m *UI) jumpToLinkTarget() tea.Cmd {
+	messageID := m.linkTarget.messageID
+	m.linkTarget = linkTarget{}
+	if messageID == "" {
+		return nil
+	}
+	if !m.chat.SelectMessage(messageID) {
+		return util.ReportWarn("链接指向的消息已不在会话中")
+	}
+	m.setState(m.state, uiFocusMain)
+	m.textarea.Blur()
+	m.chat.Focus()
+	return m.chat.ScrollToSelectedAndAnimate()
+}
+
+// copyMessageLink 将选中消息的深层链接复制到剪贴板，链接可以用 crush open 打开。
+func (m *UI) copyMessageLink() tea.Cmd {
+	if !m.hasSession() {
+		return nil
+	}
+	messageID := m.chat.SelectedMessageID()
+	if messageID == "" {
+		return nil
+	}
+	return common.CopyToClipboard(session.MessageLink(m.session.ID, messageID), "消息链接已复制到剪贴板")
+}
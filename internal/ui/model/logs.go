@@ -0,0 +1,50 @@
+package model
+
+import (
+	"errors"
+	"time"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/purpose168/crush-cn/internal/log"
+	"github.com/purpose168/crush-cn/internal/ui/dialog"
+	"github.com/purpose168/crush-cn/internal/ui/util"
+)
+
+// logsRefreshInterval 是日志查看对话框读取新日志的间隔。
+const logsRefreshInterval = time.Second
+
+// logsTickMsg 在需要刷新日志查看对话框时发送。
+type logsTickMsg struct{}
+
+// logsTick 返回在刷新间隔后发送 [logsTickMsg] 的命令。
+func logsTick() tea.Cmd {
+	return tea.Tick(logsRefreshInterval, func(time.Time) tea.Msg {
+		return logsTickMsg{}
+	})
+}
+
+// openLogsDialog 打开查看本次运行日志的对话框
+func (m *UI) openLogsDialog() tea.Cmd {
+	if m.dialog.ContainsDialog(dialog.LogsID) {
+		// 带到前面
+		m.dialog.BringToFront(dialog.LogsID)
+		return nil
+	}
+
+	path, offset := log.File()
+	if path == "" {
+		return util.ReportError(errors.New("日志未初始化"))
+	}
+	m.dialog.OpenDialog(dialog.NewLogs(m.com, path, offset))
+	return logsTick()
+}
+
+// handleLogsTick 在日志查看对话框打开时读取新日志并安排下一次刷新，对话框关闭后停止。
+func (m *UI) handleLogsTick() tea.Cmd {
+	d, ok := m.dialog.Dialog(dialog.LogsID).(*dialog.Logs)
+	if !ok {
+		return nil
+	}
+	d.Refresh()
+	return logsTick()
+}
@@ -1,23 +1,85 @@
 package model
 
 import (
+	"context"
 	"fmt"
 	"maps"
 	"slices"
 	"strings"
+	"time"
 
+	tea "charm.land/bubbletea/v2"
 	"charm.land/lipgloss/v2"
 	"github.com/charmbracelet/x/powernap/pkg/lsp/protocol"
+	"github.com/dustin/go-humanize"
 	"github.com/purpose168/crush-cn/internal/app"
 	"github.com/purpose168/crush-cn/internal/lsp"
 	"github.com/purpose168/crush-cn/internal/ui/common"
+	"github.com/purpose168/crush-cn/internal/ui/dialog"
 	"github.com/purpose168/crush-cn/internal/ui/styles"
+	"github.com/purpose168/crush-cn/internal/ui/util"
 )
 
-// LSPInfo 包装LSP客户端信息，按严重程度分类的诊断计数。
+// lspServersRefreshInterval 是语言服务器对话框刷新进程信息的间隔。
+const lspServersRefreshInterval = 2 * time.Second
+
+// lspServersTickMsg 在需要刷新语言服务器对话框时发送。
+type lspServersTickMsg struct{}
+
+// lspServersTick 返回在刷新间隔后发送 [lspServersTickMsg] 的命令。
+func lspServersTick() tea.Cmd {
+	return tea.Tick(lspServersRefreshInterval, func(time.Time) tea.Msg {
+		return lspServersTickMsg{}
+	})
+}
+
+// openLSPServersDialog 打开管理语言服务器的对话框
+func (m *UI) openLSPServersDialog() tea.Cmd {
+	if m.dialog.ContainsDialog(dialog.LSPServersID) {
+		m.dialog.BringToFront(dialog.LSPServersID)
+		return nil
+	}
+	m.dialog.OpenDialog(dialog.NewLSPServers(m.com))
+	return lspServersTick()
+}
+
+// handleLSPServersTick 在语言服务器对话框打开时刷新进程信息并安排下一次刷新，对话框关闭后停止。
+func (m *UI) handleLSPServersTick() tea.Cmd {
+	d, ok := m.dialog.Dialog(dialog.LSPServersID).(*dialog.LSPServers)
+	if !ok {
+		return nil
+	}
+	d.Refresh()
+	return lspServersTick()
+}
+
+// restartLSP 在后台重启语言服务器，状态变化通过 LSP 事件更新侧边栏和对话框。
+func (m *UI) restartLSP(name string) tea.Cmd {
+	return func() tea.Msg {
+		if err := m.com.App.LSPManager.Restart(name); err != nil {
+			return util.NewErrorMsg(fmt.Errorf("重启语言服务器 %s 失败: %w", name, err))
+		}
+		return util.NewInfoMsg(fmt.Sprintf("已重启语言服务器 %s", name))
+	}
+}
+
+// stopLSP 在后台停止语言服务器。
+func (m *UI) stopLSP(name string) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := m.com.App.LSPManager.Stop(ctx, name); err != nil {
+			return util.NewErrorMsg(fmt.Errorf("停止语言服务器 %s 失败: %w", name, err))
+		}
+		return util.NewInfoMsg(fmt.Sprintf("已停止语言服务器 %s", name))
+	}
+}
+
+// LSPInfo 包装LSP客户端信息，按严重程度分类的诊断计数和服务器进程信息。
 type LSPInfo struct {
 	app.LSPClientInfo
 	Diagnostics map[protocol.DiagnosticSeverity]int
+	Process     lsp.ProcessInfo
 }
 
 // lspInfo 渲染LSP状态部分，显示活动的LSP客户端及其诊断计数。
@@ -42,7 +104,8 @@ func (m *UI) lspInfo(width, maxItems int, isSection bool) string {
 			protocol.SeverityInformation: counts.Information,
 		}
 
-		lsps = append(lsps, LSPInfo{LSPClientInfo: state, Diagnostics: lspErrs})
+		proc, _ := client.Process()
+		lsps = append(lsps, LSPInfo{LSPClientInfo: state, Diagnostics: lspErrs, Process: proc})
 	}
 
 	title := t.Subtle.Render("语言服务器")
@@ -97,6 +160,9 @@ func lspList(t *styles.Styles, lsps []LSPInfo, width, maxItems int) string {
 		case lsp.StateReady:
 			icon = t.ItemOnlineIcon.String()
 			diagnostics = lspDiagnostics(t, l.Diagnostics)
+			if l.Process.PID != 0 {
+				description = t.Subtle.Render(fmt.Sprintf("PID %d · %s", l.Process.PID, humanize.Bytes(l.Process.Memory)))
+			}
 		case lsp.StateError:
 			icon = t.ItemErrorIcon.String()
 			description = t.Subtle.Render("错误")
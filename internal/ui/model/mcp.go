@@ -4,10 +4,13 @@ import (
 	"fmt"
 	"strings"
 
+	tea "charm.land/bubbletea/v2"
 	"charm.land/lipgloss/v2"
 	"github.com/purpose168/crush-cn/internal/agent/tools/mcp"
+	"github.com/purpose168/crush-cn/internal/config"
 	"github.com/purpose168/crush-cn/internal/ui/common"
 	"github.com/purpose168/crush-cn/internal/ui/styles"
+	"github.com/purpose168/crush-cn/internal/ui/util"
 )
 
 // mcpInfo 渲染MCP状态部分，显示活动的MCP客户端及其工具/提示计数。
@@ -75,6 +78,9 @@ func mcpList(t *styles.Styles, mcps []mcp.ClientInfo, width, maxItems int) strin
 			if m.Error != nil {
 				description = t.Subtle.Render(fmt.Sprintf("错误: %s", m.Error.Error()))
 			}
+		case mcp.StateUnauthorized:
+			icon = t.ItemErrorIcon.String()
+			description = t.Subtle.Render("需要认证")
 		case mcp.StateDisabled:
 			icon = t.ItemOfflineIcon.Foreground(t.Muted.GetBackground()).String()
 			description = t.Subtle.Render("已禁用")
@@ -98,3 +104,23 @@ func mcpList(t *styles.Styles, mcps []mcp.ClientInfo, width, maxItems int) strin
 	}
 	return lipgloss.JoinVertical(lipgloss.Left, renderedMcps...)
 }
+
+// allowToolAlways 返回将工具持久化到 permissions.allowed_tools 的命令。
+func (m *UI) allowToolAlways(toolName string) tea.Cmd {
+	return func() tea.Msg {
+		if err := m.com.Config().AllowTool(toolName); err != nil {
+			return util.ReportError(err)()
+		}
+		return util.NewInfoMsg("已始终允许 " + toolName + "，设置已保存到 permissions.allowed_tools")
+	}
+}
+
+// allowSamplingAlways 将 MCP 服务器的采样设置改为 allow 并保存到配置，之后该服务器的采样请求不再需要确认。
+func (m *UI) allowSamplingAlways(name string) tea.Cmd {
+	return func() tea.Msg {
+		if err := m.com.Config().SetConfigField("mcp."+name+".sampling", string(config.MCPSamplingAllow)); err != nil {
+			return util.ReportError(err)()
+		}
+		return util.NewInfoMsg("已始终允许 " + name + " 的采样请求，设置已保存到 mcp." + name + ".sampling")
+	}
+}
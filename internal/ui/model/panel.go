@@ -0,0 +1,95 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+
+	"charm.land/lipgloss/v2"
+	uv "github.com/charmbracelet/ultraviolet"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/purpose168/crush-cn/internal/session"
+	"github.com/purpose168/crush-cn/internal/ui/chat"
+	"github.com/purpose168/crush-cn/internal/ui/common"
+	"github.com/purpose168/crush-cn/internal/ui/styles"
+)
+
+// panelMaxWidth 是面板布局中右侧面板的最大宽度。
+const panelMaxWidth = 60
+
+// drawPanel 渲染面板布局的右侧面板，实时显示当前的任务计划、提示队列和带有增删行数的已修改文件。
+func (m *UI) drawPanel(scr uv.Screen, area uv.Rectangle) {
+	if m.session == nil {
+		return
+	}
+
+	const minFilesShown = 2
+
+	t := m.com.Styles
+	width := area.Dx()
+	height := area.Dy()
+
+	title := t.Muted.Width(width).MaxHeight(2).Render(m.session.Title)
+	todosSection := m.panelTodos(width)
+	queueSection := m.panelQueue(width)
+
+	header := lipgloss.JoinVertical(lipgloss.Left, title, "", todosSection, "", queueSection, "")
+	// 已修改文件部分的标题和空行占用两行
+	maxFiles := max(minFilesShown, height-lipgloss.Height(header)-2)
+	filesSection := m.filesInfo(m.sessionDir(), width, maxFiles, true)
+
+	uv.NewStyledString(
+		lipgloss.NewStyle().
+			MaxWidth(width).
+			MaxHeight(height).
+			Render(lipgloss.JoinVertical(lipgloss.Left, header, filesSection)),
+	).Draw(scr, area)
+}
+
+// panelTodos 渲染面板中的任务计划部分。
+func (m *UI) panelTodos(width int) string {
+	t := m.com.Styles
+	todos := m.session.Todos
+	if len(todos) == 0 {
+		return fmt.Sprintf("%s\n\n%s", common.Section(t, "计划", width), t.Subtle.Render("无"))
+	}
+
+	completed := 0
+	for _, todo := range todos {
+		if todo.Status == session.TodoStatusCompleted {
+			completed++
+		}
+	}
+	title := common.Section(t, "计划", width, t.Muted.Render(fmt.Sprintf("%d/%d", completed, len(todos))))
+
+	inProgressIcon := t.Tool.TodoInProgressIcon.Render(styles.SpinnerIcon)
+	if m.todoIsSpinning {
+		inProgressIcon = m.todoSpinner.View()
+	}
+	list := chat.FormatTodosList(t, todos, inProgressIcon, width)
+	if m.session.PlanAwaitingApproval() {
+		list += "\n" + t.Pills.HelpKey.Render("ctrl+b") + " " + t.Pills.HelpText.Render("审阅计划")
+	}
+	return fmt.Sprintf("%s\n\n%s", title, list)
+}
+
+// panelQueue 渲染面板中的提示队列部分。
+func (m *UI) panelQueue(width int) string {
+	t := m.com.Styles
+	var items []string
+	if m.promptQueue > 0 && m.com.App != nil && m.com.App.AgentCoordinator != nil {
+		items = m.com.App.AgentCoordinator.QueuedPromptsList(m.session.ID)
+	}
+	if len(items) == 0 {
+		return fmt.Sprintf("%s\n\n%s", common.Section(t, "队列", width), t.Subtle.Render("无"))
+	}
+
+	prefix := t.Pills.QueueItemPrefix.Render() + " "
+	lines := make([]string, 0, len(items))
+	for _, item := range items {
+		// 只显示提示的第一行
+		text, _, _ := strings.Cut(item, "\n")
+		lines = append(lines, ansi.Truncate(prefix+t.Muted.Render(text), width, "…"))
+	}
+	title := common.Section(t, "队列", width, t.Muted.Render(fmt.Sprintf("%d", len(items))))
+	return fmt.Sprintf("%s\n\n%s", title, strings.Join(lines, "\n"))
+}
@@ -1,11 +1,13 @@
 package model
 
 import (
+	"cmp"
 	"fmt"
 	"strings"
 
 	tea "charm.land/bubbletea/v2"
 	"charm.land/lipgloss/v2"
+	"github.com/charmbracelet/x/ansi"
 	"github.com/purpose168/crush-cn/internal/session"
 	"github.com/purpose168/crush-cn/internal/ui/chat"
 	"github.com/purpose168/crush-cn/internal/ui/styles"
@@ -71,8 +73,9 @@ func queuePill(queue int, focused, panelFocused bool, t *styles.Styles) string {
 	return pillStyle(focused, panelFocused, t).Render(content)
 }
 
-// todoPill 渲染带有可选旋转器和任务名称的任务进度药丸。
-func todoPill(todos []session.Todo, spinnerView string, focused, panelFocused bool, t *styles.Styles) string {
+// todoPill 渲染带有可选旋转器和任务名称的任务进度药丸。planPending 表示
+// 这些任务是计划模式下等待批准的计划。
+func todoPill(todos []session.Todo, spinnerView string, planPending, focused, panelFocused bool, t *styles.Styles) string {
 	if !hasIncompleteTodos(todos) {
 		return ""
 	}
@@ -93,6 +96,9 @@ func todoPill(todos []session.Todo, spinnerView string, focused, panelFocused bo
 	total := len(todos)
 
 	label := t.Base.Render("待办")
+	if planPending {
+		label = t.Base.Render("计划")
+	}
 	progress := t.Muted.Render(fmt.Sprintf("%d/%d", completed, total))
 
 	var content string
@@ -139,6 +145,69 @@ func queueList(queueItems []string, t *styles.Styles) string {
 	return strings.Join(lines, "\n")
 }
 
+// startTodoSpinner 启动进行中任务的旋转器。无障碍模式下不旋转。
+func (m *UI) startTodoSpinner() tea.Cmd {
+	if m.accessible {
+		return nil
+	}
+	m.todoIsSpinning = true
+	return m.todoSpinner.Tick
+}
+
+// accessiblePillLines 以适合屏幕阅读器的逐行纯文本形式返回药丸内容。
+func (m *UI) accessiblePillLines() []string {
+	var lines []string
+	if hasIncompleteTodos(m.session.Todos) {
+		completed := 0
+		var current string
+		for _, todo := range m.session.Todos {
+			switch todo.Status {
+			case session.TodoStatusCompleted:
+				completed++
+			case session.TodoStatusInProgress:
+				if current == "" {
+					current = cmp.Or(todo.ActiveForm, todo.Content)
+				}
+			}
+		}
+		line := fmt.Sprintf("待办: 已完成 %d/%d", completed, len(m.session.Todos))
+		if m.session.PlanAwaitingApproval() {
+			line = fmt.Sprintf("计划: %d 个步骤等待批准, 按 ctrl+b 审阅", len(m.session.Todos))
+		}
+		if current != "" {
+			line += ", 进行中: " + current
+		}
+		lines = append(lines, line)
+		if m.pillsExpanded && m.focusedPillSection == pillSectionTodos {
+			for _, todo := range m.session.Todos {
+				lines = append(lines, fmt.Sprintf("- [%s] %s", todoStatusText(todo.Status), todo.Content))
+			}
+		}
+	}
+	if m.promptQueue > 0 {
+		lines = append(lines, fmt.Sprintf("排队的提示: %d", m.promptQueue))
+		if m.pillsExpanded && m.focusedPillSection == pillSectionQueue &&
+			m.com.App != nil && m.com.App.AgentCoordinator != nil {
+			for _, item := range m.com.App.AgentCoordinator.QueuedPromptsList(m.session.ID) {
+				lines = append(lines, "- "+strings.ReplaceAll(item, "\n", " "))
+			}
+		}
+	}
+	return lines
+}
+
+// todoStatusText 返回任务状态的文字描述。
+func todoStatusText(status session.TodoStatus) string {
+	switch status {
+	case session.TodoStatusCompleted:
+		return "已完成"
+	case session.TodoStatusInProgress:
+		return "进行中"
+	default:
+		return "待处理"
+	}
+}
+
 // togglePillsExpanded 切换药丸面板的展开状态。
 func (m *UI) togglePillsExpanded() tea.Cmd {
 	if !m.hasSession() {
@@ -188,7 +257,8 @@ func (m *UI) switchPillSection(dir int) tea.Cmd {
 
 // pillsAreaHeight 计算药丸区域所需的总高度。
 func (m *UI) pillsAreaHeight() int {
-	if !m.hasSession() {
+	// 面板布局在右侧显示任务和队列
+	if !m.hasSession() || (m.panelLayout && !m.isCompact) {
 		return 0
 	}
 	hasIncomplete := hasIncompleteTodos(m.session.Todos)
@@ -198,6 +268,10 @@ func (m *UI) pillsAreaHeight() int {
 		return 0
 	}
 
+	if m.accessible {
+		return len(m.accessiblePillLines())
+	}
+
 	pillsAreaHeight := pillHeightWithBorder
 	if m.pillsExpanded {
 		if m.focusedPillSection == pillSectionTodos && hasIncomplete {
@@ -232,6 +306,15 @@ func (m *UI) renderPills() {
 	}
 
 	t := m.com.Styles
+	if m.accessible {
+		lines := m.accessiblePillLines()
+		for i, line := range lines {
+			lines[i] = ansi.Truncate(line, width-paddingLeft, "…")
+		}
+		m.pillsView = t.Base.PaddingLeft(paddingLeft).Render(strings.Join(lines, "\n"))
+		return
+	}
+
 	todosFocused := m.pillsExpanded && m.focusedPillSection == pillSectionTodos
 	queueFocused := m.pillsExpanded && m.focusedPillSection == pillSectionQueue
 
@@ -242,7 +325,7 @@ func (m *UI) renderPills() {
 
 	var pills []string
 	if hasIncomplete {
-		pills = append(pills, todoPill(m.session.Todos, inProgressIcon, todosFocused, m.pillsExpanded, t))
+		pills = append(pills, todoPill(m.session.Todos, inProgressIcon, m.session.PlanAwaitingApproval(), todosFocused, m.pillsExpanded, t))
 	}
 	if hasQueue {
 		pills = append(pills, queuePill(m.promptQueue, queueFocused, m.pillsExpanded, t))
@@ -274,6 +357,11 @@ func (m *UI) renderPills() {
 	helpText := t.Pills.HelpText.Render(helpDesc)
 	helpHint := lipgloss.JoinHorizontal(lipgloss.Center, helpKey, " ", helpText)
 	pillsRow = lipgloss.JoinHorizontal(lipgloss.Center, pillsRow, " ", helpHint)
+	if m.session.PlanAwaitingApproval() {
+		planKey := t.Pills.HelpKey.Render("ctrl+b")
+		planText := t.Pills.HelpText.Render("审阅计划")
+		pillsRow = lipgloss.JoinHorizontal(lipgloss.Center, pillsRow, "  ", planKey, " ", planText)
+	}
 
 	pillsArea := pillsRow
 	if expandedList != "" {
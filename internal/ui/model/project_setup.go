@@ -0,0 +1,87 @@
+package model
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/purpose168/crush-cn/internal/projectsetup"
+	"github.com/purpose168/crush-cn/internal/ui/dialog"
+	"github.com/purpose168/crush-cn/internal/ui/util"
+)
+
+// projectSetupMsg 携带检测到的项目设置建议。manual 为 true 表示由用户从命令面板触发。
+type projectSetupMsg struct {
+	suggestions []projectsetup.Suggestion
+	manual      bool
+}
+
+// detectProjectSetup 返回检查工作区并生成项目设置建议的命令。
+func (m *UI) detectProjectSetup(manual bool) tea.Cmd {
+	cfg := m.com.Config()
+	return func() tea.Msg {
+		if !manual && !projectsetup.NeedsSetup(cfg) {
+			return nil
+		}
+		return projectSetupMsg{suggestions: projectsetup.Detect(cfg), manual: manual}
+	}
+}
+
+// handleProjectSetup 打开项目设置建议对话框。启动时自动检测到的建议只提示一次，
+// 没有建议时直接标记为已完成。
+func (m *UI) handleProjectSetup(msg projectSetupMsg) tea.Cmd {
+	if len(msg.suggestions) == 0 {
+		if msg.manual {
+			return util.ReportInfo("没有新的项目设置建议")
+		}
+		return m.markProjectSetupDone
+	}
+	if !msg.manual && m.hasSession() {
+		// 用户已经开始或打开了会话，下次启动时再提示
+		return nil
+	}
+	m.dialog.CloseDialog(dialog.ProjectSetupID)
+	m.dialog.OpenDialog(dialog.NewProjectSetup(m.com, msg.suggestions))
+	return nil
+}
+
+// applyProjectSetup 将选中的建议写入项目配置，并重建智能体工具、启动新启用的 LSP 服务器。
+func (m *UI) applyProjectSetup(suggestions []projectsetup.Suggestion) tea.Cmd {
+	m.dialog.CloseDialog(dialog.ProjectSetupID)
+	cfg := m.com.Config()
+	if err := projectsetup.Apply(cfg, suggestions); err != nil {
+		return util.ReportError(fmt.Errorf("保存项目配置失败: %w", err))
+	}
+	if m.com.App.LSPManager != nil {
+		for _, s := range suggestions {
+			if s.Kind == projectsetup.EnableLSP {
+				m.com.App.LSPManager.Configure(s.Name, s.LSP)
+			}
+		}
+	}
+	cfg.SetupAgents()
+	return tea.Batch(
+		m.markProjectSetupDone,
+		func() tea.Msg {
+			if err := m.com.App.UpdateAgentModel(context.Background()); err != nil {
+				return util.ReportError(err)()
+			}
+			return util.NewInfoMsg(fmt.Sprintf("已应用 %d 条项目设置建议", len(suggestions)))
+		},
+	)
+}
+
+// skipProjectSetup 关闭项目设置建议对话框，之后启动时不再提示。
+func (m *UI) skipProjectSetup() tea.Cmd {
+	m.dialog.CloseDialog(dialog.ProjectSetupID)
+	return m.markProjectSetupDone
+}
+
+// markProjectSetupDone 标记项目已完成一次性设置。
+func (m *UI) markProjectSetupDone() tea.Msg {
+	if err := projectsetup.MarkDone(m.com.Config()); err != nil {
+		slog.Error("标记项目设置完成失败", "error", err)
+	}
+	return nil
+}
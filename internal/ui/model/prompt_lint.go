@@ -0,0 +1,57 @@
+package model
+
+import (
+	"context"
+	"log/slog"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/purpose168/crush-cn/internal/message"
+	"github.com/purpose168/crush-cn/internal/promptlint"
+	"github.com/purpose168/crush-cn/internal/ui/dialog"
+)
+
+// promptEnhancedMsg 携带小模型改写后的提示。
+type promptEnhancedMsg struct {
+	rewritten string
+	err       error
+}
+
+// lintPrompt 在启用提示检查且提示含糊时打开提示建议对话框，返回是否需要等待用户选择。
+// 待发送的提示由对话框保存，用户确认后重新发送。
+func (m *UI) lintPrompt(content string, attachments []message.Attachment) bool {
+	if !m.com.Config().Options.PromptLint {
+		return false
+	}
+	issues := promptlint.Lint(content, promptlint.Context{
+		HasHistory:     m.hasSession() && m.chat.Len() > 0,
+		HasAttachments: len(attachments) > 0,
+	})
+	if len(issues) == 0 {
+		return false
+	}
+	m.dialog.OpenDialog(dialog.NewPromptLint(m.com, content, attachments, issues))
+	return true
+}
+
+// enhancePrompt 在后台让小模型结合当前会话改写提示。
+func (m *UI) enhancePrompt(content string) tea.Cmd {
+	var sessionID string
+	if m.hasSession() {
+		sessionID = m.session.ID
+	}
+	enhance := func() tea.Msg {
+		rewritten, err := m.com.App.AgentCoordinator.EnhancePrompt(context.Background(), sessionID, content)
+		if err != nil {
+			slog.Error("改写提示失败", "error", err)
+		}
+		return promptEnhancedMsg{rewritten: rewritten, err: err}
+	}
+	return tea.Batch(m.dialog.StartLoading(), enhance)
+}
+
+// handlePromptEnhanced 将改写结果交给仍然打开的提示建议对话框。
+func (m *UI) handlePromptEnhanced(msg promptEnhancedMsg) {
+	if d, ok := m.dialog.Dialog(dialog.PromptLintID).(*dialog.PromptLint); ok {
+		d.SetRewritten(msg.rewritten, msg.err)
+	}
+}
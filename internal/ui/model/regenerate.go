@@ -0,0 +1,176 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"slices"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/purpose168/crush-cn/internal/agent"
+	"github.com/purpose168/crush-cn/internal/config"
+	"github.com/purpose168/crush-cn/internal/message"
+	"github.com/purpose168/crush-cn/internal/permission"
+	"github.com/purpose168/crush-cn/internal/session"
+	"github.com/purpose168/crush-cn/internal/ui/dialog"
+	"github.com/purpose168/crush-cn/internal/ui/util"
+)
+
+// openRegenerateDialog 打开重新生成回复的模型选择对话框，列出当前模型和最近使用的大模型。
+func (m *UI) openRegenerateDialog() tea.Cmd {
+	if m.dialog.ContainsDialog(dialog.RegenerateID) {
+		// 带到前面
+		m.dialog.BringToFront(dialog.RegenerateID)
+		return nil
+	}
+	if !m.hasSession() {
+		return util.ReportWarn("没有活动会话")
+	}
+	if m.isAgentBusy() {
+		return util.ReportWarn("智能体忙碌，请等待...")
+	}
+
+	cfg := m.com.Config()
+	current := cfg.Models[config.SelectedModelTypeLarge]
+	models := []dialog.RegenerateModel{m.regenerateModel(current, true)}
+	for _, recent := range cfg.RecentModels[config.SelectedModelTypeLarge] {
+		if sameModel(recent, current) {
+			continue
+		}
+		if _, ok := cfg.Providers.Get(recent.Provider); !ok {
+			continue
+		}
+		models = append(models, m.regenerateModel(recent, false))
+	}
+	m.dialog.OpenDialog(dialog.NewRegenerate(m.com, m.session.ID, models))
+	return nil
+}
+
+// regenerateModel 返回带显示名称的可选模型
+func (m *UI) regenerateModel(model config.SelectedModel, current bool) dialog.RegenerateModel {
+	entry := dialog.RegenerateModel{
+		Model:    model,
+		Name:     m.modelName(model.Provider, model.Model),
+		Provider: model.Provider,
+		Current:  current,
+	}
+	if p, ok := m.com.Config().Providers.Get(model.Provider); ok && p.Name != "" {
+		entry.Provider = p.Name
+	}
+	return entry
+}
+
+// modelName 返回模型的显示名称，找不到模型时返回模型 ID
+func (m *UI) modelName(provider, model string) string {
+	if info := m.com.Config().GetModel(provider, model); info != nil && info.Name != "" {
+		return info.Name
+	}
+	return model
+}
+
+func sameModel(a, b config.SelectedModel) bool {
+	return a.Provider == b.Provider && a.Model == b.Model
+}
+
+// regenerate 重新生成会话的最后一轮回复。选择了其他模型时先将其设为大模型。
+func (m *UI) regenerate(msg dialog.ActionRegenerate) tea.Cmd {
+	cfg := m.com.Config()
+	switchModel := msg.Model != nil && !sameModel(*msg.Model, cfg.Models[config.SelectedModelTypeLarge])
+	if switchModel {
+		if m.isAnyAgentBusy() {
+			return util.ReportWarn("智能体忙碌，请等待后再切换模型...")
+		}
+		if err := cfg.UpdatePreferredModel(config.SelectedModelTypeLarge, *msg.Model); err != nil {
+			return util.ReportError(err)
+		}
+	}
+	return func() tea.Msg {
+		if switchModel {
+			if err := m.com.App.UpdateAgentModel(context.Background()); err != nil {
+				return util.ReportError(err)()
+			}
+		}
+		_, err := m.com.App.AgentCoordinator.Regenerate(context.Background(), msg.SessionID)
+		if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, permission.ErrorPermissionDenied) {
+			return nil
+		}
+		return util.InfoMsg{
+			Type: util.InfoTypeError,
+			Msg:  err.Error(),
+		}
+	}
+}
+
+// openReplyVariantsDialog 打开比较最后一轮对话各个回复版本的对话框
+func (m *UI) openReplyVariantsDialog() tea.Cmd {
+	if m.dialog.ContainsDialog(dialog.ReplyVariantsID) {
+		// 带到前面
+		m.dialog.BringToFront(dialog.ReplyVariantsID)
+		return nil
+	}
+	if !m.hasSession() {
+		return util.ReportWarn("没有活动会话")
+	}
+	if len(m.session.Variants) == 0 {
+		return util.ReportInfo("没有其他回复版本，在聊天中按 R 重新生成回复")
+	}
+
+	msgs, err := m.com.App.Messages.List(context.Background(), m.session.ID)
+	if err != nil {
+		return util.ReportError(err)
+	}
+	var variants []dialog.ReplyVariant
+	if current, _, ok := agent.LastTurn(*m.session, msgs); ok {
+		variants = append(variants, m.replyVariant(current, -1))
+	}
+	for i, v := range slices.Backward(m.session.Variants) {
+		variants = append(variants, m.replyVariant(v, i))
+	}
+	m.dialog.OpenDialog(dialog.NewReplyVariants(m.com, m.session.ID, variants))
+	return nil
+}
+
+func (m *UI) replyVariant(v session.Variant, index int) dialog.ReplyVariant {
+	return dialog.ReplyVariant{
+		Variant:   v,
+		Index:     index,
+		ModelName: m.modelName(v.Provider, v.Model),
+	}
+}
+
+// keepVariant 将最后一轮对话换成选中的回复版本
+func (m *UI) keepVariant(msg dialog.ActionKeepVariant) tea.Cmd {
+	return func() tea.Msg {
+		if err := m.com.App.AgentCoordinator.KeepVariant(context.Background(), msg.SessionID, msg.Index); err != nil {
+			return util.ReportError(err)()
+		}
+		return util.NewInfoMsg("已切换回复版本")
+	}
+}
+
+// deleteVariant 删除选中的回复版本
+func (m *UI) deleteVariant(msg dialog.ActionDeleteVariant) tea.Cmd {
+	return func() tea.Msg {
+		if err := m.com.App.AgentCoordinator.DeleteVariant(context.Background(), msg.SessionID, msg.Index); err != nil {
+			return util.ReportError(err)()
+		}
+		return nil
+	}
+}
+
+// visibleMessages 去掉备选回复版本中的消息，它们不属于当前对话
+func (m *UI) visibleMessages(msgs []message.Message) []message.Message {
+	if !m.hasSession() {
+		return msgs
+	}
+	hidden := m.session.HiddenMessages()
+	if len(hidden) == 0 {
+		return msgs
+	}
+	return slices.DeleteFunc(slices.Clone(msgs), func(msg message.Message) bool { return hidden[msg.ID] })
+}
+
+// variantsChanged 报告会话更新后聊天中显示的消息是否因回复版本变化而改变
+func variantsChanged(prev, next session.Session) bool {
+	return !maps.Equal(prev.HiddenMessages(), next.HiddenMessages())
+}
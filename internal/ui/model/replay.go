@@ -0,0 +1,220 @@
+package model
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"charm.land/bubbles/v2/key"
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+	"github.com/purpose168/crush-cn/internal/message"
+	"github.com/purpose168/crush-cn/internal/ui/util"
+)
+
+const (
+	// replayMinDelay 和 replayMaxDelay 限制自动播放时两条消息之间的间隔，
+	// 间隔按消息的原始时间差计算
+	replayMinDelay = 300 * time.Millisecond
+	replayMaxDelay = 3 * time.Second
+)
+
+// replayState 是会话回放模式的状态。回放按消息逐步重现会话，
+// 每一步只渲染截至当前消息的聊天内容
+type replayState struct {
+	sessionID string
+	msgs      []message.Message
+	// step 是当前显示的消息数
+	step    int
+	playing bool
+	// tick 标识当前的播放计时器，用于丢弃暂停或跳转后过期的计时消息
+	tick int
+}
+
+// replayLoadedMsg 表示回放的会话消息已加载
+type replayLoadedMsg struct {
+	sessionID string
+	msgs      []message.Message
+}
+
+// replayTickMsg 推进自动播放
+type replayTickMsg struct {
+	tick int
+}
+
+// startReplay 加载会话消息并进入回放模式
+func (m *UI) startReplay(sessionID string) tea.Cmd {
+	if m.isAgentBusy() {
+		return util.ReportWarn("智能体忙碌，请等待后再回放会话...")
+	}
+	return func() tea.Msg {
+		msgs, err := m.com.App.Messages.List(context.Background(), sessionID)
+		if err != nil {
+			return util.ReportError(err)()
+		}
+		return replayLoadedMsg{sessionID: sessionID, msgs: msgs}
+	}
+}
+
+// handleReplayLoaded 在消息加载后进入回放模式并显示第一条消息
+func (m *UI) handleReplayLoaded(msg replayLoadedMsg) tea.Cmd {
+	if !m.hasSession() || msg.sessionID != m.session.ID {
+		return nil
+	}
+	msgs := m.visibleMessages(msg.msgs)
+	if len(msgs) == 0 {
+		return util.ReportInfo("会话中没有可回放的消息")
+	}
+	m.replay = &replayState{sessionID: msg.sessionID, msgs: msgs}
+	m.textarea.Blur()
+	m.focus = uiFocusMain
+	m.chat.Focus()
+	return m.setReplayStep(1)
+}
+
+// stopReplay 退出回放模式并恢复完整的聊天内容
+func (m *UI) stopReplay() tea.Cmd {
+	if m.replay == nil {
+		return nil
+	}
+	m.replay = nil
+	if !m.hasSession() {
+		return nil
+	}
+	msgs, err := m.com.App.Messages.List(context.Background(), m.session.ID)
+	if err != nil {
+		return util.ReportError(err)
+	}
+	return m.setSessionMessages(msgs)
+}
+
+// setReplayStep 渲染截至第 step 条消息的聊天内容
+func (m *UI) setReplayStep(step int) tea.Cmd {
+	r := m.replay
+	r.step = min(max(step, 1), len(r.msgs))
+	if r.step == len(r.msgs) {
+		r.playing = false
+	}
+
+	// 工具结果只在对应消息出现后链接，未完成的工具调用保持静态以免启动动画
+	items := m.sessionMessageItems(r.msgs[:r.step])
+	m.chat.SetMessages(items...)
+	m.chat.SelectLast()
+	return m.chat.ScrollToBottomAndAnimate()
+}
+
+// replayDelay 返回自动播放时从当前消息前进到下一条消息的等待时间
+func (r *replayState) replayDelay() time.Duration {
+	if r.step >= len(r.msgs) {
+		return replayMinDelay
+	}
+	delta := time.Duration(r.msgs[r.step].CreatedAt-r.msgs[r.step-1].CreatedAt) * time.Second
+	return min(max(delta, replayMinDelay), replayMaxDelay)
+}
+
+// replayTickCmd 安排下一次自动播放
+func (m *UI) replayTickCmd() tea.Cmd {
+	r := m.replay
+	r.tick++
+	tick := r.tick
+	return tea.Tick(r.replayDelay(), func(time.Time) tea.Msg {
+		return replayTickMsg{tick: tick}
+	})
+}
+
+// handleReplayTick 在自动播放时前进一条消息
+func (m *UI) handleReplayTick(msg replayTickMsg) tea.Cmd {
+	r := m.replay
+	if r == nil || !r.playing || msg.tick != r.tick {
+		return nil
+	}
+	cmd := m.setReplayStep(r.step + 1)
+	if !r.playing {
+		return cmd
+	}
+	return tea.Batch(cmd, m.replayTickCmd())
+}
+
+// handleReplayKey 处理回放模式中的按键
+func (m *UI) handleReplayKey(msg tea.KeyPressMsg) tea.Cmd {
+	r := m.replay
+	k := &m.keyMap.Replay
+	switch {
+	case key.Matches(msg, k.Exit):
+		return m.stopReplay()
+	case key.Matches(msg, k.Play):
+		if r.playing {
+			r.playing = false
+			return nil
+		}
+		if r.step == len(r.msgs) {
+			// 在结尾处重新从头播放
+			r.step = 1
+		}
+		r.playing = true
+		return tea.Batch(m.setReplayStep(r.step), m.replayTickCmd())
+	case key.Matches(msg, k.Prev):
+		r.playing = false
+		return m.setReplayStep(r.step - 1)
+	case key.Matches(msg, k.Next):
+		r.playing = false
+		return m.setReplayStep(r.step + 1)
+	case key.Matches(msg, k.First):
+		r.playing = false
+		return m.setReplayStep(1)
+	case key.Matches(msg, k.Last):
+		r.playing = false
+		return m.setReplayStep(len(r.msgs))
+	case key.Matches(msg, m.keyMap.Chat.Up):
+		return m.chat.ScrollByAndAnimate(-1)
+	case key.Matches(msg, m.keyMap.Chat.Down):
+		return m.chat.ScrollByAndAnimate(1)
+	}
+	return nil
+}
+
+// replayView 渲染替代编辑器显示的回放进度栏
+func (m *UI) replayView(width int) string {
+	r := m.replay
+	t := m.com.Styles
+	current := r.msgs[r.step-1]
+	first := r.msgs[0]
+
+	state := "⏸ 已暂停"
+	if r.playing {
+		state = "▶ 播放中"
+	}
+	elapsed := time.Duration(current.CreatedAt-first.CreatedAt) * time.Second
+	info := fmt.Sprintf("%s  %d/%d  %s  %s  +%s",
+		state,
+		r.step, len(r.msgs),
+		replayRoleLabel(current.Role),
+		time.Unix(current.CreatedAt, 0).Format("2006-01-02 15:04:05"),
+		elapsed,
+	)
+
+	barWidth := max(width-2, 1)
+	filled := barWidth * r.step / len(r.msgs)
+	bar := t.Base.Render(strings.Repeat("━", filled)) + t.Subtle.Render(strings.Repeat("─", barWidth-filled))
+
+	return lipgloss.NewStyle().Padding(0, 1).Render(lipgloss.JoinVertical(
+		lipgloss.Left,
+		t.Base.Render("会话回放"),
+		t.Muted.Render(info),
+		bar,
+	))
+}
+
+// replayRoleLabel 返回消息角色的显示名称
+func replayRoleLabel(role message.MessageRole) string {
+	switch role {
+	case message.User:
+		return "用户"
+	case message.Assistant:
+		return "助手"
+	case message.Tool:
+		return "工具结果"
+	}
+	return string(role)
+}
@@ -0,0 +1,87 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/purpose168/crush-cn/internal/permission"
+	"github.com/purpose168/crush-cn/internal/session"
+	"github.com/purpose168/crush-cn/internal/ui/dialog"
+	"github.com/purpose168/crush-cn/internal/ui/util"
+)
+
+// interruptedRunMsg 携带上次退出时被中断的运行及其会话。
+type interruptedRunMsg struct {
+	session session.Session
+	run     session.Run
+}
+
+// checkInterruptedRun 返回查找上次退出时智能体仍在运行的会话的命令
+func (m *UI) checkInterruptedRun() tea.Cmd {
+	return func() tea.Msg {
+		ctx := context.Background()
+		run, ok, err := m.com.App.Sessions.InterruptedRun(ctx)
+		if err != nil {
+			slog.Error("查找被中断的运行失败", "error", err)
+			return nil
+		}
+		if !ok {
+			return nil
+		}
+		sess, err := m.com.App.Sessions.Get(ctx, run.SessionID)
+		if err != nil {
+			slog.Error("加载被中断的会话失败", "session_id", run.SessionID, "error", err)
+			return nil
+		}
+		return interruptedRunMsg{session: sess, run: run}
+	}
+}
+
+// openResumeDialog 打开恢复被中断运行的对话框
+func (m *UI) openResumeDialog(sess session.Session, run session.Run) {
+	m.dialog.CloseDialog(dialog.ResumeID)
+	m.dialog.OpenDialog(dialog.NewResume(m.com, sess, run))
+}
+
+// resumeSession 打开被中断的会话。restart 为 true 时依次重新运行被中断的提示和
+// 排队的提示，否则只删除运行记录。
+func (m *UI) resumeSession(sess session.Session, run session.Run, restart bool) tea.Cmd {
+	cmds := []tea.Cmd{m.openSessionInTab(sess.ID, sess.Title)}
+	if !restart {
+		return tea.Batch(append(cmds, m.discardRun(run.SessionID))...)
+	}
+	if m.com.App.AgentCoordinator == nil {
+		return tea.Batch(append(cmds, util.ReportError(errors.New("编码器智能体未初始化")))...)
+	}
+	prompts := append([]string{run.Prompt}, run.Queued...)
+	cmds = append(cmds, func() tea.Msg {
+		// 逐条运行，与排队的提示在原来的运行中的执行顺序相同
+		for _, prompt := range prompts {
+			_, err := m.com.App.AgentCoordinator.Run(context.Background(), run.SessionID, prompt)
+			if err == nil {
+				continue
+			}
+			if errors.Is(err, context.Canceled) || errors.Is(err, permission.ErrorPermissionDenied) {
+				return nil
+			}
+			return util.InfoMsg{
+				Type: util.InfoTypeError,
+				Msg:  err.Error(),
+			}
+		}
+		return nil
+	})
+	return tea.Batch(cmds...)
+}
+
+// discardRun 返回删除会话运行记录的命令，下次启动时不再提示恢复
+func (m *UI) discardRun(sessionID string) tea.Cmd {
+	return func() tea.Msg {
+		if err := m.com.App.Sessions.FinishRun(context.Background(), sessionID); err != nil {
+			slog.Error("删除运行记录失败", "session_id", sessionID, "error", err)
+		}
+		return nil
+	}
+}
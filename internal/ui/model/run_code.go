@@ -0,0 +1,56 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/purpose168/crush-cn/internal/codeblock"
+	"github.com/purpose168/crush-cn/internal/permission"
+	"github.com/purpose168/crush-cn/internal/ui/dialog"
+	"github.com/purpose168/crush-cn/internal/ui/util"
+)
+
+// codeBlockRunMsg 是运行代码块结束后的结果。
+type codeBlockRunMsg struct {
+	err error
+}
+
+// runSelectedCodeBlocks 运行选中助手消息中的代码块。只有一个代码块时直接运行，
+// 有多个时打开对话框选择要运行的代码块。
+func (m *UI) runSelectedCodeBlocks() tea.Cmd {
+	blocks := m.chat.SelectedCodeBlocks()
+	switch len(blocks) {
+	case 0:
+		return util.ReportWarn("选中的消息中没有可运行的代码块（支持 sh、python 和 node）")
+	case 1:
+		return m.runCodeBlock(blocks[0])
+	}
+	m.dialog.OpenDialog(dialog.NewCodeBlocks(m.com, blocks))
+	return nil
+}
+
+// runCodeBlock 在当前会话中通过 bash 工具运行代码块。运行需要与模型发起的命令相同的权限，
+// 输出作为工具结果追加到会话中。
+func (m *UI) runCodeBlock(block codeblock.Block) tea.Cmd {
+	if !m.hasSession() || m.com.App == nil || m.com.App.AgentCoordinator == nil {
+		return nil
+	}
+	if m.isAgentBusy() {
+		return util.ReportWarn("智能体忙碌，请等待后再运行代码块...")
+	}
+	sessionID := m.session.ID
+	description := fmt.Sprintf("运行 %s 代码块", block.Language)
+	return func() tea.Msg {
+		err := m.com.App.AgentCoordinator.RunCommand(context.Background(), sessionID, description, block.Command())
+		if errors.Is(err, permission.ErrorPermissionDenied) || errors.Is(err, context.Canceled) {
+			return codeBlockRunMsg{}
+		}
+		if err != nil {
+			slog.Error("运行代码块失败", "error", err)
+		}
+		return codeBlockRunMsg{err: err}
+	}
+}
@@ -0,0 +1,65 @@
+package model
+
+import (
+	"fmt"
+	"image/color"
+	"log/slog"
+	"os"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/alecthomas/chroma/v2"
+	chromastyles "github.com/alecthomas/chroma/v2/styles"
+	fimage "github.com/purpose168/crush-cn/internal/ui/image"
+	"github.com/purpose168/crush-cn/internal/ui/util"
+)
+
+// exportSelectionImage 将选中的文本按当前主题渲染为代码卡片图片并写入临时文件，
+// toClipboard 为 true 时同时把图片复制到剪贴板。
+func (m *UI) exportSelectionImage(content string, toClipboard bool) tea.Cmd {
+	m.selection = ""
+	t := m.com.Styles
+	style, err := chroma.MustNewStyle("crush", t.ChromaTheme()).Builder().
+		Add(chroma.Background, fmt.Sprintf("bg:%s %s", hexColor(t.BgBase), hexColor(t.FgBase))).
+		Build()
+	if err != nil {
+		style = chromastyles.Fallback
+	}
+	card := fimage.CodeCard{
+		Style:  style,
+		Margin: t.Primary,
+		Title:  "Crush",
+	}
+
+	return func() tea.Msg {
+		data, err := card.Render(content)
+		if err != nil {
+			return util.NewErrorMsg(fmt.Errorf("渲染图片失败: %w", err))
+		}
+		f, err := os.CreateTemp("", "crush-snippet-*.png")
+		if err != nil {
+			return util.NewErrorMsg(fmt.Errorf("创建图片文件失败: %w", err))
+		}
+		_, err = f.Write(data)
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return util.NewErrorMsg(fmt.Errorf("写入图片文件失败: %w", err))
+		}
+
+		if !toClipboard {
+			return util.NewInfoMsg("图片已保存到 " + f.Name())
+		}
+		if err := writeClipboard(clipboardFormatImage, data); err != nil {
+			slog.Warn("复制图片到剪贴板失败", "error", err)
+			return util.NewWarnMsg(fmt.Sprintf("图片已保存到 %s，但无法复制到剪贴板", f.Name()))
+		}
+		return util.NewInfoMsg(fmt.Sprintf("图片已保存到 %s 并复制到剪贴板", f.Name()))
+	}
+}
+
+// hexColor 返回 c 的 #rrggbb 表示。
+func hexColor(c color.Color) string {
+	r, g, b, _ := c.RGBA()
+	return fmt.Sprintf("#%02x%02x%02x", r>>8, g>>8, b>>8)
+}
@@ -4,9 +4,11 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"maps"
 	"path/filepath"
 	"slices"
 	"strings"
+	"time"
 
 	tea "charm.land/bubbletea/v2"
 	"charm.land/lipgloss/v2"
@@ -168,15 +170,44 @@ func (m *UI) filesInfo(cwd string, width, maxItems int, isSection bool) string {
 		}
 		filesWithChanges = append(filesWithChanges, f)
 	}
+	var pinned []string
+	if m.session != nil {
+		pinned = m.session.PinnedFiles
+	}
 	if len(filesWithChanges) > 0 {
-		list = fileList(t, cwd, filesWithChanges, width, maxItems)
+		list = fileList(t, cwd, filesWithChanges, pinned, width, maxItems)
+	}
+	if len(pinned) > 0 {
+		list += "\n" + t.Subtle.Render(fmt.Sprintf("%s 已固定 %d 个文件", styles.PinIcon, len(pinned)))
 	}
 
 	return lipgloss.NewStyle().Width(width).Render(fmt.Sprintf("%s\n\n%s", title, list))
 }
 
+// envInfo 为侧边栏渲染会话环境变量部分，最多显示 maxItems 项。会话没有设置环境变量时返回空字符串。
+func (m *UI) envInfo(width, maxItems int) string {
+	if m.session == nil || len(m.session.Env) == 0 {
+		return ""
+	}
+	t := m.com.Styles
+
+	keys := slices.Sorted(maps.Keys(m.session.Env))
+	lines := make([]string, 0, min(len(keys), maxItems)+1)
+	for _, key := range keys[:min(len(keys), maxItems)] {
+		line := t.Files.Path.Render(key) + t.Subtle.Render("="+m.session.Env[key])
+		lines = append(lines, ansi.Truncate(line, width, "…"))
+	}
+	if len(keys) > maxItems {
+		lines = append(lines, t.Subtle.Render(fmt.Sprintf("以及其余 %d 项", len(keys)-maxItems)))
+	}
+
+	title := common.Section(t, "环境变量", width)
+	return lipgloss.NewStyle().Width(width).Render(title + "\n\n" + strings.Join(lines, "\n"))
+}
+
 // fileList 渲染带有差异统计的文件列表，截断至maxItems并在需要时显示"...以及其余N项"消息。
-func fileList(t *styles.Styles, cwd string, filesWithChanges []SessionFile, width, maxItems int) string {
+// 固定的文件以图标标记。
+func fileList(t *styles.Styles, cwd string, filesWithChanges []SessionFile, pinned []string, width, maxItems int) string {
 	if maxItems <= 0 {
 		return ""
 	}
@@ -205,6 +236,9 @@ func fileList(t *styles.Styles, cwd string, filesWithChanges []SessionFile, widt
 			filePath = rel
 		}
 		filePath = fsext.DirTrim(filePath, 2)
+		if slices.Contains(pinned, f.FirstVersion.Path) {
+			filePath = styles.PinIcon + " " + filePath
+		}
 		filePath = ansi.Truncate(filePath, width-(lipgloss.Width(extraContent)-2), "…")
 
 		line := t.Files.Path.Render(filePath)
@@ -224,6 +258,64 @@ func fileList(t *styles.Styles, cwd string, filesWithChanges []SessionFile, widt
 	return lipgloss.JoinVertical(lipgloss.Left, renderedFiles...)
 }
 
+// pinnableFiles 返回当前会话中可固定的文件：已修改的文件在前，随后是已读取的文件。
+func (m *UI) pinnableFiles() ([]string, error) {
+	paths := make([]string, 0, len(m.sessionFiles))
+	for _, f := range m.sessionFiles {
+		paths = append(paths, f.LatestVersion.Path)
+	}
+	readFiles, err := m.com.App.FileTracker.ListReadFiles(context.Background(), m.session.ID)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range readFiles {
+		if !slices.Contains(paths, p) {
+			paths = append(paths, p)
+		}
+	}
+	return paths, nil
+}
+
+// setPinnedFiles 返回更新会话固定文件列表的命令。更新后的会话通过会话事件同步到界面。
+func (m *UI) setPinnedFiles(sessionID string, paths []string) tea.Cmd {
+	return func() tea.Msg {
+		if _, err := m.com.App.Sessions.SetPinnedFiles(context.Background(), sessionID, paths); err != nil {
+			return util.ReportError(err)()
+		}
+		return nil
+	}
+}
+
+// createCheckpoint 返回为会话当前的文件状态创建命名检查点的命令。
+func (m *UI) createCheckpoint(sessionID, name string) tea.Cmd {
+	return func() tea.Msg {
+		cp, err := m.com.App.Checkpoints.Create(context.Background(), sessionID, name)
+		if err != nil {
+			return util.ReportError(err)()
+		}
+		return util.NewInfoMsg(fmt.Sprintf("已创建检查点「%s」（%d 个文件）", cp.Name, len(cp.Files)))
+	}
+}
+
+// restoreCheckpoint 返回将文件恢复到检查点的命令。恢复前会自动创建一个检查点，
+// 以便撤销这次恢复。
+func (m *UI) restoreCheckpoint(sessionID, id, name string) tea.Cmd {
+	return func() tea.Msg {
+		ctx := context.Background()
+		if _, err := m.com.App.Checkpoints.Create(ctx, sessionID, fmt.Sprintf("恢复「%s」之前", name)); err != nil {
+			return util.ReportError(fmt.Errorf("创建恢复前的检查点失败: %w", err))()
+		}
+		changed, err := m.com.App.Checkpoints.Restore(ctx, id)
+		if err != nil {
+			return util.ReportError(err)()
+		}
+		if len(changed) == 0 {
+			return util.NewInfoMsg(fmt.Sprintf("文件已处于检查点「%s」的状态", name))
+		}
+		return util.NewInfoMsg(fmt.Sprintf("已将 %d 个文件恢复到检查点「%s」", len(changed), name))
+	}
+}
+
 // startLSPs 为给定的文件路径启动LSP服务器。
 func (m *UI) startLSPs(paths []string) tea.Cmd {
 	if len(paths) == 0 {
@@ -238,3 +330,192 @@ func (m *UI) startLSPs(paths []string) tea.Cmd {
 		return nil
 	}
 }
+
+// redactionReport 返回报告会话中已脱敏内容统计的命令。
+func (m *UI) redactionReport(sessionID string) tea.Cmd {
+	if m.com.App.AgentCoordinator == nil {
+		return nil
+	}
+	findings := m.com.App.AgentCoordinator.RedactionReport(sessionID)
+	if len(findings) == 0 {
+		return util.ReportInfo("本会话中没有被脱敏的内容")
+	}
+	total := 0
+	parts := make([]string, 0, len(findings))
+	for _, f := range findings {
+		total += f.Count
+		parts = append(parts, fmt.Sprintf("%s×%d（%s）", f.Rule, f.Count, f.Source))
+	}
+	return util.ReportInfo(fmt.Sprintf("本会话已脱敏 %d 处：%s", total, strings.Join(parts, "、")))
+}
+
+// retentionCheckMsg 携带超出保留策略、等待用户确认清理的会话。
+type retentionCheckMsg struct {
+	sessions []session.Session
+	// manual 表示检查由用户通过命令触发，没有需要清理的会话时也会提示。
+	manual bool
+}
+
+// checkRetention 返回按配置的保留策略查找待清理会话的命令。在标签页中打开的会话和
+// 正在运行的会话不会被清理。未配置保留策略时返回 nil。
+func (m *UI) checkRetention(manual bool) tea.Cmd {
+	r := m.com.Config().Options.Retention
+	if !r.Enabled() {
+		return nil
+	}
+	policy := session.RetentionPolicy{
+		MaxSessions: r.MaxSessions,
+		MaxAge:      time.Duration(r.MaxAgeDays) * 24 * time.Hour,
+	}
+	open := make(map[string]bool, len(m.tabs)+1)
+	if m.session != nil {
+		open[m.session.ID] = true
+	}
+	for _, tab := range m.tabs {
+		open[tab.sessionID] = true
+	}
+	return func() tea.Msg {
+		sessions, err := m.com.App.Sessions.List(context.Background())
+		if err != nil {
+			return util.ReportError(err)()
+		}
+		expired := policy.Expired(sessions, time.Now(), func(s session.Session) bool {
+			return open[s.ID] || m.isSessionBusy(s.ID)
+		})
+		return retentionCheckMsg{sessions: expired, manual: manual}
+	}
+}
+
+// pruneSessions 返回删除会话及其消息和文件历史的命令。删除前仍在运行的会话会被跳过。
+func (m *UI) pruneSessions(sessions []session.Session) tea.Cmd {
+	return func() tea.Msg {
+		deleted := 0
+		for _, sess := range sessions {
+			if m.isSessionBusy(sess.ID) {
+				continue
+			}
+			if err := m.com.App.Sessions.Delete(context.Background(), sess.ID); err != nil {
+				slog.Error("清理会话失败", "session_id", sess.ID, "error", err)
+				continue
+			}
+			deleted++
+		}
+		if deleted < len(sessions) {
+			return util.NewWarnMsg(fmt.Sprintf("已清理 %d 个会话，%d 个会话未能删除", deleted, len(sessions)-deleted))
+		}
+		return util.NewInfoMsg(fmt.Sprintf("已清理 %d 个会话", deleted))
+	}
+}
+
+// isSessionBusy 报告智能体是否正在处理指定会话。
+func (m *UI) isSessionBusy(sessionID string) bool {
+	return m.com.App.AgentCoordinator != nil && m.com.App.AgentCoordinator.IsSessionBusy(sessionID)
+}
+
+// setSessionEnv 返回更新会话环境变量的命令。更新后的会话通过会话事件同步到界面。
+func (m *UI) setSessionEnv(sessionID string, env map[string]string) tea.Cmd {
+	return func() tea.Msg {
+		if _, err := m.com.App.Sessions.SetEnv(context.Background(), sessionID, env); err != nil {
+			return util.ReportError(err)()
+		}
+		if len(env) == 0 {
+			return util.NewInfoMsg("已清除会话环境变量")
+		}
+		return util.NewInfoMsg(fmt.Sprintf("已设置 %d 个会话环境变量", len(env)))
+	}
+}
+
+// sessionDir 返回当前会话的工作目录，没有会话或会话未设置工作目录时返回项目根目录。
+func (m *UI) sessionDir() string {
+	root := m.com.Config().WorkingDir()
+	if m.session == nil {
+		return root
+	}
+	return m.session.Dir(root)
+}
+
+// setTodos 返回保存用户编辑后的待办事项的命令。智能体在下一轮中会收到更新后的列表。
+func (m *UI) setTodos(sessionID string, todos []session.Todo) tea.Cmd {
+	return func() tea.Msg {
+		if _, err := m.com.App.Sessions.SetTodos(context.Background(), sessionID, todos); err != nil {
+			return util.ReportError(err)()
+		}
+		return util.NewInfoMsg("已保存待办事项")
+	}
+}
+
+// setSessionWorkingDir 返回更新会话工作目录的命令。更新后的会话通过会话事件同步到界面。
+func (m *UI) setSessionWorkingDir(sessionID, dir string) tea.Cmd {
+	return func() tea.Msg {
+		if _, err := m.com.App.Sessions.SetWorkingDir(context.Background(), sessionID, dir); err != nil {
+			return util.ReportError(err)()
+		}
+		if dir == "" {
+			return util.NewInfoMsg("会话工作目录已重置为项目根目录")
+		}
+		return util.NewInfoMsg(fmt.Sprintf("会话工作目录已设置为 %s", dir))
+	}
+}
+
+// setSessionTags 返回更新会话标签的命令。更新后的会话通过会话事件同步到界面。
+func (m *UI) setSessionTags(sessionID string, tags []string) tea.Cmd {
+	return func() tea.Msg {
+		if _, err := m.com.App.Sessions.SetTags(context.Background(), sessionID, tags); err != nil {
+			return util.ReportError(err)()
+		}
+		if len(tags) == 0 {
+			return util.NewInfoMsg("已清除会话标签")
+		}
+		return util.NewInfoMsg("会话标签已设置为 " + session.FormatTags(tags))
+	}
+}
+
+// planModeEnabled 报告当前会话（或尚未创建的新会话）是否处于计划模式。
+func (m *UI) planModeEnabled() bool {
+	if m.hasSession() {
+		return m.session.PlanMode.Enabled()
+	}
+	return m.newSessionPlanMode
+}
+
+// togglePlanMode 切换当前会话的计划模式。没有会话时在发送第一条消息创建会话后生效。
+func (m *UI) togglePlanMode() tea.Cmd {
+	mode := session.PlanModePlanning
+	if m.planModeEnabled() {
+		mode = session.PlanModeOff
+	}
+	if m.hasSession() {
+		sess, err := m.com.App.Sessions.SetPlanMode(context.Background(), m.session.ID, mode)
+		if err != nil {
+			return util.ReportError(err)
+		}
+		m.session = &sess
+	} else {
+		m.newSessionPlanMode = mode.Enabled()
+	}
+	if mode.Enabled() {
+		return util.ReportInfo("已启用计划模式：智能体会先写出计划，批准后才能修改文件或执行命令")
+	}
+	return util.ReportInfo("已禁用计划模式")
+}
+
+// approvePlan 保存用户审阅后的计划并让智能体开始执行。
+func (m *UI) approvePlan(sessionID string, todos []session.Todo, edited bool) tea.Cmd {
+	ctx := context.Background()
+	if _, err := m.com.App.Sessions.SetTodos(ctx, sessionID, todos); err != nil {
+		return util.ReportError(err)
+	}
+	sess, err := m.com.App.Sessions.SetPlanMode(ctx, sessionID, session.PlanModeApproved)
+	if err != nil {
+		return util.ReportError(err)
+	}
+	if m.session != nil && m.session.ID == sessionID {
+		m.session = &sess
+	}
+
+	prompt := "I approved the plan in the todo list. Execute it step by step."
+	if edited {
+		prompt = "I edited and approved the plan in the todo list. Execute the updated plan step by step."
+	}
+	return m.sendMessage(prompt)
+}
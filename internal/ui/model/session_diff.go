@@ -0,0 +1,63 @@
+package model
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/purpose168/crush-cn/internal/history"
+	"github.com/purpose168/crush-cn/internal/ui/dialog"
+	"github.com/purpose168/crush-cn/internal/ui/util"
+)
+
+// sessionPatchDir 是数据目录下存放导出的会话补丁的子目录名。
+const sessionPatchDir = "patches"
+
+// sessionDiffMsg 携带会话中从初始版本到最新版本的文件更改。
+type sessionDiffMsg struct {
+	sessionID string
+	changes   []history.FileChange
+}
+
+// loadSessionDiff 返回加载会话文件历史并计算全部文件更改的命令。
+func (m *UI) loadSessionDiff(sessionID string) tea.Cmd {
+	return func() tea.Msg {
+		files, err := m.com.App.History.ListBySession(context.Background(), sessionID)
+		if err != nil {
+			return util.ReportError(fmt.Errorf("加载文件历史失败: %w", err))()
+		}
+		return sessionDiffMsg{sessionID: sessionID, changes: history.SessionChanges(files)}
+	}
+}
+
+// handleSessionDiff 打开会话差异对话框，会话没有修改文件时给出提示。
+func (m *UI) handleSessionDiff(msg sessionDiffMsg) tea.Cmd {
+	if len(msg.changes) == 0 {
+		return util.ReportInfo("本会话没有修改任何文件")
+	}
+	if m.dialog.ContainsDialog(dialog.SessionDiffID) {
+		m.dialog.BringToFront(dialog.SessionDiffID)
+		return nil
+	}
+	m.dialog.OpenDialog(dialog.NewSessionDiff(m.com, msg.sessionID, msg.changes))
+	return nil
+}
+
+// exportSessionPatch 返回将会话的文件更改写入补丁文件的命令，补丁可以使用 git apply 应用。
+func (m *UI) exportSessionPatch(sessionID string, changes []history.FileChange) tea.Cmd {
+	cfg := m.com.Config()
+	dir := filepath.Join(cfg.Options.DataDirectory, sessionPatchDir)
+	patch := history.Patch(changes, cfg.WorkingDir())
+	return func() tea.Msg {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return util.ReportError(fmt.Errorf("创建补丁目录失败: %w", err))()
+		}
+		path := filepath.Join(dir, sessionID+".patch")
+		if err := os.WriteFile(path, []byte(patch), 0o644); err != nil {
+			return util.ReportError(fmt.Errorf("写入补丁文件失败: %w", err))()
+		}
+		return util.NewInfoMsg("已导出会话补丁：" + path)
+	}
+}
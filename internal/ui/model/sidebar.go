@@ -108,14 +108,17 @@ func (m *UI) drawSidebar(scr uv.Screen, area uv.Rectangle) {
 		return
 	}
 
-	const logoHeightBreakpoint = 30
+	const (
+		logoHeightBreakpoint = 30
+		maxEnvShown          = 4
+	)
 
 	t := m.com.Styles
 	width := area.Dx()
 	height := area.Dy()
 
 	title := t.Muted.Width(width).MaxHeight(2).Render(m.session.Title)
-	cwd := common.PrettyPath(t, m.com.Config().WorkingDir(), width)
+	cwd := common.PrettyPath(t, m.sessionDir(), width)
 	sidebarLogo := m.sidebarLogo
 	if height < logoHeightBreakpoint {
 		sidebarLogo = logo.SmallRender(m.com.Styles, width)
@@ -141,7 +144,11 @@ func (m *UI) drawSidebar(scr uv.Screen, area uv.Rectangle) {
 
 	lspSection := m.lspInfo(width, maxLSPs, true)
 	mcpSection := m.mcpInfo(width, maxMCPs, true)
-	filesSection := m.filesInfo(m.com.Config().WorkingDir(), width, maxFiles, true)
+	filesSection := m.filesInfo(m.sessionDir(), width, maxFiles, true)
+	envSection := m.envInfo(width, maxEnvShown)
+	if envSection != "" {
+		envSection = "\n" + envSection
+	}
 
 	uv.NewStyledString(
 		lipgloss.NewStyle().
@@ -152,6 +159,7 @@ func (m *UI) drawSidebar(scr uv.Screen, area uv.Rectangle) {
 					lipgloss.Left,
 					sidebarHeader,
 					filesSection,
+					envSection,
 					"",
 					lspSection,
 					"",
@@ -0,0 +1,40 @@
+package model
+
+import (
+	tea "charm.land/bubbletea/v2"
+	"github.com/purpose168/crush-cn/internal/snippets"
+	"github.com/purpose168/crush-cn/internal/ui/completions"
+	"github.com/purpose168/crush-cn/internal/ui/dialog"
+	"github.com/purpose168/crush-cn/internal/ui/util"
+)
+
+// insertSnippetCompletion 用选中片段的内容替换;query。
+// 片段带有占位符时先移除;query，填写参数后再插入替换后的内容。
+func (m *UI) insertSnippetCompletion(value completions.SnippetCompletionValue) {
+	if len(value.Arguments) == 0 {
+		m.insertCompletionText(value.Content)
+		return
+	}
+	m.replaceCompletionWord("")
+	m.dialog.OpenDialog(dialog.NewArguments(
+		m.com,
+		"片段参数："+value.Name,
+		"",
+		value.Arguments,
+		dialog.ActionInsertSnippet{
+			Content:   value.Content,
+			Arguments: value.Arguments,
+		},
+	))
+}
+
+// saveSnippet 将内容以给定名称保存到片段库。
+func (m *UI) saveSnippet(name, content string) tea.Cmd {
+	return func() tea.Msg {
+		snippet, err := snippets.Save(snippets.Dir(), name, content)
+		if err != nil {
+			return util.ReportError(err)()
+		}
+		return util.NewInfoMsg("已保存片段 ;" + snippet.Name)
+	}
+}
@@ -1,6 +1,10 @@
 package model
 
 import (
+	"context"
+	"log/slog"
+	"os/exec"
+	"strings"
 	"time"
 
 	"charm.land/bubbles/v2/help"
@@ -9,12 +13,24 @@ import (
 	uv "github.com/charmbracelet/ultraviolet"
 	"github.com/charmbracelet/x/ansi"
 	"github.com/purpose168/crush-cn/internal/ui/common"
+	"github.com/purpose168/crush-cn/internal/ui/model/status"
 	"github.com/purpose168/crush-cn/internal/ui/util"
 )
 
 // DefaultStatusTTL 是状态消息的默认生存时间。
 const DefaultStatusTTL = 5 * time.Second
 
+// updateStatusTTL 是新版本提示的生存时间。
+const updateStatusTTL = 30 * time.Second
+
+// statusWidgetsInterval 是状态栏小部件的刷新间隔，用于更新时钟和 git 分支。
+const statusWidgetsInterval = 10 * time.Second
+
+// statusWidgetsTickMsg 在状态栏小部件需要刷新时发送。
+type statusWidgetsTickMsg struct {
+	branch string
+}
+
 // Status 是状态栏和帮助模型。
 type Status struct {
 	com      *common.Common
@@ -22,6 +38,10 @@ type Status struct {
 	help     help.Model
 	helpKm   help.KeyMap
 	msg      util.InfoMsg
+
+	widgets    []status.Widget
+	widgetInfo status.Info
+	branch     string
 }
 
 // NewStatus 创建一个新的状态栏和帮助模型。
@@ -31,9 +51,45 @@ func NewStatus(com *common.Common, km help.KeyMap) *Status {
 	s.help = help.New()
 	s.help.Styles = com.Styles.Help
 	s.helpKm = km
+
+	widgets, unknown := status.Resolve(com.Config().Options.TUI.StatusBar)
+	if len(unknown) > 0 {
+		slog.Warn("忽略未知的状态栏小部件", "widgets", unknown)
+	}
+	s.widgets = widgets
 	return s
 }
 
+// SetWidgetInfo 设置渲染状态栏小部件所需的会话和模型状态。
+func (s *Status) SetWidgetInfo(info status.Info) {
+	s.widgetInfo = info
+}
+
+// SetBranch 设置 git 分支小部件显示的分支。
+func (s *Status) SetBranch(branch string) {
+	s.branch = branch
+}
+
+// RefreshWidgets 返回一个命令，在 delay 之后读取 git 分支并请求重绘状态栏小部件。
+// 未启用任何小部件时返回 nil。
+func (s *Status) RefreshWidgets(delay time.Duration) tea.Cmd {
+	if len(s.widgets) == 0 {
+		return nil
+	}
+	wantBranch := false
+	for _, w := range s.widgets {
+		wantBranch = wantBranch || w.Name() == status.GitBranch
+	}
+	dir := s.com.Config().WorkingDir()
+	return tea.Tick(delay, func(time.Time) tea.Msg {
+		var msg statusWidgetsTickMsg
+		if wantBranch {
+			msg.branch = currentGitBranch(dir)
+		}
+		return msg
+	})
+}
+
 // SetInfoMsg 设置状态信息消息。
 func (s *Status) SetInfoMsg(msg util.InfoMsg) {
 	s.msg = msg
@@ -67,8 +123,18 @@ func (s *Status) SetHideHelp(hideHelp bool) {
 // Draw 将状态栏绘制到屏幕上。
 func (s *Status) Draw(scr uv.Screen, area uv.Rectangle) {
 	if !s.hideHelp {
+		helpArea := area
+		if bar := s.widgetsView(area.Dx() / 2); bar != "" {
+			barWidth := lipgloss.Width(bar)
+			helpArea.Max.X -= barWidth
+			barArea := area
+			barArea.Min.X = helpArea.Max.X
+			barArea.Max.Y = barArea.Min.Y + 1
+			uv.NewStyledString(bar).Draw(scr, barArea)
+		}
+		s.help.SetWidth(helpArea.Dx())
 		helpView := s.com.Styles.Status.Help.Render(s.help.View(s.helpKm))
-		uv.NewStyledString(helpView).Draw(scr, area)
+		uv.NewStyledString(helpView).Draw(scr, helpArea)
 	}
 
 	// 渲染通知
@@ -76,6 +142,11 @@ func (s *Status) Draw(scr uv.Screen, area uv.Rectangle) {
 		return
 	}
 
+	if s.com.Config().Options.TUI.Accessible {
+		s.drawPlain(scr, area)
+		return
+	}
+
 	var indStyle lipgloss.Style
 	var msgStyle lipgloss.Style
 	switch s.msg.Type {
@@ -105,9 +176,82 @@ func (s *Status) Draw(scr uv.Screen, area uv.Rectangle) {
 	uv.NewStyledString(ind+info).Draw(scr, area)
 }
 
+// widgetsView 渲染不超过 maxWidth 个单元格的状态栏小部件区域，
+// 没有可见的小部件时返回空字符串。
+func (s *Status) widgetsView(maxWidth int) string {
+	if len(s.widgets) == 0 {
+		return ""
+	}
+	style := s.com.Styles.Status.Widgets
+	info := s.widgetInfo
+	info.Styles = s.com.Styles
+	info.Now = time.Now()
+	info.Branch = s.branch
+	view := status.Layout(info, s.widgets, maxWidth-style.GetHorizontalFrameSize())
+	if view == "" {
+		return ""
+	}
+	return style.Render(view)
+}
+
+// drawPlain 以带文字前缀的纯文本绘制信息消息，不使用颜色指示器，
+// 便于屏幕阅读器朗读。
+func (s *Status) drawPlain(scr uv.Screen, area uv.Rectangle) {
+	var label string
+	switch s.msg.Type {
+	case util.InfoTypeError:
+		label = "错误"
+	case util.InfoTypeWarn:
+		label = "警告"
+	case util.InfoTypeUpdate:
+		label = "更新"
+	case util.InfoTypeSuccess:
+		label = "成功"
+	default:
+		label = "信息"
+	}
+	msg := ansi.Truncate(label+": "+s.msg.Msg, area.Dx(), "…")
+	info := s.com.Styles.Base.Width(area.Dx()).Render(msg)
+	uv.NewStyledString(info).Draw(scr, area)
+}
+
 // clearInfoMsgCmd 返回一个命令，在给定的TTL之后清除信息消息。
 func clearInfoMsgCmd(ttl time.Duration) tea.Cmd {
 	return tea.Tick(ttl, func(time.Time) tea.Msg {
 		return util.ClearStatusMsg{}
 	})
 }
+
+// currentGitBranch 返回 dir 所在仓库当前的 git 分支，
+// 不在仓库中、处于分离头指针或 git 不可用时返回空字符串。
+func currentGitBranch(dir string) string {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, "git", "branch", "--show-current")
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// statusWidgetInfo 收集状态栏小部件所需的会话和模型状态。
+func (m *UI) statusWidgetInfo() status.Info {
+	var info status.Info
+	model := m.selectedLargeModel()
+	if model != nil {
+		info.Model = model.CatwalkCfg.Name
+		info.ContextWindow = model.CatwalkCfg.ContextWindow
+	}
+	info.BudgetSpent = m.budget.Spent
+	info.BudgetLimit = m.budget.Limit
+	if m.hasSession() {
+		info.HasSession = true
+		info.UsedTokens = m.session.PromptTokens + m.session.CompletionTokens
+		if m.com.App.AgentCoordinator != nil {
+			info.QueuedPrompts = m.com.App.AgentCoordinator.QueuedPrompts(m.session.ID)
+		}
+	}
+	return info
+}
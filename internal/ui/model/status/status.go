@@ -0,0 +1,125 @@
+// Package status 实现状态栏右侧的可插拔小部件及其布局。
+package status
+
+import (
+	"strings"
+	"time"
+
+	"charm.land/lipgloss/v2"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/purpose168/crush-cn/internal/ui/styles"
+)
+
+// Info 是渲染小部件时可用的状态。
+type Info struct {
+	Styles *styles.Styles
+	Now    time.Time
+
+	// Branch 是工作目录当前的 git 分支，不在仓库中或处于分离头指针时为空。
+	Branch string
+	// Model 是当前大模型的名称。
+	Model string
+	// HasSession 表示当前是否有会话，令牌用量只对会话有意义。
+	HasSession bool
+	// UsedTokens 是当前会话已使用的令牌数。
+	UsedTokens int64
+	// ContextWindow 是当前大模型的上下文窗口大小。
+	ContextWindow int64
+	// QueuedPrompts 是当前会话中排队等待的提示数。
+	QueuedPrompts int
+	// BudgetSpent 是今日已花费的费用（美元）。
+	BudgetSpent float64
+	// BudgetLimit 是每日预算（美元），0 表示未配置预算。
+	BudgetLimit float64
+}
+
+// Widget 是状态栏中的一个小部件。
+type Widget interface {
+	// Name 返回在配置中引用该小部件的名称。
+	Name() string
+	// Render 渲染小部件，返回空字符串时小部件被隐藏。
+	// 布局引擎负责测量宽度并在空间不足时截断，小部件无需关心可用宽度。
+	Render(info Info) string
+}
+
+// widgetFunc 将渲染函数适配为 [Widget]。
+type widgetFunc struct {
+	name   string
+	render func(Info) string
+}
+
+func (w widgetFunc) Name() string            { return w.name }
+func (w widgetFunc) Render(info Info) string { return w.render(info) }
+
+// NewWidget 使用给定名称和渲染函数创建一个小部件。
+func NewWidget(name string, render func(Info) string) Widget {
+	return widgetFunc{name: name, render: render}
+}
+
+// registry 保存所有可用的小部件，以名称为键。
+var registry = map[string]Widget{
+	Clock:     clockWidget,
+	GitBranch: gitBranchWidget,
+	Model:     modelWidget,
+	Tokens:    tokensWidget,
+	Queue:     queueWidget,
+	Budget:    budgetWidget,
+}
+
+// Register 注册一个小部件，同名的小部件会被替换。
+// 必须在创建 UI 之前调用。
+func Register(w Widget) {
+	registry[w.Name()] = w
+}
+
+// Lookup 按名称查找小部件。
+func Lookup(name string) (Widget, bool) {
+	w, ok := registry[name]
+	return w, ok
+}
+
+// Resolve 按给定顺序查找小部件，返回找到的小部件和未知的名称。
+func Resolve(names []string) (widgets []Widget, unknown []string) {
+	for _, name := range names {
+		w, ok := Lookup(name)
+		if !ok {
+			unknown = append(unknown, name)
+			continue
+		}
+		widgets = append(widgets, w)
+	}
+	return widgets, unknown
+}
+
+// Layout 按顺序渲染小部件并用分隔符连接，结果不超过 width 个单元格。
+// 隐藏的小部件会被跳过；放不下的小部件会被截断，其后的小部件被丢弃。
+func Layout(info Info, widgets []Widget, width int) string {
+	sep := info.Styles.Status.WidgetSeparator.Render(" • ")
+	sepWidth := lipgloss.Width(sep)
+
+	var b strings.Builder
+	used := 0
+	for _, w := range widgets {
+		view := w.Render(info)
+		if view == "" {
+			continue
+		}
+		prefix, prefixWidth := "", 0
+		if used > 0 {
+			prefix, prefixWidth = sep, sepWidth
+		}
+		viewWidth := lipgloss.Width(view)
+		if used+prefixWidth+viewWidth > width {
+			// 至少保留一个字符和省略号，否则截断没有意义。
+			if rest := width - used - prefixWidth; rest >= 2 {
+				b.WriteString(prefix)
+				b.WriteString(ansi.Truncate(view, rest, "…"))
+			}
+			break
+		}
+		b.WriteString(prefix)
+		b.WriteString(view)
+		used += prefixWidth + viewWidth
+	}
+	return b.String()
+}
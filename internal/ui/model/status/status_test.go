@@ -0,0 +1,65 @@
+package status
+
+import (
+	"testing"
+	"time"
+
+	"charm.land/lipgloss/v2"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/purpose168/crush-cn/internal/ui/styles"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLayout(t *testing.T) {
+	t.Parallel()
+
+	st := styles.DefaultStyles()
+	info := Info{
+		Styles:        &st,
+		Now:           time.Date(2026, 1, 2, 15, 4, 0, 0, time.UTC),
+		Model:         "Claude Sonnet",
+		HasSession:    true,
+		UsedTokens:    50_000,
+		ContextWindow: 200_000,
+	}
+	widgets, unknown := Resolve([]string{Clock, GitBranch, "nope", Model, Tokens, Queue})
+	require.Equal(t, []string{"nope"}, unknown)
+	require.Len(t, widgets, 5)
+
+	t.Run("fits", func(t *testing.T) {
+		t.Parallel()
+		view := Layout(info, widgets, 100)
+		// 分支和排队为空，会被隐藏。
+		require.Equal(t, "15:04 • Claude Sonnet • ▰▰▱▱▱▱▱▱ 25%", ansi.Strip(view))
+	})
+
+	t.Run("truncates", func(t *testing.T) {
+		t.Parallel()
+		view := Layout(info, widgets, 16)
+		require.Equal(t, "15:04 • Claude …", ansi.Strip(view))
+		require.Equal(t, 16, lipgloss.Width(view))
+	})
+
+	t.Run("drops", func(t *testing.T) {
+		t.Parallel()
+		view := Layout(info, widgets, 9)
+		require.Equal(t, "15:04", ansi.Strip(view))
+	})
+}
+
+func TestBudgetWidget(t *testing.T) {
+	t.Parallel()
+
+	st := styles.DefaultStyles()
+	require.Empty(t, budgetWidget.Render(Info{Styles: &st, BudgetSpent: 3}))
+	require.Equal(t, "$1.50/$5.00", ansi.Strip(budgetWidget.Render(Info{Styles: &st, BudgetSpent: 1.5, BudgetLimit: 5})))
+	require.Equal(t, styles.LSPWarningIcon+" $4.20/$5.00", ansi.Strip(budgetWidget.Render(Info{Styles: &st, BudgetSpent: 4.2, BudgetLimit: 5})))
+}
+
+// TestRegister 修改全局注册表，因此不与其他测试并行运行。
+func TestRegister(t *testing.T) {
+	Register(NewWidget("test_custom", func(Info) string { return "custom" }))
+	w, ok := Lookup("test_custom")
+	require.True(t, ok)
+	require.Equal(t, "custom", w.Render(Info{}))
+}
@@ -0,0 +1,81 @@
+package status
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/purpose168/crush-cn/internal/ui/styles"
+)
+
+// 内置小部件的名称。
+const (
+	Clock     = "clock"
+	GitBranch = "git_branch"
+	Model     = "model"
+	Tokens    = "tokens"
+	Queue     = "queue"
+	Budget    = "budget"
+)
+
+// tokenMeterCells 是令牌用量条的单元格数。
+const tokenMeterCells = 8
+
+// clockWidget 显示当前时间。
+var clockWidget = NewWidget(Clock, func(info Info) string {
+	return info.Styles.Status.Widget.Render(info.Now.Format("15:04"))
+})
+
+// gitBranchWidget 显示工作目录当前的 git 分支。
+var gitBranchWidget = NewWidget(GitBranch, func(info Info) string {
+	if info.Branch == "" {
+		return ""
+	}
+	return info.Styles.Status.Widget.Render(info.Branch)
+})
+
+// modelWidget 显示当前大模型的名称。
+var modelWidget = NewWidget(Model, func(info Info) string {
+	if info.Model == "" {
+		return ""
+	}
+	return info.Styles.Status.Widget.Render(info.Model)
+})
+
+// tokensWidget 以用量条和百分比显示当前会话的上下文窗口使用情况。
+var tokensWidget = NewWidget(Tokens, func(info Info) string {
+	if !info.HasSession || info.ContextWindow <= 0 {
+		return ""
+	}
+	ratio := min(1, float64(info.UsedTokens)/float64(info.ContextWindow))
+	filled := int(ratio * tokenMeterCells)
+
+	t := info.Styles
+	meter := t.Status.Widget.Render(strings.Repeat("▰", filled)) +
+		t.Subtle.Render(strings.Repeat("▱", tokenMeterCells-filled))
+	view := meter + " " + t.Status.Widget.Render(fmt.Sprintf("%d%%", int(ratio*100)))
+	if ratio > 0.8 {
+		view = t.LSP.WarningDiagnostic.Render(styles.LSPWarningIcon) + " " + view
+	}
+	return view
+})
+
+// queueWidget 显示当前会话中排队等待的提示数。
+var queueWidget = NewWidget(Queue, func(info Info) string {
+	if info.QueuedPrompts == 0 {
+		return ""
+	}
+	return info.Styles.Status.Widget.Render(fmt.Sprintf("排队 %d", info.QueuedPrompts))
+})
+
+// budgetWidget 显示今日费用和每日预算，达到预算的 80% 后以警告样式显示。
+var budgetWidget = NewWidget(Budget, func(info Info) string {
+	if info.BudgetLimit <= 0 {
+		return ""
+	}
+	t := info.Styles
+	view := fmt.Sprintf("$%.2f/$%.2f", info.BudgetSpent, info.BudgetLimit)
+	if info.BudgetSpent < info.BudgetLimit*0.8 {
+		return t.Status.Widget.Render(view)
+	}
+	return t.LSP.WarningDiagnostic.Render(styles.LSPWarningIcon + " " + view)
+})
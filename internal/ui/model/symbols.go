@@ -0,0 +1,224 @@
+package model
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"log/slog"
+	"path/filepath"
+	"strings"
+	"time"
+
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+	uv "github.com/charmbracelet/ultraviolet"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/purpose168/crush-cn/internal/lsp"
+	"github.com/purpose168/crush-cn/internal/ui/common"
+	"github.com/purpose168/crush-cn/internal/ui/completions"
+)
+
+const (
+	// symbolSearchDelay 是停止输入后到搜索工作区符号之间的延迟，避免每次按键都请求语言服务器。
+	symbolSearchDelay = 150 * time.Millisecond
+	// symbolSearchLimit 是符号补全最多显示的符号数。
+	symbolSearchLimit = 50
+	// symbolMentionMinQuery 是@补全中搜索符号所需的最短查询，过短的查询会匹配大量符号。
+	symbolMentionMinQuery = 2
+	// symbolMentionLines 是@补全中选择符号时引用的行数，从符号定义所在行开始。
+	symbolMentionLines = 20
+	// symbolRequestTimeout 是等待语言服务器返回符号或悬停文档的最长时间。
+	symbolRequestTimeout = 3 * time.Second
+	// symbolDetailMaxWidth 和 symbolDetailMaxHeight 限制悬停文档面板的大小。
+	symbolDetailMaxWidth  = 60
+	symbolDetailMaxHeight = 12
+)
+
+// symbolSearchMsg 在输入停顿后触发工作区符号搜索。
+type symbolSearchMsg struct {
+	query string
+}
+
+// symbolsLoadedMsg 携带语言服务器返回的工作区符号。
+type symbolsLoadedMsg struct {
+	query   string
+	symbols []completions.SymbolCompletionValue
+}
+
+// symbolHoverMsg 携带符号的悬停文档。
+type symbolHoverMsg struct {
+	key   string
+	hover string
+}
+
+// scheduleSymbolSearch 在输入停顿后搜索与 query 匹配的工作区符号。
+func (m *UI) scheduleSymbolSearch(query string) tea.Cmd {
+	if query == "" {
+		m.completions.SetSymbols(nil)
+		return nil
+	}
+	return tea.Tick(symbolSearchDelay, func(time.Time) tea.Msg {
+		return symbolSearchMsg{query: query}
+	})
+}
+
+// scheduleMentionSymbolSearch 在@补全中输入停顿后搜索与 query 匹配的工作区符号，与文件一起显示。
+// 查询过短、像路径或带有行范围，或者没有已就绪的语言服务器时不搜索。
+func (m *UI) scheduleMentionSymbolSearch(query string) tea.Cmd {
+	if len(query) < symbolMentionMinQuery || strings.ContainsAny(query, "/:") || len(m.com.App.LSPManager.ReadyClients()) == 0 {
+		return nil
+	}
+	return tea.Tick(symbolSearchDelay, func(time.Time) tea.Msg {
+		return symbolSearchMsg{query: query}
+	})
+}
+
+// searchingSymbols 报告补全窗口是否在搜索与 query 匹配的符号。
+// 查询已经变化时不再需要结果，只有最后一次输入会请求语言服务器。
+func (m *UI) searchingSymbols(query string) bool {
+	if !m.completionsOpen || query != m.completionsQuery {
+		return false
+	}
+	return m.completionsTrigger == "#" || (m.completionsTrigger == "@" && m.completionsRangePath == "")
+}
+
+// searchSymbols 在所有已就绪的语言服务器中搜索工作区符号。
+func (m *UI) searchSymbols(query string) tea.Cmd {
+	if !m.searchingSymbols(query) {
+		return nil
+	}
+	clients := m.com.App.LSPManager.ReadyClients()
+	workingDir := m.com.Config().WorkingDir()
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), symbolRequestTimeout)
+		defer cancel()
+
+		found, err := lsp.SearchWorkspaceSymbols(ctx, clients, query, workingDir, symbolSearchLimit)
+		if err != nil {
+			slog.Debug("搜索工作区符号失败", "error", err)
+		}
+		symbols := make([]completions.SymbolCompletionValue, len(found))
+		for i, symbol := range found {
+			symbols[i] = completions.SymbolCompletionValue{
+				LSP:       symbol.LSP,
+				Name:      symbol.Name,
+				Path:      symbol.Path,
+				Line:      symbol.Line,
+				Character: symbol.Character,
+			}
+		}
+		return symbolsLoadedMsg{query: query, symbols: symbols}
+	}
+}
+
+// handleSymbolsLoaded 将搜索到的符号显示在补全窗口中。#补全只显示符号并请求选中符号的文档，
+// @补全将符号显示在文件之后。
+func (m *UI) handleSymbolsLoaded(msg symbolsLoadedMsg) tea.Cmd {
+	if !m.searchingSymbols(msg.query) {
+		return nil
+	}
+	if m.completionsTrigger == "@" {
+		m.completions.SetMentionSymbols(msg.symbols)
+		return nil
+	}
+	m.completions.SetSymbols(msg.symbols)
+	return m.updateSymbolDetail()
+}
+
+// updateSymbolDetail 显示选中符号的悬停文档，文档尚未加载时向语言服务器请求。
+func (m *UI) updateSymbolDetail() tea.Cmd {
+	symbol, ok := m.completions.SelectedValue().(completions.SymbolCompletionValue)
+	if !ok {
+		m.completions.SetDetail("")
+		return nil
+	}
+	key := symbolKey(symbol)
+	if hover, ok := m.symbolHovers[key]; ok {
+		m.completions.SetDetail(hover)
+		return nil
+	}
+	m.completions.SetDetail("")
+	m.symbolHovers[key] = ""
+
+	client, ok := m.com.App.LSPManager.Clients().Get(symbol.LSP)
+	if !ok {
+		return nil
+	}
+	path := symbol.Path
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(m.com.Config().WorkingDir(), path)
+	}
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), symbolRequestTimeout)
+		defer cancel()
+		hover, err := client.Hover(ctx, path, symbol.Line, symbol.Character)
+		if err != nil {
+			slog.Debug("获取符号悬停文档失败", "lsp", symbol.LSP, "symbol", symbol.Name, "error", err)
+			return nil
+		}
+		return symbolHoverMsg{key: key, hover: hover}
+	}
+}
+
+// handleSymbolHover 缓存符号的悬停文档，符号仍被选中时显示。
+func (m *UI) handleSymbolHover(msg symbolHoverMsg) {
+	m.symbolHovers[msg.key] = msg.hover
+	if symbol, ok := m.completions.SelectedValue().(completions.SymbolCompletionValue); ok && symbolKey(symbol) == msg.key {
+		m.completions.SetDetail(msg.hover)
+	}
+}
+
+// insertSymbolCompletion 将选中的符号及其位置插入到文本区域中，替换 #query。
+func (m *UI) insertSymbolCompletion(symbol completions.SymbolCompletionValue) {
+	m.insertCompletionText(fmt.Sprintf("%s (%s)", symbol.Name, symbol.Location()))
+}
+
+// insertSymbolMention 将@query替换为符号定义所在文件的行范围引用，并附加从定义开始的几行
+func (m *UI) insertSymbolMention(symbol completions.SymbolCompletionValue) tea.Cmd {
+	end := symbol.Line + symbolMentionLines - 1
+	if lines, ok := textLineCount(symbol.Path); ok {
+		end = max(symbol.Line, min(end, lines))
+	}
+	return m.insertLineRangeCompletion(completions.LineRangeCompletionValue{
+		Path:  symbol.Path,
+		Start: symbol.Line,
+		End:   end,
+	})
+}
+
+// drawSymbolDetail 在补全窗口旁绘制选中符号的悬停文档，右侧空间不足时绘制在左侧。
+func (m *UI) drawSymbolDetail(scr uv.Screen, area, popup image.Rectangle) {
+	detail := m.completions.Detail()
+	if detail == "" {
+		return
+	}
+	style := m.com.Styles.Completions.Detail
+	width := min(symbolDetailMaxWidth, max(area.Max.X-popup.Max.X, popup.Min.X-area.Min.X))
+	if width < 20 {
+		return
+	}
+	contentWidth := width - style.GetHorizontalFrameSize()
+	rendered, err := common.MarkdownRenderer(m.com.Styles, contentWidth).Render(detail)
+	if err != nil {
+		rendered = detail
+	}
+	lines := strings.Split(strings.Trim(rendered, "\n"), "\n")
+	lines = lines[:min(len(lines), symbolDetailMaxHeight)]
+	for i, line := range lines {
+		lines[i] = ansi.Truncate(line, contentWidth, "…")
+	}
+	view := style.Width(width).Render(strings.Join(lines, "\n"))
+
+	x := popup.Max.X
+	if x+width > area.Max.X {
+		x = popup.Min.X - width
+	}
+	height := lipgloss.Height(view)
+	y := max(area.Min.Y, popup.Max.Y-height)
+	uv.NewStyledString(view).Draw(scr, image.Rect(x, y, x+width, y+height))
+}
+
+// symbolKey 返回缓存符号悬停文档所用的键。
+func symbolKey(symbol completions.SymbolCompletionValue) string {
+	return fmt.Sprintf("%s:%s:%d", symbol.LSP, symbol.Location(), symbol.Character)
+}
@@ -0,0 +1,148 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/purpose168/crush-cn/internal/ui/util"
+)
+
+// maxSessionTabs 是可以同时打开的会话标签页数量上限，与 ctrl+1..9 对应。
+const maxSessionTabs = 9
+
+// sessionTabMaxTitleWidth 是标签栏中单个标签标题的最大宽度。
+const sessionTabMaxTitleWidth = 24
+
+// sessionTab 是标签栏中的一个会话。只有当前标签页的聊天状态保存在 [UI] 中，
+// 切换标签页时会从数据库重新加载会话，因此后台标签页的智能体运行不受影响。
+type sessionTab struct {
+	// sessionID 为空表示尚未发送消息的新会话。
+	sessionID string
+	title     string
+	// draft 是切换离开时编辑器中未发送的内容。
+	draft string
+}
+
+// hasTabBar 报告是否显示标签栏。只有一个标签页时不显示，保持原有布局。
+func (m *UI) hasTabBar() bool {
+	return len(m.tabs) > 1 && (m.state == uiLanding || m.state == uiChat)
+}
+
+// tabIndex 返回打开了 sessionID 的标签页索引，不存在时返回 -1。
+func (m *UI) tabIndex(sessionID string) int {
+	for i, tab := range m.tabs {
+		if tab.sessionID != "" && tab.sessionID == sessionID {
+			return i
+		}
+	}
+	return -1
+}
+
+// setActiveTabSession 将当前标签页关联到指定会话。
+func (m *UI) setActiveTabSession(sessionID, title string) {
+	m.tabs[m.activeTab].sessionID = sessionID
+	m.tabs[m.activeTab].title = title
+}
+
+// isActiveTabSession 报告 sessionID 是否属于当前标签页。
+func (m *UI) isActiveTabSession(sessionID string) bool {
+	return m.tabs[m.activeTab].sessionID == sessionID
+}
+
+// openSessionInTab 打开会话。会话已在某个标签页中打开时切换到该标签页，
+// 否则在当前标签页中加载。
+func (m *UI) openSessionInTab(sessionID, title string) tea.Cmd {
+	if i := m.tabIndex(sessionID); i >= 0 {
+		if i == m.activeTab {
+			return m.loadSession(sessionID)
+		}
+		return m.switchTab(i)
+	}
+	m.setActiveTabSession(sessionID, title)
+	return m.loadSession(sessionID)
+}
+
+// newTab 打开一个新会话标签页并切换到该标签页。
+func (m *UI) newTab() tea.Cmd {
+	if len(m.tabs) >= maxSessionTabs {
+		return util.ReportWarn(fmt.Sprintf("最多只能打开 %d 个标签页", maxSessionTabs))
+	}
+	m.tabs[m.activeTab].draft = m.textarea.Value()
+	m.tabs = append(m.tabs, sessionTab{})
+	m.activeTab = len(m.tabs) - 1
+	m.textarea.Reset()
+	return m.resetSession()
+}
+
+// switchTab 切换到索引为 i 的标签页。
+func (m *UI) switchTab(i int) tea.Cmd {
+	if i < 0 || i >= len(m.tabs) || i == m.activeTab {
+		return nil
+	}
+	m.tabs[m.activeTab].draft = m.textarea.Value()
+	m.activeTab = i
+	return m.enterActiveTab()
+}
+
+// closeTab 关闭当前标签页。正在运行的会话需要先取消才能关闭。
+func (m *UI) closeTab() tea.Cmd {
+	if len(m.tabs) <= 1 {
+		return nil
+	}
+	if m.isAgentBusy() {
+		return util.ReportWarn("智能体正在处理此会话，请先取消再关闭标签页...")
+	}
+	m.tabs = append(m.tabs[:m.activeTab], m.tabs[m.activeTab+1:]...)
+	m.activeTab = min(m.activeTab, len(m.tabs)-1)
+	return m.enterActiveTab()
+}
+
+// removeSessionTab 在会话被删除时关闭其后台标签页。当前标签页由调用方处理。
+func (m *UI) removeSessionTab(sessionID string) {
+	i := m.tabIndex(sessionID)
+	if i < 0 || i == m.activeTab {
+		return
+	}
+	m.tabs = append(m.tabs[:i], m.tabs[i+1:]...)
+	if i < m.activeTab {
+		m.activeTab--
+	}
+}
+
+// enterActiveTab 恢复当前标签页的编辑器内容并加载其会话。
+func (m *UI) enterActiveTab() tea.Cmd {
+	tab := m.tabs[m.activeTab]
+	m.textarea.Reset()
+	m.textarea.SetValue(tab.draft)
+	m.isCanceling = false
+	if tab.sessionID == "" {
+		return m.resetSession()
+	}
+	return m.loadSession(tab.sessionID)
+}
+
+// renderTabBar 渲染会话标签栏，正在运行的会话带有忙碌指示器。
+func (m *UI) renderTabBar(width int) string {
+	t := m.com.Styles
+	titleWidth := min(sessionTabMaxTitleWidth, max(4, width/len(m.tabs)-6))
+
+	var b strings.Builder
+	for i, tab := range m.tabs {
+		title := tab.title
+		if title == "" {
+			title = "新会话"
+		}
+		style := t.Header.TabInactive
+		if i == m.activeTab {
+			style = t.Header.TabActive
+		}
+		label := fmt.Sprintf("%d %s", i+1, ansi.Truncate(title, titleWidth, "…"))
+		if tab.sessionID != "" && m.isSessionBusy(tab.sessionID) {
+			label += " " + t.Header.TabBusy.Inherit(style).Render("●")
+		}
+		b.WriteString(style.Render(label))
+	}
+	return ansi.Truncate(b.String(), width, "…")
+}
@@ -0,0 +1,49 @@
+package model
+
+import (
+	"time"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/purpose168/crush-cn/internal/ui/chat"
+)
+
+// toolDeadlineTickMsg 在有工具带时限运行时每秒触发一次，用于刷新倒计时。
+type toolDeadlineTickMsg struct{}
+
+// applyToolTimeouts 为新建的工具项设置配置的执行时限。
+func (m *UI) applyToolTimeouts(items ...chat.MessageItem) {
+	tools := m.com.Config().Tools
+	for _, item := range items {
+		toolItem, ok := item.(chat.ToolMessageItem)
+		if !ok {
+			continue
+		}
+		if timeoutable, ok := item.(chat.Timeoutable); ok {
+			timeoutable.SetTimeout(tools.Timeout(toolItem.ToolCall().Name))
+		}
+	}
+}
+
+// startToolDeadlineTick 在有工具带时限运行且尚未计时时开始每秒刷新。
+func (m *UI) startToolDeadlineTick() tea.Cmd {
+	if m.toolDeadlineTicking || !m.chat.HasToolDeadline() {
+		return nil
+	}
+	m.toolDeadlineTicking = true
+	return toolDeadlineTick()
+}
+
+// handleToolDeadlineTick 在仍有工具带时限运行时继续计时。
+func (m *UI) handleToolDeadlineTick() tea.Cmd {
+	if !m.chat.HasToolDeadline() {
+		m.toolDeadlineTicking = false
+		return nil
+	}
+	return toolDeadlineTick()
+}
+
+func toolDeadlineTick() tea.Cmd {
+	return tea.Tick(time.Second, func(time.Time) tea.Msg {
+		return toolDeadlineTickMsg{}
+	})
+}
@@ -0,0 +1,60 @@
+package model
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/charmbracelet/x/editor"
+	"github.com/purpose168/crush-cn/internal/ui/chat"
+	"github.com/purpose168/crush-cn/internal/ui/util"
+)
+
+// maxFailedToolErrorLength 是写入后续提示的工具错误信息的最大字节数
+const maxFailedToolErrorLength = 2000
+
+// retryFailedTool 让智能体根据错误信息重试失败的工具调用。智能体忙碌时提示进入队列。
+func (m *UI) retryFailedTool(failed chat.FailedTool) tea.Cmd {
+	prompt := fmt.Sprintf(
+		"The `%s` tool call failed with this error:\n\n%s\n\nFix the cause of the error and retry the call.",
+		failed.Call.Name, failedToolError(failed),
+	)
+	return m.sendMessage(prompt)
+}
+
+// rereadFailedToolFile 让智能体重新读取失败工具操作的文件，再重试调用。
+func (m *UI) rereadFailedToolFile(failed chat.FailedTool) tea.Cmd {
+	prompt := fmt.Sprintf(
+		"The `%s` tool call on `%s` failed with this error:\n\n%s\n\nThe file may have changed since you last read it. Read `%s` again with the view tool to get its current content, then retry the change.",
+		failed.Call.Name, failed.FilePath, failedToolError(failed), failed.FilePath,
+	)
+	return m.sendMessage(prompt)
+}
+
+// openFailedToolFile 在外部编辑器中打开失败工具操作的文件。
+func (m *UI) openFailedToolFile(failed chat.FailedTool) tea.Cmd {
+	path := failed.FilePath
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(m.com.Config().WorkingDir(), path)
+	}
+	cmd, err := editor.Command("crush", path)
+	if err != nil {
+		return util.ReportError(err)
+	}
+	return tea.ExecProcess(cmd, func(err error) tea.Msg {
+		if err != nil {
+			return util.NewErrorMsg(err)
+		}
+		return nil
+	})
+}
+
+// failedToolError 返回写入提示的错误信息，过长时截断
+func failedToolError(failed chat.FailedTool) string {
+	text := failed.Error
+	if len(text) > maxFailedToolErrorLength {
+		text = strings.ToValidUTF8(text[:maxFailedToolErrorLength], "") + "..."
+	}
+	return "```\n" + text + "\n```"
+}
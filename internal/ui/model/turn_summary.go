@@ -0,0 +1,75 @@
+package model
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/purpose168/crush-cn/internal/message"
+	"github.com/purpose168/crush-cn/internal/ui/chat"
+)
+
+// turnSummaryItem 为以 last 结束的回合创建摘要项，turn 是该回合的消息。
+// 回合中没有调用工具时返回 nil，模型信息已经足以描述纯文本回复。
+func (m *UI) turnSummaryItem(last *message.Message, turn []*message.Message) chat.MessageItem {
+	summary := chat.SummarizeTurn(turn)
+	if summary.ToolCalls == 0 {
+		return nil
+	}
+	return chat.NewTurnSummaryItem(m.com.Styles, last.ID, summary)
+}
+
+// citationsItem 为以 last 结束的回合创建来源脚注项，turn 是该回合的消息。
+// 回合中没有抓取或搜索网页时返回 nil。
+func (m *UI) citationsItem(last *message.Message, turn []*message.Message) chat.MessageItem {
+	sources := chat.CollectCitations(turn)
+	if len(sources) == 0 {
+		return nil
+	}
+	return chat.NewCitationsItem(m.com.Styles, last.ID, sources)
+}
+
+// appendTurnEnd 在回合结束时依次追加来源脚注、模型信息和回合摘要，回合的消息从数据库中读取。
+func (m *UI) appendTurnEnd(last message.Message) {
+	var turn []*message.Message
+	if msgs, err := m.com.App.Messages.List(context.Background(), last.SessionID); err != nil {
+		slog.Error("读取回合消息失败", "error", err)
+	} else {
+		turn = turnMessages(msgs, last)
+	}
+
+	if item := m.citationsItem(&last, turn); item != nil && m.chat.MessageItem(item.ID()) == nil {
+		m.chat.AppendMessages(item)
+	}
+	m.chat.AppendMessages(chat.NewAssistantInfoItem(m.com.Styles, &last, m.com.Config(), time.Unix(m.lastUserMessageTime, 0)))
+	if turn == nil || m.chat.MessageItem(chat.TurnSummaryID(last.ID)) != nil {
+		return
+	}
+	if item := m.turnSummaryItem(&last, turn); item != nil {
+		m.chat.AppendMessages(item)
+	}
+}
+
+// turnMessages 返回以 last 结束的回合的消息，即 last 之前最后一条用户消息之后的消息。
+// last 使用传入的版本，它可能比数据库中的版本更新。
+func turnMessages(msgs []message.Message, last message.Message) []*message.Message {
+	end := len(msgs)
+	for i := range msgs {
+		if msgs[i].ID == last.ID {
+			end = i
+			break
+		}
+	}
+	start := 0
+	for i := end - 1; i >= 0; i-- {
+		if msgs[i].Role == message.User {
+			start = i + 1
+			break
+		}
+	}
+	turn := make([]*message.Message, 0, end-start+1)
+	for i := start; i < end; i++ {
+		turn = append(turn, &msgs[i])
+	}
+	return append(turn, &last)
+}
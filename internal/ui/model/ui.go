@@ -3,6 +3,9 @@ package model
 import (
 	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"image"
@@ -28,8 +31,11 @@ import (
 	"github.com/charmbracelet/ultraviolet/layout"
 	"github.com/charmbracelet/ultraviolet/screen"
 	"github.com/charmbracelet/x/editor"
+	"github.com/purpose168/crush-cn/internal/agent/tools"
 	"github.com/purpose168/crush-cn/internal/agent/tools/mcp"
 	"github.com/purpose168/crush-cn/internal/app"
+	"github.com/purpose168/crush-cn/internal/budget"
+	"github.com/purpose168/crush-cn/internal/capability"
 	"github.com/purpose168/crush-cn/internal/commands"
 	"github.com/purpose168/crush-cn/internal/config"
 	"github.com/purpose168/crush-cn/internal/fsext"
@@ -39,6 +45,7 @@ import (
 	"github.com/purpose168/crush-cn/internal/permission"
 	"github.com/purpose168/crush-cn/internal/pubsub"
 	"github.com/purpose168/crush-cn/internal/session"
+	"github.com/purpose168/crush-cn/internal/snippets"
 	"github.com/purpose168/crush-cn/internal/ui/anim"
 	"github.com/purpose168/crush-cn/internal/ui/attachments"
 	"github.com/purpose168/crush-cn/internal/ui/chat"
@@ -131,6 +138,9 @@ type UI struct {
 	// 在没有会话ID时跟踪已读取的文件
 	sessionFileReads []string
 
+	// 会话ID到离开该会话时聊天滚动位置的映射，切换回会话时恢复
+	chatPositions map[string]chatPosition
+
 	lastUserMessageTime int64
 
 	// 终端的宽度和高度（以单元格为单位）
@@ -139,6 +149,8 @@ type UI struct {
 	layout uiLayout
 
 	isTransparent bool
+	// accessible 表示启用了无障碍模式：禁用动画并使用纯文本状态。
+	accessible bool
 
 	focus uiFocusState
 	state uiState
@@ -152,10 +164,13 @@ type UI struct {
 	// isCanceling 跟踪用户是否已按一次ESC键取消
 	isCanceling bool
 
+	// replay 在会话回放模式中不为nil
+	replay *replayState
+
 	header *header
 
-	// sendProgressBar 指示TUI向终端发送进度条更新
-	sendProgressBar    bool
+	// terminal 决定如何向终端和终端复用器报告标题与进度条
+	terminal           common.TerminalIntegration
 	progressBarEnabled bool
 
 	// caps 保存我们查询的不同终端能力
@@ -170,12 +185,29 @@ type UI struct {
 	readyPlaceholder   string
 	workingPlaceholder string
 
+	// 语音输入状态，recording 在录音时非空
+	recording    *voiceRecording
+	transcribing bool
+
+	// toolDeadlineTicking 表示是否正在每秒刷新工具的超时倒计时
+	toolDeadlineTicking bool
+
+	// budget 是今日的费用和预算
+	budget budget.Status
+
 	// 自动完成状态
 	completions              *completions.Completions
 	completionsOpen          bool
 	completionsStartIndex    int
 	completionsQuery         string
 	completionsPositionStart image.Point // 用户输入'@'时的x,y坐标
+	// completionsTrigger 是打开补全的字符：'@' 补全文件和资源，'#' 补全 LSP 符号，';' 补全片段
+	completionsTrigger string
+	// completionsRangePath 是正在选择行范围的文件，为空表示不在行范围选择步骤中
+	completionsRangePath  string
+	completionsRangeLines int
+	// symbolHovers 按位置缓存 LSP 符号的悬停文档
+	symbolHovers map[string]string
 
 	// 聊天组件
 	chat *Chat
@@ -201,6 +233,15 @@ type UI struct {
 	// forceCompactMode 跟踪紧凑模式是否由用户切换强制启用
 	forceCompactMode bool
 
+	// panelLayout 表示非紧凑模式下右侧显示任务计划、提示队列和已修改文件的面板而不是侧边栏
+	panelLayout bool
+
+	// linkTarget 是启动时要打开的会话链接，会话加载后滚动到其中的消息
+	linkTarget linkTarget
+
+	// newSessionPlanMode 表示尚未创建的会话是否以计划模式开始
+	newSessionPlanMode bool
+
 	// isCompact 跟踪当前是否处于紧凑布局模式
 	//（通过用户切换或基于窗口大小自动切换）
 	isCompact bool
@@ -220,13 +261,20 @@ type UI struct {
 
 	// 鼠标高亮相关状态
 	lastClickTime time.Time
+	// selection 是最近一次在聊天中选中并复制的文本，可以连同预设指令发送给智能体
+	selection string
 
 	// 提示历史记录，用于通过上/下键导航到之前的消息
 	promptHistory struct {
 		messages []string
+		sent     []sentPrompt
 		index    int
 		draft    string
 	}
+
+	// 会话标签页，至少包含一个标签页，activeTab 对应当前显示的会话
+	tabs      []sessionTab
+	activeTab int
 }
 
 // New 创建一个新的 [UI] 模型实例
@@ -273,17 +321,19 @@ func New(com *common.Common) *UI {
 	header := newHeader(com)
 
 	ui := &UI{
-		com:         com,
-		dialog:      dialog.NewOverlay(),
-		keyMap:      keyMap,
-		textarea:    ta,
-		chat:        ch,
-		header:      header,
-		completions: comp,
-		attachments: attachments,
-		todoSpinner: todoSpinner,
-		lspStates:   make(map[string]app.LSPClientInfo),
-		mcpStates:   make(map[string]mcp.ClientInfo),
+		com:          com,
+		dialog:       dialog.NewOverlay(),
+		keyMap:       keyMap,
+		textarea:     ta,
+		chat:         ch,
+		header:       header,
+		completions:  comp,
+		symbolHovers: make(map[string]string),
+		attachments:  attachments,
+		todoSpinner:  todoSpinner,
+		lspStates:    make(map[string]app.LSPClientInfo),
+		mcpStates:    make(map[string]mcp.ClientInfo),
+		tabs:         []sessionTab{{}},
 	}
 
 	status := NewStatus(com, ui)
@@ -295,6 +345,7 @@ func New(com *common.Common) *UI {
 
 	// 从配置初始化紧凑模式
 	ui.forceCompactMode = com.Config().Options.TUI.CompactMode
+	ui.panelLayout = com.Config().Options.TUI.Layout == config.TUILayoutPanel
 
 	// 设置引导状态默认值
 	ui.onboarding.yesInitializeSelected = true
@@ -316,6 +367,11 @@ func New(com *common.Common) *UI {
 	ui.progressBarEnabled = opts.Progress == nil || *opts.Progress
 	// 启用透明模式
 	ui.isTransparent = opts.TUI.Transparent != nil && *opts.TUI.Transparent
+	// 启用无障碍模式
+	ui.accessible = opts.TUI.Accessible
+	anim.SetReducedMotion(ui.accessible)
+	// 设置聊天 Markdown 的排版选项
+	chat.SetMarkdownOptions(opts.TUI.Markdown)
 
 	return ui
 }
@@ -332,6 +388,35 @@ func (m *UI) Init() tea.Cmd {
 	cmds = append(cmds, m.loadCustomCommands())
 	// 异步加载提示历史记录
 	cmds = append(cmds, m.loadPromptHistory())
+	// 立即刷新一次状态栏小部件，之后定期刷新
+	if cmd := m.status.RefreshWidgets(0); cmd != nil {
+		cmds = append(cmds, cmd)
+	}
+	// 读取今日费用，用于状态栏和预算提醒
+	cmds = append(cmds, m.loadBudget())
+	// 按保留策略检查是否有需要清理的旧会话
+	if m.state != uiOnboarding {
+		cmds = append(cmds, m.checkRetention(false))
+	}
+	if m.state == uiLanding {
+		if m.linkTarget.sessionID != "" {
+			// 打开命令行中传入的会话链接
+			cmds = append(cmds, m.openSessionInTab(m.linkTarget.sessionID, ""))
+		} else {
+			// 查找上次退出时被中断的运行，提示用户恢复
+			cmds = append(cmds, m.checkInterruptedRun())
+			// 首次在项目中启动时检查工作区并给出设置建议
+			cmds = append(cmds, m.detectProjectSetup(false))
+		}
+	}
+	// 等待较长代码块的后台语法高亮完成
+	cmds = append(cmds, chat.WaitHighlight())
+	// 恢复上次崩溃前未发送的输入
+	cmds = append(cmds, m.restoreCrashDraft())
+	// 提示配置文件中被忽略或有误的配置项
+	if issues := m.com.Config().ValidationIssues(); len(issues) > 0 {
+		cmds = append(cmds, util.ReportWarn(fmt.Sprintf("配置文件存在 %d 个问题，运行 crush config validate 查看详情", len(issues))))
+	}
 	return tea.Batch(cmds...)
 }
 
@@ -373,6 +458,11 @@ func (m *UI) loadMCPrompts() tea.Msg {
 
 // Update 处理UI模型的更新
 func (m *UI) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	model, cmd := m.update(msg)
+	return model, tea.Batch(cmd, m.terminal.StatusCmd(m.windowTitle(), m.isAnyAgentBusy(), m.progressBarEnabled))
+}
+
+func (m *UI) update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmds []tea.Cmd
 	if m.hasSession() && m.isAgentBusy() {
 		queueSize := m.com.App.AgentCoordinator.QueuedPrompts(m.session.ID)
@@ -383,17 +473,24 @@ func (m *UI) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	}
 	// 更新终端能力
 	m.caps.Update(msg)
+	m.terminal.Update(msg)
 	switch msg := msg.(type) {
 	case tea.EnvMsg:
-		// 这是Windows Terminal吗？
-		if !m.sendProgressBar {
-			m.sendProgressBar = slices.Contains(msg, "WT_SESSION")
-		}
 		cmds = append(cmds, common.QueryCmd(uv.Environ(msg)))
 	case loadSessionMsg:
+		if !m.isActiveTabSession(msg.session.ID) {
+			// 加载完成前已切换到其他标签页
+			break
+		}
+		if m.session != nil && m.session.ID != msg.session.ID {
+			m.saveChatPosition()
+		}
+		m.setActiveTabSession(msg.session.ID, msg.session.Title)
 		if m.forceCompactMode {
 			m.isCompact = true
 		}
+		m.replay = nil
+		m.selection = ""
 		m.setState(uiChat, m.focus)
 		m.session = msg.session
 		m.sessionFiles = msg.files
@@ -406,11 +503,19 @@ func (m *UI) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		if cmd := m.setSessionMessages(msgs); cmd != nil {
 			cmds = append(cmds, cmd)
 		}
+		if pos, ok := m.chatPositions[m.session.ID]; ok {
+			delete(m.chatPositions, m.session.ID)
+			if cmd := m.chat.RestorePositionAndAnimate(pos); cmd != nil {
+				cmds = append(cmds, cmd)
+			}
+		}
+		if m.linkTarget.sessionID == m.session.ID {
+			cmds = append(cmds, m.jumpToLinkTarget())
+		}
 		if hasInProgressTodo(m.session.Todos) {
 			// 仅当有进行中的待办事项时才启动旋转器
 			if m.isAgentBusy() {
-				m.todoIsSpinning = true
-				cmds = append(cmds, m.todoSpinner.Tick)
+				cmds = append(cmds, m.startTodoSpinner())
 			}
 			m.updateLayoutAndSize()
 		}
@@ -430,6 +535,24 @@ func (m *UI) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case sendMessageMsg:
 		cmds = append(cmds, m.sendMessage(msg.Content, msg.Attachments...))
 
+	case retentionCheckMsg:
+		if len(msg.sessions) == 0 {
+			if msg.manual {
+				cmds = append(cmds, util.ReportInfo("没有超出保留策略的会话"))
+			}
+			break
+		}
+		m.openRetentionDialog(msg.sessions)
+	case interruptedRunMsg:
+		if m.hasSession() {
+			// 用户已经开始或打开了其他会话
+			break
+		}
+		m.openResumeDialog(msg.session, msg.run)
+	case crashDraftMsg:
+		if cmd := m.applyCrashDraft(msg.draft); cmd != nil {
+			cmds = append(cmds, cmd)
+		}
 	case userCommandsLoadedMsg:
 		m.customCommands = msg.Commands
 		dia := m.dialog.Dialog(dialog.CommandsID)
@@ -443,6 +566,7 @@ func (m *UI) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 
 	case mcpStateChangedMsg:
+		cmds = append(cmds, m.promptMCPAuth(msg.states))
 		m.mcpStates = msg.states
 	case mcpPromptsLoadedMsg:
 		m.mcpPrompts = msg.Prompts
@@ -458,6 +582,7 @@ func (m *UI) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	case promptHistoryLoadedMsg:
 		m.promptHistory.messages = msg.messages
+		m.promptHistory.sent = msg.sent
 		m.promptHistory.index = -1
 		m.promptHistory.draft = ""
 
@@ -471,16 +596,30 @@ func (m *UI) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					cmds = append(cmds, cmd)
 				}
 			}
+			delete(m.chatPositions, msg.Payload.ID)
+			m.removeSessionTab(msg.Payload.ID)
 			break
 		}
+		if i := m.tabIndex(msg.Payload.ID); i >= 0 {
+			m.tabs[i].title = msg.Payload.Title
+		}
 		if m.session != nil && msg.Payload.ID == m.session.ID {
 			prevHasInProgress := hasInProgressTodo(m.session.Todos)
+			prev := *m.session
 			m.session = &msg.Payload
 			if !prevHasInProgress && hasInProgressTodo(m.session.Todos) {
-				m.todoIsSpinning = true
-				cmds = append(cmds, m.todoSpinner.Tick)
+				cmds = append(cmds, m.startTodoSpinner())
 				m.updateLayoutAndSize()
 			}
+			if variantsChanged(prev, *m.session) && m.replay == nil {
+				// 重新生成或切换回复版本后聊天显示的消息随之变化
+				msgs, err := m.com.App.Messages.List(context.Background(), m.session.ID)
+				if err != nil {
+					cmds = append(cmds, util.ReportError(err))
+				} else if cmd := m.setSessionMessages(msgs); cmd != nil {
+					cmds = append(cmds, cmd)
+				}
+			}
 		}
 	case pubsub.Event[message.Message]:
 		// 检查这是否是智能体工具的子会话消息
@@ -494,6 +633,10 @@ func (m *UI) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 			break
 		}
+		if m.replay != nil {
+			// 回放模式中聊天只显示回放内容，退出回放时会重新加载消息
+			break
+		}
 		switch msg.Type {
 		case pubsub.CreatedEvent:
 			cmds = append(cmds, m.appendSessionMessage(msg.Payload))
@@ -504,8 +647,7 @@ func (m *UI) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 		// 如果有新消息则启动旋转器
 		if hasInProgressTodo(m.session.Todos) && m.isAgentBusy() && !m.todoIsSpinning {
-			m.todoIsSpinning = true
-			cmds = append(cmds, m.todoSpinner.Tick)
+			cmds = append(cmds, m.startTodoSpinner())
 		}
 		// 如果智能体不再忙碌则停止旋转器
 		if m.todoIsSpinning && !m.isAgentBusy() {
@@ -515,8 +657,60 @@ func (m *UI) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.renderPills()
 	case pubsub.Event[history.File]:
 		cmds = append(cmds, m.handleFileEvent(msg.Payload))
+	case pubsub.Event[budget.Status]:
+		cmds = append(cmds, m.updateBudget(msg.Payload))
+	case budgetLoadedMsg:
+		cmds = append(cmds, m.updateBudget(msg.status))
+	case promptEnhancedMsg:
+		m.handlePromptEnhanced(msg)
+	case logsTickMsg:
+		if cmd := m.handleLogsTick(); cmd != nil {
+			cmds = append(cmds, cmd)
+		}
+	case lspServersTickMsg:
+		if cmd := m.handleLSPServersTick(); cmd != nil {
+			cmds = append(cmds, cmd)
+		}
+	case connectionsTickMsg:
+		if cmd := m.handleConnectionsTick(); cmd != nil {
+			cmds = append(cmds, cmd)
+		}
+	case imagePreparedMsg:
+		m.attachments.Update(msg.att)
+	case codeBlockRunMsg:
+		if msg.err != nil {
+			cmds = append(cmds, util.ReportError(msg.err))
+		}
+	case projectSetupMsg:
+		if cmd := m.handleProjectSetup(msg); cmd != nil {
+			cmds = append(cmds, cmd)
+		}
+	case sessionDiffMsg:
+		if cmd := m.handleSessionDiff(msg); cmd != nil {
+			cmds = append(cmds, cmd)
+		}
+	case app.UpdateAvailableMsg:
+		// 开发版本无法自动更新，不提示
+		if !msg.IsDevelopment {
+			cmds = append(cmds, util.CmdHandler(util.InfoMsg{
+				Type: util.InfoTypeUpdate,
+				Msg:  fmt.Sprintf("Crush %s 已发布（当前 %s），运行 crush update 更新", msg.LatestVersion, msg.CurrentVersion),
+				TTL:  updateStatusTTL,
+			}))
+		}
 	case pubsub.Event[app.LSPEvent]:
 		m.lspStates = app.GetLSPStates()
+		if d, ok := m.dialog.Dialog(dialog.LSPServersID).(*dialog.LSPServers); ok {
+			d.Refresh()
+		}
+	case pubsub.Event[tools.DownloadProgress]:
+		if item, ok := m.chat.MessageItem(msg.Payload.ToolCallID).(*chat.DownloadToolMessageItem); ok {
+			item.SetProgress(msg.Payload)
+		}
+	case pubsub.Event[tools.BashOutput]:
+		if item, ok := m.chat.MessageItem(msg.Payload.ToolCallID).(*chat.BashToolMessageItem); ok {
+			item.SetOutput(msg.Payload.Tail)
+		}
 	case pubsub.Event[mcp.Event]:
 		switch msg.Payload.Type {
 		case mcp.EventStateChanged:
@@ -537,14 +731,13 @@ func (m *UI) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 	case pubsub.Event[permission.PermissionNotification]:
 		m.handlePermissionNotification(msg.Payload)
+	case replayLoadedMsg:
+		cmds = append(cmds, m.handleReplayLoaded(msg))
+	case replayTickMsg:
+		cmds = append(cmds, m.handleReplayTick(msg))
 	case cancelTimerExpiredMsg:
 		m.isCanceling = false
 	case tea.TerminalVersionMsg:
-		termVersion := strings.ToLower(msg.Name)
-		// 仅对以下终端启用进度条
-		if !m.sendProgressBar {
-			m.sendProgressBar = strings.Contains(termVersion, "ghostty")
-		}
 		return m, nil
 	case tea.WindowSizeMsg:
 		m.width, m.height = msg.Width, msg.Height
@@ -555,6 +748,11 @@ func (m *UI) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.keyMap.Models.SetHelp("ctrl+m", "模型")
 			m.keyMap.Editor.Newline.SetHelp("shift+enter", "换行")
 		}
+	case chat.ThumbnailReadyMsg:
+		m.chat.SetThumbnail(msg)
+	case chat.HighlightReadyMsg:
+		// 工具项在渲染时检测到高亮完成并重新渲染，这里只需继续等待
+		cmds = append(cmds, chat.WaitHighlight())
 	case copyChatHighlightMsg:
 		cmds = append(cmds, m.copyChatHighlight())
 	case DelayedClickMsg:
@@ -710,6 +908,24 @@ func (m *UI) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		if cmd := m.handlePasteMsg(msg); cmd != nil {
 			cmds = append(cmds, cmd)
 		}
+	case symbolSearchMsg:
+		if cmd := m.searchSymbols(msg.query); cmd != nil {
+			cmds = append(cmds, cmd)
+		}
+	case symbolsLoadedMsg:
+		if cmd := m.handleSymbolsLoaded(msg); cmd != nil {
+			cmds = append(cmds, cmd)
+		}
+	case symbolHoverMsg:
+		m.handleSymbolHover(msg)
+	case voiceTranscribedMsg:
+		if cmd := m.handleVoiceTranscribed(msg); cmd != nil {
+			cmds = append(cmds, cmd)
+		}
+	case toolDeadlineTickMsg:
+		if cmd := m.handleToolDeadlineTick(); cmd != nil {
+			cmds = append(cmds, cmd)
+		}
 	case openEditorMsg:
 		var cmd tea.Cmd
 		m.textarea.SetValue(msg.Text)
@@ -727,9 +943,24 @@ func (m *UI) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		cmds = append(cmds, clearInfoMsgCmd(ttl))
 	case util.ClearStatusMsg:
 		m.status.ClearInfoMsg()
+	case statusWidgetsTickMsg:
+		m.status.SetBranch(msg.branch)
+		cmds = append(cmds, m.status.RefreshWidgets(statusWidgetsInterval))
 	case completions.CompletionItemsLoadedMsg:
 		if m.completionsOpen {
-			m.completions.SetItems(msg.Files, msg.Resources)
+			m.completions.SetItems(msg.Files, msg.Resources, msg.Custom)
+		}
+	case completions.SnippetsLoadedMsg:
+		if msg.Err != nil {
+			slog.Warn("加载片段失败", "error", msg.Err)
+		}
+		if m.completionsOpen && m.completionsTrigger == ";" {
+			if len(msg.Snippets) == 0 {
+				m.closeCompletions()
+				break
+			}
+			m.completions.SetSnippets(msg.Snippets)
+			m.completions.Filter(m.completionsQuery)
 		}
 	case uv.KittyGraphicsEvent:
 		if !bytes.HasPrefix(msg.Payload, []byte("OK")) {
@@ -758,16 +989,70 @@ func (m *UI) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		if m.com.App.Permissions.SkipRequests() {
 			m.textarea.Placeholder = "Yolo模式！"
 		}
+		if m.com.App.DryRun.Enabled() {
+			m.textarea.Placeholder = "[演练模式] " + m.textarea.Placeholder
+		}
+		if m.planModeEnabled() {
+			m.textarea.Placeholder = "[计划模式] " + m.textarea.Placeholder
+		}
+		switch {
+		case m.recording != nil:
+			m.textarea.Placeholder = "正在录音，按 alt+v 结束，按 esc 取消..."
+		case m.transcribing:
+			m.textarea.Placeholder = "正在转写..."
+		}
 	}
 
 	// 此时这只能处理 [message.Attachment] 消息，我们应该返回所有命令
+	if cmd, rejected := m.rejectUnsupportedAttachment(msg); rejected {
+		return m, tea.Batch(append(cmds, cmd)...)
+	}
+	if cmd := m.prepareImageAttachment(msg); cmd != nil {
+		return m, tea.Batch(append(cmds, cmd)...)
+	}
 	_ = m.attachments.Update(msg)
 	return m, tea.Batch(cmds...)
 }
 
+// saveChatPosition 在离开当前会话前记录聊天的滚动位置和选中项。
+// 回放模式中聊天显示的是回放内容，不记录。
+func (m *UI) saveChatPosition() {
+	if m.session == nil || m.replay != nil || m.chat.Len() == 0 {
+		return
+	}
+	if m.chatPositions == nil {
+		m.chatPositions = make(map[string]chatPosition)
+	}
+	m.chatPositions[m.session.ID] = m.chat.Position()
+}
+
 // setSessionMessages 为当前会话的聊天设置消息
 func (m *UI) setSessionMessages(msgs []message.Message) tea.Cmd {
 	var cmds []tea.Cmd
+	items := m.sessionMessageItems(m.visibleMessages(msgs))
+
+	// 如果用户在智能体工作时切换会话，我们要确保显示动画
+	for _, item := range items {
+		if animatable, ok := item.(chat.Animatable); ok {
+			if cmd := animatable.StartAnimation(); cmd != nil {
+				cmds = append(cmds, cmd)
+			}
+		}
+	}
+
+	m.chat.SetMessages(items...)
+	if cmd := m.chat.ScrollToBottomAndAnimate(); cmd != nil {
+		cmds = append(cmds, cmd)
+	}
+	m.chat.SelectLast()
+	if cmd := m.chat.LoadThumbnails(chat.NewImageSettings(&m.caps), items...); cmd != nil {
+		cmds = append(cmds, cmd)
+	}
+	return tea.Batch(cmds...)
+}
+
+// sessionMessageItems 将会话消息转换为聊天项，并链接工具结果和嵌套工具调用
+func (m *UI) sessionMessageItems(msgs []message.Message) []chat.MessageItem {
 	// 构建工具结果映射以链接工具调用及其结果
 	msgPtrs := make([]*message.Message, len(msgs))
 	for i := range msgs {
@@ -780,16 +1065,25 @@ func (m *UI) setSessionMessages(msgs []message.Message) tea.Cmd {
 
 	// 添加消息到聊天，并链接工具结果
 	items := make([]chat.MessageItem, 0, len(msgs)*2)
-	for _, msg := range msgPtrs {
+	turnStart := 0
+	for i, msg := range msgPtrs {
 		switch msg.Role {
 		case message.User:
 			m.lastUserMessageTime = msg.CreatedAt
+			turnStart = i + 1
 			items = append(items, chat.ExtractMessageItems(m.com.Styles, msg, toolResultMap)...)
 		case message.Assistant:
 			items = append(items, chat.ExtractMessageItems(m.com.Styles, msg, toolResultMap)...)
 			if msg.FinishPart() != nil && msg.FinishPart().Reason == message.FinishReasonEndTurn {
+				turn := msgPtrs[turnStart : i+1]
+				if citationsItem := m.citationsItem(msg, turn); citationsItem != nil {
+					items = append(items, citationsItem)
+				}
 				infoItem := chat.NewAssistantInfoItem(m.com.Styles, msg, m.com.Config(), time.Unix(m.lastUserMessageTime, 0))
 				items = append(items, infoItem)
+				if summaryItem := m.turnSummaryItem(msg, turn); summaryItem != nil {
+					items = append(items, summaryItem)
+				}
 			}
 		default:
 			items = append(items, chat.ExtractMessageItems(m.com.Styles, msg, toolResultMap)...)
@@ -798,22 +1092,7 @@ func (m *UI) setSessionMessages(msgs []message.Message) tea.Cmd {
 
 	// 为智能体/agentic_fetch工具加载嵌套工具调用
 	m.loadNestedToolCalls(items)
-
-	// 如果用户在智能体工作时切换会话，我们要确保显示动画
-	for _, item := range items {
-		if animatable, ok := item.(chat.Animatable); ok {
-			if cmd := animatable.StartAnimation(); cmd != nil {
-				cmds = append(cmds, cmd)
-			}
-		}
-	}
-
-	m.chat.SetMessages(items...)
-	if cmd := m.chat.ScrollToBottomAndAnimate(); cmd != nil {
-		cmds = append(cmds, cmd)
-	}
-	m.chat.SelectLast()
-	return tea.Batch(cmds...)
+	return items
 }
 
 // loadNestedToolCalls 递归加载智能体/agentic_fetch工具的嵌套工具调用
@@ -917,8 +1196,7 @@ func (m *UI) appendSessionMessage(msg message.Message) tea.Cmd {
 			}
 		}
 		if msg.FinishPart() != nil && msg.FinishPart().Reason == message.FinishReasonEndTurn {
-			infoItem := chat.NewAssistantInfoItem(m.com.Styles, &msg, m.com.Config(), time.Unix(m.lastUserMessageTime, 0))
-			m.chat.AppendMessages(infoItem)
+			m.appendTurnEnd(msg)
 			if atBottom {
 				if cmd := m.chat.ScrollToBottomAndAnimate(); cmd != nil {
 					cmds = append(cmds, cmd)
@@ -934,6 +1212,9 @@ func (m *UI) appendSessionMessage(msg message.Message) tea.Cmd {
 			}
 			if toolMsgItem, ok := toolItem.(chat.ToolMessageItem); ok {
 				toolMsgItem.SetResult(&tr)
+				if cmd := m.chat.LoadThumbnails(chat.NewImageSettings(&m.caps), toolMsgItem); cmd != nil {
+					cmds = append(cmds, cmd)
+				}
 				if atBottom {
 					if cmd := m.chat.ScrollToBottomAndAnimate(); cmd != nil {
 						cmds = append(cmds, cmd)
@@ -951,7 +1232,7 @@ func (m *UI) handleClickFocus(msg tea.MouseClickMsg) (cmd tea.Cmd) {
 		return nil
 	case image.Pt(msg.X, msg.Y).In(m.layout.sidebar):
 		return nil
-	case m.focus != uiFocusEditor && image.Pt(msg.X, msg.Y).In(m.layout.editor):
+	case m.focus != uiFocusEditor && m.replay == nil && image.Pt(msg.X, msg.Y).In(m.layout.editor):
 		m.focus = uiFocusEditor
 		cmd = m.textarea.Focus()
 		m.chat.Blur()
@@ -967,6 +1248,16 @@ func (m *UI) handleClickFocus(msg tea.MouseClickMsg) (cmd tea.Cmd) {
 // 当助手消息更新时，它可能还包括更新的工具调用
 // 这就是为什么我们需要处理创建/更新每个工具调用消息
 func (m *UI) updateSessionMessage(msg message.Message) tea.Cmd {
+	if msg.Role == message.Tool {
+		// 工具结果保存后仍可能更新，例如附加编辑后检查失败的报告
+		for _, tr := range msg.ToolResults() {
+			if toolItem, ok := m.chat.MessageItem(tr.ToolCallID).(chat.ToolMessageItem); ok {
+				toolItem.SetResult(&tr)
+			}
+		}
+		return nil
+	}
+
 	var cmds []tea.Cmd
 	existingItem := m.chat.MessageItem(msg.ID)
 	atBottom := m.chat.list.AtBottom()
@@ -974,6 +1265,7 @@ func (m *UI) updateSessionMessage(msg message.Message) tea.Cmd {
 	if existingItem != nil {
 		if assistantItem, ok := existingItem.(*chat.AssistantMessageItem); ok {
 			assistantItem.SetMessage(&msg)
+			m.refreshReasoningTraceDialog(msg.ID)
 		}
 	}
 
@@ -984,12 +1276,17 @@ func (m *UI) updateSessionMessage(msg message.Message) tea.Cmd {
 		if infoItem := m.chat.MessageItem(chat.AssistantInfoID(msg.ID)); infoItem != nil {
 			m.chat.RemoveMessage(chat.AssistantInfoID(msg.ID))
 		}
+		if summaryItem := m.chat.MessageItem(chat.TurnSummaryID(msg.ID)); summaryItem != nil {
+			m.chat.RemoveMessage(chat.TurnSummaryID(msg.ID))
+		}
+		if citationsItem := m.chat.MessageItem(chat.CitationsID(msg.ID)); citationsItem != nil {
+			m.chat.RemoveMessage(chat.CitationsID(msg.ID))
+		}
 	}
 
 	if shouldRenderAssistant && msg.FinishPart() != nil && msg.FinishPart().Reason == message.FinishReasonEndTurn {
 		if infoItem := m.chat.MessageItem(chat.AssistantInfoID(msg.ID)); infoItem == nil {
-			newInfoItem := chat.NewAssistantInfoItem(m.com.Styles, &msg, m.com.Config(), time.Unix(m.lastUserMessageTime, 0))
-			m.chat.AppendMessages(newInfoItem)
+			m.appendTurnEnd(msg)
 		}
 	}
 
@@ -1008,6 +1305,7 @@ func (m *UI) updateSessionMessage(msg message.Message) tea.Cmd {
 			items = append(items, chat.NewToolMessageItem(m.com.Styles, msg.ID, tc, nil, false))
 		}
 	}
+	m.applyToolTimeouts(items...)
 
 	for _, item := range items {
 		if animatable, ok := item.(chat.Animatable); ok {
@@ -1018,6 +1316,9 @@ func (m *UI) updateSessionMessage(msg message.Message) tea.Cmd {
 	}
 
 	m.chat.AppendMessages(items...)
+	if cmd := m.startToolDeadlineTick(); cmd != nil {
+		cmds = append(cmds, cmd)
+	}
 	if atBottom {
 		if cmd := m.chat.ScrollToBottomAndAnimate(); cmd != nil {
 			cmds = append(cmds, cmd)
@@ -1136,7 +1437,7 @@ func (m *UI) handleDialogMsg(msg tea.Msg) tea.Cmd {
 			break
 		}
 
-		if m.dialog.ContainsDialog(dialog.FilePickerID) {
+		if m.dialog.ContainsDialog(dialog.FilePickerID) || m.dialog.ContainsDialog(dialog.ImageViewerID) {
 			defer fimage.ResetCache()
 		}
 
@@ -1159,7 +1460,7 @@ func (m *UI) handleDialogMsg(msg tea.Msg) tea.Cmd {
 	// 会话对话框消息
 	case dialog.ActionSelectSession:
 		m.dialog.CloseDialog(dialog.SessionsID)
-		cmds = append(cmds, m.loadSession(msg.Session.ID))
+		cmds = append(cmds, m.openSessionInTab(msg.Session.ID, msg.Session.Title))
 
 	// 打开对话框消息
 	case dialog.ActionOpenDialog:
@@ -1174,6 +1475,34 @@ func (m *UI) handleDialogMsg(msg tea.Msg) tea.Cmd {
 		m.com.App.Permissions.SetSkipRequests(yolo)
 		m.setEditorPrompt(yolo)
 		m.dialog.CloseDialog(dialog.CommandsID)
+	case dialog.ActionAuthenticateMCP:
+		m.dialog.CloseDialog(dialog.CommandsID)
+		if cmd := m.openMCPAuthDialog(msg.Name); cmd != nil {
+			cmds = append(cmds, cmd)
+		}
+	case dialog.ActionMCPAuthenticated:
+		m.dialog.CloseDialog(dialog.OAuthID)
+		cfg := m.com.Config()
+		cmds = append(cmds, func() tea.Msg {
+			if err := mcp.Reconnect(context.Background(), cfg, msg.Name); err != nil {
+				return util.NewErrorMsg(fmt.Errorf("MCP 服务器 %s 重新连接失败: %w", msg.Name, err))
+			}
+			return util.NewInfoMsg(fmt.Sprintf("MCP 服务器 %s 认证成功", msg.Name))
+		})
+	case dialog.ActionToggleDryRun:
+		enabled := !m.com.App.DryRun.Enabled()
+		m.com.App.DryRun.SetEnabled(enabled)
+		m.dialog.CloseDialog(dialog.CommandsID)
+		if enabled {
+			cmds = append(cmds, util.ReportInfo("已启用演练模式：编辑工具不会修改文件，更改将写入补丁文件"))
+		} else if m.hasSession() {
+			cmds = append(cmds, util.ReportInfo("已禁用演练模式，本会话的补丁文件："+m.com.App.DryRun.PatchPath(m.session.ID)))
+		} else {
+			cmds = append(cmds, util.ReportInfo("已禁用演练模式"))
+		}
+	case dialog.ActionTogglePlanMode:
+		m.dialog.CloseDialog(dialog.CommandsID)
+		cmds = append(cmds, m.togglePlanMode())
 	case dialog.ActionNewSession:
 		if m.isAgentBusy() {
 			cmds = append(cmds, util.ReportWarn("智能体忙碌，请等待后再开始新会话..."))
@@ -1183,6 +1512,16 @@ func (m *UI) handleDialogMsg(msg tea.Msg) tea.Cmd {
 			cmds = append(cmds, cmd)
 		}
 		m.dialog.CloseDialog(dialog.CommandsID)
+	case dialog.ActionNewTab:
+		m.dialog.CloseDialog(dialog.CommandsID)
+		if cmd := m.newTab(); cmd != nil {
+			cmds = append(cmds, cmd)
+		}
+	case dialog.ActionCloseTab:
+		m.dialog.CloseDialog(dialog.CommandsID)
+		if cmd := m.closeTab(); cmd != nil {
+			cmds = append(cmds, cmd)
+		}
 	case dialog.ActionSummarize:
 		if m.isAgentBusy() {
 			cmds = append(cmds, util.ReportWarn("智能体忙碌，请等待后再总结会话..."))
@@ -1199,6 +1538,128 @@ func (m *UI) handleDialogMsg(msg tea.Msg) tea.Cmd {
 	case dialog.ActionToggleHelp:
 		m.status.ToggleHelp()
 		m.dialog.CloseDialog(dialog.CommandsID)
+	case dialog.ActionCleanupSessions:
+		m.dialog.CloseDialog(dialog.CommandsID)
+		cmds = append(cmds, m.checkRetention(true))
+	case dialog.ActionPruneSessions:
+		m.dialog.CloseDialog(dialog.RetentionID)
+		cmds = append(cmds, m.pruneSessions(msg.Sessions))
+	case dialog.ActionConfirmBudget:
+		m.dialog.CloseDialog(dialog.BudgetID)
+		m.com.App.Budget.Confirm()
+		m.budget.Confirmed = true
+		cmds = append(cmds, m.sendMessage(msg.Content, msg.Attachments...))
+	case dialog.ActionDeclineBudget:
+		m.dialog.CloseDialog(dialog.BudgetID)
+		m.restorePrompt(msg.Content, msg.Attachments)
+	case dialog.ActionSendPrompt:
+		m.dialog.CloseDialog(dialog.PromptLintID)
+		m.dialog.CloseDialog(dialog.DuplicatePromptID)
+		cmds = append(cmds, m.sendMessage(msg.Content, msg.Attachments...), m.loadPromptHistory())
+	case dialog.ActionEnhancePrompt:
+		cmds = append(cmds, m.enhancePrompt(msg.Content))
+	case dialog.ActionSetAttachments:
+		m.attachments.Set(msg.Attachments)
+		if len(msg.Attachments) == 0 {
+			m.dialog.CloseDialog(dialog.AttachmentManagerID)
+		}
+	case dialog.ActionViewAttachment:
+		cmds = append(cmds, m.openImageViewerDialog(attachmentImage(msg.Attachment)))
+	case dialog.ActionEditPrompt:
+		m.dialog.CloseDialog(dialog.PromptLintID)
+		m.dialog.CloseDialog(dialog.DuplicatePromptID)
+		m.restorePrompt(msg.Content, msg.Attachments)
+	case dialog.ActionJumpToPrompt:
+		m.dialog.CloseDialog(dialog.DuplicatePromptID)
+		m.restorePrompt(msg.Content, msg.Attachments)
+		if cmd := m.jumpToAnswer(msg.MessageID); cmd != nil {
+			cmds = append(cmds, cmd)
+		}
+	case dialog.ActionResumeSession:
+		m.dialog.CloseDialog(dialog.ResumeID)
+		cmds = append(cmds, m.resumeSession(msg.Session, msg.Run, msg.Restart))
+	case dialog.ActionDiscardRun:
+		m.dialog.CloseDialog(dialog.ResumeID)
+		cmds = append(cmds, m.discardRun(msg.SessionID))
+	case dialog.ActionReplaySession:
+		cmds = append(cmds, m.startReplay(msg.SessionID))
+		m.dialog.CloseDialog(dialog.CommandsID)
+	case dialog.ActionPasteAttach:
+		m.dialog.CloseDialog(dialog.PastePreviewID)
+		cmds = append(cmds, m.pasteAttachment(msg.Content))
+	case dialog.ActionPasteInsert:
+		m.dialog.CloseDialog(dialog.PastePreviewID)
+		m.textarea.InsertString(msg.Content)
+	case dialog.ActionSetPinnedFiles:
+		cmds = append(cmds, m.setPinnedFiles(msg.SessionID, msg.Paths))
+	case dialog.ActionAddBookmark:
+		m.dialog.CloseFrontDialog()
+		cmds = append(cmds, m.addBookmark(msg))
+	case dialog.ActionSetBookmarks:
+		cmds = append(cmds, m.setBookmarks(msg.SessionID, msg.Bookmarks))
+	case dialog.ActionJumpToBookmark:
+		m.dialog.CloseDialog(dialog.BookmarksID)
+		if cmd := m.jumpToBookmark(msg.ItemID); cmd != nil {
+			cmds = append(cmds, cmd)
+		}
+	case dialog.ActionRegenerate:
+		m.dialog.CloseDialog(dialog.RegenerateID)
+		cmds = append(cmds, m.regenerate(msg))
+	case dialog.ActionKeepVariant:
+		m.dialog.CloseDialog(dialog.ReplyVariantsID)
+		cmds = append(cmds, m.keepVariant(msg))
+	case dialog.ActionDeleteVariant:
+		cmds = append(cmds, m.deleteVariant(msg))
+	case dialog.ActionSetWorkspaceRoots:
+		m.dialog.CloseDialog(dialog.WorkspaceRootsID)
+		cmds = append(cmds, m.setWorkspaceRoots(msg.Roots))
+	case dialog.ActionSetSessionEnv:
+		m.dialog.CloseDialog(dialog.SessionEnvID)
+		cmds = append(cmds, m.setSessionEnv(msg.SessionID, msg.Env))
+	case dialog.ActionSetTodos:
+		m.dialog.CloseDialog(dialog.TodosID)
+		cmds = append(cmds, m.setTodos(msg.SessionID, msg.Todos))
+	case dialog.ActionSetSessionWorkingDir:
+		m.dialog.CloseDialog(dialog.SessionSettingsID)
+		cmds = append(cmds, m.setSessionWorkingDir(msg.SessionID, msg.Dir))
+	case dialog.ActionOpenSessionTags:
+		if cmd := m.openSessionTagsDialog(msg.SessionID, msg.Tags); cmd != nil {
+			cmds = append(cmds, cmd)
+		}
+	case dialog.ActionSetSessionTags:
+		m.dialog.CloseDialog(dialog.SessionTagsID)
+		if d, ok := m.dialog.Dialog(dialog.SessionsID).(*dialog.Session); ok {
+			d.SetSessionTags(msg.SessionID, msg.Tags)
+		}
+		cmds = append(cmds, m.setSessionTags(msg.SessionID, msg.Tags))
+	case dialog.ActionApprovePlan:
+		m.dialog.CloseDialog(dialog.PlanID)
+		cmds = append(cmds, m.approvePlan(msg.SessionID, msg.Todos, msg.Edited))
+	case dialog.ActionCreateCheckpoint:
+		m.dialog.CloseDialog(dialog.CheckpointsID)
+		cmds = append(cmds, m.createCheckpoint(msg.SessionID, msg.Name))
+	case dialog.ActionRestoreCheckpoint:
+		if m.isAgentBusy() {
+			cmds = append(cmds, util.ReportWarn("智能体正在工作，请等待..."))
+			break
+		}
+		m.dialog.CloseDialog(dialog.CheckpointsID)
+		cmds = append(cmds, m.restoreCheckpoint(msg.SessionID, msg.ID, msg.Name))
+	case dialog.ActionDetectProjectSetup:
+		m.dialog.CloseDialog(dialog.CommandsID)
+		cmds = append(cmds, m.detectProjectSetup(true))
+	case dialog.ActionApplyProjectSetup:
+		cmds = append(cmds, m.applyProjectSetup(msg.Suggestions))
+	case dialog.ActionSkipProjectSetup:
+		cmds = append(cmds, m.skipProjectSetup())
+	case dialog.ActionSessionDiff:
+		cmds = append(cmds, m.loadSessionDiff(msg.SessionID))
+		m.dialog.CloseDialog(dialog.CommandsID)
+	case dialog.ActionExportSessionPatch:
+		cmds = append(cmds, m.exportSessionPatch(msg.SessionID, msg.Changes))
+	case dialog.ActionRedactionReport:
+		cmds = append(cmds, m.redactionReport(msg.SessionID))
+		m.dialog.CloseDialog(dialog.CommandsID)
 	case dialog.ActionExternalEditor:
 		if m.isAgentBusy() {
 			cmds = append(cmds, util.ReportWarn("智能体正在工作，请等待..."))
@@ -1209,6 +1670,9 @@ func (m *UI) handleDialogMsg(msg tea.Msg) tea.Cmd {
 	case dialog.ActionToggleCompactMode:
 		cmds = append(cmds, m.toggleCompactMode())
 		m.dialog.CloseDialog(dialog.CommandsID)
+	case dialog.ActionCycleLayout:
+		cmds = append(cmds, m.cycleLayout())
+		m.dialog.CloseDialog(dialog.CommandsID)
 	case dialog.ActionToggleThinking:
 		cmds = append(cmds, func() tea.Msg {
 			cfg := m.com.Config()
@@ -1235,7 +1699,8 @@ func (m *UI) handleDialogMsg(msg tea.Msg) tea.Cmd {
 		})
 		m.dialog.CloseDialog(dialog.CommandsID)
 	case dialog.ActionQuit:
-		cmds = append(cmds, tea.Quit)
+		m.cancelVoiceInput()
+		cmds = append(cmds, tea.Sequence(m.terminal.ResetCmd(), tea.Quit))
 	case dialog.ActionInitializeProject:
 		if m.isAgentBusy() {
 			cmds = append(cmds, util.ReportWarn("智能体忙碌，请等待后再总结会话..."))
@@ -1245,7 +1710,7 @@ func (m *UI) handleDialogMsg(msg tea.Msg) tea.Cmd {
 		m.dialog.CloseDialog(dialog.CommandsID)
 
 	case dialog.ActionSelectModel:
-		if m.isAgentBusy() {
+		if m.isAnyAgentBusy() {
 			cmds = append(cmds, util.ReportWarn("智能体忙碌，请等待..."))
 			break
 		}
@@ -1305,9 +1770,15 @@ func (m *UI) handleDialogMsg(msg tea.Msg) tea.Cmd {
 			if err := m.com.App.InitCoderAgent(context.TODO()); err != nil {
 				cmds = append(cmds, util.ReportError(err))
 			}
+			// 引导的最后一步：为检测到的项目语言配置 LSP。
+			if len(dialog.DetectLSPSuggestions(m.com.Config())) > 0 {
+				if cmd := m.openLSPSetupDialog(); cmd != nil {
+					cmds = append(cmds, cmd)
+				}
+			}
 		}
 	case dialog.ActionSelectReasoningEffort:
-		if m.isAgentBusy() {
+		if m.isAnyAgentBusy() {
 			cmds = append(cmds, util.ReportWarn("智能体忙碌，请等待..."))
 			break
 		}
@@ -1343,6 +1814,19 @@ func (m *UI) handleDialogMsg(msg tea.Msg) tea.Cmd {
 			m.com.App.Permissions.Grant(msg.Permission)
 		case dialog.PermissionAllowForSession:
 			m.com.App.Permissions.GrantPersistent(msg.Permission)
+		case dialog.PermissionAllowWindow:
+			m.com.App.Permissions.GrantWindow(msg.Permission, msg.Window)
+			cmds = append(cmds, util.ReportInfo(fmt.Sprintf("已在本次会话中允许 %s %s，之后恢复确认", msg.Permission.ToolName, msg.Window)))
+		case dialog.PermissionAllowAlways:
+			if msg.Permission.ToolName == mcp.SamplingToolName {
+				m.com.App.Permissions.AllowTool(msg.Permission.ToolName + ":" + msg.Permission.Action)
+				m.com.App.Permissions.Grant(msg.Permission)
+				cmds = append(cmds, m.allowSamplingAlways(msg.Permission.Action))
+				break
+			}
+			m.com.App.Permissions.AllowTool(msg.Permission.ToolName)
+			m.com.App.Permissions.Grant(msg.Permission)
+			cmds = append(cmds, m.allowToolAlways(msg.Permission.ToolName))
 		case dialog.PermissionDeny:
 			m.com.App.Permissions.Deny(msg.Permission)
 		}
@@ -1360,6 +1844,20 @@ func (m *UI) handleDialogMsg(msg tea.Msg) tea.Cmd {
 			},
 		))
 
+	case dialog.ActionAskAboutSelection:
+		m.selection = ""
+		cmds = append(cmds, m.sendMessage(msg.Content))
+		m.dialog.CloseFrontDialog()
+	case dialog.ActionRestartLSP:
+		cmds = append(cmds, m.restartLSP(msg.Name))
+	case dialog.ActionStopLSP:
+		cmds = append(cmds, m.stopLSP(msg.Name))
+	case dialog.ActionExportSelectionImage:
+		m.dialog.CloseFrontDialog()
+		cmds = append(cmds, m.exportSelectionImage(msg.Content, msg.Copy))
+	case dialog.ActionRunCodeBlock:
+		m.dialog.CloseFrontDialog()
+		cmds = append(cmds, m.runCodeBlock(msg.Block))
 	case dialog.ActionRunCustomCommand:
 		if len(msg.Arguments) > 0 && msg.Args == nil {
 			m.dialog.CloseFrontDialog()
@@ -1397,6 +1895,31 @@ func (m *UI) handleDialogMsg(msg tea.Msg) tea.Cmd {
 			break
 		}
 		cmds = append(cmds, m.runMCPPrompt(msg.ClientID, msg.PromptID, msg.Args))
+	case dialog.ActionSaveSnippet:
+		if msg.Name == "" {
+			content := msg.Content
+			if content == "" {
+				content = m.textarea.Value()
+			}
+			if strings.TrimSpace(content) == "" {
+				cmds = append(cmds, util.ReportWarn("输入框为空，没有可保存的片段"))
+				break
+			}
+			m.dialog.CloseFrontDialog()
+			m.dialog.OpenDialog(dialog.NewArguments(
+				m.com,
+				"保存为片段",
+				"片段中 $FILE 形式的占位符会在插入时要求填写。",
+				[]commands.Argument{{ID: dialog.SnippetNameArg, Title: "名称", Required: true}},
+				dialog.ActionSaveSnippet{Content: content},
+			))
+			break
+		}
+		m.dialog.CloseFrontDialog()
+		cmds = append(cmds, m.saveSnippet(msg.Name, msg.Content))
+	case dialog.ActionInsertSnippet:
+		m.dialog.CloseFrontDialog()
+		m.textarea.InsertString(substituteArgs(msg.Content, msg.Args))
 	default:
 		cmds = append(cmds, util.CmdHandler(msg))
 	}
@@ -1463,10 +1986,44 @@ func (m *UI) handleKeyPressMsg(msg tea.KeyPressMsg) tea.Cmd {
 				cmds = append(cmds, cmd)
 			}
 			return true
+		case key.Matches(msg, m.keyMap.Layout):
+			if m.state == uiChat {
+				cmds = append(cmds, m.cycleLayout())
+				return true
+			}
+		case key.Matches(msg, m.keyMap.Tabs.New):
+			if m.state == uiLanding || m.state == uiChat {
+				if cmd := m.newTab(); cmd != nil {
+					cmds = append(cmds, cmd)
+				}
+				return true
+			}
+		case key.Matches(msg, m.keyMap.Tabs.Switch):
+			if m.hasTabBar() {
+				// 按键以数字结尾，例如 ctrl+1
+				keystroke := msg.String()
+				if cmd := m.switchTab(int(keystroke[len(keystroke)-1] - '1')); cmd != nil {
+					cmds = append(cmds, cmd)
+				}
+				return true
+			}
 		case key.Matches(msg, m.keyMap.Chat.Details) && m.isCompact:
 			m.detailsOpen = !m.detailsOpen
 			m.updateLayoutAndSize()
 			return true
+		case key.Matches(msg, m.keyMap.Chat.ReviewPlan):
+			if m.state == uiChat && m.hasSession() && m.session.PlanAwaitingApproval() {
+				if cmd := m.openPlanDialog(); cmd != nil {
+					cmds = append(cmds, cmd)
+				}
+				return true
+			}
+			if m.state == uiChat && m.hasSession() && len(m.session.Todos) > 0 {
+				if cmd := m.openTodosDialog(); cmd != nil {
+					cmds = append(cmds, cmd)
+				}
+				return true
+			}
 		case key.Matches(msg, m.keyMap.Chat.TogglePills):
 			if m.state == uiChat && m.hasSession() {
 				if cmd := m.togglePillsExpanded(); cmd != nil {
@@ -1476,6 +2033,10 @@ func (m *UI) handleKeyPressMsg(msg tea.KeyPressMsg) tea.Cmd {
 			}
 		case key.Matches(msg, m.keyMap.Chat.PillLeft):
 			if m.state == uiChat && m.hasSession() && m.pillsExpanded && m.focus != uiFocusEditor {
+				// 选中的工具项处于水平滚动模式时左右键滚动代码
+				if m.chat.ScrollCodeSelectedItem(-1) {
+					return true
+				}
 				if cmd := m.switchPillSection(-1); cmd != nil {
 					cmds = append(cmds, cmd)
 				}
@@ -1483,13 +2044,22 @@ func (m *UI) handleKeyPressMsg(msg tea.KeyPressMsg) tea.Cmd {
 			}
 		case key.Matches(msg, m.keyMap.Chat.PillRight):
 			if m.state == uiChat && m.hasSession() && m.pillsExpanded && m.focus != uiFocusEditor {
+				// 选中的工具项处于水平滚动模式时左右键滚动代码
+				if m.chat.ScrollCodeSelectedItem(1) {
+					return true
+				}
 				if cmd := m.switchPillSection(1); cmd != nil {
 					cmds = append(cmds, cmd)
 				}
 				return true
 			}
+		case key.Matches(msg, m.keyMap.Chat.AskSelection):
+			if m.state == uiChat && m.selection != "" {
+				m.openSelectionActionsDialog()
+				return true
+			}
 		case key.Matches(msg, m.keyMap.Suspend):
-			if m.isAgentBusy() {
+			if m.isAnyAgentBusy() {
 				cmds = append(cmds, util.ReportWarn("智能体忙碌，请等待..."))
 				return true
 			}
@@ -1523,6 +2093,14 @@ func (m *UI) handleKeyPressMsg(msg tea.KeyPressMsg) tea.Cmd {
 		}
 	}
 
+	// 回放模式中只处理全局按键和回放按键
+	if m.replay != nil && m.state == uiChat {
+		if !handleGlobalKeys(msg) {
+			cmds = append(cmds, m.handleReplayKey(msg))
+		}
+		return tea.Batch(cmds...)
+	}
+
 	switch m.state {
 	case uiOnboarding:
 		return tea.Batch(cmds...)
@@ -1537,8 +2115,15 @@ func (m *UI) handleKeyPressMsg(msg tea.KeyPressMsg) tea.Cmd {
 				if msg, ok := m.completions.Update(msg); ok {
 					switch msg := msg.(type) {
 					case completions.SelectionMsg[completions.FileCompletionValue]:
-						cmds = append(cmds, m.insertFileCompletion(msg.Value.Path))
+						cmd, keepOpen := m.selectFileCompletion(msg.Value.Path, msg.KeepOpen)
+						cmds = append(cmds, cmd)
+						if !keepOpen {
+							m.closeCompletions()
+						}
+					case completions.SelectionMsg[completions.LineRangeCompletionValue]:
+						// 上下移动时只切换选中的范围，确认后才插入
 						if !msg.KeepOpen {
+							cmds = append(cmds, m.insertLineRangeCompletion(msg.Value))
 							m.closeCompletions()
 						}
 					case completions.SelectionMsg[completions.ResourceCompletionValue]:
@@ -1546,9 +2131,36 @@ func (m *UI) handleKeyPressMsg(msg tea.KeyPressMsg) tea.Cmd {
 						if !msg.KeepOpen {
 							m.closeCompletions()
 						}
+					case completions.SelectionMsg[completions.CustomCompletionValue]:
+						// 提示词可能包含多个单词，上下移动时不插入，确认后才插入
+						if !msg.KeepOpen {
+							cmds = append(cmds, m.insertCustomCompletion(msg.Value))
+							m.closeCompletions()
+						}
+					case completions.SelectionMsg[completions.SnippetCompletionValue]:
+						if !msg.KeepOpen {
+							m.insertSnippetCompletion(msg.Value)
+							m.closeCompletions()
+						}
+					case completions.SelectionMsg[completions.SymbolCompletionValue]:
+						if m.completionsTrigger == "@" {
+							// @补全中的符号引用其定义所在的行，上下移动时不插入，确认后才插入
+							if !msg.KeepOpen {
+								cmds = append(cmds, m.insertSymbolMention(msg.Value))
+								m.closeCompletions()
+							}
+							break
+						}
+						m.insertSymbolCompletion(msg.Value)
+						if !msg.KeepOpen {
+							m.closeCompletions()
+						}
 					case completions.ClosedMsg:
 						m.completionsOpen = false
 					}
+					if m.completionsOpen && m.completionsTrigger == "#" {
+						cmds = append(cmds, m.updateSymbolDetail())
+					}
 					return tea.Batch(cmds...)
 				}
 			}
@@ -1558,12 +2170,26 @@ func (m *UI) handleKeyPressMsg(msg tea.KeyPressMsg) tea.Cmd {
 			}
 
 			switch {
+			case key.Matches(msg, m.keyMap.Editor.ManageAttachments):
+				if len(m.attachments.List()) == 0 {
+					cmds = append(cmds, util.ReportInfo("当前消息没有附件"))
+					break
+				}
+				m.dialog.OpenDialog(dialog.NewAttachmentManager(m.com, m.attachments.List()))
 			case key.Matches(msg, m.keyMap.Editor.AddImage):
+				if cmd := m.requireCapability(capability.Images); cmd != nil {
+					cmds = append(cmds, cmd)
+					break
+				}
 				if cmd := m.openFilesDialog(); cmd != nil {
 					cmds = append(cmds, cmd)
 				}
 
 			case key.Matches(msg, m.keyMap.Editor.PasteImage):
+				if cmd := m.requireCapability(capability.Images); cmd != nil {
+					cmds = append(cmds, cmd)
+					break
+				}
 				cmds = append(cmds, m.pasteImageFromClipboard)
 
 			case key.Matches(msg, m.keyMap.Editor.SendMessage):
@@ -1591,6 +2217,12 @@ func (m *UI) handleKeyPressMsg(msg tea.KeyPressMsg) tea.Cmd {
 				m.randomizePlaceholders()
 				m.historyReset()
 
+				if m.confirmDuplicatePrompt(value, attachments) {
+					return nil
+				}
+				if m.lintPrompt(value, attachments) {
+					return nil
+				}
 				return tea.Batch(m.sendMessage(value, attachments...), m.loadPromptHistory())
 			case key.Matches(msg, m.keyMap.Chat.NewSession):
 				if !m.hasSession() {
@@ -1610,6 +2242,13 @@ func (m *UI) handleKeyPressMsg(msg tea.KeyPressMsg) tea.Cmd {
 					m.chat.Focus()
 					m.chat.SetSelected(m.chat.Len() - 1)
 				}
+			case key.Matches(msg, m.keyMap.Editor.Voice):
+				if cmd := m.toggleVoiceInput(); cmd != nil {
+					cmds = append(cmds, cmd)
+				}
+			case key.Matches(msg, m.keyMap.Editor.Escape) && m.recording != nil:
+				m.cancelVoiceInput()
+				cmds = append(cmds, util.ReportInfo("已取消录音"))
 			case key.Matches(msg, m.keyMap.Editor.OpenEditor):
 				if m.isAgentBusy() {
 					cmds = append(cmds, util.ReportWarn("智能体正在工作，请等待..."))
@@ -1656,30 +2295,56 @@ func (m *UI) handleKeyPressMsg(msg tea.KeyPressMsg) tea.Cmd {
 					// 仅在提示开头或空白字符之后显示
 					if curIdx == 0 || (curIdx > 0 && isWhitespace(curValue[curIdx-1])) {
 						m.completionsOpen = true
+						m.completionsTrigger = "@"
 						m.completionsQuery = ""
 						m.completionsStartIndex = curIdx
 						m.completionsPositionStart = m.completionsPosition()
-						depth, limit := m.com.Config().Options.TUI.Completions.Limits()
-						cmds = append(cmds, m.completions.Open(depth, limit))
+						completionsCfg := m.com.Config().Options.TUI.Completions
+						depth, limit := completionsCfg.Limits()
+						cmds = append(cmds, m.completions.Open(depth, limit, completionsCfg.CustomEntries()))
 					}
 				}
 
-				// 如果用户开始输入时详情打开，则移除详情
-				if m.detailsOpen {
-					m.detailsOpen = false
-					m.updateLayoutAndSize()
+				// 在#上触发 LSP 符号补全，仅在有已就绪的语言服务器时
+				if msg.String() == "#" && !m.completionsOpen && len(m.com.App.LSPManager.ReadyClients()) > 0 {
+					if curIdx == 0 || (curIdx > 0 && isWhitespace(curValue[curIdx-1])) {
+						m.completionsOpen = true
+						m.completionsTrigger = "#"
+						m.completionsQuery = ""
+						m.completionsStartIndex = curIdx
+						m.completionsPositionStart = m.completionsPosition()
+						m.completions.SetSymbols(nil)
+					}
 				}
 
-				ta, cmd := m.textarea.Update(msg)
-				m.textarea = ta
-				cmds = append(cmds, cmd)
-
-				// 任何文本修改都成为当前草稿
+				// 在;上触发片段补全
+				if msg.String() == ";" && !m.completionsOpen {
+					if curIdx == 0 || (curIdx > 0 && isWhitespace(curValue[curIdx-1])) {
+						m.completionsOpen = true
+						m.completionsTrigger = ";"
+						m.completionsQuery = ""
+						m.completionsStartIndex = curIdx
+						m.completionsPositionStart = m.completionsPosition()
+						cmds = append(cmds, completions.LoadSnippets(snippets.Dir()))
+					}
+				}
+
+				// 如果用户开始输入时详情打开，则移除详情
+				if m.detailsOpen {
+					m.detailsOpen = false
+					m.updateLayoutAndSize()
+				}
+
+				ta, cmd := m.textarea.Update(msg)
+				m.textarea = ta
+				cmds = append(cmds, cmd)
+
+				// 任何文本修改都成为当前草稿
 				m.updateHistoryDraft(curValue)
 
 				// 更新文本区域后，检查是否需要过滤自动完成
 				// 跳过初始@按键的过滤，因为项目正在异步加载
-				if m.completionsOpen && msg.String() != "@" {
+				if m.completionsOpen && msg.String() != m.completionsTrigger {
 					newValue := m.textarea.Value()
 					newIdx := len(newValue)
 
@@ -1692,9 +2357,24 @@ func (m *UI) handleKeyPressMsg(msg tea.KeyPressMsg) tea.Cmd {
 					} else {
 						// 提取当前单词并过滤
 						word := m.textareaWord()
-						if strings.HasPrefix(word, "@") {
-							m.completionsQuery = word[1:]
-							m.completions.Filter(m.completionsQuery)
+						if strings.HasPrefix(word, m.completionsTrigger) {
+							switch {
+							case m.completionsRangePath != "":
+								m.updateRangeStep(word[1:])
+							case m.completionsTrigger == ";":
+								m.completionsQuery = word[1:]
+								m.completions.Filter(m.completionsQuery)
+							case m.completionsTrigger == "#":
+								m.completionsQuery = word[1:]
+								m.completions.Filter(m.completionsQuery)
+								cmds = append(cmds, m.scheduleSymbolSearch(m.completionsQuery), m.updateSymbolDetail())
+							default:
+								// 文件引用可以带有行范围，例如 @main.go:120-180，只按路径过滤
+								m.completionsQuery = word[1:]
+								path, _ := completions.SplitMention(m.completionsQuery)
+								m.completions.Filter(path)
+								cmds = append(cmds, m.scheduleMentionSymbolSearch(m.completionsQuery))
+							}
 						} else if m.completionsOpen {
 							m.closeCompletions()
 						}
@@ -1721,6 +2401,52 @@ func (m *UI) handleKeyPressMsg(msg tea.KeyPressMsg) tea.Cmd {
 				}
 			case key.Matches(msg, m.keyMap.Chat.Expand):
 				m.chat.ToggleExpandedSelectedItem()
+			case key.Matches(msg, m.keyMap.Chat.ViewImage):
+				if img, ok := m.chat.SelectedImage(); ok {
+					if cmd := m.openImageViewerDialog(img); cmd != nil {
+						cmds = append(cmds, cmd)
+					}
+				} else if failed, ok := m.chat.SelectedFailedTool(); ok && failed.FilePath != "" {
+					cmds = append(cmds, m.openFailedToolFile(failed))
+				}
+			case key.Matches(msg, m.keyMap.Chat.ViewReasoning):
+				if trace, ok := m.chat.SelectedReasoningTrace(); ok {
+					m.openReasoningTraceDialog(trace)
+				}
+			case key.Matches(msg, m.keyMap.Chat.RunCode):
+				if failed, ok := m.chat.SelectedFailedTool(); ok {
+					cmds = append(cmds, m.retryFailedTool(failed))
+				} else if cmd := m.runSelectedCodeBlocks(); cmd != nil {
+					cmds = append(cmds, cmd)
+				}
+			case key.Matches(msg, m.keyMap.Chat.RereadFile):
+				if failed, ok := m.chat.SelectedFailedTool(); ok && failed.FilePath != "" {
+					cmds = append(cmds, m.rereadFailedToolFile(failed))
+				}
+			case key.Matches(msg, m.keyMap.Chat.Bookmark):
+				if cmd := m.toggleSelectedBookmark(); cmd != nil {
+					cmds = append(cmds, cmd)
+				}
+			case key.Matches(msg, m.keyMap.Chat.Regenerate):
+				if cmd := m.openRegenerateDialog(); cmd != nil {
+					cmds = append(cmds, cmd)
+				}
+			case key.Matches(msg, m.keyMap.Chat.Variants):
+				if cmd := m.openReplyVariantsDialog(); cmd != nil {
+					cmds = append(cmds, cmd)
+				}
+			case key.Matches(msg, m.keyMap.Chat.CopyLink):
+				if cmd := m.copyMessageLink(); cmd != nil {
+					cmds = append(cmds, cmd)
+				}
+			case key.Matches(msg, m.keyMap.Chat.CodeWrap):
+				if wrap, ok := m.chat.CycleCodeWrapSelectedItem(); ok {
+					cmds = append(cmds, util.ReportInfo("代码长行："+wrap.String()))
+				}
+			case key.Matches(msg, m.keyMap.Chat.CodeLeft):
+				m.chat.ScrollCodeSelectedItem(-1)
+			case key.Matches(msg, m.keyMap.Chat.CodeRight):
+				m.chat.ScrollCodeSelectedItem(1)
 			case key.Matches(msg, m.keyMap.Chat.Up):
 				if cmd := m.chat.ScrollByAndAnimate(-1); cmd != nil {
 					cmds = append(cmds, cmd)
@@ -1844,9 +2570,12 @@ func (m *UI) Draw(scr uv.Screen, area uv.Rectangle) *tea.Cursor {
 		editor.Draw(scr, layout.editor)
 
 	case uiChat:
-		if m.isCompact {
+		switch {
+		case m.isCompact:
 			m.drawHeader(scr, layout.header)
-		} else {
+		case m.panelLayout:
+			m.drawPanel(scr, layout.sidebar)
+		default:
 			m.drawSidebar(scr, layout.sidebar)
 		}
 
@@ -1859,8 +2588,11 @@ func (m *UI) Draw(scr uv.Screen, area uv.Rectangle) *tea.Cursor {
 		if !m.isCompact {
 			editorWidth -= layout.sidebar.Dx()
 		}
-		editor := uv.NewStyledString(m.renderEditorView(editorWidth))
-		editor.Draw(scr, layout.editor)
+		editorView := m.renderEditorView(editorWidth)
+		if m.replay != nil {
+			editorView = m.replayView(editorWidth)
+		}
+		uv.NewStyledString(editorView).Draw(scr, layout.editor)
 
 		// 在紧凑模式下打开时绘制详情覆盖层
 		if m.isCompact && m.detailsOpen {
@@ -1868,10 +2600,15 @@ func (m *UI) Draw(scr uv.Screen, area uv.Rectangle) *tea.Cursor {
 		}
 	}
 
+	if !layout.tabs.Empty() {
+		uv.NewStyledString(m.renderTabBar(layout.tabs.Dx())).Draw(scr, layout.tabs)
+	}
+
 	isOnboarding := m.state == uiOnboarding
 
 	// 添加状态和帮助层
 	m.status.SetHideHelp(isOnboarding)
+	m.status.SetWidgetInfo(m.statusWidgetInfo())
 	m.status.Draw(scr, layout.status)
 
 	// 如果打开，绘制自动完成弹出窗口
@@ -1888,10 +2625,14 @@ func (m *UI) Draw(scr uv.Screen, area uv.Rectangle) *tea.Cursor {
 		y = max(0, y)
 
 		completionsView := uv.NewStyledString(m.completions.Render())
-		completionsView.Draw(scr, image.Rectangle{
+		popup := image.Rectangle{
 			Min: image.Pt(x, y),
 			Max: image.Pt(x+w, y+h),
-		})
+		}
+		completionsView.Draw(scr, popup)
+		if m.completionsTrigger == "#" {
+			m.drawSymbolDetail(scr, area, popup)
+		}
 	}
 
 	// 调试渲染（视觉上查看tui何时重新渲染）
@@ -1943,7 +2684,7 @@ func (m *UI) View() tea.View {
 		v.BackgroundColor = m.com.Styles.Background
 	}
 	v.MouseMode = tea.MouseModeCellMotion
-	v.WindowTitle = "crush " + home.Short(m.com.Config().WorkingDir())
+	v.WindowTitle = m.terminal.Title(m.windowTitle(), m.isAnyAgentBusy())
 
 	canvas := uv.NewScreenBuffer(m.width, m.height)
 	v.Cursor = m.Draw(canvas, canvas.Bounds())
@@ -1958,7 +2699,7 @@ func (m *UI) View() tea.View {
 	content = strings.Join(contentLines, "\n")
 
 	v.Content = content
-	if m.progressBarEnabled && m.sendProgressBar && m.isAgentBusy() {
+	if m.progressBarEnabled && m.terminal.NativeProgress() && m.isAnyAgentBusy() {
 		// HACK: 使用随机百分比以防止ghostty在超时后隐藏它
 		v.ProgressBar = tea.NewProgressBar(tea.ProgressBarIndeterminate, rand.Intn(100))
 	}
@@ -1966,6 +2707,11 @@ func (m *UI) View() tea.View {
 	return v
 }
 
+// windowTitle 返回终端窗口标题
+func (m *UI) windowTitle() string {
+	return "crush " + home.Short(m.com.Config().WorkingDir())
+}
+
 // ShortHelp 实现 [help.KeyMap]
 func (m *UI) ShortHelp() []key.Binding {
 	var binds []key.Binding
@@ -1976,6 +2722,11 @@ func (m *UI) ShortHelp() []key.Binding {
 		commands.SetHelp("/ or ctrl+p", "命令")
 	}
 
+	if m.replay != nil && m.state == uiChat {
+		r := &k.Replay
+		return []key.Binding{r.Play, r.Prev, r.First, r.Exit, commands, k.Help}
+	}
+
 	switch m.state {
 	case uiInitialize:
 		binds = append(binds, k.Quit)
@@ -2002,6 +2753,14 @@ func (m *UI) ShortHelp() []key.Binding {
 			commands,
 			k.Models,
 		)
+		if m.selection != "" {
+			binds = append(binds, k.Chat.AskSelection)
+		}
+		if m.session.PlanAwaitingApproval() {
+			binds = append(binds, k.Chat.ReviewPlan)
+		} else if len(m.session.Todos) > 0 {
+			binds = append(binds, k.Chat.EditTodos)
+		}
 
 		switch m.focus {
 		case uiFocusEditor:
@@ -2052,6 +2811,14 @@ func (m *UI) FullHelp() [][]key.Binding {
 		commands.SetHelp("/ or ctrl+p", "命令")
 	}
 
+	if m.replay != nil && m.state == uiChat {
+		r := &k.Replay
+		return [][]key.Binding{
+			{r.Play, r.Prev, r.First, r.Exit},
+			{k.Chat.UpDown, commands, k.Sessions, help},
+		}
+	}
+
 	switch m.state {
 	case uiInitialize:
 		binds = append(binds,
@@ -2083,27 +2850,46 @@ func (m *UI) FullHelp() [][]key.Binding {
 			commands,
 			k.Models,
 			k.Sessions,
+			k.Layout,
 		)
 		if hasSession {
 			mainBinds = append(mainBinds, k.Chat.NewSession)
 		}
+		if m.selection != "" {
+			mainBinds = append(mainBinds, k.Chat.AskSelection)
+		}
 
 		binds = append(binds, mainBinds)
 
+		tabBinds := []key.Binding{k.Tabs.New}
+		if len(m.tabs) > 1 {
+			tabBinds = append(tabBinds, k.Tabs.Switch)
+		}
+		binds = append(binds, tabBinds)
+		if m.session.PlanAwaitingApproval() {
+			binds = append(binds, []key.Binding{k.Chat.ReviewPlan})
+		} else if len(m.session.Todos) > 0 {
+			binds = append(binds, []key.Binding{k.Chat.EditTodos})
+		}
+
 		switch m.focus {
 		case uiFocusEditor:
 			binds = append(binds,
 				[]key.Binding{
 					k.Editor.Newline,
-					k.Editor.AddImage,
-					k.Editor.PasteImage,
+					m.gatedBinding(k.Editor.AddImage, capability.Images),
+					m.gatedBinding(k.Editor.PasteImage, capability.Images),
 					k.Editor.MentionFile,
 					k.Editor.OpenEditor,
 				},
 			)
+			if m.com.Config().Options.Voice != nil {
+				binds = append(binds, []key.Binding{k.Editor.Voice})
+			}
 			if hasAttachments {
 				binds = append(binds,
 					[]key.Binding{
+						k.Editor.ManageAttachments,
 						k.Editor.AttachmentDeleteMode,
 						k.Editor.DeleteAllAttachments,
 						k.Editor.Escape,
@@ -2127,6 +2913,18 @@ func (m *UI) FullHelp() [][]key.Binding {
 				[]key.Binding{
 					k.Chat.Copy,
 					k.Chat.ClearHighlight,
+					k.Chat.ViewImage,
+					k.Chat.ViewReasoning,
+					k.Chat.RunCode,
+					k.Chat.RereadFile,
+					k.Chat.Bookmark,
+					k.Chat.CopyLink,
+					k.Chat.CodeWrap,
+					k.Chat.CodeLeft,
+				},
+				[]key.Binding{
+					k.Chat.Regenerate,
+					k.Chat.Variants,
 				},
 			)
 			if m.pillsExpanded && hasIncompleteTodos(m.session.Todos) && m.promptQueue > 0 {
@@ -2144,15 +2942,19 @@ func (m *UI) FullHelp() [][]key.Binding {
 				},
 				[]key.Binding{
 					k.Editor.Newline,
-					k.Editor.AddImage,
-					k.Editor.PasteImage,
+					m.gatedBinding(k.Editor.AddImage, capability.Images),
+					m.gatedBinding(k.Editor.PasteImage, capability.Images),
 					k.Editor.MentionFile,
 					k.Editor.OpenEditor,
 				},
 			)
+			if m.com.Config().Options.Voice != nil {
+				binds = append(binds, []key.Binding{k.Editor.Voice})
+			}
 			if hasAttachments {
 				binds = append(binds,
 					[]key.Binding{
+						k.Editor.ManageAttachments,
 						k.Editor.AttachmentDeleteMode,
 						k.Editor.DeleteAllAttachments,
 						k.Editor.Escape,
@@ -2191,6 +2993,38 @@ func (m *UI) toggleCompactMode() tea.Cmd {
 	return nil
 }
 
+// cycleLayout 依次切换聊天布局：侧边栏、面板、紧凑模式，并持久化到配置。
+func (m *UI) cycleLayout() tea.Cmd {
+	var name string
+	switch {
+	case m.forceCompactMode:
+		m.forceCompactMode, m.panelLayout = false, false
+		name = "侧边栏"
+	case m.panelLayout:
+		m.forceCompactMode = true
+		name = "紧凑"
+	default:
+		m.panelLayout = true
+		name = "面板"
+	}
+
+	cfg := m.com.Config()
+	if err := cfg.SetCompactMode(m.forceCompactMode); err != nil {
+		return util.ReportError(err)
+	}
+	layout := config.TUILayoutSidebar
+	if m.panelLayout {
+		layout = config.TUILayoutPanel
+	}
+	if err := cfg.SetTUILayout(layout); err != nil {
+		return util.ReportError(err)
+	}
+
+	m.updateLayoutAndSize()
+
+	return util.ReportInfo("布局: " + name)
+}
+
 // updateLayoutAndSize 更新UI组件的布局和大小
 func (m *UI) updateLayoutAndSize() {
 	// 确定我们是否应该处于紧凑模式
@@ -2223,7 +3057,7 @@ func (m *UI) updateSize() {
 	// 处理不同的应用程序状态
 	switch m.state {
 	case uiChat:
-		if !m.isCompact {
+		if !m.isCompact && !m.panelLayout {
 			m.cacheSidebarLogo(m.layout.sidebar.Dx())
 		}
 	}
@@ -2343,11 +3177,15 @@ func (m *UI) generateLayout(w, h int) uiLayout {
 			//
 			// ------|---
 			// 主体  |
-			// ------| 侧边栏
+			// ------| 侧边栏或面板
 			// 编辑器|
 			// ----------
 			// 帮助
 
+			if m.panelLayout {
+				// 面板显示任务和文件路径，比侧边栏宽
+				sidebarWidth = min(max(sidebarWidth, appRect.Dx()/3), panelMaxWidth)
+			}
 			mainRect, sideRect := layout.SplitHorizontal(appRect, layout.Fixed(appRect.Dx()-sidebarWidth))
 			// 添加左侧填充
 			sideRect.Min.X += 1
@@ -2369,6 +3207,13 @@ func (m *UI) generateLayout(w, h int) uiLayout {
 		}
 	}
 
+	if m.hasTabBar() && uiLayout.main.Dy() > 3 {
+		// 在主体顶部为标签栏留出一行，并添加一行间隙
+		uiLayout.tabs = uiLayout.main
+		uiLayout.tabs.Max.Y = uiLayout.tabs.Min.Y + 1
+		uiLayout.main.Min.Y += 2
+	}
+
 	if !uiLayout.editor.Empty() {
 		// 添加编辑器上下边距1
 		if len(m.attachments.List()) == 0 {
@@ -2408,6 +3253,9 @@ type uiLayout struct {
 
 	// session details 是紧凑模式下会话详情覆盖层的区域
 	sessionDetails uv.Rectangle
+
+	// tabs 是会话标签栏的区域，只有一个标签页时为空
+	tabs uv.Rectangle
 }
 
 func (m *UI) openEditor(value string) tea.Cmd {
@@ -2493,14 +3341,26 @@ func (m *UI) yoloPromptFunc(info textarea.PromptInfo) string {
 // closeCompletions 关闭自动完成弹出窗口并重置状态
 func (m *UI) closeCompletions() {
 	m.completionsOpen = false
+	m.completionsTrigger = ""
 	m.completionsQuery = ""
 	m.completionsStartIndex = 0
+	m.completionsRangePath = ""
+	m.completionsRangeLines = 0
 	m.completions.Close()
 }
 
-// insertCompletionText 用给定文本替换文本区域中的@query
+// insertCompletionText 用给定文本替换文本区域中的@query或#query
 // 如果无法执行替换，则返回false
 func (m *UI) insertCompletionText(text string) bool {
+	if !m.replaceCompletionWord(text) {
+		return false
+	}
+	m.textarea.InsertRune(' ')
+	return true
+}
+
+// replaceCompletionWord 用给定文本替换触发补全的单词并将光标移到末尾
+func (m *UI) replaceCompletionWord(text string) bool {
 	value := m.textarea.Value()
 	if m.completionsStartIndex > len(value) {
 		return false
@@ -2511,7 +3371,6 @@ func (m *UI) insertCompletionText(text string) bool {
 	newValue := value[:m.completionsStartIndex] + text + value[endIdx:]
 	m.textarea.SetValue(newValue)
 	m.textarea.MoveToEnd()
-	m.textarea.InsertRune(' ')
 	return true
 }
 
@@ -2610,6 +3469,25 @@ func (m *UI) insertMCPResourceCompletion(item completions.ResourceCompletionValu
 	}
 }
 
+// insertCustomCompletion 按自定义补全条目的类型插入：链接和提示词直接替换@query，
+// 片段插入条目名称并将文本作为附件添加
+func (m *UI) insertCustomCompletion(value completions.CustomCompletionValue) tea.Cmd {
+	if value.Kind == config.CompletionKindURL || value.Kind == config.CompletionKindPrompt {
+		m.insertCompletionText(value.Text)
+		return nil
+	}
+	if !m.insertCompletionText(value.Name) {
+		return nil
+	}
+	return func() tea.Msg {
+		return message.Attachment{
+			FileName: value.Name,
+			MimeType: "text/plain; charset=utf-8",
+			Content:  []byte(value.Text),
+		}
+	}
+}
+
 // completionsPosition 返回自动完成弹出窗口的X和Y位置
 func (m *UI) completionsPosition() image.Point {
 	cur := m.textarea.Cursor()
@@ -2635,9 +3513,13 @@ func isWhitespace(b byte) bool {
 	return b == ' ' || b == '\t' || b == '\n' || b == '\r'
 }
 
-// isAgentBusy 如果智能体协调器存在且当前
-// 忙于处理请求，则返回true
+// isAgentBusy 如果智能体正在处理当前标签页的会话，则返回true
 func (m *UI) isAgentBusy() bool {
+	return m.com.App != nil && m.hasSession() && m.isSessionBusy(m.session.ID)
+}
+
+// isAnyAgentBusy 如果智能体协调器存在且正在处理任一会话，则返回true
+func (m *UI) isAnyAgentBusy() bool {
 	return m.com.App != nil &&
 		m.com.App.AgentCoordinator != nil &&
 		m.com.App.AgentCoordinator.IsBusy()
@@ -2699,6 +3581,9 @@ func (m *UI) sendMessage(content string, attachments ...message.Attachment) tea.
 	if m.com.App.AgentCoordinator == nil {
 		return util.ReportError(fmt.Errorf("编码器智能体未初始化"))
 	}
+	if m.confirmBudget(content, attachments) {
+		return nil
+	}
 
 	var cmds []tea.Cmd
 	if !m.hasSession() {
@@ -2706,11 +3591,19 @@ func (m *UI) sendMessage(content string, attachments ...message.Attachment) tea.
 		if err != nil {
 			return util.ReportError(err)
 		}
+		if m.newSessionPlanMode {
+			m.newSessionPlanMode = false
+			newSession, err = m.com.App.Sessions.SetPlanMode(context.Background(), newSession.ID, session.PlanModePlanning)
+			if err != nil {
+				return util.ReportError(err)
+			}
+		}
 		if m.forceCompactMode {
 			m.isCompact = true
 		}
 		if newSession.ID != "" {
 			m.session = &newSession
+			m.setActiveTabSession(newSession.ID, newSession.Title)
 			cmds = append(cmds, m.loadSession(newSession.ID))
 		}
 		m.setState(uiChat, m.focus)
@@ -2812,6 +3705,74 @@ func (m *UI) openDialog(id string) tea.Cmd {
 		if cmd := m.openQuitDialog(); cmd != nil {
 			cmds = append(cmds, cmd)
 		}
+	case dialog.QueueID:
+		if cmd := m.openQueueDialog(); cmd != nil {
+			cmds = append(cmds, cmd)
+		}
+	case dialog.PinnedFilesID:
+		if cmd := m.openPinnedFilesDialog(); cmd != nil {
+			cmds = append(cmds, cmd)
+		}
+	case dialog.BookmarksID:
+		if cmd := m.openBookmarksDialog(); cmd != nil {
+			cmds = append(cmds, cmd)
+		}
+	case dialog.RegenerateID:
+		if cmd := m.openRegenerateDialog(); cmd != nil {
+			cmds = append(cmds, cmd)
+		}
+	case dialog.ReplyVariantsID:
+		if cmd := m.openReplyVariantsDialog(); cmd != nil {
+			cmds = append(cmds, cmd)
+		}
+	case dialog.SessionEnvID:
+		if cmd := m.openSessionEnvDialog(); cmd != nil {
+			cmds = append(cmds, cmd)
+		}
+	case dialog.SessionSettingsID:
+		if cmd := m.openSessionSettingsDialog(); cmd != nil {
+			cmds = append(cmds, cmd)
+		}
+	case dialog.SessionTagsID:
+		if m.session == nil {
+			cmds = append(cmds, util.ReportWarn("没有活动会话"))
+			break
+		}
+		if cmd := m.openSessionTagsDialog(m.session.ID, m.session.Tags); cmd != nil {
+			cmds = append(cmds, cmd)
+		}
+	case dialog.PlanID:
+		if cmd := m.openPlanDialog(); cmd != nil {
+			cmds = append(cmds, cmd)
+		}
+	case dialog.TodosID:
+		if cmd := m.openTodosDialog(); cmd != nil {
+			cmds = append(cmds, cmd)
+		}
+	case dialog.WorkspaceRootsID:
+		if cmd := m.openWorkspaceRootsDialog(); cmd != nil {
+			cmds = append(cmds, cmd)
+		}
+	case dialog.CheckpointsID:
+		if cmd := m.openCheckpointsDialog(); cmd != nil {
+			cmds = append(cmds, cmd)
+		}
+	case dialog.LSPSetupID:
+		if cmd := m.openLSPSetupDialog(); cmd != nil {
+			cmds = append(cmds, cmd)
+		}
+	case dialog.LogsID:
+		if cmd := m.openLogsDialog(); cmd != nil {
+			cmds = append(cmds, cmd)
+		}
+	case dialog.LSPServersID:
+		if cmd := m.openLSPServersDialog(); cmd != nil {
+			cmds = append(cmds, cmd)
+		}
+	case dialog.ConnectionsID:
+		if cmd := m.openConnectionsDialog(); cmd != nil {
+			cmds = append(cmds, cmd)
+		}
 	default:
 		// 未知对话框
 		break
@@ -2819,6 +3780,36 @@ func (m *UI) openDialog(id string) tea.Cmd {
 	return tea.Batch(cmds...)
 }
 
+// openMCPAuthDialog 打开 MCP 服务器的 OAuth 认证对话框
+func (m *UI) openMCPAuthDialog(name string) tea.Cmd {
+	if m.dialog.ContainsDialog(dialog.OAuthID) {
+		m.dialog.BringToFront(dialog.OAuthID)
+		return nil
+	}
+	dlg, cmd := dialog.NewOAuthMCP(m.com, name)
+	m.dialog.OpenDialog(dlg)
+	return cmd
+}
+
+// promptMCPAuth 在 MCP 服务器返回 401 而需要认证时提示用户。没有其他对话框时直接打开认证对话框，
+// 否则提示用户通过命令面板认证。
+func (m *UI) promptMCPAuth(states map[string]mcp.ClientInfo) tea.Cmd {
+	for _, c := range m.com.Config().MCP.Sorted() {
+		state, ok := states[c.Name]
+		if !ok || state.State != mcp.StateUnauthorized {
+			continue
+		}
+		if prev, ok := m.mcpStates[c.Name]; ok && prev.State == mcp.StateUnauthorized {
+			continue
+		}
+		if !m.dialog.HasDialogs() && (m.state == uiLanding || m.state == uiChat) {
+			return m.openMCPAuthDialog(c.Name)
+		}
+		return util.ReportWarn(fmt.Sprintf("MCP 服务器 %s 需要认证，请在命令面板中选择「认证 MCP 服务器」", c.Name))
+	}
+	return nil
+}
+
 // openQuitDialog 打开退出确认对话框
 func (m *UI) openQuitDialog() tea.Cmd {
 	if m.dialog.ContainsDialog(dialog.QuitID) {
@@ -2832,6 +3823,12 @@ func (m *UI) openQuitDialog() tea.Cmd {
 	return nil
 }
 
+// openRetentionDialog 打开清理超出保留策略的会话前的确认对话框
+func (m *UI) openRetentionDialog(sessions []session.Session) {
+	m.dialog.CloseDialog(dialog.RetentionID)
+	m.dialog.OpenDialog(dialog.NewRetention(m.com, sessions))
+}
+
 // openModelsDialog 打开模型对话框
 func (m *UI) openModelsDialog() tea.Cmd {
 	if m.dialog.ContainsDialog(dialog.ModelsID) {
@@ -2868,12 +3865,22 @@ func (m *UI) openCommandsDialog() tea.Cmd {
 	if err != nil {
 		return util.ReportError(err)
 	}
+	commands.SetTabCount(len(m.tabs))
 
 	m.dialog.OpenDialog(commands)
 
 	return nil
 }
 
+// openSelectionActionsDialog 打开将选中文本发送给智能体的菜单
+func (m *UI) openSelectionActionsDialog() {
+	if m.dialog.ContainsDialog(dialog.SelectionActionsID) {
+		m.dialog.BringToFront(dialog.SelectionActionsID)
+		return
+	}
+	m.dialog.OpenDialog(dialog.NewSelectionActions(m.com, m.selection))
+}
+
 // openReasoningDialog 打开推理努力对话框
 func (m *UI) openReasoningDialog() tea.Cmd {
 	if m.dialog.ContainsDialog(dialog.ReasoningID) {
@@ -2914,6 +3921,178 @@ func (m *UI) openSessionsDialog() tea.Cmd {
 	return nil
 }
 
+// openQueueDialog 打开当前会话的提示队列管理对话框
+func (m *UI) openQueueDialog() tea.Cmd {
+	if m.dialog.ContainsDialog(dialog.QueueID) {
+		// 带到前面
+		m.dialog.BringToFront(dialog.QueueID)
+		return nil
+	}
+
+	if m.session == nil {
+		return util.ReportWarn("没有活动会话")
+	}
+
+	queueDialog, err := dialog.NewQueue(m.com, m.session.ID)
+	if err != nil {
+		return util.ReportError(err)
+	}
+
+	m.dialog.OpenDialog(queueDialog)
+	return nil
+}
+
+// openSessionEnvDialog 打开当前会话的环境变量编辑对话框
+func (m *UI) openSessionEnvDialog() tea.Cmd {
+	if m.dialog.ContainsDialog(dialog.SessionEnvID) {
+		// 带到前面
+		m.dialog.BringToFront(dialog.SessionEnvID)
+		return nil
+	}
+
+	if m.session == nil {
+		return util.ReportWarn("没有活动会话")
+	}
+
+	envDialog, cmd := dialog.NewSessionEnv(m.com, m.session.ID, m.session.Env)
+	m.dialog.OpenDialog(envDialog)
+	return cmd
+}
+
+// openSessionSettingsDialog 打开当前会话的设置对话框
+func (m *UI) openSessionSettingsDialog() tea.Cmd {
+	if m.dialog.ContainsDialog(dialog.SessionSettingsID) {
+		// 带到前面
+		m.dialog.BringToFront(dialog.SessionSettingsID)
+		return nil
+	}
+
+	if m.session == nil {
+		return util.ReportWarn("没有活动会话")
+	}
+
+	cfg := m.com.Config()
+	// 远程开发时项目不在本地，不检查目录是否存在
+	settingsDialog, cmd := dialog.NewSessionSettings(m.com, m.session.ID, cfg.WorkingDir(), m.session.WorkingDir, !cfg.Remote.Enabled())
+	m.dialog.OpenDialog(settingsDialog)
+	return cmd
+}
+
+// openSessionTagsDialog 打开会话的标签对话框，并列出其他会话中已使用的标签作为提示
+func (m *UI) openSessionTagsDialog(sessionID string, tags []string) tea.Cmd {
+	if m.dialog.ContainsDialog(dialog.SessionTagsID) {
+		// 带到前面
+		m.dialog.BringToFront(dialog.SessionTagsID)
+		return nil
+	}
+
+	sessions, err := m.com.App.Sessions.List(context.Background())
+	if err != nil {
+		slog.Warn("列出会话标签失败", "error", err)
+	}
+	tagsDialog, cmd := dialog.NewSessionTags(m.com, sessionID, tags, session.AllTags(sessions))
+	m.dialog.OpenDialog(tagsDialog)
+	return cmd
+}
+
+// openTodosDialog 打开当前会话的待办事项编辑对话框
+func (m *UI) openTodosDialog() tea.Cmd {
+	if m.dialog.ContainsDialog(dialog.TodosID) {
+		// 带到前面
+		m.dialog.BringToFront(dialog.TodosID)
+		return nil
+	}
+
+	if m.session == nil {
+		return util.ReportWarn("没有活动会话")
+	}
+
+	m.dialog.OpenDialog(dialog.NewTodos(m.com, m.session.ID, m.session.Todos))
+	return nil
+}
+
+// openPlanDialog 打开当前会话中等待批准的计划的审阅对话框
+func (m *UI) openPlanDialog() tea.Cmd {
+	if m.dialog.ContainsDialog(dialog.PlanID) {
+		// 带到前面
+		m.dialog.BringToFront(dialog.PlanID)
+		return nil
+	}
+
+	if m.session == nil || !m.session.PlanAwaitingApproval() {
+		return util.ReportWarn("没有等待批准的计划")
+	}
+	if m.isAgentBusy() {
+		return util.ReportWarn("智能体忙碌，请等待计划写完后再审阅...")
+	}
+
+	planDialog, cmd := dialog.NewPlan(m.com, m.session.ID, m.session.Todos)
+	m.dialog.OpenDialog(planDialog)
+	return cmd
+}
+
+// openPinnedFilesDialog 打开当前会话的固定文件管理对话框
+func (m *UI) openPinnedFilesDialog() tea.Cmd {
+	if m.dialog.ContainsDialog(dialog.PinnedFilesID) {
+		// 带到前面
+		m.dialog.BringToFront(dialog.PinnedFilesID)
+		return nil
+	}
+
+	if m.session == nil {
+		return util.ReportWarn("没有活动会话")
+	}
+
+	paths, err := m.pinnableFiles()
+	if err != nil {
+		return util.ReportError(err)
+	}
+	if len(paths) == 0 && len(m.session.PinnedFiles) == 0 {
+		return util.ReportInfo("当前会话中还没有可固定的文件")
+	}
+
+	m.dialog.OpenDialog(dialog.NewPinnedFiles(m.com, m.session.ID, paths, m.session.PinnedFiles))
+	return nil
+}
+
+// openCheckpointsDialog 打开当前会话的检查点对话框
+func (m *UI) openCheckpointsDialog() tea.Cmd {
+	if m.dialog.ContainsDialog(dialog.CheckpointsID) {
+		// 带到前面
+		m.dialog.BringToFront(dialog.CheckpointsID)
+		return nil
+	}
+
+	if m.session == nil {
+		return util.ReportWarn("没有活动会话")
+	}
+
+	checkpointsDialog, err := dialog.NewCheckpoints(m.com, m.session.ID)
+	if err != nil {
+		return util.ReportError(err)
+	}
+
+	m.dialog.OpenDialog(checkpointsDialog)
+	return nil
+}
+
+// openLSPSetupDialog 打开根据项目语言配置 LSP 服务器的对话框
+func (m *UI) openLSPSetupDialog() tea.Cmd {
+	if m.dialog.ContainsDialog(dialog.LSPSetupID) {
+		// 带到前面
+		m.dialog.BringToFront(dialog.LSPSetupID)
+		return nil
+	}
+
+	lspDialog, err := dialog.NewLSPSetup(m.com)
+	if err != nil {
+		return util.ReportWarn(err.Error())
+	}
+
+	m.dialog.OpenDialog(lspDialog)
+	return nil
+}
+
 // openFilesDialog 打开文件选择器对话框
 func (m *UI) openFilesDialog() tea.Cmd {
 	if m.dialog.ContainsDialog(dialog.FilePickerID) {
@@ -2929,6 +4108,42 @@ func (m *UI) openFilesDialog() tea.Cmd {
 	return cmd
 }
 
+// openImageViewerDialog 打开图像查看对话框，以完整尺寸显示图像
+func (m *UI) openImageViewerDialog(img chat.ImageData) tea.Cmd {
+	m.dialog.CloseDialog(dialog.ImageViewerID)
+	viewer, cmd := dialog.NewImageViewer(m.com, &m.caps, img.ID, img.Title, img.Data, img.MIMEType)
+	m.dialog.OpenDialog(viewer)
+	return cmd
+}
+
+// attachmentImage 将待发送的图片附件转换为图像查看对话框使用的数据
+func attachmentImage(att message.Attachment) chat.ImageData {
+	sum := sha256.Sum256(att.Content)
+	return chat.ImageData{
+		ID:       "attachment-" + hex.EncodeToString(sum[:8]),
+		Title:    filepath.Base(att.FileName),
+		Data:     base64.StdEncoding.EncodeToString(att.Content),
+		MIMEType: att.MimeType,
+	}
+}
+
+// openReasoningTraceDialog 打开查看助手消息推理过程的对话框
+func (m *UI) openReasoningTraceDialog(trace chat.ReasoningTrace) {
+	m.dialog.CloseDialog(dialog.ReasoningTraceID)
+	m.dialog.OpenDialog(dialog.NewReasoningTrace(m.com, trace))
+}
+
+// refreshReasoningTraceDialog 在正在查看的消息更新时刷新推理过程对话框
+func (m *UI) refreshReasoningTraceDialog(messageID string) {
+	viewer, ok := m.dialog.Dialog(dialog.ReasoningTraceID).(*dialog.ReasoningTrace)
+	if !ok || viewer.MessageID() != messageID {
+		return
+	}
+	if trace, ok := m.chat.ReasoningTrace(messageID); ok {
+		viewer.SetTrace(trace)
+	}
+}
+
 // openPermissionsDialog 为权限请求打开权限对话框
 func (m *UI) openPermissionsDialog(perm permission.PermissionRequest) tea.Cmd {
 	// 首先关闭任何现有的权限对话框
@@ -2939,6 +4154,8 @@ func (m *UI) openPermissionsDialog(perm permission.PermissionRequest) tea.Cmd {
 	if diffMode := m.com.Config().Options.TUI.DiffMode; diffMode != "" {
 		opts = append(opts, dialog.WithDiffMode(diffMode == "split"))
 	}
+	duration, uses := m.com.Config().Permissions.Window()
+	opts = append(opts, dialog.WithWindow(permission.Window{Duration: duration, Uses: uses}))
 
 	permDialog := dialog.NewPermissions(m.com, perm, opts...)
 	m.dialog.OpenDialog(permDialog)
@@ -2969,9 +4186,29 @@ func (m *UI) newSession() tea.Cmd {
 		return nil
 	}
 
+	m.setActiveTabSession("", "")
+	cmd := m.resetSession()
+	if len(m.tabs) > 1 {
+		// 其他标签页的会话可能仍在使用 LSP
+		return cmd
+	}
+	return tea.Batch(
+		func() tea.Msg {
+			m.com.App.LSPManager.StopAll(context.Background())
+			return nil
+		},
+		cmd,
+	)
+}
+
+// resetSession 清除当前会话的聊天状态并回到着陆页面
+func (m *UI) resetSession() tea.Cmd {
+	m.saveChatPosition()
 	m.session = nil
 	m.sessionFiles = nil
 	m.sessionFileReads = nil
+	m.replay = nil
+	m.selection = ""
 	m.setState(uiLanding, uiFocusEditor)
 	m.textarea.Focus()
 	m.chat.Blur()
@@ -2980,13 +4217,7 @@ func (m *UI) newSession() tea.Cmd {
 	m.promptQueue = 0
 	m.pillsView = ""
 	m.historyReset()
-	return tea.Batch(
-		func() tea.Msg {
-			m.com.App.LSPManager.StopAll(context.Background())
-			return nil
-		},
-		m.loadPromptHistory(),
-	)
+	return m.loadPromptHistory()
 }
 
 // handlePasteMsg 处理粘贴消息
@@ -3000,21 +4231,9 @@ func (m *UI) handlePasteMsg(msg tea.PasteMsg) tea.Cmd {
 	}
 
 	if strings.Count(msg.Content, "\n") > pasteLinesThreshold {
-		return func() tea.Msg {
-			content := []byte(msg.Content)
-			if int64(len(content)) > common.MaxAttachmentSize {
-				return util.ReportWarn("粘贴内容过大（>5MB）")
-			}
-			name := fmt.Sprintf("paste_%d.txt", m.pasteIdx())
-			mimeBufferSize := min(512, len(content))
-			mimeType := http.DetectContentType(content[:mimeBufferSize])
-			return message.Attachment{
-				FileName: name,
-				FilePath: name,
-				MimeType: mimeType,
-				Content:  content,
-			}
-		}
+		// 让用户在预览后决定作为附件、作为文本插入还是取消
+		m.dialog.OpenDialog(dialog.NewPastePreview(m.com, msg.Content))
+		return nil
 	}
 
 	// 尝试将粘贴的内容解析为文件路径。如果可以解析，
@@ -3057,6 +4276,25 @@ func (m *UI) handlePasteMsg(msg tea.PasteMsg) tea.Cmd {
 	return tea.Batch(cmds...)
 }
 
+// pasteAttachment 返回将粘贴的内容作为附件添加的命令
+func (m *UI) pasteAttachment(text string) tea.Cmd {
+	return func() tea.Msg {
+		content := []byte(text)
+		if int64(len(content)) > common.MaxAttachmentSize {
+			return util.ReportWarn("粘贴内容过大（>5MB）")
+		}
+		name := fmt.Sprintf("paste_%d.txt", m.pasteIdx())
+		mimeBufferSize := min(512, len(content))
+		mimeType := http.DetectContentType(content[:mimeBufferSize])
+		return message.Attachment{
+			FileName: name,
+			FilePath: name,
+			MimeType: mimeType,
+			Content:  content,
+		}
+	}
+}
+
 // handleFilePathPaste 处理粘贴的文件路径
 func (m *UI) handleFilePathPaste(path string) tea.Cmd {
 	return func() tea.Msg {
@@ -3067,8 +4305,12 @@ func (m *UI) handleFilePathPaste(path string) tea.Cmd {
 		if fileInfo.IsDir() {
 			return util.ReportWarn("不能附加目录")
 		}
-		if fileInfo.Size() > common.MaxAttachmentSize {
-			return util.ReportWarn("文件过大（>5MB）")
+		limit := common.MaxAttachmentSize
+		if isImagePath(path) {
+			limit = common.MaxImageSourceSize
+		}
+		if fileInfo.Size() > limit {
+			return util.ReportWarn(fmt.Sprintf("文件过大（>%dMB）", limit>>20))
 		}
 
 		content, err := os.ReadFile(path)
@@ -3093,10 +4335,10 @@ func (m *UI) handleFilePathPaste(path string) tea.Cmd {
 // 将剪贴板文本解释为文件路径
 func (m *UI) pasteImageFromClipboard() tea.Msg {
 	imageData, err := readClipboard(clipboardFormatImage)
-	if int64(len(imageData)) > common.MaxAttachmentSize {
+	if int64(len(imageData)) > common.MaxImageSourceSize {
 		return util.InfoMsg{
 			Type: util.InfoTypeError,
-			Msg:  "文件过大，最大5MB",
+			Msg:  "文件过大，最大50MB",
 		}
 	}
 	name := fmt.Sprintf("paste_%d.png", m.pasteIdx())
@@ -3120,15 +4362,7 @@ func (m *UI) pasteImageFromClipboard() tea.Msg {
 		return util.NewInfoMsg("剪贴板不包含图像或有效的文件路径")
 	}
 
-	lowerPath := strings.ToLower(path)
-	isAllowed := false
-	for _, ext := range common.AllowedImageTypes {
-		if strings.HasSuffix(lowerPath, ext) {
-			isAllowed = true
-			break
-		}
-	}
-	if !isAllowed {
+	if !isImagePath(path) {
 		return util.NewInfoMsg("文件类型不是支持的图像格式")
 	}
 
@@ -3139,10 +4373,10 @@ func (m *UI) pasteImageFromClipboard() tea.Msg {
 			Msg:  fmt.Sprintf("无法读取文件: %v", statErr),
 		}
 	}
-	if fileInfo.Size() > common.MaxAttachmentSize {
+	if fileInfo.Size() > common.MaxImageSourceSize {
 		return util.InfoMsg{
 			Type: util.InfoTypeError,
-			Msg:  "文件过大，最大5MB",
+			Msg:  "文件过大，最大50MB",
 		}
 	}
 
@@ -3213,7 +4447,7 @@ func (m *UI) drawSessionDetails(scr uv.Screen, area uv.Rectangle) {
 
 	lspSection := m.lspInfo(sectionWidth, maxItemsPerSection, false)
 	mcpSection := m.mcpInfo(sectionWidth, maxItemsPerSection, false)
-	filesSection := m.filesInfo(m.com.Config().WorkingDir(), sectionWidth, maxItemsPerSection, false)
+	filesSection := m.filesInfo(m.sessionDir(), sectionWidth, maxItemsPerSection, false)
 	sections := lipgloss.JoinHorizontal(lipgloss.Top, filesSection, " ", lspSection, " ", mcpSection)
 	uv.NewStyledString(
 		s.CompactDetails.View.
@@ -3292,9 +4526,13 @@ func handleMCPResourcesEvent(name string) tea.Cmd {
 
 func (m *UI) copyChatHighlight() tea.Cmd {
 	text := m.chat.HighlightContent()
+	if text == "" {
+		return nil
+	}
+	m.selection = text
 	return common.CopyToClipboardWithCallback(
 		text,
-		"选中的文本已复制到剪贴板",
+		fmt.Sprintf("选中的文本已复制到剪贴板，按 %s 询问智能体或导出为图片", m.keyMap.Chat.AskSelection.Help().Key),
 		func() tea.Msg {
 			m.chat.ClearMouse()
 			return nil
@@ -0,0 +1,102 @@
+package model
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/purpose168/crush-cn/internal/ui/util"
+	"github.com/purpose168/crush-cn/internal/voice"
+)
+
+// transcribeTimeout 是等待转写接口返回的最长时间。
+const transcribeTimeout = 2 * time.Minute
+
+// voiceRecording 是一个正在进行的语音输入录音及其转写设置。
+type voiceRecording struct {
+	recording   *voice.Recording
+	transcriber voice.Transcriber
+}
+
+// voiceTranscribedMsg 携带语音输入的转写结果。
+type voiceTranscribedMsg struct {
+	text string
+	err  error
+}
+
+// toggleVoiceInput 开始录音；如果已经在录音，则结束录音并转写。
+func (m *UI) toggleVoiceInput() tea.Cmd {
+	cfg := m.com.Config().Options.Voice
+	if cfg == nil {
+		return util.ReportWarn("未配置语音输入，请在配置中设置 options.voice")
+	}
+	if m.transcribing {
+		return util.ReportWarn("正在转写上一段录音，请稍候...")
+	}
+
+	if m.recording != nil {
+		rec := m.recording
+		m.recording = nil
+		m.transcribing = true
+		return func() tea.Msg {
+			audio, err := rec.recording.Stop()
+			if err != nil {
+				return voiceTranscribedMsg{err: err}
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), transcribeTimeout)
+			defer cancel()
+			text, err := rec.transcriber.Transcribe(ctx, audio, "audio.wav")
+			return voiceTranscribedMsg{text: text, err: err}
+		}
+	}
+
+	// 在开始录音前解析 API 密钥，避免录完音才发现无法转写。
+	// 未配置密钥时尝试 OPENAI_API_KEY，本地部署的接口可能不需要密钥。
+	var apiKey string
+	if cfg.APIKey != "" {
+		var err error
+		apiKey, err = m.com.Config().Resolve(cfg.APIKey)
+		if err != nil {
+			return util.ReportError(fmt.Errorf("解析语音输入的 API 密钥失败: %w", err))
+		}
+	} else {
+		apiKey, _ = m.com.Config().Resolve("$OPENAI_API_KEY")
+	}
+	recording, err := voice.Record(cfg.Command, cfg.Args, "")
+	if err != nil {
+		return util.ReportError(err)
+	}
+	m.recording = &voiceRecording{
+		recording: recording,
+		transcriber: voice.Transcriber{
+			BaseURL:  cfg.BaseURL,
+			APIKey:   apiKey,
+			Model:    cfg.Model,
+			Language: cfg.Language,
+		},
+	}
+	return nil
+}
+
+// cancelVoiceInput 丢弃正在进行的录音。
+func (m *UI) cancelVoiceInput() {
+	if m.recording == nil {
+		return
+	}
+	m.recording.recording.Cancel()
+	m.recording = nil
+}
+
+// handleVoiceTranscribed 将转写结果插入编辑器的光标处。
+func (m *UI) handleVoiceTranscribed(msg voiceTranscribedMsg) tea.Cmd {
+	m.transcribing = false
+	if msg.err != nil {
+		return util.ReportError(msg.err)
+	}
+	if msg.text == "" {
+		return util.ReportWarn("没有识别到语音")
+	}
+	m.textarea.InsertString(msg.text)
+	return nil
+}
@@ -0,0 +1,41 @@
+package model
+
+import (
+	"fmt"
+	"slices"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/purpose168/crush-cn/internal/agent/tools/mcp"
+	"github.com/purpose168/crush-cn/internal/ui/dialog"
+	"github.com/purpose168/crush-cn/internal/ui/util"
+)
+
+// openWorkspaceRootsDialog 打开编辑工作区附加目录的对话框。
+func (m *UI) openWorkspaceRootsDialog() tea.Cmd {
+	if m.dialog.ContainsDialog(dialog.WorkspaceRootsID) {
+		m.dialog.BringToFront(dialog.WorkspaceRootsID)
+		return nil
+	}
+	rootsDialog, cmd := dialog.NewWorkspaceRoots(m.com)
+	m.dialog.OpenDialog(rootsDialog)
+	return cmd
+}
+
+// setWorkspaceRoots 更新工作区附加目录，通知已连接的 MCP 服务器根目录已变化，
+// 并返回将目录保存到项目配置的命令。
+func (m *UI) setWorkspaceRoots(roots []string) tea.Cmd {
+	// 保存为空数组而不是 null。
+	roots = append([]string{}, roots...)
+	cfg := m.com.Config()
+	cfg.Options.WorkspaceRoots = slices.Clone(roots)
+	mcp.SetRoots(cfg.WorkspaceRoots())
+	return func() tea.Msg {
+		if err := cfg.SetProjectConfigField("options.workspace_roots", roots); err != nil {
+			return util.ReportError(fmt.Errorf("保存工作区目录失败: %w", err))()
+		}
+		if len(roots) == 0 {
+			return util.NewInfoMsg("已清除工作区附加目录")
+		}
+		return util.NewInfoMsg(fmt.Sprintf("已设置 %d 个工作区附加目录", len(roots)))
+	}
+}
@@ -18,12 +18,18 @@ import (
 
 // 图标常量定义
 const (
-	CheckIcon   string = "✓" // 勾选图标
-	SpinnerIcon string = "⋯" // 加载中图标
-	LoadingIcon string = "⟳" // 刷新图标
-	ModelIcon   string = "◇" // 模型图标
+	CheckIcon       string = "✓" // 勾选图标
+	SpinnerIcon     string = "⋯" // 加载中图标
+	LoadingIcon     string = "⟳" // 刷新图标
+	ModelIcon       string = "◇" // 模型图标
+	PinIcon         string = "◆" // 固定文件图标
+	TurnSummaryIcon string = "Σ" // 回合摘要图标
+	CitationsIcon   string = "¶" // 来源脚注图标
+	BookmarkIcon    string = "▪" // 书签图标
 
 	ArrowRightIcon string = "→" // 右箭头图标
+	ExpandedIcon   string = "▼" // 已展开图标
+	CollapsedIcon  string = "▶" // 已折叠图标
 
 	ToolPending string = "●" // 工具等待状态图标
 	ToolSuccess string = "✓" // 工具成功状态图标
@@ -84,6 +90,11 @@ type Styles struct {
 		KeystrokeTip lipgloss.Style // 快捷键操作文本样式 (例如: "打开", "关闭")
 		WorkingDir   lipgloss.Style // 当前工作目录样式
 		Separator    lipgloss.Style // 分隔点样式 (•)
+
+		// 会话标签栏样式
+		TabActive   lipgloss.Style // 当前标签页样式
+		TabInactive lipgloss.Style // 其他标签页样式
+		TabBusy     lipgloss.Style // 标签页忙碌指示器样式
 	}
 
 	// 紧凑详情样式
@@ -249,6 +260,7 @@ type Styles struct {
 			AssistantInfoModel     lipgloss.Style // 助手信息模型样式
 			AssistantInfoProvider  lipgloss.Style // 助手信息提供者样式
 			AssistantInfoDuration  lipgloss.Style // 助手信息时长样式
+			AssistantInfoMetrics   lipgloss.Style // 助手信息响应指标样式
 		}
 	}
 
@@ -284,6 +296,11 @@ type Styles struct {
 		// 状态消息样式
 		StateWaiting   lipgloss.Style // "等待工具响应..." 样式
 		StateCancelled lipgloss.Style // "已取消。" 样式
+		StateTimeout   lipgloss.Style // 临近执行时限时的倒计时样式
+
+		// 进度条样式
+		ProgressFilled lipgloss.Style // 进度条已完成部分样式
+		ProgressEmpty  lipgloss.Style // 进度条未完成部分样式
 
 		// 错误样式
 		ErrorTag     lipgloss.Style // ERROR标签样式
@@ -357,6 +374,11 @@ type Styles struct {
 		// ContentPanel is used for content blocks with subtle background.
 		ContentPanel lipgloss.Style
 
+		// RiskHigh and RiskMedium are the badges for risky commands in the
+		// permissions dialog.
+		RiskHigh   lipgloss.Style
+		RiskMedium lipgloss.Style
+
 		// Scrollbar styles for scrollable content.
 		ScrollbarThumb lipgloss.Style
 		ScrollbarTrack lipgloss.Style
@@ -412,6 +434,10 @@ type Styles struct {
 		InfoMessage    lipgloss.Style
 		UpdateMessage  lipgloss.Style
 		SuccessMessage lipgloss.Style
+
+		Widgets         lipgloss.Style // 状态栏小部件区域
+		Widget          lipgloss.Style // 状态栏小部件文本
+		WidgetSeparator lipgloss.Style // 状态栏小部件之间的分隔符
 	}
 
 	// Completions popup styles
@@ -419,6 +445,8 @@ type Styles struct {
 		Normal  lipgloss.Style
 		Focused lipgloss.Style
 		Match   lipgloss.Style
+		// Detail 是补全弹出窗口旁显示选中项文档的面板样式
+		Detail lipgloss.Style
 	}
 
 	// Attachments styles
@@ -1086,6 +1114,9 @@ func DefaultStyles() Styles {
 	s.Header.KeystrokeTip = s.Subtle
 	s.Header.WorkingDir = s.Muted
 	s.Header.Separator = s.Subtle
+	s.Header.TabActive = base.Foreground(fgBase).Background(bgOverlay).Bold(true).Padding(0, 1)
+	s.Header.TabInactive = s.Muted.Padding(0, 1)
+	s.Header.TabBusy = base.Foreground(green)
 
 	s.CompactDetails.Title = s.Base
 	s.CompactDetails.View = s.Base.Padding(0, 1, 1, 1).Border(lipgloss.RoundedBorder()).BorderForeground(borderFocus)
@@ -1133,6 +1164,10 @@ func DefaultStyles() Styles {
 
 	s.Tool.StateWaiting = base.Foreground(fgSubtle)
 	s.Tool.StateCancelled = base.Foreground(fgSubtle)
+	s.Tool.StateTimeout = base.Foreground(warning)
+
+	s.Tool.ProgressFilled = base.Foreground(primary)
+	s.Tool.ProgressEmpty = base.Foreground(border)
 
 	s.Tool.ErrorTag = base.Padding(0, 1).Background(red).Foreground(white)
 	s.Tool.ErrorMessage = base.Foreground(fgHalfMuted)
@@ -1252,6 +1287,7 @@ func DefaultStyles() Styles {
 	s.Chat.Message.AssistantInfoModel = s.Muted
 	s.Chat.Message.AssistantInfoProvider = s.Subtle
 	s.Chat.Message.AssistantInfoDuration = s.Subtle
+	s.Chat.Message.AssistantInfoMetrics = s.Subtle
 
 	// Thinking section styles
 	s.Chat.Message.ThinkingBox = s.Subtle.Background(bgBaseLighter)
@@ -1284,6 +1320,8 @@ func DefaultStyles() Styles {
 
 	s.Dialog.List = base.Margin(0, 0, 1, 0)
 	s.Dialog.ContentPanel = base.Background(bgSubtle).Foreground(fgBase).Padding(1, 2)
+	s.Dialog.RiskHigh = base.Padding(0, 1).Background(red).Foreground(white).Bold(true)
+	s.Dialog.RiskMedium = base.Padding(0, 1).Background(yellow).Foreground(bgBase).Bold(true)
 	s.Dialog.Spinner = base.Foreground(secondary)
 	s.Dialog.ScrollbarThumb = base.Foreground(secondary)
 	s.Dialog.ScrollbarTrack = base.Foreground(border)
@@ -1325,11 +1363,15 @@ func DefaultStyles() Styles {
 	s.Status.UpdateMessage = s.Status.SuccessMessage
 	s.Status.WarnMessage = s.Status.SuccessMessage.Foreground(bgOverlay).Background(warning)
 	s.Status.ErrorMessage = s.Status.SuccessMessage.Foreground(white).Background(redDark)
+	s.Status.Widgets = lipgloss.NewStyle().Padding(0, 1)
+	s.Status.Widget = s.Muted
+	s.Status.WidgetSeparator = s.Subtle
 
 	// Completions styles
 	s.Completions.Normal = base.Background(bgSubtle).Foreground(fgBase)
 	s.Completions.Focused = base.Background(primary).Foreground(white)
 	s.Completions.Match = base.Underline(true)
+	s.Completions.Detail = base.Background(bgSubtle).Foreground(fgBase).Padding(0, 1)
 
 	// Attachments styles
 	attachmentIconStyle := base.Foreground(bgSubtle).Background(green).Padding(0, 1)
@@ -0,0 +1,234 @@
+package update
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+)
+
+const (
+	// ChecksumsName 是发布版本中校验和文件的名称
+	ChecksumsName = "checksums.txt"
+	// SignatureName 是校验和文件的 cosign 签名包的名称
+	SignatureName = ChecksumsName + ".sigstore.json"
+
+	// signerIdentity 是签名证书中发布工作流的身份
+	signerIdentity = `^https://github\.com/purpose168/crush-cn/`
+	// signerIssuer 是签名证书的 OIDC 签发者
+	signerIssuer = "https://token.actions.githubusercontent.com"
+
+	// maxDownloadSize 是下载文件的大小上限
+	maxDownloadSize = 512 << 20
+)
+
+// ErrNoCosign 表示系统中没有安装 cosign，无法校验签名
+var ErrNoCosign = errors.New("cosign not found in PATH")
+
+// ArchiveName 返回指定版本和平台的发布归档文件名，与 .goreleaser.yml 中的 name_template 一致。
+func ArchiveName(version, goos, goarch string) string {
+	version = strings.TrimPrefix(version, "v")
+	arch := goarch
+	switch goarch {
+	case "amd64":
+		arch = "x86_64"
+	case "386":
+		arch = "i386"
+	case "arm":
+		arch = "armv7"
+	}
+	ext := ".tar.gz"
+	if goos == "windows" {
+		ext = ".zip"
+	}
+	return fmt.Sprintf("crush_%s_%s_%s%s", version, strings.ToUpper(goos[:1])+goos[1:], arch, ext)
+}
+
+// PlatformArchiveName 返回当前平台的发布归档文件名。
+func PlatformArchiveName(version string) string {
+	return ArchiveName(version, runtime.GOOS, runtime.GOARCH)
+}
+
+// Download 下载 url 指向的文件。
+func Download(ctx context.Context, url string) ([]byte, error) {
+	client := &http.Client{Timeout: 10 * time.Minute}
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", userAgent)
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("download %s returned status %d", url, resp.StatusCode)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxDownloadSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxDownloadSize {
+		return nil, fmt.Errorf("download %s exceeds %d bytes", url, maxDownloadSize)
+	}
+	return data, nil
+}
+
+// VerifyChecksum 校验 data 的 SHA-256 是否与校验和文件中 name 的记录一致。
+func VerifyChecksum(checksums []byte, name string, data []byte) error {
+	sum := sha256.Sum256(data)
+	got := hex.EncodeToString(sum[:])
+	for line := range strings.SplitSeq(string(checksums), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 || strings.TrimPrefix(fields[1], "*") != name {
+			continue
+		}
+		if !strings.EqualFold(fields[0], got) {
+			return fmt.Errorf("checksum mismatch for %s: expected %s, got %s", name, fields[0], got)
+		}
+		return nil
+	}
+	return fmt.Errorf("no checksum for %s", name)
+}
+
+// VerifySignature 使用 cosign 校验校验和文件的签名包，确认它由本项目的发布工作流签名。
+// 系统中没有安装 cosign 时返回 [ErrNoCosign]。
+func VerifySignature(ctx context.Context, checksums, bundle []byte) error {
+	cosign, err := exec.LookPath("cosign")
+	if err != nil {
+		return ErrNoCosign
+	}
+
+	dir, err := os.MkdirTemp("", "crush-update-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+	checksumsPath := filepath.Join(dir, ChecksumsName)
+	bundlePath := filepath.Join(dir, SignatureName)
+	if err := os.WriteFile(checksumsPath, checksums, 0o600); err != nil {
+		return err
+	}
+	if err := os.WriteFile(bundlePath, bundle, 0o600); err != nil {
+		return err
+	}
+
+	out, err := exec.CommandContext(ctx, cosign, "verify-blob",
+		"--bundle", bundlePath,
+		"--certificate-identity-regexp", signerIdentity,
+		"--certificate-oidc-issuer", signerIssuer,
+		checksumsPath,
+	).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("signature verification failed: %w: %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// ExtractBinary 从发布归档中取出 crush 可执行文件。
+func ExtractBinary(archiveName string, data []byte) ([]byte, error) {
+	if strings.HasSuffix(archiveName, ".zip") {
+		return extractZip(data, "crush.exe")
+	}
+	return extractTarGz(data, "crush")
+}
+
+func extractTarGz(data []byte, binary string) ([]byte, error) {
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer gz.Close()
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil, fmt.Errorf("%s not found in archive", binary)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if hdr.Typeflag == tar.TypeReg && path.Base(hdr.Name) == binary {
+			return io.ReadAll(io.LimitReader(tr, maxDownloadSize))
+		}
+	}
+}
+
+func extractZip(data []byte, binary string) ([]byte, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range zr.File {
+		if f.FileInfo().IsDir() || path.Base(f.Name) != binary {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, err
+		}
+		defer rc.Close()
+		return io.ReadAll(io.LimitReader(rc, maxDownloadSize))
+	}
+	return nil, fmt.Errorf("%s not found in archive", binary)
+}
+
+// Replace 用 binary 原子地替换 exe 指向的可执行文件。新文件先写入同一目录下的临时文件，
+// 再重命名覆盖原文件，因此失败时原文件保持不变。Windows 上无法覆盖正在运行的文件，
+// 原文件会先被重命名为 exe.old。
+func Replace(exe string, binary []byte) error {
+	exe, err := filepath.EvalSymlinks(exe)
+	if err != nil {
+		return err
+	}
+	info, err := os.Stat(exe)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(exe), ".crush-update-*")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+	defer os.Remove(tmpPath)
+	if _, err := tmp.Write(binary); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmpPath, info.Mode().Perm()|0o111); err != nil {
+		return err
+	}
+
+	if runtime.GOOS == "windows" {
+		old := exe + ".old"
+		_ = os.Remove(old)
+		if err := os.Rename(exe, old); err != nil {
+			return err
+		}
+		if err := os.Rename(tmpPath, exe); err != nil {
+			_ = os.Rename(old, exe)
+			return err
+		}
+		return nil
+	}
+	return os.Rename(tmpPath, exe)
+}
@@ -0,0 +1,95 @@
+package update
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestArchiveName(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, "crush_0.12.0_Linux_x86_64.tar.gz", ArchiveName("v0.12.0", "linux", "amd64"))
+	require.Equal(t, "crush_0.12.0_Darwin_arm64.tar.gz", ArchiveName("0.12.0", "darwin", "arm64"))
+	require.Equal(t, "crush_0.12.0_Windows_i386.zip", ArchiveName("0.12.0", "windows", "386"))
+	require.Equal(t, "crush_0.12.0_Linux_armv7.tar.gz", ArchiveName("0.12.0", "linux", "arm"))
+}
+
+func TestVerifyChecksum(t *testing.T) {
+	t.Parallel()
+
+	data := []byte("binary")
+	sum := sha256.Sum256(data)
+	checksums := []byte(hex.EncodeToString(sum[:]) + "  crush_0.12.0_Linux_x86_64.tar.gz\n" +
+		"0000  crush_0.12.0_Darwin_arm64.tar.gz\n")
+
+	require.NoError(t, VerifyChecksum(checksums, "crush_0.12.0_Linux_x86_64.tar.gz", data))
+	require.ErrorContains(t, VerifyChecksum(checksums, "crush_0.12.0_Darwin_arm64.tar.gz", data), "mismatch")
+	require.ErrorContains(t, VerifyChecksum(checksums, "crush_0.12.0_Windows_i386.zip", data), "no checksum")
+}
+
+func TestExtractBinary(t *testing.T) {
+	t.Parallel()
+
+	var tgz bytes.Buffer
+	gz := gzip.NewWriter(&tgz)
+	tw := tar.NewWriter(gz)
+	for name, content := range map[string]string{
+		"crush_0.12.0_Linux_x86_64/README.md": "readme",
+		"crush_0.12.0_Linux_x86_64/crush":     "elf",
+	} {
+		require.NoError(t, tw.WriteHeader(&tar.Header{Name: name, Mode: 0o755, Size: int64(len(content)), Typeflag: tar.TypeReg}))
+		_, err := tw.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+	require.NoError(t, gz.Close())
+
+	binary, err := ExtractBinary("crush_0.12.0_Linux_x86_64.tar.gz", tgz.Bytes())
+	require.NoError(t, err)
+	require.Equal(t, "elf", string(binary))
+
+	var zipped bytes.Buffer
+	zw := zip.NewWriter(&zipped)
+	w, err := zw.Create("crush_0.12.0_Windows_x86_64/crush.exe")
+	require.NoError(t, err)
+	_, err = w.Write([]byte("pe"))
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+
+	binary, err = ExtractBinary("crush_0.12.0_Windows_x86_64.zip", zipped.Bytes())
+	require.NoError(t, err)
+	require.Equal(t, "pe", string(binary))
+
+	_, err = ExtractBinary("crush_0.12.0_Linux_x86_64.tar.gz", zipped.Bytes())
+	require.Error(t, err)
+}
+
+func TestReplace(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	exe := filepath.Join(dir, "crush")
+	require.NoError(t, os.WriteFile(exe, []byte("old"), 0o755))
+
+	require.NoError(t, Replace(exe, []byte("new")))
+	data, err := os.ReadFile(exe)
+	require.NoError(t, err)
+	require.Equal(t, "new", string(data))
+
+	info, err := os.Stat(exe)
+	require.NoError(t, err)
+	require.NotZero(t, info.Mode().Perm()&0o111)
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, entries, 1, "the temporary file is renamed over the executable")
+}
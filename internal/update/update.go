@@ -24,9 +24,10 @@ var Default Client = &github{}
 // Info 包含可用更新的信息
 
 type Info struct {
-	Current string // 当前版本
-	Latest  string // 最新版本
-	URL     string // 更新链接
+	Current string  // 当前版本
+	Latest  string  // 最新版本
+	URL     string  // 更新链接
+	Assets  []Asset // 最新版本的发布文件
 }
 
 // goInstallRegexp 匹配类似这样的版本字符串：
@@ -83,14 +84,33 @@ func Check(ctx context.Context, current string, client Client) (Info, error) {
 	info.Current = strings.TrimPrefix(info.Current, "v")
 	// 设置更新链接
 	info.URL = release.HTMLURL
+	info.Assets = release.Assets
 	return info, nil
 }
 
 // Release 表示 GitHub 发布版本
 
 type Release struct {
-	TagName string `json:"tag_name"` // 版本标签
-	HTMLURL string `json:"html_url"` // 发布页面 URL
+	TagName string  `json:"tag_name"` // 版本标签
+	HTMLURL string  `json:"html_url"` // 发布页面 URL
+	Assets  []Asset `json:"assets"`   // 发布的文件
+}
+
+// Asset 表示发布版本中的一个文件
+
+type Asset struct {
+	Name string `json:"name"`                 // 文件名
+	URL  string `json:"browser_download_url"` // 下载链接
+}
+
+// Asset 返回发布版本中名为 name 的文件
+func (r *Release) Asset(name string) (Asset, bool) {
+	for _, asset := range r.Assets {
+		if asset.Name == name {
+			return asset, true
+		}
+	}
+	return Asset{}, false
 }
 
 // Client 是一个可以获取最新发布版本的客户端接口
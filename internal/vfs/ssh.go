@@ -0,0 +1,291 @@
+package vfs
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// notExistCode 是远程脚本在路径不存在时使用的退出码
+const notExistCode = 44
+
+// sshConnectionErrorCode 是 ssh 无法建立连接时的退出码
+const sshConnectionErrorCode = 255
+
+// SSHOptions 是远程主机的连接设置
+type SSHOptions struct {
+	// Host 是主机名或 ~/.ssh/config 中的别名
+	Host string
+	// User 是远程用户名，为空时使用 ssh 配置
+	User string
+	// Port 是 SSH 端口，为 0 时使用 ssh 配置
+	Port int
+}
+
+// SSH 通过系统的 ssh 命令在远程主机上执行文件操作和 shell 命令。
+// 多次调用通过 ControlMaster 复用同一个连接，避免每次操作都重新握手。
+// 远程主机需要提供 POSIX sh 以及 cat、stat、ls、mkdir 等常用命令。
+type SSH struct {
+	opts        SSHOptions
+	program     string
+	controlPath string
+}
+
+var _ FS = (*SSH)(nil)
+
+// NewSSH 创建连接到远程主机的文件系统
+func NewSSH(opts SSHOptions) *SSH {
+	return &SSH{
+		opts:        opts,
+		program:     "ssh",
+		controlPath: filepath.Join(os.TempDir(), "crush-ssh-%C"),
+	}
+}
+
+// Destination 返回 user@host 形式的远程地址，用于展示
+func (s *SSH) Destination() string {
+	if s.opts.User == "" {
+		return s.opts.Host
+	}
+	return s.opts.User + "@" + s.opts.Host
+}
+
+// args 返回执行远程脚本的 ssh 参数。脚本总是交给远程的 sh 执行，
+// 不受远程用户登录 shell 的影响。
+func (s *SSH) args(script string) []string {
+	args := []string{
+		"-o", "BatchMode=yes",
+		"-o", "ControlMaster=auto",
+		"-o", "ControlPath=" + s.controlPath,
+		"-o", "ControlPersist=10m",
+	}
+	if s.opts.Port != 0 {
+		args = append(args, "-p", strconv.Itoa(s.opts.Port))
+	}
+	if s.opts.User != "" {
+		args = append(args, "-l", s.opts.User)
+	}
+	return append(args, "--", s.opts.Host, "sh -c "+Quote(script))
+}
+
+// Run 在远程主机的 dir 目录中执行 shell 命令，env 中 KEY=VALUE 形式的变量通过 env 命令
+// 追加到远程主机的环境变量中。命令以非零状态退出时返回 [*exec.ExitError]。
+func (s *SSH) Run(ctx context.Context, dir string, env []string, command string, stdin io.Reader, stdout, stderr io.Writer) error {
+	script := command
+	if assignments := envAssignments(env); assignments != "" {
+		script = "exec env " + assignments + " sh -c " + Quote(command)
+	}
+	if dir != "" {
+		script = "cd " + Quote(dir) + " || exit 1\n" + script
+	}
+	cmd := exec.CommandContext(ctx, s.program, s.args(script)...)
+	cmd.Stdin = stdin
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	// 远程命令启动的后台进程可能继续持有输出管道，取消后不再无限等待。
+	cmd.WaitDelay = time.Second
+	return cmd.Run()
+}
+
+// envAssignments 返回传给 env 命令的变量赋值参数，忽略不是 KEY=VALUE 形式的项
+func envAssignments(env []string) string {
+	var args []string
+	for _, kv := range env {
+		if key, _, ok := strings.Cut(kv, "="); ok && key != "" {
+			args = append(args, Quote(kv))
+		}
+	}
+	return strings.Join(args, " ")
+}
+
+// output 执行远程脚本并返回标准输出，把退出码转换为 op 操作 name 时的错误
+func (s *SSH) output(ctx context.Context, op, name, script string, stdin io.Reader) ([]byte, error) {
+	var stdout, stderr bytes.Buffer
+	err := s.Run(ctx, "", nil, script, stdin, &stdout, &stderr)
+	if err == nil {
+		return stdout.Bytes(), nil
+	}
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return nil, ctxErr
+	}
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) {
+		return nil, fmt.Errorf("无法执行 ssh: %w", err)
+	}
+	msg := strings.TrimSpace(stderr.String())
+	switch exitErr.ExitCode() {
+	case notExistCode:
+		return nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrNotExist}
+	case sshConnectionErrorCode:
+		return nil, fmt.Errorf("SSH 连接 %s 失败: %s", s.Destination(), msg)
+	}
+	if msg == "" {
+		msg = exitErr.Error()
+	}
+	return nil, &fs.PathError{Op: op, Path: name, Err: errors.New(msg)}
+}
+
+// existsCheck 返回在路径不存在时以 notExistCode 退出的脚本片段
+func existsCheck(quoted string) string {
+	return fmt.Sprintf("[ -e %s ] || exit %d\n", quoted, notExistCode)
+}
+
+func (s *SSH) ReadFile(ctx context.Context, name string) ([]byte, error) {
+	q := Quote(name)
+	return s.output(ctx, "open", name, existsCheck(q)+"cat -- "+q, nil)
+}
+
+func (s *SSH) WriteFile(ctx context.Context, name string, data []byte, perm fs.FileMode) error {
+	q := Quote(name)
+	script := fmt.Sprintf("if [ ! -e %[1]s ]; then : > %[1]s && chmod %[2]o %[1]s || exit 1; fi\ncat > %[1]s", q, perm.Perm())
+	_, err := s.output(ctx, "open", name, script, bytes.NewReader(data))
+	return err
+}
+
+func (s *SSH) Stat(ctx context.Context, name string) (fs.FileInfo, error) {
+	q := Quote(name)
+	// GNU stat 使用 -c，BSD 和 macOS 的 stat 使用 -f
+	script := existsCheck(q) +
+		fmt.Sprintf("if [ -d %[1]s ]; then printf 'd '; else printf 'f '; fi\n", q) +
+		fmt.Sprintf("stat -c '%%Y %%s %%a' -- %[1]s 2>/dev/null || stat -f '%%m %%z %%Lp' -- %[1]s", q)
+	out, err := s.output(ctx, "stat", name, script, nil)
+	if err != nil {
+		return nil, err
+	}
+	info, err := parseStat(path.Base(name), string(out))
+	if err != nil {
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: err}
+	}
+	return info, nil
+}
+
+func (s *SSH) ReadDir(ctx context.Context, name string) ([]fs.DirEntry, error) {
+	q := Quote(name)
+	out, err := s.output(ctx, "open", name, existsCheck(q)+"cd "+q+" && ls -1Ap", nil)
+	if err != nil {
+		return nil, err
+	}
+	return parseDirEntries(string(out)), nil
+}
+
+func (s *SSH) MkdirAll(ctx context.Context, name string, perm fs.FileMode) error {
+	_, err := s.output(ctx, "mkdir", name, fmt.Sprintf("mkdir -p -m %o -- %s", perm.Perm(), Quote(name)), nil)
+	return err
+}
+
+func (s *SSH) Remove(ctx context.Context, name string) error {
+	q := Quote(name)
+	script := existsCheck(q) + fmt.Sprintf("if [ -d %[1]s ]; then rmdir -- %[1]s; else rm -f -- %[1]s; fi", q)
+	_, err := s.output(ctx, "remove", name, script, nil)
+	return err
+}
+
+// Open 读取远程文件的全部内容，返回的文件在内存中读取和定位
+func (s *SSH) Open(ctx context.Context, name string) (io.ReadSeekCloser, error) {
+	data, err := s.ReadFile(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	return nopCloser{bytes.NewReader(data)}, nil
+}
+
+// Quote 把 s 引用为 POSIX shell 中的单个参数
+func Quote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// parseStat 解析远程 Stat 脚本的输出，格式为 "类型 修改时间 大小 权限"
+func parseStat(name, out string) (fs.FileInfo, error) {
+	fields := strings.Fields(out)
+	if len(fields) != 4 {
+		return nil, fmt.Errorf("无法解析 stat 输出: %q", out)
+	}
+	mtime, err := strconv.ParseInt(fields[1], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("无法解析修改时间: %w", err)
+	}
+	size, err := strconv.ParseInt(fields[2], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("无法解析文件大小: %w", err)
+	}
+	perm, err := strconv.ParseUint(fields[3], 8, 32)
+	if err != nil {
+		return nil, fmt.Errorf("无法解析文件权限: %w", err)
+	}
+	mode := fs.FileMode(perm).Perm()
+	if fields[0] == "d" {
+		mode |= fs.ModeDir
+	}
+	return fileInfo{name: name, size: size, mode: mode, modTime: time.Unix(mtime, 0)}, nil
+}
+
+// parseDirEntries 解析 ls -1Ap 的输出，目录名以 / 结尾
+func parseDirEntries(out string) []fs.DirEntry {
+	var entries []fs.DirEntry
+	for line := range strings.SplitSeq(out, "\n") {
+		if line == "" {
+			continue
+		}
+		name, isDir := strings.CutSuffix(line, "/")
+		entries = append(entries, dirEntry{name: name, dir: isDir})
+	}
+	slices.SortFunc(entries, func(a, b fs.DirEntry) int {
+		return strings.Compare(a.Name(), b.Name())
+	})
+	return entries
+}
+
+// fileInfo 是远程文件的 [fs.FileInfo]
+type fileInfo struct {
+	name    string
+	size    int64
+	mode    fs.FileMode
+	modTime time.Time
+}
+
+func (i fileInfo) Name() string       { return i.name }
+func (i fileInfo) Size() int64        { return i.size }
+func (i fileInfo) Mode() fs.FileMode  { return i.mode }
+func (i fileInfo) ModTime() time.Time { return i.modTime }
+func (i fileInfo) IsDir() bool        { return i.mode.IsDir() }
+func (i fileInfo) Sys() any           { return nil }
+
+// dirEntry 是远程目录中的条目。ls 只提供名称和类型，Info 返回的大小和修改时间为零值。
+type dirEntry struct {
+	name string
+	dir  bool
+}
+
+func (e dirEntry) Name() string { return e.name }
+func (e dirEntry) IsDir() bool  { return e.dir }
+func (e dirEntry) Type() fs.FileMode {
+	if e.dir {
+		return fs.ModeDir
+	}
+	return 0
+}
+
+func (e dirEntry) Info() (fs.FileInfo, error) {
+	mode := fs.FileMode(0o644)
+	if e.dir {
+		mode = fs.ModeDir | 0o755
+	}
+	return fileInfo{name: e.name, mode: mode}, nil
+}
+
+type nopCloser struct {
+	io.ReadSeeker
+}
+
+func (nopCloser) Close() error { return nil }
@@ -0,0 +1,154 @@
+package vfs
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSSH 返回一个在本地执行远程脚本的 SSH，ssh 程序被替换为执行最后一个参数的脚本
+func fakeSSH(t *testing.T) *SSH {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("需要 POSIX sh")
+	}
+	program := filepath.Join(t.TempDir(), "ssh")
+	require.NoError(t, os.WriteFile(program, []byte("#!/bin/sh\neval \"last=\\${$#}\"\nexec sh -c \"$last\"\n"), 0o755))
+	s := NewSSH(SSHOptions{Host: "devbox"})
+	s.program = program
+	return s
+}
+
+func TestQuote(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, `'plain'`, Quote("plain"))
+	require.Equal(t, `'it'\''s a $file'`, Quote("it's a $file"))
+
+	out, err := exec.Command("sh", "-c", "printf %s "+Quote(`a'b "c" $d`)).Output()
+	require.NoError(t, err)
+	require.Equal(t, `a'b "c" $d`, string(out))
+}
+
+func TestSSHArgs(t *testing.T) {
+	t.Parallel()
+
+	s := NewSSH(SSHOptions{Host: "devbox", User: "ubuntu", Port: 2222})
+	s.controlPath = "/tmp/ctl"
+	require.Equal(t, "ubuntu@devbox", s.Destination())
+	require.Equal(t, []string{
+		"-o", "BatchMode=yes",
+		"-o", "ControlMaster=auto",
+		"-o", "ControlPath=/tmp/ctl",
+		"-o", "ControlPersist=10m",
+		"-p", "2222",
+		"-l", "ubuntu",
+		"--", "devbox", `sh -c 'echo '\''hi'\'''`,
+	}, s.args("echo 'hi'"))
+	require.Equal(t, "devbox", NewSSH(SSHOptions{Host: "devbox"}).Destination())
+}
+
+func TestSSHFileOperations(t *testing.T) {
+	t.Parallel()
+
+	s := fakeSSH(t)
+	ctx := t.Context()
+	dir := t.TempDir()
+	name := filepath.Join(dir, "sub dir", "it's.txt")
+
+	_, err := s.ReadFile(ctx, name)
+	require.True(t, os.IsNotExist(err))
+	_, err = s.Stat(ctx, name)
+	require.True(t, os.IsNotExist(err))
+
+	require.NoError(t, s.MkdirAll(ctx, filepath.Dir(name), 0o755))
+	require.NoError(t, s.WriteFile(ctx, name, []byte("hello\nworld\n"), 0o600))
+
+	data, err := s.ReadFile(ctx, name)
+	require.NoError(t, err)
+	require.Equal(t, "hello\nworld\n", string(data))
+
+	info, err := s.Stat(ctx, name)
+	require.NoError(t, err)
+	require.Equal(t, "it's.txt", info.Name())
+	require.Equal(t, int64(12), info.Size())
+	require.False(t, info.IsDir())
+	require.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+	local, err := os.Stat(name)
+	require.NoError(t, err)
+	require.Equal(t, local.ModTime().Unix(), info.ModTime().Unix())
+
+	info, err = s.Stat(ctx, dir)
+	require.NoError(t, err)
+	require.True(t, info.IsDir())
+
+	require.NoError(t, s.WriteFile(ctx, filepath.Join(dir, ".hidden"), nil, 0o644))
+	entries, err := s.ReadDir(ctx, dir)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	require.Equal(t, ".hidden", entries[0].Name())
+	require.False(t, entries[0].IsDir())
+	require.Equal(t, "sub dir", entries[1].Name())
+	require.True(t, entries[1].IsDir())
+
+	f, err := s.Open(ctx, name)
+	require.NoError(t, err)
+	defer f.Close()
+	_, err = f.Seek(6, io.SeekStart)
+	require.NoError(t, err)
+	rest, err := io.ReadAll(f)
+	require.NoError(t, err)
+	require.Equal(t, "world\n", string(rest))
+
+	err = s.WriteFile(ctx, filepath.Join(dir, "missing", "file.txt"), []byte("x"), 0o644)
+	require.Error(t, err)
+
+	require.NoError(t, s.Remove(ctx, name))
+	require.NoError(t, s.Remove(ctx, filepath.Dir(name)))
+	_, err = os.Stat(filepath.Dir(name))
+	require.True(t, os.IsNotExist(err))
+	require.True(t, os.IsNotExist(s.Remove(ctx, name)))
+}
+
+func TestSSHRun(t *testing.T) {
+	t.Parallel()
+
+	s := fakeSSH(t)
+	dir := t.TempDir()
+
+	var stdout bytes.Buffer
+	err := s.Run(t.Context(), dir, nil, "pwd; cat", bytes.NewReader([]byte("input")), &stdout, io.Discard)
+	require.NoError(t, err)
+	resolved, err := filepath.EvalSymlinks(dir)
+	require.NoError(t, err)
+	require.Contains(t, []string{dir + "\ninput", resolved + "\ninput"}, stdout.String())
+
+	stdout.Reset()
+	env := []string{"GREETING=it's $HOME", "NOVALUE", "=x"}
+	err = s.Run(t.Context(), dir, env, `printf %s "$GREETING"`, nil, &stdout, io.Discard)
+	require.NoError(t, err)
+	require.Equal(t, "it's $HOME", stdout.String())
+
+	err = s.Run(t.Context(), dir, env, "exit 3", nil, io.Discard, io.Discard)
+	var exitErr *exec.ExitError
+	require.ErrorAs(t, err, &exitErr)
+	require.Equal(t, 3, exitErr.ExitCode())
+}
+
+func TestParseStat(t *testing.T) {
+	t.Parallel()
+
+	info, err := parseStat("src", "d 1700000000 4096 755\n")
+	require.NoError(t, err)
+	require.True(t, info.IsDir())
+	require.Equal(t, os.ModeDir|0o755, info.Mode())
+
+	_, err = parseStat("src", "stat: illegal option")
+	require.Error(t, err)
+}
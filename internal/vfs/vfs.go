@@ -0,0 +1,70 @@
+// Package vfs 抽象文件工具使用的文件系统，使同一套工具既可以操作本地文件，
+// 也可以通过 SSH 操作远程主机上的文件。
+package vfs
+
+import (
+	"context"
+	"io"
+	"io/fs"
+	"os"
+)
+
+// FS 是文件工具读写文件使用的文件系统。路径都是绝对路径，
+// 错误遵循 os 包的约定，文件不存在时 [os.IsNotExist] 返回 true。
+type FS interface {
+	// ReadFile 读取文件的全部内容
+	ReadFile(ctx context.Context, name string) ([]byte, error)
+	// WriteFile 写入文件，文件不存在时以 perm 权限创建
+	WriteFile(ctx context.Context, name string, data []byte, perm fs.FileMode) error
+	// Stat 返回文件信息
+	Stat(ctx context.Context, name string) (fs.FileInfo, error)
+	// ReadDir 返回目录中的条目，按名称排序
+	ReadDir(ctx context.Context, name string) ([]fs.DirEntry, error)
+	// MkdirAll 创建目录及其所有父目录
+	MkdirAll(ctx context.Context, name string, perm fs.FileMode) error
+	// Remove 删除文件或空目录
+	Remove(ctx context.Context, name string) error
+	// Open 打开文件用于读取
+	Open(ctx context.Context, name string) (io.ReadSeekCloser, error)
+}
+
+// Local 是本地文件系统，直接调用 os 包
+type Local struct{}
+
+var _ FS = Local{}
+
+func (Local) ReadFile(_ context.Context, name string) ([]byte, error) {
+	return os.ReadFile(name)
+}
+
+func (Local) WriteFile(_ context.Context, name string, data []byte, perm fs.FileMode) error {
+	return os.WriteFile(name, data, perm)
+}
+
+func (Local) Stat(_ context.Context, name string) (fs.FileInfo, error) {
+	return os.Stat(name)
+}
+
+func (Local) ReadDir(_ context.Context, name string) ([]fs.DirEntry, error) {
+	return os.ReadDir(name)
+}
+
+func (Local) MkdirAll(_ context.Context, name string, perm fs.FileMode) error {
+	return os.MkdirAll(name, perm)
+}
+
+func (Local) Remove(_ context.Context, name string) error {
+	return os.Remove(name)
+}
+
+func (Local) Open(_ context.Context, name string) (io.ReadSeekCloser, error) {
+	return os.Open(name)
+}
+
+// OrLocal 返回 fsys，fsys 为 nil 时返回本地文件系统
+func OrLocal(fsys FS) FS {
+	if fsys == nil {
+		return Local{}
+	}
+	return fsys
+}
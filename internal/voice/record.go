@@ -0,0 +1,99 @@
+// Package voice 实现语音输入：通过外部命令录音，并使用兼容 OpenAI Whisper 的
+// 接口将录音转写为文本。
+package voice
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// FilePlaceholder 是录音命令参数中的占位符，会被替换为录音文件的路径。
+const FilePlaceholder = "{file}"
+
+// DefaultCommand 和 DefaultArgs 是未配置录音命令时使用的 sox 命令，
+// 以 16kHz 单声道录制，这是语音识别模型的常用输入格式。
+var (
+	DefaultCommand = "sox"
+	DefaultArgs    = []string{"-d", "-q", "-r", "16000", "-c", "1", FilePlaceholder}
+)
+
+// stopTimeout 是发送中断信号后等待录音命令退出的最长时间。
+const stopTimeout = 5 * time.Second
+
+// Recording 是一个正在进行的录音。
+type Recording struct {
+	cmd    *exec.Cmd
+	path   string
+	stderr bytes.Buffer
+	done   chan error
+}
+
+// Record 启动录音命令，直到调用 [Recording.Stop] 为止。command 为空时使用 sox。
+// 录音文件创建在 dir 中，dir 为空时使用系统临时目录。
+func Record(command string, args []string, dir string) (*Recording, error) {
+	if command == "" {
+		command, args = DefaultCommand, DefaultArgs
+	}
+
+	f, err := os.CreateTemp(dir, "crush-voice-*.wav")
+	if err != nil {
+		return nil, fmt.Errorf("创建录音文件失败: %w", err)
+	}
+	path := f.Name()
+	f.Close()
+	// 删除占位文件，避免录音命令（例如 ffmpeg）因文件已存在而询问是否覆盖。
+	os.Remove(path)
+
+	r := &Recording{path: path, done: make(chan error, 1)}
+	expanded := make([]string, len(args))
+	for i, arg := range args {
+		expanded[i] = strings.ReplaceAll(arg, FilePlaceholder, path)
+	}
+	r.cmd = exec.Command(command, expanded...)
+	r.cmd.Dir = filepath.Dir(path)
+	r.cmd.Stderr = &r.stderr
+	if err := r.cmd.Start(); err != nil {
+		return nil, fmt.Errorf("启动录音命令 %s 失败: %w", command, err)
+	}
+	go func() { r.done <- r.cmd.Wait() }()
+	return r, nil
+}
+
+// Stop 中断录音命令并返回录制的音频。录音文件会被删除。
+func (r *Recording) Stop() ([]byte, error) {
+	defer os.Remove(r.path)
+
+	// 录音命令通常在收到中断信号后写入文件尾并正常退出；
+	// 不支持中断信号的平台上直接结束进程。
+	if err := r.cmd.Process.Signal(os.Interrupt); err != nil {
+		_ = r.cmd.Process.Kill()
+	}
+	select {
+	case <-r.done:
+	case <-time.After(stopTimeout):
+		_ = r.cmd.Process.Kill()
+		<-r.done
+	}
+
+	audio, err := os.ReadFile(r.path)
+	if err != nil || len(audio) == 0 {
+		if msg := strings.TrimSpace(r.stderr.String()); msg != "" {
+			return nil, fmt.Errorf("未录制到音频: %s", msg)
+		}
+		return nil, errors.New("未录制到音频")
+	}
+	return audio, nil
+}
+
+// Cancel 中断录音命令并丢弃录音。
+func (r *Recording) Cancel() {
+	_ = r.cmd.Process.Kill()
+	<-r.done
+	os.Remove(r.path)
+}
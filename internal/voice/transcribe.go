@@ -0,0 +1,93 @@
+package voice
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"strings"
+)
+
+// 转写接口的默认值。
+const (
+	DefaultBaseURL = "https://api.openai.com/v1"
+	DefaultModel   = "whisper-1"
+)
+
+// Transcriber 通过兼容 OpenAI Whisper 的 /audio/transcriptions 接口转写音频。
+type Transcriber struct {
+	BaseURL  string
+	APIKey   string
+	Model    string
+	Language string
+	Client   *http.Client
+}
+
+// Transcribe 将音频转写为文本。filename 用于告知服务端音频格式。
+func (t Transcriber) Transcribe(ctx context.Context, audio []byte, filename string) (string, error) {
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	part, err := w.CreateFormFile("file", filename)
+	if err != nil {
+		return "", err
+	}
+	if _, err := part.Write(audio); err != nil {
+		return "", err
+	}
+	fields := map[string]string{
+		"model":           cmp.Or(t.Model, DefaultModel),
+		"language":        t.Language,
+		"response_format": "json",
+	}
+	for name, value := range fields {
+		if value == "" {
+			continue
+		}
+		if err := w.WriteField(name, value); err != nil {
+			return "", err
+		}
+	}
+	if err := w.Close(); err != nil {
+		return "", err
+	}
+
+	url := strings.TrimSuffix(cmp.Or(t.BaseURL, DefaultBaseURL), "/") + "/audio/transcriptions"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, &body)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", w.FormDataContentType())
+	if t.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+t.APIKey)
+	}
+
+	client := t.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("请求转写接口失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return "", fmt.Errorf("读取转写结果失败: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("转写接口返回 %s: %s", resp.Status, strings.TrimSpace(string(data)))
+	}
+
+	var result struct {
+		Text string `json:"text"`
+	}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return "", fmt.Errorf("解析转写结果失败: %w", err)
+	}
+	return strings.TrimSpace(result.Text), nil
+}
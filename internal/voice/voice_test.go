@@ -0,0 +1,87 @@
+package voice
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTranscribe(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/v1/audio/transcriptions", r.URL.Path)
+		require.Equal(t, "Bearer key", r.Header.Get("Authorization"))
+		require.NoError(t, r.ParseMultipartForm(1<<20))
+		require.Equal(t, DefaultModel, r.FormValue("model"))
+		require.Equal(t, "zh", r.FormValue("language"))
+
+		f, header, err := r.FormFile("file")
+		require.NoError(t, err)
+		defer f.Close()
+		require.Equal(t, "audio.wav", header.Filename)
+		audio, err := io.ReadAll(f)
+		require.NoError(t, err)
+		require.Equal(t, "RIFF", string(audio))
+
+		require.NoError(t, json.NewEncoder(w).Encode(map[string]string{"text": " 你好，世界 \n"}))
+	}))
+	t.Cleanup(srv.Close)
+
+	tr := Transcriber{BaseURL: srv.URL + "/v1/", APIKey: "key", Language: "zh", Client: srv.Client()}
+	text, err := tr.Transcribe(t.Context(), []byte("RIFF"), "audio.wav")
+	require.NoError(t, err)
+	require.Equal(t, "你好，世界", text)
+}
+
+func TestTranscribeError(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"error":"invalid api key"}`, http.StatusUnauthorized)
+	}))
+	t.Cleanup(srv.Close)
+
+	_, err := Transcriber{BaseURL: srv.URL, Client: srv.Client()}.Transcribe(t.Context(), []byte("RIFF"), "audio.wav")
+	require.ErrorContains(t, err, "invalid api key")
+}
+
+func TestRecord(t *testing.T) {
+	t.Parallel()
+	if runtime.GOOS == "windows" {
+		t.Skip("依赖 POSIX shell 和中断信号")
+	}
+
+	t.Run("stop", func(t *testing.T) {
+		t.Parallel()
+		r, err := Record("sh", []string{"-c", `printf audio > "$0" && exec sleep 10`, FilePlaceholder}, t.TempDir())
+		require.NoError(t, err)
+		require.Eventually(t, func() bool {
+			info, err := os.Stat(r.path)
+			return err == nil && info.Size() > 0
+		}, 5*time.Second, 10*time.Millisecond)
+		audio, err := r.Stop()
+		require.NoError(t, err)
+		require.Equal(t, "audio", string(audio))
+		require.NoFileExists(t, r.path)
+	})
+
+	t.Run("no audio", func(t *testing.T) {
+		t.Parallel()
+		r, err := Record("sh", []string{"-c", `echo "no input device" >&2; touch "$0"`, FilePlaceholder + ".done"}, t.TempDir())
+		require.NoError(t, err)
+		require.Eventually(t, func() bool {
+			_, err := os.Stat(r.path + ".done")
+			return err == nil
+		}, 5*time.Second, 10*time.Millisecond)
+		_, err = r.Stop()
+		require.ErrorContains(t, err, "no input device")
+	})
+}